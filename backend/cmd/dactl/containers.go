@@ -0,0 +1,295 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"docker-auto/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// waitInterval is how often --wait polls the server
+const waitInterval = 2 * time.Second
+
+func newContainersCommand(g *globals) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "containers",
+		Aliases: []string{"container", "ct"},
+		Short:   "List and operate containers",
+	}
+	cmd.AddCommand(
+		newContainersListCommand(g),
+		newContainerActionCommand(g, "start", "Start containers"),
+		newContainerActionCommand(g, "stop", "Stop containers"),
+		newContainerActionCommand(g, "restart", "Restart containers"),
+		newContainersUpdateCommand(g),
+	)
+	return cmd
+}
+
+func newContainersListCommand(g *globals) *cobra.Command {
+	var (
+		opts        client.ContainerListOptions
+		updatesOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List containers",
+		Args:    exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := g.client()
+			if err != nil {
+				return err
+			}
+			if updatesOnly {
+				hasUpdate := true
+				opts.HasUpdate = &hasUpdate
+			}
+
+			list, err := c.ListContainers(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if g.output == outputJSON {
+				return printJSON(list)
+			}
+
+			t := newTable("ID", "NAME", "IMAGE", "STATUS", "POLICY", "UPDATE", "PORTS")
+			for _, container := range list.Containers {
+				t.row(container.ID, container.Name, container.Image+":"+container.Tag,
+					container.Status, container.UpdatePolicy, container.HasUpdate, container.PublishedPorts)
+			}
+			if err := t.flush(); err != nil {
+				return err
+			}
+			if list.HasNext {
+				statusf("Showing %d of %d containers; use --page and --limit for more", len(list.Containers), list.Total)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.Search, "search", "", "only containers whose name or image contains this")
+	flags.StringVar(&opts.Status, "status", "", "only containers with this status")
+	flags.StringVar(&opts.UpdatePolicy, "policy", "", "only containers with this update policy")
+	flags.BoolVar(&updatesOnly, "updates", false, "only containers with an update available")
+	flags.IntVar(&opts.Page, "page", 1, "page")
+	flags.IntVar(&opts.Limit, "limit", 50, "containers per page")
+	return cmd
+}
+
+// newContainerActionCommand runs start, stop or restart. One container is
+// handled by its own endpoint, several by a bulk operation.
+func newContainerActionCommand(g *globals, action, short string) *cobra.Command {
+	var (
+		wait    bool
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   action + " ID...",
+		Short: short,
+		Args:  minArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			c, err := g.client()
+			if err != nil {
+				return err
+			}
+
+			var results []*client.OperationResult
+			if len(ids) == 1 {
+				result := &client.OperationResult{ContainerID: ids[0], Success: true}
+				if err := containerAction(cmd.Context(), c, action, ids[0]); err != nil {
+					result.Success = false
+					result.Error = err.Error()
+				}
+				results = append(results, result)
+			} else {
+				results, err = c.Bulk(cmd.Context(), &client.BulkRequest{ContainerIDs: ids, Action: action})
+				if err != nil {
+					return err
+				}
+			}
+
+			if wait {
+				ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+				defer cancel()
+				statuses := []string{"running"}
+				if action == "stop" {
+					statuses = []string{"stopped", "exited"}
+				}
+				for _, result := range results {
+					if !result.Success {
+						continue
+					}
+					statusf("Waiting for container %d to be %s...", result.ContainerID, statuses[0])
+					container, err := c.WaitForStatus(ctx, result.ContainerID, waitInterval, statuses...)
+					if container != nil && result.Name == "" {
+						result.Name = container.Name
+					}
+					if err != nil {
+						result.Success = false
+						result.Error = fmt.Sprintf("not %s: %v", statuses[0], err)
+					}
+				}
+			}
+
+			return printResults(g, results)
+		},
+	}
+
+	cmd.Flags().BoolVar(&wait, "wait", false, "wait until the containers reach the new state")
+	cmd.Flags().DurationVar(&timeout, "timeout", 2*time.Minute, "how long --wait waits")
+	return cmd
+}
+
+func containerAction(ctx context.Context, c *client.Client, action string, id int64) error {
+	switch action {
+	case "start":
+		return c.StartContainer(ctx, id)
+	case "stop":
+		return c.StopContainer(ctx, id)
+	}
+	return c.RestartContainer(ctx, id)
+}
+
+func newContainersUpdateCommand(g *globals) *cobra.Command {
+	var (
+		req     client.UpdateRequest
+		wait    bool
+		timeout time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:   "update ID...",
+		Short: "Update containers to the latest image",
+		Long: `Update containers to the latest image allowed by their version policy.
+
+Updates run on the server. Without --wait the command returns once they are
+started; with --wait it follows each update to the end and fails if any did.`,
+		Args: minArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			c, err := g.client()
+			if err != nil {
+				return err
+			}
+
+			// One request per container, unlike a bulk update, returns the
+			// update records --wait follows
+			results := make([]*client.OperationResult, 0, len(ids))
+			updates := make(map[int64]*client.UpdateHistory, len(ids))
+			for _, id := range ids {
+				result := &client.OperationResult{ContainerID: id, Success: true}
+				update, err := c.UpdateContainer(cmd.Context(), id, &req)
+				if err != nil {
+					result.Success = false
+					result.Error = err.Error()
+				} else {
+					updates[id] = update
+					result.Message = fmt.Sprintf("update %d %s", update.ID, update.Status)
+				}
+				results = append(results, result)
+			}
+
+			if wait {
+				ctx, cancel := context.WithTimeout(cmd.Context(), timeout)
+				defer cancel()
+				for _, result := range results {
+					update := updates[result.ContainerID]
+					if update == nil {
+						continue
+					}
+					statusf("Waiting for update %d of container %d...", update.ID, result.ContainerID)
+					started := time.Now()
+					done, err := c.WaitForUpdate(ctx, result.ContainerID, update.ID, waitInterval)
+					result.DurationMS = time.Since(started).Milliseconds()
+					switch {
+					case err != nil:
+						result.Success = false
+						result.Message = ""
+						result.Error = fmt.Sprintf("update %d: %v", update.ID, err)
+					case !done.Succeeded():
+						result.Success = false
+						result.Message = ""
+						result.Error = fmt.Sprintf("update %d %s: %s", done.ID, done.Status, done.ErrorMessage)
+					default:
+						result.Message = fmt.Sprintf("update %d %s: %s", done.ID, done.Status, done.NewImage)
+						result.Warnings = done.Warnings
+					}
+				}
+			}
+
+			return printResults(g, results)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&req.Strategy, "strategy", "", "update strategy: recreate, rolling, blue_green or health_gated")
+	flags.StringVar(&req.Tag, "tag", "", "move to this tag instead of updating the current one")
+	flags.BoolVar(&req.Force, "force", false, "update even if no newer image was found")
+	flags.BoolVar(&req.Backup, "backup", false, "back up the container first")
+	flags.StringVar(&req.Note, "note", "", "note attached to the update")
+	flags.BoolVar(&wait, "wait", false, "wait until the updates finish")
+	flags.DurationVar(&timeout, "timeout", 15*time.Minute, "how long --wait waits")
+	return cmd
+}
+
+// printResults prints per-container results and fails if any container did
+func printResults(g *globals, results []*client.OperationResult) error {
+	failed := 0
+	for _, result := range results {
+		if !result.Success {
+			failed++
+		}
+	}
+
+	if g.output == outputJSON {
+		if err := printJSON(results); err != nil {
+			return err
+		}
+	} else {
+		t := newTable("ID", "NAME", "RESULT", "MESSAGE")
+		for _, result := range results {
+			outcome, message := "ok", result.Message
+			if !result.Success {
+				outcome, message = "failed", result.Error
+			}
+			t.row(result.ContainerID, result.Name, outcome, message)
+		}
+		if err := t.flush(); err != nil {
+			return err
+		}
+	}
+
+	if failed > 0 {
+		statusf("%d of %d containers failed", failed, len(results))
+		return errOperationFailed
+	}
+	return nil
+}
+
+func parseIDs(args []string) ([]int64, error) {
+	ids := make([]int64, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.ParseInt(arg, 10, 64)
+		if err != nil || id <= 0 {
+			return nil, usageErrorf("invalid container ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"docker-auto/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newLoginCommand(g *globals) *cobra.Command {
+	var (
+		username      string
+		passwordStdin bool
+		tokenName     string
+		expiresInDays int
+	)
+
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "Store the server address and an API token",
+		Long: `Store the server address and an API token for later commands.
+
+With --token the given API token is checked and stored. With --username the
+password is read from stdin, and an API token allowed to read, control and
+update containers and trigger tasks is created and stored; the password is not
+kept.`,
+		Example: `  echo "$PASSWORD" | dactl login --server https://docker-auto.example.com --username admin --password-stdin
+  dactl login --server https://docker-auto.example.com --token dat_...`,
+		Args: exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			server := firstNonEmpty(g.server, os.Getenv("DACTL_SERVER"), cfg.Server, defaultServer)
+			c := client.New(server, "")
+			c.UserAgent = "dactl"
+
+			token := firstNonEmpty(g.token, os.Getenv("DACTL_TOKEN"))
+			switch {
+			case username != "":
+				if !passwordStdin {
+					return usageErrorf("--username needs --password-stdin")
+				}
+				password, err := readPassword()
+				if err != nil {
+					return err
+				}
+				if _, err := c.Login(cmd.Context(), username, password); err != nil {
+					return fmt.Errorf("login failed: %w", err)
+				}
+				created, err := c.CreateToken(cmd.Context(), tokenName, []string{
+					client.ScopeContainersRead,
+					client.ScopeContainersControl,
+					client.ScopeContainersUpdate,
+					client.ScopeTasksTrigger,
+				}, expiresInDays)
+				if err != nil {
+					return fmt.Errorf("failed to create API token: %w", err)
+				}
+				token = created.Token
+
+			case token != "":
+				c.SetToken(token)
+				if _, err := c.ListContainers(cmd.Context(), client.ContainerListOptions{Limit: 1}); err != nil {
+					return fmt.Errorf("token rejected: %w", err)
+				}
+
+			default:
+				return usageErrorf("pass --token or --username with --password-stdin")
+			}
+
+			path, err := saveConfig(&config{Server: server, Token: token})
+			if err != nil {
+				return err
+			}
+			statusf("Logged in to %s; token stored in %s", server, path)
+			return nil
+		},
+	}
+
+	cmd.Flags().StringVarP(&username, "username", "u", "", "user to create an API token for")
+	cmd.Flags().BoolVar(&passwordStdin, "password-stdin", false, "read the password from stdin")
+	cmd.Flags().StringVar(&tokenName, "token-name", defaultTokenName(), "name of the created API token")
+	cmd.Flags().IntVar(&expiresInDays, "expires-in-days", 0, "lifetime of the created API token, the server default when 0")
+	return cmd
+}
+
+func newLogoutCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "logout",
+		Short: "Remove the stored API token",
+		Long:  "Remove the stored API token. The token stays valid until revoked on the server.",
+		Args:  exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig()
+			if err != nil {
+				return err
+			}
+			if cfg.Token == "" {
+				statusf("Not logged in")
+				return nil
+			}
+			cfg.Token = ""
+			if _, err := saveConfig(cfg); err != nil {
+				return err
+			}
+			statusf("Token removed")
+			return nil
+		},
+	}
+}
+
+// readPassword reads the first line of stdin
+func readPassword() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", fmt.Errorf("failed to read the password from stdin: %w", err)
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if password == "" {
+		return "", errors.New("empty password on stdin")
+	}
+	return password, nil
+}
+
+func defaultTokenName() string {
+	if host, err := os.Hostname(); err == nil && host != "" {
+		return "dactl@" + host
+	}
+	return "dactl"
+}
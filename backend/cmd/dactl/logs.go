@@ -0,0 +1,50 @@
+package main
+
+import (
+	"os"
+	"strconv"
+	"time"
+
+	"docker-auto/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newLogsCommand(g *globals) *cobra.Command {
+	var (
+		opts  client.LogOptions
+		since time.Duration
+	)
+
+	cmd := &cobra.Command{
+		Use:     "logs ID",
+		Aliases: []string{"tail-logs"},
+		Short:   "Print the logs of a container",
+		Long: `Print the logs of a container, following them with --follow until
+interrupted. Secrets are redacted by the server. Logs are plain text
+whatever the output format.`,
+		Args: exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || id <= 0 {
+				return usageErrorf("invalid container ID %q", args[0])
+			}
+			c, err := g.client()
+			if err != nil {
+				return err
+			}
+			if since > 0 {
+				opts.Since = time.Now().Add(-since)
+			}
+
+			return c.StreamLogs(cmd.Context(), id, opts, os.Stdout)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.IntVarP(&opts.Tail, "tail", "n", 100, "lines to print before following")
+	flags.BoolVarP(&opts.Follow, "follow", "f", false, "follow the log output")
+	flags.BoolVarP(&opts.Timestamps, "timestamps", "t", false, "prefix lines with their timestamp")
+	flags.DurationVar(&since, "since", 0, "only lines of this last duration, e.g. 10m")
+	return cmd
+}
@@ -0,0 +1,164 @@
+// Command dactl operates a docker-auto server from the command line.
+//
+//	dactl login --server https://docker-auto.example.com --username admin --password-stdin
+//	dactl containers list
+//	dactl containers update 12 14 --wait
+//	dactl tasks trigger 3
+//	dactl logs 12 --follow
+//
+// The server and API token come from --server and --token, DACTL_SERVER and
+// DACTL_TOKEN, or the file written by login, in that order. Output is a table,
+// or JSON with --output json. The exit status is 1 when a request fails or
+// any container of an operation does, and 2 on usage errors.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"docker-auto/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+// Exit statuses
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+// errOperationFailed is returned by commands whose request succeeded while
+// some containers failed; the results were already printed
+var errOperationFailed = errors.New("operation failed")
+
+// usageError is an invalid invocation
+type usageError struct {
+	msg string
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usageErrorf(format string, args ...interface{}) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// globals are the persistent flags
+type globals struct {
+	server string
+	token  string
+	output string
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	g := &globals{}
+	root := newRootCommand(g)
+	err := root.ExecuteContext(ctx)
+	stop()
+
+	switch {
+	case err == nil:
+		os.Exit(exitOK)
+	case errors.Is(err, errOperationFailed):
+		os.Exit(exitFailed)
+	}
+
+	fmt.Fprintln(os.Stderr, "Error:", err)
+	var usageErr *usageError
+	if errors.As(err, &usageErr) {
+		os.Exit(exitUsage)
+	}
+	if client.IsUnauthorized(err) {
+		fmt.Fprintln(os.Stderr, "Run 'dactl login' or set DACTL_TOKEN.")
+	}
+	os.Exit(exitFailed)
+}
+
+func newRootCommand(g *globals) *cobra.Command {
+	root := &cobra.Command{
+		Use:           "dactl",
+		Short:         "Operate a docker-auto server",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			if g.output != outputTable && g.output != outputJSON {
+				return usageErrorf("invalid output format %q: must be %s or %s", g.output, outputTable, outputJSON)
+			}
+			return nil
+		},
+	}
+	root.SetFlagErrorFunc(func(cmd *cobra.Command, err error) error {
+		return &usageError{msg: err.Error()}
+	})
+
+	flags := root.PersistentFlags()
+	flags.StringVar(&g.server, "server", "", "server address (env DACTL_SERVER)")
+	flags.StringVar(&g.token, "token", "", "API token (env DACTL_TOKEN)")
+	flags.StringVarP(&g.output, "output", "o", outputTable, "output format: table or json")
+
+	root.AddCommand(
+		newLoginCommand(g),
+		newLogoutCommand(),
+		newContainersCommand(g),
+		newTasksCommand(g),
+		newCheckUpdatesCommand(g),
+		newLogsCommand(g),
+	)
+	return root
+}
+
+// exactArgs and minArgs are cobra's argument checks failing with usage
+// errors
+func exactArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) != n {
+			return usageErrorf("%s takes %d argument(s), got %d", cmd.CommandPath(), n, len(args))
+		}
+		return nil
+	}
+}
+
+func minArgs(n int) cobra.PositionalArgs {
+	return func(cmd *cobra.Command, args []string) error {
+		if len(args) < n {
+			return usageErrorf("%s takes at least %d argument(s), got %d", cmd.CommandPath(), n, len(args))
+		}
+		return nil
+	}
+}
+
+// client returns a client for the configured server and token
+func (g *globals) client() (*client.Client, error) {
+	cfg, err := loadConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	server := firstNonEmpty(g.server, os.Getenv("DACTL_SERVER"), cfg.Server, defaultServer)
+	token := firstNonEmpty(g.token, os.Getenv("DACTL_TOKEN"), cfg.Token)
+	if token == "" {
+		return nil, errors.New("no API token: run 'dactl login' or set DACTL_TOKEN")
+	}
+
+	c := client.New(server, token)
+	c.UserAgent = "dactl"
+	return c, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Output formats
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+// printJSON writes v as indented JSON to stdout
+func printJSON(v interface{}) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+// table writes aligned columns to stdout
+type table struct {
+	w *tabwriter.Writer
+}
+
+func newTable(headers ...string) *table {
+	t := &table{w: tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)}
+	t.row(toInterfaces(headers)...)
+	return t
+}
+
+func (t *table) row(cells ...interface{}) {
+	values := make([]string, len(cells))
+	for i, cell := range cells {
+		values[i] = cellString(cell)
+	}
+	fmt.Fprintln(t.w, strings.Join(values, "\t"))
+}
+
+func (t *table) flush() error {
+	return t.w.Flush()
+}
+
+func cellString(cell interface{}) string {
+	switch v := cell.(type) {
+	case nil:
+		return "-"
+	case string:
+		if v == "" {
+			return "-"
+		}
+		// Tabs and newlines would break the columns
+		return strings.Join(strings.Fields(v), " ")
+	case bool:
+		if v {
+			return "yes"
+		}
+		return "no"
+	case *time.Time:
+		if v == nil || v.IsZero() {
+			return "-"
+		}
+		return v.Local().Format("2006-01-02 15:04:05")
+	case time.Time:
+		return cellString(&v)
+	}
+	return fmt.Sprint(cell)
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
+
+// statusf writes progress to stderr, keeping stdout for the results
+func statusf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+}
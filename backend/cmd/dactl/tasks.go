@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"docker-auto/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newTasksCommand(g *globals) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "tasks",
+		Aliases: []string{"task"},
+		Short:   "List and trigger scheduled tasks",
+	}
+	cmd.AddCommand(newTasksListCommand(g), newTasksTriggerCommand(g))
+	return cmd
+}
+
+func newTasksListCommand(g *globals) *cobra.Command {
+	var (
+		opts       client.TaskListOptions
+		activeOnly bool
+	)
+
+	cmd := &cobra.Command{
+		Use:     "list",
+		Aliases: []string{"ls"},
+		Short:   "List scheduled tasks",
+		Args:    exactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c, err := g.client()
+			if err != nil {
+				return err
+			}
+			if activeOnly {
+				active := true
+				opts.Active = &active
+			}
+
+			list, err := c.ListTasks(cmd.Context(), opts)
+			if err != nil {
+				return err
+			}
+			if g.output == outputJSON {
+				return printJSON(list)
+			}
+
+			t := newTable("ID", "NAME", "TYPE", "SCHEDULE", "STATE", "LAST RUN", "NEXT RUN", "RUNS", "FAILURES")
+			for _, task := range list.Tasks {
+				t.row(task.ID, task.Name, task.Type, firstNonEmpty(task.CronExpression, task.ScheduleType),
+					taskState(task), task.LastRunAt, task.NextRunAt, task.RunCount, task.FailureCount)
+			}
+			return t.flush()
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Name, "name", "", "only tasks whose name contains this")
+	cmd.Flags().StringVar(&opts.Type, "type", "", "only tasks of this type")
+	cmd.Flags().BoolVar(&activeOnly, "active", false, "only active tasks")
+	cmd.Flags().IntVar(&opts.Limit, "limit", 100, "maximum number of tasks")
+	return cmd
+}
+
+func taskState(task *client.Task) string {
+	switch {
+	case task.IsRunning:
+		return "running"
+	case task.IsPaused:
+		return "paused"
+	case !task.IsActive:
+		return "inactive"
+	}
+	return "active"
+}
+
+func newTasksTriggerCommand(g *globals) *cobra.Command {
+	return &cobra.Command{
+		Use:   "trigger ID",
+		Short: "Run a task now",
+		Args:  exactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil || id <= 0 {
+				return usageErrorf("invalid task ID %q", args[0])
+			}
+			c, err := g.client()
+			if err != nil {
+				return err
+			}
+
+			if err := c.TriggerTask(cmd.Context(), id); err != nil {
+				return err
+			}
+			if g.output == outputJSON {
+				return printJSON(map[string]interface{}{"task_id": id, "triggered": true})
+			}
+			fmt.Printf("Task %d triggered\n", id)
+			return nil
+		},
+	}
+}
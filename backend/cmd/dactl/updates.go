@@ -0,0 +1,59 @@
+package main
+
+import (
+	"docker-auto/pkg/client"
+
+	"github.com/spf13/cobra"
+)
+
+func newCheckUpdatesCommand(g *globals) *cobra.Command {
+	req := &client.UpdateCheckRequest{}
+
+	cmd := &cobra.Command{
+		Use:   "check-updates [ID...]",
+		Short: "Ask the registries for image updates",
+		Long: `Ask the registries whether the images of the given containers, or all
+containers, have updates. Fails if any container could not be checked.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ids, err := parseIDs(args)
+			if err != nil {
+				return err
+			}
+			req.ContainerIDs = ids
+			c, err := g.client()
+			if err != nil {
+				return err
+			}
+
+			result, err := c.CheckUpdates(cmd.Context(), req)
+			if err != nil {
+				return err
+			}
+
+			if g.output == outputJSON {
+				if err := printJSON(result); err != nil {
+					return err
+				}
+			} else {
+				t := newTable("ID", "NAME", "IMAGE", "CURRENT", "LATEST", "UPDATE", "ERROR")
+				for _, check := range result.Containers {
+					t.row(check.ContainerID, check.Name, check.Image, check.CurrentTag,
+						check.LatestTag, check.UpdateAvailable, check.Error)
+				}
+				if err := t.flush(); err != nil {
+					return err
+				}
+				statusf("%d checked, %d updates available, %d failed", result.Checked, result.UpdatesAvailable, result.Failed)
+			}
+
+			if result.Failed > 0 {
+				return errOperationFailed
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().BoolVar(&req.Force, "force", false, "ignore cached versions")
+	cmd.Flags().IntVar(&req.MaxConcurrency, "concurrency", 0, "registries queried at once, the server default when 0")
+	return cmd
+}
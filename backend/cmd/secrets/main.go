@@ -0,0 +1,82 @@
+// Command secrets inspects and re-encrypts the secrets stored in the database.
+//
+//	secrets check   report unencrypted, unreadable or stale secrets
+//	secrets rotate  encrypt plaintext secrets and re-encrypt those sealed with
+//	                older keys under ENCRYPTION_KEY
+//
+// To rotate keys without downtime, move the current key into
+// ENCRYPTION_PREVIOUS_KEYS as "version:key", set the new ENCRYPTION_KEY with a
+// higher ENCRYPTION_KEY_VERSION and restart the servers, then run rotate. Once
+// check reports no stale secrets the old key can be removed.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+)
+
+func main() {
+	if len(os.Args) != 2 || (os.Args[1] != "check" && os.Args[1] != "rotate") {
+		fmt.Fprintln(os.Stderr, "usage: secrets check|rotate")
+		os.Exit(2)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fatalf("Failed to load config: %v", err)
+	}
+
+	db, err := utils.InitDB(cfg)
+	if err != nil {
+		fatalf("Failed to connect to database: %v", err)
+	}
+
+	secretService := service.NewSecretService(
+		repository.NewSecretRepository(db),
+		repository.NewSystemConfigRepository(db),
+		cfg,
+	)
+
+	ctx := context.Background()
+	switch os.Args[1] {
+	case "check":
+		status, err := secretService.Check(ctx)
+		if err != nil {
+			fatalf("Secret check failed: %v", err)
+		}
+		printJSON(status)
+		if !status.Healthy() {
+			os.Exit(1)
+		}
+
+	case "rotate":
+		result, err := secretService.Rotate(ctx)
+		if result != nil {
+			printJSON(result)
+		}
+		if err != nil {
+			fatalf("Secret rotation failed: %v", err)
+		}
+		if len(result.Failures) > 0 || result.Conflicts > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+func printJSON(v interface{}) {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(v)
+}
+
+func fatalf(format string, args ...interface{}) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
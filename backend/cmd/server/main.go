@@ -11,9 +11,13 @@ import (
 	"time"
 
 	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/docker"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
 )
@@ -40,7 +44,26 @@ import (
 // @name Authorization
 // @description Type "Bearer" followed by a space and JWT token.
 
+// @securityDefinitions.apikey ApiKeyAuth
+// @in header
+// @name X-API-Key
+// @description API token from API_KEYS, authorized with API_KEY_ROLE.
+
+// @securityDefinitions.apikey PersonalTokenAuth
+// @in header
+// @name Authorization
+// @description Type "Token" followed by a space and a personal access token from /api/tokens.
+
+// @securityDefinitions.apikey CookieAuth
+// @in cookie
+// @name docker_auto_session
+// @description Session cookie. Unsafe methods must send the csrf_token cookie value in X-CSRF-Token.
+
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == docker.SelfUpdateCommand {
+		os.Exit(runUpdater())
+	}
+
 	// Initialize logger
 	logger := logrus.New()
 	logger.SetFormatter(&logrus.JSONFormatter{})
@@ -60,23 +83,31 @@ func main() {
 	logger.Info("Starting Docker Auto Update System...")
 
 	// Initialize database (optional for standalone mode)
-	_, err = setupDatabase(cfg, logger)
+	db, err := setupDatabase(cfg, logger)
 	if err != nil {
 		logger.Warnf("Database setup failed (continuing without database): %v", err)
 		logger.Info("Running in standalone mode without database persistence")
+	} else if err := checkStoredSecrets(cfg, db, logger); err != nil {
+		logger.Fatalf("Refusing to start: %v", err)
 	}
 
-	// Initialize Redis (TODO: Implement Redis setup when needed)
-	// redisClient, err := setupRedis(cfg, logger)
-	// if err != nil {
-	//	logger.Fatalf("Failed to setup Redis: %v", err)
-	// }
+	// Initialize Redis (optional; caches stay in process without it)
+	redisClient, err := setupRedis(cfg, logger)
+	if err != nil {
+		logger.Warnf("Redis setup failed (continuing with in-process caches): %v", err)
+	}
+	if redisClient != nil {
+		defer redisClient.Close()
+	}
 
 	// Initialize repositories (TODO: Implement repository manager)
 	// repos := repository.NewRepositories(db, redisClient)
 
 	// Initialize services (TODO: Implement service manager)
 	// services := service.NewServices(repos, cfg, logger)
+	// The manager must settle updates left half-done (FinishSelfUpdate,
+	// RecoverInterruptedUpdates) before the scheduler starts, and stop the
+	// scheduler and DrainUpdates on shutdown. Until then nothing runs them.
 
 	// Initialize HTTP server
 	router := setupRouter(cfg, logger)
@@ -132,17 +163,60 @@ func setupDatabase(cfg *config.Config, logger *logrus.Logger) (*gorm.DB, error)
 	return db, nil
 }
 
-func setupRedis(cfg *config.Config, logger *logrus.Logger) (interface{}, error) {
+// checkStoredSecrets warns loudly about stored secrets that are unencrypted
+// or unreadable with the configured keys, and fails in production when
+// REQUIRE_ENCRYPTED_SECRETS is set
+func checkStoredSecrets(cfg *config.Config, db *gorm.DB, logger *logrus.Logger) error {
+	secretService := service.NewSecretService(
+		repository.NewSecretRepository(db),
+		repository.NewSystemConfigRepository(db),
+		cfg,
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	status, err := secretService.Check(ctx)
+	if err != nil {
+		logger.Warnf("Stored secret check failed: %v", err)
+		return nil
+	}
+
+	for _, warning := range status.Warnings {
+		logger.Warn(warning)
+	}
+	if status.Healthy() {
+		return nil
+	}
+
+	for _, problem := range status.Problems {
+		logger.WithField("check", "secret_encryption").Error(problem)
+	}
+	logger.Error("Stored secrets are not fully protected: set ENCRYPTION_KEY and run 'secrets rotate'")
+
+	if cfg.IsProduction() && cfg.Security.RequireEncryptedSecrets {
+		return fmt.Errorf("stored secrets failed the encryption check")
+	}
+	return nil
+}
+
+// setupRedis connects to the shared cache. Without REDIS_URL it returns a nil
+// client and image versions and scan results are cached per replica.
+func setupRedis(cfg *config.Config, logger *logrus.Logger) (*redis.Client, error) {
+	if cfg.Redis.URL == "" {
+		logger.Info("Redis not configured, using in-process caches")
+		return nil, nil
+	}
+
 	logger.Info("Setting up Redis connection...")
 
-	// TODO: Implement Redis initialization when needed
-	// redisClient, err := utils.InitRedis(cfg)
-	// if err != nil {
-	//	return nil, fmt.Errorf("failed to connect to Redis: %w", err)
-	// }
+	redisClient, err := utils.InitRedis(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
+	}
 
-	logger.Info("Redis setup completed (placeholder)")
-	return nil, nil
+	logger.Info("Redis setup completed")
+	return redisClient, nil
 }
 
 func setupRouter(cfg *config.Config, logger *logrus.Logger) *gin.Engine {
@@ -171,12 +245,12 @@ func setupRouter(cfg *config.Config, logger *logrus.Logger) *gin.Engine {
 	}
 
 	// Setup static file serving from embedded filesystem
-	setupStaticFiles(router, logger)
+	setupStaticFiles(router, cfg, logger)
 
 	return router
 }
 
-func setupStaticFiles(router *gin.Engine, logger *logrus.Logger) {
+func setupStaticFiles(router *gin.Engine, cfg *config.Config, logger *logrus.Logger) {
 	// Get the embedded filesystem for the dist directory
 	distFS, err := fs.Sub(frontendFS, "frontend/dist")
 	if err != nil {
@@ -187,28 +261,8 @@ func setupStaticFiles(router *gin.Engine, logger *logrus.Logger) {
 	// Serve static files
 	router.StaticFS("/assets", http.FS(distFS))
 
-	// Handle SPA routing - serve index.html for all non-API routes
-	router.NoRoute(func(c *gin.Context) {
-		// Skip API routes
-		if len(c.Request.URL.Path) >= 4 && c.Request.URL.Path[:4] == "/api" {
-			c.JSON(404, gin.H{"error": "API endpoint not found"})
-			return
-		}
-
-		// Try to serve the requested file
-		file, err := distFS.Open(c.Request.URL.Path[1:]) // Remove leading slash
-		if err == nil {
-			defer file.Close()
-			stat, err := file.Stat()
-			if err == nil && !stat.IsDir() {
-				c.FileFromFS(c.Request.URL.Path[1:], http.FS(distFS))
-				return
-			}
-		}
-
-		// Serve index.html for SPA routing
-		c.FileFromFS("index.html", http.FS(distFS))
-	})
+	// Handle SPA routing - serve index.html for frontend routes
+	router.NoRoute(newNoRouteHandler(distFS, cfg.GetSPARoutePrefixes()))
 
 	logger.Info("Static file serving configured with embedded frontend")
 }
\ No newline at end of file
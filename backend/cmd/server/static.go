@@ -0,0 +1,117 @@
+package main
+
+import (
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxStaticPathLength bounds the request paths looked up in the frontend
+// filesystem
+const maxStaticPathLength = 1024
+
+// staticPathError explains why a request path was rejected
+type staticPathError struct {
+	status  int
+	message string
+}
+
+// cleanStaticPath turns a request path into a name for fs.Open. Query or
+// fragment remnants are stripped; traversal segments, NUL bytes, backslashes
+// and overlong paths are rejected. The root path maps to "".
+func cleanStaticPath(requestPath string) (string, *staticPathError) {
+	if len(requestPath) > maxStaticPathLength {
+		return "", &staticPathError{http.StatusRequestURITooLong, "Request path too long"}
+	}
+
+	if i := strings.IndexAny(requestPath, "?#"); i >= 0 {
+		requestPath = requestPath[:i]
+	}
+	if strings.ContainsAny(requestPath, "\x00\\") {
+		return "", &staticPathError{http.StatusBadRequest, "Invalid request path"}
+	}
+
+	// Checked before cleaning, which would otherwise quietly resolve ".."
+	for _, segment := range strings.Split(requestPath, "/") {
+		if segment == ".." {
+			return "", &staticPathError{http.StatusBadRequest, "Invalid request path"}
+		}
+	}
+
+	name := strings.TrimPrefix(path.Clean("/"+requestPath), "/")
+	if name != "" && !fs.ValidPath(name) {
+		return "", &staticPathError{http.StatusBadRequest, "Invalid request path"}
+	}
+	return name, nil
+}
+
+// isAPIPath reports whether the path belongs to the API. Paths that merely
+// start with "api", like /apidocs, do not.
+func isAPIPath(requestPath string) bool {
+	return requestPath == "/api" || strings.HasPrefix(requestPath, "/api/")
+}
+
+// isSPARoute reports whether index.html should be served for the path. An
+// empty prefix list serves it for every path.
+func isSPARoute(name string, prefixes []string) bool {
+	if name == "" || len(prefixes) == 0 {
+		return true
+	}
+
+	requestPath := "/" + name
+	for _, prefix := range prefixes {
+		if requestPath == prefix || strings.HasPrefix(requestPath, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// newNoRouteHandler serves unmatched requests: API paths get the standard
+// JSON 404, existing frontend files are served as is, and SPA routes fall back
+// to index.html.
+func newNoRouteHandler(distFS fs.FS, spaPrefixes []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestPath := c.Request.URL.Path
+
+		if isAPIPath(requestPath) {
+			rb := utils.NewResponseBuilder(c)
+			rb.ErrorWithDetails(http.StatusNotFound, "API endpoint not found", []utils.ErrorDetail{
+				utils.NewErrorDetail("path", "No route matches "+c.Request.Method+" "+requestPath, "NOT_FOUND"),
+			})
+			return
+		}
+
+		name, pathErr := cleanStaticPath(requestPath)
+		if pathErr != nil {
+			c.String(pathErr.status, pathErr.message)
+			return
+		}
+
+		if name != "" {
+			if stat, err := fs.Stat(distFS, name); err == nil && !stat.IsDir() {
+				c.FileFromFS(name, http.FS(distFS))
+				return
+			}
+		}
+
+		if !isSPARoute(name, spaPrefixes) {
+			c.String(http.StatusNotFound, "404 page not found")
+			return
+		}
+
+		// Read directly: FileFromFS would have http.FileServer redirect
+		// /index.html to ./, looping on the fallback
+		index, err := fs.ReadFile(distFS, "index.html")
+		if err != nil {
+			c.String(http.StatusNotFound, "404 page not found")
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", index)
+	}
+}
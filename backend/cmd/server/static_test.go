@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/gin-gonic/gin"
+)
+
+var testDist = fstest.MapFS{
+	"index.html":       {Data: []byte("<html>app</html>")},
+	"favicon.ico":      {Data: []byte("icon")},
+	"assets/app.js":    {Data: []byte("console.log('app')")},
+	"assets/style.css": {Data: []byte("body{}")},
+}
+
+var noRouteTests = []struct {
+	target string
+	status int
+	body   string
+}{
+	{"/", http.StatusOK, "<html>app</html>"},
+	{"/dashboard", http.StatusOK, "<html>app</html>"},
+	{"/dashboard/containers/12", http.StatusOK, "<html>app</html>"},
+	{"/dashboard#fragment", http.StatusOK, "<html>app</html>"},
+	{"/assets/app.js", http.StatusOK, "console.log('app')"},
+	{"/assets/app.js?v=1", http.StatusOK, "console.log('app')"},
+	{"/assets/missing.js", http.StatusNotFound, ""},
+	{"/apidocs", http.StatusNotFound, ""},
+	{"/api", http.StatusNotFound, ""},
+	{"/api/unknown", http.StatusNotFound, ""},
+	{"/..", http.StatusBadRequest, ""},
+	{"/../etc/passwd", http.StatusBadRequest, ""},
+	{"/%2e%2e/%2e%2e/etc/passwd", http.StatusBadRequest, ""},
+	{"/assets/%2e%2e/%2e%2e/secret", http.StatusBadRequest, ""},
+	{"/assets/..\\..\\secret", http.StatusBadRequest, ""},
+	{"/dashboard/%00", http.StatusBadRequest, ""},
+	{"/" + strings.Repeat("a", maxStaticPathLength+1), http.StatusRequestURITooLong, ""},
+}
+
+func serveNoRoute(router *gin.Engine, target string) (*httptest.ResponseRecorder, *url.URL, bool) {
+	// As the HTTP server does, only requests with a parsable target reach
+	// the handler
+	u, err := url.ParseRequestURI(target)
+	if err != nil {
+		return nil, nil, false
+	}
+	req := &http.Request{Method: http.MethodGet, URL: u, RequestURI: target, Header: http.Header{}}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w, u, true
+}
+
+func newTestNoRouteRouter() *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.NoRoute(newNoRouteHandler(testDist, []string{"/dashboard"}))
+	return router
+}
+
+func TestNoRouteHandler(t *testing.T) {
+	router := newTestNoRouteRouter()
+
+	for _, tt := range noRouteTests {
+		w, _, ok := serveNoRoute(router, tt.target)
+		if !ok {
+			t.Fatalf("invalid target %q", tt.target)
+		}
+		if w.Code != tt.status || (tt.body != "" && w.Body.String() != tt.body) {
+			t.Errorf("GET %.40q = %d %.40q, want %d %q", tt.target, w.Code, w.Body, tt.status, tt.body)
+		}
+	}
+}
+
+// FuzzNoRouteHandler throws request targets at the fallback handler. It must
+// not panic, must answer API paths with the standard JSON 404, and may only
+// ever serve index.html or a file of the frontend build.
+func FuzzNoRouteHandler(f *testing.F) {
+	for _, tt := range noRouteTests {
+		f.Add(tt.target)
+	}
+	seeds := []string{
+		"/assets/",
+		"/api/",
+		"/api/%2e%2e/index.html",
+		"//api/unknown",
+		"/..%2f..%2fetc%2fpasswd",
+		"/%2E%2E%5C%2E%2E%5Cwindows",
+		"/%ZZ",
+		"/%",
+		"/%25",
+		"/a/./b/../../index.html",
+		"/dashboard/%3Fq=1",
+		"/%E2%80%AE",
+		"/\xff\xfe",
+		"/" + strings.Repeat("../", 400),
+		"/" + strings.Repeat("%2e%2e/", 200),
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	router := newTestNoRouteRouter()
+	served := map[string]bool{}
+	for _, file := range testDist {
+		served[string(file.Data)] = true
+	}
+
+	f.Fuzz(func(t *testing.T, target string) {
+		w, u, ok := serveNoRoute(router, target)
+		if !ok {
+			t.Skip()
+		}
+
+		requestPath := u.Path
+		switch {
+		case isAPIPath(requestPath):
+			var body struct {
+				Success   bool   `json:"success"`
+				ErrorCode string `json:"error_code"`
+			}
+			if w.Code != http.StatusNotFound || json.Unmarshal(w.Body.Bytes(), &body) != nil || body.Success || body.ErrorCode != "NOT_FOUND" {
+				t.Fatalf("GET %q = %d %s, want the JSON 404", target, w.Code, w.Body)
+			}
+		case w.Code == http.StatusOK:
+			if !served[w.Body.String()] {
+				t.Fatalf("GET %q served %q, not a frontend file", target, w.Body)
+			}
+		case w.Code == http.StatusMovedPermanently:
+			// http.FileServer redirects .../index.html to the directory
+			if !strings.HasSuffix(requestPath, "/index.html") {
+				t.Fatalf("GET %q redirected to %q", target, w.Header().Get("Location"))
+			}
+		case w.Code == http.StatusBadRequest || w.Code == http.StatusNotFound || w.Code == http.StatusRequestURITooLong:
+		default:
+			t.Fatalf("GET %q = %d, want 200, 400, 404 or 414", target, w.Code)
+		}
+
+		// Traversal never gets past the path check
+		for _, segment := range strings.Split(requestPath, "/") {
+			if segment == ".." && w.Code != http.StatusBadRequest && w.Code != http.StatusRequestURITooLong && !isAPIPath(requestPath) {
+				t.Fatalf("GET %q = %d, want traversal rejected", target, w.Code)
+			}
+		}
+	})
+}
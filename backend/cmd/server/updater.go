@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/pkg/docker"
+)
+
+// runUpdater runs the server binary as the self-update helper started by
+// the server it replaces, and returns the exit code the server reads back.
+// Its output is the update log.
+func runUpdater() int {
+	logf := func(format string, args ...interface{}) {
+		fmt.Printf("%s %s\n", time.Now().UTC().Format(time.RFC3339), fmt.Sprintf(format, args...))
+	}
+
+	var plan docker.SelfUpdatePlan
+	if err := json.Unmarshal([]byte(os.Getenv(docker.SelfUpdatePlanEnv)), &plan); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid %s: %v\n", docker.SelfUpdatePlanEnv, err)
+		return docker.SelfUpdateExitFailed
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to load config: %v\n", err)
+		return docker.SelfUpdateExitFailed
+	}
+
+	dc, err := docker.NewDockerClient(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return docker.SelfUpdateExitFailed
+	}
+	defer dc.Close()
+
+	pingCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err = dc.Ping(pingCtx)
+	cancel()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "cannot reach the Docker daemon at %s: %v\n", cfg.Docker.Host, err)
+		return docker.SelfUpdateExitFailed
+	}
+	if plan.Check {
+		return docker.SelfUpdateExitOK
+	}
+
+	// The server is stopped by now; nothing cancels the update
+	if err := dc.RunSelfUpdate(context.Background(), &plan, logf); err != nil {
+		fmt.Fprintf(os.Stderr, "self-update failed: %v\n", err)
+		if errors.Is(err, docker.ErrSelfUpdateRestoreFailed) {
+			return docker.SelfUpdateExitRestoreFailed
+		}
+		return docker.SelfUpdateExitFailed
+	}
+	return docker.SelfUpdateExitOK
+}
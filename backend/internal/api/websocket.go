@@ -400,6 +400,8 @@ func (wsc *WebSocketConnection) createFilterFromTopic(topic string) events.Event
 			events.EventTaskCompleted,
 			events.EventTaskFailed,
 		}
+	case "scheduler":
+		filter.Types = []events.EventType{events.EventSchedulerLog}
 	case "user.notification":
 		filter.UserID = wsc.UserID
 		filter.Types = []events.EventType{
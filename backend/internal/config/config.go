@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
@@ -22,6 +23,9 @@ type Config struct {
 	// Cache settings
 	Cache CacheConfig `mapstructure:",squash"`
 
+	// Redis settings
+	Redis RedisConfig `mapstructure:",squash"`
+
 	// JWT settings
 	JWT JWTConfig `mapstructure:",squash"`
 
@@ -37,6 +41,9 @@ type Config struct {
 	// Security settings
 	Security SecurityConfig `mapstructure:",squash"`
 
+	// OpenID Connect login settings
+	OIDC OIDCConfig `mapstructure:",squash"`
+
 	// System settings
 	System SystemConfig `mapstructure:",squash"`
 
@@ -45,6 +52,12 @@ type Config struct {
 
 	// Scheduler settings
 	Scheduler SchedulerConfig `mapstructure:",squash"`
+
+	// Frontend settings
+	Frontend FrontendConfig `mapstructure:",squash"`
+
+	// Feature flag settings
+	Features FeaturesConfig `mapstructure:",squash"`
 }
 
 type DatabaseConfig struct {
@@ -71,6 +84,16 @@ type CacheConfig struct {
 	Enabled               bool `mapstructure:"CACHE_ENABLED"`
 }
 
+// RedisConfig configures the cache shared between replicas. Without a URL
+// caches stay in process.
+type RedisConfig struct {
+	URL                string `mapstructure:"REDIS_URL"`
+	KeyPrefix          string `mapstructure:"REDIS_KEY_PREFIX"`
+	MaxIdleConns       int    `mapstructure:"REDIS_MAX_IDLE_CONNS"`
+	MaxActiveConns     int    `mapstructure:"REDIS_MAX_ACTIVE_CONNS"`
+	IdleTimeoutSeconds int    `mapstructure:"REDIS_IDLE_TIMEOUT_SECONDS"`
+}
+
 type JWTConfig struct {
 	Secret           string `mapstructure:"JWT_SECRET"`
 	ExpireHours      int    `mapstructure:"JWT_EXPIRE_HOURS"`
@@ -82,12 +105,44 @@ type DockerConfig struct {
 	APIVersion     string `mapstructure:"DOCKER_API_VERSION"`
 	Timeout        int    `mapstructure:"DOCKER_TIMEOUT"`
 	ValidateImages bool   `mapstructure:"DOCKER_VALIDATE_IMAGES"`
+
+	// Host-level pull throttling
+	PullMaxConcurrent int     `mapstructure:"DOCKER_PULL_MAX_CONCURRENT"`
+	PullBandwidthMBps float64 `mapstructure:"DOCKER_PULL_BANDWIDTH_MBPS"`
+
+	// Container name to ID cache TTL, 0 disables the cache
+	NameCacheSeconds int `mapstructure:"DOCKER_NAME_CACHE_SECONDS"`
+
+	// Exec attachments and log/stats streams left unread this long are
+	// closed; sessions older than the max age are closed too, 0 disables it
+	SessionIdleTimeoutSeconds int `mapstructure:"DOCKER_SESSION_IDLE_TIMEOUT_SECONDS"`
+	SessionMaxAgeSeconds      int `mapstructure:"DOCKER_SESSION_MAX_AGE_SECONDS"`
+	// Container events waiting to be applied; past this, events are
+	// coalesced to the latest per container
+	EventQueueSize int `mapstructure:"DOCKER_EVENT_QUEUE_SIZE"`
+
+	// Status sync: containers without events are still inspected once they
+	// have not been for the max staleness, at most the stale batch per run
+	SyncMaxStalenessSeconds int `mapstructure:"DOCKER_SYNC_MAX_STALENESS_SECONDS"`
+	SyncStaleBatch          int `mapstructure:"DOCKER_SYNC_STALE_BATCH"`
+
+	// Remote Docker hosts: clients are pinged again once their last ping is
+	// this old; SSH host keys are checked against the known hosts file
+	HostPingIntervalSeconds int    `mapstructure:"DOCKER_HOST_PING_INTERVAL_SECONDS"`
+	SSHKnownHostsFile       string `mapstructure:"DOCKER_SSH_KNOWN_HOSTS_FILE"`
+
+	// Containers labeled <prefix>.enable=true are adopted by the container
+	// discovery task; empty disables discovery
+	DiscoveryLabelPrefix string `mapstructure:"DOCKER_DISCOVERY_LABEL_PREFIX"`
 }
 
 type ImageCheckConfig struct {
 	DefaultInterval      int `mapstructure:"DEFAULT_CHECK_INTERVAL"`
 	MaxConcurrentChecks  int `mapstructure:"MAX_CONCURRENT_CHECKS"`
 	ImageCacheHours      int `mapstructure:"IMAGE_CACHE_HOURS"`
+
+	// Token registry push webhooks must present; empty disables them
+	RegistryWebhookToken string `mapstructure:"REGISTRY_WEBHOOK_TOKEN"`
 }
 
 type NotificationConfig struct {
@@ -108,6 +163,9 @@ type EmailConfig struct {
 type WebhookConfig struct {
 	Enabled bool   `mapstructure:"WEBHOOK_ENABLED"`
 	URL     string `mapstructure:"WEBHOOK_URL"`
+
+	// HMAC-SHA256 key signing outbound webhook bodies, empty disables signing
+	Secret string `mapstructure:"WEBHOOK_SECRET"`
 }
 
 type WeChatConfig struct {
@@ -123,6 +181,74 @@ type SecurityConfig struct {
 	CORSAllowedOrigins  string `mapstructure:"CORS_ALLOWED_ORIGINS"`
 	CORSAllowedMethods  string `mapstructure:"CORS_ALLOWED_METHODS"`
 	CORSAllowedHeaders  string `mapstructure:"CORS_ALLOWED_HEADERS"`
+
+	// Redact secrets from container logs returned by the API; admins can
+	// still request raw logs
+	LogRedactionEnabled bool `mapstructure:"LOG_REDACTION_ENABLED"`
+
+	// Interactive exec sessions into containers over WebSocket; hardened
+	// deployments can turn them off
+	ContainerExecEnabled bool `mapstructure:"CONTAINER_EXEC_ENABLED"`
+
+	// Version of ENCRYPTION_KEY, stored with every encrypted secret. Retired
+	// keys stay readable while listed in ENCRYPTION_PREVIOUS_KEYS as
+	// comma separated "version:key" pairs.
+	EncryptionKeyVersion   int    `mapstructure:"ENCRYPTION_KEY_VERSION"`
+	EncryptionPreviousKeys string `mapstructure:"ENCRYPTION_PREVIOUS_KEYS"`
+
+	// Refuse to start in production while stored secrets are unencrypted or
+	// cannot be decrypted with the configured keys
+	RequireEncryptedSecrets bool `mapstructure:"REQUIRE_ENCRYPTED_SECRETS"`
+
+	// API keys accepted in the X-API-Key header on routes open to API
+	// tokens, comma separated; they act with APIKeyRole (viewer or operator)
+	APIKeys    string `mapstructure:"API_KEYS"`
+	APIKeyRole string `mapstructure:"API_KEY_ROLE"`
+
+	// Security posture reports flag active users not seen for this many
+	// days, and notify admins of findings that regressed since the last one
+	PostureInactiveUserDays  int  `mapstructure:"SECURITY_POSTURE_INACTIVE_USER_DAYS"`
+	PostureNotifyRegressions bool `mapstructure:"SECURITY_POSTURE_NOTIFY_REGRESSIONS"`
+
+	// API rate limiter: ban clients that keep exceeding their limits, and
+	// lower the limits while the server is under load
+	RateLimitBanningEnabled bool `mapstructure:"RATE_LIMIT_BANNING_ENABLED"`
+	RateLimitDynamicEnabled bool `mapstructure:"RATE_LIMIT_DYNAMIC_ENABLED"`
+}
+
+// OIDCConfig configures signing in through an OpenID Connect provider while
+// the oidc feature flag is on. Users are provisioned or linked by email.
+type OIDCConfig struct {
+	IssuerURL    string `mapstructure:"OIDC_ISSUER_URL"`
+	ClientID     string `mapstructure:"OIDC_CLIENT_ID"`
+	ClientSecret string `mapstructure:"OIDC_CLIENT_SECRET"`
+	// The callback registered at the provider, .../api/auth/oidc/callback
+	RedirectURL string `mapstructure:"OIDC_REDIRECT_URL"`
+	Scopes      string `mapstructure:"OIDC_SCOPES"`
+
+	// Comma separated email domains allowed to sign in, empty allows any
+	AllowedDomains string `mapstructure:"OIDC_ALLOWED_DOMAINS"`
+
+	// Roles from the groups claim as comma separated "group=role" pairs, the
+	// first matching pair winning. Without a match new users get the default
+	// role and existing ones keep theirs.
+	GroupsClaim string `mapstructure:"OIDC_GROUPS_CLAIM"`
+	RoleMapping string `mapstructure:"OIDC_ROLE_MAPPING"`
+	DefaultRole string `mapstructure:"OIDC_DEFAULT_ROLE"`
+
+	// Frontend URL the callback redirects to with the tokens in the URL
+	// fragment; empty answers the callback with JSON
+	PostLoginRedirect string `mapstructure:"OIDC_POST_LOGIN_REDIRECT"`
+
+	// Password login, which can be turned off once users sign in through the
+	// provider
+	LocalLoginEnabled bool `mapstructure:"LOCAL_LOGIN_ENABLED"`
+}
+
+// OIDCRoleMapping maps a provider group to a role
+type OIDCRoleMapping struct {
+	Group string
+	Role  string
 }
 
 type SystemConfig struct {
@@ -132,6 +258,45 @@ type SystemConfig struct {
 	MaxMemoryUsagePercent  int `mapstructure:"MAX_MEMORY_USAGE_PERCENT"`
 	MaxDiskUsagePercent    int `mapstructure:"MAX_DISK_USAGE_PERCENT"`
 	MaxCPUUsagePercent     int `mapstructure:"MAX_CPU_USAGE_PERCENT"`
+
+	// Rows per compliance report export; longer exports are truncated
+	ReportExportMaxRows int `mapstructure:"REPORT_EXPORT_MAX_ROWS"`
+
+	// Public status pages: requests per minute per client IP, and how long
+	// clients and proxies may cache a page
+	StatusPageRateLimit    int `mapstructure:"STATUS_PAGE_RATE_LIMIT"`
+	StatusPageCacheSeconds int `mapstructure:"STATUS_PAGE_CACHE_SECONDS"`
+
+	// Minutes during which the author of an update note may still edit it;
+	// admins may edit notes at any time
+	UpdateNoteEditWindowMinutes int `mapstructure:"UPDATE_NOTE_EDIT_WINDOW_MINUTES"`
+
+	// Team quotas: containers without a memory or CPU limit reserve these
+	// defaults, and team admins are notified when usage reaches the warn
+	// percent of a limit
+	QuotaDefaultMemoryMB int     `mapstructure:"QUOTA_DEFAULT_MEMORY_MB"`
+	QuotaDefaultCPUs     float64 `mapstructure:"QUOTA_DEFAULT_CPUS"`
+	QuotaWarnPercent     int     `mapstructure:"QUOTA_WARN_PERCENT"`
+
+	// Directory the backup task writes to and restores are read from
+	BackupStoragePath string `mapstructure:"BACKUP_STORAGE_PATH"`
+
+	// GitOps reconcile: the YAML source read when a run names none, an
+	// http(s) URL or a file path, and the bearer token sent to fetch a URL
+	GitOpsSource string `mapstructure:"GITOPS_SOURCE"`
+	GitOpsToken  string `mapstructure:"GITOPS_TOKEN"`
+}
+
+type FrontendConfig struct {
+	// Comma separated path prefixes served index.html by the SPA fallback,
+	// empty serves it for every non-API path
+	SPARoutePrefixes string `mapstructure:"SPA_ROUTE_PREFIXES"`
+}
+
+type FeaturesConfig struct {
+	// Comma separated feature flag overrides, e.g. "image_scanning=false,oidc".
+	// A bare name enables the flag.
+	Flags string `mapstructure:"FEATURE_FLAGS"`
 }
 
 type MonitoringConfig struct {
@@ -139,6 +304,39 @@ type MonitoringConfig struct {
 	PrometheusPath          string `mapstructure:"PROMETHEUS_PATH"`
 	HealthCheckInterval     int    `mapstructure:"HEALTH_CHECK_INTERVAL"`
 	HealthCheckTimeout      int    `mapstructure:"HEALTH_CHECK_TIMEOUT"`
+
+	// Credentials for the metrics endpoint: basic auth when a username is
+	// set, a bearer token when a token is set. With neither it is open.
+	PrometheusUsername string `mapstructure:"PROMETHEUS_USERNAME"`
+	PrometheusPassword string `mapstructure:"PROMETHEUS_PASSWORD"`
+	PrometheusToken    string `mapstructure:"PROMETHEUS_TOKEN"`
+
+	// Volume usage sampling. Volumes whose last known size exceeds the scan
+	// limit are not measured again; a helper container runs du for drivers
+	// that do not report sizes.
+	VolumeSampleMinIntervalMinutes int    `mapstructure:"VOLUME_SAMPLE_MIN_INTERVAL_MINUTES"`
+	VolumeScanMaxSizeGB            int    `mapstructure:"VOLUME_SCAN_MAX_SIZE_GB"`
+	VolumeScanTimeoutSeconds       int    `mapstructure:"VOLUME_SCAN_TIMEOUT_SECONDS"`
+	VolumeHelperImage              string `mapstructure:"VOLUME_HELPER_IMAGE"`
+
+	// Volume alert rules
+	VolumeGrowthAlertPercent int `mapstructure:"VOLUME_GROWTH_ALERT_PERCENT"`
+	VolumeGrowthAlertDays    int `mapstructure:"VOLUME_GROWTH_ALERT_DAYS"`
+	DockerRootMinFreePercent int `mapstructure:"DOCKER_ROOT_MIN_FREE_PERCENT"`
+
+	// Crash loop detection: a container restarting threshold times within
+	// the window is flagged, and the flag clears once it has run for the
+	// stable period. A threshold of 0 disables detection.
+	CrashLoopRestartThreshold int  `mapstructure:"CRASH_LOOP_RESTART_THRESHOLD"`
+	CrashLoopWindowMinutes    int  `mapstructure:"CRASH_LOOP_WINDOW_MINUTES"`
+	CrashLoopStableMinutes    int  `mapstructure:"CRASH_LOOP_STABLE_MINUTES"`
+	CrashLoopHoldUpdates      bool `mapstructure:"CRASH_LOOP_HOLD_UPDATES"`
+
+	// Container stats history: running managed containers are sampled every
+	// interval and one averaged row per container is stored each resolution
+	// period. An interval of 0 disables collection.
+	ContainerMetricsIntervalSeconds   int `mapstructure:"CONTAINER_METRICS_INTERVAL_SECONDS"`
+	ContainerMetricsResolutionSeconds int `mapstructure:"CONTAINER_METRICS_RESOLUTION_SECONDS"`
 }
 
 // Load reads configuration from environment variables and config files
@@ -204,6 +402,13 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("CACHE_CONFIG_TTL_MINUTES", 5)
 	v.SetDefault("CACHE_CLEANUP_INTERVAL_MINUTES", 5)
 
+	// Redis defaults; no URL keeps caches in process
+	v.SetDefault("REDIS_URL", "")
+	v.SetDefault("REDIS_KEY_PREFIX", "docker-auto:")
+	v.SetDefault("REDIS_MAX_IDLE_CONNS", 10)
+	v.SetDefault("REDIS_MAX_ACTIVE_CONNS", 100)
+	v.SetDefault("REDIS_IDLE_TIMEOUT_SECONDS", 300)
+
 	// JWT defaults (will be validated later)
 	v.SetDefault("JWT_SECRET", "")
 	v.SetDefault("JWT_EXPIRE_HOURS", 24)
@@ -214,23 +419,66 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("DOCKER_API_VERSION", "1.41")
 	v.SetDefault("DOCKER_TIMEOUT", 30)
 	v.SetDefault("DOCKER_VALIDATE_IMAGES", false)
+	v.SetDefault("DOCKER_PULL_MAX_CONCURRENT", 3)
+	v.SetDefault("DOCKER_PULL_BANDWIDTH_MBPS", 0)
+	v.SetDefault("DOCKER_NAME_CACHE_SECONDS", 10)
+	v.SetDefault("DOCKER_SESSION_IDLE_TIMEOUT_SECONDS", 300)
+	v.SetDefault("DOCKER_SESSION_MAX_AGE_SECONDS", 0)
+	v.SetDefault("DOCKER_EVENT_QUEUE_SIZE", 256)
+	v.SetDefault("DOCKER_SYNC_MAX_STALENESS_SECONDS", 900)
+	v.SetDefault("DOCKER_SYNC_STALE_BATCH", 50)
+	v.SetDefault("DOCKER_HOST_PING_INTERVAL_SECONDS", 60)
+	v.SetDefault("DOCKER_SSH_KNOWN_HOSTS_FILE", "~/.ssh/known_hosts")
+	v.SetDefault("DOCKER_DISCOVERY_LABEL_PREFIX", "docker-auto")
 
 	// Image check defaults
 	v.SetDefault("DEFAULT_CHECK_INTERVAL", 60)
 	v.SetDefault("MAX_CONCURRENT_CHECKS", 10)
 	v.SetDefault("IMAGE_CACHE_HOURS", 6)
+	v.SetDefault("REGISTRY_WEBHOOK_TOKEN", "")
 
 	// Notification defaults
 	v.SetDefault("EMAIL_ENABLED", false)
 	v.SetDefault("SMTP_PORT", 587)
 	v.SetDefault("WEBHOOK_ENABLED", false)
+	v.SetDefault("WEBHOOK_SECRET", "")
 	v.SetDefault("WECHAT_ENABLED", false)
 
+	// Frontend defaults, matching the frontend router
+	v.SetDefault("SPA_ROUTE_PREFIXES", "/login,/register,/forgot-password,/dashboard,/containers,/updates,/settings,/403,/404,/500")
+
+	// Feature flag defaults are declared in code; no overrides by default
+	v.SetDefault("FEATURE_FLAGS", "")
+
 	// Security defaults
 	v.SetDefault("HTTPS_ENABLED", false)
 	v.SetDefault("CORS_ALLOWED_ORIGINS", "*")
 	v.SetDefault("CORS_ALLOWED_METHODS", "GET,POST,PUT,DELETE,OPTIONS")
 	v.SetDefault("CORS_ALLOWED_HEADERS", "Content-Type,Authorization,X-Requested-With")
+	v.SetDefault("LOG_REDACTION_ENABLED", true)
+	v.SetDefault("CONTAINER_EXEC_ENABLED", true)
+	v.SetDefault("ENCRYPTION_KEY_VERSION", 1)
+	v.SetDefault("ENCRYPTION_PREVIOUS_KEYS", "")
+	v.SetDefault("REQUIRE_ENCRYPTED_SECRETS", false)
+	v.SetDefault("API_KEYS", "")
+	v.SetDefault("API_KEY_ROLE", "viewer")
+	v.SetDefault("SECURITY_POSTURE_INACTIVE_USER_DAYS", 90)
+	v.SetDefault("SECURITY_POSTURE_NOTIFY_REGRESSIONS", true)
+	v.SetDefault("RATE_LIMIT_BANNING_ENABLED", true)
+	v.SetDefault("RATE_LIMIT_DYNAMIC_ENABLED", true)
+
+	// OIDC defaults; no issuer leaves OIDC login unconfigured
+	v.SetDefault("OIDC_ISSUER_URL", "")
+	v.SetDefault("OIDC_CLIENT_ID", "")
+	v.SetDefault("OIDC_CLIENT_SECRET", "")
+	v.SetDefault("OIDC_REDIRECT_URL", "")
+	v.SetDefault("OIDC_SCOPES", "openid,email,profile")
+	v.SetDefault("OIDC_ALLOWED_DOMAINS", "")
+	v.SetDefault("OIDC_GROUPS_CLAIM", "groups")
+	v.SetDefault("OIDC_ROLE_MAPPING", "")
+	v.SetDefault("OIDC_DEFAULT_ROLE", "viewer")
+	v.SetDefault("OIDC_POST_LOGIN_REDIRECT", "")
+	v.SetDefault("LOCAL_LOGIN_ENABLED", true)
 
 	// System defaults
 	v.SetDefault("MAX_LOG_RETENTION_DAYS", 30)
@@ -239,12 +487,47 @@ func setDefaults(v *viper.Viper) {
 	v.SetDefault("MAX_MEMORY_USAGE_PERCENT", 80)
 	v.SetDefault("MAX_DISK_USAGE_PERCENT", 85)
 	v.SetDefault("MAX_CPU_USAGE_PERCENT", 90)
+	v.SetDefault("REPORT_EXPORT_MAX_ROWS", 100000)
+	v.SetDefault("STATUS_PAGE_RATE_LIMIT", 60)
+	v.SetDefault("STATUS_PAGE_CACHE_SECONDS", 30)
+	v.SetDefault("UPDATE_NOTE_EDIT_WINDOW_MINUTES", 60)
+	v.SetDefault("QUOTA_DEFAULT_MEMORY_MB", 512)
+	v.SetDefault("QUOTA_DEFAULT_CPUS", 1.0)
+	v.SetDefault("QUOTA_WARN_PERCENT", 80)
+	v.SetDefault("BACKUP_STORAGE_PATH", "/var/backups/docker-auto")
+	v.SetDefault("GITOPS_SOURCE", "")
+	v.SetDefault("GITOPS_TOKEN", "")
+
+	// Scheduler defaults
+	v.SetDefault("SCHEDULER_MAX_CONCURRENT_TASKS", 10)
+	v.SetDefault("SCHEDULER_TASK_TIMEOUT", "30m")
+	v.SetDefault("SCHEDULER_RETRY_BACKOFF", 2.0)
+	v.SetDefault("SCHEDULER_RETRY_MAX_DELAY", "1h")
+	v.SetDefault("SCHEDULER_EVENT_RETENTION", 10000)
 
 	// Monitoring defaults
 	v.SetDefault("PROMETHEUS_ENABLED", true)
 	v.SetDefault("PROMETHEUS_PATH", "/metrics")
+	v.SetDefault("PROMETHEUS_USERNAME", "")
+	v.SetDefault("PROMETHEUS_PASSWORD", "")
+	v.SetDefault("PROMETHEUS_TOKEN", "")
 	v.SetDefault("HEALTH_CHECK_INTERVAL", 30)
 	v.SetDefault("HEALTH_CHECK_TIMEOUT", 10)
+
+	// Volume usage defaults
+	v.SetDefault("VOLUME_SAMPLE_MIN_INTERVAL_MINUTES", 60)
+	v.SetDefault("VOLUME_SCAN_MAX_SIZE_GB", 100)
+	v.SetDefault("VOLUME_SCAN_TIMEOUT_SECONDS", 300)
+	v.SetDefault("VOLUME_HELPER_IMAGE", "busybox:stable")
+	v.SetDefault("VOLUME_GROWTH_ALERT_PERCENT", 20)
+	v.SetDefault("VOLUME_GROWTH_ALERT_DAYS", 7)
+	v.SetDefault("DOCKER_ROOT_MIN_FREE_PERCENT", 15)
+	v.SetDefault("CRASH_LOOP_RESTART_THRESHOLD", 5)
+	v.SetDefault("CRASH_LOOP_WINDOW_MINUTES", 10)
+	v.SetDefault("CRASH_LOOP_STABLE_MINUTES", 30)
+	v.SetDefault("CRASH_LOOP_HOLD_UPDATES", true)
+	v.SetDefault("CONTAINER_METRICS_INTERVAL_SECONDS", 15)
+	v.SetDefault("CONTAINER_METRICS_RESOLUTION_SECONDS", 60)
 }
 
 func validate(config *Config) error {
@@ -270,6 +553,37 @@ func validate(config *Config) error {
 		config.Cache.DefaultTTLMinutes = 30
 	}
 
+	if config.System.ReportExportMaxRows <= 0 {
+		config.System.ReportExportMaxRows = 100000
+	}
+
+	if _, err := config.GetFeatureFlags(); err != nil {
+		return err
+	}
+
+	if _, err := config.GetEncryptionKeys(); err != nil {
+		return err
+	}
+
+	if config.Security.APIKeyRole != "viewer" && config.Security.APIKeyRole != "operator" {
+		return fmt.Errorf("invalid API_KEY_ROLE: %s, must be viewer or operator", config.Security.APIKeyRole)
+	}
+	for _, key := range config.GetAPIKeys() {
+		if len(key) < 32 {
+			return fmt.Errorf("API keys must be at least 32 characters long")
+		}
+	}
+
+	if config.OIDC.IssuerURL != "" && (config.OIDC.ClientID == "" || config.OIDC.RedirectURL == "") {
+		return fmt.Errorf("OIDC_CLIENT_ID and OIDC_REDIRECT_URL are required with OIDC_ISSUER_URL")
+	}
+	if _, err := config.GetOIDCRoleMapping(); err != nil {
+		return err
+	}
+	if !config.OIDC.LocalLoginEnabled && !config.OIDCConfigured() {
+		return fmt.Errorf("LOCAL_LOGIN_ENABLED=false requires OIDC login to be configured")
+	}
+
 	// Validate environment
 	validEnvs := []string{"development", "production", "test"}
 	if !contains(validEnvs, config.Environment) {
@@ -300,6 +614,150 @@ func (c *Config) GetDSN() string {
 	)
 }
 
+// GetLibpqEnv returns the database connection as the PG* environment
+// variables read by pg_dump and psql
+func (c *Config) GetLibpqEnv() []string {
+	env := []string{
+		"PGHOST=" + c.Database.Host,
+		"PGPORT=" + strconv.Itoa(c.Database.Port),
+		"PGUSER=" + c.Database.User,
+		"PGPASSWORD=" + c.Database.Password,
+		"PGDATABASE=" + c.Database.Name,
+	}
+	if c.Database.SSLMode != "" {
+		env = append(env, "PGSSLMODE="+c.Database.SSLMode)
+	}
+	return env
+}
+
+// GetAPIKeys returns the configured API keys
+func (c *Config) GetAPIKeys() []string {
+	var keys []string
+	for _, key := range strings.Split(c.Security.APIKeys, ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// OIDCConfigured returns true if an OpenID Connect provider is configured
+func (c *Config) OIDCConfigured() bool {
+	return c.OIDC.IssuerURL != "" && c.OIDC.ClientID != ""
+}
+
+// GetOIDCScopes returns the scopes requested from the OpenID Connect provider
+func (c *Config) GetOIDCScopes() []string {
+	return splitList(c.OIDC.Scopes)
+}
+
+// GetOIDCAllowedDomains returns the lower-cased email domains allowed to sign
+// in through the provider, none allowing any
+func (c *Config) GetOIDCAllowedDomains() []string {
+	var domains []string
+	for _, domain := range splitList(c.OIDC.AllowedDomains) {
+		domains = append(domains, strings.ToLower(strings.TrimPrefix(domain, "@")))
+	}
+	return domains
+}
+
+// GetOIDCRoleMapping returns the group to role mappings of OIDC_ROLE_MAPPING
+// in order
+func (c *Config) GetOIDCRoleMapping() ([]OIDCRoleMapping, error) {
+	var mappings []OIDCRoleMapping
+	for _, entry := range splitList(c.OIDC.RoleMapping) {
+		group, role, found := strings.Cut(entry, "=")
+		group, role = strings.TrimSpace(group), strings.TrimSpace(role)
+		if !found || group == "" || role == "" {
+			return nil, fmt.Errorf("invalid OIDC_ROLE_MAPPING entry %q: expected group=role", entry)
+		}
+		mappings = append(mappings, OIDCRoleMapping{Group: group, Role: role})
+	}
+	return mappings, nil
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// GetSPARoutePrefixes returns the normalized SPA route prefixes
+func (c *Config) GetSPARoutePrefixes() []string {
+	var prefixes []string
+	for _, prefix := range strings.Split(c.Frontend.SPARoutePrefixes, ",") {
+		prefix = strings.TrimRight(strings.TrimSpace(prefix), "/")
+		if prefix == "" {
+			continue
+		}
+		if !strings.HasPrefix(prefix, "/") {
+			prefix = "/" + prefix
+		}
+		prefixes = append(prefixes, prefix)
+	}
+	return prefixes
+}
+
+// GetFeatureFlags returns the feature flag overrides set by FEATURE_FLAGS,
+// keyed by lower-cased flag name
+func (c *Config) GetFeatureFlags() (map[string]bool, error) {
+	flags := make(map[string]bool)
+	for _, entry := range strings.Split(c.Features.Flags, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(entry, "=")
+		name = strings.ToLower(strings.TrimSpace(name))
+		enabled := true
+		if hasValue {
+			parsed, err := strconv.ParseBool(strings.TrimSpace(value))
+			if err != nil {
+				return nil, fmt.Errorf("invalid FEATURE_FLAGS entry %q: value must be true or false", entry)
+			}
+			enabled = parsed
+		}
+		flags[name] = enabled
+	}
+	return flags, nil
+}
+
+// GetEncryptionKeys returns the secret encryption keys by version: the
+// current ENCRYPTION_KEY and any retired keys kept for decryption
+func (c *Config) GetEncryptionKeys() (map[int]string, error) {
+	keys := make(map[int]string)
+	for _, entry := range strings.Split(c.Security.EncryptionPreviousKeys, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		version, key, found := strings.Cut(entry, ":")
+		parsed, err := strconv.Atoi(strings.TrimSpace(version))
+		if !found || err != nil || parsed <= 0 || strings.TrimSpace(key) == "" {
+			return nil, fmt.Errorf("invalid ENCRYPTION_PREVIOUS_KEYS entry: expected version:key")
+		}
+		keys[parsed] = strings.TrimSpace(key)
+	}
+
+	if c.Security.EncryptionKey == "" {
+		return keys, nil
+	}
+	if c.Security.EncryptionKeyVersion <= 0 {
+		return nil, fmt.Errorf("ENCRYPTION_KEY_VERSION must be positive")
+	}
+	if previous, exists := keys[c.Security.EncryptionKeyVersion]; exists && previous != c.Security.EncryptionKey {
+		return nil, fmt.Errorf("ENCRYPTION_PREVIOUS_KEYS reuses version %d of ENCRYPTION_KEY with a different key", c.Security.EncryptionKeyVersion)
+	}
+	keys[c.Security.EncryptionKeyVersion] = c.Security.EncryptionKey
+	return keys, nil
+}
+
 // IsCacheEnabled returns true if caching is enabled
 func (c *Config) IsCacheEnabled() bool {
 	return c.Cache.Enabled
@@ -321,9 +779,14 @@ type SchedulerConfig struct {
 	TaskTimeout        time.Duration `mapstructure:"SCHEDULER_TASK_TIMEOUT"`
 	RetryDelay         time.Duration `mapstructure:"SCHEDULER_RETRY_DELAY"`
 	MaxRetries         int           `mapstructure:"SCHEDULER_MAX_RETRIES"`
+	RetryBackoff       float64       `mapstructure:"SCHEDULER_RETRY_BACKOFF"`
+	RetryMaxDelay      time.Duration `mapstructure:"SCHEDULER_RETRY_MAX_DELAY"`
 	CleanupInterval    time.Duration `mapstructure:"SCHEDULER_CLEANUP_INTERVAL"`
 	HistoryRetention   time.Duration `mapstructure:"SCHEDULER_HISTORY_RETENTION"`
 	LogLevel           string        `mapstructure:"SCHEDULER_LOG_LEVEL"`
 	EnableMetrics      bool          `mapstructure:"SCHEDULER_ENABLE_METRICS"`
 	TimeZone           string        `mapstructure:"SCHEDULER_TIMEZONE"`
+
+	// EventRetention is the number of scheduler events kept in the event log
+	EventRetention int `mapstructure:"SCHEDULER_EVENT_RETENTION"`
 }
\ No newline at end of file
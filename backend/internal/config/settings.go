@@ -0,0 +1,227 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrUnknownSetting is returned for keys that are not configuration settings
+	ErrUnknownSetting = errors.New("unknown setting")
+
+	// ErrRestartRequired is returned when changing a setting that is only
+	// read at startup
+	ErrRestartRequired = errors.New("setting is only read at startup; change it in the environment and restart")
+)
+
+// redactedValue replaces the value of secret settings that are set
+const redactedValue = "********"
+
+// reloadableSettings are the settings that can be changed while running,
+// with the check of their new value
+var reloadableSettings = map[string]func(value interface{}) error{
+	"LOG_LEVEL": func(value interface{}) error {
+		if _, err := logrus.ParseLevel(value.(string)); err != nil {
+			return fmt.Errorf("must be one of trace, debug, info, warn, error, fatal or panic")
+		}
+		return nil
+	},
+	"SCHEDULER_MAX_CONCURRENT_TASKS": func(value interface{}) error {
+		if n := value.(int); n < 1 || n > 100 {
+			return fmt.Errorf("must be between 1 and 100")
+		}
+		return nil
+	},
+	"SCHEDULER_TASK_TIMEOUT": func(value interface{}) error {
+		if d := value.(time.Duration); d < time.Minute || d > 24*time.Hour {
+			return fmt.Errorf("must be between 1m and 24h")
+		}
+		return nil
+	},
+	"RATE_LIMIT_BANNING_ENABLED": nil,
+	"RATE_LIMIT_DYNAMIC_ENABLED": nil,
+	"EMAIL_ENABLED":              nil,
+	"WEBHOOK_ENABLED":            nil,
+	"WECHAT_ENABLED":             nil,
+}
+
+// secretSettings are settings holding credentials without a telling suffix
+var secretSettings = map[string]bool{
+	"REDIS_URL":          true,
+	"WEBHOOK_URL":        true,
+	"WECHAT_WEBHOOK_URL": true,
+}
+
+// SettingError is a rejected change of a setting
+type SettingError struct {
+	Key string
+	Err error
+}
+
+func (e *SettingError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+// Unwrap returns the reason the change was rejected
+func (e *SettingError) Unwrap() error {
+	return e.Err
+}
+
+// Setting is a configuration setting, named by its environment variable
+type Setting struct {
+	Key        string
+	Value      interface{}
+	Redacted   bool
+	Reloadable bool
+}
+
+// IsReloadable reports whether a setting can be changed while running
+func IsReloadable(key string) bool {
+	_, ok := reloadableSettings[key]
+	return ok
+}
+
+// ReloadableKeys returns the settings that can be changed while running
+func ReloadableKeys() []string {
+	keys := make([]string, 0, len(reloadableSettings))
+	for key := range reloadableSettings {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// Settings returns every setting in declaration order. Secrets that are set
+// have their value redacted.
+func (c *Config) Settings() []Setting {
+	var settings []Setting
+	walkSettings(reflect.ValueOf(c).Elem(), func(key string, field reflect.Value) {
+		setting := Setting{
+			Key:        key,
+			Value:      settingValue(field),
+			Reloadable: IsReloadable(key),
+		}
+		if isSecretSetting(key) && !field.IsZero() {
+			setting.Value = redactedValue
+			setting.Redacted = true
+		}
+		settings = append(settings, setting)
+	})
+	return settings
+}
+
+// Value returns a setting's value in the form Set accepts
+func (c *Config) Value(key string) (string, error) {
+	field, ok := c.field(key)
+	if !ok {
+		return "", &SettingError{Key: key, Err: ErrUnknownSetting}
+	}
+	return fmt.Sprint(settingValue(field)), nil
+}
+
+// Set parses and checks a new value of a reloadable setting and applies it
+func (c *Config) Set(key, value string) error {
+	field, ok := c.field(key)
+	if !ok {
+		return &SettingError{Key: key, Err: ErrUnknownSetting}
+	}
+	check, ok := reloadableSettings[key]
+	if !ok {
+		return &SettingError{Key: key, Err: ErrRestartRequired}
+	}
+
+	parsed, err := parseSetting(field.Type(), value)
+	if err != nil {
+		return &SettingError{Key: key, Err: err}
+	}
+	if check != nil {
+		if err := check(parsed.Interface()); err != nil {
+			return &SettingError{Key: key, Err: err}
+		}
+	}
+
+	field.Set(parsed)
+	return nil
+}
+
+func (c *Config) field(key string) (reflect.Value, bool) {
+	var found reflect.Value
+	walkSettings(reflect.ValueOf(c).Elem(), func(name string, field reflect.Value) {
+		if name == key {
+			found = field
+		}
+	})
+	return found, found.IsValid()
+}
+
+// walkSettings calls fn with every field bound to an environment variable,
+// descending into squashed structs
+func walkSettings(v reflect.Value, fn func(key string, field reflect.Value)) {
+	for i := 0; i < v.NumField(); i++ {
+		tag := v.Type().Field(i).Tag.Get("mapstructure")
+		switch {
+		case tag == "":
+			continue
+		case strings.HasSuffix(tag, ",squash"):
+			walkSettings(v.Field(i), fn)
+		default:
+			fn(tag, v.Field(i))
+		}
+	}
+}
+
+func settingValue(field reflect.Value) interface{} {
+	if d, ok := field.Interface().(time.Duration); ok {
+		return d.String()
+	}
+	return field.Interface()
+}
+
+func parseSetting(typ reflect.Type, value string) (reflect.Value, error) {
+	value = strings.TrimSpace(value)
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be a duration such as 30m")
+		}
+		return reflect.ValueOf(d), nil
+	}
+
+	switch typ.Kind() {
+	case reflect.String:
+		return reflect.ValueOf(value).Convert(typ), nil
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be true or false")
+		}
+		return reflect.ValueOf(b).Convert(typ), nil
+	case reflect.Int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be an integer")
+		}
+		return reflect.ValueOf(n).Convert(typ), nil
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("must be a number")
+		}
+		return reflect.ValueOf(f).Convert(typ), nil
+	}
+	return reflect.Value{}, fmt.Errorf("cannot be set")
+}
+
+func isSecretSetting(key string) bool {
+	for _, suffix := range []string{"_PASSWORD", "_SECRET", "_TOKEN", "_KEY", "_KEYS"} {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return secretSettings[key]
+}
@@ -0,0 +1,134 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ActivityController handles reading and exporting the activity log
+type ActivityController struct {
+	activityService *service.ActivityService
+	logger          *logrus.Logger
+}
+
+// NewActivityController creates a new activity controller
+func NewActivityController(activityService *service.ActivityService, logger *logrus.Logger) *ActivityController {
+	return &ActivityController{
+		activityService: activityService,
+		logger:          logger,
+	}
+}
+
+// ListActivity godoc
+// @Summary List activity log
+// @Description Get activity log entries, newest first. Admins see every entry; other users only their own and get 403 when asking for another user's.
+// @Tags Activity
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "User ID"
+// @Param action query string false "Action, e.g. container_started"
+// @Param resource_type query string false "Resource type, e.g. container"
+// @Param resource_id query int false "Resource ID"
+// @Param from query string false "Start, RFC3339 or YYYY-MM-DD"
+// @Param to query string false "End, RFC3339 or YYYY-MM-DD, a day is included"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]model.ActivityLog} "Activity log entries"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/activity [get]
+func (ac *ActivityController) ListActivity(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	var query dto.ActivityQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		rb.BadRequest("Invalid query parameters: " + err.Error())
+		return
+	}
+
+	response, err := ac.activityService.ListActivity(c.Request.Context(), middleware.CurrentActor(c), &query)
+	if err != nil {
+		ac.respondActivityError(rb, err, "Failed to list activity")
+		return
+	}
+
+	rb.SuccessWithPagination(response.Activities, &utils.Pagination{
+		Page:       response.Page,
+		Limit:      response.Limit,
+		Total:      response.Total,
+		TotalPages: int((response.Total + int64(response.Limit) - 1) / int64(response.Limit)),
+		HasNext:    response.HasNext,
+		HasPrev:    response.HasPrev,
+	})
+}
+
+// ExportActivity godoc
+// @Summary Export activity log
+// @Description Export activity log entries created in [from, to) as CSV or JSON, oldest first, with the same filters and visibility as the listing. Exports longer than REPORT_EXPORT_MAX_ROWS are truncated; X-Export-Truncated is set and the body says so.
+// @Tags Activity
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param user_id query int false "User ID"
+// @Param action query string false "Action, e.g. container_started"
+// @Param resource_type query string false "Resource type, e.g. container"
+// @Param resource_id query int false "Resource ID"
+// @Param from query string false "Start, RFC3339 or YYYY-MM-DD (default: 90 days before to)"
+// @Param to query string false "End, RFC3339 or YYYY-MM-DD, a day is included (default: now)"
+// @Param format query string false "csv or json (default: csv)"
+// @Success 200 {file} file "Activity log export"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/activity/export [get]
+func (ac *ActivityController) ExportActivity(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	var query dto.ActivityQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		rb.BadRequest("Invalid query parameters: " + err.Error())
+		return
+	}
+
+	export, err := ac.activityService.PrepareExport(c.Request.Context(), middleware.CurrentActor(c), &query)
+	if err != nil {
+		ac.respondActivityError(rb, err, "Failed to export activity")
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename()))
+	c.Header("X-Export-Truncated", strconv.FormatBool(export.Truncated()))
+	c.Header("X-Export-Row-Limit", strconv.Itoa(export.RowLimit))
+	c.Header("X-Export-Total-Rows", strconv.FormatInt(export.TotalRows, 10))
+	c.Status(http.StatusOK)
+
+	if err := ac.activityService.WriteExport(c.Request.Context(), c.Writer, export); err != nil {
+		ac.logger.WithError(err).Error("Activity export interrupted")
+	}
+}
+
+// respondActivityError maps activity log errors onto HTTP responses
+func (ac *ActivityController) respondActivityError(rb *utils.ResponseBuilder, err error, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request:"):
+		rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden(err.Error())
+	default:
+		ac.logger.WithError(err).Error(message)
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// APITokenController handles the signed in user's personal access tokens
+type APITokenController struct {
+	tokenService *service.APITokenService
+	logger       *logrus.Logger
+}
+
+// NewAPITokenController creates a new personal access token controller
+func NewAPITokenController(tokenService *service.APITokenService, logger *logrus.Logger) *APITokenController {
+	return &APITokenController{
+		tokenService: tokenService,
+		logger:       logger,
+	}
+}
+
+// ListTokens godoc
+// @Summary List API tokens
+// @Description Get the signed in user's personal access tokens, including revoked and expired ones. Token values are never returned after creation.
+// @Tags API Tokens
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]model.APIToken} "API tokens"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /api/tokens [get]
+func (tc *APITokenController) ListTokens(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	tokens, err := tc.tokenService.ListTokens(c.Request.Context(), middleware.CurrentActor(c))
+	if err != nil {
+		tc.respondError(rb, err, "Failed to list API tokens")
+		return
+	}
+
+	rb.Success(tokens)
+}
+
+// CreateToken godoc
+// @Summary Create API token
+// @Description Create a personal access token for automation. It is sent as "Authorization: Token <value>", acts as the signed in user with the user's role, and only reaches routes covered by its scopes: containers:read, containers:control, containers:update and tasks:trigger. The value is returned only in this response. Tokens expire after expires_in_days, 90 by default and at most 365.
+// @Tags API Tokens
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateAPITokenRequest true "API token"
+// @Success 201 {object} utils.APIResponse{data=dto.CreatedAPIToken} "API token created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /api/tokens [post]
+func (tc *APITokenController) CreateToken(c *gin.Context) {
+	var req dto.CreateAPITokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	created, err := tc.tokenService.CreateToken(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		tc.respondError(rb, err, "Failed to create API token")
+		return
+	}
+
+	rb.Created(created)
+}
+
+// RevokeToken godoc
+// @Summary Revoke API token
+// @Description Revoke one of the signed in user's personal access tokens. Requests with it are refused from then on; the record is kept.
+// @Tags API Tokens
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "API token ID"
+// @Success 200 {object} utils.APIResponse "API token revoked"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "API token not found"
+// @Router /api/tokens/{id} [delete]
+func (tc *APITokenController) RevokeToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid API token ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := tc.tokenService.RevokeToken(c.Request.Context(), middleware.CurrentActor(c), id); err != nil {
+		tc.respondError(rb, err, "Failed to revoke API token")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "API token revoked successfully")
+}
+
+// respondError maps API token service errors onto HTTP responses
+func (tc *APITokenController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request:"):
+		rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("API token not found")
+	default:
+		tc.logger.WithError(err).Error(message)
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,97 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+)
+
+// storedTokenRepo finds personal access tokens by the hash of their value
+type storedTokenRepo struct {
+	repository.APITokenRepository
+	tokens map[string]*model.APIToken
+}
+
+func (r *storedTokenRepo) GetByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	for value, token := range r.tokens {
+		if utils.HashSHA256(value) == tokenHash {
+			copied := *token
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("api token not found")
+}
+
+func (r *storedTokenRepo) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	return nil
+}
+
+func TestPersonalTokensAreRefusedOutsideTheirGrant(t *testing.T) {
+	now := time.Now()
+	expires, expired := now.Add(time.Hour), now.Add(-time.Minute)
+	operator := &model.User{ID: 2, Username: "alice", Role: model.UserRoleOperator, IsActive: true}
+	viewer := &model.User{ID: 3, Username: "bob", Role: model.UserRoleViewer, IsActive: true}
+	disabled := &model.User{ID: 4, Username: "carol", Role: model.UserRoleOperator}
+	token := func(id int, user *model.User, scope model.TokenScope, expiresAt *time.Time) *model.APIToken {
+		return &model.APIToken{ID: id, UserID: user.ID, User: user, Name: "ci", Scopes: model.StringList{string(scope)}, ExpiresAt: expiresAt}
+	}
+	revoked := token(3, operator, model.TokenScopeContainersControl, &expires)
+	revoked.RevokedAt = &now
+
+	cfg := newTestRouterConfig()
+	cfg.APITokenService = service.NewAPITokenService(&storedTokenRepo{tokens: map[string]*model.APIToken{
+		"dat_read":     token(1, operator, model.TokenScopeContainersRead, &expires),
+		"dat_control":  token(2, operator, model.TokenScopeContainersControl, &expires),
+		"dat_revoked":  revoked,
+		"dat_expired":  token(4, operator, model.TokenScopeContainersControl, &expired),
+		"dat_viewer":   token(5, viewer, model.TokenScopeContainersControl, &expires),
+		"dat_disabled": token(6, disabled, model.TokenScopeContainersControl, &expires),
+	}}, nil)
+	router, _ := newTestRouter(t, cfg)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		want   int
+	}{
+		// Admitted callers reach the handler, which rejects the container ID
+		{"read scope reads", http.MethodGet, "/api/containers/abc/status", "dat_read", http.StatusBadRequest},
+		{"control scope starts", http.MethodPost, "/api/containers/abc/start", "dat_control", http.StatusBadRequest},
+
+		{"read scope cannot start", http.MethodPost, "/api/containers/abc/start", "dat_read", http.StatusForbidden},
+		{"control scope cannot read", http.MethodGet, "/api/containers/abc/status", "dat_control", http.StatusForbidden},
+		{"scope beyond the user's role", http.MethodPost, "/api/containers/abc/start", "dat_viewer", http.StatusForbidden},
+		{"route without a scope", http.MethodPost, "/api/containers", "dat_control", http.StatusUnauthorized},
+		{"revoked", http.MethodPost, "/api/containers/abc/start", "dat_revoked", http.StatusUnauthorized},
+		{"expired", http.MethodPost, "/api/containers/abc/start", "dat_expired", http.StatusUnauthorized},
+		{"inactive user", http.MethodPost, "/api/containers/abc/start", "dat_disabled", http.StatusUnauthorized},
+		{"unknown", http.MethodPost, "/api/containers/abc/start", "dat_unknown", http.StatusUnauthorized},
+
+		// Bulk control is admitted with the control scope, bulk updates are not
+		{"control scope cannot bulk update", http.MethodPost, "/api/containers/bulk", "dat_control", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(`{"container_ids": [1], "action": "update"}`))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Token "+tt.token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("%s %s with %s = %d, want %d: %s", tt.method, tt.path, tt.token, w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ApprovalPolicyController handles the policies that approve or reject
+// pending updates
+type ApprovalPolicyController struct {
+	approvalService *service.ApprovalPolicyService
+	logger          *logrus.Logger
+}
+
+// NewApprovalPolicyController creates a new approval policy controller
+func NewApprovalPolicyController(approvalService *service.ApprovalPolicyService, logger *logrus.Logger) *ApprovalPolicyController {
+	return &ApprovalPolicyController{
+		approvalService: approvalService,
+		logger:          logger,
+	}
+}
+
+// ListApprovalPolicies godoc
+// @Summary List approval policies
+// @Description Get every update approval policy in evaluation order; the first enabled policy matching a pending update decides it
+// @Tags Updates
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]model.ApprovalPolicy} "Approval policies"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/updates/policies [get]
+func (ac *ApprovalPolicyController) ListApprovalPolicies(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	policies, err := ac.approvalService.ListPolicies(c.Request.Context())
+	if err != nil {
+		ac.respondError(rb, err, "Failed to list approval policies")
+		return
+	}
+
+	rb.Success(policies)
+}
+
+// CreateApprovalPolicy godoc
+// @Summary Create approval policy
+// @Description Create a policy that auto-approves or auto-rejects the pending updates matching all of its conditions
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.ApprovalPolicyRequest true "Approval policy"
+// @Success 201 {object} utils.APIResponse{data=model.ApprovalPolicy} "Approval policy created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/updates/policies [post]
+func (ac *ApprovalPolicyController) CreateApprovalPolicy(c *gin.Context) {
+	var req service.ApprovalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	policy, err := ac.approvalService.CreatePolicy(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		ac.respondError(rb, err, "Failed to create approval policy")
+		return
+	}
+
+	rb.Created(policy)
+}
+
+// UpdateApprovalPolicy godoc
+// @Summary Update approval policy
+// @Description Replace an approval policy; its version advances so that later decisions record the new rules
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Approval policy ID"
+// @Param request body service.ApprovalPolicyRequest true "Approval policy"
+// @Success 200 {object} utils.APIResponse{data=model.ApprovalPolicy} "Approval policy updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Approval policy not found"
+// @Router /api/updates/policies/{id} [put]
+func (ac *ApprovalPolicyController) UpdateApprovalPolicy(c *gin.Context) {
+	id, ok := approvalPolicyID(c)
+	if !ok {
+		return
+	}
+
+	var req service.ApprovalPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	policy, err := ac.approvalService.UpdatePolicy(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		ac.respondError(rb, err, "Failed to update approval policy")
+		return
+	}
+
+	rb.Success(policy)
+}
+
+// DeleteApprovalPolicy godoc
+// @Summary Delete approval policy
+// @Description Delete an approval policy; updates it already decided keep its name as their approver
+// @Tags Updates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Approval policy ID"
+// @Success 200 {object} utils.APIResponse "Approval policy deleted"
+// @Failure 404 {object} utils.APIResponse "Approval policy not found"
+// @Router /api/updates/policies/{id} [delete]
+func (ac *ApprovalPolicyController) DeleteApprovalPolicy(c *gin.Context) {
+	id, ok := approvalPolicyID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := ac.approvalService.DeletePolicy(c.Request.Context(), middleware.CurrentActor(c), id); err != nil {
+		ac.respondError(rb, err, "Failed to delete approval policy")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Approval policy deleted successfully")
+}
+
+// EvaluateApprovalPolicy godoc
+// @Summary Dry-run an approval policy
+// @Description Replay recent pending updates against the saved policies with a draft in place, replacing policy_id if given, and show what each would have become. Nothing is changed.
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.ApprovalPolicyEvaluateRequest true "Draft policy"
+// @Success 200 {object} utils.APIResponse{data=service.ApprovalDryRun} "Replayed outcomes"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /api/updates/policies/evaluate [post]
+func (ac *ApprovalPolicyController) EvaluateApprovalPolicy(c *gin.Context) {
+	var req service.ApprovalPolicyEvaluateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := ac.approvalService.DryRun(c.Request.Context(), &req)
+	if err != nil {
+		ac.respondError(rb, err, "Failed to evaluate approval policy")
+		return
+	}
+
+	rb.Success(result)
+}
+
+func approvalPolicyID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid approval policy ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError maps approval policy service errors onto HTTP responses
+func (ac *ApprovalPolicyController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	ac.logger.WithError(err).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Approval policy not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
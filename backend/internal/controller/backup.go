@@ -0,0 +1,101 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// BackupController handles listing and restoring backups
+type BackupController struct {
+	backupService *service.BackupService
+	logger        *logrus.Logger
+}
+
+// NewBackupController creates a new backup controller
+func NewBackupController(backupService *service.BackupService, logger *logrus.Logger) *BackupController {
+	return &BackupController{
+		backupService: backupService,
+		logger:        logger,
+	}
+}
+
+// ListBackups godoc
+// @Summary List backups
+// @Description List the backups written by the backup task under the backup storage path, newest first, with the details of their manifest and the components a restore can take from them
+// @Tags Backups
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]dto.BackupInfo} "Backups"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/backups [get]
+func (bc *BackupController) ListBackups(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	backups, err := bc.backupService.ListBackups(c.Request.Context())
+	if err != nil {
+		bc.logger.WithError(err).Error("Failed to list backups")
+		rb.InternalServerError("Failed to list backups")
+		return
+	}
+
+	rb.Success(backups)
+}
+
+// RestoreBackup godoc
+// @Summary Restore a backup
+// @Description Restore the database, container configs or configuration files of a backup. A dry run reports what would change — row counts per table, containers to create or update with the fields that differ, conflicts that would be skipped, configuration files to write — and returns a confirm token; the restore itself requires that token and fails if the backup or the affected containers changed since. Restoring the database replaces every table, refuses to start while scheduled tasks are executing, and stops the scheduler until it is done. Container configs are upserted by name; running Docker containers are not touched.
+// @Tags Backups
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Backup ID"
+// @Param request body dto.RestoreBackupRequest true "Components to restore"
+// @Success 200 {object} utils.APIResponse{data=dto.BackupRestoreResult} "Restore diff or result"
+// @Failure 400 {object} utils.APIResponse "Invalid request, missing confirm token or damaged backup"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Backup not found"
+// @Failure 409 {object} utils.APIResponse "Confirm token out of date, or tasks or another restore running"
+// @Failure 500 {object} utils.APIResponse{data=dto.BackupRestoreResult} "Restore failed"
+// @Router /api/backups/{id}/restore [post]
+func (bc *BackupController) RestoreBackup(c *gin.Context) {
+	var req dto.RestoreBackupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := bc.backupService.Restore(c.Request.Context(), middleware.CurrentActor(c), c.Param("id"), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrConfirmationInvalid), errors.Is(err, service.ErrRestoreBusy):
+			rb.Conflict(err.Error())
+		case strings.HasPrefix(err.Error(), "invalid request:"):
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+		case result != nil:
+			bc.logger.WithError(err).WithField("backup_id", c.Param("id")).Error("Failed to restore backup")
+			rb.ErrorWithData(http.StatusInternalServerError, err.Error(), result, nil)
+		case strings.Contains(err.Error(), "not found"):
+			rb.NotFound("Backup not found")
+		default:
+			bc.logger.WithError(err).WithField("backup_id", c.Param("id")).Error("Failed to restore backup")
+			rb.InternalServerError("Failed to restore backup")
+		}
+		return
+	}
+
+	rb.Success(result)
+}
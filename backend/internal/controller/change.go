@@ -0,0 +1,70 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ChangeController handles the container change feed endpoint
+type ChangeController struct {
+	changeFeedService *service.ChangeFeedService
+	logger            *logrus.Logger
+}
+
+// NewChangeController creates a new change controller
+func NewChangeController(changeFeedService *service.ChangeFeedService, logger *logrus.Logger) *ChangeController {
+	return &ChangeController{
+		changeFeedService: changeFeedService,
+		logger:            logger,
+	}
+}
+
+// ListChanges godoc
+// @Summary List container changes
+// @Description Read the container change feed after a sequence number. Pass next_seq back as since_seq to continue; records are immutable and seq has no gaps within the retained window.
+// @Tags Changes
+// @Produce json
+// @Security BearerAuth
+// @Param since_seq query int false "Return changes after this sequence number" default(0)
+// @Param limit query int false "Maximum number of changes" default(100)
+// @Success 200 {object} utils.APIResponse{data=service.ChangeFeedPage} "Change feed page"
+// @Failure 400 {object} utils.APIResponse "Invalid since_seq or limit"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 410 {object} utils.APIResponse "Changes after since_seq have been pruned"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/changes [get]
+func (cc *ChangeController) ListChanges(c *gin.Context) {
+	sinceSeq, err := strconv.ParseInt(c.DefaultQuery("since_seq", "0"), 10, 64)
+	if err != nil || sinceSeq < 0 {
+		utils.BadRequestJSON(c, "Invalid since_seq")
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 {
+		utils.BadRequestJSON(c, "Invalid limit")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	page, err := cc.changeFeedService.ListChanges(c.Request.Context(), sinceSeq, limit)
+	if err != nil {
+		if errors.Is(err, service.ErrChangeFeedGap) {
+			rb.Error(http.StatusGone, "Changes after since_seq have been pruned; resynchronize from oldest retained sequence")
+			return
+		}
+		cc.logger.WithError(err).Error("Failed to list container changes")
+		rb.InternalServerError("Failed to retrieve container changes")
+		return
+	}
+
+	rb.Success(page)
+}
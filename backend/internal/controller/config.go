@@ -0,0 +1,84 @@
+package controller
+
+import (
+	"errors"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ConfigController handles the configuration administration endpoints
+type ConfigController struct {
+	configService *service.ConfigService
+	logger        *logrus.Logger
+}
+
+// NewConfigController creates a new config controller
+func NewConfigController(configService *service.ConfigService, logger *logrus.Logger) *ConfigController {
+	return &ConfigController{
+		configService: configService,
+		logger:        logger,
+	}
+}
+
+// GetConfig godoc
+// @Summary Get configuration
+// @Description Get every setting, named by its environment variable, with the value in effect and whether it comes from the environment or an override set through the API. Secrets are redacted. Reloadable settings can be changed without a restart.
+// @Tags Configuration
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=dto.ConfigSettings} "Configuration"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/admin/config [get]
+func (cc *ConfigController) GetConfig(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(cc.configService.GetConfig())
+}
+
+// PatchConfig godoc
+// @Summary Change configuration
+// @Description Change reloadable settings: LOG_LEVEL, SCHEDULER_MAX_CONCURRENT_TASKS, SCHEDULER_TASK_TIMEOUT, RATE_LIMIT_BANNING_ENABLED, RATE_LIMIT_DYNAMIC_ENABLED, EMAIL_ENABLED, WEBHOOK_ENABLED and WECHAT_ENABLED. Changes apply at once and are kept as overrides winning over the environment on the next start; a null value clears the override. Either every setting is changed or none. Errors name the rejected setting in their details.
+// @Tags Configuration
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.PatchConfigRequest true "Settings"
+// @Success 200 {object} utils.APIResponse{data=dto.ConfigSettings} "Configuration"
+// @Failure 400 {object} utils.APIResponse "Unknown setting or invalid value"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 422 {object} utils.APIResponse "Setting requires a restart"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/admin/config [patch]
+func (cc *ConfigController) PatchConfig(c *gin.Context) {
+	var req dto.PatchConfigRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+	settings, err := cc.configService.PatchConfig(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		var settingErr *config.SettingError
+		if coded := apperrors.As(err); coded != nil && errors.As(err, &settingErr) {
+			rb.ErrorWithCode(apperrors.HTTPStatus(coded.Code), coded.Code, coded.Message, []utils.ErrorDetail{
+				utils.NewErrorDetail(settingErr.Key, settingErr.Err.Error(), string(coded.Code)),
+			})
+			return
+		}
+		cc.logger.WithError(err).Error("Failed to change configuration")
+		rb.FromError(err, "Failed to change configuration")
+		return
+	}
+
+	rb.Success(settings)
+}
@@ -1,14 +1,18 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 	"time"
 
+	"docker-auto/internal/dto"
 	"docker-auto/internal/middleware"
-	"docker-auto/internal/model"
 	"docker-auto/internal/service"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -18,13 +22,16 @@ import (
 // ContainerController handles container-related HTTP requests
 type ContainerController struct {
 	containerService *service.ContainerService
-	logger           *logrus.Logger
+	// userService closes exec sockets of revoked login sessions; optional
+	userService *service.UserService
+	logger      *logrus.Logger
 }
 
 // NewContainerController creates a new container controller
-func NewContainerController(containerService *service.ContainerService, logger *logrus.Logger) *ContainerController {
+func NewContainerController(containerService *service.ContainerService, userService *service.UserService, logger *logrus.Logger) *ContainerController {
 	return &ContainerController{
 		containerService: containerService,
+		userService:      userService,
 		logger:           logger,
 	}
 }
@@ -43,20 +50,20 @@ func NewContainerController(containerService *service.ContainerService, logger *
 // @Param status query string false "Filter by status"
 // @Param update_policy query string false "Filter by update policy"
 // @Param has_update query boolean false "Filter containers with available updates"
+// @Param drifted query boolean false "Filter containers drifted from their stored configuration"
+// @Param has_drift query boolean false "Alias of drifted"
+// @Param stack_id query int false "Filter by stack"
+// @Param include_health query boolean false "Add live status, health, uptime and restart count from the daemon; items whose daemon does not answer within a few seconds keep the last synced status and are marked stale"
 // @Param sort_by query string false "Sort field" default(updated_at)
 // @Param sort_order query string false "Sort order (asc/desc)" default(desc)
-// @Success 200 {object} utils.APIResponse{data=service.ContainerListResponse} "Containers list"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerListResponse} "Containers list"
 // @Failure 400 {object} utils.APIResponse "Invalid request parameters"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers [get]
 func (cc *ContainerController) ListContainers(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	// Parse query parameters
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
@@ -89,7 +96,7 @@ func (cc *ContainerController) ListContainers(c *gin.Context) {
 	}
 
 	// Build filter
-	filter := &service.ContainerFilter{
+	filter := &dto.ContainerFilter{
 		SearchQuery: search,
 		HasUpdate:   hasUpdate,
 		SortBy:      sortBy,
@@ -103,15 +110,43 @@ func (cc *ContainerController) ListContainers(c *gin.Context) {
 	}
 
 	if status != "" {
-		filter.ContainerFilter.Status = &status
+		filter.ContainerFilter.Status = model.ContainerStatus(status)
 	}
 	if updatePolicy != "" {
-		filter.ContainerFilter.UpdatePolicy = &updatePolicy
+		filter.ContainerFilter.UpdatePolicy = model.UpdatePolicy(updatePolicy)
+	}
+	driftedStr := c.Query("drifted")
+	if driftedStr == "" {
+		driftedStr = c.Query("has_drift")
+	}
+	if driftedStr != "" {
+		drifted, err := strconv.ParseBool(driftedStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid drifted filter")
+			return
+		}
+		filter.ContainerFilter.Drifted = &drifted
+	}
+	if stackIDStr := c.Query("stack_id"); stackIDStr != "" {
+		stackID, err := strconv.Atoi(stackIDStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid stack ID")
+			return
+		}
+		filter.ContainerFilter.StackID = &stackID
+	}
+	if includeHealthStr := c.Query("include_health"); includeHealthStr != "" {
+		includeHealth, err := strconv.ParseBool(includeHealthStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid include_health parameter")
+			return
+		}
+		filter.IncludeHealth = includeHealth
 	}
 
 	rb := utils.NewResponseBuilder(c)
 
-	response, err := cc.containerService.ListContainers(c.Request.Context(), userID, filter)
+	response, err := cc.containerService.ListContainers(c.Request.Context(), middleware.CurrentActor(c), filter)
 	if err != nil {
 		cc.logger.WithError(err).WithField("user_id", userID).Error("Failed to list containers")
 		rb.InternalServerError("Failed to retrieve containers")
@@ -135,22 +170,18 @@ func (cc *ContainerController) ListContainers(c *gin.Context) {
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body service.CreateContainerRequest true "Container configuration"
+// @Param request body dto.CreateContainerRequest true "Container configuration"
 // @Success 201 {object} utils.APIResponse{data=model.Container} "Container created successfully"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
-// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 403 {object} utils.APIResponse "Forbidden, or team quota exceeded (QUOTA_EXCEEDED) with the usage in data"
 // @Failure 409 {object} utils.APIResponse "Container name already exists"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers [post]
 func (cc *ContainerController) CreateContainer(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
-	var req service.CreateContainerRequest
+	var req dto.CreateContainerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		cc.logger.WithError(err).WithField("user_id", userID).Warn("Invalid create container request")
 		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
@@ -159,14 +190,10 @@ func (cc *ContainerController) CreateContainer(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	container, err := cc.containerService.CreateContainer(c.Request.Context(), userID, &req)
+	container, err := cc.containerService.CreateContainer(c.Request.Context(), middleware.CurrentActor(c), &req)
 	if err != nil {
 		cc.logger.WithError(err).WithField("user_id", userID).Error("Failed to create container")
-		if err.Error() == "container with name '"+req.Name+"' already exists" {
-			rb.Conflict("Container name already exists")
-			return
-		}
-		rb.InternalServerError("Failed to create container")
+		respondError(rb, err, "Failed to create container")
 		return
 	}
 
@@ -181,12 +208,12 @@ func (cc *ContainerController) CreateContainer(c *gin.Context) {
 
 // GetContainer godoc
 // @Summary Get container details
-// @Description Get detailed information about a specific container
+// @Description Get detailed information about a specific container. Secret environment variable and label values in config_json are masked as "*****" unless the caller has the container:secrets permission; pending_changes lists stored env and label changes the Docker container gets when next recreated
 // @Tags Containers
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Container ID"
-// @Success 200 {object} utils.APIResponse{data=service.ContainerDetail} "Container details"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerDetail} "Container details"
 // @Failure 400 {object} utils.APIResponse "Invalid container ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -194,11 +221,7 @@ func (cc *ContainerController) CreateContainer(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id} [get]
 func (cc *ContainerController) GetContainer(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -209,13 +232,14 @@ func (cc *ContainerController) GetContainer(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	detail, err := cc.containerService.GetContainer(c.Request.Context(), userID, containerID)
+	reveal := middleware.HasPermission(c, middleware.PermissionContainerSecrets)
+	detail, err := cc.containerService.GetContainer(c.Request.Context(), middleware.CurrentActor(c), containerID, reveal)
 	if err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to get container")
-		rb.NotFound("Container not found")
+		respondError(rb, err, "Failed to get container")
 		return
 	}
 
@@ -224,26 +248,22 @@ func (cc *ContainerController) GetContainer(c *gin.Context) {
 
 // UpdateContainer godoc
 // @Summary Update container configuration
-// @Description Update configuration of an existing container
+// @Description Update configuration of an existing container. Resource limits (cpu_limit, memory_limit, memory_swap, pids_limit) are applied to the running container; removed limits take effect when it is recreated. Warnings the Docker daemon reports applying them are returned in data.warnings.
 // @Tags Containers
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Container ID"
-// @Param request body service.UpdateContainerRequest true "Container update data"
+// @Param request body dto.UpdateContainerRequest true "Container update data"
 // @Success 200 {object} utils.APIResponse "Container updated successfully"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
-// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 403 {object} utils.APIResponse "Forbidden, or team quota exceeded (QUOTA_EXCEEDED) with the usage in data"
 // @Failure 404 {object} utils.APIResponse "Container not found"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id} [put]
 func (cc *ContainerController) UpdateContainer(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -252,7 +272,7 @@ func (cc *ContainerController) UpdateContainer(c *gin.Context) {
 		return
 	}
 
-	var req service.UpdateContainerRequest
+	var req dto.UpdateContainerRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
@@ -264,16 +284,13 @@ func (cc *ContainerController) UpdateContainer(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	if err := cc.containerService.UpdateContainer(c.Request.Context(), userID, containerID, &req); err != nil {
+	warnings, err := cc.containerService.UpdateContainer(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
+	if err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to update container")
-		if err.Error() == "container not found" {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to update container")
+		respondError(rb, err, "Failed to update container")
 		return
 	}
 
@@ -282,6 +299,11 @@ func (cc *ContainerController) UpdateContainer(c *gin.Context) {
 		"container_id": containerID,
 	}).Info("Container updated successfully")
 
+	if len(warnings) > 0 {
+		rb.SuccessWithMessage(gin.H{"warnings": warnings}, "Container updated with daemon warnings")
+		return
+	}
+
 	rb.SuccessWithMessage(nil, "Container updated successfully")
 }
 
@@ -300,11 +322,7 @@ func (cc *ContainerController) UpdateContainer(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id} [delete]
 func (cc *ContainerController) DeleteContainer(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -315,16 +333,12 @@ func (cc *ContainerController) DeleteContainer(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	if err := cc.containerService.DeleteContainer(c.Request.Context(), userID, containerID); err != nil {
+	if err := cc.containerService.DeleteContainer(c.Request.Context(), middleware.CurrentActor(c), containerID); err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to delete container")
-		if err.Error() == "container not found" {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to delete container")
+		respondError(rb, err, "Failed to delete container")
 		return
 	}
 
@@ -340,7 +354,7 @@ func (cc *ContainerController) DeleteContainer(c *gin.Context) {
 
 // StartContainer godoc
 // @Summary Start container
-// @Description Start a stopped container
+// @Description Start a stopped container. Warnings the Docker daemon reports when creating it are returned in data.warnings.
 // @Tags Containers
 // @Produce json
 // @Security BearerAuth
@@ -349,15 +363,14 @@ func (cc *ContainerController) DeleteContainer(c *gin.Context) {
 // @Failure 400 {object} utils.APIResponse "Invalid container ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
-// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 404 {object} utils.APIResponse "Container or image not found"
+// @Failure 409 {object} utils.APIResponse "Port already allocated (PORT_ALLOCATED) or container name in use (NAME_CONFLICT)"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Failure 503 {object} utils.APIResponse "Docker daemon unavailable"
+// @Failure 507 {object} utils.APIResponse "Not enough disk space (DISK_FULL) or memory on the Docker host"
 // @Router /api/containers/{id}/start [post]
 func (cc *ContainerController) StartContainer(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -368,16 +381,13 @@ func (cc *ContainerController) StartContainer(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	if err := cc.containerService.StartContainer(c.Request.Context(), userID, containerID); err != nil {
+	warnings, err := cc.containerService.StartContainer(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to start container")
-		if err.Error() == "container not found" {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to start container")
+		respondError(rb, err, "Failed to start container")
 		return
 	}
 
@@ -386,6 +396,11 @@ func (cc *ContainerController) StartContainer(c *gin.Context) {
 		"container_id": containerID,
 	}).Info("Container started successfully")
 
+	if len(warnings) > 0 {
+		rb.SuccessWithMessage(gin.H{"warnings": warnings}, "Container started with daemon warnings")
+		return
+	}
+
 	rb.SuccessWithMessage(nil, "Container started successfully")
 }
 
@@ -404,11 +419,7 @@ func (cc *ContainerController) StartContainer(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id}/stop [post]
 func (cc *ContainerController) StopContainer(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -419,16 +430,12 @@ func (cc *ContainerController) StopContainer(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	if err := cc.containerService.StopContainer(c.Request.Context(), userID, containerID); err != nil {
+	if err := cc.containerService.StopContainer(c.Request.Context(), middleware.CurrentActor(c), containerID); err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to stop container")
-		if err.Error() == "container not found" {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to stop container")
+		respondError(rb, err, "Failed to stop container")
 		return
 	}
 
@@ -455,11 +462,7 @@ func (cc *ContainerController) StopContainer(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id}/restart [post]
 func (cc *ContainerController) RestartContainer(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -470,16 +473,12 @@ func (cc *ContainerController) RestartContainer(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	if err := cc.containerService.RestartContainer(c.Request.Context(), userID, containerID); err != nil {
+	if err := cc.containerService.RestartContainer(c.Request.Context(), middleware.CurrentActor(c), containerID); err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to restart container")
-		if err.Error() == "container not found" {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to restart container")
+		respondError(rb, err, "Failed to restart container")
 		return
 	}
 
@@ -493,14 +492,14 @@ func (cc *ContainerController) RestartContainer(c *gin.Context) {
 
 // UpdateContainerImage godoc
 // @Summary Trigger manual update
-// @Description Trigger a manual update for a container
+// @Description Trigger a manual update for a container. With dry_run the checks run and the plan is returned; nothing is pulled or recreated. The health_gated strategy starts the new image next to the running container and only replaces it once the new one passes health_probe, its Docker health check, or a short uptime check; a failed update leaves the running container untouched and keeps the health and container logs on the update record.
 // @Tags Containers
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Container ID"
-// @Param request body service.UpdateImageRequest false "Update options"
-// @Success 200 {object} utils.APIResponse{data=model.UpdateHistory} "Update initiated successfully"
+// @Param request body dto.UpdateImageRequest false "Update options"
+// @Success 200 {object} utils.APIResponse{data=model.UpdateHistory} "Update initiated successfully, or dto.UpdatePlan for a dry run"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -508,11 +507,7 @@ func (cc *ContainerController) RestartContainer(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id}/update [post]
 func (cc *ContainerController) UpdateContainerImage(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -521,7 +516,7 @@ func (cc *ContainerController) UpdateContainerImage(c *gin.Context) {
 		return
 	}
 
-	var req service.UpdateImageRequest
+	var req dto.UpdateImageRequest
 	// Optional request body
 	if c.Request.ContentLength > 0 {
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -536,17 +531,23 @@ func (cc *ContainerController) UpdateContainerImage(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	updateHistory, err := cc.containerService.UpdateContainerImage(c.Request.Context(), userID, containerID, &req)
+	if req.DryRun {
+		plan, err := cc.containerService.PlanContainerUpdate(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
+		if err != nil {
+			cc.respondDriftError(rb, err, containerID, "Failed to plan container update")
+			return
+		}
+		rb.Success(plan)
+		return
+	}
+
+	updateHistory, err := cc.containerService.UpdateContainerImage(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
 	if err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to update container image")
-		if err.Error() == "container not found" {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to update container")
+		respondError(rb, err, "Failed to update container")
 		return
 	}
 
@@ -561,7 +562,7 @@ func (cc *ContainerController) UpdateContainerImage(c *gin.Context) {
 
 // GetContainerLogs godoc
 // @Summary Get container logs
-// @Description Retrieve logs from a container
+// @Description Retrieve logs from a container. Passwords, tokens, keys and connection string credentials are redacted, along with the container's log_redact_patterns, unless an admin passes raw=true.
 // @Tags Containers
 // @Produce json
 // @Security BearerAuth
@@ -571,7 +572,8 @@ func (cc *ContainerController) UpdateContainerImage(c *gin.Context) {
 // @Param until query string false "Show logs until timestamp (RFC3339)"
 // @Param follow query boolean false "Follow log output"
 // @Param timestamps query boolean false "Include timestamps" default(true)
-// @Success 200 {object} utils.APIResponse{data=service.LogResponse} "Container logs"
+// @Param raw query boolean false "Skip secret redaction (admins only, audited)" default(false)
+// @Success 200 {object} utils.APIResponse{data=dto.LogResponse} "Container logs"
 // @Failure 400 {object} utils.APIResponse "Invalid request parameters"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -579,11 +581,7 @@ func (cc *ContainerController) UpdateContainerImage(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id}/logs [get]
 func (cc *ContainerController) GetContainerLogs(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -592,49 +590,101 @@ func (cc *ContainerController) GetContainerLogs(c *gin.Context) {
 		return
 	}
 
-	// Parse query parameters
+	options := parseLogOptions(c, "false")
+
+	rb := utils.NewResponseBuilder(c)
+
+	logResponse, err := cc.containerService.GetContainerLogs(c.Request.Context(), middleware.CurrentActor(c), containerID, options)
+	if err != nil {
+		cc.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":      userID,
+			"container_id": containerID,
+		}).Error("Failed to get container logs")
+		respondError(rb, err, "Failed to retrieve logs")
+		return
+	}
+
+	rb.Success(logResponse)
+}
+
+// StreamContainerLogs godoc
+// @Summary Stream container logs
+// @Description Stream a container's logs as plain text, following new output by default. Secrets are redacted as in GetContainerLogs, line by line, unless an admin passes raw=true.
+// @Tags Containers
+// @Produce plain
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param tail query int false "Number of past log lines to start with" default(100)
+// @Param since query string false "Show logs since timestamp (RFC3339)"
+// @Param until query string false "Show logs until timestamp (RFC3339)"
+// @Param follow query boolean false "Follow log output" default(true)
+// @Param timestamps query boolean false "Include timestamps" default(true)
+// @Param raw query boolean false "Skip secret redaction (admins only, audited)" default(false)
+// @Success 200 {string} string "Log stream"
+// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/logs/stream [get]
+func (cc *ContainerController) StreamContainerLogs(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	options := parseLogOptions(c, "true")
+
+	// Headers are only committed with the first log bytes, so errors found
+	// before streaming starts still get a JSON response
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Header("X-Content-Type-Options", "nosniff")
+
+	err = cc.containerService.StreamContainerLogs(c.Request.Context(), middleware.CurrentActor(c), containerID, options, c.Writer)
+	if err == nil {
+		return
+	}
+
+	cc.logger.WithError(err).WithFields(logrus.Fields{
+		"user_id":      userID,
+		"container_id": containerID,
+	}).Error("Failed to stream container logs")
+	if c.Writer.Written() {
+		return
+	}
+
+	respondError(utils.NewResponseBuilder(c), err, "Failed to stream logs")
+}
+
+// parseLogOptions reads the log query parameters shared by the log endpoints
+func parseLogOptions(c *gin.Context, followDefault string) *dto.LogOptions {
 	tail, _ := strconv.Atoi(c.DefaultQuery("tail", "100"))
-	sinceStr := c.Query("since")
-	untilStr := c.Query("until")
-	follow, _ := strconv.ParseBool(c.DefaultQuery("follow", "false"))
+	follow, _ := strconv.ParseBool(c.DefaultQuery("follow", followDefault))
 	timestamps, _ := strconv.ParseBool(c.DefaultQuery("timestamps", "true"))
+	raw, _ := strconv.ParseBool(c.DefaultQuery("raw", "false"))
 
-	// Create log options
-	options := &service.LogOptions{
+	options := &dto.LogOptions{
 		Tail:       tail,
 		Follow:     follow,
 		Timestamps: timestamps,
+		Raw:        raw,
 	}
 
-	// Parse timestamps
-	if sinceStr != "" {
+	if sinceStr := c.Query("since"); sinceStr != "" {
 		if since, err := time.Parse(time.RFC3339, sinceStr); err == nil {
 			options.Since = since
 		}
 	}
-	if untilStr != "" {
+	if untilStr := c.Query("until"); untilStr != "" {
 		if until, err := time.Parse(time.RFC3339, untilStr); err == nil {
 			options.Until = until
 		}
 	}
 
-	rb := utils.NewResponseBuilder(c)
-
-	logResponse, err := cc.containerService.GetContainerLogs(c.Request.Context(), userID, containerID, options)
-	if err != nil {
-		cc.logger.WithError(err).WithFields(logrus.Fields{
-			"user_id":      userID,
-			"container_id": containerID,
-		}).Error("Failed to get container logs")
-		if err.Error() == "container not found" || err.Error() == "container has no Docker instance" {
-			rb.NotFound("Container not found or not running")
-			return
-		}
-		rb.InternalServerError("Failed to retrieve logs")
-		return
-	}
-
-	rb.Success(logResponse)
+	return options
 }
 
 // GetContainerStats godoc
@@ -644,7 +694,7 @@ func (cc *ContainerController) GetContainerLogs(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Container ID"
-// @Success 200 {object} utils.APIResponse{data=service.ContainerStats} "Container statistics"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerStats} "Container statistics"
 // @Failure 400 {object} utils.APIResponse "Invalid container ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -652,11 +702,7 @@ func (cc *ContainerController) GetContainerLogs(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/{id}/stats [get]
 func (cc *ContainerController) GetContainerStats(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	containerIDStr := c.Param("id")
 	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
@@ -667,17 +713,13 @@ func (cc *ContainerController) GetContainerStats(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	stats, err := cc.containerService.GetContainerStats(c.Request.Context(), userID, containerID)
+	stats, err := cc.containerService.GetContainerStats(c.Request.Context(), middleware.CurrentActor(c), containerID)
 	if err != nil {
 		cc.logger.WithError(err).WithFields(logrus.Fields{
 			"user_id":      userID,
 			"container_id": containerID,
 		}).Error("Failed to get container stats")
-		if err.Error() == "container not found" || err.Error() == "container has no Docker instance" {
-			rb.NotFound("Container not found or not running")
-			return
-		}
-		rb.InternalServerError("Failed to retrieve statistics")
+		respondError(rb, err, "Failed to retrieve statistics")
 		return
 	}
 
@@ -691,7 +733,7 @@ func (cc *ContainerController) GetContainerStats(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Container ID"
-// @Success 200 {object} utils.APIResponse{data=service.ContainerStatus} "Container status"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerStatus} "Container status"
 // @Failure 400 {object} utils.APIResponse "Invalid container ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -708,44 +750,70 @@ func (cc *ContainerController) GetContainerStatus(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	status, err := cc.containerService.GetContainerStatus(c.Request.Context(), containerID)
+	status, err := cc.containerService.GetContainerStatus(c.Request.Context(), middleware.CurrentActor(c), containerID)
 	if err != nil {
 		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to get container status")
-		if err.Error() == "container not found" {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to retrieve status")
+		respondError(rb, err, "Failed to retrieve status")
 		return
 	}
 
 	rb.Success(status)
 }
 
+// GetNextUpdateWindow godoc
+// @Summary Get next update window
+// @Description Get when the updater may next apply an update to a container, given its hold-down and maintenance windows
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=dto.UpdateWindow} "Next update window"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/next-window [get]
+func (cc *ContainerController) GetNextUpdateWindow(c *gin.Context) {
+	containerIDStr := c.Param("id")
+	containerID, err := strconv.ParseInt(containerIDStr, 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	window, err := cc.containerService.NextUpdateWindow(c.Request.Context(), containerID)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to get next update window")
+		respondError(rb, err, "Failed to retrieve next update window")
+		return
+	}
+
+	rb.Success(window)
+}
+
 // Bulk operations
 
 // BulkContainerOperation godoc
 // @Summary Bulk container operation
-// @Description Perform bulk operations on multiple containers
+// @Description Perform bulk operations on multiple containers, up to max_concurrency (default 5, at most 20) at once. Personal access tokens need the containers:control scope, and containers:update for the update action. A failing container does not stop the others unless fail_fast is set, which skips the containers not yet started. Each result carries when it started and how long it took; with dry_run image updates are planned, and each result carries its plan.
 // @Tags Containers
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body service.BulkUpdateRequest true "Bulk operation request"
-// @Success 200 {object} utils.APIResponse{data=[]service.OperationResult} "Bulk operation results"
+// @Param request body dto.BulkUpdateRequest true "Bulk operation request"
+// @Success 200 {object} utils.APIResponse{data=[]dto.OperationResult} "Bulk operation results"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/containers/bulk [post]
 func (cc *ContainerController) BulkContainerOperation(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
-	var req service.BulkUpdateRequest
+	var req dto.BulkUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		cc.logger.WithError(err).WithField("user_id", userID).Warn("Invalid bulk operation request")
 		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
@@ -759,54 +827,16 @@ func (cc *ContainerController) BulkContainerOperation(c *gin.Context) {
 		rb.BadRequest("At least one container ID is required")
 		return
 	}
-
-	results := make([]service.OperationResult, 0, len(req.ContainerIDs))
-
-	// Process each container
-	for _, containerID := range req.ContainerIDs {
-		result := service.OperationResult{
-			ContainerID: containerID,
-			Success:     false,
-		}
-
-		// Get container name for result
-		if container, err := cc.containerService.GetContainer(c.Request.Context(), userID, containerID); err == nil {
-			result.Name = container.Container.Name
-		}
-
-		var err error
-		switch req.Action {
-		case "start":
-			err = cc.containerService.StartContainer(c.Request.Context(), userID, containerID)
-		case "stop":
-			err = cc.containerService.StopContainer(c.Request.Context(), userID, containerID)
-		case "restart":
-			err = cc.containerService.RestartContainer(c.Request.Context(), userID, containerID)
-		case "update":
-			if req.UpdateImage != nil {
-				_, err = cc.containerService.UpdateContainerImage(c.Request.Context(), userID, containerID, req.UpdateImage)
-			} else {
-				err = cc.containerService.UpdateContainer(c.Request.Context(), userID, containerID, &service.UpdateContainerRequest{
-					Config: req.Config,
-				})
-			}
-		default:
-			err = fmt.Errorf("invalid action: %s", req.Action)
-		}
-
-		if err != nil {
-			result.Error = err.Error()
-			cc.logger.WithError(err).WithFields(logrus.Fields{
-				"user_id":      userID,
-				"container_id": containerID,
-				"action":       req.Action,
-			}).Warn("Bulk operation failed for container")
-		} else {
-			result.Success = true
-			result.Message = "Operation completed successfully"
-		}
-
-		results = append(results, result)
+	// The route admits tokens that control containers; updates need more
+	if req.Action == "update" && !middleware.TokenAllows(c, model.TokenScopeContainersUpdate) {
+		rb.Forbidden("Required token scope: " + string(model.TokenScopeContainersUpdate))
+		return
+	}
+	results, err := cc.containerService.BulkUpdateContainers(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		cc.logger.WithError(err).WithField("user_id", userID).Error("Bulk container operation failed")
+		respondError(rb, err, "Bulk container operation failed")
+		return
 	}
 
 	// Count successes and failures
@@ -814,15 +844,23 @@ func (cc *ContainerController) BulkContainerOperation(c *gin.Context) {
 	for _, result := range results {
 		if result.Success {
 			successCount++
+		} else {
+			cc.logger.WithFields(logrus.Fields{
+				"user_id":      userID,
+				"container_id": result.ContainerID,
+				"action":       req.Action,
+				"error":        result.Error,
+			}).Warn("Bulk operation failed for container")
 		}
 	}
 
 	cc.logger.WithFields(logrus.Fields{
-		"user_id":      userID,
-		"action":       req.Action,
-		"total":        len(req.ContainerIDs),
-		"success":      successCount,
-		"failed":       len(req.ContainerIDs) - successCount,
+		"user_id":         userID,
+		"action":          req.Action,
+		"total":           len(results),
+		"success":         successCount,
+		"failed":          len(results) - successCount,
+		"max_concurrency": req.Concurrency(),
 	}).Info("Bulk container operation completed")
 
 	rb.Success(results)
@@ -830,11 +868,12 @@ func (cc *ContainerController) BulkContainerOperation(c *gin.Context) {
 
 // SyncContainerStatus godoc
 // @Summary Sync container status
-// @Description Synchronize container status with Docker daemon
+// @Description Synchronize container status with Docker daemon. Only containers that had events since the last sync, are restarting or have not been verified within DOCKER_SYNC_MAX_STALENESS_SECONDS are inspected, unless full is set.
 // @Tags Containers
 // @Produce json
 // @Security BearerAuth
-// @Success 200 {object} utils.APIResponse{data=service.SyncResult} "Sync completed"
+// @Param full query bool false "Inspect every container"
+// @Success 200 {object} utils.APIResponse{data=dto.SyncResult} "Sync completed"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
@@ -842,12 +881,127 @@ func (cc *ContainerController) BulkContainerOperation(c *gin.Context) {
 func (cc *ContainerController) SyncContainerStatus(c *gin.Context) {
 	rb := utils.NewResponseBuilder(c)
 
-	if err := cc.containerService.SyncContainerStatus(c.Request.Context()); err != nil {
+	full, _ := strconv.ParseBool(c.DefaultQuery("full", "false"))
+
+	result, err := cc.containerService.SyncContainerStatus(c.Request.Context(), full)
+	if err != nil {
 		cc.logger.WithError(err).Error("Failed to sync container status")
 		rb.InternalServerError("Failed to sync container status")
 		return
 	}
 
-	cc.logger.Info("Container status sync completed")
-	rb.SuccessWithMessage(nil, "Container status synchronized successfully")
-}
\ No newline at end of file
+	cc.logger.WithFields(logrus.Fields{
+		"inspected": result.Inspected,
+		"skipped":   result.Skipped,
+	}).Info("Container status sync completed")
+	rb.SuccessWithMessage(result, "Container status synchronized successfully")
+}
+
+// DiscoverContainers godoc
+// @Summary Discover labeled containers
+// @Description Adopt the containers labeled <DOCKER_DISCOVERY_LABEL_PREFIX>.enable=true on the local daemon and the enabled Docker hosts, with their update policy, registry and maintenance window taken from the update-policy, registry and maintenance-window labels. Adopted containers that lost the enable label are marked unmanaged. Labeled containers named like a container registered otherwise are reported as conflicts and left alone.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=dto.DiscoveryResult} "Discovery completed"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/discover [post]
+func (cc *ContainerController) DiscoverContainers(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := cc.containerService.DiscoverContainers(c.Request.Context(), middleware.CurrentActor(c))
+	if err != nil {
+		cc.logger.WithError(err).Error("Failed to discover containers")
+		rb.InternalServerError("Failed to discover containers")
+		return
+	}
+
+	rb.SuccessWithMessage(result, "Container discovery completed")
+}
+
+// CheckContainerUpdates godoc
+// @Summary Check containers for image updates
+// @Description Compare the image digest each container runs with the digest its tag resolves to in the registry, for all containers or the listed ones. Containers on the same image share one registry lookup; versions checked within IMAGE_CACHE_HOURS are reused unless force is set. max_concurrency bounds concurrent registry lookups up to MAX_CONCURRENT_CHECKS.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateCheckRequest false "Containers to check"
+// @Success 200 {object} utils.APIResponse{data=dto.UpdateCheckResult} "Per-container check results"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/check-updates [post]
+func (cc *ContainerController) CheckContainerUpdates(c *gin.Context) {
+	var req dto.UpdateCheckRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+			return
+		}
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := cc.containerService.CheckContainerUpdates(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		cc.respondDriftError(rb, err, 0, "Failed to check for updates")
+		return
+	}
+
+	rb.SuccessWithMessage(result, fmt.Sprintf("%d updates available, %d failed", result.UpdatesAvailable, result.Failed))
+}
+
+// respondError responds to a failed service call: with the usage for quota
+// violations, with the daemon's error code for Docker errors, and otherwise
+// with the code the service gave the error, or a 500 with fallback
+func respondError(rb *utils.ResponseBuilder, err error, fallback string) {
+	if respondQuotaError(rb, err) {
+		return
+	}
+	// A generic daemon error says less than the code the service gave it
+	if appErr := middleware.AppErrorFromDocker(err); appErr != nil && (appErr.Code != docker.CodeDockerError || apperrors.CodeOf(err) == "") {
+		respondDockerError(rb, err)
+		return
+	}
+	rb.FromError(err, fallback)
+}
+
+// respondDockerError responds with the status and error code of a Docker
+// daemon error, with the daemon's sanitized message as details. It reports
+// whether err came from Docker.
+func respondDockerError(rb *utils.ResponseBuilder, err error) bool {
+	appErr := middleware.AppErrorFromDocker(err)
+	if appErr == nil {
+		return false
+	}
+
+	rb.ErrorWithCode(appErr.StatusCode, apperrors.Code(appErr.Code), appErr.Message, []utils.ErrorDetail{
+		utils.NewErrorDetail("docker", appErr.Details, appErr.Code),
+	})
+	return true
+}
+
+// respondQuotaError responds 403 QUOTA_EXCEEDED with the team's usage when
+// err is a quota violation. It reports whether it responded.
+func respondQuotaError(rb *utils.ResponseBuilder, err error) bool {
+	var quotaErr *service.QuotaExceededError
+	if !errors.As(err, &quotaErr) {
+		return false
+	}
+
+	details := make([]utils.ErrorDetail, 0, len(quotaErr.Exceeded))
+	for _, dim := range quotaErr.Status.Dimensions {
+		for _, name := range quotaErr.Exceeded {
+			if dim.Name == name {
+				details = append(details, utils.NewErrorDetail(dim.Name,
+					fmt.Sprintf("would use %d of %d", dim.Used, *dim.Limit), "QUOTA_EXCEEDED"))
+			}
+		}
+	}
+	rb.ErrorWithData(http.StatusForbidden, "Team quota exceeded", quotaErr, details)
+	return true
+}
@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxComposeFileSize bounds the compose file an import reads
+const maxComposeFileSize = 1 << 20
+
+// ImportCompose godoc
+// @Summary Import a Docker Compose project
+// @Description Create one managed container per service of a docker-compose.yml, sent as the raw YAML body or as the "file" field of a multipart form. Image, container_name, environment, labels, ports, volumes and restart are mapped; services whose container already exists by name are skipped, and features that cannot be mapped, such as build or depends_on, are reported as warnings on the service.
+// @Tags Containers
+// @Accept application/x-yaml
+// @Accept multipart/form-data
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file false "Compose file"
+// @Success 200 {object} utils.APIResponse{data=dto.ComposeImportResult} "Per-service import results"
+// @Failure 400 {object} utils.APIResponse "Invalid compose file"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/import/compose [post]
+func (cc *ContainerController) ImportCompose(c *gin.Context) {
+	data, err := readComposeFile(c)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid compose file: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := cc.containerService.ImportCompose(c.Request.Context(), middleware.CurrentActor(c), data)
+	if err != nil {
+		cc.respondDriftError(rb, err, 0, "Failed to import compose project")
+		return
+	}
+
+	rb.SuccessWithMessage(result, fmt.Sprintf("%d created, %d skipped, %d failed", result.Created, result.Skipped, result.Failed))
+}
+
+// readComposeFile reads the compose file from a multipart upload or the body
+func readComposeFile(c *gin.Context) ([]byte, error) {
+	body := io.Reader(c.Request.Body)
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("missing file field: %v", err)
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read: %v", err)
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxComposeFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read: %v", err)
+	}
+	if len(data) > maxComposeFileSize {
+		return nil, fmt.Errorf("larger than %d bytes", maxComposeFileSize)
+	}
+	if len(strings.TrimSpace(string(data))) == 0 {
+		return nil, fmt.Errorf("file is empty")
+	}
+	return data, nil
+}
@@ -0,0 +1,60 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AcknowledgeCrashLoop godoc
+// @Summary Acknowledge container crash loop
+// @Description Release the hold a detected crash loop put on automatic updates of the container. The crash_looping flag clears by itself once the container has run for the stable period.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=model.Container} "Crash loop acknowledged"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID or container not crash looping"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/ack-crashloop [post]
+func (cc *ContainerController) AcknowledgeCrashLoop(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	container, err := cc.containerService.AcknowledgeCrashLoop(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to acknowledge crash loop")
+
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid request"):
+			rb.BadRequest(err.Error())
+		case strings.HasPrefix(err.Error(), "access denied"):
+			rb.Forbidden("Access denied")
+		case strings.Contains(err.Error(), "not found"):
+			rb.NotFound("Container not found")
+		default:
+			rb.InternalServerError("Failed to acknowledge crash loop")
+		}
+		return
+	}
+
+	cc.logger.WithFields(logrus.Fields{
+		"actor":        middleware.CurrentActor(c).String(),
+		"container_id": containerID,
+	}).Info("Container crash loop acknowledged")
+
+	rb.Success(container)
+}
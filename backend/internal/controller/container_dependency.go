@@ -0,0 +1,99 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetContainerDependencies godoc
+// @Summary List container dependencies
+// @Description List the containers a container depends on and the containers depending on it. Bulk operations and scheduled updates start and update dependencies before their dependents and stop them after.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerDependencies} "Dependencies"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/dependencies [get]
+func (cc *ContainerController) GetContainerDependencies(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	dependencies, err := cc.containerService.GetContainerDependencies(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to get container dependencies")
+		cc.dependenciesError(rb, err, "Failed to get container dependencies")
+		return
+	}
+
+	rb.Success(dependencies)
+}
+
+// SetContainerDependencies godoc
+// @Summary Set container dependencies
+// @Description Replace the containers a container depends on; an empty list removes them all. Dependencies that would form a loop are rejected with an error naming the containers around it.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param request body dto.SetContainerDependenciesRequest true "Containers the container depends on"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerDependencies} "Dependencies after the change"
+// @Failure 400 {object} utils.APIResponse "Invalid request or dependency cycle"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/dependencies [put]
+func (cc *ContainerController) SetContainerDependencies(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var req dto.SetContainerDependenciesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	dependencies, err := cc.containerService.SetContainerDependencies(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to set container dependencies")
+		cc.dependenciesError(rb, err, "Failed to set container dependencies")
+		return
+	}
+
+	rb.Success(dependencies)
+}
+
+func (cc *ContainerController) dependenciesError(rb *utils.ResponseBuilder, err error, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden("Access denied")
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Container not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GetContainerDrift godoc
+// @Summary Get container configuration drift
+// @Description Inspect the live container and compare its image digest, environment, mounts, ports, restart policy, resource limits and labels with the stored configuration. Secret environment values are compared by hash and never returned. A drifted report carries the confirm_token for converging the container.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=service.DriftReport} "Drift report"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID or container not created"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/drift [get]
+func (cc *ContainerController) GetContainerDrift(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	report, err := cc.containerService.GetContainerDrift(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
+		cc.respondDriftError(rb, err, containerID, "Failed to check container drift")
+		return
+	}
+
+	rb.Success(report)
+}
+
+// ConvergeContainer godoc
+// @Summary Converge container to its stored configuration
+// @Description Recreate a drifted container from its stored configuration through a tracked update. Requires the confirm_token of a drift report showing the same drift.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param request body service.ConvergeRequest true "Converge confirmation"
+// @Success 200 {object} utils.APIResponse{data=service.ConvergeResult} "Container converged"
+// @Failure 400 {object} utils.APIResponse "Invalid request or container not drifted"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 409 {object} utils.APIResponse "Confirmation token invalid or drift changed"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/converge [post]
+func (cc *ContainerController) ConvergeContainer(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var req service.ConvergeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := cc.containerService.ConvergeContainer(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
+	if err != nil {
+		cc.respondDriftError(rb, err, containerID, "Failed to converge container")
+		return
+	}
+
+	cc.logger.WithFields(logrus.Fields{
+		"actor":        middleware.CurrentActor(c).String(),
+		"container_id": containerID,
+		"update_id":    result.Update.ID,
+	}).Info("Container converged to its stored configuration")
+
+	rb.Success(result)
+}
+
+// RedeployContainer godoc
+// @Summary Redeploy container from its deployed digest
+// @Description Resolve image drift, where the container runs another image digest than it was deployed with because the image was pulled and the container recreated outside the application. By default the container is recreated from the deployed digest through a tracked update, pulling it when missing and pointing the tag back at it. With repin the digest it runs is taken as deployed instead, and as the pin of containers pinned by digest.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param request body service.RedeployRequest false "Redeploy options"
+// @Success 200 {object} utils.APIResponse{data=service.RedeployResult} "Image drift resolved"
+// @Failure 400 {object} utils.APIResponse "Invalid request or no image drift"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/redeploy [post]
+func (cc *ContainerController) RedeployContainer(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var req service.RedeployRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+			return
+		}
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := cc.containerService.RedeployContainer(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to redeploy container")
+		respondError(rb, err, "Failed to redeploy container")
+		return
+	}
+
+	cc.logger.WithFields(logrus.Fields{
+		"actor":        middleware.CurrentActor(c).String(),
+		"container_id": containerID,
+		"repin":        req.Repin,
+	}).Info("Container image drift resolved")
+
+	rb.Success(result)
+}
+
+func (cc *ContainerController) respondDriftError(rb *utils.ResponseBuilder, err error, containerID int64, message string) {
+	cc.logger.WithError(err).WithField("container_id", containerID).Error(message)
+
+	if errors.Is(err, service.ErrConfirmationInvalid) {
+		rb.Conflict(err.Error())
+		return
+	}
+	respondError(rb, err, message)
+}
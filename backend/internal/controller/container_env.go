@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetContainerEnv godoc
+// @Summary List container environment variables
+// @Description List the stored environment variables of a container. Secret values are masked as "*****" unless the caller has the container:secrets permission.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerConfigEntries} "Environment variables"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/env [get]
+func (cc *ContainerController) GetContainerEnv(c *gin.Context) {
+	cc.getConfigEntries(c, "environment variables", cc.containerService.GetContainerEnv)
+}
+
+// GetContainerLabels godoc
+// @Summary List container labels
+// @Description List the stored labels of a container. Values matching a sensitive pattern are masked as "*****" unless the caller has the container:secrets permission.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerConfigEntries} "Labels"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/labels [get]
+func (cc *ContainerController) GetContainerLabels(c *gin.Context) {
+	cc.getConfigEntries(c, "labels", cc.containerService.GetContainerLabels)
+}
+
+// PatchContainerEnv godoc
+// @Summary Change container environment variables
+// @Description Add, update and remove stored environment variables of a container, in order. Variables matching a sensitive pattern, or sent with secret set, are flagged secret and masked in responses. A created container gets the changes when next recreated and is marked pending_recreate until then.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param request body dto.PatchConfigEntriesRequest true "Environment variable operations"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerConfigEntries} "Environment variables after the change"
+// @Failure 400 {object} utils.APIResponse "Invalid request or operation"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/env [patch]
+func (cc *ContainerController) PatchContainerEnv(c *gin.Context) {
+	cc.patchConfigEntries(c, "environment variables", cc.containerService.PatchContainerEnv)
+}
+
+// PatchContainerLabels godoc
+// @Summary Change container labels
+// @Description Add, update and remove stored labels of a container, in order. A created container gets the changes when next recreated and is marked pending_recreate until then.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param request body dto.PatchConfigEntriesRequest true "Label operations"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerConfigEntries} "Labels after the change"
+// @Failure 400 {object} utils.APIResponse "Invalid request or operation"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/labels [patch]
+func (cc *ContainerController) PatchContainerLabels(c *gin.Context) {
+	cc.patchConfigEntries(c, "labels", cc.containerService.PatchContainerLabels)
+}
+
+func (cc *ContainerController) getConfigEntries(c *gin.Context, what string,
+	list func(ctx context.Context, actor model.Actor, containerID int64, reveal bool) (*dto.ContainerConfigEntries, error)) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	reveal := middleware.HasPermission(c, middleware.PermissionContainerSecrets)
+	entries, err := list(c.Request.Context(), middleware.CurrentActor(c), containerID, reveal)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to get container " + what)
+		cc.configEntriesError(rb, err, "Failed to get container "+what)
+		return
+	}
+
+	rb.Success(entries)
+}
+
+func (cc *ContainerController) patchConfigEntries(c *gin.Context, what string,
+	apply func(ctx context.Context, actor model.Actor, containerID int64, req *dto.PatchConfigEntriesRequest, reveal bool) (*dto.ContainerConfigEntries, error)) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var req dto.PatchConfigEntriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	reveal := middleware.HasPermission(c, middleware.PermissionContainerSecrets)
+	entries, err := apply(c.Request.Context(), middleware.CurrentActor(c), containerID, &req, reveal)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to change container " + what)
+		cc.configEntriesError(rb, err, "Failed to change container "+what)
+		return
+	}
+
+	rb.Success(entries)
+}
+
+func (cc *ContainerController) configEntriesError(rb *utils.ResponseBuilder, err error, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden("Access denied")
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Container not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
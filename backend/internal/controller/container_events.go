@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/events"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// containerEventHeartbeat is how often an idle event stream sends a comment
+// so proxies keep the connection open
+const containerEventHeartbeat = 30 * time.Second
+
+// StreamContainerEvents godoc
+// @Summary Stream container events
+// @Description Server-Sent Events stream of state changes of the caller's containers: start, stop, die and health_status from the Docker daemon, and update_started and update_completed from updates. Each event's data is JSON with the container ID; a comment is sent every 30 seconds while idle. The stream covers the local daemon and reconnects to it on its own.
+// @Tags Containers
+// @Produce text/event-stream
+// @Security BearerAuth
+// @Success 200 {object} events.ContainerState "Event stream"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 503 {object} utils.APIResponse "Container events are not configured"
+// @Router /api/events [get]
+func (cc *ContainerController) StreamContainerEvents(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	subscription, err := cc.containerService.SubscribeContainerEvents(middleware.CurrentActor(c))
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "access denied") {
+			rb.Forbidden(err.Error())
+		} else {
+			rb.ServiceUnavailable(err.Error())
+		}
+		return
+	}
+	defer cc.containerService.UnsubscribeContainerEvents(subscription)
+
+	// The stream outlives the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		cc.logger.WithError(err).Debug("Failed to clear write deadline of container event stream")
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	if _, err := fmt.Fprint(c.Writer, ": connected\n\n"); err != nil {
+		return
+	}
+	c.Writer.Flush()
+
+	heartbeat := time.NewTicker(containerEventHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			_, err = fmt.Fprint(c.Writer, ": heartbeat\n\n")
+		case event, ok := <-subscription.Channel:
+			if !ok {
+				// The publisher shut down
+				return
+			}
+			state, ok := events.ContainerStateOf(event)
+			if !ok {
+				continue
+			}
+			data, marshalErr := json.Marshal(state)
+			if marshalErr != nil {
+				cc.logger.WithError(marshalErr).Warn("Failed to encode container event")
+				continue
+			}
+			_, err = fmt.Fprintf(c.Writer, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, state.Action, data)
+		}
+		if err != nil {
+			// The client went away
+			return
+		}
+		c.Writer.Flush()
+	}
+}
@@ -0,0 +1,193 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// execReadLimit bounds a client message; input is typed or pasted
+	execReadLimit = 64 * 1024
+	// execWriteTimeout bounds writing one frame to a slow client
+	execWriteTimeout = 10 * time.Second
+)
+
+// execUpgrader upgrades exec requests; origins other than the server's own
+// are refused
+var execUpgrader = websocket.Upgrader{
+	ReadBufferSize:  4096,
+	WriteBufferSize: 32 * 1024,
+}
+
+// execMessage is a client message on an exec socket: input for the command's
+// stdin, or the terminal size after a resize
+type execMessage struct {
+	Type string `json:"type"`
+	Data string `json:"data,omitempty"`
+	Rows uint   `json:"rows,omitempty"`
+	Cols uint   `json:"cols,omitempty"`
+}
+
+// ExecContainer godoc
+// @Summary Open a terminal in a container
+// @Description Upgrade to a WebSocket bridged to a command run with a TTY in the container, /bin/sh unless cmd is given (repeat cmd for each argument). Binary frames from the client are stdin; text frames are JSON messages, {"type":"input","data":"..."} or {"type":"resize","rows":24,"cols":80}. The server sends the TTY output as binary frames and closes the socket with the exit code as reason when the command exits. Closing the socket ends the session. Requires the container:exec permission and operate permission on the container; sessions are recorded in the activity log.
+// @Tags Containers
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param cmd query []string false "Command and arguments" collectionFormat(multi)
+// @Param user query string false "User to run the command as"
+// @Param rows query int false "Initial terminal rows"
+// @Param cols query int false "Initial terminal columns"
+// @Success 101 {string} string "Switching protocols"
+// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden or exec disabled"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 409 {object} utils.APIResponse "Container not running"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/exec [get]
+func (cc *ContainerController) ExecContainer(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		rb.BadRequest("Invalid container ID")
+		return
+	}
+
+	if !websocket.IsWebSocketUpgrade(c.Request) {
+		rb.BadRequest("WebSocket upgrade required")
+		return
+	}
+
+	logger := cc.logger.WithContext(c.Request.Context()).WithFields(logrus.Fields{
+		"user_id":      middleware.CurrentUserID(c),
+		"container_id": containerID,
+	})
+
+	options := &dto.ExecOptions{
+		Command: c.QueryArray("cmd"),
+		User:    c.Query("user"),
+	}
+
+	ctx := c.Request.Context()
+	session, err := cc.containerService.OpenExecSession(ctx, middleware.CurrentActor(c), containerID, options)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to open exec session")
+		respondError(rb, err, "Failed to open exec session")
+		return
+	}
+	defer session.Close()
+
+	conn, err := execUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has responded
+		logger.WithError(err).Warn("Failed to upgrade exec connection")
+		return
+	}
+	defer conn.Close()
+	conn.SetReadLimit(execReadLimit)
+
+	rows, _ := strconv.ParseUint(c.Query("rows"), 10, 32)
+	cols, _ := strconv.ParseUint(c.Query("cols"), 10, 32)
+	if rows > 0 && cols > 0 {
+		if err := session.Resize(ctx, uint(rows), uint(cols)); err != nil {
+			logger.WithError(err).Debug("Failed to set exec terminal size")
+		}
+	}
+
+	var writeMu sync.Mutex
+	writeMessage := func(messageType int, data []byte) error {
+		writeMu.Lock()
+		defer writeMu.Unlock()
+		conn.SetWriteDeadline(time.Now().Add(execWriteTimeout))
+		return conn.WriteMessage(messageType, data)
+	}
+
+	// Revoking the login session the socket was opened with ends it
+	if principal := middleware.GetPrincipal(c); cc.userService != nil && principal.Claims != nil && principal.Claims.SessionID != "" {
+		release := cc.userService.TrackSessionConnection(ctx, principal.Claims.UserID, principal.Claims.SessionID, func() {
+			conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "session revoked"), time.Now().Add(time.Second))
+			conn.Close()
+		})
+		defer release()
+	}
+
+	// Output is pumped until the command exits or the session is closed
+	outputDone := make(chan struct{})
+	go func() {
+		defer close(outputDone)
+		buf := make([]byte, 32*1024)
+		for {
+			n, readErr := session.Read(buf)
+			if n > 0 {
+				if err := writeMessage(websocket.BinaryMessage, buf[:n]); err != nil {
+					conn.Close()
+					return
+				}
+			}
+			if readErr != nil {
+				break
+			}
+		}
+
+		reason := "session closed"
+		if code, err := session.ExitCode(ctx); err == nil {
+			reason = fmt.Sprintf("exit code %d", code)
+		}
+		writeMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, reason))
+		conn.Close()
+	}()
+
+	for {
+		messageType, data, err := conn.ReadMessage()
+		if err != nil {
+			break
+		}
+		if err := handleExecMessage(ctx, session, messageType, data, logger); err != nil {
+			break
+		}
+	}
+
+	session.Close()
+	<-outputDone
+}
+
+// handleExecMessage applies a client message to the session. It fails when
+// the command's stdin is closed; malformed messages are ignored.
+func handleExecMessage(ctx context.Context, session *service.ExecSession, messageType int, data []byte, logger *logrus.Entry) error {
+	if messageType == websocket.BinaryMessage {
+		_, err := session.Write(data)
+		return err
+	}
+
+	var msg execMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nil
+	}
+	switch msg.Type {
+	case "input":
+		_, err := session.Write([]byte(msg.Data))
+		return err
+	case "resize":
+		if msg.Rows > 0 && msg.Cols > 0 {
+			if err := session.Resize(ctx, msg.Rows, msg.Cols); err != nil {
+				logger.WithError(err).Debug("Failed to resize exec terminal")
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-auto/internal/service"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+)
+
+func TestExecRouteRequiresExecPermission(t *testing.T) {
+	cfg := newTestRouterConfig()
+	cfg.RoleService = service.NewRoleService(&customRoleRepo{roles: []*model.Role{
+		{Name: "shell", Permissions: model.StringList{string(model.PermissionContainerExec)}},
+		{Name: "deployer", Permissions: model.StringList{
+			string(model.PermissionContainerRead), string(model.PermissionContainerWrite), string(model.PermissionContainerUpdate),
+		}},
+	}}, nil)
+	router, _ := newTestRouter(t, cfg)
+
+	tests := []struct {
+		role model.UserRole
+		want int
+	}{
+		// Admitted callers reach the handler, which wants a WebSocket upgrade
+		{model.UserRoleOperator, http.StatusBadRequest},
+		{"shell", http.StatusBadRequest},
+		{model.UserRoleViewer, http.StatusForbidden},
+		{"deployer", http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.role), func(t *testing.T) {
+			token, err := utils.GenerateJWT(&model.User{ID: 2, Username: "alice", Role: tt.role, IsActive: true}, cfg.Config.JWT.Secret)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/api/containers/1/exec", nil)
+			req.Header.Set("Authorization", "Bearer "+token)
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Errorf("GET /api/containers/1/exec as %s = %d, want %d: %s", tt.role, w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestExecRouteRefusedWhenDisabled(t *testing.T) {
+	cfg := newTestRouterConfig()
+	cfg.Config.Security.ContainerExecEnabled = false
+	cfg.ContainerService = service.NewContainerService(nil, nil, nil, nil, nil, nil, cfg.Config, nil, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	router, _ := newTestRouter(t, cfg)
+
+	token, err := utils.GenerateJWT(&model.User{ID: 1, Username: "admin", Role: model.UserRoleAdmin, IsActive: true}, cfg.Config.JWT.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/containers/1/exec", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+
+	if w.Code != http.StatusForbidden || !strings.Contains(w.Body.String(), string(apperrors.CodeExecDisabled)) {
+		t.Errorf("exec with exec disabled = %d %s, want 403 %s", w.Code, w.Body, apperrors.CodeExecDisabled)
+	}
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"strconv"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ExportContainerConfig godoc
+// @Summary Export container configuration
+// @Description Export a container's configuration in canonical form: map keys sorted, ports ordered by container port and protocol, volumes by target, and default or empty values omitted. Exporting the same configuration twice gives identical output.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerExport} "Container configuration"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/export [get]
+func (cc *ContainerController) ExportContainerConfig(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	export, err := cc.containerService.ExportContainerConfig(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
+		cc.respondDriftError(rb, err, containerID, "Failed to export container configuration")
+		return
+	}
+
+	rb.Success(export)
+}
+
+// DiffContainerConfig godoc
+// @Summary Diff an exported configuration
+// @Description Compare an exported configuration with the managed container of the same name and list the changes importing it would make, without making them. Secret environment values are masked.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ContainerExport true "Exported configuration"
+// @Success 200 {object} utils.APIResponse{data=dto.ConfigDiff} "Configuration diff"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/diff-config [post]
+func (cc *ContainerController) DiffContainerConfig(c *gin.Context) {
+	var doc dto.ContainerExport
+	if err := c.ShouldBindJSON(&doc); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	diff, err := cc.containerService.DiffContainerConfig(c.Request.Context(), middleware.CurrentActor(c), &doc)
+	if err != nil {
+		cc.respondDriftError(rb, err, 0, "Failed to diff container configuration")
+		return
+	}
+
+	rb.Success(diff)
+}
@@ -0,0 +1,173 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListHealthChecks godoc
+// @Summary List container health checks
+// @Description Get the health checks configured for a container with the outcome of their latest run
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=[]model.ContainerHealthCheck} "Health checks"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Router /api/containers/{id}/healthchecks [get]
+func (cc *ContainerController) ListHealthChecks(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	checks, err := cc.containerService.ListHealthChecks(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
+		cc.respondHealthCheckError(rb, err, containerID, "Failed to list health checks")
+		return
+	}
+
+	rb.Success(checks)
+}
+
+// CreateHealthCheck godoc
+// @Summary Add container health check
+// @Description Add an http, tcp or command health check to a container. The health checker runs it every interval_seconds and fails the container after failure_threshold consecutive failures, restarting it when restart_on_failure is set. Command checks require the container owner to have exec permission.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param request body dto.HealthCheckRequest true "Health check"
+// @Success 201 {object} utils.APIResponse{data=model.ContainerHealthCheck} "Health check added"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Router /api/containers/{id}/healthchecks [post]
+func (cc *ContainerController) CreateHealthCheck(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var req dto.HealthCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	check, err := cc.containerService.CreateHealthCheck(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
+	if err != nil {
+		cc.respondHealthCheckError(rb, err, containerID, "Failed to add health check")
+		return
+	}
+
+	rb.Created(check)
+}
+
+// UpdateHealthCheck godoc
+// @Summary Update container health check
+// @Description Replace the configuration of a container's health check; the outcome of its latest run is kept
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param checkId path int true "Health check ID"
+// @Param request body dto.HealthCheckRequest true "Health check"
+// @Success 200 {object} utils.APIResponse{data=model.ContainerHealthCheck} "Health check updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container or health check not found"
+// @Router /api/containers/{id}/healthchecks/{checkId} [put]
+func (cc *ContainerController) UpdateHealthCheck(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+	checkID, err := strconv.Atoi(c.Param("checkId"))
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid health check ID")
+		return
+	}
+
+	var req dto.HealthCheckRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	check, err := cc.containerService.UpdateHealthCheck(c.Request.Context(), middleware.CurrentActor(c), containerID, checkID, &req)
+	if err != nil {
+		cc.respondHealthCheckError(rb, err, containerID, "Failed to update health check")
+		return
+	}
+
+	rb.Success(check)
+}
+
+// DeleteHealthCheck godoc
+// @Summary Delete container health check
+// @Description Remove a health check from a container
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param checkId path int true "Health check ID"
+// @Success 200 {object} utils.APIResponse "Health check deleted"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container or health check not found"
+// @Router /api/containers/{id}/healthchecks/{checkId} [delete]
+func (cc *ContainerController) DeleteHealthCheck(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+	checkID, err := strconv.Atoi(c.Param("checkId"))
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid health check ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := cc.containerService.DeleteHealthCheck(c.Request.Context(), middleware.CurrentActor(c), containerID, checkID); err != nil {
+		cc.respondHealthCheckError(rb, err, containerID, "Failed to delete health check")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Health check deleted successfully")
+}
+
+// respondHealthCheckError maps health check errors onto HTTP responses
+func (cc *ContainerController) respondHealthCheckError(rb *utils.ResponseBuilder, err error, containerID int64, message string) {
+	cc.logger.WithError(err).WithField("container_id", containerID).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Container or health check not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,53 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BatchContainerLabels godoc
+// @Summary Set and remove labels on many containers
+// @Description Apply label operations to the containers matched by a filter or listed by ID. Labels are stored at once and reach Docker the next time each container is recreated, or right away with apply_now, which recreates the containers in stack start order. A batch changing more than ten containers returns a preview with a confirm_token; repeat the request with it to apply.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.LabelBatchRequest true "Label batch"
+// @Success 200 {object} utils.APIResponse{data=service.LabelBatchResponse} "Per-container results, or a preview awaiting confirmation"
+// @Failure 400 {object} utils.APIResponse "Invalid request or label"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 409 {object} utils.APIResponse "Confirmation token invalid or matched containers changed"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/labels/batch [post]
+func (cc *ContainerController) BatchContainerLabels(c *gin.Context) {
+	var req service.LabelBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	response, err := cc.containerService.BatchLabels(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		cc.logger.WithError(err).Error("Failed to batch container labels")
+		switch {
+		case errors.Is(err, service.ErrConfirmationInvalid):
+			rb.Conflict(err.Error())
+		case strings.HasPrefix(err.Error(), "invalid request"):
+			rb.BadRequest(err.Error())
+		default:
+			rb.InternalServerError("Failed to batch container labels")
+		}
+		return
+	}
+
+	rb.Success(response)
+}
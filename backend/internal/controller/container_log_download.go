@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DownloadContainerLogs godoc
+// @Summary Download container logs
+// @Description Download a container's logs between since and until as a plain text file named after the container and the time range, gzip-compressed when the client accepts it. Until defaults to now and is fixed when the request starts. Logs larger than max_bytes keep their newest lines: the file then starts with a "[docker-auto] log truncated" line and X-Log-Truncated is true. Secrets are redacted as in GetContainerLogs unless an admin passes raw=true.
+// @Tags Containers
+// @Produce plain
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param since query string false "Logs since timestamp (RFC3339)"
+// @Param until query string false "Logs until timestamp (RFC3339, default: now)"
+// @Param max_bytes query int false "Largest download in bytes, up to 524288000" default(10485760)
+// @Param timestamps query boolean false "Include timestamps" default(true)
+// @Param raw query boolean false "Skip secret redaction (admins only, audited)" default(false)
+// @Success 200 {file} file "Container log"
+// @Header 200 {boolean} X-Log-Truncated "Whether the oldest lines were left out"
+// @Header 200 {integer} X-Log-Total-Bytes "Size of the log in the range"
+// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/logs/download [get]
+func (cc *ContainerController) DownloadContainerLogs(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		rb.BadRequest("Invalid container ID")
+		return
+	}
+
+	options, maxBytes, err := parseLogDownloadQuery(c)
+	if err != nil {
+		rb.BadRequest(err.Error())
+		return
+	}
+
+	logger := cc.logger.WithFields(logrus.Fields{
+		"user_id":      middleware.CurrentUserID(c),
+		"container_id": containerID,
+	})
+
+	download, err := cc.containerService.PrepareLogDownload(c.Request.Context(), middleware.CurrentActor(c), containerID, options, maxBytes)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid request:"):
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+		case err.Error() == "container has no Docker instance" || strings.Contains(err.Error(), "not found"):
+			rb.NotFound("Container not found or not running")
+		case strings.HasPrefix(err.Error(), "access denied"):
+			rb.Forbidden(err.Error())
+		default:
+			logger.WithError(err).Error("Failed to prepare container log download")
+			rb.InternalServerError("Failed to download logs")
+		}
+		return
+	}
+
+	c.Header("Content-Type", "text/plain; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", download.Filename()))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("X-Log-Truncated", strconv.FormatBool(download.Truncated()))
+	c.Header("X-Log-Total-Bytes", strconv.FormatInt(download.TotalBytes, 10))
+	c.Header("X-Log-Max-Bytes", strconv.FormatInt(download.MaxBytes, 10))
+
+	var w io.Writer = c.Writer
+	if strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+		c.Header("Content-Encoding", "gzip")
+		c.Header("Vary", "Accept-Encoding")
+		gz := gzip.NewWriter(c.Writer)
+		defer gz.Close()
+		w = gz
+	}
+	c.Status(http.StatusOK)
+
+	if err := cc.containerService.WriteLogDownload(c.Request.Context(), w, download); err != nil {
+		logger.WithError(err).Error("Container log download interrupted")
+	}
+}
+
+// parseLogDownloadQuery reads the query parameters of a log download. Unlike
+// the other log endpoints, malformed times are rejected rather than ignored.
+func parseLogDownloadQuery(c *gin.Context) (*dto.LogOptions, int64, error) {
+	options := &dto.LogOptions{}
+
+	var err error
+	if options.Timestamps, err = strconv.ParseBool(c.DefaultQuery("timestamps", "true")); err != nil {
+		return nil, 0, fmt.Errorf("timestamps must be true or false")
+	}
+	if options.Raw, err = strconv.ParseBool(c.DefaultQuery("raw", "false")); err != nil {
+		return nil, 0, fmt.Errorf("raw must be true or false")
+	}
+	if value := c.Query("since"); value != "" {
+		if options.Since, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, 0, fmt.Errorf("since must be an RFC3339 timestamp")
+		}
+	}
+	if value := c.Query("until"); value != "" {
+		if options.Until, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, 0, fmt.Errorf("until must be an RFC3339 timestamp")
+		}
+	}
+
+	maxBytes := int64(service.DefaultLogDownloadBytes)
+	if value := c.Query("max_bytes"); value != "" {
+		if maxBytes, err = strconv.ParseInt(value, 10, 64); err != nil {
+			return nil, 0, fmt.Errorf("max_bytes must be a number")
+		}
+	}
+
+	return options, maxBytes, nil
+}
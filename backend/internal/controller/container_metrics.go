@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetContainerMetricsHistory godoc
+// @Summary Get container stats history
+// @Description Get a container's CPU, memory, network and disk usage over a time range as series aligned on one timestamp per step. CPU and memory are averages over each step; network and disk values are the bytes transferred during it. Steps without samples are null. The range defaults to the last hour; the step is raised to a multiple of the collection resolution that keeps the series within 1000 points.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param from query string false "Start, RFC3339 or YYYY-MM-DD"
+// @Param to query string false "End, RFC3339 or YYYY-MM-DD (the day is included)"
+// @Param step query string false "Step, a duration such as 5m or seconds"
+// @Success 200 {object} utils.APIResponse{data=dto.ContainerMetricsSeries} "Container stats history"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID or query"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/metrics [get]
+func (cc *ContainerController) GetContainerMetricsHistory(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var query dto.ContainerMetricsQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequestJSON(c, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	series, err := cc.containerService.GetContainerMetricsHistory(c.Request.Context(), middleware.CurrentActor(c), containerID, &query)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid request:"):
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+		case strings.HasPrefix(err.Error(), "access denied"):
+			rb.Forbidden("Access denied")
+		case strings.Contains(err.Error(), "not found"):
+			rb.NotFound("Container not found")
+		default:
+			cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to get container stats history")
+			rb.InternalServerError("Failed to get container stats history")
+		}
+		return
+	}
+
+	rb.Success(series)
+}
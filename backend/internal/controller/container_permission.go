@@ -0,0 +1,143 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ListContainerPermissions godoc
+// @Summary List container permissions
+// @Description List the users and roles a container is shared with and their permission: view reads it, operate also starts, stops and updates it, manage also changes its configuration, deletes it and shares it. Requires manage on the container.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=[]model.ContainerPermission} "Permissions"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/permissions [get]
+func (cc *ContainerController) ListContainerPermissions(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	permissions, err := cc.containerService.ListContainerPermissions(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to list container permissions")
+		cc.permissionError(rb, err, "Failed to list container permissions")
+		return
+	}
+
+	rb.Success(permissions)
+}
+
+// GrantContainerPermission godoc
+// @Summary Share a container
+// @Description Grant a user, or every user of a role, view, operate or manage on a container, replacing their earlier permission. Admins keep access to every container and the creator keeps manage. Requires manage on the container.
+// @Tags Containers
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param request body dto.GrantContainerPermissionRequest true "User or role and permission"
+// @Success 200 {object} utils.APIResponse{data=model.ContainerPermission} "Permission granted"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/permissions [post]
+func (cc *ContainerController) GrantContainerPermission(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var req dto.GrantContainerPermissionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	permission, err := cc.containerService.GrantContainerPermission(c.Request.Context(), middleware.CurrentActor(c), containerID, &req)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to grant container permission")
+		cc.permissionError(rb, err, "Failed to grant container permission")
+		return
+	}
+
+	rb.Success(permission)
+}
+
+// RevokeContainerPermission godoc
+// @Summary Stop sharing a container
+// @Description Remove the permission of a user or a role on a container. The creator's manage cannot be revoked. Requires manage on the container.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param user_id query int false "User whose permission to remove"
+// @Param role query string false "Role whose permission to remove"
+// @Success 200 {object} utils.APIResponse "Permission revoked"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container or permission not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/permissions [delete]
+func (cc *ContainerController) RevokeContainerPermission(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	var query dto.RevokeContainerPermissionQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		utils.BadRequestJSON(c, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := cc.containerService.RevokeContainerPermission(c.Request.Context(), middleware.CurrentActor(c), containerID, &query); err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to revoke container permission")
+		cc.permissionError(rb, err, "Failed to revoke container permission")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Permission revoked")
+}
+
+func (cc *ContainerController) permissionError(rb *utils.ResponseBuilder, err error, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request:"):
+		rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden("Access denied")
+	case err.Error() == "permission not found":
+		rb.NotFound("Permission not found")
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Container not found")
+	case strings.Contains(err.Error(), "not available"):
+		rb.ServiceUnavailable(err.Error())
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"errors"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SelfUpdate godoc
+// @Summary Update docker-auto itself
+// @Description Update the container docker-auto runs in to its deploy image, or to tag. The image is pulled, then a helper container started from the current image takes the update over: it stops this server, recreates its container with the same configuration and the new image, and brings the old container back if the new one does not become healthy. The update returned is still running; update_progress and update_completed events on the events stream follow it, and the new server records the outcome. Refused when the helper cannot reach the Docker socket.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.SelfUpdateRequest false "Update options"
+// @Success 200 {object} utils.APIResponse{data=model.UpdateHistory} "Update handed to the helper"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "docker-auto's container is not managed"
+// @Failure 409 {object} utils.APIResponse "Not running in a container, or the helper cannot reach Docker"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/system/self-update [post]
+func (cc *ContainerController) SelfUpdate(c *gin.Context) {
+	var req dto.SelfUpdateRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+			return
+		}
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	history, err := cc.containerService.SelfUpdate(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrSelfUpdateUnavailable):
+			rb.Conflict(err.Error())
+		case strings.HasPrefix(err.Error(), "invalid request:"):
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+		case strings.Contains(err.Error(), "not found"):
+			rb.NotFound("docker-auto's container is not managed")
+		default:
+			cc.logger.WithError(err).Error("Failed to start self-update")
+			if respondDockerError(rb, err) {
+				return
+			}
+			rb.InternalServerError("Failed to start self-update")
+		}
+		return
+	}
+
+	cc.logger.WithField("update_id", history.ID).Info("Self-update handed to helper")
+	rb.Success(history)
+}
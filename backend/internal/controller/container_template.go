@@ -0,0 +1,220 @@
+package controller
+
+import (
+	"strconv"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ContainerTemplateController handles container templates and creating
+// containers from them
+type ContainerTemplateController struct {
+	templateService *service.ContainerTemplateService
+	logger          *logrus.Logger
+}
+
+// NewContainerTemplateController creates a new container template controller
+func NewContainerTemplateController(templateService *service.ContainerTemplateService, logger *logrus.Logger) *ContainerTemplateController {
+	return &ContainerTemplateController{
+		templateService: templateService,
+		logger:          logger,
+	}
+}
+
+// ListTemplates godoc
+// @Summary List container templates
+// @Description Get the shared container templates, built-in ones included, and the caller's private ones
+// @Tags Container Templates
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]model.ContainerTemplate} "Container templates"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Router /api/templates [get]
+func (tc *ContainerTemplateController) ListTemplates(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	templates, err := tc.templateService.ListTemplates(c.Request.Context(), middleware.CurrentActor(c))
+	if err != nil {
+		tc.logger.WithError(err).Error("Failed to list container templates")
+		respondError(rb, err, "Failed to list container templates")
+		return
+	}
+
+	rb.Success(templates)
+}
+
+// GetTemplate godoc
+// @Summary Get container template
+// @Description Get a shared container template or one of the caller's own
+// @Tags Container Templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} utils.APIResponse{data=model.ContainerTemplate} "Container template"
+// @Failure 400 {object} utils.APIResponse "Invalid template ID"
+// @Failure 404 {object} utils.APIResponse "Template not found"
+// @Router /api/templates/{id} [get]
+func (tc *ContainerTemplateController) GetTemplate(c *gin.Context) {
+	id, ok := templateID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	template, err := tc.templateService.GetTemplate(c.Request.Context(), middleware.CurrentActor(c), id)
+	if err != nil {
+		respondError(rb, err, "Failed to get container template")
+		return
+	}
+
+	rb.Success(template)
+}
+
+// CreateTemplate godoc
+// @Summary Create container template
+// @Description Create a container template. Config holds container create request fields other than name, image and tag; its strings may hold {{NAME}} placeholders for the declared variables, and a number variable alone in a string renders as a number. The template is rejected unless it renders a valid create request. Only admins may share templates.
+// @Tags Container Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ContainerTemplateRequest true "Container template"
+// @Success 201 {object} utils.APIResponse{data=model.ContainerTemplate} "Template created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/templates [post]
+func (tc *ContainerTemplateController) CreateTemplate(c *gin.Context) {
+	var req dto.ContainerTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	template, err := tc.templateService.CreateTemplate(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		respondError(rb, err, "Failed to create container template")
+		return
+	}
+
+	rb.Created(template)
+}
+
+// UpdateTemplate godoc
+// @Summary Update container template
+// @Description Replace a container template. Owners change their private templates and admins shared ones; built-in templates cannot be changed.
+// @Tags Container Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body dto.ContainerTemplateRequest true "Container template"
+// @Success 200 {object} utils.APIResponse{data=model.ContainerTemplate} "Template updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Template not found"
+// @Router /api/templates/{id} [put]
+func (tc *ContainerTemplateController) UpdateTemplate(c *gin.Context) {
+	id, ok := templateID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.ContainerTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	template, err := tc.templateService.UpdateTemplate(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		respondError(rb, err, "Failed to update container template")
+		return
+	}
+
+	rb.Success(template)
+}
+
+// DeleteTemplate godoc
+// @Summary Delete container template
+// @Description Delete a container template; containers created from it are kept
+// @Tags Container Templates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Success 200 {object} utils.APIResponse "Template deleted"
+// @Failure 400 {object} utils.APIResponse "Built-in template"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Template not found"
+// @Router /api/templates/{id} [delete]
+func (tc *ContainerTemplateController) DeleteTemplate(c *gin.Context) {
+	id, ok := templateID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := tc.templateService.DeleteTemplate(c.Request.Context(), middleware.CurrentActor(c), id); err != nil {
+		respondError(rb, err, "Failed to delete container template")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Container template deleted successfully")
+}
+
+// InstantiateTemplate godoc
+// @Summary Create container from template
+// @Description Render a container template with the given variables, which fall back to their defaults, and create the container it describes as POST /api/containers would
+// @Tags Container Templates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Template ID"
+// @Param request body dto.InstantiateTemplateRequest true "Container name and variables"
+// @Success 201 {object} utils.APIResponse{data=model.Container} "Container created"
+// @Failure 400 {object} utils.APIResponse "Invalid request or missing variables"
+// @Failure 404 {object} utils.APIResponse "Template not found"
+// @Failure 409 {object} utils.APIResponse "Container already exists"
+// @Router /api/templates/{id}/instantiate [post]
+func (tc *ContainerTemplateController) InstantiateTemplate(c *gin.Context) {
+	id, ok := templateID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.InstantiateTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	container, err := tc.templateService.Instantiate(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		tc.logger.WithError(err).WithField("template_id", id).Warn("Failed to create container from template")
+		respondError(rb, err, "Failed to create container from template")
+		return
+	}
+
+	rb.Created(container)
+}
+
+func templateID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid template ID")
+		return 0, false
+	}
+	return id, true
+}
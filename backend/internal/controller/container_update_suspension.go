@@ -0,0 +1,49 @@
+package controller
+
+import (
+	"strconv"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ResumeContainerUpdates godoc
+// @Summary Resume suspended automatic updates
+// @Description Lift the suspension repeated failed automatic updates put on the container and reset its failure count. Updates also resume by themselves once the image digest changes.
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Success 200 {object} utils.APIResponse{data=model.Container} "Automatic updates resumed"
+// @Failure 400 {object} utils.APIResponse "Invalid container ID or updates not suspended"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/resume-updates [post]
+func (cc *ContainerController) ResumeContainerUpdates(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	container, err := cc.containerService.ResumeUpdates(c.Request.Context(), middleware.CurrentActor(c), containerID)
+	if err != nil {
+		cc.logger.WithError(err).WithField("container_id", containerID).Error("Failed to resume container updates")
+		respondError(rb, err, "Failed to resume container updates")
+		return
+	}
+
+	cc.logger.WithFields(logrus.Fields{
+		"actor":        middleware.CurrentActor(c).String(),
+		"container_id": containerID,
+	}).Info("Container automatic updates resumed")
+
+	rb.Success(container)
+}
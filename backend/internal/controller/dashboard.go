@@ -0,0 +1,66 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DashboardController handles the landing page summary
+type DashboardController struct {
+	dashboardService *service.DashboardService
+	logger           *logrus.Logger
+}
+
+// NewDashboardController creates a new dashboard controller
+func NewDashboardController(dashboardService *service.DashboardService, logger *logrus.Logger) *DashboardController {
+	return &DashboardController{
+		dashboardService: dashboardService,
+		logger:           logger,
+	}
+}
+
+// GetDashboard godoc
+// @Summary Get dashboard summary
+// @Description Get the landing page summary in one call: managed containers by status, containers the image version cache knows an update for, the most recent updates, the scheduler status, Docker disk usage and the caller's unread notification count. The parts are read concurrently; a part that cannot be read, e.g. disk usage while the Docker daemon is unreachable, is null and named in warnings.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param recent_updates query int false "Number of recent updates, 1 to 50" default(10)
+// @Success 200 {object} utils.APIResponse{data=service.Dashboard} "Dashboard summary"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/dashboard [get]
+func (dc *DashboardController) GetDashboard(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	recentUpdates := service.DefaultDashboardRecentUpdates
+	if value := c.Query("recent_updates"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			rb.BadRequest("recent_updates must be a number")
+			return
+		}
+		recentUpdates = n
+	}
+
+	dashboard, err := dc.dashboardService.GetDashboard(c.Request.Context(), middleware.CurrentUserID(c), recentUpdates)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid request:") {
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+			return
+		}
+		dc.logger.WithError(err).Error("Failed to get dashboard")
+		rb.InternalServerError("Failed to get dashboard")
+		return
+	}
+
+	rb.Success(dashboard)
+}
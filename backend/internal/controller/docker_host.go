@@ -0,0 +1,224 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DockerHostController handles the remote Docker daemons containers run on
+type DockerHostController struct {
+	hostService *service.DockerHostService
+	logger      *logrus.Logger
+}
+
+// NewDockerHostController creates a new Docker host controller
+func NewDockerHostController(hostService *service.DockerHostService, logger *logrus.Logger) *DockerHostController {
+	return &DockerHostController{
+		hostService: hostService,
+		logger:      logger,
+	}
+}
+
+// ListHosts godoc
+// @Summary List Docker hosts
+// @Description Get the remote Docker daemons containers can run on, with the outcome of their latest ping. Containers without a host run on the local daemon.
+// @Tags Docker Hosts
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]model.DockerHost} "Docker hosts"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/hosts [get]
+func (hc *DockerHostController) ListHosts(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	hosts, err := hc.hostService.ListHosts(c.Request.Context())
+	if err != nil {
+		hc.respondError(rb, err, "Failed to list Docker hosts")
+		return
+	}
+
+	rb.Success(hosts)
+}
+
+// GetHost godoc
+// @Summary Get Docker host
+// @Description Get a remote Docker daemon
+// @Tags Docker Hosts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Docker host ID"
+// @Success 200 {object} utils.APIResponse{data=model.DockerHost} "Docker host"
+// @Failure 404 {object} utils.APIResponse "Docker host not found"
+// @Router /api/hosts/{id} [get]
+func (hc *DockerHostController) GetHost(c *gin.Context) {
+	id, ok := dockerHostID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	host, err := hc.hostService.GetHost(c.Request.Context(), id)
+	if err != nil {
+		hc.respondError(rb, err, "Failed to get Docker host")
+		return
+	}
+
+	rb.Success(host)
+}
+
+// CreateHost godoc
+// @Summary Create Docker host
+// @Description Add a remote Docker daemon reached over tcp:// (optionally with TLS), unix:// or ssh://. Certificate and key paths are files on the server; SSH host keys must be in DOCKER_SSH_KNOWN_HOSTS_FILE.
+// @Tags Docker Hosts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateDockerHostRequest true "Docker host"
+// @Success 201 {object} utils.APIResponse{data=model.DockerHost} "Docker host created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 409 {object} utils.APIResponse "Name already in use"
+// @Router /api/hosts [post]
+func (hc *DockerHostController) CreateHost(c *gin.Context) {
+	var req dto.CreateDockerHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	host, err := hc.hostService.CreateHost(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		hc.respondError(rb, err, "Failed to create Docker host")
+		return
+	}
+
+	rb.Created(host)
+}
+
+// UpdateHost godoc
+// @Summary Update Docker host
+// @Description Change a remote Docker daemon; omitted fields keep their values. Disabling a host stops its containers from being managed and synced.
+// @Tags Docker Hosts
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Docker host ID"
+// @Param request body dto.UpdateDockerHostRequest true "Docker host changes"
+// @Success 200 {object} utils.APIResponse{data=model.DockerHost} "Docker host updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Docker host not found"
+// @Failure 409 {object} utils.APIResponse "Name already in use"
+// @Router /api/hosts/{id} [put]
+func (hc *DockerHostController) UpdateHost(c *gin.Context) {
+	id, ok := dockerHostID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateDockerHostRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	host, err := hc.hostService.UpdateHost(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		hc.respondError(rb, err, "Failed to update Docker host")
+		return
+	}
+
+	rb.Success(host)
+}
+
+// DeleteHost godoc
+// @Summary Delete Docker host
+// @Description Remove a remote Docker daemon. It is refused while containers are attached unless force is set, which removes their records and leaves the Docker containers running on the daemon.
+// @Tags Docker Hosts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Docker host ID"
+// @Param force query bool false "Also remove the attached containers' records"
+// @Success 200 {object} utils.APIResponse "Docker host deleted"
+// @Failure 404 {object} utils.APIResponse "Docker host not found"
+// @Failure 409 {object} utils.APIResponse "Containers still attached"
+// @Router /api/hosts/{id} [delete]
+func (hc *DockerHostController) DeleteHost(c *gin.Context) {
+	id, ok := dockerHostID(c)
+	if !ok {
+		return
+	}
+
+	force, _ := strconv.ParseBool(c.DefaultQuery("force", "false"))
+	rb := utils.NewResponseBuilder(c)
+
+	if err := hc.hostService.DeleteHost(c.Request.Context(), middleware.CurrentActor(c), id, force); err != nil {
+		hc.respondError(rb, err, "Failed to delete Docker host")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Docker host deleted successfully")
+}
+
+// PingHost godoc
+// @Summary Ping Docker host
+// @Description Check that a remote Docker daemon answers. The outcome is recorded on the host; a failed ping is reported in the result.
+// @Tags Docker Hosts
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Docker host ID"
+// @Success 200 {object} utils.APIResponse{data=dto.DockerHostPingResult} "Ping result"
+// @Failure 404 {object} utils.APIResponse "Docker host not found"
+// @Router /api/hosts/{id}/ping [post]
+func (hc *DockerHostController) PingHost(c *gin.Context) {
+	id, ok := dockerHostID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := hc.hostService.PingHost(c.Request.Context(), id)
+	if err != nil {
+		hc.respondError(rb, err, "Failed to ping Docker host")
+		return
+	}
+
+	rb.Success(result)
+}
+
+func dockerHostID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid Docker host ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError maps Docker host service errors onto HTTP responses
+func (hc *DockerHostController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request:"):
+		rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+	case strings.Contains(err.Error(), "already exists"), strings.Contains(err.Error(), "still has"):
+		rb.Conflict(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Docker host not found")
+	default:
+		hc.logger.WithError(err).Error(message)
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"errors"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// DockerSessionController lists and force-closes the exec attachments and
+// streams held open against the Docker daemon
+type DockerSessionController struct {
+	dockerClient *docker.DockerClient
+	logger       *logrus.Logger
+}
+
+// NewDockerSessionController creates a new Docker session controller
+func NewDockerSessionController(dockerClient *docker.DockerClient, logger *logrus.Logger) *DockerSessionController {
+	return &DockerSessionController{
+		dockerClient: dockerClient,
+		logger:       logger,
+	}
+}
+
+// DockerSessionList is the open sessions with their counts by type
+type DockerSessionList struct {
+	Sessions           []docker.SessionInfo       `json:"sessions"`
+	Counts             map[docker.SessionType]int `json:"counts"`
+	Reaped             int64                      `json:"reaped"`
+	IdleTimeoutSeconds int                        `json:"idle_timeout_seconds"`
+}
+
+// ListDockerSessions godoc
+// @Summary List Docker sessions
+// @Description List open exec attachments and log, stats and event streams, with their purpose, owner and last activity
+// @Tags System
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=DockerSessionList} "Open sessions"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 503 {object} utils.APIResponse "Docker client unavailable"
+// @Router /api/system/docker-sessions [get]
+func (dc *DockerSessionController) ListDockerSessions(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	if dc.dockerClient == nil {
+		rb.ServiceUnavailable("Docker client unavailable")
+		return
+	}
+
+	metrics := dc.dockerClient.GetMetrics()
+	list := &DockerSessionList{
+		Sessions: dc.dockerClient.ActiveSessions(),
+		Counts:   metrics.ActiveSessions,
+		Reaped:   metrics.ReapedSessions,
+	}
+	if timeout := dc.dockerClient.SessionIdleTimeout(); timeout > 0 {
+		list.IdleTimeoutSeconds = int(timeout.Seconds())
+	}
+
+	rb.Success(list)
+}
+
+// CloseDockerSession godoc
+// @Summary Close Docker session
+// @Description Force-close an open exec attachment or stream; whoever holds it sees the connection drop
+// @Tags System
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} utils.APIResponse "Session closed"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Session not found"
+// @Router /api/system/docker-sessions/{id} [delete]
+func (dc *DockerSessionController) CloseDockerSession(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	if dc.dockerClient == nil {
+		rb.ServiceUnavailable("Docker client unavailable")
+		return
+	}
+
+	id := c.Param("id")
+	err := dc.dockerClient.CloseSession(id)
+	if errors.Is(err, docker.ErrSessionNotFound) {
+		rb.NotFound("Session not found")
+		return
+	}
+
+	// The session is gone even when closing its connection reported an error
+	if err != nil {
+		dc.logger.WithError(err).WithField("session_id", id).Warn("Error closing Docker session")
+	}
+	dc.logger.WithFields(logrus.Fields{
+		"session_id": id,
+		"actor":      middleware.CurrentActor(c).String(),
+	}).Info("Docker session force-closed")
+
+	rb.SuccessWithMessage(nil, "Session closed successfully")
+}
@@ -0,0 +1,85 @@
+package controller
+
+import (
+	"errors"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// FeatureController handles feature flag endpoints
+type FeatureController struct {
+	featureService *service.FeatureService
+	logger         *logrus.Logger
+}
+
+// NewFeatureController creates a new feature flag controller
+func NewFeatureController(featureService *service.FeatureService, logger *logrus.Logger) *FeatureController {
+	return &FeatureController{
+		featureService: featureService,
+		logger:         logger,
+	}
+}
+
+// GetFeatures godoc
+// @Summary Get feature flags
+// @Description Get the effective feature flags, for the frontend to gate UI elements. features maps each flag to its value; flags describes every flag with its default, stability and where its value comes from.
+// @Tags Features
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=service.FeatureFlags} "Feature flags"
+// @Router /api/features [get]
+func (fc *FeatureController) GetFeatures(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(fc.featureService.List(c.Request.Context()))
+}
+
+// SetFeature godoc
+// @Summary Set feature flag
+// @Description Override a feature flag at runtime; the override is kept in the system settings. A null value clears the override. Flags that require a restart can only be set through FEATURE_FLAGS.
+// @Tags Features
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Feature flag name"
+// @Param request body service.SetFeatureRequest true "Override"
+// @Success 200 {object} utils.APIResponse{data=service.FeatureState} "Feature flag updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Feature flag not found"
+// @Failure 409 {object} utils.APIResponse "Feature flag requires a restart"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/admin/features/{name} [put]
+func (fc *FeatureController) SetFeature(c *gin.Context) {
+	var req service.SetFeatureRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+	name := c.Param("name")
+
+	state, err := fc.featureService.SetFeature(c.Request.Context(), middleware.CurrentActor(c), name, &req)
+	if err != nil {
+		fc.logger.WithError(err).WithField("feature", name).Error("Failed to set feature flag")
+
+		switch {
+		case errors.Is(err, service.ErrFeatureNotFound):
+			rb.NotFound("Feature flag not found")
+		case errors.Is(err, service.ErrFeatureRequiresRestart):
+			rb.ErrorWithDetails(409, "Feature flag cannot be changed at runtime", []utils.ErrorDetail{
+				utils.NewErrorDetail("name", err.Error(), "REQUIRES_RESTART"),
+			})
+		default:
+			rb.InternalServerError("Failed to set feature flag")
+		}
+		return
+	}
+
+	rb.Success(state)
+}
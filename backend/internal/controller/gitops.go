@@ -0,0 +1,140 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// GitOpsController handles GitOps reconciles and their reports
+type GitOpsController struct {
+	gitopsService *service.GitOpsService
+	logger        *logrus.Logger
+}
+
+// NewGitOpsController creates a new GitOps controller
+func NewGitOpsController(gitopsService *service.GitOpsService, logger *logrus.Logger) *GitOpsController {
+	return &GitOpsController{
+		gitopsService: gitopsService,
+		logger:        logger,
+	}
+}
+
+// Reconcile godoc
+// @Summary Reconcile containers with a GitOps source
+// @Description Read the container specs of a YAML source, an http(s) URL or a file path defaulting to GITOPS_SOURCE, and reconcile the managed containers with it: missing containers are created, changed ones updated and left pending recreation, and with prune the containers the source created but no longer lists removed. Containers edited outside the source since the last reconcile, or not created from it, are reported as conflicts and left alone unless force is set. A dry run only reports. The run is stored and returned as a report.
+// @Tags GitOps
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.GitOpsReconcileRequest false "Reconcile options"
+// @Success 201 {object} utils.APIResponse{data=model.GitOpsRun} "Reconcile report"
+// @Failure 400 {object} utils.APIResponse "Invalid request or no source configured"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 409 {object} utils.APIResponse "A reconcile is already running"
+// @Failure 502 {object} utils.APIResponse "The source could not be read"
+// @Router /api/gitops/reconcile [post]
+func (gc *GitOpsController) Reconcile(c *gin.Context) {
+	var req dto.GitOpsReconcileRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+			return
+		}
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	run, err := gc.gitopsService.Reconcile(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		gc.logger.WithError(err).Error("Failed to reconcile gitops source")
+		switch {
+		case errors.Is(err, service.ErrGitOpsReconcileInProgress):
+			rb.Conflict(err.Error())
+		case strings.HasPrefix(err.Error(), "invalid request:"):
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+		case run != nil:
+			// The source could not be read or parsed; the failed run is stored
+			rb.Error(http.StatusBadGateway, err.Error())
+		default:
+			rb.InternalServerError("Failed to reconcile gitops source")
+		}
+		return
+	}
+
+	rb.Created(run)
+}
+
+// ListRuns godoc
+// @Summary List GitOps reconcile reports
+// @Description List the most recent reconcile runs, scheduled and manual, newest first
+// @Tags GitOps
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Number of runs (default 20, max 100)"
+// @Success 200 {object} utils.APIResponse{data=[]model.GitOpsRun} "Runs"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/gitops/runs [get]
+func (gc *GitOpsController) ListRuns(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.Query("limit"))
+
+	rb := utils.NewResponseBuilder(c)
+
+	runs, err := gc.gitopsService.ListRuns(c.Request.Context(), limit)
+	if err != nil {
+		gc.logger.WithError(err).Error("Failed to list gitops runs")
+		rb.InternalServerError("Failed to list gitops runs")
+		return
+	}
+
+	rb.Success(runs)
+}
+
+// GetRun godoc
+// @Summary Get a GitOps reconcile report
+// @Description Get a reconcile run with what it did, or would do in a dry run, to every container
+// @Tags GitOps
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Run ID"
+// @Success 200 {object} utils.APIResponse{data=model.GitOpsRun} "Run"
+// @Failure 400 {object} utils.APIResponse "Invalid run ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Run not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/gitops/runs/{id} [get]
+func (gc *GitOpsController) GetRun(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid run ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	run, err := gc.gitopsService.GetRun(c.Request.Context(), id)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			rb.NotFound("GitOps run not found")
+			return
+		}
+		gc.logger.WithError(err).WithField("run_id", id).Error("Failed to get gitops run")
+		rb.InternalServerError("Failed to get gitops run")
+		return
+	}
+
+	rb.Success(run)
+}
@@ -6,9 +6,7 @@ import (
 	"strings"
 	"time"
 
-	"docker-auto/internal/middleware"
 	"docker-auto/internal/service"
-	"docker-auto/pkg/registry"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -59,7 +57,7 @@ func (ic *ImageController) ListImages(c *gin.Context) {
 		results, err := ic.imageService.SearchImages(c.Request.Context(), search, registryURL)
 		if err != nil {
 			ic.logger.WithError(err).WithField("search", search).Error("Failed to search images")
-			rb.InternalServerError("Failed to search images")
+			respondError(rb, err, "Failed to search images")
 			return
 		}
 
@@ -85,7 +83,7 @@ func (ic *ImageController) ListImages(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param request body service.ImageCheckFilter false "Check criteria"
-// @Success 200 {object} utils.APIResponse{data=[]service.UpdateInfo} "Update information"
+// @Success 200 {object} utils.APIResponse{data=[]dto.UpdateInfo} "Update information"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -108,7 +106,7 @@ func (ic *ImageController) CheckUpdates(c *gin.Context) {
 	updateInfos, err := ic.imageService.CheckImagesByFilter(c.Request.Context(), &filter)
 	if err != nil {
 		ic.logger.WithError(err).Error("Failed to check image updates")
-		rb.InternalServerError("Failed to check for updates")
+		respondError(rb, err, "Failed to check for updates")
 		return
 	}
 
@@ -144,17 +142,92 @@ func (ic *ImageController) GetImageVersions(c *gin.Context) {
 	versions, err := ic.imageService.GetImageVersions(c.Request.Context(), imageName)
 	if err != nil {
 		ic.logger.WithError(err).WithField("image", imageName).Error("Failed to get image versions")
-		if strings.Contains(err.Error(), "not found") {
-			rb.NotFound("Image not found")
-			return
-		}
-		rb.InternalServerError("Failed to retrieve image versions")
+		respondError(rb, err, "Failed to retrieve image versions")
 		return
 	}
 
 	rb.Success(versions)
 }
 
+// GetImageHistory godoc
+// @Summary Get image version history
+// @Description Get every version of the image's repository seen by the update checker, most recent first, with release notes links and the periods managed containers ran each version
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Image name (format: registry/image or image)"
+// @Param limit query int false "Maximum number of versions" default(50)
+// @Success 200 {object} utils.APIResponse{data=service.ImageVersionHistory} "Image version history"
+// @Failure 400 {object} utils.APIResponse "Invalid image name"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/{name}/history [get]
+func (ic *ImageController) GetImageHistory(c *gin.Context) {
+	imageName := c.Param("name")
+	if imageName == "" {
+		utils.BadRequestJSON(c, "Image name is required")
+		return
+	}
+
+	// Decode URL-encoded image name (handle slashes)
+	imageName = strings.ReplaceAll(imageName, "%2F", "/")
+
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	rb := utils.NewResponseBuilder(c)
+
+	history, err := ic.imageService.GetImageHistory(c.Request.Context(), imageName, limit)
+	if err != nil {
+		ic.logger.WithError(err).WithField("image", imageName).Error("Failed to get image history")
+		respondError(rb, err, "Failed to retrieve image history")
+		return
+	}
+
+	rb.Success(history)
+}
+
+// GetImageScan godoc
+// @Summary Get image scan result
+// @Description Get the stored vulnerability scan of an image, by digest or by name and tag
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Image name (format: registry/image or image)"
+// @Param tag query string false "Tag" default(latest)
+// @Param digest query string false "Image digest; takes precedence over tag"
+// @Success 200 {object} utils.APIResponse{data=service.ImageScanResult} "Scan result"
+// @Failure 400 {object} utils.APIResponse "Invalid image name"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "No scan result for image"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/{name}/scan [get]
+func (ic *ImageController) GetImageScan(c *gin.Context) {
+	imageName := c.Param("name")
+	if imageName == "" {
+		utils.BadRequestJSON(c, "Image name is required")
+		return
+	}
+
+	// Decode URL-encoded image name (handle slashes)
+	imageName = strings.ReplaceAll(imageName, "%2F", "/")
+
+	imageRef := imageName + ":" + c.DefaultQuery("tag", "latest")
+	if digest := c.Query("digest"); digest != "" {
+		imageRef = imageName + "@" + digest
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := ic.imageService.GetScanResult(c.Request.Context(), imageRef)
+	if err != nil {
+		ic.logger.WithError(err).WithField("image", imageRef).Error("Failed to get image scan result")
+		respondError(rb, err, "Failed to retrieve image scan result")
+		return
+	}
+
+	rb.Success(result)
+}
+
 // PullImage godoc
 // @Summary Pull specific image version
 // @Description Pull a specific version of an image
@@ -290,7 +363,7 @@ func (ic *ImageController) SearchImages(c *gin.Context) {
 			"query":    query,
 			"registry": registryURL,
 		}).Error("Failed to search images")
-		rb.InternalServerError("Failed to search images")
+		respondError(rb, err, "Failed to search images")
 		return
 	}
 
@@ -308,6 +381,51 @@ func (ic *ImageController) SearchImages(c *gin.Context) {
 	rb.Success(results)
 }
 
+// ListImageTags godoc
+// @Summary Browse image tags
+// @Description List the tags of an image's repository in its registry, versions newest first, with digests and last push times where the registry provides them
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param image query string true "Image repository, e.g. library/nginx or ghcr.io/team/app"
+// @Param registry query string false "Registry URL, defaults to the image's registry"
+// @Param page query int false "Page" default(1)
+// @Param page_size query int false "Page size" default(25)
+// @Success 200 {object} utils.APIResponse{data=registry.TagPage} "Tags"
+// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Repository not found"
+// @Failure 422 {object} utils.APIResponse "Registry does not support listing tags"
+// @Failure 502 {object} utils.APIResponse "Registry request failed"
+// @Router /api/images/tags [get]
+func (ic *ImageController) ListImageTags(c *gin.Context) {
+	image := strings.TrimSpace(c.Query("image"))
+	if image == "" {
+		utils.BadRequestJSON(c, "Image is required")
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	pageSize, _ := strconv.Atoi(c.DefaultQuery("page_size", "25"))
+	if page < 1 {
+		page = 1
+	}
+	if pageSize < 1 || pageSize > 100 {
+		pageSize = 25
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	tags, err := ic.imageService.ListImageTags(c.Request.Context(), image, c.Query("registry"), page, pageSize)
+	if err != nil {
+		ic.logger.WithError(err).WithField("image", image).Error("Failed to list image tags")
+		respondError(rb, err, "Failed to list image tags")
+		return
+	}
+
+	rb.SuccessWithPagination(tags, utils.CreatePagination(page, pageSize, int64(tags.Total)))
+}
+
 // GetImageInfo godoc
 // @Summary Get image information
 // @Description Get detailed information about a specific image
@@ -351,11 +469,7 @@ func (ic *ImageController) GetImageInfo(c *gin.Context) {
 			"tag":      tag,
 			"registry": registryURL,
 		}).Error("Failed to get image info")
-		if strings.Contains(err.Error(), "not found") {
-			rb.NotFound("Image not found")
-			return
-		}
-		rb.InternalServerError("Failed to retrieve image information")
+		respondError(rb, err, "Failed to retrieve image information")
 		return
 	}
 
@@ -414,7 +528,7 @@ func (ic *ImageController) CompareImageVersions(c *gin.Context) {
 	comparison, err := ic.imageService.CompareImageVersions(c.Request.Context(), currentVersion, latestVersion)
 	if err != nil {
 		ic.logger.WithError(err).Error("Failed to compare image versions")
-		rb.InternalServerError("Failed to compare versions")
+		respondError(rb, err, "Failed to compare versions")
 		return
 	}
 
@@ -453,7 +567,7 @@ func (ic *ImageController) RefreshImageCache(c *gin.Context) {
 
 	if err := ic.imageService.RefreshImageCache(c.Request.Context(), imageName); err != nil {
 		ic.logger.WithError(err).WithField("image", imageName).Error("Failed to refresh image cache")
-		rb.InternalServerError("Failed to refresh cache")
+		respondError(rb, err, "Failed to refresh cache")
 		return
 	}
 
@@ -468,7 +582,7 @@ func (ic *ImageController) RefreshImageCache(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param container_id query int true "Container ID"
-// @Success 200 {object} utils.APIResponse{data=service.UpdateInfo} "Update information"
+// @Success 200 {object} utils.APIResponse{data=dto.UpdateInfo} "Update information"
 // @Failure 400 {object} utils.APIResponse "Invalid container ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 404 {object} utils.APIResponse "Container not found"
@@ -502,11 +616,7 @@ func (ic *ImageController) GetImageUpdateInfo(c *gin.Context) {
 	updateInfo, err := ic.imageService.CheckImageUpdate(c.Request.Context(), containerID)
 	if err != nil {
 		ic.logger.WithError(err).WithField("container_id", containerID).Error("Failed to check image update")
-		if strings.Contains(err.Error(), "not found") {
-			rb.NotFound("Container not found")
-			return
-		}
-		rb.InternalServerError("Failed to check for updates")
+		respondError(rb, err, "Failed to check for updates")
 		return
 	}
 
@@ -563,7 +673,7 @@ func (ic *ImageController) ScheduleImageCheck(c *gin.Context) {
 			"container_id": req.ContainerID,
 			"interval":     interval,
 		}).Error("Failed to schedule image check")
-		rb.InternalServerError("Failed to schedule check")
+		respondError(rb, err, "Failed to schedule check")
 		return
 	}
 
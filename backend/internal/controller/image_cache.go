@@ -0,0 +1,80 @@
+package controller
+
+import (
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FlushImageCacheRequest selects what to flush from the image version cache
+type FlushImageCacheRequest struct {
+	// Repository limits the flush to one repository, e.g. nginx or
+	// ghcr.io/org/app; empty flushes every image
+	Repository string `json:"repository"`
+}
+
+// FlushImageCache godoc
+// @Summary Flush the image version cache
+// @Description Invalidate the cached versions of one repository, or of every image, and the update information cached for the containers running them. Checks in flight during the flush do not store what they fetched.
+// @Tags Images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body FlushImageCacheRequest false "Repository to flush"
+// @Success 200 {object} utils.APIResponse{data=service.ImageCacheFlushResult} "Cache flushed"
+// @Failure 400 {object} utils.APIResponse "Invalid repository"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/cache/flush [post]
+func (ic *ImageController) FlushImageCache(c *gin.Context) {
+	var req FlushImageCacheRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+			return
+		}
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := ic.imageService.FlushImageCache(c.Request.Context(), middleware.CurrentActor(c), req.Repository)
+	if err != nil {
+		ic.logger.WithError(err).WithField("repository", req.Repository).Error("Failed to flush image cache")
+		if strings.HasPrefix(err.Error(), "invalid request") {
+			rb.BadRequest(err.Error())
+			return
+		}
+		rb.InternalServerError("Failed to flush image cache")
+		return
+	}
+
+	ic.logger.WithField("repository", result.Repository).Info("Image cache flushed")
+	rb.SuccessWithMessage(result, "Image cache flushed successfully")
+}
+
+// GetImageCacheStats godoc
+// @Summary Get image version cache statistics
+// @Description Get the number of cached versions, stale and invalidated entries, the oldest entry, per-repository counts, and the hit rate and flush counts since startup
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=service.ImageCacheStats} "Cache statistics"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/cache/stats [get]
+func (ic *ImageController) GetImageCacheStats(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	stats, err := ic.imageService.GetImageCacheStats(c.Request.Context())
+	if err != nil {
+		ic.logger.WithError(err).Error("Failed to get image cache stats")
+		rb.InternalServerError("Failed to get image cache stats")
+		return
+	}
+
+	rb.Success(stats)
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ImagePolicyController handles image-level update policy defaults
+type ImagePolicyController struct {
+	policyService *service.ImagePolicyService
+	logger        *logrus.Logger
+}
+
+// NewImagePolicyController creates a new image policy controller
+func NewImagePolicyController(policyService *service.ImagePolicyService, logger *logrus.Logger) *ImagePolicyController {
+	return &ImagePolicyController{
+		policyService: policyService,
+		logger:        logger,
+	}
+}
+
+// ListPolicies godoc
+// @Summary List image policies
+// @Description Get image-level update policy defaults
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param repository query string false "Filter by repository"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]model.ImagePolicy} "Image policies"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/policies [get]
+func (pc *ImagePolicyController) ListPolicies(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	filter := &model.ImagePolicyFilter{
+		Repository: c.Query("repository"),
+		Limit:      limit,
+		Offset:     (page - 1) * limit,
+	}
+
+	policies, total, err := pc.policyService.ListPolicies(c.Request.Context(), filter)
+	if err != nil {
+		pc.logger.WithError(err).Error("Failed to list image policies")
+		rb.InternalServerError("Failed to retrieve image policies")
+		return
+	}
+
+	rb.SuccessWithPagination(policies, utils.CreatePagination(page, limit, total))
+}
+
+// GetPolicy godoc
+// @Summary Get image policy
+// @Description Get an image-level update policy by ID
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Policy ID"
+// @Success 200 {object} utils.APIResponse{data=model.ImagePolicy} "Image policy"
+// @Failure 400 {object} utils.APIResponse "Invalid policy ID"
+// @Failure 404 {object} utils.APIResponse "Image policy not found"
+// @Router /api/images/policies/{id} [get]
+func (pc *ImagePolicyController) GetPolicy(c *gin.Context) {
+	policyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid policy ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	policy, err := pc.policyService.GetPolicy(c.Request.Context(), policyID)
+	if err != nil {
+		pc.respondError(rb, err, "Failed to get image policy")
+		return
+	}
+
+	rb.Success(policy)
+}
+
+// CreatePolicy godoc
+// @Summary Create image policy
+// @Description Define update policy defaults for containers running an image
+// @Tags Images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.ImagePolicyRequest true "Image policy"
+// @Success 201 {object} utils.APIResponse{data=service.ImagePolicyResult} "Image policy created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 409 {object} utils.APIResponse "Image policy already exists"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/policies [post]
+func (pc *ImagePolicyController) CreatePolicy(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var req service.ImagePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := pc.policyService.CreatePolicy(c.Request.Context(), userID, &req)
+	if err != nil {
+		pc.respondError(rb, err, "Failed to create image policy")
+		return
+	}
+
+	rb.Created(result)
+}
+
+// UpdatePolicy godoc
+// @Summary Update image policy
+// @Description Replace an image-level update policy; omitted settings fall back to global defaults
+// @Tags Images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Policy ID"
+// @Param request body service.ImagePolicyRequest true "Image policy"
+// @Success 200 {object} utils.APIResponse{data=service.ImagePolicyResult} "Image policy updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Image policy not found"
+// @Failure 409 {object} utils.APIResponse "Image policy already exists"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/policies/{id} [put]
+func (pc *ImagePolicyController) UpdatePolicy(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	policyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid policy ID")
+		return
+	}
+
+	var req service.ImagePolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := pc.policyService.UpdatePolicy(c.Request.Context(), userID, policyID, &req)
+	if err != nil {
+		pc.respondError(rb, err, "Failed to update image policy")
+		return
+	}
+
+	rb.Success(result)
+}
+
+// DeletePolicy godoc
+// @Summary Delete image policy
+// @Description Delete an image-level update policy; affected containers fall back to global defaults
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Policy ID"
+// @Success 200 {object} utils.APIResponse{data=service.ImagePolicyResult} "Image policy deleted"
+// @Failure 400 {object} utils.APIResponse "Invalid policy ID"
+// @Failure 404 {object} utils.APIResponse "Image policy not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/policies/{id} [delete]
+func (pc *ImagePolicyController) DeletePolicy(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	policyID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid policy ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := pc.policyService.DeletePolicy(c.Request.Context(), userID, policyID)
+	if err != nil {
+		pc.respondError(rb, err, "Failed to delete image policy")
+		return
+	}
+
+	rb.SuccessWithMessage(result, "Image policy deleted successfully")
+}
+
+// respondError maps image policy service errors onto HTTP responses
+func (pc *ImagePolicyController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	pc.logger.WithError(err).Error(message)
+
+	switch {
+	case errors.Is(err, service.ErrImagePolicyNotFound):
+		rb.NotFound("Image policy not found")
+	case errors.Is(err, service.ErrImagePolicyExists):
+		rb.Conflict("Image policy already exists for this repository and tag pattern")
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ImageRetargetController handles moving containers between image tags
+type ImageRetargetController struct {
+	retargetService *service.ImageRetargetService
+	logger          *logrus.Logger
+}
+
+// NewImageRetargetController creates a new image retarget controller
+func NewImageRetargetController(retargetService *service.ImageRetargetService, logger *logrus.Logger) *ImageRetargetController {
+	return &ImageRetargetController{
+		retargetService: retargetService,
+		logger:          logger,
+	}
+}
+
+// RetargetImages godoc
+// @Summary Retarget containers to another image tag
+// @Description Preview moving every matching container from the source image reference to the target. Send the request again with the preview's confirm_token to run it as a tracked bulk operation.
+// @Tags Images
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.RetargetRequest true "Retarget request"
+// @Success 200 {object} utils.APIResponse{data=service.RetargetResponse} "Retarget preview"
+// @Success 201 {object} utils.APIResponse{data=service.RetargetResponse} "Retarget started"
+// @Failure 400 {object} utils.APIResponse "Invalid request or target not found in the registry"
+// @Failure 409 {object} utils.APIResponse "Confirmation token invalid or preview out of date"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/images/retarget [post]
+func (rc *ImageRetargetController) RetargetImages(c *gin.Context) {
+	var req service.RetargetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := rc.retargetService.Retarget(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to retarget images")
+		return
+	}
+
+	if result.Operation != nil {
+		rb.Created(result)
+		return
+	}
+	rb.Success(result)
+}
+
+// GetRetargetOperation godoc
+// @Summary Get retarget progress
+// @Description Get the progress and per-container outcomes of a retarget operation
+// @Tags Images
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Operation ID"
+// @Success 200 {object} utils.APIResponse{data=model.BulkOperation} "Retarget operation"
+// @Failure 400 {object} utils.APIResponse "Invalid operation ID"
+// @Failure 403 {object} utils.APIResponse "Access denied"
+// @Failure 404 {object} utils.APIResponse "Operation not found"
+// @Router /api/images/retarget/{id} [get]
+func (rc *ImageRetargetController) GetRetargetOperation(c *gin.Context) {
+	operationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid operation ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	operation, err := rc.retargetService.GetOperation(c.Request.Context(), middleware.CurrentActor(c), operationID)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to get retarget operation")
+		return
+	}
+
+	rb.Success(operation)
+}
+
+func (rc *ImageRetargetController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	rc.logger.WithError(err).Error(message)
+
+	switch {
+	case errors.Is(err, service.ErrConfirmationInvalid):
+		rb.Conflict(err.Error())
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden("Access denied")
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Retarget operation not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
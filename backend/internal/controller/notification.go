@@ -3,9 +3,12 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"docker-auto/internal/middleware"
 	"docker-auto/internal/service"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -33,19 +36,11 @@ func NewNotificationController(
 	}
 }
 
-// GetNotifications retrieves notifications for the current user
+// GetNotifications retrieves notifications for the current user. ?cursor=
+// (empty for the first page) pages by keyset instead of offset and returns
+// next_cursor in the pagination.
 func (nc *NotificationController) GetNotifications(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
-		return
-	}
+	uid := middleware.CurrentUserID(c)
 
 	// Parse query parameters
 	limit := 20 // default
@@ -64,6 +59,70 @@ func (nc *NotificationController) GetNotifications(c *gin.Context) {
 
 	notificationType := c.Query("type")
 
+	filter := &model.UserNotificationFilter{
+		UserID: &uid,
+		Type:   notificationType,
+	}
+	if ack := c.Query("acknowledged"); ack != "" {
+		acknowledged, err := strconv.ParseBool(ack)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid acknowledged filter")
+			return
+		}
+		filter.Acknowledged = &acknowledged
+	}
+	for _, id := range c.QueryArray("container_id") {
+		containerID, err := strconv.ParseInt(id, 10, 64)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid container_id filter")
+			return
+		}
+		filter.ContainerIDs = append(filter.ContainerIDs, containerID)
+	}
+
+	// Cursor pagination: ?cursor= (empty for the first page) replaces offset
+	if cursorToken, useCursor := c.GetQuery("cursor"); useCursor {
+		cursor, err := model.DecodeCursor(cursorToken)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid cursor")
+			return
+		}
+
+		notifications, nextCursor, err := nc.notificationService.GetNotificationsAfter(
+			c.Request.Context(),
+			filter,
+			cursor,
+			limit,
+		)
+		rb := utils.NewResponseBuilder(c)
+		if err != nil {
+			nc.logger.WithError(err).Error("Failed to get notifications")
+			rb.InternalServerError("Failed to retrieve notifications")
+			return
+		}
+
+		rb.SuccessWithPagination(notifications, utils.CreateCursorPagination(limit, cursorToken, nextCursor))
+		return
+	}
+
+	// Acknowledgment and container filters go through the generic listing
+	if filter.Acknowledged != nil || len(filter.ContainerIDs) > 0 {
+		filter.Limit = limit
+		filter.Offset = offset
+		filter.OrderBy = "created_at DESC"
+
+		notifications, total, err := nc.notificationService.ListNotifications(c.Request.Context(), filter)
+		rb := utils.NewResponseBuilder(c)
+		if err != nil {
+			nc.logger.WithError(err).Error("Failed to get notifications")
+			rb.InternalServerError("Failed to retrieve notifications")
+			return
+		}
+
+		rb.SuccessWithPagination(notifications, utils.CreatePagination(offset/limit+1, limit, total))
+		return
+	}
+
 	// Get notifications
 	var notifications interface{}
 	var err error
@@ -87,26 +146,16 @@ func (nc *NotificationController) GetNotifications(c *gin.Context) {
 
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to get notifications")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve notifications", err)
+		utils.InternalServerErrorJSON(c, "Failed to retrieve notifications")
 		return
 	}
 
-	utils.SuccessResponse(c, notifications, "Notifications retrieved successfully")
+	utils.SuccessJSONWithMessage(c, notifications, "Notifications retrieved successfully")
 }
 
 // GetUnreadNotifications retrieves unread notifications for the current user
 func (nc *NotificationController) GetUnreadNotifications(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
-		return
-	}
+	uid := middleware.CurrentUserID(c)
 
 	// Parse limit
 	limit := 20 // default
@@ -120,7 +169,7 @@ func (nc *NotificationController) GetUnreadNotifications(c *gin.Context) {
 	notifications, err := nc.notificationService.GetNotifications(c.Request.Context(), uid, limit, 0)
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to get unread notifications")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to retrieve unread notifications", err)
+		utils.InternalServerErrorJSON(c, "Failed to retrieve unread notifications")
 		return
 	}
 
@@ -132,76 +181,57 @@ func (nc *NotificationController) GetUnreadNotifications(c *gin.Context) {
 		unreadNotifications = append(unreadNotifications, notif)
 	}
 
-	utils.SuccessResponse(c, unreadNotifications, "Unread notifications retrieved successfully")
+	utils.SuccessJSONWithMessage(c, unreadNotifications, "Unread notifications retrieved successfully")
 }
 
 // GetNotificationCount retrieves notification count for the current user
 func (nc *NotificationController) GetNotificationCount(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
-		return
-	}
+	uid := middleware.CurrentUserID(c)
 
 	unreadCount, err := nc.notificationService.GetUnreadCount(c.Request.Context(), uid)
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to get notification count")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get notification count", err)
+		utils.InternalServerErrorJSON(c, "Failed to get notification count")
 		return
 	}
 
-	utils.SuccessResponse(c, gin.H{
+	utils.SuccessJSONWithMessage(c, gin.H{
 		"unread_count": unreadCount,
 	}, "Notification count retrieved successfully")
 }
 
 // GetNotificationStats retrieves notification statistics for the current user
 func (nc *NotificationController) GetNotificationStats(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
-		return
-	}
+	uid := middleware.CurrentUserID(c)
 
 	stats, err := nc.notificationService.GetNotificationStats(c.Request.Context(), uid)
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to get notification stats")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to get notification statistics", err)
+		utils.InternalServerErrorJSON(c, "Failed to get notification statistics")
 		return
 	}
 
-	utils.SuccessResponse(c, stats, "Notification statistics retrieved successfully")
+	utils.SuccessJSONWithMessage(c, stats, "Notification statistics retrieved successfully")
+}
+
+// GetNotificationSchemas returns the versioned payload schemas carried in
+// notification data, for webhook and API integrators
+func (nc *NotificationController) GetNotificationSchemas(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(nc.notificationService.GetPayloadSchemas())
 }
 
 // GetNotification retrieves a specific notification
 func (nc *NotificationController) GetNotification(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
 	notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID", err)
+		utils.BadRequestJSON(c, "Invalid notification ID")
 		return
 	}
 
 	// Note: This is a simplified implementation
 	// In a real implementation, you'd verify the notification belongs to the user
-	utils.SuccessResponse(c, gin.H{
+	utils.SuccessJSONWithMessage(c, gin.H{
 		"id":      notificationID,
 		"message": "Notification details would be here",
 	}, "Notification retrieved successfully")
@@ -209,24 +239,14 @@ func (nc *NotificationController) GetNotification(c *gin.Context) {
 
 // MarkAsRead marks notifications as read
 func (nc *NotificationController) MarkAsRead(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
-		return
-	}
+	uid := middleware.CurrentUserID(c)
 
 	var request struct {
 		NotificationIDs []int64 `json:"notification_ids" binding:"required"`
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		utils.BadRequestJSON(c, "Invalid request body: "+err.Error())
 		return
 	}
 
@@ -240,95 +260,102 @@ func (nc *NotificationController) MarkAsRead(c *gin.Context) {
 	}
 
 	if len(errors) > 0 {
-		utils.ErrorResponse(c, http.StatusPartialContent, "Some notifications could not be marked as read", errors)
+		utils.NewResponseBuilder(c).ErrorWithData(http.StatusPartialContent, "Some notifications could not be marked as read", errors, nil)
 		return
 	}
 
-	utils.SuccessResponse(c, nil, "Notifications marked as read successfully")
+	utils.SuccessJSONWithMessage(c, nil, "Notifications marked as read successfully")
 }
 
 // MarkAllAsRead marks all notifications as read for the current user
 func (nc *NotificationController) MarkAllAsRead(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
-		return
-	}
+	uid := middleware.CurrentUserID(c)
 
 	err := nc.notificationService.MarkAllAsRead(c.Request.Context(), uid)
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to mark all notifications as read")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to mark all notifications as read", err)
+		utils.InternalServerErrorJSON(c, "Failed to mark all notifications as read")
 		return
 	}
 
-	utils.SuccessResponse(c, nil, "All notifications marked as read successfully")
+	utils.SuccessJSONWithMessage(c, nil, "All notifications marked as read successfully")
 }
 
-// MarkNotificationAsRead marks a specific notification as read
-func (nc *NotificationController) MarkNotificationAsRead(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
+// AcknowledgeNotifications acknowledges the current user's notifications matching a filter
+// @Summary Acknowledge notifications
+// @Description Acknowledge notifications by type, containers and time range, snoozing identical notifications
+// @Tags notifications
+// @Accept json
+// @Produce json
+// @Param request body service.AcknowledgeNotificationsRequest true "Acknowledgment filter"
+// @Success 200 {object} utils.APIResponse{data=service.AcknowledgeNotificationsResult}
+// @Failure 400 {object} utils.APIResponse
+// @Failure 401 {object} utils.APIResponse
+// @Failure 500 {object} utils.APIResponse
+// @Router /notifications/acknowledge [post]
+func (nc *NotificationController) AcknowledgeNotifications(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	uid := middleware.CurrentUserID(c)
+
+	var req service.AcknowledgeNotificationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		rb.BadRequest("Invalid request body: " + err.Error())
 		return
 	}
 
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
+	result, err := nc.notificationService.AcknowledgeNotifications(c.Request.Context(), uid, &req)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid request:") {
+			rb.BadRequest(err.Error())
+			return
+		}
+		nc.logger.WithError(err).Error("Failed to acknowledge notifications")
+		rb.InternalServerError("Failed to acknowledge notifications")
 		return
 	}
 
+	rb.Success(result)
+}
+
+// MarkNotificationAsRead marks a specific notification as read
+func (nc *NotificationController) MarkNotificationAsRead(c *gin.Context) {
+	uid := middleware.CurrentUserID(c)
+
 	notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID", err)
+		utils.BadRequestJSON(c, "Invalid notification ID")
 		return
 	}
 
 	err = nc.notificationService.MarkAsRead(c.Request.Context(), notificationID, uid)
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to mark notification as read")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to mark notification as read", err)
+		utils.InternalServerErrorJSON(c, "Failed to mark notification as read")
 		return
 	}
 
-	utils.SuccessResponse(c, nil, "Notification marked as read successfully")
+	utils.SuccessJSONWithMessage(c, nil, "Notification marked as read successfully")
 }
 
 // DeleteNotification deletes a specific notification
 func (nc *NotificationController) DeleteNotification(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.ErrorResponse(c, http.StatusUnauthorized, "User not authenticated", nil)
-		return
-	}
-
-	uid, ok := userID.(int64)
-	if !ok {
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Invalid user ID", nil)
-		return
-	}
+	uid := middleware.CurrentUserID(c)
 
 	notificationID, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid notification ID", err)
+		utils.BadRequestJSON(c, "Invalid notification ID")
 		return
 	}
 
 	err = nc.notificationService.DeleteNotification(c.Request.Context(), notificationID, uid)
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to delete notification")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to delete notification", err)
+		utils.InternalServerErrorJSON(c, "Failed to delete notification")
 		return
 	}
 
-	utils.SuccessResponse(c, nil, "Notification deleted successfully")
+	utils.SuccessJSONWithMessage(c, nil, "Notification deleted successfully")
 }
 
 // BroadcastNotification creates a broadcast notification (admin only)
@@ -341,7 +368,7 @@ func (nc *NotificationController) BroadcastNotification(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		utils.BadRequestJSON(c, "Invalid request body: "+err.Error())
 		return
 	}
 
@@ -355,11 +382,11 @@ func (nc *NotificationController) BroadcastNotification(c *gin.Context) {
 
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to broadcast notification")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to broadcast notification", err)
+		utils.InternalServerErrorJSON(c, "Failed to broadcast notification")
 		return
 	}
 
-	utils.SuccessResponse(c, nil, "Notification broadcast successfully")
+	utils.SuccessJSONWithMessage(c, nil, "Notification broadcast successfully")
 }
 
 // GetNotificationTemplates retrieves notification templates (admin only)
@@ -381,7 +408,7 @@ func (nc *NotificationController) GetNotificationTemplates(c *gin.Context) {
 		},
 	}
 
-	utils.SuccessResponse(c, templates, "Notification templates retrieved successfully")
+	utils.SuccessJSONWithMessage(c, templates, "Notification templates retrieved successfully")
 }
 
 // CreateNotificationTemplate creates a new notification template (admin only)
@@ -394,7 +421,7 @@ func (nc *NotificationController) CreateNotificationTemplate(c *gin.Context) {
 	}
 
 	if err := c.ShouldBindJSON(&request); err != nil {
-		utils.ErrorResponse(c, http.StatusBadRequest, "Invalid request body", err)
+		utils.BadRequestJSON(c, "Invalid request body: "+err.Error())
 		return
 	}
 
@@ -407,11 +434,11 @@ func (nc *NotificationController) CreateNotificationTemplate(c *gin.Context) {
 
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to create notification template")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to create notification template", err)
+		utils.InternalServerErrorJSON(c, "Failed to create notification template")
 		return
 	}
 
-	utils.SuccessResponse(c, nil, "Notification template created successfully")
+	utils.SuccessJSONWithMessage(c, nil, "Notification template created successfully")
 }
 
 // CleanupOldNotifications removes old notifications (admin only)
@@ -426,11 +453,11 @@ func (nc *NotificationController) CleanupOldNotifications(c *gin.Context) {
 	err := nc.notificationService.CleanupOldNotifications(c.Request.Context(), retentionDays)
 	if err != nil {
 		nc.logger.WithError(err).Error("Failed to cleanup old notifications")
-		utils.ErrorResponse(c, http.StatusInternalServerError, "Failed to cleanup old notifications", err)
+		utils.InternalServerErrorJSON(c, "Failed to cleanup old notifications")
 		return
 	}
 
-	utils.SuccessResponse(c, gin.H{
+	utils.SuccessJSONWithMessage(c, gin.H{
 		"retention_days": retentionDays,
 		"cleanup_date":   time.Now(),
 	}, "Old notifications cleaned up successfully")
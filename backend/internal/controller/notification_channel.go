@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// NotificationChannelController handles the webhook, email and Slack channels
+// notifications are delivered through
+type NotificationChannelController struct {
+	channelService *service.NotificationChannelService
+	logger         *logrus.Logger
+}
+
+// NewNotificationChannelController creates a new notification channel controller
+func NewNotificationChannelController(channelService *service.NotificationChannelService, logger *logrus.Logger) *NotificationChannelController {
+	return &NotificationChannelController{
+		channelService: channelService,
+		logger:         logger,
+	}
+}
+
+// ListChannels godoc
+// @Summary List notification channels
+// @Description Get the signed in user's notification channels, and the global ones for admins, with secrets redacted and the outcome of the latest delivery
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]model.NotificationChannel} "Notification channels"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/notifications/channels [get]
+func (nc *NotificationChannelController) ListChannels(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	channels, err := nc.channelService.ListChannels(c.Request.Context(), channelOwner(c))
+	if err != nil {
+		nc.respondError(rb, err, "Failed to list notification channels")
+		return
+	}
+
+	rb.Success(channels)
+}
+
+// GetChannel godoc
+// @Summary Get notification channel
+// @Description Get a notification channel with secrets redacted
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification channel ID"
+// @Success 200 {object} utils.APIResponse{data=model.NotificationChannel} "Notification channel"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Notification channel not found"
+// @Router /api/notifications/channels/{id} [get]
+func (nc *NotificationChannelController) GetChannel(c *gin.Context) {
+	id, ok := notificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	channel, err := nc.channelService.GetChannel(c.Request.Context(), channelOwner(c), id)
+	if err != nil {
+		nc.respondError(rb, err, "Failed to get notification channel")
+		return
+	}
+
+	rb.Success(channel)
+}
+
+// CreateChannel godoc
+// @Summary Create notification channel
+// @Description Add a webhook, email or Slack channel for the signed in user, or a global one (admins only) that receives the notifications raised by the system
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateNotificationChannelRequest true "Notification channel"
+// @Success 201 {object} utils.APIResponse{data=model.NotificationChannel} "Notification channel created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/notifications/channels [post]
+func (nc *NotificationChannelController) CreateChannel(c *gin.Context) {
+	var req dto.CreateNotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	channel, err := nc.channelService.CreateChannel(c.Request.Context(), middleware.CurrentActor(c), channelOwner(c), &req)
+	if err != nil {
+		nc.respondError(rb, err, "Failed to create notification channel")
+		return
+	}
+
+	rb.Created(channel)
+}
+
+// UpdateChannel godoc
+// @Summary Update notification channel
+// @Description Change a notification channel; omitted fields and secrets left empty or sent back redacted keep their values
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification channel ID"
+// @Param request body dto.UpdateNotificationChannelRequest true "Notification channel changes"
+// @Success 200 {object} utils.APIResponse{data=model.NotificationChannel} "Notification channel updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Notification channel not found"
+// @Router /api/notifications/channels/{id} [put]
+func (nc *NotificationChannelController) UpdateChannel(c *gin.Context) {
+	id, ok := notificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateNotificationChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	channel, err := nc.channelService.UpdateChannel(c.Request.Context(), middleware.CurrentActor(c), channelOwner(c), id, &req)
+	if err != nil {
+		nc.respondError(rb, err, "Failed to update notification channel")
+		return
+	}
+
+	rb.Success(channel)
+}
+
+// DeleteChannel godoc
+// @Summary Delete notification channel
+// @Description Delete a notification channel
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification channel ID"
+// @Success 200 {object} utils.APIResponse "Notification channel deleted"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Notification channel not found"
+// @Router /api/notifications/channels/{id} [delete]
+func (nc *NotificationChannelController) DeleteChannel(c *gin.Context) {
+	id, ok := notificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := nc.channelService.DeleteChannel(c.Request.Context(), middleware.CurrentActor(c), channelOwner(c), id); err != nil {
+		nc.respondError(rb, err, "Failed to delete notification channel")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Notification channel deleted successfully")
+}
+
+// TestChannel godoc
+// @Summary Test notification channel
+// @Description Send a test notification through a channel once, without retries. The outcome is recorded on the channel; a failed delivery is reported in the result.
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Notification channel ID"
+// @Success 200 {object} utils.APIResponse{data=dto.NotificationChannelTestResult} "Test result"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Notification channel not found"
+// @Router /api/notifications/channels/{id}/test [post]
+func (nc *NotificationChannelController) TestChannel(c *gin.Context) {
+	id, ok := notificationChannelID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := nc.channelService.TestChannel(c.Request.Context(), channelOwner(c), id)
+	if err != nil {
+		nc.respondError(rb, err, "Failed to test notification channel")
+		return
+	}
+
+	rb.Success(result)
+}
+
+// channelOwner returns the signed in user as the manager of notification
+// channels
+func channelOwner(c *gin.Context) service.ChannelOwner {
+	owner := service.ChannelOwner{UserID: middleware.CurrentUserID(c)}
+	owner.Admin = middleware.HasPermission(c, middleware.PermissionAdmin)
+	return owner
+}
+
+func notificationChannelID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid notification channel ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError maps notification channel service errors onto HTTP responses
+func (nc *NotificationChannelController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	nc.logger.WithError(err).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Notification channel not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,62 @@
+package controller
+
+import (
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetPreferences godoc
+// @Summary Get notification preferences
+// @Description Get the signed in user's delivery preferences: the update digest interval, when the last digest was sent and how many notifications wait for the next one
+// @Tags Notifications
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=dto.NotificationPreferences} "Notification preferences"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/notifications/preferences [get]
+func (nc *NotificationChannelController) GetPreferences(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	preferences, err := nc.channelService.GetPreferences(c.Request.Context(), middleware.CurrentUserID(c))
+	if err != nil {
+		nc.respondError(rb, err, "Failed to get notification preferences")
+		return
+	}
+
+	rb.Success(preferences)
+}
+
+// UpdatePreferences godoc
+// @Summary Update notification preferences
+// @Description Choose whether update available and update completed notifications for the signed in user's channels are sent at once (off) or as one hourly or daily digest. Failed updates and health alerts are always sent at once.
+// @Tags Notifications
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateNotificationPreferencesRequest true "Notification preferences"
+// @Success 200 {object} utils.APIResponse{data=dto.NotificationPreferences} "Notification preferences updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/notifications/preferences [put]
+func (nc *NotificationChannelController) UpdatePreferences(c *gin.Context) {
+	var req dto.UpdateNotificationPreferencesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	preferences, err := nc.channelService.UpdatePreferences(c.Request.Context(), middleware.CurrentUserID(c), &req)
+	if err != nil {
+		nc.respondError(rb, err, "Failed to update notification preferences")
+		return
+	}
+
+	rb.Success(preferences)
+}
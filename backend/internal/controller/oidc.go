@@ -0,0 +1,122 @@
+package controller
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// The state cookie of an OIDC login, only sent to the OIDC endpoints
+const (
+	oidcStateCookie     = "oidc_login_state"
+	oidcStateCookiePath = "/api/auth/oidc"
+)
+
+// OIDCController handles signing in through an OpenID Connect provider
+type OIDCController struct {
+	oidcService *service.OIDCService
+	logger      *logrus.Logger
+}
+
+// NewOIDCController creates a new OIDC login controller
+func NewOIDCController(oidcService *service.OIDCService, logger *logrus.Logger) *OIDCController {
+	return &OIDCController{
+		oidcService: oidcService,
+		logger:      logger,
+	}
+}
+
+// Login godoc
+// @Summary Start OIDC login
+// @Description Redirect to the identity provider to sign in, with state, nonce and PKCE. The login state is kept in a signed cookie for the callback.
+// @Tags Authentication
+// @Success 302 "Redirect to the identity provider"
+// @Failure 404 {object} utils.APIResponse "OIDC feature disabled"
+// @Failure 503 {object} utils.APIResponse "OIDC login not configured or provider unavailable"
+// @Router /api/auth/oidc/login [get]
+func (oc *OIDCController) Login(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	authURL, sealedState, err := oc.oidcService.LoginURL(c.Request.Context())
+	if err != nil {
+		rb.FromError(err, "Failed to start OIDC login")
+		return
+	}
+
+	oc.setStateCookie(c, sealedState, int(service.OIDCStateTTL.Seconds()))
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// Callback godoc
+// @Summary Complete OIDC login
+// @Description Redirect target of the identity provider. Validates the ID token, provisions or links the user by email, maps provider groups to a role and issues the login token pair, as JSON or, when a post-login redirect is configured, in the fragment of a redirect to the frontend.
+// @Tags Authentication
+// @Produce json
+// @Param code query string true "Authorization code"
+// @Param state query string true "Login state"
+// @Success 200 {object} utils.APIResponse{data=service.LoginResponse} "Login successful"
+// @Success 302 "Redirect to the frontend with the tokens"
+// @Failure 401 {object} utils.APIResponse "Invalid login state or ID token"
+// @Failure 403 {object} utils.APIResponse "Email domain not allowed or user inactive"
+// @Failure 503 {object} utils.APIResponse "OIDC login not configured or provider unavailable"
+// @Router /api/auth/oidc/callback [get]
+func (oc *OIDCController) Callback(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	// The state is single use
+	sealedState, _ := c.Cookie(oidcStateCookie)
+	oc.setStateCookie(c, "", -1)
+
+	if providerError := c.Query("error"); providerError != "" {
+		oc.logger.WithFields(logrus.Fields{
+			"error":       providerError,
+			"description": c.Query("error_description"),
+			"client_ip":   c.ClientIP(),
+		}).Warn("Identity provider refused OIDC login")
+		rb.Unauthorized("Sign-in at the identity provider failed: " + providerError)
+		return
+	}
+
+	response, err := oc.oidcService.Callback(c.Request.Context(), &service.OIDCCallbackRequest{
+		Code:        c.Query("code"),
+		State:       c.Query("state"),
+		SealedState: sealedState,
+		IPAddress:   c.ClientIP(),
+		UserAgent:   c.Request.UserAgent(),
+	})
+	if err != nil {
+		oc.logger.WithError(err).WithField("client_ip", c.ClientIP()).Warn("OIDC login failed")
+		rb.FromError(err, "OIDC login failed")
+		return
+	}
+
+	oc.logger.WithFields(logrus.Fields{
+		"user_id":   response.User.ID,
+		"username":  response.User.Username,
+		"client_ip": c.ClientIP(),
+	}).Info("User logged in through OIDC")
+
+	// The fragment is not sent to servers, keeping the tokens out of logs
+	if redirect := oc.oidcService.PostLoginRedirect(); redirect != "" {
+		fragment := url.Values{
+			"access_token":  {response.AccessToken},
+			"refresh_token": {response.RefreshToken},
+			"expires_in":    {strconv.FormatInt(response.ExpiresIn, 10)},
+		}
+		c.Redirect(http.StatusFound, redirect+"#"+fragment.Encode())
+		return
+	}
+
+	rb.SuccessWithMessage(response, "Login successful")
+}
+
+func (oc *OIDCController) setStateCookie(c *gin.Context, value string, maxAge int) {
+	c.SetSameSite(http.SameSiteLaxMode)
+	c.SetCookie(oidcStateCookie, value, maxAge, oidcStateCookiePath, "", oc.oidcService.SecureCookies(), true)
+}
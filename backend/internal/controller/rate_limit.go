@@ -0,0 +1,222 @@
+package controller
+
+import (
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RateLimitController handles the rate limit administration endpoints
+type RateLimitController struct {
+	rateLimitService *service.RateLimitService
+	logger           *logrus.Logger
+}
+
+// NewRateLimitController creates a new rate limit controller
+func NewRateLimitController(rateLimitService *service.RateLimitService, logger *logrus.Logger) *RateLimitController {
+	return &RateLimitController{
+		rateLimitService: rateLimitService,
+		logger:           logger,
+	}
+}
+
+// GetRateLimits godoc
+// @Summary Get rate limits
+// @Description Get the limits the API rate limiter applies now: global, user, IP and subnet limits (lowered while dynamic limiting reacts to load), the per-endpoint limits and the IP and user access lists
+// @Tags Rate Limits
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=dto.RateLimitSettings} "Rate limits"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/admin/ratelimits [get]
+func (rc *RateLimitController) GetRateLimits(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(rc.rateLimitService.GetSettings())
+}
+
+// SetEndpointRateLimit godoc
+// @Summary Set endpoint rate limit
+// @Description Add or replace the limit on an API path. The change applies at once and is kept across restarts. The sign-in endpoints must allow at least 3 requests per hour.
+// @Tags Rate Limits
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.UpdateEndpointRateLimitRequest true "Endpoint limit"
+// @Success 200 {object} utils.APIResponse{data=dto.RateLimitSettings} "Rate limits"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/admin/ratelimits/endpoints [put]
+func (rc *RateLimitController) SetEndpointRateLimit(c *gin.Context) {
+	var req dto.UpdateEndpointRateLimitRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	settings, err := rc.rateLimitService.SetEndpointLimit(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		rc.fail(c, err, "Failed to set endpoint rate limit")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(settings)
+}
+
+// RemoveEndpointRateLimit godoc
+// @Summary Remove endpoint rate limit
+// @Description Remove the limit on an API path, leaving it to the global, user and IP limits. The change applies at once and is kept across restarts.
+// @Tags Rate Limits
+// @Produce json
+// @Security BearerAuth
+// @Param endpoint query string true "API path, e.g. /api/containers"
+// @Success 200 {object} utils.APIResponse{data=dto.RateLimitSettings} "Rate limits"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Endpoint has no limit"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/admin/ratelimits/endpoints [delete]
+func (rc *RateLimitController) RemoveEndpointRateLimit(c *gin.Context) {
+	settings, err := rc.rateLimitService.RemoveEndpointLimit(c.Request.Context(), middleware.CurrentActor(c), c.Query("endpoint"))
+	if err != nil {
+		rc.fail(c, err, "Failed to remove endpoint rate limit")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(settings)
+}
+
+// AddRateLimitListEntry godoc
+// @Summary Add to a rate limit access list
+// @Description Put an IP or user on the whitelist, which bypasses every limit, or the blacklist, which rejects every request. You cannot blacklist your own IP or user, nor put an entry on both lists.
+// @Tags Rate Limits
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param list path string true "whitelist or blacklist"
+// @Param request body dto.RateLimitSubject true "IP or user"
+// @Success 200 {object} utils.APIResponse{data=dto.RateLimitSettings} "Rate limits"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "List not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/admin/ratelimits/lists/{list} [post]
+func (rc *RateLimitController) AddRateLimitListEntry(c *gin.Context) {
+	var subject dto.RateLimitSubject
+	if err := c.ShouldBindJSON(&subject); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	settings, err := rc.rateLimitService.AddListEntry(c.Request.Context(), middleware.CurrentActor(c), c.ClientIP(), c.Param("list"), &subject)
+	if err != nil {
+		rc.fail(c, err, "Failed to update rate limit list")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(settings)
+}
+
+// RemoveRateLimitListEntry godoc
+// @Summary Remove from a rate limit access list
+// @Description Take an IP or user off the whitelist or blacklist
+// @Tags Rate Limits
+// @Produce json
+// @Security BearerAuth
+// @Param list path string true "whitelist or blacklist"
+// @Param ip query string false "IP address"
+// @Param user_id query int false "User ID"
+// @Success 200 {object} utils.APIResponse{data=dto.RateLimitSettings} "Rate limits"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "List or entry not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/admin/ratelimits/lists/{list} [delete]
+func (rc *RateLimitController) RemoveRateLimitListEntry(c *gin.Context) {
+	var subject dto.RateLimitSubject
+	if err := c.ShouldBindQuery(&subject); err != nil {
+		utils.BadRequestJSON(c, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	settings, err := rc.rateLimitService.RemoveListEntry(c.Request.Context(), middleware.CurrentActor(c), c.Param("list"), &subject)
+	if err != nil {
+		rc.fail(c, err, "Failed to update rate limit list")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(settings)
+}
+
+// ListRateLimitBans godoc
+// @Summary List rate limit bans
+// @Description List the IPs and users banned for repeated rate limit violations, the longest bans first. Bans are kept in memory only.
+// @Tags Rate Limits
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]dto.RateLimitBan} "Bans"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/admin/ratelimits/bans [get]
+func (rc *RateLimitController) ListRateLimitBans(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+	rb.Success(rc.rateLimitService.ListBans())
+}
+
+// LiftRateLimitBan godoc
+// @Summary Lift a rate limit ban
+// @Description Lift the ban of an IP or user and forget their violations
+// @Tags Rate Limits
+// @Produce json
+// @Security BearerAuth
+// @Param ip query string false "IP address"
+// @Param user_id query int false "User ID"
+// @Success 200 {object} utils.APIResponse "Ban lifted"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Not banned"
+// @Router /api/admin/ratelimits/bans [delete]
+func (rc *RateLimitController) LiftRateLimitBan(c *gin.Context) {
+	var subject dto.RateLimitSubject
+	if err := c.ShouldBindQuery(&subject); err != nil {
+		utils.BadRequestJSON(c, "Invalid query parameters: "+err.Error())
+		return
+	}
+
+	if err := rc.rateLimitService.Unban(c.Request.Context(), middleware.CurrentActor(c), &subject); err != nil {
+		rc.fail(c, err, "Failed to lift rate limit ban")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+	rb.SuccessWithMessage(nil, "Ban lifted")
+}
+
+// fail writes the error response of a rate limit change
+func (rc *RateLimitController) fail(c *gin.Context, err error, message string) {
+	rb := utils.NewResponseBuilder(c)
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request:"):
+		rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound(err.Error())
+	default:
+		rc.logger.WithError(err).Error(message)
+		rb.InternalServerError(message)
+	}
+}
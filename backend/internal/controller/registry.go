@@ -1,14 +1,13 @@
 package controller
 
 import (
-	"net/http"
 	"strconv"
 	"strings"
-	"time"
 
+	"docker-auto/internal/dto"
 	"docker-auto/internal/middleware"
 	"docker-auto/internal/service"
-	"docker-auto/pkg/registry"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -17,226 +16,111 @@ import (
 
 // RegistryController handles registry-related HTTP requests
 type RegistryController struct {
-	imageService *service.ImageService
-	logger       *logrus.Logger
+	credentialService *service.RegistryCredentialService
+	imageService      *service.ImageService
+	logger            *logrus.Logger
 }
 
 // NewRegistryController creates a new registry controller
-func NewRegistryController(imageService *service.ImageService, logger *logrus.Logger) *RegistryController {
+func NewRegistryController(credentialService *service.RegistryCredentialService, imageService *service.ImageService, logger *logrus.Logger) *RegistryController {
 	return &RegistryController{
-		imageService: imageService,
-		logger:       logger,
+		credentialService: credentialService,
+		imageService:      imageService,
+		logger:            logger,
 	}
 }
 
-// RegistryRequest represents a registry configuration request
-type RegistryRequest struct {
-	Name        string               `json:"name" binding:"required"`
-	URL         string               `json:"url" binding:"required"`
-	Type        string               `json:"type" binding:"required"` // dockerhub, harbor, ecr, etc.
-	Description string               `json:"description,omitempty"`
-	AuthConfig  *registry.AuthConfig `json:"auth_config,omitempty"`
-	IsDefault   bool                 `json:"is_default,omitempty"`
-	Enabled     bool                 `json:"enabled"`
-	Settings    map[string]interface{} `json:"settings,omitempty"`
-}
-
-// RegistryResponse represents a registry configuration response
-type RegistryResponse struct {
-	ID          int64                  `json:"id"`
-	Name        string                 `json:"name"`
-	URL         string                 `json:"url"`
-	Type        string                 `json:"type"`
-	Description string                 `json:"description,omitempty"`
-	IsDefault   bool                   `json:"is_default"`
-	Enabled     bool                   `json:"enabled"`
-	Status      string                 `json:"status"` // connected, error, unknown
-	LastChecked *string                `json:"last_checked,omitempty"`
-	Error       string                 `json:"error,omitempty"`
-	Settings    map[string]interface{} `json:"settings,omitempty"`
-	CreatedAt   string                 `json:"created_at"`
-	UpdatedAt   string                 `json:"updated_at"`
-}
-
-// RegistryTestResult represents registry connection test result
-type RegistryTestResult struct {
-	Success     bool                   `json:"success"`
-	Message     string                 `json:"message"`
-	Duration    string                 `json:"duration"`
-	Error       string                 `json:"error,omitempty"`
-	Capabilities []string              `json:"capabilities,omitempty"`
-	Info        *registry.RegistryInfo `json:"info,omitempty"`
-}
-
 // ListRegistries godoc
-// @Summary List configured registries
-// @Description Get list of all configured container registries
+// @Summary List registry credentials
+// @Description List the stored private registry credentials. Passwords and tokens are never returned.
 // @Tags Registries
 // @Produce json
 // @Security BearerAuth
-// @Param enabled query boolean false "Filter by enabled status"
-// @Param type query string false "Filter by registry type"
-// @Success 200 {object} utils.APIResponse{data=[]RegistryResponse} "Registries list"
+// @Param active query boolean false "Filter by active status"
+// @Param auth_type query string false "Filter by auth type (basic, token)"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]model.RegistryCredentials} "Registry credentials"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries [get]
 func (rc *RegistryController) ListRegistries(c *gin.Context) {
-	enabledStr := c.Query("enabled")
-	registryType := c.Query("type")
-
-	rb := utils.NewResponseBuilder(c)
-
-	// For now, return a mock list of registries
-	// In a real implementation, you would query the registry repository
-	registries := []RegistryResponse{
-		{
-			ID:          1,
-			Name:        "Docker Hub",
-			URL:         "https://registry-1.docker.io",
-			Type:        "dockerhub",
-			Description: "Official Docker registry",
-			IsDefault:   true,
-			Enabled:     true,
-			Status:      "connected",
-			CreatedAt:   "2023-01-01T00:00:00Z",
-			UpdatedAt:   "2023-01-01T00:00:00Z",
-		},
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
 	}
 
-	// Apply filters
-	filteredRegistries := []RegistryResponse{}
-	for _, reg := range registries {
-		// Filter by enabled status
-		if enabledStr != "" {
-			enabled, _ := strconv.ParseBool(enabledStr)
-			if reg.Enabled != enabled {
-				continue
-			}
+	filter := &model.RegistryCredentialsFilter{
+		AuthType: model.RegistryAuthType(c.Query("auth_type")),
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}
+	if activeStr := c.Query("active"); activeStr != "" {
+		active, err := strconv.ParseBool(activeStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid active filter")
+			return
 		}
+		filter.IsActive = &active
+	}
 
-		// Filter by type
-		if registryType != "" && reg.Type != registryType {
-			continue
-		}
+	rb := utils.NewResponseBuilder(c)
 
-		filteredRegistries = append(filteredRegistries, reg)
+	credentials, total, err := rc.credentialService.ListCredentials(c.Request.Context(), filter)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to retrieve registries")
+		return
 	}
 
-	rc.logger.WithFields(logrus.Fields{
-		"enabled": enabledStr,
-		"type":    registryType,
-		"count":   len(filteredRegistries),
-	}).Info("Registries listed")
-
-	rb.Success(filteredRegistries)
+	rb.SuccessWithPagination(credentials, utils.CreatePagination(page, limit, total))
 }
 
 // CreateRegistry godoc
-// @Summary Add registry
-// @Description Add a new container registry configuration
+// @Summary Add registry credentials
+// @Description Store credentials for a private registry. Update checks and pulls of images on the registry's host authenticate with them. The password and token are stored encrypted.
 // @Tags Registries
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body RegistryRequest true "Registry configuration"
-// @Success 201 {object} utils.APIResponse{data=RegistryResponse} "Registry created successfully"
+// @Param request body dto.CreateRegistryCredentialRequest true "Registry credentials"
+// @Success 201 {object} utils.APIResponse{data=model.RegistryCredentials} "Registry credentials created"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
-// @Failure 409 {object} utils.APIResponse "Registry already exists"
+// @Failure 409 {object} utils.APIResponse "Credentials already exist"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries [post]
 func (rc *RegistryController) CreateRegistry(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
-
-	var req RegistryRequest
+	var req dto.CreateRegistryCredentialRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		rc.logger.WithError(err).WithField("user_id", userID).Warn("Invalid registry creation request")
 		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
 		return
 	}
 
 	rb := utils.NewResponseBuilder(c)
 
-	// Validate registry type
-	validTypes := []string{"dockerhub", "harbor", "ecr", "gcr", "acr", "generic"}
-	validType := false
-	for _, t := range validTypes {
-		if req.Type == t {
-			validType = true
-			break
-		}
-	}
-	if !validType {
-		rb.BadRequest("Invalid registry type. Supported types: " + strings.Join(validTypes, ", "))
-		return
-	}
-
-	// Validate URL format
-	if !strings.HasPrefix(req.URL, "http://") && !strings.HasPrefix(req.URL, "https://") {
-		rb.BadRequest("Registry URL must start with http:// or https://")
+	credentials, err := rc.credentialService.CreateCredential(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to create registry credentials")
 		return
 	}
 
-	// Test connection before creating
-	if req.AuthConfig != nil {
-		err := rc.imageService.TestRegistryConnection(c.Request.Context(), req.URL, req.AuthConfig)
-		if err != nil {
-			rc.logger.WithError(err).WithFields(logrus.Fields{
-				"user_id": userID,
-				"url":     req.URL,
-				"type":    req.Type,
-			}).Warn("Registry connection test failed during creation")
-			rb.BadRequest("Failed to connect to registry: " + err.Error())
-			return
-		}
-	}
-
-	// For now, this is a placeholder implementation
-	// In a real implementation, you would:
-	// 1. Check if registry with same name/URL already exists
-	// 2. Save registry configuration to database
-	// 3. Register the registry with the image service
-	// 4. Return the created registry
-
-	rc.logger.WithFields(logrus.Fields{
-		"user_id": userID,
-		"name":    req.Name,
-		"url":     req.URL,
-		"type":    req.Type,
-	}).Info("Registry creation requested (placeholder implementation)")
-
-	// Mock response
-	response := RegistryResponse{
-		ID:          2, // Mock ID
-		Name:        req.Name,
-		URL:         req.URL,
-		Type:        req.Type,
-		Description: req.Description,
-		IsDefault:   req.IsDefault,
-		Enabled:     req.Enabled,
-		Status:      "connected",
-		Settings:    req.Settings,
-		CreatedAt:   "2023-01-01T00:00:00Z",
-		UpdatedAt:   "2023-01-01T00:00:00Z",
-	}
-
-	rb.Created(response)
+	rb.Created(credentials)
 }
 
 // GetRegistry godoc
-// @Summary Get registry details
-// @Description Get detailed information about a specific registry
+// @Summary Get registry credentials
+// @Description Get stored registry credentials without their password or token
 // @Tags Registries
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Registry ID"
-// @Success 200 {object} utils.APIResponse{data=RegistryResponse} "Registry details"
+// @Success 200 {object} utils.APIResponse{data=model.RegistryCredentials} "Registry credentials"
 // @Failure 400 {object} utils.APIResponse "Invalid registry ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -244,114 +128,65 @@ func (rc *RegistryController) CreateRegistry(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries/{id} [get]
 func (rc *RegistryController) GetRegistry(c *gin.Context) {
-	registryIDStr := c.Param("id")
-	registryID, err := strconv.ParseInt(registryIDStr, 10, 64)
-	if err != nil {
-		utils.BadRequestJSON(c, "Invalid registry ID")
+	registryID, ok := parseRegistryID(c)
+	if !ok {
 		return
 	}
 
 	rb := utils.NewResponseBuilder(c)
 
-	// For now, return mock data
-	// In a real implementation, you would query the registry repository
-	if registryID == 1 {
-		response := RegistryResponse{
-			ID:          1,
-			Name:        "Docker Hub",
-			URL:         "https://registry-1.docker.io",
-			Type:        "dockerhub",
-			Description: "Official Docker registry",
-			IsDefault:   true,
-			Enabled:     true,
-			Status:      "connected",
-			CreatedAt:   "2023-01-01T00:00:00Z",
-			UpdatedAt:   "2023-01-01T00:00:00Z",
-		}
-		rb.Success(response)
+	credentials, err := rc.credentialService.GetCredential(c.Request.Context(), registryID)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to retrieve registry")
 		return
 	}
 
-	rc.logger.WithField("registry_id", registryID).Warn("Registry not found")
-	rb.NotFound("Registry not found")
+	rb.Success(credentials)
 }
 
 // UpdateRegistry godoc
-// @Summary Update registry
-// @Description Update registry configuration
+// @Summary Update registry credentials
+// @Description Update stored registry credentials. Omitted fields keep their values, so the password and token only change when sent.
 // @Tags Registries
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Registry ID"
-// @Param request body RegistryRequest true "Registry update data"
-// @Success 200 {object} utils.APIResponse "Registry updated successfully"
+// @Param request body dto.UpdateRegistryCredentialRequest true "Changed fields"
+// @Success 200 {object} utils.APIResponse{data=model.RegistryCredentials} "Registry credentials updated"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 404 {object} utils.APIResponse "Registry not found"
+// @Failure 409 {object} utils.APIResponse "Credentials already exist"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries/{id} [put]
 func (rc *RegistryController) UpdateRegistry(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
-
-	registryIDStr := c.Param("id")
-	registryID, err := strconv.ParseInt(registryIDStr, 10, 64)
-	if err != nil {
-		utils.BadRequestJSON(c, "Invalid registry ID")
+	registryID, ok := parseRegistryID(c)
+	if !ok {
 		return
 	}
 
-	var req RegistryRequest
+	var req dto.UpdateRegistryCredentialRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		rc.logger.WithError(err).WithFields(logrus.Fields{
-			"user_id":     userID,
-			"registry_id": registryID,
-		}).Warn("Invalid registry update request")
 		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
 		return
 	}
 
 	rb := utils.NewResponseBuilder(c)
 
-	// Test connection if auth config is provided
-	if req.AuthConfig != nil {
-		err := rc.imageService.TestRegistryConnection(c.Request.Context(), req.URL, req.AuthConfig)
-		if err != nil {
-			rc.logger.WithError(err).WithFields(logrus.Fields{
-				"user_id":     userID,
-				"registry_id": registryID,
-				"url":         req.URL,
-			}).Warn("Registry connection test failed during update")
-			rb.BadRequest("Failed to connect to registry: " + err.Error())
-			return
-		}
+	credentials, err := rc.credentialService.UpdateCredential(c.Request.Context(), middleware.CurrentActor(c), registryID, &req)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to update registry credentials")
+		return
 	}
 
-	// For now, this is a placeholder implementation
-	// In a real implementation, you would:
-	// 1. Get existing registry from database
-	// 2. Update the registry configuration
-	// 3. Update the registry in the image service
-	// 4. Return success response
-
-	rc.logger.WithFields(logrus.Fields{
-		"user_id":     userID,
-		"registry_id": registryID,
-		"name":        req.Name,
-		"url":         req.URL,
-	}).Info("Registry update requested (placeholder implementation)")
-
-	rb.SuccessWithMessage(nil, "Registry updated successfully")
+	rb.SuccessWithMessage(credentials, "Registry updated successfully")
 }
 
 // DeleteRegistry godoc
-// @Summary Remove registry
-// @Description Remove a registry configuration
+// @Summary Remove registry credentials
+// @Description Delete stored registry credentials; the registry is reached anonymously afterwards
 // @Tags Registries
 // @Produce json
 // @Security BearerAuth
@@ -361,134 +196,50 @@ func (rc *RegistryController) UpdateRegistry(c *gin.Context) {
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 404 {object} utils.APIResponse "Registry not found"
-// @Failure 409 {object} utils.APIResponse "Cannot delete default registry"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries/{id} [delete]
 func (rc *RegistryController) DeleteRegistry(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
-
-	registryIDStr := c.Param("id")
-	registryID, err := strconv.ParseInt(registryIDStr, 10, 64)
-	if err != nil {
-		utils.BadRequestJSON(c, "Invalid registry ID")
+	registryID, ok := parseRegistryID(c)
+	if !ok {
 		return
 	}
 
 	rb := utils.NewResponseBuilder(c)
 
-	// For now, this is a placeholder implementation
-	// In a real implementation, you would:
-	// 1. Get registry from database
-	// 2. Check if it's the default registry (cannot delete)
-	// 3. Check if any containers are using this registry
-	// 4. Remove registry from database and image service
-	// 5. Return success response
-
-	// Prevent deletion of registry ID 1 (Docker Hub)
-	if registryID == 1 {
-		rb.Conflict("Cannot delete the default Docker Hub registry")
+	if err := rc.credentialService.DeleteCredential(c.Request.Context(), middleware.CurrentActor(c), registryID); err != nil {
+		rc.respondError(rb, err, "Failed to delete registry credentials")
 		return
 	}
 
-	rc.logger.WithFields(logrus.Fields{
-		"user_id":     userID,
-		"registry_id": registryID,
-	}).Info("Registry deletion requested (placeholder implementation)")
-
 	rb.SuccessWithMessage(nil, "Registry deleted successfully")
 }
 
 // TestRegistryConnection godoc
-// @Summary Test registry connection
-// @Description Test connection to a registry
+// @Summary Test registry credentials
+// @Description Authenticate against the registry's /v2/ endpoint with the stored credentials. A failed connection is reported in the result.
 // @Tags Registries
-// @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Registry ID"
-// @Param request body registry.AuthConfig false "Authentication configuration for testing"
-// @Success 200 {object} utils.APIResponse{data=RegistryTestResult} "Connection test result"
-// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Success 200 {object} utils.APIResponse{data=dto.RegistryTestResult} "Connection test result"
+// @Failure 400 {object} utils.APIResponse "Invalid registry ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 404 {object} utils.APIResponse "Registry not found"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries/{id}/test [post]
 func (rc *RegistryController) TestRegistryConnection(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
-
-	registryIDStr := c.Param("id")
-	registryID, err := strconv.ParseInt(registryIDStr, 10, 64)
-	if err != nil {
-		utils.BadRequestJSON(c, "Invalid registry ID")
+	registryID, ok := parseRegistryID(c)
+	if !ok {
 		return
 	}
 
-	var authConfig *registry.AuthConfig
-	if c.Request.ContentLength > 0 {
-		if err := c.ShouldBindJSON(&authConfig); err != nil {
-			rc.logger.WithError(err).WithFields(logrus.Fields{
-				"user_id":     userID,
-				"registry_id": registryID,
-			}).Warn("Invalid registry test request")
-			utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
-			return
-		}
-	}
-
 	rb := utils.NewResponseBuilder(c)
 
-	// For testing purposes, use hardcoded registry URL
-	// In a real implementation, you would get the URL from the database
-	var registryURL string
-	switch registryID {
-	case 1:
-		registryURL = "https://registry-1.docker.io"
-	default:
-		rb.NotFound("Registry not found")
-		return
-	}
-
-	// Measure connection test duration
-	start := time.Now()
-	err = rc.imageService.TestRegistryConnection(c.Request.Context(), registryURL, authConfig)
-	duration := time.Since(start)
-
-	result := RegistryTestResult{
-		Success:  err == nil,
-		Duration: duration.String(),
-	}
-
+	result, err := rc.credentialService.TestCredential(c.Request.Context(), registryID)
 	if err != nil {
-		result.Message = "Connection failed"
-		result.Error = err.Error()
-		rc.logger.WithError(err).WithFields(logrus.Fields{
-			"user_id":     userID,
-			"registry_id": registryID,
-			"duration":    duration,
-		}).Warn("Registry connection test failed")
-	} else {
-		result.Message = "Connection successful"
-		result.Capabilities = []string{"push", "pull", "search"} // Mock capabilities
-
-		// Try to get registry info
-		if info, err := rc.imageService.GetRegistryInfo(c.Request.Context(), registryURL); err == nil {
-			result.Info = info
-		}
-
-		rc.logger.WithFields(logrus.Fields{
-			"user_id":     userID,
-			"registry_id": registryID,
-			"duration":    duration,
-		}).Info("Registry connection test successful")
+		rc.respondError(rb, err, "Failed to test registry connection")
+		return
 	}
 
 	rb.Success(result)
@@ -509,27 +260,20 @@ func (rc *RegistryController) TestRegistryConnection(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries/{id}/info [get]
 func (rc *RegistryController) GetRegistryInfo(c *gin.Context) {
-	registryIDStr := c.Param("id")
-	registryID, err := strconv.ParseInt(registryIDStr, 10, 64)
-	if err != nil {
-		utils.BadRequestJSON(c, "Invalid registry ID")
+	registryID, ok := parseRegistryID(c)
+	if !ok {
 		return
 	}
 
 	rb := utils.NewResponseBuilder(c)
 
-	// For testing purposes, use hardcoded registry URL
-	// In a real implementation, you would get the URL from the database
-	var registryURL string
-	switch registryID {
-	case 1:
-		registryURL = "https://registry-1.docker.io"
-	default:
-		rb.NotFound("Registry not found")
+	credentials, err := rc.credentialService.GetCredential(c.Request.Context(), registryID)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to retrieve registry")
 		return
 	}
 
-	info, err := rc.imageService.GetRegistryInfo(c.Request.Context(), registryURL)
+	info, err := rc.imageService.GetRegistryInfo(c.Request.Context(), credentials.RegistryURL)
 	if err != nil {
 		rc.logger.WithError(err).WithField("registry_id", registryID).Error("Failed to get registry info")
 		rb.InternalServerError("Failed to retrieve registry information")
@@ -556,10 +300,8 @@ func (rc *RegistryController) GetRegistryInfo(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries/{id}/search [get]
 func (rc *RegistryController) SearchRegistryImages(c *gin.Context) {
-	registryIDStr := c.Param("id")
-	registryID, err := strconv.ParseInt(registryIDStr, 10, 64)
-	if err != nil {
-		utils.BadRequestJSON(c, "Invalid registry ID")
+	registryID, ok := parseRegistryID(c)
+	if !ok {
 		return
 	}
 
@@ -576,18 +318,13 @@ func (rc *RegistryController) SearchRegistryImages(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	// For testing purposes, use hardcoded registry URL
-	// In a real implementation, you would get the URL from the database
-	var registryURL string
-	switch registryID {
-	case 1:
-		registryURL = "https://registry-1.docker.io"
-	default:
-		rb.NotFound("Registry not found")
+	credentials, err := rc.credentialService.GetCredential(c.Request.Context(), registryID)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to retrieve registry")
 		return
 	}
 
-	results, err := rc.imageService.SearchImages(c.Request.Context(), query, registryURL)
+	results, err := rc.imageService.SearchImages(c.Request.Context(), query, credentials.RegistryURL)
 	if err != nil {
 		rc.logger.WithError(err).WithFields(logrus.Fields{
 			"registry_id": registryID,
@@ -642,22 +379,22 @@ func (rc *RegistryController) GetRegistryStatistics(c *gin.Context) {
 	// 3. Calculate usage metrics
 
 	stats := map[string]interface{}{
-		"registry_id":        registryID,
-		"containers_using":   0, // Number of containers using this registry
-		"images_tracked":     0, // Number of different images tracked
-		"total_pulls":        0, // Total image pulls
-		"total_pushes":       0, // Total image pushes (if supported)
-		"last_pull":          nil, // Last pull timestamp
-		"last_push":          nil, // Last push timestamp
-		"popular_images":     []interface{}{}, // Most pulled images
-		"recent_activity":    []interface{}{}, // Recent pull/push activity
-		"storage_usage":      map[string]interface{}{ // Storage usage if available
-			"total_size":     0,
-			"image_count":    0,
-			"layer_count":    0,
+		"registry_id":      registryID,
+		"containers_using": 0,               // Number of containers using this registry
+		"images_tracked":   0,               // Number of different images tracked
+		"total_pulls":      0,               // Total image pulls
+		"total_pushes":     0,               // Total image pushes (if supported)
+		"last_pull":        nil,             // Last pull timestamp
+		"last_push":        nil,             // Last push timestamp
+		"popular_images":   []interface{}{}, // Most pulled images
+		"recent_activity":  []interface{}{}, // Recent pull/push activity
+		"storage_usage": map[string]interface{}{ // Storage usage if available
+			"total_size":  0,
+			"image_count": 0,
+			"layer_count": 0,
 		},
-		"error_rate":         0.0, // Error rate for registry operations
-		"availability":       100.0, // Registry availability percentage
+		"error_rate":   0.0,   // Error rate for registry operations
+		"availability": 100.0, // Registry availability percentage
 	}
 
 	rc.logger.WithField("registry_id", registryID).Info("Registry statistics requested")
@@ -666,12 +403,12 @@ func (rc *RegistryController) GetRegistryStatistics(c *gin.Context) {
 
 // SetDefaultRegistry godoc
 // @Summary Set default registry
-// @Description Set a registry as the default for new containers
+// @Description Mark registry credentials as the default registry
 // @Tags Registries
 // @Produce json
 // @Security BearerAuth
 // @Param id path int true "Registry ID"
-// @Success 200 {object} utils.APIResponse "Default registry updated"
+// @Success 200 {object} utils.APIResponse{data=model.RegistryCredentials} "Default registry updated"
 // @Failure 400 {object} utils.APIResponse "Invalid registry ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
@@ -679,32 +416,45 @@ func (rc *RegistryController) GetRegistryStatistics(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/registries/{id}/default [post]
 func (rc *RegistryController) SetDefaultRegistry(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
+	registryID, ok := parseRegistryID(c)
+	if !ok {
 		return
 	}
 
-	registryIDStr := c.Param("id")
-	registryID, err := strconv.ParseInt(registryIDStr, 10, 64)
+	rb := utils.NewResponseBuilder(c)
+
+	credentials, err := rc.credentialService.SetDefaultCredential(c.Request.Context(), middleware.CurrentActor(c), registryID)
 	if err != nil {
-		utils.BadRequestJSON(c, "Invalid registry ID")
+		rc.respondError(rb, err, "Failed to set default registry")
 		return
 	}
 
-	rb := utils.NewResponseBuilder(c)
-
-	// For now, this is a placeholder implementation
-	// In a real implementation, you would:
-	// 1. Verify registry exists and is enabled
-	// 2. Update all other registries to not be default
-	// 3. Set this registry as default
-	// 4. Update system configuration
+	rb.SuccessWithMessage(credentials, "Default registry updated successfully")
+}
 
-	rc.logger.WithFields(logrus.Fields{
-		"user_id":     userID,
-		"registry_id": registryID,
-	}).Info("Default registry change requested (placeholder implementation)")
+// parseRegistryID reads the registry ID path parameter, responding with a
+// bad request when it is invalid
+func parseRegistryID(c *gin.Context) (int64, bool) {
+	registryID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || registryID <= 0 {
+		utils.BadRequestJSON(c, "Invalid registry ID")
+		return 0, false
+	}
+	return registryID, true
+}
 
-	rb.SuccessWithMessage(nil, "Default registry updated successfully")
-}
\ No newline at end of file
+// respondError maps a service error to a response
+func (rc *RegistryController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	rc.logger.WithError(err).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound(err.Error())
+	case strings.Contains(err.Error(), "already exist"):
+		rb.Conflict(err.Error())
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,180 @@
+package controller
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"docker-auto/internal/service"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RegistryWebhookController receives push notifications from registries and
+// refreshes the cached versions of the pushed repositories
+type RegistryWebhookController struct {
+	imageService *service.ImageService
+	token        string
+	logger       *logrus.Logger
+}
+
+// NewRegistryWebhookController creates a registry webhook controller accepting
+// requests that present token
+func NewRegistryWebhookController(imageService *service.ImageService, token string, logger *logrus.Logger) *RegistryWebhookController {
+	return &RegistryWebhookController{
+		imageService: imageService,
+		token:        token,
+		logger:       logger,
+	}
+}
+
+// dockerHubPush is the payload of a Docker Hub push webhook
+type dockerHubPush struct {
+	PushData struct {
+		Tag string `json:"tag"`
+	} `json:"push_data"`
+	Repository struct {
+		RepoName string `json:"repo_name"`
+	} `json:"repository"`
+}
+
+// registryNotification is the notification envelope of registries
+// implementing the distribution spec, such as registry:2 and Harbor
+type registryNotification struct {
+	Events []struct {
+		Action string `json:"action"`
+		Target struct {
+			Repository string `json:"repository"`
+			Tag        string `json:"tag"`
+		} `json:"target"`
+		Request struct {
+			Host string `json:"host"`
+		} `json:"request"`
+	} `json:"events"`
+}
+
+// DockerHubPush godoc
+// @Summary Receive a Docker Hub push
+// @Description Flush the cached versions of the pushed repository and fetch them again. The webhook token is passed in the token query parameter, as Docker Hub cannot send headers.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param token query string true "Webhook token"
+// @Success 200 {object} utils.APIResponse{data=service.ImageCacheFlushResult} "Repository refreshed"
+// @Failure 400 {object} utils.APIResponse "Invalid payload"
+// @Failure 401 {object} utils.APIResponse "Invalid webhook token"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /webhooks/dockerhub [post]
+func (wc *RegistryWebhookController) DockerHubPush(c *gin.Context) {
+	if !wc.authorized(c) {
+		return
+	}
+
+	var payload dockerHubPush
+	if err := c.ShouldBindJSON(&payload); err != nil || payload.Repository.RepoName == "" {
+		utils.BadRequestJSON(c, "Invalid Docker Hub payload")
+		return
+	}
+
+	wc.refresh(c, "dockerhub", []string{payload.Repository.RepoName})
+}
+
+// RegistryPush godoc
+// @Summary Receive registry push notifications
+// @Description Flush the cached versions of the repositories pushed to a registry implementing the distribution notification spec and fetch them again. Events other than pushes are ignored.
+// @Tags Webhooks
+// @Accept json
+// @Produce json
+// @Param registryId path string true "Name of the sending registry, used in logs"
+// @Param token query string false "Webhook token, unless sent in the X-Webhook-Token header"
+// @Success 200 {object} utils.APIResponse{data=[]service.ImageCacheFlushResult} "Repositories refreshed"
+// @Failure 400 {object} utils.APIResponse "Invalid payload"
+// @Failure 401 {object} utils.APIResponse "Invalid webhook token"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /webhooks/registry/{registryId} [post]
+func (wc *RegistryWebhookController) RegistryPush(c *gin.Context) {
+	if !wc.authorized(c) {
+		return
+	}
+
+	var payload registryNotification
+	if err := c.ShouldBindJSON(&payload); err != nil {
+		utils.BadRequestJSON(c, "Invalid registry notification")
+		return
+	}
+
+	var repositories []string
+	seen := make(map[string]bool)
+	for _, event := range payload.Events {
+		if event.Action != "push" || event.Target.Repository == "" {
+			continue
+		}
+		repository := qualifyRepository(event.Request.Host, event.Target.Repository)
+		if !seen[repository] {
+			seen[repository] = true
+			repositories = append(repositories, repository)
+		}
+	}
+
+	wc.refresh(c, c.Param("registryId"), repositories)
+}
+
+func (wc *RegistryWebhookController) refresh(c *gin.Context, source string, repositories []string) {
+	rb := utils.NewResponseBuilder(c)
+	actor := model.SystemActor(model.ActorComponentWebhook)
+
+	results := make([]*service.ImageCacheFlushResult, 0, len(repositories))
+	for _, repository := range repositories {
+		result, err := wc.imageService.RefreshRepository(c.Request.Context(), actor, repository)
+		if err != nil {
+			wc.logger.WithError(err).WithFields(logrus.Fields{
+				"source":     source,
+				"repository": repository,
+			}).Error("Failed to refresh repository after push")
+			if strings.HasPrefix(err.Error(), "invalid request") {
+				rb.BadRequest(err.Error())
+				return
+			}
+			rb.InternalServerError("Failed to refresh repository")
+			return
+		}
+		results = append(results, result)
+	}
+
+	wc.logger.WithFields(logrus.Fields{
+		"source":       source,
+		"repositories": repositories,
+	}).Info("Registry push received")
+	rb.Success(results)
+}
+
+// authorized checks the webhook token, from the X-Webhook-Token header or the
+// token query parameter
+func (wc *RegistryWebhookController) authorized(c *gin.Context) bool {
+	token := c.GetHeader("X-Webhook-Token")
+	if token == "" {
+		token = c.Query("token")
+	}
+	if wc.token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(wc.token)) != 1 {
+		wc.logger.WithFields(logrus.Fields{
+			"path":      c.Request.URL.Path,
+			"client_ip": c.ClientIP(),
+		}).Warn("Registry webhook rejected: invalid token")
+		utils.UnauthorizedJSON(c, "Invalid webhook token")
+		return false
+	}
+	return true
+}
+
+// qualifyRepository prefixes a repository with the registry host it was
+// pushed to, unless that is Docker Hub
+func qualifyRepository(host, repository string) string {
+	host = strings.ToLower(strings.TrimSpace(host))
+	switch host {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io":
+		return repository
+	}
+	return host + "/" + repository
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ReportController handles compliance report exports
+type ReportController struct {
+	reportService *service.ReportService
+	logger        *logrus.Logger
+}
+
+// NewReportController creates a new report controller
+func NewReportController(reportService *service.ReportService, logger *logrus.Logger) *ReportController {
+	return &ReportController{
+		reportService: reportService,
+		logger:        logger,
+	}
+}
+
+// ExportUpdates godoc
+// @Summary Export update history
+// @Description Export container updates started in [from, to) as CSV or JSON, with the trigger (scheduled, manual or approval), actor, approver, result and scan verdict of each. Non-admins only get updates of containers they created. Exports longer than REPORT_EXPORT_MAX_ROWS are truncated; X-Export-Truncated is set and the body says so.
+// @Tags Reports
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start, RFC3339 or YYYY-MM-DD (default: 90 days before to)"
+// @Param to query string false "End, RFC3339 or YYYY-MM-DD, a day is included (default: now)"
+// @Param format query string false "csv or json (default: csv)"
+// @Success 200 {file} file "Update history export"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/reports/updates [get]
+func (rc *ReportController) ExportUpdates(c *gin.Context) {
+	rc.export(c, service.ReportKindUpdates)
+}
+
+// ExportTaskExecutions godoc
+// @Summary Export task executions
+// @Description Export scheduled task runs started in [from, to) as CSV or JSON. Non-admins only get runs of tasks they created. Exports longer than REPORT_EXPORT_MAX_ROWS are truncated; X-Export-Truncated is set and the body says so.
+// @Tags Reports
+// @Produce text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Start, RFC3339 or YYYY-MM-DD (default: 90 days before to)"
+// @Param to query string false "End, RFC3339 or YYYY-MM-DD, a day is included (default: now)"
+// @Param format query string false "csv or json (default: csv)"
+// @Success 200 {file} file "Task execution export"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/reports/task-executions [get]
+func (rc *ReportController) ExportTaskExecutions(c *gin.Context) {
+	rc.export(c, service.ReportKindTaskExecutions)
+}
+
+// export prepares the report, sets the download headers and streams the body.
+// Errors after the first byte can only be logged.
+func (rc *ReportController) export(c *gin.Context, kind service.ReportKind) {
+	rb := utils.NewResponseBuilder(c)
+
+	var req service.ReportRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		rb.BadRequest("Invalid query parameters: " + err.Error())
+		return
+	}
+
+	export, err := rc.reportService.PrepareExport(c.Request.Context(), middleware.CurrentActor(c), kind, &req)
+	if err != nil {
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid request:"):
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+		case errors.Is(err, service.ErrReportAccessDenied):
+			rb.Error(http.StatusForbidden, err.Error())
+		default:
+			rc.logger.WithError(err).WithField("report", kind).Error("Failed to prepare report export")
+			rb.InternalServerError("Failed to export report")
+		}
+		return
+	}
+
+	c.Header("Content-Type", export.ContentType())
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", export.Filename()))
+	c.Header("X-Export-Truncated", strconv.FormatBool(export.Truncated()))
+	c.Header("X-Export-Row-Limit", strconv.Itoa(export.RowLimit))
+	c.Header("X-Export-Total-Rows", strconv.FormatInt(export.TotalRows, 10))
+	c.Status(http.StatusOK)
+
+	if err := rc.reportService.WriteExport(c.Request.Context(), c.Writer, export); err != nil {
+		rc.logger.WithError(err).WithField("report", kind).Error("Report export interrupted")
+	}
+}
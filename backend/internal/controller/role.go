@@ -0,0 +1,217 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RoleController handles roles and the permissions they grant
+type RoleController struct {
+	roleService *service.RoleService
+	logger      *logrus.Logger
+}
+
+// NewRoleController creates a new role controller
+func NewRoleController(roleService *service.RoleService, logger *logrus.Logger) *RoleController {
+	return &RoleController{
+		roleService: roleService,
+		logger:      logger,
+	}
+}
+
+// ListRoles godoc
+// @Summary List roles
+// @Description Get the built-in admin, operator and viewer roles followed by the custom roles, with their permissions and the number of users assigned each
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]dto.RoleResponse} "Roles"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/roles [get]
+func (rc *RoleController) ListRoles(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	roles, err := rc.roleService.ListRoles(c.Request.Context())
+	if err != nil {
+		rc.respondError(rb, err, "Failed to list roles")
+		return
+	}
+
+	rb.Success(roles)
+}
+
+// GetPermissionMatrix godoc
+// @Summary Get permission matrix
+// @Description Get every permission roles can grant with the roles granting it, directly or through a broader permission
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=dto.PermissionMatrix} "Permission matrix"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/roles/permissions [get]
+func (rc *RoleController) GetPermissionMatrix(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	matrix, err := rc.roleService.GetPermissionMatrix(c.Request.Context())
+	if err != nil {
+		rc.respondError(rb, err, "Failed to get permission matrix")
+		return
+	}
+
+	rb.Success(matrix)
+}
+
+// GetRole godoc
+// @Summary Get role
+// @Description Get a role with its permissions
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} utils.APIResponse{data=dto.RoleResponse} "Role"
+// @Failure 404 {object} utils.APIResponse "Role not found"
+// @Router /api/roles/{id} [get]
+func (rc *RoleController) GetRole(c *gin.Context) {
+	id, ok := roleID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	role, err := rc.roleService.GetRole(c.Request.Context(), id)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to get role")
+		return
+	}
+
+	rb.Success(role)
+}
+
+// CreateRole godoc
+// @Summary Create role
+// @Description Add a custom role granting a set of permissions. Users are assigned roles by name, so the name cannot be changed later.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.CreateRoleRequest true "Role"
+// @Success 201 {object} utils.APIResponse{data=model.Role} "Role created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 409 {object} utils.APIResponse "Name already in use"
+// @Router /api/roles [post]
+func (rc *RoleController) CreateRole(c *gin.Context) {
+	var req dto.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	role, err := rc.roleService.CreateRole(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to create role")
+		return
+	}
+
+	rb.Created(role)
+}
+
+// UpdateRole godoc
+// @Summary Update role
+// @Description Change the description or permissions of a custom role; omitted fields keep their values. Users assigned the role get the new permissions within 30 seconds. Built-in roles cannot be changed.
+// @Tags Roles
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Param request body dto.UpdateRoleRequest true "Role changes"
+// @Success 200 {object} utils.APIResponse{data=model.Role} "Role updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request or built-in role"
+// @Failure 404 {object} utils.APIResponse "Role not found"
+// @Router /api/roles/{id} [put]
+func (rc *RoleController) UpdateRole(c *gin.Context) {
+	id, ok := roleID(c)
+	if !ok {
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	role, err := rc.roleService.UpdateRole(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		rc.respondError(rb, err, "Failed to update role")
+		return
+	}
+
+	rb.Success(role)
+}
+
+// DeleteRole godoc
+// @Summary Delete role
+// @Description Remove a custom role. It is refused while users are assigned the role. Built-in roles cannot be deleted.
+// @Tags Roles
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Role ID"
+// @Success 200 {object} utils.APIResponse "Role deleted"
+// @Failure 400 {object} utils.APIResponse "Built-in role"
+// @Failure 404 {object} utils.APIResponse "Role not found"
+// @Failure 409 {object} utils.APIResponse "Users still assigned"
+// @Router /api/roles/{id} [delete]
+func (rc *RoleController) DeleteRole(c *gin.Context) {
+	id, ok := roleID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := rc.roleService.DeleteRole(c.Request.Context(), middleware.CurrentActor(c), id); err != nil {
+		rc.respondError(rb, err, "Failed to delete role")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Role deleted successfully")
+}
+
+func roleID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid role ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError maps role service errors onto HTTP responses
+func (rc *RoleController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request:"):
+		rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+	case strings.Contains(err.Error(), "already exists"), strings.Contains(err.Error(), "still has"):
+		rb.Conflict(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Role not found")
+	default:
+		rc.logger.WithError(err).Error(message)
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,159 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-auto/internal/repository"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+)
+
+// customRoleRepo stores roles, assigned to users as counted in assigned
+type customRoleRepo struct {
+	repository.RoleRepository
+	roles    []*model.Role
+	assigned map[model.UserRole]int64
+}
+
+func (r *customRoleRepo) List(ctx context.Context) ([]*model.Role, error) {
+	return r.roles, nil
+}
+
+func (r *customRoleRepo) GetByID(ctx context.Context, id int) (*model.Role, error) {
+	for _, role := range r.roles {
+		if role.ID == id {
+			copied := *role
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("role with ID %d not found", id)
+}
+
+func (r *customRoleRepo) GetByName(ctx context.Context, name model.UserRole) (*model.Role, error) {
+	for _, role := range r.roles {
+		if role.Name == name {
+			copied := *role
+			return &copied, nil
+		}
+	}
+	return nil, fmt.Errorf("role %s not found", name)
+}
+
+func (r *customRoleRepo) Update(ctx context.Context, role *model.Role) error {
+	for i, stored := range r.roles {
+		if stored.ID == role.ID {
+			copied := *role
+			r.roles[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (r *customRoleRepo) CountUsers(ctx context.Context) (map[model.UserRole]int64, error) {
+	return r.assigned, nil
+}
+
+// serveAs sends a request authenticated with a JWT of a user with role
+func serveAs(t *testing.T, router http.Handler, cfg *RouterConfig, role model.UserRole, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	token, err := utils.GenerateJWT(&model.User{ID: 2, Username: "alice", Role: role, IsActive: true}, cfg.Config.JWT.Secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRoleRoutesRequireAdmin(t *testing.T) {
+	roles := &customRoleRepo{roles: []*model.Role{
+		{ID: 1, Name: model.UserRoleAdmin, BuiltIn: true, Permissions: model.StringList{string(model.PermissionAdmin)}},
+		{ID: 4, Name: "auditor", Permissions: model.StringList{string(model.PermissionUserRead), string(model.PermissionSystemRead)}},
+		{ID: 5, Name: "deployer", Permissions: model.StringList{string(model.PermissionContainerRead)}},
+	}, assigned: map[model.UserRole]int64{"deployer": 2}}
+	cfg := newTestRouterConfig()
+	cfg.RoleService = service.NewRoleService(roles, nil)
+	router, _ := newTestRouter(t, cfg)
+
+	requests := []struct{ method, path, body string }{
+		{http.MethodGet, "/api/roles", ""},
+		{http.MethodGet, "/api/roles/permissions", ""},
+		{http.MethodPost, "/api/roles", `{"name": "root", "permissions": ["admin"]}`},
+		{http.MethodPut, "/api/roles/4", `{"permissions": ["admin"]}`},
+		{http.MethodDelete, "/api/roles/4", ""},
+	}
+	for _, role := range []model.UserRole{model.UserRoleOperator, model.UserRoleViewer, "auditor"} {
+		for _, r := range requests {
+			if w := serveAs(t, router, cfg, role, r.method, r.path, r.body); w.Code != http.StatusForbidden {
+				t.Errorf("%s %s as %s = %d, want %d: %s", r.method, r.path, role, w.Code, http.StatusForbidden, w.Body)
+			}
+		}
+	}
+	if perms := roles.roles[1].Permissions; len(perms) != 2 || perms[0] != string(model.PermissionUserRead) {
+		t.Errorf("auditor permissions = %v, want them unchanged", perms)
+	}
+
+	// Admins are refused changes to built-in roles and roles still in use
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodGet, "/api/roles", "", http.StatusOK},
+		{http.MethodPut, "/api/roles/1", `{"permissions": ["container:read"]}`, http.StatusBadRequest},
+		{http.MethodDelete, "/api/roles/1", "", http.StatusBadRequest},
+		{http.MethodDelete, "/api/roles/5", "", http.StatusConflict},
+		{http.MethodPost, "/api/roles", `{"name": "admin", "permissions": ["container:read"]}`, http.StatusConflict},
+		{http.MethodPost, "/api/roles", `{"name": "developer", "permissions": ["container:read"]}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		if w := serveAs(t, router, cfg, model.UserRoleAdmin, tt.method, tt.path, tt.body); w.Code != tt.want {
+			t.Errorf("%s %s as admin = %d, want %d: %s", tt.method, tt.path, w.Code, tt.want, w.Body)
+		}
+	}
+}
+
+func TestCustomRolePermissionsAreEnforced(t *testing.T) {
+	roles := &customRoleRepo{roles: []*model.Role{
+		{ID: 4, Name: "reader", Permissions: model.StringList{string(model.PermissionContainerRead)}},
+	}}
+	cfg := newTestRouterConfig()
+	cfg.RoleService = service.NewRoleService(roles, nil)
+	router, _ := newTestRouter(t, cfg)
+
+	tests := []struct {
+		role   model.UserRole
+		method string
+		path   string
+		want   int
+	}{
+		// Admitted callers reach the handler, which rejects the container ID
+		{"reader", http.MethodGet, "/api/containers/abc/status", http.StatusBadRequest},
+		{"reader", http.MethodPost, "/api/containers/abc/start", http.StatusForbidden},
+		{"reader", http.MethodGet, "/api/roles", http.StatusForbidden},
+		// A role that no longer exists grants nothing
+		{"removed", http.MethodGet, "/api/containers/abc/status", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		if w := serveAs(t, router, cfg, tt.role, tt.method, tt.path, ""); w.Code != tt.want {
+			t.Errorf("%s %s as %s = %d, want %d: %s", tt.method, tt.path, tt.role, w.Code, tt.want, w.Body)
+		}
+	}
+
+	// Taking a permission from the role refuses its users on their next request
+	if w := serveAs(t, router, cfg, model.UserRoleAdmin, http.MethodPut, "/api/roles/4", `{"permissions": ["image:read"]}`); w.Code != http.StatusOK {
+		t.Fatalf("updating reader = %d: %s", w.Code, w.Body)
+	}
+	if w := serveAs(t, router, cfg, "reader", http.MethodGet, "/api/containers/abc/status", ""); w.Code != http.StatusForbidden {
+		t.Errorf("GET /api/containers/abc/status as reader after the update = %d, want %d", w.Code, http.StatusForbidden)
+	}
+}
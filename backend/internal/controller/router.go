@@ -1,12 +1,17 @@
 package controller
 
 import (
+	"context"
+	"net/http"
 	"time"
 
 	"docker-auto/internal/api"
 	"docker-auto/internal/config"
 	"docker-auto/internal/middleware"
 	"docker-auto/internal/service"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -14,335 +19,722 @@ import (
 
 // RouterConfig holds configuration for the router setup
 type RouterConfig struct {
-	Config              *config.Config
-	Logger              *logrus.Logger
-	UserService         *service.UserService
-	ContainerService    *service.ContainerService
-	ImageService        *service.ImageService
-	NotificationService *service.NotificationService
-	WebSocketManager    *api.WebSocketManager
+	Config               *config.Config
+	Logger               *logrus.Logger
+	UserService          *service.UserService
+	OIDCService          *service.OIDCService
+	RoleService          *service.RoleService
+	APITokenService      *service.APITokenService
+	ContainerService     *service.ContainerService
+	TemplateService      *service.ContainerTemplateService
+	DockerHostService    *service.DockerHostService
+	ImageService         *service.ImageService
+	ImagePolicyService   *service.ImagePolicyService
+	ImageRetargetService *service.ImageRetargetService
+	StackService         *service.StackService
+	ChangeFeedService    *service.ChangeFeedService
+	NotificationService  *service.NotificationService
+	ChannelService       *service.NotificationChannelService
+	SetupService         *service.SetupService
+	FeatureService       *service.FeatureService
+	VolumeService        *service.VolumeService
+	ReportService        *service.ReportService
+	ActivityService      *service.ActivityService
+	StatusPageService    *service.StatusPageService
+	ApprovalService      *service.ApprovalPolicyService
+	TeamService          *service.TeamService
+	PostureService       *service.SecurityPostureService
+	GitOpsService        *service.GitOpsService
+	RegistryService      *service.RegistryCredentialService
+	SchedulerService     *service.SchedulerService
+	SystemBundleService  *service.SystemBundleService
+	BackupService        *service.BackupService
+	RateLimitService     *service.RateLimitService
+	ConfigService        *service.ConfigService
+	DashboardService     *service.DashboardService
+	SystemInfoService    *service.SystemInfoService
+	WebSocketManager     *api.WebSocketManager
+	DockerClient         *docker.DockerClient
 }
 
-// SetupRoutes configures all API routes with proper middleware chains
-func SetupRoutes(router *gin.Engine, cfg *RouterConfig) {
+// SetupRoutes configures all API routes with proper middleware chains and
+// returns the table of what each route requires
+func SetupRoutes(router *gin.Engine, cfg *RouterConfig) *RouteTable {
+	var tokens middleware.TokenResolver
+	if cfg.APITokenService != nil {
+		tokens = cfg.APITokenService
+	}
+	var roles middleware.RoleResolver
+	if cfg.RoleService != nil {
+		roles = cfg.RoleService
+	}
+	var sessions middleware.SessionResolver
+	if cfg.UserService != nil {
+		sessions = cfg.UserService
+	}
+	table := newRouteTable(cfg.Config, tokens, roles, sessions)
+
 	// Apply global middleware
 	setupGlobalMiddleware(router, cfg)
 
 	// Setup API routes
-	setupAPIRoutes(router, cfg)
+	setupAPIRoutes(router, table, cfg)
 
 	// Setup health check and metrics routes (no auth required)
-	setupPublicRoutes(router, cfg)
+	setupPublicRoutes(router, table, cfg)
+
+	return table
 }
 
 // setupGlobalMiddleware configures global middleware that applies to all routes
 func setupGlobalMiddleware(router *gin.Engine, cfg *RouterConfig) {
-	// Request ID middleware for tracing
+	// Request ID middleware for tracing; entries logged with a request
+	// context carry its ID
 	router.Use(middleware.RequestIDMiddleware())
+	logrus.AddHook(middleware.RequestIDHook{})
+	if cfg.Logger != nil && cfg.Logger != logrus.StandardLogger() {
+		cfg.Logger.AddHook(middleware.RequestIDHook{})
+	}
 
 	// Logger middleware
 	router.Use(middleware.LoggerMiddleware(cfg.Logger))
 
-	// CORS middleware
-	corsConfig := middleware.CORSConfig{
-		AllowedOrigins:   []string{"*"}, // Configure based on environment
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowedHeaders:   []string{"*"},
-		AllowCredentials: true,
-		MaxAge:           12 * time.Hour,
-	}
-	router.Use(middleware.CORSMiddleware(corsConfig))
+	// CORS middleware, allowing the configured origins
+	router.Use(middleware.CORSMiddleware(cfg.Config))
 
 	// Error handling middleware
-	router.Use(middleware.ErrorHandlerMiddleware(cfg.Logger))
+	router.Use(middleware.ErrorHandlerMiddleware())
 
 	// Recovery middleware
 	router.Use(gin.Recovery())
 }
 
 // setupPublicRoutes configures routes that don't require authentication
-func setupPublicRoutes(router *gin.Engine, cfg *RouterConfig) {
-	systemController := NewSystemController(cfg.Logger)
-
-	// Health check endpoint (public)
-	router.GET("/health", systemController.HealthCheck)
-	router.GET("/api/health", systemController.HealthCheck)
-
-	// API info endpoint (public)
-	router.GET("/api", func(c *gin.Context) {
-		c.JSON(200, gin.H{
-			"name":    "Docker Auto Update System API",
-			"version": "v1",
-			"status":  "running",
-		})
-	})
+func setupPublicRoutes(router *gin.Engine, table *RouteTable, cfg *RouterConfig) {
+	systemController := NewSystemController(systemInfoService(cfg), cfg.Logger)
+
+	table.Register(&router.RouterGroup,
+		// Health check endpoints
+		get("/health", authPublic, systemController.HealthCheck),
+		get("/api/health", authPublic, systemController.HealthCheck),
+
+		// API info endpoint
+		get("/api", authPublic, func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"name":    "Docker Auto Update System API",
+				"version": "v1",
+				"status":  "running",
+			})
+		}),
+	)
+
+	// Prometheus metrics, optionally behind basic auth or a bearer token
+	if monitoring := cfg.Config.Monitoring; monitoring.PrometheusEnabled {
+		path := monitoring.PrometheusPath
+		if path == "" {
+			path = "/metrics"
+		}
+		auth := middleware.MetricsAuth(monitoring.PrometheusUsername, monitoring.PrometheusPassword, monitoring.PrometheusToken)
+
+		// Scrapers authenticate with the metrics credentials, not a user
+		table.AllowPublic(http.MethodGet, path)
+		table.Register(&router.RouterGroup,
+			get(path, authPublic, auth, gin.WrapH(metrics.Handler())),
+		)
+	}
+
+	// Public status pages, rate limited apart from the API
+	if cfg.StatusPageService != nil {
+		statusPageController := NewStatusPageController(cfg.StatusPageService, cfg.Logger)
+
+		limit := cfg.Config.System.StatusPageRateLimit
+		if limit <= 0 {
+			limit = 60
+		}
+		limiter := middleware.RateLimitMiddleware(middleware.NewRateLimiter(limit, time.Minute))
+
+		table.Register(&router.RouterGroup,
+			get("/status/:token", authPublic, limiter, statusPageController.GetPublicStatus),
+		)
+	}
 }
 
-// setupAPIRoutes configures all authenticated API routes
-func setupAPIRoutes(router *gin.Engine, cfg *RouterConfig) {
+// setupAPIRoutes configures all API routes. Each route declares its auth
+// requirement in its table entry; handlers can rely on the principal.
+func setupAPIRoutes(router *gin.Engine, table *RouteTable, cfg *RouterConfig) {
 	// Create API v1 group
 	api := router.Group("/api")
 
-	// Apply rate limiting to API endpoints
-	rateLimitConfig := middleware.RateLimitConfig{
-		Requests: 100, // requests per window
-		Window:   time.Minute,
-		KeyFunc:  middleware.ClientIPKeyFunc,
+	// Apply rate limiting to API endpoints: 100 requests a minute per client IP
+	api.Use(middleware.RateLimitMiddleware(middleware.NewRateLimiter(100, time.Minute)))
+
+	// Per-endpoint limits, access lists and bans, adjustable at runtime
+	if cfg.RateLimitService != nil {
+		api.Use(middleware.EnhancedRateLimitMiddleware(cfg.RateLimitService.Limiter()))
+	}
+
+	// Without a configured service, flags come from FEATURE_FLAGS and defaults only
+	if cfg.FeatureService == nil {
+		cfg.FeatureService = service.NewFeatureService(cfg.Config, nil, nil)
+	}
+
+	// Setup wizard routes stay reachable before the system is initialized
+	table.Register(api, setupRoutes(cfg)...)
+
+	// Everything registered below requires setup to be complete
+	if cfg.SetupService != nil {
+		api.Use(middleware.RequireSetupComplete(cfg.SetupService))
+	}
+
+	for _, routes := range [][]Route{
+		featureRoutes(cfg),
+		authRoutes(cfg),
+		oidcRoutes(cfg),
+		dashboardRoutes(cfg),
+		apiTokenRoutes(cfg),
+		userRoutes(cfg),
+		roleRoutes(cfg),
+		containerRoutes(cfg),
+		templateRoutes(cfg),
+		dockerHostRoutes(cfg),
+		stackRoutes(cfg),
+		changeRoutes(cfg),
+		imageRoutes(cfg),
+		updateRoutes(cfg),
+		schedulerRoutes(cfg),
+		systemRoutes(cfg),
+		systemBundleRoutes(cfg),
+		selfUpdateRoutes(cfg),
+		backupRoutes(cfg),
+		rateLimitRoutes(cfg),
+		configRoutes(cfg),
+		registryRoutes(cfg),
+		notificationRoutes(cfg),
+		volumeRoutes(cfg),
+		teamRoutes(cfg),
+		securityPostureRoutes(cfg),
+		gitopsRoutes(cfg),
+		reportRoutes(cfg),
+		activityRoutes(cfg),
+		statusPageRoutes(cfg),
+	} {
+		table.Register(api, routes...)
+	}
+
+	// WebSocket routes are registered at startup only, so the flag requires a restart
+	if cfg.FeatureService.Enabled(context.Background(), model.FeatureWebSockets) {
+		table.Register(api, webSocketRoutes(cfg)...)
+	}
+}
+
+// setupRoutes returns the first-run setup wizard routes
+func setupRoutes(cfg *RouterConfig) []Route {
+	if cfg.SetupService == nil {
+		return nil
+	}
+
+	setupController := NewSetupController(cfg.SetupService, cfg.Logger)
+
+	return []Route{
+		get("/setup/status", authPublic, setupController.GetSetupStatus),
+		post("/setup", authPublic, setupController.RunSetup),
 	}
-	api.Use(middleware.RateLimitMiddleware(rateLimitConfig))
+}
 
-	// Setup authentication routes (no auth required)
-	setupAuthRoutes(api, cfg)
+// featureRoutes returns the feature flag routes. Flags are public so the
+// frontend can gate UI before login.
+func featureRoutes(cfg *RouterConfig) []Route {
+	featureController := NewFeatureController(cfg.FeatureService, cfg.Logger)
 
-	// Setup authenticated routes
-	setupAuthenticatedRoutes(api, cfg)
+	return []Route{
+		get("/features", authPublic, featureController.GetFeatures),
+		put("/admin/features/:name", authAdmin, featureController.SetFeature),
+	}
 }
 
-// setupAuthRoutes configures authentication-related routes
-func setupAuthRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
+// authRoutes returns the authentication routes
+func authRoutes(cfg *RouterConfig) []Route {
 	userController := NewUserController(cfg.UserService, cfg.Logger)
 
-	auth := api.Group("/auth")
-	{
-		// Public authentication endpoints
-		auth.POST("/login", userController.Login)
-		auth.POST("/refresh", userController.RefreshToken)
+	return []Route{
+		post("/auth/login", authPublic, userController.Login),
+		post("/auth/refresh", authPublic, userController.RefreshToken),
 
-		// Authenticated endpoints
-		authRequired := auth.Group("")
-		authRequired.Use(middleware.JWTAuthMiddleware(cfg.Config.JWT.Secret))
-		{
-			authRequired.POST("/logout", userController.Logout)
-			authRequired.GET("/profile", userController.GetProfile)
-			authRequired.PUT("/profile", userController.UpdateProfile)
-			authRequired.PUT("/password", userController.ChangePassword)
-		}
+		post("/auth/logout", authSignedIn, userController.Logout),
+		get("/auth/profile", authSignedIn, userController.GetProfile),
+		put("/auth/profile", authSignedIn, userController.UpdateProfile),
+		put("/auth/password", authSignedIn, userController.ChangePassword),
+
+		// The signed in user's login sessions
+		get("/auth/sessions", authSignedIn, userController.ListSessions),
+		del("/auth/sessions", authSignedIn, userController.RevokeOtherSessions),
+		del("/auth/sessions/:id", authSignedIn, userController.RevokeSession),
 	}
 }
 
-// setupAuthenticatedRoutes configures all routes that require authentication
-func setupAuthenticatedRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
-	// All routes below require authentication
-	protected := api.Group("")
-	protected.Use(middleware.JWTAuthMiddleware(cfg.Config.JWT.Secret))
-	protected.Use(middleware.RequireActiveUser())
+// oidcRoutes returns the routes signing in through an OpenID Connect
+// provider
+func oidcRoutes(cfg *RouterConfig) []Route {
+	if cfg.OIDCService == nil {
+		return nil
+	}
+
+	oidcController := NewOIDCController(cfg.OIDCService, cfg.Logger)
+	requireOIDC := middleware.RequireFeature(cfg.FeatureService, model.FeatureOIDC)
 
-	// Setup individual route groups
-	setupUserRoutes(protected, cfg)
-	setupContainerRoutes(protected, cfg)
-	setupImageRoutes(protected, cfg)
-	setupUpdateRoutes(protected, cfg)
-	setupSystemRoutes(protected, cfg)
-	setupRegistryRoutes(protected, cfg)
-	setupNotificationRoutes(protected, cfg)
-	setupWebSocketRoutes(api, cfg)
+	return []Route{
+		get("/auth/oidc/login", authPublic, requireOIDC, oidcController.Login),
+		get("/auth/oidc/callback", authPublic, requireOIDC, oidcController.Callback),
+	}
 }
 
-// setupUserRoutes configures user management routes
-func setupUserRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
+// apiTokenRoutes returns the routes managing the signed in user's personal
+// access tokens. Tokens cannot manage tokens.
+func apiTokenRoutes(cfg *RouterConfig) []Route {
+	if cfg.APITokenService == nil {
+		return nil
+	}
+
+	tokenController := NewAPITokenController(cfg.APITokenService, cfg.Logger)
+
+	return []Route{
+		get("/tokens", authSignedIn, tokenController.ListTokens),
+		post("/tokens", authSignedIn, tokenController.CreateToken),
+		del("/tokens/:id", authSignedIn, tokenController.RevokeToken),
+	}
+}
+
+// userRoutes returns the user management routes
+func userRoutes(cfg *RouterConfig) []Route {
 	userController := NewUserController(cfg.UserService, cfg.Logger)
 
-	users := api.Group("/users")
-	{
-		// User listing and creation (admin only)
-		users.GET("", middleware.RequireAdmin(), userController.ListUsers)
-		users.POST("", middleware.RequireAdmin(), userController.CreateUser)
+	// Users may read their own record
+	readUser := middleware.AuthRequirement{Modes: userAuthModes, Permission: middleware.PermissionUserRead, AllowSelf: true}
 
-		// Individual user operations
-		userRoutes := users.Group("/:id")
-		{
-			// Read operations (allow self-access)
-			userRoutes.GET("",
-				middleware.PermissionMiddlewareWithConfig(
-					middleware.PermissionUserRead,
-					&middleware.PermissionConfig{AllowSelf: true, LogViolations: true},
-				),
-				userController.GetUser)
-
-			// Admin-only operations
-			userRoutes.PUT("", middleware.RequireAdmin(), userController.UpdateUser)
-			userRoutes.DELETE("", middleware.RequireAdmin(), userController.DeleteUser)
-			userRoutes.PUT("/password", middleware.RequireAdmin(), userController.ChangeUserPassword)
-
-			// Session management
-			userRoutes.GET("/sessions", middleware.RequireAdmin(), userController.GetUserSessions)
-			userRoutes.DELETE("/sessions", middleware.RequireAdmin(), userController.RevokeAllUserSessions)
-			userRoutes.DELETE("/sessions/:sessionId", middleware.RequireAdmin(), userController.RevokeUserSession)
-		}
+	return []Route{
+		get("/users", authAdmin, userController.ListUsers),
+		post("/users", authAdmin, userController.CreateUser),
+
+		get("/users/:id", readUser, userController.GetUser),
+		put("/users/:id", authAdmin, userController.UpdateUser),
+		del("/users/:id", authAdmin, userController.DeleteUser),
+		put("/users/:id/password", authAdmin, userController.ChangeUserPassword),
+
+		// Session management
+		get("/admin/users/:id/sessions", authAdmin, userController.GetUserSessions),
+		del("/admin/users/:id/sessions", authAdmin, userController.RevokeAllUserSessions),
+		del("/admin/users/:id/sessions/:sessionId", authAdmin, userController.RevokeUserSession),
 	}
 }
 
-// setupContainerRoutes configures container management routes
-func setupContainerRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
-	containerController := NewContainerController(cfg.ContainerService, cfg.Logger)
+// containerRoutes returns the container management routes
+func containerRoutes(cfg *RouterConfig) []Route {
+	containerController := NewContainerController(cfg.ContainerService, cfg.UserService, cfg.Logger)
 
-	containers := api.Group("/containers")
-	{
+	return []Route{
 		// Container listing and creation
-		containers.GET("", middleware.RequireContainerRead(), containerController.ListContainers)
-		containers.POST("", middleware.RequireContainerWrite(), containerController.CreateContainer)
+		get("/containers", authContainerRead, containerController.ListContainers),
+		post("/containers", authContainerWrite, containerController.CreateContainer),
 
 		// Bulk operations
-		containers.POST("/bulk", middleware.RequireContainerManage(), containerController.BulkContainerOperation)
-		containers.POST("/sync", middleware.RequireOperator(), containerController.SyncContainerStatus)
+		post("/containers/bulk", authContainerManage.WithScope(model.TokenScopeContainersControl), containerController.BulkContainerOperation),
+		post("/containers/sync", authOperator.UsersOnly(), containerController.SyncContainerStatus),
+		post("/containers/discover", authOperator.UsersOnly(), containerController.DiscoverContainers),
+		post("/containers/check-updates", authContainerRead, containerController.CheckContainerUpdates),
+		post("/containers/labels/batch", authContainerManage, containerController.BatchContainerLabels),
+		post("/containers/diff-config", authContainerRead, containerController.DiffContainerConfig),
+		post("/containers/import/compose", authContainerWrite, containerController.ImportCompose),
+
+		// Server-Sent Events of container state changes
+		get("/events", authContainerRead, containerController.StreamContainerEvents),
+
+		// Read operations
+		get("/containers/:id", authContainerRead, containerController.GetContainer),
+		get("/containers/:id/status", authContainerRead, containerController.GetContainerStatus),
+		get("/containers/:id/logs", authContainerRead, containerController.GetContainerLogs),
+		get("/containers/:id/logs/stream", authContainerRead, containerController.StreamContainerLogs),
+		get("/containers/:id/logs/download", authContainerRead, containerController.DownloadContainerLogs),
+		get("/containers/:id/stats", authContainerRead, containerController.GetContainerStats),
+		get("/containers/:id/metrics", authContainerRead, containerController.GetContainerMetricsHistory),
+		get("/containers/:id/next-window", authContainerRead, containerController.GetNextUpdateWindow),
+		get("/containers/:id/drift", authContainerRead, containerController.GetContainerDrift),
+		get("/containers/:id/export", authContainerRead, containerController.ExportContainerConfig),
+		get("/containers/:id/healthchecks", authContainerRead, containerController.ListHealthChecks),
+		get("/containers/:id/env", authContainerRead, containerController.GetContainerEnv),
+		get("/containers/:id/labels", authContainerRead, containerController.GetContainerLabels),
+		get("/containers/:id/dependencies", authContainerRead, containerController.GetContainerDependencies),
+		get("/containers/:id/history", authContainerRead, containerController.GetContainerUpdateHistory),
+
+		// Write operations
+		put("/containers/:id", authContainerWrite, containerController.UpdateContainer),
+		patch("/containers/:id/env", authContainerWrite, containerController.PatchContainerEnv),
+		patch("/containers/:id/labels", authContainerWrite, containerController.PatchContainerLabels),
+		put("/containers/:id/dependencies", authContainerWrite, containerController.SetContainerDependencies),
+		del("/containers/:id", authContainerManage, containerController.DeleteContainer),
+		post("/containers/:id/healthchecks", authContainerWrite, containerController.CreateHealthCheck),
+		put("/containers/:id/healthchecks/:checkId", authContainerWrite, containerController.UpdateHealthCheck),
+		del("/containers/:id/healthchecks/:checkId", authContainerWrite, containerController.DeleteHealthCheck),
+
+		// Sharing
+		get("/containers/:id/permissions", authContainerWrite, containerController.ListContainerPermissions),
+		post("/containers/:id/permissions", authContainerWrite, containerController.GrantContainerPermission),
+		del("/containers/:id/permissions", authContainerWrite, containerController.RevokeContainerPermission),
+
+		// Container control operations
+		post("/containers/:id/start", authContainerControl, containerController.StartContainer),
+		post("/containers/:id/stop", authContainerControl, containerController.StopContainer),
+		post("/containers/:id/restart", authContainerControl, containerController.RestartContainer),
+		post("/containers/:id/update", authContainerUpdate, containerController.UpdateContainerImage),
+		post("/containers/:id/converge", authContainerUpdate, containerController.ConvergeContainer),
+		post("/containers/:id/redeploy", authContainerUpdate, containerController.RedeployContainer),
+		post("/containers/:id/ack-crashloop", authContainerUpdate, containerController.AcknowledgeCrashLoop),
+		post("/containers/:id/resume-updates", authContainerUpdate, containerController.ResumeContainerUpdates),
+
+		// Interactive terminal over WebSocket
+		get("/containers/:id/exec", authContainerExec, containerController.ExecContainer),
+	}
+}
 
-		// Individual container operations
-		containerRoutes := containers.Group("/:id")
-		{
-			// Read operations
-			containerRoutes.GET("", middleware.RequireContainerRead(), containerController.GetContainer)
-			containerRoutes.GET("/status", middleware.RequireContainerRead(), containerController.GetContainerStatus)
-			containerRoutes.GET("/logs", middleware.RequireContainerRead(), containerController.GetContainerLogs)
-			containerRoutes.GET("/stats", middleware.RequireContainerRead(), containerController.GetContainerStats)
-
-			// Write operations
-			containerRoutes.PUT("", middleware.RequireContainerWrite(), containerController.UpdateContainer)
-			containerRoutes.DELETE("", middleware.RequireContainerManage(), containerController.DeleteContainer)
-
-			// Container control operations
-			containerRoutes.POST("/start", middleware.RequireContainerManage(), containerController.StartContainer)
-			containerRoutes.POST("/stop", middleware.RequireContainerManage(), containerController.StopContainer)
-			containerRoutes.POST("/restart", middleware.RequireContainerManage(), containerController.RestartContainer)
-			containerRoutes.POST("/update", middleware.RequireContainerManage(), containerController.UpdateContainerImage)
-		}
+// stackRoutes returns the container stack routes
+func stackRoutes(cfg *RouterConfig) []Route {
+	if cfg.StackService == nil {
+		return nil
+	}
+
+	stackController := NewStackController(cfg.StackService, cfg.Logger)
+
+	return []Route{
+		get("/stacks", authContainerRead, stackController.ListStacks),
+		post("/stacks", authContainerWrite, stackController.CreateStack),
+
+		get("/stacks/:id", authContainerRead, stackController.GetStack),
+		put("/stacks/:id", authContainerWrite, stackController.UpdateStack),
+		del("/stacks/:id", authContainerManage, stackController.DeleteStack),
+
+		// Group operations
+		post("/stacks/:id/start", authContainerControl, stackController.StartStack),
+		post("/stacks/:id/stop", authContainerControl, stackController.StopStack),
+		post("/stacks/:id/restart", authContainerControl, stackController.RestartStack),
+		post("/stacks/:id/update", authContainerUpdate, stackController.UpdateStackImages),
 	}
 }
 
-// setupImageRoutes configures image management routes
-func setupImageRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
+// changeRoutes returns the container change feed routes
+func changeRoutes(cfg *RouterConfig) []Route {
+	if cfg.ChangeFeedService == nil {
+		return nil
+	}
+
+	changeController := NewChangeController(cfg.ChangeFeedService, cfg.Logger)
+
+	return []Route{
+		get("/changes", authContainerRead, changeController.ListChanges),
+	}
+}
+
+// imageRoutes returns the image management routes
+func imageRoutes(cfg *RouterConfig) []Route {
 	imageController := NewImageController(cfg.ImageService, cfg.Logger)
 
-	images := api.Group("/images")
-	{
+	routes := []Route{
 		// Image listing and search
-		images.GET("", middleware.RequireViewer(), imageController.ListImages)
-		images.GET("/search", middleware.RequireViewer(), imageController.SearchImages)
+		get("/images", authViewer, imageController.ListImages),
+		get("/images/search", authViewer, imageController.SearchImages),
+		get("/images/tags", authViewer, imageController.ListImageTags),
 
 		// Update checking operations
-		images.POST("/check-updates", middleware.RequireOperator(), imageController.CheckUpdates)
-		images.GET("/update-info", middleware.RequireViewer(), imageController.GetImageUpdateInfo)
-		images.POST("/schedule-check", middleware.RequireOperator(), imageController.ScheduleImageCheck)
+		post("/images/check-updates", authOperator, imageController.CheckUpdates),
+		get("/images/update-info", authViewer, imageController.GetImageUpdateInfo),
+		post("/images/schedule-check", authOperator, imageController.ScheduleImageCheck),
 
 		// Image comparison
-		images.POST("/compare", middleware.RequireViewer(), imageController.CompareImageVersions)
+		post("/images/compare", authViewer, imageController.CompareImageVersions),
 
-		// Individual image operations (using URL-encoded image names)
-		imageRoutes := images.Group("/:name")
-		{
-			// Read operations
-			imageRoutes.GET("/info", middleware.RequireViewer(), imageController.GetImageInfo)
-			imageRoutes.GET("/versions", middleware.RequireViewer(), imageController.GetImageVersions)
-			imageRoutes.GET("/security", middleware.RequireViewer(), imageController.GetImageSecurityIssues)
-
-			// Write operations (admin/operator only)
-			imageRoutes.POST("/pull", middleware.RequireOperator(), imageController.PullImage)
-			imageRoutes.POST("/refresh", middleware.RequireOperator(), imageController.RefreshImageCache)
-			imageRoutes.DELETE("", middleware.RequireOperator(), imageController.RemoveImage)
-		}
+		// Image version cache
+		get("/images/cache/stats", authViewer, imageController.GetImageCacheStats),
+		post("/images/cache/flush", authAdmin, imageController.FlushImageCache),
 	}
+
+	// Image-level update policy defaults, recorded against the signed in user
+	if cfg.ImagePolicyService != nil {
+		policyController := NewImagePolicyController(cfg.ImagePolicyService, cfg.Logger)
+
+		routes = append(routes,
+			get("/images/policies", authViewer, policyController.ListPolicies),
+			post("/images/policies", authOperator.UsersOnly(), policyController.CreatePolicy),
+			get("/images/policies/:id", authViewer, policyController.GetPolicy),
+			put("/images/policies/:id", authOperator.UsersOnly(), policyController.UpdatePolicy),
+			del("/images/policies/:id", authOperator.UsersOnly(), policyController.DeletePolicy),
+		)
+	}
+
+	// Bulk retargeting of containers between image tags acts on owned containers
+	if cfg.ImageRetargetService != nil {
+		retargetController := NewImageRetargetController(cfg.ImageRetargetService, cfg.Logger)
+
+		routes = append(routes,
+			post("/images/retarget", authOperator.UsersOnly(), retargetController.RetargetImages),
+			get("/images/retarget/:id", authViewer.UsersOnly(), retargetController.GetRetargetOperation),
+		)
+	}
+
+	// Individual image operations (using URL-encoded image names)
+	return append(routes,
+		get("/images/:name/info", authViewer, imageController.GetImageInfo),
+		get("/images/:name/versions", authViewer, imageController.GetImageVersions),
+		get("/images/:name/history", authViewer, middleware.RequireFeature(cfg.FeatureService, model.FeatureImageHistory), imageController.GetImageHistory),
+		get("/images/:name/security", authViewer, imageController.GetImageSecurityIssues),
+		get("/images/:name/scan", authViewer, middleware.RequireFeature(cfg.FeatureService, model.FeatureImageScanning), imageController.GetImageScan),
+
+		post("/images/:name/pull", authOperator, imageController.PullImage),
+		post("/images/:name/refresh", authOperator, imageController.RefreshImageCache),
+		del("/images/:name", authOperator, imageController.RemoveImage),
+	)
 }
 
-// setupUpdateRoutes configures update management routes
-func setupUpdateRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
+// updateRoutes returns the update management routes. Operations on
+// containers act on the signed in user's containers.
+func updateRoutes(cfg *RouterConfig) []Route {
 	updateController := NewUpdateController(cfg.ContainerService, cfg.ImageService, cfg.Logger)
 
-	updates := api.Group("/updates")
-	{
+	// Personal access tokens read and run updates with the container scopes
+	authUpdateRead := authViewer.WithScope(model.TokenScopeContainersRead)
+	authUpdateRun := authOperator.UsersOnly().WithScope(model.TokenScopeContainersUpdate)
+
+	routes := []Route{
 		// Update history and status
-		updates.GET("/history", middleware.RequireViewer(), updateController.GetUpdateHistory)
-		updates.GET("/status", middleware.RequireViewer(), updateController.GetUpdateStatus)
-		updates.GET("/metrics", middleware.RequireViewer(), updateController.GetUpdateMetrics)
-		updates.GET("/available", middleware.RequireViewer(), updateController.CheckAvailableUpdates)
+		get("/updates", authUpdateRead, updateController.ListUpdates),
+		get("/updates/history", authUpdateRead, updateController.ListUpdates),
+		get("/updates/summary", authUpdateRead, updateController.GetUpdateSummary),
+		get("/updates/status", authUpdateRead, updateController.GetUpdateStatus),
+		get("/updates/metrics", authViewer, updateController.GetUpdateMetrics),
+		get("/updates/available", authUpdateRead, updateController.CheckAvailableUpdates),
 
 		// Update operations
-		updates.POST("/batch", middleware.RequireOperator(), updateController.TriggerBatchUpdate)
-		updates.POST("/schedule", middleware.RequireOperator(), updateController.ScheduleUpdate)
+		post("/updates/batch", authUpdateRun, updateController.TriggerBatchUpdate),
+		post("/updates/schedule", authOperator.UsersOnly(), updateController.ScheduleUpdate),
 
 		// Individual update operations
-		updateRoutes := updates.Group("/:id")
-		{
-			updateRoutes.GET("", middleware.RequireViewer(), updateController.GetUpdateDetails)
-			updateRoutes.POST("/cancel", middleware.RequireOperator(), updateController.CancelUpdate)
-		}
+		get("/updates/:id", authViewer.UsersOnly().WithScope(model.TokenScopeContainersRead), updateController.GetUpdateDetails),
+		get("/updates/:id/progress", authViewer.UsersOnly().WithScope(model.TokenScopeContainersRead), updateController.GetUpdateProgress),
+		post("/updates/:id/cancel", authUpdateRun, updateController.CancelUpdate),
+		post("/updates/:id/notes", authOperator.UsersOnly(), updateController.AddUpdateNote),
+		put("/updates/:id/notes/:noteId", authOperator.UsersOnly(), updateController.EditUpdateNote),
 
 		// Rollback operations
-		updates.POST("/rollback/:id", middleware.RequireOperator(), updateController.RollbackUpdate)
+		post("/updates/rollback/:id", authUpdateRun, updateController.RollbackUpdate),
+	}
+
+	// Approval policies decide pending updates of every container
+	if cfg.ApprovalService != nil {
+		approvalController := NewApprovalPolicyController(cfg.ApprovalService, cfg.Logger)
+
+		routes = append(routes,
+			get("/updates/policies", authViewer, approvalController.ListApprovalPolicies),
+			post("/updates/policies", authAdmin, approvalController.CreateApprovalPolicy),
+			post("/updates/policies/evaluate", authOperator, approvalController.EvaluateApprovalPolicy),
+			put("/updates/policies/:id", authAdmin, approvalController.UpdateApprovalPolicy),
+			del("/updates/policies/:id", authAdmin, approvalController.DeleteApprovalPolicy),
+		)
+	}
+
+	return routes
+}
+
+// schedulerRoutes returns the scheduled task routes. Tasks are accessed as
+// the signed in user; personal access tokens may only trigger them.
+func schedulerRoutes(cfg *RouterConfig) []Route {
+	if cfg.SchedulerService == nil {
+		return nil
+	}
+
+	schedulerController := NewSchedulerController(cfg.SchedulerService)
+
+	authTaskRead := authViewer.UsersOnly()
+	authTaskWrite := authOperator.UsersOnly()
+
+	return []Route{
+		// Scheduler status and control
+		get("/scheduler/status", authTaskRead, schedulerController.GetSchedulerStatus),
+		post("/scheduler/start", authAdmin, schedulerController.StartScheduler),
+		post("/scheduler/stop", authAdmin, schedulerController.StopScheduler),
+
+		// Task management
+		get("/scheduler/tasks", authTaskRead, schedulerController.ListTasks),
+		post("/scheduler/tasks", authTaskWrite, schedulerController.CreateTask),
+		get("/scheduler/tasks/:id", authTaskRead, schedulerController.GetTask),
+		put("/scheduler/tasks/:id", authTaskWrite, schedulerController.UpdateTask),
+		del("/scheduler/tasks/:id", authTaskWrite, schedulerController.DeleteTask),
+
+		// Task control
+		post("/scheduler/tasks/:id/pause", authTaskWrite, schedulerController.PauseTask),
+		post("/scheduler/tasks/:id/resume", authTaskWrite, schedulerController.ResumeTask),
+		post("/scheduler/tasks/:id/trigger", authTaskWrite.WithScope(model.TokenScopeTasksTrigger), schedulerController.TriggerTask),
+		get("/scheduler/tasks/:id/executions", authTaskRead, schedulerController.GetTaskExecutions),
+		post("/scheduler/tasks/:id/executions/:execId/cancel", authTaskWrite, schedulerController.CancelExecution),
+
+		// Execution timeline, event log and reference data
+		get("/scheduler/timeline", authTaskRead, schedulerController.GetTimeline),
+		get("/scheduler/events", authTaskRead, schedulerController.GetSchedulerEvents),
+		get("/scheduler/task-types", authTaskRead, schedulerController.GetTaskTypes),
+		get("/scheduler/cron-expressions", authTaskRead, schedulerController.GetCronExpressions),
 	}
 }
 
-// setupSystemRoutes configures system management routes
-func setupSystemRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
-	systemController := NewSystemController(cfg.Logger)
+// systemRoutes returns the system management routes
+func systemRoutes(cfg *RouterConfig) []Route {
+	systemController := NewSystemController(systemInfoService(cfg), cfg.Logger)
+	dockerSessionController := NewDockerSessionController(cfg.DockerClient, cfg.Logger)
 
-	system := api.Group("/system")
-	{
-		// System information (viewer access)
-		system.GET("/info", middleware.RequireViewer(), systemController.GetSystemInfo)
-		system.GET("/health", systemController.HealthCheck) // No auth required for health
-		system.GET("/metrics", middleware.RequireViewer(), systemController.GetSystemMetrics)
+	return []Route{
+		// System information
+		get("/system/info", authViewer, systemController.GetSystemInfo),
+		get("/system/health", authPublic, systemController.HealthCheck),
+		get("/system/metrics", authViewer, systemController.GetSystemMetrics),
 
-		// System configuration (admin only)
-		system.GET("/config", middleware.RequireAdmin(), systemController.GetSystemConfig)
-		system.PUT("/config", middleware.RequireAdmin(), systemController.UpdateSystemConfig)
+		// System configuration
+		get("/system/config", authAdmin, systemController.GetSystemConfig),
+		put("/system/config", authAdmin, systemController.UpdateSystemConfig),
 
-		// System operations (admin only)
-		system.POST("/restart", middleware.RequireAdmin(), systemController.RestartService)
-		system.GET("/logs", middleware.RequireAdmin(), systemController.GetLogs)
+		// System operations
+		post("/system/restart", authAdmin, systemController.RestartService),
+		get("/system/logs", authAdmin, systemController.GetLogs),
+
+		// Open exec attachments and streams against the Docker daemon
+		get("/system/docker-sessions", authAdmin, dockerSessionController.ListDockerSessions),
+		del("/system/docker-sessions/:id", authAdmin, dockerSessionController.CloseDockerSession),
 	}
 }
 
-// setupRegistryRoutes configures registry management routes
-func setupRegistryRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
-	registryController := NewRegistryController(cfg.ImageService, cfg.Logger)
+// systemInfoService returns the configured system info service, or one
+// checking just Docker and the scheduler when none is configured
+func systemInfoService(cfg *RouterConfig) *service.SystemInfoService {
+	if cfg.SystemInfoService != nil {
+		return cfg.SystemInfoService
+	}
+	return service.NewSystemInfoService(nil, nil, cfg.SchedulerService, cfg.DockerClient)
+}
 
-	registries := api.Group("/registries")
-	{
-		// Registry listing and creation
-		registries.GET("", middleware.RequireViewer(), registryController.ListRegistries)
-		registries.POST("", middleware.RequireAdmin(), registryController.CreateRegistry)
+// systemBundleRoutes returns the system configuration export and import
+// routes
+func systemBundleRoutes(cfg *RouterConfig) []Route {
+	if cfg.SystemBundleService == nil {
+		return nil
+	}
 
-		// Individual registry operations
-		registryRoutes := registries.Group("/:id")
-		{
-			// Read operations
-			registryRoutes.GET("", middleware.RequireViewer(), registryController.GetRegistry)
-			registryRoutes.GET("/info", middleware.RequireViewer(), registryController.GetRegistryInfo)
-			registryRoutes.GET("/stats", middleware.RequireViewer(), registryController.GetRegistryStatistics)
-			registryRoutes.GET("/search", middleware.RequireViewer(), registryController.SearchRegistryImages)
-
-			// Write operations (admin only)
-			registryRoutes.PUT("", middleware.RequireAdmin(), registryController.UpdateRegistry)
-			registryRoutes.DELETE("", middleware.RequireAdmin(), registryController.DeleteRegistry)
-
-			// Registry operations
-			registryRoutes.POST("/test", middleware.RequireOperator(), registryController.TestRegistryConnection)
-			registryRoutes.POST("/default", middleware.RequireAdmin(), registryController.SetDefaultRegistry)
-		}
+	bundleController := NewSystemBundleController(cfg.SystemBundleService, cfg.Logger)
+
+	return []Route{
+		get("/system/export", authAdmin, bundleController.ExportSystem),
+		post("/system/import", authAdmin, bundleController.ImportSystem),
 	}
 }
 
-// Additional helper functions for advanced routing features
+// selfUpdateRoutes returns the route updating docker-auto's own container
+func selfUpdateRoutes(cfg *RouterConfig) []Route {
+	if cfg.ContainerService == nil {
+		return nil
+	}
 
-// SetupWebhookRoutes configures webhook endpoints (if needed)
-func SetupWebhookRoutes(router *gin.Engine, cfg *RouterConfig) {
-	webhooks := router.Group("/webhooks")
-	{
-		// Registry webhooks for push notifications
-		webhooks.POST("/registry/:registryId", func(c *gin.Context) {
-			// Handle registry push webhooks
-			c.JSON(200, gin.H{"status": "received"})
-		})
+	containerController := NewContainerController(cfg.ContainerService, cfg.UserService, cfg.Logger)
 
-		// Docker Hub webhook
-		webhooks.POST("/dockerhub", func(c *gin.Context) {
-			// Handle Docker Hub push notifications
-			c.JSON(200, gin.H{"status": "received"})
-		})
+	return []Route{
+		post("/system/self-update", authAdmin, containerController.SelfUpdate),
 	}
 }
 
-// SetupSwaggerRoutes configures Swagger documentation routes
-func SetupSwaggerRoutes(router *gin.Engine, cfg *RouterConfig) {
+// backupRoutes returns the backup listing and restore routes
+func backupRoutes(cfg *RouterConfig) []Route {
+	if cfg.BackupService == nil {
+		return nil
+	}
+
+	backupController := NewBackupController(cfg.BackupService, cfg.Logger)
+
+	return []Route{
+		get("/backups", authAdmin, backupController.ListBackups),
+		post("/backups/:id/restore", authAdmin, backupController.RestoreBackup),
+	}
+}
+
+// rateLimitRoutes returns the rate limit administration routes
+func rateLimitRoutes(cfg *RouterConfig) []Route {
+	if cfg.RateLimitService == nil {
+		return nil
+	}
+
+	rateLimitController := NewRateLimitController(cfg.RateLimitService, cfg.Logger)
+
+	return []Route{
+		get("/admin/ratelimits", authAdmin, rateLimitController.GetRateLimits),
+		put("/admin/ratelimits/endpoints", authAdmin, rateLimitController.SetEndpointRateLimit),
+		del("/admin/ratelimits/endpoints", authAdmin, rateLimitController.RemoveEndpointRateLimit),
+		post("/admin/ratelimits/lists/:list", authAdmin, rateLimitController.AddRateLimitListEntry),
+		del("/admin/ratelimits/lists/:list", authAdmin, rateLimitController.RemoveRateLimitListEntry),
+		get("/admin/ratelimits/bans", authAdmin, rateLimitController.ListRateLimitBans),
+		del("/admin/ratelimits/bans", authAdmin, rateLimitController.LiftRateLimitBan),
+	}
+}
+
+// configRoutes returns the configuration administration routes
+func configRoutes(cfg *RouterConfig) []Route {
+	if cfg.ConfigService == nil {
+		return nil
+	}
+
+	configController := NewConfigController(cfg.ConfigService, cfg.Logger)
+
+	return []Route{
+		get("/admin/config", authAdmin, configController.GetConfig),
+		patch("/admin/config", authAdmin, configController.PatchConfig),
+	}
+}
+
+// registryRoutes returns the registry credential management routes
+func registryRoutes(cfg *RouterConfig) []Route {
+	if cfg.RegistryService == nil {
+		return nil
+	}
+
+	registryController := NewRegistryController(cfg.RegistryService, cfg.ImageService, cfg.Logger)
+
+	return []Route{
+		// Registry listing and creation
+		get("/registries", authViewer, registryController.ListRegistries),
+		post("/registries", authAdmin, registryController.CreateRegistry),
+
+		// Read operations
+		get("/registries/:id", authViewer, registryController.GetRegistry),
+		get("/registries/:id/info", authViewer, registryController.GetRegistryInfo),
+		get("/registries/:id/stats", authViewer, registryController.GetRegistryStatistics),
+		get("/registries/:id/search", authViewer, registryController.SearchRegistryImages),
+
+		// Write operations
+		put("/registries/:id", authAdmin, registryController.UpdateRegistry),
+		del("/registries/:id", authAdmin, registryController.DeleteRegistry),
+
+		// Registry operations
+		post("/registries/:id/test", authOperator, registryController.TestRegistryConnection),
+		post("/registries/:id/default", authAdmin, registryController.SetDefaultRegistry),
+	}
+}
+
+// Additional helper functions for advanced routing features
+
+// SetupWebhookRoutes configures the registry push webhooks, which
+// authenticate with the registry webhook token rather than a user
+func SetupWebhookRoutes(router *gin.Engine, table *RouteTable, cfg *RouterConfig) {
+	webhookController := NewRegistryWebhookController(cfg.ImageService, cfg.Config.ImageCheck.RegistryWebhookToken, cfg.Logger)
+
+	table.Register(router.Group("/webhooks"),
+		post("/registry/:registryId", authPublic, webhookController.RegistryPush),
+		post("/dockerhub", authPublic, webhookController.DockerHubPush),
+	)
+}
+
+// SetupSwaggerRoutes configures Swagger documentation routes. The security of
+// each operation is served from the route table, so it matches what is enforced.
+func SetupSwaggerRoutes(router *gin.Engine, table *RouteTable, cfg *RouterConfig) {
 	// Only enable in development environment
 	if cfg.Config.Environment == "development" {
 		// Swagger documentation would be served here
@@ -351,12 +743,16 @@ func SetupSwaggerRoutes(router *gin.Engine, cfg *RouterConfig) {
 			c.JSON(200, gin.H{
 				"message": "API documentation not yet implemented",
 				"api_info": gin.H{
-					"name":    "Docker Auto Update System API",
-					"version": "v1",
+					"name":      "Docker Auto Update System API",
+					"version":   "v1",
 					"base_path": "/api",
 				},
 			})
 		})
+
+		router.GET("/docs/security", func(c *gin.Context) {
+			c.JSON(200, gin.H{"paths": table.OpenAPISecurity()})
+		})
 	}
 }
 
@@ -389,92 +785,290 @@ func SetupCompleteRouter(cfg *RouterConfig) *gin.Engine {
 	router := gin.New()
 
 	// Setup main routes
-	SetupRoutes(router, cfg)
+	table := SetupRoutes(router, cfg)
 
 	// Setup additional routes based on configuration
 	if cfg.Config.Environment == "development" {
-		SetupSwaggerRoutes(router, cfg)
+		SetupSwaggerRoutes(router, table, cfg)
 		SetupDevRoutes(router, cfg)
 	}
 
-	// Setup webhook routes if webhooks are enabled
-	// SetupWebhookRoutes(router, cfg)
+	// Setup webhook routes if a registry webhook token is configured
+	if cfg.Config.ImageCheck.RegistryWebhookToken != "" {
+		SetupWebhookRoutes(router, table, cfg)
+	}
+
+	// Refuse to start with a route nobody declared auth for
+	if err := table.AssertAuth(router); err != nil {
+		panic(err)
+	}
 
 	cfg.Logger.Info("Router setup completed with all endpoints configured")
 
 	return router
 }
 
-// setupNotificationRoutes configures notification management routes
-func setupNotificationRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
+// notificationRoutes returns the notification routes. Notifications belong
+// to the signed in user.
+func notificationRoutes(cfg *RouterConfig) []Route {
 	notificationController := NewNotificationController(cfg.NotificationService, cfg.Logger)
 
-	notifications := api.Group("/notifications")
-	{
+	authRecipient := authViewer.UsersOnly()
+
+	routes := []Route{
 		// Notification listing and statistics
-		notifications.GET("", middleware.RequireViewer(), notificationController.GetNotifications)
-		notifications.GET("/unread", middleware.RequireViewer(), notificationController.GetUnreadNotifications)
-		notifications.GET("/count", middleware.RequireViewer(), notificationController.GetNotificationCount)
-		notifications.GET("/stats", middleware.RequireViewer(), notificationController.GetNotificationStats)
+		get("/notifications", authRecipient, notificationController.GetNotifications),
+		get("/notifications/unread", authRecipient, notificationController.GetUnreadNotifications),
+		get("/notifications/count", authRecipient, notificationController.GetNotificationCount),
+		get("/notifications/stats", authRecipient, notificationController.GetNotificationStats),
+		get("/notifications/schemas", authViewer, notificationController.GetNotificationSchemas),
 
 		// Notification management
-		notifications.POST("/mark-read", middleware.RequireViewer(), notificationController.MarkAsRead)
-		notifications.POST("/mark-all-read", middleware.RequireViewer(), notificationController.MarkAllAsRead)
+		post("/notifications/mark-read", authRecipient, notificationController.MarkAsRead),
+		post("/notifications/mark-all-read", authRecipient, notificationController.MarkAllAsRead),
+		post("/notifications/acknowledge", authRecipient, notificationController.AcknowledgeNotifications),
 
 		// Individual notification operations
-		notificationRoutes := notifications.Group("/:id")
-		{
-			notificationRoutes.GET("", middleware.RequireViewer(), notificationController.GetNotification)
-			notificationRoutes.PUT("/read", middleware.RequireViewer(), notificationController.MarkNotificationAsRead)
-			notificationRoutes.DELETE("", middleware.RequireViewer(), notificationController.DeleteNotification)
-		}
+		get("/notifications/:id", authRecipient, notificationController.GetNotification),
+		put("/notifications/:id/read", authRecipient, notificationController.MarkNotificationAsRead),
+		del("/notifications/:id", authRecipient, notificationController.DeleteNotification),
 
 		// Admin-only operations
-		adminNotifications := notifications.Group("/admin")
-		adminNotifications.Use(middleware.RequireAdmin())
-		{
-			adminNotifications.POST("/broadcast", notificationController.BroadcastNotification)
-			adminNotifications.GET("/templates", notificationController.GetNotificationTemplates)
-			adminNotifications.POST("/templates", notificationController.CreateNotificationTemplate)
-			adminNotifications.DELETE("/cleanup", notificationController.CleanupOldNotifications)
-		}
+		post("/notifications/admin/broadcast", authAdmin, notificationController.BroadcastNotification),
+		get("/notifications/admin/templates", authAdmin, notificationController.GetNotificationTemplates),
+		post("/notifications/admin/templates", authAdmin, notificationController.CreateNotificationTemplate),
+		del("/notifications/admin/cleanup", authAdmin, notificationController.CleanupOldNotifications),
+	}
+
+	// Delivery channels belong to the signed in user; admins also manage the
+	// global ones
+	if cfg.ChannelService != nil {
+		channelController := NewNotificationChannelController(cfg.ChannelService, cfg.Logger)
+
+		routes = append(routes,
+			get("/notifications/channels", authRecipient, channelController.ListChannels),
+			post("/notifications/channels", authRecipient, channelController.CreateChannel),
+			get("/notifications/channels/:id", authRecipient, channelController.GetChannel),
+			put("/notifications/channels/:id", authRecipient, channelController.UpdateChannel),
+			del("/notifications/channels/:id", authRecipient, channelController.DeleteChannel),
+			post("/notifications/channels/:id/test", authRecipient, channelController.TestChannel),
+
+			// Update digest preferences
+			get("/notifications/preferences", authRecipient, channelController.GetPreferences),
+			put("/notifications/preferences", authRecipient, channelController.UpdatePreferences),
+		)
+	}
+
+	return routes
+}
+
+// dashboardRoutes returns the landing page summary route
+func dashboardRoutes(cfg *RouterConfig) []Route {
+	if cfg.DashboardService == nil {
+		return nil
+	}
+
+	dashboardController := NewDashboardController(cfg.DashboardService, cfg.Logger)
+
+	return []Route{
+		get("/dashboard", authViewer, dashboardController.GetDashboard),
+	}
+}
+
+// volumeRoutes returns the volume usage routes
+func volumeRoutes(cfg *RouterConfig) []Route {
+	if cfg.VolumeService == nil {
+		return nil
+	}
+
+	volumeController := NewVolumeController(cfg.VolumeService, cfg.Logger)
+
+	return []Route{
+		get("/volumes", authViewer, volumeController.ListVolumes),
+		post("/volumes/sample", authOperator, volumeController.SampleVolumes),
+	}
+}
+
+// teamRoutes returns the team and team quota routes; changes are admin-only
+func teamRoutes(cfg *RouterConfig) []Route {
+	if cfg.TeamService == nil {
+		return nil
+	}
+
+	teamController := NewTeamController(cfg.TeamService, cfg.Logger)
+
+	return []Route{
+		get("/teams", authViewer, teamController.ListTeams),
+		post("/teams", authAdmin, teamController.CreateTeam),
+		put("/teams/:id/members", authAdmin, teamController.SetTeamMembers),
+		get("/teams/:id/quota", authViewer, teamController.GetTeamQuota),
+		put("/teams/:id/quota", authAdmin, teamController.SetTeamQuota),
+	}
+}
+
+// securityPostureRoutes returns the admin-only security posture routes
+func securityPostureRoutes(cfg *RouterConfig) []Route {
+	if cfg.PostureService == nil {
+		return nil
+	}
+
+	postureController := NewSecurityPostureController(cfg.PostureService, cfg.Logger)
+
+	return []Route{
+		get("/security/posture", authAdmin, postureController.GetLatestPosture),
+		get("/security/posture/compare", authAdmin, postureController.ComparePosture),
+		post("/security/posture/evaluate", authAdmin.UsersOnly(), postureController.EvaluatePosture),
 	}
 }
 
-// setupWebSocketRoutes configures WebSocket routes
-func setupWebSocketRoutes(api *gin.RouterGroup, cfg *RouterConfig) {
-	// WebSocket endpoint for real-time communication
-	// Note: WebSocket upgrade happens without standard JWT middleware
-	// Authentication is handled within the WebSocket handler via query parameter
-	api.GET("/ws", cfg.WebSocketManager.HandleWebSocket)
+// gitopsRoutes returns the admin-only GitOps reconcile routes
+func gitopsRoutes(cfg *RouterConfig) []Route {
+	if cfg.GitOpsService == nil {
+		return nil
+	}
+
+	gitopsController := NewGitOpsController(cfg.GitOpsService, cfg.Logger)
+
+	return []Route{
+		get("/gitops/runs", authAdmin, gitopsController.ListRuns),
+		get("/gitops/runs/:id", authAdmin, gitopsController.GetRun),
+		post("/gitops/reconcile", authAdmin.UsersOnly(), gitopsController.Reconcile),
+	}
+}
+
+// reportRoutes returns the compliance report export routes. Exports are
+// recorded against the requesting user.
+func reportRoutes(cfg *RouterConfig) []Route {
+	if cfg.ReportService == nil {
+		return nil
+	}
+
+	reportController := NewReportController(cfg.ReportService, cfg.Logger)
+
+	return []Route{
+		get("/reports/updates", authViewer.UsersOnly(), reportController.ExportUpdates),
+		get("/reports/task-executions", authViewer.UsersOnly(), reportController.ExportTaskExecutions),
+	}
+}
+
+// roleRoutes returns the routes managing custom roles
+func roleRoutes(cfg *RouterConfig) []Route {
+	if cfg.RoleService == nil {
+		return nil
+	}
+
+	roleController := NewRoleController(cfg.RoleService, cfg.Logger)
+
+	return []Route{
+		get("/roles", authAdmin, roleController.ListRoles),
+		post("/roles", authAdmin, roleController.CreateRole),
+		get("/roles/permissions", authAdmin, roleController.GetPermissionMatrix),
+		get("/roles/:id", authAdmin, roleController.GetRole),
+		put("/roles/:id", authAdmin, roleController.UpdateRole),
+		del("/roles/:id", authAdmin, roleController.DeleteRole),
+	}
+}
+
+// templateRoutes returns the container template routes. Templates are
+// private to their owner or shared; instantiating one creates a container.
+func templateRoutes(cfg *RouterConfig) []Route {
+	if cfg.TemplateService == nil {
+		return nil
+	}
+
+	templateController := NewContainerTemplateController(cfg.TemplateService, cfg.Logger)
 
-	// WebSocket management endpoints (authenticated)
-	wsManagement := api.Group("/ws")
-	wsManagement.Use(middleware.JWTAuthMiddleware(cfg.Config.JWT.Secret))
-	wsManagement.Use(middleware.RequireActiveUser())
-	{
-		wsManagement.GET("/stats", middleware.RequireAdmin(), func(c *gin.Context) {
+	return []Route{
+		get("/templates", authContainerRead, templateController.ListTemplates),
+		post("/templates", authContainerWrite, templateController.CreateTemplate),
+		get("/templates/:id", authContainerRead, templateController.GetTemplate),
+		put("/templates/:id", authContainerWrite, templateController.UpdateTemplate),
+		del("/templates/:id", authContainerWrite, templateController.DeleteTemplate),
+		post("/templates/:id/instantiate", authContainerWrite, templateController.InstantiateTemplate),
+	}
+}
+
+// dockerHostRoutes returns the routes managing remote Docker hosts
+func dockerHostRoutes(cfg *RouterConfig) []Route {
+	if cfg.DockerHostService == nil {
+		return nil
+	}
+
+	hostController := NewDockerHostController(cfg.DockerHostService, cfg.Logger)
+
+	return []Route{
+		get("/hosts", authAdmin, hostController.ListHosts),
+		post("/hosts", authAdmin, hostController.CreateHost),
+		get("/hosts/:id", authAdmin, hostController.GetHost),
+		put("/hosts/:id", authAdmin, hostController.UpdateHost),
+		del("/hosts/:id", authAdmin, hostController.DeleteHost),
+		post("/hosts/:id/ping", authAdmin, hostController.PingHost),
+	}
+}
+
+// activityRoutes returns the activity log routes. Non-admins only read their
+// own entries.
+func activityRoutes(cfg *RouterConfig) []Route {
+	if cfg.ActivityService == nil {
+		return nil
+	}
+
+	activityController := NewActivityController(cfg.ActivityService, cfg.Logger)
+
+	return []Route{
+		get("/activity", authViewer.UsersOnly(), activityController.ListActivity),
+		get("/activity/export", authViewer.UsersOnly(), activityController.ExportActivity),
+	}
+}
+
+// statusPageRoutes returns the routes configuring public status pages
+func statusPageRoutes(cfg *RouterConfig) []Route {
+	if cfg.StatusPageService == nil {
+		return nil
+	}
+
+	statusPageController := NewStatusPageController(cfg.StatusPageService, cfg.Logger)
+
+	return []Route{
+		get("/status-pages", authAdmin, statusPageController.ListStatusPages),
+		post("/status-pages", authAdmin, statusPageController.CreateStatusPage),
+		get("/status-pages/:id", authAdmin, statusPageController.GetStatusPage),
+		put("/status-pages/:id", authAdmin, statusPageController.UpdateStatusPage),
+		del("/status-pages/:id", authAdmin, statusPageController.DeleteStatusPage),
+		post("/status-pages/:id/rotate-token", authAdmin, statusPageController.RotateStatusPageToken),
+	}
+}
+
+// webSocketRoutes returns the WebSocket routes
+func webSocketRoutes(cfg *RouterConfig) []Route {
+	return []Route{
+		// The upgrade is authenticated by the WebSocket handler via query
+		// parameter, as browsers cannot set headers on it
+		get("/ws", authPublic, cfg.WebSocketManager.HandleWebSocket),
+
+		// WebSocket management endpoints
+		get("/ws/stats", authAdmin, func(c *gin.Context) {
 			stats := cfg.WebSocketManager.GetStats()
 			c.JSON(200, gin.H{"data": stats})
-		})
+		}),
 
-		wsManagement.GET("/connections", middleware.RequireAdmin(), func(c *gin.Context) {
+		get("/ws/connections", authAdmin, func(c *gin.Context) {
 			connections := cfg.WebSocketManager.GetConnections()
 			connectionData := make([]gin.H, len(connections))
 			for i, conn := range connections {
 				connectionData[i] = gin.H{
-					"id":         conn.ID,
-					"user_id":    conn.UserID,
-					"last_ping":  conn.LastPing,
-					"connected":  !conn.IsClosed(),
+					"id":        conn.ID,
+					"user_id":   conn.UserID,
+					"last_ping": conn.LastPing,
+					"connected": !conn.IsClosed(),
 				}
 			}
 			c.JSON(200, gin.H{"data": connectionData})
-		})
+		}),
 
-		wsManagement.POST("/cleanup", middleware.RequireAdmin(), func(c *gin.Context) {
+		post("/ws/cleanup", authAdmin, func(c *gin.Context) {
 			cfg.WebSocketManager.CleanupInactiveConnections()
 			c.JSON(200, gin.H{"message": "Cleanup completed"})
-		})
+		}),
 	}
-}
\ No newline at end of file
+}
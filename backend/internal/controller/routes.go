@@ -0,0 +1,237 @@
+package controller
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	// userAuthModes authenticate a signed in user
+	userAuthModes = []middleware.AuthMode{middleware.AuthJWT, middleware.AuthCookie}
+	// tokenAuthModes also admit API tokens, acting with API_KEY_ROLE
+	tokenAuthModes = []middleware.AuthMode{middleware.AuthJWT, middleware.AuthCookie, middleware.AuthAPIToken}
+)
+
+// Auth requirements used by the route tables. They require permissions
+// rather than roles, so custom roles can be granted them; the viewer,
+// operator and admin requirements match what those built-in roles grant.
+// Container permissions are checked against the owning user, so container
+// routes admit users and the personal access tokens acting for them only.
+var (
+	authPublic   = middleware.AuthRequirement{Modes: []middleware.AuthMode{middleware.AuthNone}}
+	authSignedIn = middleware.AuthRequirement{Modes: userAuthModes}
+	authViewer   = middleware.AuthRequirement{Modes: tokenAuthModes, Permission: middleware.PermissionRead}
+	authOperator = middleware.AuthRequirement{Modes: tokenAuthModes, Permission: middleware.PermissionWrite}
+	authAdmin    = middleware.AuthRequirement{Modes: userAuthModes, Permission: middleware.PermissionAdmin}
+
+	authContainerRead   = middleware.AuthRequirement{Modes: userAuthModes, Permission: middleware.PermissionContainerRead}.WithScope(model.TokenScopeContainersRead)
+	authContainerWrite  = middleware.AuthRequirement{Modes: userAuthModes, Permission: middleware.PermissionContainerWrite}
+	authContainerManage = middleware.AuthRequirement{Modes: userAuthModes, Permission: middleware.PermissionContainerManage}
+
+	// Managing routes open to personal access tokens with the matching scope
+	authContainerControl = authContainerManage.WithScope(model.TokenScopeContainersControl)
+	authContainerUpdate  = middleware.AuthRequirement{Modes: userAuthModes, Permission: middleware.PermissionContainerUpdate}.WithScope(model.TokenScopeContainersUpdate)
+	authContainerExec    = middleware.AuthRequirement{Modes: userAuthModes, Permission: middleware.PermissionContainerExec}.WithScope(model.TokenScopeContainersControl)
+)
+
+// publicRoutes lists the routes that may be served without authentication.
+// Any other route without a declared requirement fails startup.
+var publicRoutes = map[string]bool{
+	"GET /health":                         true,
+	"GET /api":                            true,
+	"GET /api/health":                     true,
+	"GET /api/setup/status":               true,
+	"POST /api/setup":                     true,
+	"GET /api/features":                   true,
+	"POST /api/auth/login":                true,
+	"POST /api/auth/refresh":              true,
+	"GET /api/auth/oidc/login":            true,
+	"GET /api/auth/oidc/callback":         true,
+	"GET /api/system/health":              true,
+	"GET /api/ws":                         true, // authenticates the upgrade itself
+	"GET /status/:token":                  true, // the token is the credential
+	"GET /docs":                           true,
+	"GET /docs/security":                  true,
+	"GET /dev/ping":                       true,
+	"POST /dev/echo":                      true,
+	"POST /webhooks/dockerhub":            true,
+	"POST /webhooks/registry/:registryId": true,
+}
+
+// Route is an API endpoint with the authentication it requires
+type Route struct {
+	Method   string
+	Path     string
+	Auth     middleware.AuthRequirement
+	Handlers []gin.HandlerFunc
+}
+
+func route(method, path string, auth middleware.AuthRequirement, handlers ...gin.HandlerFunc) Route {
+	return Route{Method: method, Path: path, Auth: auth, Handlers: handlers}
+}
+
+func get(path string, auth middleware.AuthRequirement, handlers ...gin.HandlerFunc) Route {
+	return route(http.MethodGet, path, auth, handlers...)
+}
+
+func post(path string, auth middleware.AuthRequirement, handlers ...gin.HandlerFunc) Route {
+	return route(http.MethodPost, path, auth, handlers...)
+}
+
+func put(path string, auth middleware.AuthRequirement, handlers ...gin.HandlerFunc) Route {
+	return route(http.MethodPut, path, auth, handlers...)
+}
+
+func patch(path string, auth middleware.AuthRequirement, handlers ...gin.HandlerFunc) Route {
+	return route(http.MethodPatch, path, auth, handlers...)
+}
+
+func del(path string, auth middleware.AuthRequirement, handlers ...gin.HandlerFunc) Route {
+	return route(http.MethodDelete, path, auth, handlers...)
+}
+
+// RouteTable registers routes behind the auth chain and remembers what each
+// one requires
+type RouteTable struct {
+	chain  *middleware.AuthChain
+	routes []Route
+	// public extends publicRoutes with routes at configurable paths
+	public map[string]bool
+}
+
+// newRouteTable creates a route table enforcing auth with the configured
+// JWT secret and API keys, personal access tokens resolved by tokens, custom
+// roles resolved by roles and login sessions checked by sessions
+func newRouteTable(cfg *config.Config, tokens middleware.TokenResolver, roles middleware.RoleResolver, sessions middleware.SessionResolver) *RouteTable {
+	return &RouteTable{
+		chain: middleware.NewAuthChain(middleware.AuthChainConfig{
+			JWTSecret:  cfg.JWT.Secret,
+			APIKeys:    cfg.GetAPIKeys(),
+			APIKeyRole: model.UserRole(cfg.Security.APIKeyRole),
+			Tokens:     tokens,
+			Roles:      roles,
+			Sessions:   sessions,
+		}),
+	}
+}
+
+// Register adds routes to group, each preceded by the middleware enforcing its
+// requirement. An invalid requirement is a programming error and panics, as
+// gin does for conflicting routes.
+func (t *RouteTable) Register(group *gin.RouterGroup, routes ...Route) {
+	for _, r := range routes {
+		if err := r.Auth.Validate(); err != nil {
+			panic(fmt.Sprintf("route %s %s: %v", r.Method, group.BasePath()+r.Path, err))
+		}
+
+		handlers := append(t.chain.Handlers(r.Auth), r.Handlers...)
+		group.Handle(r.Method, r.Path, handlers...)
+
+		r.Path = strings.TrimSuffix(group.BasePath(), "/") + r.Path
+		t.routes = append(t.routes, r)
+	}
+}
+
+// AllowPublic admits a route at a configurable path, which publicRoutes
+// can't list, to be served without authentication
+func (t *RouteTable) AllowPublic(method, path string) {
+	if t.public == nil {
+		t.public = make(map[string]bool)
+	}
+	t.public[method+" "+path] = true
+}
+
+// Routes returns the registered routes
+func (t *RouteTable) Routes() []Route {
+	return t.routes
+}
+
+// AssertAuth checks that every route served by engine declares an auth
+// requirement, unless it is listed in publicRoutes or allowed by AllowPublic
+func (t *RouteTable) AssertAuth(engine *gin.Engine) error {
+	declared := make(map[string]middleware.AuthRequirement, len(t.routes))
+	for _, r := range t.routes {
+		declared[r.Method+" "+r.Path] = r.Auth
+	}
+
+	var missing []string
+	for _, info := range engine.Routes() {
+		key := info.Method + " " + info.Path
+		if auth, ok := declared[key]; ok && !auth.Public() {
+			continue
+		}
+		if !publicRoutes[key] && !t.public[key] {
+			missing = append(missing, key)
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return fmt.Errorf("routes without an auth requirement: %s", strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// OpenAPI security scheme names, matching the securityDefinitions of the API docs
+var openAPISchemes = map[middleware.AuthMode]string{
+	middleware.AuthJWT:           "BearerAuth",
+	middleware.AuthAPIToken:      "ApiKeyAuth",
+	middleware.AuthCookie:        "CookieAuth",
+	middleware.AuthPersonalToken: "PersonalTokenAuth",
+}
+
+// RouteSecurity is the documented security of an operation
+type RouteSecurity struct {
+	Security   []map[string][]string `json:"security"`
+	MinRole    model.UserRole        `json:"x-min-role,omitempty"`
+	Permission string                `json:"x-permission,omitempty"`
+}
+
+// OpenAPISecurity returns the security of every registered operation by
+// OpenAPI path and method, so the docs describe what is enforced. Public
+// operations have an empty security list.
+func (t *RouteTable) OpenAPISecurity() map[string]map[string]RouteSecurity {
+	paths := make(map[string]map[string]RouteSecurity)
+	for _, r := range t.routes {
+		security := RouteSecurity{
+			Security:   []map[string][]string{},
+			MinRole:    r.Auth.MinRole,
+			Permission: string(r.Auth.Permission),
+		}
+		if !r.Auth.Public() {
+			for _, mode := range r.Auth.Modes {
+				scopes := []string{}
+				if mode == middleware.AuthPersonalToken {
+					scopes = append(scopes, string(r.Auth.Scope))
+				}
+				security.Security = append(security.Security, map[string][]string{openAPISchemes[mode]: scopes})
+			}
+		}
+
+		path := openAPIPath(r.Path)
+		if paths[path] == nil {
+			paths[path] = make(map[string]RouteSecurity)
+		}
+		paths[path][strings.ToLower(r.Method)] = security
+	}
+	return paths
+}
+
+// openAPIPath converts gin path parameters to OpenAPI ones, e.g. /:id to /{id}
+func openAPIPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			segments[i] = "{" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
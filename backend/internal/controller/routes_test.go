@@ -0,0 +1,148 @@
+package controller
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestRouterConfig enables every optional route group that needs no
+// service
+func newTestRouterConfig() *RouterConfig {
+	cfg := &config.Config{Environment: "development"}
+	cfg.JWT.Secret = "test-secret"
+	cfg.Monitoring.PrometheusEnabled = true
+	cfg.ImageCheck.RegistryWebhookToken = "webhook-token"
+
+	logger := logrus.New()
+	logger.SetOutput(io.Discard)
+	return &RouterConfig{Config: cfg, Logger: logger}
+}
+
+// newTestRouter runs the steps of SetupCompleteRouter, keeping the table
+func newTestRouter(t *testing.T, cfg *RouterConfig) (*gin.Engine, *RouteTable) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	table := SetupRoutes(router, cfg)
+	SetupSwaggerRoutes(router, table, cfg)
+	SetupDevRoutes(router, cfg)
+	SetupWebhookRoutes(router, table, cfg)
+	return router, table
+}
+
+func TestSetupCompleteRouterDeclaresAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// SetupCompleteRouter panics if a route lacks an auth requirement
+	router := SetupCompleteRouter(newTestRouterConfig())
+
+	// A protected route refuses anonymous requests before its handler runs
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/containers/1/status", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("GET /api/containers/1/status = %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+}
+
+func TestRouterAllowsOnlyConfiguredCORSOrigins(t *testing.T) {
+	cfg := newTestRouterConfig()
+	cfg.Config.Security.CORSAllowedOrigins = "https://ops.example.com"
+	router, _ := newTestRouter(t, cfg)
+
+	tests := []struct {
+		origin string
+		want   string
+	}{
+		{"https://ops.example.com", "https://ops.example.com"},
+		{"https://evil.example.net", ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(http.MethodOptions, "/api/containers", nil)
+		req.Header.Set("Origin", tt.origin)
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+			t.Errorf("preflight from %s allowed origin %q, want %q", tt.origin, got, tt.want)
+		}
+	}
+}
+
+func TestPublicRoutesAreServed(t *testing.T) {
+	// Route groups registered only with their service configured; the
+	// services are not called while routes are registered
+	cfg := newTestRouterConfig()
+	cfg.OIDCService = &service.OIDCService{}
+	cfg.SetupService = &service.SetupService{}
+	cfg.StatusPageService = &service.StatusPageService{}
+
+	router, table := newTestRouter(t, cfg)
+	if err := table.AssertAuth(router); err != nil {
+		t.Fatalf("AssertAuth failed: %v", err)
+	}
+
+	// The allowlist holds only routes the router serves
+	served := make(map[string]bool)
+	for _, info := range router.Routes() {
+		served[info.Method+" "+info.Path] = true
+	}
+	for key := range publicRoutes {
+		if !served[key] {
+			t.Errorf("public route %s is not served", key)
+		}
+	}
+}
+
+func TestAssertAuthRejectsUndeclaredRoutes(t *testing.T) {
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+
+	tests := []struct {
+		name     string
+		register func(router *gin.Engine, table *RouteTable)
+		route    string
+	}{
+		{
+			name: "registered around the table",
+			register: func(router *gin.Engine, table *RouteTable) {
+				router.Group("/api").GET("/containers/:id/secrets", ok)
+			},
+			route: "GET /api/containers/:id/secrets",
+		},
+		{
+			name: "declared public without an allowlist entry",
+			register: func(router *gin.Engine, table *RouteTable) {
+				table.Register(router.Group("/api"), get("/containers/:id/secrets", authPublic, ok))
+			},
+			route: "GET /api/containers/:id/secrets",
+		},
+		{
+			name: "allowlisted path under another method",
+			register: func(router *gin.Engine, table *RouteTable) {
+				router.DELETE("/api/health", ok)
+			},
+			route: "DELETE /api/health",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, table := newTestRouter(t, newTestRouterConfig())
+			tt.register(router, table)
+
+			err := table.AssertAuth(router)
+			if err == nil || !strings.Contains(err.Error(), tt.route) {
+				t.Fatalf("AssertAuth error = %v, want it to name %s", err, tt.route)
+			}
+		})
+	}
+}
@@ -4,11 +4,13 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"docker-auto/internal/middleware"
-	"docker-auto/internal/model"
 	"docker-auto/internal/service"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
@@ -26,48 +28,12 @@ func NewSchedulerController(schedulerService *service.SchedulerService) *Schedul
 	}
 }
 
-// RegisterRoutes registers scheduler routes
-func (c *SchedulerController) RegisterRoutes(router *gin.RouterGroup) {
-	scheduler := router.Group("/scheduler")
-	scheduler.Use(middleware.JWT())
-
-	// Scheduler status and control
-	scheduler.GET("/status", c.GetSchedulerStatus)
-	scheduler.POST("/start", middleware.Permission("admin"), c.StartScheduler)
-	scheduler.POST("/stop", middleware.Permission("admin"), c.StopScheduler)
-
-	// Task management
-	tasks := scheduler.Group("/tasks")
-	{
-		tasks.POST("", c.CreateTask)
-		tasks.GET("", c.ListTasks)
-		tasks.GET("/:id", c.GetTask)
-		tasks.PUT("/:id", c.UpdateTask)
-		tasks.DELETE("/:id", c.DeleteTask)
-
-		// Task control
-		tasks.POST("/:id/pause", c.PauseTask)
-		tasks.POST("/:id/resume", c.ResumeTask)
-		tasks.POST("/:id/trigger", c.TriggerTask)
-
-		// Task executions
-		tasks.GET("/:id/executions", c.GetTaskExecutions)
-	}
-
-	// Task types information
-	scheduler.GET("/task-types", c.GetTaskTypes)
-	scheduler.GET("/cron-expressions", c.GetCronExpressions)
-}
-
 // GetSchedulerStatus returns the current scheduler status
 func (c *SchedulerController) GetSchedulerStatus(ctx *gin.Context) {
 	status, err := c.schedulerService.GetSchedulerStatus(ctx.Request.Context())
 	if err != nil {
 		logrus.WithError(err).Error("Failed to get scheduler status")
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to get scheduler status",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to get scheduler status")
 		return
 	}
 
@@ -88,10 +54,7 @@ func (c *SchedulerController) StartScheduler(ctx *gin.Context) {
 
 	if err := c.schedulerService.Start(ctx.Request.Context()); err != nil {
 		logrus.WithError(err).Error("Failed to start scheduler")
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to start scheduler",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to start scheduler")
 		return
 	}
 
@@ -113,10 +76,7 @@ func (c *SchedulerController) StopScheduler(ctx *gin.Context) {
 
 	if err := c.schedulerService.Stop(ctx.Request.Context()); err != nil {
 		logrus.WithError(err).Error("Failed to stop scheduler")
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to stop scheduler",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to stop scheduler")
 		return
 	}
 
@@ -142,10 +102,7 @@ func (c *SchedulerController) CreateTask(ctx *gin.Context) {
 	task, err := c.schedulerService.CreateTask(ctx.Request.Context(), userID, &req)
 	if err != nil {
 		logrus.WithError(err).WithField("user_id", userID).Error("Failed to create task")
-		ctx.JSON(http.StatusBadRequest, gin.H{
-			"error":   "Failed to create task",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusBadRequest, "Failed to create task")
 		return
 	}
 
@@ -174,15 +131,7 @@ func (c *SchedulerController) GetTask(ctx *gin.Context) {
 			"task_id": taskID,
 		}).Error("Failed to get task")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "access denied" {
-			statusCode = http.StatusForbidden
-		}
-
-		ctx.JSON(statusCode, gin.H{
-			"error":   "Failed to get task",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to get task")
 		return
 	}
 
@@ -218,15 +167,7 @@ func (c *SchedulerController) UpdateTask(ctx *gin.Context) {
 			"task_id": taskID,
 		}).Error("Failed to update task")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "access denied" {
-			statusCode = http.StatusForbidden
-		}
-
-		ctx.JSON(statusCode, gin.H{
-			"error":   "Failed to update task",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to update task")
 		return
 	}
 
@@ -253,15 +194,7 @@ func (c *SchedulerController) DeleteTask(ctx *gin.Context) {
 			"task_id": taskID,
 		}).Error("Failed to delete task")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "access denied" {
-			statusCode = http.StatusForbidden
-		}
-
-		ctx.JSON(statusCode, gin.H{
-			"error":   "Failed to delete task",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to delete task")
 		return
 	}
 
@@ -306,10 +239,7 @@ func (c *SchedulerController) ListTasks(ctx *gin.Context) {
 	response, err := c.schedulerService.ListTasks(ctx.Request.Context(), userID, filter)
 	if err != nil {
 		logrus.WithError(err).WithField("user_id", userID).Error("Failed to list tasks")
-		ctx.JSON(http.StatusInternalServerError, gin.H{
-			"error":   "Failed to list tasks",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to list tasks")
 		return
 	}
 
@@ -334,15 +264,7 @@ func (c *SchedulerController) PauseTask(ctx *gin.Context) {
 			"task_id": taskID,
 		}).Error("Failed to pause task")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "access denied" {
-			statusCode = http.StatusForbidden
-		}
-
-		ctx.JSON(statusCode, gin.H{
-			"error":   "Failed to pause task",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to pause task")
 		return
 	}
 
@@ -369,15 +291,7 @@ func (c *SchedulerController) ResumeTask(ctx *gin.Context) {
 			"task_id": taskID,
 		}).Error("Failed to resume task")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "access denied" {
-			statusCode = http.StatusForbidden
-		}
-
-		ctx.JSON(statusCode, gin.H{
-			"error":   "Failed to resume task",
-			"details": err.Error(),
-		})
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to resume task")
 		return
 	}
 
@@ -404,26 +318,46 @@ func (c *SchedulerController) TriggerTask(ctx *gin.Context) {
 			"task_id": taskID,
 		}).Error("Failed to trigger task")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "access denied" {
-			statusCode = http.StatusForbidden
-		} else if err.Error() == "scheduler is not running" {
-			statusCode = http.StatusServiceUnavailable
-		}
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to trigger task")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Task triggered successfully",
+	})
+}
 
-		ctx.JSON(statusCode, gin.H{
-			"error":   "Failed to trigger task",
+// CancelExecution cancels a running execution of a task
+func (c *SchedulerController) CancelExecution(ctx *gin.Context) {
+	userID := getUserID(ctx)
+	taskID, err := getTaskID(ctx)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{
+			"error":   "Invalid task ID",
 			"details": err.Error(),
 		})
 		return
 	}
+	executionID := ctx.Param("execId")
 
-	ctx.JSON(http.StatusOK, gin.H{
-		"message": "Task triggered successfully",
+	if err := c.schedulerService.CancelExecution(ctx.Request.Context(), userID, taskID, executionID); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"user_id":      userID,
+			"task_id":      taskID,
+			"execution_id": executionID,
+		}).Error("Failed to cancel task execution")
+
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to cancel task execution")
+		return
+	}
+
+	ctx.JSON(http.StatusAccepted, gin.H{
+		"message": "Task execution cancellation requested",
 	})
 }
 
-// GetTaskExecutions retrieves execution history for a task
+// GetTaskExecutions retrieves execution history for a task. ?cursor= (empty
+// for the first page) pages by keyset instead of offset and returns next_cursor.
 func (c *SchedulerController) GetTaskExecutions(ctx *gin.Context) {
 	userID := getUserID(ctx)
 	taskID, err := getTaskID(ctx)
@@ -466,6 +400,19 @@ func (c *SchedulerController) GetTaskExecutions(ctx *gin.Context) {
 		}
 	}
 
+	// Cursor pagination: ?cursor= (empty for the first page) replaces offset
+	if cursorToken, useCursor := ctx.GetQuery("cursor"); useCursor {
+		cursor, err := model.DecodeCursor(cursorToken)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid cursor",
+				"details": err.Error(),
+			})
+			return
+		}
+		filter.Cursor = cursor
+	}
+
 	response, err := c.schedulerService.GetTaskExecutions(ctx.Request.Context(), userID, taskID, filter)
 	if err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
@@ -473,15 +420,97 @@ func (c *SchedulerController) GetTaskExecutions(ctx *gin.Context) {
 			"task_id": taskID,
 		}).Error("Failed to get task executions")
 
-		statusCode := http.StatusInternalServerError
-		if err.Error() == "access denied" {
-			statusCode = http.StatusForbidden
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to get task executions")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, response)
+}
+
+// GetTimeline returns task execution intervals for a time range of at most 7 days
+func (c *SchedulerController) GetTimeline(ctx *gin.Context) {
+	userID := getUserID(ctx)
+
+	to := time.Now()
+	if toStr := ctx.Query("to"); toStr != "" {
+		parsed, err := time.Parse(time.RFC3339, toStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid 'to' time",
+				"details": err.Error(),
+			})
+			return
 		}
+		to = parsed
+	}
+
+	from := to.Add(-24 * time.Hour)
+	if fromStr := ctx.Query("from"); fromStr != "" {
+		parsed, err := time.Parse(time.RFC3339, fromStr)
+		if err != nil {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error":   "Invalid 'from' time",
+				"details": err.Error(),
+			})
+			return
+		}
+		from = parsed
+	}
 
-		ctx.JSON(statusCode, gin.H{
-			"error":   "Failed to get task executions",
-			"details": err.Error(),
-		})
+	timeline, err := c.schedulerService.GetTimeline(ctx.Request.Context(), userID, from, to)
+	if err != nil {
+		if !apperrors.HasCode(err, apperrors.CodeInvalidRequest) {
+			logrus.WithError(err).WithField("user_id", userID).Error("Failed to get scheduler timeline")
+		}
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to get scheduler timeline")
+		return
+	}
+
+	ctx.JSON(http.StatusOK, timeline)
+}
+
+// GetSchedulerEvents returns the scheduler event log, newest first. It can be
+// filtered by task_id and by one or more comma-separated event types.
+func (c *SchedulerController) GetSchedulerEvents(ctx *gin.Context) {
+	userID := getUserID(ctx)
+	query := &service.SchedulerEventQuery{}
+
+	if taskIDStr := ctx.Query("task_id"); taskIDStr != "" {
+		taskID, err := strconv.Atoi(taskIDStr)
+		if err != nil || taskID <= 0 {
+			ctx.JSON(http.StatusBadRequest, gin.H{
+				"error": "Invalid task ID",
+			})
+			return
+		}
+		query.TaskID = &taskID
+	}
+
+	if typeStr := ctx.Query("type"); typeStr != "" {
+		for _, eventType := range strings.Split(typeStr, ",") {
+			if eventType = strings.TrimSpace(eventType); eventType != "" {
+				query.Types = append(query.Types, eventType)
+			}
+		}
+	}
+
+	if limitStr := ctx.Query("limit"); limitStr != "" {
+		if limit, err := strconv.Atoi(limitStr); err == nil && limit > 0 {
+			query.Limit = limit
+		}
+	}
+
+	if offsetStr := ctx.Query("offset"); offsetStr != "" {
+		if offset, err := strconv.Atoi(offsetStr); err == nil && offset >= 0 {
+			query.Offset = offset
+		}
+	}
+
+	response, err := c.schedulerService.ListEvents(ctx.Request.Context(), userID, query)
+	if err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Error("Failed to get scheduler events")
+
+		respondSchedulerError(ctx, err, http.StatusInternalServerError, "Failed to get scheduler events")
 		return
 	}
 
@@ -525,6 +554,8 @@ func (c *SchedulerController) GetTaskTypes(ctx *gin.Context) {
 				"cleanup_unused_images":       "Remove unused Docker images",
 				"cleanup_stopped_containers":  "Remove old stopped containers",
 				"image_retention_days":        "Days to keep Docker images",
+				"image_prune_mode":            "Image pruning (age, or managed to keep managed containers' images and prune superseded ones)",
+				"keep_image_versions":         "Superseded image versions kept per container in managed mode",
 				"dry_run":                     "Preview cleanup without actual deletion",
 			},
 		},
@@ -594,6 +625,24 @@ func (c *SchedulerController) GetCronExpressions(ctx *gin.Context) {
 
 // Helper functions
 
+// respondSchedulerError responds with the status of err's code, or with
+// fallbackStatus for errors without one
+func respondSchedulerError(ctx *gin.Context, err error, fallbackStatus int, message string) {
+	status, code := fallbackStatus, apperrors.CodeOf(err)
+	if code != "" {
+		status = apperrors.HTTPStatus(code)
+	} else {
+		code = apperrors.CodeForStatus(fallbackStatus)
+	}
+
+	ctx.JSON(status, gin.H{
+		"error":      message,
+		"details":    err.Error(),
+		"error_code": code,
+		"request_id": middleware.RequestID(ctx),
+	})
+}
+
 // getUserID extracts user ID from the JWT token in context
 func getUserID(ctx *gin.Context) int64 {
 	if userID, exists := ctx.Get("user_id"); exists {
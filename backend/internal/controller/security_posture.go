@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SecurityPostureController handles security posture reports
+type SecurityPostureController struct {
+	postureService *service.SecurityPostureService
+	logger         *logrus.Logger
+}
+
+// NewSecurityPostureController creates a new security posture controller
+func NewSecurityPostureController(postureService *service.SecurityPostureService, logger *logrus.Logger) *SecurityPostureController {
+	return &SecurityPostureController{
+		postureService: postureService,
+		logger:         logger,
+	}
+}
+
+// GetLatestPosture godoc
+// @Summary Get the latest security posture report
+// @Description Get the most recent scored evaluation of the runtime security configuration, with the penalty of every rule and its findings
+// @Tags Security
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=model.SecurityPostureReport} "Latest report"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "No report has been evaluated"
+// @Router /api/security/posture [get]
+func (pc *SecurityPostureController) GetLatestPosture(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	report, err := pc.postureService.GetLatest(c.Request.Context())
+	if err != nil {
+		pc.respondError(rb, err, "Failed to get security posture")
+		return
+	}
+
+	rb.Success(report)
+}
+
+// ComparePosture godoc
+// @Summary Compare security posture reports
+// @Description List the findings that improved, regressed or stayed unchanged between two reports. from and to are report IDs, dates (the day's last report) or RFC3339 times; to defaults to the latest report and from to the one before to.
+// @Tags Security
+// @Produce json
+// @Security BearerAuth
+// @Param from query string false "Earlier report ID, date or time"
+// @Param to query string false "Later report ID, date or time"
+// @Success 200 {object} utils.APIResponse{data=model.PostureComparison} "Comparison"
+// @Failure 400 {object} utils.APIResponse "Invalid report reference"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Report not found"
+// @Router /api/security/posture/compare [get]
+func (pc *SecurityPostureController) ComparePosture(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	comparison, err := pc.postureService.Compare(c.Request.Context(), c.Query("from"), c.Query("to"))
+	if err != nil {
+		pc.respondError(rb, err, "Failed to compare security posture")
+		return
+	}
+
+	rb.Success(comparison)
+}
+
+// EvaluatePosture godoc
+// @Summary Evaluate security posture now
+// @Description Evaluate the runtime security configuration and store the report. The evaluation only reads configuration and stored state. Admins are notified of regressions since the previous report when SECURITY_POSTURE_NOTIFY_REGRESSIONS is set.
+// @Tags Security
+// @Produce json
+// @Security BearerAuth
+// @Success 201 {object} utils.APIResponse{data=model.SecurityPostureReport} "Report created"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/security/posture/evaluate [post]
+func (pc *SecurityPostureController) EvaluatePosture(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	report, err := pc.postureService.Evaluate(c.Request.Context(), middleware.CurrentActor(c))
+	if err != nil {
+		pc.respondError(rb, err, "Failed to evaluate security posture")
+		return
+	}
+
+	rb.Created(report)
+}
+
+// respondError maps security posture errors onto HTTP responses
+func (pc *SecurityPostureController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	pc.logger.WithError(err).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound(err.Error())
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,181 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gorilla/websocket"
+)
+
+// sessionStore keeps login sessions in memory
+type sessionStore struct {
+	repository.UserSessionRepository
+	mu       sync.Mutex
+	sessions map[string]*model.UserSession
+}
+
+func (r *sessionStore) GetByID(ctx context.Context, id string) (*model.UserSession, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	session, ok := r.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("session not found")
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (r *sessionStore) Delete(ctx context.Context, id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.sessions, id)
+	return nil
+}
+
+func (r *sessionStore) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	return nil
+}
+
+// discardedActivity drops activity logs
+type discardedActivity struct {
+	repository.ActivityLogRepository
+}
+
+func (r *discardedActivity) Create(ctx context.Context, log *model.ActivityLog) error {
+	return nil
+}
+
+// shellContainerRepo serves container 5, created by user 2
+type shellContainerRepo struct {
+	repository.ContainerRepository
+}
+
+func (r *shellContainerRepo) GetByID(ctx context.Context, id int64) (*model.Container, error) {
+	creator := 2
+	return &model.Container{ID: 5, Name: "web", ContainerID: "abc123", CreatedBy: &creator}, nil
+}
+
+// newShellDaemon runs exec instances that wait for input, reporting on closed
+// when the client hangs up
+func newShellDaemon(t *testing.T, closed chan<- struct{}) *docker.DockerClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/v1.44")
+		switch {
+		case r.Method == http.MethodPost && path == "/containers/abc123/exec":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(map[string]string{"Id": "exec1"})
+		case r.Method == http.MethodPost && path == "/exec/exec1/start":
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+			buf.Flush()
+			io.Copy(io.Discard, buf)
+			closed <- struct{}{}
+		case r.Method == http.MethodGet && path == "/exec/exec1/json":
+			json.NewEncoder(w).Encode(map[string]interface{}{"ID": "exec1", "Running": false, "ExitCode": 137})
+		default:
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	cfg := newTestRouterConfig().Config
+	cfg.Docker.Host = "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	cfg.Docker.APIVersion = "1.44"
+	cfg.Docker.Timeout = 10
+	cfg.Docker.PullMaxConcurrent = 1
+	dc, err := docker.NewDockerClient(cfg)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { dc.Close() })
+	return dc
+}
+
+func TestRevokedSessionClosesItsExecSocket(t *testing.T) {
+	now := time.Now()
+	sessions := &sessionStore{sessions: map[string]*model.UserSession{
+		"laptop": {ID: "laptop", UserID: 2, ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+		"phone":  {ID: "phone", UserID: 2, ExpiresAt: now.Add(time.Hour), CreatedAt: now},
+	}}
+	closed := make(chan struct{}, 2)
+
+	cfg := newTestRouterConfig()
+	cfg.Config.JWT.ExpireHours = 1
+	cfg.Config.Security.ContainerExecEnabled = true
+	cfg.UserService = service.NewUserService(nil, nil, sessions, &discardedActivity{}, cfg.Config, nil)
+	cfg.ContainerService = service.NewContainerService(&shellContainerRepo{}, nil, nil, nil, newShellDaemon(t, closed), nil, cfg.Config, cfg.UserService, nil, nil, nil, nil,
+		nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, nil)
+	router, _ := newTestRouter(t, cfg)
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	alice := &model.User{ID: 2, Username: "alice", Role: model.UserRoleOperator, IsActive: true}
+	tokens := make(map[string]string)
+	for sessionID := range sessions.sessions {
+		token, err := utils.NewJWTManager(cfg.Config).GenerateSessionAccessToken(alice, sessionID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tokens[sessionID] = token
+	}
+	dial := func(sessionID string) (*websocket.Conn, *http.Response, error) {
+		header := http.Header{"Authorization": {"Bearer " + tokens[sessionID]}}
+		return websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/api/containers/5/exec", header)
+	}
+
+	conn, _, err := dial("laptop")
+	if err != nil {
+		t.Fatalf("opening the terminal: %v", err)
+	}
+	defer conn.Close()
+
+	// Alice signs the laptop out from her phone
+	req := httptest.NewRequest(http.MethodDelete, "/api/auth/sessions/laptop", nil)
+	req.Header.Set("Authorization", "Bearer "+tokens["phone"])
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("revoking the laptop session = %d: %s", w.Code, w.Body)
+	}
+
+	// The laptop's terminal is closed, and with it the exec attachment
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		if _, _, err = conn.ReadMessage(); err != nil {
+			break
+		}
+	}
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) || !strings.Contains(err.Error(), "session revoked") {
+		t.Errorf("terminal ended with %v, want closed as session revoked", err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the exec attachment was left open")
+	}
+
+	// The revoked session cannot open another
+	if _, resp, err := dial("laptop"); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("reopening with the revoked session = %v, want 401", err)
+	}
+}
@@ -0,0 +1,103 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// SetupController handles the first-run setup wizard
+type SetupController struct {
+	setupService *service.SetupService
+	logger       *logrus.Logger
+}
+
+// NewSetupController creates a new setup controller
+func NewSetupController(setupService *service.SetupService, logger *logrus.Logger) *SetupController {
+	return &SetupController{
+		setupService: setupService,
+		logger:       logger,
+	}
+}
+
+// GetSetupStatus godoc
+// @Summary Get setup status
+// @Description Report whether first-run setup has been completed
+// @Tags Setup
+// @Produce json
+// @Success 200 {object} utils.APIResponse{data=service.SetupStatus} "Setup status"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/setup/status [get]
+func (sc *SetupController) GetSetupStatus(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	status, err := sc.setupService.GetStatus(c.Request.Context())
+	if err != nil {
+		sc.logger.WithError(err).Error("Failed to get setup status")
+		rb.InternalServerError("Failed to get setup status")
+		return
+	}
+
+	rb.Success(status)
+}
+
+// RunSetup godoc
+// @Summary Run first-run setup
+// @Description Create the initial admin, save Docker and retention settings and seed default tasks
+// @Tags Setup
+// @Accept json
+// @Produce json
+// @Param request body service.SetupRequest true "Setup wizard data"
+// @Success 201 {object} utils.APIResponse{data=service.SetupResult} "Setup completed"
+// @Failure 400 {object} utils.APIResponse "Invalid request or Docker connection failed"
+// @Failure 409 {object} utils.APIResponse "Setup already in progress"
+// @Failure 410 {object} utils.APIResponse "Setup already completed"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/setup [post]
+func (sc *SetupController) RunSetup(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	if sc.setupService.IsComplete(c.Request.Context()) {
+		rb.Error(http.StatusGone, "Setup has already been completed")
+		return
+	}
+
+	var req service.SetupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	result, err := sc.setupService.RunSetup(c.Request.Context(), &req)
+	if err != nil {
+		sc.logger.WithError(err).WithField("client_ip", c.ClientIP()).Warn("Setup failed")
+
+		switch {
+		case errors.Is(err, service.ErrSetupComplete):
+			rb.Error(http.StatusGone, "Setup has already been completed")
+		case errors.Is(err, service.ErrSetupInProgress):
+			rb.Conflict("Setup is already in progress")
+		case strings.Contains(err.Error(), "invalid setup request"),
+			strings.Contains(err.Error(), "docker connection test failed"):
+			rb.BadRequest(err.Error())
+		case strings.Contains(err.Error(), "already exists"):
+			rb.Conflict(err.Error())
+		default:
+			rb.InternalServerError("Failed to complete setup")
+		}
+		return
+	}
+
+	sc.logger.WithFields(logrus.Fields{
+		"admin":     result.Admin.Username,
+		"client_ip": c.ClientIP(),
+	}).Info("Setup wizard completed")
+
+	rb.Created(result)
+}
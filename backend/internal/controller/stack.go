@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// StackController handles container stack endpoints
+type StackController struct {
+	stackService *service.StackService
+	logger       *logrus.Logger
+}
+
+// NewStackController creates a new stack controller
+func NewStackController(stackService *service.StackService, logger *logrus.Logger) *StackController {
+	return &StackController{
+		stackService: stackService,
+		logger:       logger,
+	}
+}
+
+// ListStacks godoc
+// @Summary List stacks
+// @Description Get stacks with their combined status, running members and pending updates
+// @Tags Stacks
+// @Produce json
+// @Security BearerAuth
+// @Param name query string false "Filter by name"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(20)
+// @Success 200 {object} utils.APIResponse{data=[]service.StackSummary} "Stacks"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/stacks [get]
+func (sc *StackController) ListStacks(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	filter := &model.StackFilter{
+		Name:   c.Query("name"),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+
+	stacks, total, err := sc.stackService.ListStacks(c.Request.Context(), userID, filter)
+	if err != nil {
+		sc.logger.WithError(err).Error("Failed to list stacks")
+		rb.InternalServerError("Failed to retrieve stacks")
+		return
+	}
+
+	rb.SuccessWithPagination(stacks, utils.CreatePagination(page, limit, total))
+}
+
+// GetStack godoc
+// @Summary Get stack
+// @Description Get a stack with its members' health, pending updates and effective policies
+// @Tags Stacks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stack ID"
+// @Success 200 {object} utils.APIResponse{data=service.StackDetail} "Stack details"
+// @Failure 400 {object} utils.APIResponse "Invalid stack ID"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Stack not found"
+// @Router /api/stacks/{id} [get]
+func (sc *StackController) GetStack(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	stackID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid stack ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	detail, err := sc.stackService.GetStack(c.Request.Context(), userID, stackID)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to get stack")
+		return
+	}
+
+	rb.Success(detail)
+}
+
+// CreateStack godoc
+// @Summary Create stack
+// @Description Group containers into a stack; container_ids gives the start order
+// @Tags Stacks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.StackRequest true "Stack"
+// @Success 201 {object} utils.APIResponse{data=service.StackDetail} "Stack created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 409 {object} utils.APIResponse "Stack already exists"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/stacks [post]
+func (sc *StackController) CreateStack(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	var req service.StackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	detail, err := sc.stackService.CreateStack(c.Request.Context(), userID, &req)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to create stack")
+		return
+	}
+
+	rb.Created(detail)
+}
+
+// UpdateStack godoc
+// @Summary Update stack
+// @Description Replace a stack's members and policy overrides; omitted settings fall through to image policies and global defaults
+// @Tags Stacks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stack ID"
+// @Param request body service.StackRequest true "Stack"
+// @Success 200 {object} utils.APIResponse{data=service.StackDetail} "Stack updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Stack not found"
+// @Failure 409 {object} utils.APIResponse "Stack already exists"
+// @Router /api/stacks/{id} [put]
+func (sc *StackController) UpdateStack(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	stackID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid stack ID")
+		return
+	}
+
+	var req service.StackRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	detail, err := sc.stackService.UpdateStack(c.Request.Context(), userID, stackID, &req)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to update stack")
+		return
+	}
+
+	rb.Success(detail)
+}
+
+// DeleteStack godoc
+// @Summary Delete stack
+// @Description Dissolve a stack, keeping its containers, or delete it together with its members
+// @Tags Stacks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stack ID"
+// @Param delete_members query boolean false "Also delete the member containers" default(false)
+// @Success 200 {object} utils.APIResponse{data=service.StackOperationResult} "Stack deleted"
+// @Failure 400 {object} utils.APIResponse "Invalid stack ID"
+// @Failure 404 {object} utils.APIResponse "Stack not found"
+// @Router /api/stacks/{id} [delete]
+func (sc *StackController) DeleteStack(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	stackID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid stack ID")
+		return
+	}
+
+	deleteMembers := c.Query("delete_members") == "true"
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := sc.stackService.DeleteStack(c.Request.Context(), userID, stackID, deleteMembers)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to delete stack")
+		return
+	}
+
+	rb.SuccessWithMessage(result, "Stack deleted successfully")
+}
+
+// StartStack godoc
+// @Summary Start stack
+// @Description Start the members of a stack in start order, halting at the first failure
+// @Tags Stacks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stack ID"
+// @Success 200 {object} utils.APIResponse{data=service.StackOperationResult} "Per-member results"
+// @Failure 404 {object} utils.APIResponse "Stack not found"
+// @Router /api/stacks/{id}/start [post]
+func (sc *StackController) StartStack(c *gin.Context) {
+	sc.runAction(c, service.StackActionStart)
+}
+
+// StopStack godoc
+// @Summary Stop stack
+// @Description Stop the members of a stack in reverse start order
+// @Tags Stacks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stack ID"
+// @Success 200 {object} utils.APIResponse{data=service.StackOperationResult} "Per-member results"
+// @Failure 404 {object} utils.APIResponse "Stack not found"
+// @Router /api/stacks/{id}/stop [post]
+func (sc *StackController) StopStack(c *gin.Context) {
+	sc.runAction(c, service.StackActionStop)
+}
+
+// RestartStack godoc
+// @Summary Restart stack
+// @Description Restart the members of a stack in start order, halting at the first failure
+// @Tags Stacks
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stack ID"
+// @Success 200 {object} utils.APIResponse{data=service.StackOperationResult} "Per-member results"
+// @Failure 404 {object} utils.APIResponse "Stack not found"
+// @Router /api/stacks/{id}/restart [post]
+func (sc *StackController) RestartStack(c *gin.Context) {
+	sc.runAction(c, service.StackActionRestart)
+}
+
+// UpdateStackImages godoc
+// @Summary Update stack
+// @Description Update the images of a stack's members in start order, halting at the first failure
+// @Tags Stacks
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Stack ID"
+// @Param request body dto.UpdateImageRequest false "Update options applied to every member"
+// @Success 200 {object} utils.APIResponse{data=service.StackOperationResult} "Per-member results"
+// @Failure 404 {object} utils.APIResponse "Stack not found"
+// @Router /api/stacks/{id}/update [post]
+func (sc *StackController) UpdateStackImages(c *gin.Context) {
+	sc.runAction(c, service.StackActionUpdate)
+}
+
+// runAction runs a group action on the stack in the path
+func (sc *StackController) runAction(c *gin.Context, action string) {
+	userID := middleware.CurrentUserID(c)
+
+	stackID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid stack ID")
+		return
+	}
+
+	var updateReq dto.UpdateImageRequest
+	// Optional request body
+	if action == service.StackActionUpdate && c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&updateReq); err != nil {
+			utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+			return
+		}
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := sc.stackService.RunAction(c.Request.Context(), userID, stackID, action, &updateReq)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to "+action+" stack")
+		return
+	}
+
+	rb.Success(result)
+}
+
+// respondError maps stack service errors onto HTTP responses
+func (sc *StackController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	sc.logger.WithError(err).Error(message)
+
+	switch {
+	case errors.Is(err, service.ErrStackNotFound):
+		rb.NotFound("Stack not found")
+	case errors.Is(err, service.ErrStackExists):
+		rb.Conflict("A stack with this name already exists")
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden("Access denied")
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	default:
+		rb.InternalServerError(message)
+	}
+}
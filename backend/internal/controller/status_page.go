@@ -0,0 +1,249 @@
+package controller
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// StatusPageController handles status page configuration and serves the
+// public pages
+type StatusPageController struct {
+	statusPageService *service.StatusPageService
+	logger            *logrus.Logger
+}
+
+// NewStatusPageController creates a new status page controller
+func NewStatusPageController(statusPageService *service.StatusPageService, logger *logrus.Logger) *StatusPageController {
+	return &StatusPageController{
+		statusPageService: statusPageService,
+		logger:            logger,
+	}
+}
+
+// GetPublicStatus godoc
+// @Summary Get a public status page
+// @Description Get the state of the components of an enabled status page. Only display names, groups, states and incident times are returned. Unknown tokens and disabled pages both return 404.
+// @Tags Status Pages
+// @Produce json
+// @Param token path string true "Status page token"
+// @Success 200 {object} service.PublicStatus "Component states"
+// @Failure 404 {object} utils.APIResponse "Not found"
+// @Failure 429 {object} utils.APIResponse "Too many requests"
+// @Router /status/{token} [get]
+func (sc *StatusPageController) GetPublicStatus(c *gin.Context) {
+	status, err := sc.statusPageService.GetPublicStatus(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		sc.logger.WithError(err).Error("Failed to render status page")
+		utils.InternalServerErrorJSON(c, "Failed to get status")
+		return
+	}
+	if status == nil {
+		utils.NotFoundJSON(c, "Not found")
+		return
+	}
+
+	c.Header("Cache-Control", fmt.Sprintf("public, max-age=%d", int(sc.statusPageService.CacheTTL().Seconds())))
+	c.JSON(200, status)
+}
+
+// ListStatusPages godoc
+// @Summary List status pages
+// @Description Get every status page with its token and components
+// @Tags Status Pages
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]model.StatusPage} "Status pages"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/status-pages [get]
+func (sc *StatusPageController) ListStatusPages(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	pages, err := sc.statusPageService.ListPages(c.Request.Context())
+	if err != nil {
+		sc.respondError(rb, err, "Failed to list status pages")
+		return
+	}
+
+	rb.Success(pages)
+}
+
+// GetStatusPage godoc
+// @Summary Get status page
+// @Description Get a status page configuration by ID
+// @Tags Status Pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Status page ID"
+// @Success 200 {object} utils.APIResponse{data=model.StatusPage} "Status page"
+// @Failure 400 {object} utils.APIResponse "Invalid status page ID"
+// @Failure 404 {object} utils.APIResponse "Status page not found"
+// @Router /api/status-pages/{id} [get]
+func (sc *StatusPageController) GetStatusPage(c *gin.Context) {
+	id, ok := statusPageID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	page, err := sc.statusPageService.GetPage(c.Request.Context(), id)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to get status page")
+		return
+	}
+
+	rb.Success(page)
+}
+
+// CreateStatusPage godoc
+// @Summary Create status page
+// @Description Publish selected containers under display names at a new unguessable URL, /status/{token}
+// @Tags Status Pages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.StatusPageRequest true "Status page"
+// @Success 201 {object} utils.APIResponse{data=model.StatusPage} "Status page created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/status-pages [post]
+func (sc *StatusPageController) CreateStatusPage(c *gin.Context) {
+	var req service.StatusPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	page, err := sc.statusPageService.CreatePage(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to create status page")
+		return
+	}
+
+	rb.Created(page)
+}
+
+// UpdateStatusPage godoc
+// @Summary Update status page
+// @Description Replace the title, components and signal mapping of a status page; its URL stays the same
+// @Tags Status Pages
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Status page ID"
+// @Param request body service.StatusPageRequest true "Status page"
+// @Success 200 {object} utils.APIResponse{data=model.StatusPage} "Status page updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Status page not found"
+// @Router /api/status-pages/{id} [put]
+func (sc *StatusPageController) UpdateStatusPage(c *gin.Context) {
+	id, ok := statusPageID(c)
+	if !ok {
+		return
+	}
+
+	var req service.StatusPageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	page, err := sc.statusPageService.UpdatePage(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to update status page")
+		return
+	}
+
+	rb.Success(page)
+}
+
+// RotateStatusPageToken godoc
+// @Summary Rotate status page token
+// @Description Give a status page a new URL; the old one stops working at once
+// @Tags Status Pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Status page ID"
+// @Success 200 {object} utils.APIResponse{data=model.StatusPage} "Token rotated"
+// @Failure 404 {object} utils.APIResponse "Status page not found"
+// @Router /api/status-pages/{id}/rotate-token [post]
+func (sc *StatusPageController) RotateStatusPageToken(c *gin.Context) {
+	id, ok := statusPageID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	page, err := sc.statusPageService.RotateToken(c.Request.Context(), middleware.CurrentActor(c), id)
+	if err != nil {
+		sc.respondError(rb, err, "Failed to rotate status page token")
+		return
+	}
+
+	rb.Success(page)
+}
+
+// DeleteStatusPage godoc
+// @Summary Delete status page
+// @Description Delete a status page; its URL returns 404 afterwards
+// @Tags Status Pages
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Status page ID"
+// @Success 200 {object} utils.APIResponse "Status page deleted"
+// @Failure 404 {object} utils.APIResponse "Status page not found"
+// @Router /api/status-pages/{id} [delete]
+func (sc *StatusPageController) DeleteStatusPage(c *gin.Context) {
+	id, ok := statusPageID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	if err := sc.statusPageService.DeletePage(c.Request.Context(), middleware.CurrentActor(c), id); err != nil {
+		sc.respondError(rb, err, "Failed to delete status page")
+		return
+	}
+
+	rb.SuccessWithMessage(nil, "Status page deleted successfully")
+}
+
+func statusPageID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid status page ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError maps status page service errors onto HTTP responses
+func (sc *StatusPageController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	sc.logger.WithError(err).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Status page not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
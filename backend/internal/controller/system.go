@@ -7,7 +7,7 @@ import (
 	"time"
 
 	"docker-auto/internal/middleware"
-	"docker-auto/internal/model"
+	"docker-auto/internal/service"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -16,32 +16,35 @@ import (
 
 // SystemController handles system-related HTTP requests
 type SystemController struct {
-	logger *logrus.Logger
+	systemInfoService *service.SystemInfoService
+	logger            *logrus.Logger
 }
 
 // NewSystemController creates a new system controller
-func NewSystemController(logger *logrus.Logger) *SystemController {
+func NewSystemController(systemInfoService *service.SystemInfoService, logger *logrus.Logger) *SystemController {
 	return &SystemController{
-		logger: logger,
+		systemInfoService: systemInfoService,
+		logger:            logger,
 	}
 }
 
 // SystemInfo represents system information response
 type SystemInfo struct {
-	Version     string            `json:"version"`
-	BuildTime   string            `json:"build_time"`
-	GitCommit   string            `json:"git_commit"`
-	GoVersion   string            `json:"go_version"`
-	Platform    string            `json:"platform"`
-	Uptime      string            `json:"uptime"`
-	Memory      MemoryInfo        `json:"memory"`
-	CPU         CPUInfo           `json:"cpu"`
-	Docker      DockerInfo        `json:"docker"`
-	Database    DatabaseInfo      `json:"database"`
-	Cache       CacheInfo         `json:"cache"`
-	Containers  ContainerStats    `json:"containers"`
-	Status      string            `json:"status"`
-	Timestamp   time.Time         `json:"timestamp"`
+	Version    string                       `json:"version"`
+	BuildTime  string                       `json:"build_time"`
+	GitCommit  string                       `json:"git_commit"`
+	GoVersion  string                       `json:"go_version"`
+	Platform   string                       `json:"platform"`
+	Uptime     string                       `json:"uptime"`
+	Memory     MemoryInfo                   `json:"memory"`
+	CPU        CPUInfo                      `json:"cpu"`
+	Docker     service.DockerDiagnostics    `json:"docker"`
+	Database   service.DatabaseDiagnostics  `json:"database"`
+	Cache      service.CacheDiagnostics     `json:"cache"`
+	Scheduler  service.SchedulerDiagnostics `json:"scheduler"`
+	Containers ContainerStats               `json:"containers"`
+	Status     string                       `json:"status"`
+	Timestamp  time.Time                    `json:"timestamp"`
 }
 
 // MemoryInfo represents memory usage information
@@ -60,38 +63,6 @@ type CPUInfo struct {
 	LoadAverage float64 `json:"load_average"`
 }
 
-// DockerInfo represents Docker daemon information
-type DockerInfo struct {
-	Connected   bool   `json:"connected"`
-	Version     string `json:"version,omitempty"`
-	APIVersion  string `json:"api_version,omitempty"`
-	Platform    string `json:"platform,omitempty"`
-	Experimental bool  `json:"experimental,omitempty"`
-	Error       string `json:"error,omitempty"`
-}
-
-// DatabaseInfo represents database connection information
-type DatabaseInfo struct {
-	Connected      bool   `json:"connected"`
-	Type           string `json:"type"`
-	Version        string `json:"version,omitempty"`
-	MaxConnections int    `json:"max_connections,omitempty"`
-	ActiveConns    int    `json:"active_connections,omitempty"`
-	IdleConns      int    `json:"idle_connections,omitempty"`
-	Error          string `json:"error,omitempty"`
-}
-
-// CacheInfo represents cache system information
-type CacheInfo struct {
-	Enabled    bool   `json:"enabled"`
-	Type       string `json:"type"`
-	Connected  bool   `json:"connected"`
-	Size       int64  `json:"size,omitempty"`
-	Keys       int64  `json:"keys,omitempty"`
-	HitRate    float64 `json:"hit_rate,omitempty"`
-	Error      string `json:"error,omitempty"`
-}
-
 // ContainerStats represents container statistics
 type ContainerStats struct {
 	Total         int64 `json:"total"`
@@ -233,16 +204,18 @@ type CheckResult struct {
 	Duration  string    `json:"duration,omitempty"`
 }
 
+// version, buildTime and gitCommit are set at build time with
+// -ldflags "-X docker-auto/internal/controller.version=..."
 var (
 	startTime = time.Now()
-	version   = "2.1.0" // This would typically be set during build
+	version   = "2.1.0"
 	buildTime = "unknown"
 	gitCommit = "unknown"
 )
 
 // GetSystemInfo godoc
 // @Summary Get system information
-// @Description Get comprehensive system information including version, status, and resource usage
+// @Description Get backend build and resource usage along with diagnostics of each component: the Docker daemon's version, negotiated API version, storage driver, object counts and disk usage, database connectivity and schema version, Redis connectivity and whether the scheduler runs. Each component reports its own status and error, and each check is bounded by a short timeout, so a failing component leaves the others readable and the overall status degraded.
 // @Tags System
 // @Produce json
 // @Security BearerAuth
@@ -273,25 +246,10 @@ func (sc *SystemController) GetSystemInfo(c *gin.Context) {
 		LoadAverage:  0.0, // Would require system-specific implementation
 	}
 
-	// Docker information (placeholder)
-	dockerInfo := DockerInfo{
-		Connected: false, // Would check actual Docker connection
-		Error:     "Docker connection not implemented",
-	}
-
-	// Database information (placeholder)
-	databaseInfo := DatabaseInfo{
-		Connected: false, // Would check actual database connection
-		Type:      "PostgreSQL",
-		Error:     "Database status check not implemented",
-	}
-
-	// Cache information (placeholder)
-	cacheInfo := CacheInfo{
-		Enabled:   false,
-		Type:      "Redis",
-		Connected: false,
-		Error:     "Cache status check not implemented",
+	diagnostics := sc.systemInfoService.GetDiagnostics(c.Request.Context())
+	status := "ok"
+	if !diagnostics.Healthy() {
+		status = "degraded"
 	}
 
 	// Container statistics (placeholder)
@@ -312,15 +270,16 @@ func (sc *SystemController) GetSystemInfo(c *gin.Context) {
 		Uptime:      time.Since(startTime).String(),
 		Memory:      memoryInfo,
 		CPU:         cpuInfo,
-		Docker:      dockerInfo,
-		Database:    databaseInfo,
-		Cache:       cacheInfo,
+		Docker:      diagnostics.Docker,
+		Database:    diagnostics.Database,
+		Cache:       diagnostics.Cache,
+		Scheduler:   diagnostics.Scheduler,
 		Containers:  containerStats,
-		Status:      "running",
+		Status:      status,
 		Timestamp:   time.Now(),
 	}
 
-	sc.logger.Info("System information requested")
+	sc.logger.WithField("status", status).Info("System information requested")
 	rb.Success(systemInfo)
 }
 
@@ -433,11 +392,7 @@ func (sc *SystemController) GetSystemConfig(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/system/config [put]
 func (sc *SystemController) UpdateSystemConfig(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	var config SystemConfig
 	if err := c.ShouldBindJSON(&config); err != nil {
@@ -559,7 +514,7 @@ func (sc *SystemController) HealthCheck(c *gin.Context) {
 	sc.logger.WithField("status", overallStatus).Debug("Health check performed")
 
 	if overallStatus == "unhealthy" {
-		c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse("System is unhealthy"))
+		c.JSON(http.StatusServiceUnavailable, utils.ErrorResponse(http.StatusServiceUnavailable, "System is unhealthy"))
 		return
 	}
 
@@ -677,11 +632,7 @@ func (sc *SystemController) GetSystemMetrics(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/system/restart [post]
 func (sc *SystemController) RestartService(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	rb := utils.NewResponseBuilder(c)
 
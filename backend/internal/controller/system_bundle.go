@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"net/http"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// bundlePassphraseHeader carries the export passphrase, keeping it out of
+// URLs and access logs
+const bundlePassphraseHeader = "X-Bundle-Passphrase"
+
+// SystemBundleController handles export and import of the system
+// configuration
+type SystemBundleController struct {
+	bundleService *service.SystemBundleService
+	logger        *logrus.Logger
+}
+
+// NewSystemBundleController creates a new system bundle controller
+func NewSystemBundleController(bundleService *service.SystemBundleService, logger *logrus.Logger) *SystemBundleController {
+	return &SystemBundleController{
+		bundleService: bundleService,
+		logger:        logger,
+	}
+}
+
+// ExportSystem godoc
+// @Summary Export system configuration
+// @Description Export every managed container with its configuration, the scheduled tasks, registry credentials and global notification channels as a versioned bundle for importing on another server. Secrets are encrypted under the passphrase sent in the X-Bundle-Passphrase header, which must be at least 12 characters. Stack, team and Docker host membership is not exported.
+// @Tags System
+// @Produce json
+// @Security BearerAuth
+// @Param X-Bundle-Passphrase header string true "Passphrase encrypting the bundle's secrets"
+// @Success 200 {object} utils.APIResponse{data=dto.SystemBundle} "System bundle"
+// @Failure 400 {object} utils.APIResponse "Missing or short passphrase"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/system/export [get]
+func (bc *SystemBundleController) ExportSystem(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	bundle, err := bc.bundleService.Export(c.Request.Context(), middleware.CurrentActor(c), c.GetHeader(bundlePassphraseHeader))
+	if err != nil {
+		bc.respondError(rb, err, nil, "Failed to export system configuration")
+		return
+	}
+
+	rb.Success(bundle)
+}
+
+// ImportSystem godoc
+// @Summary Import system configuration
+// @Description Import a bundle from the export endpoint. The bundle version and passphrase are checked first, then entries whose names already exist are reported as conflicts. With dry_run nothing is changed; otherwise everything is created in one transaction, and nothing at all when there are conflicts or any entry fails. Existing entries are never changed.
+// @Tags System
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.ImportSystemBundleRequest true "Bundle and passphrase"
+// @Success 200 {object} utils.APIResponse{data=dto.SystemImportResult} "Import result"
+// @Failure 400 {object} utils.APIResponse "Invalid bundle, unsupported version or wrong passphrase"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 409 {object} utils.APIResponse{data=dto.SystemImportResult} "Conflicts with the existing configuration"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/system/import [post]
+func (bc *SystemBundleController) ImportSystem(c *gin.Context) {
+	var req dto.ImportSystemBundleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	result, err := bc.bundleService.Import(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		bc.respondError(rb, err, result, "Failed to import system configuration")
+		return
+	}
+
+	rb.Success(result)
+}
+
+// respondError maps system bundle service errors onto HTTP responses
+func (bc *SystemBundleController) respondError(rb *utils.ResponseBuilder, err error, result *dto.SystemImportResult, message string) {
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request:"):
+		rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden(err.Error())
+	case result != nil && len(result.Conflicts) > 0:
+		rb.ErrorWithData(http.StatusConflict, err.Error(), result, nil)
+	default:
+		bc.logger.WithError(err).Error(message)
+		rb.InternalServerError(message)
+	}
+}
@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// TeamController handles teams and their resource quotas
+type TeamController struct {
+	teamService *service.TeamService
+	logger      *logrus.Logger
+}
+
+// NewTeamController creates a new team controller
+func NewTeamController(teamService *service.TeamService, logger *logrus.Logger) *TeamController {
+	return &TeamController{
+		teamService: teamService,
+		logger:      logger,
+	}
+}
+
+// ListTeams godoc
+// @Summary List teams
+// @Description Get every team with its members and quota
+// @Tags Teams
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]model.Team} "Teams"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/teams [get]
+func (tc *TeamController) ListTeams(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	teams, err := tc.teamService.ListTeams(c.Request.Context())
+	if err != nil {
+		tc.respondError(rb, err, "Failed to list teams")
+		return
+	}
+
+	rb.Success(teams)
+}
+
+// CreateTeam godoc
+// @Summary Create team
+// @Description Create a team without members or quota
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body service.TeamRequest true "Team"
+// @Success 201 {object} utils.APIResponse{data=model.Team} "Team created"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Router /api/teams [post]
+func (tc *TeamController) CreateTeam(c *gin.Context) {
+	var req service.TeamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	team, err := tc.teamService.CreateTeam(c.Request.Context(), middleware.CurrentActor(c), &req)
+	if err != nil {
+		tc.respondError(rb, err, "Failed to create team")
+		return
+	}
+
+	rb.Created(team)
+}
+
+// SetTeamMembers godoc
+// @Summary Set team members
+// @Description Replace the members of a team. Team admins are notified when the team nears its quota.
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Team ID"
+// @Param request body service.TeamMembersRequest true "Members"
+// @Success 200 {object} utils.APIResponse{data=model.Team} "Members updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 404 {object} utils.APIResponse "Team not found"
+// @Router /api/teams/{id}/members [put]
+func (tc *TeamController) SetTeamMembers(c *gin.Context) {
+	id, ok := teamID(c)
+	if !ok {
+		return
+	}
+
+	var req service.TeamMembersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	team, err := tc.teamService.SetMembers(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		tc.respondError(rb, err, "Failed to set team members")
+		return
+	}
+
+	rb.Success(team)
+}
+
+// GetTeamQuota godoc
+// @Summary Get team quota usage
+// @Description Get a team's reserved containers, memory and CPU against its quota. Containers without limits count with the default reservation.
+// @Tags Teams
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Team ID"
+// @Success 200 {object} utils.APIResponse{data=service.TeamQuotaStatus} "Quota usage"
+// @Failure 400 {object} utils.APIResponse "Invalid team ID"
+// @Failure 404 {object} utils.APIResponse "Team not found"
+// @Router /api/teams/{id}/quota [get]
+func (tc *TeamController) GetTeamQuota(c *gin.Context) {
+	id, ok := teamID(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	status, err := tc.teamService.GetQuota(c.Request.Context(), id)
+	if err != nil {
+		tc.respondError(rb, err, "Failed to get team quota")
+		return
+	}
+
+	rb.Success(status)
+}
+
+// SetTeamQuota godoc
+// @Summary Set team quota
+// @Description Replace a team's quota; omitted limits are unlimited. Lowering a limit below current usage blocks growth but leaves existing containers alone.
+// @Tags Teams
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Team ID"
+// @Param request body service.TeamQuotaRequest true "Quota"
+// @Success 200 {object} utils.APIResponse{data=service.TeamQuotaStatus} "Quota updated"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Team not found"
+// @Router /api/teams/{id}/quota [put]
+func (tc *TeamController) SetTeamQuota(c *gin.Context) {
+	id, ok := teamID(c)
+	if !ok {
+		return
+	}
+
+	var req service.TeamQuotaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	status, err := tc.teamService.SetQuota(c.Request.Context(), middleware.CurrentActor(c), id, &req)
+	if err != nil {
+		tc.respondError(rb, err, "Failed to set team quota")
+		return
+	}
+
+	rb.Success(status)
+}
+
+func teamID(c *gin.Context) (int, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		utils.BadRequestJSON(c, "Invalid team ID")
+		return 0, false
+	}
+	return id, true
+}
+
+// respondError maps team service errors onto HTTP responses
+func (tc *TeamController) respondError(rb *utils.ResponseBuilder, err error, message string) {
+	tc.logger.WithError(err).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Team not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -3,10 +3,11 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
+	"docker-auto/internal/dto"
 	"docker-auto/internal/middleware"
-	"docker-auto/internal/model"
 	"docker-auto/internal/service"
 	"docker-auto/pkg/utils"
 
@@ -30,137 +31,24 @@ func NewUpdateController(containerService *service.ContainerService, imageServic
 	}
 }
 
-// GetUpdateHistory godoc
-// @Summary Get update history
-// @Description Get paginated list of update history records
-// @Tags Updates
-// @Produce json
-// @Security BearerAuth
-// @Param page query int false "Page number" default(1)
-// @Param limit query int false "Items per page" default(20)
-// @Param container_id query int false "Filter by container ID"
-// @Param status query string false "Filter by status"
-// @Param start_date query string false "Filter by start date (RFC3339)"
-// @Param end_date query string false "Filter by end date (RFC3339)"
-// @Param sort_by query string false "Sort field" default(started_at)
-// @Param sort_order query string false "Sort order (asc/desc)" default(desc)
-// @Success 200 {object} utils.APIResponse{data=[]model.UpdateHistory} "Update history"
-// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
-// @Failure 401 {object} utils.APIResponse "Unauthorized"
-// @Failure 500 {object} utils.APIResponse "Internal server error"
-// @Router /api/updates/history [get]
-func (uc *UpdateController) GetUpdateHistory(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
-
-	// Parse query parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
-	containerIDStr := c.Query("container_id")
-	status := c.Query("status")
-	startDateStr := c.Query("start_date")
-	endDateStr := c.Query("end_date")
-	sortBy := c.DefaultQuery("sort_by", "started_at")
-	sortOrder := c.DefaultQuery("sort_order", "desc")
-
-	// Validate pagination parameters
-	if page < 1 {
-		page = 1
-	}
-	if limit < 1 || limit > 100 {
-		limit = 20
-	}
-
-	// Parse container ID if provided
-	var containerID *int64
-	if containerIDStr != "" {
-		if id, err := strconv.ParseInt(containerIDStr, 10, 64); err == nil {
-			containerID = &id
-		} else {
-			utils.BadRequestJSON(c, "Invalid container ID")
-			return
-		}
-	}
-
-	// Parse dates if provided
-	var startDate, endDate *time.Time
-	if startDateStr != "" {
-		if parsed, err := time.Parse(time.RFC3339, startDateStr); err == nil {
-			startDate = &parsed
-		} else {
-			utils.BadRequestJSON(c, "Invalid start date format (use RFC3339)")
-			return
-		}
-	}
-	if endDateStr != "" {
-		if parsed, err := time.Parse(time.RFC3339, endDateStr); err == nil {
-			endDate = &parsed
-		} else {
-			utils.BadRequestJSON(c, "Invalid end date format (use RFC3339)")
-			return
-		}
-	}
-
-	// Build filter
-	filter := &model.UpdateHistoryFilter{
-		ContainerID: containerID,
-		Status:      status,
-		StartDate:   startDate,
-		EndDate:     endDate,
-		Limit:       limit,
-		Offset:      (page - 1) * limit,
-		OrderBy:     sortBy,
-	}
-
-	if sortOrder == "desc" {
-		filter.OrderBy += " DESC"
-	}
-
-	rb := utils.NewResponseBuilder(c)
-
-	// For now, return empty list as we need to implement the update history repository methods
-	// In a real implementation, you'd call something like:
-	// history, total, err := uc.updateHistoryRepo.List(c.Request.Context(), filter)
-	uc.logger.WithFields(logrus.Fields{
-		"user_id":      userID,
-		"container_id": containerID,
-		"status":       status,
-		"page":         page,
-		"limit":        limit,
-	}).Info("Update history requested (placeholder implementation)")
-
-	// Placeholder response
-	emptyHistory := []interface{}{}
-	pagination := utils.CreatePagination(page, limit, 0)
-
-	rb.SuccessWithPagination(emptyHistory, pagination)
-}
-
 // TriggerBatchUpdate godoc
 // @Summary Trigger batch updates
-// @Description Trigger updates for multiple containers
+// @Description Trigger updates for multiple containers. With dry_run the updates are planned, and each result carries its plan.
 // @Tags Updates
 // @Accept json
 // @Produce json
 // @Security BearerAuth
-// @Param request body service.BulkUpdateRequest true "Batch update request"
-// @Success 200 {object} utils.APIResponse{data=[]service.OperationResult} "Update results"
+// @Param request body dto.BulkUpdateRequest true "Batch update request"
+// @Success 200 {object} utils.APIResponse{data=[]dto.OperationResult} "Update results"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/batch [post]
 func (uc *UpdateController) TriggerBatchUpdate(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
-	var req service.BulkUpdateRequest
+	var req dto.BulkUpdateRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		uc.logger.WithError(err).WithField("user_id", userID).Warn("Invalid batch update request")
 		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
@@ -186,22 +74,35 @@ func (uc *UpdateController) TriggerBatchUpdate(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	results := make([]service.OperationResult, 0, len(req.ContainerIDs))
+	results := make([]dto.OperationResult, 0, len(req.ContainerIDs))
 
 	// Process each container
 	for _, containerID := range req.ContainerIDs {
-		result := service.OperationResult{
+		result := dto.OperationResult{
 			ContainerID: containerID,
 			Success:     false,
 		}
 
 		// Get container name for result
-		if container, err := uc.containerService.GetContainer(c.Request.Context(), userID, containerID); err == nil {
+		if container, err := uc.containerService.GetContainer(c.Request.Context(), middleware.CurrentActor(c), containerID, false); err == nil {
 			result.Name = container.Container.Name
 		}
 
+		if req.IsDryRun() {
+			plan, err := uc.containerService.PlanContainerUpdate(c.Request.Context(), middleware.CurrentActor(c), containerID, req.UpdateImage)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Success = true
+				result.Message = "Update planned"
+				result.Plan = plan
+			}
+			results = append(results, result)
+			continue
+		}
+
 		// Trigger update
-		updateHistory, err := uc.containerService.UpdateContainerImage(c.Request.Context(), userID, containerID, req.UpdateImage)
+		updateHistory, err := uc.containerService.UpdateContainerImage(c.Request.Context(), middleware.CurrentActor(c), containerID, req.UpdateImage)
 		if err != nil {
 			result.Error = err.Error()
 			uc.logger.WithError(err).WithFields(logrus.Fields{
@@ -251,11 +152,7 @@ func (uc *UpdateController) TriggerBatchUpdate(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/status [get]
 func (uc *UpdateController) GetUpdateStatus(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	activeOnly, _ := strconv.ParseBool(c.DefaultQuery("active_only", "false"))
 
@@ -315,11 +212,7 @@ func (uc *UpdateController) GetUpdateStatus(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/rollback/{id} [post]
 func (uc *UpdateController) RollbackUpdate(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	updateIDStr := c.Param("id")
 	updateID, err := strconv.ParseInt(updateIDStr, 10, 64)
@@ -368,7 +261,7 @@ func (uc *UpdateController) RollbackUpdate(c *gin.Context) {
 
 // GetUpdateDetails godoc
 // @Summary Get update details
-// @Description Get detailed information about a specific update
+// @Description Get detailed information about a specific update, with the step checklist of resumable updates
 // @Tags Updates
 // @Produce json
 // @Security BearerAuth
@@ -376,15 +269,12 @@ func (uc *UpdateController) RollbackUpdate(c *gin.Context) {
 // @Success 200 {object} utils.APIResponse{data=model.UpdateHistory} "Update details"
 // @Failure 400 {object} utils.APIResponse "Invalid update ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 404 {object} utils.APIResponse "Update not found"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/{id} [get]
 func (uc *UpdateController) GetUpdateDetails(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	updateIDStr := c.Param("id")
 	updateID, err := strconv.ParseInt(updateIDStr, 10, 64)
@@ -395,14 +285,64 @@ func (uc *UpdateController) GetUpdateDetails(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	// For now, this is a placeholder implementation
-	// In a real implementation, you would query the update history repository
-	uc.logger.WithFields(logrus.Fields{
-		"user_id":   userID,
-		"update_id": updateID,
-	}).Info("Update details requested (placeholder implementation)")
+	history, err := uc.containerService.GetUpdate(c.Request.Context(), middleware.CurrentActor(c), updateID)
+	if err != nil {
+		uc.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":   userID,
+			"update_id": updateID,
+		}).Error("Failed to get update details")
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			rb.NotFound("Update not found")
+		case strings.Contains(err.Error(), "access denied"):
+			rb.Forbidden("Access denied")
+		default:
+			rb.InternalServerError("Failed to get update details")
+		}
+		return
+	}
+
+	rb.Success(history)
+}
+
+// GetUpdateProgress godoc
+// @Summary Get update progress
+// @Description Get the status of an update and, while it runs, the progress of its image pull aggregated over the layers. The same progress is pushed on the event stream as update_progress events in the pulling_image step.
+// @Tags Updates
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Update History ID"
+// @Success 200 {object} utils.APIResponse{data=dto.UpdateProgress} "Update progress"
+// @Failure 400 {object} utils.APIResponse "Invalid update ID"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Update not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/updates/{id}/progress [get]
+func (uc *UpdateController) GetUpdateProgress(c *gin.Context) {
+	updateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid update ID")
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	progress, err := uc.containerService.GetUpdateProgress(c.Request.Context(), middleware.CurrentActor(c), updateID)
+	if err != nil {
+		uc.logger.WithError(err).WithField("update_id", updateID).Error("Failed to get update progress")
+		switch {
+		case strings.Contains(err.Error(), "not found"):
+			rb.NotFound("Update not found")
+		case strings.Contains(err.Error(), "access denied"):
+			rb.Forbidden("Access denied")
+		default:
+			rb.InternalServerError("Failed to get update progress")
+		}
+		return
+	}
 
-	rb.Error(http.StatusNotImplemented, "Update details retrieval not yet implemented")
+	rb.Success(progress)
 }
 
 // CancelUpdate godoc
@@ -420,11 +360,7 @@ func (uc *UpdateController) GetUpdateDetails(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/{id}/cancel [post]
 func (uc *UpdateController) CancelUpdate(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	updateIDStr := c.Param("id")
 	updateID, err := strconv.ParseInt(updateIDStr, 10, 64)
@@ -463,11 +399,7 @@ func (uc *UpdateController) CancelUpdate(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/metrics [get]
 func (uc *UpdateController) GetUpdateMetrics(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	period := c.DefaultQuery("period", "7d")
 
@@ -504,9 +436,10 @@ func (uc *UpdateController) GetUpdateMetrics(c *gin.Context) {
 		"success_rate": 0.0,
 		"average_duration": "0s",
 		"by_strategy": map[string]int{
-			"recreate":   0,
-			"rolling":    0,
-			"blue_green": 0,
+			"recreate":     0,
+			"rolling":      0,
+			"blue_green":   0,
+			"health_gated": 0,
 		},
 		"by_status": map[string]int{
 			"completed": 0,
@@ -533,16 +466,12 @@ func (uc *UpdateController) GetUpdateMetrics(c *gin.Context) {
 // @Produce json
 // @Security BearerAuth
 // @Param force query boolean false "Force refresh cache" default(false)
-// @Success 200 {object} utils.APIResponse{data=[]service.UpdateInfo} "Available updates"
+// @Success 200 {object} utils.APIResponse{data=[]service.ImageUpdateInfo} "Available updates"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/available [get]
 func (uc *UpdateController) CheckAvailableUpdates(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	force, _ := strconv.ParseBool(c.DefaultQuery("force", "false"))
 
@@ -557,7 +486,7 @@ func (uc *UpdateController) CheckAvailableUpdates(c *gin.Context) {
 	}
 
 	// Filter to only return containers with available updates
-	availableUpdates := make([]*service.UpdateInfo, 0)
+	availableUpdates := make([]*service.ImageUpdateInfo, 0)
 	for _, updateInfo := range updateInfos {
 		if updateInfo.UpdateAvailable {
 			availableUpdates = append(availableUpdates, updateInfo)
@@ -589,18 +518,14 @@ func (uc *UpdateController) CheckAvailableUpdates(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/updates/schedule [post]
 func (uc *UpdateController) ScheduleUpdate(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	var req struct {
-		ContainerIDs  []int64                      `json:"container_ids" binding:"required"`
-		ScheduledTime time.Time                    `json:"scheduled_time" binding:"required"`
-		UpdateImage   *service.UpdateImageRequest  `json:"update_image,omitempty"`
-		Config        map[string]interface{}       `json:"config,omitempty"`
-		Description   string                       `json:"description,omitempty"`
+		ContainerIDs  []int64                 `json:"container_ids" binding:"required"`
+		ScheduledTime time.Time               `json:"scheduled_time" binding:"required"`
+		UpdateImage   *dto.UpdateImageRequest `json:"update_image,omitempty"`
+		Config        map[string]interface{}  `json:"config,omitempty"`
+		Description   string                  `json:"description,omitempty"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
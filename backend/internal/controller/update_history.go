@@ -0,0 +1,234 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/middleware"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// ListUpdates godoc
+// @Summary List update history
+// @Description List the updates of the caller's containers, newest first, with page or cursor pagination. Each entry carries the time from start to completion; an update recorded as finished without a completion time has display_status "interrupted" and no elapsed time.
+// @Tags Updates
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param cursor query string false "Opaque cursor from next_cursor; use instead of page (empty for the first page)"
+// @Param limit query int false "Items per page" default(20)
+// @Param container_id query int false "Filter by container ID"
+// @Param status query string false "Filter by status"
+// @Param triggered_by query string false "Filter by trigger (auto, manual, schedule, webhook, retarget, converge)"
+// @Param user_id query int false "Filter by the user who started the update"
+// @Param image query string false "Filter by old or new image containing the text"
+// @Param start_date query string false "Updates started at or after (RFC3339)"
+// @Param end_date query string false "Updates started at or before (RFC3339)"
+// @Success 200 {object} utils.APIResponse{data=[]dto.UpdateHistoryEntry} "Update history"
+// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/updates [get]
+// @Router /api/updates/history [get]
+func (uc *UpdateController) ListUpdates(c *gin.Context) {
+	filter, ok := parseUpdateHistoryFilter(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	response, err := uc.containerService.ListUpdateHistory(c.Request.Context(), middleware.CurrentActor(c), filter)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid request:") {
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+			return
+		}
+		uc.logger.WithError(err).WithField("user_id", middleware.CurrentUserID(c)).Error("Failed to list update history")
+		rb.InternalServerError("Failed to list update history")
+		return
+	}
+
+	rb.SuccessWithPagination(response.Updates, updateHistoryPagination(c, response))
+}
+
+// GetUpdateSummary godoc
+// @Summary Summarize update history
+// @Description Count the updates of the caller's containers per status and average the duration of those with a completion time. Takes the filters of the update history list; updates recorded as finished without a completion time are counted as interrupted and left out of the average.
+// @Tags Updates
+// @Produce json
+// @Security BearerAuth
+// @Param container_id query int false "Filter by container ID"
+// @Param status query string false "Filter by status"
+// @Param triggered_by query string false "Filter by trigger"
+// @Param user_id query int false "Filter by the user who started the update"
+// @Param image query string false "Filter by old or new image containing the text"
+// @Param start_date query string false "Updates started at or after (RFC3339)"
+// @Param end_date query string false "Updates started at or before (RFC3339)"
+// @Success 200 {object} utils.APIResponse{data=model.UpdateHistorySummary} "Update summary"
+// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/updates/summary [get]
+func (uc *UpdateController) GetUpdateSummary(c *gin.Context) {
+	filter, ok := parseUpdateHistoryFilter(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	summary, err := uc.containerService.SummarizeUpdateHistory(c.Request.Context(), middleware.CurrentActor(c), filter)
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "invalid request:") {
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+			return
+		}
+		uc.logger.WithError(err).WithField("user_id", middleware.CurrentUserID(c)).Error("Failed to summarize update history")
+		rb.InternalServerError("Failed to summarize update history")
+		return
+	}
+
+	rb.Success(summary)
+}
+
+// GetContainerUpdateHistory godoc
+// @Summary List container update history
+// @Description List the updates of a container, newest first, with the filters and pagination of the update history list
+// @Tags Containers
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Container ID"
+// @Param page query int false "Page number" default(1)
+// @Param cursor query string false "Opaque cursor from next_cursor; use instead of page (empty for the first page)"
+// @Param limit query int false "Items per page" default(20)
+// @Param status query string false "Filter by status"
+// @Param triggered_by query string false "Filter by trigger"
+// @Param user_id query int false "Filter by the user who started the update"
+// @Param image query string false "Filter by old or new image containing the text"
+// @Param start_date query string false "Updates started at or after (RFC3339)"
+// @Param end_date query string false "Updates started at or before (RFC3339)"
+// @Success 200 {object} utils.APIResponse{data=[]dto.UpdateHistoryEntry} "Update history"
+// @Failure 400 {object} utils.APIResponse "Invalid request parameters"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Container not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/containers/{id}/history [get]
+func (cc *ContainerController) GetContainerUpdateHistory(c *gin.Context) {
+	containerID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid container ID")
+		return
+	}
+
+	filter, ok := parseUpdateHistoryFilter(c)
+	if !ok {
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	response, err := cc.containerService.GetContainerUpdateHistory(c.Request.Context(), middleware.CurrentActor(c), containerID, filter)
+	if err != nil {
+		cc.logger.WithError(err).WithFields(logrus.Fields{
+			"user_id":      middleware.CurrentUserID(c),
+			"container_id": containerID,
+		}).Error("Failed to get container update history")
+		switch {
+		case strings.HasPrefix(err.Error(), "invalid request:"):
+			rb.BadRequest(strings.TrimPrefix(err.Error(), "invalid request: "))
+		case strings.HasPrefix(err.Error(), "access denied"):
+			rb.Forbidden("Access denied")
+		case strings.Contains(err.Error(), "not found"):
+			rb.NotFound("Container not found")
+		default:
+			rb.InternalServerError("Failed to get container update history")
+		}
+		return
+	}
+
+	rb.SuccessWithPagination(response.Updates, updateHistoryPagination(c, response))
+}
+
+// parseUpdateHistoryFilter reads the update history filters and pagination
+// from the query, answering 400 and returning false when one is invalid
+func parseUpdateHistoryFilter(c *gin.Context) (*model.UpdateHistoryFilter, bool) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	// Validate pagination parameters
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	filter := &model.UpdateHistoryFilter{
+		Status:      model.UpdateStatus(c.Query("status")),
+		TriggeredBy: model.TriggerType(c.Query("triggered_by")),
+		Image:       c.Query("image"),
+		Limit:       limit,
+		Offset:      (page - 1) * limit,
+	}
+
+	if containerIDStr := c.Query("container_id"); containerIDStr != "" {
+		containerID, err := strconv.Atoi(containerIDStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid container ID")
+			return nil, false
+		}
+		filter.ContainerID = &containerID
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		userID, err := strconv.Atoi(userIDStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid user ID")
+			return nil, false
+		}
+		filter.CreatedBy = &userID
+	}
+	if startDateStr := c.Query("start_date"); startDateStr != "" {
+		startDate, err := time.Parse(time.RFC3339, startDateStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid start date format (use RFC3339)")
+			return nil, false
+		}
+		filter.StartedAfter = &startDate
+	}
+	if endDateStr := c.Query("end_date"); endDateStr != "" {
+		endDate, err := time.Parse(time.RFC3339, endDateStr)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid end date format (use RFC3339)")
+			return nil, false
+		}
+		filter.StartedBefore = &endDate
+	}
+
+	// Cursor pagination: ?cursor= (empty for the first page) replaces page
+	if cursorToken, useCursor := c.GetQuery("cursor"); useCursor {
+		cursor, err := model.DecodeCursor(cursorToken)
+		if err != nil {
+			utils.BadRequestJSON(c, "Invalid cursor")
+			return nil, false
+		}
+		filter.Cursor = cursor
+	}
+
+	return filter, true
+}
+
+// updateHistoryPagination describes the page of an update history list
+func updateHistoryPagination(c *gin.Context, response *dto.UpdateHistoryListResponse) *utils.Pagination {
+	if cursorToken, useCursor := c.GetQuery("cursor"); useCursor {
+		return utils.CreateCursorPagination(response.Limit, cursorToken, response.NextCursor)
+	}
+	return utils.CreatePagination(response.Page, response.Limit, response.Total)
+}
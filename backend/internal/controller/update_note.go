@@ -0,0 +1,110 @@
+package controller
+
+import (
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/middleware"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// AddUpdateNote godoc
+// @Summary Add a note to an update
+// @Description Attach free text to an update, e.g. who it was coordinated with. Notes are shown in the update details and the compliance export.
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Update History ID"
+// @Param request body service.UpdateNoteRequest true "Note"
+// @Success 201 {object} utils.APIResponse{data=model.UpdateNote} "Note added"
+// @Failure 400 {object} utils.APIResponse "Invalid note"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 404 {object} utils.APIResponse "Update not found"
+// @Router /api/updates/{id}/notes [post]
+func (uc *UpdateController) AddUpdateNote(c *gin.Context) {
+	updateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid update ID")
+		return
+	}
+
+	var req service.UpdateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	note, err := uc.containerService.AddUpdateNote(c.Request.Context(), middleware.CurrentActor(c), updateID, &req)
+	if err != nil {
+		uc.respondNoteError(rb, err, updateID, "Failed to add update note")
+		return
+	}
+
+	rb.Created(note)
+}
+
+// EditUpdateNote godoc
+// @Summary Edit an update note
+// @Description Replace the body of a note. Authors can edit their notes for a configurable time after adding them; admins can edit any note.
+// @Tags Updates
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Update History ID"
+// @Param noteId path int true "Note ID"
+// @Param request body service.UpdateNoteRequest true "Note"
+// @Success 200 {object} utils.APIResponse{data=model.UpdateNote} "Note updated"
+// @Failure 400 {object} utils.APIResponse "Invalid note"
+// @Failure 403 {object} utils.APIResponse "Not the author, or the edit window has passed"
+// @Failure 404 {object} utils.APIResponse "Update or note not found"
+// @Router /api/updates/{id}/notes/{noteId} [put]
+func (uc *UpdateController) EditUpdateNote(c *gin.Context) {
+	updateID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid update ID")
+		return
+	}
+	noteID, err := strconv.Atoi(c.Param("noteId"))
+	if err != nil || noteID <= 0 {
+		utils.BadRequestJSON(c, "Invalid note ID")
+		return
+	}
+
+	var req service.UpdateNoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequestJSON(c, "Invalid request format: "+err.Error())
+		return
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	note, err := uc.containerService.EditUpdateNote(c.Request.Context(), middleware.CurrentActor(c), updateID, noteID, &req)
+	if err != nil {
+		uc.respondNoteError(rb, err, updateID, "Failed to edit update note")
+		return
+	}
+
+	rb.Success(note)
+}
+
+// respondNoteError maps update note errors onto HTTP responses
+func (uc *UpdateController) respondNoteError(rb *utils.ResponseBuilder, err error, updateID int64, message string) {
+	uc.logger.WithError(err).WithField("update_id", updateID).Error(message)
+
+	switch {
+	case strings.HasPrefix(err.Error(), "invalid request"):
+		rb.BadRequest(err.Error())
+	case strings.HasPrefix(err.Error(), "access denied"):
+		rb.Forbidden(err.Error())
+	case strings.Contains(err.Error(), "not found"):
+		rb.NotFound("Update or note not found")
+	default:
+		rb.InternalServerError(message)
+	}
+}
@@ -3,9 +3,12 @@ package controller
 import (
 	"net/http"
 	"strconv"
+	"strings"
 
 	"docker-auto/internal/middleware"
 	"docker-auto/internal/service"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -18,6 +21,16 @@ type UserController struct {
 	logger      *logrus.Logger
 }
 
+// userSortColumns are the columns users can be listed by
+var userSortColumns = map[string]bool{
+	"username":      true,
+	"email":         true,
+	"role":          true,
+	"created_at":    true,
+	"updated_at":    true,
+	"last_login_at": true,
+}
+
 // NewUserController creates a new user controller
 func NewUserController(userService *service.UserService, logger *logrus.Logger) *UserController {
 	return &UserController{
@@ -38,6 +51,7 @@ func NewUserController(userService *service.UserService, logger *logrus.Logger)
 // @Success 200 {object} utils.APIResponse{data=service.LoginResponse} "Login successful"
 // @Failure 400 {object} utils.APIResponse "Invalid request"
 // @Failure 401 {object} utils.APIResponse "Invalid credentials"
+// @Failure 403 {object} utils.APIResponse "Password login is disabled"
 // @Failure 429 {object} utils.APIResponse "Rate limit exceeded"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/auth/login [post]
@@ -52,8 +66,15 @@ func (uc *UserController) Login(c *gin.Context) {
 	// Create response builder for consistent formatting
 	rb := utils.NewResponseBuilder(c)
 
+	req.IPAddress = c.ClientIP()
+	req.UserAgent = c.Request.UserAgent()
+
 	// Call user service to authenticate
 	response, err := uc.userService.Login(c.Request.Context(), &req)
+	if apperrors.HasCode(err, apperrors.CodePermissionDenied) {
+		rb.Forbidden("Password login is disabled; sign in through single sign-on")
+		return
+	}
 	if err != nil {
 		uc.logger.WithError(err).WithFields(logrus.Fields{
 			"username":  req.Username,
@@ -84,13 +105,12 @@ func (uc *UserController) Login(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/auth/logout [post]
 func (uc *UserController) Logout(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	sessionID := c.GetHeader("Session-ID") // Optional session ID for specific session logout
+	if sessionID == "" {
+		sessionID = currentSessionID(c)
+	}
 
 	rb := utils.NewResponseBuilder(c)
 
@@ -115,11 +135,7 @@ func (uc *UserController) Logout(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/auth/profile [get]
 func (uc *UserController) GetProfile(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	rb := utils.NewResponseBuilder(c)
 
@@ -160,11 +176,7 @@ func (uc *UserController) GetProfile(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/auth/profile [put]
 func (uc *UserController) UpdateProfile(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	var req service.UpdateProfileRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -203,11 +215,7 @@ func (uc *UserController) UpdateProfile(c *gin.Context) {
 // @Failure 500 {object} utils.APIResponse "Internal server error"
 // @Router /api/auth/password [put]
 func (uc *UserController) ChangePassword(c *gin.Context) {
-	userID, exists := middleware.GetUserIDFromContext(c)
-	if !exists {
-		utils.UnauthorizedJSON(c, "Authentication required")
-		return
-	}
+	userID := middleware.CurrentUserID(c)
 
 	var req service.ChangePasswordRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -371,15 +379,21 @@ func (uc *UserController) ListUsers(c *gin.Context) {
 		}
 	}
 
-	// Build filter
-	filter := &service.UserFilter{
-		Search:    search,
-		Role:      role,
-		IsActive:  isActive,
-		SortBy:    sortBy,
-		SortOrder: sortOrder,
-		Page:      page,
-		Limit:     limit,
+	// Build filter; the sort column is interpolated into SQL, so only
+	// known columns are accepted
+	filter := &model.UserFilter{
+		Username: search,
+		Role:     model.UserRole(role),
+		IsActive: isActive,
+		Limit:    limit,
+		Offset:   (page - 1) * limit,
+	}
+	if userSortColumns[sortBy] {
+		direction := "DESC"
+		if strings.EqualFold(sortOrder, "asc") {
+			direction = "ASC"
+		}
+		filter.OrderBy = sortBy + " " + direction
 	}
 
 	rb := utils.NewResponseBuilder(c)
@@ -590,6 +604,76 @@ func (uc *UserController) ChangeUserPassword(c *gin.Context) {
 	rb.Error(http.StatusNotImplemented, "Admin password change not yet implemented")
 }
 
+// ListSessions godoc
+// @Summary List own sessions
+// @Description Get the active login sessions of the authenticated user, flagging the one of the request
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse{data=[]service.SessionInfo} "Active sessions"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/auth/sessions [get]
+func (uc *UserController) ListSessions(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	rb := utils.NewResponseBuilder(c)
+
+	sessions, err := uc.userService.ListSessions(c.Request.Context(), userID, currentSessionID(c))
+	if err != nil {
+		uc.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user sessions")
+		rb.InternalServerError("Failed to retrieve sessions")
+		return
+	}
+
+	rb.Success(sessions)
+}
+
+// RevokeSession godoc
+// @Summary Revoke own session
+// @Description Revoke a login session of the authenticated user; its tokens stop being accepted immediately
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Session ID"
+// @Success 200 {object} utils.APIResponse "Session revoked successfully"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 404 {object} utils.APIResponse "Session not found"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/auth/sessions/{id} [delete]
+func (uc *UserController) RevokeSession(c *gin.Context) {
+	uc.revokeSession(c, middleware.CurrentUserID(c), c.Param("id"))
+}
+
+// RevokeOtherSessions godoc
+// @Summary Revoke other sessions
+// @Description Revoke every login session of the authenticated user except the one of the request
+// @Tags Authentication
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} utils.APIResponse "Other sessions revoked successfully"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/auth/sessions [delete]
+func (uc *UserController) RevokeOtherSessions(c *gin.Context) {
+	userID := middleware.CurrentUserID(c)
+
+	rb := utils.NewResponseBuilder(c)
+
+	revoked, err := uc.userService.RevokeOtherSessions(c.Request.Context(), middleware.CurrentActor(c), userID, currentSessionID(c))
+	if err != nil {
+		uc.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke other sessions")
+		rb.FromError(err, "Failed to revoke sessions")
+		return
+	}
+
+	uc.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"revoked": revoked,
+	}).Info("Other user sessions revoked successfully")
+	rb.SuccessWithMessage(gin.H{"revoked": revoked}, "Other sessions revoked successfully")
+}
+
 // GetUserSessions godoc
 // @Summary Get user active sessions
 // @Description Get list of active sessions for a user
@@ -601,9 +685,8 @@ func (uc *UserController) ChangeUserPassword(c *gin.Context) {
 // @Failure 400 {object} utils.APIResponse "Invalid user ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
-// @Failure 404 {object} utils.APIResponse "User not found"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
-// @Router /api/users/{id}/sessions [get]
+// @Router /api/admin/users/{id}/sessions [get]
 func (uc *UserController) GetUserSessions(c *gin.Context) {
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
@@ -614,27 +697,18 @@ func (uc *UserController) GetUserSessions(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	sessions, err := uc.userService.GetActiveSessions(c.Request.Context(), userID)
+	current := ""
+	if userID == middleware.CurrentUserID(c) {
+		current = currentSessionID(c)
+	}
+	sessions, err := uc.userService.ListSessions(c.Request.Context(), userID, current)
 	if err != nil {
 		uc.logger.WithError(err).WithField("user_id", userID).Error("Failed to get user sessions")
 		rb.InternalServerError("Failed to retrieve sessions")
 		return
 	}
 
-	// Convert to response format
-	sessionResponses := make([]*service.SessionInfo, len(sessions))
-	for i, session := range sessions {
-		sessionResponses[i] = &service.SessionInfo{
-			ID:        session.ID,
-			UserID:    session.UserID,
-			IPAddress: session.IPAddress,
-			UserAgent: session.UserAgent,
-			ExpiresAt: session.ExpiresAt,
-			CreatedAt: session.CreatedAt,
-		}
-	}
-
-	rb.Success(sessionResponses)
+	rb.Success(sessions)
 }
 
 // RevokeUserSession godoc
@@ -651,9 +725,20 @@ func (uc *UserController) GetUserSessions(c *gin.Context) {
 // @Failure 403 {object} utils.APIResponse "Forbidden"
 // @Failure 404 {object} utils.APIResponse "Session not found"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
-// @Router /api/users/{id}/sessions/{sessionId} [delete]
+// @Router /api/admin/users/{id}/sessions/{sessionId} [delete]
 func (uc *UserController) RevokeUserSession(c *gin.Context) {
-	sessionID := c.Param("sessionId")
+	userIDStr := c.Param("id")
+	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	if err != nil {
+		utils.BadRequestJSON(c, "Invalid user ID")
+		return
+	}
+
+	uc.revokeSession(c, userID, c.Param("sessionId"))
+}
+
+// revokeSession revokes a session of userID on behalf of the caller
+func (uc *UserController) revokeSession(c *gin.Context, userID int64, sessionID string) {
 	if sessionID == "" {
 		utils.BadRequestJSON(c, "Session ID is required")
 		return
@@ -661,23 +746,22 @@ func (uc *UserController) RevokeUserSession(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	if err := uc.userService.RevokeSession(c.Request.Context(), sessionID); err != nil {
+	if err := uc.userService.RevokeSession(c.Request.Context(), middleware.CurrentActor(c), userID, sessionID); err != nil {
 		uc.logger.WithError(err).WithField("session_id", sessionID).Error("Failed to revoke session")
-		if err.Error() == "session not found" {
-			rb.NotFound("Session not found")
-			return
-		}
-		rb.InternalServerError("Failed to revoke session")
+		rb.FromError(err, "Failed to revoke session")
 		return
 	}
 
-	uc.logger.WithField("session_id", sessionID).Info("Session revoked successfully")
+	uc.logger.WithFields(logrus.Fields{
+		"user_id":    userID,
+		"session_id": sessionID,
+	}).Info("Session revoked successfully")
 	rb.SuccessWithMessage(nil, "Session revoked successfully")
 }
 
 // RevokeAllUserSessions godoc
 // @Summary Revoke all user sessions
-// @Description Revoke all active sessions for a user
+// @Description Revoke all active sessions for a user, logging them out everywhere
 // @Tags Users
 // @Produce json
 // @Security BearerAuth
@@ -686,9 +770,8 @@ func (uc *UserController) RevokeUserSession(c *gin.Context) {
 // @Failure 400 {object} utils.APIResponse "Invalid user ID"
 // @Failure 401 {object} utils.APIResponse "Unauthorized"
 // @Failure 403 {object} utils.APIResponse "Forbidden"
-// @Failure 404 {object} utils.APIResponse "User not found"
 // @Failure 500 {object} utils.APIResponse "Internal server error"
-// @Router /api/users/{id}/sessions [delete]
+// @Router /api/admin/users/{id}/sessions [delete]
 func (uc *UserController) RevokeAllUserSessions(c *gin.Context) {
 	userIDStr := c.Param("id")
 	userID, err := strconv.ParseInt(userIDStr, 10, 64)
@@ -699,12 +782,24 @@ func (uc *UserController) RevokeAllUserSessions(c *gin.Context) {
 
 	rb := utils.NewResponseBuilder(c)
 
-	if err := uc.userService.RevokeAllSessions(c.Request.Context(), userID); err != nil {
+	revoked, err := uc.userService.RevokeOtherSessions(c.Request.Context(), middleware.CurrentActor(c), userID, "")
+	if err != nil {
 		uc.logger.WithError(err).WithField("user_id", userID).Error("Failed to revoke all sessions")
-		rb.InternalServerError("Failed to revoke sessions")
+		rb.FromError(err, "Failed to revoke sessions")
 		return
 	}
 
-	uc.logger.WithField("user_id", userID).Info("All user sessions revoked successfully")
-	rb.SuccessWithMessage(nil, "All sessions revoked successfully")
-}
\ No newline at end of file
+	uc.logger.WithFields(logrus.Fields{
+		"user_id": userID,
+		"revoked": revoked,
+	}).Info("All user sessions revoked successfully")
+	rb.SuccessWithMessage(gin.H{"revoked": revoked}, "All sessions revoked successfully")
+}
+
+// currentSessionID returns the login session of the request's JWT, if any
+func currentSessionID(c *gin.Context) string {
+	if claims := middleware.GetUserFromContext(c); claims != nil {
+		return claims.SessionID
+	}
+	return ""
+}
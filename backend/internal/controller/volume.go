@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/service"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// VolumeController handles volume usage endpoints
+type VolumeController struct {
+	volumeService *service.VolumeService
+	logger        *logrus.Logger
+}
+
+// NewVolumeController creates a new volume controller
+func NewVolumeController(volumeService *service.VolumeService, logger *logrus.Logger) *VolumeController {
+	return &VolumeController{
+		volumeService: volumeService,
+		logger:        logger,
+	}
+}
+
+// ListVolumes godoc
+// @Summary List volumes
+// @Description List a page of Docker volumes with their size from the last sampling run, the containers mounting them and their 7 and 30 day growth. Volumes skipped during sampling have a null size_bytes and a size_note such as "size unknown (too large to scan)". The daemon is read on every request; refreshed_at says when.
+// @Tags Volumes
+// @Produce json
+// @Security BearerAuth
+// @Param name query string false "Volume name glob, e.g. app_*"
+// @Param label query string false "Label key or key=value"
+// @Param driver query string false "Volume driver"
+// @Param status query string false "in_use or unused"
+// @Param sort query string false "name, size or created_at" default(name)
+// @Param order query string false "asc or desc" default(asc)
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(50)
+// @Success 200 {object} utils.APIResponse{data=service.VolumeList} "Volumes"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/volumes [get]
+func (vc *VolumeController) ListVolumes(c *gin.Context) {
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	rb := utils.NewResponseBuilder(c)
+
+	query := &dto.VolumeListQuery{
+		Name:   c.Query("name"),
+		Label:  c.Query("label"),
+		Driver: c.Query("driver"),
+		Status: c.Query("status"),
+		Sort:   c.Query("sort"),
+		Desc:   strings.EqualFold(c.Query("order"), "desc"),
+		Limit:  limit,
+		Offset: (page - 1) * limit,
+	}
+
+	list, err := vc.volumeService.ListVolumes(c.Request.Context(), query)
+	if err != nil {
+		vc.logger.WithError(err).Error("Failed to list volumes")
+		if strings.HasPrefix(err.Error(), "invalid request") {
+			rb.BadRequest(err.Error())
+			return
+		}
+		if respondDockerError(rb, err) {
+			return
+		}
+		rb.InternalServerError("Failed to list volumes")
+		return
+	}
+
+	rb.SuccessWithPagination(list, utils.CreatePagination(page, limit, list.Total))
+}
+
+// SampleVolumes godoc
+// @Summary Sample volume usage
+// @Description Measure the size of every volume now and evaluate the volume alert rules. Sampling is rate-limited by VOLUME_SAMPLE_MIN_INTERVAL_MINUTES.
+// @Tags Volumes
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body dto.VolumeSampleRequest false "Sampling options"
+// @Success 200 {object} utils.APIResponse{data=dto.VolumeSampleResult} "Sampling result"
+// @Failure 400 {object} utils.APIResponse "Invalid request"
+// @Failure 401 {object} utils.APIResponse "Unauthorized"
+// @Failure 403 {object} utils.APIResponse "Forbidden"
+// @Failure 409 {object} utils.APIResponse "Sampling already in progress"
+// @Failure 429 {object} utils.APIResponse "Sampled too recently"
+// @Failure 500 {object} utils.APIResponse "Internal server error"
+// @Router /api/volumes/sample [post]
+func (vc *VolumeController) SampleVolumes(c *gin.Context) {
+	rb := utils.NewResponseBuilder(c)
+
+	var req dto.VolumeSampleRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			rb.BadRequest("Invalid request format: " + err.Error())
+			return
+		}
+	}
+
+	result, err := vc.volumeService.Sample(c.Request.Context(), &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, dto.ErrVolumeSampleRateLimited):
+			rb.Error(http.StatusTooManyRequests, err.Error())
+		case errors.Is(err, dto.ErrVolumeSampleInProgress):
+			rb.Conflict(err.Error())
+		default:
+			vc.logger.WithError(err).Error("Failed to sample volume usage")
+			if respondDockerError(rb, err) {
+				return
+			}
+			rb.InternalServerError("Failed to sample volume usage")
+		}
+		return
+	}
+
+	rb.Success(result)
+}
@@ -0,0 +1,27 @@
+package dto
+
+import "docker-auto/pkg/model"
+
+// ActivityQuery filters and pages the activity log. From and To are RFC3339
+// timestamps or YYYY-MM-DD days; a day given as To is included.
+type ActivityQuery struct {
+	UserID       *int64 `form:"user_id"`
+	Action       string `form:"action"`
+	ResourceType string `form:"resource_type"`
+	ResourceID   *int   `form:"resource_id"`
+	From         string `form:"from"`
+	To           string `form:"to"`
+	Page         int    `form:"page"`
+	Limit        int    `form:"limit"`
+	Format       string `form:"format"` // Export only: csv or json
+}
+
+// ActivityListResponse represents a page of activity log entries
+type ActivityListResponse struct {
+	Activities []*model.ActivityLog `json:"activities"`
+	Total      int64                `json:"total"`
+	Page       int                  `json:"page"`
+	Limit      int                  `json:"limit"`
+	HasNext    bool                 `json:"has_next"`
+	HasPrev    bool                 `json:"has_prev"`
+}
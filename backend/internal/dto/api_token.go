@@ -0,0 +1,24 @@
+package dto
+
+import "docker-auto/pkg/model"
+
+// Expiry limits of personal access tokens, in days
+const (
+	DefaultAPITokenExpiryDays = 90
+	MaxAPITokenExpiryDays     = 365
+)
+
+// CreateAPITokenRequest creates a personal access token limited to scopes.
+// It expires after ExpiresInDays, DefaultAPITokenExpiryDays when unset.
+type CreateAPITokenRequest struct {
+	Name          string   `json:"name" binding:"required"`
+	Scopes        []string `json:"scopes" binding:"required"`
+	ExpiresInDays int      `json:"expires_in_days,omitempty"`
+}
+
+// CreatedAPIToken is a new personal access token with its value, which is
+// returned only this once
+type CreatedAPIToken struct {
+	*model.APIToken
+	Token string `json:"token"`
+}
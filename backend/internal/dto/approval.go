@@ -0,0 +1,31 @@
+package dto
+
+import "docker-auto/pkg/model"
+
+// Outcomes of evaluating the approval policies against a pending update
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+	ApprovalPending  = "pending"
+)
+
+// UpdateProposal is an update the update checker found for a container
+type UpdateProposal struct {
+	Container        *model.Container `json:"-"`
+	Tag              string           `json:"tag"`
+	Digest           string           `json:"digest,omitempty"`
+	UpdateType       string           `json:"update_type"`
+	IsSecurityUpdate bool             `json:"is_security_update"`
+}
+
+// ApprovalDecision is the outcome for a pending update. Policy is the
+// approver recorded for policy decisions, empty when the update was left for
+// a human.
+type ApprovalDecision struct {
+	UpdateID  int                      `json:"update_id"`
+	Outcome   string                   `json:"outcome"`
+	PolicyID  int                      `json:"policy_id,omitempty"`
+	Policy    string                   `json:"policy,omitempty"`
+	Applied   bool                     `json:"applied"`
+	Candidate *model.ApprovalCandidate `json:"candidate,omitempty"`
+}
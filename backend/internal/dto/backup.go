@@ -0,0 +1,90 @@
+package dto
+
+import (
+	"time"
+)
+
+// Backup components a restore can apply
+const (
+	BackupComponentDatabase         = "database"
+	BackupComponentContainerConfigs = "container_configs"
+	BackupComponentConfigurations   = "configurations"
+)
+
+// Actions a restore takes on a container or configuration file
+const (
+	RestoreActionCreate    = "create"
+	RestoreActionUpdate    = "update"
+	RestoreActionUnchanged = "unchanged"
+	RestoreActionConflict  = "conflict"
+)
+
+// BackupInfo describes a backup found under the backup storage path. The
+// type, sizes and file count come from its manifest, when it has one.
+type BackupInfo struct {
+	ID          string    `json:"id"`
+	CreatedAt   time.Time `json:"created_at"`
+	BackupType  string    `json:"backup_type,omitempty"`
+	TotalSize   int64     `json:"total_size"`
+	FileCount   int       `json:"file_count"`
+	HasManifest bool      `json:"has_manifest"`
+	// ManifestError is set when the manifest exists but cannot be read
+	ManifestError string   `json:"manifest_error,omitempty"`
+	Components    []string `json:"components"`
+}
+
+// RestoreBackupRequest selects what to restore from a backup. A dry run
+// returns what would change and a confirm token; the restore itself needs
+// that token, and fails if the backup or the affected containers changed
+// since the dry run.
+type RestoreBackupRequest struct {
+	Components   []string `json:"components" binding:"required,min=1"`
+	DryRun       bool     `json:"dry_run"`
+	ConfirmToken string   `json:"confirm_token,omitempty"`
+}
+
+// TableRestoreDiff compares the rows of a table now and in the backup
+type TableRestoreDiff struct {
+	Table       string `json:"table"`
+	CurrentRows int64  `json:"current_rows"`
+	BackupRows  int64  `json:"backup_rows"`
+}
+
+// DatabaseRestoreDiff describes the database dump of a backup. A restore
+// replaces every table with the dump's contents.
+type DatabaseRestoreDiff struct {
+	File   string             `json:"file"`
+	Format string             `json:"format"`
+	Tables []TableRestoreDiff `json:"tables"`
+}
+
+// ContainerRestoreChange is what a restore does to the container of that
+// name. Conflicts are skipped.
+type ContainerRestoreChange struct {
+	Name   string   `json:"name"`
+	Action string   `json:"action"`
+	Fields []string `json:"fields,omitempty"`
+	Reason string   `json:"reason,omitempty"`
+	Error  string   `json:"error,omitempty"`
+}
+
+// ConfigurationRestoreChange is what a restore does to a configuration file
+type ConfigurationRestoreChange struct {
+	File   string `json:"file"`
+	Action string `json:"action"`
+}
+
+// BackupRestoreResult is the diff of a restore and, unless it was a dry run,
+// its outcome
+type BackupRestoreResult struct {
+	BackupID       string                       `json:"backup_id"`
+	Components     []string                     `json:"components"`
+	DryRun         bool                         `json:"dry_run"`
+	Applied        bool                         `json:"applied"`
+	Database       *DatabaseRestoreDiff         `json:"database,omitempty"`
+	Containers     []ContainerRestoreChange     `json:"containers,omitempty"`
+	Configurations []ConfigurationRestoreChange `json:"configurations,omitempty"`
+	Conflicts      int                          `json:"conflicts"`
+	ConfirmToken   string                       `json:"confirm_token,omitempty"`
+	ExpiresAt      *time.Time                   `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,28 @@
+package dto
+
+// Sources of a configuration setting's value
+const (
+	ConfigSourceEnvironment = "environment"
+	ConfigSourceOverride    = "override"
+)
+
+// ConfigSetting is a configuration setting, named by its environment
+// variable, with the value in effect. Secrets that are set are redacted.
+type ConfigSetting struct {
+	Key        string      `json:"key"`
+	Value      interface{} `json:"value"`
+	Source     string      `json:"source"`
+	Reloadable bool        `json:"reloadable"`
+	Redacted   bool        `json:"redacted,omitempty"`
+}
+
+// ConfigSettings is the configuration in effect
+type ConfigSettings struct {
+	Settings []ConfigSetting `json:"settings"`
+}
+
+// PatchConfigRequest changes hot-reloadable settings by key. A null value
+// clears the override, restoring the value from the environment.
+type PatchConfigRequest struct {
+	Settings map[string]interface{} `json:"settings" binding:"required"`
+}
@@ -0,0 +1,979 @@
+// Package dto holds the requests, responses, filters and results passed
+// between controllers, services and scheduler tasks. It depends on the model
+// only, so any layer can import it.
+package dto
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/schedule"
+)
+
+// Container service request types
+
+// CreateContainerRequest represents a request to create a new container
+type CreateContainerRequest struct {
+	Name         string                 `json:"name" binding:"required" validate:"required,min=3,max=100"`
+	Image        string                 `json:"image" binding:"required" validate:"required,min=3,max=255"`
+	Tag          string                 `json:"tag" validate:"max=100"`
+	Config       map[string]interface{} `json:"config"`
+	UpdatePolicy string                 `json:"update_policy" binding:"omitempty,oneof=auto manual scheduled disabled inherit" validate:"oneof=auto manual scheduled disabled inherit"`
+	RegistryURL  string                 `json:"registry_url,omitempty" validate:"omitempty,url"`
+	RegistryAuth *RegistryAuth          `json:"registry_auth,omitempty"`
+
+	// Policy overrides; omitted settings are inherited from the image policy
+	CheckIntervalMinutes   *int                      `json:"check_interval_minutes,omitempty"`
+	HoldDownHours          *int                      `json:"hold_down_hours,omitempty"`
+	MaintenanceWindows     []model.MaintenanceWindow `json:"maintenance_windows,omitempty"`
+	VulnerabilityThreshold string                    `json:"vulnerability_threshold,omitempty"`
+
+	// VersionPolicy limits the tags updates may move to: latest, digest-only,
+	// patch, minor, major or pinned; unset defaults to latest
+	VersionPolicy string `json:"version_policy,omitempty" binding:"omitempty,oneof=latest digest-only patch minor major pinned"`
+
+	// PinByDigest deploys the container by digest. ImageDigest pins a specific
+	// digest; when empty the digest the tag currently resolves to is used.
+	PinByDigest bool   `json:"pin_by_digest,omitempty"`
+	ImageDigest string `json:"image_digest,omitempty"`
+
+	// SecretEnv names environment variables holding secrets
+	SecretEnv []string `json:"secret_env,omitempty"`
+
+	// WarmupSeconds and PostStartHooks run after every start of the container
+	WarmupSeconds  int                     `json:"warmup_seconds,omitempty"`
+	PostStartHooks model.PostStartHookList `json:"post_start_hooks,omitempty"`
+
+	// TeamID places the container under a team's quota
+	TeamID *int `json:"team_id,omitempty"`
+
+	// HostID is the Docker host to run the container on; unset runs it on the
+	// local daemon. It cannot be changed later.
+	HostID *int `json:"host_id,omitempty"`
+
+	// RestartPolicy is no, always, unless-stopped or on-failure; unset
+	// defaults to unless-stopped
+	RestartPolicy string `json:"restart_policy,omitempty"`
+
+	// StopTimeoutSeconds is the grace period between the stop signal and
+	// SIGKILL; StopSignal replaces the image's stop signal, e.g. SIGQUIT
+	StopTimeoutSeconds *int   `json:"stop_timeout_seconds,omitempty"`
+	StopSignal         string `json:"stop_signal,omitempty"`
+}
+
+// UpdateContainerRequest represents a request to update container configuration
+type UpdateContainerRequest struct {
+	Config       map[string]interface{} `json:"config,omitempty"`
+	UpdatePolicy *string                `json:"update_policy,omitempty" binding:"omitempty,oneof=auto manual scheduled disabled inherit" validate:"omitempty,oneof=auto manual scheduled disabled inherit"`
+	RegistryURL  *string                `json:"registry_url,omitempty" validate:"omitempty,url"`
+	RegistryAuth *RegistryAuth          `json:"registry_auth,omitempty"`
+
+	// Policy overrides; a negative number or empty list reverts to the inherited value
+	CheckIntervalMinutes   *int                       `json:"check_interval_minutes,omitempty"`
+	HoldDownHours          *int                       `json:"hold_down_hours,omitempty"`
+	MaintenanceWindows     *[]model.MaintenanceWindow `json:"maintenance_windows,omitempty"`
+	VulnerabilityThreshold *string                    `json:"vulnerability_threshold,omitempty"`
+
+	// VersionPolicy limits the tags updates may move to
+	VersionPolicy *string `json:"version_policy,omitempty" binding:"omitempty,oneof=latest digest-only patch minor major pinned"`
+
+	// PinByDigest toggles digest pinning; enabling it pins the digest the tag
+	// currently resolves to
+	PinByDigest *bool `json:"pin_by_digest,omitempty"`
+
+	// HealthActions replaces the remediation chain; an empty list removes it
+	HealthActions *model.HealthActionList `json:"health_actions,omitempty"`
+
+	// LogRedactPatterns replaces the container's custom log redaction
+	// patterns; an empty list removes them
+	LogRedactPatterns *[]string `json:"log_redact_patterns,omitempty"`
+
+	// SecretEnv replaces the names of environment variables holding secrets,
+	// which drift reports compare without showing their values
+	SecretEnv *[]string `json:"secret_env,omitempty"`
+
+	// WarmupSeconds sets the delay after the container comes up before its
+	// post-start hooks run and dependents start
+	WarmupSeconds *int `json:"warmup_seconds,omitempty"`
+
+	// PostStartHooks replaces the ordered post-start hooks; an empty list
+	// removes them
+	PostStartHooks *model.PostStartHookList `json:"post_start_hooks,omitempty"`
+
+	// TeamID moves the container under another team's quota; 0 removes it
+	// from its team
+	TeamID *int `json:"team_id,omitempty"`
+
+	// Resource limits are applied to the Docker container right away and
+	// stored with its config for the next recreate; 0 removes a limit.
+	// CPULimit is in cores, e.g. 1.5, or in nano-CPUs from a million up.
+	CPULimit    *float64 `json:"cpu_limit,omitempty"`
+	MemoryLimit *int64   `json:"memory_limit,omitempty"`
+	MemorySwap  *int64   `json:"memory_swap,omitempty"`
+	PidsLimit   *int64   `json:"pids_limit,omitempty"`
+
+	// StopTimeoutSeconds sets the grace period before SIGKILL when the
+	// container is stopped; a negative number reverts to the default.
+	// StopSignal sets the stop signal; empty reverts to the image's.
+	StopTimeoutSeconds *int    `json:"stop_timeout_seconds,omitempty"`
+	StopSignal         *string `json:"stop_signal,omitempty"`
+}
+
+// UpdateImageRequest represents a request to update container image
+type UpdateImageRequest struct {
+	Strategy string `json:"strategy,omitempty" validate:"omitempty,oneof=recreate rolling blue_green health_gated"`
+	Force    bool   `json:"force,omitempty"`
+	Backup   bool   `json:"backup,omitempty"`
+	// Tag moves the container to another tag, which its version policy must
+	// allow; unset keeps the current tag
+	Tag string `json:"tag,omitempty"`
+	// DryRun runs the checks and returns an UpdatePlan without pulling or
+	// recreating anything
+	DryRun bool `json:"dry_run,omitempty"`
+	// Note is attached to the update record, saving a second call
+	Note string `json:"note,omitempty"`
+	// HealthProbe gates a health_gated update on an HTTP check of the new
+	// container instead of its Docker health check
+	HealthProbe *HealthGateProbe `json:"health_probe,omitempty"`
+	// HealthTimeoutSeconds bounds the wait of a health_gated update for the
+	// new container to become healthy, DefaultHealthGateTimeout when unset
+	HealthTimeoutSeconds int `json:"health_timeout_seconds,omitempty"`
+}
+
+// HealthGateProbe is an HTTP check of the new container of a health_gated
+// update, sent to the container's address on its network. It passes on
+// ExpectedStatus, or any 2xx status when unset.
+type HealthGateProbe struct {
+	Port           int    `json:"port" binding:"required"`
+	Path           string `json:"path,omitempty"`
+	ExpectedStatus int    `json:"expected_status,omitempty"`
+}
+
+// Limits of UpdateImageRequest.HealthTimeoutSeconds
+const (
+	DefaultHealthGateTimeout = 120
+	MaxHealthGateTimeout     = 3600
+)
+
+// SelfUpdateRequest updates the container docker-auto itself runs in
+type SelfUpdateRequest struct {
+	// Tag moves docker-auto to another tag, which its version policy must
+	// allow; unset keeps the current tag
+	Tag string `json:"tag,omitempty"`
+	// Note is attached to the update record
+	Note string `json:"note,omitempty"`
+	// HealthTimeoutSeconds bounds the wait for the new container to become
+	// healthy, DefaultHealthGateTimeout when unset
+	HealthTimeoutSeconds int `json:"health_timeout_seconds,omitempty"`
+}
+
+// Validate validates SelfUpdateRequest
+func (r *SelfUpdateRequest) Validate() error {
+	if r.HealthTimeoutSeconds < 0 || r.HealthTimeoutSeconds > MaxHealthGateTimeout {
+		return fmt.Errorf("health timeout must be between 1 and %d seconds", MaxHealthGateTimeout)
+	}
+	return nil
+}
+
+// BulkUpdateRequest represents a request for bulk container updates
+type BulkUpdateRequest struct {
+	ContainerIDs []int64                `json:"container_ids" binding:"required" validate:"required,min=1"`
+	Action       string                 `json:"action" binding:"required" validate:"required,oneof=start stop restart update"`
+	UpdateImage  *UpdateImageRequest    `json:"update_image,omitempty"`
+	Config       map[string]interface{} `json:"config,omitempty"`
+	// DryRun plans image updates instead of applying them, as
+	// UpdateImageRequest.DryRun does
+	DryRun bool `json:"dry_run,omitempty"`
+	// MaxConcurrency bounds how many containers are handled at once,
+	// DefaultBulkConcurrency when unset and at most MaxBulkConcurrency
+	MaxConcurrency int `json:"max_concurrency,omitempty"`
+	// FailFast skips the containers not yet started once one fails
+	FailFast bool `json:"fail_fast,omitempty"`
+}
+
+// Bounds of BulkUpdateRequest.MaxConcurrency
+const (
+	DefaultBulkConcurrency = 5
+	MaxBulkConcurrency     = 20
+)
+
+// IsDryRun reports whether image updates are planned instead of applied
+func (r *BulkUpdateRequest) IsDryRun() bool {
+	return r.DryRun || (r.UpdateImage != nil && r.UpdateImage.DryRun)
+}
+
+// Concurrency returns how many containers may be handled at once
+func (r *BulkUpdateRequest) Concurrency() int {
+	switch {
+	case r.MaxConcurrency <= 0:
+		return DefaultBulkConcurrency
+	case r.MaxConcurrency > MaxBulkConcurrency:
+		return MaxBulkConcurrency
+	default:
+		return r.MaxConcurrency
+	}
+}
+
+// UpdatePlan is what an image update would do, computed by a dry run
+type UpdatePlan struct {
+	ContainerID     int64               `json:"container_id"`
+	Name            string              `json:"name"`
+	Strategy        string              `json:"strategy"`
+	VersionPolicy   model.VersionPolicy `json:"version_policy"`
+	OldImage        string              `json:"old_image"`
+	NewImage        string              `json:"new_image"`
+	OldDigest       string              `json:"old_digest,omitempty"`
+	NewDigest       string              `json:"new_digest,omitempty"`
+	UpdateAvailable bool                `json:"update_available"`
+
+	// Changes are the differences between the live container and the
+	// stored configuration that recreating the container applies
+	Changes []ConfigChange `json:"changes"`
+
+	// EstimatedDowntimeSeconds is the stop timeout plus the time the new
+	// container takes to start, 0 when the container is not running
+	EstimatedDowntimeSeconds int `json:"estimated_downtime_seconds"`
+
+	// Warnings are checks that could not be completed
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// RegistryAuth represents registry authentication information
+type RegistryAuth struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+	AuthType string `json:"auth_type" validate:"oneof=basic token oauth"`
+}
+
+// Container service response types
+
+// ContainerDetail represents detailed container information
+type ContainerDetail struct {
+	*model.Container
+	DockerStatus *model.ContainerStatus `json:"docker_status,omitempty"`
+	Metrics      *ContainerMetrics      `json:"metrics,omitempty"`
+	UpdateInfo   *UpdateInfo            `json:"update_info,omitempty"`
+	LogsSample   []string               `json:"logs_sample,omitempty"`
+
+	// EffectivePolicy is the resolved update policy and where each setting came from
+	EffectivePolicy *model.EffectivePolicy `json:"effective_policy,omitempty"`
+
+	// DigestPin is set for containers deployed by digest
+	DigestPin *DigestPinInfo `json:"digest_pin,omitempty"`
+
+	// HasWarnings is set while the daemon's last warnings for the container stand
+	HasWarnings bool `json:"has_warnings"`
+
+	// Permission is the caller's effective permission on the container
+	Permission model.ContainerPermissionLevel `json:"permission"`
+
+	// HealthState is the health checker's last result and remediation history
+	HealthState *model.ContainerHealthState `json:"health_state,omitempty"`
+
+	// HealthChecks are the configured health checks with their latest result
+	HealthChecks []*model.ContainerHealthCheck `json:"health_checks,omitempty"`
+
+	// Ports lists every host binding of the running container, and exposed
+	// ports that are not published
+	Ports []docker.PortEntry `json:"ports,omitempty"`
+
+	// Limits are the configured resource limits and those the Docker
+	// container has
+	Limits *ContainerLimits `json:"limits,omitempty"`
+
+	// PendingRecreate is set while the Docker container lacks changes to the
+	// stored environment variables or labels, listed in PendingChanges, which
+	// it gets when next recreated
+	PendingRecreate bool           `json:"pending_recreate"`
+	PendingChanges  []ConfigChange `json:"pending_changes,omitempty"`
+}
+
+// DigestPinInfo describes the pinned digest of a container against its tag
+type DigestPinInfo struct {
+	Tag          string `json:"tag"`
+	PinnedDigest string `json:"pinned_digest"`
+	TagDigest    string `json:"tag_digest,omitempty"`
+	Drift        bool   `json:"drift"`
+
+	// ReleaseNotesURL links the release notes of the pending digest
+	ReleaseNotesURL string `json:"release_notes_url,omitempty"`
+}
+
+// ContainerSummary represents container summary for list views
+type ContainerSummary struct {
+	ID            int64                 `json:"id"`
+	Name          string                `json:"name"`
+	Image         string                `json:"image"`
+	Tag           string                `json:"tag"`
+	Status        model.ContainerStatus `json:"status"`
+	DockerStatus  string                `json:"docker_status"`
+	UpdatePolicy  model.UpdatePolicy    `json:"update_policy"`
+	VersionPolicy model.VersionPolicy   `json:"version_policy"`
+	HasUpdate     bool                  `json:"has_update"`
+	HasWarnings   bool                  `json:"has_warnings"`
+	WarningCount  int                   `json:"warning_count,omitempty"`
+	Drifted       bool                  `json:"drifted"`
+	CrashLooping  bool                  `json:"crash_looping"`
+	Adopted       bool                  `json:"adopted"`
+	Unmanaged     bool                  `json:"unmanaged"`
+	CreatedAt     time.Time             `json:"created_at"`
+	UpdatedAt     time.Time             `json:"updated_at"`
+
+	// PublishedPorts is a compact rendering of the published ports, e.g.
+	// "8080->80/tcp, 127.0.0.1:53->53/udp"
+	PublishedPorts string `json:"published_ports,omitempty"`
+
+	// Permission is the caller's effective permission on the container
+	Permission model.ContainerPermissionLevel `json:"permission"`
+
+	// Live state, only set when listed with include_health. Health is
+	// healthy, unhealthy, starting or none. Stale means the daemon did not
+	// answer in time and DockerStatus is the last synced status.
+	Health        string `json:"health,omitempty"`
+	UptimeSeconds int64  `json:"uptime_seconds,omitempty"`
+	RestartCount  int    `json:"restart_count,omitempty"`
+	Stale         bool   `json:"stale,omitempty"`
+}
+
+// ContainerListResponse represents paginated container list response
+type ContainerListResponse struct {
+	Containers []*ContainerSummary `json:"containers"`
+	Total      int64               `json:"total"`
+	Page       int                 `json:"page"`
+	Limit      int                 `json:"limit"`
+	HasNext    bool                `json:"has_next"`
+	HasPrev    bool                `json:"has_prev"`
+}
+
+// ContainerMetrics represents container performance metrics
+type ContainerMetrics struct {
+	CPUPercent    float64           `json:"cpu_percent"`
+	MemoryUsage   int64             `json:"memory_usage"`
+	MemoryLimit   int64             `json:"memory_limit"`
+	MemoryPercent float64           `json:"memory_percent"`
+	NetworkIO     *NetworkIOMetrics `json:"network_io,omitempty"`
+	BlockIO       *BlockIOMetrics   `json:"block_io,omitempty"`
+	PIDs          int               `json:"pids"`
+	Timestamp     time.Time         `json:"timestamp"`
+}
+
+// NetworkIOMetrics represents network I/O metrics
+type NetworkIOMetrics struct {
+	RxBytes   int64 `json:"rx_bytes"`
+	TxBytes   int64 `json:"tx_bytes"`
+	RxPackets int64 `json:"rx_packets"`
+	TxPackets int64 `json:"tx_packets"`
+}
+
+// BlockIOMetrics represents disk I/O metrics
+type BlockIOMetrics struct {
+	ReadBytes  int64 `json:"read_bytes"`
+	WriteBytes int64 `json:"write_bytes"`
+	ReadOps    int64 `json:"read_ops"`
+	WriteOps   int64 `json:"write_ops"`
+}
+
+// UpdateWindow is when the updater may next apply an update to a container,
+// given its hold-down and maintenance windows
+type UpdateWindow struct {
+	ContainerID    int64              `json:"container_id"`
+	Eligible       bool               `json:"eligible"`
+	Unrestricted   bool               `json:"unrestricted"`
+	HeldDownUntil  *time.Time         `json:"held_down_until,omitempty"`
+	NextEligibleAt *time.Time         `json:"next_eligible_at,omitempty"`
+	Window         *schedule.Interval `json:"window,omitempty"`
+	Timestamp      time.Time          `json:"timestamp"`
+}
+
+// Container operation types
+
+// ContainerStatus represents current container runtime status
+type ContainerStatus struct {
+	ID           int64                  `json:"id"`
+	Name         string                 `json:"name"`
+	Status       model.ContainerStatus  `json:"status"`
+	DockerStatus *model.ContainerStatus `json:"docker_status,omitempty"`
+	Health       string                 `json:"health,omitempty"`
+	Uptime       time.Duration          `json:"uptime"`
+	RestartCount int                    `json:"restart_count"`
+	LastRestart  *time.Time             `json:"last_restart,omitempty"`
+	Operation    string                 `json:"operation,omitempty"`
+	Timestamp    time.Time              `json:"timestamp"`
+}
+
+// ContainerStats represents container resource statistics
+type ContainerStats struct {
+	ID        int64             `json:"id"`
+	Name      string            `json:"name"`
+	Metrics   *ContainerMetrics `json:"metrics"`
+	Timestamp time.Time         `json:"timestamp"`
+}
+
+// OperationResult represents the result of a container operation
+type OperationResult struct {
+	ContainerID int64  `json:"container_id"`
+	Name        string `json:"name"`
+	Success     bool   `json:"success"`
+	Message     string `json:"message,omitempty"`
+	Error       string `json:"error,omitempty"`
+
+	// Warnings the Docker daemon reported while performing the operation
+	Warnings []string `json:"warnings,omitempty"`
+
+	// Plan is what a dry-run image update would do
+	Plan *UpdatePlan `json:"plan,omitempty"`
+
+	// Timestamp and DurationMS are when the operation started and how long
+	// it took; set by bulk operations
+	Timestamp  *time.Time `json:"timestamp,omitempty"`
+	DurationMS int64      `json:"duration_ms,omitempty"`
+}
+
+// ComposeImportResult is the outcome of importing a Docker Compose project.
+// Warnings are about the project as a whole.
+type ComposeImportResult struct {
+	Created  int                    `json:"created"`
+	Skipped  int                    `json:"skipped"`
+	Failed   int                    `json:"failed"`
+	Warnings []string               `json:"warnings,omitempty"`
+	Services []ComposeServiceResult `json:"services"`
+}
+
+// ComposeServiceResult is the outcome of importing one compose service.
+// Skipped services already exist by name and were left alone.
+type ComposeServiceResult struct {
+	Service string `json:"service"`
+	OperationResult
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// LogOptions represents options for retrieving container logs
+type LogOptions struct {
+	Since      time.Time `json:"since,omitempty"`
+	Until      time.Time `json:"until,omitempty"`
+	Tail       int       `json:"tail,omitempty"`
+	Follow     bool      `json:"follow,omitempty"`
+	Timestamps bool      `json:"timestamps,omitempty"`
+
+	// Raw skips log redaction; admins only
+	Raw bool `json:"raw,omitempty"`
+}
+
+// ExecOptions is the command an exec session runs in a container
+type ExecOptions struct {
+	// Command defaults to /bin/sh
+	Command []string `json:"command,omitempty"`
+	User    string   `json:"user,omitempty"`
+}
+
+// LogResponse represents container logs response
+type LogResponse struct {
+	ContainerID int64      `json:"container_id"`
+	Name        string     `json:"name"`
+	Logs        []LogEntry `json:"logs"`
+	Truncated   bool       `json:"truncated"`
+	Since       time.Time  `json:"since"`
+	Until       time.Time  `json:"until"`
+	Count       int        `json:"count"`
+}
+
+// LogEntry represents a single log entry
+type LogEntry struct {
+	Timestamp time.Time `json:"timestamp"`
+	Source    string    `json:"source"` // stdout, stderr
+	Message   string    `json:"message"`
+}
+
+// Container export/import types
+
+// ContainerExport represents exported container configuration. Exports are
+// rendered canonically so that re-exporting an unchanged container gives the
+// same bytes: map keys are sorted, ports and volumes are in a fixed order,
+// default values are omitted and nothing time-dependent is included.
+type ContainerExport struct {
+	Version       string            `json:"version"`
+	Name          string            `json:"name"`
+	Image         string            `json:"image"`
+	Tag           string            `json:"tag,omitempty"`            // omitted when latest
+	UpdatePolicy  string            `json:"update_policy,omitempty"`  // omitted when auto
+	VersionPolicy string            `json:"version_policy,omitempty"` // omitted when latest
+	RegistryURL   string            `json:"registry_url,omitempty"`
+	PinByDigest   bool              `json:"pin_by_digest,omitempty"`
+	ImageDigest   string            `json:"image_digest,omitempty"`
+	Environment   map[string]string `json:"environment,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	Ports         []PortMapping     `json:"ports,omitempty"`
+	Volumes       []VolumeMapping   `json:"volumes,omitempty"`
+	// Config holds the remaining configuration, without empty values
+	Config map[string]interface{} `json:"config,omitempty"`
+}
+
+// ConfigChange is a difference between an exported document and the stored
+// configuration. Secret environment values are never shown.
+type ConfigChange struct {
+	Field   string      `json:"field"`
+	Key     string      `json:"key,omitempty"`
+	Change  string      `json:"change"` // added, removed, changed
+	Current interface{} `json:"current,omitempty"`
+	Desired interface{} `json:"desired,omitempty"`
+	Secret  bool        `json:"secret,omitempty"`
+}
+
+// ConfigDiff is what importing an exported document would change. Create is
+// set when no container of that name is managed yet.
+type ConfigDiff struct {
+	Name        string         `json:"name"`
+	ContainerID *int64         `json:"container_id,omitempty"`
+	Create      bool           `json:"create"`
+	Changed     bool           `json:"changed"`
+	Changes     []ConfigChange `json:"changes"`
+}
+
+// PortMapping represents port mapping configuration
+type PortMapping struct {
+	ContainerPort int    `json:"container_port"`
+	HostPort      int    `json:"host_port,omitempty"`
+	Protocol      string `json:"protocol,omitempty"` // tcp, udp
+	HostIP        string `json:"host_ip,omitempty"`
+}
+
+// VolumeMapping represents volume mapping configuration
+type VolumeMapping struct {
+	Source      string `json:"source"` // host path or volume name
+	Target      string `json:"target"` // container path
+	Type        string `json:"type"`   // bind, volume, tmpfs
+	ReadOnly    bool   `json:"read_only"`
+	Consistency string `json:"consistency,omitempty"` // default, consistent, cached, delegated
+}
+
+// Update related types
+
+// UpdateInfo represents information about available updates
+type UpdateInfo struct {
+	ContainerID     int64                    `json:"container_id"`
+	Name            string                   `json:"name"`
+	CurrentImage    string                   `json:"current_image"`
+	CurrentTag      string                   `json:"current_tag"`
+	LatestImage     string                   `json:"latest_image,omitempty"`
+	LatestTag       string                   `json:"latest_tag,omitempty"`
+	UpdateAvailable bool                     `json:"update_available"`
+	UpdateType      string                   `json:"update_type,omitempty"` // major, minor, patch, unknown
+	LastChecked     time.Time                `json:"last_checked"`
+	VersionInfo     *VersionComparisonResult `json:"version_info,omitempty"`
+}
+
+// UpdateCheckRequest selects the containers an on-demand update check
+// covers; no IDs means every container the caller can see
+type UpdateCheckRequest struct {
+	ContainerIDs   []int64 `json:"container_ids,omitempty"`
+	MaxConcurrency int     `json:"max_concurrency,omitempty"`
+	// Force ignores cached versions and asks the registry again
+	Force bool `json:"force,omitempty"`
+}
+
+// ContainerUpdateCheck compares the digest a container runs with the one its
+// tag resolves to in the registry
+type ContainerUpdateCheck struct {
+	ContainerID     int64     `json:"container_id"`
+	Name            string    `json:"name"`
+	Image           string    `json:"image"`
+	CurrentTag      string    `json:"current_tag"`
+	CurrentDigest   string    `json:"current_digest,omitempty"`
+	LatestTag       string    `json:"latest_tag,omitempty"`
+	LatestDigest    string    `json:"latest_digest,omitempty"`
+	UpdateAvailable bool      `json:"update_available"`
+	Cached          bool      `json:"cached"`
+	CheckedAt       time.Time `json:"checked_at"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// UpdateCheckResult is the outcome of an on-demand update check
+type UpdateCheckResult struct {
+	Checked          int                    `json:"checked"`
+	UpdatesAvailable int                    `json:"updates_available"`
+	Failed           int                    `json:"failed"`
+	Cached           int                    `json:"cached"`
+	Containers       []ContainerUpdateCheck `json:"containers"`
+}
+
+// VersionComparisonResult represents the result of version comparison
+type VersionComparisonResult struct {
+	CurrentVersion string          `json:"current_version"`
+	LatestVersion  string          `json:"latest_version"`
+	CompareResult  int             `json:"compare_result"` // -1: current < latest, 0: equal, 1: current > latest
+	VersionType    string          `json:"version_type"`   // semantic, date, hash, unknown
+	Changes        []VersionChange `json:"changes,omitempty"`
+	SecurityIssues []SecurityIssue `json:"security_issues,omitempty"`
+	Recommendation string          `json:"recommendation,omitempty"`
+}
+
+// VersionChange represents a change between versions
+type VersionChange struct {
+	Type        string    `json:"type"` // feature, bugfix, security, breaking
+	Description string    `json:"description"`
+	Impact      string    `json:"impact"` // low, medium, high, critical
+	Date        time.Time `json:"date,omitempty"`
+}
+
+// SecurityIssue represents a security issue found in the current version
+type SecurityIssue struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description"`
+	Severity    string    `json:"severity"` // low, medium, high, critical
+	CVSS        float64   `json:"cvss,omitempty"`
+	FixedIn     string    `json:"fixed_in,omitempty"`
+	PublishedAt time.Time `json:"published_at,omitempty"`
+}
+
+// Filter types
+
+// ContainerFilter represents filters for container queries (extends model.ContainerFilter)
+type ContainerFilter struct {
+	*model.ContainerFilter
+	HasUpdate   *bool     `json:"has_update,omitempty"`
+	LastUpdated time.Time `json:"last_updated,omitempty"`
+	SearchQuery string    `json:"search_query,omitempty"`
+	SortBy      string    `json:"sort_by,omitempty"`    // name, created_at, updated_at, status
+	SortOrder   string    `json:"sort_order,omitempty"` // asc, desc
+
+	// IncludeHealth fetches live state and health of the page's containers
+	// from the daemon in one batch
+	IncludeHealth bool `json:"include_health,omitempty"`
+}
+
+// Sync and maintenance types
+
+// SyncResult represents the result of container status synchronization
+type SyncResult struct {
+	TotalContainers  int `json:"total_containers"`
+	SyncedContainers int `json:"synced_containers"`
+	ErrorContainers  int `json:"error_containers"`
+	// Inspected containers were checked against the daemon; skipped ones had
+	// no events and were verified recently enough
+	Inspected int `json:"inspected"`
+	Skipped   int `json:"skipped"`
+	// Full is set when every container was inspected, on request or because
+	// container events were not being received
+	Full           bool       `json:"full"`
+	EventWatermark *time.Time `json:"event_watermark,omitempty"`
+	// Hosts are the enabled remote Docker hosts synced besides the local daemon
+	Hosts         []HostSyncResult        `json:"hosts,omitempty"`
+	StatusChanges []ContainerStatusChange `json:"status_changes,omitempty"`
+	Errors        []SyncError             `json:"errors,omitempty"`
+	Duration      time.Duration           `json:"duration"`
+	Timestamp     time.Time               `json:"timestamp"`
+}
+
+// HostSyncResult represents the sync of one remote Docker host
+type HostSyncResult struct {
+	HostID     int    `json:"host_id"`
+	Name       string `json:"name"`
+	Containers int    `json:"containers"`
+	Error      string `json:"error,omitempty"`
+}
+
+// ContainerStatusChange represents a status change detected during sync
+type ContainerStatusChange struct {
+	ContainerID int64                 `json:"container_id"`
+	Name        string                `json:"name"`
+	OldStatus   model.ContainerStatus `json:"old_status"`
+	NewStatus   model.ContainerStatus `json:"new_status"`
+	Reason      string                `json:"reason,omitempty"`
+}
+
+// DiscoveryResult represents the outcome of container discovery by labels
+type DiscoveryResult struct {
+	// Labeled is the number of containers carrying the enable label
+	Labeled   int      `json:"labeled"`
+	Adopted   []string `json:"adopted,omitempty"`
+	Updated   []string `json:"updated,omitempty"`
+	Unmanaged []string `json:"unmanaged,omitempty"`
+	// Conflicts are labeled containers whose name belongs to a container
+	// registered otherwise, which is kept as is
+	Conflicts []string      `json:"conflicts,omitempty"`
+	Errors    []SyncError   `json:"errors,omitempty"`
+	Duration  time.Duration `json:"duration"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// SyncError represents an error that occurred during sync
+type SyncError struct {
+	ContainerID int64  `json:"container_id"`
+	Name        string `json:"name"`
+	Error       string `json:"error"`
+	Recoverable bool   `json:"recoverable"`
+}
+
+// Validation helpers
+
+// Validate validates CreateContainerRequest
+func (r *CreateContainerRequest) Validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("container name is required")
+	}
+	if r.Image == "" {
+		return fmt.Errorf("container image is required")
+	}
+	if r.Tag == "" {
+		r.Tag = "latest"
+	}
+	if r.UpdatePolicy == "" {
+		r.UpdatePolicy = string(model.UpdatePolicyInherit)
+	}
+	if r.UpdatePolicy != "" && !IsValidUpdatePolicy(r.UpdatePolicy) {
+		return fmt.Errorf("invalid update policy")
+	}
+	if r.VersionPolicy == "" {
+		r.VersionPolicy = string(model.VersionPolicyLatest)
+	}
+	if !model.IsValidVersionPolicy(r.VersionPolicy) {
+		return fmt.Errorf("invalid version policy")
+	}
+	if r.CheckIntervalMinutes != nil && *r.CheckIntervalMinutes < 1 {
+		return fmt.Errorf("check interval must be at least 1 minute")
+	}
+	if r.HoldDownHours != nil && *r.HoldDownHours < 0 {
+		return fmt.Errorf("hold-down cannot be negative")
+	}
+	if r.VulnerabilityThreshold != "" && !IsValidVulnerabilityThreshold(r.VulnerabilityThreshold) {
+		return fmt.Errorf("invalid vulnerability threshold")
+	}
+	if err := ValidateMaintenanceWindows(r.MaintenanceWindows); err != nil {
+		return err
+	}
+	if r.ImageDigest != "" {
+		if !r.PinByDigest {
+			return fmt.Errorf("image digest requires pin_by_digest")
+		}
+		if !isValidImageDigest(r.ImageDigest) {
+			return fmt.Errorf("invalid image digest")
+		}
+	}
+	if err := model.ValidateWarmup(r.WarmupSeconds); err != nil {
+		return err
+	}
+	if err := r.PostStartHooks.Validate(); err != nil {
+		return err
+	}
+	if r.RestartPolicy != "" && !IsValidRestartPolicy(r.RestartPolicy) {
+		return fmt.Errorf("invalid restart policy")
+	}
+	if r.StopTimeoutSeconds != nil {
+		if err := model.ValidateStopTimeout(*r.StopTimeoutSeconds); err != nil {
+			return err
+		}
+	}
+	if r.StopSignal != "" {
+		if err := model.ValidateStopSignal(r.StopSignal); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate validates UpdateImageRequest
+func (r *UpdateImageRequest) Validate() error {
+	if r.Strategy != "" && !IsValidUpdateStrategy(r.Strategy) {
+		return fmt.Errorf("invalid update strategy")
+	}
+	if r.Strategy != string(model.UpdateStrategyHealthGated) {
+		if r.HealthProbe != nil || r.HealthTimeoutSeconds != 0 {
+			return fmt.Errorf("health_probe and health_timeout_seconds require the health_gated strategy")
+		}
+		return nil
+	}
+	if r.HealthTimeoutSeconds < 0 || r.HealthTimeoutSeconds > MaxHealthGateTimeout {
+		return fmt.Errorf("health timeout must be between 1 and %d seconds", MaxHealthGateTimeout)
+	}
+	if probe := r.HealthProbe; probe != nil {
+		if probe.Port < 1 || probe.Port > 65535 {
+			return fmt.Errorf("health probe port must be between 1 and 65535")
+		}
+		if probe.Path != "" && !strings.HasPrefix(probe.Path, "/") {
+			return fmt.Errorf("health probe path must start with /")
+		}
+		if probe.ExpectedStatus != 0 && (probe.ExpectedStatus < 100 || probe.ExpectedStatus > 599) {
+			return fmt.Errorf("health probe expected status must be an HTTP status code")
+		}
+	}
+	return nil
+}
+
+// HealthTimeout returns how long a health_gated update waits for the new
+// container
+func (r *UpdateImageRequest) HealthTimeout() time.Duration {
+	if r.HealthTimeoutSeconds <= 0 {
+		return DefaultHealthGateTimeout * time.Second
+	}
+	return time.Duration(r.HealthTimeoutSeconds) * time.Second
+}
+
+// Validate validates UpdateContainerRequest
+func (r *UpdateContainerRequest) Validate() error {
+	if r.UpdatePolicy != nil && *r.UpdatePolicy != "" {
+		validPolicies := []string{"auto", "manual", "scheduled", "disabled", "inherit"}
+		valid := false
+		for _, policy := range validPolicies {
+			if *r.UpdatePolicy == policy {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return fmt.Errorf("invalid update policy")
+		}
+	}
+	if r.VersionPolicy != nil && !model.IsValidVersionPolicy(*r.VersionPolicy) {
+		return fmt.Errorf("invalid version policy")
+	}
+	if r.CheckIntervalMinutes != nil && *r.CheckIntervalMinutes == 0 {
+		return fmt.Errorf("check interval must be at least 1 minute")
+	}
+	if r.VulnerabilityThreshold != nil && *r.VulnerabilityThreshold != "" && !IsValidVulnerabilityThreshold(*r.VulnerabilityThreshold) {
+		return fmt.Errorf("invalid vulnerability threshold")
+	}
+	if r.MaintenanceWindows != nil {
+		if err := ValidateMaintenanceWindows(*r.MaintenanceWindows); err != nil {
+			return err
+		}
+	}
+	if r.HealthActions != nil {
+		if err := r.HealthActions.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.LogRedactPatterns != nil {
+		if err := docker.ValidateRedactionPatterns(*r.LogRedactPatterns); err != nil {
+			return err
+		}
+	}
+	if r.WarmupSeconds != nil {
+		if err := model.ValidateWarmup(*r.WarmupSeconds); err != nil {
+			return err
+		}
+	}
+	if r.PostStartHooks != nil {
+		if err := r.PostStartHooks.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.StopTimeoutSeconds != nil && *r.StopTimeoutSeconds >= 0 {
+		if err := model.ValidateStopTimeout(*r.StopTimeoutSeconds); err != nil {
+			return err
+		}
+	}
+	if r.StopSignal != nil && *r.StopSignal != "" {
+		if err := model.ValidateStopSignal(*r.StopSignal); err != nil {
+			return err
+		}
+	}
+	return r.validateLimits()
+}
+
+// Helper functions
+
+// IsValidUpdatePolicy reports whether policy is a known update policy
+func IsValidUpdatePolicy(policy string) bool {
+	for _, valid := range model.GetValidUpdatePolicies() {
+		if model.UpdatePolicy(policy) == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// IsValidRestartPolicy reports whether policy is a Docker restart policy
+func IsValidRestartPolicy(policy string) bool {
+	switch docker.RestartPolicy(policy) {
+	case docker.RestartPolicyNo, docker.RestartPolicyAlways, docker.RestartPolicyUnlessStopped, docker.RestartPolicyOnFailure:
+		return true
+	}
+	return false
+}
+
+// IsValidVulnerabilityThreshold reports whether threshold is a known
+// vulnerability severity
+func IsValidVulnerabilityThreshold(threshold string) bool {
+	for _, valid := range model.GetValidVulnerabilityThresholds() {
+		if threshold == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateMaintenanceWindow checks that a maintenance window parses
+func ValidateMaintenanceWindow(window model.MaintenanceWindow) error {
+	if _, err := window.Schedule(); err != nil {
+		return fmt.Errorf("invalid maintenance window: %w", err)
+	}
+	return nil
+}
+
+// ValidateMaintenanceWindows checks that each maintenance window parses and
+// that no two of them overlap, which usually means a typo in one of them
+func ValidateMaintenanceWindows(windows []model.MaintenanceWindow) error {
+	parsed := make(schedule.Windows, 0, len(windows))
+	for _, window := range windows {
+		if err := ValidateMaintenanceWindow(window); err != nil {
+			return err
+		}
+		w, _ := window.Schedule()
+		parsed = append(parsed, w)
+	}
+	if i, j, ok := parsed.Overlapping(time.Now()); ok {
+		return fmt.Errorf("invalid maintenance windows: window %d overlaps window %d", i+1, j+1)
+	}
+	return nil
+}
+
+// isValidImageDigest checks for an algorithm:hex content digest
+func isValidImageDigest(digest string) bool {
+	algorithm, hex, found := strings.Cut(digest, ":")
+	if !found || algorithm == "" || len(hex) < 32 {
+		return false
+	}
+	for _, ch := range hex {
+		if !strings.ContainsRune("0123456789abcdef", ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetSortableFields returns list of fields that can be used for sorting
+func GetSortableFields() []string {
+	return []string{"name", "created_at", "updated_at", "status", "image"}
+}
+
+// IsValidSortField checks if the field is valid for sorting
+func IsValidSortField(field string) bool {
+	validFields := GetSortableFields()
+	for _, validField := range validFields {
+		if field == validField {
+			return true
+		}
+	}
+	return false
+}
+
+// GetValidUpdateStrategies returns list of valid update strategies
+func GetValidUpdateStrategies() []string {
+	return []string{"recreate", "rolling", "blue_green", "health_gated"}
+}
+
+// IsValidUpdateStrategy checks if the strategy is valid
+func IsValidUpdateStrategy(strategy string) bool {
+	validStrategies := GetValidUpdateStrategies()
+	for _, validStrategy := range validStrategies {
+		if strategy == validStrategy {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,110 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+
+	"docker-auto/pkg/model"
+)
+
+// MaskedValue replaces secret values for callers who may not reveal them
+const MaskedValue = "*****"
+
+// maxConfigOperations bounds the operations of one environment or label patch
+const maxConfigOperations = 100
+
+// Environment variable and label operations
+const (
+	ConfigOpAdd    = "add"
+	ConfigOpUpdate = "update"
+	ConfigOpRemove = "remove"
+)
+
+// ConfigEntry is one environment variable or label of a container. Secret
+// values are masked unless the caller may reveal them.
+type ConfigEntry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// ContainerConfigEntries lists the stored environment variables or labels of
+// a container
+type ContainerConfigEntries struct {
+	ContainerID int64         `json:"container_id"`
+	Entries     []ConfigEntry `json:"entries"`
+	// PendingRecreate is set while the Docker container differs from the
+	// stored configuration; it gets the changes when next recreated
+	PendingRecreate bool `json:"pending_recreate"`
+}
+
+// ConfigEntryOperation adds, updates or removes one environment variable or
+// label. Add fails for an existing key, update and remove for a missing one.
+type ConfigEntryOperation struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	// Secret flags an environment variable as secret although its name and
+	// value match no sensitive pattern
+	Secret bool `json:"secret,omitempty"`
+}
+
+// PatchConfigEntriesRequest applies operations, in order, to the stored
+// environment variables or labels of a container
+type PatchConfigEntriesRequest struct {
+	Operations []ConfigEntryOperation `json:"operations"`
+}
+
+// ValidateEnv validates the request as environment variable operations
+func (r *PatchConfigEntriesRequest) ValidateEnv() error {
+	return r.validate(func(op ConfigEntryOperation) error {
+		if op.Key == "" {
+			return fmt.Errorf("environment variable name is required")
+		}
+		if strings.ContainsAny(op.Key, "=\x00") {
+			return fmt.Errorf("environment variable name %q must not contain '='", op.Key)
+		}
+		if strings.ContainsRune(op.Value, 0) {
+			return fmt.Errorf("value of environment variable %q must not contain NUL", op.Key)
+		}
+		return nil
+	})
+}
+
+// ValidateLabels validates the request as label operations
+func (r *PatchConfigEntriesRequest) ValidateLabels() error {
+	return r.validate(func(op ConfigEntryOperation) error {
+		if err := model.ValidateLabelKey(op.Key); err != nil {
+			return err
+		}
+		if op.Secret {
+			return fmt.Errorf("labels cannot be flagged secret")
+		}
+		return model.ValidateLabelValue(op.Key, op.Value)
+	})
+}
+
+func (r *PatchConfigEntriesRequest) validate(validateEntry func(ConfigEntryOperation) error) error {
+	if len(r.Operations) == 0 {
+		return fmt.Errorf("at least one operation is required")
+	}
+	if len(r.Operations) > maxConfigOperations {
+		return fmt.Errorf("at most %d operations are allowed", maxConfigOperations)
+	}
+
+	for i, op := range r.Operations {
+		switch op.Op {
+		case ConfigOpAdd, ConfigOpUpdate:
+		case ConfigOpRemove:
+			if op.Value != "" || op.Secret {
+				return fmt.Errorf("operation %d: remove takes no value", i+1)
+			}
+		default:
+			return fmt.Errorf("operation %d: unknown operation %q", i+1, op.Op)
+		}
+		if err := validateEntry(op); err != nil {
+			return fmt.Errorf("operation %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
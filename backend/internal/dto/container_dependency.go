@@ -0,0 +1,43 @@
+package dto
+
+import (
+	"fmt"
+
+	"docker-auto/pkg/model"
+)
+
+// maxContainerDependencies bounds the dependencies of one container
+const maxContainerDependencies = 50
+
+// DependencyContainer names a container on either side of a dependency
+type DependencyContainer struct {
+	ID     int64                 `json:"id"`
+	Name   string                `json:"name"`
+	Status model.ContainerStatus `json:"status"`
+}
+
+// ContainerDependencies lists the containers a container depends on, which
+// start before it and stop after it, and those depending on it
+type ContainerDependencies struct {
+	ContainerID int64                 `json:"container_id"`
+	DependsOn   []DependencyContainer `json:"depends_on"`
+	Dependents  []DependencyContainer `json:"dependents"`
+}
+
+// SetContainerDependenciesRequest replaces the containers a container
+// depends on; an empty list removes them all
+type SetContainerDependenciesRequest struct {
+	DependsOn []int64 `json:"depends_on"`
+}
+
+// Validate validates the request for the container containerID
+func (r *SetContainerDependenciesRequest) Validate(containerID int64) error {
+	if len(r.DependsOn) > maxContainerDependencies {
+		return fmt.Errorf("at most %d dependencies are allowed", maxContainerDependencies)
+	}
+	ids := make([]int, len(r.DependsOn))
+	for i, id := range r.DependsOn {
+		ids[i] = int(id)
+	}
+	return model.ValidateDependencies(int(containerID), ids)
+}
@@ -0,0 +1,40 @@
+package dto
+
+import "docker-auto/pkg/model"
+
+// HealthCheckRequest configures a health check of a container. Endpoint is
+// the URL of an http check or the host:port of a tcp check; command checks
+// run Command inside the container. Unset interval, timeout and threshold
+// default to 60 seconds, 10 seconds and 3 failures.
+type HealthCheckRequest struct {
+	Name             string   `json:"name" binding:"required"`
+	Type             string   `json:"type" binding:"required,oneof=http tcp command"`
+	Endpoint         string   `json:"endpoint,omitempty"`
+	Method           string   `json:"method,omitempty"`
+	ExpectedStatus   int      `json:"expected_status,omitempty"`
+	ExpectedBody     string   `json:"expected_body,omitempty"`
+	Command          []string `json:"command,omitempty"`
+	ExpectedExit     int      `json:"expected_exit,omitempty"`
+	IntervalSeconds  int      `json:"interval_seconds,omitempty"`
+	TimeoutSeconds   int      `json:"timeout_seconds,omitempty"`
+	FailureThreshold int      `json:"failure_threshold,omitempty"`
+	RestartOnFailure bool     `json:"restart_on_failure"`
+	Enabled          *bool    `json:"enabled,omitempty"` // Defaults to true
+}
+
+// Apply copies the request onto the check's configuration
+func (r *HealthCheckRequest) Apply(check *model.ContainerHealthCheck) {
+	check.Name = r.Name
+	check.Type = model.HealthCheckType(r.Type)
+	check.Endpoint = r.Endpoint
+	check.Method = r.Method
+	check.ExpectedStatus = r.ExpectedStatus
+	check.ExpectedBody = r.ExpectedBody
+	check.Command = model.StringList(r.Command)
+	check.ExpectedExit = r.ExpectedExit
+	check.IntervalSeconds = r.IntervalSeconds
+	check.TimeoutSeconds = r.TimeoutSeconds
+	check.FailureThreshold = r.FailureThreshold
+	check.RestartOnFailure = r.RestartOnFailure
+	check.Enabled = r.Enabled == nil || *r.Enabled
+}
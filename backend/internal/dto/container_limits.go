@@ -0,0 +1,103 @@
+package dto
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// MinMemoryLimit is the smallest memory limit a container may be given
+	MinMemoryLimit = 4 * 1024 * 1024
+
+	// MinCPULimit is the smallest CPU limit in cores
+	MinCPULimit = 0.01
+
+	// nanoCPUThreshold separates CPU limits given in nano-CPUs from those
+	// given in cores; no host has a million cores
+	nanoCPUThreshold = 1e6
+)
+
+// ResourceLimits are the CPU, memory and process limits of a container. Zero
+// means no limit.
+type ResourceLimits struct {
+	// CPULimit is in cores, NanoCPUs the same limit in billionths of a core
+	CPULimit    float64 `json:"cpu_limit"`
+	NanoCPUs    int64   `json:"nano_cpus"`
+	MemoryLimit int64   `json:"memory_limit"`
+	// MemorySwap is memory plus swap in bytes; -1 allows unlimited swap
+	MemorySwap int64 `json:"memory_swap"`
+	PidsLimit  int64 `json:"pids_limit"`
+}
+
+// ContainerLimits shows the limits stored for a container, which it is
+// created with, next to those its Docker container currently has
+type ContainerLimits struct {
+	Configured ResourceLimits  `json:"configured"`
+	Applied    *ResourceLimits `json:"applied,omitempty"`
+	// Pending is set while the applied limits differ from the configured
+	// ones, e.g. after removing a limit, until the container is recreated
+	Pending bool `json:"pending"`
+}
+
+// NewResourceLimits returns the limits for a CPU limit in nano-CPUs
+func NewResourceLimits(nanoCPUs, memory, memorySwap, pidsLimit int64) ResourceLimits {
+	return ResourceLimits{
+		CPULimit:    float64(nanoCPUs) / 1e9,
+		NanoCPUs:    nanoCPUs,
+		MemoryLimit: memory,
+		MemorySwap:  memorySwap,
+		PidsLimit:   pidsLimit,
+	}
+}
+
+// HasLimitChanges reports whether the request changes resource limits
+func (r *UpdateContainerRequest) HasLimitChanges() bool {
+	return r.CPULimit != nil || r.MemoryLimit != nil || r.MemorySwap != nil || r.PidsLimit != nil
+}
+
+// validateLimits checks the resource limits of the request
+func (r *UpdateContainerRequest) validateLimits() error {
+	if r.CPULimit != nil {
+		if _, err := CPULimitNanoCPUs(*r.CPULimit); err != nil {
+			return err
+		}
+	}
+	if r.MemoryLimit != nil && *r.MemoryLimit != 0 && *r.MemoryLimit < MinMemoryLimit {
+		return fmt.Errorf("memory_limit must be at least %d bytes (4MB), or 0 to remove it", MinMemoryLimit)
+	}
+	if r.MemorySwap != nil {
+		if *r.MemorySwap < -1 {
+			return fmt.Errorf("memory_swap must be -1 for unlimited swap, 0 for the default, or a byte count")
+		}
+		if r.MemoryLimit != nil && *r.MemorySwap > 0 && *r.MemorySwap < *r.MemoryLimit {
+			return fmt.Errorf("memory_swap must not be smaller than memory_limit")
+		}
+	}
+	if r.PidsLimit != nil && *r.PidsLimit < 0 {
+		return fmt.Errorf("pids_limit must not be negative")
+	}
+	return nil
+}
+
+// CPULimitNanoCPUs converts a CPU limit to nano-CPUs. Values from a million
+// up are taken as nano-CPUs, smaller ones as cores, e.g. 1.5; 0 removes the
+// limit.
+func CPULimitNanoCPUs(limit float64) (int64, error) {
+	switch {
+	case limit == 0:
+		return 0, nil
+	case limit < 0 || math.IsNaN(limit) || math.IsInf(limit, 0):
+		return 0, fmt.Errorf("cpu_limit must be a positive number of cores or nano-CPUs")
+	case limit >= nanoCPUThreshold:
+		if limit != math.Trunc(limit) {
+			return 0, fmt.Errorf("cpu_limit in nano-CPUs must be a whole number")
+		}
+		if limit < MinCPULimit*1e9 {
+			return 0, fmt.Errorf("cpu_limit must be at least %g cores", MinCPULimit)
+		}
+		return int64(limit), nil
+	case limit < MinCPULimit:
+		return 0, fmt.Errorf("cpu_limit must be at least %g cores", MinCPULimit)
+	}
+	return int64(math.Round(limit * 1e9)), nil
+}
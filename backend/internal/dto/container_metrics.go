@@ -0,0 +1,31 @@
+package dto
+
+import "time"
+
+// ContainerMetricsQuery selects the range of a container's stats history.
+// From and To are RFC3339 timestamps or YYYY-MM-DD days and default to the
+// last hour. Step is a duration such as "5m" or a number of seconds; it is
+// chosen from the range when empty.
+type ContainerMetricsQuery struct {
+	From string `form:"from"`
+	To   string `form:"to"`
+	Step string `form:"step"`
+}
+
+// ContainerMetricsSeries is a container's stats history as series aligned on
+// Timestamps, one point per step. Points of steps without samples are null.
+// CPU and memory are averages over the step; network and disk values are the
+// bytes transferred during it.
+type ContainerMetricsSeries struct {
+	ContainerID int64       `json:"container_id"`
+	From        time.Time   `json:"from"`
+	To          time.Time   `json:"to"`
+	StepSeconds int         `json:"step_seconds"`
+	Timestamps  []time.Time `json:"timestamps"`
+	CPUPercent  []*float64  `json:"cpu_percent"`
+	MemoryUsage []*int64    `json:"memory_usage"`
+	NetworkRx   []*int64    `json:"network_rx"`
+	NetworkTx   []*int64    `json:"network_tx"`
+	DiskRead    []*int64    `json:"disk_read"`
+	DiskWrite   []*int64    `json:"disk_write"`
+}
@@ -0,0 +1,51 @@
+package dto
+
+import (
+	"fmt"
+
+	"docker-auto/pkg/model"
+)
+
+// GrantContainerPermissionRequest grants a user, or every user of a role, a
+// permission on a container. A second grant to the same user or role
+// replaces the first.
+type GrantContainerPermissionRequest struct {
+	UserID     *int                           `json:"user_id,omitempty"`
+	Role       model.UserRole                 `json:"role,omitempty"`
+	Permission model.ContainerPermissionLevel `json:"permission" binding:"required"`
+}
+
+// Validate validates GrantContainerPermissionRequest
+func (r *GrantContainerPermissionRequest) Validate() error {
+	if err := validatePermissionSubject(r.UserID, r.Role); err != nil {
+		return err
+	}
+	if !r.Permission.IsValid() {
+		return fmt.Errorf("permission must be view, operate or manage")
+	}
+	return nil
+}
+
+// RevokeContainerPermissionQuery names the user or role whose permission on a
+// container is revoked
+type RevokeContainerPermissionQuery struct {
+	UserID *int           `form:"user_id"`
+	Role   model.UserRole `form:"role"`
+}
+
+// Validate validates RevokeContainerPermissionQuery
+func (q *RevokeContainerPermissionQuery) Validate() error {
+	return validatePermissionSubject(q.UserID, q.Role)
+}
+
+// validatePermissionSubject checks that exactly one of a user and a role is
+// named
+func validatePermissionSubject(userID *int, role model.UserRole) error {
+	if (userID == nil) == (role == "") {
+		return fmt.Errorf("exactly one of user_id and role is required")
+	}
+	if userID != nil && *userID <= 0 {
+		return fmt.Errorf("invalid user ID")
+	}
+	return nil
+}
@@ -0,0 +1,28 @@
+package dto
+
+import "docker-auto/pkg/model"
+
+// ContainerTemplateRequest creates or replaces a container template. Config
+// holds create request fields other than name, image and tag, with {{NAME}}
+// placeholders for the variables. Only admins may share a template.
+type ContainerTemplateRequest struct {
+	Name        string                   `json:"name" binding:"required"`
+	Description string                   `json:"description,omitempty"`
+	Image       string                   `json:"image" binding:"required"`
+	DefaultTag  string                   `json:"default_tag,omitempty"`
+	Config      map[string]interface{}   `json:"config"`
+	Variables   []model.TemplateVariable `json:"variables"`
+	Shared      bool                     `json:"shared"`
+}
+
+// InstantiateTemplateRequest creates a container from a template. Variables
+// left out take their defaults; Tag defaults to the template's default tag.
+type InstantiateTemplateRequest struct {
+	Name      string            `json:"name" binding:"required"`
+	Tag       string            `json:"tag,omitempty"`
+	Variables map[string]string `json:"variables"`
+
+	// TeamID and HostID place the container as in a create request
+	TeamID *int `json:"team_id,omitempty"`
+	HostID *int `json:"host_id,omitempty"`
+}
@@ -0,0 +1,26 @@
+package dto_test
+
+import (
+	"testing"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/model"
+)
+
+func TestCreateContainerRequestDefaultsToInherit(t *testing.T) {
+	req := &dto.CreateContainerRequest{Name: "web", Image: "nginx"}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if req.UpdatePolicy != string(model.UpdatePolicyInherit) {
+		t.Errorf("update policy = %q, want %q", req.UpdatePolicy, model.UpdatePolicyInherit)
+	}
+
+	req = &dto.CreateContainerRequest{Name: "web", Image: "nginx", UpdatePolicy: string(model.UpdatePolicyAuto)}
+	if err := req.Validate(); err != nil {
+		t.Fatalf("Validate failed: %v", err)
+	}
+	if req.UpdatePolicy != string(model.UpdatePolicyAuto) {
+		t.Errorf("update policy = %q, want the requested %q", req.UpdatePolicy, model.UpdatePolicyAuto)
+	}
+}
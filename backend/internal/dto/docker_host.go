@@ -0,0 +1,33 @@
+package dto
+
+// CreateDockerHostRequest adds a remote Docker daemon. Endpoint is a
+// tcp://, unix:// or ssh://user@host[:port][/socket] URL; the TLS and SSH
+// fields are paths of files on the server.
+type CreateDockerHostRequest struct {
+	Name       string `json:"name" binding:"required"`
+	Endpoint   string `json:"endpoint" binding:"required"`
+	TLSCACert  string `json:"tls_ca_cert,omitempty"`
+	TLSCert    string `json:"tls_cert,omitempty"`
+	TLSKey     string `json:"tls_key,omitempty"`
+	SSHKeyPath string `json:"ssh_key_path,omitempty"`
+	Enabled    *bool  `json:"enabled,omitempty"`
+}
+
+// UpdateDockerHostRequest changes a Docker host. Omitted fields keep their
+// values.
+type UpdateDockerHostRequest struct {
+	Name       *string `json:"name,omitempty"`
+	Endpoint   *string `json:"endpoint,omitempty"`
+	TLSCACert  *string `json:"tls_ca_cert,omitempty"`
+	TLSCert    *string `json:"tls_cert,omitempty"`
+	TLSKey     *string `json:"tls_key,omitempty"`
+	SSHKeyPath *string `json:"ssh_key_path,omitempty"`
+	Enabled    *bool   `json:"enabled,omitempty"`
+}
+
+// DockerHostPingResult is the outcome of pinging a Docker host
+type DockerHostPingResult struct {
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
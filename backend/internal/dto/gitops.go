@@ -0,0 +1,30 @@
+package dto
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GitOpsReconcileRequest reconciles the managed containers with a GitOps
+// source: an http(s) URL or a path to a YAML file, defaulting to the
+// configured GITOPS_SOURCE. Prune removes the containers the source managed
+// that it no longer lists; Force overwrites containers edited outside the
+// source instead of reporting them as conflicts. A dry run only reports.
+type GitOpsReconcileRequest struct {
+	Source string `json:"source,omitempty"`
+	Prune  bool   `json:"prune"`
+	Force  bool   `json:"force"`
+	DryRun bool   `json:"dry_run"`
+}
+
+// Validate validates the request
+func (r *GitOpsReconcileRequest) Validate() error {
+	r.Source = strings.TrimSpace(r.Source)
+	if len(r.Source) > 500 {
+		return fmt.Errorf("source must be at most 500 characters")
+	}
+	if strings.Contains(r.Source, "://") && !strings.HasPrefix(r.Source, "http://") && !strings.HasPrefix(r.Source, "https://") {
+		return fmt.Errorf("source must be an http(s) URL or a file path")
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package dto_test
+
+import (
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+const modulePath = "docker-auto"
+
+// layeringRules lists, per package, the imports it must not have. A rule
+// ending in "/" matches every package below it.
+var layeringRules = []struct {
+	pkg       string
+	forbidden []string
+}{
+	{pkg: "internal/service", forbidden: []string{"pkg/scheduler/tasks"}},
+	{pkg: "pkg/scheduler/tasks", forbidden: []string{"internal/service"}},
+	// dto embeds the model types it exchanges, such as containers and
+	// maintenance windows, rather than copying them, so model stays free of
+	// internal packages too
+	{pkg: "internal/dto", forbidden: []string{"internal/"}},
+	{pkg: "pkg/model", forbidden: []string{"internal/"}},
+}
+
+func TestPackageLayering(t *testing.T) {
+	root := filepath.Join("..", "..")
+
+	for _, rule := range layeringRules {
+		imports, err := packageImports(filepath.Join(root, filepath.FromSlash(rule.pkg)))
+		if err != nil {
+			t.Fatalf("%s: %v", rule.pkg, err)
+		}
+
+		for path, file := range imports {
+			local, ok := strings.CutPrefix(path, modulePath+"/")
+			if !ok {
+				continue
+			}
+			for _, forbidden := range rule.forbidden {
+				if local == forbidden || (strings.HasSuffix(forbidden, "/") && strings.HasPrefix(local, forbidden)) {
+					t.Errorf("%s imports %s (%s)", rule.pkg, path, file)
+				}
+			}
+		}
+	}
+}
+
+// packageImports returns the imports of the non-test files in dir, each with
+// a file that has it
+func packageImports(dir string) (map[string]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	imports := make(map[string]string)
+	fset := token.NewFileSet()
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasSuffix(name, "_test.go") {
+			continue
+		}
+
+		file, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, parser.ImportsOnly)
+		if err != nil {
+			return nil, err
+		}
+		for _, spec := range file.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			if err != nil {
+				return nil, err
+			}
+			imports[path] = name
+		}
+	}
+	return imports, nil
+}
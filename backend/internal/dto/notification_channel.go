@@ -0,0 +1,65 @@
+package dto
+
+import (
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// CreateNotificationChannelRequest adds a channel delivering notifications by
+// webhook, email or Slack. Settings holds the section matching Type. Global
+// channels are created by admins and receive the notifications raised by the
+// system only.
+type CreateNotificationChannelRequest struct {
+	Name              string                   `json:"name" binding:"required"`
+	Type              string                   `json:"type" binding:"required,oneof=webhook email slack"`
+	Global            bool                     `json:"global"`
+	Enabled           *bool                    `json:"enabled,omitempty"`
+	NotificationTypes []string                 `json:"notification_types,omitempty"` // Empty subscribes to every type
+	MinPriority       string                   `json:"min_priority,omitempty" binding:"omitempty,oneof=low normal high critical"`
+	Settings          model.NotificationConfig `json:"settings"`
+}
+
+// UpdateNotificationChannelRequest changes a notification channel. Omitted
+// fields keep their values; in Settings, secrets left empty or sent back
+// redacted keep the stored ones.
+type UpdateNotificationChannelRequest struct {
+	Name              *string                   `json:"name,omitempty"`
+	Enabled           *bool                     `json:"enabled,omitempty"`
+	NotificationTypes *[]string                 `json:"notification_types,omitempty"`
+	MinPriority       *string                   `json:"min_priority,omitempty" binding:"omitempty,oneof=low normal high critical"`
+	Settings          *model.NotificationConfig `json:"settings,omitempty"`
+}
+
+// NotificationChannelTestResult is the outcome of a test notification
+type NotificationChannelTestResult struct {
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// NotificationPreferences are the signed in user's delivery preferences.
+// With a digest interval, update available and update completed
+// notifications for the user's channels are buffered and sent as one digest
+// per interval; failures and health alerts are sent at once.
+type NotificationPreferences struct {
+	Digest             model.DigestInterval `json:"digest"`
+	DigestSentAt       *time.Time           `json:"digest_sent_at,omitempty"`
+	PendingDigestItems int                  `json:"pending_digest_items"`
+}
+
+// UpdateNotificationPreferencesRequest changes the signed in user's delivery
+// preferences. Turning the digest off sends the buffered notifications with
+// the next flush.
+type UpdateNotificationPreferencesRequest struct {
+	Digest string `json:"digest" binding:"required,oneof=off hourly daily"`
+}
+
+// DigestFlushResult is the outcome of sending the digests that are due
+type DigestFlushResult struct {
+	Users         int `json:"users"`
+	Sent          int `json:"sent"`
+	Notifications int `json:"notifications"`
+	Failed        int `json:"failed"`
+}
@@ -0,0 +1,68 @@
+package dto
+
+import "time"
+
+// Access lists of the rate limiter. Blacklisted IPs and users are always
+// rejected; whitelisted ones bypass every limit.
+const (
+	RateLimitWhitelist = "whitelist"
+	RateLimitBlacklist = "blacklist"
+)
+
+// EndpointRateLimit limits the requests to one API path from each client
+type EndpointRateLimit struct {
+	Endpoint      string   `json:"endpoint"`
+	Limit         int      `json:"limit"`
+	WindowSeconds int      `json:"window_seconds"`
+	Methods       []string `json:"methods,omitempty"` // every method when empty
+	RequireAuth   bool     `json:"require_auth"`      // only requests with credentials count
+}
+
+// RateLimitSettings are the limits the rate limiter applies now. Global,
+// user and IP limits are lowered while dynamic limiting reacts to load.
+type RateLimitSettings struct {
+	GlobalLimit         int                 `json:"global_limit"`
+	GlobalWindowSeconds int                 `json:"global_window_seconds"`
+	UserLimit           int                 `json:"user_limit"`
+	UserWindowSeconds   int                 `json:"user_window_seconds"`
+	IPLimit             int                 `json:"ip_limit"`
+	IPWindowSeconds     int                 `json:"ip_window_seconds"`
+	SubnetLimit         int                 `json:"subnet_limit"`
+	SubnetWindowSeconds int                 `json:"subnet_window_seconds"`
+	BanningEnabled      bool                `json:"banning_enabled"`
+	BanThreshold        int                 `json:"ban_threshold"`
+	BanDurationSeconds  int                 `json:"ban_duration_seconds"`
+	Endpoints           []EndpointRateLimit `json:"endpoints"`
+	IPWhitelist         []string            `json:"ip_whitelist"`
+	IPBlacklist         []string            `json:"ip_blacklist"`
+	UserWhitelist       []int64             `json:"user_whitelist"`
+	UserBlacklist       []int64             `json:"user_blacklist"`
+	// Persisted is set once endpoint limits or access lists were changed
+	// through the API; the stored values replace the defaults at startup
+	Persisted bool `json:"persisted"`
+}
+
+// UpdateEndpointRateLimitRequest sets the limit on an endpoint, replacing
+// any limit it has. The endpoint is a route as registered, so
+// /api/containers/:id/start limits starting any container.
+type UpdateEndpointRateLimitRequest struct {
+	Endpoint      string   `json:"endpoint" binding:"required"`
+	Limit         int      `json:"limit"`
+	WindowSeconds int      `json:"window_seconds"`
+	Methods       []string `json:"methods"`
+	RequireAuth   bool     `json:"require_auth"`
+}
+
+// RateLimitSubject is an IP or a user on an access list or banned. Exactly
+// one of them is set.
+type RateLimitSubject struct {
+	IP     string `json:"ip,omitempty" form:"ip"`
+	UserID int64  `json:"user_id,omitempty" form:"user_id"`
+}
+
+// RateLimitBan is an IP or user banned for repeated rate limit violations
+type RateLimitBan struct {
+	RateLimitSubject
+	Violations  int       `json:"violations"`
+	BannedUntil time.Time `json:"banned_until"`
+}
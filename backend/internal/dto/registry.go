@@ -0,0 +1,35 @@
+package dto
+
+// CreateRegistryCredentialRequest stores credentials for a private registry.
+// basic auth needs a username and password; token auth needs a token and
+// sends it as the password when a username is set.
+type CreateRegistryCredentialRequest struct {
+	Name        string `json:"name" binding:"required"`
+	RegistryURL string `json:"registry_url" binding:"required"`
+	AuthType    string `json:"auth_type"` // basic (default) or token
+	Username    string `json:"username,omitempty"`
+	Password    string `json:"password,omitempty"`
+	Token       string `json:"token,omitempty"`
+	IsActive    *bool  `json:"is_active,omitempty"`
+}
+
+// UpdateRegistryCredentialRequest changes stored credentials. Omitted fields,
+// including the password and token, keep their stored values.
+type UpdateRegistryCredentialRequest struct {
+	Name        *string `json:"name,omitempty"`
+	RegistryURL *string `json:"registry_url,omitempty"`
+	AuthType    *string `json:"auth_type,omitempty"`
+	Username    *string `json:"username,omitempty"`
+	Password    *string `json:"password,omitempty"`
+	Token       *string `json:"token,omitempty"`
+	IsActive    *bool   `json:"is_active,omitempty"`
+}
+
+// RegistryTestResult is the outcome of a registry connection test
+type RegistryTestResult struct {
+	Success    bool   `json:"success"`
+	Host       string `json:"host"`
+	Message    string `json:"message"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
@@ -0,0 +1,38 @@
+package dto
+
+import "docker-auto/pkg/model"
+
+// CreateRoleRequest adds a custom role. Permissions are permissions listed
+// in the permission matrix, or resource:* for all of a resource's.
+type CreateRoleRequest struct {
+	Name        string             `json:"name" binding:"required"`
+	Description string             `json:"description,omitempty"`
+	Permissions []model.Permission `json:"permissions" binding:"required"`
+}
+
+// UpdateRoleRequest changes a custom role. Omitted fields keep their values;
+// given permissions replace the role's.
+type UpdateRoleRequest struct {
+	Description *string            `json:"description,omitempty"`
+	Permissions []model.Permission `json:"permissions,omitempty"`
+}
+
+// RoleResponse is a role with the number of users assigned it
+type RoleResponse struct {
+	*model.Role
+	UserCount int64 `json:"user_count"`
+}
+
+// PermissionMatrix lists every permission and the roles granting it
+type PermissionMatrix struct {
+	Roles       []model.UserRole      `json:"roles"`
+	Permissions []PermissionMatrixRow `json:"permissions"`
+}
+
+// PermissionMatrixRow is a permission and the roles granting it, directly or
+// through a broader permission
+type PermissionMatrixRow struct {
+	Permission  model.Permission `json:"permission"`
+	Description string           `json:"description"`
+	GrantedTo   []model.UserRole `json:"granted_to"`
+}
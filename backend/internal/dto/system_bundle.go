@@ -0,0 +1,141 @@
+package dto
+
+import (
+	"encoding/json"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+const (
+	// SystemBundleVersion is the version of the bundle format this server
+	// writes and reads
+	SystemBundleVersion = 1
+
+	// MinBundlePassphraseLength is the shortest passphrase secrets in a
+	// bundle may be encrypted under
+	MinBundlePassphraseLength = 12
+)
+
+// BundleConflict kinds
+const (
+	BundleKindContainer           = "container"
+	BundleKindScheduledTask       = "scheduled_task"
+	BundleKindRegistryCredentials = "registry_credentials"
+	BundleKindNotificationChannel = "notification_channel"
+)
+
+// SystemBundle is a server's configuration exported for moving to another
+// server. Secrets are encrypted under the passphrase given at export;
+// PassphraseCheck is a known value encrypted the same way, so a wrong
+// passphrase is reported before anything is read.
+type SystemBundle struct {
+	Version              int                         `json:"version"`
+	ExportedAt           time.Time                   `json:"exported_at"`
+	PassphraseCheck      string                      `json:"passphrase_check"`
+	Containers           []BundleContainer           `json:"containers"`
+	ScheduledTasks       []BundleScheduledTask       `json:"scheduled_tasks"`
+	RegistryCredentials  []BundleRegistryCredential  `json:"registry_credentials"`
+	NotificationChannels []BundleNotificationChannel `json:"notification_channels"`
+}
+
+// BundleContainer is a managed container in a system bundle. Stack, team and
+// host membership refer to records of the exporting server and is left out.
+type BundleContainer struct {
+	Name          string          `json:"name"`
+	Image         string          `json:"image"`
+	Tag           string          `json:"tag"`
+	Config        json.RawMessage `json:"config,omitempty"`
+	UpdatePolicy  string          `json:"update_policy"`
+	VersionPolicy string          `json:"version_policy"`
+	RegistryURL   string          `json:"registry_url,omitempty"`
+	// RegistryAuth is the container's own registry login, encrypted
+	RegistryAuth  string          `json:"registry_auth,omitempty"`
+	HealthCheck   json.RawMessage `json:"health_check,omitempty"`
+	Labels        json.RawMessage `json:"labels,omitempty"`
+	RestartPolicy string          `json:"restart_policy,omitempty"`
+
+	StopTimeoutSeconds *int   `json:"stop_timeout_seconds,omitempty"`
+	StopSignal         string `json:"stop_signal,omitempty"`
+
+	CheckIntervalMinutes   *int            `json:"check_interval_minutes,omitempty"`
+	HoldDownHours          *int            `json:"hold_down_hours,omitempty"`
+	MaintenanceWindows     json.RawMessage `json:"maintenance_windows,omitempty"`
+	VulnerabilityThreshold string          `json:"vulnerability_threshold,omitempty"`
+
+	PinByDigest bool   `json:"pin_by_digest,omitempty"`
+	ImageDigest string `json:"image_digest,omitempty"`
+
+	HealthActions     model.HealthActionList  `json:"health_actions,omitempty"`
+	WarmupSeconds     int                     `json:"warmup_seconds,omitempty"`
+	PostStartHooks    model.PostStartHookList `json:"post_start_hooks,omitempty"`
+	LogRedactPatterns model.StringList        `json:"log_redact_patterns,omitempty"`
+	SecretEnv         model.StringList        `json:"secret_env,omitempty"`
+	StackOrder        int                     `json:"stack_order,omitempty"`
+}
+
+// BundleScheduledTask is a scheduled task in a system bundle; its targets
+// are container names
+type BundleScheduledTask struct {
+	Name             string          `json:"name"`
+	Type             string          `json:"type"`
+	ScheduleType     string          `json:"schedule_type,omitempty"`
+	CronExpression   string          `json:"cron_expression,omitempty"`
+	RunAt            *time.Time      `json:"run_at,omitempty"`
+	TargetContainers []string        `json:"target_containers,omitempty"`
+	Parameters       json.RawMessage `json:"parameters,omitempty"`
+	IsActive         bool            `json:"is_active"`
+}
+
+// BundleRegistryCredential is stored registry credentials in a system
+// bundle; Password and Token are encrypted
+type BundleRegistryCredential struct {
+	Name        string          `json:"name"`
+	RegistryURL string          `json:"registry_url"`
+	Username    string          `json:"username,omitempty"`
+	Password    string          `json:"password,omitempty"`
+	Token       string          `json:"token,omitempty"`
+	AuthType    string          `json:"auth_type"`
+	IsDefault   bool            `json:"is_default"`
+	IsActive    bool            `json:"is_active"`
+	Metadata    json.RawMessage `json:"metadata,omitempty"`
+}
+
+// BundleNotificationChannel is a global notification channel in a system
+// bundle; Settings is its encrypted settings document
+type BundleNotificationChannel struct {
+	Name              string   `json:"name"`
+	Type              string   `json:"type"`
+	Enabled           bool     `json:"enabled"`
+	NotificationTypes []string `json:"notification_types,omitempty"`
+	MinPriority       string   `json:"min_priority"`
+	Settings          string   `json:"settings"`
+}
+
+// ImportSystemBundleRequest imports a system bundle. With DryRun the bundle
+// is checked and the result reported without changing anything.
+type ImportSystemBundleRequest struct {
+	Passphrase string        `json:"passphrase" binding:"required"`
+	DryRun     bool          `json:"dry_run"`
+	Bundle     *SystemBundle `json:"bundle" binding:"required"`
+}
+
+// BundleConflict is an entry of a bundle that cannot be imported alongside
+// the existing configuration
+type BundleConflict struct {
+	Kind   string `json:"kind"`
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// SystemImportResult reports what an import created, or would create on a
+// dry run. Nothing is created while there are conflicts.
+type SystemImportResult struct {
+	DryRun               bool             `json:"dry_run"`
+	Applied              bool             `json:"applied"`
+	Conflicts            []BundleConflict `json:"conflicts"`
+	Containers           int              `json:"containers"`
+	ScheduledTasks       int              `json:"scheduled_tasks"`
+	RegistryCredentials  int              `json:"registry_credentials"`
+	NotificationChannels int              `json:"notification_channels"`
+}
@@ -0,0 +1,63 @@
+package dto
+
+import (
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// UpdateStatusInterrupted is the display status of an update recorded as
+// finished without a completion time
+const UpdateStatusInterrupted = "interrupted"
+
+// UpdateHistoryEntry is an update history row with its container name and
+// computed duration
+type UpdateHistoryEntry struct {
+	*model.UpdateHistory
+	ContainerName string `json:"container_name,omitempty"`
+	// DisplayStatus is the status, or "interrupted" for an update recorded as
+	// finished without a completion time
+	DisplayStatus string `json:"display_status"`
+	// ElapsedSeconds is the time from start to completion; null while the
+	// update runs or when it was interrupted
+	ElapsedSeconds *float64 `json:"elapsed_seconds"`
+}
+
+// NewUpdateHistoryEntry describes the update history row
+func NewUpdateHistoryEntry(history *model.UpdateHistory) *UpdateHistoryEntry {
+	entry := &UpdateHistoryEntry{
+		UpdateHistory: history,
+		ContainerName: history.Container.Name,
+		DisplayStatus: string(history.Status),
+	}
+	if history.IsInterrupted() {
+		entry.DisplayStatus = UpdateStatusInterrupted
+	}
+	if elapsed, ok := history.Elapsed(); ok {
+		seconds := elapsed.Seconds()
+		entry.ElapsedSeconds = &seconds
+	}
+	return entry
+}
+
+// UpdateHistoryListResponse is a page of update history, newest first
+type UpdateHistoryListResponse struct {
+	Updates    []*UpdateHistoryEntry `json:"updates"`
+	Total      int64                 `json:"total"`
+	Page       int                   `json:"page"`
+	Limit      int                   `json:"limit"`
+	HasNext    bool                  `json:"has_next"`
+	HasPrev    bool                  `json:"has_prev"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// UpdateProgress is how far an update has come. Pull is the progress of the
+// image pull while the update runs in this server; it is dropped once the
+// update ends.
+type UpdateProgress struct {
+	UpdateID    int                 `json:"update_id"`
+	ContainerID int                 `json:"container_id"`
+	Status      model.UpdateStatus  `json:"status"`
+	Pull        *model.PullProgress `json:"pull,omitempty"`
+	UpdatedAt   *time.Time          `json:"updated_at,omitempty"`
+}
@@ -0,0 +1,102 @@
+package dto
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+)
+
+var (
+	// ErrVolumeSampleRateLimited is returned when volumes were sampled more
+	// recently than the configured minimum interval
+	ErrVolumeSampleRateLimited = errors.New("volume usage was sampled too recently")
+
+	// ErrVolumeSampleInProgress is returned while another sampling run is active
+	ErrVolumeSampleInProgress = errors.New("volume usage sampling already in progress")
+)
+
+// Volume listing sort fields
+const (
+	VolumeSortName      = "name"
+	VolumeSortSize      = "size"
+	VolumeSortCreatedAt = "created_at"
+)
+
+// Volume listing status filters
+const (
+	VolumeStatusInUse  = "in_use"
+	VolumeStatusUnused = "unused"
+)
+
+// VolumeListQuery filters, sorts and pages the volume listing
+type VolumeListQuery struct {
+	// Name is a path.Match glob on the volume name, e.g. "app_*"
+	Name string
+	// Label is "key" or "key=value"
+	Label  string
+	Driver string
+	// Status is in_use or unused
+	Status string
+	Sort   string
+	Desc   bool
+	Limit  int
+	Offset int
+}
+
+// Validate checks the query and fills in the default sort
+func (q *VolumeListQuery) Validate() error {
+	if q.Name != "" {
+		if _, err := path.Match(q.Name, ""); err != nil {
+			return fmt.Errorf("invalid request: invalid name pattern %q", q.Name)
+		}
+	}
+
+	switch q.Status {
+	case "", VolumeStatusInUse, VolumeStatusUnused:
+	default:
+		return fmt.Errorf("invalid request: status must be %q or %q", VolumeStatusInUse, VolumeStatusUnused)
+	}
+
+	switch q.Sort {
+	case "":
+		q.Sort = VolumeSortName
+	case VolumeSortName, VolumeSortSize, VolumeSortCreatedAt:
+	default:
+		return fmt.Errorf("invalid request: sort must be one of %s, %s, %s", VolumeSortName, VolumeSortSize, VolumeSortCreatedAt)
+	}
+
+	return nil
+}
+
+// MatchesLabel reports whether labels satisfy the label filter
+func (q *VolumeListQuery) MatchesLabel(labels map[string]string) bool {
+	if q.Label == "" {
+		return true
+	}
+	key, value, hasValue := strings.Cut(q.Label, "=")
+	got, ok := labels[key]
+	return ok && (!hasValue || got == value)
+}
+
+// VolumeSampleRequest tunes a sampling run
+type VolumeSampleRequest struct {
+	// RescanLarge measures volumes previously skipped as too large again
+	RescanLarge bool `json:"rescan_large"`
+}
+
+// VolumeSampleResult summarizes a sampling run
+type VolumeSampleResult struct {
+	SampledAt   time.Time                `json:"sampled_at"`
+	Volumes     int                      `json:"volumes"`
+	Reported    int                      `json:"reported"`
+	Measured    int                      `json:"measured"`
+	TooLarge    int                      `json:"too_large"`
+	Unavailable int                      `json:"unavailable"`
+	DataRoot    *docker.FilesystemUsage  `json:"data_root,omitempty"`
+	Alerts      []model.VolumeAlertEntry `json:"alerts"`
+}
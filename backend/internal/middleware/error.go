@@ -6,6 +6,7 @@ import (
 	"runtime/debug"
 	"strings"
 
+	"docker-auto/pkg/docker"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -24,6 +25,7 @@ const (
 	ErrorTypeInternal     ErrorType = "internal"
 	ErrorTypeNotFound     ErrorType = "not_found"
 	ErrorTypeUnauthorized ErrorType = "unauthorized"
+	ErrorTypeDocker       ErrorType = "docker"
 )
 
 // AppError represents an application error with additional context
@@ -31,6 +33,7 @@ type AppError struct {
 	Type       ErrorType `json:"type"`
 	Message    string    `json:"message"`
 	Details    string    `json:"details,omitempty"`
+	Code       string    `json:"code,omitempty"`
 	StatusCode int       `json:"status_code"`
 	Internal   error     `json:"-"`
 }
@@ -71,6 +74,25 @@ func NewAppErrorWithCause(errorType ErrorType, message string, statusCode int, c
 	}
 }
 
+// AppErrorFromDocker translates an error returned by the Docker daemon into
+// an application error with the daemon's status and error code, keeping its
+// sanitized message as details. It returns nil for errors not from Docker.
+func AppErrorFromDocker(err error) *AppError {
+	dockerErr := docker.TranslateError(err, "", "")
+	if dockerErr == nil {
+		return nil
+	}
+
+	return &AppError{
+		Type:       ErrorTypeDocker,
+		Message:    dockerErr.Message,
+		Details:    dockerErr.Details,
+		Code:       dockerErr.Code,
+		StatusCode: dockerErr.StatusCode,
+		Internal:   err,
+	}
+}
+
 // ErrorHandlerMiddleware creates an error handling middleware
 func ErrorHandlerMiddleware() gin.HandlerFunc {
 	return ErrorHandlerMiddlewareWithConfig(&ErrorConfig{
@@ -158,12 +180,17 @@ func handleError(c *gin.Context, err error) {
 	var statusCode int
 	var response *utils.APIResponse
 
-	// Check if it's an AppError
-	if ae, ok := err.(*AppError); ok {
+	// Check if it's an AppError, or a Docker error that translates to one
+	ae, ok := err.(*AppError)
+	if !ok {
+		ae = AppErrorFromDocker(err)
+	}
+	if ae != nil {
 		statusCode = ae.StatusCode
 
 		logFields := logrus.Fields{
 			"error_type": ae.Type,
+			"error_code": ae.Code,
 			"message":    ae.Message,
 			"path":       c.Request.URL.Path,
 			"method":     c.Request.Method,
@@ -188,9 +215,9 @@ func handleError(c *gin.Context, err error) {
 		}
 
 		// Build response
-		if ae.Details != "" {
+		if ae.Details != "" || ae.Code != "" {
 			errorResp := utils.ErrorResponseWithDetails(statusCode, ae.Message, []utils.ErrorDetail{
-				{Message: ae.Details},
+				{Message: ae.Details, Code: ae.Code},
 			})
 			c.JSON(statusCode, errorResp)
 			return
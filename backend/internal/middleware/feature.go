@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FeatureChecker reports whether a feature flag is enabled
+type FeatureChecker interface {
+	Enabled(ctx context.Context, feature model.Feature) bool
+}
+
+// RequireFeature rejects requests with FEATURE_DISABLED while the feature is
+// switched off. A nil checker lets every request through.
+func RequireFeature(checker FeatureChecker, feature model.Feature) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if checker == nil || checker.Enabled(c.Request.Context(), feature) {
+			c.Next()
+			return
+		}
+
+		c.JSON(http.StatusNotFound, utils.ErrorResponseWithDetails(
+			http.StatusNotFound,
+			"Feature is disabled",
+			[]utils.ErrorDetail{{
+				Field:   string(feature),
+				Message: "The " + string(feature) + " feature is disabled on this server",
+				Code:    "FEATURE_DISABLED",
+			}},
+		))
+		c.Abort()
+	}
+}
@@ -1,6 +1,7 @@
 package middleware
 
 import (
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 	"net/http"
 
@@ -50,6 +51,7 @@ func JWTAuthMiddleware(secret string) gin.HandlerFunc {
 		// Store user information in context
 		c.Set(ContextUserKey, claims)
 		c.Set(ContextUserIDKey, claims.UserID)
+		c.Request = c.Request.WithContext(model.WithActor(c.Request.Context(), model.UserActor(claims.UserID, claims.Username)))
 
 		logrus.WithFields(logrus.Fields{
 			"user_id":  claims.UserID,
@@ -92,6 +94,7 @@ func JWTAuthOptionalMiddleware(secret string) gin.HandlerFunc {
 		// Store user information in context
 		c.Set(ContextUserKey, claims)
 		c.Set(ContextUserIDKey, claims.UserID)
+		c.Request = c.Request.WithContext(model.WithActor(c.Request.Context(), model.UserActor(claims.UserID, claims.Username)))
 
 		logrus.WithFields(logrus.Fields{
 			"user_id":  claims.UserID,
@@ -132,6 +135,15 @@ func GetUserIDFromContext(c *gin.Context) (int64, bool) {
 	return 0, false
 }
 
+// RequestActor returns the authenticated user as the actor of the operations
+// a request performs
+func RequestActor(c *gin.Context, userID int64) model.Actor {
+	if claims := getUserFromContext(c); claims != nil && claims.UserID == userID {
+		return model.UserActor(userID, claims.Username)
+	}
+	return model.UserActor(userID, "")
+}
+
 // RequireAuth ensures that the request is authenticated
 func RequireAuth() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -134,6 +134,10 @@ func (rl *RequestLogger) Handler() gin.HandlerFunc {
 			"size":        c.Writer.Size(),
 		}
 
+		if requestID := RequestID(c); requestID != "" {
+			fields["request_id"] = requestID
+		}
+
 		// Add user info if available
 		if userID != nil {
 			fields["user_id"] = userID
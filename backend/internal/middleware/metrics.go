@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// MetricsAuth guards the Prometheus endpoint. A scraper authenticates with
+// basic auth when a username is configured, or with a bearer token when a
+// token is configured; with neither the endpoint is open.
+func MetricsAuth(username, password, token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if username == "" && token == "" {
+			c.Next()
+			return
+		}
+
+		if username != "" {
+			if user, pass, ok := c.Request.BasicAuth(); ok &&
+				subtle.ConstantTimeCompare([]byte(user), []byte(username)) == 1 &&
+				subtle.ConstantTimeCompare([]byte(pass), []byte(password)) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		if token != "" {
+			bearer := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+			if subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+
+		if username != "" {
+			c.Header("WWW-Authenticate", `Basic realm="metrics"`)
+		}
+		c.JSON(http.StatusUnauthorized, utils.ErrorResponse(http.StatusUnauthorized, "Invalid or missing metrics credentials"))
+		c.Abort()
+	}
+}
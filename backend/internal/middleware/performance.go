@@ -267,6 +267,9 @@ func checkETag(c *gin.Context) bool {
 
 // getRequestID gets or generates a request ID
 func getRequestID(c *gin.Context) string {
+	if id := RequestID(c); id != "" {
+		return id
+	}
 	if id := c.Request.Header.Get("X-Request-ID"); id != "" {
 		return id
 	}
@@ -5,48 +5,51 @@ import (
 	"net/http"
 	"strings"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
 )
 
-// Permission defines different permission levels
-type Permission string
+// Permission is a permission roles grant; see model.Permission
+type Permission = model.Permission
 
 const (
 	// Basic permissions
-	PermissionRead   Permission = "read"
-	PermissionWrite  Permission = "write"
-	PermissionDelete Permission = "delete"
-	PermissionAdmin  Permission = "admin"
+	PermissionRead   = model.PermissionRead
+	PermissionWrite  = model.PermissionWrite
+	PermissionDelete = model.PermissionDelete
+	PermissionAdmin  = model.PermissionAdmin
 
 	// Resource-specific permissions
-	PermissionContainerRead     Permission = "container:read"
-	PermissionContainerWrite    Permission = "container:write"
-	PermissionContainerDelete   Permission = "container:delete"
-	PermissionContainerManage   Permission = "container:manage"
-
-	PermissionImageRead         Permission = "image:read"
-	PermissionImageWrite        Permission = "image:write"
-	PermissionImageDelete       Permission = "image:delete"
-
-	PermissionUserRead          Permission = "user:read"
-	PermissionUserWrite         Permission = "user:write"
-	PermissionUserDelete        Permission = "user:delete"
-	PermissionUserManage        Permission = "user:manage"
-
-	PermissionSystemRead        Permission = "system:read"
-	PermissionSystemWrite       Permission = "system:write"
-	PermissionSystemManage      Permission = "system:manage"
-
-	PermissionScheduleRead      Permission = "schedule:read"
-	PermissionScheduleWrite     Permission = "schedule:write"
-	PermissionScheduleDelete    Permission = "schedule:delete"
-
-	PermissionNotificationRead  Permission = "notification:read"
-	PermissionNotificationWrite Permission = "notification:write"
+	PermissionContainerRead    = model.PermissionContainerRead
+	PermissionContainerWrite   = model.PermissionContainerWrite
+	PermissionContainerUpdate  = model.PermissionContainerUpdate
+	PermissionContainerDelete  = model.PermissionContainerDelete
+	PermissionContainerManage  = model.PermissionContainerManage
+	PermissionContainerExec    = model.PermissionContainerExec
+	PermissionContainerSecrets = model.PermissionContainerSecrets
+
+	PermissionImageRead   = model.PermissionImageRead
+	PermissionImageWrite  = model.PermissionImageWrite
+	PermissionImageDelete = model.PermissionImageDelete
+
+	PermissionUserRead   = model.PermissionUserRead
+	PermissionUserWrite  = model.PermissionUserWrite
+	PermissionUserDelete = model.PermissionUserDelete
+	PermissionUserManage = model.PermissionUserManage
+
+	PermissionSystemRead   = model.PermissionSystemRead
+	PermissionSystemWrite  = model.PermissionSystemWrite
+	PermissionSystemManage = model.PermissionSystemManage
+
+	PermissionScheduleRead   = model.PermissionScheduleRead
+	PermissionScheduleWrite  = model.PermissionScheduleWrite
+	PermissionScheduleDelete = model.PermissionScheduleDelete
+
+	PermissionNotificationRead  = model.PermissionNotificationRead
+	PermissionNotificationWrite = model.PermissionNotificationWrite
 )
 
 // PermissionConfig represents permission middleware configuration
@@ -57,38 +60,6 @@ type PermissionConfig struct {
 	LogViolations  bool     // Log permission violations
 }
 
-// rolePermissions defines permissions for each role
-var rolePermissions = map[model.UserRole][]Permission{
-	model.UserRoleAdmin: {
-		// Admin has all permissions
-		PermissionRead, PermissionWrite, PermissionDelete, PermissionAdmin,
-		PermissionContainerRead, PermissionContainerWrite, PermissionContainerDelete, PermissionContainerManage,
-		PermissionImageRead, PermissionImageWrite, PermissionImageDelete,
-		PermissionUserRead, PermissionUserWrite, PermissionUserDelete, PermissionUserManage,
-		PermissionSystemRead, PermissionSystemWrite, PermissionSystemManage,
-		PermissionScheduleRead, PermissionScheduleWrite, PermissionScheduleDelete,
-		PermissionNotificationRead, PermissionNotificationWrite,
-	},
-	model.UserRoleOperator: {
-		// Operator can manage containers and images, read system info
-		PermissionRead, PermissionWrite,
-		PermissionContainerRead, PermissionContainerWrite, PermissionContainerDelete, PermissionContainerManage,
-		PermissionImageRead, PermissionImageWrite, PermissionImageDelete,
-		PermissionSystemRead,
-		PermissionScheduleRead, PermissionScheduleWrite, PermissionScheduleDelete,
-		PermissionNotificationRead, PermissionNotificationWrite,
-	},
-	model.UserRoleViewer: {
-		// Viewer can only read most resources
-		PermissionRead,
-		PermissionContainerRead,
-		PermissionImageRead,
-		PermissionSystemRead,
-		PermissionScheduleRead,
-		PermissionNotificationRead,
-	},
-}
-
 // PermissionMiddleware creates a permission checking middleware
 func PermissionMiddleware(requiredPermission Permission) gin.HandlerFunc {
 	return PermissionMiddlewareWithConfig(requiredPermission, &PermissionConfig{
@@ -168,63 +139,14 @@ func PermissionMiddlewareWithConfig(requiredPermission Permission, config *Permi
 	}
 }
 
-// checkUserPermission checks if a user role has the required permission
+// checkUserPermission checks if a built-in user role has the required
+// permission. Custom roles are resolved by the AuthChain only.
 func checkUserPermission(userRole model.UserRole, permission Permission) bool {
-	permissions, exists := rolePermissions[userRole]
+	permissions, exists := model.BuiltinRolePermissions(model.CanonicalRole(userRole))
 	if !exists {
 		return false
 	}
-
-	// Check for exact permission match
-	for _, p := range permissions {
-		if p == permission {
-			return true
-		}
-	}
-
-	// Check for wildcard permissions
-	return checkWildcardPermissions(permissions, permission)
-}
-
-// checkWildcardPermissions checks for wildcard permission matches
-func checkWildcardPermissions(userPermissions []Permission, requiredPermission Permission) bool {
-	requiredStr := string(requiredPermission)
-
-	for _, p := range userPermissions {
-		pStr := string(p)
-
-		// Check if user has admin permission (grants everything)
-		if p == PermissionAdmin {
-			return true
-		}
-
-		// Check resource-specific wildcard (e.g., container:* allows container:read)
-		if strings.Contains(requiredStr, ":") {
-			parts := strings.Split(requiredStr, ":")
-			if len(parts) == 2 {
-				resourceWildcard := parts[0] + ":*"
-				if pStr == resourceWildcard {
-					return true
-				}
-
-				// Check if user has manage permission for the resource
-				managePermission := parts[0] + ":manage"
-				if pStr == managePermission {
-					return true
-				}
-			}
-		}
-
-		// Check basic permission inheritance
-		if requiredPermission == PermissionRead && (p == PermissionWrite || p == PermissionDelete) {
-			return true
-		}
-		if requiredPermission == PermissionWrite && p == PermissionDelete {
-			return true
-		}
-	}
-
-	return false
+	return model.GrantsPermission(permissions, permission)
 }
 
 // checkSelfAccess checks if user is accessing their own resource
@@ -253,14 +175,15 @@ func checkSelfAccess(c *gin.Context, user *utils.Claims) bool {
 }
 
 
+// roleLevels orders the roles for minimum role checks
+var roleLevels = map[model.UserRole]int{
+	model.UserRoleViewer:   1,
+	model.UserRoleOperator: 2,
+	model.UserRoleAdmin:    3,
+}
+
 // RequireMinRole creates a middleware that requires a minimum role level
 func RequireMinRole(minRole model.UserRole) gin.HandlerFunc {
-	roleHierarchy := map[model.UserRole]int{
-		model.UserRoleViewer:   1,
-		model.UserRoleOperator: 2,
-		model.UserRoleAdmin:    3,
-	}
-
 	return func(c *gin.Context) {
 		user := GetUserFromContext(c)
 		if user == nil {
@@ -269,8 +192,8 @@ func RequireMinRole(minRole model.UserRole) gin.HandlerFunc {
 			return
 		}
 
-		userLevel, userExists := roleHierarchy[user.Role]
-		minLevel, minExists := roleHierarchy[minRole]
+		userLevel, userExists := roleLevels[user.Role]
+		minLevel, minExists := roleLevels[minRole]
 
 		if !userExists || !minExists || userLevel < minLevel {
 			logrus.WithFields(logrus.Fields{
@@ -7,6 +7,7 @@ import (
 	"sync"
 	"time"
 
+	"docker-auto/pkg/security"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -365,4 +366,65 @@ func GlobalRateLimitMiddleware() gin.HandlerFunc {
 		},
 	}
 	return RateLimitMiddlewareWithConfig(limiter, config)
-}
\ No newline at end of file
+}
+
+// EnhancedRateLimitMiddleware checks requests against an enhanced rate
+// limiter, whose limits can change while it runs. It runs before route auth,
+// so requests are identified by IP and count as authenticated when they
+// carry credentials.
+func EnhancedRateLimitMiddleware(limiter *security.EnhancedRateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID, _ := GetUserIDFromContext(c)
+		result, err := limiter.CheckLimit(&security.RateLimitContext{
+			IP:        c.ClientIP(),
+			UserID:    userID,
+			Endpoint:  rateLimitEndpoint(c),
+			Method:    c.Request.Method,
+			UserAgent: c.Request.UserAgent(),
+			Timestamp: time.Now(),
+			IsAuth:    userID > 0 || c.GetHeader("Authorization") != "" || c.GetHeader("X-API-Key") != "",
+		})
+		if err != nil {
+			logrus.WithError(err).Warn("Rate limit check failed")
+			c.Next()
+			return
+		}
+
+		if !result.ResetTime.IsZero() {
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(result.ResetTime.Unix(), 10))
+		}
+
+		if !result.Allowed {
+			logrus.WithFields(logrus.Fields{
+				"path":      c.Request.URL.Path,
+				"method":    c.Request.Method,
+				"client_ip": c.ClientIP(),
+				"reason":    result.Reason,
+			}).Warn("Rate limit exceeded")
+
+			if result.RetryAfter > 0 {
+				c.Header("Retry-After", strconv.Itoa(int(result.RetryAfter.Seconds())))
+			}
+			c.JSON(http.StatusTooManyRequests, utils.ErrorResponseWithDetails(
+				http.StatusTooManyRequests,
+				"Too many requests",
+				[]utils.ErrorDetail{{Message: result.Reason}},
+			))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitEndpoint returns the route a request matched, such as
+// /api/containers/:id/start, so a limit on a parameterized route covers every
+// path it matches. Requests that matched no route are limited by their path.
+func rateLimitEndpoint(c *gin.Context) string {
+	if route := c.FullPath(); route != "" {
+		return route
+	}
+	return c.Request.URL.Path
+}
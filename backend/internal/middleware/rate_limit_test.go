@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"docker-auto/pkg/security"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestEnhancedRateLimitMiddlewareMatchesParameterizedRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	config := security.DefaultEnhancedRateLimitConfig()
+	config.EnableDynamicLimits = false
+	config.EnableBanning = false
+	config.BurstMultiplier = 1
+	config.EndpointLimits = map[string]security.EndpointLimit{
+		"/api/containers/:id/start": {Limit: 2, Window: time.Minute, Methods: []string{http.MethodPost}},
+	}
+	limiter := security.NewEnhancedRateLimiter(config)
+	t.Cleanup(limiter.Stop)
+
+	router := gin.New()
+	router.Use(EnhancedRateLimitMiddleware(limiter))
+	ok := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.POST("/api/containers/:id/start", ok)
+	router.POST("/api/containers/:id/stop", ok)
+
+	tests := []struct {
+		path string
+		want int
+	}{
+		// Starting any container counts against the one route limit
+		{"/api/containers/1/start", http.StatusOK},
+		{"/api/containers/2/start", http.StatusOK},
+		{"/api/containers/3/start", http.StatusTooManyRequests},
+		// Other routes under the same prefix are not limited by it
+		{"/api/containers/1/stop", http.StatusOK},
+		{"/api/containers/1/stop", http.StatusOK},
+		{"/api/containers/1/stop", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, tt.path, nil)
+		req.RemoteAddr = "192.0.2.10:1234"
+		router.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("POST %s = %d, want %d", tt.path, w.Code, tt.want)
+		}
+	}
+}
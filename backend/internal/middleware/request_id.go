@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// requestIDPattern is what an incoming request ID must look like to be kept;
+// others are replaced so that clients cannot inject into logs
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+type requestIDKey struct{}
+
+// RequestIDMiddleware assigns each request an ID, the client's X-Request-ID
+// when it sends a valid one. The ID is returned in the X-Request-ID header
+// and API responses, and is set on the request context, where RequestIDHook
+// adds it to log entries.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !requestIDPattern.MatchString(requestID) {
+			var err error
+			requestID, err = utils.GenerateSecureRandomString(16)
+			if err != nil {
+				logrus.WithError(err).Error("Failed to generate request ID")
+				requestID = fmt.Sprintf("req-%d", time.Now().UnixNano())
+			}
+		}
+
+		c.Header(RequestIDHeader, requestID)
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), requestIDKey{}, requestID))
+
+		c.Next()
+	}
+}
+
+// RequestID returns the ID of the request
+func RequestID(c *gin.Context) string {
+	return c.GetString("request_id")
+}
+
+// RequestIDFromContext returns the ID of the request ctx belongs to, empty
+// outside of requests
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// RequestIDHook adds the request ID to entries logged with the context of a
+// request, e.g. logrus.WithContext(ctx)
+type RequestIDHook struct{}
+
+// Levels implements logrus.Hook
+func (RequestIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+// Fire implements logrus.Hook
+func (RequestIDHook) Fire(entry *logrus.Entry) error {
+	if requestID := RequestIDFromContext(entry.Context); requestID != "" {
+		entry.Data["request_id"] = requestID
+	}
+	return nil
+}
@@ -0,0 +1,531 @@
+package middleware
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/sirupsen/logrus"
+)
+
+// AuthMode is a way a request may authenticate
+type AuthMode string
+
+const (
+	// AuthNone marks a public route
+	AuthNone AuthMode = "none"
+	// AuthJWT accepts a bearer JWT in the Authorization header
+	AuthJWT AuthMode = "jwt"
+	// AuthAPIToken accepts a configured API key in the API key header
+	AuthAPIToken AuthMode = "api_token"
+	// AuthCookie accepts a JWT in the session cookie; unsafe methods must
+	// repeat the CSRF cookie in the X-CSRF-Token header
+	AuthCookie AuthMode = "cookie"
+	// AuthPersonalToken accepts a personal access token as
+	// "Authorization: Token <value>" on routes declaring a scope
+	AuthPersonalToken AuthMode = "personal_token"
+)
+
+// personalTokenScheme is the Authorization scheme of personal access tokens
+const personalTokenScheme = "Token "
+
+// ContextPrincipalKey is the key used to store the authenticated principal in context
+const ContextPrincipalKey = "principal"
+
+// Principal is the authenticated caller of a request
+type Principal struct {
+	Mode  AuthMode
+	Actor model.Actor
+	Role  model.UserRole
+	// Permissions are the permissions Role grants
+	Permissions []model.Permission
+	// Claims is nil for API tokens
+	Claims *utils.Claims
+	// Token is the personal access token the request authenticated with
+	Token *model.APIToken
+}
+
+// Can reports whether the principal's role grants the permission
+func (p *Principal) Can(permission Permission) bool {
+	return model.GrantsPermission(p.Permissions, permission)
+}
+
+// AuthRequirement declares how callers of a route authenticate and what they
+// need to be allowed in
+type AuthRequirement struct {
+	Modes      []AuthMode
+	Permission Permission
+	MinRole    model.UserRole
+	// AllowSelf lets users read their own user resource without Permission
+	AllowSelf bool
+	// Scope is the scope personal access tokens need, besides their user
+	// meeting Permission and MinRole
+	Scope model.TokenScope
+}
+
+// Public reports whether the route requires no authentication
+func (r AuthRequirement) Public() bool {
+	return len(r.Modes) == 1 && r.Modes[0] == AuthNone
+}
+
+// Allows reports whether the route accepts the authentication mode
+func (r AuthRequirement) Allows(mode AuthMode) bool {
+	for _, allowed := range r.Modes {
+		if allowed == mode {
+			return true
+		}
+	}
+	return false
+}
+
+// WithScope returns the requirement also admitting personal access tokens
+// granted scope
+func (r AuthRequirement) WithScope(scope model.TokenScope) AuthRequirement {
+	r.Modes = append(append([]AuthMode{}, r.Modes...), AuthPersonalToken)
+	r.Scope = scope
+	return r
+}
+
+// UsersOnly returns the requirement without API tokens, for routes acting on
+// behalf of the signed in user. Personal access tokens act for their user and
+// are kept.
+func (r AuthRequirement) UsersOnly() AuthRequirement {
+	modes := make([]AuthMode, 0, len(r.Modes))
+	for _, mode := range r.Modes {
+		if mode != AuthAPIToken {
+			modes = append(modes, mode)
+		}
+	}
+	r.Modes = modes
+	return r
+}
+
+// Validate checks that the requirement is either public or names at least one
+// authentication mode
+func (r AuthRequirement) Validate() error {
+	if len(r.Modes) == 0 {
+		return fmt.Errorf("no authentication mode declared")
+	}
+	for _, mode := range r.Modes {
+		switch mode {
+		case AuthJWT, AuthAPIToken, AuthCookie:
+		case AuthPersonalToken:
+			if r.Scope == "" {
+				return fmt.Errorf("personal access tokens need a scope")
+			}
+		case AuthNone:
+			if len(r.Modes) > 1 {
+				return fmt.Errorf("public routes cannot declare other authentication modes")
+			}
+		default:
+			return fmt.Errorf("unknown authentication mode %q", mode)
+		}
+	}
+	if r.Public() && (r.Permission != "" || r.MinRole != "") {
+		return fmt.Errorf("public routes cannot require a permission or role")
+	}
+	if r.Scope != "" && !r.Allows(AuthPersonalToken) {
+		return fmt.Errorf("a scope requires the personal token mode")
+	}
+	return nil
+}
+
+// String describes the requirement, e.g. "jwt|cookie container:read"
+func (r AuthRequirement) String() string {
+	modes := make([]string, len(r.Modes))
+	for i, mode := range r.Modes {
+		modes[i] = string(mode)
+	}
+	description := strings.Join(modes, "|")
+	if r.MinRole != "" {
+		description += " role>=" + string(r.MinRole)
+	}
+	if r.Permission != "" {
+		description += " " + string(r.Permission)
+	}
+	if r.Scope != "" {
+		description += " scope=" + string(r.Scope)
+	}
+	return description
+}
+
+// AuthChainConfig configures the credentials an AuthChain accepts
+type AuthChainConfig struct {
+	JWTSecret string
+
+	APIKeyHeader string
+	APIKeys      []string
+	// APIKeyRole is the role API tokens are authorized as
+	APIKeyRole model.UserRole
+
+	SessionCookie string
+	CSRFCookie    string
+
+	// Tokens resolves personal access tokens; without it they are refused
+	Tokens TokenResolver
+
+	// Roles resolves the permissions of custom roles; without it only the
+	// built-in roles grant permissions
+	Roles RoleResolver
+
+	// Sessions checks the login sessions JWTs are bound to; without it a
+	// JWT is accepted until it expires
+	Sessions SessionResolver
+}
+
+// RoleResolver returns the permissions a custom role grants
+type RoleResolver interface {
+	RolePermissions(ctx context.Context, role model.UserRole) ([]model.Permission, error)
+}
+
+// TokenResolver resolves the value of a personal access token to the token,
+// with its user, if it is usable
+type TokenResolver interface {
+	ResolveToken(ctx context.Context, value string) (*model.APIToken, error)
+}
+
+// SessionResolver reports an error unless the login session of a user is
+// still active
+type SessionResolver interface {
+	ResolveSession(ctx context.Context, userID int64, sessionID string) error
+}
+
+// AuthChain builds the middleware enforcing route auth requirements
+type AuthChain struct {
+	config AuthChainConfig
+}
+
+// NewAuthChain creates an auth chain. API tokens authorize as viewers unless
+// another role is configured.
+func NewAuthChain(config AuthChainConfig) *AuthChain {
+	if config.APIKeyHeader == "" {
+		config.APIKeyHeader = "X-API-Key"
+	}
+	if config.APIKeyRole == "" {
+		config.APIKeyRole = model.UserRoleViewer
+	}
+	if config.SessionCookie == "" {
+		config.SessionCookie = "docker_auto_session"
+	}
+	if config.CSRFCookie == "" {
+		config.CSRFCookie = "csrf_token"
+	}
+	return &AuthChain{config: config}
+}
+
+// Handlers returns the middleware enforcing req, none for public routes
+func (a *AuthChain) Handlers(req AuthRequirement) []gin.HandlerFunc {
+	if req.Public() {
+		return nil
+	}
+	return []gin.HandlerFunc{a.authenticate(req), authorize(req)}
+}
+
+// authError is an authentication failure reported to the caller
+type authError struct {
+	status  int
+	message string
+}
+
+func (e *authError) Error() string {
+	return e.message
+}
+
+func unauthorized(message string) *authError {
+	return &authError{status: http.StatusUnauthorized, message: message}
+}
+
+// credential is a credential a request may present
+type credential struct {
+	mode         AuthMode
+	present      bool
+	authenticate func(c *gin.Context) (*Principal, *authError)
+}
+
+// authenticate resolves the principal from the first credential the route
+// accepts. A credential that is present but invalid is rejected rather than
+// skipped.
+func (a *AuthChain) authenticate(req AuthRequirement) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_, cookieErr := c.Cookie(a.config.SessionCookie)
+		authorization := c.GetHeader(AuthorizationHeaderKey)
+		personalToken := strings.HasPrefix(authorization, personalTokenScheme)
+		credentials := []credential{
+			{AuthJWT, authorization != "" && !personalToken, a.authenticateJWT},
+			{AuthPersonalToken, personalToken, a.authenticatePersonalToken},
+			{AuthAPIToken, c.GetHeader(a.config.APIKeyHeader) != "", a.authenticateAPIToken},
+			{AuthCookie, cookieErr == nil, a.authenticateCookie},
+		}
+
+		var (
+			principal *Principal
+			failure   *authError
+			presented []AuthMode
+		)
+		for _, cred := range credentials {
+			if !cred.present {
+				continue
+			}
+			presented = append(presented, cred.mode)
+			if req.Allows(cred.mode) {
+				principal, failure = cred.authenticate(c)
+				break
+			}
+		}
+
+		if principal == nil && failure == nil {
+			failure = unauthorized("Authentication required")
+			if len(presented) > 0 {
+				failure = unauthorized(fmt.Sprintf("%s authentication is not accepted for this endpoint", presented[0]))
+			}
+		}
+		if failure != nil {
+			logrus.WithFields(logrus.Fields{
+				"path":      c.Request.URL.Path,
+				"method":    c.Request.Method,
+				"presented": presented,
+				"required":  req.String(),
+			}).Warnf("Authentication failed: %s", failure.message)
+			c.JSON(failure.status, utils.ErrorResponse(failure.status, failure.message))
+			c.Abort()
+			return
+		}
+
+		principal.Permissions = a.rolePermissions(c.Request.Context(), principal.Role)
+
+		c.Set(ContextPrincipalKey, principal)
+		if principal.Claims != nil {
+			c.Set(ContextUserKey, principal.Claims)
+			c.Set(ContextUserIDKey, principal.Claims.UserID)
+		} else if principal.Token != nil {
+			c.Set(ContextUserIDKey, principal.Token.UserID)
+		}
+		c.Request = c.Request.WithContext(model.WithActor(c.Request.Context(), principal.Actor))
+
+		c.Next()
+	}
+}
+
+// rolePermissions returns the permissions of a built-in or custom role. A
+// role that cannot be resolved grants nothing.
+func (a *AuthChain) rolePermissions(ctx context.Context, role model.UserRole) []model.Permission {
+	if permissions, builtin := model.BuiltinRolePermissions(model.CanonicalRole(role)); builtin {
+		return permissions
+	}
+	if a.config.Roles == nil {
+		return nil
+	}
+	permissions, err := a.config.Roles.RolePermissions(ctx, role)
+	if err != nil {
+		logrus.WithError(err).WithField("role", role).Warn("Failed to resolve role permissions")
+		return nil
+	}
+	return permissions
+}
+
+func (a *AuthChain) authenticateJWT(c *gin.Context) (*Principal, *authError) {
+	token, err := extractTokenFromHeader(c.GetHeader(AuthorizationHeaderKey))
+	if err != nil {
+		return nil, unauthorized("Invalid authorization header format")
+	}
+	return a.userPrincipal(c.Request.Context(), AuthJWT, token)
+}
+
+func (a *AuthChain) authenticateCookie(c *gin.Context) (*Principal, *authError) {
+	token, _ := c.Cookie(a.config.SessionCookie)
+
+	switch c.Request.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+	default:
+		expected, err := c.Cookie(a.config.CSRFCookie)
+		if err != nil || !isValidCSRFToken(c.GetHeader("X-CSRF-Token"), expected, "") {
+			return nil, &authError{status: http.StatusForbidden, message: "CSRF token missing or invalid"}
+		}
+	}
+
+	return a.userPrincipal(c.Request.Context(), AuthCookie, token)
+}
+
+// userPrincipal validates a JWT. Tokens bound to a login session are refused
+// once the session is revoked; tokens issued without one are not tracked.
+func (a *AuthChain) userPrincipal(ctx context.Context, mode AuthMode, token string) (*Principal, *authError) {
+	claims, err := utils.ValidateJWT(token, a.config.JWTSecret)
+	if err != nil {
+		return nil, unauthorized("Invalid or expired token")
+	}
+	if !claims.IsActive {
+		return nil, &authError{status: http.StatusForbidden, message: "Account is not active"}
+	}
+	if claims.SessionID != "" && a.config.Sessions != nil {
+		if err := a.config.Sessions.ResolveSession(ctx, claims.UserID, claims.SessionID); err != nil {
+			return nil, unauthorized("Session expired or revoked")
+		}
+	}
+	return &Principal{
+		Mode:   mode,
+		Actor:  model.UserActor(claims.UserID, claims.Username),
+		Role:   claims.Role,
+		Claims: claims,
+	}, nil
+}
+
+func (a *AuthChain) authenticateAPIToken(c *gin.Context) (*Principal, *authError) {
+	apiKey := c.GetHeader(a.config.APIKeyHeader)
+	for _, validKey := range a.config.APIKeys {
+		if validKey != "" && subtle.ConstantTimeCompare([]byte(apiKey), []byte(validKey)) == 1 {
+			return &Principal{
+				Mode:  AuthAPIToken,
+				Actor: model.APITokenActor(apiKeyName(apiKey)),
+				Role:  a.config.APIKeyRole,
+			}, nil
+		}
+	}
+	return nil, unauthorized("Invalid or missing API key")
+}
+
+func (a *AuthChain) authenticatePersonalToken(c *gin.Context) (*Principal, *authError) {
+	if a.config.Tokens == nil {
+		return nil, unauthorized("Personal access tokens are not enabled")
+	}
+
+	value := strings.TrimSpace(strings.TrimPrefix(c.GetHeader(AuthorizationHeaderKey), personalTokenScheme))
+	token, err := a.config.Tokens.ResolveToken(c.Request.Context(), value)
+	if err != nil {
+		return nil, unauthorized("Invalid, expired or revoked token")
+	}
+	return &Principal{
+		Mode:  AuthPersonalToken,
+		Actor: token.Actor(token.User.Username),
+		Role:  token.User.Role,
+		Token: token,
+	}, nil
+}
+
+// authorize checks the principal's role and permission, and the scope of
+// personal access tokens
+func authorize(req AuthRequirement) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+
+		allowed := true
+		detail := ""
+		if req.MinRole != "" && roleLevels[principal.Role] < roleLevels[req.MinRole] {
+			allowed = false
+			detail = fmt.Sprintf("Minimum required role: %s", req.MinRole)
+		}
+		if allowed && req.Permission != "" && !principal.Can(req.Permission) {
+			allowed = req.AllowSelf && principal.Claims != nil && checkSelfAccess(c, principal.Claims)
+			detail = fmt.Sprintf("Required permission: %s", req.Permission)
+		}
+		if allowed && principal.Token != nil && !principal.Token.HasScope(req.Scope) {
+			allowed = false
+			detail = fmt.Sprintf("Required token scope: %s", req.Scope)
+		}
+
+		if !allowed {
+			logrus.WithFields(logrus.Fields{
+				"actor":     principal.Actor.String(),
+				"role":      principal.Role,
+				"path":      c.Request.URL.Path,
+				"method":    c.Request.Method,
+				"required":  req.String(),
+				"client_ip": c.ClientIP(),
+			}).Warn("Permission denied")
+
+			c.JSON(http.StatusForbidden, utils.ErrorResponseWithDetails(
+				http.StatusForbidden,
+				"Insufficient permissions",
+				[]utils.ErrorDetail{{Message: detail}},
+			))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequirePermission creates a middleware admitting callers whose role grants
+// the permission, for checks beyond a route's auth requirement. It runs after
+// the AuthChain handlers.
+func RequirePermission(permission Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		principal := GetPrincipal(c)
+		if principal == nil {
+			c.JSON(http.StatusUnauthorized, utils.ErrorResponse(http.StatusUnauthorized, "Authentication required"))
+			c.Abort()
+			return
+		}
+
+		if !principal.Can(permission) {
+			logrus.WithFields(logrus.Fields{
+				"actor":      principal.Actor.String(),
+				"role":       principal.Role,
+				"path":       c.Request.URL.Path,
+				"method":     c.Request.Method,
+				"permission": permission,
+			}).Warn("Permission denied")
+
+			c.JSON(http.StatusForbidden, utils.ErrorResponseWithDetails(
+				http.StatusForbidden,
+				"Insufficient permissions",
+				[]utils.ErrorDetail{{Message: fmt.Sprintf("Required permission: %s", permission)}},
+			))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HasPermission reports whether the authenticated caller's role grants the
+// permission, false on public routes
+func HasPermission(c *gin.Context, permission Permission) bool {
+	principal := GetPrincipal(c)
+	return principal != nil && principal.Can(permission)
+}
+
+// GetPrincipal returns the authenticated caller, nil on public routes
+func GetPrincipal(c *gin.Context) *Principal {
+	if principal, exists := c.Get(ContextPrincipalKey); exists {
+		if p, ok := principal.(*Principal); ok {
+			return p
+		}
+	}
+	return nil
+}
+
+// CurrentActor returns the actor of the authenticated caller. Handlers behind
+// an auth requirement can rely on it.
+func CurrentActor(c *gin.Context) model.Actor {
+	if principal := GetPrincipal(c); principal != nil {
+		return principal.Actor
+	}
+	if userID, ok := GetUserIDFromContext(c); ok {
+		return RequestActor(c, userID)
+	}
+	return model.Actor{}
+}
+
+// TokenAllows reports whether the caller may act with scope. Only personal
+// access tokens are limited by scopes; handlers check the scopes a route's
+// requirement cannot express, e.g. ones depending on the request body.
+func TokenAllows(c *gin.Context, scope model.TokenScope) bool {
+	principal := GetPrincipal(c)
+	return principal == nil || principal.Token == nil || principal.Token.HasScope(scope)
+}
+
+// CurrentUserID returns the ID of the signed in user or of the user a
+// personal access token acts for, zero for configured API keys. Routes whose
+// handlers need a user declare UsersOnly requirements.
+func CurrentUserID(c *gin.Context) int64 {
+	userID, _ := GetUserIDFromContext(c)
+	return userID
+}
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"docker-auto/pkg/model"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequirePermissionRefusesCallersWithoutIt(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		principal *Principal
+		want      int
+	}{
+		{"anonymous", nil, http.StatusUnauthorized},
+		{"viewer", &Principal{Role: model.UserRoleViewer, Permissions: []model.Permission{model.PermissionContainerRead}}, http.StatusForbidden},
+		{"unresolved custom role", &Principal{Role: "removed"}, http.StatusForbidden},
+		{"wildcard on another resource", &Principal{Role: "imager", Permissions: []model.Permission{"image:*"}}, http.StatusForbidden},
+		{"granted", &Principal{Role: "deployer", Permissions: []model.Permission{model.PermissionContainerUpdate}}, http.StatusOK},
+		{"admin", &Principal{Role: model.UserRoleAdmin, Permissions: []model.Permission{model.PermissionAdmin}}, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.POST("/api/containers/:id/update", func(c *gin.Context) {
+				if tt.principal != nil {
+					c.Set(ContextPrincipalKey, tt.principal)
+				}
+			}, RequirePermission(PermissionContainerUpdate), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/containers/1/update", nil))
+			if w.Code != tt.want {
+				t.Errorf("as %s = %d, want %d", tt.name, w.Code, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,16 @@
 package middleware
 
 import (
+	"crypto/sha256"
 	"crypto/subtle"
+	"encoding/hex"
 	"fmt"
 	"net/http"
 	"net/url"
 	"strings"
 	"time"
 
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
 	"github.com/gin-gonic/gin"
@@ -163,6 +166,9 @@ func SecurityMiddleware(config *SecurityConfig) gin.HandlerFunc {
 				c.Abort()
 				return
 			}
+			// Attributed to the token unless a user also signs in
+			actor := model.APITokenActor(apiKeyName(c.GetHeader(config.APIKeyHeader)))
+			c.Request = c.Request.WithContext(model.WithActor(c.Request.Context(), actor))
 		}
 
 		// Handle CORS preflight requests
@@ -305,6 +311,12 @@ func validateAPIKey(c *gin.Context, config *SecurityConfig) error {
 	return fmt.Errorf("invalid API key")
 }
 
+// apiKeyName identifies an API key in audit records without revealing it
+func apiKeyName(apiKey string) string {
+	sum := sha256.Sum256([]byte(apiKey))
+	return "key-" + hex.EncodeToString(sum[:])[:12]
+}
+
 // handleCORSPreflight handles CORS preflight requests
 func handleCORSPreflight(c *gin.Context, config *SecurityConfig) {
 	origin := c.GetHeader("Origin")
@@ -464,29 +476,6 @@ func SetCSRFTokenMiddleware(cookieName string, secure bool) gin.HandlerFunc {
 	}
 }
 
-// RequestIDMiddleware adds a unique request ID to each request
-func RequestIDMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		requestID := c.GetHeader("X-Request-ID")
-		if requestID == "" {
-			var err error
-			requestID, err = utils.GenerateSecureRandomString(16)
-			if err != nil {
-				logrus.WithError(err).Error("Failed to generate request ID")
-				requestID = fmt.Sprintf("req-%d", time.Now().UnixNano())
-			}
-		}
-
-		c.Header("X-Request-ID", requestID)
-		c.Set("request_id", requestID)
-
-		// Add to logger context
-		logrus.WithField("request_id", requestID).Debug("Request processed")
-
-		c.Next()
-	}
-}
-
 // IPWhitelistMiddleware allows only whitelisted IPs
 func IPWhitelistMiddleware(allowedIPs []string) gin.HandlerFunc {
 	return func(c *gin.Context) {
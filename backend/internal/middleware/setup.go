@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"docker-auto/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupChecker reports whether first-run setup has been completed
+type SetupChecker interface {
+	IsComplete(ctx context.Context) bool
+}
+
+// RequireSetupComplete rejects requests with SETUP_REQUIRED until first-run
+// setup has finished. Health endpoints are always allowed through.
+func RequireSetupComplete(checker SetupChecker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if strings.HasSuffix(c.Request.URL.Path, "/health") {
+			c.Next()
+			return
+		}
+
+		if !checker.IsComplete(c.Request.Context()) {
+			c.JSON(http.StatusServiceUnavailable, utils.ErrorResponseWithDetails(
+				http.StatusServiceUnavailable,
+				"System setup has not been completed",
+				[]utils.ErrorDetail{{
+					Message: "Complete the setup wizard via POST /api/setup",
+					Code:    "SETUP_REQUIRED",
+				}},
+			))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
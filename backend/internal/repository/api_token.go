@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// apiTokenRepository implements APITokenRepository interface
+type apiTokenRepository struct {
+	db *gorm.DB
+}
+
+// NewAPITokenRepository creates a new personal access token repository
+func NewAPITokenRepository(db *gorm.DB) APITokenRepository {
+	return &apiTokenRepository{db: db}
+}
+
+// Create creates a new personal access token
+func (r *apiTokenRepository) Create(ctx context.Context, token *model.APIToken) error {
+	if token == nil {
+		return fmt.Errorf("api token cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(token).Error; err != nil {
+		return fmt.Errorf("failed to create api token: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a personal access token by ID
+func (r *apiTokenRepository) GetByID(ctx context.Context, id int) (*model.APIToken, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid api token ID: %d", id)
+	}
+
+	var token model.APIToken
+	err := r.db.WithContext(ctx).First(&token, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("api token with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api token by ID: %w", err)
+	}
+	return &token, nil
+}
+
+// GetByHash retrieves a personal access token by the hash of its value, with
+// its user
+func (r *apiTokenRepository) GetByHash(ctx context.Context, tokenHash string) (*model.APIToken, error) {
+	var token model.APIToken
+	err := r.db.WithContext(ctx).Preload("User").Where("token_hash = ?", tokenHash).First(&token).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("api token not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api token by hash: %w", err)
+	}
+	return &token, nil
+}
+
+// ListByUser returns the tokens of a user, newest first
+func (r *apiTokenRepository) ListByUser(ctx context.Context, userID int64) ([]*model.APIToken, error) {
+	var tokens []*model.APIToken
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&tokens).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// CountUsable counts the tokens of a user that are neither revoked nor
+// expired at now
+func (r *apiTokenRepository) CountUsable(ctx context.Context, userID int64, now time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.APIToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count api tokens: %w", err)
+	}
+	return count, nil
+}
+
+// Revoke marks a token revoked at the given time; revoking it again keeps
+// the first time
+func (r *apiTokenRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.APIToken{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at.UTC())
+	if result.Error != nil {
+		return fmt.Errorf("failed to revoke api token: %w", result.Error)
+	}
+	return nil
+}
+
+// TouchLastUsed records when a token was last used
+func (r *apiTokenRepository) TouchLastUsed(ctx context.Context, id int, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&model.APIToken{}).
+		Where("id = ?", id).
+		UpdateColumn("last_used_at", at.UTC()).Error
+	if err != nil {
+		return fmt.Errorf("failed to record api token use: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// approvalPolicyRepository implements ApprovalPolicyRepository interface
+type approvalPolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewApprovalPolicyRepository creates a new approval policy repository
+func NewApprovalPolicyRepository(db *gorm.DB) ApprovalPolicyRepository {
+	return &approvalPolicyRepository{db: db}
+}
+
+// Create creates a new approval policy
+func (r *approvalPolicyRepository) Create(ctx context.Context, policy *model.ApprovalPolicy) error {
+	if policy == nil {
+		return fmt.Errorf("approval policy cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(policy).Error; err != nil {
+		return fmt.Errorf("failed to create approval policy: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an approval policy by ID
+func (r *approvalPolicyRepository) GetByID(ctx context.Context, id int) (*model.ApprovalPolicy, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid approval policy ID: %d", id)
+	}
+
+	var policy model.ApprovalPolicy
+	err := r.db.WithContext(ctx).First(&policy, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("approval policy with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get approval policy by ID: %w", err)
+	}
+	return &policy, nil
+}
+
+// Update updates an existing approval policy
+func (r *approvalPolicyRepository) Update(ctx context.Context, policy *model.ApprovalPolicy) error {
+	if policy == nil {
+		return fmt.Errorf("approval policy cannot be nil")
+	}
+	if policy.ID <= 0 {
+		return fmt.Errorf("invalid approval policy ID: %d", policy.ID)
+	}
+	if err := r.db.WithContext(ctx).Save(policy).Error; err != nil {
+		return fmt.Errorf("failed to update approval policy: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes an approval policy by ID
+func (r *approvalPolicyRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.ApprovalPolicy{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete approval policy: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("approval policy with ID %d not found", id)
+	}
+	return nil
+}
+
+// List returns every approval policy in evaluation order
+func (r *approvalPolicyRepository) List(ctx context.Context) ([]*model.ApprovalPolicy, error) {
+	var policies []*model.ApprovalPolicy
+	if err := r.db.WithContext(ctx).Order("position ASC, id ASC").Find(&policies).Error; err != nil {
+		return nil, fmt.Errorf("failed to list approval policies: %w", err)
+	}
+	return policies, nil
+}
+
+// ListEnabled returns the enabled approval policies in evaluation order
+func (r *approvalPolicyRepository) ListEnabled(ctx context.Context) ([]*model.ApprovalPolicy, error) {
+	var policies []*model.ApprovalPolicy
+	err := r.db.WithContext(ctx).
+		Where("enabled = ?", true).
+		Order("position ASC, id ASC").
+		Find(&policies).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list enabled approval policies: %w", err)
+	}
+	return policies, nil
+}
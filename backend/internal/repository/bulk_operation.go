@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// bulkOperationRepository implements BulkOperationRepository interface
+type bulkOperationRepository struct {
+	db *gorm.DB
+}
+
+// NewBulkOperationRepository creates a new bulk operation repository
+func NewBulkOperationRepository(db *gorm.DB) BulkOperationRepository {
+	return &bulkOperationRepository{db: db}
+}
+
+// Create creates a new bulk operation
+func (r *bulkOperationRepository) Create(ctx context.Context, operation *model.BulkOperation) error {
+	if operation == nil {
+		return fmt.Errorf("bulk operation cannot be nil")
+	}
+
+	if err := r.db.WithContext(ctx).Create(operation).Error; err != nil {
+		return fmt.Errorf("failed to create bulk operation: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a bulk operation by ID
+func (r *bulkOperationRepository) GetByID(ctx context.Context, id int64) (*model.BulkOperation, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid bulk operation ID: %d", id)
+	}
+
+	var operation model.BulkOperation
+	if err := r.db.WithContext(ctx).First(&operation, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("bulk operation with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get bulk operation by ID: %w", err)
+	}
+
+	return &operation, nil
+}
+
+// Update saves the progress of a bulk operation
+func (r *bulkOperationRepository) Update(ctx context.Context, operation *model.BulkOperation) error {
+	if operation == nil {
+		return fmt.Errorf("bulk operation cannot be nil")
+	}
+	if operation.ID <= 0 {
+		return fmt.Errorf("invalid bulk operation ID: %d", operation.ID)
+	}
+
+	if err := r.db.WithContext(ctx).Save(operation).Error; err != nil {
+		return fmt.Errorf("failed to update bulk operation: %w", err)
+	}
+
+	return nil
+}
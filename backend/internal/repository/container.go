@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"time"
 
-	"docker-auto/internal/model"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // containerRepository implements ContainerRepository interface
@@ -43,11 +45,13 @@ func (r *containerRepository) Create(ctx context.Context, container *model.Conta
 		return fmt.Errorf("container with name '%s' already exists", container.Name)
 	}
 
-	if err := r.db.WithContext(ctx).Create(container).Error; err != nil {
-		return fmt.Errorf("failed to create container: %w", err)
-	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(container).Error; err != nil {
+			return fmt.Errorf("failed to create container: %w", err)
+		}
 
-	return nil
+		return appendContainerChange(ctx, tx, container, model.ContainerChangeCreate, model.DiffContainers(nil, container))
+	})
 }
 
 // GetByID retrieves a container by ID
@@ -64,7 +68,7 @@ func (r *containerRepository) GetByID(ctx context.Context, id int64) (*model.Con
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("container with ID %d not found", id)
+			return nil, apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
 		}
 		return nil, fmt.Errorf("failed to get container by ID: %w", err)
 	}
@@ -86,7 +90,7 @@ func (r *containerRepository) GetByName(ctx context.Context, name string) (*mode
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("container with name '%s' not found", name)
+			return nil, apperrors.Newf(apperrors.CodeContainerNotFound, "container with name '%s' not found", name)
 		}
 		return nil, fmt.Errorf("failed to get container by name: %w", err)
 	}
@@ -108,7 +112,7 @@ func (r *containerRepository) GetByContainerID(ctx context.Context, containerID
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("container with container ID '%s' not found", containerID)
+			return nil, apperrors.Newf(apperrors.CodeContainerNotFound, "container with container ID '%s' not found", containerID)
 		}
 		return nil, fmt.Errorf("failed to get container by container ID: %w", err)
 	}
@@ -129,7 +133,7 @@ func (r *containerRepository) Update(ctx context.Context, container *model.Conta
 	var existingContainer model.Container
 	if err := r.db.WithContext(ctx).First(&existingContainer, container.ID).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return fmt.Errorf("container with ID %d not found", container.ID)
+			return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", container.ID)
 		}
 		return fmt.Errorf("failed to check container existence: %w", err)
 	}
@@ -148,11 +152,17 @@ func (r *containerRepository) Update(ctx context.Context, container *model.Conta
 	// Update timestamp manually
 	container.UpdatedAt = time.Now().UTC()
 
-	if err := r.db.WithContext(ctx).Save(container).Error; err != nil {
-		return fmt.Errorf("failed to update container: %w", err)
-	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(container).Error; err != nil {
+			return fmt.Errorf("failed to update container: %w", err)
+		}
 
-	return nil
+		diff := model.DiffContainers(&existingContainer, container)
+		if len(diff) == 0 {
+			return nil
+		}
+		return appendContainerChange(ctx, tx, container, model.ContainerChangeTypeFor(diff), diff)
+	})
 }
 
 // Delete deletes a container by ID
@@ -161,16 +171,21 @@ func (r *containerRepository) Delete(ctx context.Context, id int64) error {
 		return fmt.Errorf("invalid container ID: %d", id)
 	}
 
-	result := r.db.WithContext(ctx).Delete(&model.Container{}, id)
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete container: %w", result.Error)
-	}
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.Container
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&existing, id).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
+			}
+			return fmt.Errorf("failed to delete container: %w", err)
+		}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("container with ID %d not found", id)
-	}
+		if err := tx.Delete(&model.Container{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete container: %w", err)
+		}
 
-	return nil
+		return appendContainerChange(ctx, tx, &existing, model.ContainerChangeDelete, model.DiffContainers(&existing, nil))
+	})
 }
 
 // List retrieves containers with filtering and pagination
@@ -185,6 +200,11 @@ func (r *containerRepository) List(ctx context.Context, filter *model.ContainerF
 		if filter.CreatedBy != nil {
 			query = query.Where("created_by = ?", *filter.CreatedBy)
 		}
+		if filter.AccessibleBy != nil {
+			query = query.Where("(created_by = ? OR id IN (?))", *filter.AccessibleBy,
+				r.db.Model(&model.ContainerPermission{}).Select("container_id").
+					Where("user_id = ? OR (role <> '' AND role = ?)", *filter.AccessibleBy, filter.AccessibleRole))
+		}
 		if filter.Name != "" {
 			query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
 		}
@@ -197,6 +217,15 @@ func (r *containerRepository) List(ctx context.Context, filter *model.ContainerF
 		if filter.UpdatePolicy != "" {
 			query = query.Where("update_policy = ?", filter.UpdatePolicy)
 		}
+		if filter.StackID != nil {
+			query = query.Where("stack_id = ?", *filter.StackID)
+		}
+		if filter.HostID != nil {
+			query = query.Where("host_id = ?", *filter.HostID)
+		}
+		if filter.Drifted != nil {
+			query = query.Where("drifted = ?", *filter.Drifted)
+		}
 	}
 
 	// Get total count
@@ -286,45 +315,301 @@ func (r *containerRepository) UpdateStatus(ctx context.Context, id int64, status
 		return fmt.Errorf("invalid container ID: %d", id)
 	}
 
-	result := r.db.WithContext(ctx).
-		Model(&model.Container{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
 			"status":     status,
 			"updated_at": time.Now().UTC(),
-		})
+		}, "id = ?", id)
+		return err
+	})
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update container status: %w", result.Error)
+	if err != nil {
+		return fmt.Errorf("failed to update container status: %w", err)
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("container with ID %d not found", id)
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
 	}
 
 	return nil
 }
 
-// UpdateContainerID updates the Docker container ID
+// UpdateContainerID records a Docker container the application created for
+// the container, clearing the image digests for the status sync to record
 func (r *containerRepository) UpdateContainerID(ctx context.Context, id int64, containerID string) error {
+	return r.updateContainerID(ctx, id, map[string]interface{}{
+		"container_id":    containerID,
+		"deployed_digest": "",
+		"running_digest":  "",
+		"updated_at":      time.Now().UTC(),
+	})
+}
+
+// RelinkContainerID records the Docker container found for the container by
+// name, keeping the deployed digest so an image replaced along with the
+// Docker container is reported as drift
+func (r *containerRepository) RelinkContainerID(ctx context.Context, id int64, containerID string) error {
+	return r.updateContainerID(ctx, id, map[string]interface{}{
+		"container_id": containerID,
+		"updated_at":   time.Now().UTC(),
+	})
+}
+
+func (r *containerRepository) updateContainerID(ctx context.Context, id int64, fields map[string]interface{}) error {
 	if id <= 0 {
 		return fmt.Errorf("invalid container ID: %d", id)
 	}
 
-	result := r.db.WithContext(ctx).
-		Model(&model.Container{}).
-		Where("id = ?", id).
-		Updates(map[string]interface{}{
-			"container_id": containerID,
-			"updated_at":   time.Now().UTC(),
-		})
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, fields, "id = ?", id)
+		return err
+	})
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update container ID: %w", result.Error)
+	if err != nil {
+		return fmt.Errorf("failed to update container ID: %w", err)
 	}
 
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("container with ID %d not found", id)
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateWarnings replaces the daemon warnings recorded for a container
+func (r *containerRepository) UpdateWarnings(ctx context.Context, id int64, warnings model.StringList) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid container ID: %d", id)
+	}
+
+	now := time.Now().UTC()
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
+			"warnings":    warnings,
+			"warnings_at": &now,
+		}, "id = ?", id)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update container warnings: %w", err)
+	}
+
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateImageDigests records the digest the container was deployed with and
+// the one it runs
+func (r *containerRepository) UpdateImageDigests(ctx context.Context, id int64, deployed, running string) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid container ID: %d", id)
+	}
+
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
+			"deployed_digest": deployed,
+			"running_digest":  running,
+		}, "id = ?", id)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update container image digests: %w", err)
+	}
+
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateDrift records whether the live container matches its stored
+// configuration
+func (r *containerRepository) UpdateDrift(ctx context.Context, id int64, drifted bool) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid container ID: %d", id)
+	}
+
+	now := time.Now().UTC()
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
+			"drifted":          drifted,
+			"drift_checked_at": &now,
+		}, "id = ?", id)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update container drift: %w", err)
+	}
+
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// UpdateCrashLoop records the crash loop state of the container and whether
+// it holds automatic updates
+func (r *containerRepository) UpdateCrashLoop(ctx context.Context, id int64, crashLooping bool, detectedAt *time.Time, hold bool) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid container ID: %d", id)
+	}
+
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
+			"crash_looping":          crashLooping,
+			"crash_loop_detected_at": detectedAt,
+			"crash_loop_hold":        hold,
+		}, "id = ?", id)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update container crash loop: %w", err)
+	}
+
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// MarkUpdateChecked records when the update checker queried the registry for
+// the containers
+func (r *containerRepository) MarkUpdateChecked(ctx context.Context, ids []int64, checkedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := updateContainersTracked(ctx, tx, map[string]interface{}{
+			"update_checked_at": checkedAt.UTC(),
+		}, "id IN ?", ids)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark containers update checked: %w", err)
+	}
+
+	return nil
+}
+
+// DeferUpdate records that the container's automatic update waits for its
+// maintenance window to open at until; nil clears the deferral
+func (r *containerRepository) DeferUpdate(ctx context.Context, id int64, until *time.Time) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid container ID: %d", id)
+	}
+
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
+			"update_deferred_until": until,
+		}, "id = ?", id)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to defer container update: %w", err)
+	}
+
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// SaveUpdateFailures records the container's count of failed automatic
+// updates and whether they are suspended
+func (r *containerRepository) SaveUpdateFailures(ctx context.Context, container *model.Container) error {
+	if container == nil || container.ID <= 0 {
+		return fmt.Errorf("invalid container")
+	}
+
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
+			"update_failures":         container.UpdateFailures,
+			"last_update_error":       container.LastUpdateError,
+			"last_update_failed_at":   container.LastUpdateFailedAt,
+			"update_suspended":        container.UpdateSuspended,
+			"update_suspended_digest": container.UpdateSuspendedDigest,
+		}, "id = ?", container.ID)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to save container update failures: %w", err)
+	}
+
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", container.ID)
+	}
+
+	return nil
+}
+
+// MarkSynced records when the status sync last inspected the containers.
+// Sync timestamps are not changes, so the update is not tracked.
+func (r *containerRepository) MarkSynced(ctx context.Context, ids []int64, syncedAt time.Time) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).Model(&model.Container{}).
+		Where("id IN ?", ids).
+		Update("last_synced_at", syncedAt.UTC()).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark containers synced: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePostStart stores the latest post-start run of the container
+func (r *containerRepository) UpdatePostStart(ctx context.Context, id int64, run *model.PostStartRun) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid container ID: %d", id)
+	}
+
+	var matched int64
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var err error
+		matched, err = updateContainersTracked(ctx, tx, map[string]interface{}{
+			"last_post_start": run,
+		}, "id = ?", id)
+		return err
+	})
+
+	if err != nil {
+		return fmt.Errorf("failed to update container post-start run: %w", err)
+	}
+
+	if matched == 0 {
+		return apperrors.Newf(apperrors.CodeContainerNotFound, "container with ID %d not found", id)
 	}
 
 	return nil
@@ -358,16 +643,16 @@ func (r *containerRepository) UpdateStatusBatch(ctx context.Context, ids []int64
 		}
 	}
 
-	result := r.db.WithContext(ctx).
-		Model(&model.Container{}).
-		Where("id IN ?", ids).
-		Updates(map[string]interface{}{
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		_, err := updateContainersTracked(ctx, tx, map[string]interface{}{
 			"status":     status,
 			"updated_at": time.Now().UTC(),
-		})
+		}, "id IN ?", ids)
+		return err
+	})
 
-	if result.Error != nil {
-		return fmt.Errorf("failed to update container status batch: %w", result.Error)
+	if err != nil {
+		return fmt.Errorf("failed to update container status batch: %w", err)
 	}
 
 	return nil
@@ -433,6 +718,48 @@ func (r *containerRepository) Exists(ctx context.Context, name string) (bool, er
 	return count > 0, nil
 }
 
+// CountByStatus returns the number of containers in each status
+func (r *containerRepository) CountByStatus(ctx context.Context) (map[model.ContainerStatus]int64, error) {
+	var rows []struct {
+		Status model.ContainerStatus
+		Count  int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.Container{}).
+		Select("status, COUNT(*) AS count").
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count containers by status: %w", err)
+	}
+
+	counts := make(map[model.ContainerStatus]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Status] = row.Count
+	}
+	return counts, nil
+}
+
+// CountWithCachedUpdates counts the containers whose image has a cached
+// latest version with another tag, or, for containers deployed by digest,
+// another digest. Invalidated cache rows are ignored.
+func (r *containerRepository) CountWithCachedUpdates(ctx context.Context) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Container{}).
+		Where(`EXISTS (
+			SELECT 1 FROM image_versions
+			WHERE image_versions.image_name = containers.image
+				AND image_versions.is_latest = ?
+				AND image_versions.invalidated_at IS NULL
+				AND (image_versions.tag <> containers.tag
+					OR (containers.image_digest <> '' AND image_versions.digest <> '' AND image_versions.digest <> containers.image_digest))
+		)`, true).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count containers with updates: %w", err)
+	}
+	return count, nil
+}
+
 // registryCredentialsRepository implements RegistryCredentialsRepository interface
 type registryCredentialsRepository struct {
 	db *gorm.DB
@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// changeFeedLockKey is the PostgreSQL advisory lock serializing change feed
+// appends. It is held until the appending transaction ends, so seq numbers are
+// handed out in commit order and a rolled back change leaves no gap.
+const changeFeedLockKey int64 = 0x63686e67
+
+// containerChangeRepository implements ContainerChangeRepository interface
+type containerChangeRepository struct {
+	db *gorm.DB
+}
+
+// NewContainerChangeRepository creates a new container change repository
+func NewContainerChangeRepository(db *gorm.DB) ContainerChangeRepository {
+	return &containerChangeRepository{db: db}
+}
+
+// ListSince retrieves change records after sinceSeq in seq order
+func (r *containerChangeRepository) ListSince(ctx context.Context, sinceSeq int64, limit int) ([]*model.ContainerChange, error) {
+	var changes []*model.ContainerChange
+	query := r.db.WithContext(ctx).
+		Where("seq > ?", sinceSeq).
+		Order("seq ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	if err := query.Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to list container changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+// GetSeqRange returns the oldest and latest retained seq, both zero when the
+// feed is empty
+func (r *containerChangeRepository) GetSeqRange(ctx context.Context) (int64, int64, error) {
+	var seqRange struct {
+		Oldest int64
+		Latest int64
+	}
+	err := r.db.WithContext(ctx).
+		Model(&model.ContainerChange{}).
+		Select("COALESCE(MIN(seq), 0) AS oldest, COALESCE(MAX(seq), 0) AS latest").
+		Scan(&seqRange).Error
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get change feed range: %w", err)
+	}
+
+	return seqRange.Oldest, seqRange.Latest, nil
+}
+
+// DeleteOlderThan deletes change records created before the cutoff date, but
+// none past maxSeq. Only a prefix of the feed is ever deleted, so the retained
+// records stay gapless, and the latest record is always kept so numbering
+// carries on after it.
+func (r *containerChangeRepository) DeleteOlderThan(ctx context.Context, cutoffDate time.Time, maxSeq int64) (int64, error) {
+	limit, err := r.pruneLimit(ctx, cutoffDate, maxSeq)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	result := r.db.WithContext(ctx).
+		Where("seq <= ?", limit).
+		Delete(&model.ContainerChange{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old container changes: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CountOlderThan counts the change records DeleteOlderThan would delete
+func (r *containerChangeRepository) CountOlderThan(ctx context.Context, cutoffDate time.Time, maxSeq int64) (int64, error) {
+	limit, err := r.pruneLimit(ctx, cutoffDate, maxSeq)
+	if err != nil {
+		return 0, err
+	}
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	var count int64
+	err = r.db.WithContext(ctx).
+		Model(&model.ContainerChange{}).
+		Where("seq <= ?", limit).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count old container changes: %w", err)
+	}
+
+	return count, nil
+}
+
+// pruneLimit returns the highest seq that may be pruned
+func (r *containerChangeRepository) pruneLimit(ctx context.Context, cutoffDate time.Time, maxSeq int64) (int64, error) {
+	var limit int64
+	err := r.db.WithContext(ctx).
+		Model(&model.ContainerChange{}).
+		Where("created_at < ?", cutoffDate).
+		Select("COALESCE(MAX(seq), 0)").
+		Scan(&limit).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to find prunable container changes: %w", err)
+	}
+
+	_, latest, err := r.GetSeqRange(ctx)
+	if err != nil {
+		return 0, err
+	}
+	if limit >= latest {
+		limit = latest - 1
+	}
+	if limit > maxSeq {
+		limit = maxSeq
+	}
+
+	return limit, nil
+}
+
+// GetCursor returns the last seq a push consumer acknowledged, zero if it has
+// acknowledged none
+func (r *containerChangeRepository) GetCursor(ctx context.Context, consumer string) (int64, error) {
+	var cursor model.ChangeFeedCursor
+	err := r.db.WithContext(ctx).
+		Where("consumer = ?", consumer).
+		First(&cursor).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("failed to get change feed cursor: %w", err)
+	}
+
+	return cursor.LastSeq, nil
+}
+
+// SetCursor records the last seq a push consumer acknowledged
+func (r *containerChangeRepository) SetCursor(ctx context.Context, consumer string, seq int64) error {
+	cursor := &model.ChangeFeedCursor{
+		Consumer:  consumer,
+		LastSeq:   seq,
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "consumer"}},
+			DoUpdates: clause.AssignmentColumns([]string{"last_seq", "updated_at"}),
+		}).
+		Create(cursor).Error
+	if err != nil {
+		return fmt.Errorf("failed to set change feed cursor: %w", err)
+	}
+
+	return nil
+}
+
+// appendContainerChange records a change to container in the transaction tx,
+// attributed to the actor on ctx
+func appendContainerChange(ctx context.Context, tx *gorm.DB, container *model.Container, changeType model.ContainerChangeType, diff model.JSONMap) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", changeFeedLockKey).Error; err != nil {
+		return fmt.Errorf("failed to lock change feed: %w", err)
+	}
+
+	var latest int64
+	if err := tx.Model(&model.ContainerChange{}).Select("COALESCE(MAX(seq), 0)").Scan(&latest).Error; err != nil {
+		return fmt.Errorf("failed to get change feed position: %w", err)
+	}
+
+	actor := model.ActorFromContext(ctx)
+	change := &model.ContainerChange{
+		Seq:           latest + 1,
+		ContainerID:   container.ID,
+		ContainerName: container.Name,
+		ChangeType:    changeType,
+		Diff:          diff,
+		ActorType:     actor.Type,
+		ActorID:       actor.OwnerID(),
+		Actor:         actor.Name,
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if err := tx.Create(change).Error; err != nil {
+		return fmt.Errorf("failed to record container change: %w", err)
+	}
+
+	return nil
+}
+
+// updateContainersTracked applies column updates to the containers matching
+// the condition and records a change for every container that differs
+// afterwards. It returns the number of containers matched.
+func updateContainersTracked(ctx context.Context, tx *gorm.DB, values map[string]interface{}, query interface{}, args ...interface{}) (int64, error) {
+	var before []*model.Container
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(query, args...).Find(&before).Error; err != nil {
+		return 0, fmt.Errorf("failed to load containers: %w", err)
+	}
+	if len(before) == 0 {
+		return 0, nil
+	}
+
+	ids := make([]int, 0, len(before))
+	for _, container := range before {
+		ids = append(ids, container.ID)
+	}
+
+	if err := tx.Model(&model.Container{}).Where("id IN ?", ids).Updates(values).Error; err != nil {
+		return 0, err
+	}
+
+	var after []*model.Container
+	if err := tx.Where("id IN ?", ids).Find(&after).Error; err != nil {
+		return 0, fmt.Errorf("failed to reload containers: %w", err)
+	}
+
+	previous := make(map[int]*model.Container, len(before))
+	for _, container := range before {
+		previous[container.ID] = container
+	}
+	sort.Slice(after, func(i, j int) bool { return after[i].ID < after[j].ID })
+
+	for _, container := range after {
+		diff := model.DiffContainers(previous[container.ID], container)
+		if len(diff) == 0 {
+			continue
+		}
+		if err := appendContainerChange(ctx, tx, container, model.ContainerChangeTypeFor(diff), diff); err != nil {
+			return 0, err
+		}
+	}
+
+	return int64(len(before)), nil
+}
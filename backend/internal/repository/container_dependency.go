@@ -0,0 +1,62 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// containerDependencyLockKey is the PostgreSQL advisory lock serializing
+// dependency writes, so two concurrent writes cannot close a loop that
+// neither sees on its own
+const containerDependencyLockKey int64 = 0x64657073
+
+// ListDependencies returns every container dependency
+func (r *containerRepository) ListDependencies(ctx context.Context) ([]*model.ContainerDependency, error) {
+	var dependencies []*model.ContainerDependency
+	if err := r.db.WithContext(ctx).Order("container_id, depends_on_id").Find(&dependencies).Error; err != nil {
+		return nil, fmt.Errorf("failed to list container dependencies: %w", err)
+	}
+	return dependencies, nil
+}
+
+// SetDependencies replaces the containers the container depends on. Nothing
+// is written when the new dependencies would form a loop; the error is then a
+// *model.DependencyCycleError.
+func (r *containerRepository) SetDependencies(ctx context.Context, id int64, dependsOn []int64) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid container ID: %d", id)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", containerDependencyLockKey).Error; err != nil {
+			return fmt.Errorf("failed to lock container dependencies: %w", err)
+		}
+
+		var existing []*model.ContainerDependency
+		if err := tx.Where("container_id <> ?", id).Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to list container dependencies: %w", err)
+		}
+
+		dependencies := make([]*model.ContainerDependency, len(dependsOn))
+		for i, dependencyID := range dependsOn {
+			dependencies[i] = &model.ContainerDependency{ContainerID: int(id), DependsOnID: int(dependencyID)}
+		}
+		if cycle := model.NewDependencyGraph(append(existing, dependencies...)).FindCycle(); cycle != nil {
+			return &model.DependencyCycleError{Cycle: cycle}
+		}
+
+		if err := tx.Where("container_id = ?", id).Delete(&model.ContainerDependency{}).Error; err != nil {
+			return fmt.Errorf("failed to clear container dependencies: %w", err)
+		}
+		if len(dependencies) > 0 {
+			if err := tx.Create(&dependencies).Error; err != nil {
+				return fmt.Errorf("failed to set container dependencies: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// containerHealthCheckRepository implements ContainerHealthCheckRepository interface
+type containerHealthCheckRepository struct {
+	db *gorm.DB
+}
+
+// NewContainerHealthCheckRepository creates a new container health check repository
+func NewContainerHealthCheckRepository(db *gorm.DB) ContainerHealthCheckRepository {
+	return &containerHealthCheckRepository{db: db}
+}
+
+// Create creates a new health check
+func (r *containerHealthCheckRepository) Create(ctx context.Context, check *model.ContainerHealthCheck) error {
+	if check == nil {
+		return fmt.Errorf("health check cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(check).Error; err != nil {
+		return fmt.Errorf("failed to create health check: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a health check by ID
+func (r *containerHealthCheckRepository) GetByID(ctx context.Context, id int) (*model.ContainerHealthCheck, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid health check ID: %d", id)
+	}
+
+	var check model.ContainerHealthCheck
+	err := r.db.WithContext(ctx).First(&check, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("health check with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get health check by ID: %w", err)
+	}
+	return &check, nil
+}
+
+// Update updates the configuration of a health check, leaving the outcome of
+// its latest run to SaveResult
+func (r *containerHealthCheckRepository) Update(ctx context.Context, check *model.ContainerHealthCheck) error {
+	if check == nil {
+		return fmt.Errorf("health check cannot be nil")
+	}
+	if check.ID <= 0 {
+		return fmt.Errorf("invalid health check ID: %d", check.ID)
+	}
+
+	err := r.db.WithContext(ctx).Model(check).
+		Select("name", "type", "endpoint", "method", "expected_status", "expected_body", "command", "expected_exit",
+			"interval_seconds", "timeout_seconds", "failure_threshold", "restart_on_failure", "enabled", "updated_at").
+		Updates(check).Error
+	if err != nil {
+		return fmt.Errorf("failed to update health check: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a health check by ID
+func (r *containerHealthCheckRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.ContainerHealthCheck{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete health check: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("health check with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListByContainer returns the health checks of a container in the order they
+// were added
+func (r *containerHealthCheckRepository) ListByContainer(ctx context.Context, containerID int) ([]*model.ContainerHealthCheck, error) {
+	var checks []*model.ContainerHealthCheck
+	err := r.db.WithContext(ctx).
+		Where("container_id = ?", containerID).
+		Order("id ASC").
+		Find(&checks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list health checks: %w", err)
+	}
+	return checks, nil
+}
+
+// SaveResult stores the outcome of the check's latest run
+func (r *containerHealthCheckRepository) SaveResult(ctx context.Context, check *model.ContainerHealthCheck) error {
+	result := r.db.WithContext(ctx).Model(&model.ContainerHealthCheck{}).
+		Where("id = ?", check.ID).
+		Updates(map[string]interface{}{
+			"last_status":          check.LastStatus,
+			"last_checked_at":      check.LastCheckedAt,
+			"last_message":         check.LastMessage,
+			"last_duration_ms":     check.LastDurationMS,
+			"consecutive_failures": check.ConsecutiveFailures,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to save health check result: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("health check with ID %d not found", check.ID)
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+func newHealthCheckTestRepo(t *testing.T) ContainerHealthCheckRepository {
+	t.Helper()
+
+	return NewContainerHealthCheckRepository(newTestDB(t, &model.ContainerHealthCheck{}))
+}
+
+func TestContainerHealthCheckRepositoryCRUD(t *testing.T) {
+	ctx := context.Background()
+	repo := newHealthCheckTestRepo(t)
+
+	check := &model.ContainerHealthCheck{
+		ContainerID:      1,
+		Name:             "api",
+		Type:             model.HealthCheckHTTP,
+		Endpoint:         "http://api:8080/health",
+		FailureThreshold: 2,
+		RestartOnFailure: true,
+		Enabled:          false,
+	}
+	if err := repo.Create(ctx, check); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, check.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.Name != "api" || got.Endpoint != check.Endpoint || !got.RestartOnFailure {
+		t.Errorf("GetByID = %+v, want the created check", got)
+	}
+	if got.Enabled {
+		t.Error("a check created disabled must stay disabled")
+	}
+	if got.IntervalSeconds != 60 || got.TimeoutSeconds != 10 {
+		t.Errorf("defaults = %ds interval, %ds timeout, want 60s and 10s", got.IntervalSeconds, got.TimeoutSeconds)
+	}
+
+	got.Name = "api-tcp"
+	got.Type = model.HealthCheckTCP
+	got.Endpoint = "api:8080"
+	got.Enabled = true
+	got.RestartOnFailure = false
+	if err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	got, err = repo.GetByID(ctx, check.ID)
+	if err != nil {
+		t.Fatalf("GetByID after update: %v", err)
+	}
+	if got.Name != "api-tcp" || got.Type != model.HealthCheckTCP || !got.Enabled || got.RestartOnFailure {
+		t.Errorf("after Update = %+v", got)
+	}
+
+	if err := repo.Delete(ctx, check.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := repo.GetByID(ctx, check.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("GetByID after delete = %v, want not found", err)
+	}
+	if err := repo.Delete(ctx, check.ID); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("second Delete = %v, want not found", err)
+	}
+}
+
+func TestContainerHealthCheckRepositoryListByContainer(t *testing.T) {
+	ctx := context.Background()
+	repo := newHealthCheckTestRepo(t)
+
+	for _, c := range []struct {
+		containerID int
+		name        string
+	}{{1, "first"}, {2, "other"}, {1, "second"}} {
+		check := &model.ContainerHealthCheck{
+			ContainerID: c.containerID,
+			Name:        c.name,
+			Type:        model.HealthCheckCommand,
+			Command:     model.StringList{"true"},
+			Enabled:     true,
+		}
+		if err := repo.Create(ctx, check); err != nil {
+			t.Fatalf("Create %s: %v", c.name, err)
+		}
+	}
+
+	checks, err := repo.ListByContainer(ctx, 1)
+	if err != nil {
+		t.Fatalf("ListByContainer: %v", err)
+	}
+	if len(checks) != 2 || checks[0].Name != "first" || checks[1].Name != "second" {
+		t.Fatalf("ListByContainer(1) = %v, want first and second in order", checks)
+	}
+	if len(checks[0].Command) != 1 || checks[0].Command[0] != "true" {
+		t.Errorf("Command = %v, want [true]", checks[0].Command)
+	}
+
+	none, err := repo.ListByContainer(ctx, 3)
+	if err != nil {
+		t.Fatalf("ListByContainer(3): %v", err)
+	}
+	if len(none) != 0 {
+		t.Errorf("ListByContainer(3) = %v, want none", none)
+	}
+}
+
+func TestContainerHealthCheckRepositorySaveResult(t *testing.T) {
+	ctx := context.Background()
+	repo := newHealthCheckTestRepo(t)
+
+	check := &model.ContainerHealthCheck{
+		ContainerID:      1,
+		Name:             "db",
+		Type:             model.HealthCheckTCP,
+		Endpoint:         "db:5432",
+		FailureThreshold: 2,
+		Enabled:          true,
+	}
+	if err := repo.Create(ctx, check); err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+
+	now := time.Now()
+	check.Record(false, "connection refused", 15*time.Millisecond, now)
+	check.Record(false, "connection refused", 20*time.Millisecond, now.Add(time.Minute))
+	check.Name = "renamed"
+	if err := repo.SaveResult(ctx, check); err != nil {
+		t.Fatalf("SaveResult: %v", err)
+	}
+
+	got, err := repo.GetByID(ctx, check.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ConsecutiveFailures != 2 || got.LastStatus != "unhealthy" || !got.Failing() {
+		t.Errorf("result = %d failures, status %q, want 2 and unhealthy", got.ConsecutiveFailures, got.LastStatus)
+	}
+	if got.LastMessage != "connection refused" || got.LastDurationMS != 20 || got.LastCheckedAt == nil {
+		t.Errorf("result = %q in %dms at %v", got.LastMessage, got.LastDurationMS, got.LastCheckedAt)
+	}
+	if got.Name != "db" {
+		t.Errorf("SaveResult changed the configuration: name %q", got.Name)
+	}
+
+	got.Record(true, "TCP connection successful", time.Millisecond, now.Add(2*time.Minute))
+	if err := repo.SaveResult(ctx, got); err != nil {
+		t.Fatalf("SaveResult: %v", err)
+	}
+	got, err = repo.GetByID(ctx, check.ID)
+	if err != nil {
+		t.Fatalf("GetByID: %v", err)
+	}
+	if got.ConsecutiveFailures != 0 || got.LastStatus != "healthy" {
+		t.Errorf("after success = %d failures, status %q, want 0 and healthy", got.ConsecutiveFailures, got.LastStatus)
+	}
+
+	if err := repo.SaveResult(ctx, &model.ContainerHealthCheck{ID: 999}); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("SaveResult of missing check = %v, want not found", err)
+	}
+}
@@ -0,0 +1,43 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// containerHealthStateRepository implements ContainerHealthStateRepository interface
+type containerHealthStateRepository struct {
+	db *gorm.DB
+}
+
+// NewContainerHealthStateRepository creates a new container health state repository
+func NewContainerHealthStateRepository(db *gorm.DB) ContainerHealthStateRepository {
+	return &containerHealthStateRepository{db: db}
+}
+
+// GetByContainerID returns the container's health state, or a new unsaved
+// state when none has been recorded yet
+func (r *containerHealthStateRepository) GetByContainerID(ctx context.Context, containerID int) (*model.ContainerHealthState, error) {
+	var state model.ContainerHealthState
+	err := r.db.WithContext(ctx).Where("container_id = ?", containerID).First(&state).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &model.ContainerHealthState{ContainerID: containerID, Status: "unknown"}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container health state: %w", err)
+	}
+	return &state, nil
+}
+
+// Save creates or updates the health state
+func (r *containerHealthStateRepository) Save(ctx context.Context, state *model.ContainerHealthState) error {
+	if err := r.db.WithContext(ctx).Save(state).Error; err != nil {
+		return fmt.Errorf("failed to save container health state: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// containerMetricRepository implements ContainerMetricRepository interface
+type containerMetricRepository struct {
+	db *gorm.DB
+}
+
+// NewContainerMetricRepository creates a new container metric repository
+func NewContainerMetricRepository(db *gorm.DB) ContainerMetricRepository {
+	return &containerMetricRepository{db: db}
+}
+
+// CreateBatch stores the metrics of one collection period
+func (r *containerMetricRepository) CreateBatch(ctx context.Context, metrics []*model.ContainerMetric) error {
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).CreateInBatches(metrics, 100).Error; err != nil {
+		return fmt.Errorf("failed to create container metrics: %w", err)
+	}
+
+	return nil
+}
+
+// Aggregate sums up the container's metrics in [from, to) into buckets of
+// step. CPU and memory are averaged weighted by the samples behind each row.
+func (r *containerMetricRepository) Aggregate(ctx context.Context, containerID int, from, to time.Time, step time.Duration) ([]*model.ContainerMetricBucket, error) {
+	seconds := int64(step / time.Second)
+	if seconds < 1 {
+		return nil, fmt.Errorf("step must be at least one second")
+	}
+
+	epoch := "CAST(EXTRACT(EPOCH FROM sampled_at) AS BIGINT)"
+	if r.db.Dialector.Name() == "sqlite" {
+		epoch = "CAST(strftime('%s', sampled_at) AS INTEGER)"
+	}
+	bucket := fmt.Sprintf("(%s / %d) * %d", epoch, seconds, seconds)
+
+	var buckets []*model.ContainerMetricBucket
+	err := r.db.WithContext(ctx).
+		Model(&model.ContainerMetric{}).
+		Select(bucket+` AS start,
+			SUM(cpu_percent * samples) / SUM(samples) AS cpu_percent,
+			CAST(SUM(memory_usage * samples) / SUM(samples) AS BIGINT) AS memory_usage,
+			CAST(SUM(network_rx_bytes) AS BIGINT) AS network_rx_bytes,
+			CAST(SUM(network_tx_bytes) AS BIGINT) AS network_tx_bytes,
+			CAST(SUM(block_read_bytes) AS BIGINT) AS block_read_bytes,
+			CAST(SUM(block_write_bytes) AS BIGINT) AS block_write_bytes`).
+		Where("container_id = ? AND sampled_at >= ? AND sampled_at < ?", containerID, from, to).
+		Group("start").
+		Order("start").
+		Scan(&buckets).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to aggregate container metrics: %w", err)
+	}
+
+	return buckets, nil
+}
+
+// DeleteOlderThan deletes metrics taken before the cutoff date
+func (r *containerMetricRepository) DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("sampled_at < ?", cutoffDate).Delete(&model.ContainerMetric{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old container metrics: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CountOlderThan counts metrics taken before the cutoff date
+func (r *containerMetricRepository) CountOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.ContainerMetric{}).Where("sampled_at < ?", cutoffDate).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count old container metrics: %w", err)
+	}
+
+	return count, nil
+}
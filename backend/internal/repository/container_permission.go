@@ -0,0 +1,94 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// containerPermissionRepository implements ContainerPermissionRepository interface
+type containerPermissionRepository struct {
+	db *gorm.DB
+}
+
+// NewContainerPermissionRepository creates a new container permission repository
+func NewContainerPermissionRepository(db *gorm.DB) ContainerPermissionRepository {
+	return &containerPermissionRepository{db: db}
+}
+
+// Grant stores the permission, replacing an earlier grant to the same user
+// or role on the container
+func (r *containerPermissionRepository) Grant(ctx context.Context, permission *model.ContainerPermission) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var existing model.ContainerPermission
+		err := subjectQuery(tx.Where("container_id = ?", permission.ContainerID), permission.UserID, permission.Role).
+			First(&existing).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			if err := tx.Create(permission).Error; err != nil {
+				return fmt.Errorf("failed to create container permission: %w", err)
+			}
+			return nil
+		case err != nil:
+			return fmt.Errorf("failed to get container permission: %w", err)
+		}
+
+		permission.ID = existing.ID
+		permission.CreatedAt = existing.CreatedAt
+		if err := tx.Save(permission).Error; err != nil {
+			return fmt.Errorf("failed to update container permission: %w", err)
+		}
+		return nil
+	})
+}
+
+// Revoke removes the grant to the user or role on the container
+func (r *containerPermissionRepository) Revoke(ctx context.Context, containerID int, userID *int, role model.UserRole) (bool, error) {
+	result := subjectQuery(r.db.WithContext(ctx).Where("container_id = ?", containerID), userID, role).
+		Delete(&model.ContainerPermission{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to revoke container permission: %w", result.Error)
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// ListByContainer returns the grants on a container, users first
+func (r *containerPermissionRepository) ListByContainer(ctx context.Context, containerID int) ([]*model.ContainerPermission, error) {
+	var permissions []*model.ContainerPermission
+	err := r.db.WithContext(ctx).
+		Preload("User").
+		Where("container_id = ?", containerID).
+		Order("role, user_id, id").
+		Find(&permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list container permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// ListForUser returns the grants to the user and to the role
+func (r *containerPermissionRepository) ListForUser(ctx context.Context, userID int, role model.UserRole) ([]*model.ContainerPermission, error) {
+	var permissions []*model.ContainerPermission
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? OR (role <> '' AND role = ?)", userID, role).
+		Find(&permissions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list user container permissions: %w", err)
+	}
+
+	return permissions, nil
+}
+
+// subjectQuery narrows query to the grant of the user, or of the role when
+// userID is nil
+func subjectQuery(query *gorm.DB, userID *int, role model.UserRole) *gorm.DB {
+	if userID != nil {
+		return query.Where("user_id = ?", *userID)
+	}
+	return query.Where("user_id IS NULL AND role = ?", role)
+}
@@ -0,0 +1,90 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// containerTemplateRepository implements ContainerTemplateRepository interface
+type containerTemplateRepository struct {
+	db *gorm.DB
+}
+
+// NewContainerTemplateRepository creates a new container template repository
+func NewContainerTemplateRepository(db *gorm.DB) ContainerTemplateRepository {
+	return &containerTemplateRepository{db: db}
+}
+
+// Create creates a new container template
+func (r *containerTemplateRepository) Create(ctx context.Context, template *model.ContainerTemplate) error {
+	if template == nil {
+		return fmt.Errorf("container template cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(template).Error; err != nil {
+		return fmt.Errorf("failed to create container template: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a container template by ID
+func (r *containerTemplateRepository) GetByID(ctx context.Context, id int) (*model.ContainerTemplate, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid container template ID: %d", id)
+	}
+
+	var template model.ContainerTemplate
+	err := r.db.WithContext(ctx).First(&template, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("container template with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container template by ID: %w", err)
+	}
+	return &template, nil
+}
+
+// Update updates an existing container template
+func (r *containerTemplateRepository) Update(ctx context.Context, template *model.ContainerTemplate) error {
+	if template == nil {
+		return fmt.Errorf("container template cannot be nil")
+	}
+	if template.ID <= 0 {
+		return fmt.Errorf("invalid container template ID: %d", template.ID)
+	}
+	if err := r.db.WithContext(ctx).Save(template).Error; err != nil {
+		return fmt.Errorf("failed to update container template: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a container template by ID
+func (r *containerTemplateRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.ContainerTemplate{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete container template: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("container template with ID %d not found", id)
+	}
+	return nil
+}
+
+// ListVisible returns the shared templates and those owned by ownerID,
+// ordered by name; with a nil owner only the shared ones
+func (r *containerTemplateRepository) ListVisible(ctx context.Context, ownerID *int) ([]*model.ContainerTemplate, error) {
+	query := r.db.WithContext(ctx).Where("shared = ?", true)
+	if ownerID != nil {
+		query = r.db.WithContext(ctx).Where("shared = ? OR owner_id = ?", true, *ownerID)
+	}
+
+	var templates []*model.ContainerTemplate
+	if err := query.Order("name ASC, id ASC").Find(&templates).Error; err != nil {
+		return nil, fmt.Errorf("failed to list container templates: %w", err)
+	}
+	return templates, nil
+}
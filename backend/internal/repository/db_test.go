@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB opens an in-memory SQLite database with models migrated
+func openTestDB(models ...interface{}) (*gorm.DB, error) {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+
+	// Every connection to :memory: opens a database of its own
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connection pool: %w", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := db.AutoMigrate(models...); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to migrate: %w", err)
+	}
+	return db, nil
+}
+
+// newTestDB opens a database as openTestDB does, closed when the test ends
+func newTestDB(tb testing.TB, models ...interface{}) *gorm.DB {
+	tb.Helper()
+
+	db, err := openTestDB(models...)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	sqlDB, _ := db.DB()
+	tb.Cleanup(func() { sqlDB.Close() })
+	return db
+}
@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// dockerHostRepository implements DockerHostRepository interface
+type dockerHostRepository struct {
+	db *gorm.DB
+}
+
+// NewDockerHostRepository creates a new Docker host repository
+func NewDockerHostRepository(db *gorm.DB) DockerHostRepository {
+	return &dockerHostRepository{db: db}
+}
+
+// Create creates a new Docker host
+func (r *dockerHostRepository) Create(ctx context.Context, host *model.DockerHost) error {
+	if host == nil {
+		return fmt.Errorf("docker host cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(host).Error; err != nil {
+		return fmt.Errorf("failed to create docker host: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a Docker host by ID
+func (r *dockerHostRepository) GetByID(ctx context.Context, id int) (*model.DockerHost, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid docker host ID: %d", id)
+	}
+
+	var host model.DockerHost
+	err := r.db.WithContext(ctx).First(&host, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("docker host with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker host by ID: %w", err)
+	}
+	return &host, nil
+}
+
+// GetByName retrieves a Docker host by name
+func (r *dockerHostRepository) GetByName(ctx context.Context, name string) (*model.DockerHost, error) {
+	var host model.DockerHost
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&host).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("docker host with name '%s' not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get docker host by name: %w", err)
+	}
+	return &host, nil
+}
+
+// Update updates the configuration of a Docker host, leaving the ping
+// outcome to RecordPing
+func (r *dockerHostRepository) Update(ctx context.Context, host *model.DockerHost) error {
+	if host == nil {
+		return fmt.Errorf("docker host cannot be nil")
+	}
+	if host.ID <= 0 {
+		return fmt.Errorf("invalid docker host ID: %d", host.ID)
+	}
+
+	err := r.db.WithContext(ctx).Model(host).
+		Select("name", "endpoint", "tls_ca_cert", "tls_cert", "tls_key", "ssh_key_path", "enabled", "updated_at").
+		Updates(host).Error
+	if err != nil {
+		return fmt.Errorf("failed to update docker host: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a Docker host by ID
+func (r *dockerHostRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.DockerHost{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete docker host: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("docker host with ID %d not found", id)
+	}
+	return nil
+}
+
+// List returns the Docker hosts by name
+func (r *dockerHostRepository) List(ctx context.Context, enabledOnly bool) ([]*model.DockerHost, error) {
+	query := r.db.WithContext(ctx).Model(&model.DockerHost{})
+	if enabledOnly {
+		query = query.Where("enabled = ?", true)
+	}
+
+	var hosts []*model.DockerHost
+	if err := query.Order("name ASC").Find(&hosts).Error; err != nil {
+		return nil, fmt.Errorf("failed to list docker hosts: %w", err)
+	}
+	return hosts, nil
+}
+
+// RecordPing stores the outcome of a health ping on the host; an empty
+// pingErr is a successful ping
+func (r *dockerHostRepository) RecordPing(ctx context.Context, id int, pingErr string, at time.Time) error {
+	result := r.db.WithContext(ctx).Model(&model.DockerHost{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_ping_at":    at.UTC(),
+			"last_ping_error": pingErr,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to record docker host ping: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("docker host with ID %d not found", id)
+	}
+	return nil
+}
+
+// CountContainers counts the containers attached to the host
+func (r *dockerHostRepository) CountContainers(ctx context.Context, id int) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.Container{}).Where("host_id = ?", id).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count docker host containers: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gitOpsRepository implements GitOpsRepository interface
+type gitOpsRepository struct {
+	db *gorm.DB
+}
+
+// NewGitOpsRepository creates a new GitOps repository
+func NewGitOpsRepository(db *gorm.DB) GitOpsRepository {
+	return &gitOpsRepository{db: db}
+}
+
+// ListResources returns the containers managed by a source
+func (r *gitOpsRepository) ListResources(ctx context.Context, source string) ([]*model.GitOpsResource, error) {
+	var resources []*model.GitOpsResource
+	err := r.db.WithContext(ctx).
+		Where("source = ?", source).
+		Order("name").
+		Find(&resources).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gitops resources: %w", err)
+	}
+	return resources, nil
+}
+
+// GetResourceByContainer returns the resource of a container, nil if no
+// source manages it
+func (r *gitOpsRepository) GetResourceByContainer(ctx context.Context, containerID int) (*model.GitOpsResource, error) {
+	var resource model.GitOpsResource
+	err := r.db.WithContext(ctx).Where("container_id = ?", containerID).First(&resource).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gitops resource: %w", err)
+	}
+	return &resource, nil
+}
+
+// SaveResource stores the resource, replacing the one of its container
+func (r *gitOpsRepository) SaveResource(ctx context.Context, resource *model.GitOpsResource) error {
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "container_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"source", "name", "applied_hash", "reconciled_at", "updated_at"}),
+	}).Create(resource).Error
+	if err != nil {
+		return fmt.Errorf("failed to save gitops resource: %w", err)
+	}
+	return nil
+}
+
+// CreateRun stores a reconcile report
+func (r *gitOpsRepository) CreateRun(ctx context.Context, run *model.GitOpsRun) error {
+	if err := r.db.WithContext(ctx).Create(run).Error; err != nil {
+		return fmt.Errorf("failed to create gitops run: %w", err)
+	}
+	return nil
+}
+
+// UpdateRun saves a reconcile report
+func (r *gitOpsRepository) UpdateRun(ctx context.Context, run *model.GitOpsRun) error {
+	if err := r.db.WithContext(ctx).Save(run).Error; err != nil {
+		return fmt.Errorf("failed to update gitops run: %w", err)
+	}
+	return nil
+}
+
+// GetRun retrieves a reconcile report
+func (r *gitOpsRepository) GetRun(ctx context.Context, id int) (*model.GitOpsRun, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid gitops run ID: %d", id)
+	}
+
+	var run model.GitOpsRun
+	err := r.db.WithContext(ctx).First(&run, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("gitops run with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gitops run: %w", err)
+	}
+	return &run, nil
+}
+
+// ListRuns returns the most recent runs first
+func (r *gitOpsRepository) ListRuns(ctx context.Context, limit int) ([]*model.GitOpsRun, error) {
+	var runs []*model.GitOpsRun
+	err := r.db.WithContext(ctx).
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&runs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list gitops runs: %w", err)
+	}
+	return runs, nil
+}
@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// imagePolicyRepository implements ImagePolicyRepository interface
+type imagePolicyRepository struct {
+	db *gorm.DB
+}
+
+// NewImagePolicyRepository creates a new image policy repository
+func NewImagePolicyRepository(db *gorm.DB) ImagePolicyRepository {
+	return &imagePolicyRepository{db: db}
+}
+
+// Create creates a new image policy
+func (r *imagePolicyRepository) Create(ctx context.Context, policy *model.ImagePolicy) error {
+	if policy == nil {
+		return fmt.Errorf("image policy cannot be nil")
+	}
+	if model.NormalizeRepository(policy.Repository) == "" {
+		return fmt.Errorf("repository is required")
+	}
+
+	exists, err := r.exists(ctx, policy.Repository, policy.TagPattern, 0)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("image policy for '%s' with tag pattern '%s' already exists",
+			model.NormalizeRepository(policy.Repository), policy.TagPattern)
+	}
+
+	if err := r.db.WithContext(ctx).Create(policy).Error; err != nil {
+		return fmt.Errorf("failed to create image policy: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves an image policy by ID
+func (r *imagePolicyRepository) GetByID(ctx context.Context, id int64) (*model.ImagePolicy, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid image policy ID: %d", id)
+	}
+
+	var policy model.ImagePolicy
+	err := r.db.WithContext(ctx).First(&policy, id).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("image policy with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get image policy by ID: %w", err)
+	}
+
+	return &policy, nil
+}
+
+// Update updates an existing image policy
+func (r *imagePolicyRepository) Update(ctx context.Context, policy *model.ImagePolicy) error {
+	if policy == nil {
+		return fmt.Errorf("image policy cannot be nil")
+	}
+	if policy.ID <= 0 {
+		return fmt.Errorf("invalid image policy ID: %d", policy.ID)
+	}
+
+	exists, err := r.exists(ctx, policy.Repository, policy.TagPattern, policy.ID)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("image policy for '%s' with tag pattern '%s' already exists",
+			model.NormalizeRepository(policy.Repository), policy.TagPattern)
+	}
+
+	result := r.db.WithContext(ctx).Save(policy)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update image policy: %w", result.Error)
+	}
+
+	return nil
+}
+
+// Delete deletes an image policy by ID
+func (r *imagePolicyRepository) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid image policy ID: %d", id)
+	}
+
+	result := r.db.WithContext(ctx).Delete(&model.ImagePolicy{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete image policy: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("image policy with ID %d not found", id)
+	}
+
+	return nil
+}
+
+// List retrieves image policies with filtering and pagination
+func (r *imagePolicyRepository) List(ctx context.Context, filter *model.ImagePolicyFilter) ([]*model.ImagePolicy, int64, error) {
+	var policies []*model.ImagePolicy
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ImagePolicy{})
+
+	if filter != nil && filter.Repository != "" {
+		query = query.Where("repository ILIKE ?", "%"+filter.Repository+"%")
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count image policies: %w", err)
+	}
+
+	orderBy := "repository ASC, tag_pattern ASC"
+	if filter != nil && filter.OrderBy != "" {
+		orderBy = filter.OrderBy
+	}
+	query = query.Order(orderBy)
+
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	if err := query.Find(&policies).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list image policies: %w", err)
+	}
+
+	return policies, total, nil
+}
+
+// GetByRepository retrieves every policy for a repository, whatever its tag pattern
+func (r *imagePolicyRepository) GetByRepository(ctx context.Context, repository string) ([]*model.ImagePolicy, error) {
+	normalized := model.NormalizeRepository(repository)
+	if normalized == "" {
+		return nil, fmt.Errorf("repository cannot be empty")
+	}
+
+	var policies []*model.ImagePolicy
+	err := r.db.WithContext(ctx).
+		Where("repository = ?", normalized).
+		Find(&policies).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image policies by repository: %w", err)
+	}
+
+	return policies, nil
+}
+
+// exists checks for another policy with the same repository and tag pattern
+func (r *imagePolicyRepository) exists(ctx context.Context, repository, tagPattern string, excludeID int) (bool, error) {
+	var count int64
+	query := r.db.WithContext(ctx).Model(&model.ImagePolicy{}).
+		Where("repository = ? AND tag_pattern = ?", model.NormalizeRepository(repository), tagPattern)
+	if excludeID > 0 {
+		query = query.Where("id <> ?", excludeID)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return false, fmt.Errorf("failed to check image policy existence: %w", err)
+	}
+
+	return count > 0, nil
+}
@@ -5,7 +5,7 @@ import (
 	"fmt"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 
 	"gorm.io/gorm"
 )
@@ -324,6 +324,67 @@ func (r *imageVersionRepository) GetVersionHistory(ctx context.Context, imageNam
 	return versions, nil
 }
 
+// GetVersionRecord retrieves the history record of a repository version, or nil
+// when the version has not been seen
+func (r *imageVersionRepository) GetVersionRecord(ctx context.Context, repository, tag, digest string) (*model.ImageVersionRecord, error) {
+	var record model.ImageVersionRecord
+	err := r.db.WithContext(ctx).
+		Where("repository = ? AND tag = ? AND digest = ?", repository, tag, digest).
+		First(&record).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get image version record: %w", err)
+	}
+
+	return &record, nil
+}
+
+// SaveVersionRecord creates or updates a version history record
+func (r *imageVersionRepository) SaveVersionRecord(ctx context.Context, record *model.ImageVersionRecord) error {
+	if record == nil {
+		return fmt.Errorf("image version record cannot be nil")
+	}
+	if record.Repository == "" {
+		return fmt.Errorf("repository is required")
+	}
+	if record.Tag == "" {
+		record.Tag = "latest"
+	}
+
+	if err := r.db.WithContext(ctx).Save(record).Error; err != nil {
+		return fmt.Errorf("failed to save image version record: %w", err)
+	}
+
+	return nil
+}
+
+// ListVersionRecords lists the version history of a repository, most recently
+// first seen first
+func (r *imageVersionRepository) ListVersionRecords(ctx context.Context, repository string, limit int) ([]*model.ImageVersionRecord, error) {
+	if repository == "" {
+		return nil, fmt.Errorf("repository cannot be empty")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var records []*model.ImageVersionRecord
+	err := r.db.WithContext(ctx).
+		Where("repository = ?", repository).
+		Order("first_seen_at DESC, id DESC").
+		Limit(limit).
+		Find(&records).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to list image version records: %w", err)
+	}
+
+	return records, nil
+}
+
 // DeleteOldVersions deletes old versions for an image, keeping the specified count of recent ones
 func (r *imageVersionRepository) DeleteOldVersions(ctx context.Context, imageName string, keepCount int) error {
 	if imageName == "" {
@@ -402,12 +463,11 @@ func (r *imageVersionRepository) GetCachedVersions(ctx context.Context, imageNam
 		return nil, fmt.Errorf("image name cannot be empty")
 	}
 
-	// Consider versions stale if older than 6 hours
-	staleThreshold := time.Now().UTC().Add(-6 * time.Hour)
+	staleThreshold := time.Now().UTC().Add(-model.ImageVersionCacheTTL)
 
 	var versions []*model.ImageVersion
 	err := r.db.WithContext(ctx).
-		Where("image_name = ? AND checked_at > ?", imageName, staleThreshold).
+		Where("image_name = ? AND checked_at > ? AND invalidated_at IS NULL", imageName, staleThreshold).
 		Order("checked_at DESC").
 		Find(&versions).Error
 
@@ -418,6 +478,184 @@ func (r *imageVersionRepository) GetCachedVersions(ctx context.Context, imageNam
 	return versions, nil
 }
 
+// imageCacheLockClass namespaces the per-image PostgreSQL advisory locks
+// serializing cache writes and invalidations of an image
+const imageCacheLockClass int32 = 0x696d67
+
+// lockImageCache takes the cache lock of an image until tx ends
+func lockImageCache(tx *gorm.DB, imageName string) error {
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?, hashtext(?))", imageCacheLockClass, imageName).Error; err != nil {
+		return fmt.Errorf("failed to lock image cache: %w", err)
+	}
+	return nil
+}
+
+// GetCacheGeneration returns the cache generation of an image, zero when
+// nothing is cached for it
+func (r *imageVersionRepository) GetCacheGeneration(ctx context.Context, imageName string) (int64, error) {
+	return cacheGeneration(r.db.WithContext(ctx), imageName)
+}
+
+func cacheGeneration(db *gorm.DB, imageName string) (int64, error) {
+	var generation int64
+	err := db.Model(&model.ImageVersion{}).
+		Where("image_name = ?", imageName).
+		Select("COALESCE(MAX(generation), 0)").
+		Scan(&generation).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get cache generation: %w", err)
+	}
+	return generation, nil
+}
+
+// UpsertVersionIfGeneration stores a version fetched while its image was at
+// generation, advancing the generation. It stores nothing and returns false
+// when the image was written or invalidated in the meantime.
+func (r *imageVersionRepository) UpsertVersionIfGeneration(ctx context.Context, version *model.ImageVersion, generation int64) (bool, error) {
+	if version == nil {
+		return false, fmt.Errorf("image version cannot be nil")
+	}
+	if version.ImageName == "" {
+		return false, fmt.Errorf("image name is required")
+	}
+	if version.Digest == "" {
+		return false, fmt.Errorf("image digest is required")
+	}
+	if version.Tag == "" {
+		version.Tag = "latest"
+	}
+	if version.RegistryURL == "" {
+		version.RegistryURL = "docker.io"
+	}
+
+	stored := false
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := lockImageCache(tx, version.ImageName); err != nil {
+			return err
+		}
+
+		current, err := cacheGeneration(tx, version.ImageName)
+		if err != nil {
+			return err
+		}
+		if current != generation {
+			return nil
+		}
+
+		var existing model.ImageVersion
+		err = tx.Where("image_name = ? AND tag = ? AND registry_url = ?", version.ImageName, version.Tag, version.RegistryURL).
+			First(&existing).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return fmt.Errorf("failed to check existing version: %w", err)
+		}
+
+		version.CheckedAt = time.Now().UTC()
+		version.Generation = generation + 1
+		version.InvalidatedAt = nil
+
+		if err == gorm.ErrRecordNotFound {
+			if err := tx.Create(version).Error; err != nil {
+				return fmt.Errorf("failed to create image version: %w", err)
+			}
+		} else {
+			version.ID = existing.ID
+			if err := tx.Save(version).Error; err != nil {
+				return fmt.Errorf("failed to update image version: %w", err)
+			}
+		}
+
+		stored = true
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return stored, nil
+}
+
+// ListCachedImages returns the names of the images with cached versions
+func (r *imageVersionRepository) ListCachedImages(ctx context.Context) ([]string, error) {
+	var imageNames []string
+	if err := r.db.WithContext(ctx).Model(&model.ImageVersion{}).Distinct().Order("image_name").Pluck("image_name", &imageNames).Error; err != nil {
+		return nil, fmt.Errorf("failed to list cached images: %w", err)
+	}
+	return imageNames, nil
+}
+
+// InvalidateCache marks the cached versions of an image, or of every image
+// when imageName is empty, as invalidated and advances their generation so
+// checks already in flight cannot store what they fetched. It returns the
+// number of rows invalidated.
+func (r *imageVersionRepository) InvalidateCache(ctx context.Context, imageName string) (int64, error) {
+	imageNames := []string{imageName}
+	if imageName == "" {
+		var err error
+		if imageNames, err = r.ListCachedImages(ctx); err != nil {
+			return 0, err
+		}
+	}
+
+	var invalidated int64
+	now := time.Now().UTC()
+	for _, name := range imageNames {
+		err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+			if err := lockImageCache(tx, name); err != nil {
+				return err
+			}
+			result := tx.Model(&model.ImageVersion{}).
+				Where("image_name = ?", name).
+				UpdateColumns(map[string]interface{}{
+					"generation":     gorm.Expr("generation + 1"),
+					"invalidated_at": now,
+				})
+			if result.Error != nil {
+				return fmt.Errorf("failed to invalidate image cache: %w", result.Error)
+			}
+			invalidated += result.RowsAffected
+			return nil
+		})
+		if err != nil {
+			return invalidated, err
+		}
+	}
+
+	return invalidated, nil
+}
+
+// GetCacheStats summarizes the cached image versions
+func (r *imageVersionRepository) GetCacheStats(ctx context.Context) (*model.ImageCacheStats, error) {
+	stats := &model.ImageCacheStats{Repositories: []model.ImageCacheRepositoryStats{}}
+	db := r.db.WithContext(ctx).Model(&model.ImageVersion{})
+
+	if err := db.Session(&gorm.Session{}).Count(&stats.Entries).Error; err != nil {
+		return nil, fmt.Errorf("failed to count cached versions: %w", err)
+	}
+	if err := db.Session(&gorm.Session{}).Where("invalidated_at IS NOT NULL").Count(&stats.Invalidated).Error; err != nil {
+		return nil, fmt.Errorf("failed to count invalidated versions: %w", err)
+	}
+	staleThreshold := time.Now().UTC().Add(-model.ImageVersionCacheTTL)
+	if err := db.Session(&gorm.Session{}).Where("invalidated_at IS NULL AND checked_at <= ?", staleThreshold).Count(&stats.StaleEntries).Error; err != nil {
+		return nil, fmt.Errorf("failed to count stale versions: %w", err)
+	}
+
+	var oldest *time.Time
+	if err := db.Session(&gorm.Session{}).Where("invalidated_at IS NULL").Select("MIN(checked_at)").Scan(&oldest).Error; err != nil {
+		return nil, fmt.Errorf("failed to get oldest cached version: %w", err)
+	}
+	stats.OldestCheckedAt = oldest
+
+	err := db.Session(&gorm.Session{}).
+		Select("image_name, registry_url, COUNT(*) AS entries, MAX(generation) AS generation, MAX(checked_at) AS last_checked_at").
+		Group("image_name, registry_url").
+		Order("entries DESC, image_name").
+		Scan(&stats.Repositories).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count cached versions per image: %w", err)
+	}
+
+	return stats, nil
+}
+
 // SearchByName searches image versions by name pattern
 func (r *imageVersionRepository) SearchByName(ctx context.Context, namePattern string) ([]*model.ImageVersion, error) {
 	if namePattern == "" {
@@ -511,6 +749,28 @@ func (r *updateHistoryRepository) Create(ctx context.Context, history *model.Upd
 	return nil
 }
 
+// Update updates an existing update history entry
+func (r *updateHistoryRepository) Update(ctx context.Context, history *model.UpdateHistory) error {
+	if history == nil {
+		return fmt.Errorf("update history cannot be nil")
+	}
+
+	if history.ID <= 0 {
+		return fmt.Errorf("invalid update history ID: %d", history.ID)
+	}
+
+	result := r.db.WithContext(ctx).Omit("Container", "CreatedByUser").Save(history)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update update history: %w", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("update history with ID %d not found", history.ID)
+	}
+
+	return nil
+}
+
 // GetByID retrieves an update history entry by ID
 func (r *updateHistoryRepository) GetByID(ctx context.Context, id int64) (*model.UpdateHistory, error) {
 	if id <= 0 {
@@ -556,31 +816,15 @@ func (r *updateHistoryRepository) List(ctx context.Context, filter *model.Update
 	var histories []*model.UpdateHistory
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&model.UpdateHistory{})
+	query := filterUpdateHistory(r.db.WithContext(ctx).Model(&model.UpdateHistory{}), filter)
 
-	// Apply filters
-	if filter != nil {
-		if filter.ContainerID != nil {
-			query = query.Where("container_id = ?", *filter.ContainerID)
-		}
-		if filter.Status != "" {
-			query = query.Where("status = ?", filter.Status)
-		}
-		if filter.CreatedBy != nil {
-			query = query.Where("created_by = ?", *filter.CreatedBy)
-		}
-		if filter.StartedAfter != nil {
-			query = query.Where("started_at >= ?", *filter.StartedAfter)
-		}
-		if filter.StartedBefore != nil {
-			query = query.Where("started_at <= ?", *filter.StartedBefore)
-		}
-		if filter.CompletedAfter != nil {
-			query = query.Where("completed_at >= ?", *filter.CompletedAfter)
-		}
-		if filter.CompletedBefore != nil {
-			query = query.Where("completed_at <= ?", *filter.CompletedBefore)
+	// Keyset pagination skips the count and offset scan entirely
+	if filter != nil && filter.Cursor != nil {
+		query = applyKeyset(query, "started_at", filter.Cursor, filter.Limit)
+		if err := query.Preload("Container").Preload("CreatedByUser").Find(&histories).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to list update histories: %w", err)
 		}
+		return histories, 0, nil
 	}
 
 	// Get total count
@@ -615,6 +859,89 @@ func (r *updateHistoryRepository) List(ctx context.Context, filter *model.Update
 	return histories, total, nil
 }
 
+// filterUpdateHistory applies the filters of filter to the query
+func filterUpdateHistory(query *gorm.DB, filter *model.UpdateHistoryFilter) *gorm.DB {
+	if filter == nil {
+		return query
+	}
+
+	if filter.ContainerID != nil {
+		query = query.Where("container_id = ?", *filter.ContainerID)
+	}
+	if filter.ContainerOwner != nil {
+		query = query.Where("container_id IN (SELECT id FROM containers WHERE created_by = ?)", *filter.ContainerOwner)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.TriggeredBy != "" {
+		query = query.Where("triggered_by = ?", filter.TriggeredBy)
+	}
+	if filter.Strategy != "" {
+		query = query.Where("strategy = ?", filter.Strategy)
+	}
+	if filter.CreatedBy != nil {
+		query = query.Where("created_by = ?", *filter.CreatedBy)
+	}
+	if filter.Image != "" {
+		pattern := "%" + filter.Image + "%"
+		query = query.Where("(new_image ILIKE ? OR old_image ILIKE ?)", pattern, pattern)
+	}
+	if filter.StartedAfter != nil {
+		query = query.Where("started_at >= ?", *filter.StartedAfter)
+	}
+	if filter.StartedBefore != nil {
+		query = query.Where("started_at <= ?", *filter.StartedBefore)
+	}
+	if filter.CompletedAfter != nil {
+		query = query.Where("completed_at >= ?", *filter.CompletedAfter)
+	}
+	if filter.CompletedBefore != nil {
+		query = query.Where("completed_at <= ?", *filter.CompletedBefore)
+	}
+	return query
+}
+
+// Summarize counts the updates matching the filter per status and averages
+// the duration of those with a completion time. Pagination fields of the
+// filter are ignored.
+func (r *updateHistoryRepository) Summarize(ctx context.Context, filter *model.UpdateHistoryFilter) (*model.UpdateHistorySummary, error) {
+	var rows []struct {
+		Status          model.UpdateStatus
+		Count           int64
+		Interrupted     int64
+		Completed       int64
+		DurationSeconds float64
+	}
+	err := filterUpdateHistory(r.db.WithContext(ctx).Model(&model.UpdateHistory{}), filter).
+		Select(`status,
+			COUNT(*) AS count,
+			SUM(CASE WHEN completed_at IS NULL AND status NOT IN (?, ?) THEN 1 ELSE 0 END) AS interrupted,
+			SUM(CASE WHEN completed_at >= started_at THEN 1 ELSE 0 END) AS completed,
+			COALESCE(SUM(CASE WHEN completed_at >= started_at THEN EXTRACT(EPOCH FROM completed_at - started_at) END), 0) AS duration_seconds`,
+			model.UpdateStatusPending, model.UpdateStatusRunning).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize update histories: %w", err)
+	}
+
+	summary := &model.UpdateHistorySummary{ByStatus: make(map[model.UpdateStatus]int64, len(rows))}
+	var completed int64
+	var durationSeconds float64
+	for _, row := range rows {
+		summary.ByStatus[row.Status] = row.Count
+		summary.Total += row.Count
+		summary.Interrupted += row.Interrupted
+		completed += row.Completed
+		durationSeconds += row.DurationSeconds
+	}
+	if completed > 0 {
+		summary.AverageDurationSeconds = durationSeconds / float64(completed)
+	}
+	return summary, nil
+}
+
 // GetByContainerID retrieves update history for a specific container
 func (r *updateHistoryRepository) GetByContainerID(ctx context.Context, containerID int64, limit, offset int) ([]*model.UpdateHistory, int64, error) {
 	if containerID <= 0 {
@@ -668,6 +995,23 @@ func (r *updateHistoryRepository) GetByStatus(ctx context.Context, status model.
 	return histories, nil
 }
 
+// GetLatestForTarget retrieves the latest update of a container to an image
+// and digest, nil when there is none
+func (r *updateHistoryRepository) GetLatestForTarget(ctx context.Context, containerID int, newImage, newDigest string) (*model.UpdateHistory, error) {
+	var history model.UpdateHistory
+	err := r.db.WithContext(ctx).
+		Where("container_id = ? AND new_image = ? AND COALESCE(new_digest, '') = ?", containerID, newImage, newDigest).
+		Order("started_at DESC, id DESC").
+		First(&history).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest update for target: %w", err)
+	}
+	return &history, nil
+}
+
 // GetRecent retrieves recent update history entries
 func (r *updateHistoryRepository) GetRecent(ctx context.Context, limit int) ([]*model.UpdateHistory, error) {
 	if limit <= 0 {
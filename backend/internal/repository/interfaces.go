@@ -2,8 +2,8 @@ package repository
 
 import (
 	"context"
+	"docker-auto/pkg/model"
 	"time"
-	"docker-auto/internal/model"
 )
 
 // UserRepository defines the interface for user repository operations
@@ -47,6 +47,10 @@ type UserSessionRepository interface {
 	// Session management
 	IsValidSession(ctx context.Context, refreshToken string) (bool, error)
 	CleanupExpiredSessions(ctx context.Context) (int64, error)
+	// DeleteOtherUserSessions deletes the sessions of a user except keepID,
+	// returning those deleted
+	DeleteOtherUserSessions(ctx context.Context, userID int64, keepID string) ([]*model.UserSession, error)
+	TouchLastSeen(ctx context.Context, id string, at time.Time) error
 }
 
 // ActivityLogRepository defines the interface for activity log repository operations
@@ -58,6 +62,8 @@ type ActivityLogRepository interface {
 
 	// Query operations
 	List(ctx context.Context, filter *model.ActivityLogFilter) ([]*model.ActivityLog, int64, error)
+	Count(ctx context.Context, filter *model.ActivityLogFilter) (int64, error)
+	Stream(ctx context.Context, filter *model.ActivityLogFilter, limit int, fn func(*model.ActivityLog) error) error
 	GetByUserID(ctx context.Context, userID int64, limit, offset int) ([]*model.ActivityLog, int64, error)
 	GetByResourceID(ctx context.Context, resourceType string, resourceID int64) ([]*model.ActivityLog, error)
 
@@ -86,16 +92,187 @@ type ContainerRepository interface {
 
 	// Container management operations
 	UpdateStatus(ctx context.Context, id int64, status model.ContainerStatus) error
+	// UpdateContainerID records a Docker container the application created,
+	// clearing the image digests; RelinkContainerID one found by name
 	UpdateContainerID(ctx context.Context, id int64, containerID string) error
+	RelinkContainerID(ctx context.Context, id int64, containerID string) error
+	UpdateWarnings(ctx context.Context, id int64, warnings model.StringList) error
+	UpdateDrift(ctx context.Context, id int64, drifted bool) error
+	UpdateImageDigests(ctx context.Context, id int64, deployed, running string) error
+	UpdateCrashLoop(ctx context.Context, id int64, crashLooping bool, detectedAt *time.Time, hold bool) error
+	MarkUpdateChecked(ctx context.Context, ids []int64, checkedAt time.Time) error
+	DeferUpdate(ctx context.Context, id int64, until *time.Time) error
+	SaveUpdateFailures(ctx context.Context, container *model.Container) error
+	MarkSynced(ctx context.Context, ids []int64, syncedAt time.Time) error
+	UpdatePostStart(ctx context.Context, id int64, run *model.PostStartRun) error
 	GetAutoUpdateContainers(ctx context.Context) ([]*model.Container, error)
 
 	// Batch operations
 	UpdateStatusBatch(ctx context.Context, ids []int64, status model.ContainerStatus) error
 	GetByIDs(ctx context.Context, ids []int64) ([]*model.Container, error)
 
+	// Dependencies
+	ListDependencies(ctx context.Context) ([]*model.ContainerDependency, error)
+	// SetDependencies replaces what the container depends on, returning a
+	// *model.DependencyCycleError when that would close a loop
+	SetDependencies(ctx context.Context, id int64, dependsOn []int64) error
+
 	// Search operations
 	SearchByImage(ctx context.Context, image string) ([]*model.Container, error)
 	Exists(ctx context.Context, name string) (bool, error)
+
+	// Statistics
+	CountByStatus(ctx context.Context) (map[model.ContainerStatus]int64, error)
+	// CountWithCachedUpdates counts the containers the image version cache
+	// knows a newer version for
+	CountWithCachedUpdates(ctx context.Context) (int64, error)
+}
+
+// ContainerChangeRepository defines the interface for the container change feed.
+// Records are appended by ContainerRepository in the transaction of the change.
+type ContainerChangeRepository interface {
+	// Feed reads
+	ListSince(ctx context.Context, sinceSeq int64, limit int) ([]*model.ContainerChange, error)
+	GetSeqRange(ctx context.Context) (oldest, latest int64, err error)
+
+	// Retention
+	DeleteOlderThan(ctx context.Context, cutoffDate time.Time, maxSeq int64) (int64, error)
+	CountOlderThan(ctx context.Context, cutoffDate time.Time, maxSeq int64) (int64, error)
+
+	// Push consumer cursors
+	GetCursor(ctx context.Context, consumer string) (int64, error)
+	SetCursor(ctx context.Context, consumer string, seq int64) error
+}
+
+// ContainerHealthStateRepository defines the interface for container health
+// state persistence
+type ContainerHealthStateRepository interface {
+	// GetByContainerID returns the container's health state, or a new unsaved
+	// state when none has been recorded yet
+	GetByContainerID(ctx context.Context, containerID int) (*model.ContainerHealthState, error)
+	Save(ctx context.Context, state *model.ContainerHealthState) error
+}
+
+// ContainerHealthCheckRepository defines the interface for the health checks
+// configured per container
+type ContainerHealthCheckRepository interface {
+	Create(ctx context.Context, check *model.ContainerHealthCheck) error
+	GetByID(ctx context.Context, id int) (*model.ContainerHealthCheck, error)
+	Update(ctx context.Context, check *model.ContainerHealthCheck) error
+	Delete(ctx context.Context, id int) error
+	ListByContainer(ctx context.Context, containerID int) ([]*model.ContainerHealthCheck, error)
+	// SaveResult stores the outcome of the check's latest run
+	SaveResult(ctx context.Context, check *model.ContainerHealthCheck) error
+}
+
+// VolumeUsageRepository defines the interface for volume usage sample persistence
+type VolumeUsageRepository interface {
+	CreateBatch(ctx context.Context, samples []*model.VolumeUsageSample) error
+
+	// GetLatest returns the most recent sample of every volume
+	GetLatest(ctx context.Context) ([]*model.VolumeUsageSample, error)
+	// GetLastSampledAt returns when volumes were last sampled, nil if never
+	GetLastSampledAt(ctx context.Context) (*time.Time, error)
+	// GetKnownSizeAt returns the volume's most recent sample with a known
+	// size taken at or before t, nil if there is none
+	GetKnownSizeAt(ctx context.Context, volumeName string, t time.Time) (*model.VolumeUsageSample, error)
+
+	DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error)
+}
+
+// ContainerPermissionRepository defines the interface for the permissions
+// granted on containers
+type ContainerPermissionRepository interface {
+	// Grant stores the permission, replacing an earlier grant to the same
+	// user or role on the container
+	Grant(ctx context.Context, permission *model.ContainerPermission) error
+	// Revoke removes the grant to the user or role on the container and
+	// reports whether there was one
+	Revoke(ctx context.Context, containerID int, userID *int, role model.UserRole) (bool, error)
+	ListByContainer(ctx context.Context, containerID int) ([]*model.ContainerPermission, error)
+	// ListForUser returns the grants to the user and to the role across
+	// containers
+	ListForUser(ctx context.Context, userID int, role model.UserRole) ([]*model.ContainerPermission, error)
+}
+
+// ContainerMetricRepository defines the interface for container stats history
+type ContainerMetricRepository interface {
+	CreateBatch(ctx context.Context, metrics []*model.ContainerMetric) error
+
+	// Aggregate sums up the container's metrics taken in [from, to) into
+	// buckets of step, aligned to multiples of step since the Unix epoch.
+	// Buckets without metrics are left out.
+	Aggregate(ctx context.Context, containerID int, from, to time.Time, step time.Duration) ([]*model.ContainerMetricBucket, error)
+
+	DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error)
+	CountOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error)
+}
+
+// SecurityPostureRepository defines the interface for security posture reports
+type SecurityPostureRepository interface {
+	Create(ctx context.Context, report *model.SecurityPostureReport) error
+	GetByID(ctx context.Context, id int) (*model.SecurityPostureReport, error)
+	// GetLatest returns the most recent report, nil if there is none
+	GetLatest(ctx context.Context) (*model.SecurityPostureReport, error)
+	// GetAtOrBefore returns the most recent report evaluated at or before t,
+	// nil if there is none
+	GetAtOrBefore(ctx context.Context, t time.Time) (*model.SecurityPostureReport, error)
+}
+
+// GitOpsRepository defines the interface for GitOps managed containers and
+// reconcile reports
+type GitOpsRepository interface {
+	// ListResources returns the containers managed by a source
+	ListResources(ctx context.Context, source string) ([]*model.GitOpsResource, error)
+	// GetResourceByContainer returns the resource of a container, nil if no
+	// source manages it
+	GetResourceByContainer(ctx context.Context, containerID int) (*model.GitOpsResource, error)
+	// SaveResource stores the resource, replacing the one of its container
+	SaveResource(ctx context.Context, resource *model.GitOpsResource) error
+
+	CreateRun(ctx context.Context, run *model.GitOpsRun) error
+	UpdateRun(ctx context.Context, run *model.GitOpsRun) error
+	GetRun(ctx context.Context, id int) (*model.GitOpsRun, error)
+	// ListRuns returns the most recent runs first
+	ListRuns(ctx context.Context, limit int) ([]*model.GitOpsRun, error)
+}
+
+// BulkOperationRepository defines the interface for bulk operation persistence
+type BulkOperationRepository interface {
+	Create(ctx context.Context, operation *model.BulkOperation) error
+	GetByID(ctx context.Context, id int64) (*model.BulkOperation, error)
+	Update(ctx context.Context, operation *model.BulkOperation) error
+}
+
+// SecretRepository reads and rewrites the values of encrypted secret columns
+// for key rotation and plaintext migration
+type SecretRepository interface {
+	// ListValues returns up to limit non-null values with IDs above afterID,
+	// in ID order
+	ListValues(ctx context.Context, column model.SecretColumn, afterID, limit int) ([]model.SecretValue, error)
+	// ReplaceValue stores replacement only if the row still holds current,
+	// reporting whether it did
+	ReplaceValue(ctx context.Context, column model.SecretColumn, id int, current, replacement string) (bool, error)
+}
+
+// SystemBundleRepository reads and restores the configuration moved between
+// servers by system export and import
+type SystemBundleRepository interface {
+	// Snapshot returns every container, scheduled task, registry credential
+	// and global notification channel; TaskTargets is left empty
+	Snapshot(ctx context.Context) (*model.SystemSnapshot, error)
+	// Restore creates everything in snapshot in one transaction
+	Restore(ctx context.Context, snapshot *model.SystemSnapshot) error
+}
+
+// ReportRepository defines the interface for compliance report exports.
+// Stream methods call fn once per row, in started_at order, and stop at
+// limit rows or at the first error fn returns.
+type ReportRepository interface {
+	CountUpdates(ctx context.Context, filter *model.ReportFilter) (int64, error)
+	StreamUpdates(ctx context.Context, filter *model.ReportFilter, limit int, fn func(*model.UpdateReportRecord) error) error
+	CountTaskExecutions(ctx context.Context, filter *model.ReportFilter) (int64, error)
+	StreamTaskExecutions(ctx context.Context, filter *model.ReportFilter, limit int, fn func(*model.TaskExecutionReportRecord) error) error
 }
 
 // RegistryCredentialsRepository defines the interface for registry credentials repository operations
@@ -119,11 +296,20 @@ type RegistryCredentialsRepository interface {
 	Exists(ctx context.Context, name string) (bool, error)
 }
 
+// UpdateNoteRepository defines the interface for update note repository operations
+type UpdateNoteRepository interface {
+	Create(ctx context.Context, note *model.UpdateNote) error
+	GetByID(ctx context.Context, id int) (*model.UpdateNote, error)
+	Update(ctx context.Context, note *model.UpdateNote) error
+	ListByUpdate(ctx context.Context, updateID int) ([]*model.UpdateNote, error)
+}
+
 // UpdateHistoryRepository defines the interface for update history repository operations
 type UpdateHistoryRepository interface {
 	// Basic CRUD operations
 	Create(ctx context.Context, history *model.UpdateHistory) error
 	GetByID(ctx context.Context, id int64) (*model.UpdateHistory, error)
+	Update(ctx context.Context, history *model.UpdateHistory) error
 	Delete(ctx context.Context, id int64) error
 
 	// Query operations
@@ -131,10 +317,16 @@ type UpdateHistoryRepository interface {
 	GetByContainerID(ctx context.Context, containerID int64, limit, offset int) ([]*model.UpdateHistory, int64, error)
 	GetByStatus(ctx context.Context, status model.UpdateStatus) ([]*model.UpdateHistory, error)
 	GetRecent(ctx context.Context, limit int) ([]*model.UpdateHistory, error)
+	// GetLatestForTarget returns the latest update of the container to the
+	// image and digest, nil when there is none
+	GetLatestForTarget(ctx context.Context, containerID int, newImage, newDigest string) (*model.UpdateHistory, error)
 
 	// Statistics operations
 	GetUpdateStats(ctx context.Context, containerID int64) (*model.UpdateStats, error)
 	GetSuccessRate(ctx context.Context, containerID int64) (float64, error)
+	// Summarize counts the updates matching the filter per status and
+	// averages their duration
+	Summarize(ctx context.Context, filter *model.UpdateHistoryFilter) (*model.UpdateHistorySummary, error)
 
 	// Maintenance operations
 	DeleteOldHistory(ctx context.Context, retentionDays int) (int64, error)
@@ -162,9 +354,19 @@ type ImageVersionRepository interface {
 	GetVersionHistory(ctx context.Context, imageName string, limit int) ([]*model.ImageVersion, error)
 	DeleteOldVersions(ctx context.Context, imageName string, keepCount int) error
 
+	// Version history; GetVersionRecord returns nil for a version not seen yet
+	GetVersionRecord(ctx context.Context, repository, tag, digest string) (*model.ImageVersionRecord, error)
+	SaveVersionRecord(ctx context.Context, record *model.ImageVersionRecord) error
+	ListVersionRecords(ctx context.Context, repository string, limit int) ([]*model.ImageVersionRecord, error)
+
 	// Cache operations
 	RefreshImageCache(ctx context.Context, imageName string) error
 	GetCachedVersions(ctx context.Context, imageName string) ([]*model.ImageVersion, error)
+	GetCacheGeneration(ctx context.Context, imageName string) (int64, error)
+	UpsertVersionIfGeneration(ctx context.Context, version *model.ImageVersion, generation int64) (bool, error)
+	ListCachedImages(ctx context.Context) ([]string, error)
+	InvalidateCache(ctx context.Context, imageName string) (int64, error)
+	GetCacheStats(ctx context.Context) (*model.ImageCacheStats, error)
 
 	// Cleanup operations
 	DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error)
@@ -175,6 +377,51 @@ type ImageVersionRepository interface {
 	GetOutdatedImages(ctx context.Context) ([]*model.ImageVersion, error)
 }
 
+// ImagePolicyRepository defines the interface for image policy repository operations
+type ImagePolicyRepository interface {
+	// Basic CRUD operations
+	Create(ctx context.Context, policy *model.ImagePolicy) error
+	GetByID(ctx context.Context, id int64) (*model.ImagePolicy, error)
+	Update(ctx context.Context, policy *model.ImagePolicy) error
+	Delete(ctx context.Context, id int64) error
+
+	// Query operations
+	List(ctx context.Context, filter *model.ImagePolicyFilter) ([]*model.ImagePolicy, int64, error)
+	GetByRepository(ctx context.Context, repository string) ([]*model.ImagePolicy, error)
+}
+
+// ScanResultRepository defines the interface for image scan result repository operations
+type ScanResultRepository interface {
+	// Upsert stores a scan result, replacing any earlier result for the same digest
+	Upsert(ctx context.Context, result *model.ScanResult) error
+	GetByDigest(ctx context.Context, digest string) (*model.ScanResult, error)
+	GetLatestByImage(ctx context.Context, imageName string) (*model.ScanResult, error)
+
+	// Cleanup operations; results for the kept digests are never deleted
+	DeleteOlderThan(ctx context.Context, cutoffDate time.Time, keepDigests []string) (int64, error)
+	CountOlderThan(ctx context.Context, cutoffDate time.Time, keepDigests []string) (int64, error)
+}
+
+// StackRepository defines the interface for stack repository operations
+type StackRepository interface {
+	// Basic CRUD operations
+	Create(ctx context.Context, stack *model.Stack) error
+	GetByID(ctx context.Context, id int64) (*model.Stack, error)
+	Update(ctx context.Context, stack *model.Stack) error
+	Delete(ctx context.Context, id int64) error
+
+	// Query operations
+	List(ctx context.Context, filter *model.StackFilter) ([]*model.Stack, int64, error)
+	GetByName(ctx context.Context, name string) (*model.Stack, error)
+	GetByComposeProject(ctx context.Context, project string) (*model.Stack, error)
+
+	// Membership operations
+	CreateWithMembers(ctx context.Context, stack *model.Stack, containerIDs []int64) error
+	UpdateWithMembers(ctx context.Context, stack *model.Stack, containerIDs []int64) error
+	SetMembers(ctx context.Context, stackID int64, containerIDs []int64) error
+	AddMember(ctx context.Context, stackID int64, containerID int64) error
+}
+
 // SystemConfigRepository defines the interface for system configuration repository operations
 type SystemConfigRepository interface {
 	// Basic CRUD operations
@@ -248,6 +495,12 @@ type NotificationRepository interface {
 	MarkAsRead(ctx context.Context, notificationID int64, userID int64) error
 	MarkAllAsRead(ctx context.Context, userID int64) (int64, error)
 
+	// Acknowledgment operations
+	AcknowledgeMatching(ctx context.Context, filter *model.UserNotificationFilter, userID int64, note string, snoozedUntil time.Time) (int64, error)
+	// GetLatestAcknowledged returns the most recently acknowledged notification of the
+	// type about the container for the user, or nil when there is none
+	GetLatestAcknowledged(ctx context.Context, userID *int64, notificationType string, containerID int64) (*model.UserNotification, error)
+
 	// Cleanup operations
 	DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error)
 	CountOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error)
@@ -279,11 +532,40 @@ type NotificationLogRepository interface {
 	CreateBatch(ctx context.Context, logs []*model.NotificationLog) error
 }
 
+// NotificationChannelRepository defines the interface for notification
+// channel repository operations
+type NotificationChannelRepository interface {
+	Create(ctx context.Context, channel *model.NotificationChannel) error
+	GetByID(ctx context.Context, id int) (*model.NotificationChannel, error)
+	Update(ctx context.Context, channel *model.NotificationChannel) error
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context, filter *model.NotificationChannelFilter) ([]*model.NotificationChannel, error)
+
+	// RecordDelivery stores the outcome of a delivery on the channel and
+	// LogDelivery adds it to the notification log
+	RecordDelivery(ctx context.Context, id int, status model.NotificationStatus, deliveryErr string, at time.Time) error
+	LogDelivery(ctx context.Context, log *model.NotificationLog) error
+}
+
+// PendingNotificationRepository defines the interface for the update
+// notifications buffered for digests
+type PendingNotificationRepository interface {
+	Create(ctx context.Context, pending *model.PendingNotification) error
+	// ListUserIDs returns the users with buffered notifications
+	ListUserIDs(ctx context.Context) ([]int64, error)
+	// ListByUser returns a user's buffered notifications, oldest first
+	ListByUser(ctx context.Context, userID int64) ([]*model.PendingNotification, error)
+	DeleteByIDs(ctx context.Context, ids []int64) error
+}
+
 // ScheduledTaskRepository defines the interface for scheduled task repository operations
 type ScheduledTaskRepository interface {
 	// Basic CRUD operations
 	Create(ctx context.Context, task *model.ScheduledTask) error
 	GetByID(ctx context.Context, id int64) (*model.ScheduledTask, error)
+	// Update writes the task definition and next run only if the stored
+	// version still matches task.Version, returning model.ErrTaskVersionConflict
+	// otherwise. Run bookkeeping columns are left to RecordRun.
 	Update(ctx context.Context, task *model.ScheduledTask) error
 	Delete(ctx context.Context, id int64) error
 
@@ -298,6 +580,13 @@ type ScheduledTaskRepository interface {
 	UpdateLastRun(ctx context.Context, id int64) error
 	UpdateNextRun(ctx context.Context, id int64) error
 	SetEnabled(ctx context.Context, id int64, enabled bool) error
+	// RecordRun counts a finished run in place: run_count, failure_count and
+	// last_run_at are incremented and set atomically, and next_run_at only
+	// ever moves forward
+	RecordRun(ctx context.Context, id int64, ranAt time.Time, failed bool, nextRunAt *time.Time) error
+	// CompleteRun counts the run of a one-shot task like RecordRun, then
+	// deactivates it, sets completed_at and clears next_run_at
+	CompleteRun(ctx context.Context, id int64, ranAt time.Time, failed bool) error
 
 	// Execution tracking
 	GetActiveTasks(ctx context.Context) ([]*model.ScheduledTask, error)
@@ -332,22 +621,136 @@ type TaskExecutionLogRepository interface {
 	CreateBatch(ctx context.Context, logs []*model.TaskExecutionLog) error
 }
 
+// SchedulerEventRepository defines the interface for scheduler event log
+// repository operations
+type SchedulerEventRepository interface {
+	Create(ctx context.Context, event *model.SchedulerEventLog) error
+	List(ctx context.Context, filter *model.SchedulerEventFilter) ([]*model.SchedulerEventLog, int64, error)
+	Prune(ctx context.Context, keep int) (int64, error)
+}
+
+// StatusPageRepository defines the interface for public status page
+// repository operations
+type StatusPageRepository interface {
+	Create(ctx context.Context, page *model.StatusPage) error
+	GetByID(ctx context.Context, id int) (*model.StatusPage, error)
+	GetByToken(ctx context.Context, token string) (*model.StatusPage, error)
+	Update(ctx context.Context, page *model.StatusPage) error
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context) ([]*model.StatusPage, error)
+}
+
+// ContainerTemplateRepository defines the interface for container template
+// repository operations
+type ContainerTemplateRepository interface {
+	Create(ctx context.Context, template *model.ContainerTemplate) error
+	GetByID(ctx context.Context, id int) (*model.ContainerTemplate, error)
+	Update(ctx context.Context, template *model.ContainerTemplate) error
+	Delete(ctx context.Context, id int) error
+	ListVisible(ctx context.Context, ownerID *int) ([]*model.ContainerTemplate, error)
+}
+
+// ApprovalPolicyRepository defines the interface for update approval policy
+// repository operations
+type ApprovalPolicyRepository interface {
+	Create(ctx context.Context, policy *model.ApprovalPolicy) error
+	GetByID(ctx context.Context, id int) (*model.ApprovalPolicy, error)
+	Update(ctx context.Context, policy *model.ApprovalPolicy) error
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context) ([]*model.ApprovalPolicy, error)
+	ListEnabled(ctx context.Context) ([]*model.ApprovalPolicy, error)
+}
+
+// TeamRepository defines the interface for team and team quota repository
+// operations
+type TeamRepository interface {
+	Create(ctx context.Context, team *model.Team) error
+	GetByID(ctx context.Context, id int) (*model.Team, error)
+	List(ctx context.Context) ([]*model.Team, error)
+	ReplaceMembers(ctx context.Context, teamID int, members []model.TeamMember) error
+	GetQuota(ctx context.Context, teamID int) (*model.TeamQuota, error)
+	SaveQuota(ctx context.Context, quota *model.TeamQuota) error
+	ListContainers(ctx context.Context, teamID int) ([]*model.Container, error)
+}
+
+// DockerHostRepository defines the interface for Docker host repository operations
+type DockerHostRepository interface {
+	Create(ctx context.Context, host *model.DockerHost) error
+	GetByID(ctx context.Context, id int) (*model.DockerHost, error)
+	GetByName(ctx context.Context, name string) (*model.DockerHost, error)
+	Update(ctx context.Context, host *model.DockerHost) error
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context, enabledOnly bool) ([]*model.DockerHost, error)
+
+	// RecordPing stores the outcome of a health ping on the host
+	RecordPing(ctx context.Context, id int, pingErr string, at time.Time) error
+	CountContainers(ctx context.Context, id int) (int64, error)
+}
+
+// RoleRepository defines the interface for role repository operations
+type RoleRepository interface {
+	Create(ctx context.Context, role *model.Role) error
+	GetByID(ctx context.Context, id int) (*model.Role, error)
+	GetByName(ctx context.Context, name model.UserRole) (*model.Role, error)
+	Update(ctx context.Context, role *model.Role) error
+	Delete(ctx context.Context, id int) error
+	List(ctx context.Context) ([]*model.Role, error)
+
+	// CountUsers returns the number of users assigned each role
+	CountUsers(ctx context.Context) (map[model.UserRole]int64, error)
+}
+
+// APITokenRepository defines the interface for personal access token
+// repository operations
+type APITokenRepository interface {
+	Create(ctx context.Context, token *model.APIToken) error
+	GetByID(ctx context.Context, id int) (*model.APIToken, error)
+	GetByHash(ctx context.Context, tokenHash string) (*model.APIToken, error)
+	ListByUser(ctx context.Context, userID int64) ([]*model.APIToken, error)
+	CountUsable(ctx context.Context, userID int64, now time.Time) (int64, error)
+	Revoke(ctx context.Context, id int, at time.Time) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time) error
+}
+
 // RepositoryManager aggregates all repositories
 type RepositoryManager interface {
 	// Repository getters
 	User() UserRepository
 	UserSession() UserSessionRepository
+	Role() RoleRepository
+	APIToken() APITokenRepository
 	ActivityLog() ActivityLogRepository
 	Container() ContainerRepository
+	ContainerChange() ContainerChangeRepository
+	ContainerHealthState() ContainerHealthStateRepository
+	StatusPage() StatusPageRepository
+	VolumeUsage() VolumeUsageRepository
+	ContainerMetric() ContainerMetricRepository
+	ContainerPermission() ContainerPermissionRepository
+	Report() ReportRepository
 	RegistryCredentials() RegistryCredentialsRepository
+	Secret() SecretRepository
+	SystemBundle() SystemBundleRepository
 	UpdateHistory() UpdateHistoryRepository
+	UpdateNote() UpdateNoteRepository
+	ApprovalPolicy() ApprovalPolicyRepository
+	BulkOperation() BulkOperationRepository
 	ImageVersion() ImageVersionRepository
+	ImagePolicy() ImagePolicyRepository
+	ScanResult() ScanResultRepository
+	SecurityPosture() SecurityPostureRepository
+	GitOps() GitOpsRepository
+	Stack() StackRepository
+	Team() TeamRepository
+	DockerHost() DockerHostRepository
 	SystemConfig() SystemConfigRepository
 	NotificationTemplate() NotificationTemplateRepository
 	Notification() NotificationRepository
 	NotificationLog() NotificationLogRepository
+	PendingNotification() PendingNotificationRepository
 	ScheduledTask() ScheduledTaskRepository
 	TaskExecutionLog() TaskExecutionLogRepository
+	SchedulerEvent() SchedulerEventRepository
 
 	// Transaction management
 	WithTransaction(fn func(RepositoryManager) error) error
@@ -0,0 +1,311 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// notificationRepository implements NotificationRepository interface
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository creates a new user notification repository
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+// Create creates a new notification
+func (r *notificationRepository) Create(ctx context.Context, notification *model.UserNotification) error {
+	if notification == nil {
+		return fmt.Errorf("notification cannot be nil")
+	}
+
+	if err := r.db.WithContext(ctx).Omit("User").Create(notification).Error; err != nil {
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a notification by ID
+func (r *notificationRepository) GetByID(ctx context.Context, id int64) (*model.UserNotification, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid notification ID: %d", id)
+	}
+
+	var notification model.UserNotification
+	if err := r.db.WithContext(ctx).First(&notification, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("notification with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get notification by ID: %w", err)
+	}
+	return &notification, nil
+}
+
+// Update updates a notification
+func (r *notificationRepository) Update(ctx context.Context, notification *model.UserNotification) error {
+	if notification == nil {
+		return fmt.Errorf("notification cannot be nil")
+	}
+	if notification.ID <= 0 {
+		return fmt.Errorf("invalid notification ID: %d", notification.ID)
+	}
+
+	if err := r.db.WithContext(ctx).Omit("User").Save(notification).Error; err != nil {
+		return fmt.Errorf("failed to update notification: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a notification by ID
+func (r *notificationRepository) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid notification ID: %d", id)
+	}
+
+	result := r.db.WithContext(ctx).Delete(&model.UserNotification{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete notification: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification with ID %d not found", id)
+	}
+	return nil
+}
+
+// List retrieves notifications with filtering and pagination
+func (r *notificationRepository) List(ctx context.Context, filter *model.UserNotificationFilter) ([]*model.UserNotification, int64, error) {
+	var notifications []*model.UserNotification
+	var total int64
+
+	query := r.notificationQuery(ctx, filter)
+
+	// Keyset pagination skips the count and offset scan entirely
+	if filter != nil && filter.Cursor != nil {
+		query = applyKeyset(query, "created_at", filter.Cursor, filter.Limit)
+		if err := query.Find(&notifications).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+		}
+		return notifications, 0, nil
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+
+	orderBy := "created_at DESC, id DESC"
+	if filter != nil && filter.OrderBy != "" {
+		orderBy = filter.OrderBy
+	}
+	query = query.Order(orderBy)
+
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	if err := query.Find(&notifications).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	return notifications, total, nil
+}
+
+// notificationQuery applies the filter's conditions. The user condition
+// leads so the (user_id, created_at, id) keyset index can serve it.
+func (r *notificationRepository) notificationQuery(ctx context.Context, filter *model.UserNotificationFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.UserNotification{})
+	if filter == nil {
+		return query
+	}
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	if filter.IsRead != nil {
+		query = query.Where("is_read = ?", *filter.IsRead)
+	}
+	if filter.CreatedAfter != nil {
+		query = query.Where("created_at >= ?", *filter.CreatedAfter)
+	}
+	if filter.CreatedBefore != nil {
+		query = query.Where("created_at <= ?", *filter.CreatedBefore)
+	}
+	if filter.Acknowledged != nil {
+		if *filter.Acknowledged {
+			query = query.Where("acknowledged_at IS NOT NULL")
+		} else {
+			query = query.Where("acknowledged_at IS NULL")
+		}
+	}
+	if len(filter.ContainerIDs) > 0 {
+		query = query.Where("container_id IN ?", filter.ContainerIDs)
+	}
+	return query
+}
+
+// GetByUserID retrieves the notifications of a user, newest first
+func (r *notificationRepository) GetByUserID(ctx context.Context, userID int64, limit, offset int) ([]*model.UserNotification, error) {
+	notifications, _, err := r.List(ctx, &model.UserNotificationFilter{
+		UserID: &userID,
+		Limit:  limit,
+		Offset: offset,
+	})
+	return notifications, err
+}
+
+// GetByUserIDAndType retrieves the notifications of a type for a user,
+// newest first
+func (r *notificationRepository) GetByUserIDAndType(ctx context.Context, userID int64, notificationType string, limit, offset int) ([]*model.UserNotification, error) {
+	notifications, _, err := r.List(ctx, &model.UserNotificationFilter{
+		UserID: &userID,
+		Type:   notificationType,
+		Limit:  limit,
+		Offset: offset,
+	})
+	return notifications, err
+}
+
+// GetUnreadCount counts the unread notifications of a user
+func (r *notificationRepository) GetUnreadCount(ctx context.Context, userID int64) (int64, error) {
+	isRead := false
+	return r.count(ctx, &model.UserNotificationFilter{UserID: &userID, IsRead: &isRead})
+}
+
+// GetTotalCount counts the notifications of a user
+func (r *notificationRepository) GetTotalCount(ctx context.Context, userID int64) (int64, error) {
+	return r.count(ctx, &model.UserNotificationFilter{UserID: &userID})
+}
+
+// GetCountByType counts the notifications of a type for a user
+func (r *notificationRepository) GetCountByType(ctx context.Context, userID int64, notificationType string) (int64, error) {
+	return r.count(ctx, &model.UserNotificationFilter{UserID: &userID, Type: notificationType})
+}
+
+func (r *notificationRepository) count(ctx context.Context, filter *model.UserNotificationFilter) (int64, error) {
+	var count int64
+	if err := r.notificationQuery(ctx, filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkAsRead marks a notification of the user as read
+func (r *notificationRepository) MarkAsRead(ctx context.Context, notificationID int64, userID int64) error {
+	result := r.db.WithContext(ctx).Model(&model.UserNotification{}).
+		Where("id = ? AND user_id = ?", notificationID, userID).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to mark notification as read: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification with ID %d not found", notificationID)
+	}
+	return nil
+}
+
+// MarkAllAsRead marks all unread notifications of the user as read
+func (r *notificationRepository) MarkAllAsRead(ctx context.Context, userID int64) (int64, error) {
+	result := r.db.WithContext(ctx).Model(&model.UserNotification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": time.Now(),
+		})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to mark notifications as read: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// AcknowledgeMatching acknowledges the notifications matching the filter
+// and returns how many were acknowledged
+func (r *notificationRepository) AcknowledgeMatching(ctx context.Context, filter *model.UserNotificationFilter, userID int64, note string, snoozedUntil time.Time) (int64, error) {
+	result := r.notificationQuery(ctx, filter).Updates(map[string]interface{}{
+		"acknowledged_at": time.Now(),
+		"acknowledged_by": userID,
+		"ack_note":        note,
+		"snoozed_until":   snoozedUntil,
+	})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to acknowledge notifications: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// GetLatestAcknowledged returns the most recently acknowledged notification of
+// the type about the container for the user, or nil when there is none
+func (r *notificationRepository) GetLatestAcknowledged(ctx context.Context, userID *int64, notificationType string, containerID int64) (*model.UserNotification, error) {
+	query := r.db.WithContext(ctx).
+		Where("type = ? AND container_id = ? AND acknowledged_at IS NOT NULL", notificationType, containerID)
+	if userID != nil {
+		query = query.Where("user_id = ?", *userID)
+	} else {
+		query = query.Where("user_id IS NULL")
+	}
+
+	var notifications []*model.UserNotification
+	if err := query.Order("acknowledged_at DESC, id DESC").Limit(1).Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to get acknowledged notification: %w", err)
+	}
+	if len(notifications) == 0 {
+		return nil, nil
+	}
+	return notifications[0], nil
+}
+
+// DeleteOlderThan deletes notifications created before the cutoff
+func (r *notificationRepository) DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("created_at < ?", cutoffDate).
+		Delete(&model.UserNotification{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old notifications: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// CountOlderThan counts notifications created before the cutoff
+func (r *notificationRepository) CountOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UserNotification{}).
+		Where("created_at < ?", cutoffDate).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count old notifications: %w", err)
+	}
+	return count, nil
+}
+
+// CreateBatch creates multiple notifications in a single statement
+func (r *notificationRepository) CreateBatch(ctx context.Context, notifications []*model.UserNotification) error {
+	if len(notifications) == 0 {
+		return fmt.Errorf("notifications slice cannot be empty")
+	}
+	for i, notification := range notifications {
+		if notification == nil {
+			return fmt.Errorf("notification at index %d cannot be nil", i)
+		}
+	}
+
+	if err := r.db.WithContext(ctx).Omit("User").CreateInBatches(notifications, 100).Error; err != nil {
+		return fmt.Errorf("failed to create notifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// notificationChannelRepository implements NotificationChannelRepository interface
+type notificationChannelRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationChannelRepository creates a new notification channel repository
+func NewNotificationChannelRepository(db *gorm.DB) NotificationChannelRepository {
+	return &notificationChannelRepository{db: db}
+}
+
+// Create creates a new notification channel
+func (r *notificationChannelRepository) Create(ctx context.Context, channel *model.NotificationChannel) error {
+	if channel == nil {
+		return fmt.Errorf("notification channel cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(channel).Error; err != nil {
+		return fmt.Errorf("failed to create notification channel: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a notification channel by ID
+func (r *notificationChannelRepository) GetByID(ctx context.Context, id int) (*model.NotificationChannel, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid notification channel ID: %d", id)
+	}
+
+	var channel model.NotificationChannel
+	err := r.db.WithContext(ctx).First(&channel, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("notification channel with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notification channel by ID: %w", err)
+	}
+	return &channel, nil
+}
+
+// Update updates the configuration of a notification channel, leaving the
+// delivery outcome to RecordDelivery
+func (r *notificationChannelRepository) Update(ctx context.Context, channel *model.NotificationChannel) error {
+	if channel == nil {
+		return fmt.Errorf("notification channel cannot be nil")
+	}
+	if channel.ID <= 0 {
+		return fmt.Errorf("invalid notification channel ID: %d", channel.ID)
+	}
+
+	err := r.db.WithContext(ctx).Model(channel).
+		Select("name", "type", "enabled", "notification_types", "min_priority", "settings_encrypted", "updated_at").
+		Updates(channel).Error
+	if err != nil {
+		return fmt.Errorf("failed to update notification channel: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a notification channel by ID
+func (r *notificationChannelRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.NotificationChannel{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete notification channel: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification channel with ID %d not found", id)
+	}
+	return nil
+}
+
+// List returns the notification channels matching the filter, global ones
+// first
+func (r *notificationChannelRepository) List(ctx context.Context, filter *model.NotificationChannelFilter) ([]*model.NotificationChannel, error) {
+	query := r.db.WithContext(ctx).Model(&model.NotificationChannel{})
+
+	if filter != nil {
+		switch {
+		case filter.UserID != nil && filter.Global:
+			query = query.Where("user_id = ? OR user_id IS NULL", *filter.UserID)
+		case filter.UserID != nil:
+			query = query.Where("user_id = ?", *filter.UserID)
+		case filter.Global:
+			query = query.Where("user_id IS NULL")
+		}
+		if filter.EnabledOnly {
+			query = query.Where("enabled = ?", true)
+		}
+	}
+
+	var channels []*model.NotificationChannel
+	if err := query.Order("user_id NULLS FIRST, id ASC").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+	return channels, nil
+}
+
+// RecordDelivery stores the outcome of a delivery on the channel; failures
+// count up until a delivery succeeds
+func (r *notificationChannelRepository) RecordDelivery(ctx context.Context, id int, status model.NotificationStatus, deliveryErr string, at time.Time) error {
+	failed := gorm.Expr("0")
+	if status == model.NotificationStatusFailed {
+		failed = gorm.Expr("failed_deliveries + 1")
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.NotificationChannel{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_delivery_at":     at.UTC(),
+			"last_delivery_status": status,
+			"last_delivery_error":  deliveryErr,
+			"failed_deliveries":    failed,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to record notification channel delivery: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("notification channel with ID %d not found", id)
+	}
+	return nil
+}
+
+// LogDelivery adds a delivery to the notification log
+func (r *notificationChannelRepository) LogDelivery(ctx context.Context, log *model.NotificationLog) error {
+	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+		return fmt.Errorf("failed to log notification delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// applyKeyset orders the query newest first by (timeColumn, id) and, unless
+// the cursor is zero, restricts it to rows after the cursor. The row-value
+// comparison lets PostgreSQL walk the composite (timeColumn DESC, id DESC)
+// index instead of scanning past an offset, so pages stay stable while new
+// rows are inserted.
+func applyKeyset(query *gorm.DB, timeColumn string, cursor *model.Cursor, limit int) *gorm.DB {
+	if !cursor.IsZero() {
+		query = query.Where(fmt.Sprintf("(%s, id) < (?, ?)", timeColumn), cursor.Time, cursor.ID)
+	}
+
+	query = query.Order(fmt.Sprintf("%s DESC, id DESC", timeColumn))
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	return query
+}
@@ -0,0 +1,275 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+func newPaginationTestDB(tb testing.TB) *gorm.DB {
+	tb.Helper()
+
+	return newTestDB(tb,
+		&model.User{},
+		&model.Container{},
+		&model.ActivityLog{},
+		&model.UpdateHistory{},
+		&model.ScheduledTask{},
+		&model.TaskExecutionLog{},
+		&model.UserNotification{},
+	)
+}
+
+// keysetRow is a listed row's position in the keyset order
+type keysetRow struct {
+	id int64
+	at time.Time
+}
+
+// keysetSubject lists one repository by cursor and inserts rows into it
+type keysetSubject struct {
+	name   string
+	insert func(ctx context.Context, db *gorm.DB, at time.Time) error
+	page   func(ctx context.Context, db *gorm.DB, cursor *model.Cursor, limit int) ([]keysetRow, error)
+}
+
+var userID int64 = 1
+
+var keysetSubjects = []keysetSubject{
+	{
+		name: "activity logs",
+		insert: func(ctx context.Context, db *gorm.DB, at time.Time) error {
+			return NewActivityLogRepository(db).Create(ctx, &model.ActivityLog{
+				UserID: &userID, Action: "login", ResourceType: "user", CreatedAt: at,
+			})
+		},
+		page: func(ctx context.Context, db *gorm.DB, cursor *model.Cursor, limit int) ([]keysetRow, error) {
+			logs, _, err := NewActivityLogRepository(db).List(ctx, &model.ActivityLogFilter{Cursor: cursor, Limit: limit})
+			rows := make([]keysetRow, len(logs))
+			for i, log := range logs {
+				rows[i] = keysetRow{int64(log.ID), log.CreatedAt}
+			}
+			return rows, err
+		},
+	},
+	{
+		name: "update history",
+		insert: func(ctx context.Context, db *gorm.DB, at time.Time) error {
+			return db.WithContext(ctx).Create(&model.UpdateHistory{
+				ContainerID: 1, NewImage: "nginx:latest", Status: model.UpdateStatusSuccess, StartedAt: at,
+			}).Error
+		},
+		page: func(ctx context.Context, db *gorm.DB, cursor *model.Cursor, limit int) ([]keysetRow, error) {
+			histories, _, err := NewUpdateHistoryRepository(db).List(ctx, &model.UpdateHistoryFilter{Cursor: cursor, Limit: limit})
+			rows := make([]keysetRow, len(histories))
+			for i, history := range histories {
+				rows[i] = keysetRow{int64(history.ID), history.StartedAt}
+			}
+			return rows, err
+		},
+	},
+	{
+		name: "task executions",
+		insert: func(ctx context.Context, db *gorm.DB, at time.Time) error {
+			return NewTaskExecutionLogRepository(db).Create(ctx, &model.TaskExecutionLog{
+				TaskID: 1, Status: model.ExecutionStatusSuccess, StartedAt: at,
+			})
+		},
+		page: func(ctx context.Context, db *gorm.DB, cursor *model.Cursor, limit int) ([]keysetRow, error) {
+			logs, _, err := NewTaskExecutionLogRepository(db).List(ctx, &model.TaskExecutionLogFilter{Cursor: cursor, Limit: limit})
+			rows := make([]keysetRow, len(logs))
+			for i, log := range logs {
+				rows[i] = keysetRow{int64(log.ID), log.StartedAt}
+			}
+			return rows, err
+		},
+	},
+	{
+		name: "notifications",
+		insert: func(ctx context.Context, db *gorm.DB, at time.Time) error {
+			return NewNotificationRepository(db).Create(ctx, &model.UserNotification{
+				UserID: &userID, Type: "info", Title: "t", Message: "m", CreatedAt: at,
+			})
+		},
+		page: func(ctx context.Context, db *gorm.DB, cursor *model.Cursor, limit int) ([]keysetRow, error) {
+			notifications, _, err := NewNotificationRepository(db).List(ctx, &model.UserNotificationFilter{UserID: &userID, Cursor: cursor, Limit: limit})
+			rows := make([]keysetRow, len(notifications))
+			for i, notification := range notifications {
+				rows[i] = keysetRow{notification.ID, notification.CreatedAt}
+			}
+			return rows, err
+		},
+	},
+}
+
+// TestKeysetPaginationStableUnderInserts walks each repository page by page
+// while new rows arrive between the reads. Every row that existed before the walk must
+// be returned exactly once, in (time, id) descending order; offset paging
+// would repeat rows as the inserts shift them down.
+func TestKeysetPaginationStableUnderInserts(t *testing.T) {
+	const (
+		existing = 250
+		pageSize = 20
+	)
+
+	for _, subject := range keysetSubjects {
+		t.Run(subject.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newPaginationTestDB(t)
+
+			// Rows share timestamps in threes so the ID breaks the ties
+			base := time.Now().UTC().Add(-time.Hour).Truncate(time.Second)
+			for i := 0; i < existing; i++ {
+				if err := subject.insert(ctx, db, base.Add(time.Duration(i/3)*time.Second)); err != nil {
+					t.Fatalf("failed to seed row %d: %v", i, err)
+				}
+			}
+
+			seen := make(map[int64]bool)
+			var previous *keysetRow
+			cursor := &model.Cursor{}
+			for pages := 0; ; pages++ {
+				if pages > existing {
+					t.Fatal("pagination did not terminate")
+				}
+				rows, err := subject.page(ctx, db, cursor, pageSize)
+				if err != nil {
+					t.Fatalf("failed to read page %d: %v", pages, err)
+				}
+				for _, row := range rows {
+					if seen[row.id] {
+						t.Fatalf("row %d returned twice", row.id)
+					}
+					seen[row.id] = true
+					if previous != nil && (row.at.After(previous.at) || (row.at.Equal(previous.at) && row.id > previous.id)) {
+						t.Fatalf("row %d (%s) listed after row %d (%s)", row.id, row.at, previous.id, previous.at)
+					}
+					row := row
+					previous = &row
+				}
+
+				last := len(rows) - 1
+				if last < 0 {
+					break
+				}
+				next, err := model.DecodeCursor(model.NextCursor(len(rows), pageSize, rows[last].at, rows[last].id))
+				if err != nil {
+					t.Fatalf("invalid next cursor: %v", err)
+				}
+				if next.IsZero() {
+					break
+				}
+				cursor = next
+
+				// A page worth of new rows arrives before the next read
+				for i := 0; i < pageSize; i++ {
+					if err := subject.insert(ctx, db, time.Now().UTC()); err != nil {
+						t.Fatalf("failed to insert row: %v", err)
+					}
+				}
+			}
+
+			for id := int64(1); id <= existing; id++ {
+				if !seen[id] {
+					t.Errorf("row %d was never returned", id)
+				}
+			}
+			if len(seen) != existing {
+				t.Errorf("returned %d rows, want the %d that existed before the walk", len(seen), existing)
+			}
+		})
+	}
+}
+
+// benchmarkRows is the table size the keyset and offset benchmarks run at
+const benchmarkRows = 500_000
+
+var (
+	benchmarkOnce sync.Once
+	benchmarkDB   *gorm.DB
+	benchmarkErr  error
+)
+
+// benchmarkExecutionLogs returns a database of benchmarkRows execution logs
+// with the keyset index, seeded once for all benchmarks
+func benchmarkExecutionLogs(b *testing.B) *gorm.DB {
+	b.Helper()
+
+	benchmarkOnce.Do(func() {
+		db, err := openTestDB(&model.TaskExecutionLog{})
+		if err != nil {
+			benchmarkErr = err
+			return
+		}
+		// As created by utils.createIndexes for PostgreSQL
+		if benchmarkErr = db.Exec("CREATE INDEX idx_task_execution_logs_keyset ON task_execution_logs (started_at DESC, id DESC)").Error; benchmarkErr != nil {
+			return
+		}
+
+		base := time.Now().UTC().Add(-benchmarkRows * time.Second)
+		batch := make([]*model.TaskExecutionLog, 0, 1000)
+		benchmarkErr = db.Transaction(func(tx *gorm.DB) error {
+			for i := 0; i < benchmarkRows; i++ {
+				batch = append(batch, &model.TaskExecutionLog{
+					TaskID:    i%50 + 1,
+					Status:    model.ExecutionStatusSuccess,
+					StartedAt: base.Add(time.Duration(i) * time.Second),
+				})
+				if len(batch) == cap(batch) {
+					if err := tx.CreateInBatches(batch, 100).Error; err != nil {
+						return err
+					}
+					batch = batch[:0]
+				}
+			}
+			return nil
+		})
+		benchmarkDB = db
+	})
+	if benchmarkErr != nil {
+		b.Fatalf("failed to seed %d execution logs: %v", benchmarkRows, benchmarkErr)
+	}
+	return benchmarkDB
+}
+
+// BenchmarkExecutionLogPage reads one page of 50 at increasing depths of a
+// 500k row table, by offset and by cursor
+func BenchmarkExecutionLogPage(b *testing.B) {
+	const pageSize = 50
+	ctx := context.Background()
+	repo := NewTaskExecutionLogRepository(benchmarkExecutionLogs(b))
+
+	for _, depth := range []int{0, 10_000, 250_000, 490_000} {
+		// The cursor a client holds after reading depth rows
+		var cursor model.Cursor
+		if depth > 0 {
+			var log model.TaskExecutionLog
+			if err := benchmarkDB.Order("started_at DESC, id DESC").Offset(depth - 1).Limit(1).Take(&log).Error; err != nil {
+				b.Fatalf("failed to find the row at depth %d: %v", depth, err)
+			}
+			cursor = *model.NewCursor(log.StartedAt, int64(log.ID))
+		}
+
+		b.Run(fmt.Sprintf("offset/depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := repo.List(ctx, &model.TaskExecutionLogFilter{Limit: pageSize, Offset: depth}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("cursor/depth=%d", depth), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				page := cursor
+				if _, _, err := repo.List(ctx, &model.TaskExecutionLogFilter{Limit: pageSize, Cursor: &page}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
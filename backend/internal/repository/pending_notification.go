@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// pendingNotificationRepository implements PendingNotificationRepository interface
+type pendingNotificationRepository struct {
+	db *gorm.DB
+}
+
+// NewPendingNotificationRepository creates a new pending notification repository
+func NewPendingNotificationRepository(db *gorm.DB) PendingNotificationRepository {
+	return &pendingNotificationRepository{db: db}
+}
+
+// Create buffers a notification for a digest
+func (r *pendingNotificationRepository) Create(ctx context.Context, pending *model.PendingNotification) error {
+	if pending == nil {
+		return fmt.Errorf("pending notification cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(pending).Error; err != nil {
+		return fmt.Errorf("failed to create pending notification: %w", err)
+	}
+	return nil
+}
+
+// ListUserIDs returns the users with buffered notifications
+func (r *pendingNotificationRepository) ListUserIDs(ctx context.Context) ([]int64, error) {
+	var userIDs []int64
+	err := r.db.WithContext(ctx).
+		Model(&model.PendingNotification{}).
+		Distinct("user_id").
+		Order("user_id").
+		Pluck("user_id", &userIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list users with pending notifications: %w", err)
+	}
+	return userIDs, nil
+}
+
+// ListByUser returns a user's buffered notifications, oldest first
+func (r *pendingNotificationRepository) ListByUser(ctx context.Context, userID int64) ([]*model.PendingNotification, error) {
+	var pending []*model.PendingNotification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Order("created_at, id").
+		Find(&pending).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pending notifications: %w", err)
+	}
+	return pending, nil
+}
+
+// DeleteByIDs removes buffered notifications once their digest was sent
+func (r *pendingNotificationRepository) DeleteByIDs(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := r.db.WithContext(ctx).Where("id IN ?", ids).Delete(&model.PendingNotification{}).Error; err != nil {
+		return fmt.Errorf("failed to delete pending notifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// reportRepository implements ReportRepository interface
+type reportRepository struct {
+	db *gorm.DB
+}
+
+// NewReportRepository creates a new report repository
+func NewReportRepository(db *gorm.DB) ReportRepository {
+	return &reportRepository{db: db}
+}
+
+// CountUpdates counts the update history entries matching the filter
+func (r *reportRepository) CountUpdates(ctx context.Context, filter *model.ReportFilter) (int64, error) {
+	var count int64
+	if err := r.updatesQuery(ctx, filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count updates: %w", err)
+	}
+	return count, nil
+}
+
+// StreamUpdates reads the update history entries matching the filter one
+// row at a time
+func (r *reportRepository) StreamUpdates(ctx context.Context, filter *model.ReportFilter, limit int, fn func(*model.UpdateReportRecord) error) error {
+	query := r.updatesQuery(ctx, filter).
+		Select(`uh.id, uh.container_id, COALESCE(c.name, '') AS container_name,
+			COALESCE(uh.old_image, '') AS old_image, COALESCE(uh.old_digest, '') AS old_digest,
+			uh.new_image, COALESCE(uh.new_digest, '') AS new_digest,
+			uh.status, COALESCE(uh.error_message, '') AS error_message, uh.duration_seconds,
+			uh.triggered_by, uh.actor_type, COALESCE(uh.actor_name, '') AS actor_name,
+			COALESCE(u.username, '') AS username, COALESCE(uh.approver, '') AS approver,
+			COALESCE(uh.checkpoint->>'resolved_digest', '') AS resolved_digest,
+			uh.started_at, uh.completed_at, sr.passed AS scan_passed,
+			COALESCE((SELECT string_agg(un.author_name || ': ' || un.body, E'\n' ORDER BY un.created_at, un.id)
+				FROM update_notes un WHERE un.update_id = uh.id), '') AS notes`).
+		Joins("LEFT JOIN users u ON u.id = uh.created_by").
+		Joins("LEFT JOIN scan_results sr ON sr.image_digest = uh.new_digest AND uh.new_digest <> ''").
+		Order("uh.started_at ASC, uh.id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query updates: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record model.UpdateReportRecord
+		if err := r.db.ScanRows(rows, &record); err != nil {
+			return fmt.Errorf("failed to scan update row: %w", err)
+		}
+		if err := fn(&record); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read updates: %w", err)
+	}
+	return nil
+}
+
+// CountTaskExecutions counts the task executions matching the filter
+func (r *reportRepository) CountTaskExecutions(ctx context.Context, filter *model.ReportFilter) (int64, error) {
+	var count int64
+	if err := r.taskExecutionsQuery(ctx, filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count task executions: %w", err)
+	}
+	return count, nil
+}
+
+// StreamTaskExecutions reads the task executions matching the filter one
+// row at a time
+func (r *reportRepository) StreamTaskExecutions(ctx context.Context, filter *model.ReportFilter, limit int, fn func(*model.TaskExecutionReportRecord) error) error {
+	query := r.taskExecutionsQuery(ctx, filter).
+		Select(`tel.id, tel.task_id, COALESCE(st.name, '') AS task_name, COALESCE(st.type, '') AS task_type,
+			COALESCE(u.username, '') AS username, COALESCE(tel.triggered_by, '') AS triggered_by,
+			tel.status, COALESCE(tel.message, '') AS message, tel.duration_seconds,
+			tel.started_at, tel.completed_at`).
+		Joins("LEFT JOIN users u ON u.id = st.created_by").
+		Order("tel.started_at ASC, tel.id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query task executions: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var record model.TaskExecutionReportRecord
+		if err := r.db.ScanRows(rows, &record); err != nil {
+			return fmt.Errorf("failed to scan task execution row: %w", err)
+		}
+		if err := fn(&record); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read task executions: %w", err)
+	}
+	return nil
+}
+
+// updatesQuery selects update history in the filter's window, scoped to the
+// containers of the filter's owner
+func (r *reportRepository) updatesQuery(ctx context.Context, filter *model.ReportFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Table("update_history uh").
+		Joins("LEFT JOIN containers c ON c.id = uh.container_id").
+		Where("uh.started_at >= ? AND uh.started_at < ?", filter.From, filter.To)
+	if filter.OwnerID != nil {
+		query = query.Where("c.created_by = ?", *filter.OwnerID)
+	}
+	return query
+}
+
+// taskExecutionsQuery selects task executions in the filter's window, scoped
+// to the tasks of the filter's owner
+func (r *reportRepository) taskExecutionsQuery(ctx context.Context, filter *model.ReportFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Table("task_execution_logs tel").
+		Joins("LEFT JOIN scheduled_tasks st ON st.id = tel.task_id").
+		Where("tel.started_at >= ? AND tel.started_at < ?", filter.From, filter.To)
+	if filter.OwnerID != nil {
+		query = query.Where("st.created_by = ?", *filter.OwnerID)
+	}
+	return query
+}
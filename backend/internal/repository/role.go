@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// roleRepository implements RoleRepository interface
+type roleRepository struct {
+	db *gorm.DB
+}
+
+// NewRoleRepository creates a new role repository
+func NewRoleRepository(db *gorm.DB) RoleRepository {
+	return &roleRepository{db: db}
+}
+
+// Create creates a new role
+func (r *roleRepository) Create(ctx context.Context, role *model.Role) error {
+	if role == nil {
+		return fmt.Errorf("role cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(role).Error; err != nil {
+		return fmt.Errorf("failed to create role: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a role by ID
+func (r *roleRepository) GetByID(ctx context.Context, id int) (*model.Role, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid role ID: %d", id)
+	}
+
+	var role model.Role
+	err := r.db.WithContext(ctx).First(&role, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("role with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role by ID: %w", err)
+	}
+	return &role, nil
+}
+
+// GetByName retrieves a role by name
+func (r *roleRepository) GetByName(ctx context.Context, name model.UserRole) (*model.Role, error) {
+	var role model.Role
+	err := r.db.WithContext(ctx).Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("role with name '%s' not found", name)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role by name: %w", err)
+	}
+	return &role, nil
+}
+
+// Update updates the description and permissions of a role. Roles are
+// referenced by name, so the name is left unchanged.
+func (r *roleRepository) Update(ctx context.Context, role *model.Role) error {
+	if role == nil {
+		return fmt.Errorf("role cannot be nil")
+	}
+	if role.ID <= 0 {
+		return fmt.Errorf("invalid role ID: %d", role.ID)
+	}
+
+	err := r.db.WithContext(ctx).Model(role).
+		Select("description", "permissions", "updated_at").
+		Updates(role).Error
+	if err != nil {
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a role by ID
+func (r *roleRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.Role{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete role: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("role with ID %d not found", id)
+	}
+	return nil
+}
+
+// List returns the built-in roles followed by the custom roles, each by name
+func (r *roleRepository) List(ctx context.Context) ([]*model.Role, error) {
+	var roles []*model.Role
+	err := r.db.WithContext(ctx).Order("built_in DESC, name ASC").Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list roles: %w", err)
+	}
+	return roles, nil
+}
+
+// CountUsers returns the number of users assigned each role
+func (r *roleRepository) CountUsers(ctx context.Context) (map[model.UserRole]int64, error) {
+	var rows []struct {
+		Role  model.UserRole
+		Count int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.User{}).
+		Select("role, COUNT(*) AS count").
+		Group("role").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users by role: %w", err)
+	}
+
+	counts := make(map[model.UserRole]int64, len(rows))
+	for _, row := range rows {
+		counts[row.Role] = row.Count
+	}
+	return counts, nil
+}
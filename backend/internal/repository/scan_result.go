@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// scanResultRepository implements ScanResultRepository interface
+type scanResultRepository struct {
+	db *gorm.DB
+}
+
+// NewScanResultRepository creates a new scan result repository
+func NewScanResultRepository(db *gorm.DB) ScanResultRepository {
+	return &scanResultRepository{db: db}
+}
+
+// Upsert stores a scan result, replacing any earlier result for the same
+// digest. The image name is stored normalized, see model.NormalizeImageReference.
+func (r *scanResultRepository) Upsert(ctx context.Context, result *model.ScanResult) error {
+	if result == nil {
+		return fmt.Errorf("scan result cannot be nil")
+	}
+	if result.ImageDigest == "" {
+		return fmt.Errorf("image digest is required")
+	}
+	if normalized := model.NormalizeImageReference(result.ImageName); normalized != "" {
+		result.ImageName = normalized
+	}
+	if result.Vulnerabilities == "" {
+		result.Vulnerabilities = "[]"
+	}
+
+	err := r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "image_digest"}},
+			DoUpdates: clause.AssignmentColumns([]string{
+				"image_name", "scanned_at", "vulnerabilities", "total_vulns",
+				"critical_vulns", "high_vulns", "medium_vulns", "low_vulns",
+				"passed", "scanner_version", "updated_at",
+			}),
+		}).
+		Create(result).Error
+	if err != nil {
+		return fmt.Errorf("failed to upsert scan result: %w", err)
+	}
+
+	return nil
+}
+
+// GetByDigest retrieves the scan result for an image digest
+func (r *scanResultRepository) GetByDigest(ctx context.Context, digest string) (*model.ScanResult, error) {
+	if digest == "" {
+		return nil, fmt.Errorf("image digest cannot be empty")
+	}
+
+	var result model.ScanResult
+	err := r.db.WithContext(ctx).
+		Where("image_digest = ?", digest).
+		First(&result).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.Newf(apperrors.CodeNotFound, "scan result for digest '%s' not found", digest)
+		}
+		return nil, fmt.Errorf("failed to get scan result by digest: %w", err)
+	}
+
+	return &result, nil
+}
+
+// GetLatestByImage retrieves the most recent scan result for an image
+// reference in any of its forms. Results stored before names were normalized
+// are found by the reference as given.
+func (r *scanResultRepository) GetLatestByImage(ctx context.Context, imageName string) (*model.ScanResult, error) {
+	if imageName == "" {
+		return nil, fmt.Errorf("image name cannot be empty")
+	}
+
+	var result model.ScanResult
+	err := r.db.WithContext(ctx).
+		Where("image_name IN ?", []string{model.NormalizeImageReference(imageName), imageName}).
+		Order("scanned_at DESC").
+		First(&result).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, apperrors.Newf(apperrors.CodeNotFound, "scan result for image '%s' not found", imageName)
+		}
+		return nil, fmt.Errorf("failed to get latest scan result: %w", err)
+	}
+
+	return &result, nil
+}
+
+// DeleteOlderThan deletes scan results older than the cutoff date, except those
+// for the kept digests
+func (r *scanResultRepository) DeleteOlderThan(ctx context.Context, cutoffDate time.Time, keepDigests []string) (int64, error) {
+	result := r.olderThan(ctx, cutoffDate, keepDigests).Delete(&model.ScanResult{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old scan results: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
+
+// CountOlderThan counts the scan results DeleteOlderThan would delete
+func (r *scanResultRepository) CountOlderThan(ctx context.Context, cutoffDate time.Time, keepDigests []string) (int64, error) {
+	var count int64
+	if err := r.olderThan(ctx, cutoffDate, keepDigests).Model(&model.ScanResult{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count old scan results: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *scanResultRepository) olderThan(ctx context.Context, cutoffDate time.Time, keepDigests []string) *gorm.DB {
+	query := r.db.WithContext(ctx).Where("scanned_at < ?", cutoffDate)
+	if len(keepDigests) > 0 {
+		query = query.Where("image_digest NOT IN ?", keepDigests)
+	}
+	return query
+}
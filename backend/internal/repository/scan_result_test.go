@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+)
+
+func newScanResultTestRepo(t *testing.T) ScanResultRepository {
+	t.Helper()
+
+	db := newTestDB(t, &model.ScanResult{})
+	return NewScanResultRepository(db)
+}
+
+func TestScanResultLatestByImageMatchesEveryReferenceForm(t *testing.T) {
+	ctx := context.Background()
+	repo := newScanResultTestRepo(t)
+	now := time.Now().UTC()
+
+	older := &model.ScanResult{ImageDigest: "sha256:old", ImageName: "nginx", ScannedAt: now.Add(-time.Hour), ScannerVersion: "1"}
+	newer := &model.ScanResult{ImageDigest: "sha256:new", ImageName: "docker.io/library/nginx:latest", ScannedAt: now, ScannerVersion: "1"}
+	other := &model.ScanResult{ImageDigest: "sha256:other", ImageName: "nginx:1.25", ScannedAt: now.Add(time.Minute), ScannerVersion: "1"}
+	for _, result := range []*model.ScanResult{older, newer, other} {
+		if err := repo.Upsert(ctx, result); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+	if newer.ImageName != "library/nginx:latest" {
+		t.Errorf("stored image name = %q, want library/nginx:latest", newer.ImageName)
+	}
+
+	for _, ref := range []string{"nginx", "nginx:latest", "docker.io/library/nginx:latest", "library/nginx"} {
+		result, err := repo.GetLatestByImage(ctx, ref)
+		if err != nil {
+			t.Fatalf("GetLatestByImage(%q) failed: %v", ref, err)
+		}
+		if result.ImageDigest != "sha256:new" {
+			t.Errorf("GetLatestByImage(%q) = %s, want sha256:new", ref, result.ImageDigest)
+		}
+	}
+
+	if _, err := repo.GetLatestByImage(ctx, "redis"); !apperrors.HasCode(err, apperrors.CodeNotFound) {
+		t.Errorf("GetLatestByImage(redis) error = %v, want not found", err)
+	}
+}
+
+func TestScanResultLatestByImageFindsLegacyNames(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &model.ScanResult{})
+	repo := NewScanResultRepository(db)
+
+	// Stored by an earlier version, under the name as it was scanned
+	legacy := &model.ScanResult{ImageDigest: "sha256:legacy", ImageName: "nginx:latest", ScannedAt: time.Now().UTC(), Vulnerabilities: "[]"}
+	if err := db.Create(legacy).Error; err != nil {
+		t.Fatalf("failed to seed legacy result: %v", err)
+	}
+
+	result, err := repo.GetLatestByImage(ctx, "nginx:latest")
+	if err != nil {
+		t.Fatalf("GetLatestByImage failed: %v", err)
+	}
+	if result.ImageDigest != "sha256:legacy" {
+		t.Errorf("GetLatestByImage = %s, want sha256:legacy", result.ImageDigest)
+	}
+}
+
+func TestScanResultPruneKeepsDeployedDigests(t *testing.T) {
+	ctx := context.Background()
+	repo := newScanResultTestRepo(t)
+	now := time.Now().UTC()
+
+	for digest, age := range map[string]time.Duration{
+		"sha256:deployed": 90 * 24 * time.Hour,
+		"sha256:stale":    90 * 24 * time.Hour,
+		"sha256:recent":   time.Hour,
+	} {
+		if err := repo.Upsert(ctx, &model.ScanResult{ImageDigest: digest, ImageName: "nginx", ScannedAt: now.Add(-age)}); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+
+	cutoff := now.AddDate(0, 0, -30)
+	keep := []string{"sha256:deployed"}
+	count, err := repo.CountOlderThan(ctx, cutoff, keep)
+	if err != nil {
+		t.Fatalf("CountOlderThan failed: %v", err)
+	}
+	deleted, err := repo.DeleteOlderThan(ctx, cutoff, keep)
+	if err != nil {
+		t.Fatalf("DeleteOlderThan failed: %v", err)
+	}
+	if count != 1 || deleted != 1 {
+		t.Errorf("counted %d and deleted %d, want 1 and 1", count, deleted)
+	}
+
+	for digest, wantKept := range map[string]bool{"sha256:deployed": true, "sha256:stale": false, "sha256:recent": true} {
+		_, err := repo.GetByDigest(ctx, digest)
+		if kept := err == nil; kept != wantKept {
+			t.Errorf("%s kept = %v, want %v (err %v)", digest, kept, wantKept, err)
+		}
+	}
+}
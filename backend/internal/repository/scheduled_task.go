@@ -0,0 +1,384 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// scheduledTaskRepository implements ScheduledTaskRepository interface
+type scheduledTaskRepository struct {
+	db *gorm.DB
+}
+
+// NewScheduledTaskRepository creates a new scheduled task repository
+func NewScheduledTaskRepository(db *gorm.DB) ScheduledTaskRepository {
+	return &scheduledTaskRepository{db: db}
+}
+
+// Create creates a new scheduled task
+func (r *scheduledTaskRepository) Create(ctx context.Context, task *model.ScheduledTask) error {
+	if task == nil {
+		return fmt.Errorf("task cannot be nil")
+	}
+
+	if err := r.db.WithContext(ctx).Create(task).Error; err != nil {
+		return fmt.Errorf("failed to create scheduled task: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a scheduled task by ID
+func (r *scheduledTaskRepository) GetByID(ctx context.Context, id int64) (*model.ScheduledTask, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid task ID: %d", id)
+	}
+
+	var task model.ScheduledTask
+	err := r.db.WithContext(ctx).First(&task, id).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, apperrors.Newf(apperrors.CodeTaskNotFound, "scheduled task with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get scheduled task by ID: %w", err)
+	}
+
+	return &task, nil
+}
+
+// Update writes the task definition with an optimistic version check. The
+// columns are updated directly, so the model's update hook does not move
+// next_run_at behind the caller's back.
+func (r *scheduledTaskRepository) Update(ctx context.Context, task *model.ScheduledTask) error {
+	if task == nil {
+		return fmt.Errorf("task cannot be nil")
+	}
+	if task.ID <= 0 {
+		return fmt.Errorf("invalid task ID: %d", task.ID)
+	}
+	if err := task.ValidateSchedule(); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+
+	now := time.Now()
+	result := r.db.WithContext(ctx).Model(&model.ScheduledTask{}).
+		Where("id = ? AND version = ?", task.ID, task.Version).
+		UpdateColumns(map[string]interface{}{
+			"name":              task.Name,
+			"type":              task.Type,
+			"schedule_type":     task.ScheduleType,
+			"cron_expression":   task.CronExpression,
+			"run_at":            task.RunAt,
+			"completed_at":      task.CompletedAt,
+			"target_containers": task.TargetContainers,
+			"parameters":        task.Parameters,
+			"is_active":         task.IsActive,
+			"next_run_at":       task.NextRunAt,
+			"version":           gorm.Expr("version + 1"),
+			"updated_at":        now,
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update scheduled task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return r.missingOrConflict(ctx, int64(task.ID))
+	}
+
+	task.Version++
+	task.UpdatedAt = now
+	return nil
+}
+
+// Delete deletes a scheduled task
+func (r *scheduledTaskRepository) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid task ID: %d", id)
+	}
+
+	result := r.db.WithContext(ctx).Delete(&model.ScheduledTask{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete scheduled task: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.Newf(apperrors.CodeTaskNotFound, "scheduled task with ID %d not found", id)
+	}
+	return nil
+}
+
+// List retrieves scheduled tasks with filtering and pagination
+func (r *scheduledTaskRepository) List(ctx context.Context, filter *model.ScheduledTaskFilter) ([]*model.ScheduledTask, int64, error) {
+	var tasks []*model.ScheduledTask
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.ScheduledTask{})
+
+	if filter != nil {
+		if filter.Name != "" {
+			query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
+		}
+		if filter.Type != "" {
+			query = query.Where("type = ?", filter.Type)
+		}
+		if filter.IsActive != nil {
+			query = query.Where("is_active = ?", *filter.IsActive)
+		}
+		if filter.CreatedBy != nil {
+			query = query.Where("created_by = ?", *filter.CreatedBy)
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count scheduled tasks: %w", err)
+	}
+
+	orderBy := "created_at DESC"
+	if filter != nil && filter.OrderBy != "" {
+		orderBy = filter.OrderBy
+	}
+	query = query.Order(orderBy)
+
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	if err := query.Find(&tasks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list scheduled tasks: %w", err)
+	}
+
+	return tasks, total, nil
+}
+
+// GetByType retrieves scheduled tasks of a type
+func (r *scheduledTaskRepository) GetByType(ctx context.Context, taskType model.TaskType) ([]*model.ScheduledTask, error) {
+	var tasks []*model.ScheduledTask
+	if err := r.db.WithContext(ctx).Where("type = ?", taskType).Order("name").Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get scheduled tasks by type: %w", err)
+	}
+	return tasks, nil
+}
+
+// GetByStatus retrieves the tasks whose latest execution has the status
+func (r *scheduledTaskRepository) GetByStatus(ctx context.Context, status model.TaskStatus) ([]*model.ScheduledTask, error) {
+	latest := r.db.Model(&model.TaskExecutionLog{}).
+		Select("DISTINCT ON (task_id) task_id, status").
+		Order("task_id, started_at DESC")
+
+	var tasks []*model.ScheduledTask
+	err := r.db.WithContext(ctx).
+		Where("id IN (?)", r.db.Table("(?) AS latest", latest).Select("task_id").Where("status = ?", status)).
+		Order("name").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get scheduled tasks by status: %w", err)
+	}
+	return tasks, nil
+}
+
+// GetDueTasks retrieves active tasks whose next run has come
+func (r *scheduledTaskRepository) GetDueTasks(ctx context.Context) ([]*model.ScheduledTask, error) {
+	var tasks []*model.ScheduledTask
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND (next_run_at IS NULL OR next_run_at <= ?)", true, time.Now()).
+		Order("next_run_at").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due scheduled tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// UpdateStatus is not supported: a task's status is that of its latest
+// execution log
+func (r *scheduledTaskRepository) UpdateStatus(ctx context.Context, id int64, status model.TaskStatus) error {
+	return fmt.Errorf("scheduled tasks have no stored status; record an execution log instead")
+}
+
+// UpdateLastRun sets the last run time to now
+func (r *scheduledTaskRepository) UpdateLastRun(ctx context.Context, id int64) error {
+	result := r.db.WithContext(ctx).Model(&model.ScheduledTask{}).
+		Where("id = ?", id).
+		UpdateColumn("last_run_at", time.Now())
+	if result.Error != nil {
+		return fmt.Errorf("failed to update last run: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.Newf(apperrors.CodeTaskNotFound, "scheduled task with ID %d not found", id)
+	}
+	return nil
+}
+
+// UpdateNextRun moves the next run to the schedule's next time after now
+func (r *scheduledTaskRepository) UpdateNextRun(ctx context.Context, id int64) error {
+	task, err := r.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	nextRun, err := task.NextRunAfter(time.Now())
+	if err != nil {
+		return err
+	}
+
+	if err := r.advanceNextRun(r.db.WithContext(ctx), id, nextRun).Error; err != nil {
+		return fmt.Errorf("failed to update next run: %w", err)
+	}
+	return nil
+}
+
+// SetEnabled activates or deactivates a task
+func (r *scheduledTaskRepository) SetEnabled(ctx context.Context, id int64, enabled bool) error {
+	result := r.db.WithContext(ctx).Model(&model.ScheduledTask{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"is_active":  enabled,
+			"version":    gorm.Expr("version + 1"),
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to set task enabled: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.Newf(apperrors.CodeTaskNotFound, "scheduled task with ID %d not found", id)
+	}
+	return nil
+}
+
+// RecordRun counts a finished run with a single UPDATE, so it neither loses
+// increments to concurrent runs nor overwrites a concurrent edit of the task
+// definition. A new next run bumps the version, making writers holding the
+// old one re-read.
+func (r *scheduledTaskRepository) RecordRun(ctx context.Context, id int64, ranAt time.Time, failed bool, nextRunAt *time.Time) error {
+	failures := gorm.Expr("failure_count")
+	if failed {
+		failures = gorm.Expr("failure_count + 1")
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&model.ScheduledTask{}).
+			Where("id = ?", id).
+			UpdateColumns(map[string]interface{}{
+				"run_count":     gorm.Expr("run_count + 1"),
+				"failure_count": failures,
+				"last_run_at":   latestRunAt(ranAt),
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to record task run: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return apperrors.Newf(apperrors.CodeTaskNotFound, "scheduled task with ID %d not found", id)
+		}
+
+		if nextRunAt != nil {
+			if err := r.advanceNextRun(tx, id, *nextRunAt).Error; err != nil {
+				return fmt.Errorf("failed to update next run: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// CompleteRun counts the run of a one-shot task and completes it in a single
+// UPDATE. The version is bumped so an edit racing the run re-reads the task.
+func (r *scheduledTaskRepository) CompleteRun(ctx context.Context, id int64, ranAt time.Time, failed bool) error {
+	failures := gorm.Expr("failure_count")
+	if failed {
+		failures = gorm.Expr("failure_count + 1")
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.ScheduledTask{}).
+		Where("id = ?", id).
+		UpdateColumns(map[string]interface{}{
+			"run_count":     gorm.Expr("run_count + 1"),
+			"failure_count": failures,
+			"last_run_at":   latestRunAt(ranAt),
+			"is_active":     false,
+			"completed_at":  time.Now(),
+			"next_run_at":   nil,
+			"version":       gorm.Expr("version + 1"),
+			"updated_at":    time.Now(),
+		})
+	if result.Error != nil {
+		return fmt.Errorf("failed to complete task run: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return apperrors.Newf(apperrors.CodeTaskNotFound, "scheduled task with ID %d not found", id)
+	}
+	return nil
+}
+
+// GetActiveTasks retrieves all active tasks
+func (r *scheduledTaskRepository) GetActiveTasks(ctx context.Context) ([]*model.ScheduledTask, error) {
+	var tasks []*model.ScheduledTask
+	if err := r.db.WithContext(ctx).Where("is_active = ?", true).Order("name").Find(&tasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to get active scheduled tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// GetOverdueTasks retrieves active tasks whose next run has passed
+func (r *scheduledTaskRepository) GetOverdueTasks(ctx context.Context) ([]*model.ScheduledTask, error) {
+	var tasks []*model.ScheduledTask
+	err := r.db.WithContext(ctx).
+		Where("is_active = ? AND next_run_at < ?", true, time.Now()).
+		Order("next_run_at").
+		Find(&tasks).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get overdue scheduled tasks: %w", err)
+	}
+	return tasks, nil
+}
+
+// CreateBatch creates multiple scheduled tasks
+func (r *scheduledTaskRepository) CreateBatch(ctx context.Context, tasks []*model.ScheduledTask) error {
+	if len(tasks) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).Create(&tasks).Error; err != nil {
+		return fmt.Errorf("failed to create scheduled tasks: %w", err)
+	}
+	return nil
+}
+
+// advanceNextRun sets next_run_at when it moves the next run forward and bumps
+// the version; an earlier time, as computed by a run that finished late, is
+// ignored
+func (r *scheduledTaskRepository) advanceNextRun(db *gorm.DB, id int64, nextRun time.Time) *gorm.DB {
+	return db.Model(&model.ScheduledTask{}).
+		Where("id = ? AND (next_run_at IS NULL OR next_run_at < ?)", id, nextRun).
+		UpdateColumns(map[string]interface{}{
+			"next_run_at": nextRun,
+			"version":     gorm.Expr("version + 1"),
+		})
+}
+
+// latestRunAt keeps last_run_at at the latest start of a run, so a run that
+// finishes after a later one doesn't move it back
+func latestRunAt(ranAt time.Time) clause.Expr {
+	return gorm.Expr("CASE WHEN last_run_at IS NULL OR last_run_at < ? THEN ? ELSE last_run_at END", ranAt, ranAt)
+}
+
+// missingOrConflict tells a version conflict from a missing task after an
+// update matched no rows
+func (r *scheduledTaskRepository) missingOrConflict(ctx context.Context, id int64) error {
+	var count int64
+	if err := r.db.WithContext(ctx).Model(&model.ScheduledTask{}).Where("id = ?", id).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check scheduled task: %w", err)
+	}
+	if count == 0 {
+		return apperrors.Newf(apperrors.CodeTaskNotFound, "scheduled task with ID %d not found", id)
+	}
+	return model.ErrTaskVersionConflict
+}
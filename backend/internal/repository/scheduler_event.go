@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// schedulerEventRepository implements SchedulerEventRepository interface
+type schedulerEventRepository struct {
+	db *gorm.DB
+}
+
+// NewSchedulerEventRepository creates a new scheduler event repository
+func NewSchedulerEventRepository(db *gorm.DB) SchedulerEventRepository {
+	return &schedulerEventRepository{db: db}
+}
+
+// Create records a scheduler event
+func (r *schedulerEventRepository) Create(ctx context.Context, event *model.SchedulerEventLog) error {
+	if event == nil {
+		return fmt.Errorf("scheduler event cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(event).Error; err != nil {
+		return fmt.Errorf("failed to create scheduler event: %w", err)
+	}
+	return nil
+}
+
+// List returns scheduler events matching the filter, newest first
+func (r *schedulerEventRepository) List(ctx context.Context, filter *model.SchedulerEventFilter) ([]*model.SchedulerEventLog, int64, error) {
+	query := r.db.WithContext(ctx).Model(&model.SchedulerEventLog{})
+
+	if filter != nil {
+		if filter.TaskID != nil {
+			query = query.Where("task_id = ?", *filter.TaskID)
+		}
+		if len(filter.Types) > 0 {
+			query = query.Where("type IN ?", filter.Types)
+		}
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count scheduler events: %w", err)
+	}
+
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	var events []*model.SchedulerEventLog
+	if err := query.Order("id DESC").Find(&events).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list scheduler events: %w", err)
+	}
+	return events, total, nil
+}
+
+// Prune deletes all but the newest keep events
+func (r *schedulerEventRepository) Prune(ctx context.Context, keep int) (int64, error) {
+	if keep <= 0 {
+		return 0, fmt.Errorf("invalid number of events to keep: %d", keep)
+	}
+
+	result := r.db.WithContext(ctx).Exec(
+		"DELETE FROM scheduler_events WHERE id <= (SELECT id FROM scheduler_events ORDER BY id DESC OFFSET ? LIMIT 1)",
+		keep,
+	)
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to prune scheduler events: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// secretRepository implements SecretRepository interface
+type secretRepository struct {
+	db *gorm.DB
+}
+
+// NewSecretRepository creates a new secret column repository
+func NewSecretRepository(db *gorm.DB) SecretRepository {
+	return &secretRepository{db: db}
+}
+
+// ListValues returns a page of stored values of a secret column
+func (r *secretRepository) ListValues(ctx context.Context, column model.SecretColumn, afterID, limit int) ([]model.SecretValue, error) {
+	query := r.db.WithContext(ctx).
+		Table(column.Table).
+		Select("id, "+column.Column+"::text AS value").
+		Where("id > ?", afterID).
+		Where(column.Column + " IS NOT NULL")
+	if column.Where != "" {
+		query = query.Where(column.Where, column.WhereArgs...)
+	}
+
+	var values []model.SecretValue
+	if err := query.Order("id").Limit(limit).Scan(&values).Error; err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", column.Name(), err)
+	}
+
+	return values, nil
+}
+
+// ReplaceValue rewrites one value, guarded by the value it replaces so a
+// concurrent write is never overwritten
+func (r *secretRepository) ReplaceValue(ctx context.Context, column model.SecretColumn, id int, current, replacement string) (bool, error) {
+	updates := map[string]interface{}{column.Column: replacement}
+	if column.EncryptedFlag != "" {
+		updates[column.EncryptedFlag] = true
+	}
+
+	result := r.db.WithContext(ctx).
+		Table(column.Table).
+		Where("id = ?", id).
+		Where(column.Column+"::text = ?", current).
+		Updates(updates)
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update %s for ID %d: %w", column.Name(), id, result.Error)
+	}
+
+	return result.RowsAffected == 1, nil
+}
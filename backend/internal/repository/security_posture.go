@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// securityPostureRepository implements SecurityPostureRepository interface
+type securityPostureRepository struct {
+	db *gorm.DB
+}
+
+// NewSecurityPostureRepository creates a new security posture repository
+func NewSecurityPostureRepository(db *gorm.DB) SecurityPostureRepository {
+	return &securityPostureRepository{db: db}
+}
+
+// Create stores a posture report
+func (r *securityPostureRepository) Create(ctx context.Context, report *model.SecurityPostureReport) error {
+	if report == nil {
+		return fmt.Errorf("security posture report cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(report).Error; err != nil {
+		return fmt.Errorf("failed to create security posture report: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a posture report
+func (r *securityPostureRepository) GetByID(ctx context.Context, id int) (*model.SecurityPostureReport, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid security posture report ID: %d", id)
+	}
+
+	var report model.SecurityPostureReport
+	err := r.db.WithContext(ctx).First(&report, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("security posture report with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get security posture report: %w", err)
+	}
+	return &report, nil
+}
+
+// GetLatest returns the most recent report, nil if there is none
+func (r *securityPostureRepository) GetLatest(ctx context.Context) (*model.SecurityPostureReport, error) {
+	return r.first(r.db.WithContext(ctx))
+}
+
+// GetAtOrBefore returns the most recent report evaluated at or before t
+func (r *securityPostureRepository) GetAtOrBefore(ctx context.Context, t time.Time) (*model.SecurityPostureReport, error) {
+	return r.first(r.db.WithContext(ctx).Where("evaluated_at <= ?", t))
+}
+
+func (r *securityPostureRepository) first(query *gorm.DB) (*model.SecurityPostureReport, error) {
+	var report model.SecurityPostureReport
+	err := query.Order("evaluated_at DESC, id DESC").First(&report).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get security posture report: %w", err)
+	}
+	return &report, nil
+}
@@ -0,0 +1,278 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// stackRepository implements StackRepository interface
+type stackRepository struct {
+	db *gorm.DB
+}
+
+// NewStackRepository creates a new stack repository
+func NewStackRepository(db *gorm.DB) StackRepository {
+	return &stackRepository{db: db}
+}
+
+// Create creates a new stack
+func (r *stackRepository) Create(ctx context.Context, stack *model.Stack) error {
+	return createStack(r.db.WithContext(ctx), stack)
+}
+
+// CreateWithMembers creates a stack and sets its members in one transaction,
+// so a failed membership change leaves no stack behind
+func (r *stackRepository) CreateWithMembers(ctx context.Context, stack *model.Stack, containerIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := createStack(tx, stack); err != nil {
+			return err
+		}
+		return setStackMembers(ctx, tx, int64(stack.ID), containerIDs)
+	})
+}
+
+func createStack(db *gorm.DB, stack *model.Stack) error {
+	if stack == nil {
+		return fmt.Errorf("stack cannot be nil")
+	}
+	if stack.Name == "" {
+		return fmt.Errorf("stack name is required")
+	}
+
+	var count int64
+	if err := db.Model(&model.Stack{}).Where("name = ?", stack.Name).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check stack existence: %w", err)
+	}
+	if count > 0 {
+		return fmt.Errorf("stack with name '%s' already exists", stack.Name)
+	}
+
+	if err := db.Omit("Members").Create(stack).Error; err != nil {
+		return fmt.Errorf("failed to create stack: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a stack and its members by ID
+func (r *stackRepository) GetByID(ctx context.Context, id int64) (*model.Stack, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid stack ID: %d", id)
+	}
+
+	var stack model.Stack
+	err := r.db.WithContext(ctx).
+		Preload("Members").
+		First(&stack, id).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("stack with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get stack by ID: %w", err)
+	}
+
+	return &stack, nil
+}
+
+// Update updates an existing stack; membership is changed through SetMembers
+func (r *stackRepository) Update(ctx context.Context, stack *model.Stack) error {
+	return updateStack(r.db.WithContext(ctx), stack)
+}
+
+// UpdateWithMembers updates a stack and replaces its members in one
+// transaction
+func (r *stackRepository) UpdateWithMembers(ctx context.Context, stack *model.Stack, containerIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := updateStack(tx, stack); err != nil {
+			return err
+		}
+		return setStackMembers(ctx, tx, int64(stack.ID), containerIDs)
+	})
+}
+
+func updateStack(db *gorm.DB, stack *model.Stack) error {
+	if stack == nil {
+		return fmt.Errorf("stack cannot be nil")
+	}
+	if stack.ID <= 0 {
+		return fmt.Errorf("invalid stack ID: %d", stack.ID)
+	}
+
+	result := db.Omit("Members").Save(stack)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update stack: %w", result.Error)
+	}
+
+	return nil
+}
+
+// Delete deletes a stack by ID, releasing its members
+func (r *stackRepository) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid stack ID: %d", id)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if _, err := updateContainersTracked(ctx, tx, map[string]interface{}{"stack_id": nil, "stack_order": 0}, "stack_id = ?", id); err != nil {
+			return fmt.Errorf("failed to release stack members: %w", err)
+		}
+
+		result := tx.Delete(&model.Stack{}, id)
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete stack: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("stack with ID %d not found", id)
+		}
+
+		return nil
+	})
+}
+
+// List retrieves stacks and their members with filtering and pagination
+func (r *stackRepository) List(ctx context.Context, filter *model.StackFilter) ([]*model.Stack, int64, error) {
+	var stacks []*model.Stack
+	var total int64
+
+	query := r.db.WithContext(ctx).Model(&model.Stack{})
+
+	if filter != nil {
+		if filter.Name != "" {
+			query = query.Where("name ILIKE ?", "%"+filter.Name+"%")
+		}
+		if filter.CreatedBy != nil {
+			query = query.Where("created_by = ?", *filter.CreatedBy)
+		}
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count stacks: %w", err)
+	}
+
+	orderBy := "name ASC"
+	if filter != nil && filter.OrderBy != "" {
+		orderBy = filter.OrderBy
+	}
+	query = query.Order(orderBy)
+
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	if err := query.Preload("Members").Find(&stacks).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	return stacks, total, nil
+}
+
+// GetByName retrieves a stack by name
+func (r *stackRepository) GetByName(ctx context.Context, name string) (*model.Stack, error) {
+	if name == "" {
+		return nil, fmt.Errorf("stack name cannot be empty")
+	}
+
+	var stack model.Stack
+	err := r.db.WithContext(ctx).
+		Preload("Members").
+		Where("name = ?", name).
+		First(&stack).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("stack with name '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to get stack by name: %w", err)
+	}
+
+	return &stack, nil
+}
+
+// GetByComposeProject retrieves the stack created for a compose project
+func (r *stackRepository) GetByComposeProject(ctx context.Context, project string) (*model.Stack, error) {
+	if project == "" {
+		return nil, fmt.Errorf("compose project cannot be empty")
+	}
+
+	var stack model.Stack
+	err := r.db.WithContext(ctx).
+		Preload("Members").
+		Where("compose_project = ?", project).
+		First(&stack).Error
+
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("stack for compose project '%s' not found", project)
+		}
+		return nil, fmt.Errorf("failed to get stack by compose project: %w", err)
+	}
+
+	return &stack, nil
+}
+
+// SetMembers replaces the members of a stack. Containers join in the given
+// order, which becomes their start order.
+func (r *stackRepository) SetMembers(ctx context.Context, stackID int64, containerIDs []int64) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return setStackMembers(ctx, tx, stackID, containerIDs)
+	})
+}
+
+func setStackMembers(ctx context.Context, tx *gorm.DB, stackID int64, containerIDs []int64) error {
+	if stackID <= 0 {
+		return fmt.Errorf("invalid stack ID: %d", stackID)
+	}
+
+	if _, err := updateContainersTracked(ctx, tx, map[string]interface{}{"stack_id": nil, "stack_order": 0}, "stack_id = ?", stackID); err != nil {
+		return fmt.Errorf("failed to release stack members: %w", err)
+	}
+
+	for i, containerID := range containerIDs {
+		matched, err := updateContainersTracked(ctx, tx, map[string]interface{}{"stack_id": stackID, "stack_order": i}, "id = ?", containerID)
+		if err != nil {
+			return fmt.Errorf("failed to add container %d to stack: %w", containerID, err)
+		}
+		if matched == 0 {
+			return fmt.Errorf("container with ID %d not found", containerID)
+		}
+	}
+
+	return nil
+}
+
+// AddMember appends a container to the end of a stack's start order
+func (r *stackRepository) AddMember(ctx context.Context, stackID int64, containerID int64) error {
+	if stackID <= 0 {
+		return fmt.Errorf("invalid stack ID: %d", stackID)
+	}
+
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var next int64
+		if err := tx.Model(&model.Container{}).
+			Where("stack_id = ?", stackID).
+			Select("COALESCE(MAX(stack_order) + 1, 0)").
+			Scan(&next).Error; err != nil {
+			return fmt.Errorf("failed to get stack order: %w", err)
+		}
+
+		matched, err := updateContainersTracked(ctx, tx, map[string]interface{}{"stack_id": stackID, "stack_order": next}, "id = ?", containerID)
+		if err != nil {
+			return fmt.Errorf("failed to add container to stack: %w", err)
+		}
+		if matched == 0 {
+			return fmt.Errorf("container with ID %d not found", containerID)
+		}
+
+		return nil
+	})
+}
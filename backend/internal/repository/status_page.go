@@ -0,0 +1,102 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// statusPageRepository implements StatusPageRepository interface
+type statusPageRepository struct {
+	db *gorm.DB
+}
+
+// NewStatusPageRepository creates a new status page repository
+func NewStatusPageRepository(db *gorm.DB) StatusPageRepository {
+	return &statusPageRepository{db: db}
+}
+
+// Create creates a new status page
+func (r *statusPageRepository) Create(ctx context.Context, page *model.StatusPage) error {
+	if page == nil {
+		return fmt.Errorf("status page cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(page).Error; err != nil {
+		return fmt.Errorf("failed to create status page: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a status page by ID
+func (r *statusPageRepository) GetByID(ctx context.Context, id int) (*model.StatusPage, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid status page ID: %d", id)
+	}
+
+	var page model.StatusPage
+	err := r.db.WithContext(ctx).First(&page, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("status page with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status page by ID: %w", err)
+	}
+	return &page, nil
+}
+
+// GetByToken retrieves a status page by its public token, nil when no page
+// has the token
+func (r *statusPageRepository) GetByToken(ctx context.Context, token string) (*model.StatusPage, error) {
+	if token == "" {
+		return nil, nil
+	}
+
+	var page model.StatusPage
+	err := r.db.WithContext(ctx).Where("token = ?", token).First(&page).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get status page by token: %w", err)
+	}
+	return &page, nil
+}
+
+// Update updates an existing status page
+func (r *statusPageRepository) Update(ctx context.Context, page *model.StatusPage) error {
+	if page == nil {
+		return fmt.Errorf("status page cannot be nil")
+	}
+	if page.ID <= 0 {
+		return fmt.Errorf("invalid status page ID: %d", page.ID)
+	}
+	if err := r.db.WithContext(ctx).Save(page).Error; err != nil {
+		return fmt.Errorf("failed to update status page: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a status page by ID
+func (r *statusPageRepository) Delete(ctx context.Context, id int) error {
+	result := r.db.WithContext(ctx).Delete(&model.StatusPage{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete status page: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("status page with ID %d not found", id)
+	}
+	return nil
+}
+
+// List returns every status page ordered by title
+func (r *statusPageRepository) List(ctx context.Context) ([]*model.StatusPage, error) {
+	var pages []*model.StatusPage
+	if err := r.db.WithContext(ctx).Order("title ASC, id ASC").Find(&pages).Error; err != nil {
+		return nil, fmt.Errorf("failed to list status pages: %w", err)
+	}
+	return pages, nil
+}
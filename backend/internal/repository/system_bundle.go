@@ -0,0 +1,116 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// systemBundleRepository implements SystemBundleRepository interface
+type systemBundleRepository struct {
+	db *gorm.DB
+}
+
+// NewSystemBundleRepository creates a new system bundle repository
+func NewSystemBundleRepository(db *gorm.DB) SystemBundleRepository {
+	return &systemBundleRepository{db: db}
+}
+
+// Snapshot reads the exportable configuration, each kind ordered by name
+func (r *systemBundleRepository) Snapshot(ctx context.Context) (*model.SystemSnapshot, error) {
+	db := r.db.WithContext(ctx)
+	snapshot := &model.SystemSnapshot{}
+
+	if err := db.Order("name ASC").Find(&snapshot.Containers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	if err := db.Order("name ASC").Find(&snapshot.ScheduledTasks).Error; err != nil {
+		return nil, fmt.Errorf("failed to list scheduled tasks: %w", err)
+	}
+	if err := db.Order("name ASC").Find(&snapshot.RegistryCredentials).Error; err != nil {
+		return nil, fmt.Errorf("failed to list registry credentials: %w", err)
+	}
+	if err := db.Where("user_id IS NULL").Order("name ASC, id ASC").Find(&snapshot.NotificationChannels).Error; err != nil {
+		return nil, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// Restore creates everything in the snapshot in one transaction, so either
+// all of it is created or none of it. Containers are created first and the
+// targets of scheduled tasks resolved from TaskTargets by container name.
+func (r *systemBundleRepository) Restore(ctx context.Context, snapshot *model.SystemSnapshot) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, container := range snapshot.Containers {
+			if err := tx.Create(container).Error; err != nil {
+				return fmt.Errorf("failed to create container %s: %w", container.Name, err)
+			}
+		}
+
+		for _, credentials := range snapshot.RegistryCredentials {
+			// is_active defaults to true, so a false value is not inserted
+			active := credentials.IsActive
+			if err := tx.Create(credentials).Error; err != nil {
+				return fmt.Errorf("failed to create registry credentials %s: %w", credentials.Name, err)
+			}
+			if !active {
+				credentials.IsActive = false
+				if err := tx.Model(credentials).UpdateColumn("is_active", false).Error; err != nil {
+					return fmt.Errorf("failed to create registry credentials %s: %w", credentials.Name, err)
+				}
+			}
+		}
+
+		for _, channel := range snapshot.NotificationChannels {
+			if err := tx.Create(channel).Error; err != nil {
+				return fmt.Errorf("failed to create notification channel %s: %w", channel.Name, err)
+			}
+		}
+
+		if len(snapshot.ScheduledTasks) == 0 {
+			return nil
+		}
+
+		var containers []*model.Container
+		if err := tx.Select("id", "name").Find(&containers).Error; err != nil {
+			return fmt.Errorf("failed to list containers: %w", err)
+		}
+		containerIDs := make(map[string]int64, len(containers))
+		for _, container := range containers {
+			containerIDs[container.Name] = int64(container.ID)
+		}
+
+		for _, task := range snapshot.ScheduledTasks {
+			targets := make([]int64, 0, len(snapshot.TaskTargets[task.Name]))
+			for _, name := range snapshot.TaskTargets[task.Name] {
+				id, ok := containerIDs[name]
+				if !ok {
+					return fmt.Errorf("scheduled task %s targets container %s, which does not exist", task.Name, name)
+				}
+				targets = append(targets, id)
+			}
+			targetsJSON, err := json.Marshal(targets)
+			if err != nil {
+				return fmt.Errorf("failed to encode targets of scheduled task %s: %w", task.Name, err)
+			}
+			task.TargetContainers = string(targetsJSON)
+
+			active := task.IsActive
+			if err := tx.Create(task).Error; err != nil {
+				return fmt.Errorf("failed to create scheduled task %s: %w", task.Name, err)
+			}
+			if !active {
+				task.IsActive = false
+				if err := tx.Model(task).UpdateColumn("is_active", false).Error; err != nil {
+					return fmt.Errorf("failed to create scheduled task %s: %w", task.Name, err)
+				}
+			}
+		}
+		return nil
+	})
+}
@@ -8,7 +8,7 @@ import (
 	"sync"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 
 	"gorm.io/gorm"
 )
@@ -0,0 +1,349 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// taskExecutionLogRepository implements TaskExecutionLogRepository interface
+type taskExecutionLogRepository struct {
+	db *gorm.DB
+}
+
+// NewTaskExecutionLogRepository creates a new task execution log repository
+func NewTaskExecutionLogRepository(db *gorm.DB) TaskExecutionLogRepository {
+	return &taskExecutionLogRepository{db: db}
+}
+
+// Create creates a new task execution log
+func (r *taskExecutionLogRepository) Create(ctx context.Context, log *model.TaskExecutionLog) error {
+	if log == nil {
+		return fmt.Errorf("execution log cannot be nil")
+	}
+
+	if err := r.db.WithContext(ctx).Create(log).Error; err != nil {
+		return fmt.Errorf("failed to create execution log: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a task execution log by ID
+func (r *taskExecutionLogRepository) GetByID(ctx context.Context, id int64) (*model.TaskExecutionLog, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid execution log ID: %d", id)
+	}
+
+	var log model.TaskExecutionLog
+	if err := r.db.WithContext(ctx).First(&log, id).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("execution log with ID %d not found", id)
+		}
+		return nil, fmt.Errorf("failed to get execution log by ID: %w", err)
+	}
+	return &log, nil
+}
+
+// Update updates a task execution log
+func (r *taskExecutionLogRepository) Update(ctx context.Context, log *model.TaskExecutionLog) error {
+	if log == nil {
+		return fmt.Errorf("execution log cannot be nil")
+	}
+	if log.ID <= 0 {
+		return fmt.Errorf("invalid execution log ID: %d", log.ID)
+	}
+
+	if err := r.db.WithContext(ctx).Omit("Task").Save(log).Error; err != nil {
+		return fmt.Errorf("failed to update execution log: %w", err)
+	}
+	return nil
+}
+
+// Delete deletes a task execution log by ID
+func (r *taskExecutionLogRepository) Delete(ctx context.Context, id int64) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid execution log ID: %d", id)
+	}
+
+	result := r.db.WithContext(ctx).Delete(&model.TaskExecutionLog{}, id)
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete execution log: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("execution log with ID %d not found", id)
+	}
+	return nil
+}
+
+// List retrieves task execution logs with filtering and pagination
+func (r *taskExecutionLogRepository) List(ctx context.Context, filter *model.TaskExecutionLogFilter) ([]*model.TaskExecutionLog, int64, error) {
+	var logs []*model.TaskExecutionLog
+	var total int64
+
+	query := r.executionQuery(ctx, filter)
+
+	// Keyset pagination skips the count and offset scan entirely
+	if filter != nil && filter.Cursor != nil {
+		query = applyKeyset(query, "started_at", filter.Cursor, filter.Limit)
+		if err := query.Find(&logs).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to list execution logs: %w", err)
+		}
+		return logs, 0, nil
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to count execution logs: %w", err)
+	}
+
+	orderBy := "started_at DESC"
+	if filter != nil && filter.OrderBy != "" {
+		orderBy = filter.OrderBy
+	}
+	query = query.Order(orderBy)
+
+	if filter != nil {
+		if filter.Limit > 0 {
+			query = query.Limit(filter.Limit)
+		}
+		if filter.Offset > 0 {
+			query = query.Offset(filter.Offset)
+		}
+	}
+
+	if err := query.Find(&logs).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to list execution logs: %w", err)
+	}
+	return logs, total, nil
+}
+
+// executionQuery applies the filter's conditions
+func (r *taskExecutionLogRepository) executionQuery(ctx context.Context, filter *model.TaskExecutionLogFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.TaskExecutionLog{})
+	if filter == nil {
+		return query
+	}
+
+	if filter.TaskID != nil {
+		query = query.Where("task_id = ?", *filter.TaskID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.StartedAfter != nil {
+		query = query.Where("started_at >= ?", *filter.StartedAfter)
+	}
+	if filter.StartedBefore != nil {
+		query = query.Where("started_at <= ?", *filter.StartedBefore)
+	}
+	if filter.CompletedAfter != nil {
+		query = query.Where("completed_at >= ?", *filter.CompletedAfter)
+	}
+	if filter.CompletedBefore != nil {
+		query = query.Where("completed_at <= ?", *filter.CompletedBefore)
+	}
+	return query
+}
+
+// GetByTaskID retrieves the execution logs of a task, newest first
+func (r *taskExecutionLogRepository) GetByTaskID(ctx context.Context, taskID int64, limit, offset int) ([]*model.TaskExecutionLog, int64, error) {
+	if taskID <= 0 {
+		return nil, 0, fmt.Errorf("invalid task ID: %d", taskID)
+	}
+
+	id := int(taskID)
+	return r.List(ctx, &model.TaskExecutionLogFilter{
+		TaskID: &id,
+		Limit:  limit,
+		Offset: offset,
+	})
+}
+
+// GetByStatus retrieves the execution logs with a status, newest first
+func (r *taskExecutionLogRepository) GetByStatus(ctx context.Context, status model.ExecutionStatus) ([]*model.TaskExecutionLog, error) {
+	var logs []*model.TaskExecutionLog
+	err := r.db.WithContext(ctx).
+		Where("status = ?", status).
+		Order("started_at DESC, id DESC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution logs by status: %w", err)
+	}
+	return logs, nil
+}
+
+// GetRecent retrieves the latest execution logs
+func (r *taskExecutionLogRepository) GetRecent(ctx context.Context, limit int) ([]*model.TaskExecutionLog, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+
+	var logs []*model.TaskExecutionLog
+	err := r.db.WithContext(ctx).
+		Order("started_at DESC, id DESC").
+		Limit(limit).
+		Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent execution logs: %w", err)
+	}
+	return logs, nil
+}
+
+// GetRunningExecutions retrieves the executions still marked running
+func (r *taskExecutionLogRepository) GetRunningExecutions(ctx context.Context) ([]*model.TaskExecutionLog, error) {
+	return r.GetByStatus(ctx, model.ExecutionStatusRunning)
+}
+
+// GetFailedExecutions retrieves the executions that failed or timed out in
+// the last since hours
+func (r *taskExecutionLogRepository) GetFailedExecutions(ctx context.Context, since int) ([]*model.TaskExecutionLog, error) {
+	if since <= 0 {
+		return nil, fmt.Errorf("since must be positive")
+	}
+
+	var logs []*model.TaskExecutionLog
+	err := r.db.WithContext(ctx).
+		Where("status IN ?", []model.ExecutionStatus{model.ExecutionStatusFailed, model.ExecutionStatusTimeout}).
+		Where("started_at >= ?", time.Now().UTC().Add(-time.Duration(since)*time.Hour)).
+		Order("started_at DESC, id DESC").
+		Find(&logs).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get failed execution logs: %w", err)
+	}
+	return logs, nil
+}
+
+// UpdateStatus sets the status of an execution log
+func (r *taskExecutionLogRepository) UpdateStatus(ctx context.Context, id int64, status model.ExecutionStatus) error {
+	if id <= 0 {
+		return fmt.Errorf("invalid execution log ID: %d", id)
+	}
+
+	result := r.db.WithContext(ctx).Model(&model.TaskExecutionLog{}).
+		Where("id = ?", id).
+		Update("status", status)
+	if result.Error != nil {
+		return fmt.Errorf("failed to update execution log status: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("execution log with ID %d not found", id)
+	}
+	return nil
+}
+
+// GetExecutionStats summarizes the executions of a task
+func (r *taskExecutionLogRepository) GetExecutionStats(ctx context.Context, taskID int64) (*model.ExecutionStats, error) {
+	if taskID <= 0 {
+		return nil, fmt.Errorf("invalid task ID: %d", taskID)
+	}
+
+	var rows []struct {
+		Status      model.ExecutionStatus
+		Count       int
+		AvgDuration float64
+	}
+	err := r.db.WithContext(ctx).Model(&model.TaskExecutionLog{}).
+		Select("status, COUNT(*) AS count, AVG(duration_seconds) AS avg_duration").
+		Where("task_id = ?", taskID).
+		Group("status").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get execution stats: %w", err)
+	}
+
+	stats := &model.ExecutionStats{}
+	var totalDuration float64
+	for _, row := range rows {
+		stats.TotalExecutions += row.Count
+		totalDuration += row.AvgDuration * float64(row.Count)
+		switch row.Status {
+		case model.ExecutionStatusSuccess:
+			stats.SuccessfulExecutions += row.Count
+		case model.ExecutionStatusFailed:
+			stats.FailedExecutions += row.Count
+		case model.ExecutionStatusTimeout:
+			stats.TimeoutExecutions += row.Count
+		}
+	}
+	if stats.TotalExecutions > 0 {
+		stats.SuccessRate = float64(stats.SuccessfulExecutions) / float64(stats.TotalExecutions) * 100
+		stats.AverageExecutionTime = int(totalDuration / float64(stats.TotalExecutions))
+	}
+
+	for _, last := range []struct {
+		into     **time.Time
+		statuses []model.ExecutionStatus
+	}{
+		{&stats.LastExecutionTime, nil},
+		{&stats.LastSuccessTime, []model.ExecutionStatus{model.ExecutionStatusSuccess}},
+		{&stats.LastFailureTime, []model.ExecutionStatus{model.ExecutionStatusFailed, model.ExecutionStatusTimeout}},
+	} {
+		query := r.db.WithContext(ctx).Model(&model.TaskExecutionLog{}).Where("task_id = ?", taskID)
+		if last.statuses != nil {
+			query = query.Where("status IN ?", last.statuses)
+		}
+		var log model.TaskExecutionLog
+		err := query.Order("started_at DESC, id DESC").Limit(1).Find(&log).Error
+		if err != nil {
+			return nil, fmt.Errorf("failed to get execution stats: %w", err)
+		}
+		if log.ID != 0 {
+			startedAt := log.StartedAt
+			*last.into = &startedAt
+		}
+	}
+
+	return stats, nil
+}
+
+// DeleteOldLogs deletes execution logs older than the retention days
+func (r *taskExecutionLogRepository) DeleteOldLogs(ctx context.Context, retentionDays int) (int64, error) {
+	if retentionDays <= 0 {
+		return 0, fmt.Errorf("retention days must be positive")
+	}
+	return r.DeleteOlderThan(ctx, time.Now().UTC().AddDate(0, 0, -retentionDays))
+}
+
+// DeleteOlderThan deletes execution logs started before the cutoff
+func (r *taskExecutionLogRepository) DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).
+		Where("started_at < ?", cutoffDate).
+		Delete(&model.TaskExecutionLog{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old execution logs: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
+
+// CountOlderThan counts execution logs started before the cutoff
+func (r *taskExecutionLogRepository) CountOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.TaskExecutionLog{}).
+		Where("started_at < ?", cutoffDate).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count old execution logs: %w", err)
+	}
+	return count, nil
+}
+
+// CreateBatch creates multiple execution logs in a single statement
+func (r *taskExecutionLogRepository) CreateBatch(ctx context.Context, logs []*model.TaskExecutionLog) error {
+	if len(logs) == 0 {
+		return fmt.Errorf("logs slice cannot be empty")
+	}
+	for i, log := range logs {
+		if log == nil {
+			return fmt.Errorf("execution log at index %d cannot be nil", i)
+		}
+	}
+
+	if err := r.db.WithContext(ctx).CreateInBatches(logs, 100).Error; err != nil {
+		return fmt.Errorf("failed to create execution logs: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// teamRepository implements TeamRepository interface
+type teamRepository struct {
+	db *gorm.DB
+}
+
+// NewTeamRepository creates a new team repository
+func NewTeamRepository(db *gorm.DB) TeamRepository {
+	return &teamRepository{db: db}
+}
+
+// Create creates a new team
+func (r *teamRepository) Create(ctx context.Context, team *model.Team) error {
+	if team == nil {
+		return fmt.Errorf("team cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(team).Error; err != nil {
+		return fmt.Errorf("failed to create team: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves a team with its members and quota
+func (r *teamRepository) GetByID(ctx context.Context, id int) (*model.Team, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid team ID: %d", id)
+	}
+
+	var team model.Team
+	err := r.db.WithContext(ctx).
+		Preload("Members").
+		Preload("Quota").
+		First(&team, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("team with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team by ID: %w", err)
+	}
+	return &team, nil
+}
+
+// List returns every team with its members and quota, by name
+func (r *teamRepository) List(ctx context.Context) ([]*model.Team, error) {
+	var teams []*model.Team
+	err := r.db.WithContext(ctx).
+		Preload("Members").
+		Preload("Quota").
+		Order("name ASC").
+		Find(&teams).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list teams: %w", err)
+	}
+	return teams, nil
+}
+
+// ReplaceMembers replaces the members of a team
+func (r *teamRepository) ReplaceMembers(ctx context.Context, teamID int, members []model.TeamMember) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("team_id = ?", teamID).Delete(&model.TeamMember{}).Error; err != nil {
+			return fmt.Errorf("failed to remove team members: %w", err)
+		}
+		if len(members) == 0 {
+			return nil
+		}
+		for i := range members {
+			members[i].ID = 0
+			members[i].TeamID = teamID
+		}
+		if err := tx.Create(&members).Error; err != nil {
+			return fmt.Errorf("failed to add team members: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetQuota returns a team's quota, nil when it has none
+func (r *teamRepository) GetQuota(ctx context.Context, teamID int) (*model.TeamQuota, error) {
+	var quota model.TeamQuota
+	err := r.db.WithContext(ctx).Where("team_id = ?", teamID).First(&quota).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get team quota: %w", err)
+	}
+	return &quota, nil
+}
+
+// SaveQuota creates or replaces a team's quota
+func (r *teamRepository) SaveQuota(ctx context.Context, quota *model.TeamQuota) error {
+	if quota == nil {
+		return fmt.Errorf("team quota cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Save(quota).Error; err != nil {
+		return fmt.Errorf("failed to save team quota: %w", err)
+	}
+	return nil
+}
+
+// ListContainers returns the containers counting against a team's quota
+func (r *teamRepository) ListContainers(ctx context.Context, teamID int) ([]*model.Container, error) {
+	var containers []*model.Container
+	if err := r.db.WithContext(ctx).Where("team_id = ?", teamID).Find(&containers).Error; err != nil {
+		return nil, fmt.Errorf("failed to list team containers: %w", err)
+	}
+	return containers, nil
+}
@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// updateNoteRepository implements UpdateNoteRepository interface
+type updateNoteRepository struct {
+	db *gorm.DB
+}
+
+// NewUpdateNoteRepository creates a new update note repository
+func NewUpdateNoteRepository(db *gorm.DB) UpdateNoteRepository {
+	return &updateNoteRepository{db: db}
+}
+
+// Create creates a new update note
+func (r *updateNoteRepository) Create(ctx context.Context, note *model.UpdateNote) error {
+	if note == nil {
+		return fmt.Errorf("update note cannot be nil")
+	}
+	if err := r.db.WithContext(ctx).Create(note).Error; err != nil {
+		return fmt.Errorf("failed to create update note: %w", err)
+	}
+	return nil
+}
+
+// GetByID retrieves an update note by ID
+func (r *updateNoteRepository) GetByID(ctx context.Context, id int) (*model.UpdateNote, error) {
+	if id <= 0 {
+		return nil, fmt.Errorf("invalid update note ID: %d", id)
+	}
+
+	var note model.UpdateNote
+	err := r.db.WithContext(ctx).First(&note, id).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("update note with ID %d not found", id)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get update note by ID: %w", err)
+	}
+	return &note, nil
+}
+
+// Update updates the body of an existing update note
+func (r *updateNoteRepository) Update(ctx context.Context, note *model.UpdateNote) error {
+	if note == nil {
+		return fmt.Errorf("update note cannot be nil")
+	}
+	if note.ID <= 0 {
+		return fmt.Errorf("invalid update note ID: %d", note.ID)
+	}
+
+	err := r.db.WithContext(ctx).Model(note).Select("body", "markdown", "updated_at").Updates(note).Error
+	if err != nil {
+		return fmt.Errorf("failed to update update note: %w", err)
+	}
+	return nil
+}
+
+// ListByUpdate returns the notes of an update, oldest first
+func (r *updateNoteRepository) ListByUpdate(ctx context.Context, updateID int) ([]*model.UpdateNote, error) {
+	var notes []*model.UpdateNote
+	err := r.db.WithContext(ctx).
+		Where("update_id = ?", updateID).
+		Order("created_at ASC, id ASC").
+		Find(&notes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list update notes: %w", err)
+	}
+	return notes, nil
+}
@@ -3,9 +3,10 @@ package repository
 import (
 	"context"
 	"fmt"
+	"net"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 
 	"gorm.io/gorm"
 )
@@ -419,7 +420,12 @@ func (r *userSessionRepository) Create(ctx context.Context, session *model.UserS
 		return fmt.Errorf("refresh token is required")
 	}
 
-	if err := r.db.WithContext(ctx).Create(session).Error; err != nil {
+	db := r.db.WithContext(ctx)
+	// ip_address is an inet; leave it null rather than fail on a bad address
+	if net.ParseIP(session.IPAddress) == nil {
+		db = db.Omit("IPAddress")
+	}
+	if err := db.Create(session).Error; err != nil {
 		return fmt.Errorf("failed to create user session: %w", err)
 	}
 
@@ -552,6 +558,50 @@ func (r *userSessionRepository) DeleteUserSessions(ctx context.Context, userID i
 	return nil
 }
 
+// DeleteOtherUserSessions deletes the sessions of a user except keepID, all
+// of them when keepID is empty, and returns the deleted sessions
+func (r *userSessionRepository) DeleteOtherUserSessions(ctx context.Context, userID int64, keepID string) ([]*model.UserSession, error) {
+	if userID <= 0 {
+		return nil, fmt.Errorf("invalid user ID: %d", userID)
+	}
+
+	var deleted []*model.UserSession
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("user_id = ?", userID)
+		if keepID != "" {
+			query = query.Where("id <> ?", keepID)
+		}
+		if err := query.Find(&deleted).Error; err != nil {
+			return err
+		}
+		if len(deleted) == 0 {
+			return nil
+		}
+
+		ids := make([]string, len(deleted))
+		for i, session := range deleted {
+			ids[i] = session.ID
+		}
+		return tx.Where("id IN ?", ids).Delete(&model.UserSession{}).Error
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete user sessions: %w", err)
+	}
+
+	return deleted, nil
+}
+
+// TouchLastSeen records when a session was last used
+func (r *userSessionRepository) TouchLastSeen(ctx context.Context, id string, at time.Time) error {
+	err := r.db.WithContext(ctx).Model(&model.UserSession{}).
+		Where("id = ?", id).
+		UpdateColumn("last_seen_at", at.UTC()).Error
+	if err != nil {
+		return fmt.Errorf("failed to record session use: %w", err)
+	}
+	return nil
+}
+
 // IsValidSession checks if a session with given refresh token is valid
 func (r *userSessionRepository) IsValidSession(ctx context.Context, refreshToken string) (bool, error) {
 	if refreshToken == "" {
@@ -658,22 +708,15 @@ func (r *activityLogRepository) List(ctx context.Context, filter *model.Activity
 	var logs []*model.ActivityLog
 	var total int64
 
-	query := r.db.WithContext(ctx).Model(&model.ActivityLog{})
+	query := r.activityQuery(ctx, filter)
 
-	// Apply filters
-	if filter != nil {
-		if filter.UserID != nil {
-			query = query.Where("user_id = ?", *filter.UserID)
-		}
-		if filter.Action != "" {
-			query = query.Where("action ILIKE ?", "%"+filter.Action+"%")
-		}
-		if filter.ResourceType != "" {
-			query = query.Where("resource_type = ?", filter.ResourceType)
-		}
-		if filter.ResourceID != nil {
-			query = query.Where("resource_id = ?", *filter.ResourceID)
+	// Keyset pagination skips the count and offset scan entirely
+	if filter != nil && filter.Cursor != nil {
+		query = applyKeyset(query, "created_at", filter.Cursor, filter.Limit)
+		if err := query.Preload("User").Find(&logs).Error; err != nil {
+			return nil, 0, fmt.Errorf("failed to list activity logs: %w", err)
 		}
+		return logs, 0, nil
 	}
 
 	// Get total count
@@ -708,6 +751,75 @@ func (r *activityLogRepository) List(ctx context.Context, filter *model.Activity
 	return logs, total, nil
 }
 
+// Count counts the activity logs matching the filter
+func (r *activityLogRepository) Count(ctx context.Context, filter *model.ActivityLogFilter) (int64, error) {
+	var count int64
+	if err := r.activityQuery(ctx, filter).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count activity logs: %w", err)
+	}
+	return count, nil
+}
+
+// Stream reads the activity logs matching the filter oldest first, one row
+// at a time
+func (r *activityLogRepository) Stream(ctx context.Context, filter *model.ActivityLogFilter, limit int, fn func(*model.ActivityLog) error) error {
+	query := r.activityQuery(ctx, filter).Order("created_at ASC, id ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	rows, err := query.Rows()
+	if err != nil {
+		return fmt.Errorf("failed to query activity logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log model.ActivityLog
+		if err := r.db.ScanRows(rows, &log); err != nil {
+			return fmt.Errorf("failed to scan activity log row: %w", err)
+		}
+		if err := fn(&log); err != nil {
+			return err
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read activity logs: %w", err)
+	}
+	return nil
+}
+
+// activityQuery applies the filter's conditions. Every condition is an
+// equality or a created_at range so the user, resource and created_at
+// indexes can serve it.
+func (r *activityLogRepository) activityQuery(ctx context.Context, filter *model.ActivityLogFilter) *gorm.DB {
+	query := r.db.WithContext(ctx).Model(&model.ActivityLog{})
+	if filter == nil {
+		return query
+	}
+
+	if filter.UserID != nil {
+		query = query.Where("user_id = ?", *filter.UserID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.ResourceType != "" {
+		query = query.Where("resource_type = ?", filter.ResourceType)
+	}
+	if filter.ResourceID != nil {
+		query = query.Where("resource_id = ?", *filter.ResourceID)
+	}
+	if filter.Since != nil {
+		query = query.Where("created_at >= ?", *filter.Since)
+	}
+	if filter.Until != nil {
+		query = query.Where("created_at < ?", *filter.Until)
+	}
+	return query
+}
+
 // GetByUserID retrieves activity logs for a specific user
 func (r *activityLogRepository) GetByUserID(ctx context.Context, userID int64, limit, offset int) ([]*model.ActivityLog, int64, error) {
 	if userID <= 0 {
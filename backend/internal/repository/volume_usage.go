@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// volumeUsageRepository implements VolumeUsageRepository interface
+type volumeUsageRepository struct {
+	db *gorm.DB
+}
+
+// NewVolumeUsageRepository creates a new volume usage repository
+func NewVolumeUsageRepository(db *gorm.DB) VolumeUsageRepository {
+	return &volumeUsageRepository{db: db}
+}
+
+// CreateBatch stores the samples of one sampling run
+func (r *volumeUsageRepository) CreateBatch(ctx context.Context, samples []*model.VolumeUsageSample) error {
+	if len(samples) == 0 {
+		return nil
+	}
+
+	if err := r.db.WithContext(ctx).CreateInBatches(samples, 100).Error; err != nil {
+		return fmt.Errorf("failed to create volume usage samples: %w", err)
+	}
+
+	return nil
+}
+
+// GetLatest returns the most recent sample of every volume
+func (r *volumeUsageRepository) GetLatest(ctx context.Context) ([]*model.VolumeUsageSample, error) {
+	var samples []*model.VolumeUsageSample
+	err := r.db.WithContext(ctx).
+		Raw("SELECT DISTINCT ON (volume_name) * FROM volume_usage_samples ORDER BY volume_name, sampled_at DESC").
+		Scan(&samples).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get latest volume usage samples: %w", err)
+	}
+
+	return samples, nil
+}
+
+// GetLastSampledAt returns when volumes were last sampled, nil if never
+func (r *volumeUsageRepository) GetLastSampledAt(ctx context.Context) (*time.Time, error) {
+	var sample model.VolumeUsageSample
+	err := r.db.WithContext(ctx).Order("sampled_at DESC").First(&sample).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last volume sample time: %w", err)
+	}
+
+	return &sample.SampledAt, nil
+}
+
+// GetKnownSizeAt returns the volume's most recent sample with a known size
+// taken at or before t, nil if there is none
+func (r *volumeUsageRepository) GetKnownSizeAt(ctx context.Context, volumeName string, t time.Time) (*model.VolumeUsageSample, error) {
+	var sample model.VolumeUsageSample
+	err := r.db.WithContext(ctx).
+		Where("volume_name = ? AND sampled_at <= ? AND size_bytes >= 0", volumeName, t).
+		Where("size_status IN ?", []model.VolumeSizeStatus{model.VolumeSizeReported, model.VolumeSizeMeasured}).
+		Order("sampled_at DESC").
+		First(&sample).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume usage sample: %w", err)
+	}
+
+	return &sample, nil
+}
+
+// DeleteOlderThan deletes samples taken before the cutoff date
+func (r *volumeUsageRepository) DeleteOlderThan(ctx context.Context, cutoffDate time.Time) (int64, error) {
+	result := r.db.WithContext(ctx).Where("sampled_at < ?", cutoffDate).Delete(&model.VolumeUsageSample{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to delete old volume usage samples: %w", result.Error)
+	}
+
+	return result.RowsAffected, nil
+}
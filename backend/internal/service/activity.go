@@ -0,0 +1,320 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ActivityExport is a prepared activity log export: its rows are counted so
+// the response headers can announce truncation before the body is streamed
+type ActivityExport struct {
+	Format    ReportFormat
+	Filter    model.ActivityLogFilter
+	TotalRows int64
+	RowLimit  int
+
+	actor model.Actor
+}
+
+// Truncated reports whether the export stops short of the matching rows
+func (e *ActivityExport) Truncated() bool {
+	return e.TotalRows > int64(e.RowLimit)
+}
+
+// ContentType returns the MIME type of the export
+func (e *ActivityExport) ContentType() string {
+	return e.Format.ContentType()
+}
+
+// Filename returns the download name of the export, e.g.
+// "activity_20260101_20260401.csv"
+func (e *ActivityExport) Filename() string {
+	return fmt.Sprintf("activity_%s_%s.%s",
+		e.Filter.Since.UTC().Format("20060102"), e.Filter.Until.UTC().Format("20060102"), e.Format)
+}
+
+// ActivityService reads and exports the activity log
+type ActivityService struct {
+	activityRepo repository.ActivityLogRepository
+	userRepo     repository.UserRepository
+	maxRows      int
+}
+
+// NewActivityService creates a new activity service instance
+func NewActivityService(
+	activityRepo repository.ActivityLogRepository,
+	userRepo repository.UserRepository,
+	cfg *config.Config,
+) *ActivityService {
+	return &ActivityService{
+		activityRepo: activityRepo,
+		userRepo:     userRepo,
+		maxRows:      cfg.System.ReportExportMaxRows,
+	}
+}
+
+// ListActivity returns a page of the activity log entries matching the
+// query, newest first. Admins and system actors read every entry; other
+// users only their own.
+func (s *ActivityService) ListActivity(ctx context.Context, actor model.Actor, query *dto.ActivityQuery) (*dto.ActivityListResponse, error) {
+	filter := activityFilter(query)
+
+	if query.From != "" {
+		since, _, err := parseReportTime(query.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: from: %w", err)
+		}
+		filter.Since = &since
+	}
+	if query.To != "" {
+		until, day, err := parseReportTime(query.To)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: to: %w", err)
+		}
+		if day {
+			until = until.AddDate(0, 0, 1)
+		}
+		filter.Until = &until
+	}
+	if filter.Since != nil && filter.Until != nil && !filter.Since.Before(*filter.Until) {
+		return nil, fmt.Errorf("invalid request: from must be before to")
+	}
+
+	if err := s.scopeActivity(ctx, actor, filter); err != nil {
+		return nil, err
+	}
+
+	// Set defaults
+	page := query.Page
+	if page < 1 {
+		page = 1
+	}
+	filter.Limit = query.Limit
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 20
+	}
+	filter.Offset = (page - 1) * filter.Limit
+	filter.OrderBy = "created_at DESC, id DESC"
+
+	logs, total, err := s.activityRepo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ActivityListResponse{
+		Activities: logs,
+		Total:      total,
+		Page:       page,
+		Limit:      filter.Limit,
+		HasNext:    filter.Offset+filter.Limit < int(total),
+		HasPrev:    filter.Offset > 0,
+	}, nil
+}
+
+// PrepareExport validates the query, scopes it to the actor like
+// ListActivity and counts the rows to export. The range defaults to the
+// last 90 days.
+func (s *ActivityService) PrepareExport(ctx context.Context, actor model.Actor, query *dto.ActivityQuery) (*ActivityExport, error) {
+	format, err := parseReportFormat(query.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	window, err := parseReportWindow(query.From, query.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	filter := activityFilter(query)
+	filter.Since = &window.From
+	filter.Until = &window.To
+
+	if err := s.scopeActivity(ctx, actor, filter); err != nil {
+		return nil, err
+	}
+
+	total, err := s.activityRepo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ActivityExport{
+		Format:    format,
+		Filter:    *filter,
+		TotalRows: total,
+		RowLimit:  s.maxRows,
+		actor:     actor,
+	}, nil
+}
+
+// WriteExport streams the prepared export to w and records it in the
+// activity log. w is flushed as rows are written when it supports it.
+func (s *ActivityService) WriteExport(ctx context.Context, w io.Writer, export *ActivityExport) error {
+	var (
+		rows int
+		err  error
+	)
+	switch export.Format {
+	case ReportFormatJSON:
+		rows, err = s.writeJSON(ctx, w, export)
+	default:
+		rows, err = s.writeCSV(ctx, w, export)
+	}
+
+	s.logExport(export, rows, err)
+	return err
+}
+
+// writeCSV writes the header, the rows and, when truncated, a trailing
+// comment line explaining the cut
+func (s *ActivityService) writeCSV(ctx context.Context, w io.Writer, export *ActivityExport) (int, error) {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(model.ActivityReportColumns); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	err := s.activityRepo.Stream(ctx, &export.Filter, export.RowLimit, func(entry *model.ActivityLog) error {
+		if err := cw.Write(entry.Record()); err != nil {
+			return err
+		}
+		rows++
+		if rows%reportFlushRows == 0 {
+			return flushReport(cw, w)
+		}
+		return nil
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	if export.Truncated() {
+		cw.Flush()
+		if _, err := fmt.Fprintf(w, "# Export truncated: %d of %d rows; narrow the date range to export the rest\n",
+			rows, export.TotalRows); err != nil {
+			return rows, err
+		}
+	}
+
+	return rows, flushReport(cw, w)
+}
+
+// writeJSON writes an object holding the truncation details and the rows,
+// encoding one row at a time
+func (s *ActivityService) writeJSON(ctx context.Context, w io.Writer, export *ActivityExport) (int, error) {
+	if _, err := fmt.Fprintf(w, `{"report":"activity","truncated":%t,"row_limit":%d,"total_rows":%d,"rows":[`,
+		export.Truncated(), export.RowLimit, export.TotalRows); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	err := s.activityRepo.Stream(ctx, &export.Filter, export.RowLimit, func(entry *model.ActivityLog) error {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return err
+		}
+		if rows > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		rows++
+		if rows%reportFlushRows == 0 {
+			flushWriter(w)
+		}
+		return nil
+	})
+	if err != nil {
+		return rows, err
+	}
+
+	if _, err := io.WriteString(w, "]}\n"); err != nil {
+		return rows, err
+	}
+	flushWriter(w)
+	return rows, nil
+}
+
+// scopeActivity restricts the filter to the actor's own entries unless the
+// actor is an admin or a system component. Asking for another user's
+// entries is denied rather than silently narrowed.
+func (s *ActivityService) scopeActivity(ctx context.Context, actor model.Actor, filter *model.ActivityLogFilter) error {
+	if actor.IsSystem() {
+		return nil
+	}
+	if actor.UserID == nil {
+		return fmt.Errorf("access denied: the activity log can only be read by users")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, *actor.UserID)
+	if err != nil {
+		return err
+	}
+	if user.IsAdmin() {
+		return nil
+	}
+
+	if filter.UserID != nil && *filter.UserID != *actor.UserID {
+		return fmt.Errorf("access denied: only admins can read other users' activity")
+	}
+	filter.UserID = actor.UserID
+	return nil
+}
+
+// logExport records an export, including failed ones, in the activity log
+func (s *ActivityService) logExport(export *ActivityExport, rows int, exportErr error) {
+	metadata := map[string]interface{}{
+		"from":       export.Filter.Since.UTC().Format(time.RFC3339),
+		"to":         export.Filter.Until.UTC().Format(time.RFC3339),
+		"format":     export.Format,
+		"rows":       rows,
+		"total_rows": export.TotalRows,
+		"truncated":  export.Truncated(),
+	}
+	if export.Filter.UserID != nil {
+		metadata["user_id"] = *export.Filter.UserID
+	}
+	description := fmt.Sprintf("Exported %d activity log rows as %s", rows, export.Format)
+	if exportErr != nil {
+		metadata["error"] = exportErr.Error()
+		description = fmt.Sprintf("Export of activity log as %s failed after %d rows", export.Format, rows)
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	activity := &model.ActivityLog{
+		Action:       "activity_exported",
+		ResourceType: "report",
+		ResourceName: "activity",
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(export.actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).Warn("Failed to log activity export")
+	}
+}
+
+// activityFilter copies the query's equality filters
+func activityFilter(query *dto.ActivityQuery) *model.ActivityLogFilter {
+	return &model.ActivityLogFilter{
+		UserID:       query.UserID,
+		Action:       query.Action,
+		ResourceType: query.ResourceType,
+		ResourceID:   query.ResourceID,
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// apiTokenBytes is the entropy of a personal access token
+	apiTokenBytes = 32
+	// apiTokenPrefixLength is how much of a token is kept to tell it apart
+	apiTokenPrefixLength = 12
+	// maxAPITokensPerUser bounds the usable tokens of a user
+	maxAPITokensPerUser = 25
+	// apiTokenUseInterval is how stale last_used_at may get before a use
+	// of the token is written
+	apiTokenUseInterval = time.Minute
+)
+
+// APITokenService manages personal access tokens and resolves them for the
+// auth chain
+type APITokenService struct {
+	tokenRepo    repository.APITokenRepository
+	activityRepo repository.ActivityLogRepository
+}
+
+// NewAPITokenService creates a new personal access token service instance
+func NewAPITokenService(tokenRepo repository.APITokenRepository, activityRepo repository.ActivityLogRepository) *APITokenService {
+	return &APITokenService{
+		tokenRepo:    tokenRepo,
+		activityRepo: activityRepo,
+	}
+}
+
+// CreateToken creates a personal access token for the actor. The token's
+// value is returned only here; just its hash is stored.
+func (s *APITokenService) CreateToken(ctx context.Context, actor model.Actor, req *dto.CreateAPITokenRequest) (*dto.CreatedAPIToken, error) {
+	if actor.UserID == nil || actor.Type != model.ActorTypeUser {
+		return nil, fmt.Errorf("access denied: api tokens are created by signed in users")
+	}
+
+	days := req.ExpiresInDays
+	if days == 0 {
+		days = dto.DefaultAPITokenExpiryDays
+	}
+	if days < 0 || days > dto.MaxAPITokenExpiryDays {
+		return nil, fmt.Errorf("invalid request: expiry must be between 1 and %d days", dto.MaxAPITokenExpiryDays)
+	}
+
+	now := time.Now()
+	expiresAt := now.AddDate(0, 0, days).UTC()
+	token := &model.APIToken{
+		UserID:    *actor.UserID,
+		Name:      strings.TrimSpace(req.Name),
+		Scopes:    model.StringList(uniqueScopes(req.Scopes)),
+		ExpiresAt: &expiresAt,
+	}
+	if err := token.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	usable, err := s.tokenRepo.CountUsable(ctx, token.UserID, now)
+	if err != nil {
+		return nil, err
+	}
+	if usable >= maxAPITokensPerUser {
+		return nil, fmt.Errorf("invalid request: at most %d api tokens can be in use; revoke one first", maxAPITokensPerUser)
+	}
+
+	value, err := newAPITokenValue()
+	if err != nil {
+		return nil, err
+	}
+	token.TokenHash = utils.HashSHA256(value)
+	token.Prefix = value[:apiTokenPrefixLength]
+
+	if err := s.tokenRepo.Create(ctx, token); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(actor, "api_token_create", token, fmt.Sprintf("Created API token %s", token.Name))
+	return &dto.CreatedAPIToken{APIToken: token, Token: value}, nil
+}
+
+// ListTokens lists the actor's tokens, revoked and expired ones included
+func (s *APITokenService) ListTokens(ctx context.Context, actor model.Actor) ([]*model.APIToken, error) {
+	if actor.UserID == nil {
+		return nil, fmt.Errorf("access denied: api tokens belong to users")
+	}
+	return s.tokenRepo.ListByUser(ctx, *actor.UserID)
+}
+
+// RevokeToken revokes one of the actor's tokens. Tokens of other users are
+// reported as not found.
+func (s *APITokenService) RevokeToken(ctx context.Context, actor model.Actor, id int) error {
+	token, err := s.tokenRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if !actor.IsUser(token.UserID) {
+		return fmt.Errorf("api token with ID %d not found", id)
+	}
+	if token.RevokedAt != nil {
+		return nil
+	}
+
+	if err := s.tokenRepo.Revoke(ctx, id, time.Now()); err != nil {
+		return err
+	}
+
+	s.logActivity(actor, "api_token_revoke", token, fmt.Sprintf("Revoked API token %s", token.Name))
+	return nil
+}
+
+// ResolveToken returns the usable token with the given value, with its user.
+// Tokens of inactive users are refused.
+func (s *APITokenService) ResolveToken(ctx context.Context, value string) (*model.APIToken, error) {
+	if !strings.HasPrefix(value, model.APITokenPrefix) {
+		return nil, fmt.Errorf("api token not found")
+	}
+
+	token, err := s.tokenRepo.GetByHash(ctx, utils.HashSHA256(value))
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if !token.IsUsable(now) {
+		return nil, fmt.Errorf("api token is revoked or expired")
+	}
+	if token.User == nil || !token.User.IsActive {
+		return nil, fmt.Errorf("api token user is not active")
+	}
+
+	if token.LastUsedAt == nil || now.Sub(*token.LastUsedAt) >= apiTokenUseInterval {
+		if err := s.tokenRepo.TouchLastUsed(ctx, token.ID, now); err != nil {
+			logrus.WithError(err).WithField("token_id", token.ID).Warn("Failed to record API token use")
+		}
+		token.LastUsedAt = &now
+	}
+	return token, nil
+}
+
+func newAPITokenValue() (string, error) {
+	bytes, err := utils.GenerateSecureRandomBytes(apiTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate api token: %w", err)
+	}
+	return fmt.Sprintf("%s%x", model.APITokenPrefix, bytes), nil
+}
+
+// uniqueScopes trims scopes and drops duplicates, keeping their order
+func uniqueScopes(scopes []string) []string {
+	seen := make(map[string]bool, len(scopes))
+	unique := make([]string, 0, len(scopes))
+	for _, scope := range scopes {
+		scope = strings.TrimSpace(scope)
+		if scope == "" || seen[scope] {
+			continue
+		}
+		seen[scope] = true
+		unique = append(unique, scope)
+	}
+	return unique
+}
+
+// logActivity records a token change. The token value is never logged.
+func (s *APITokenService) logActivity(actor model.Actor, action string, token *model.APIToken, description string) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"prefix":     token.Prefix,
+		"scopes":     token.Scopes,
+		"expires_at": token.ExpiresAt,
+	})
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "api_token",
+		ResourceID:   &token.ID,
+		ResourceName: token.Name,
+		Description:  description,
+		Metadata:     string(metadata),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("token_id", token.ID).Warn("Failed to log API token activity")
+	}
+}
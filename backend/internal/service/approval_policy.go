@@ -0,0 +1,540 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultApprovalDryRunLimit = 50
+	maxApprovalDryRunLimit     = 200
+)
+
+// ApprovalPolicyService manages update approval policies and applies them to
+// the pending updates the update checker proposes
+type ApprovalPolicyService struct {
+	policyRepo        repository.ApprovalPolicyRepository
+	updateHistoryRepo repository.UpdateHistoryRepository
+	containerRepo     repository.ContainerRepository
+	imageRepo         repository.ImageVersionRepository
+	scanRepo          repository.ScanResultRepository
+	activityRepo      repository.ActivityLogRepository
+	containerService  *ContainerService
+}
+
+// NewApprovalPolicyService creates a new approval policy service instance
+func NewApprovalPolicyService(
+	policyRepo repository.ApprovalPolicyRepository,
+	updateHistoryRepo repository.UpdateHistoryRepository,
+	containerRepo repository.ContainerRepository,
+	imageRepo repository.ImageVersionRepository,
+	scanRepo repository.ScanResultRepository,
+	activityRepo repository.ActivityLogRepository,
+	containerService *ContainerService,
+) *ApprovalPolicyService {
+	return &ApprovalPolicyService{
+		policyRepo:        policyRepo,
+		updateHistoryRepo: updateHistoryRepo,
+		containerRepo:     containerRepo,
+		imageRepo:         imageRepo,
+		scanRepo:          scanRepo,
+		activityRepo:      activityRepo,
+		containerService:  containerService,
+	}
+}
+
+// ApprovalPolicyRequest creates or replaces an approval policy
+type ApprovalPolicyRequest struct {
+	Name       string                   `json:"name" binding:"required"`
+	Position   int                      `json:"position"`
+	Enabled    *bool                    `json:"enabled,omitempty"`
+	Action     model.ApprovalAction     `json:"action" binding:"required"`
+	Conditions model.ApprovalConditions `json:"conditions"`
+}
+
+// ApprovalPolicyEvaluateRequest replays recent pending updates against a
+// draft policy. PolicyID names the saved policy the draft would replace.
+type ApprovalPolicyEvaluateRequest struct {
+	Policy   ApprovalPolicyRequest `json:"policy" binding:"required"`
+	PolicyID int                   `json:"policy_id,omitempty"`
+	Limit    int                   `json:"limit,omitempty"`
+}
+
+// ApprovalDryRun is what the policies would have done with the draft in place
+type ApprovalDryRun struct {
+	Evaluated int                     `json:"evaluated"`
+	Matched   int                     `json:"matched"`
+	Approved  int                     `json:"approved"`
+	Rejected  int                     `json:"rejected"`
+	Pending   int                     `json:"pending"`
+	Results   []*ApprovalDryRunResult `json:"results"`
+}
+
+// ApprovalDryRunResult is the replayed outcome for one pending update.
+// DraftMatches is set when the draft's conditions match, even if an earlier
+// policy decides first.
+type ApprovalDryRunResult struct {
+	UpdateID      int                      `json:"update_id"`
+	ContainerID   int                      `json:"container_id"`
+	ContainerName string                   `json:"container_name"`
+	NewImage      string                   `json:"new_image"`
+	Candidate     *model.ApprovalCandidate `json:"candidate"`
+	DraftMatches  bool                     `json:"draft_matches"`
+	Outcome       string                   `json:"outcome"`
+	Policy        string                   `json:"policy,omitempty"`
+}
+
+// pendingUpdateMetadata is what the update checker knew about a pending
+// update when it proposed it
+type pendingUpdateMetadata struct {
+	UpdateType       string `json:"update_type"`
+	IsSecurityUpdate bool   `json:"is_security_update"`
+}
+
+// ListPolicies returns every approval policy in evaluation order
+func (s *ApprovalPolicyService) ListPolicies(ctx context.Context) ([]*model.ApprovalPolicy, error) {
+	return s.policyRepo.List(ctx)
+}
+
+// CreatePolicy creates an approval policy at version 1
+func (s *ApprovalPolicyService) CreatePolicy(ctx context.Context, actor model.Actor, req *ApprovalPolicyRequest) (*model.ApprovalPolicy, error) {
+	policy := &model.ApprovalPolicy{Enabled: true, Version: 1}
+	if err := applyApprovalPolicyRequest(policy, req); err != nil {
+		return nil, err
+	}
+	if actor.UserID != nil {
+		createdBy := int(*actor.UserID)
+		policy.CreatedBy = &createdBy
+	}
+
+	if err := s.policyRepo.Create(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	s.logPolicyActivity(actor, "approval_policy_create", policy, "Approval policy created")
+	return policy, nil
+}
+
+// UpdatePolicy replaces an approval policy and advances its version
+func (s *ApprovalPolicyService) UpdatePolicy(ctx context.Context, actor model.Actor, id int, req *ApprovalPolicyRequest) (*model.ApprovalPolicy, error) {
+	policy, err := s.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyApprovalPolicyRequest(policy, req); err != nil {
+		return nil, err
+	}
+	policy.Version++
+
+	if err := s.policyRepo.Update(ctx, policy); err != nil {
+		return nil, err
+	}
+
+	s.logPolicyActivity(actor, "approval_policy_update", policy, "Approval policy updated")
+	return policy, nil
+}
+
+// DeletePolicy deletes an approval policy
+func (s *ApprovalPolicyService) DeletePolicy(ctx context.Context, actor model.Actor, id int) error {
+	policy, err := s.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.policyRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logPolicyActivity(actor, "approval_policy_delete", policy, "Approval policy deleted")
+	return nil
+}
+
+func applyApprovalPolicyRequest(policy *model.ApprovalPolicy, req *ApprovalPolicyRequest) error {
+	policy.Name = req.Name
+	policy.Position = req.Position
+	policy.Action = req.Action
+	policy.Conditions = req.Conditions
+	if req.Enabled != nil {
+		policy.Enabled = *req.Enabled
+	}
+
+	if err := policy.Validate(); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+	return nil
+}
+
+// ProposeUpdate records an update the update checker found as pending and
+// evaluates the approval policies against it. An update already proposed is
+// not recorded again: if it is still undecided the policies are evaluated
+// again, since its age may now satisfy them, and if it was approved but not
+// yet applied it is retried. It returns nil for updates already rejected,
+// running or applied.
+func (s *ApprovalPolicyService) ProposeUpdate(ctx context.Context, proposal *dto.UpdateProposal) (*dto.ApprovalDecision, error) {
+	container := proposal.Container
+	tag := proposal.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	newImage := container.Image + ":" + tag
+
+	history, err := s.updateHistoryRepo.GetLatestForTarget(ctx, container.ID, newImage, proposal.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed or rolled back attempt is proposed afresh
+	if history != nil && (history.Status == model.UpdateStatusFailed || history.Status == model.UpdateStatusRollback) {
+		history = nil
+	}
+
+	if history != nil {
+		if history.Status != model.UpdateStatusPending {
+			return nil, nil
+		}
+		if history.Approver != "" {
+			decision := &dto.ApprovalDecision{UpdateID: history.ID, Outcome: dto.ApprovalApproved, Policy: history.Approver}
+			decision.Applied = s.queueApproved(ctx, container, history)
+			return decision, nil
+		}
+		return s.decide(ctx, container, history)
+	}
+
+	metadata, _ := json.Marshal(&pendingUpdateMetadata{
+		UpdateType:       proposal.UpdateType,
+		IsSecurityUpdate: proposal.IsSecurityUpdate,
+	})
+	history = &model.UpdateHistory{
+		ContainerID: container.ID,
+		OldImage:    container.GetDeployImageRef(),
+		NewImage:    newImage,
+		OldDigest:   container.ImageDigest,
+		NewDigest:   proposal.Digest,
+		Status:      model.UpdateStatusPending,
+		TriggeredBy: model.TriggerTypeAuto,
+		Metadata:    string(metadata),
+	}
+	history.SetActor(model.SystemActor(model.ActorComponentUpdateChecker))
+
+	if err := s.updateHistoryRepo.Create(ctx, history); err != nil {
+		return nil, err
+	}
+
+	return s.decide(ctx, container, history)
+}
+
+// decide applies the first enabled policy matching a pending update
+func (s *ApprovalPolicyService) decide(ctx context.Context, container *model.Container, history *model.UpdateHistory) (*dto.ApprovalDecision, error) {
+	policies, err := s.policyRepo.ListEnabled(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	candidate := s.candidate(ctx, container, history)
+	decision := &dto.ApprovalDecision{UpdateID: history.ID, Outcome: dto.ApprovalPending, Candidate: candidate}
+
+	policy := firstMatchingPolicy(policies, candidate, time.Now())
+	if policy == nil {
+		return decision, nil
+	}
+
+	decision.PolicyID = policy.ID
+	decision.Policy = policy.Approver()
+	history.Approver = decision.Policy
+
+	if policy.Action == model.ApprovalActionReject {
+		decision.Outcome = dto.ApprovalRejected
+		history.Status = model.UpdateStatusCancelled
+		history.ErrorMessage = "rejected by approval policy " + decision.Policy
+		if err := s.updateHistoryRepo.Update(ctx, history); err != nil {
+			return nil, err
+		}
+		s.logDecision(policy, history, candidate, "update_auto_rejected", "Update rejected by approval policy")
+		return decision, nil
+	}
+
+	decision.Outcome = dto.ApprovalApproved
+	if err := s.updateHistoryRepo.Update(ctx, history); err != nil {
+		return nil, err
+	}
+	s.logDecision(policy, history, candidate, "update_auto_approved", "Update approved by approval policy")
+
+	decision.Applied = s.queueApproved(ctx, container, history)
+	return decision, nil
+}
+
+// queueApproved applies an approved update if the container's update window
+// is open. Otherwise the update stays pending with its approver recorded and
+// is retried the next time the update checker proposes it.
+func (s *ApprovalPolicyService) queueApproved(ctx context.Context, container *model.Container, history *model.UpdateHistory) bool {
+	if s.containerService == nil {
+		return false
+	}
+
+	logger := logrus.WithFields(logrus.Fields{
+		"container_id": container.ID,
+		"update_id":    history.ID,
+		"approver":     history.Approver,
+	})
+
+	effective, err := s.containerService.EffectivePolicy(ctx, container)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to resolve effective policy of approved update")
+		return false
+	}
+	if effective.UpdatePolicy == model.UpdatePolicyDisabled {
+		logger.Info("Approved update left pending: updates are disabled by policy")
+		return false
+	}
+
+	window, err := s.containerService.NextUpdateWindow(ctx, int64(container.ID))
+	if err != nil {
+		logger.WithError(err).Warn("Failed to get update window of approved update")
+		return false
+	}
+	if !window.Eligible {
+		logger.Info("Approved update left pending until the update window opens")
+		return false
+	}
+
+	image, tag, _ := model.ParseImageReference(history.NewImage)
+	container.Image = image
+	container.Tag = tag
+	if container.PinByDigest && history.NewDigest != "" {
+		container.ImageDigest = history.NewDigest
+		container.PendingDigest = ""
+	}
+	if err := s.containerRepo.Update(ctx, container); err != nil {
+		logger.WithError(err).Warn("Failed to retarget container for approved update")
+		return false
+	}
+
+	actor := model.SystemActor(model.ActorComponentApproval)
+	if err := s.containerService.applyImageUpdate(ctx, actor, container, history, &dto.UpdateImageRequest{Strategy: string(history.Strategy)}); err != nil {
+		logger.WithError(err).Warn("Failed to apply approved update")
+		return false
+	}
+	return true
+}
+
+// candidate describes a pending update as the policies see it
+func (s *ApprovalPolicyService) candidate(ctx context.Context, container *model.Container, history *model.UpdateHistory) *model.ApprovalCandidate {
+	var metadata pendingUpdateMetadata
+	if history.Metadata != "" {
+		json.Unmarshal([]byte(history.Metadata), &metadata)
+	}
+
+	repository := model.NormalizeRepository(container.Image)
+	candidate := &model.ApprovalCandidate{
+		UpdateType: metadata.UpdateType,
+		ScanDiff:   model.ScanDiffNotScanned,
+		Repository: repository,
+	}
+
+	if container.Labels != "" {
+		json.Unmarshal([]byte(container.Labels), &candidate.Labels)
+	}
+
+	if s.scanRepo != nil && history.OldDigest != "" && history.NewDigest != "" {
+		// A digest without a scan result reads as not scanned
+		running, _ := s.scanRepo.GetByDigest(ctx, history.OldDigest)
+		proposed, _ := s.scanRepo.GetByDigest(ctx, history.NewDigest)
+		candidate.ScanDiff = model.ScanDiffVerdict(running, proposed)
+	}
+
+	if s.imageRepo != nil {
+		_, tag, _ := model.ParseImageReference(history.NewImage)
+		record, err := s.imageRepo.GetVersionRecord(ctx, repository, tag, history.NewDigest)
+		if err != nil {
+			logrus.WithError(err).WithField("update_id", history.ID).Debug("Failed to get release time of pending update")
+		} else if record != nil {
+			releasedAt := record.FirstSeenAt
+			candidate.ReleasedAt = &releasedAt
+		}
+	}
+
+	return candidate
+}
+
+func firstMatchingPolicy(policies []*model.ApprovalPolicy, candidate *model.ApprovalCandidate, now time.Time) *model.ApprovalPolicy {
+	for _, policy := range policies {
+		if policy.Enabled && policy.Matches(candidate, now) {
+			return policy
+		}
+	}
+	return nil
+}
+
+// DryRun replays recent pending updates against the saved policies with a
+// draft in place, without changing anything
+func (s *ApprovalPolicyService) DryRun(ctx context.Context, req *ApprovalPolicyEvaluateRequest) (*ApprovalDryRun, error) {
+	draft := &model.ApprovalPolicy{Enabled: true, Version: 1}
+	if err := applyApprovalPolicyRequest(draft, &req.Policy); err != nil {
+		return nil, err
+	}
+
+	saved, err := s.policyRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A new draft sorts after saved policies at the same position, as it
+	// would once created
+	draft.ID = math.MaxInt32
+	policies := make([]*model.ApprovalPolicy, 0, len(saved)+1)
+	for _, policy := range saved {
+		if policy.ID == req.PolicyID {
+			draft.ID = policy.ID
+			draft.Version = policy.Version + 1
+			continue
+		}
+		policies = append(policies, policy)
+	}
+	policies = append(policies, draft)
+	sort.SliceStable(policies, func(i, j int) bool {
+		if policies[i].Position != policies[j].Position {
+			return policies[i].Position < policies[j].Position
+		}
+		return policies[i].ID < policies[j].ID
+	})
+
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultApprovalDryRunLimit
+	}
+	if limit > maxApprovalDryRunLimit {
+		limit = maxApprovalDryRunLimit
+	}
+
+	pending, _, err := s.updateHistoryRepo.List(ctx, &model.UpdateHistoryFilter{
+		Status: model.UpdateStatusPending,
+		Limit:  limit,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result := &ApprovalDryRun{Results: make([]*ApprovalDryRunResult, 0, len(pending))}
+	for _, history := range pending {
+		container := &history.Container
+		if container.ID == 0 {
+			if container, err = s.containerRepo.GetByID(ctx, int64(history.ContainerID)); err != nil {
+				continue
+			}
+		}
+
+		candidate := s.candidate(ctx, container, history)
+		entry := &ApprovalDryRunResult{
+			UpdateID:      history.ID,
+			ContainerID:   container.ID,
+			ContainerName: container.Name,
+			NewImage:      history.NewImage,
+			Candidate:     candidate,
+			DraftMatches:  draft.Matches(candidate, now),
+			Outcome:       dto.ApprovalPending,
+		}
+
+		if policy := firstMatchingPolicy(policies, candidate, now); policy != nil {
+			entry.Policy = policy.Approver()
+			entry.Outcome = dto.ApprovalApproved
+			if policy.Action == model.ApprovalActionReject {
+				entry.Outcome = dto.ApprovalRejected
+			}
+		}
+
+		result.Evaluated++
+		if entry.DraftMatches {
+			result.Matched++
+		}
+		switch entry.Outcome {
+		case dto.ApprovalApproved:
+			result.Approved++
+		case dto.ApprovalRejected:
+			result.Rejected++
+		default:
+			result.Pending++
+		}
+		result.Results = append(result.Results, entry)
+	}
+
+	return result, nil
+}
+
+// logPolicyActivity records a change to an approval policy
+func (s *ApprovalPolicyService) logPolicyActivity(actor model.Actor, action string, policy *model.ApprovalPolicy, description string) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"version":    policy.Version,
+		"position":   policy.Position,
+		"enabled":    policy.Enabled,
+		"action":     policy.Action,
+		"conditions": policy.Conditions,
+	})
+
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "approval_policy",
+		ResourceID:   &policy.ID,
+		ResourceName: policy.Name,
+		Description:  description,
+		Metadata:     string(metadata),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("policy_id", policy.ID).Warn("Failed to log approval policy activity")
+	}
+}
+
+// logDecision audits an approval or rejection with the policy version that
+// made it and what the policy saw
+func (s *ApprovalPolicyService) logDecision(policy *model.ApprovalPolicy, history *model.UpdateHistory, candidate *model.ApprovalCandidate, action, description string) {
+	logrus.WithFields(logrus.Fields{
+		"update_id":      history.ID,
+		"container_id":   history.ContainerID,
+		"policy_id":      policy.ID,
+		"policy_version": policy.Version,
+		"action":         action,
+	}).Info(description)
+
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"policy_id":      policy.ID,
+		"policy_name":    policy.Name,
+		"policy_version": policy.Version,
+		"container_id":   history.ContainerID,
+		"new_image":      history.NewImage,
+		"new_digest":     history.NewDigest,
+		"candidate":      candidate,
+	})
+
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "update",
+		ResourceID:   &history.ID,
+		ResourceName: history.NewImage,
+		Description:  fmt.Sprintf("%s %s", description, policy.Approver()),
+		Metadata:     string(metadata),
+	}
+	activity.SetActor(model.SystemActor(model.ActorComponentApproval))
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to log approval decision")
+	}
+}
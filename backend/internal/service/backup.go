@@ -0,0 +1,669 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// backupRestoreOperation names restore confirmations
+const backupRestoreOperation = "backup_restore"
+
+// ErrRestoreBusy is returned when a restore cannot start because another
+// restore or scheduled task executions are running
+var ErrRestoreBusy = errors.New("restore cannot start now")
+
+// backupComponentOrder is the order a restore applies components in. The
+// database goes first so container configs are upserted into the restored
+// one.
+var backupComponentOrder = []string{
+	dto.BackupComponentDatabase,
+	dto.BackupComponentContainerConfigs,
+	dto.BackupComponentConfigurations,
+}
+
+// BackupService lists the backups written by the backup task under the
+// backup storage path and restores them
+type BackupService struct {
+	db                  *gorm.DB
+	containerRepo       repository.ContainerRepository
+	activityRepo        repository.ActivityLogRepository
+	notificationService *NotificationService
+	schedulerService    *SchedulerService
+	config              *config.Config
+	tokens              *confirmationTokens
+	restoring           sync.Mutex
+}
+
+// NewBackupService creates a new backup service instance. schedulerService
+// may be nil when the backend runs without a scheduler.
+func NewBackupService(
+	db *gorm.DB,
+	containerRepo repository.ContainerRepository,
+	activityRepo repository.ActivityLogRepository,
+	notificationService *NotificationService,
+	schedulerService *SchedulerService,
+	cfg *config.Config,
+) *BackupService {
+	return &BackupService{
+		db:                  db,
+		containerRepo:       containerRepo,
+		activityRepo:        activityRepo,
+		notificationService: notificationService,
+		schedulerService:    schedulerService,
+		config:              cfg,
+		tokens:              newConfirmationTokens(cfg.JWT.Secret),
+	}
+}
+
+// ListBackups lists the backups under the storage path, newest first
+func (s *BackupService) ListBackups(ctx context.Context) ([]*dto.BackupInfo, error) {
+	storagePath := s.config.System.BackupStoragePath
+	entries, err := os.ReadDir(storagePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []*dto.BackupInfo{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read backup storage: %w", err)
+	}
+
+	backups := []*dto.BackupInfo{}
+	for _, entry := range entries {
+		if !entry.IsDir() || !model.IsBackupID(entry.Name()) {
+			continue
+		}
+		backups = append(backups, readBackupInfo(filepath.Join(storagePath, entry.Name()), entry.Name()))
+	}
+
+	// IDs embed the start time, so they sort chronologically
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].ID > backups[j].ID
+	})
+	return backups, nil
+}
+
+// Restore previews restoring components of a backup or, given the confirm
+// token of an unchanged dry run, applies them. Restoring the database
+// replaces every table with the backup's and stops the scheduler meanwhile;
+// container configs are upserted by name, leaving conflicting ones alone.
+// Only database records change: running Docker containers are not touched.
+func (s *BackupService) Restore(ctx context.Context, actor model.Actor, backupID string, req *dto.RestoreBackupRequest) (*dto.BackupRestoreResult, error) {
+	dir, err := s.backupDir(backupID)
+	if err != nil {
+		return nil, err
+	}
+	components, err := restoreComponents(dir, req.Components)
+	if err != nil {
+		return nil, err
+	}
+	if !req.DryRun && req.ConfirmToken == "" {
+		return nil, fmt.Errorf("invalid request: confirm_token is required; run a dry run first to get one")
+	}
+
+	plan, err := s.planRestore(ctx, dir, backupID, components)
+	if err != nil {
+		return nil, err
+	}
+
+	var userID int64
+	if actor.UserID != nil {
+		userID = *actor.UserID
+	}
+	digest := plan.digest()
+
+	if req.DryRun {
+		token, expiresAt := s.tokens.Issue(backupRestoreOperation, userID, digest)
+		plan.result.DryRun = true
+		plan.result.ConfirmToken = token
+		plan.result.ExpiresAt = &expiresAt
+		return plan.result, nil
+	}
+
+	if err := s.tokens.Verify(req.ConfirmToken, backupRestoreOperation, userID, digest); err != nil {
+		return nil, err
+	}
+	if !s.restoring.TryLock() {
+		return nil, fmt.Errorf("%w: another restore is running", ErrRestoreBusy)
+	}
+	defer s.restoring.Unlock()
+
+	startedAt := time.Now()
+	err = s.applyRestore(ctx, actor, plan)
+	plan.result.Applied = err == nil
+	s.reportRestore(actor, plan.result, err, time.Since(startedAt))
+	if err != nil {
+		return plan.result, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"actor":      actor.String(),
+		"backup_id":  backupID,
+		"components": components,
+	}).Info("Backup restored")
+
+	return plan.result, nil
+}
+
+// backupDir returns the directory of a backup
+func (s *BackupService) backupDir(backupID string) (string, error) {
+	if !model.IsBackupID(backupID) {
+		return "", fmt.Errorf("backup %s not found", backupID)
+	}
+	dir := filepath.Join(s.config.System.BackupStoragePath, backupID)
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return "", fmt.Errorf("backup %s not found", backupID)
+	}
+	return dir, nil
+}
+
+// restoreComponents validates the requested components against those in the
+// backup and puts them in backupComponentOrder
+func restoreComponents(dir string, requested []string) ([]string, error) {
+	available := backupComponents(dir)
+	for _, component := range requested {
+		if !slices.Contains(backupComponentOrder, component) {
+			return nil, fmt.Errorf("invalid request: unknown component %q", component)
+		}
+		if !slices.Contains(available, component) {
+			return nil, fmt.Errorf("invalid request: backup has no %s", component)
+		}
+	}
+
+	components := []string{}
+	for _, component := range backupComponentOrder {
+		if slices.Contains(requested, component) {
+			components = append(components, component)
+		}
+	}
+	if len(components) == 0 {
+		return nil, fmt.Errorf("invalid request: no components selected")
+	}
+	return components, nil
+}
+
+// restorePlan is what a restore would change, worked out from the backup
+// and the current state
+type restorePlan struct {
+	dir        string
+	manifest   *model.BackupManifest
+	result     *dto.BackupRestoreResult
+	containers map[string]*model.BackupContainerConfig
+	checksums  []string
+}
+
+// digest identifies the plan for confirmation: the backup files it reads and
+// the changes it makes to containers and configuration files. Row counts of
+// the current tables are left out since they change all the time.
+func (p *restorePlan) digest() string {
+	entries := append([]string{p.result.BackupID, strings.Join(p.result.Components, ",")}, p.checksums...)
+	for _, change := range p.result.Containers {
+		entries = append(entries, fmt.Sprintf("container:%s:%s:%s", change.Name, change.Action, strings.Join(change.Fields, ",")))
+	}
+	for _, change := range p.result.Configurations {
+		entries = append(entries, fmt.Sprintf("configuration:%s:%s", change.File, change.Action))
+	}
+
+	sum := sha256.Sum256([]byte(strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// readFile reads a file of the backup through read, which may be nil, and
+// checks it against the manifest's checksum when the manifest lists it
+func (p *restorePlan) readFile(rel string, read func(r io.Reader) error) (string, error) {
+	file, err := os.Open(filepath.Join(p.dir, filepath.FromSlash(rel)))
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	reader := io.TeeReader(file, hash)
+	if read != nil {
+		if err := read(reader); err != nil {
+			return "", err
+		}
+	}
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return "", err
+	}
+
+	checksum := hex.EncodeToString(hash.Sum(nil))
+	if p.manifest != nil {
+		if expected, listed := p.manifest.Checksums[rel]; listed && expected != checksum {
+			return "", fmt.Errorf("%s does not match the checksum in the backup manifest", rel)
+		}
+	}
+	p.checksums = append(p.checksums, rel+":"+checksum)
+	return checksum, nil
+}
+
+// planRestore works out what restoring the components would change
+func (s *BackupService) planRestore(ctx context.Context, dir, backupID string, components []string) (*restorePlan, error) {
+	plan := &restorePlan{
+		dir:        dir,
+		containers: map[string]*model.BackupContainerConfig{},
+		result: &dto.BackupRestoreResult{
+			BackupID:   backupID,
+			Components: components,
+		},
+	}
+
+	manifest, err := readBackupManifest(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("invalid request: backup manifest cannot be read: %v", err)
+	}
+	plan.manifest = manifest
+
+	for _, component := range components {
+		switch component {
+		case dto.BackupComponentDatabase:
+			err = s.planDatabaseRestore(ctx, plan)
+		case dto.BackupComponentContainerConfigs:
+			err = s.planContainerRestore(ctx, plan)
+		case dto.BackupComponentConfigurations:
+			err = planConfigurationRestore(plan)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, change := range plan.result.Containers {
+		if change.Action == dto.RestoreActionConflict {
+			plan.result.Conflicts++
+		}
+	}
+	return plan, nil
+}
+
+// planContainerRestore compares the saved container configs with the
+// existing containers of the same name
+func (s *BackupService) planContainerRestore(ctx context.Context, plan *restorePlan) error {
+	entries, err := os.ReadDir(filepath.Join(plan.dir, model.BackupContainerConfigsDir))
+	if err != nil {
+		return fmt.Errorf("failed to read container configs: %w", err)
+	}
+
+	existing, err := s.containersByName(ctx)
+	if err != nil {
+		return err
+	}
+
+	changes := []dto.ContainerRestoreChange{}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		rel := model.BackupContainerConfigsDir + "/" + entry.Name()
+
+		var saved model.BackupContainerConfig
+		_, err := plan.readFile(rel, func(r io.Reader) error {
+			return json.NewDecoder(r).Decode(&saved)
+		})
+		switch {
+		case err != nil:
+			changes = append(changes, dto.ContainerRestoreChange{Name: entry.Name(), Action: dto.RestoreActionConflict, Reason: err.Error()})
+			continue
+		case saved.Name == "" || saved.Image == "":
+			changes = append(changes, dto.ContainerRestoreChange{Name: entry.Name(), Action: dto.RestoreActionConflict, Reason: "config has no name or image"})
+			continue
+		case plan.containers[saved.Name] != nil:
+			changes = append(changes, dto.ContainerRestoreChange{Name: saved.Name, Action: dto.RestoreActionConflict, Reason: "the backup holds more than one config for this name"})
+			continue
+		case saved.UpdatePolicy != "" && !slices.Contains(model.GetValidUpdatePolicies(), saved.UpdatePolicy):
+			changes = append(changes, dto.ContainerRestoreChange{Name: saved.Name, Action: dto.RestoreActionConflict, Reason: fmt.Sprintf("unknown update policy %q", saved.UpdatePolicy)})
+			continue
+		}
+
+		plan.containers[saved.Name] = &saved
+		changes = append(changes, containerRestoreChange(&saved, existing[saved.Name]))
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Name < changes[j].Name })
+	plan.result.Containers = changes
+	return nil
+}
+
+// containerRestoreChange compares a saved container config with the
+// existing container of that name, which may be nil
+func containerRestoreChange(saved *model.BackupContainerConfig, current *model.Container) dto.ContainerRestoreChange {
+	change := dto.ContainerRestoreChange{Name: saved.Name, Action: dto.RestoreActionCreate}
+	if current == nil {
+		return change
+	}
+	if current.Status == model.ContainerStatusRemoving {
+		change.Action = dto.RestoreActionConflict
+		change.Reason = "the existing container is being removed"
+		return change
+	}
+
+	change.Fields = containerRestoreFields(saved, current)
+	change.Action = dto.RestoreActionUpdate
+	if len(change.Fields) == 0 {
+		change.Action = dto.RestoreActionUnchanged
+	}
+	return change
+}
+
+// containerRestoreFields names the fields a restore changes on a container
+func containerRestoreFields(saved *model.BackupContainerConfig, current *model.Container) []string {
+	var fields []string
+	if saved.Image != current.Image {
+		fields = append(fields, "image")
+	}
+	if saved.Tag != current.Tag {
+		fields = append(fields, "tag")
+	}
+	if saved.ConfigJSON != current.ConfigJSON {
+		fields = append(fields, "config_json")
+	}
+	if saved.UpdatePolicy != "" && saved.UpdatePolicy != current.UpdatePolicy {
+		fields = append(fields, "update_policy")
+	}
+	if saved.RegistryURL != current.RegistryURL {
+		fields = append(fields, "registry_url")
+	}
+	return fields
+}
+
+// planConfigurationRestore compares the saved configuration files with the
+// ones in the working directory
+func planConfigurationRestore(plan *restorePlan) error {
+	changes := []dto.ConfigurationRestoreChange{}
+	for _, name := range model.BackupConfigurationFiles {
+		rel := model.BackupConfigurationsDir + "/" + name
+		if _, err := os.Stat(filepath.Join(plan.dir, filepath.FromSlash(rel))); errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+
+		checksum, err := plan.readFile(rel, nil)
+		if err != nil {
+			return fmt.Errorf("invalid request: %v", err)
+		}
+
+		change := dto.ConfigurationRestoreChange{File: name, Action: dto.RestoreActionUpdate}
+		current, err := fileChecksum(name)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			change.Action = dto.RestoreActionCreate
+		case err != nil:
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		case current == checksum:
+			change.Action = dto.RestoreActionUnchanged
+		}
+		changes = append(changes, change)
+	}
+
+	plan.result.Configurations = changes
+	return nil
+}
+
+// applyRestore applies the components of the plan in order, stopping at the
+// first that fails
+func (s *BackupService) applyRestore(ctx context.Context, actor model.Actor, plan *restorePlan) error {
+	for _, component := range plan.result.Components {
+		var err error
+		switch component {
+		case dto.BackupComponentDatabase:
+			err = s.restoreDatabase(ctx, plan)
+		case dto.BackupComponentContainerConfigs:
+			err = s.restoreContainers(ctx, actor, plan)
+		case dto.BackupComponentConfigurations:
+			err = restoreConfigurations(plan)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to restore %s: %w", component, err)
+		}
+	}
+	return nil
+}
+
+// restoreContainers creates the saved containers that do not exist and
+// updates those that differ. The comparison is repeated against the current
+// containers, which a database restore just before may have replaced.
+func (s *BackupService) restoreContainers(ctx context.Context, actor model.Actor, plan *restorePlan) error {
+	existing, err := s.containersByName(ctx)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	for i := range plan.result.Containers {
+		change := &plan.result.Containers[i]
+		saved := plan.containers[change.Name]
+		if change.Action == dto.RestoreActionConflict || saved == nil {
+			continue
+		}
+
+		*change = containerRestoreChange(saved, existing[saved.Name])
+		switch change.Action {
+		case dto.RestoreActionCreate:
+			container := &model.Container{
+				Name:         saved.Name,
+				Image:        saved.Image,
+				Tag:          saved.Tag,
+				ConfigJSON:   saved.ConfigJSON,
+				UpdatePolicy: saved.UpdatePolicy,
+				RegistryURL:  saved.RegistryURL,
+				Status:       model.ContainerStatusUnknown,
+				CreatedBy:    actor.OwnerID(),
+			}
+			if container.UpdatePolicy == "" {
+				container.UpdatePolicy = model.UpdatePolicyManual
+			}
+			err = s.containerRepo.Create(ctx, container)
+		case dto.RestoreActionUpdate:
+			current := existing[saved.Name]
+			current.Image = saved.Image
+			current.Tag = saved.Tag
+			current.ConfigJSON = saved.ConfigJSON
+			current.RegistryURL = saved.RegistryURL
+			if saved.UpdatePolicy != "" {
+				current.UpdatePolicy = saved.UpdatePolicy
+			}
+			err = s.containerRepo.Update(ctx, current)
+		default:
+			continue
+		}
+		if err != nil {
+			change.Error = err.Error()
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d containers could not be saved", failed)
+	}
+	return nil
+}
+
+// restoreConfigurations writes the saved configuration files that differ
+// into the working directory
+func restoreConfigurations(plan *restorePlan) error {
+	for _, change := range plan.result.Configurations {
+		if change.Action == dto.RestoreActionUnchanged {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(plan.dir, model.BackupConfigurationsDir, change.File))
+		if err != nil {
+			return err
+		}
+		mode := fs.FileMode(0644)
+		if info, err := os.Stat(change.File); err == nil {
+			mode = info.Mode().Perm()
+		}
+
+		// Write next to the target and rename, so a failure never leaves a
+		// half-written file behind
+		tmp := change.File + ".restore"
+		if err := os.WriteFile(tmp, data, mode); err != nil {
+			return err
+		}
+		if err := os.Rename(tmp, change.File); err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+	return nil
+}
+
+// containersByName returns every container by name
+func (s *BackupService) containersByName(ctx context.Context) (map[string]*model.Container, error) {
+	containers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	byName := make(map[string]*model.Container, len(containers))
+	for _, container := range containers {
+		byName[container.Name] = container
+	}
+	return byName, nil
+}
+
+// reportRestore records a restore in the activity log and sends its
+// completion notification
+func (s *BackupService) reportRestore(actor model.Actor, result *dto.BackupRestoreResult, restoreErr error, duration time.Duration) {
+	var created, updated int
+	for _, change := range result.Containers {
+		if change.Error != "" {
+			continue
+		}
+		switch change.Action {
+		case dto.RestoreActionCreate:
+			created++
+		case dto.RestoreActionUpdate:
+			updated++
+		}
+	}
+
+	if s.activityRepo != nil {
+		metadata, _ := json.Marshal(map[string]interface{}{
+			"components":         result.Components,
+			"success":            restoreErr == nil,
+			"containers_created": created,
+			"containers_updated": updated,
+			"conflicts":          result.Conflicts,
+		})
+		description := fmt.Sprintf("Backup %s restored (%s)", result.BackupID, strings.Join(result.Components, ", "))
+		if restoreErr != nil {
+			description = fmt.Sprintf("Restore of backup %s failed: %v", result.BackupID, restoreErr)
+		}
+		activity := &model.ActivityLog{
+			Action:       "backup_restored",
+			ResourceType: "backup",
+			ResourceName: result.BackupID,
+			Description:  description,
+			Metadata:     string(metadata),
+		}
+		activity.SetActor(actor)
+		if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+			logrus.WithError(err).Warn("Failed to log backup restore")
+		}
+	}
+
+	if s.notificationService == nil {
+		return
+	}
+	payload := model.NewBackupRestorePayload(result.BackupID, result.Components, restoreErr, created, updated, result.Conflicts, duration)
+	notification := &model.Notification{
+		Type:     model.NotificationTypeBackup,
+		Title:    "Backup Restored",
+		Message:  payload.Summary(),
+		Priority: model.NotificationPriorityNormal,
+		Data:     model.NotificationData(payload),
+	}
+	if restoreErr != nil {
+		notification.Title = "Backup Restore Failed"
+		notification.Priority = model.NotificationPriorityHigh
+	}
+	if err := s.notificationService.SendNotification(context.Background(), notification); err != nil {
+		logrus.WithError(err).Warn("Failed to send backup restore notification")
+	}
+}
+
+// readBackupInfo describes the backup in dir
+func readBackupInfo(dir, id string) *dto.BackupInfo {
+	info := &dto.BackupInfo{ID: id, Components: backupComponents(dir)}
+	if createdAt, err := time.ParseInLocation(model.BackupIDLayout, strings.TrimPrefix(id, "backup-"), time.Local); err == nil {
+		info.CreatedAt = createdAt
+	}
+
+	manifest, err := readBackupManifest(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		info.ManifestError = err.Error()
+	default:
+		info.HasManifest = true
+		info.CreatedAt = manifest.CreatedAt
+		info.BackupType = manifest.BackupType
+		info.TotalSize = manifest.TotalSize
+		info.FileCount = manifest.FileCount
+	}
+	return info
+}
+
+// readBackupManifest reads the manifest of the backup in dir
+func readBackupManifest(dir string) (*model.BackupManifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, model.BackupManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var manifest model.BackupManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// backupComponents lists the components a restore can take from the backup
+// in dir
+func backupComponents(dir string) []string {
+	components := []string{}
+	if _, _, err := backupDatabaseFile(dir); err == nil {
+		components = append(components, dto.BackupComponentDatabase)
+	}
+	if info, err := os.Stat(filepath.Join(dir, model.BackupContainerConfigsDir)); err == nil && info.IsDir() {
+		components = append(components, dto.BackupComponentContainerConfigs)
+	}
+	if info, err := os.Stat(filepath.Join(dir, model.BackupConfigurationsDir)); err == nil && info.IsDir() {
+		components = append(components, dto.BackupComponentConfigurations)
+	}
+	return components
+}
+
+// fileChecksum returns the SHA-256 of a file
+func fileChecksum(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
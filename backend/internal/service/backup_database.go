@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// Formats of the database dump in a backup
+const (
+	backupFormatPgDump = "pg_dump"
+	backupFormatJSONL  = "jsonl"
+)
+
+// backupDatabaseFile returns the database dump of the backup in dir and its
+// format
+func backupDatabaseFile(dir string) (string, string, error) {
+	for _, candidate := range []struct{ file, format string }{
+		{model.BackupDatabaseDumpFile, backupFormatPgDump},
+		{model.BackupDatabaseExportFile, backupFormatJSONL},
+	} {
+		if _, err := os.Stat(filepath.Join(dir, candidate.file)); err == nil {
+			return candidate.file, candidate.format, nil
+		}
+	}
+	return "", "", fs.ErrNotExist
+}
+
+// planDatabaseRestore counts the rows of each table in the dump against the
+// current tables
+func (s *BackupService) planDatabaseRestore(ctx context.Context, plan *restorePlan) error {
+	if s.db == nil {
+		return fmt.Errorf("database not configured")
+	}
+	file, format, err := backupDatabaseFile(plan.dir)
+	if err != nil {
+		return fmt.Errorf("invalid request: backup has no database dump")
+	}
+
+	postgres := s.db.Dialector.Name() == "postgres"
+	switch {
+	case format == backupFormatPgDump && !postgres:
+		return fmt.Errorf("invalid request: a pg_dump backup can only be restored to PostgreSQL")
+	case format == backupFormatJSONL && postgres:
+		return fmt.Errorf("invalid request: a table export can only be restored to the database type it was taken from")
+	}
+
+	backupRows := map[string]int64{}
+	_, err = plan.readFile(file, func(r io.Reader) error {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		if format == backupFormatPgDump {
+			return countDumpRows(gz, backupRows)
+		}
+		return countExportRows(gz, backupRows)
+	})
+	if err != nil {
+		return fmt.Errorf("invalid request: database dump cannot be read: %v", err)
+	}
+
+	tables, err := modelTables(s.db)
+	if err != nil {
+		return err
+	}
+	known := make(map[string]bool, len(tables))
+	for _, table := range tables {
+		known[table] = true
+	}
+	for table := range backupRows {
+		if !known[table] {
+			if format == backupFormatJSONL {
+				return fmt.Errorf("invalid request: database dump holds unknown table %s", table)
+			}
+			tables = append(tables, table)
+		}
+	}
+	sort.Strings(tables)
+
+	diff := &dto.DatabaseRestoreDiff{File: file, Format: format, Tables: make([]dto.TableRestoreDiff, 0, len(tables))}
+	for _, table := range tables {
+		entry := dto.TableRestoreDiff{Table: table, BackupRows: backupRows[table]}
+		if s.db.Migrator().HasTable(table) {
+			if err := s.db.WithContext(ctx).Table(table).Count(&entry.CurrentRows).Error; err != nil {
+				return fmt.Errorf("failed to count rows of %s: %w", table, err)
+			}
+		}
+		diff.Tables = append(diff.Tables, entry)
+	}
+
+	plan.result.Database = diff
+	return nil
+}
+
+// restoreDatabase replaces the database with the dump and migrates it to the
+// current schema. It refuses to start while scheduled tasks execute and
+// keeps the scheduler stopped until it is done.
+func (s *BackupService) restoreDatabase(ctx context.Context, plan *restorePlan) error {
+	if s.schedulerService != nil {
+		if running := s.schedulerService.RunningTaskCount(); running > 0 {
+			return fmt.Errorf("%w: %d scheduled task executions are running", ErrRestoreBusy, running)
+		}
+		if s.schedulerService.IsRunning() {
+			if err := s.schedulerService.Stop(ctx); err != nil {
+				return fmt.Errorf("failed to stop scheduler: %w", err)
+			}
+			defer func() {
+				if err := s.schedulerService.Start(context.Background()); err != nil {
+					logrus.WithError(err).Error("Failed to restart scheduler after database restore")
+				}
+			}()
+		}
+	}
+
+	file, err := os.Open(filepath.Join(plan.dir, plan.result.Database.File))
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return err
+	}
+
+	if plan.result.Database.Format == backupFormatPgDump {
+		err = restorePostgresDump(ctx, s.config, gz)
+	} else {
+		err = importTables(ctx, s.db, gz)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Statements prepared against the replaced tables are stale
+	if prepared, ok := s.db.ConnPool.(*gorm.PreparedStmtDB); ok {
+		prepared.Reset()
+	}
+
+	if err := model.AutoMigrate(s.db); err != nil {
+		return fmt.Errorf("failed to migrate restored database: %w", err)
+	}
+	return nil
+}
+
+// restorePostgresDump applies a plain pg_dump with psql in one transaction.
+// The public schema is emptied first, in the same transaction, since the
+// dump recreates every object in it.
+func restorePostgresDump(ctx context.Context, cfg *config.Config, dump io.Reader) error {
+	path, err := exec.LookPath("psql")
+	if err != nil {
+		return fmt.Errorf("psql not found: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, path, "--no-psqlrc", "--quiet", "--single-transaction", "--set", "ON_ERROR_STOP=1")
+	cmd.Env = append(os.Environ(), cfg.GetLibpqEnv()...)
+	cmd.Stdin = io.MultiReader(strings.NewReader("DROP SCHEMA public CASCADE;\nCREATE SCHEMA public;\n"), dump)
+	var stderr bytes.Buffer
+	cmd.Stdout = io.Discard
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("psql failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("psql failed: %w", err)
+	}
+	return nil
+}
+
+// importTables empties the application tables and inserts the rows of a
+// table export, all in one transaction
+func importTables(ctx context.Context, db *gorm.DB, export io.Reader) error {
+	tables, err := modelTables(db)
+	if err != nil {
+		return err
+	}
+
+	return db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		// Children before parents
+		for i := len(tables) - 1; i >= 0; i-- {
+			if !tx.Migrator().HasTable(tables[i]) {
+				continue
+			}
+			if err := tx.Exec("DELETE FROM " + tx.Statement.Quote(tables[i])).Error; err != nil {
+				return fmt.Errorf("failed to empty %s: %w", tables[i], err)
+			}
+		}
+
+		decoder := json.NewDecoder(export)
+		decoder.UseNumber()
+		for {
+			var line struct {
+				Table string                 `json:"table"`
+				Row   map[string]interface{} `json:"row"`
+			}
+			err := decoder.Decode(&line)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("invalid table export: %w", err)
+			}
+			if err := tx.Table(line.Table).Create(line.Row).Error; err != nil {
+				return fmt.Errorf("failed to insert into %s: %w", line.Table, err)
+			}
+		}
+	})
+}
+
+// countDumpRows counts the rows of each COPY block in a plain pg_dump
+func countDumpRows(dump io.Reader, counts map[string]int64) error {
+	reader := bufio.NewReaderSize(dump, 64*1024)
+	table := ""
+	lineStart := true
+	for {
+		// Rows can be longer than the buffer; only the first chunk of a line
+		// is looked at
+		chunk, err := reader.ReadSlice('\n')
+		if lineStart && len(chunk) > 0 {
+			switch {
+			case table == "" && bytes.HasPrefix(chunk, []byte("COPY ")):
+				table = dumpCopyTable(string(chunk))
+			case table != "" && string(bytes.TrimRight(chunk, "\r\n")) == `\.`:
+				table = ""
+			case table != "":
+				counts[table]++
+			}
+		}
+		lineStart = err == nil
+
+		switch {
+		case errors.Is(err, bufio.ErrBufferFull):
+		case errors.Is(err, io.EOF):
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
+
+// dumpCopyTable returns the table of a "COPY public.name (...) FROM stdin;"
+// line without its schema
+func dumpCopyTable(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 2 {
+		return ""
+	}
+	name := fields[1]
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		name = name[i+1:]
+	}
+	return strings.Trim(name, `"`)
+}
+
+// countExportRows counts the rows of each table in a table export
+func countExportRows(export io.Reader, counts map[string]int64) error {
+	decoder := json.NewDecoder(export)
+	for {
+		var line struct {
+			Table string `json:"table"`
+		}
+		err := decoder.Decode(&line)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		counts[line.Table]++
+	}
+}
+
+// modelTables returns the tables of model.AllModels in migration order
+func modelTables(db *gorm.DB) ([]string, error) {
+	models := model.AllModels()
+	tables := make([]string, 0, len(models))
+	for _, m := range models {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return nil, fmt.Errorf("failed to parse model %T: %w", m, err)
+		}
+		tables = append(tables, stmt.Schema.Table)
+	}
+	return tables, nil
+}
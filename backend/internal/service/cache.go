@@ -8,7 +8,7 @@ import (
 	"time"
 
 	"docker-auto/internal/config"
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
 	"github.com/sirupsen/logrus"
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrChangeFeedGap is returned when changes after the requested seq have
+// already been pruned, so the consumer has to resynchronize
+var ErrChangeFeedGap = errors.New("requested changes have been pruned")
+
+const (
+	defaultChangeFeedLimit = 100
+	maxChangeFeedLimit     = 1000
+)
+
+// ChangeFeedService serves the container change feed and pushes it to the
+// configured webhook
+type ChangeFeedService struct {
+	changeRepo repository.ContainerChangeRepository
+	configRepo repository.SystemConfigRepository
+	client     *http.Client
+}
+
+// ChangeFeedPage is a batch of change records read from a cursor
+type ChangeFeedPage struct {
+	Changes   []*model.ContainerChange `json:"changes"`
+	NextSeq   int64                    `json:"next_seq"` // since_seq for the next page
+	OldestSeq int64                    `json:"oldest_seq"`
+	LatestSeq int64                    `json:"latest_seq"`
+	HasMore   bool                     `json:"has_more"`
+}
+
+// ChangeFeedWebhookBody is the JSON body of a change feed webhook delivery.
+// Deliveries are at least once; consumers deduplicate on seq.
+type ChangeFeedWebhookBody struct {
+	FirstSeq int64                    `json:"first_seq"`
+	LastSeq  int64                    `json:"last_seq"`
+	Changes  []*model.ContainerChange `json:"changes"`
+}
+
+// NewChangeFeedService creates a new change feed service
+func NewChangeFeedService(changeRepo repository.ContainerChangeRepository, configRepo repository.SystemConfigRepository) *ChangeFeedService {
+	return &ChangeFeedService{
+		changeRepo: changeRepo,
+		configRepo: configRepo,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// ListChanges returns up to limit change records after sinceSeq in seq order
+func (s *ChangeFeedService) ListChanges(ctx context.Context, sinceSeq int64, limit int) (*ChangeFeedPage, error) {
+	if sinceSeq < 0 {
+		return nil, fmt.Errorf("invalid request: since_seq cannot be negative")
+	}
+	if limit <= 0 {
+		limit = defaultChangeFeedLimit
+	}
+	if limit > maxChangeFeedLimit {
+		limit = maxChangeFeedLimit
+	}
+
+	oldest, latest, err := s.changeRepo.GetSeqRange(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if oldest > 0 && sinceSeq < oldest-1 {
+		return nil, ErrChangeFeedGap
+	}
+
+	changes, err := s.changeRepo.ListSince(ctx, sinceSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &ChangeFeedPage{
+		Changes:   changes,
+		NextSeq:   sinceSeq,
+		OldestSeq: oldest,
+		LatestSeq: latest,
+	}
+	if len(changes) > 0 {
+		page.NextSeq = changes[len(changes)-1].Seq
+	}
+	page.HasMore = page.NextSeq < latest
+
+	return page, nil
+}
+
+// DeliverWebhook pushes undelivered changes to the change feed webhook in seq
+// order, at most maxBatches batches of batchSize. The webhook cursor only
+// advances once a batch is acknowledged with a 2xx response, so a failed
+// batch is sent again on the next run. It returns the number of changes
+// delivered.
+func (s *ChangeFeedService) DeliverWebhook(ctx context.Context, batchSize, maxBatches int) (int, error) {
+	url := s.webhookURL(ctx)
+	if url == "" {
+		return 0, nil
+	}
+
+	cursor, err := s.changeRepo.GetCursor(ctx, model.ChangeFeedWebhookConsumer)
+	if err != nil {
+		return 0, err
+	}
+
+	delivered := 0
+	for batch := 0; batch < maxBatches; batch++ {
+		changes, err := s.changeRepo.ListSince(ctx, cursor, batchSize)
+		if err != nil {
+			return delivered, err
+		}
+		if len(changes) == 0 {
+			break
+		}
+
+		body := &ChangeFeedWebhookBody{
+			FirstSeq: changes[0].Seq,
+			LastSeq:  changes[len(changes)-1].Seq,
+			Changes:  changes,
+		}
+		if err := s.postWebhook(ctx, url, body); err != nil {
+			return delivered, fmt.Errorf("failed to deliver changes %d-%d: %w", body.FirstSeq, body.LastSeq, err)
+		}
+
+		if err := s.changeRepo.SetCursor(ctx, model.ChangeFeedWebhookConsumer, body.LastSeq); err != nil {
+			return delivered, err
+		}
+		cursor = body.LastSeq
+		delivered += len(changes)
+
+		if len(changes) < batchSize {
+			break
+		}
+	}
+
+	return delivered, nil
+}
+
+// PruneChanges deletes change records older than the retention period, or only
+// counts them on a dry run. Changes the webhook has not acknowledged yet are
+// kept regardless of age.
+func (s *ChangeFeedService) PruneChanges(ctx context.Context, retentionDays int, dryRun bool) (int64, error) {
+	cutoffDate := time.Now().AddDate(0, 0, -retentionDays)
+
+	maxSeq := int64(math.MaxInt64)
+	if s.webhookURL(ctx) != "" {
+		cursor, err := s.changeRepo.GetCursor(ctx, model.ChangeFeedWebhookConsumer)
+		if err != nil {
+			return 0, err
+		}
+		maxSeq = cursor
+	}
+
+	if dryRun {
+		return s.changeRepo.CountOlderThan(ctx, cutoffDate, maxSeq)
+	}
+	return s.changeRepo.DeleteOlderThan(ctx, cutoffDate, maxSeq)
+}
+
+func (s *ChangeFeedService) postWebhook(ctx context.Context, url string, body *ChangeFeedWebhookBody) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (s *ChangeFeedService) webhookURL(ctx context.Context) string {
+	if s.configRepo == nil {
+		return ""
+	}
+
+	config, err := s.configRepo.GetByKey(ctx, model.ConfigKeyChangesWebhookURL)
+	if err != nil {
+		return ""
+	}
+
+	url, err := config.GetStringValue()
+	if err != nil {
+		logrus.WithError(err).Warn("Invalid change feed webhook URL setting")
+		return ""
+	}
+	return strings.TrimSpace(url)
+}
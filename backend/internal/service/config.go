@@ -0,0 +1,295 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ConfigService shows the configuration in effect and changes the settings
+// that can be reloaded while running. Changes apply to the logger, the
+// scheduler and the rate limiter at once and are kept in the system
+// settings, where they win over the environment on the next start.
+type ConfigService struct {
+	config           *config.Config
+	configRepo       repository.SystemConfigRepository
+	activityRepo     repository.ActivityLogRepository
+	logger           *logrus.Logger
+	schedulerService *SchedulerService
+	rateLimitService *RateLimitService
+
+	// mu serializes changes, which check every setting before applying any
+	mu sync.Mutex
+	// environment holds the reloadable settings as read at startup, restored
+	// when their override is cleared
+	environment map[string]string
+	overrides   map[string]string
+}
+
+// NewConfigService creates a new config service instance. The scheduler and
+// rate limit services are optional.
+func NewConfigService(
+	cfg *config.Config,
+	configRepo repository.SystemConfigRepository,
+	activityRepo repository.ActivityLogRepository,
+	logger *logrus.Logger,
+	schedulerService *SchedulerService,
+	rateLimitService *RateLimitService,
+) *ConfigService {
+	environment := make(map[string]string)
+	for _, key := range config.ReloadableKeys() {
+		if value, err := cfg.Value(key); err == nil {
+			environment[key] = value
+		}
+	}
+
+	return &ConfigService{
+		config:           cfg,
+		configRepo:       configRepo,
+		activityRepo:     activityRepo,
+		logger:           logger,
+		schedulerService: schedulerService,
+		rateLimitService: rateLimitService,
+		environment:      environment,
+		overrides:        make(map[string]string),
+	}
+}
+
+// LoadOverrides applies the overrides kept in the system settings over the
+// environment and the reloadable settings to the running components. It is
+// called once at startup; invalid overrides are skipped.
+func (s *ConfigService) LoadOverrides(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.configRepo != nil {
+		stored, err := s.configRepo.GetByKey(ctx, model.ConfigKeyAppSettingOverrides)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return fmt.Errorf("failed to load setting overrides: %w", err)
+		}
+		if stored != nil {
+			var overrides map[string]string
+			if err := json.Unmarshal([]byte(stored.ConfigValue), &overrides); err != nil {
+				return fmt.Errorf("invalid setting overrides: %w", err)
+			}
+			for key, value := range overrides {
+				if err := s.config.Set(key, value); err != nil {
+					logrus.WithError(err).WithField("setting", key).Warn("Ignoring invalid setting override")
+					continue
+				}
+				s.overrides[key] = value
+			}
+		}
+	}
+
+	s.apply(config.ReloadableKeys())
+
+	if len(s.overrides) > 0 {
+		logrus.WithField("overrides", len(s.overrides)).Info("Loaded setting overrides")
+	}
+	return nil
+}
+
+// GetConfig returns every setting with the value in effect
+func (s *ConfigService) GetConfig() *dto.ConfigSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	settings := s.config.Settings()
+	result := &dto.ConfigSettings{Settings: make([]dto.ConfigSetting, 0, len(settings))}
+	for _, setting := range settings {
+		source := dto.ConfigSourceEnvironment
+		if _, ok := s.overrides[setting.Key]; ok {
+			source = dto.ConfigSourceOverride
+		}
+		result.Settings = append(result.Settings, dto.ConfigSetting{
+			Key:        setting.Key,
+			Value:      setting.Value,
+			Source:     source,
+			Reloadable: setting.Reloadable,
+			Redacted:   setting.Redacted,
+		})
+	}
+	return result
+}
+
+// PatchConfig changes reloadable settings, or restores their value from the
+// environment when given null. Every setting is checked before any is
+// applied, so a rejected one leaves the configuration unchanged. Settings
+// only read at startup are refused with a restart required error.
+func (s *ConfigService) PatchConfig(ctx context.Context, actor model.Actor, req *dto.PatchConfigRequest) (*dto.ConfigSettings, error) {
+	if req == nil || len(req.Settings) == 0 {
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "invalid request: settings cannot be empty")
+	}
+
+	keys := make([]string, 0, len(req.Settings))
+	for key := range req.Settings {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	s.mu.Lock()
+	values := make(map[string]string, len(keys))
+	staged := *s.config
+	for _, key := range keys {
+		value, err := s.settingValue(key, req.Settings[key])
+		if err == nil {
+			err = staged.Set(key, value)
+		}
+		if err != nil {
+			s.mu.Unlock()
+			return nil, settingError(err)
+		}
+		values[key] = value
+	}
+
+	overrides := make(map[string]string, len(s.overrides)+len(keys))
+	for key, value := range s.overrides {
+		overrides[key] = value
+	}
+	for _, key := range keys {
+		if req.Settings[key] == nil {
+			delete(overrides, key)
+		} else {
+			overrides[key] = values[key]
+		}
+	}
+	if err := s.saveOverrides(ctx, overrides); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+
+	for _, key := range keys {
+		// Checked on the staged copy above
+		s.config.Set(key, values[key])
+	}
+	s.overrides = overrides
+	s.apply(keys)
+	s.mu.Unlock()
+
+	s.logChange(actor, keys, values)
+	logrus.WithFields(logrus.Fields{
+		"settings": strings.Join(keys, ","),
+		"actor":    actor.String(),
+	}).Info("Configuration changed")
+
+	return s.GetConfig(), nil
+}
+
+// settingValue returns the value a setting is set to: the one requested, or
+// the environment's for null
+func (s *ConfigService) settingValue(key string, requested interface{}) (string, error) {
+	switch v := requested.(type) {
+	case nil:
+		return s.environment[key], nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	}
+	return "", &config.SettingError{Key: key, Err: errors.New("must be a string, number or boolean")}
+}
+
+// apply hands the changed settings to the components that read them once
+func (s *ConfigService) apply(keys []string) {
+	changed := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		changed[key] = true
+	}
+
+	if changed["LOG_LEVEL"] {
+		if level, err := logrus.ParseLevel(s.config.LogLevel); err == nil {
+			logrus.SetLevel(level)
+			if s.logger != nil {
+				s.logger.SetLevel(level)
+			}
+		}
+	}
+
+	limits := s.config.Scheduler
+	if (changed["SCHEDULER_MAX_CONCURRENT_TASKS"] || changed["SCHEDULER_TASK_TIMEOUT"]) &&
+		s.schedulerService != nil && limits.MaxConcurrentTasks > 0 && limits.TaskTimeout > 0 {
+		s.schedulerService.SetLimits(limits.MaxConcurrentTasks, limits.TaskTimeout)
+	}
+
+	if (changed["RATE_LIMIT_BANNING_ENABLED"] || changed["RATE_LIMIT_DYNAMIC_ENABLED"]) && s.rateLimitService != nil {
+		s.rateLimitService.SetToggles(s.config.Security.RateLimitBanningEnabled, s.config.Security.RateLimitDynamicEnabled)
+	}
+}
+
+// saveOverrides writes the overrides to the system settings
+func (s *ConfigService) saveOverrides(ctx context.Context, overrides map[string]string) error {
+	if s.configRepo == nil {
+		return fmt.Errorf("setting overrides are not available")
+	}
+
+	value, err := json.Marshal(overrides)
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.configRepo.GetByKey(ctx, model.ConfigKeyAppSettingOverrides)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("failed to get setting overrides: %w", err)
+	}
+	if existing == nil {
+		err = s.configRepo.Create(ctx, &model.SystemConfig{
+			ConfigKey:   model.ConfigKeyAppSettingOverrides,
+			ConfigValue: string(value),
+			Description: "Hot-reloadable settings changed through the API",
+		})
+	} else {
+		updated := *existing
+		updated.ConfigValue = string(value)
+		err = s.configRepo.Update(ctx, &updated)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save setting overrides: %w", err)
+	}
+	return nil
+}
+
+// logChange records a configuration change in the activity log
+func (s *ConfigService) logChange(actor model.Actor, keys []string, values map[string]string) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{"settings": values})
+	activity := &model.ActivityLog{
+		Action:       "config_changed",
+		ResourceType: "config",
+		ResourceName: strings.Join(keys, ","),
+		Description:  "Configuration changed: " + strings.Join(keys, ", "),
+		Metadata:     string(metadata),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).Warn("Failed to log configuration change")
+	}
+}
+
+// settingError gives a rejected setting change its error code. The message
+// names the setting.
+func settingError(err error) error {
+	if errors.Is(err, config.ErrRestartRequired) {
+		return apperrors.Wrap(err, apperrors.CodeRestartRequired, "restart required")
+	}
+	return apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+}
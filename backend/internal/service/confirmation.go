@@ -0,0 +1,64 @@
+package service
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrConfirmationInvalid is returned for confirmation tokens that are
+// malformed, expired, or issued for a preview that no longer matches what the
+// operation would do
+var ErrConfirmationInvalid = errors.New("confirmation token is invalid, expired or out of date; preview the operation again")
+
+// confirmationTokenTTL bounds how long a preview can be confirmed
+const confirmationTokenTTL = 10 * time.Minute
+
+// confirmationTokens issues stateless tokens binding the execution of a bulk
+// operation to the preview the user reviewed. A token signs the operation,
+// the user, a digest of the preview and an expiry.
+type confirmationTokens struct {
+	secret []byte
+}
+
+func newConfirmationTokens(secret string) *confirmationTokens {
+	return &confirmationTokens{secret: []byte("confirmation:" + secret)}
+}
+
+// Issue returns a token for the operation previewed by userID, together with
+// its expiry
+func (t *confirmationTokens) Issue(operation string, userID int64, preview string) (string, time.Time) {
+	expiresAt := time.Now().Add(confirmationTokenTTL).Truncate(time.Second)
+	expiry := strconv.FormatInt(expiresAt.Unix(), 10)
+	return expiry + "." + t.sign(operation, userID, preview, expiry), expiresAt
+}
+
+// Verify checks a token against the operation and a freshly computed preview
+// digest, so a confirmation fails if the affected resources changed since the
+// preview
+func (t *confirmationTokens) Verify(token, operation string, userID int64, preview string) error {
+	expiry, signature, found := strings.Cut(token, ".")
+	if !found {
+		return ErrConfirmationInvalid
+	}
+	unix, err := strconv.ParseInt(expiry, 10, 64)
+	if err != nil || time.Now().After(time.Unix(unix, 0)) {
+		return ErrConfirmationInvalid
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(t.sign(operation, userID, preview, expiry))) {
+		return ErrConfirmationInvalid
+	}
+	return nil
+}
+
+func (t *confirmationTokens) sign(operation string, userID int64, preview, expiry string) string {
+	mac := hmac.New(sha256.New, t.secret)
+	fmt.Fprintf(mac, "%s\n%d\n%s\n%s", operation, userID, preview, expiry)
+	return hex.EncodeToString(mac.Sum(nil))
+}
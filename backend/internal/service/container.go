@@ -4,60 +4,133 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strconv"
 	"time"
 
 	"docker-auto/internal/config"
-	"docker-auto/internal/model"
+	"docker-auto/internal/dto"
 	"docker-auto/internal/repository"
 	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/events"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
 
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
 	"github.com/sirupsen/logrus"
 )
 
+// ErrContainerNotCreated is returned for Docker operations on a managed
+// container that has no Docker container
+var ErrContainerNotCreated = apperrors.New(apperrors.CodeContainerNotCreated, "container has no Docker instance")
+
 // ContainerService manages container operations and business logic
 type ContainerService struct {
 	containerRepo     repository.ContainerRepository
 	updateHistoryRepo repository.UpdateHistoryRepository
+	updateNoteRepo    repository.UpdateNoteRepository
 	activityRepo      repository.ActivityLogRepository
 	dockerClient      *docker.DockerClient
 	cache             *CacheService
 	config            *config.Config
 	userService       *UserService
+	policyService     *ImagePolicyService
+	stackRepo         repository.StackRepository
+	configRepo        repository.SystemConfigRepository
+	healthStateRepo   repository.ContainerHealthStateRepository
+	healthCheckRepo   repository.ContainerHealthCheckRepository
+	imageVersionRepo  repository.ImageVersionRepository
+	metricRepo        repository.ContainerMetricRepository
+	permissionRepo    repository.ContainerPermissionRepository
+	webhookService    *WebhookService
+	teamService       *TeamService
+	imageService      *ImageService
+	tokens            *confirmationTokens
+	syncState         *containerSyncState
+	restarts          *restartTracker
+	updates           *updateDrain
+	progress          *updateProgressRegistry
+	hostRepo          repository.DockerHostRepository
+	hostPool          *docker.HostPool
+	publisher         events.Publisher
+
+	notificationService *NotificationService
+	secretService       *SecretService
 }
 
 // NewContainerService creates a new container service instance
 func NewContainerService(
 	containerRepo repository.ContainerRepository,
 	updateHistoryRepo repository.UpdateHistoryRepository,
+	updateNoteRepo repository.UpdateNoteRepository,
 	activityRepo repository.ActivityLogRepository,
 	dockerClient *docker.DockerClient,
 	cache *CacheService,
 	config *config.Config,
 	userService *UserService,
+	policyService *ImagePolicyService,
+	stackRepo repository.StackRepository,
+	configRepo repository.SystemConfigRepository,
+	healthStateRepo repository.ContainerHealthStateRepository,
+	healthCheckRepo repository.ContainerHealthCheckRepository,
+	imageVersionRepo repository.ImageVersionRepository,
+	metricRepo repository.ContainerMetricRepository,
+	permissionRepo repository.ContainerPermissionRepository,
+	webhookService *WebhookService,
+	teamService *TeamService,
+	imageService *ImageService,
+	hostRepo repository.DockerHostRepository,
+	hostPool *docker.HostPool,
+	publisher events.Publisher,
+	notificationService *NotificationService,
+	secretService *SecretService,
 ) *ContainerService {
 	return &ContainerService{
 		containerRepo:     containerRepo,
 		updateHistoryRepo: updateHistoryRepo,
+		updateNoteRepo:    updateNoteRepo,
 		activityRepo:      activityRepo,
 		dockerClient:      dockerClient,
 		cache:             cache,
 		config:            config,
 		userService:       userService,
+		policyService:     policyService,
+		stackRepo:         stackRepo,
+		configRepo:        configRepo,
+		healthStateRepo:   healthStateRepo,
+		healthCheckRepo:   healthCheckRepo,
+		imageVersionRepo:  imageVersionRepo,
+		metricRepo:        metricRepo,
+		permissionRepo:    permissionRepo,
+		webhookService:    webhookService,
+		teamService:       teamService,
+		imageService:      imageService,
+		tokens:            newConfirmationTokens(config.JWT.Secret),
+		syncState:         newContainerSyncState(),
+		restarts:          newRestartTracker(),
+		updates:           &updateDrain{},
+		progress:          newUpdateProgressRegistry(),
+		hostRepo:          hostRepo,
+		hostPool:          hostPool,
+		publisher:         publisher,
+
+		notificationService: notificationService,
+		secretService:       secretService,
 	}
 }
 
 // Container CRUD operations
 
 // CreateContainer creates a new container configuration
-func (s *ContainerService) CreateContainer(ctx context.Context, userID int64, req *CreateContainerRequest) (*model.Container, error) {
+func (s *ContainerService) CreateContainer(ctx context.Context, actor model.Actor, req *dto.CreateContainerRequest) (*model.Container, error) {
 	if req == nil {
 		return nil, fmt.Errorf("create container request cannot be nil")
 	}
 
 	if err := req.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
 	}
 
 	// Check if container name already exists
@@ -66,12 +139,18 @@ func (s *ContainerService) CreateContainer(ctx context.Context, userID int64, re
 		return nil, fmt.Errorf("failed to check container existence: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("container with name '%s' already exists", req.Name)
+		return nil, apperrors.Newf(apperrors.CodeContainerExists, "container with name '%s' already exists", req.Name)
+	}
+
+	// Resolve the daemon the container will run on
+	dc, err := s.hostClient(ctx, req.HostID)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
 	}
 
 	// Validate Docker image exists (optional check)
 	if s.config.Docker.ValidateImages {
-		if err := s.validateImageExists(ctx, req.Image, req.Tag); err != nil {
+		if err := s.validateImageExists(ctx, dc, nil, req.Image, req.Tag); err != nil {
 			logrus.WithError(err).WithFields(logrus.Fields{
 				"image": req.Image,
 				"tag":   req.Tag,
@@ -80,7 +159,6 @@ func (s *ContainerService) CreateContainer(ctx context.Context, userID int64, re
 	}
 
 	// Create container model
-	userIDInt := int(userID)
 	container := &model.Container{
 		Name:         req.Name,
 		Image:        req.Image,
@@ -88,7 +166,43 @@ func (s *ContainerService) CreateContainer(ctx context.Context, userID int64, re
 		Status:       model.ContainerStatusStopped,
 		UpdatePolicy: model.UpdatePolicy(req.UpdatePolicy),
 		RegistryURL:  req.RegistryURL,
-		CreatedBy:    &userIDInt,
+		CreatedBy:    actor.OwnerID(),
+
+		VersionPolicy:          model.VersionPolicy(req.VersionPolicy),
+		CheckIntervalMinutes:   req.CheckIntervalMinutes,
+		HoldDownHours:          req.HoldDownHours,
+		VulnerabilityThreshold: req.VulnerabilityThreshold,
+		SecretEnv:              model.StringList(req.SecretEnv),
+		WarmupSeconds:          req.WarmupSeconds,
+		PostStartHooks:         req.PostStartHooks,
+		TeamID:                 req.TeamID,
+		HostID:                 req.HostID,
+		RestartPolicy:          req.RestartPolicy,
+		StopTimeoutSeconds:     req.StopTimeoutSeconds,
+		StopSignal:             req.StopSignal,
+	}
+
+	if container.PostStartHooks.HasExec() {
+		if err := s.checkExecPermission(ctx, container, actor); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.MaintenanceWindows != nil {
+		windowsJSON, err := json.Marshal(req.MaintenanceWindows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal maintenance windows: %w", err)
+		}
+		container.MaintenanceWindows = string(windowsJSON)
+	}
+
+	if req.PinByDigest {
+		digest, err := s.resolvePinnedDigest(ctx, dc, container, req.ImageDigest)
+		if err != nil {
+			return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+		}
+		container.PinByDigest = true
+		container.ImageDigest = digest
 	}
 
 	// Set configuration JSON
@@ -106,64 +220,106 @@ func (s *ContainerService) CreateContainer(ctx context.Context, userID int64, re
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal registry auth: %w", err)
 		}
-		container.RegistryAuth = string(authJSON)
+		if err := s.sealRegistryAuth(container, string(authJSON)); err != nil {
+			return nil, err
+		}
 	}
 
-	// Save to database
-	if err := s.containerRepo.Create(ctx, container); err != nil {
-		return nil, fmt.Errorf("failed to create container: %w", err)
+	// Save to database, within the team's quota
+	err = s.admitToTeam(ctx, actor, container, func() error {
+		if err := s.containerRepo.Create(ctx, container); err != nil {
+			return fmt.Errorf("failed to create container: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.grantCreatorPermission(ctx, container); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to grant the creator manage on the container")
 	}
+	s.refreshContainerMetrics(ctx)
 
 	// Log activity
-	s.logContainerActivity(userID, int64(container.ID), "container_created", "Container created successfully", map[string]interface{}{
+	s.logContainerActivity(actor, int64(container.ID), "container_created", "Container created successfully", map[string]interface{}{
 		"container_name": container.Name,
 		"image":          container.GetFullImageName(),
 		"update_policy":  container.UpdatePolicy,
 	})
 
 	// Invalidate cache
-	s.invalidateContainerCache(userID)
+	s.invalidateContainerCache(actor)
 
 	logrus.WithFields(logrus.Fields{
 		"container_id":   container.ID,
 		"container_name": container.Name,
-		"user_id":        userID,
+		"actor":          actor.String(),
 		"image":          container.GetFullImageName(),
 	}).Info("Container created successfully")
 
 	return container, nil
 }
 
-// GetContainer retrieves container details by ID
-func (s *ContainerService) GetContainer(ctx context.Context, userID int64, containerID int64) (*ContainerDetail, error) {
+// GetContainer retrieves container details by ID. Secret environment and
+// label values are masked unless reveal is set.
+func (s *ContainerService) GetContainer(ctx context.Context, actor model.Actor, containerID int64, reveal bool) (*dto.ContainerDetail, error) {
 	// Get container from database
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container: %w", err)
 	}
 
-	// Check user permissions
-	if err := s.checkContainerPermission(container, userID); err != nil {
+	// Check user permissions; secrets are revealed to those who manage it
+	required := model.ContainerPermissionView
+	if reveal {
+		required = model.ContainerPermissionManage
+	}
+	permission, err := s.authorizeContainer(ctx, container, actor, required)
+	if err != nil {
 		return nil, err
 	}
 
 	// Build detailed response
-	detail := &ContainerDetail{
-		Container: container,
+	detail := &dto.ContainerDetail{
+		Container:   container,
+		HasWarnings: container.HasWarnings(),
+		Permission:  permission,
 	}
 
-	// Get Docker status if container has Docker ID
-	if container.ContainerID != "" {
-		if dockerStatus, err := s.dockerClient.GetContainerStatus(ctx, container.ContainerID); err == nil {
+	// The live details below are skipped when the container's host is unavailable
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Docker host unavailable")
+	}
+
+	// Get Docker status and ports if container has Docker ID
+	if container.ContainerID != "" && dc != nil {
+		if dockerStatus, ports, err := dc.GetContainerStatusAndPorts(ctx, container.ContainerID); err == nil {
 			detail.DockerStatus = &dockerStatus
+			detail.Ports = ports
 		} else {
 			logrus.WithError(err).WithField("container_id", container.ContainerID).Warn("Failed to get Docker status")
 		}
 	}
 
+	// Compare the configured resource limits with the applied ones
+	var live *types.ContainerJSON
+	if container.ContainerID != "" && dc != nil {
+		if inspected, err := dc.GetContainer(ctx, container.ContainerID); err == nil {
+			live = inspected
+		}
+	}
+	detail.Limits = containerLimits(container, live)
+
+	// Show the stored env and label changes the Docker container lacks
+	if container.Drifted && live != nil {
+		detail.PendingChanges = pendingConfigChanges(ctx, dc, container, live, reveal)
+		detail.PendingRecreate = len(detail.PendingChanges) > 0
+	}
+
 	// Get metrics if container is running
-	if container.IsRunning() && container.ContainerID != "" {
-		if metrics, err := s.getContainerMetrics(ctx, container.ContainerID); err == nil {
+	if container.IsRunning() && container.ContainerID != "" && dc != nil {
+		if metrics, err := s.getContainerMetrics(ctx, dc, container.ContainerID); err == nil {
 			detail.Metrics = metrics
 		}
 	}
@@ -173,35 +329,156 @@ func (s *ContainerService) GetContainer(ctx context.Context, userID int64, conta
 		detail.UpdateInfo = updateInfo
 	}
 
+	// Resolve the effective update policy
+	if effective, err := s.EffectivePolicy(ctx, container); err == nil {
+		detail.EffectivePolicy = effective
+	} else {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to resolve effective policy")
+	}
+
+	// Show the pinned digest against what the tag resolves to now
+	if container.PinByDigest {
+		detail.DigestPin = &dto.DigestPinInfo{
+			Tag:          container.Tag,
+			PinnedDigest: container.ImageDigest,
+			TagDigest:    container.PendingDigest,
+			Drift:        container.HasDigestDrift(),
+		}
+
+		if detail.DigestPin.Drift && s.imageVersionRepo != nil {
+			record, err := s.imageVersionRepo.GetVersionRecord(ctx, model.NormalizeRepository(container.Image), container.Tag, container.PendingDigest)
+			if err != nil {
+				logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to get pending image version")
+			} else if record != nil {
+				detail.DigestPin.ReleaseNotesURL = record.ReleaseNotesURL
+			}
+		}
+	}
+
+	// Show the last health check and remediation actions
+	if s.healthStateRepo != nil {
+		if state, err := s.healthStateRepo.GetByContainerID(ctx, container.ID); err == nil {
+			detail.HealthState = state
+		} else {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to get container health state")
+		}
+	}
+	if s.healthCheckRepo != nil {
+		if checks, err := s.healthCheckRepo.ListByContainer(ctx, container.ID); err == nil {
+			detail.HealthChecks = checks
+		} else {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to get container health checks")
+		}
+	}
+
 	// Get recent logs sample
-	if container.ContainerID != "" {
-		if logs, err := s.getLogsSample(ctx, container.ContainerID); err == nil {
+	if container.ContainerID != "" && dc != nil {
+		if logs, err := s.getLogsSample(ctx, dc, container.ContainerID); err == nil {
 			detail.LogsSample = logs
 		}
 	}
 
+	if !reveal {
+		detail.Container = maskedContainer(container)
+	} else if auth, err := s.openRegistryAuth(container); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to decrypt registry login")
+	} else if auth != container.RegistryAuth {
+		revealed := *container
+		revealed.RegistryAuth = auth
+		detail.Container = &revealed
+	}
+
 	return detail, nil
 }
 
-// UpdateContainer updates container configuration
-func (s *ContainerService) UpdateContainer(ctx context.Context, userID int64, containerID int64, req *UpdateContainerRequest) error {
+// EffectivePolicy resolves the update policy that applies to a container,
+// falling back to the container's own settings when no policy service is wired
+func (s *ContainerService) EffectivePolicy(ctx context.Context, container *model.Container) (*model.EffectivePolicy, error) {
+	if s.policyService == nil {
+		return model.ResolveEffectivePolicy(container, nil, nil, model.PolicyDefaults{
+			UpdatePolicy:         model.UpdatePolicyManual,
+			CheckIntervalMinutes: s.config.ImageCheck.DefaultInterval,
+		}), nil
+	}
+	return s.policyService.EffectivePolicy(ctx, container)
+}
+
+// NextUpdateWindow returns when the updater may next apply an update to the
+// container: the first maintenance window occurrence still open once the
+// hold-down since its last update has passed
+func (s *ContainerService) NextUpdateWindow(ctx context.Context, containerID int64) (*dto.UpdateWindow, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	effective, err := s.EffectivePolicy(ctx, container)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve effective policy: %w", err)
+	}
+
+	now := time.Now()
+	result := &dto.UpdateWindow{
+		ContainerID:  containerID,
+		Unrestricted: len(effective.MaintenanceWindows) == 0,
+		Timestamp:    now,
+	}
+
+	earliest := now
+	if effective.HoldDownHours > 0 && s.updateHistoryRepo != nil {
+		histories, _, err := s.updateHistoryRepo.GetByContainerID(ctx, containerID, 1, 0)
+		if err == nil && len(histories) > 0 {
+			holdUntil := histories[0].StartedAt.Add(time.Duration(effective.HoldDownHours) * time.Hour)
+			if holdUntil.After(now) {
+				result.HeldDownUntil = &holdUntil
+				earliest = holdUntil
+			}
+		}
+	}
+
+	if result.Unrestricted {
+		result.NextEligibleAt = &earliest
+		result.Eligible = !earliest.After(now)
+		return result, nil
+	}
+
+	window, ok := model.ParseMaintenanceWindows(effective.MaintenanceWindows).NextOpen(earliest)
+	if !ok {
+		// None of the windows ever opens
+		return result, nil
+	}
+
+	eligibleAt := window.Start
+	if earliest.After(eligibleAt) {
+		eligibleAt = earliest
+	}
+	result.Window = &window
+	result.NextEligibleAt = &eligibleAt
+	result.Eligible = !eligibleAt.After(now)
+
+	return result, nil
+}
+
+// UpdateContainer updates container configuration and returns the warnings the
+// daemon reported applying new resource limits
+func (s *ContainerService) UpdateContainer(ctx context.Context, actor model.Actor, containerID int64, req *dto.UpdateContainerRequest) ([]string, error) {
 	if req == nil {
-		return fmt.Errorf("update container request cannot be nil")
+		return nil, fmt.Errorf("update container request cannot be nil")
 	}
 
 	if err := req.Validate(); err != nil {
-		return fmt.Errorf("invalid request: %w", err)
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
 	}
 
 	// Get existing container
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
-		return fmt.Errorf("failed to get container: %w", err)
+		return nil, fmt.Errorf("failed to get container: %w", err)
 	}
 
 	// Check permissions
-	if err := s.checkContainerPermission(container, userID); err != nil {
-		return err
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionManage); err != nil {
+		return nil, err
 	}
 
 	// Update fields
@@ -211,7 +488,7 @@ func (s *ContainerService) UpdateContainer(ctx context.Context, userID int64, co
 	if req.Config != nil {
 		configJSON, err := json.Marshal(req.Config)
 		if err != nil {
-			return fmt.Errorf("failed to marshal config: %w", err)
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
 		}
 		if container.ConfigJSON != string(configJSON) {
 			container.ConfigJSON = string(configJSON)
@@ -220,58 +497,230 @@ func (s *ContainerService) UpdateContainer(ctx context.Context, userID int64, co
 		}
 	}
 
+	var limits *docker.ResourceConfig
+	limitRemoved := false
+	if req.HasLimitChanges() {
+		resources, changed, removed, err := storeLimits(container, req)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			limits, limitRemoved = resources, removed
+			changes["resources"] = resources
+			updated = true
+		}
+	}
+
 	if req.UpdatePolicy != nil && *req.UpdatePolicy != string(container.UpdatePolicy) {
 		container.UpdatePolicy = model.UpdatePolicy(*req.UpdatePolicy)
 		changes["update_policy"] = *req.UpdatePolicy
 		updated = true
 	}
 
+	if req.VersionPolicy != nil && *req.VersionPolicy != string(container.GetVersionPolicy()) {
+		container.VersionPolicy = model.VersionPolicy(*req.VersionPolicy)
+		changes["version_policy"] = *req.VersionPolicy
+		updated = true
+	}
+
+	// Policy overrides; negative values and empty lists clear the override so the
+	// setting is inherited again
+	if req.CheckIntervalMinutes != nil {
+		container.CheckIntervalMinutes = nil
+		if *req.CheckIntervalMinutes >= 0 {
+			container.CheckIntervalMinutes = req.CheckIntervalMinutes
+		}
+		changes["check_interval_minutes"] = *req.CheckIntervalMinutes
+		updated = true
+	}
+
+	if req.HoldDownHours != nil {
+		container.HoldDownHours = nil
+		if *req.HoldDownHours >= 0 {
+			container.HoldDownHours = req.HoldDownHours
+		}
+		changes["hold_down_hours"] = *req.HoldDownHours
+		updated = true
+	}
+
+	if req.MaintenanceWindows != nil {
+		container.MaintenanceWindows = ""
+		if len(*req.MaintenanceWindows) > 0 {
+			windowsJSON, err := json.Marshal(*req.MaintenanceWindows)
+			if err != nil {
+				return nil, fmt.Errorf("failed to marshal maintenance windows: %w", err)
+			}
+			container.MaintenanceWindows = string(windowsJSON)
+		}
+		changes["maintenance_windows"] = *req.MaintenanceWindows
+		updated = true
+	}
+
+	if req.VulnerabilityThreshold != nil && *req.VulnerabilityThreshold != container.VulnerabilityThreshold {
+		container.VulnerabilityThreshold = *req.VulnerabilityThreshold
+		changes["vulnerability_threshold"] = *req.VulnerabilityThreshold
+		updated = true
+	}
+
 	if req.RegistryURL != nil && *req.RegistryURL != container.RegistryURL {
 		container.RegistryURL = *req.RegistryURL
 		changes["registry_url"] = *req.RegistryURL
 		updated = true
 	}
 
+	if req.PinByDigest != nil && *req.PinByDigest != container.PinByDigest {
+		if *req.PinByDigest {
+			dc, err := s.dockerFor(ctx, container)
+			if err != nil {
+				return nil, err
+			}
+			digest, err := s.resolvePinnedDigest(ctx, dc, container, "")
+			if err != nil {
+				return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+			}
+			container.ImageDigest = digest
+			changes["image_digest"] = digest
+		} else {
+			container.ImageDigest = ""
+		}
+		container.PinByDigest = *req.PinByDigest
+		container.PendingDigest = ""
+		changes["pin_by_digest"] = *req.PinByDigest
+		updated = true
+	}
+
+	if req.HealthActions != nil {
+		if req.HealthActions.HasExec() {
+			if err := s.checkExecPermission(ctx, container, actor); err != nil {
+				return nil, err
+			}
+		}
+		container.HealthActions = *req.HealthActions
+		changes["health_actions"] = *req.HealthActions
+		updated = true
+	}
+
+	if req.LogRedactPatterns != nil {
+		container.LogRedactPatterns = model.StringList(*req.LogRedactPatterns)
+		changes["log_redact_patterns"] = *req.LogRedactPatterns
+		updated = true
+	}
+
+	if req.SecretEnv != nil {
+		container.SecretEnv = model.StringList(*req.SecretEnv)
+		changes["secret_env"] = *req.SecretEnv
+		updated = true
+	}
+
+	if req.WarmupSeconds != nil {
+		container.WarmupSeconds = *req.WarmupSeconds
+		changes["warmup_seconds"] = *req.WarmupSeconds
+		updated = true
+	}
+
+	if req.PostStartHooks != nil {
+		if req.PostStartHooks.HasExec() {
+			if err := s.checkExecPermission(ctx, container, actor); err != nil {
+				return nil, err
+			}
+		}
+		container.PostStartHooks = *req.PostStartHooks
+		changes["post_start_hooks"] = *req.PostStartHooks
+		updated = true
+	}
+
+	if req.StopTimeoutSeconds != nil {
+		container.StopTimeoutSeconds = nil
+		if *req.StopTimeoutSeconds >= 0 {
+			container.StopTimeoutSeconds = req.StopTimeoutSeconds
+		}
+		changes["stop_timeout_seconds"] = *req.StopTimeoutSeconds
+		updated = true
+	}
+
+	if req.StopSignal != nil {
+		container.StopSignal = *req.StopSignal
+		changes["stop_signal"] = *req.StopSignal
+		updated = true
+	}
+
 	if req.RegistryAuth != nil {
 		authJSON, err := json.Marshal(req.RegistryAuth)
 		if err != nil {
-			return fmt.Errorf("failed to marshal registry auth: %w", err)
+			return nil, fmt.Errorf("failed to marshal registry auth: %w", err)
 		}
-		if container.RegistryAuth != string(authJSON) {
-			container.RegistryAuth = string(authJSON)
+		if current, err := s.openRegistryAuth(container); err != nil || current != string(authJSON) {
+			if err := s.sealRegistryAuth(container, string(authJSON)); err != nil {
+				return nil, err
+			}
 			changes["registry_auth"] = "updated"
 			updated = true
 		}
 	}
 
+	if req.TeamID != nil {
+		var teamID *int
+		if *req.TeamID > 0 {
+			teamID = req.TeamID
+		}
+		if !sameIntPtr(teamID, container.TeamID) {
+			container.TeamID = teamID
+			changes["team_id"] = *req.TeamID
+			updated = true
+		}
+	}
+
 	if !updated {
-		return nil // No changes made
+		return nil, nil // No changes made
 	}
 
-	// Save changes
-	if err := s.containerRepo.Update(ctx, container); err != nil {
-		return fmt.Errorf("failed to update container: %w", err)
+	// Save changes; new limits and team moves must fit the team's quota
+	save := func() error {
+		if err := s.containerRepo.Update(ctx, container); err != nil {
+			return fmt.Errorf("failed to update container: %w", err)
+		}
+		return nil
+	}
+	_, configChanged := changes["config"]
+	_, teamChanged := changes["team_id"]
+	if configChanged || teamChanged || limits != nil {
+		err = s.admitToTeam(ctx, actor, container, save)
+	} else {
+		err = save()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// New limits take effect without recreating the container
+	var warnings []string
+	if limits != nil {
+		warnings, err = s.applyLimits(ctx, container, limits, limitRemoved)
+		if err != nil {
+			s.cache.Delete(fmt.Sprintf("container:detail:%d", containerID))
+			return nil, err
+		}
 	}
 
 	// Log activity
-	s.logContainerActivity(userID, int64(container.ID), "container_updated", "Container configuration updated", changes)
+	s.logContainerActivity(actor, int64(container.ID), "container_updated", "Container configuration updated", changes)
 
 	// Invalidate cache
-	s.invalidateContainerCache(userID)
+	s.invalidateContainerCache(actor)
 	s.cache.Delete(fmt.Sprintf("container:detail:%d", containerID))
 
 	logrus.WithFields(logrus.Fields{
 		"container_id":   container.ID,
 		"container_name": container.Name,
-		"user_id":        userID,
+		"actor":          actor.String(),
 		"changes":        changes,
 	}).Info("Container updated successfully")
 
-	return nil
+	return warnings, nil
 }
 
 // DeleteContainer removes a container
-func (s *ContainerService) DeleteContainer(ctx context.Context, userID int64, containerID int64) error {
+func (s *ContainerService) DeleteContainer(ctx context.Context, actor model.Actor, containerID int64) error {
 	// Get container
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
@@ -279,28 +728,17 @@ func (s *ContainerService) DeleteContainer(ctx context.Context, userID int64, co
 	}
 
 	// Check permissions
-	if err := s.checkContainerPermission(container, userID); err != nil {
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionManage); err != nil {
 		return err
 	}
 
-	// Stop Docker container if running
+	// Stop Docker container if running; like a failed removal, an
+	// unavailable host leaves the Docker container behind
 	if container.ContainerID != "" {
-		if dockerStatus, err := s.dockerClient.GetContainerStatus(ctx, container.ContainerID); err == nil {
-			if dockerStatus == model.ContainerStatusRunning {
-				timeout := 30
-				if err := s.dockerClient.StopContainer(ctx, container.ContainerID, &timeout); err != nil {
-					logrus.WithError(err).WithField("container_id", container.ContainerID).Warn("Failed to stop Docker container before deletion")
-				}
-			}
-		}
-
-		// Remove Docker container
-		removeOptions := types.ContainerRemoveOptions{
-			Force:         true,
-			RemoveVolumes: true,
-		}
-		if err := s.dockerClient.RemoveContainer(ctx, container.ContainerID, removeOptions); err != nil {
-			logrus.WithError(err).WithField("container_id", container.ContainerID).Warn("Failed to remove Docker container")
+		if dc, err := s.dockerFor(ctx, container); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ContainerID).Warn("Docker host unavailable, Docker container not removed")
+		} else {
+			s.removeDockerContainer(ctx, dc, container)
 		}
 	}
 
@@ -308,36 +746,44 @@ func (s *ContainerService) DeleteContainer(ctx context.Context, userID int64, co
 	if err := s.containerRepo.Delete(ctx, containerID); err != nil {
 		return fmt.Errorf("failed to delete container: %w", err)
 	}
+	s.refreshContainerMetrics(ctx)
 
 	// Log activity
-	s.logContainerActivity(userID, int64(container.ID), "container_deleted", "Container deleted successfully", map[string]interface{}{
+	s.logContainerActivity(actor, int64(container.ID), "container_deleted", "Container deleted successfully", map[string]interface{}{
 		"container_name": container.Name,
 		"image":          container.GetFullImageName(),
 	})
 
 	// Invalidate cache
-	s.invalidateContainerCache(userID)
+	s.invalidateContainerCache(actor)
 
 	logrus.WithFields(logrus.Fields{
 		"container_id":   container.ID,
 		"container_name": container.Name,
-		"user_id":        userID,
+		"actor":          actor.String(),
 	}).Info("Container deleted successfully")
 
 	return nil
 }
 
 // ListContainers retrieves paginated list of containers
-func (s *ContainerService) ListContainers(ctx context.Context, userID int64, filter *ContainerFilter) (*ContainerListResponse, error) {
+func (s *ContainerService) ListContainers(ctx context.Context, actor model.Actor, filter *dto.ContainerFilter) (*dto.ContainerListResponse, error) {
 	if filter == nil {
-		filter = &ContainerFilter{
+		filter = &dto.ContainerFilter{
 			ContainerFilter: &model.ContainerFilter{},
 		}
 	}
 
-	// Set user filter
-	userIDInt := int(userID)
-	filter.ContainerFilter.CreatedBy = &userIDInt
+	// Set user filter; system components and admins see every container,
+	// users those they created or were granted
+	access, err := s.containerAccessFor(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if access.none() {
+		return &dto.ContainerListResponse{Containers: []*dto.ContainerSummary{}}, nil
+	}
+	access.scope(filter.ContainerFilter)
 
 	// Set defaults
 	if filter.Limit <= 0 {
@@ -351,7 +797,7 @@ func (s *ContainerService) ListContainers(ctx context.Context, userID int64, fil
 	}
 
 	// Validate sort field
-	if filter.SortBy != "" && !IsValidSortField(filter.SortBy) {
+	if filter.SortBy != "" && !dto.IsValidSortField(filter.SortBy) {
 		return nil, fmt.Errorf("invalid sort field: %s", filter.SortBy)
 	}
 
@@ -377,23 +823,42 @@ func (s *ContainerService) ListContainers(ctx context.Context, userID int64, fil
 	}
 
 	// Convert to summary format
-	summaries := make([]*ContainerSummary, len(containers))
+	clients := s.newDockerClients()
+	var live *pageLiveState
+	if filter.IncludeHealth {
+		live = s.inspectPage(ctx, clients, containers)
+	}
+	now := time.Now()
+	summaries := make([]*dto.ContainerSummary, len(containers))
 	for i, container := range containers {
-		summary := &ContainerSummary{
-			ID:           int64(container.ID),
-			Name:         container.Name,
-			Image:        container.Image,
-			Tag:          container.Tag,
-			Status:       container.Status,
-			UpdatePolicy: container.UpdatePolicy,
-			CreatedAt:    container.CreatedAt,
-			UpdatedAt:    container.UpdatedAt,
+		summary := &dto.ContainerSummary{
+			ID:            int64(container.ID),
+			Name:          container.Name,
+			Image:         container.Image,
+			Tag:           container.Tag,
+			Status:        container.Status,
+			UpdatePolicy:  container.UpdatePolicy,
+			VersionPolicy: container.GetVersionPolicy(),
+			HasWarnings:   container.HasWarnings(),
+			WarningCount:  len(container.Warnings),
+			Drifted:       container.Drifted,
+			CrashLooping:  container.CrashLooping,
+			Adopted:       container.Adopted,
+			Unmanaged:     container.Unmanaged,
+			CreatedAt:     container.CreatedAt,
+			UpdatedAt:     container.UpdatedAt,
+			Permission:    access.permission(container),
 		}
 
-		// Get Docker status
-		if container.ContainerID != "" {
-			if dockerStatus, err := s.dockerClient.GetContainerStatus(ctx, container.ContainerID); err == nil {
+		// Get Docker status and published ports
+		if live != nil {
+			live.apply(summary, container, now)
+		} else if container.ContainerID != "" {
+			if dc, err := clients.get(ctx, container); err != nil {
+				logrus.WithError(err).WithField("container_id", container.ID).Debug("Docker host unavailable")
+			} else if dockerStatus, ports, err := dc.GetContainerStatusAndPorts(ctx, container.ContainerID); err == nil {
 				summary.DockerStatus = string(dockerStatus)
+				summary.PublishedPorts = docker.FormatPublishedPorts(ports)
 			}
 		}
 
@@ -412,7 +877,7 @@ func (s *ContainerService) ListContainers(ctx context.Context, userID int64, fil
 	hasNext := filter.Offset+filter.Limit < int(total)
 	hasPrev := filter.Offset > 0
 
-	return &ContainerListResponse{
+	return &dto.ContainerListResponse{
 		Containers: summaries,
 		Total:      total,
 		Page:       page,
@@ -424,24 +889,32 @@ func (s *ContainerService) ListContainers(ctx context.Context, userID int64, fil
 
 // Container operations
 
-// StartContainer starts a container
-func (s *ContainerService) StartContainer(ctx context.Context, userID int64, containerID int64) error {
+// StartContainer starts a container, creating its Docker container on first
+// start. It returns the daemon's creation warnings, if it created one.
+func (s *ContainerService) StartContainer(ctx context.Context, actor model.Actor, containerID int64) ([]string, error) {
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
-		return fmt.Errorf("failed to get container: %w", err)
+		return nil, fmt.Errorf("failed to get container: %w", err)
 	}
 
-	if err := s.checkContainerPermission(container, userID); err != nil {
-		return err
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create Docker container if not exists
+	var warnings []string
 	if container.ContainerID == "" {
-		dockerContainerID, err := s.createDockerContainer(ctx, container)
+		dockerContainerID, daemonWarnings, err := s.createDockerContainer(ctx, dc, container)
 		if err != nil {
-			return fmt.Errorf("failed to create Docker container: %w", err)
+			return nil, fmt.Errorf("failed to create Docker container: %w", err)
 		}
-		container.ContainerID = dockerContainerID
+		container.SetDeployedContainer(dockerContainerID)
+		warnings = s.RecordDaemonWarnings(ctx, container, daemonWarnings)
 
 		// Update container with Docker ID
 		if err := s.containerRepo.UpdateContainerID(ctx, containerID, dockerContainerID); err != nil {
@@ -450,42 +923,53 @@ func (s *ContainerService) StartContainer(ctx context.Context, userID int64, con
 	}
 
 	// Start Docker container
-	if err := s.dockerClient.StartContainer(ctx, container.ContainerID); err != nil {
-		return fmt.Errorf("failed to start container: %w", err)
+	if err := dc.StartContainer(ctx, container.ContainerID); err != nil {
+		return warnings, fmt.Errorf("failed to start container: %w", err)
 	}
 
 	// Update status
 	if err := s.containerRepo.UpdateStatus(ctx, containerID, model.ContainerStatusRunning); err != nil {
 		logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to update container status")
 	}
+	s.refreshContainerMetrics(ctx)
 
 	// Log activity
-	s.logContainerActivity(userID, containerID, "container_started", "Container started successfully", nil)
+	s.logContainerActivity(actor, containerID, "container_started", "Container started successfully", nil)
 
 	// Invalidate cache
 	s.cache.Delete(fmt.Sprintf("container:status:%d", containerID))
 
-	return nil
+	// Warm up and run the post-start hooks before reporting the start, so
+	// containers started after this one wait for it
+	if _, err := s.RunPostStart(ctx, actor, container, container.ContainerID, model.PostStartTriggerStart, 0); err != nil {
+		return warnings, err
+	}
+
+	return warnings, nil
 }
 
 // StopContainer stops a container
-func (s *ContainerService) StopContainer(ctx context.Context, userID int64, containerID int64) error {
+func (s *ContainerService) StopContainer(ctx context.Context, actor model.Actor, containerID int64) error {
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
 		return fmt.Errorf("failed to get container: %w", err)
 	}
 
-	if err := s.checkContainerPermission(container, userID); err != nil {
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
 		return err
 	}
 
 	if container.ContainerID == "" {
-		return fmt.Errorf("container has no Docker instance")
+		return ErrContainerNotCreated
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return err
 	}
 
-	// Stop Docker container
-	timeout := 30 // seconds
-	if err := s.dockerClient.StopContainer(ctx, container.ContainerID, &timeout); err != nil {
+	// Stop Docker container, giving it its grace period
+	if err := stopDockerContainer(ctx, dc, container, container.ContainerID); err != nil {
 		return fmt.Errorf("failed to stop container: %w", err)
 	}
 
@@ -493,9 +977,10 @@ func (s *ContainerService) StopContainer(ctx context.Context, userID int64, cont
 	if err := s.containerRepo.UpdateStatus(ctx, containerID, model.ContainerStatusStopped); err != nil {
 		logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to update container status")
 	}
+	s.refreshContainerMetrics(ctx)
 
 	// Log activity
-	s.logContainerActivity(userID, containerID, "container_stopped", "Container stopped successfully", nil)
+	s.logContainerActivity(actor, containerID, "container_stopped", "Container stopped successfully", nil)
 
 	// Invalidate cache
 	s.cache.Delete(fmt.Sprintf("container:status:%d", containerID))
@@ -504,23 +989,28 @@ func (s *ContainerService) StopContainer(ctx context.Context, userID int64, cont
 }
 
 // RestartContainer restarts a container
-func (s *ContainerService) RestartContainer(ctx context.Context, userID int64, containerID int64) error {
+func (s *ContainerService) RestartContainer(ctx context.Context, actor model.Actor, containerID int64) error {
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
 		return fmt.Errorf("failed to get container: %w", err)
 	}
 
-	if err := s.checkContainerPermission(container, userID); err != nil {
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
 		return err
 	}
 
 	if container.ContainerID == "" {
-		return fmt.Errorf("container has no Docker instance")
+		return ErrContainerNotCreated
 	}
 
-	// Restart Docker container
-	timeout := 30 // seconds
-	if err := s.dockerClient.RestartContainer(ctx, container.ContainerID, &timeout); err != nil {
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return err
+	}
+
+	// Restart Docker container, giving it its grace period
+	timeout := container.StopTimeout()
+	if err := dc.RestartContainerWithSignal(ctx, container.ContainerID, container.StopSignal, &timeout); err != nil {
 		return fmt.Errorf("failed to restart container: %w", err)
 	}
 
@@ -528,20 +1018,31 @@ func (s *ContainerService) RestartContainer(ctx context.Context, userID int64, c
 	if err := s.containerRepo.UpdateStatus(ctx, containerID, model.ContainerStatusRunning); err != nil {
 		logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to update container status")
 	}
+	s.refreshContainerMetrics(ctx)
 
 	// Log activity
-	s.logContainerActivity(userID, containerID, "container_restarted", "Container restarted successfully", nil)
+	s.logContainerActivity(actor, containerID, "container_restarted", "Container restarted successfully", nil)
 
 	// Invalidate cache
 	s.cache.Delete(fmt.Sprintf("container:status:%d", containerID))
 
+	if _, err := s.RunPostStart(ctx, actor, container, container.ContainerID, model.PostStartTriggerRestart, 0); err != nil {
+		return err
+	}
+
 	return nil
 }
 
 // UpdateContainerImage updates container to use a new image version
-func (s *ContainerService) UpdateContainerImage(ctx context.Context, userID int64, containerID int64, req *UpdateImageRequest) (*model.UpdateHistory, error) {
+func (s *ContainerService) UpdateContainerImage(ctx context.Context, actor model.Actor, containerID int64, req *dto.UpdateImageRequest) (*model.UpdateHistory, error) {
 	if req == nil {
-		req = &UpdateImageRequest{Strategy: "recreate", Backup: true}
+		req = &dto.UpdateImageRequest{Strategy: "recreate", Backup: true}
+	}
+	if req.DryRun {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: dry runs are planned with PlanContainerUpdate")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
 	}
 
 	container, err := s.containerRepo.GetByID(ctx, containerID)
@@ -549,24 +1050,103 @@ func (s *ContainerService) UpdateContainerImage(ctx context.Context, userID int6
 		return nil, fmt.Errorf("failed to get container: %w", err)
 	}
 
-	if err := s.checkContainerPermission(container, userID); err != nil {
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+
+	// Reject a bad note before anything is updated
+	if req.Note != "" {
+		if _, err := model.SanitizeUpdateNote(req.Note); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+		}
+	}
+
+	retagged := req.Tag != "" && req.Tag != container.Tag
+	if err := checkVersionPolicy(container, req.Tag); err != nil {
 		return nil, err
 	}
+	// The new tag is stored once the container runs it, so only updates that
+	// recreate the container can change it
+	if retagged && !s.recreatesContainer(container, req) {
+		return nil, apperrors.Newf(apperrors.CodeNotImplemented, "changing the tag needs the %s strategy, the only one that recreates the container so far", model.UpdateStrategyHealthGated)
+	}
+	if !actor.IsSystem() {
+		s.logWindowOverride(ctx, actor, container)
+	}
 
 	// Create update history record
-	userIDInt := int(userID)
 	updateHistory := &model.UpdateHistory{
 		ContainerID:   int(containerID),
-		OldImage:      container.GetFullImageName(),
-		Status:        model.UpdateStatusRunning,
+		OldImage:      container.GetDeployImageRef(),
 		Strategy:      model.UpdateStrategy(req.Strategy),
 		TriggeredBy:   model.TriggerTypeManual,
-		CreatedBy:     &userIDInt,
-		StartedAt:     time.Now(),
+	}
+	if actor.IsSystem() {
+		updateHistory.TriggeredBy = model.TriggerTypeAuto
+	}
+	updateHistory.SetActor(actor)
+
+	if retagged {
+		container.Tag = req.Tag
+	}
+	if err := s.applyImageUpdate(ctx, actor, container, updateHistory, req); err != nil {
+		return nil, err
+	}
+	if retagged {
+		if err := s.containerRepo.Update(ctx, container); err != nil {
+			return nil, fmt.Errorf("failed to update container: %w", err)
+		}
 	}
 
-	if err := s.updateHistoryRepo.Create(ctx, updateHistory); err != nil {
-		return nil, fmt.Errorf("failed to create update history: %w", err)
+	if req.Note != "" {
+		note, err := s.addUpdateNote(ctx, actor, updateHistory, &UpdateNoteRequest{Body: req.Note})
+		if err != nil {
+			logrus.WithError(err).WithField("update_id", updateHistory.ID).Warn("Failed to attach note to update")
+		} else {
+			updateHistory.Notes = []*model.UpdateNote{note}
+		}
+	}
+
+	return updateHistory, nil
+}
+
+// recreatesContainer reports whether applyImageUpdate replaces the Docker
+// container for req: self-updates and health-gated updates do, the other
+// strategies only record the update so far
+func (s *ContainerService) recreatesContainer(container *model.Container, req *dto.UpdateImageRequest) bool {
+	if s.IsSelfContainer(container) {
+		return true
+	}
+	return req.Strategy == string(model.UpdateStrategyHealthGated) && container.ContainerID != ""
+}
+
+// applyImageUpdate runs the update recorded by updateHistory, creating the
+// record or, for updates recorded as pending, marking it running
+func (s *ContainerService) applyImageUpdate(ctx context.Context, actor model.Actor, container *model.Container, updateHistory *model.UpdateHistory, req *dto.UpdateImageRequest) error {
+	if !s.updates.begin() {
+		return ErrShuttingDown
+	}
+	defer s.updates.end()
+
+	containerID := int64(container.ID)
+	updateHistory.Status = model.UpdateStatusRunning
+	updateHistory.StartedAt = time.Now()
+
+	if updateHistory.ID == 0 {
+		if err := s.updateHistoryRepo.Create(ctx, updateHistory); err != nil {
+			return fmt.Errorf("failed to create update history: %w", err)
+		}
+	} else if err := s.updateHistoryRepo.Update(ctx, updateHistory); err != nil {
+		return fmt.Errorf("failed to update history: %w", err)
+	}
+	defer s.ForgetUpdateProgress(updateHistory.ID)
+	s.publishUpdateStarted(container, updateHistory)
+
+	if s.IsSelfContainer(container) {
+		return s.startSelfUpdate(ctx, actor, container, updateHistory, req)
+	}
+	if s.recreatesContainer(container, req) {
+		return s.finishHealthGatedUpdate(ctx, actor, container, updateHistory, req)
 	}
 
 	// TODO: Implement actual image update logic based on strategy
@@ -581,68 +1161,113 @@ func (s *ContainerService) UpdateContainerImage(ctx context.Context, userID int6
 	updateHistory.Status = model.UpdateStatusCompleted
 	updateHistory.CompletedAt = &time.Time{}
 	*updateHistory.CompletedAt = time.Now()
-	updateHistory.NewImage = container.GetFullImageName() // Placeholder
+	updateHistory.NewImage = container.GetDeployImageRef() // Placeholder
 
-	if err := s.updateHistoryRepo.Create(ctx, updateHistory); err != nil {
+	if err := s.updateHistoryRepo.Update(ctx, updateHistory); err != nil {
 		logrus.WithError(err).WithField("update_id", updateHistory.ID).Warn("Failed to update history record")
 	}
+	metrics.RecordContainerUpdate(string(updateHistory.Status))
+	s.publishUpdateCompleted(container, updateHistory)
+	s.recordAppliedUpdate(ctx, container, updateHistory)
 
 	// Log activity
-	s.logContainerActivity(userID, containerID, "image_updated", "Container image updated", map[string]interface{}{
+	s.logContainerActivity(actor, containerID, "image_updated", "Container image updated", map[string]interface{}{
 		"old_image":  updateHistory.OldImage,
 		"new_image":  updateHistory.NewImage,
 		"strategy":   req.Strategy,
 		"update_id":  updateHistory.ID,
 	})
 
-	return updateHistory, nil
+	return nil
+}
+
+// GetUpdate retrieves an update history record, including the step checklist
+// of checkpointed updates
+func (s *ContainerService) GetUpdate(ctx context.Context, actor model.Actor, updateID int64) (*model.UpdateHistory, error) {
+	history, err := s.updateHistoryRepo.GetByID(ctx, updateID)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, int64(history.ContainerID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	if s.updateNoteRepo != nil {
+		notes, err := s.updateNoteRepo.ListByUpdate(ctx, history.ID)
+		if err != nil {
+			logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to get update notes")
+		} else {
+			history.Notes = notes
+		}
+	}
+
+	return history, nil
 }
 
 // Container status and monitoring
 
 // GetContainerStatus retrieves current container status
-func (s *ContainerService) GetContainerStatus(ctx context.Context, containerID int64) (*ContainerStatus, error) {
+func (s *ContainerService) GetContainerStatus(ctx context.Context, actor model.Actor, containerID int64) (*dto.ContainerStatus, error) {
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container: %w", err)
 	}
 
-	status := &ContainerStatus{
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	status := &dto.ContainerStatus{
 		ID:        int64(container.ID),
 		Name:      container.Name,
 		Status:    container.Status,
 		Timestamp: time.Now(),
 	}
 
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Docker host unavailable")
+		return status, nil
+	}
+
 	// Get Docker status if available
 	if container.ContainerID != "" {
-		if dockerStatus, err := s.dockerClient.GetContainerStatus(ctx, container.ContainerID); err == nil {
+		if dockerStatus, err := dc.GetContainerStatus(ctx, container.ContainerID); err == nil {
 			status.DockerStatus = &dockerStatus
 			// Additional docker info would need to be fetched separately if needed
 		}
 	}
 
+	// Report pending image pulls, e.g. "queued behind 3 pulls"
+	status.Operation = dc.GetPullThrottle().Status(docker.ContainerPullKey(containerID))
+
 	return status, nil
 }
 
 // GetContainerLogs retrieves container logs
-func (s *ContainerService) GetContainerLogs(ctx context.Context, userID int64, containerID int64, options *LogOptions) (*LogResponse, error) {
+func (s *ContainerService) GetContainerLogs(ctx context.Context, actor model.Actor, containerID int64, options *dto.LogOptions) (*dto.LogResponse, error) {
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container: %w", err)
 	}
 
-	if err := s.checkContainerPermission(container, userID); err != nil {
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
 		return nil, err
 	}
 
 	if container.ContainerID == "" {
-		return nil, fmt.Errorf("container has no Docker instance")
+		return nil, ErrContainerNotCreated
 	}
 
 	// Set default options
 	if options == nil {
-		options = &LogOptions{
+		options = &dto.LogOptions{
 			Tail:       100,
 			Timestamps: true,
 		}
@@ -664,17 +1289,27 @@ func (s *ContainerService) GetContainerLogs(ctx context.Context, userID int64, c
 		dockerOptions.Until = options.Until.Format(time.RFC3339)
 	}
 
+	redactor, err := s.containerLogRedactor(ctx, container, actor, options.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get logs from Docker
-	logs, err := s.dockerClient.GetContainerLogsAsEntries(ctx, container.ContainerID, dockerOptions)
+	logs, err := dc.GetContainerLogsAsEntries(ctx, container.ContainerID, dockerOptions)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container logs: %w", err)
 	}
 
 	// Build response
-	response := &LogResponse{
+	response := &dto.LogResponse{
 		ContainerID: containerID,
 		Name:        container.Name,
-		Logs:        convertDockerLogEntries(logs),
+		Logs:        convertDockerLogEntries(logs, redactor),
 		Count:       len(logs),
 		Since:       options.Since,
 		Until:       options.Until,
@@ -688,41 +1323,117 @@ func (s *ContainerService) GetContainerLogs(ctx context.Context, userID int64, c
 	return response, nil
 }
 
-// convertDockerLogEntries converts docker.LogEntry to service.LogEntry
-func convertDockerLogEntries(dockerLogs []docker.LogEntry) []LogEntry {
-	logs := make([]LogEntry, len(dockerLogs))
+// convertDockerLogEntries converts docker.LogEntry to service.LogEntry,
+// redacting messages unless redactor is nil
+func convertDockerLogEntries(dockerLogs []docker.LogEntry, redactor *docker.LogRedactor) []dto.LogEntry {
+	logs := make([]dto.LogEntry, len(dockerLogs))
 	for i, log := range dockerLogs {
-		logs[i] = LogEntry{
+		logs[i] = dto.LogEntry{
 			Timestamp: log.Timestamp,
 			Source:    log.Source,
-			Message:   log.Message,
+			Message:   redactor.Redact(log.Message),
 		}
 	}
 	return logs
 }
 
+// StreamContainerLogs writes the container's log stream to w as plain text,
+// redacted line by line like GetContainerLogs. With options.Follow it runs
+// until ctx is cancelled or the container stops.
+func (s *ContainerService) StreamContainerLogs(ctx context.Context, actor model.Actor, containerID int64, options *dto.LogOptions, w io.Writer) error {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return err
+	}
+
+	if container.ContainerID == "" {
+		return ErrContainerNotCreated
+	}
+
+	if options == nil {
+		options = &dto.LogOptions{Tail: 100, Follow: true}
+	}
+
+	redactor, err := s.containerLogRedactor(ctx, container, actor, options.Raw)
+	if err != nil {
+		return err
+	}
+
+	dockerOptions := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Tail:       strconv.Itoa(options.Tail),
+		Follow:     options.Follow,
+		Timestamps: options.Timestamps,
+	}
+	if !options.Since.IsZero() {
+		dockerOptions.Since = options.Since.Format(time.RFC3339)
+	}
+	if !options.Until.IsZero() {
+		dockerOptions.Until = options.Until.Format(time.RFC3339)
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return err
+	}
+
+	reader, err := dc.GetContainerLogs(ctx, container.ContainerID, dockerOptions)
+	if err != nil {
+		return fmt.Errorf("failed to get container logs: %w", err)
+	}
+	defer reader.Close()
+
+	// stdout and stderr frames interleave, so each keeps its own partial line
+	out := &flushingWriter{w: w}
+	stdout := docker.NewRedactingWriter(out, redactor)
+	stderr := docker.NewRedactingWriter(out, redactor)
+
+	_, copyErr := stdcopy.StdCopy(stdout, stderr, reader)
+	if err := stdout.Flush(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if err := stderr.Flush(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil && ctx.Err() == nil {
+		return fmt.Errorf("failed to stream container logs: %w", copyErr)
+	}
+
+	return nil
+}
+
 // GetContainerStats retrieves container resource statistics
-func (s *ContainerService) GetContainerStats(ctx context.Context, userID int64, containerID int64) (*ContainerStats, error) {
+func (s *ContainerService) GetContainerStats(ctx context.Context, actor model.Actor, containerID int64) (*dto.ContainerStats, error) {
 	container, err := s.containerRepo.GetByID(ctx, containerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container: %w", err)
 	}
 
-	if err := s.checkContainerPermission(container, userID); err != nil {
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
 		return nil, err
 	}
 
 	if container.ContainerID == "" {
-		return nil, fmt.Errorf("container has no Docker instance")
+		return nil, ErrContainerNotCreated
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
 	}
 
 	// Get metrics
-	metrics, err := s.getContainerMetrics(ctx, container.ContainerID)
+	metrics, err := s.getContainerMetrics(ctx, dc, container.ContainerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get container stats: %w", err)
 	}
 
-	return &ContainerStats{
+	return &dto.ContainerStats{
 		ID:        containerID,
 		Name:      container.Name,
 		Metrics:   metrics,
@@ -730,69 +1441,70 @@ func (s *ContainerService) GetContainerStats(ctx context.Context, userID int64,
 	}, nil
 }
 
-// SyncContainerStatus synchronizes container status with Docker daemon
-func (s *ContainerService) SyncContainerStatus(ctx context.Context) error {
-	// Get all containers with Docker IDs
-	allContainers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+// resolveContainerID looks up the container's Docker ID by name on its host
+// and records it when it changed. It returns false when no container has the
+// name.
+func (s *ContainerService) resolveContainerID(ctx context.Context, dc *docker.DockerClient, container *model.Container) bool {
+	containerID, err := dc.FindContainerIDByName(ctx, container.Name)
 	if err != nil {
-		return fmt.Errorf("failed to get containers: %w", err)
+		return false
+	}
+	if containerID == container.ContainerID {
+		return true
 	}
 
-	syncResult := &SyncResult{
-		TotalContainers: len(allContainers),
-		Timestamp:       time.Now(),
+	if err := s.containerRepo.RelinkContainerID(ctx, int64(container.ID), containerID); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to update Docker container ID")
+		return false
 	}
-	startTime := time.Now()
+	container.ContainerID = containerID
+	return true
+}
 
-	for _, container := range allContainers {
-		if container.ContainerID == "" {
-			continue
-		}
+// WatchContainerEvents keeps container statuses current from the local
+// daemon's event stream between full syncs, until ctx is done. Containers on
+// remote Docker hosts are kept current by the status sync alone.
+func (s *ContainerService) WatchContainerEvents(ctx context.Context) *docker.EventWatcher {
+	if s.dockerClient == nil {
+		return nil
+	}
+	return s.dockerClient.WatchContainerEvents(ctx, s.applyContainerEvent, s.config.Docker.EventQueueSize)
+}
 
-		// Get Docker status
-		dockerStatus, err := s.dockerClient.GetContainerStatus(ctx, container.ContainerID)
-		if err != nil {
-			syncResult.ErrorContainers++
-			syncResult.Errors = append(syncResult.Errors, SyncError{
-				ContainerID: int64(container.ID),
-				Name:        container.Name,
-				Error:       err.Error(),
-				Recoverable: true,
-			})
-			continue
-		}
-
-		// Use the status directly
-		newStatus := dockerStatus
-
-		// Update status if changed
-		if container.Status != newStatus {
-			if err := s.containerRepo.UpdateStatus(ctx, int64(container.ID), newStatus); err != nil {
-				logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to update container status")
-			} else {
-				syncResult.StatusChanges = append(syncResult.StatusChanges, ContainerStatusChange{
-					ContainerID: int64(container.ID),
-					Name:        container.Name,
-					OldStatus:   container.Status,
-					NewStatus:   newStatus,
-					Reason:      "Docker status sync",
-				})
-			}
-		}
+// applyContainerEvent records the status a container event reports and
+// publishes it on the container state stream. Events come from the local
+// daemon; those for containers this application does not manage there are
+// ignored, and applying the same event twice leaves the same status.
+func (s *ContainerService) applyContainerEvent(ctx context.Context, event *docker.ContainerEvent) error {
+	s.syncState.markChanged(event.ContainerID, event.Time)
 
-		syncResult.SyncedContainers++
+	streamed := streamedDaemonActions[event.Action] && s.publisher != nil
+	if event.Status == "" && !streamed {
+		return nil
 	}
 
-	syncResult.Duration = time.Since(startTime)
-
-	logrus.WithFields(logrus.Fields{
-		"total_containers":  syncResult.TotalContainers,
-		"synced_containers": syncResult.SyncedContainers,
-		"error_containers":  syncResult.ErrorContainers,
-		"status_changes":    len(syncResult.StatusChanges),
-		"duration":          syncResult.Duration,
-	}).Info("Container status sync completed")
+	container, err := s.containerRepo.GetByContainerID(ctx, event.ContainerID)
+	if err != nil {
+		if apperrors.HasCode(err, apperrors.CodeContainerNotFound) {
+			return nil
+		}
+		return err
+	}
+	if container.HostID != nil {
+		return nil
+	}
+	if streamed {
+		s.publishDaemonEvent(container, event)
+	}
+	if event.Status == "" || container.Status == event.Status {
+		return nil
+	}
 
+	if err := s.containerRepo.UpdateStatus(ctx, int64(container.ID), event.Status); err != nil {
+		return err
+	}
+	s.refreshContainerMetrics(ctx)
+	s.cache.Delete(fmt.Sprintf("container:status:%d", container.ID))
 	return nil
 }
 
@@ -0,0 +1,401 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// composeServiceKeys are the service keys an import maps onto a container
+var composeServiceKeys = map[string]bool{
+	"image":          true,
+	"container_name": true,
+	"environment":    true,
+	"labels":         true,
+	"ports":          true,
+	"volumes":        true,
+	"restart":        true,
+	"build":          true,
+	"depends_on":     true,
+}
+
+// composeFile is the part of a compose file an import reads
+type composeFile struct {
+	Services map[string]map[string]interface{} `yaml:"services"`
+	Networks map[string]interface{}            `yaml:"networks"`
+	Secrets  map[string]interface{}            `yaml:"secrets"`
+	Configs  map[string]interface{}            `yaml:"configs"`
+}
+
+// ImportCompose creates one managed container per service of a compose file.
+// Services whose container already exists by name are skipped, and compose
+// features that cannot be mapped are reported as warnings; only a file that
+// cannot be parsed fails the import as a whole.
+func (s *ContainerService) ImportCompose(ctx context.Context, actor model.Actor, data []byte) (*dto.ComposeImportResult, error) {
+	var file composeFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: failed to parse compose file: %w", err)
+	}
+	if len(file.Services) == 0 {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: compose file has no services")
+	}
+
+	result := &dto.ComposeImportResult{Services: []dto.ComposeServiceResult{}}
+	for _, section := range []struct {
+		name    string
+		entries map[string]interface{}
+	}{{"networks", file.Networks}, {"secrets", file.Secrets}, {"configs", file.Configs}} {
+		if len(section.entries) > 0 {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("top-level %s are not imported", section.name))
+		}
+	}
+
+	names := make([]string, 0, len(file.Services))
+	for name := range file.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		serviceResult := s.importComposeService(ctx, actor, name, file.Services[name])
+		switch {
+		case serviceResult.Skipped:
+			result.Skipped++
+		case serviceResult.Success:
+			result.Created++
+		default:
+			result.Failed++
+		}
+		result.Services = append(result.Services, serviceResult)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"actor":   actor.String(),
+		"created": result.Created,
+		"skipped": result.Skipped,
+		"failed":  result.Failed,
+	}).Info("Compose project imported")
+
+	return result, nil
+}
+
+// importComposeService creates the container for one compose service
+func (s *ContainerService) importComposeService(ctx context.Context, actor model.Actor, name string, service map[string]interface{}) dto.ComposeServiceResult {
+	result := dto.ComposeServiceResult{Service: name}
+
+	req, warnings, err := composeServiceRequest(name, service)
+	result.Name = name
+	if req != nil {
+		result.Name = req.Name
+	}
+	result.Warnings = warnings
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	exists, err := s.containerRepo.Exists(ctx, req.Name)
+	if err != nil {
+		result.Error = fmt.Sprintf("failed to check container existence: %v", err)
+		return result
+	}
+	if exists {
+		result.Skipped = true
+		result.Message = fmt.Sprintf("container '%s' already exists", req.Name)
+		return result
+	}
+
+	container, err := s.CreateContainer(ctx, actor, req)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	result.ContainerID = int64(container.ID)
+	result.Success = true
+	result.Message = "Container created"
+	return result
+}
+
+// composeServiceRequest maps a compose service onto a create request. The
+// warnings list what was ignored or changed on the way.
+func composeServiceRequest(name string, service map[string]interface{}) (*dto.CreateContainerRequest, []string, error) {
+	var warnings []string
+	warn := func(format string, args ...interface{}) {
+		warnings = append(warnings, fmt.Sprintf(format, args...))
+	}
+
+	req := &dto.CreateContainerRequest{Name: name}
+	if containerName, ok := service["container_name"].(string); ok && containerName != "" {
+		req.Name = containerName
+	}
+
+	image, _ := service["image"].(string)
+	if _, ok := service["build"]; ok {
+		if image == "" {
+			return req, warnings, fmt.Errorf("build is not supported; set an image to import the service")
+		}
+		warn("build is ignored; the image is pulled instead")
+	}
+	if image == "" {
+		return req, warnings, fmt.Errorf("image is required")
+	}
+	req.Image, req.Tag, req.ImageDigest = model.ParseImageReference(image)
+	req.PinByDigest = req.ImageDigest != ""
+
+	if _, ok := service["depends_on"]; ok {
+		warn("depends_on is ignored; containers are created without start ordering")
+	}
+
+	var unsupported []string
+	for key := range service {
+		if !composeServiceKeys[key] && !strings.HasPrefix(key, "x-") {
+			unsupported = append(unsupported, key)
+		}
+	}
+	if len(unsupported) > 0 {
+		sort.Strings(unsupported)
+		warn("unsupported keys are ignored: %s", strings.Join(unsupported, ", "))
+	}
+
+	config := make(map[string]interface{})
+
+	if env := composeEnvironment(service["environment"], warn); len(env) > 0 {
+		config["env"] = env
+	}
+	if labels := composeLabels(service["labels"], warn); len(labels) > 0 {
+		config["labels"] = labels
+	}
+	if ports := composePorts(service["ports"], warn); len(ports) > 0 {
+		config["ports"] = ports
+	}
+	if volumes := composeVolumes(service["volumes"], warn); len(volumes) > 0 {
+		config["volumes"] = volumes
+	}
+	if len(config) > 0 {
+		req.Config = config
+	}
+
+	if restart, ok := service["restart"].(string); ok && restart != "" {
+		policy, retries, _ := strings.Cut(restart, ":")
+		switch {
+		case !dto.IsValidRestartPolicy(policy):
+			warn("restart policy %q is not supported; unless-stopped is used", restart)
+		case retries != "":
+			warn("restart retry count %s is ignored", retries)
+			req.RestartPolicy = policy
+		default:
+			req.RestartPolicy = policy
+		}
+	}
+
+	return req, warnings, nil
+}
+
+// composeEnvironment reads the list or map form of environment. Variables
+// without a value would be passed through from the host running compose,
+// which has no meaning here, so they are skipped.
+func composeEnvironment(raw interface{}, warn func(string, ...interface{})) []string {
+	var env []string
+	add := func(name string, value interface{}, set bool) {
+		if !set || value == nil {
+			warn("environment variable %s has no value and is skipped", name)
+			return
+		}
+		str := fmt.Sprint(value)
+		if strings.Contains(str, "${") {
+			warn("environment variable %s is not interpolated", name)
+		}
+		env = append(env, name+"="+str)
+	}
+
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			name, value, found := strings.Cut(fmt.Sprint(item), "=")
+			add(name, value, found)
+		}
+	case map[string]interface{}:
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(name, v[name], true)
+		}
+	}
+	return env
+}
+
+// composeLabels reads the list or map form of labels
+func composeLabels(raw interface{}, warn func(string, ...interface{})) map[string]string {
+	labels := make(map[string]string)
+	switch v := raw.(type) {
+	case []interface{}:
+		for _, item := range v {
+			name, value, _ := strings.Cut(fmt.Sprint(item), "=")
+			labels[name] = value
+		}
+	case map[string]interface{}:
+		for name, value := range v {
+			if value == nil {
+				value = ""
+			}
+			labels[name] = fmt.Sprint(value)
+		}
+	}
+	for _, name := range sortedKeys(labels, nil) {
+		err := model.ValidateLabelKey(name)
+		if err == nil {
+			err = model.ValidateLabelValue(name, labels[name])
+		}
+		if err != nil {
+			warn("label is skipped: %v", err)
+			delete(labels, name)
+		}
+	}
+	return labels
+}
+
+// composePorts reads short ([host_ip:][host:]container[/protocol]) and long
+// port syntax. Port ranges cannot be stored and are skipped.
+func composePorts(raw interface{}, warn func(string, ...interface{})) []dto.PortMapping {
+	items, _ := raw.([]interface{})
+	var ports []dto.PortMapping
+	for _, item := range items {
+		var mapping dto.PortMapping
+		var err error
+		switch v := item.(type) {
+		case map[string]interface{}:
+			mapping.ContainerPort, err = composePortNumber(v["target"])
+			if err == nil && v["published"] != nil {
+				mapping.HostPort, err = composePortNumber(v["published"])
+			}
+			mapping.Protocol, _ = v["protocol"].(string)
+			mapping.HostIP, _ = v["host_ip"].(string)
+		default:
+			mapping, err = parseComposePort(fmt.Sprint(v))
+		}
+		if err != nil {
+			warn("port %v is skipped: %v", item, err)
+			continue
+		}
+		if mapping.Protocol == "" {
+			mapping.Protocol = "tcp"
+		}
+		if mapping.Protocol != "tcp" {
+			warn("port %d/%s is stored but not published; only TCP ports are", mapping.ContainerPort, mapping.Protocol)
+		}
+		ports = append(ports, mapping)
+	}
+	return ports
+}
+
+// parseComposePort parses the short port syntax
+func parseComposePort(spec string) (dto.PortMapping, error) {
+	var mapping dto.PortMapping
+	spec, mapping.Protocol, _ = strings.Cut(spec, "/")
+
+	// The host IP may be an IPv6 address in brackets
+	if strings.HasPrefix(spec, "[") {
+		end := strings.Index(spec, "]:")
+		if end < 0 {
+			return mapping, fmt.Errorf("invalid host IP")
+		}
+		mapping.HostIP = spec[1:end]
+		spec = spec[end+2:]
+	}
+
+	parts := strings.Split(spec, ":")
+	if len(parts) == 3 {
+		mapping.HostIP = parts[0]
+		parts = parts[1:]
+	}
+	if len(parts) > 2 {
+		return mapping, fmt.Errorf("invalid port")
+	}
+
+	var err error
+	if mapping.ContainerPort, err = composePortNumber(parts[len(parts)-1]); err != nil {
+		return mapping, err
+	}
+	if len(parts) == 2 && parts[0] != "" {
+		if mapping.HostPort, err = composePortNumber(parts[0]); err != nil {
+			return mapping, err
+		}
+	}
+	return mapping, nil
+}
+
+func composePortNumber(raw interface{}) (int, error) {
+	str := fmt.Sprint(raw)
+	if strings.Contains(str, "-") {
+		return 0, fmt.Errorf("port ranges are not supported")
+	}
+	port, err := strconv.Atoi(str)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("invalid port %q", str)
+	}
+	return port, nil
+}
+
+// composeVolumes reads short (source:target[:mode]) and long volume syntax.
+// Relative bind sources depend on where the compose file lives and are
+// skipped.
+func composeVolumes(raw interface{}, warn func(string, ...interface{})) []docker.VolumeMount {
+	items, _ := raw.([]interface{})
+	var volumes []docker.VolumeMount
+	for _, item := range items {
+		var mount docker.VolumeMount
+		switch v := item.(type) {
+		case map[string]interface{}:
+			mount.Type, _ = v["type"].(string)
+			mount.Source, _ = v["source"].(string)
+			mount.Target, _ = v["target"].(string)
+			mount.ReadOnly, _ = v["read_only"].(bool)
+		default:
+			parts := strings.Split(fmt.Sprint(v), ":")
+			switch len(parts) {
+			case 1:
+				mount.Target = parts[0]
+			case 2, 3:
+				mount.Source, mount.Target = parts[0], parts[1]
+				if len(parts) == 3 {
+					for _, option := range strings.Split(parts[2], ",") {
+						if option == "ro" {
+							mount.ReadOnly = true
+						}
+					}
+				}
+			}
+		}
+
+		if mount.Target == "" {
+			warn("volume %v is skipped: no target", item)
+			continue
+		}
+		if mount.Type == "" {
+			mount.Type = "volume"
+			if strings.HasPrefix(mount.Source, "/") || strings.HasPrefix(mount.Source, ".") || strings.HasPrefix(mount.Source, "~") {
+				mount.Type = "bind"
+			}
+		}
+		if mount.Type == "bind" && !strings.HasPrefix(mount.Source, "/") {
+			warn("volume %s is skipped: bind sources must be absolute paths", mount.Source)
+			continue
+		}
+		volumes = append(volumes, mount)
+	}
+	return volumes
+}
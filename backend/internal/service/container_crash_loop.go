@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// restartTracker remembers the restarts the status sync saw per container.
+// Docker only reports a restart count, so a restart is timed by the sync that
+// first sees it.
+type restartTracker struct {
+	mu         sync.Mutex
+	containers map[int]*restartHistory
+}
+
+type restartHistory struct {
+	dockerID     string
+	restartCount int
+	restarts     []time.Time
+}
+
+func newRestartTracker() *restartTracker {
+	return &restartTracker{containers: make(map[int]*restartHistory)}
+}
+
+// observe records the daemon's restart count for a container and returns the
+// number of restarts seen within window before now. The first count seen is
+// the baseline; a container recreated since keeps its history and its
+// restarts count from zero.
+func (t *restartTracker) observe(id int, dockerID string, restartCount int, now time.Time, window time.Duration) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	history, ok := t.containers[id]
+	switch {
+	case !ok:
+		history = &restartHistory{dockerID: dockerID, restartCount: restartCount}
+		t.containers[id] = history
+	case history.dockerID != dockerID:
+		history.dockerID = dockerID
+		history.restartCount = 0
+	case restartCount < history.restartCount:
+		history.restartCount = restartCount
+	}
+
+	for i := history.restartCount; i < restartCount; i++ {
+		history.restarts = append(history.restarts, now)
+	}
+	history.restartCount = restartCount
+
+	cutoff := now.Add(-window)
+	kept := history.restarts[:0]
+	for _, at := range history.restarts {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	history.restarts = kept
+
+	return len(kept)
+}
+
+// crashLoopRule returns the crash loop settings; a threshold of 0 disables
+// detection
+func (s *ContainerService) crashLoopRule() (threshold int, window, stable time.Duration, hold bool) {
+	monitoring := s.config.Monitoring
+
+	window = time.Duration(monitoring.CrashLoopWindowMinutes) * time.Minute
+	if window <= 0 {
+		window = 10 * time.Minute
+	}
+	stable = time.Duration(monitoring.CrashLoopStableMinutes) * time.Minute
+	if stable <= 0 {
+		stable = 30 * time.Minute
+	}
+	return monitoring.CrashLoopRestartThreshold, window, stable, monitoring.CrashLoopHoldUpdates
+}
+
+// recordRestarts checks an inspected container for a crash loop. A container
+// reaching the restart threshold is flagged and reported; a flagged container
+// running for the stable period is cleared. A hold on updates stays until the
+// crash loop is acknowledged.
+func (s *ContainerService) recordRestarts(ctx context.Context, container *model.Container, live *types.ContainerJSON, now time.Time) {
+	threshold, window, stable, hold := s.crashLoopRule()
+	if threshold <= 0 || live == nil || live.ContainerJSONBase == nil {
+		return
+	}
+
+	restarts := s.restarts.observe(container.ID, live.ID, live.RestartCount, now, window)
+
+	switch {
+	case !container.CrashLooping && restarts >= threshold:
+		s.markCrashLoop(ctx, container, restarts, window, hold, now)
+	case container.CrashLooping && runningFor(live, now) >= stable:
+		if err := s.containerRepo.UpdateCrashLoop(ctx, int64(container.ID), false, container.CrashLoopDetectedAt, container.CrashLoopHold); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to clear container crash loop")
+			return
+		}
+		container.CrashLooping = false
+		logrus.WithField("container_id", container.ID).Info("Container recovered from crash loop")
+	}
+}
+
+// markCrashLoop flags a crash looping container and notifies about it
+func (s *ContainerService) markCrashLoop(ctx context.Context, container *model.Container, restarts int, window time.Duration, hold bool, now time.Time) {
+	detectedAt := now.UTC()
+	held := container.CrashLoopHold || hold
+	if err := s.containerRepo.UpdateCrashLoop(ctx, int64(container.ID), true, &detectedAt, held); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to record container crash loop")
+		return
+	}
+	container.CrashLooping = true
+	container.CrashLoopDetectedAt = &detectedAt
+	container.CrashLoopHold = held
+
+	windowMinutes := int(window / time.Minute)
+	logrus.WithFields(logrus.Fields{
+		"container_id": container.ID,
+		"restarts":     restarts,
+		"window":       window,
+	}).Warn("Container is crash looping")
+
+	s.logContainerActivity(model.SystemActor(model.ActorComponentStatusSync), int64(container.ID), "crash_loop_detected",
+		fmt.Sprintf("Container %s restarted %d times in %d minutes", container.Name, restarts, windowMinutes),
+		map[string]interface{}{"restarts": restarts, "window_minutes": windowMinutes, "updates_held": held})
+
+	if s.notificationService == nil {
+		return
+	}
+
+	payload := model.NewCrashLoopPayload(container.ID, container.Name, restarts, windowMinutes, held)
+	notification := &model.Notification{
+		Type:     model.NotificationTypeHealthCheck,
+		Title:    fmt.Sprintf("Container %s is crash looping", container.Name),
+		Message:  payload.Summary(),
+		Priority: model.NotificationPriorityHigh,
+		Data:     model.NotificationData(payload),
+	}
+	if err := s.notificationService.SendNotification(ctx, notification); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to send crash loop notification")
+	}
+}
+
+// AcknowledgeCrashLoop releases the hold a crash loop put on automatic
+// updates. The crash loop flag itself clears once the container runs stably.
+func (s *ContainerService) AcknowledgeCrashLoop(ctx context.Context, actor model.Actor, containerID int64) (*model.Container, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+
+	if !container.CrashLooping && !container.CrashLoopHold {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: container %s is not crash looping", container.Name)
+	}
+
+	if err := s.containerRepo.UpdateCrashLoop(ctx, containerID, container.CrashLooping, container.CrashLoopDetectedAt, false); err != nil {
+		return nil, err
+	}
+	container.CrashLoopHold = false
+
+	metadata := map[string]interface{}{"crash_looping": container.CrashLooping}
+	if container.CrashLoopDetectedAt != nil {
+		metadata["detected_at"] = container.CrashLoopDetectedAt
+	}
+	s.logContainerActivity(actor, containerID, "crash_loop_acknowledge",
+		fmt.Sprintf("Acknowledged crash loop of container %s", container.Name), metadata)
+
+	return container, nil
+}
+
+// runningFor returns how long an inspected container has been running
+func runningFor(live *types.ContainerJSON, now time.Time) time.Duration {
+	if live.State == nil || !live.State.Running {
+		return 0
+	}
+	startedAt, err := time.Parse(time.RFC3339Nano, live.State.StartedAt)
+	if err != nil {
+		return 0
+	}
+	return now.Sub(startedAt)
+}
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+	logtest "github.com/sirupsen/logrus/hooks/test"
+)
+
+// crashLoopRepo records the crash loop updates of the service under test
+type crashLoopRepo struct {
+	repository.ContainerRepository
+	updates int
+}
+
+func (r *crashLoopRepo) UpdateCrashLoop(ctx context.Context, id int64, crashLooping bool, detectedAt *time.Time, hold bool) error {
+	r.updates++
+	return nil
+}
+
+func newCrashLoopTestService(t *testing.T, hold bool) (*ContainerService, *crashLoopRepo, *logtest.Hook) {
+	t.Helper()
+
+	logger, hook := logtest.NewNullLogger()
+	logger.SetLevel(logrus.InfoLevel)
+
+	repo := &crashLoopRepo{}
+	cfg := &config.Config{Monitoring: config.MonitoringConfig{
+		CrashLoopRestartThreshold: 5,
+		CrashLoopWindowMinutes:    10,
+		CrashLoopStableMinutes:    30,
+		CrashLoopHoldUpdates:      hold,
+	}}
+	s := &ContainerService{
+		containerRepo:       repo,
+		config:              cfg,
+		restarts:            newRestartTracker(),
+		notificationService: NewNotificationService(nil, logger, nil, nil, nil, nil, nil, nil),
+	}
+	return s, repo, hook
+}
+
+// inspected returns an inspection of a container restarted restartCount
+// times, running since startedAt
+func inspected(dockerID string, restartCount int, startedAt time.Time) *types.ContainerJSON {
+	return &types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+		ID:           dockerID,
+		RestartCount: restartCount,
+		State: &types.ContainerState{
+			Running:   true,
+			StartedAt: startedAt.Format(time.RFC3339Nano),
+		},
+	}}
+}
+
+// crashLoopNotifications returns the crash loop notifications sent so far
+func crashLoopNotifications(hook *logtest.Hook) []*logrus.Entry {
+	var sent []*logrus.Entry
+	for _, entry := range hook.AllEntries() {
+		data, _ := entry.Data["data"].(model.JSONMap)
+		if entry.Message == "Notification sent" && data["schema"] == string(model.PayloadSchemaCrashLoop) {
+			sent = append(sent, entry)
+		}
+	}
+	return sent
+}
+
+func TestRecordRestartsFlagsBurstAndClearsAfterStablePeriod(t *testing.T) {
+	s, _, hook := newCrashLoopTestService(t, true)
+	ctx := context.Background()
+	container := &model.Container{ID: 1, Name: "web", UpdatePolicy: model.UpdatePolicyAuto}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// The first inspection is the baseline, however often the container
+	// restarted before
+	s.recordRestarts(ctx, container, inspected("abc", 40, start), start)
+	if container.CrashLooping {
+		t.Fatal("baseline restart count flagged the container")
+	}
+
+	// A restart every minute: the fifth within ten minutes is a crash loop
+	now := start
+	for i := 1; i <= 5; i++ {
+		now = start.Add(time.Duration(i) * time.Minute)
+		s.recordRestarts(ctx, container, inspected("abc", 40+i, now), now)
+		if i < 5 && container.CrashLooping {
+			t.Fatalf("flagged after %d restarts", i)
+		}
+	}
+	if !container.CrashLooping || container.CrashLoopDetectedAt == nil {
+		t.Fatal("container not flagged after 5 restarts in 10 minutes")
+	}
+	if !container.CrashLoopHold || container.IsAutoUpdateEnabled() {
+		t.Error("crash loop did not hold automatic updates")
+	}
+	if sent := crashLoopNotifications(hook); len(sent) != 1 {
+		t.Fatalf("sent %d crash loop notifications, want 1", len(sent))
+	} else if sent[0].Data["priority"] != model.NotificationPriorityHigh {
+		t.Errorf("notification priority = %v, want high", sent[0].Data["priority"])
+	}
+
+	// Restarting on is reported once
+	now = now.Add(time.Minute)
+	s.recordRestarts(ctx, container, inspected("abc", 46, now), now)
+	if sent := crashLoopNotifications(hook); len(sent) != 1 {
+		t.Fatalf("sent %d crash loop notifications while still looping, want 1", len(sent))
+	}
+
+	// Running without restarts for less than the stable period keeps the flag
+	lastStart := now
+	now = lastStart.Add(20 * time.Minute)
+	s.recordRestarts(ctx, container, inspected("abc", 46, lastStart), now)
+	if !container.CrashLooping {
+		t.Fatal("flag cleared before the stable period")
+	}
+
+	now = lastStart.Add(30 * time.Minute)
+	s.recordRestarts(ctx, container, inspected("abc", 46, lastStart), now)
+	if container.CrashLooping {
+		t.Fatal("flag not cleared after the stable period")
+	}
+	if !container.CrashLoopHold {
+		t.Error("hold released without acknowledgement")
+	}
+}
+
+func TestRecordRestartsIgnoresSlowRestarts(t *testing.T) {
+	s, repo, hook := newCrashLoopTestService(t, true)
+	ctx := context.Background()
+	container := &model.Container{ID: 1, Name: "worker"}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.recordRestarts(ctx, container, inspected("abc", 0, start), start)
+	for i := 1; i <= 12; i++ {
+		now := start.Add(time.Duration(i) * 3 * time.Minute)
+		s.recordRestarts(ctx, container, inspected("abc", i, now), now)
+	}
+
+	if container.CrashLooping || repo.updates != 0 || len(crashLoopNotifications(hook)) != 0 {
+		t.Fatal("restarts every 3 minutes flagged as a crash loop")
+	}
+}
+
+func TestRecordRestartsCountsAcrossRecreation(t *testing.T) {
+	s, _, _ := newCrashLoopTestService(t, false)
+	ctx := context.Background()
+	container := &model.Container{ID: 1, Name: "api", UpdatePolicy: model.UpdatePolicyAuto}
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	s.recordRestarts(ctx, container, inspected("old", 7, start), start)
+	for i := 1; i <= 3; i++ {
+		now := start.Add(time.Duration(i) * time.Minute)
+		s.recordRestarts(ctx, container, inspected("old", 7+i, now), now)
+	}
+
+	// Recreated by an update, the new container restarts from zero
+	now := start.Add(4 * time.Minute)
+	s.recordRestarts(ctx, container, inspected("new", 2, now), now)
+
+	if !container.CrashLooping {
+		t.Fatal("restarts before and after recreation not counted together")
+	}
+	if container.CrashLoopHold || !container.IsAutoUpdateEnabled() {
+		t.Error("updates held with CRASH_LOOP_HOLD_UPDATES off")
+	}
+}
+
+func TestCrashLoopHoldOverridesAutomaticPolicy(t *testing.T) {
+	container := &model.Container{UpdatePolicy: model.UpdatePolicyAuto, CrashLoopHold: true}
+
+	effective := model.ResolveEffectivePolicy(container, nil, nil, model.PolicyDefaults{UpdatePolicy: model.UpdatePolicyAuto})
+	if effective.IsEligibleForAutoUpdate() {
+		t.Fatal("held container eligible for automatic updates")
+	}
+	if effective.Sources["update_policy"] != model.PolicySourceCrashLoop {
+		t.Errorf("update_policy source = %s, want crash_loop", effective.Sources["update_policy"])
+	}
+
+	container.UpdatePolicy = model.UpdatePolicyDisabled
+	effective = model.ResolveEffectivePolicy(container, nil, nil, model.PolicyDefaults{UpdatePolicy: model.UpdatePolicyAuto})
+	if effective.UpdatePolicy != model.UpdatePolicyDisabled {
+		t.Errorf("hold changed disabled policy to %s", effective.UpdatePolicy)
+	}
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GetContainerDependencies lists the containers a container depends on and
+// those depending on it
+func (s *ContainerService) GetContainerDependencies(ctx context.Context, actor model.Actor, id int64) (*dto.ContainerDependencies, error) {
+	container, err := s.containerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	graph, err := s.dependencyGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.containerDependencies(ctx, graph, container.ID)
+}
+
+// SetContainerDependencies replaces the containers a container depends on.
+// Dependencies that would form a loop are rejected, naming the containers
+// around it.
+func (s *ContainerService) SetContainerDependencies(ctx context.Context, actor model.Actor, id int64, req *dto.SetContainerDependenciesRequest) (*dto.ContainerDependencies, error) {
+	if err := req.Validate(id); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionManage); err != nil {
+		return nil, err
+	}
+
+	dependencies, err := s.containerRepo.GetByIDs(ctx, req.DependsOn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	found := make(map[int64]bool, len(dependencies))
+	for _, dependency := range dependencies {
+		if err := s.checkContainerPermission(ctx, dependency, actor, model.ContainerPermissionView); err != nil {
+			return nil, err
+		}
+		found[int64(dependency.ID)] = true
+	}
+	for _, dependencyID := range req.DependsOn {
+		if !found[dependencyID] {
+			return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: dependency container %d does not exist", dependencyID)
+		}
+	}
+
+	if err := s.containerRepo.SetDependencies(ctx, id, req.DependsOn); err != nil {
+		var cycleErr *model.DependencyCycleError
+		if errors.As(err, &cycleErr) {
+			return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: dependency cycle: %s", s.describeCycle(ctx, cycleErr.Cycle))
+		}
+		return nil, fmt.Errorf("failed to set dependencies: %w", err)
+	}
+
+	s.logContainerActivity(actor, id, "container_dependencies_updated", fmt.Sprintf("Dependencies of container %s set", container.Name), map[string]interface{}{
+		"depends_on": req.DependsOn,
+	})
+
+	graph, err := s.dependencyGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.containerDependencies(ctx, graph, container.ID)
+}
+
+// dependencyGraph loads the dependencies between all managed containers
+func (s *ContainerService) dependencyGraph(ctx context.Context) (model.DependencyGraph, error) {
+	dependencies, err := s.containerRepo.ListDependencies(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container dependencies: %w", err)
+	}
+	return model.NewDependencyGraph(dependencies), nil
+}
+
+// containerDependencies describes both sides of the dependencies of the
+// container id
+func (s *ContainerService) containerDependencies(ctx context.Context, graph model.DependencyGraph, id int) (*dto.ContainerDependencies, error) {
+	result := &dto.ContainerDependencies{
+		ContainerID: int64(id),
+		DependsOn:   []dto.DependencyContainer{},
+		Dependents:  []dto.DependencyContainer{},
+	}
+
+	dependsOn := graph[id]
+	dependents := graph.Dependents(id)
+	containers, err := s.containerRepo.GetByIDs(ctx, toInt64IDs(append(append([]int(nil), dependsOn...), dependents...)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	byID := make(map[int]*model.Container, len(containers))
+	for _, container := range containers {
+		byID[container.ID] = container
+	}
+
+	describe := func(ids []int) []dto.DependencyContainer {
+		described := make([]dto.DependencyContainer, 0, len(ids))
+		for _, containerID := range ids {
+			if container := byID[containerID]; container != nil {
+				described = append(described, dto.DependencyContainer{
+					ID:     int64(container.ID),
+					Name:   container.Name,
+					Status: container.Status,
+				})
+			}
+		}
+		return described
+	}
+	result.DependsOn = describe(dependsOn)
+	result.Dependents = describe(dependents)
+	return result, nil
+}
+
+// describeCycle names the containers around a dependency loop, falling back
+// to the ID of a container that cannot be read
+func (s *ContainerService) describeCycle(ctx context.Context, cycle []int) string {
+	names := make(map[int]string, len(cycle))
+	if containers, err := s.containerRepo.GetByIDs(ctx, toInt64IDs(cycle)); err == nil {
+		for _, container := range containers {
+			names[container.ID] = container.Name
+		}
+	}
+
+	parts := make([]string, len(cycle))
+	for i, id := range cycle {
+		if name, ok := names[id]; ok {
+			parts[i] = fmt.Sprintf("%s (%d)", name, id)
+		} else {
+			parts[i] = fmt.Sprintf("%d", id)
+		}
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// waitForDependencies waits up to timeout for each container the container
+// id depends on to report healthy. A dependency without a Docker health check
+// counts as healthy.
+func (s *ContainerService) waitForDependencies(ctx context.Context, graph model.DependencyGraph, id int, timeout time.Duration) error {
+	dependsOn := graph[id]
+	if len(dependsOn) == 0 {
+		return nil
+	}
+
+	dependencies, err := s.containerRepo.GetByIDs(ctx, toInt64IDs(dependsOn))
+	if err != nil {
+		return fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	for _, dependency := range dependencies {
+		if dependency.ContainerID == "" {
+			return fmt.Errorf("dependency %s has no Docker container", dependency.Name)
+		}
+		dc, err := s.hostClient(ctx, dependency.HostID)
+		if err != nil {
+			return err
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"container_id":  id,
+			"dependency_id": dependency.ID,
+		}).Debug("Waiting for dependency to become healthy")
+		if err := dc.WaitForHealthy(ctx, dependency.ContainerID, timeout); err != nil {
+			return fmt.Errorf("dependency %s is not healthy: %w", dependency.Name, err)
+		}
+	}
+	return nil
+}
+
+// toInt64IDs converts container IDs for the repository
+func toInt64IDs(ids []int) []int64 {
+	converted := make([]int64, len(ids))
+	for i, id := range ids {
+		converted[i] = int64(id)
+	}
+	return converted
+}
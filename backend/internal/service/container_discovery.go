@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// DiscoverContainers adopts the containers labeled <prefix>.enable=true on
+// the local daemon and the enabled remote hosts, taking their update policy,
+// registry and maintenance window from their labels. Adopted containers whose
+// labels changed are updated; those that lost the enable label, or are gone,
+// are marked unmanaged and keep their history. A labeled container named like
+// a container registered otherwise is left to the existing record.
+func (s *ContainerService) DiscoverContainers(ctx context.Context, actor model.Actor) (*dto.DiscoveryResult, error) {
+	startTime := time.Now()
+	result := &dto.DiscoveryResult{Timestamp: startTime}
+
+	prefix := ""
+	if s.config != nil {
+		prefix = s.config.Docker.DiscoveryLabelPrefix
+	}
+	if prefix == "" {
+		logrus.Debug("Container discovery is disabled")
+		return result, nil
+	}
+
+	records, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+	byName := make(map[string]*model.Container, len(records))
+	for _, record := range records {
+		byName[record.Name] = record
+	}
+
+	s.discoverOnDaemon(ctx, actor, s.dockerClient, nil, prefix, byName, result)
+
+	hosts, err := s.enabledDockerHosts(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list Docker hosts for discovery")
+	}
+	for _, host := range hosts {
+		dc, err := s.pingDockerHost(ctx, host)
+		if err != nil {
+			result.Errors = append(result.Errors, dto.SyncError{
+				Name:        host.Name,
+				Error:       fmt.Sprintf("Docker host unavailable: %v", err),
+				Recoverable: true,
+			})
+			continue
+		}
+		hostID := host.ID
+		s.discoverOnDaemon(ctx, actor, dc, &hostID, prefix, byName, result)
+	}
+
+	if len(result.Updated) > 0 || len(result.Unmanaged) > 0 {
+		s.refreshContainerMetrics(ctx)
+		s.invalidateContainerCache(actor)
+	}
+	result.Duration = time.Since(startTime)
+
+	logrus.WithFields(logrus.Fields{
+		"labeled":   result.Labeled,
+		"adopted":   len(result.Adopted),
+		"updated":   len(result.Updated),
+		"unmanaged": len(result.Unmanaged),
+		"conflicts": len(result.Conflicts),
+		"errors":    len(result.Errors),
+		"duration":  result.Duration,
+	}).Info("Container discovery completed")
+
+	return result, nil
+}
+
+// discoverOnDaemon runs discovery for the daemon dc of host hostID, nil for
+// the local daemon. Records are only marked unmanaged once the daemon could
+// be listed.
+func (s *ContainerService) discoverOnDaemon(ctx context.Context, actor model.Actor, dc *docker.DockerClient, hostID *int, prefix string, byName map[string]*model.Container, result *dto.DiscoveryResult) {
+	if dc == nil {
+		return
+	}
+
+	dockerContainers, err := dc.ListContainers(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		result.Errors = append(result.Errors, dto.SyncError{
+			Error:       fmt.Sprintf("failed to list Docker containers: %v", err),
+			Recoverable: true,
+		})
+		return
+	}
+
+	labeled := make(map[string]bool)
+	for _, dockerContainer := range dockerContainers {
+		if len(dockerContainer.Names) == 0 || !model.DiscoveryEnabled(prefix, dockerContainer.Labels) {
+			continue
+		}
+		name := strings.TrimPrefix(dockerContainer.Names[0], "/")
+		labeled[name] = true
+		result.Labeled++
+
+		labels, err := model.ParseDiscoveryLabels(prefix, dockerContainer.Labels)
+		if err != nil {
+			result.Errors = append(result.Errors, dto.SyncError{Name: name, Error: err.Error()})
+			continue
+		}
+
+		record := byName[name]
+		if record == nil {
+			container, err := s.importContainer(ctx, actor, dc, dockerContainer.ID, hostID, nil, labels)
+			if err != nil {
+				result.Errors = append(result.Errors, dto.SyncError{Name: name, Error: err.Error(), Recoverable: true})
+				continue
+			}
+			byName[name] = container
+			result.Adopted = append(result.Adopted, name)
+			continue
+		}
+
+		if !record.Adopted || !sameDockerHost(record.HostID, hostID) {
+			logrus.WithFields(logrus.Fields{
+				"container_name": name,
+				"container_id":   record.ID,
+			}).Warn("Labeled container has the name of a registered container; keeping the existing record")
+			result.Conflicts = append(result.Conflicts, name)
+			continue
+		}
+
+		if changed, err := s.applyDiscoveryLabels(ctx, actor, record, labels); err != nil {
+			result.Errors = append(result.Errors, dto.SyncError{ContainerID: int64(record.ID), Name: name, Error: err.Error(), Recoverable: true})
+		} else if changed {
+			result.Updated = append(result.Updated, name)
+		}
+	}
+
+	for name, record := range byName {
+		if !record.Adopted || record.Unmanaged || labeled[name] || !sameDockerHost(record.HostID, hostID) {
+			continue
+		}
+		if err := s.unmanageContainer(ctx, actor, record, prefix); err != nil {
+			result.Errors = append(result.Errors, dto.SyncError{ContainerID: int64(record.ID), Name: name, Error: err.Error(), Recoverable: true})
+			continue
+		}
+		result.Unmanaged = append(result.Unmanaged, name)
+	}
+}
+
+// applyDiscoveryLabels updates an adopted container to its current labels,
+// managing it again if it was unmanaged. It reports whether anything changed.
+func (s *ContainerService) applyDiscoveryLabels(ctx context.Context, actor model.Actor, container *model.Container, labels *model.DiscoveryLabels) (bool, error) {
+	if container.UpdatePolicy == labels.UpdatePolicy &&
+		container.RegistryURL == labels.RegistryURL &&
+		container.MaintenanceWindows == labels.MaintenanceWindows &&
+		!container.Unmanaged {
+		return false, nil
+	}
+
+	updated := *container
+	updated.UpdatePolicy = labels.UpdatePolicy
+	updated.RegistryURL = labels.RegistryURL
+	updated.MaintenanceWindows = labels.MaintenanceWindows
+	updated.Unmanaged = false
+	if err := s.containerRepo.Update(ctx, &updated); err != nil {
+		return false, fmt.Errorf("failed to update container: %w", err)
+	}
+
+	s.logContainerActivity(actor, int64(container.ID), "container_labels_applied", "Container updated from its discovery labels", map[string]interface{}{
+		"container_name":      container.Name,
+		"update_policy":       updated.UpdatePolicy,
+		"registry_url":        updated.RegistryURL,
+		"maintenance_windows": updated.MaintenanceWindows,
+		"managed_again":       container.Unmanaged,
+	})
+	*container = updated
+	return true, nil
+}
+
+// unmanageContainer marks an adopted container whose enable label is gone as
+// unmanaged, keeping the record and its history
+func (s *ContainerService) unmanageContainer(ctx context.Context, actor model.Actor, container *model.Container, prefix string) error {
+	updated := *container
+	updated.Unmanaged = true
+	if err := s.containerRepo.Update(ctx, &updated); err != nil {
+		return fmt.Errorf("failed to update container: %w", err)
+	}
+
+	s.logContainerActivity(actor, int64(container.ID), "container_unmanaged", "Container no longer labeled for discovery", map[string]interface{}{
+		"container_name": container.Name,
+		"label":          model.DiscoveryLabel(prefix, model.DiscoveryLabelEnable),
+	})
+	logrus.WithField("container_name", container.Name).Info("Adopted container lost its discovery label; marked unmanaged")
+
+	*container = updated
+	return nil
+}
+
+// sameDockerHost reports whether two host IDs name the same daemon, nil being
+// the local one
+func sameDockerHost(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
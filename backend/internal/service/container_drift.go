@@ -0,0 +1,968 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// convergeOperation names converge confirmations
+const convergeOperation = "container_converge"
+
+// DriftSeverity tells whether a drift changes how the container behaves
+type DriftSeverity string
+
+const (
+	// DriftSeverityCosmetic drift, such as labels, does not change behaviour
+	DriftSeverityCosmetic   DriftSeverity = "cosmetic"
+	DriftSeverityFunctional DriftSeverity = "functional"
+)
+
+// Drift changes
+const (
+	DriftAdded   = "added"
+	DriftRemoved = "removed"
+	DriftChanged = "changed"
+)
+
+// FieldDrift is one difference between the stored configuration and the live
+// container. Secret values are compared by hash and left out.
+type FieldDrift struct {
+	Field    string        `json:"field"`
+	Key      string        `json:"key,omitempty"`
+	Change   string        `json:"change"`
+	Severity DriftSeverity `json:"severity"`
+	Desired  interface{}   `json:"desired,omitempty"`
+	Actual   interface{}   `json:"actual,omitempty"`
+	Secret   bool          `json:"secret,omitempty"`
+}
+
+// DriftReport compares a live container with its stored configuration
+type DriftReport struct {
+	ContainerID int64         `json:"container_id"`
+	Name        string        `json:"name"`
+	DockerID    string        `json:"docker_id"`
+	Drifted     bool          `json:"drifted"`
+	Severity    DriftSeverity `json:"severity,omitempty"`
+	Fields      []FieldDrift  `json:"fields"`
+	CheckedAt   time.Time     `json:"checked_at"`
+
+	// ConfirmToken confirms converging the container as reported
+	ConfirmToken string     `json:"confirm_token,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// ConvergeRequest confirms recreating a drifted container
+type ConvergeRequest struct {
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// ConvergeResult is the drift a converge eliminated and the update recording it
+type ConvergeResult struct {
+	Drift    *DriftReport         `json:"drift"`
+	Update   *model.UpdateHistory `json:"update,omitempty"`
+	Warnings []string             `json:"warnings,omitempty"`
+}
+
+// RedeployRequest resolves image drift. By default the container is
+// recreated from the digest it was deployed with; Repin instead takes the
+// digest it runs as deployed.
+type RedeployRequest struct {
+	Repin bool `json:"repin"`
+}
+
+// RedeployResult is the container after its image drift was resolved, with
+// the update recording the recreation when it was redeployed
+type RedeployResult struct {
+	Container *model.Container     `json:"container"`
+	Update    *model.UpdateHistory `json:"update,omitempty"`
+	Warnings  []string             `json:"warnings,omitempty"`
+}
+
+// secretEnvName matches environment variable names treated as secrets even
+// when the container does not list them in SecretEnv
+var secretEnvName = regexp.MustCompile(`(?i)passw(?:or)?d|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|credential`)
+
+// containerDesiredState is the Docker configuration stored for a container.
+// Nil sections are not managed and are not compared.
+type containerDesiredState struct {
+	Env           []string
+	Labels        map[string]string
+	Ports         []dto.PortMapping
+	Volumes       []docker.VolumeMount
+	Resources     *docker.ResourceConfig
+	RestartPolicy string
+}
+
+// desiredContainerState parses the stored configuration of a container
+func desiredContainerState(container *model.Container) (*containerDesiredState, error) {
+	var config map[string]json.RawMessage
+	if container.ConfigJSON != "" {
+		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse container config: %w", err)
+		}
+	}
+
+	state := &containerDesiredState{RestartPolicy: container.RestartPolicy}
+
+	if raw, ok := config["env"]; ok {
+		json.Unmarshal(raw, &state.Env)
+	}
+	if raw, ok := config["labels"]; ok {
+		json.Unmarshal(raw, &state.Labels)
+	}
+	if raw, ok := config["ports"]; ok {
+		json.Unmarshal(raw, &state.Ports)
+	}
+	if raw, ok := config["volumes"]; ok {
+		json.Unmarshal(raw, &state.Volumes)
+	}
+	if raw, ok := config["resources"]; ok {
+		if err := json.Unmarshal(raw, &state.Resources); err != nil {
+			return nil, fmt.Errorf("invalid resources in container config: %w", err)
+		}
+	}
+
+	return state, nil
+}
+
+// GetContainerDrift inspects the live container and reports how it differs
+// from the stored configuration. A drifted report carries the token that
+// confirms converging it.
+func (s *ContainerService) GetContainerDrift(ctx context.Context, actor model.Actor, containerID int64) (*DriftReport, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	report, _, err := s.detectDrift(ctx, dc, container, nil)
+	if err != nil {
+		return nil, err
+	}
+	s.recordDrift(ctx, container, report.Drifted)
+
+	if report.Drifted {
+		token, expiresAt := s.tokens.Issue(convergeOperation, actorUserID(actor), report.digest())
+		report.ConfirmToken = token
+		report.ExpiresAt = &expiresAt
+	}
+
+	return report, nil
+}
+
+// ConvergeContainer recreates a drifted container from its stored
+// configuration. The token from the drift report must match the drift found
+// now, so only the reviewed drift is eliminated.
+func (s *ContainerService) ConvergeContainer(ctx context.Context, actor model.Actor, containerID int64, req *ConvergeRequest) (*ConvergeResult, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	report, live, err := s.detectDrift(ctx, dc, container, nil)
+	if err != nil {
+		return nil, err
+	}
+	if !report.Drifted {
+		s.recordDrift(ctx, container, false)
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: container matches its stored configuration")
+	}
+	if req == nil || req.ConfirmToken == "" {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: confirm_token from the drift report is required")
+	}
+	if err := s.tokens.Verify(req.ConfirmToken, convergeOperation, actorUserID(actor), report.digest()); err != nil {
+		return nil, err
+	}
+
+	history := &model.UpdateHistory{
+		ContainerID: container.ID,
+		OldImage:    live.Config.Image,
+		NewImage:    container.GetDeployImageRef(),
+		NewDigest:   container.ImageDigest,
+		Status:      model.UpdateStatusRunning,
+		Strategy:    model.UpdateStrategyRecreate,
+		TriggeredBy: model.TriggerTypeConverge,
+		StartedAt:   time.Now(),
+	}
+	history.SetActor(actor)
+	if err := s.updateHistoryRepo.Create(ctx, history); err != nil {
+		return nil, fmt.Errorf("failed to create update history: %w", err)
+	}
+	s.publishUpdateStarted(container, history)
+
+	warnings, err := s.recreateDockerContainer(ctx, dc, container, live.State != nil && live.State.Running)
+
+	completedAt := time.Now()
+	history.CompletedAt = &completedAt
+	history.DurationSeconds = int(completedAt.Sub(history.StartedAt).Seconds())
+	history.Warnings = model.StringList(warnings)
+	if err != nil {
+		history.Status = model.UpdateStatusFailed
+		history.ErrorMessage = err.Error()
+	} else {
+		history.Status = model.UpdateStatusCompleted
+	}
+	metrics.RecordContainerUpdate(string(history.Status))
+	if updateErr := s.updateHistoryRepo.Update(ctx, history); updateErr != nil {
+		logrus.WithError(updateErr).WithField("update_id", history.ID).Warn("Failed to update history record")
+	}
+	s.publishUpdateCompleted(container, history)
+	s.recordAppliedUpdate(ctx, container, history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to converge container: %w", err)
+	}
+
+	s.recordDrift(ctx, container, false)
+
+	fields := make([]string, 0, len(report.Fields))
+	for _, field := range report.Fields {
+		fields = append(fields, field.Field)
+	}
+	s.logContainerActivity(actor, containerID, "container_converged", "Container recreated from its stored configuration", map[string]interface{}{
+		"update_id": history.ID,
+		"severity":  report.Severity,
+		"fields":    fields,
+	})
+
+	s.cache.Delete(fmt.Sprintf("container:status:%d", containerID))
+	s.invalidateContainerCache(actor)
+
+	return &ConvergeResult{Drift: report, Update: history, Warnings: warnings}, nil
+}
+
+// RedeployContainer resolves the image drift of a container whose image was
+// replaced outside the application: it is recreated from the digest it was
+// deployed with, or with Repin that digest is replaced by the one it runs.
+func (s *ContainerService) RedeployContainer(ctx context.Context, actor model.Actor, containerID int64, req *RedeployRequest) (*RedeployResult, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+
+	if !container.HasImageDrift() {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: container runs the image it was deployed with")
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	if req != nil && req.Repin {
+		return s.repinContainer(ctx, actor, dc, container)
+	}
+
+	deployed, running := container.DeployedDigest, container.RunningDigest
+	if _, err := s.resolvePinnedDigest(ctx, dc, container, deployed); err != nil {
+		return nil, err
+	}
+	// Point the tag back at the deployed image, which the container is
+	// recreated from
+	if !container.PinByDigest {
+		if err := dc.TagImage(ctx, container.Image+"@"+deployed, container.GetFullImageName()); err != nil {
+			return nil, fmt.Errorf("failed to tag deployed image: %w", err)
+		}
+	}
+
+	live, err := dc.GetContainer(ctx, container.ContainerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	history := &model.UpdateHistory{
+		ContainerID: container.ID,
+		OldImage:    container.Image + "@" + running,
+		NewImage:    container.Image + "@" + deployed,
+		OldDigest:   running,
+		NewDigest:   deployed,
+		Status:      model.UpdateStatusRunning,
+		Strategy:    model.UpdateStrategyRecreate,
+		TriggeredBy: model.TriggerTypeRedeploy,
+		StartedAt:   time.Now(),
+	}
+	history.SetActor(actor)
+	if err := s.updateHistoryRepo.Create(ctx, history); err != nil {
+		return nil, fmt.Errorf("failed to create update history: %w", err)
+	}
+	s.publishUpdateStarted(container, history)
+
+	warnings, err := s.recreateDockerContainer(ctx, dc, container, live.State != nil && live.State.Running)
+
+	completedAt := time.Now()
+	history.CompletedAt = &completedAt
+	history.DurationSeconds = int(completedAt.Sub(history.StartedAt).Seconds())
+	history.Warnings = model.StringList(warnings)
+	if err != nil {
+		history.Status = model.UpdateStatusFailed
+		history.ErrorMessage = err.Error()
+	} else {
+		history.Status = model.UpdateStatusCompleted
+	}
+	metrics.RecordContainerUpdate(string(history.Status))
+	if updateErr := s.updateHistoryRepo.Update(ctx, history); updateErr != nil {
+		logrus.WithError(updateErr).WithField("update_id", history.ID).Warn("Failed to update history record")
+	}
+	s.publishUpdateCompleted(container, history)
+	s.recordAppliedUpdate(ctx, container, history)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeploy container: %w", err)
+	}
+
+	if err := s.containerRepo.UpdateImageDigests(ctx, containerID, deployed, deployed); err != nil {
+		logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to record container image digests")
+	}
+	container.DeployedDigest, container.RunningDigest = deployed, deployed
+	s.recordDrift(ctx, container, false)
+
+	s.logContainerActivity(actor, containerID, "container_redeployed", "Container recreated from the digest it was deployed with", map[string]interface{}{
+		"update_id":       history.ID,
+		"deployed_digest": deployed,
+		"running_digest":  running,
+	})
+
+	s.cache.Delete(fmt.Sprintf("container:status:%d", containerID))
+	s.invalidateContainerCache(actor)
+
+	return &RedeployResult{Container: container, Update: history, Warnings: warnings}, nil
+}
+
+// repinContainer takes the digest a container runs as the one it is deployed
+// with, and for containers pinned by digest as their pin
+func (s *ContainerService) repinContainer(ctx context.Context, actor model.Actor, dc *docker.DockerClient, container *model.Container) (*RedeployResult, error) {
+	previous, running := container.DeployedDigest, container.RunningDigest
+
+	updated := *container
+	updated.DeployedDigest = running
+	if updated.PinByDigest {
+		updated.ImageDigest = running
+		updated.PendingDigest = ""
+	}
+	if err := s.containerRepo.Update(ctx, &updated); err != nil {
+		return nil, fmt.Errorf("failed to update container: %w", err)
+	}
+	*container = updated
+
+	// Other drift may remain
+	if report, _, err := s.detectDrift(ctx, dc, container, nil); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to check container drift")
+	} else {
+		s.recordDrift(ctx, container, report.Drifted)
+	}
+
+	s.logContainerActivity(actor, int64(container.ID), "container_repinned", "Running image taken as deployed", map[string]interface{}{
+		"previous_digest": previous,
+		"deployed_digest": running,
+	})
+
+	s.invalidateContainerCache(actor)
+
+	return &RedeployResult{Container: container}, nil
+}
+
+// recreateDockerContainer replaces the Docker container with one created from
+// the stored configuration, starting it when the old one was running
+func (s *ContainerService) recreateDockerContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container, start bool) ([]string, error) {
+	if start {
+		if err := stopDockerContainer(ctx, dc, container, container.ContainerID); err != nil {
+			return nil, fmt.Errorf("failed to stop container: %w", err)
+		}
+	}
+
+	// Named volumes and bind mounts survive; only the container is replaced
+	if err := dc.RemoveContainer(ctx, container.ContainerID, types.ContainerRemoveOptions{Force: true}); err != nil {
+		return nil, fmt.Errorf("failed to remove container: %w", err)
+	}
+
+	dockerContainerID, daemonWarnings, err := s.createDockerContainer(ctx, dc, container)
+	if err != nil {
+		return nil, err
+	}
+	container.SetDeployedContainer(dockerContainerID)
+	warnings := s.RecordDaemonWarnings(ctx, container, daemonWarnings)
+
+	if err := s.containerRepo.UpdateContainerID(ctx, int64(container.ID), dockerContainerID); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to update container Docker ID")
+	}
+
+	if start {
+		if err := dc.StartContainer(ctx, dockerContainerID); err != nil {
+			return warnings, fmt.Errorf("failed to start container: %w", err)
+		}
+	}
+
+	return warnings, nil
+}
+
+// recordDrift stores the drift flag when it changed
+func (s *ContainerService) recordDrift(ctx context.Context, container *model.Container, drifted bool) {
+	if container.Drifted == drifted && container.DriftCheckedAt != nil {
+		return
+	}
+	if err := s.containerRepo.UpdateDrift(ctx, int64(container.ID), drifted); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to record container drift")
+		return
+	}
+	container.Drifted = drifted
+}
+
+// detectDrift compares the live container with its stored configuration.
+// images caches image inspections across calls and may be nil.
+func (s *ContainerService) detectDrift(ctx context.Context, dc *docker.DockerClient, container *model.Container, images map[string]*types.ImageInspect) (*DriftReport, *types.ContainerJSON, error) {
+	if container.ContainerID == "" {
+		return nil, nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: container has not been created in Docker yet")
+	}
+
+	desired, err := desiredContainerState(container)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	live, err := dc.GetContainer(ctx, container.ContainerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if live.Config == nil || live.HostConfig == nil {
+		return nil, nil, fmt.Errorf("incomplete inspection of container %s", container.ContainerID)
+	}
+
+	// Image defaults explain env, labels and volumes the stored config omits
+	var image *types.ImageInspect
+	if images != nil {
+		image = images[live.Image]
+	}
+	if image == nil {
+		if image, err = dc.InspectImage(ctx, live.Image); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Debug("Failed to inspect image for drift defaults")
+			image = &types.ImageInspect{}
+		}
+		if images != nil {
+			images[live.Image] = image
+		}
+	}
+
+	report := &DriftReport{
+		ContainerID: int64(container.ID),
+		Name:        container.Name,
+		DockerID:    container.ContainerID,
+		Fields:      []FieldDrift{},
+		CheckedAt:   time.Now(),
+	}
+
+	report.Fields = append(report.Fields, diffImage(container, live, image)...)
+	if desired.Env != nil {
+		report.Fields = append(report.Fields, diffEnv(container, desired.Env, live.Config.Env, imageEnv(image))...)
+	}
+	if desired.Labels != nil {
+		report.Fields = append(report.Fields, diffLabels(desired.Labels, live.Config.Labels, imageLabels(image))...)
+	}
+	if desired.Volumes != nil {
+		report.Fields = append(report.Fields, diffMounts(desired.Volumes, live.Mounts, image)...)
+	}
+	if desired.Ports != nil {
+		report.Fields = append(report.Fields, diffPorts(desired.Ports, live)...)
+	}
+	if desired.RestartPolicy != "" {
+		actual := string(live.HostConfig.RestartPolicy.Name)
+		if actual == "" {
+			actual = "no"
+		}
+		if actual != desired.RestartPolicy {
+			report.Fields = append(report.Fields, FieldDrift{
+				Field: "restart_policy", Change: DriftChanged, Severity: DriftSeverityFunctional,
+				Desired: desired.RestartPolicy, Actual: actual,
+			})
+		}
+	}
+	report.Fields = append(report.Fields, diffResources(desired.Resources, live)...)
+
+	// Order fields so reports of the same drift compare equal
+	sort.SliceStable(report.Fields, func(i, j int) bool {
+		a, b := report.Fields[i], report.Fields[j]
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.Key < b.Key
+	})
+
+	for _, field := range report.Fields {
+		report.Drifted = true
+		if report.Severity != DriftSeverityFunctional {
+			report.Severity = field.Severity
+		}
+	}
+
+	return report, live, nil
+}
+
+// digest identifies the drift a converge confirmation vouches for
+func (r *DriftReport) digest() string {
+	entries := make([]string, 0, len(r.Fields))
+	for _, field := range r.Fields {
+		entries = append(entries, field.Field+"/"+field.Key+"/"+field.Change)
+	}
+	sort.Strings(entries)
+
+	sum := sha256.Sum256([]byte(r.DockerID + "\n" + strings.Join(entries, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+func diffImage(container *model.Container, live *types.ContainerJSON, image *types.ImageInspect) []FieldDrift {
+	if container.PinByDigest && container.ImageDigest != "" {
+		for _, repoDigest := range image.RepoDigests {
+			if strings.HasSuffix(repoDigest, "@"+container.ImageDigest) {
+				return nil
+			}
+		}
+		var actual string
+		if len(image.RepoDigests) > 0 {
+			_, _, actual = model.ParseImageReference(image.RepoDigests[0])
+		}
+		return []FieldDrift{{
+			Field: "image_digest", Change: DriftChanged, Severity: DriftSeverityFunctional,
+			Desired: container.ImageDigest, Actual: actual,
+		}}
+	}
+
+	liveImage, tag, _ := model.ParseImageReference(live.Config.Image)
+	if tag == "" {
+		tag = "latest"
+	}
+	if !isOnImage(container, liveImage, tag) {
+		return []FieldDrift{{
+			Field: "image", Change: DriftChanged, Severity: DriftSeverityFunctional,
+			Desired: container.GetFullImageName(), Actual: live.Config.Image,
+		}}
+	}
+
+	// The tag may have been pulled again and the container recreated
+	// outside the application
+	running := runningDigest(container, image)
+	if container.DeployedDigest == "" || running == "" || running == container.DeployedDigest {
+		return nil
+	}
+	return []FieldDrift{{
+		Field: "image_digest", Change: DriftChanged, Severity: DriftSeverityFunctional,
+		Desired: container.DeployedDigest, Actual: running,
+	}}
+}
+
+// runningDigest returns the registry digest of the image a container runs:
+// the deployed one when the image carries it, else the one of the
+// container's repository. It is empty for images never pushed or pulled.
+func runningDigest(container *model.Container, image *types.ImageInspect) string {
+	if image == nil {
+		return ""
+	}
+
+	deployed := container.DeployedDigest
+	if container.PinByDigest && container.ImageDigest != "" {
+		deployed = container.ImageDigest
+	}
+
+	var running string
+	for _, repoDigest := range image.RepoDigests {
+		repository, _, digest := model.ParseImageReference(repoDigest)
+		if digest != "" && digest == deployed {
+			return digest
+		}
+		if running == "" && model.NormalizeRepository(repository) == model.NormalizeRepository(container.Image) {
+			running = digest
+		}
+	}
+	if running == "" && len(image.RepoDigests) > 0 {
+		_, _, running = model.ParseImageReference(image.RepoDigests[0])
+	}
+	return running
+}
+
+// recordImageDigests records the digest the container runs, taking it as the
+// deployed one when none is recorded yet. A change to a digest other than
+// the deployed one is notified once.
+func (s *ContainerService) recordImageDigests(ctx context.Context, container *model.Container, image *types.ImageInspect) {
+	running := runningDigest(container, image)
+	if running == "" {
+		return
+	}
+	deployed := container.DeployedDigest
+	if container.PinByDigest && container.ImageDigest != "" {
+		deployed = container.ImageDigest
+	} else if deployed == "" {
+		deployed = running
+	}
+	if deployed == container.DeployedDigest && running == container.RunningDigest {
+		return
+	}
+
+	if err := s.containerRepo.UpdateImageDigests(ctx, int64(container.ID), deployed, running); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to record container image digests")
+		return
+	}
+	changed := running != container.RunningDigest
+	container.DeployedDigest, container.RunningDigest = deployed, running
+	if changed && container.HasImageDrift() {
+		s.notifyImageDrift(ctx, container)
+	}
+}
+
+// notifyImageDrift reports a container found running another image than the
+// one it was deployed with
+func (s *ContainerService) notifyImageDrift(ctx context.Context, container *model.Container) {
+	logrus.WithFields(logrus.Fields{
+		"container_id":    container.ID,
+		"deployed_digest": container.DeployedDigest,
+		"running_digest":  container.RunningDigest,
+	}).Warn("Container runs another image than it was deployed with")
+
+	s.logContainerActivity(model.SystemActor(model.ActorComponentStatusSync), int64(container.ID), "image_drift_detected",
+		fmt.Sprintf("Container %s runs another image than it was deployed with", container.Name),
+		map[string]interface{}{"deployed_digest": container.DeployedDigest, "running_digest": container.RunningDigest})
+
+	if s.notificationService == nil {
+		return
+	}
+
+	payload := model.NewImageDriftPayload(container.ID, container.Name, container.Image, container.DeployedDigest, container.RunningDigest)
+	notification := &model.Notification{
+		Type:     model.NotificationTypeContainerUpdate,
+		Title:    fmt.Sprintf("Container %s runs another image", container.Name),
+		Message:  payload.Summary(),
+		Priority: model.NotificationPriorityHigh,
+		Data:     model.NotificationData(payload),
+	}
+	if err := s.notificationService.SendNotification(ctx, notification); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to send image drift notification")
+	}
+}
+
+func diffEnv(container *model.Container, desiredEnv, liveEnv []string, defaults map[string]string) []FieldDrift {
+	secrets := make(map[string]bool, len(container.SecretEnv))
+	for _, name := range container.SecretEnv {
+		secrets[name] = true
+	}
+	isSecret := func(name string) bool {
+		return secrets[name] || secretEnvName.MatchString(name)
+	}
+
+	desired := parseEnvList(desiredEnv)
+	actual := parseEnvList(liveEnv)
+	var drifts []FieldDrift
+	for _, name := range sortedKeys(desired, actual) {
+		want, wanted := desired[name]
+		have, present := actual[name]
+
+		var change string
+		switch {
+		case wanted && !present:
+			change = DriftRemoved
+		case !wanted && present:
+			if value, isDefault := defaults[name]; isDefault && value == have {
+				continue
+			}
+			change = DriftAdded
+		case hashValue(want) != hashValue(have):
+			change = DriftChanged
+		default:
+			continue
+		}
+
+		drift := FieldDrift{Field: "env", Key: name, Change: change, Severity: DriftSeverityFunctional}
+		if isSecret(name) {
+			drift.Secret = true
+		} else {
+			if wanted {
+				drift.Desired = want
+			}
+			if present {
+				drift.Actual = have
+			}
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+func diffLabels(desired, actual, defaults map[string]string) []FieldDrift {
+	var drifts []FieldDrift
+	for _, key := range sortedKeys(desired, actual) {
+		want, wanted := desired[key]
+		have, present := actual[key]
+
+		drift := FieldDrift{Field: "labels", Key: key, Severity: DriftSeverityCosmetic}
+		switch {
+		case wanted && !present:
+			drift.Change, drift.Desired = DriftRemoved, want
+		case !wanted && present:
+			// Labels set by the image or by this application are expected
+			if value, isDefault := defaults[key]; (isDefault && value == have) || strings.HasPrefix(key, model.ReservedLabelPrefix) {
+				continue
+			}
+			drift.Change, drift.Actual = DriftAdded, have
+		case want != have:
+			drift.Change, drift.Desired, drift.Actual = DriftChanged, want, have
+		default:
+			continue
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+func diffMounts(desired []docker.VolumeMount, actual []types.MountPoint, image *types.ImageInspect) []FieldDrift {
+	liveMounts := make(map[string]types.MountPoint, len(actual))
+	for _, mount := range actual {
+		liveMounts[mount.Destination] = mount
+	}
+
+	var drifts []FieldDrift
+	seen := make(map[string]bool, len(desired))
+	for _, want := range desired {
+		seen[want.Target] = true
+		describe := mountDescription(want.Type, want.Source, want.ReadOnly)
+
+		have, present := liveMounts[want.Target]
+		if !present {
+			drifts = append(drifts, FieldDrift{
+				Field: "mounts", Key: want.Target, Change: DriftRemoved, Severity: DriftSeverityFunctional, Desired: describe,
+			})
+			continue
+		}
+
+		source := have.Source
+		if have.Type == "volume" {
+			source = have.Name
+		}
+		actualDescription := mountDescription(string(have.Type), source, !have.RW)
+		if actualDescription != describe {
+			drifts = append(drifts, FieldDrift{
+				Field: "mounts", Key: want.Target, Change: DriftChanged, Severity: DriftSeverityFunctional,
+				Desired: describe, Actual: actualDescription,
+			})
+		}
+	}
+
+	for _, have := range actual {
+		if seen[have.Destination] {
+			continue
+		}
+		// Anonymous volumes for the image's VOLUME declarations are expected
+		if have.Type == "volume" && image.Config != nil {
+			if _, declared := image.Config.Volumes[have.Destination]; declared {
+				continue
+			}
+		}
+		source := have.Source
+		if have.Type == "volume" {
+			source = have.Name
+		}
+		drifts = append(drifts, FieldDrift{
+			Field: "mounts", Key: have.Destination, Change: DriftAdded, Severity: DriftSeverityFunctional,
+			Actual: mountDescription(string(have.Type), source, !have.RW),
+		})
+	}
+	return drifts
+}
+
+func diffPorts(desired []dto.PortMapping, live *types.ContainerJSON) []FieldDrift {
+	want := make(map[string]string)
+	for _, mapping := range desired {
+		protocol := mapping.Protocol
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		hostPort := ""
+		if mapping.HostPort > 0 {
+			hostPort = strconv.Itoa(mapping.HostPort)
+		}
+		key := fmt.Sprintf("%d/%s", mapping.ContainerPort, protocol)
+		want[key] = joinBinding(want[key], portBinding(mapping.HostIP, hostPort))
+	}
+
+	have := make(map[string]string)
+	for port, bindings := range live.HostConfig.PortBindings {
+		key := string(port)
+		for _, binding := range bindings {
+			have[key] = joinBinding(have[key], portBinding(binding.HostIP, binding.HostPort))
+		}
+	}
+
+	var drifts []FieldDrift
+	for _, key := range sortedKeys(want, have) {
+		desiredBinding, wanted := want[key]
+		actualBinding, present := have[key]
+
+		drift := FieldDrift{Field: "ports", Key: key, Severity: DriftSeverityFunctional}
+		switch {
+		case wanted && !present:
+			drift.Change, drift.Desired = DriftRemoved, desiredBinding
+		case !wanted && present:
+			drift.Change, drift.Actual = DriftAdded, actualBinding
+		case desiredBinding != actualBinding:
+			drift.Change, drift.Desired, drift.Actual = DriftChanged, desiredBinding, actualBinding
+		default:
+			continue
+		}
+		drifts = append(drifts, drift)
+	}
+	return drifts
+}
+
+func diffResources(limits *docker.ResourceConfig, live *types.ContainerJSON) []FieldDrift {
+	var desired docker.ResourceConfig
+	if limits != nil {
+		desired = *limits
+	}
+
+	actual := live.HostConfig.Resources
+	pidsLimit := int64(0)
+	if actual.PidsLimit != nil && *actual.PidsLimit > 0 {
+		pidsLimit = *actual.PidsLimit
+	}
+
+	checks := []struct {
+		key           string
+		desired, have interface{}
+	}{
+		{"memory", desired.Memory, actual.Memory},
+		{"memory_reservation", desired.MemoryReservation, actual.MemoryReservation},
+		{"cpu_shares", desired.CPUShares, actual.CPUShares},
+		{"cpu_quota", desired.CPUQuota, actual.CPUQuota},
+		{"cpu_period", desired.CPUPeriod, actual.CPUPeriod},
+		{"cpuset_cpus", desired.CPUSetCPUs, actual.CpusetCpus},
+		{"pids_limit", desired.PidsLimit, pidsLimit},
+		// docker update --cpus sets NanoCPUs, which the stored config never does
+		{"nano_cpus", int64(0), actual.NanoCPUs},
+	}
+	if desired.MemorySwap != 0 {
+		checks = append(checks, struct {
+			key           string
+			desired, have interface{}
+		}{"memory_swap", desired.MemorySwap, actual.MemorySwap})
+	}
+
+	var drifts []FieldDrift
+	for _, limit := range checks {
+		if limit.desired == limit.have {
+			continue
+		}
+		drifts = append(drifts, FieldDrift{
+			Field: "resources", Key: limit.key, Change: DriftChanged, Severity: DriftSeverityFunctional,
+			Desired: limit.desired, Actual: limit.have,
+		})
+	}
+	return drifts
+}
+
+func parseEnvList(env []string) map[string]string {
+	values := make(map[string]string, len(env))
+	for _, entry := range env {
+		name, value, _ := strings.Cut(entry, "=")
+		values[name] = value
+	}
+	return values
+}
+
+func imageEnv(image *types.ImageInspect) map[string]string {
+	if image.Config == nil {
+		return nil
+	}
+	return parseEnvList(image.Config.Env)
+}
+
+func imageLabels(image *types.ImageInspect) map[string]string {
+	if image.Config == nil {
+		return nil
+	}
+	return image.Config.Labels
+}
+
+func mountDescription(mountType, source string, readOnly bool) string {
+	if mountType == "" {
+		mountType = "volume"
+	}
+	mode := "rw"
+	if readOnly {
+		mode = "ro"
+	}
+	return mountType + ":" + source + ":" + mode
+}
+
+func portBinding(hostIP, hostPort string) string {
+	if hostIP == "0.0.0.0" || hostIP == "::" {
+		hostIP = ""
+	}
+	return hostIP + ":" + hostPort
+}
+
+// joinBinding adds a host binding to a sorted, comma separated list
+func joinBinding(list, binding string) string {
+	if list == "" {
+		return binding
+	}
+	bindings := append(strings.Split(list, ","), binding)
+	sort.Strings(bindings)
+	return strings.Join(bindings, ",")
+}
+
+// sortedKeys returns the union of the maps' keys in order
+func sortedKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	for key := range b {
+		if _, ok := a[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func hashValue(value string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(value))
+}
+
+func actorUserID(actor model.Actor) int64 {
+	if actor.UserID == nil {
+		return 0
+	}
+	return *actor.UserID
+}
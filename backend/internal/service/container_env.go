@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/security"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// Stored config sections edited entry by entry
+const (
+	configSectionEnv    = "env"
+	configSectionLabels = "labels"
+)
+
+// GetContainerEnv lists the stored environment variables of a container.
+// Secret values are masked unless reveal is set.
+func (s *ContainerService) GetContainerEnv(ctx context.Context, actor model.Actor, containerID int64, reveal bool) (*dto.ContainerConfigEntries, error) {
+	return s.getConfigEntries(ctx, actor, containerID, configSectionEnv, reveal)
+}
+
+// GetContainerLabels lists the stored labels of a container. Secret values
+// are masked unless reveal is set.
+func (s *ContainerService) GetContainerLabels(ctx context.Context, actor model.Actor, containerID int64, reveal bool) (*dto.ContainerConfigEntries, error) {
+	return s.getConfigEntries(ctx, actor, containerID, configSectionLabels, reveal)
+}
+
+// PatchContainerEnv adds, updates and removes stored environment variables.
+// Variables whose name or value matches a sensitive pattern, or flagged in
+// the request, are added to the container's secret variables. A Docker
+// container gets the changes when next recreated.
+func (s *ContainerService) PatchContainerEnv(ctx context.Context, actor model.Actor, containerID int64, req *dto.PatchConfigEntriesRequest, reveal bool) (*dto.ContainerConfigEntries, error) {
+	if req == nil {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: operations are required")
+	}
+	if err := req.ValidateEnv(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+	return s.patchConfigEntries(ctx, actor, containerID, configSectionEnv, req, reveal)
+}
+
+// PatchContainerLabels adds, updates and removes stored labels. A Docker
+// container gets the changes when next recreated.
+func (s *ContainerService) PatchContainerLabels(ctx context.Context, actor model.Actor, containerID int64, req *dto.PatchConfigEntriesRequest, reveal bool) (*dto.ContainerConfigEntries, error) {
+	if req == nil {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: operations are required")
+	}
+	if err := req.ValidateLabels(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+	return s.patchConfigEntries(ctx, actor, containerID, configSectionLabels, req, reveal)
+}
+
+func (s *ContainerService) getConfigEntries(ctx context.Context, actor model.Actor, containerID int64, section string, reveal bool) (*dto.ContainerConfigEntries, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	required := model.ContainerPermissionView
+	if reveal {
+		required = model.ContainerPermissionManage
+	}
+	if err := s.checkContainerPermission(ctx, container, actor, required); err != nil {
+		return nil, err
+	}
+
+	config, err := parseContainerConfig(container)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readConfigEntries(config, section)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ContainerConfigEntries{
+		ContainerID:     containerID,
+		Entries:         maskConfigEntries(container, section, entries, reveal),
+		PendingRecreate: container.Drifted,
+	}, nil
+}
+
+func (s *ContainerService) patchConfigEntries(ctx context.Context, actor model.Actor, containerID int64, section string, req *dto.PatchConfigEntriesRequest, reveal bool) (*dto.ContainerConfigEntries, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionManage); err != nil {
+		return nil, err
+	}
+
+	config, err := parseContainerConfig(container)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := readConfigEntries(config, section)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, changed, err := applyConfigOperations(section, entries, req.Operations)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	secretEnv := container.SecretEnv
+	if section == configSectionEnv {
+		secretEnv = flagSecretEnv(container.SecretEnv, entries, req.Operations)
+		changed = changed || strings.Join(secretEnv, "\n") != strings.Join(container.SecretEnv, "\n")
+	}
+
+	if changed {
+		config[section] = encodeConfigEntries(section, entries)
+		configJSON, err := json.Marshal(config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal config: %w", err)
+		}
+		container.ConfigJSON = string(configJSON)
+		container.SecretEnv = secretEnv
+
+		if err := s.containerRepo.Update(ctx, container); err != nil {
+			return nil, fmt.Errorf("failed to update container: %w", err)
+		}
+
+		// The live container no longer matches until it is recreated
+		if container.ContainerID != "" {
+			s.recordDrift(ctx, container, true)
+		}
+
+		keys := make([]string, len(req.Operations))
+		for i, op := range req.Operations {
+			keys[i] = op.Op + " " + op.Key
+		}
+		what := "environment variables"
+		if section == configSectionLabels {
+			what = "labels"
+		}
+		s.logContainerActivity(actor, containerID, "container_"+section+"_update",
+			fmt.Sprintf("Changed %s of container %s", what, container.Name),
+			map[string]interface{}{"operations": keys, "pending_recreate": container.ContainerID != ""})
+
+		s.cache.Delete(fmt.Sprintf("container:detail:%d", containerID))
+		s.invalidateContainerCache(actor)
+
+		logrus.WithFields(logrus.Fields{
+			"actor":        actor.String(),
+			"container_id": containerID,
+			"section":      section,
+			"operations":   len(req.Operations),
+		}).Info("Container configuration entries changed")
+	}
+
+	return &dto.ContainerConfigEntries{
+		ContainerID:     containerID,
+		Entries:         maskConfigEntries(container, section, entries, reveal),
+		PendingRecreate: container.Drifted,
+	}, nil
+}
+
+// parseContainerConfig parses the stored config, keeping sections verbatim
+func parseContainerConfig(container *model.Container) (map[string]json.RawMessage, error) {
+	config := make(map[string]json.RawMessage)
+	if container.ConfigJSON != "" {
+		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse container config: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// readConfigEntries returns the environment variables, in their stored
+// order, or the labels, by key
+func readConfigEntries(config map[string]json.RawMessage, section string) ([]dto.ConfigEntry, error) {
+	raw, ok := config[section]
+	if !ok {
+		return []dto.ConfigEntry{}, nil
+	}
+
+	if section == configSectionEnv {
+		var env []string
+		if err := json.Unmarshal(raw, &env); err != nil {
+			return nil, fmt.Errorf("invalid env in container config: %w", err)
+		}
+		entries := make([]dto.ConfigEntry, 0, len(env))
+		for _, item := range env {
+			key, value, _ := strings.Cut(item, "=")
+			entries = append(entries, dto.ConfigEntry{Key: key, Value: value})
+		}
+		return entries, nil
+	}
+
+	var labels map[string]string
+	if err := json.Unmarshal(raw, &labels); err != nil {
+		return nil, fmt.Errorf("invalid labels in container config: %w", err)
+	}
+	entries := make([]dto.ConfigEntry, 0, len(labels))
+	for key, value := range labels {
+		entries = append(entries, dto.ConfigEntry{Key: key, Value: value})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Key < entries[j].Key })
+	return entries, nil
+}
+
+// encodeConfigEntries renders entries as stored: env as NAME=value, labels
+// as a map
+func encodeConfigEntries(section string, entries []dto.ConfigEntry) json.RawMessage {
+	var raw []byte
+	if section == configSectionEnv {
+		env := make([]string, len(entries))
+		for i, entry := range entries {
+			env[i] = entry.Key + "=" + entry.Value
+		}
+		raw, _ = json.Marshal(env)
+	} else {
+		labels := make(map[string]string, len(entries))
+		for _, entry := range entries {
+			labels[entry.Key] = entry.Value
+		}
+		raw, _ = json.Marshal(labels)
+	}
+	return raw
+}
+
+// applyConfigOperations applies the operations in order. Added environment
+// variables are appended, added labels kept in key order.
+func applyConfigOperations(section string, entries []dto.ConfigEntry, operations []dto.ConfigEntryOperation) ([]dto.ConfigEntry, bool, error) {
+	result := append([]dto.ConfigEntry(nil), entries...)
+	index := func(key string) int {
+		for i, entry := range result {
+			if entry.Key == key {
+				return i
+			}
+		}
+		return -1
+	}
+
+	changed := false
+	for i, op := range operations {
+		at := index(op.Key)
+		switch {
+		case op.Op == dto.ConfigOpAdd && at >= 0:
+			return nil, false, fmt.Errorf("operation %d: %s already exists", i+1, op.Key)
+		case op.Op != dto.ConfigOpAdd && at < 0:
+			return nil, false, fmt.Errorf("operation %d: %s is not set", i+1, op.Key)
+		}
+
+		switch op.Op {
+		case dto.ConfigOpAdd:
+			result = append(result, dto.ConfigEntry{Key: op.Key, Value: op.Value})
+			changed = true
+		case dto.ConfigOpUpdate:
+			if result[at].Value != op.Value {
+				result[at].Value = op.Value
+				changed = true
+			}
+		case dto.ConfigOpRemove:
+			result = append(result[:at], result[at+1:]...)
+			changed = true
+		}
+	}
+
+	if section == configSectionLabels {
+		sort.Slice(result, func(i, j int) bool { return result[i].Key < result[j].Key })
+	}
+	return result, changed, nil
+}
+
+// flagSecretEnv returns the secret variable names after a patch: those
+// flagged in the operations or matching a sensitive pattern are added, and
+// removed variables are dropped
+func flagSecretEnv(secretEnv model.StringList, entries []dto.ConfigEntry, operations []dto.ConfigEntryOperation) model.StringList {
+	present := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		present[entry.Key] = entry.Value
+	}
+	flagged := make(map[string]bool, len(operations))
+	for _, op := range operations {
+		if op.Secret {
+			flagged[op.Key] = true
+		}
+	}
+
+	result := make(model.StringList, 0, len(secretEnv))
+	seen := make(map[string]bool, len(secretEnv))
+	for _, name := range secretEnv {
+		if _, ok := present[name]; !ok && removedByOperations(name, operations) {
+			continue
+		}
+		result = append(result, name)
+		seen[name] = true
+	}
+	for _, entry := range entries {
+		if seen[entry.Key] {
+			continue
+		}
+		if flagged[entry.Key] || security.IsSensitiveEnv(entry.Key+"="+entry.Value) {
+			result = append(result, entry.Key)
+			seen[entry.Key] = true
+		}
+	}
+	return result
+}
+
+func removedByOperations(name string, operations []dto.ConfigEntryOperation) bool {
+	for _, op := range operations {
+		if op.Key == name && op.Op == dto.ConfigOpRemove {
+			return true
+		}
+	}
+	return false
+}
+
+// isSecretEntry reports whether an environment variable or label holds a
+// secret: a listed secret variable, or one matching a sensitive pattern
+func isSecretEntry(container *model.Container, section, key, value string) bool {
+	if section == configSectionEnv {
+		for _, name := range container.SecretEnv {
+			if name == key {
+				return true
+			}
+		}
+	}
+	return secretEnvName.MatchString(key) || security.IsSensitiveEnv(key+"="+value)
+}
+
+// maskConfigEntries flags secret entries and, unless reveal is set, masks
+// their values
+func maskConfigEntries(container *model.Container, section string, entries []dto.ConfigEntry, reveal bool) []dto.ConfigEntry {
+	masked := make([]dto.ConfigEntry, len(entries))
+	for i, entry := range entries {
+		entry.Secret = isSecretEntry(container, section, entry.Key, entry.Value)
+		if entry.Secret && !reveal {
+			entry.Value = dto.MaskedValue
+		}
+		masked[i] = entry
+	}
+	return masked
+}
+
+// maskedContainer returns a copy of the container whose stored config has
+// its secret environment and label values masked, and without its registry
+// login
+func maskedContainer(container *model.Container) *model.Container {
+	masked := *container
+	masked.RegistryAuth = ""
+
+	config, err := parseContainerConfig(container)
+	if err != nil {
+		return &masked
+	}
+
+	for _, section := range []string{configSectionEnv, configSectionLabels} {
+		if _, ok := config[section]; !ok {
+			continue
+		}
+		entries, err := readConfigEntries(config, section)
+		if err != nil {
+			// Hide what cannot be checked
+			delete(config, section)
+			continue
+		}
+		config[section] = encodeConfigEntries(section, maskConfigEntries(container, section, entries, false))
+	}
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return &masked
+	}
+	masked.ConfigJSON = string(configJSON)
+	return &masked
+}
+
+// sealRegistryAuth stores the registry login of a container encrypted
+func (s *ContainerService) sealRegistryAuth(container *model.Container, auth string) error {
+	if s.secretService == nil {
+		return apperrors.New(apperrors.CodeInternal, "secret encryption is not configured")
+	}
+	return s.secretService.SealContainerRegistryAuth(container, auth)
+}
+
+// openRegistryAuth returns the registry login JSON of a container
+func (s *ContainerService) openRegistryAuth(container *model.Container) (string, error) {
+	if s.secretService == nil {
+		return container.RegistryAuth, nil
+	}
+	return s.secretService.OpenContainerRegistryAuth(container)
+}
+
+// pendingConfigChanges lists the stored environment variables and labels the
+// live container does not have yet. Secret values are left out, and label
+// values matching a sensitive pattern masked unless reveal is set.
+func pendingConfigChanges(ctx context.Context, dc *docker.DockerClient, container *model.Container, live *types.ContainerJSON, reveal bool) []dto.ConfigChange {
+	if live == nil || live.Config == nil {
+		return nil
+	}
+	desired, err := desiredContainerState(container)
+	if err != nil || (desired.Env == nil && desired.Labels == nil) {
+		return nil
+	}
+
+	// Image defaults explain env and labels the stored config omits
+	image, err := dc.InspectImage(ctx, live.Image)
+	if err != nil {
+		image = &types.ImageInspect{}
+	}
+
+	var drifts []FieldDrift
+	if desired.Env != nil {
+		drifts = append(drifts, diffEnv(container, desired.Env, live.Config.Env, imageEnv(image))...)
+	}
+	if desired.Labels != nil {
+		drifts = append(drifts, diffLabels(desired.Labels, live.Config.Labels, imageLabels(image))...)
+	}
+
+	changes := make([]dto.ConfigChange, 0, len(drifts))
+	for _, drift := range drifts {
+		change := dto.ConfigChange{
+			Field:   drift.Field,
+			Key:     drift.Key,
+			Change:  drift.Change,
+			Current: drift.Actual,
+			Desired: drift.Desired,
+			Secret:  drift.Secret,
+		}
+		if !change.Secret && !reveal {
+			for _, value := range []*interface{}{&change.Current, &change.Desired} {
+				if str, ok := (*value).(string); ok && isSecretEntry(container, drift.Field, drift.Key, str) {
+					*value = dto.MaskedValue
+					change.Secret = true
+				}
+			}
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/events"
+	"docker-auto/pkg/model"
+)
+
+// streamedDaemonActions are the daemon events published on the container
+// state stream
+var streamedDaemonActions = map[string]bool{
+	events.ContainerActionStart:        true,
+	events.ContainerActionStop:         true,
+	events.ContainerActionDie:          true,
+	events.ContainerActionHealthStatus: true,
+}
+
+// SubscribeContainerEvents subscribes to state changes of the containers the
+// actor may see. The subscription must be released with
+// UnsubscribeContainerEvents.
+func (s *ContainerService) SubscribeContainerEvents(actor model.Actor) (*events.Subscription, error) {
+	if s.publisher == nil {
+		return nil, fmt.Errorf("container events are not configured")
+	}
+
+	var userID *string
+	if !actor.IsSystem() {
+		if actor.UserID == nil {
+			return nil, apperrors.Newf(apperrors.CodePermissionDenied, "access denied: container events require a user")
+		}
+		id := strconv.FormatInt(*actor.UserID, 10)
+		userID = &id
+	}
+	return s.publisher.Subscribe(events.ContainerStateFilter(userID)), nil
+}
+
+// UnsubscribeContainerEvents releases a subscription
+func (s *ContainerService) UnsubscribeContainerEvents(subscription *events.Subscription) {
+	if s.publisher != nil && subscription != nil {
+		s.publisher.Unsubscribe(subscription.ID)
+	}
+}
+
+// publishDaemonEvent publishes a daemon event of a managed container
+func (s *ContainerService) publishDaemonEvent(container *model.Container, event *docker.ContainerEvent) {
+	s.publishContainerState(container, &events.ContainerState{
+		Action: event.Action,
+		Status: string(event.Status),
+		Health: event.Health,
+		Time:   event.Time,
+	})
+}
+
+// publishUpdateStarted publishes that an update of the container began
+func (s *ContainerService) publishUpdateStarted(container *model.Container, history *model.UpdateHistory) {
+	s.publishContainerState(container, &events.ContainerState{
+		Action:   events.ContainerActionUpdateStarted,
+		Status:   string(history.Status),
+		UpdateID: history.ID,
+		Time:     history.StartedAt,
+	})
+}
+
+// publishUpdateProgress publishes the step an update of the container reached
+func (s *ContainerService) publishUpdateProgress(container *model.Container, history *model.UpdateHistory, step string) {
+	s.publishContainerState(container, &events.ContainerState{
+		Action:   events.ContainerActionUpdateProgress,
+		Status:   string(history.Status),
+		UpdateID: history.ID,
+		Step:     step,
+		Time:     time.Now(),
+	})
+}
+
+// publishUpdateCompleted publishes the outcome of an update of the container
+func (s *ContainerService) publishUpdateCompleted(container *model.Container, history *model.UpdateHistory) {
+	s.publishContainerState(container, &events.ContainerState{
+		Action:   events.ContainerActionUpdateCompleted,
+		Status:   string(history.Status),
+		UpdateID: history.ID,
+		Error:    history.ErrorMessage,
+	})
+}
+
+func (s *ContainerService) publishContainerState(container *model.Container, state *events.ContainerState) {
+	if s.publisher == nil {
+		return
+	}
+	state.ContainerID = int64(container.ID)
+	state.Name = container.Name
+	s.publisher.PublishAsync(events.NewContainerStateEvent(state, container.CreatedBy))
+}
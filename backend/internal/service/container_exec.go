@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+)
+
+// defaultExecCommand is run by exec sessions that name no command
+var defaultExecCommand = []string{"/bin/sh"}
+
+// ExecSession is an interactive command running in a container with a TTY.
+// Reads return its output and writes go to its stdin.
+type ExecSession struct {
+	ID string
+
+	conn      types.HijackedResponse
+	dc        *docker.DockerClient
+	startedAt time.Time
+	closeOnce sync.Once
+	onClose   func(duration time.Duration)
+}
+
+func (e *ExecSession) Read(p []byte) (int, error) {
+	return e.conn.Reader.Read(p)
+}
+
+func (e *ExecSession) Write(p []byte) (int, error) {
+	return e.conn.Conn.Write(p)
+}
+
+// Resize resizes the session's TTY
+func (e *ExecSession) Resize(ctx context.Context, rows, cols uint) error {
+	return e.dc.ResizeExec(ctx, e.ID, rows, cols)
+}
+
+// ExitCode returns the exit code of the command, once it has exited
+func (e *ExecSession) ExitCode(ctx context.Context) (int, error) {
+	code, running, err := e.dc.ExecExitCode(ctx, e.ID)
+	if err != nil {
+		return 0, err
+	}
+	if running {
+		return 0, fmt.Errorf("exec %s is still running", e.ID)
+	}
+	return code, nil
+}
+
+// Close detaches from the session, which hangs up the command's TTY
+func (e *ExecSession) Close() error {
+	e.closeOnce.Do(func() {
+		e.conn.Close()
+		e.onClose(time.Since(e.startedAt))
+	})
+	return nil
+}
+
+// OpenExecSession starts an interactive command in a running container. The
+// route requires the container:exec permission; the session also requires
+// operate permission on the container and is refused when container exec is
+// disabled in the configuration. Opening and closing the session are recorded
+// in the activity log with the command.
+func (s *ContainerService) OpenExecSession(ctx context.Context, actor model.Actor, containerID int64, options *dto.ExecOptions) (*ExecSession, error) {
+	if s.config == nil || !s.config.Security.ContainerExecEnabled {
+		return nil, apperrors.New(apperrors.CodeExecDisabled, "container exec is disabled")
+	}
+	if options == nil {
+		options = &dto.ExecOptions{}
+	}
+
+	command := options.Command
+	if len(command) == 0 {
+		command = defaultExecCommand
+	}
+	for _, arg := range command {
+		if strings.ContainsRune(arg, 0) {
+			return nil, apperrors.New(apperrors.CodeInvalidRequest, "invalid request: command arguments cannot contain NUL")
+		}
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+
+	if container.ContainerID == "" {
+		return nil, ErrContainerNotCreated
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	execID, conn, err := dc.StartExecSession(ctx, container.ContainerID, command, types.ExecConfig{User: options.User})
+	if err != nil {
+		return nil, err
+	}
+
+	details := map[string]interface{}{
+		"exec_id": execID,
+		"command": strings.Join(command, " "),
+	}
+	if options.User != "" {
+		details["user"] = options.User
+	}
+	s.logContainerActivity(actor, int64(container.ID), "container_exec_started", "Exec session opened", details)
+
+	return &ExecSession{
+		ID:        execID,
+		conn:      conn,
+		dc:        dc,
+		startedAt: time.Now(),
+		onClose: func(duration time.Duration) {
+			details["duration_seconds"] = int(duration.Seconds())
+			s.logContainerActivity(actor, int64(container.ID), "container_exec_ended", "Exec session closed", details)
+		},
+	}, nil
+}
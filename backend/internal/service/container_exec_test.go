@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+)
+
+// execDaemon runs exec instances that print output and exit
+type execDaemon struct {
+	output string
+
+	mu       sync.Mutex
+	commands [][]string
+	requests int
+}
+
+func (d *execDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	d.requests++
+	d.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1.44")
+	switch {
+	case r.Method == http.MethodPost && strings.HasSuffix(path, "/exec"):
+		var body struct{ Cmd []string }
+		json.NewDecoder(r.Body).Decode(&body)
+		d.mu.Lock()
+		d.commands = append(d.commands, body.Cmd)
+		d.mu.Unlock()
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": "exec1"})
+	case r.Method == http.MethodPost && path == "/exec/exec1/start":
+		conn, buf, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		buf.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+		buf.WriteString(d.output)
+		buf.Flush()
+	case r.Method == http.MethodGet && path == "/exec/exec1/json":
+		json.NewEncoder(w).Encode(map[string]interface{}{"ID": "exec1", "Running": false, "ExitCode": 0})
+	default:
+		http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+	}
+}
+
+// execContainerRepo serves one container
+type execContainerRepo struct {
+	repository.ContainerRepository
+	container model.Container
+}
+
+func (r *execContainerRepo) GetByID(ctx context.Context, id int64) (*model.Container, error) {
+	container := r.container
+	return &container, nil
+}
+
+// activityRecorder keeps the activity logged
+type activityRecorder struct {
+	repository.ActivityLogRepository
+	mu   sync.Mutex
+	logs []*model.ActivityLog
+}
+
+func (r *activityRecorder) Create(ctx context.Context, log *model.ActivityLog) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.logs = append(r.logs, log)
+	return nil
+}
+
+func newExecTestService(t *testing.T, enabled bool, daemon *execDaemon) (*ContainerService, *activityRecorder) {
+	t.Helper()
+
+	cfg := &config.Config{}
+	cfg.Security.ContainerExecEnabled = enabled
+	creator := 1
+	activity := &activityRecorder{}
+	return &ContainerService{
+		containerRepo: &execContainerRepo{container: model.Container{ID: 5, Name: "web", ContainerID: "abc123", CreatedBy: &creator}},
+		activityRepo:  activity,
+		dockerClient:  newFakeDockerClient(t, daemon.ServeHTTP),
+		config:        cfg,
+	}, activity
+}
+
+func TestOpenExecSessionRefusedWhenDisabled(t *testing.T) {
+	daemon := &execDaemon{}
+	s, activity := newExecTestService(t, false, daemon)
+
+	_, err := s.OpenExecSession(context.Background(), model.UserActor(1, "alice"), 5, nil)
+	if !apperrors.HasCode(err, apperrors.CodeExecDisabled) {
+		t.Fatalf("OpenExecSession = %v, want exec disabled", err)
+	}
+	if daemon.requests != 0 || len(activity.logs) != 0 {
+		t.Errorf("%d daemon requests, %d activity logs, want none", daemon.requests, len(activity.logs))
+	}
+}
+
+func TestOpenExecSessionNeedsOperatePermission(t *testing.T) {
+	daemon := &execDaemon{}
+	s, activity := newExecTestService(t, true, daemon)
+
+	// Bob neither created the container nor was granted access to it
+	_, err := s.OpenExecSession(context.Background(), model.UserActor(2, "bob"), 5, nil)
+	if !apperrors.HasCode(err, apperrors.CodePermissionDenied) {
+		t.Fatalf("OpenExecSession = %v, want permission denied", err)
+	}
+	if daemon.requests != 0 || len(activity.logs) != 0 {
+		t.Errorf("%d daemon requests, %d activity logs, want none", daemon.requests, len(activity.logs))
+	}
+}
+
+func TestExecSessionIsLoggedWithCommand(t *testing.T) {
+	ctx := context.Background()
+	daemon := &execDaemon{output: "total 0\n"}
+	s, activity := newExecTestService(t, true, daemon)
+
+	session, err := s.OpenExecSession(ctx, model.UserActor(1, "alice"), 5, &dto.ExecOptions{Command: []string{"ls", "-l"}})
+	if err != nil {
+		t.Fatalf("OpenExecSession failed: %v", err)
+	}
+	output, err := io.ReadAll(session)
+	if err != nil || string(output) != daemon.output {
+		t.Errorf("session output = %q, %v, want %q", output, err, daemon.output)
+	}
+	if code, err := session.ExitCode(ctx); err != nil || code != 0 {
+		t.Errorf("ExitCode = %d, %v, want 0", code, err)
+	}
+	session.Close()
+	session.Close()
+
+	if len(daemon.commands) != 1 || strings.Join(daemon.commands[0], " ") != "ls -l" {
+		t.Errorf("daemon ran %v, want ls -l", daemon.commands)
+	}
+
+	// Opening and closing are each logged once, as alice, with the command
+	want := []string{"container_exec_started", "container_exec_ended"}
+	if len(activity.logs) != len(want) {
+		t.Fatalf("logged %d activities, want %d", len(activity.logs), len(want))
+	}
+	for i, log := range activity.logs {
+		var metadata map[string]interface{}
+		json.Unmarshal([]byte(log.Metadata), &metadata)
+		if log.Action != want[i] || log.UserID == nil || *log.UserID != 1 || metadata["command"] != "ls -l" || metadata["exec_id"] != "exec1" {
+			t.Errorf("activity %d = %s by %v with %s, want %s by alice with the command", i, log.Action, log.UserID, log.Metadata, want[i])
+		}
+	}
+}
@@ -0,0 +1,425 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// containerExportVersion is the version of the export document format
+const containerExportVersion = "1.0"
+
+// exportSections are the config keys rendered as their own export sections
+var exportSections = []string{"env", "labels", "ports", "volumes"}
+
+// ExportContainerConfig exports container configuration
+func (s *ContainerService) ExportContainerConfig(ctx context.Context, actor model.Actor, containerID int64) (*dto.ContainerExport, error) {
+	// Get container
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	// Check permissions
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	export := canonicalExport(container)
+
+	// Log activity
+	s.logContainerActivity(actor, containerID, "container_exported", "Container configuration exported", nil)
+
+	return export, nil
+}
+
+// DiffContainerConfig reports what importing an exported document would
+// change on the managed container of the same name, without changing it
+func (s *ContainerService) DiffContainerConfig(ctx context.Context, actor model.Actor, doc *dto.ContainerExport) (*dto.ConfigDiff, error) {
+	if doc == nil || strings.TrimSpace(doc.Name) == "" || strings.TrimSpace(doc.Image) == "" {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: name and image are required")
+	}
+
+	desired := canonicalExport(containerFromExport(doc))
+	diff := &dto.ConfigDiff{Name: desired.Name, Changes: []dto.ConfigChange{}}
+
+	current := &dto.ContainerExport{}
+	secretEnv := model.StringList(nil)
+
+	container, err := s.containerRepo.GetByName(ctx, desired.Name)
+	if err != nil {
+		if !apperrors.HasCode(err, apperrors.CodeContainerNotFound) {
+			return nil, fmt.Errorf("failed to get container: %w", err)
+		}
+		diff.Create = true
+	} else {
+		if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+			return nil, err
+		}
+		id := int64(container.ID)
+		diff.ContainerID = &id
+		current = canonicalExport(container)
+		secretEnv = container.SecretEnv
+	}
+
+	diff.Changes = diffExports(current, desired, secretEnv)
+	diff.Changed = diff.Create || len(diff.Changes) > 0
+	return diff, nil
+}
+
+// canonicalExport renders a container's configuration deterministically.
+// Environment and labels are maps, which encode with sorted keys; ports are
+// ordered by container port and protocol and volumes by target.
+func canonicalExport(container *model.Container) *dto.ContainerExport {
+	var config map[string]interface{}
+	if container.ConfigJSON != "" {
+		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to parse container config")
+		}
+	}
+
+	export := &dto.ContainerExport{
+		Version:      containerExportVersion,
+		Name:         container.Name,
+		Image:        container.Image,
+		Tag:          container.Tag,
+		UpdatePolicy: string(container.UpdatePolicy),
+		RegistryURL:  container.RegistryURL,
+		PinByDigest:  container.PinByDigest,
+		ImageDigest:  container.ImageDigest,
+	}
+	if export.Tag == "latest" {
+		export.Tag = ""
+	}
+	if container.UpdatePolicy == model.UpdatePolicyAuto {
+		export.UpdatePolicy = ""
+	}
+	if policy := container.GetVersionPolicy(); policy != model.VersionPolicyLatest {
+		export.VersionPolicy = string(policy)
+	}
+
+	if labels, ok := config["labels"].(map[string]interface{}); ok && len(labels) > 0 {
+		export.Labels = make(map[string]string, len(labels))
+		for k, v := range labels {
+			if str, ok := v.(string); ok {
+				export.Labels[k] = str
+			}
+		}
+	}
+
+	if env, ok := config["env"].([]interface{}); ok && len(env) > 0 {
+		export.Environment = make(map[string]string, len(env))
+		for _, e := range env {
+			if envStr, ok := e.(string); ok {
+				name, value, found := strings.Cut(envStr, "=")
+				if found {
+					export.Environment[name] = value
+				}
+			}
+		}
+	}
+
+	if ports, ok := config["ports"].([]interface{}); ok {
+		for _, p := range ports {
+			portMap, ok := p.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			mapping := dto.PortMapping{}
+			if containerPort, ok := portMap["container_port"].(float64); ok {
+				mapping.ContainerPort = int(containerPort)
+			}
+			if hostPort, ok := portMap["host_port"].(float64); ok {
+				mapping.HostPort = int(hostPort)
+			}
+			if protocol, ok := portMap["protocol"].(string); ok && protocol != "tcp" {
+				mapping.Protocol = protocol
+			}
+			if hostIP, ok := portMap["host_ip"].(string); ok && hostIP != "0.0.0.0" {
+				mapping.HostIP = hostIP
+			}
+			export.Ports = append(export.Ports, mapping)
+		}
+		sort.SliceStable(export.Ports, func(i, j int) bool {
+			a, b := export.Ports[i], export.Ports[j]
+			if a.ContainerPort != b.ContainerPort {
+				return a.ContainerPort < b.ContainerPort
+			}
+			if a.Protocol != b.Protocol {
+				return a.Protocol < b.Protocol
+			}
+			if a.HostIP != b.HostIP {
+				return a.HostIP < b.HostIP
+			}
+			return a.HostPort < b.HostPort
+		})
+	}
+
+	if volumes, ok := config["volumes"].([]interface{}); ok {
+		for _, v := range volumes {
+			volumeMap, ok := v.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			mapping := dto.VolumeMapping{}
+			if source, ok := volumeMap["source"].(string); ok {
+				mapping.Source = source
+			}
+			if target, ok := volumeMap["target"].(string); ok {
+				mapping.Target = target
+			}
+			if volType, ok := volumeMap["type"].(string); ok {
+				mapping.Type = volType
+			}
+			if readOnly, ok := volumeMap["read_only"].(bool); ok {
+				mapping.ReadOnly = readOnly
+			}
+			if consistency, ok := volumeMap["consistency"].(string); ok && consistency != "default" {
+				mapping.Consistency = consistency
+			}
+			export.Volumes = append(export.Volumes, mapping)
+		}
+		sort.SliceStable(export.Volumes, func(i, j int) bool {
+			return export.Volumes[i].Target < export.Volumes[j].Target
+		})
+	}
+
+	for _, key := range exportSections {
+		delete(config, key)
+	}
+	if rest, ok := pruneEmpty(config).(map[string]interface{}); ok && len(rest) > 0 {
+		export.Config = rest
+	}
+
+	return export
+}
+
+// containerFromExport builds the container an export document describes,
+// storing the sections back into the config as the API stores them
+func containerFromExport(doc *dto.ContainerExport) *model.Container {
+	container := &model.Container{
+		Name:          strings.TrimSpace(doc.Name),
+		Image:         doc.Image,
+		Tag:           doc.Tag,
+		UpdatePolicy:  model.UpdatePolicy(doc.UpdatePolicy),
+		VersionPolicy: model.VersionPolicy(doc.VersionPolicy),
+		RegistryURL:   doc.RegistryURL,
+		PinByDigest:   doc.PinByDigest,
+		ImageDigest:   doc.ImageDigest,
+	}
+	if container.Tag == "" {
+		container.Tag = "latest"
+	}
+	if container.UpdatePolicy == "" {
+		container.UpdatePolicy = model.UpdatePolicyAuto
+	}
+
+	config := make(map[string]interface{}, len(doc.Config)+len(exportSections))
+	for k, v := range doc.Config {
+		config[k] = v
+	}
+	if len(doc.Environment) > 0 {
+		env := make([]string, 0, len(doc.Environment))
+		for name, value := range doc.Environment {
+			env = append(env, name+"="+value)
+		}
+		sort.Strings(env)
+		config["env"] = env
+	}
+	if len(doc.Labels) > 0 {
+		config["labels"] = doc.Labels
+	}
+	if len(doc.Ports) > 0 {
+		config["ports"] = doc.Ports
+	}
+	if len(doc.Volumes) > 0 {
+		volumes := make([]docker.VolumeMount, len(doc.Volumes))
+		for i, v := range doc.Volumes {
+			volumes[i] = docker.VolumeMount{
+				Type:        v.Type,
+				Source:      v.Source,
+				Target:      v.Target,
+				ReadOnly:    v.ReadOnly,
+				Consistency: v.Consistency,
+			}
+		}
+		config["volumes"] = volumes
+	}
+
+	if configJSON, err := json.Marshal(config); err == nil {
+		container.ConfigJSON = string(configJSON)
+	}
+	return container
+}
+
+// pruneEmpty drops nil, empty, false and zero values from maps, recursively.
+// Array elements are kept, as their positions matter.
+func pruneEmpty(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		pruned := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			item = pruneEmpty(item)
+			if !isEmptyValue(item) {
+				pruned[key] = item
+			}
+		}
+		return pruned
+	case []interface{}:
+		for i, item := range v {
+			v[i] = pruneEmpty(item)
+		}
+		return v
+	default:
+		return v
+	}
+}
+
+func isEmptyValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return v == ""
+	case bool:
+		return !v
+	case float64:
+		return v == 0
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	return false
+}
+
+// diffExports lists the changes from the current export to the desired one,
+// keyed so that CI output is stable
+func diffExports(current, desired *dto.ContainerExport, secretEnv model.StringList) []dto.ConfigChange {
+	var changes []dto.ConfigChange
+
+	scalars := []struct {
+		field         string
+		current, want interface{}
+	}{
+		{"image", current.Image, desired.Image},
+		{"tag", current.Tag, desired.Tag},
+		{"update_policy", current.UpdatePolicy, desired.UpdatePolicy},
+		{"version_policy", current.VersionPolicy, desired.VersionPolicy},
+		{"registry_url", current.RegistryURL, desired.RegistryURL},
+		{"pin_by_digest", current.PinByDigest, desired.PinByDigest},
+		{"image_digest", current.ImageDigest, desired.ImageDigest},
+	}
+	for _, scalar := range scalars {
+		if scalar.current != scalar.want {
+			changes = append(changes, dto.ConfigChange{
+				Field: scalar.field, Change: DriftChanged, Current: scalar.current, Desired: scalar.want,
+			})
+		}
+	}
+
+	secrets := make(map[string]bool, len(secretEnv))
+	for _, name := range secretEnv {
+		secrets[name] = true
+	}
+	for _, change := range diffStringMaps("environment", current.Environment, desired.Environment) {
+		if secrets[change.Key] || secretEnvName.MatchString(change.Key) {
+			change.Current, change.Desired, change.Secret = nil, nil, true
+		}
+		changes = append(changes, change)
+	}
+	changes = append(changes, diffStringMaps("labels", current.Labels, desired.Labels)...)
+
+	currentPorts := make(map[string]string)
+	for _, p := range current.Ports {
+		key, binding := exportPortKey(p)
+		currentPorts[key] = joinBinding(currentPorts[key], binding)
+	}
+	desiredPorts := make(map[string]string)
+	for _, p := range desired.Ports {
+		key, binding := exportPortKey(p)
+		desiredPorts[key] = joinBinding(desiredPorts[key], binding)
+	}
+	changes = append(changes, diffStringMaps("ports", currentPorts, desiredPorts)...)
+
+	currentVolumes := make(map[string]string)
+	for _, v := range current.Volumes {
+		currentVolumes[v.Target] = mountDescription(v.Type, v.Source, v.ReadOnly)
+	}
+	desiredVolumes := make(map[string]string)
+	for _, v := range desired.Volumes {
+		desiredVolumes[v.Target] = mountDescription(v.Type, v.Source, v.ReadOnly)
+	}
+	changes = append(changes, diffStringMaps("volumes", currentVolumes, desiredVolumes)...)
+
+	currentConfig := make(map[string]string, len(current.Config))
+	for key, value := range current.Config {
+		encoded, _ := json.Marshal(value)
+		currentConfig[key] = string(encoded)
+	}
+	desiredConfig := make(map[string]string, len(desired.Config))
+	for key, value := range desired.Config {
+		encoded, _ := json.Marshal(value)
+		desiredConfig[key] = string(encoded)
+	}
+	for _, change := range diffStringMaps("config", currentConfig, desiredConfig) {
+		if change.Current != nil {
+			change.Current = current.Config[change.Key]
+		}
+		if change.Desired != nil {
+			change.Desired = desired.Config[change.Key]
+		}
+		changes = append(changes, change)
+	}
+
+	if changes == nil {
+		changes = []dto.ConfigChange{}
+	}
+	return changes
+}
+
+// diffStringMaps compares two maps key by key, in key order
+func diffStringMaps(field string, current, desired map[string]string) []dto.ConfigChange {
+	var changes []dto.ConfigChange
+	for _, key := range sortedKeys(current, desired) {
+		have, present := current[key]
+		want, wanted := desired[key]
+
+		change := dto.ConfigChange{Field: field, Key: key}
+		switch {
+		case wanted && !present:
+			change.Change, change.Desired = DriftAdded, want
+		case !wanted && present:
+			change.Change, change.Current = DriftRemoved, have
+		case want != have:
+			change.Change, change.Current, change.Desired = DriftChanged, have, want
+		default:
+			continue
+		}
+		changes = append(changes, change)
+	}
+	return changes
+}
+
+// exportPortKey keys a port mapping by container port and protocol
+func exportPortKey(mapping dto.PortMapping) (string, string) {
+	protocol := mapping.Protocol
+	if protocol == "" {
+		protocol = "tcp"
+	}
+	hostPort := ""
+	if mapping.HostPort > 0 {
+		hostPort = strconv.Itoa(mapping.HostPort)
+	}
+	return fmt.Sprintf("%d/%s", mapping.ContainerPort, protocol), portBinding(mapping.HostIP, hostPort)
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// exportTestContainers are stored as the API stores them: env as a list,
+// sections in no particular order and defaults spelled out
+var exportTestContainers = []*model.Container{
+	{
+		Name:         "web",
+		Image:        "ghcr.io/acme/web",
+		Tag:          "1.4.2",
+		UpdatePolicy: model.UpdatePolicyManual,
+		RegistryURL:  "ghcr.io",
+		PinByDigest:  true,
+		ImageDigest:  "sha256:4b1c",
+		ConfigJSON: `{
+			"env": ["PORT=8080", "API_KEY=s3cret", "LOG_LEVEL=info", "EMPTY="],
+			"ports": [
+				{"container_port": 9090, "host_port": 9090, "protocol": "tcp", "host_ip": "127.0.0.1"},
+				{"container_port": 8080, "host_port": 80, "protocol": "tcp", "host_ip": "0.0.0.0"},
+				{"container_port": 8080, "host_port": 8080, "protocol": "udp"}
+			],
+			"volumes": [
+				{"source": "webdata", "target": "/srv/data", "type": "volume", "consistency": "default"},
+				{"source": "/etc/web", "target": "/etc/web", "type": "bind", "read_only": true}
+			],
+			"labels": {"tier": "frontend", "app": "web", "com.example.team": "platform"},
+			"restart_policy": "unless-stopped",
+			"network_mode": "",
+			"privileged": false,
+			"command": ["serve", "--port", "8080"],
+			"healthcheck": {"test": ["CMD", "curl", "-f", "http://localhost:8080/health"], "retries": 3, "start_period": 0},
+			"extra_hosts": []
+		}`,
+	},
+	{
+		Name:         "db",
+		Image:        "postgres",
+		Tag:          "16",
+		UpdatePolicy: model.UpdatePolicyDisabled,
+		ConfigJSON:   `{"volumes":[{"source":"pgdata","target":"/var/lib/postgresql/data"}],"env":["POSTGRES_DB=app"]}`,
+	},
+	{
+		Name:         "cache",
+		Image:        "redis",
+		Tag:          "latest",
+		UpdatePolicy: model.UpdatePolicyAuto,
+	},
+}
+
+func newExportTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	// GetByID and GetByName preload the creator and the update history
+	return newTestDB(t, &model.Container{}, &model.ScheduledTask{}, &model.RegistryCredentials{}, &model.NotificationChannel{},
+		&model.User{}, &model.UpdateHistory{})
+}
+
+// exportAll exports every container in db as the export endpoint renders
+// it, by name
+func exportAll(t *testing.T, db *gorm.DB) map[string][]byte {
+	t.Helper()
+	ctx := context.Background()
+
+	repo := repository.NewContainerRepository(db)
+	s := &ContainerService{containerRepo: repo}
+
+	exports := make(map[string][]byte, len(exportTestContainers))
+	for _, seeded := range exportTestContainers {
+		container, err := repo.GetByName(ctx, seeded.Name)
+		if err != nil {
+			t.Fatalf("GetByName(%s) failed: %v", seeded.Name, err)
+		}
+		doc, err := s.ExportContainerConfig(ctx, model.SystemActor("gitops"), int64(container.ID))
+		if err != nil {
+			t.Fatalf("ExportContainerConfig(%s) failed: %v", seeded.Name, err)
+		}
+		data, err := json.MarshalIndent(doc, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		exports[seeded.Name] = append(data, '\n')
+	}
+	return exports
+}
+
+func TestContainerExportRoundTripsThroughCleanDatabase(t *testing.T) {
+	source := newExportTestDB(t)
+	for _, container := range exportTestContainers {
+		stored := *container
+		if err := source.Create(&stored).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	first := exportAll(t, source)
+	for _, container := range exportTestContainers {
+		compareGolden(t, "container_export_"+container.Name+".golden.json", first[container.Name])
+	}
+
+	// Import every document into an empty database and export again.
+	// containerRepository.Create takes a Postgres lock for the change feed,
+	// so the imported rows are stored directly.
+	clean := newExportTestDB(t)
+	for _, container := range exportTestContainers {
+		var doc dto.ContainerExport
+		if err := json.Unmarshal(first[container.Name], &doc); err != nil {
+			t.Fatalf("failed to decode the %s export: %v", container.Name, err)
+		}
+		if err := clean.Create(containerFromExport(&doc)).Error; err != nil {
+			t.Fatalf("failed to import %s: %v", container.Name, err)
+		}
+	}
+	second := exportAll(t, clean)
+
+	for _, container := range exportTestContainers {
+		if string(second[container.Name]) != string(first[container.Name]) {
+			t.Errorf("%s export changed after a round trip:\n%s\nwant\n%s", container.Name, second[container.Name], first[container.Name])
+		}
+	}
+}
+
+func TestContainerExportIgnoresStoredOrder(t *testing.T) {
+	db := newExportTestDB(t)
+
+	// The same configuration as web, stored in another order
+	reordered := *exportTestContainers[0]
+	reordered.ConfigJSON = `{
+		"extra_hosts": [],
+		"privileged": false,
+		"healthcheck": {"start_period": 0, "retries": 3, "test": ["CMD", "curl", "-f", "http://localhost:8080/health"]},
+		"command": ["serve", "--port", "8080"],
+		"labels": {"com.example.team": "platform", "app": "web", "tier": "frontend"},
+		"volumes": [
+			{"source": "/etc/web", "target": "/etc/web", "type": "bind", "read_only": true},
+			{"source": "webdata", "target": "/srv/data", "type": "volume"}
+		],
+		"ports": [
+			{"container_port": 8080, "host_port": 8080, "protocol": "udp"},
+			{"container_port": 8080, "host_port": 80},
+			{"container_port": 9090, "host_port": 9090, "host_ip": "127.0.0.1"}
+		],
+		"env": ["LOG_LEVEL=info", "EMPTY=", "API_KEY=s3cret", "PORT=8080"],
+		"restart_policy": "unless-stopped"
+	}`
+	for _, container := range append([]*model.Container{&reordered}, exportTestContainers[1:]...) {
+		stored := *container
+		if err := db.Create(&stored).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	compareGolden(t, "container_export_web.golden.json", exportAll(t, db)["web"])
+}
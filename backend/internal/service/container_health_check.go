@@ -0,0 +1,134 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/internal/dto"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+)
+
+// ListHealthChecks returns the health checks configured for a container, with
+// the outcome of their latest run
+func (s *ContainerService) ListHealthChecks(ctx context.Context, actor model.Actor, containerID int64) ([]*model.ContainerHealthCheck, error) {
+	container, err := s.healthCheckContainer(ctx, actor, containerID, model.ContainerPermissionView)
+	if err != nil {
+		return nil, err
+	}
+	return s.healthCheckRepo.ListByContainer(ctx, container.ID)
+}
+
+// CreateHealthCheck adds a health check to a container
+func (s *ContainerService) CreateHealthCheck(ctx context.Context, actor model.Actor, containerID int64, req *dto.HealthCheckRequest) (*model.ContainerHealthCheck, error) {
+	container, err := s.healthCheckContainer(ctx, actor, containerID, model.ContainerPermissionManage)
+	if err != nil {
+		return nil, err
+	}
+
+	check := &model.ContainerHealthCheck{ContainerID: container.ID}
+	req.Apply(check)
+	if err := s.validateHealthCheck(ctx, actor, container, check); err != nil {
+		return nil, err
+	}
+
+	if err := s.healthCheckRepo.Create(ctx, check); err != nil {
+		return nil, err
+	}
+
+	s.logContainerActivity(actor, containerID, "health_check_added", "Health check added", map[string]interface{}{
+		"health_check_id": check.ID,
+		"type":            check.Type,
+	})
+	return check, nil
+}
+
+// UpdateHealthCheck replaces the configuration of a container's health check.
+// The outcome of its latest run is kept.
+func (s *ContainerService) UpdateHealthCheck(ctx context.Context, actor model.Actor, containerID int64, checkID int, req *dto.HealthCheckRequest) (*model.ContainerHealthCheck, error) {
+	container, err := s.healthCheckContainer(ctx, actor, containerID, model.ContainerPermissionManage)
+	if err != nil {
+		return nil, err
+	}
+	check, err := s.containerHealthCheck(ctx, container, checkID)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Apply(check)
+	if err := s.validateHealthCheck(ctx, actor, container, check); err != nil {
+		return nil, err
+	}
+
+	if err := s.healthCheckRepo.Update(ctx, check); err != nil {
+		return nil, err
+	}
+
+	s.logContainerActivity(actor, containerID, "health_check_updated", "Health check updated", map[string]interface{}{
+		"health_check_id": check.ID,
+		"type":            check.Type,
+	})
+	return check, nil
+}
+
+// DeleteHealthCheck removes a health check from a container
+func (s *ContainerService) DeleteHealthCheck(ctx context.Context, actor model.Actor, containerID int64, checkID int) error {
+	container, err := s.healthCheckContainer(ctx, actor, containerID, model.ContainerPermissionManage)
+	if err != nil {
+		return err
+	}
+	check, err := s.containerHealthCheck(ctx, container, checkID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.healthCheckRepo.Delete(ctx, check.ID); err != nil {
+		return err
+	}
+
+	s.logContainerActivity(actor, containerID, "health_check_deleted", "Health check deleted", map[string]interface{}{
+		"health_check_id": check.ID,
+	})
+	return nil
+}
+
+// healthCheckContainer loads a container on which the actor holds the
+// required permission for its health checks
+func (s *ContainerService) healthCheckContainer(ctx context.Context, actor model.Actor, containerID int64, required model.ContainerPermissionLevel) (*model.Container, error) {
+	if s.healthCheckRepo == nil {
+		return nil, fmt.Errorf("health checks are not available")
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	if err := s.checkContainerPermission(ctx, container, actor, required); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// containerHealthCheck loads a health check of the container
+func (s *ContainerService) containerHealthCheck(ctx context.Context, container *model.Container, checkID int) (*model.ContainerHealthCheck, error) {
+	check, err := s.healthCheckRepo.GetByID(ctx, checkID)
+	if err != nil {
+		return nil, err
+	}
+	if check.ContainerID != container.ID {
+		return nil, fmt.Errorf("health check with ID %d not found", checkID)
+	}
+	return check, nil
+}
+
+// validateHealthCheck validates a check's configuration. Command checks run
+// inside the container with the owner's authority, like exec health actions.
+func (s *ContainerService) validateHealthCheck(ctx context.Context, actor model.Actor, container *model.Container, check *model.ContainerHealthCheck) error {
+	if err := check.Validate(); err != nil {
+		return apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+	if check.Type == model.HealthCheckCommand {
+		return s.checkExecPermission(ctx, container, actor)
+	}
+	return nil
+}
@@ -0,0 +1,395 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// healthGateMinUptime is how long a new container without a health check
+	// or probe must keep running to pass the gate
+	healthGateMinUptime = 10 * time.Second
+	// healthGatePollInterval is how often the gate checks the new container
+	healthGatePollInterval = 2 * time.Second
+	// healthGateLogLines is how many log lines of a new container that failed
+	// the gate are kept on the update record
+	healthGateLogLines = 50
+)
+
+// healthGatedUpdate updates the container to its deploy image without
+// touching the running container until the new image has proven healthy.
+// The new container is staged next to the old one as <name>-update-<id>,
+// without host ports, and must pass the gate: the HTTP probe of req, else its
+// Docker health check, else staying up through the warmup. A staged
+// container that fails is removed with its health and container logs kept on
+// the update record, and the old container is left as it was.
+//
+// Once the gate passes, a staged container equivalent to the old one takes
+// its name. Containers publishing host ports, or with a static address or
+// network aliases, are recreated from the old one instead: the old container
+// is stopped, the new one created with its full configuration and gated
+// again, and the old one brought back if that fails.
+func (s *ContainerService) healthGatedUpdate(ctx context.Context, actor model.Actor, container *model.Container, history *model.UpdateHistory, req *dto.UpdateImageRequest) error {
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return err
+	}
+
+	old, err := dc.GetContainer(ctx, container.ContainerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if old.HostConfig != nil && (old.HostConfig.NetworkMode.IsHost() || old.HostConfig.NetworkMode.IsContainer()) {
+		return fmt.Errorf("health_gated updates need a container with its own network, not %s", old.HostConfig.NetworkMode)
+	}
+	wasRunning := old.State != nil && old.State.Running
+
+	target := container.GetDeployImageRef()
+	history.NewImage = target
+	err = docker.Retry(func() error {
+		return dc.PullImageWithProgress(ctx, docker.ContainerPullKey(int64(container.ID)), target, types.ImagePullOptions{}, nil,
+			s.PullProgressReporter(container, history))
+	}, docker.DefaultRetryConfig())
+	if err != nil {
+		return apperrors.Newf(apperrors.CodeImagePullFailed, "failed to pull image: %w", err)
+	}
+
+	stagingName := fmt.Sprintf("%s-update-%d", container.Name, history.ID)
+	if err := removeLeftoverContainer(ctx, dc, stagingName); err != nil {
+		return err
+	}
+
+	stagedID, err := dc.StageContainer(ctx, old.ID, target, stagingName)
+	if err != nil {
+		return fmt.Errorf("failed to create new container: %w", err)
+	}
+	if err := s.gateNewContainer(ctx, dc, container, history, stagedID, req); err != nil {
+		return err
+	}
+
+	var newID string
+	if docker.CanSwapStaged(old) {
+		newID, err = s.swapStagedContainer(ctx, dc, container, history, old.ID, stagedID, wasRunning)
+	} else {
+		removeContainerQuietly(ctx, dc, stagedID)
+		newID, err = s.replaceGatedContainer(ctx, dc, container, history, old.ID, stagingName, wasRunning, req)
+	}
+	if err != nil {
+		return err
+	}
+
+	if !wasRunning {
+		if err := dc.StopContainer(ctx, newID, nil); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to stop updated container")
+		}
+	}
+
+	if err := s.containerRepo.UpdateContainerID(ctx, int64(container.ID), newID); err != nil {
+		return fmt.Errorf("failed to record new container ID: %w", err)
+	}
+	container.SetDeployedContainer(newID)
+
+	// The image already proved healthy; hooks only warn from here
+	if wasRunning && container.HasPostStart() {
+		if _, err := s.RunPostStart(ctx, actor, container, newID, model.PostStartTriggerUpdate, req.HealthTimeout()); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Post-start sequence of updated container failed")
+		}
+	}
+
+	return nil
+}
+
+// gateNewContainer starts a new container and waits for it to pass the
+// health gate. A container that fails is removed and its logs are kept on
+// the update record.
+func (s *ContainerService) gateNewContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container, history *model.UpdateHistory, dockerID string, req *dto.UpdateImageRequest) error {
+	err := dc.StartContainer(ctx, dockerID)
+	if err == nil {
+		err = waitHealthGate(ctx, dc, dockerID, req, container.WarmupSeconds)
+	}
+	if err == nil {
+		return nil
+	}
+
+	history.Logs = s.healthGateLogs(ctx, dc, container, dockerID)
+	removeContainerQuietly(ctx, dc, dockerID)
+	return fmt.Errorf("new container failed its health gate: %w", err)
+}
+
+// swapStagedContainer puts a staged container that passed the gate in the
+// old one's place by renaming both. The old container is brought back if the
+// swap fails.
+func (s *ContainerService) swapStagedContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container, history *model.UpdateHistory, oldID, stagedID string, wasRunning bool) (string, error) {
+	retiredName := fmt.Sprintf("%s-old-%d", container.Name, history.ID)
+
+	err := stopDockerContainer(ctx, dc, container, oldID)
+	if err == nil {
+		err = dc.RenameContainer(ctx, oldID, retiredName)
+	}
+	if err == nil {
+		err = dc.RenameContainer(ctx, stagedID, container.Name)
+	}
+	if err != nil {
+		removeContainerQuietly(ctx, dc, stagedID)
+		return "", restoreOldContainer(ctx, dc, oldID, container.Name, wasRunning, fmt.Errorf("failed to swap in new container: %w", err))
+	}
+
+	s.retireOldContainer(ctx, dc, container, oldID)
+	return stagedID, nil
+}
+
+// replaceGatedContainer replaces the old container with one created from its
+// full configuration, for containers a staged copy cannot stand in for. The
+// new container is gated again and the old one brought back if it fails.
+func (s *ContainerService) replaceGatedContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container, history *model.UpdateHistory, oldID, stagingName string, wasRunning bool, req *dto.UpdateImageRequest) (string, error) {
+	if err := stopDockerContainer(ctx, dc, container, oldID); err != nil {
+		return "", restoreOldContainer(ctx, dc, oldID, container.Name, wasRunning, fmt.Errorf("failed to stop old container: %w", err))
+	}
+
+	newID, err := dc.CloneContainer(ctx, oldID, history.NewImage, stagingName)
+	if err != nil {
+		return "", restoreOldContainer(ctx, dc, oldID, container.Name, wasRunning, fmt.Errorf("failed to create new container: %w", err))
+	}
+	if err := s.gateNewContainer(ctx, dc, container, history, newID, req); err != nil {
+		return "", restoreOldContainer(ctx, dc, oldID, container.Name, wasRunning, err)
+	}
+
+	s.retireOldContainer(ctx, dc, container, oldID)
+	if err := dc.RenameContainer(ctx, newID, container.Name); err != nil {
+		// The new container runs and is recorded; only its name is off
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to rename updated container")
+	}
+	return newID, nil
+}
+
+// retireOldContainer removes the replaced container. The update already
+// succeeded, so a failure only warns.
+func (s *ContainerService) retireOldContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container, oldID string) {
+	if err := dc.RemoveContainer(ctx, oldID, types.ContainerRemoveOptions{}); err != nil && !docker.IsContainerNotFoundError(err) {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"container_id": container.ID,
+			"docker_id":    oldID,
+		}).Warn("Failed to remove replaced container")
+	}
+}
+
+// restoreOldContainer gives the old container its name back and restarts it
+// if it was running, and returns cause with any restore failure appended
+func restoreOldContainer(ctx context.Context, dc *docker.DockerClient, oldID, name string, wasRunning bool, cause error) error {
+	current, err := dc.GetContainer(ctx, oldID)
+	if err == nil && current.Name != "/"+name {
+		err = dc.RenameContainer(ctx, oldID, name)
+	}
+	if err == nil && wasRunning && (current.State == nil || !current.State.Running) {
+		err = dc.StartContainer(ctx, oldID)
+	}
+	if err != nil {
+		return fmt.Errorf("%w; restoring the old container failed: %v", cause, err)
+	}
+	return cause
+}
+
+// removeLeftoverContainer removes a container left under name by an earlier
+// attempt
+func removeLeftoverContainer(ctx context.Context, dc *docker.DockerClient, name string) error {
+	leftover, err := dc.FindContainerIDByName(ctx, name)
+	if err != nil {
+		return nil
+	}
+	if err := dc.RemoveContainer(ctx, leftover, types.ContainerRemoveOptions{Force: true}); err != nil && !docker.IsContainerNotFoundError(err) {
+		return fmt.Errorf("failed to remove leftover container %s: %w", name, err)
+	}
+	return nil
+}
+
+func removeContainerQuietly(ctx context.Context, dc *docker.DockerClient, dockerID string) {
+	if err := dc.RemoveContainer(ctx, dockerID, types.ContainerRemoveOptions{Force: true}); err != nil && !docker.IsContainerNotFoundError(err) {
+		logrus.WithError(err).WithField("docker_id", dockerID).Warn("Failed to remove new container")
+	}
+}
+
+// waitHealthGate waits until a started container passes the HTTP probe of
+// req, or without one its Docker health check reports healthy, or without
+// either it stays up for the warmup and at least healthGateMinUptime. A
+// container that exits or turns unhealthy fails at once.
+func waitHealthGate(ctx context.Context, dc *docker.DockerClient, dockerID string, req *dto.UpdateImageRequest, warmupSeconds int) error {
+	ctx, cancel := context.WithTimeout(ctx, req.HealthTimeout())
+	defer cancel()
+
+	minUptime := time.Duration(warmupSeconds) * time.Second
+	if minUptime < healthGateMinUptime {
+		minUptime = healthGateMinUptime
+	}
+	client := &http.Client{Timeout: 5 * time.Second}
+	started := time.Now()
+
+	ticker := time.NewTicker(healthGatePollInterval)
+	defer ticker.Stop()
+
+	var lastErr error
+	for {
+		inspected, err := dc.GetContainer(ctx, dockerID)
+		if err != nil && ctx.Err() == nil {
+			return fmt.Errorf("failed to inspect container: %w", err)
+		}
+		if err == nil {
+			state := inspected.State
+			if state == nil || !state.Running {
+				exitCode := 0
+				if state != nil {
+					exitCode = state.ExitCode
+				}
+				return fmt.Errorf("container exited with code %d", exitCode)
+			}
+
+			switch {
+			case req.HealthProbe != nil:
+				if lastErr = probeContainer(ctx, client, inspected, req.HealthProbe); lastErr == nil {
+					return nil
+				}
+			case state.Health != nil:
+				switch state.Health.Status {
+				case types.Healthy:
+					return nil
+				case types.Unhealthy:
+					return fmt.Errorf("container is unhealthy")
+				}
+			default:
+				if time.Since(started) >= minUptime {
+					return nil
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			if lastErr != nil {
+				return fmt.Errorf("no healthy response within %s: %w", req.HealthTimeout(), lastErr)
+			}
+			return fmt.Errorf("container did not become healthy within %s", req.HealthTimeout())
+		case <-ticker.C:
+		}
+	}
+}
+
+// probeContainer sends the HTTP probe to the container's address on its
+// first network
+func probeContainer(ctx context.Context, client *http.Client, inspected *types.ContainerJSON, probe *dto.HealthGateProbe) error {
+	address := ""
+	if inspected.NetworkSettings != nil {
+		for _, endpoint := range inspected.NetworkSettings.Networks {
+			if endpoint != nil && endpoint.IPAddress != "" {
+				address = endpoint.IPAddress
+				break
+			}
+		}
+	}
+	if address == "" {
+		return fmt.Errorf("container has no network address")
+	}
+
+	path := probe.Path
+	if path == "" {
+		path = "/"
+	}
+	url := "http://" + net.JoinHostPort(address, strconv.Itoa(probe.Port)) + path
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	response.Body.Close()
+
+	if probe.ExpectedStatus != 0 {
+		if response.StatusCode != probe.ExpectedStatus {
+			return fmt.Errorf("%s returned %d, want %d", url, response.StatusCode, probe.ExpectedStatus)
+		}
+		return nil
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("%s returned %d", url, response.StatusCode)
+	}
+	return nil
+}
+
+// healthGateLogs collects the Docker health check results and the last log
+// lines of a container that failed the gate, redacted like its logs are
+func (s *ContainerService) healthGateLogs(ctx context.Context, dc *docker.DockerClient, container *model.Container, dockerID string) string {
+	redactor, _ := s.containerLogRedactor(ctx, container, model.SystemActor(model.ActorComponentUpdater), false)
+
+	var b strings.Builder
+	if inspected, err := dc.GetContainer(ctx, dockerID); err == nil && inspected.State != nil && inspected.State.Health != nil {
+		b.WriteString("Health check log:\n")
+		for _, result := range inspected.State.Health.Log {
+			fmt.Fprintf(&b, "%s exit %d: %s\n", result.End.UTC().Format(time.RFC3339), result.ExitCode,
+				redactor.Redact(strings.TrimSpace(result.Output)))
+		}
+	}
+
+	lines, err := dc.TailLogs(ctx, dockerID, healthGateLogLines)
+	if err != nil {
+		fmt.Fprintf(&b, "Container log unavailable: %v\n", err)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "Container log (last %d lines):\n", healthGateLogLines)
+	for _, line := range lines {
+		b.WriteString(redactor.Redact(line))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// finishHealthGatedUpdate runs a health_gated update and records its outcome
+func (s *ContainerService) finishHealthGatedUpdate(ctx context.Context, actor model.Actor, container *model.Container, history *model.UpdateHistory, req *dto.UpdateImageRequest) error {
+	updateErr := s.healthGatedUpdate(ctx, actor, container, history, req)
+
+	completedAt := time.Now()
+	history.CompletedAt = &completedAt
+	history.DurationSeconds = int(completedAt.Sub(history.StartedAt).Seconds())
+	if updateErr != nil {
+		history.Status = model.UpdateStatusFailed
+		history.ErrorMessage = updateErr.Error()
+	} else {
+		history.Status = model.UpdateStatusCompleted
+	}
+
+	if err := s.updateHistoryRepo.Update(ctx, history); err != nil {
+		logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to update history record")
+	}
+	metrics.RecordContainerUpdate(string(history.Status))
+	s.publishUpdateCompleted(container, history)
+	s.recordAppliedUpdate(ctx, container, history)
+	s.cache.Delete(fmt.Sprintf("container:status:%d", container.ID))
+
+	details := map[string]interface{}{
+		"old_image": history.OldImage,
+		"new_image": history.NewImage,
+		"strategy":  req.Strategy,
+		"update_id": history.ID,
+	}
+	if updateErr != nil {
+		details["error"] = updateErr.Error()
+		s.logContainerActivity(actor, int64(container.ID), "image_update_failed", "Health-gated image update failed", details)
+		return fmt.Errorf("health-gated update failed: %w", updateErr)
+	}
+	s.logContainerActivity(actor, int64(container.ID), "image_updated", "Container image updated", details)
+	return nil
+}
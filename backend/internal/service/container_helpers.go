@@ -4,12 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/internal/dto"
 	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
 
 	"github.com/docker/docker/api/types"
 	"github.com/sirupsen/logrus"
@@ -18,219 +22,229 @@ import (
 // Batch operations
 
 // BulkStartContainers starts multiple containers
-func (s *ContainerService) BulkStartContainers(ctx context.Context, userID int64, containerIDs []int64) ([]*OperationResult, error) {
-	results := make([]*OperationResult, len(containerIDs))
+func (s *ContainerService) BulkStartContainers(ctx context.Context, actor model.Actor, containerIDs []int64) ([]*dto.OperationResult, error) {
+	return s.BulkUpdateContainers(ctx, actor, &dto.BulkUpdateRequest{ContainerIDs: containerIDs, Action: "start"})
+}
 
-	for i, containerID := range containerIDs {
-		result := &OperationResult{
-			ContainerID: containerID,
-		}
+// BulkStopContainers stops multiple containers
+func (s *ContainerService) BulkStopContainers(ctx context.Context, actor model.Actor, containerIDs []int64) ([]*dto.OperationResult, error) {
+	return s.BulkUpdateContainers(ctx, actor, &dto.BulkUpdateRequest{ContainerIDs: containerIDs, Action: "stop"})
+}
 
-		// Get container info
-		container, err := s.containerRepo.GetByID(ctx, containerID)
-		if err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Failed to get container: %v", err)
-			results[i] = result
-			continue
-		}
+// BulkUpdateContainers performs an action on multiple containers, up to
+// req.Concurrency() of them at once. A container that fails reports its
+// error in its result; the others carry on unless req.FailFast is set.
+// Containers are handled in dependency order: dependencies are started,
+// restarted and updated before their dependents, which first wait for them
+// to be healthy, and stopped after them. A container whose dependency, or
+// when stopping whose dependent, failed is skipped.
+func (s *ContainerService) BulkUpdateContainers(ctx context.Context, actor model.Actor, req *dto.BulkUpdateRequest) ([]*dto.OperationResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("bulk update request cannot be nil")
+	}
 
-		result.Name = container.Name
+	switch req.Action {
+	case "start", "stop", "restart", "update":
+	default:
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: unknown action: %s", req.Action)
+	}
+	dryRun := req.IsDryRun()
+	if dryRun && req.Action != "update" {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: dry_run applies to image updates only")
+	}
 
-		// Check permissions
-		if err := s.checkContainerPermission(container, userID); err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Permission denied: %v", err)
-			results[i] = result
+	// Listing a container twice would run two actions on it at once
+	var results []*dto.OperationResult
+	var keys []string
+	var ids []int
+	seen := make(map[int64]bool)
+	for _, containerID := range req.ContainerIDs {
+		if seen[containerID] {
 			continue
 		}
-
-		// Start container
-		if err := s.StartContainer(ctx, userID, containerID); err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Failed to start: %v", err)
-		} else {
-			result.Success = true
-			result.Message = "Container started successfully"
-		}
-
-		results[i] = result
+		seen[containerID] = true
+		results = append(results, &dto.OperationResult{ContainerID: containerID})
+		keys = append(keys, strconv.FormatInt(containerID, 10))
+		ids = append(ids, int(containerID))
 	}
 
-	// Log bulk operation
-	successCount := 0
-	for _, result := range results {
-		if result.Success {
-			successCount++
-		}
+	config := docker.BulkOperationConfig{
+		MaxConcurrency: req.Concurrency(),
+		FailFast:       req.FailFast,
 	}
 
-	s.logUserActivity(userID, "bulk_start_containers", fmt.Sprintf("Bulk start operation: %d/%d successful", successCount, len(containerIDs)), map[string]interface{}{
-		"container_ids":   containerIDs,
-		"success_count":   successCount,
-		"total_count":     len(containerIDs),
-	})
-
-	return results, nil
-}
-
-// BulkStopContainers stops multiple containers
-func (s *ContainerService) BulkStopContainers(ctx context.Context, userID int64, containerIDs []int64) ([]*OperationResult, error) {
-	results := make([]*OperationResult, len(containerIDs))
-
-	for i, containerID := range containerIDs {
-		result := &OperationResult{
-			ContainerID: containerID,
+	// Containers run in waves so dependencies start first and stop last
+	graph, err := s.dependencyGraph(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Running bulk operation without dependency ordering")
+		graph = model.DependencyGraph{}
+	}
+	stopping, waitsFor := req.Action == "stop", "dependency"
+	waves := graph.Waves(ids)
+	if stopping {
+		waitsFor = "dependent"
+		for i, j := 0, len(waves)-1; i < j; i, j = i+1, j-1 {
+			waves[i], waves[j] = waves[j], waves[i]
 		}
+	}
 
-		// Get container info
-		container, err := s.containerRepo.GetByID(ctx, containerID)
-		if err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Failed to get container: %v", err)
-			results[i] = result
+	index := make(map[int]int, len(results))
+	for i, result := range results {
+		index[int(result.ContainerID)] = i
+	}
+	outcomes := make([]docker.ParallelOperationResult, len(results))
+	failed := make(map[int]bool)
+	for _, wave := range waves {
+		if req.FailFast && len(failed) > 0 {
+			for _, containerID := range wave {
+				outcomes[index[containerID]] = docker.ParallelOperationResult{Error: docker.ErrSkippedFailFast}
+			}
 			continue
 		}
 
-		result.Name = container.Name
-
-		// Check permissions
-		if err := s.checkContainerPermission(container, userID); err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Permission denied: %v", err)
-			results[i] = result
-			continue
+		waveKeys := make([]string, len(wave))
+		for i, containerID := range wave {
+			waveKeys[i] = keys[index[containerID]]
 		}
-
-		// Stop container
-		if err := s.StopContainer(ctx, userID, containerID); err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Failed to stop: %v", err)
-		} else {
-			result.Success = true
-			result.Message = "Container stopped successfully"
+		waveOutcomes := docker.RunParallel(ctx, waveKeys, req.Action, config, func(ctx context.Context, i int) error {
+			containerID := wave[i]
+			// Skip a container when one it waits for failed in an earlier wave
+			waitsOn := graph[containerID]
+			if stopping {
+				waitsOn = graph.Dependents(containerID)
+			}
+			for _, other := range waitsOn {
+				if failed[other] {
+					name := results[index[other]].Name
+					if name == "" {
+						name = strconv.Itoa(other)
+					}
+					return fmt.Errorf("skipped: %s %s failed", waitsFor, name)
+				}
+			}
+			return s.bulkContainerAction(ctx, actor, req, graph, results[index[containerID]])
+		})
+		for i, outcome := range waveOutcomes {
+			outcomes[index[wave[i]]] = outcome
+			if !outcome.Success {
+				failed[wave[i]] = true
+			}
 		}
-
-		results[i] = result
 	}
 
-	// Log bulk operation
 	successCount := 0
-	for _, result := range results {
-		if result.Success {
+	for i, outcome := range outcomes {
+		result := results[i]
+		if !outcome.StartedAt.IsZero() {
+			startedAt := outcome.StartedAt
+			result.Timestamp = &startedAt
+			result.DurationMS = outcome.Duration.Milliseconds()
+		}
+
+		if outcome.Success {
 			successCount++
+			result.Success = true
+			result.Message = fmt.Sprintf("Container %s successful", req.Action)
+			if dryRun {
+				result.Message = "Container update planned"
+			}
+		} else {
+			result.Error = outcome.Error
 		}
 	}
 
-	s.logUserActivity(userID, "bulk_stop_containers", fmt.Sprintf("Bulk stop operation: %d/%d successful", successCount, len(containerIDs)), map[string]interface{}{
-		"container_ids":   containerIDs,
+	s.logUserActivity(actor, fmt.Sprintf("bulk_%s_containers", req.Action), fmt.Sprintf("Bulk %s operation: %d/%d successful", req.Action, successCount, len(results)), map[string]interface{}{
+		"container_ids":   req.ContainerIDs,
+		"action":          req.Action,
 		"success_count":   successCount,
-		"total_count":     len(containerIDs),
+		"total_count":     len(results),
+		"dry_run":         dryRun,
+		"max_concurrency": config.MaxConcurrency,
+		"fail_fast":       req.FailFast,
 	})
 
 	return results, nil
 }
 
-// BulkUpdateContainers performs bulk updates on multiple containers
-func (s *ContainerService) BulkUpdateContainers(ctx context.Context, userID int64, req *BulkUpdateRequest) ([]*OperationResult, error) {
-	if req == nil {
-		return nil, fmt.Errorf("bulk update request cannot be nil")
-	}
-
-	results := make([]*OperationResult, len(req.ContainerIDs))
-
-	for i, containerID := range req.ContainerIDs {
-		result := &OperationResult{
-			ContainerID: containerID,
-		}
-
-		// Get container info
-		container, err := s.containerRepo.GetByID(ctx, containerID)
-		if err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Failed to get container: %v", err)
-			results[i] = result
-			continue
-		}
+// bulkContainerAction performs the bulk action on the container of result,
+// filling in its name, warnings and plan
+func (s *ContainerService) bulkContainerAction(ctx context.Context, actor model.Actor, req *dto.BulkUpdateRequest, graph model.DependencyGraph, result *dto.OperationResult) error {
+	containerID := result.ContainerID
 
-		result.Name = container.Name
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return fmt.Errorf("Failed to get container: %v", err)
+	}
+	result.Name = container.Name
 
-		// Check permissions
-		if err := s.checkContainerPermission(container, userID); err != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Permission denied: %v", err)
-			results[i] = result
-			continue
-		}
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return fmt.Errorf("Permission denied: %v", err)
+	}
 
-		// Perform action based on request
-		var actionErr error
-		switch req.Action {
-		case "start":
-			actionErr = s.StartContainer(ctx, userID, containerID)
-		case "stop":
-			actionErr = s.StopContainer(ctx, userID, containerID)
-		case "restart":
-			actionErr = s.RestartContainer(ctx, userID, containerID)
-		case "update":
-			if req.UpdateImage != nil {
-				_, actionErr = s.UpdateContainerImage(ctx, userID, containerID, req.UpdateImage)
-			} else if req.Config != nil {
-				updateReq := &UpdateContainerRequest{
-					Config: req.Config,
-				}
-				actionErr = s.UpdateContainer(ctx, userID, containerID, updateReq)
-			}
-		default:
-			actionErr = fmt.Errorf("unknown action: %s", req.Action)
+	// Starting or updating a dependent waits for its dependencies
+	if req.Action != "stop" && !req.IsDryRun() {
+		timeout := time.Duration(dto.DefaultHealthGateTimeout) * time.Second
+		if req.UpdateImage != nil {
+			timeout = req.UpdateImage.HealthTimeout()
 		}
-
-		if actionErr != nil {
-			result.Success = false
-			result.Error = fmt.Sprintf("Failed to %s: %v", req.Action, actionErr)
-		} else {
-			result.Success = true
-			result.Message = fmt.Sprintf("Container %s successful", req.Action)
+		if err := s.waitForDependencies(ctx, graph, container.ID, timeout); err != nil {
+			return fmt.Errorf("Failed to %s: %v", req.Action, err)
 		}
-
-		results[i] = result
 	}
 
-	// Log bulk operation
-	successCount := 0
-	for _, result := range results {
-		if result.Success {
-			successCount++
+	var actionErr error
+	switch req.Action {
+	case "start":
+		result.Warnings, actionErr = s.StartContainer(ctx, actor, containerID)
+	case "stop":
+		actionErr = s.StopContainer(ctx, actor, containerID)
+	case "restart":
+		actionErr = s.RestartContainer(ctx, actor, containerID)
+	case "update":
+		if req.IsDryRun() {
+			result.Plan, actionErr = s.PlanContainerUpdate(ctx, actor, containerID, req.UpdateImage)
+		} else if req.UpdateImage != nil {
+			_, actionErr = s.UpdateContainerImage(ctx, actor, containerID, req.UpdateImage)
+		} else if req.Config != nil {
+			_, actionErr = s.UpdateContainer(ctx, actor, containerID, &dto.UpdateContainerRequest{
+				Config: req.Config,
+			})
 		}
 	}
-
-	s.logUserActivity(userID, fmt.Sprintf("bulk_%s_containers", req.Action), fmt.Sprintf("Bulk %s operation: %d/%d successful", req.Action, successCount, len(req.ContainerIDs)), map[string]interface{}{
-		"container_ids":   req.ContainerIDs,
-		"action":          req.Action,
-		"success_count":   successCount,
-		"total_count":     len(req.ContainerIDs),
-	})
-
-	return results, nil
+	if actionErr != nil {
+		return fmt.Errorf("Failed to %s: %v", req.Action, actionErr)
+	}
+	return nil
 }
 
 // Import and export operations
 
-// ImportContainerFromDocker imports an existing Docker container
-func (s *ContainerService) ImportContainerFromDocker(ctx context.Context, userID int64, dockerContainerID string) (*model.Container, error) {
+// ImportContainerFromDocker imports an existing Docker container from the
+// Docker host hostID, the local daemon when nil, placing it under the quota
+// of teamID when set
+func (s *ContainerService) ImportContainerFromDocker(ctx context.Context, actor model.Actor, dockerContainerID string, hostID, teamID *int) (*model.Container, error) {
+	dc, err := s.hostClient(ctx, hostID)
+	if err != nil {
+		return nil, err
+	}
+	return s.importContainer(ctx, actor, dc, dockerContainerID, hostID, teamID, nil)
+}
+
+// importContainer registers the Docker container dockerContainerID of the
+// daemon dc. With discovery labels the container is adopted: it takes its
+// policy from them and is marked as such.
+func (s *ContainerService) importContainer(ctx context.Context, actor model.Actor, dc *docker.DockerClient, dockerContainerID string, hostID, teamID *int, adopt *model.DiscoveryLabels) (*model.Container, error) {
 	// Get Docker container info
-	dockerContainer, err := s.dockerClient.GetContainer(ctx, dockerContainerID)
+	dockerContainer, err := dc.GetContainer(ctx, dockerContainerID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inspect Docker container: %w", err)
 	}
 
-	// Extract container configuration
+	// Extract container configuration; containers created by digest stay pinned
 	name := strings.TrimPrefix(dockerContainer.Name, "/")
-	imageParts := strings.Split(dockerContainer.Config.Image, ":")
-	image := imageParts[0]
-	tag := "latest"
-	if len(imageParts) > 1 {
-		tag = imageParts[1]
+	image, tag, digest := model.ParseImageReference(dockerContainer.Config.Image)
+	if tag == "" {
+		tag = "latest"
 	}
 
 	// Check if container already exists
@@ -239,7 +253,7 @@ func (s *ContainerService) ImportContainerFromDocker(ctx context.Context, userID
 		return nil, fmt.Errorf("failed to check container existence: %w", err)
 	}
 	if exists {
-		return nil, fmt.Errorf("container with name '%s' already exists", name)
+		return nil, apperrors.Newf(apperrors.CodeContainerExists, "container with name '%s' already exists", name)
 	}
 
 	// Create container configuration
@@ -254,6 +268,25 @@ func (s *ContainerService) ImportContainerFromDocker(ctx context.Context, userID
 		"volumes":     dockerContainer.Config.Volumes,
 	}
 
+	// Keep the live limits so the container counts against its team's quota
+	// by what it actually reserves
+	if hostConfig := dockerContainer.HostConfig; hostConfig != nil {
+		pidsLimit := int64(0)
+		if hostConfig.PidsLimit != nil && *hostConfig.PidsLimit > 0 {
+			pidsLimit = *hostConfig.PidsLimit
+		}
+		config["resources"] = &docker.ResourceConfig{
+			Memory:            hostConfig.Memory,
+			MemorySwap:        hostConfig.MemorySwap,
+			MemoryReservation: hostConfig.MemoryReservation,
+			CPUShares:         hostConfig.CPUShares,
+			CPUQuota:          hostConfig.CPUQuota,
+			CPUPeriod:         hostConfig.CPUPeriod,
+			CPUSetCPUs:        hostConfig.CpusetCpus,
+			PidsLimit:         pidsLimit,
+		}
+	}
+
 	configJSON, err := json.Marshal(config)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal config: %w", err)
@@ -267,7 +300,17 @@ func (s *ContainerService) ImportContainerFromDocker(ctx context.Context, userID
 		ContainerID:  dockerContainerID,
 		ConfigJSON:   string(configJSON),
 		UpdatePolicy: model.UpdatePolicyManual,
-		CreatedBy:    func() *int { u := int(userID); return &u }(),
+		PinByDigest:  digest != "",
+		ImageDigest:  digest,
+		CreatedBy:    actor.OwnerID(),
+		TeamID:       teamID,
+		HostID:       hostID,
+	}
+	if adopt != nil {
+		container.Adopted = true
+		container.UpdatePolicy = adopt.UpdatePolicy
+		container.RegistryURL = adopt.RegistryURL
+		container.MaintenanceWindows = adopt.MaintenanceWindows
 	}
 
 	// Set status based on Docker state
@@ -284,151 +327,173 @@ func (s *ContainerService) ImportContainerFromDocker(ctx context.Context, userID
 		container.Status = model.ContainerStatusStopped
 	}
 
-	// Save to database
-	if err := s.containerRepo.Create(ctx, container); err != nil {
-		return nil, fmt.Errorf("failed to create container: %w", err)
+	// Save to database, within the team's quota
+	err = s.admitToTeam(ctx, actor, container, func() error {
+		if err := s.containerRepo.Create(ctx, container); err != nil {
+			return fmt.Errorf("failed to create container: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.refreshContainerMetrics(ctx)
+
+	// Containers of a compose project are grouped into its stack
+	if err := s.joinComposeStack(ctx, actor, container, dockerContainer.Config.Labels[model.ComposeProjectLabel]); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to add imported container to compose stack")
 	}
 
 	// Log activity
-	s.logContainerActivity(userID, int64(container.ID), "container_imported", "Container imported from Docker", map[string]interface{}{
+	action, description := "container_imported", "Container imported from Docker"
+	if adopt != nil {
+		action, description = "container_adopted", "Container adopted by its discovery labels"
+	}
+	s.logContainerActivity(actor, int64(container.ID), action, description, map[string]interface{}{
 		"docker_container_id": dockerContainerID,
 		"container_name":      container.Name,
-		"image":               container.GetFullImageName(),
+		"image":               container.GetDeployImageRef(),
 	})
 
 	// Invalidate cache
-	s.invalidateContainerCache(userID)
+	s.invalidateContainerCache(actor)
 
 	logrus.WithFields(logrus.Fields{
 		"container_id":        container.ID,
 		"container_name":      container.Name,
 		"docker_container_id": dockerContainerID,
-		"user_id":             userID,
+		"actor":               actor.String(),
 	}).Info("Container imported successfully")
 
 	return container, nil
 }
 
-// ExportContainerConfig exports container configuration
-func (s *ContainerService) ExportContainerConfig(ctx context.Context, userID int64, containerID int64) (*ContainerExport, error) {
-	// Get container
-	container, err := s.containerRepo.GetByID(ctx, containerID)
+// Helper and utility methods
+
+// joinComposeStack adds an imported container to the stack of its compose
+// project, creating the stack on first use
+func (s *ContainerService) joinComposeStack(ctx context.Context, actor model.Actor, container *model.Container, project string) error {
+	if s.stackRepo == nil || project == "" {
+		return nil
+	}
+
+	stack, err := s.stackRepo.GetByComposeProject(ctx, project)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get container: %w", err)
+		stack = &model.Stack{
+			Name:           project,
+			ComposeProject: project,
+			Description:    fmt.Sprintf("Imported from compose project %s", project),
+			CreatedBy:      actor.OwnerID(),
+		}
+		if err := s.stackRepo.Create(ctx, stack); err != nil {
+			return fmt.Errorf("failed to create stack for compose project: %w", err)
+		}
 	}
 
-	// Check permissions
-	if err := s.checkContainerPermission(container, userID); err != nil {
-		return nil, err
+	if err := s.stackRepo.AddMember(ctx, int64(stack.ID), int64(container.ID)); err != nil {
+		return fmt.Errorf("failed to add container to stack: %w", err)
 	}
 
-	// Parse configuration
-	var config map[string]interface{}
-	if container.ConfigJSON != "" {
-		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
-			logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to parse container config")
-			config = make(map[string]interface{})
-		}
+	stackID := stack.ID
+	container.StackID = &stackID
+	return nil
+}
+
+// userActor returns the acting user, keeping the name the request context
+// carries for them
+func userActor(ctx context.Context, userID int64) model.Actor {
+	if actor := model.ActorFromContext(ctx); actor.IsUser(userID) {
+		return actor
 	}
+	return model.UserActor(userID, "")
+}
 
-	// Create export object
-	export := &ContainerExport{
-		Name:         container.Name,
-		Image:        container.Image,
-		Tag:          container.Tag,
-		Config:       config,
-		UpdatePolicy: string(container.UpdatePolicy),
-		RegistryURL:  container.RegistryURL,
-		ExportedAt:   time.Now(),
-		Version:      "1.0",
-	}
-
-	// Extract specific configuration sections
-	if labels, ok := config["labels"].(map[string]interface{}); ok {
-		export.Labels = make(map[string]string)
-		for k, v := range labels {
-			if str, ok := v.(string); ok {
-				export.Labels[k] = str
-			}
-		}
+// checkExecPermission checks that the container owner may run commands inside
+// containers, as exec health actions and post-start hooks run with the
+// owner's authority
+func (s *ContainerService) checkExecPermission(ctx context.Context, container *model.Container, actor model.Actor) error {
+	if actor.IsSystem() {
+		return nil
+	}
+	if container.CreatedBy == nil || s.userService == nil {
+		return apperrors.Newf(apperrors.CodePermissionDenied, "access denied: exec actions require a container owner with exec permission")
 	}
 
-	if env, ok := config["env"].([]interface{}); ok {
-		export.Environment = make(map[string]string)
-		for _, e := range env {
-			if envStr, ok := e.(string); ok {
-				parts := strings.SplitN(envStr, "=", 2)
-				if len(parts) == 2 {
-					export.Environment[parts[0]] = parts[1]
-				}
-			}
-		}
+	owner, err := s.userService.GetUserByID(ctx, int64(*container.CreatedBy))
+	if err != nil {
+		return fmt.Errorf("failed to get container owner: %w", err)
+	}
+	if !owner.Role.CanExecInContainers() {
+		return apperrors.Newf(apperrors.CodePermissionDenied, "access denied: container owner lacks exec permission")
 	}
+	return nil
+}
 
-	// Add port mappings if available
-	if ports, ok := config["ports"].([]interface{}); ok {
-		for _, p := range ports {
-			if portMap, ok := p.(map[string]interface{}); ok {
-				mapping := PortMapping{}
-				if containerPort, ok := portMap["container_port"].(float64); ok {
-					mapping.ContainerPort = int(containerPort)
-				}
-				if hostPort, ok := portMap["host_port"].(float64); ok {
-					mapping.HostPort = int(hostPort)
-				}
-				if protocol, ok := portMap["protocol"].(string); ok {
-					mapping.Protocol = protocol
-				}
-				if hostIP, ok := portMap["host_ip"].(string); ok {
-					mapping.HostIP = hostIP
-				}
-				export.Ports = append(export.Ports, mapping)
-			}
-		}
+// admitToTeam runs save once the container fits into its team's quota
+func (s *ContainerService) admitToTeam(ctx context.Context, actor model.Actor, container *model.Container, save func() error) error {
+	if s.teamService == nil {
+		return save()
 	}
+	return s.teamService.Admit(ctx, actor, container, save)
+}
 
-	// Add volume mappings if available
-	if volumes, ok := config["volumes"].([]interface{}); ok {
-		for _, v := range volumes {
-			if volumeMap, ok := v.(map[string]interface{}); ok {
-				mapping := VolumeMapping{}
-				if source, ok := volumeMap["source"].(string); ok {
-					mapping.Source = source
-				}
-				if target, ok := volumeMap["target"].(string); ok {
-					mapping.Target = target
-				}
-				if volType, ok := volumeMap["type"].(string); ok {
-					mapping.Type = volType
-				}
-				if readOnly, ok := volumeMap["read_only"].(bool); ok {
-					mapping.ReadOnly = readOnly
-				}
-				export.Volumes = append(export.Volumes, mapping)
-			}
+func sameIntPtr(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// containerLogRedactor returns the redactor for logs read by actor, nil when
+// logs are returned unredacted. Raw logs are limited to admins and every raw
+// read is recorded in the activity log.
+func (s *ContainerService) containerLogRedactor(ctx context.Context, container *model.Container, actor model.Actor, raw bool) (*docker.LogRedactor, error) {
+	if raw {
+		if actor.UserID == nil || s.userService == nil {
+			return nil, apperrors.Newf(apperrors.CodePermissionDenied, "access denied: raw logs require an admin")
+		}
+		user, err := s.userService.GetUserByID(ctx, *actor.UserID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user: %w", err)
 		}
+		if !user.IsAdmin() {
+			return nil, apperrors.Newf(apperrors.CodePermissionDenied, "access denied: raw logs require an admin")
+		}
+
+		s.logContainerActivity(actor, int64(container.ID), "container_logs_raw_viewed",
+			fmt.Sprintf("Unredacted logs of container %s viewed", container.Name), nil)
+		return nil, nil
 	}
 
-	// Log activity
-	s.logContainerActivity(userID, containerID, "container_exported", "Container configuration exported", nil)
+	if s.config == nil || !s.config.Security.LogRedactionEnabled {
+		return nil, nil
+	}
 
-	return export, nil
+	redactor, err := docker.NewLogRedactor(container.LogRedactPatterns)
+	if err != nil {
+		// Patterns are validated on save; never fall back to raw logs
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Ignoring invalid log redaction patterns")
+		return docker.NewLogRedactor(nil)
+	}
+	return redactor, nil
 }
 
-// Helper and utility methods
+// flushingWriter flushes the underlying writer after every write, so
+// streamed log lines reach the client as they are produced
+type flushingWriter struct {
+	w io.Writer
+}
 
-// checkContainerPermission checks if user has permission to access container
-func (s *ContainerService) checkContainerPermission(container *model.Container, userID int64) error {
-	// For now, only allow access to containers created by the user
-	// In a more complex system, you might have role-based permissions
-	if container.CreatedBy == nil || int64(*container.CreatedBy) != userID {
-		return fmt.Errorf("access denied: container belongs to different user")
+func (fw *flushingWriter) Write(p []byte) (int, error) {
+	n, err := fw.w.Write(p)
+	if err == nil {
+		flushWriter(fw.w)
 	}
-	return nil
+	return n, err
 }
 
 // logContainerActivity logs container-related activities
-func (s *ContainerService) logContainerActivity(userID int64, containerID int64, action, description string, metadata map[string]interface{}) {
+func (s *ContainerService) logContainerActivity(actor model.Actor, containerID int64, action, description string, metadata map[string]interface{}) {
 	if s.activityRepo == nil {
 		return
 	}
@@ -441,7 +506,6 @@ func (s *ContainerService) logContainerActivity(userID int64, containerID int64,
 	}
 
 	activity := &model.ActivityLog{
-		UserID:       &userID,
 		Action:       action,
 		ResourceType: "container",
 		ResourceID:   func() *int { id := int(containerID); return &id }(),
@@ -450,10 +514,11 @@ func (s *ContainerService) logContainerActivity(userID int64, containerID int64,
 		IPAddress:    "", // Would be set from request context
 		UserAgent:    "", // Would be set from request context
 	}
+	activity.SetActor(actor)
 
 	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
-			"user_id":      userID,
+			"actor":        actor.String(),
 			"container_id": containerID,
 			"action":       action,
 		}).Warn("Failed to log container activity")
@@ -461,7 +526,7 @@ func (s *ContainerService) logContainerActivity(userID int64, containerID int64,
 }
 
 // logUserActivity logs user activities
-func (s *ContainerService) logUserActivity(userID int64, action, description string, metadata map[string]interface{}) {
+func (s *ContainerService) logUserActivity(actor model.Actor, action, description string, metadata map[string]interface{}) {
 	if s.activityRepo == nil {
 		return
 	}
@@ -474,113 +539,214 @@ func (s *ContainerService) logUserActivity(userID int64, action, description str
 	}
 
 	activity := &model.ActivityLog{
-		UserID:      &userID,
 		Action:      action,
 		Description: description,
 		Metadata:    metadataJSON,
 	}
+	activity.SetActor(actor)
 
 	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
-			"user_id": userID,
-			"action":  action,
+			"actor":  actor.String(),
+			"action": action,
 		}).Warn("Failed to log user activity")
 	}
 }
 
 // invalidateContainerCache invalidates container-related cache entries
-func (s *ContainerService) invalidateContainerCache(userID int64) {
+func (s *ContainerService) invalidateContainerCache(actor model.Actor) {
 	if s.cache == nil {
 		return
 	}
 
 	// Invalidate container list cache for user
-	s.cache.Delete(fmt.Sprintf("container:list:%d", userID))
+	if actor.UserID != nil {
+		s.cache.Delete(fmt.Sprintf("container:list:%d", *actor.UserID))
+	}
 
 	// Could also invalidate other related caches
 	s.cache.Delete("containers:stats")
 	s.cache.Delete("containers:summary")
 }
 
-// validateImageExists checks if the Docker image exists (optional validation)
-func (s *ContainerService) validateImageExists(ctx context.Context, image, tag string) error {
+// validateImageExists checks if the Docker image exists (optional validation).
+// A missing image is pulled on behalf of container, which may be nil when it
+// is not created yet.
+func (s *ContainerService) validateImageExists(ctx context.Context, dc *docker.DockerClient, container *model.Container, image, tag string) error {
 	fullImage := image
 	if tag != "" && tag != "latest" {
 		fullImage = fmt.Sprintf("%s:%s", image, tag)
 	}
 
 	// Try to inspect the image
-	_, err := s.dockerClient.InspectImage(ctx, fullImage)
+	_, err := dc.InspectImage(ctx, fullImage)
 	if err != nil {
 		// Try to pull the image
-		if pullErr := s.dockerClient.PullImageAndWait(ctx, fullImage, types.ImagePullOptions{}); pullErr != nil {
-			return fmt.Errorf("image not found and failed to pull: %w", pullErr)
+		if pullErr := pullImageFor(ctx, dc, container, fullImage); pullErr != nil {
+			return apperrors.Newf(apperrors.CodeImagePullFailed, "image not found and failed to pull: %w", pullErr)
 		}
 	}
 
 	return nil
 }
 
-// createDockerContainer creates a Docker container from the container model
-func (s *ContainerService) createDockerContainer(ctx context.Context, container *model.Container) (string, error) {
-	// Parse container configuration
-	var config map[string]interface{}
-	if container.ConfigJSON != "" {
-		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
-			return "", fmt.Errorf("failed to parse container config: %w", err)
+// pullImageFor pulls ref through the host pull throttle. Pulls for a saved
+// container are keyed by it, so its status reports the queue position; others
+// are keyed by the image.
+func pullImageFor(ctx context.Context, dc *docker.DockerClient, container *model.Container, ref string) error {
+	key := ref
+	if container != nil && container.ID > 0 {
+		key = docker.ContainerPullKey(int64(container.ID))
+	}
+
+	return dc.PullImageWithProgress(ctx, key, ref, types.ImagePullOptions{}, func(ahead int) {
+		logrus.WithFields(logrus.Fields{
+			"image":       ref,
+			"pull_key":    key,
+			"pulls_ahead": ahead,
+		}).Info("Image pull queued")
+	}, nil)
+}
+
+// resolvePinnedDigest returns the digest to pin a container to. An explicit
+// digest must be pullable; otherwise the digest the tag resolves to is used.
+func (s *ContainerService) resolvePinnedDigest(ctx context.Context, dc *docker.DockerClient, container *model.Container, digest string) (string, error) {
+	if digest != "" {
+		ref := container.Image + "@" + digest
+		if _, err := dc.InspectImage(ctx, ref); err != nil {
+			if pullErr := pullImageFor(ctx, dc, container, ref); pullErr != nil {
+				return "", apperrors.Newf(apperrors.CodeImagePullFailed, "cannot pin by digest: %s cannot be resolved: %w", ref, pullErr)
+			}
 		}
+		return digest, nil
 	}
 
-	// Build Docker create options
-	createConfig := &docker.ContainerCreateConfig{
-		Name:  container.Name,
-		Image: container.Image,
-		Tag:   container.Tag,
+	image := container.GetFullImageName()
+	if err := s.validateImageExists(ctx, dc, container, container.Image, container.Tag); err != nil {
+		return "", fmt.Errorf("cannot pin by digest: %w", err)
+	}
+	resolved, err := dc.GetImageDigest(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("cannot pin by digest: failed to resolve digest of %s: %w", image, err)
 	}
+	return resolved, nil
+}
 
-	// Set environment variables
-	if env, ok := config["env"].([]interface{}); ok {
-		for _, e := range env {
-			if envStr, ok := e.(string); ok {
-				createConfig.Env = append(createConfig.Env, envStr)
-			}
-		}
+// createDockerContainer creates a Docker container from the container model and
+// returns its ID with the warnings the daemon reported
+func (s *ContainerService) createDockerContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container) (string, []string, error) {
+	desired, err := desiredContainerState(container)
+	if err != nil {
+		return "", nil, err
 	}
 
-	// Set labels
-	if labels, ok := config["labels"].(map[string]interface{}); ok {
-		createConfig.Labels = make(map[string]string)
-		for k, v := range labels {
-			if str, ok := v.(string); ok {
-				createConfig.Labels[k] = str
-			}
+	// Build Docker create options
+	createConfig := &docker.ContainerCreateConfig{
+		Name:          container.Name,
+		Image:         container.Image,
+		Tag:           container.Tag,
+		Env:           desired.Env,
+		Labels:        desired.Labels,
+		Volumes:       desired.Volumes,
+		RestartPolicy: desired.RestartPolicy,
+		Resources:     desired.Resources,
+		StopSignal:    container.StopSignal,
+		StopTimeout:   container.StopTimeoutSeconds,
+	}
+	if container.PinByDigest {
+		createConfig.Digest = container.ImageDigest
+	}
+
+	// Publish TCP ports bound to a host port
+	for _, mapping := range desired.Ports {
+		if mapping.HostPort <= 0 || (mapping.Protocol != "" && mapping.Protocol != "tcp") {
+			continue
+		}
+		if createConfig.Ports == nil {
+			createConfig.Ports = make(map[string]string)
 		}
+		createConfig.Ports[strconv.Itoa(mapping.HostPort)] = strconv.Itoa(mapping.ContainerPort)
 	}
 
 	// Add our own labels
 	if createConfig.Labels == nil {
 		createConfig.Labels = make(map[string]string)
 	}
-	createConfig.Labels["docker-auto.container-id"] = strconv.Itoa(container.ID)
-	createConfig.Labels["docker-auto.managed"] = "true"
+	createConfig.Labels[model.ReservedLabelPrefix+"container-id"] = strconv.Itoa(container.ID)
+	createConfig.Labels[model.ReservedLabelPrefix+"managed"] = "true"
 
 	// Create the container
-	containerID, err := s.dockerClient.CreateContainer(ctx, createConfig)
+	resp, err := dc.CreateContainer(ctx, createConfig)
 	if err != nil {
-		return "", fmt.Errorf("failed to create Docker container: %w", err)
+		return "", nil, fmt.Errorf("failed to create Docker container: %w", err)
 	}
 
-	return containerID.ID, nil
+	return resp.ID, resp.Warnings, nil
+}
+
+// stopDockerContainer stops the Docker container dockerID of container with
+// the container's stop signal, killing it after its stop timeout
+func stopDockerContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container, dockerID string) error {
+	timeout := container.StopTimeout()
+	return dc.StopContainerWithSignal(ctx, dockerID, container.StopSignal, &timeout)
+}
+
+// RecordDaemonWarnings stores the daemon's warnings for a container, minus those
+// matching the configured ignore list, and returns what was kept
+func (s *ContainerService) RecordDaemonWarnings(ctx context.Context, container *model.Container, warnings []string) model.StringList {
+	kept := model.FilterWarnings(warnings, s.ignoredWarnings(ctx))
+
+	if err := s.containerRepo.UpdateWarnings(ctx, int64(container.ID), kept); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to record daemon warnings")
+	}
+	container.Warnings = kept
+	s.cache.Delete(fmt.Sprintf("container:detail:%d", container.ID))
+
+	if len(kept) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"container_id":   container.ID,
+			"container_name": container.Name,
+			"warnings":       kept,
+		}).Warn("Docker daemon reported warnings for container")
+	}
+
+	return kept
+}
+
+// ignoredWarnings returns the daemon warning patterns configured to be suppressed
+func (s *ContainerService) ignoredWarnings(ctx context.Context) []string {
+	if s.configRepo == nil {
+		return nil
+	}
+
+	config, err := s.configRepo.GetByKey(ctx, model.ConfigKeyDockerIgnoredWarnings)
+	if err != nil {
+		return nil
+	}
+
+	values, err := config.GetSliceValue()
+	if err != nil {
+		logrus.WithError(err).Warn("Invalid ignored Docker warnings setting")
+		return nil
+	}
+
+	patterns := make([]string, 0, len(values))
+	for _, value := range values {
+		if pattern, ok := value.(string); ok {
+			patterns = append(patterns, pattern)
+		}
+	}
+	return patterns
 }
 
 // getContainerMetrics retrieves container performance metrics
-func (s *ContainerService) getContainerMetrics(ctx context.Context, dockerContainerID string) (*ContainerMetrics, error) {
-	stats, err := s.dockerClient.GetContainerStats(ctx, dockerContainerID)
+func (s *ContainerService) getContainerMetrics(ctx context.Context, dc *docker.DockerClient, dockerContainerID string) (*dto.ContainerMetrics, error) {
+	stats, err := dc.GetContainerStats(ctx, dockerContainerID)
 	if err != nil {
 		return nil, err
 	}
 
-	metrics := &ContainerMetrics{
+	metrics := &dto.ContainerMetrics{
 		CPUPercent:    calculateCPUPercent(stats),
 		MemoryUsage:   int64(stats.MemoryStats.Usage),
 		MemoryLimit:   int64(stats.MemoryStats.Limit),
@@ -598,7 +764,7 @@ func (s *ContainerService) getContainerMetrics(ctx context.Context, dockerContai
 			totalRxPackets += network.RxPackets
 			totalTxPackets += network.TxPackets
 		}
-		metrics.NetworkIO = &NetworkIOMetrics{
+		metrics.NetworkIO = &dto.NetworkIOMetrics{
 			RxBytes:   int64(totalRx),
 			TxBytes:   int64(totalTx),
 			RxPackets: int64(totalRxPackets),
@@ -623,7 +789,7 @@ func (s *ContainerService) getContainerMetrics(ctx context.Context, dockerContai
 				writeOps += bio.Value
 			}
 		}
-		metrics.BlockIO = &BlockIOMetrics{
+		metrics.BlockIO = &dto.BlockIOMetrics{
 			ReadBytes:  int64(readBytes),
 			WriteBytes: int64(writeBytes),
 			ReadOps:    int64(readOps),
@@ -659,10 +825,10 @@ func calculateMemoryPercent(stats *types.StatsJSON) float64 {
 }
 
 // getUpdateInfo gets update information for a container
-func (s *ContainerService) getUpdateInfo(ctx context.Context, container *model.Container) (*UpdateInfo, error) {
+func (s *ContainerService) getUpdateInfo(ctx context.Context, container *model.Container) (*dto.UpdateInfo, error) {
 	// This is a placeholder - would integrate with image service
 	// For now, return basic info
-	return &UpdateInfo{
+	return &dto.UpdateInfo{
 		ContainerID:     int64(container.ID),
 		Name:            container.Name,
 		CurrentImage:    container.Image,
@@ -673,7 +839,7 @@ func (s *ContainerService) getUpdateInfo(ctx context.Context, container *model.C
 }
 
 // getLogsSample gets a sample of recent container logs
-func (s *ContainerService) getLogsSample(ctx context.Context, dockerContainerID string) ([]string, error) {
+func (s *ContainerService) getLogsSample(ctx context.Context, dc *docker.DockerClient, dockerContainerID string) ([]string, error) {
 	logOptions := types.ContainerLogsOptions{
 		ShowStdout: true,
 		ShowStderr: true,
@@ -681,7 +847,7 @@ func (s *ContainerService) getLogsSample(ctx context.Context, dockerContainerID
 		Timestamps: false,
 	}
 
-	logs, err := s.dockerClient.GetContainerLogs(ctx, dockerContainerID, logOptions)
+	logs, err := dc.GetContainerLogs(ctx, dockerContainerID, logOptions)
 	if err != nil {
 		return nil, err
 	}
@@ -694,9 +860,9 @@ func (s *ContainerService) getLogsSample(ctx context.Context, dockerContainerID
 }
 
 // getDetailedDockerStatus gets detailed Docker status for a container
-func (s *ContainerService) getDetailedDockerStatus(ctx context.Context, containerID string) (*docker.ContainerStatus, error) {
+func (s *ContainerService) getDetailedDockerStatus(ctx context.Context, dc *docker.DockerClient, containerID string) (*docker.ContainerStatus, error) {
 	// For now, create a basic Docker status from the simplified status
-	status, err := s.dockerClient.GetContainerStatus(ctx, containerID)
+	status, err := dc.GetContainerStatus(ctx, containerID)
 	if err != nil {
 		return nil, err
 	}
@@ -713,4 +879,19 @@ func (s *ContainerService) getDetailedDockerStatus(ctx context.Context, containe
 	}
 
 	return dockerStatus, nil
-}
\ No newline at end of file
+}
+// refreshContainerMetrics recounts the managed containers by status for the
+// Prometheus gauges
+func (s *ContainerService) refreshContainerMetrics(ctx context.Context) {
+	counts, err := s.containerRepo.CountByStatus(ctx)
+	if err != nil {
+		logrus.WithError(err).Debug("Failed to refresh container metrics")
+		return
+	}
+
+	byStatus := make(map[string]int64, len(counts))
+	for status, count := range counts {
+		byStatus[string(status)] = count
+	}
+	metrics.SetContainerCounts(byStatus)
+}
@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+)
+
+// newFakeDockerClient returns a client with one pull slot talking to
+// handler in place of the Docker daemon
+func newFakeDockerClient(tb testing.TB, handler http.HandlerFunc) *docker.DockerClient {
+	tb.Helper()
+
+	server := httptest.NewServer(handler)
+	tb.Cleanup(server.Close)
+
+	cfg := &config.Config{}
+	cfg.Docker.Host = "tcp://" + strings.TrimPrefix(server.URL, "http://")
+	cfg.Docker.APIVersion = "1.44"
+	cfg.Docker.Timeout = 10
+	cfg.Docker.PullMaxConcurrent = 1
+	dc, err := docker.NewDockerClient(cfg)
+	if err != nil {
+		tb.Fatalf("failed to create client: %v", err)
+	}
+	tb.Cleanup(func() { dc.Close() })
+	return dc
+}
+
+// newPullTestClient returns a Docker client whose fake daemon has no images
+// and answers pulls once release is closed
+func newPullTestClient(t *testing.T, release <-chan struct{}) *docker.DockerClient {
+	t.Helper()
+
+	return newFakeDockerClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/images/create"):
+			<-release
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"status":"Download complete"}`))
+		case strings.Contains(r.URL.Path, "/images/"):
+			http.Error(w, `{"message":"No such image"}`, http.StatusNotFound)
+		default:
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+		}
+	})
+}
+
+func TestResolvePinnedDigestReportsPullQueuePosition(t *testing.T) {
+	ctx := context.Background()
+	release := make(chan struct{})
+	dc := newPullTestClient(t, release)
+	s := &ContainerService{}
+	container := &model.Container{ID: 7, Name: "web", Image: "nginx", Tag: "1.25"}
+
+	// A pull for a container that is not created yet takes the only slot
+	blocking := make(chan error, 1)
+	go func() {
+		blocking <- s.validateImageExists(ctx, dc, nil, "redis", "7")
+	}()
+	waitForPullStatus(t, dc, "redis:7", "pulling")
+
+	pinned := make(chan error, 1)
+	go func() {
+		_, err := s.resolvePinnedDigest(ctx, dc, container, "sha256:"+strings.Repeat("a", 64))
+		pinned <- err
+	}()
+	waitForPullStatus(t, dc, docker.ContainerPullKey(7), "queued behind 1 pulls")
+
+	close(release)
+	if err := <-blocking; err != nil {
+		t.Errorf("validateImageExists failed: %v", err)
+	}
+	if err := <-pinned; err != nil {
+		t.Errorf("resolvePinnedDigest failed: %v", err)
+	}
+	if status := dc.GetPullThrottle().Status(docker.ContainerPullKey(7)); status != "" {
+		t.Errorf("status after the pull = %q, want none", status)
+	}
+}
+
+func waitForPullStatus(t *testing.T, dc *docker.DockerClient, key, want string) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status := dc.GetPullThrottle().Status(key)
+		if status == want {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("pull status of %s = %q, want %q", key, status, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
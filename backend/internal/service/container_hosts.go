@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// dockerFor returns the client of the daemon the container runs on
+func (s *ContainerService) dockerFor(ctx context.Context, container *model.Container) (*docker.DockerClient, error) {
+	return s.hostClient(ctx, container.HostID)
+}
+
+// DockerFor returns the client of the daemon the container runs on, for the
+// scheduled tasks that drive containers on every host
+func (s *ContainerService) DockerFor(ctx context.Context, container *model.Container) (*docker.DockerClient, error) {
+	return s.dockerFor(ctx, container)
+}
+
+// hostClient returns the client of the Docker host hostID, or of the local
+// daemon when hostID is nil. Disabled hosts are refused.
+func (s *ContainerService) hostClient(ctx context.Context, hostID *int) (*docker.DockerClient, error) {
+	if hostID == nil {
+		return s.dockerClient, nil
+	}
+	if s.hostRepo == nil || s.hostPool == nil {
+		return nil, apperrors.New(apperrors.CodeDockerUnavailable, "docker hosts are not configured")
+	}
+
+	host, err := s.hostRepo.GetByID(ctx, *hostID)
+	if err != nil {
+		return nil, err
+	}
+	if !host.Enabled {
+		return nil, apperrors.Newf(apperrors.CodeDockerUnavailable, "docker host %s is disabled", host.Name)
+	}
+	client, err := s.hostPool.Client(ctx, host)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeDockerUnavailable, fmt.Sprintf("docker host %s is unavailable", host.Name))
+	}
+	return client, nil
+}
+
+// enabledDockerHosts returns the remote hosts the status sync covers
+func (s *ContainerService) enabledDockerHosts(ctx context.Context) ([]*model.DockerHost, error) {
+	if s.hostRepo == nil || s.hostPool == nil {
+		return nil, nil
+	}
+	return s.hostRepo.List(ctx, true)
+}
+
+// pingDockerHost pings the host and returns its client
+func (s *ContainerService) pingDockerHost(ctx context.Context, host *model.DockerHost) (*docker.DockerClient, error) {
+	if err := pingDockerHost(ctx, s.hostRepo, s.hostPool, host); err != nil {
+		return nil, err
+	}
+	return s.hostPool.Client(ctx, host)
+}
+
+// removeDockerContainer stops and removes the Docker container of container,
+// only warning when either fails
+func (s *ContainerService) removeDockerContainer(ctx context.Context, dc *docker.DockerClient, container *model.Container) {
+	dockerID := container.ContainerID
+	if dockerStatus, err := dc.GetContainerStatus(ctx, dockerID); err == nil {
+		if dockerStatus == model.ContainerStatusRunning {
+			if err := stopDockerContainer(ctx, dc, container, dockerID); err != nil {
+				logrus.WithError(err).WithField("container_id", dockerID).Warn("Failed to stop Docker container before deletion")
+			}
+		}
+	}
+
+	// Remove Docker container
+	removeOptions := types.ContainerRemoveOptions{
+		Force:         true,
+		RemoveVolumes: true,
+	}
+	if err := dc.RemoveContainer(ctx, dockerID, removeOptions); err != nil {
+		logrus.WithError(err).WithField("container_id", dockerID).Warn("Failed to remove Docker container")
+	}
+}
+
+// dockerClients resolves the clients of many containers, looking each host
+// up once so an unreachable host is only waited on once
+type dockerClients struct {
+	s       *ContainerService
+	clients map[int]*docker.DockerClient
+	errs    map[int]error
+}
+
+func (s *ContainerService) newDockerClients() *dockerClients {
+	return &dockerClients{
+		s:       s,
+		clients: make(map[int]*docker.DockerClient),
+		errs:    make(map[int]error),
+	}
+}
+
+// get returns the client of the daemon the container runs on
+func (c *dockerClients) get(ctx context.Context, container *model.Container) (*docker.DockerClient, error) {
+	if container.HostID == nil {
+		return c.s.dockerClient, nil
+	}
+
+	hostID := *container.HostID
+	if err, ok := c.errs[hostID]; ok {
+		return nil, err
+	}
+	if dc, ok := c.clients[hostID]; ok {
+		return dc, nil
+	}
+
+	dc, err := c.s.hostClient(ctx, container.HostID)
+	if err != nil {
+		c.errs[hostID] = err
+		return nil, err
+	}
+	c.clients[hostID] = dc
+	return dc, nil
+}
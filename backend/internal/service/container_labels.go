@@ -0,0 +1,500 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// labelBatchOperation names label batch confirmations
+const labelBatchOperation = "container_labels"
+
+// labelBatchConfirmThreshold is how many containers a label batch may change
+// without a confirmation
+const labelBatchConfirmThreshold = 10
+
+// maxLabelOperations bounds the operations of one label batch
+const maxLabelOperations = 50
+
+// Label operations
+const (
+	LabelOpSet    = "set"
+	LabelOpRemove = "remove"
+)
+
+// LabelOperation sets or removes one label
+type LabelOperation struct {
+	Op    string `json:"op"`
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+}
+
+// LabelBatchFilter selects the containers of a label batch. Set fields must
+// all match.
+type LabelBatchFilter struct {
+	StackID     *int                  `json:"stack_id,omitempty"`
+	NamePattern string                `json:"name_pattern,omitempty"`
+	Image       string                `json:"image,omitempty"`
+	Status      model.ContainerStatus `json:"status,omitempty"`
+	// Label selects containers carrying a label, given as key or key=value
+	Label string `json:"label,omitempty"`
+}
+
+// LabelBatchRequest changes the labels of the containers matched by the
+// filter or listed by ID. Batches changing more than ten containers only
+// preview until repeated with the preview's confirm token.
+type LabelBatchRequest struct {
+	ContainerIDs []int64           `json:"container_ids,omitempty"`
+	Filter       *LabelBatchFilter `json:"filter,omitempty"`
+	Operations   []LabelOperation  `json:"operations"`
+
+	// ApplyNow recreates the changed containers, in stack start order, so
+	// Docker carries the labels right away. Otherwise they are applied the
+	// next time each container is recreated.
+	ApplyNow bool `json:"apply_now,omitempty"`
+
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// Validate validates the selection and every operation
+func (r *LabelBatchRequest) Validate() error {
+	if len(r.ContainerIDs) == 0 && r.Filter == nil {
+		return fmt.Errorf("container_ids or filter is required")
+	}
+	if len(r.Operations) == 0 {
+		return fmt.Errorf("at least one label operation is required")
+	}
+	if len(r.Operations) > maxLabelOperations {
+		return fmt.Errorf("at most %d label operations are allowed", maxLabelOperations)
+	}
+
+	for i, op := range r.Operations {
+		if err := model.ValidateLabelKey(op.Key); err != nil {
+			return fmt.Errorf("operation %d: %w", i+1, err)
+		}
+		switch op.Op {
+		case LabelOpSet:
+			if err := model.ValidateLabelValue(op.Key, op.Value); err != nil {
+				return fmt.Errorf("operation %d: %w", i+1, err)
+			}
+		case LabelOpRemove:
+			if op.Value != "" {
+				return fmt.Errorf("operation %d: remove takes no value", i+1)
+			}
+		default:
+			return fmt.Errorf("operation %d: unknown label operation %q", i+1, op.Op)
+		}
+	}
+
+	if r.Filter != nil && r.Filter.NamePattern != "" {
+		if _, err := path.Match(r.Filter.NamePattern, ""); err != nil {
+			return fmt.Errorf("invalid name pattern: %w", err)
+		}
+	}
+	return nil
+}
+
+// LabelBatchResult is what a label batch did to one container
+type LabelBatchResult struct {
+	ContainerID int64             `json:"container_id"`
+	Name        string            `json:"name,omitempty"`
+	Changed     bool              `json:"changed"`
+	Labels      map[string]string `json:"labels,omitempty"`
+	Success     bool              `json:"success"`
+	// PendingRecreate is set when Docker gets the labels at the next recreation
+	PendingRecreate bool     `json:"pending_recreate,omitempty"`
+	Recreated       bool     `json:"recreated,omitempty"`
+	Message         string   `json:"message,omitempty"`
+	Error           string   `json:"error,omitempty"`
+	Warnings        []string `json:"warnings,omitempty"`
+
+	container *model.Container
+}
+
+// LabelBatchResponse reports a label batch per container. An unapplied
+// response carries the token confirming it.
+type LabelBatchResponse struct {
+	Matched  int                 `json:"matched"`
+	Changed  int                 `json:"changed"`
+	Applied  bool                `json:"applied"`
+	ApplyNow bool                `json:"apply_now"`
+	Results  []*LabelBatchResult `json:"results"`
+
+	ConfirmToken string     `json:"confirm_token,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+}
+
+// BatchLabels sets and removes labels on the matched containers. The labels
+// are stored right away; Docker gets them when the container is next
+// recreated, or now with ApplyNow.
+func (s *ContainerService) BatchLabels(ctx context.Context, actor model.Actor, req *LabelBatchRequest) (*LabelBatchResponse, error) {
+	if req == nil {
+		return nil, fmt.Errorf("label batch request cannot be nil")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	results, err := s.previewLabels(ctx, actor, req)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &LabelBatchResponse{ApplyNow: req.ApplyNow, Results: results}
+	for _, result := range results {
+		if result.container != nil {
+			response.Matched++
+		}
+		if result.Changed {
+			response.Changed++
+		}
+	}
+
+	digest := labelBatchDigest(req, results)
+	if req.ConfirmToken != "" {
+		if err := s.tokens.Verify(req.ConfirmToken, labelBatchOperation, actorUserID(actor), digest); err != nil {
+			return nil, err
+		}
+	} else if response.Changed > labelBatchConfirmThreshold {
+		token, expiresAt := s.tokens.Issue(labelBatchOperation, actorUserID(actor), digest)
+		response.ConfirmToken = token
+		response.ExpiresAt = &expiresAt
+		return response, nil
+	}
+
+	if response.Changed == 0 {
+		return response, nil
+	}
+
+	s.storeLabels(ctx, req, results)
+	if req.ApplyNow {
+		s.recreateForLabels(ctx, actor, results)
+	}
+	response.Applied = true
+
+	succeeded, recreated, failed := 0, 0, 0
+	for _, result := range results {
+		if !result.Changed {
+			continue
+		}
+		if result.Success {
+			succeeded++
+		} else {
+			failed++
+		}
+		if result.Recreated {
+			recreated++
+		}
+		s.cache.Delete(fmt.Sprintf("container:detail:%d", result.ContainerID))
+	}
+	s.invalidateContainerCache(actor)
+
+	s.logUserActivity(actor, "container_labels_batch", fmt.Sprintf("Labels changed on %d containers", response.Changed), map[string]interface{}{
+		"container_ids": req.ContainerIDs,
+		"filter":        req.Filter,
+		"operations":    req.Operations,
+		"apply_now":     req.ApplyNow,
+		"matched":       response.Matched,
+		"affected":      response.Changed,
+		"recreated":     recreated,
+		"failed":        failed,
+	})
+
+	logrus.WithFields(logrus.Fields{
+		"actor":     actor.String(),
+		"matched":   response.Matched,
+		"affected":  response.Changed,
+		"succeeded": succeeded,
+		"recreated": recreated,
+		"failed":    failed,
+	}).Info("Container label batch applied")
+
+	return response, nil
+}
+
+// previewLabels matches the containers and computes their new labels, in
+// stack start order
+func (s *ContainerService) previewLabels(ctx context.Context, actor model.Actor, req *LabelBatchRequest) ([]*LabelBatchResult, error) {
+	filter := &model.ContainerFilter{}
+	if req.Filter != nil {
+		filter.StackID = req.Filter.StackID
+		filter.Image = req.Filter.Image
+		filter.Status = req.Filter.Status
+	}
+	access, err := s.containerAccessFor(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	if access.none() {
+		return []*LabelBatchResult{}, nil
+	}
+	access.scope(filter)
+	containers, _, err := s.containerRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	selected := make(map[int64]bool, len(req.ContainerIDs))
+	for _, id := range req.ContainerIDs {
+		selected[id] = true
+	}
+
+	matched := make([]*model.Container, 0, len(containers))
+	for _, container := range containers {
+		if len(selected) > 0 && !selected[int64(container.ID)] {
+			continue
+		}
+		if !access.permission(container).Allows(model.ContainerPermissionManage) {
+			continue
+		}
+		delete(selected, int64(container.ID))
+		if req.Filter != nil && !req.Filter.matches(container) {
+			continue
+		}
+		matched = append(matched, container)
+	}
+	sortByStartOrder(matched)
+
+	results := make([]*LabelBatchResult, 0, len(matched)+len(selected))
+	for _, container := range matched {
+		result := &LabelBatchResult{ContainerID: int64(container.ID), Name: container.Name, container: container}
+
+		desired, err := desiredContainerState(container)
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+
+		labels := applyLabelOperations(desired.Labels, req.Operations)
+		result.Labels = labels
+		result.Changed = !labelsEqual(desired.Labels, labels)
+		result.Success = true
+		if !result.Changed {
+			result.Message = "labels unchanged"
+		}
+		results = append(results, result)
+	}
+
+	// Listed containers that don't exist, belong to someone else or fall
+	// outside the filter
+	missing := make([]int64, 0, len(selected))
+	for id := range selected {
+		missing = append(missing, id)
+	}
+	sort.Slice(missing, func(i, j int) bool { return missing[i] < missing[j] })
+	for _, id := range missing {
+		results = append(results, &LabelBatchResult{ContainerID: id, Error: "container not found or not matched by the filter"})
+	}
+
+	return results, nil
+}
+
+// storeLabels saves the new labels of the changed containers
+func (s *ContainerService) storeLabels(ctx context.Context, req *LabelBatchRequest, results []*LabelBatchResult) {
+	for _, result := range results {
+		if !result.Changed {
+			continue
+		}
+		container := result.container
+
+		config := make(map[string]json.RawMessage)
+		if container.ConfigJSON != "" {
+			if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
+				result.Success = false
+				result.Error = fmt.Sprintf("failed to parse container config: %v", err)
+				continue
+			}
+		}
+		labelsJSON, _ := json.Marshal(result.Labels)
+		config["labels"] = labelsJSON
+		configJSON, _ := json.Marshal(config)
+		container.ConfigJSON = string(configJSON)
+
+		if err := s.containerRepo.Update(ctx, container); err != nil {
+			result.Success = false
+			result.Error = fmt.Sprintf("failed to update container: %v", err)
+			continue
+		}
+
+		switch {
+		case container.ContainerID == "":
+			result.Message = "labels stored; applied when the container is created"
+		case !req.ApplyNow:
+			// The live container no longer matches until it is recreated
+			s.recordDrift(ctx, container, true)
+			result.PendingRecreate = true
+			result.Message = "labels stored; applied when the container is next recreated"
+		}
+	}
+}
+
+// recreateForLabels recreates the changed containers in stack start order.
+// Once a stack member fails, the members after it are left for their next
+// recreation so dependents don't restart without what they need.
+func (s *ContainerService) recreateForLabels(ctx context.Context, actor model.Actor, results []*LabelBatchResult) {
+	failedStacks := make(map[int]bool)
+
+	for _, result := range results {
+		container := result.container
+		if !result.Changed || !result.Success || container.ContainerID == "" {
+			continue
+		}
+
+		if container.StackID != nil && failedStacks[*container.StackID] {
+			s.recordDrift(ctx, container, true)
+			result.PendingRecreate = true
+			result.Message = "labels stored; recreation skipped because an earlier stack member failed"
+			continue
+		}
+
+		if err := s.recreateWithLabels(ctx, actor, container, result); err != nil {
+			s.recordDrift(ctx, container, true)
+			result.Success = false
+			result.PendingRecreate = true
+			result.Error = err.Error()
+			if container.StackID != nil {
+				failedStacks[*container.StackID] = true
+			}
+			continue
+		}
+
+		result.Recreated = true
+		result.Message = "labels applied"
+	}
+}
+
+// recreateWithLabels recreates one container from its stored configuration
+// and, if it was running, waits for its post-start sequence
+func (s *ContainerService) recreateWithLabels(ctx context.Context, actor model.Actor, container *model.Container, result *LabelBatchResult) error {
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return err
+	}
+
+	running, err := dc.IsContainerRunning(ctx, container.ContainerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+
+	warnings, err := s.recreateDockerContainer(ctx, dc, container, running)
+	result.Warnings = warnings
+	if err != nil {
+		return fmt.Errorf("failed to recreate container: %w", err)
+	}
+	s.recordDrift(ctx, container, false)
+
+	if running {
+		if _, err := s.RunPostStart(ctx, actor, container, container.ContainerID, model.PostStartTriggerRestart, 0); err != nil {
+			return err
+		}
+	}
+
+	s.logContainerActivity(actor, int64(container.ID), "container_recreated", "Container recreated to apply its labels", map[string]interface{}{
+		"docker_id": container.ContainerID,
+		"labels":    result.Labels,
+	})
+	s.cache.Delete(fmt.Sprintf("container:status:%d", container.ID))
+	return nil
+}
+
+// matches applies the filter parts the repository doesn't
+func (f *LabelBatchFilter) matches(container *model.Container) bool {
+	if f.NamePattern != "" {
+		if matched, _ := path.Match(f.NamePattern, container.Name); !matched {
+			return false
+		}
+	}
+	if f.Label != "" {
+		desired, err := desiredContainerState(container)
+		if err != nil {
+			return false
+		}
+		key, value, hasValue := strings.Cut(f.Label, "=")
+		current, present := desired.Labels[key]
+		if !present || (hasValue && current != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyLabelOperations returns labels with the operations applied in order
+func applyLabelOperations(labels map[string]string, operations []LabelOperation) map[string]string {
+	result := make(map[string]string, len(labels)+len(operations))
+	for key, value := range labels {
+		result[key] = value
+	}
+	for _, op := range operations {
+		switch op.Op {
+		case LabelOpSet:
+			result[op.Key] = op.Value
+		case LabelOpRemove:
+			delete(result, op.Key)
+		}
+	}
+	return result
+}
+
+func labelsEqual(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+// sortByStartOrder orders containers so stack members follow their stack's
+// start order; containers outside stacks come last, by ID
+func sortByStartOrder(containers []*model.Container) {
+	sort.SliceStable(containers, func(i, j int) bool {
+		a, b := containers[i], containers[j]
+		switch {
+		case a.StackID != nil && b.StackID == nil:
+			return true
+		case a.StackID == nil && b.StackID != nil:
+			return false
+		case a.StackID != nil && *a.StackID != *b.StackID:
+			return *a.StackID < *b.StackID
+		case a.StackID != nil && a.StackOrder != b.StackOrder:
+			return a.StackOrder < b.StackOrder
+		}
+		return a.ID < b.ID
+	})
+}
+
+// labelBatchDigest summarizes what a confirmation vouches for: the operations
+// and the labels each container ends up with
+func labelBatchDigest(req *LabelBatchRequest, results []*LabelBatchResult) string {
+	operations, _ := json.Marshal(req.Operations)
+
+	entries := make([]string, 0, len(results))
+	for _, result := range results {
+		if !result.Changed {
+			continue
+		}
+		labels, _ := json.Marshal(result.Labels)
+		entries = append(entries, fmt.Sprintf("%d:%s", result.ContainerID, labels))
+	}
+	sort.Strings(entries)
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		string(operations), fmt.Sprint(req.ApplyNow), strings.Join(entries, ","),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+)
+
+// storeLimits writes the resource limits of req into the container's stored
+// config, where recreates pick them up. It returns the resulting limits,
+// whether they changed and whether a limit was removed.
+func storeLimits(container *model.Container, req *dto.UpdateContainerRequest) (*docker.ResourceConfig, bool, bool, error) {
+	config := make(map[string]json.RawMessage)
+	if container.ConfigJSON != "" {
+		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
+			return nil, false, false, fmt.Errorf("failed to parse container config: %w", err)
+		}
+	}
+
+	resources := &docker.ResourceConfig{}
+	if raw, ok := config["resources"]; ok {
+		if err := json.Unmarshal(raw, resources); err != nil {
+			return nil, false, false, fmt.Errorf("failed to parse container resources: %w", err)
+		}
+		if resources == nil {
+			resources = &docker.ResourceConfig{}
+		}
+	}
+	before := *resources
+
+	if req.CPULimit != nil {
+		nanoCPUs, err := dto.CPULimitNanoCPUs(*req.CPULimit)
+		if err != nil {
+			return nil, false, false, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+		}
+		// The stored config keeps CPU limits as a CFS quota
+		resources.CPUQuota, resources.CPUPeriod = 0, 0
+		if nanoCPUs > 0 {
+			resources.CPUPeriod = defaultCPUPeriod
+			resources.CPUQuota = nanoCPUs * defaultCPUPeriod / 1e9
+		}
+	}
+	if req.MemoryLimit != nil {
+		resources.Memory = *req.MemoryLimit
+	}
+	if req.MemorySwap != nil {
+		resources.MemorySwap = *req.MemorySwap
+	}
+	if req.PidsLimit != nil {
+		resources.PidsLimit = *req.PidsLimit
+	}
+
+	if resources.MemorySwap > 0 {
+		if resources.Memory == 0 {
+			return nil, false, false, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: memory_swap requires a memory limit")
+		}
+		if resources.MemorySwap < resources.Memory {
+			return nil, false, false, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: memory_swap must not be smaller than memory_limit")
+		}
+	}
+
+	if resources.CPUQuota == before.CPUQuota && resources.CPUPeriod == before.CPUPeriod &&
+		resources.Memory == before.Memory && resources.MemorySwap == before.MemorySwap &&
+		resources.PidsLimit == before.PidsLimit {
+		return resources, false, false, nil
+	}
+	removed := (before.CPUQuota > 0 && resources.CPUQuota == 0) ||
+		(before.Memory > 0 && resources.Memory == 0) ||
+		(before.MemorySwap != 0 && resources.MemorySwap == 0) ||
+		(before.PidsLimit > 0 && resources.PidsLimit == 0)
+
+	resourcesJSON, err := json.Marshal(resources)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to marshal container resources: %w", err)
+	}
+	config["resources"] = resourcesJSON
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return nil, false, false, fmt.Errorf("failed to marshal config: %w", err)
+	}
+	container.ConfigJSON = string(configJSON)
+
+	return resources, true, removed, nil
+}
+
+// containerLimits returns the configured limits of a container and, when
+// its Docker container is known, the limits it currently has
+func containerLimits(container *model.Container, live *types.ContainerJSON) *dto.ContainerLimits {
+	limits := &dto.ContainerLimits{}
+	if desired, err := desiredContainerState(container); err == nil && desired.Resources != nil {
+		resources := desired.Resources
+		var nanoCPUs int64
+		if resources.CPUQuota > 0 {
+			period := resources.CPUPeriod
+			if period <= 0 {
+				period = defaultCPUPeriod
+			}
+			nanoCPUs = resources.CPUQuota * 1e9 / period
+		}
+		limits.Configured = dto.NewResourceLimits(nanoCPUs, resources.Memory, resources.MemorySwap, resources.PidsLimit)
+	}
+
+	if live == nil || live.HostConfig == nil {
+		return limits
+	}
+	actual := live.HostConfig.Resources
+	nanoCPUs := actual.NanoCPUs
+	if nanoCPUs == 0 && actual.CPUQuota > 0 {
+		period := actual.CPUPeriod
+		if period <= 0 {
+			period = defaultCPUPeriod
+		}
+		nanoCPUs = actual.CPUQuota * 1e9 / period
+	}
+	var pidsLimit int64
+	if actual.PidsLimit != nil && *actual.PidsLimit > 0 {
+		pidsLimit = *actual.PidsLimit
+	}
+	applied := dto.NewResourceLimits(nanoCPUs, actual.Memory, actual.MemorySwap, pidsLimit)
+	limits.Applied = &applied
+
+	// Docker fills in a swap limit when only memory is set
+	configured := limits.Configured
+	limits.Pending = configured.NanoCPUs != applied.NanoCPUs ||
+		configured.MemoryLimit != applied.MemoryLimit ||
+		configured.PidsLimit != applied.PidsLimit ||
+		(configured.MemorySwap != 0 && configured.MemorySwap != applied.MemorySwap)
+	return limits
+}
+
+// applyLimits sets the stored limits on the container's Docker container and
+// returns the daemon's warnings that are not ignored. Removed limits cannot be
+// lifted in place, so the container is flagged as drifted until it is next
+// recreated.
+func (s *ContainerService) applyLimits(ctx context.Context, container *model.Container, resources *docker.ResourceConfig, removed bool) ([]string, error) {
+	if container.ContainerID == "" {
+		return nil, nil
+	}
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+	daemonWarnings, err := dc.UpdateContainerLimits(ctx, container.ContainerID, resources)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply resource limits: %w", err)
+	}
+	warnings := s.RecordDaemonWarnings(ctx, container, daemonWarnings)
+	if removed {
+		s.recordDrift(ctx, container, true)
+	}
+	return warnings, nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+)
+
+// warningsRepo records the warnings stored for containers
+type warningsRepo struct {
+	repository.ContainerRepository
+	warnings model.StringList
+}
+
+func (r *warningsRepo) UpdateWarnings(ctx context.Context, id int64, warnings model.StringList) error {
+	r.warnings = warnings
+	return nil
+}
+
+// ignoredWarningsRepo serves the ignored daemon warnings setting
+type ignoredWarningsRepo struct {
+	repository.SystemConfigRepository
+	patterns []string
+}
+
+func (r *ignoredWarningsRepo) GetByKey(ctx context.Context, key string) (*model.SystemConfig, error) {
+	config := &model.SystemConfig{ConfigKey: key}
+	if err := config.SetValue(r.patterns); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+func TestApplyLimitsRecordsDaemonWarnings(t *testing.T) {
+	daemonWarnings := []string{
+		"Your kernel does not support swap limit capabilities or the cgroup is not mounted. Memory limited without swap.",
+		"Your kernel does not support CPU cfs period/quota or the cgroup is not mounted. Quota discarded.",
+	}
+
+	var update container.UpdateConfig
+	dc := newFakeDockerClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/containers/abc/json"):
+			json.NewEncoder(w).Encode(types.ContainerJSON{ContainerJSONBase: &types.ContainerJSONBase{
+				ID:         "abc",
+				State:      &types.ContainerState{Running: true},
+				HostConfig: &container.HostConfig{},
+			}})
+		case r.Method == http.MethodPost && strings.HasSuffix(r.URL.Path, "/containers/abc/update"):
+			json.NewDecoder(r.Body).Decode(&update)
+			json.NewEncoder(w).Encode(container.ContainerUpdateOKBody{Warnings: daemonWarnings})
+		default:
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+		}
+	})
+
+	cfg := &config.Config{}
+	cfg.Cache.CleanupIntervalMinutes = 1
+	cache := NewCacheService(cfg)
+	t.Cleanup(func() { cache.Stop() })
+	repo := &warningsRepo{}
+	s := &ContainerService{
+		containerRepo: repo,
+		configRepo:    &ignoredWarningsRepo{patterns: []string{"SWAP LIMIT"}},
+		dockerClient:  dc,
+		cache:         cache,
+		config:        cfg,
+	}
+	web := &model.Container{ID: 1, Name: "web", ContainerID: "abc"}
+
+	warnings, err := s.applyLimits(context.Background(), web, &docker.ResourceConfig{Memory: 256 << 20, CPUQuota: 50000}, false)
+	if err != nil {
+		t.Fatalf("applyLimits failed: %v", err)
+	}
+
+	if update.Memory != 256<<20 || update.CPUQuota != 50000 {
+		t.Errorf("daemon got memory %d and CPU quota %d, want %d and 50000", update.Memory, update.CPUQuota, 256<<20)
+	}
+	// The swap warning matches the ignore list case-insensitively
+	want := daemonWarnings[1:]
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+	if strings.Join(repo.warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("stored warnings = %q, want %q", repo.warnings, want)
+	}
+	if strings.Join(web.Warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("container warnings = %q, want %q", web.Warnings, want)
+	}
+}
@@ -0,0 +1,122 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// listInspectTimeout bounds the daemon round trip of a list page with
+	// include_health, across all hosts
+	listInspectTimeout = 3 * time.Second
+	// listInspectConcurrency caps the parallel inspects per daemon
+	listInspectConcurrency = 10
+)
+
+// pageLiveState is the inspected state of a list page, by container ID
+type pageLiveState struct {
+	clients map[int]*docker.DockerClient
+	live    map[int]*types.ContainerJSON
+	// stale containers' daemons did not answer in time or are unavailable
+	stale map[int]bool
+}
+
+// inspectPage inspects the page's containers in one batch per daemon. Only
+// the given containers are inspected, so the batch is bounded by the page
+// size, and every daemon shares listInspectTimeout.
+func (s *ContainerService) inspectPage(ctx context.Context, clients *dockerClients, containers []*model.Container) *pageLiveState {
+	state := &pageLiveState{
+		clients: make(map[int]*docker.DockerClient),
+		live:    make(map[int]*types.ContainerJSON),
+		stale:   make(map[int]bool),
+	}
+
+	groups := make(map[*docker.DockerClient][]*model.Container)
+	for _, container := range containers {
+		if container.ContainerID == "" {
+			continue
+		}
+		dc, err := clients.get(ctx, container)
+		if err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Debug("Docker host unavailable")
+			state.stale[container.ID] = true
+			continue
+		}
+		state.clients[container.ID] = dc
+		groups[dc] = append(groups[dc], container)
+	}
+	if len(groups) == 0 {
+		return state
+	}
+
+	inspectCtx, cancel := context.WithTimeout(ctx, listInspectTimeout)
+	defer cancel()
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for dc, group := range groups {
+		wg.Add(1)
+		go func(dc *docker.DockerClient, group []*model.Container) {
+			defer wg.Done()
+
+			ids := make([]string, len(group))
+			for i, container := range group {
+				ids[i] = container.ContainerID
+			}
+			config := docker.BulkOperationConfig{
+				MaxConcurrency:  min(len(ids), listInspectConcurrency),
+				ContinueOnError: true,
+			}
+			inspected, _ := dc.BulkInspectContainers(inspectCtx, ids, config)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, container := range group {
+				if live, ok := inspected[container.ContainerID]; ok {
+					state.live[container.ID] = live
+				} else if inspectCtx.Err() != nil {
+					state.stale[container.ID] = true
+				}
+			}
+		}(dc, group)
+	}
+	wg.Wait()
+
+	if len(state.stale) > 0 {
+		logrus.WithField("stale", len(state.stale)).Debug("Listed containers with last synced status")
+	}
+	return state
+}
+
+// apply merges a container's live state into its summary, falling back to
+// the last synced status when its daemon did not answer
+func (p *pageLiveState) apply(summary *dto.ContainerSummary, container *model.Container, now time.Time) {
+	if p.stale[container.ID] {
+		summary.DockerStatus = string(container.Status)
+		summary.Stale = true
+		return
+	}
+
+	live, ok := p.live[container.ID]
+	if !ok {
+		return
+	}
+	status, ports := p.clients[container.ID].StatusAndPorts(live)
+	summary.DockerStatus = string(status)
+	summary.PublishedPorts = docker.FormatPublishedPorts(ports)
+	summary.RestartCount = live.RestartCount
+	summary.UptimeSeconds = int64(runningFor(live, now).Seconds())
+
+	summary.Health = types.NoHealthcheck
+	if live.State != nil && live.State.Health != nil {
+		summary.Health = live.State.Health.Status
+	}
+}
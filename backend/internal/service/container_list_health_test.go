@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// listPageRepo serves a page of the stored containers
+type listPageRepo struct {
+	repository.ContainerRepository
+	containers []*model.Container
+}
+
+func (r *listPageRepo) List(ctx context.Context, filter *model.ContainerFilter) ([]*model.Container, int64, error) {
+	start := min(filter.Offset, len(r.containers))
+	end := min(start+filter.Limit, len(r.containers))
+	return r.containers[start:end], int64(len(r.containers)), nil
+}
+
+// inspectDaemon answers container inspects after delay. With barrier set,
+// inspects also wait until that many are in flight, so only a parallel batch
+// gets past it in time; once one times out the barrier is lifted.
+type inspectDaemon struct {
+	delay   time.Duration
+	barrier int
+
+	mu          sync.Mutex
+	inFlight    int
+	maxInFlight int
+	inspected   map[string]int
+	unexpected  []string
+	full        chan struct{}
+	lift        sync.Once
+}
+
+func newInspectDaemon(delay time.Duration, barrier int) *inspectDaemon {
+	return &inspectDaemon{delay: delay, barrier: barrier, inspected: make(map[string]int), full: make(chan struct{})}
+}
+
+func (d *inspectDaemon) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	_, rest, found := strings.Cut(r.URL.Path, "/containers/")
+	id, inspect := strings.CutSuffix(rest, "/json")
+	if r.Method != http.MethodGet || !found || !inspect {
+		d.mu.Lock()
+		d.unexpected = append(d.unexpected, r.Method+" "+r.URL.Path)
+		d.mu.Unlock()
+		http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+		return
+	}
+
+	d.mu.Lock()
+	d.inFlight++
+	d.maxInFlight = max(d.maxInFlight, d.inFlight)
+	d.inspected[id]++
+	if d.inFlight == d.barrier {
+		d.lift.Do(func() { close(d.full) })
+	}
+	d.mu.Unlock()
+
+	if d.barrier > 0 {
+		select {
+		case <-d.full:
+		case <-time.After(listInspectTimeout):
+			d.lift.Do(func() { close(d.full) })
+		}
+	}
+	time.Sleep(d.delay)
+
+	d.mu.Lock()
+	d.inFlight--
+	d.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(types.ContainerJSON{
+		ContainerJSONBase: &types.ContainerJSONBase{
+			ID: id,
+			State: &types.ContainerState{
+				Status:    "running",
+				Running:   true,
+				StartedAt: time.Now().Add(-time.Hour).Format(time.RFC3339Nano),
+				Health:    &types.Health{Status: types.Healthy},
+			},
+			RestartCount: 2,
+		},
+	})
+}
+
+func (d *inspectDaemon) inspects() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	total := 0
+	for _, n := range d.inspected {
+		total += n
+	}
+	return total
+}
+
+// newListTestService lists rows containers, all created in the daemon but
+// the first
+func newListTestService(tb testing.TB, rows int, daemon *inspectDaemon) *ContainerService {
+	containers := make([]*model.Container, rows)
+	for i := range containers {
+		containers[i] = &model.Container{
+			ID:          i + 1,
+			Name:        fmt.Sprintf("app-%d", i+1),
+			Image:       "nginx",
+			Status:      model.ContainerStatusStopped,
+			ContainerID: fmt.Sprintf("%064x", i+1),
+		}
+	}
+	containers[0].ContainerID = ""
+
+	dc := newFakeDockerClient(tb, daemon.ServeHTTP)
+	return &ContainerService{containerRepo: &listPageRepo{containers: containers}, dockerClient: dc}
+}
+
+func TestListContainersInspectsPageInOneParallelBatch(t *testing.T) {
+	ctx := context.Background()
+	const pageSize = 20
+
+	daemon := newInspectDaemon(0, listInspectConcurrency)
+	s := newListTestService(t, 2*pageSize, daemon)
+
+	start := time.Now()
+	resp, err := s.ListContainers(ctx, model.SystemActor("test"), &dto.ContainerFilter{
+		ContainerFilter: &model.ContainerFilter{Limit: pageSize},
+		IncludeHealth:   true,
+	})
+	if err != nil {
+		t.Fatalf("ListContainers failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= listInspectTimeout {
+		t.Fatalf("listing took %v; the inspects did not run as one parallel batch", elapsed)
+	}
+
+	// Each created container of the page is inspected once, nothing else
+	// is asked
+	if len(daemon.unexpected) > 0 {
+		t.Errorf("unexpected daemon requests: %v", daemon.unexpected)
+	}
+	if daemon.inspects() != pageSize-1 || len(daemon.inspected) != pageSize-1 {
+		t.Errorf("%d inspects of %d containers, want one per created container of the page", daemon.inspects(), len(daemon.inspected))
+	}
+	if daemon.maxInFlight != listInspectConcurrency {
+		t.Errorf("at most %d inspects in flight, want %d", daemon.maxInFlight, listInspectConcurrency)
+	}
+
+	if len(resp.Containers) != pageSize {
+		t.Fatalf("listed %d containers, want %d", len(resp.Containers), pageSize)
+	}
+	if notCreated := resp.Containers[0]; notCreated.DockerStatus != "" || notCreated.Stale {
+		t.Errorf("container not yet created = %+v, want no live state", notCreated)
+	}
+	for _, summary := range resp.Containers[1:] {
+		if n := daemon.inspected[fmt.Sprintf("%064x", summary.ID)]; n != 1 {
+			t.Errorf("container %d inspected %d times", summary.ID, n)
+		}
+		if summary.Stale || summary.DockerStatus != string(model.ContainerStatusRunning) || summary.Health != types.Healthy ||
+			summary.RestartCount != 2 || summary.UptimeSeconds < 3500 {
+			t.Errorf("container %d = %+v, want its live state", summary.ID, summary)
+		}
+	}
+}
+
+// BenchmarkListContainersIncludeHealth lists a page of 50 from a daemon
+// answering each inspect in 2ms, per row and with include_health
+func BenchmarkListContainersIncludeHealth(b *testing.B) {
+	ctx := context.Background()
+	const pageSize = 50
+
+	// Every batch logs its completion
+	level := logrus.GetLevel()
+	logrus.SetLevel(logrus.WarnLevel)
+	b.Cleanup(func() { logrus.SetLevel(level) })
+
+	for _, includeHealth := range []bool{false, true} {
+		b.Run(fmt.Sprintf("include_health=%t", includeHealth), func(b *testing.B) {
+			daemon := newInspectDaemon(2*time.Millisecond, 0)
+			s := newListTestService(b, pageSize, daemon)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				_, err := s.ListContainers(ctx, model.SystemActor("bench"), &dto.ContainerFilter{
+					ContainerFilter: &model.ContainerFilter{Limit: pageSize},
+					IncludeHealth:   includeHealth,
+				})
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+
+			b.ReportMetric(float64(daemon.inspects())/float64(b.N), "inspects/op")
+			b.ReportMetric(float64(daemon.maxInFlight), "max-in-flight")
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+const (
+	// DefaultLogDownloadBytes caps a log download when no max_bytes is given
+	DefaultLogDownloadBytes = 10 << 20
+
+	// MaxLogDownloadBytes is the largest max_bytes a log download accepts
+	MaxLogDownloadBytes = 500 << 20
+)
+
+// LogDownload is a prepared container log download. The log in the range is
+// measured first, so the response headers can announce truncation before
+// the body is streamed; the range is fixed then, so both reads see the same
+// log even as the container keeps writing.
+type LogDownload struct {
+	ContainerName string
+	Since         time.Time
+	Until         time.Time
+	MaxBytes      int64
+	// TotalBytes is the size of the log in the range, after redaction
+	TotalBytes int64
+
+	dc       *docker.DockerClient
+	dockerID string
+	options  types.ContainerLogsOptions
+	redactor *docker.LogRedactor
+}
+
+// Truncated reports whether the oldest part of the log is left out
+func (d *LogDownload) Truncated() bool {
+	return d.TotalBytes > d.MaxBytes
+}
+
+// Filename returns the download name of the log, e.g.
+// "web_20260101T000000Z_20260102T120000Z.log"; a log without a start time
+// is named from "start"
+func (d *LogDownload) Filename() string {
+	since := "start"
+	if !d.Since.IsZero() {
+		since = d.Since.UTC().Format("20060102T150405Z")
+	}
+	return fmt.Sprintf("%s_%s_%s.log", d.ContainerName, since, d.Until.UTC().Format("20060102T150405Z"))
+}
+
+// PrepareLogDownload checks access to the container's logs and measures the
+// log between options.Since and options.Until, which defaults to now.
+// Docker reads rotated log files too, so the range may reach back past the
+// current file.
+func (s *ContainerService) PrepareLogDownload(ctx context.Context, actor model.Actor, containerID int64, options *dto.LogOptions, maxBytes int64) (*LogDownload, error) {
+	if maxBytes <= 0 || maxBytes > MaxLogDownloadBytes {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: max_bytes must be between 1 and %d", MaxLogDownloadBytes)
+	}
+	if options == nil {
+		options = &dto.LogOptions{Timestamps: true}
+	}
+	until := options.Until
+	if until.IsZero() {
+		until = time.Now()
+	}
+	if !options.Since.IsZero() && !options.Since.Before(until) {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: since must be before until")
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	if container.ContainerID == "" {
+		return nil, ErrContainerNotCreated
+	}
+
+	redactor, err := s.containerLogRedactor(ctx, container, actor, options.Raw)
+	if err != nil {
+		return nil, err
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	download := &LogDownload{
+		ContainerName: container.Name,
+		Since:         options.Since,
+		Until:         until,
+		MaxBytes:      maxBytes,
+		dc:            dc,
+		dockerID:      container.ContainerID,
+		options: types.ContainerLogsOptions{
+			ShowStdout: true,
+			ShowStderr: true,
+			Timestamps: options.Timestamps,
+			Until:      until.Format(time.RFC3339Nano),
+		},
+		redactor: redactor,
+	}
+	if !options.Since.IsZero() {
+		download.options.Since = options.Since.Format(time.RFC3339Nano)
+	}
+
+	counter := &countingWriter{}
+	if err := download.copyTo(ctx, counter); err != nil {
+		return nil, err
+	}
+	download.TotalBytes = counter.n
+
+	return download, nil
+}
+
+// WriteLogDownload streams the prepared log to w. A truncated log starts with
+// a marker line and continues with the newest lines that fit in MaxBytes.
+func (s *ContainerService) WriteLogDownload(ctx context.Context, w io.Writer, download *LogDownload) error {
+	if !download.Truncated() {
+		return download.copyTo(ctx, &limitWriter{w: w, remaining: download.MaxBytes})
+	}
+
+	marker := fmt.Sprintf("[docker-auto] log truncated: showing at most the newest %d of %d bytes, older lines omitted\n",
+		download.MaxBytes, download.TotalBytes)
+	if _, err := io.WriteString(w, marker); err != nil {
+		return err
+	}
+
+	tail := &tailWriter{
+		w:    &limitWriter{w: w, remaining: download.MaxBytes},
+		skip: download.TotalBytes - download.MaxBytes,
+	}
+	return download.copyTo(ctx, tail)
+}
+
+// copyTo reads the log range from Docker and writes it to w, redacted line
+// by line
+func (d *LogDownload) copyTo(ctx context.Context, w io.Writer) error {
+	reader, err := d.dc.GetContainerLogs(ctx, d.dockerID, d.options)
+	if err != nil {
+		return fmt.Errorf("failed to get container logs: %w", err)
+	}
+	defer reader.Close()
+
+	// stdout and stderr frames interleave, so each keeps its own partial line
+	stdout := docker.NewRedactingWriter(w, d.redactor)
+	stderr := docker.NewRedactingWriter(w, d.redactor)
+
+	_, copyErr := stdcopy.StdCopy(stdout, stderr, reader)
+	if err := stdout.Flush(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if err := stderr.Flush(); err != nil && copyErr == nil {
+		copyErr = err
+	}
+	if copyErr != nil && !errors.Is(copyErr, errLogLimitReached) {
+		return fmt.Errorf("failed to read container logs: %w", copyErr)
+	}
+	return nil
+}
+
+// errLogLimitReached stops a log copy once the download is full
+var errLogLimitReached = errors.New("log download limit reached")
+
+// countingWriter counts and discards what is written to it
+type countingWriter struct {
+	n int64
+}
+
+func (cw *countingWriter) Write(p []byte) (int, error) {
+	cw.n += int64(len(p))
+	return len(p), nil
+}
+
+// limitWriter passes through at most remaining bytes, then stops the copy.
+// It only cuts a log that grew between measuring and writing.
+type limitWriter struct {
+	w         io.Writer
+	remaining int64
+}
+
+func (lw *limitWriter) Write(p []byte) (int, error) {
+	if int64(len(p)) <= lw.remaining {
+		n, err := lw.w.Write(p)
+		lw.remaining -= int64(n)
+		return n, err
+	}
+
+	n, err := lw.w.Write(p[:lw.remaining])
+	lw.remaining -= int64(n)
+	if err != nil {
+		return n, err
+	}
+	return n, errLogLimitReached
+}
+
+// tailWriter drops the first skip bytes and the rest of the line they end
+// in, then passes everything through, so the output starts at a whole line
+type tailWriter struct {
+	w    io.Writer
+	skip int64
+	// atLineStart is whether the last dropped byte ended a line
+	atLineStart bool
+	started     bool
+}
+
+func (tw *tailWriter) Write(p []byte) (int, error) {
+	if tw.started {
+		return tw.w.Write(p)
+	}
+
+	written := len(p)
+	if tw.skip >= int64(len(p)) {
+		tw.skip -= int64(len(p))
+		if len(p) > 0 {
+			tw.atLineStart = p[len(p)-1] == '\n'
+		}
+		return written, nil
+	}
+	if tw.skip > 0 {
+		tw.atLineStart = p[tw.skip-1] == '\n'
+		p = p[tw.skip:]
+		tw.skip = 0
+	}
+
+	if !tw.atLineStart {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			return written, nil
+		}
+		p = p[i+1:]
+	}
+	tw.started = true
+	if _, err := tw.w.Write(p); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
@@ -0,0 +1,331 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxMetricPoints bounds the points of a metrics series; longer ranges
+	// get a larger step
+	maxMetricPoints = 1000
+	// defaultMetricsWindow is the range of a metrics query without from
+	defaultMetricsWindow = time.Hour
+)
+
+// CollectContainerMetrics samples the stats of running managed containers
+// every collection interval and stores one averaged row per container each
+// resolution period, until ctx is done
+func (s *ContainerService) CollectContainerMetrics(ctx context.Context) {
+	interval := time.Duration(s.config.Monitoring.ContainerMetricsIntervalSeconds) * time.Second
+	if s.metricRepo == nil || interval <= 0 {
+		return
+	}
+	resolution := s.metricsResolution()
+	if resolution < interval {
+		resolution = interval
+	}
+
+	collector := newMetricsCollector()
+	period := time.Now().Truncate(resolution)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			s.storeContainerMetrics(collector, period)
+			return
+		case now := <-ticker.C:
+			if now.Sub(period) >= resolution {
+				s.storeContainerMetrics(collector, period)
+				period = now.Truncate(resolution)
+			}
+			s.sampleContainerMetrics(ctx, collector)
+		}
+	}
+}
+
+// sampleContainerMetrics adds a stats sample of every running managed
+// container to the collector, querying each host in one bulk call
+func (s *ContainerService) sampleContainerMetrics(ctx context.Context, collector *metricsCollector) {
+	containers, err := s.containerRepo.GetByStatus(ctx, model.ContainerStatusRunning)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list running containers for metrics")
+		return
+	}
+
+	clients := s.newDockerClients()
+	byHost := make(map[*docker.DockerClient][]*model.Container)
+	for _, container := range containers {
+		if container.ContainerID == "" {
+			continue
+		}
+		dc, err := clients.get(ctx, container)
+		if err != nil || dc == nil {
+			continue
+		}
+		byHost[dc] = append(byHost[dc], container)
+	}
+
+	for dc, hostContainers := range byHost {
+		ids := make([]string, len(hostContainers))
+		for i, container := range hostContainers {
+			ids[i] = container.ContainerID
+		}
+
+		stats, results := dc.BulkGetContainerStats(ctx, ids, docker.DefaultBulkConfig())
+		for _, result := range results {
+			if !result.Success {
+				logrus.WithField("container_id", result.ContainerID).Debugf("Failed to sample container stats: %s", result.Error)
+			}
+		}
+		for _, container := range hostContainers {
+			if sample, ok := stats[container.ContainerID]; ok && sample != nil {
+				collector.add(container.ID, sample)
+			}
+		}
+	}
+}
+
+// storeContainerMetrics writes the rows of the period collected so far and
+// starts the next period
+func (s *ContainerService) storeContainerMetrics(collector *metricsCollector, period time.Time) {
+	metrics := collector.flush(period)
+	if len(metrics) == 0 {
+		return
+	}
+
+	// Written on shutdown too, after ctx is done
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.metricRepo.CreateBatch(ctx, metrics); err != nil {
+		logrus.WithError(err).Warn("Failed to store container metrics")
+	}
+}
+
+// metricsResolution returns the period each stored metrics row covers
+func (s *ContainerService) metricsResolution() time.Duration {
+	seconds := s.config.Monitoring.ContainerMetricsResolutionSeconds
+	if seconds < 1 {
+		seconds = 60
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// GetContainerMetricsHistory returns the stats history of a container over
+// the query's range, aggregated by the database into at most
+// maxMetricPoints steps
+func (s *ContainerService) GetContainerMetricsHistory(ctx context.Context, actor model.Actor, containerID int64, query *dto.ContainerMetricsQuery) (*dto.ContainerMetricsSeries, error) {
+	if s.metricRepo == nil {
+		return nil, fmt.Errorf("container metrics are not collected")
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	to := time.Now()
+	if query.To != "" {
+		t, day, err := parseReportTime(query.To)
+		if err != nil {
+			return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: to: %w", err)
+		}
+		if day {
+			t = t.AddDate(0, 0, 1)
+		}
+		to = t
+	}
+	from := to.Add(-defaultMetricsWindow)
+	if query.From != "" {
+		t, _, err := parseReportTime(query.From)
+		if err != nil {
+			return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: from: %w", err)
+		}
+		from = t
+	}
+	if !from.Before(to) {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: from must be before to")
+	}
+
+	step, err := metricsStep(query.Step, to.Sub(from), s.metricsResolution())
+	if err != nil {
+		return nil, err
+	}
+
+	buckets, err := s.metricRepo.Aggregate(ctx, container.ID, from, to, step)
+	if err != nil {
+		return nil, err
+	}
+
+	return alignMetricBuckets(containerID, from, to, step, buckets), nil
+}
+
+// metricsStep parses the requested step, a duration or a number of seconds,
+// and raises it to a multiple of the resolution that keeps the range within
+// maxMetricPoints
+func metricsStep(value string, span, resolution time.Duration) (time.Duration, error) {
+	step := resolution
+	if value != "" {
+		if seconds, err := strconv.Atoi(value); err == nil {
+			step = time.Duration(seconds) * time.Second
+		} else if step, err = time.ParseDuration(value); err != nil {
+			return 0, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: step: expected a duration or seconds, got %q", value)
+		}
+		if step <= 0 {
+			return 0, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: step must be positive")
+		}
+	}
+
+	// One point more than the range divides into, as the first step starts
+	// before from
+	if minimum := span / (maxMetricPoints - 1); step < minimum {
+		step = minimum
+	}
+	if step%resolution != 0 {
+		step = (step/resolution + 1) * resolution
+	}
+	return step, nil
+}
+
+// alignMetricBuckets lays the buckets out on every step of [from, to),
+// leaving steps without metrics null
+func alignMetricBuckets(containerID int64, from, to time.Time, step time.Duration, buckets []*model.ContainerMetricBucket) *dto.ContainerMetricsSeries {
+	seconds := int64(step / time.Second)
+	byStart := make(map[int64]*model.ContainerMetricBucket, len(buckets))
+	for _, bucket := range buckets {
+		byStart[bucket.Start] = bucket
+	}
+
+	series := &dto.ContainerMetricsSeries{
+		ContainerID: containerID,
+		From:        from,
+		To:          to,
+		StepSeconds: int(seconds),
+	}
+	for start := from.Unix() / seconds * seconds; start < to.Unix(); start += seconds {
+		series.Timestamps = append(series.Timestamps, time.Unix(start, 0).UTC())
+		bucket, ok := byStart[start]
+		if !ok {
+			series.CPUPercent = append(series.CPUPercent, nil)
+			series.MemoryUsage = append(series.MemoryUsage, nil)
+			series.NetworkRx = append(series.NetworkRx, nil)
+			series.NetworkTx = append(series.NetworkTx, nil)
+			series.DiskRead = append(series.DiskRead, nil)
+			series.DiskWrite = append(series.DiskWrite, nil)
+			continue
+		}
+		series.CPUPercent = append(series.CPUPercent, &bucket.CPUPercent)
+		series.MemoryUsage = append(series.MemoryUsage, &bucket.MemoryUsage)
+		series.NetworkRx = append(series.NetworkRx, &bucket.NetworkRxBytes)
+		series.NetworkTx = append(series.NetworkTx, &bucket.NetworkTxBytes)
+		series.DiskRead = append(series.DiskRead, &bucket.BlockReadBytes)
+		series.DiskWrite = append(series.DiskWrite, &bucket.BlockWriteBytes)
+	}
+	return series
+}
+
+// metricsCollector accumulates the stats samples of each container over the
+// current period. Network and disk counters are cumulative in Docker; the
+// collector keeps the last values across periods to store the difference.
+type metricsCollector struct {
+	containers map[int]*containerMetricState
+}
+
+type containerMetricState struct {
+	samples     int
+	cpuSum      float64
+	memorySum   int64
+	memoryLimit int64
+	deltas      [4]int64
+	counters    [4]uint64
+	seen        bool // counters hold a previous sample
+}
+
+func newMetricsCollector() *metricsCollector {
+	return &metricsCollector{containers: make(map[int]*containerMetricState)}
+}
+
+// add records a stats sample of the container
+func (c *metricsCollector) add(containerID int, stats *types.StatsJSON) {
+	state, ok := c.containers[containerID]
+	if !ok {
+		state = &containerMetricState{}
+		c.containers[containerID] = state
+	}
+
+	state.samples++
+	state.cpuSum += calculateCPUPercent(stats)
+	state.memorySum += int64(stats.MemoryStats.Usage)
+	if limit := int64(stats.MemoryStats.Limit); limit > state.memoryLimit {
+		state.memoryLimit = limit
+	}
+
+	var counters [4]uint64
+	for _, network := range stats.Networks {
+		counters[0] += network.RxBytes
+		counters[1] += network.TxBytes
+	}
+	for _, bio := range stats.BlkioStats.IoServiceBytesRecursive {
+		switch bio.Op {
+		case "Read", "read":
+			counters[2] += bio.Value
+		case "Write", "write":
+			counters[3] += bio.Value
+		}
+	}
+	if state.seen {
+		for i, value := range counters {
+			// Counters start over when the container restarts
+			if value >= state.counters[i] {
+				state.deltas[i] += int64(value - state.counters[i])
+			} else {
+				state.deltas[i] += int64(value)
+			}
+		}
+	}
+	state.counters = counters
+	state.seen = true
+}
+
+// flush returns a row for every container sampled in the period starting at
+// period and resets the sums. Containers not sampled are forgotten.
+func (c *metricsCollector) flush(period time.Time) []*model.ContainerMetric {
+	metrics := make([]*model.ContainerMetric, 0, len(c.containers))
+	for containerID, state := range c.containers {
+		if state.samples == 0 {
+			delete(c.containers, containerID)
+			continue
+		}
+
+		metrics = append(metrics, &model.ContainerMetric{
+			ContainerID:     containerID,
+			SampledAt:       period,
+			Samples:         state.samples,
+			CPUPercent:      state.cpuSum / float64(state.samples),
+			MemoryUsage:     state.memorySum / int64(state.samples),
+			MemoryLimit:     state.memoryLimit,
+			NetworkRxBytes:  state.deltas[0],
+			NetworkTxBytes:  state.deltas[1],
+			BlockReadBytes:  state.deltas[2],
+			BlockWriteBytes: state.deltas[3],
+		})
+		*state = containerMetricState{counters: state.counters, seen: state.seen}
+	}
+	return metrics
+}
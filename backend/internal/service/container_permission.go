@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/internal/dto"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+)
+
+// checkContainerPermission checks that the actor holds the required
+// permission on the container
+func (s *ContainerService) checkContainerPermission(ctx context.Context, container *model.Container, actor model.Actor, required model.ContainerPermissionLevel) error {
+	_, err := s.authorizeContainer(ctx, container, actor, required)
+	return err
+}
+
+// authorizeContainer returns the actor's permission on the container once it
+// includes required
+func (s *ContainerService) authorizeContainer(ctx context.Context, container *model.Container, actor model.Actor, required model.ContainerPermissionLevel) (model.ContainerPermissionLevel, error) {
+	level, err := s.containerPermission(ctx, container, actor)
+	if err != nil {
+		return "", err
+	}
+	if level == "" {
+		return "", apperrors.Newf(apperrors.CodePermissionDenied, "access denied: container belongs to different user")
+	}
+	if !level.Allows(required) {
+		return "", apperrors.Newf(apperrors.CodePermissionDenied, "access denied: %s permission on the container is required", required)
+	}
+	return level, nil
+}
+
+// containerPermission returns the actor's effective permission on the
+// container: manage for system components, admins and its creator, else the
+// highest grant to the user or their role
+func (s *ContainerService) containerPermission(ctx context.Context, container *model.Container, actor model.Actor) (model.ContainerPermissionLevel, error) {
+	if actor.IsSystem() {
+		return model.ContainerPermissionManage, nil
+	}
+	if actor.UserID == nil {
+		return "", nil
+	}
+	if container.CreatedBy != nil && actor.IsUser(int64(*container.CreatedBy)) {
+		return model.ContainerPermissionManage, nil
+	}
+
+	user, err := s.permissionUser(ctx, actor)
+	if err != nil {
+		return "", err
+	}
+	if user.IsAdmin() {
+		return model.ContainerPermissionManage, nil
+	}
+	if s.permissionRepo == nil {
+		return "", nil
+	}
+
+	grants, err := s.permissionRepo.ListByContainer(ctx, container.ID)
+	if err != nil {
+		return "", err
+	}
+	var level model.ContainerPermissionLevel
+	for _, grant := range grants {
+		if grant.Grants(int(user.ID), user.Role) {
+			level = level.Max(grant.Level)
+		}
+	}
+	return level, nil
+}
+
+// permissionUser returns the user an actor acts for, or a user without role
+// when users cannot be looked up
+func (s *ContainerService) permissionUser(ctx context.Context, actor model.Actor) (*model.User, error) {
+	if s.userService == nil {
+		return &model.User{ID: *actor.UserID}, nil
+	}
+	user, err := s.userService.GetUserByID(ctx, *actor.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	return user, nil
+}
+
+// containerAccess is what an actor may do across containers, for listings
+type containerAccess struct {
+	all    bool // system components and admins manage every container
+	user   *model.User
+	grants map[int]model.ContainerPermissionLevel
+}
+
+// containerAccessFor loads the actor's permissions across containers. Actors
+// without a user, such as configured API keys, get none.
+func (s *ContainerService) containerAccessFor(ctx context.Context, actor model.Actor) (*containerAccess, error) {
+	if actor.IsSystem() {
+		return &containerAccess{all: true}, nil
+	}
+	if actor.UserID == nil {
+		return &containerAccess{}, nil
+	}
+
+	user, err := s.permissionUser(ctx, actor)
+	if err != nil {
+		return nil, err
+	}
+	access := &containerAccess{all: user.IsAdmin(), user: user, grants: make(map[int]model.ContainerPermissionLevel)}
+	if access.all || s.permissionRepo == nil {
+		return access, nil
+	}
+
+	grants, err := s.permissionRepo.ListForUser(ctx, int(user.ID), user.Role)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		access.grants[grant.ContainerID] = access.grants[grant.ContainerID].Max(grant.Level)
+	}
+	return access, nil
+}
+
+// none reports whether the actor may see no container at all
+func (a *containerAccess) none() bool {
+	return !a.all && a.user == nil
+}
+
+// scope restricts filter to the containers the actor may see
+func (a *containerAccess) scope(filter *model.ContainerFilter) {
+	if a.all || a.user == nil {
+		return
+	}
+	userID := int(a.user.ID)
+	filter.AccessibleBy = &userID
+	filter.AccessibleRole = a.user.Role
+}
+
+// permission returns the actor's permission on a container
+func (a *containerAccess) permission(container *model.Container) model.ContainerPermissionLevel {
+	switch {
+	case a.all:
+		return model.ContainerPermissionManage
+	case a.user == nil:
+		return ""
+	case container.CreatedBy != nil && *container.CreatedBy == int(a.user.ID):
+		return model.ContainerPermissionManage
+	}
+	return a.grants[container.ID]
+}
+
+// ListContainerPermissions returns the grants on a container
+func (s *ContainerService) ListContainerPermissions(ctx context.Context, actor model.Actor, containerID int64) ([]*model.ContainerPermission, error) {
+	container, err := s.permissionContainer(ctx, actor, containerID)
+	if err != nil {
+		return nil, err
+	}
+	return s.permissionRepo.ListByContainer(ctx, container.ID)
+}
+
+// GrantContainerPermission grants a user, or every user of a role, a
+// permission on a container, replacing their earlier grant. Sharing requires
+// manage.
+func (s *ContainerService) GrantContainerPermission(ctx context.Context, actor model.Actor, containerID int64, req *dto.GrantContainerPermissionRequest) (*model.ContainerPermission, error) {
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	container, err := s.permissionContainer(ctx, actor, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.UserID != nil && s.userService != nil {
+		if _, err := s.userService.GetUserByID(ctx, int64(*req.UserID)); err != nil {
+			return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: user %d not found", *req.UserID)
+		}
+	}
+	if req.Role != "" && s.userService != nil && !s.userService.isValidRole(ctx, string(req.Role)) {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: role %s not found", req.Role)
+	}
+	if isContainerCreator(container, req.UserID) && req.Permission != model.ContainerPermissionManage {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: the creator of a container keeps manage")
+	}
+
+	permission := &model.ContainerPermission{
+		ContainerID: container.ID,
+		UserID:      req.UserID,
+		Role:        req.Role,
+		Level:       req.Permission,
+		GrantedBy:   actor.OwnerID(),
+	}
+	if err := s.permissionRepo.Grant(ctx, permission); err != nil {
+		return nil, err
+	}
+
+	s.logContainerActivity(actor, containerID, "container_permission_granted",
+		fmt.Sprintf("Granted %s on container %s to %s", permission.Level, container.Name, permissionSubject(req.UserID, req.Role)),
+		map[string]interface{}{
+			"user_id":    req.UserID,
+			"role":       req.Role,
+			"permission": permission.Level,
+		})
+	return permission, nil
+}
+
+// RevokeContainerPermission removes the grant to a user or role on a
+// container. The creator's manage cannot be revoked.
+func (s *ContainerService) RevokeContainerPermission(ctx context.Context, actor model.Actor, containerID int64, query *dto.RevokeContainerPermissionQuery) error {
+	if err := query.Validate(); err != nil {
+		return apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	container, err := s.permissionContainer(ctx, actor, containerID)
+	if err != nil {
+		return err
+	}
+	if isContainerCreator(container, query.UserID) {
+		return apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: the creator of a container keeps manage")
+	}
+
+	revoked, err := s.permissionRepo.Revoke(ctx, container.ID, query.UserID, query.Role)
+	if err != nil {
+		return err
+	}
+	if !revoked {
+		return fmt.Errorf("permission not found")
+	}
+
+	s.logContainerActivity(actor, containerID, "container_permission_revoked",
+		fmt.Sprintf("Revoked the permission of %s on container %s", permissionSubject(query.UserID, query.Role), container.Name),
+		map[string]interface{}{
+			"user_id": query.UserID,
+			"role":    query.Role,
+		})
+	return nil
+}
+
+// permissionContainer loads a container whose grants the actor may change
+func (s *ContainerService) permissionContainer(ctx context.Context, actor model.Actor, containerID int64) (*model.Container, error) {
+	if s.permissionRepo == nil {
+		return nil, fmt.Errorf("container sharing is not available")
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionManage); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// grantCreatorPermission records the creator's manage on a new container
+func (s *ContainerService) grantCreatorPermission(ctx context.Context, container *model.Container) error {
+	if s.permissionRepo == nil || container.CreatedBy == nil {
+		return nil
+	}
+	return s.permissionRepo.Grant(ctx, &model.ContainerPermission{
+		ContainerID: container.ID,
+		UserID:      container.CreatedBy,
+		Level:       model.ContainerPermissionManage,
+		GrantedBy:   container.CreatedBy,
+	})
+}
+
+func isContainerCreator(container *model.Container, userID *int) bool {
+	return userID != nil && container.CreatedBy != nil && *userID == *container.CreatedBy
+}
+
+func permissionSubject(userID *int, role model.UserRole) string {
+	if userID != nil {
+		return fmt.Sprintf("user %d", *userID)
+	}
+	return fmt.Sprintf("role %s", role)
+}
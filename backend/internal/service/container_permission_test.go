@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+// newSharingTestService stores containers of alice and bob. Bob views web,
+// users of the deployer role operate db, and dave holds nothing.
+func newSharingTestService(t *testing.T) *ContainerService {
+	t.Helper()
+
+	db := newTestDB(t, &model.User{}, &model.Container{}, &model.ContainerPermission{})
+	users := []*model.User{
+		{ID: 1, Username: "alice", Email: "alice@example.com", Role: model.UserRoleOperator, IsActive: true},
+		{ID: 2, Username: "bob", Email: "bob@example.com", Role: model.UserRoleOperator, IsActive: true},
+		{ID: 3, Username: "carol", Email: "carol@example.com", Role: "deployer", IsActive: true},
+		{ID: 4, Username: "dave", Email: "dave@example.com", Role: model.UserRoleOperator, IsActive: true},
+		{ID: 5, Username: "erin", Email: "erin@example.com", Role: model.UserRoleAdmin, IsActive: true},
+	}
+	if err := db.Create(users).Error; err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+
+	alice, bob := 1, 2
+	containers := []*model.Container{
+		{ID: 1, Name: "web", Image: "nginx", Tag: "latest", CreatedBy: &alice},
+		{ID: 2, Name: "db", Image: "postgres", Tag: "16", CreatedBy: &alice},
+		{ID: 3, Name: "cache", Image: "redis", Tag: "7", CreatedBy: &alice},
+		{ID: 4, Name: "ops", Image: "grafana/grafana", Tag: "latest", CreatedBy: &bob},
+	}
+	if err := db.Create(containers).Error; err != nil {
+		t.Fatalf("failed to create containers: %v", err)
+	}
+
+	permissionRepo := repository.NewContainerPermissionRepository(db)
+	grants := []*model.ContainerPermission{
+		{ContainerID: 1, UserID: &bob, Level: model.ContainerPermissionView},
+		{ContainerID: 2, Role: "deployer", Level: model.ContainerPermissionOperate},
+	}
+	for _, grant := range grants {
+		if err := permissionRepo.Grant(context.Background(), grant); err != nil {
+			t.Fatalf("failed to grant: %v", err)
+		}
+	}
+
+	return &ContainerService{
+		containerRepo:  repository.NewContainerRepository(db),
+		permissionRepo: permissionRepo,
+		userService:    &UserService{userRepo: repository.NewUserRepository(db)},
+	}
+}
+
+func TestListContainersShowsOnlyAccessibleContainers(t *testing.T) {
+	ctx := context.Background()
+	s := newSharingTestService(t)
+
+	tests := []struct {
+		name  string
+		actor model.Actor
+		want  []string
+	}{
+		{"creator", model.UserActor(1, "alice"), []string{"cache manage", "db manage", "web manage"}},
+		{"user grant", model.UserActor(2, "bob"), []string{"ops manage", "web view"}},
+		{"role grant", model.UserActor(3, "carol"), []string{"db operate"}},
+		{"no grant", model.UserActor(4, "dave"), nil},
+		{"api key", model.APITokenActor("ci"), nil},
+		{"admin", model.UserActor(5, "erin"), []string{"cache manage", "db manage", "ops manage", "web manage"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list, err := s.ListContainers(ctx, tt.actor, &dto.ContainerFilter{ContainerFilter: &model.ContainerFilter{}})
+			if err != nil {
+				t.Fatalf("ListContainers failed: %v", err)
+			}
+			var got []string
+			for _, c := range list.Containers {
+				got = append(got, c.Name+" "+string(c.Permission))
+			}
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listed %q, want %q", got, tt.want)
+			}
+			if list.Total != int64(len(tt.want)) {
+				t.Errorf("total = %d, want %d", list.Total, len(tt.want))
+			}
+		})
+	}
+}
+
+func TestContainerPermissionLevelsAreEnforced(t *testing.T) {
+	ctx := context.Background()
+	s := newSharingTestService(t)
+
+	tests := []struct {
+		name      string
+		actor     model.Actor
+		container int64
+		required  model.ContainerPermissionLevel
+		allowed   bool
+	}{
+		{"viewer reads", model.UserActor(2, "bob"), 1, model.ContainerPermissionView, true},
+		{"viewer cannot operate", model.UserActor(2, "bob"), 1, model.ContainerPermissionOperate, false},
+		{"grant is per container", model.UserActor(2, "bob"), 2, model.ContainerPermissionView, false},
+		{"role operates", model.UserActor(3, "carol"), 2, model.ContainerPermissionOperate, true},
+		{"operator cannot manage", model.UserActor(3, "carol"), 2, model.ContainerPermissionManage, false},
+		{"no grant", model.UserActor(4, "dave"), 1, model.ContainerPermissionView, false},
+		{"api key", model.APITokenActor("ci"), 1, model.ContainerPermissionView, false},
+		{"creator manages", model.UserActor(1, "alice"), 2, model.ContainerPermissionManage, true},
+		{"admin manages", model.UserActor(5, "erin"), 4, model.ContainerPermissionManage, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container, err := s.containerRepo.GetByID(ctx, tt.container)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = s.checkContainerPermission(ctx, container, tt.actor, tt.required)
+			if tt.allowed && err != nil {
+				t.Errorf("%s on %s = %v, want allowed", tt.required, container.Name, err)
+			}
+			if !tt.allowed && !isAccessDenied(err) {
+				t.Errorf("%s on %s = %v, want access denied", tt.required, container.Name, err)
+			}
+		})
+	}
+
+	// Sharing takes manage; an operator cannot pass the container on
+	dave := 4
+	_, err := s.GrantContainerPermission(ctx, model.UserActor(3, "carol"), 2, &dto.GrantContainerPermissionRequest{
+		UserID: &dave, Permission: model.ContainerPermissionOperate,
+	})
+	if !isAccessDenied(err) {
+		t.Errorf("carol sharing db = %v, want access denied", err)
+	}
+	if err := s.RevokeContainerPermission(ctx, model.UserActor(2, "bob"), 1, &dto.RevokeContainerPermissionQuery{UserID: &dave}); !isAccessDenied(err) {
+		t.Errorf("bob revoking on web = %v, want access denied", err)
+	}
+	grants, err := s.permissionRepo.ListForUser(ctx, dave, model.UserRoleOperator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(grants) != 0 {
+		t.Errorf("dave holds %+v, want nothing", grants)
+	}
+}
@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultPostStartHealthTimeout bounds the wait for a started container to
+// report healthy before its warmup and hooks
+const defaultPostStartHealthTimeout = 5 * time.Minute
+
+// PostStartWebhook is the body posted by webhook post-start hooks
+type PostStartWebhook struct {
+	Event         string    `json:"event"`
+	Hook          string    `json:"hook"`
+	Trigger       string    `json:"trigger"`
+	ContainerID   int       `json:"container_id"`
+	ContainerName string    `json:"container_name"`
+	Image         string    `json:"image"`
+	DockerID      string    `json:"docker_id"`
+	StartedAt     time.Time `json:"started_at"`
+}
+
+// RunPostStart runs the post-start sequence of a container just started as
+// dockerID: it waits for the container to run and, if it has a health check,
+// to become healthy, waits the warmup delay and runs the hooks in order. The
+// run is stored on the container and in the activity log. An error is
+// returned when the container did not come up or a hook with the
+// mark_start_failed policy failed; other hook failures only warn.
+func (s *ContainerService) RunPostStart(ctx context.Context, actor model.Actor, container *model.Container, dockerID, trigger string, healthTimeout time.Duration) (*model.PostStartRun, error) {
+	if !container.HasPostStart() {
+		return nil, nil
+	}
+	if healthTimeout <= 0 {
+		healthTimeout = defaultPostStartHealthTimeout
+	}
+
+	run := &model.PostStartRun{
+		Trigger:       trigger,
+		DockerID:      dockerID,
+		WarmupSeconds: container.WarmupSeconds,
+		StartedAt:     time.Now().UTC(),
+		Hooks:         []model.PostStartHookResult{},
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		run.StartFailed = true
+		run.Error = err.Error()
+	} else if err := s.waitUntilUp(ctx, dc, dockerID, healthTimeout); err != nil {
+		run.StartFailed = true
+		run.Error = err.Error()
+	} else if err := s.warmUp(ctx, dc, dockerID, container.WarmupSeconds); err != nil {
+		run.StartFailed = true
+		run.Error = err.Error()
+	}
+
+	for _, hook := range container.PostStartHooks {
+		if run.StartFailed {
+			run.Hooks = append(run.Hooks, model.PostStartHookResult{
+				Name:      hook.Label(),
+				Type:      hook.Type,
+				OnFailure: hook.FailurePolicy(),
+				Skipped:   true,
+				StartedAt: time.Now().UTC(),
+			})
+			continue
+		}
+
+		result := s.runPostStartHook(ctx, dc, container, dockerID, trigger, hook)
+		run.Hooks = append(run.Hooks, result)
+		if result.Success {
+			continue
+		}
+
+		switch result.OnFailure {
+		case model.HookFailureMarkStartFailed:
+			run.StartFailed = true
+			run.Error = fmt.Sprintf("post-start hook %q failed: %s", result.Name, result.Error)
+		case model.HookFailureWarn:
+			run.Warnings = append(run.Warnings, fmt.Sprintf("post-start hook %q failed: %s", result.Name, result.Error))
+		}
+	}
+
+	completedAt := time.Now().UTC()
+	run.CompletedAt = &completedAt
+
+	s.recordPostStart(ctx, actor, container, run)
+
+	if run.StartFailed {
+		return run, fmt.Errorf("container did not start cleanly: %s", run.Error)
+	}
+	return run, nil
+}
+
+// waitUntilUp waits for the container to be running and healthy
+func (s *ContainerService) waitUntilUp(ctx context.Context, dc *docker.DockerClient, dockerID string, timeout time.Duration) error {
+	running, err := dc.IsContainerRunning(ctx, dockerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if !running {
+		return fmt.Errorf("container is not running")
+	}
+
+	if err := dc.WaitForHealthy(ctx, dockerID, timeout); err != nil {
+		return fmt.Errorf("container did not become healthy: %w", err)
+	}
+	return nil
+}
+
+// warmUp waits the warmup delay and checks the container survived it
+func (s *ContainerService) warmUp(ctx context.Context, dc *docker.DockerClient, dockerID string, seconds int) error {
+	if seconds <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(time.Duration(seconds) * time.Second)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("warmup interrupted: %w", ctx.Err())
+	case <-timer.C:
+	}
+
+	running, err := dc.IsContainerRunning(ctx, dockerID)
+	if err != nil {
+		return fmt.Errorf("failed to inspect container: %w", err)
+	}
+	if !running {
+		return fmt.Errorf("container stopped during its %ds warmup", seconds)
+	}
+	return nil
+}
+
+// runPostStartHook runs one hook within its timeout
+func (s *ContainerService) runPostStartHook(ctx context.Context, dc *docker.DockerClient, container *model.Container, dockerID, trigger string, hook model.PostStartHook) model.PostStartHookResult {
+	result := model.PostStartHookResult{
+		Name:      hook.Label(),
+		Type:      hook.Type,
+		OnFailure: hook.FailurePolicy(),
+		StartedAt: time.Now().UTC(),
+	}
+
+	hookCtx, cancel := context.WithTimeout(ctx, hook.Timeout())
+	defer cancel()
+
+	switch hook.Type {
+	case model.PostStartHookExec:
+		execResult, err := dc.ExecCommand(hookCtx, dockerID, hook.Command)
+		if err != nil {
+			result.Error = fmt.Sprintf("command execution failed: %v", err)
+			break
+		}
+		result.ExitCode = &execResult.ExitCode
+		result.SetOutput(execResult.Stdout + execResult.Stderr)
+		if execResult.ExitCode != 0 {
+			result.Error = fmt.Sprintf("command exited with code %d", execResult.ExitCode)
+			break
+		}
+		result.Success = true
+
+	case model.PostStartHookWebhook:
+		if s.webhookService == nil {
+			result.Error = "webhook service not available"
+			break
+		}
+		statusCode, err := s.webhookService.Post(hookCtx, hook.URL, &PostStartWebhook{
+			Event:         "container.started",
+			Hook:          result.Name,
+			Trigger:       trigger,
+			ContainerID:   container.ID,
+			ContainerName: container.Name,
+			Image:         container.GetFullImageName(),
+			DockerID:      dockerID,
+			StartedAt:     result.StartedAt,
+		})
+		result.StatusCode = statusCode
+		if err != nil {
+			result.Error = err.Error()
+			break
+		}
+		result.Success = true
+
+	default:
+		result.Error = fmt.Sprintf("unknown post-start hook type %q", hook.Type)
+	}
+
+	result.DurationMs = time.Since(result.StartedAt).Milliseconds()
+	return result
+}
+
+// recordPostStart stores the run on the container and logs it
+func (s *ContainerService) recordPostStart(ctx context.Context, actor model.Actor, container *model.Container, run *model.PostStartRun) {
+	containerID := int64(container.ID)
+	if err := s.containerRepo.UpdatePostStart(ctx, containerID, run); err != nil {
+		logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to store post-start run")
+	}
+	container.LastPostStart = run
+
+	failed := make([]string, 0)
+	for _, hook := range run.Hooks {
+		if !hook.Success && !hook.Skipped {
+			failed = append(failed, hook.Name)
+		}
+	}
+
+	description := fmt.Sprintf("Post-start sequence completed, %d hooks run", len(run.Hooks))
+	switch {
+	case run.StartFailed:
+		description = "Post-start sequence failed: " + run.Error
+	case len(failed) > 0:
+		description = fmt.Sprintf("Post-start sequence completed with failed hooks: %s", strings.Join(failed, ", "))
+	}
+
+	s.logContainerActivity(actor, containerID, "post_start_hooks", description, map[string]interface{}{
+		"trigger":        run.Trigger,
+		"docker_id":      run.DockerID,
+		"warmup_seconds": run.WarmupSeconds,
+		"start_failed":   run.StartFailed,
+		"hooks":          run.Hooks,
+	})
+}
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrShuttingDown is returned for updates requested once shutdown has begun
+var ErrShuttingDown = apperrors.New(apperrors.CodeShuttingDown, "server is shutting down; no new updates are started")
+
+// updateDrain counts the image updates in progress so that shutdown can wait
+// for them, and refuses new ones once shutdown has begun
+type updateDrain struct {
+	mu       sync.Mutex
+	draining bool
+	active   int
+	// idle is closed when the last update ends while draining
+	idle chan struct{}
+}
+
+func (d *updateDrain) begin() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.draining {
+		return false
+	}
+	d.active++
+	return true
+}
+
+func (d *updateDrain) end() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.active--
+	if d.active == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+// DrainUpdates refuses new image updates and waits until those in progress
+// finish or ctx ends. It is part of shutdown, after the scheduler stopped.
+func (s *ContainerService) DrainUpdates(ctx context.Context) error {
+	d := s.updates
+	d.mu.Lock()
+	d.draining = true
+	if d.active == 0 {
+		d.mu.Unlock()
+		return nil
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle, active := d.idle, d.active
+	d.mu.Unlock()
+
+	logrus.WithField("updates", active).Info("Waiting for container updates to finish")
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		d.mu.Lock()
+		active = d.active
+		d.mu.Unlock()
+		return fmt.Errorf("%d container updates were still running", active)
+	}
+}
+
+// RecoverInterruptedUpdates settles the updates a previous process left
+// running. An update whose new container had started is finished; any other
+// is rolled back to the old container. It is run at startup, after
+// FinishSelfUpdate and before the scheduler starts.
+func (s *ContainerService) RecoverInterruptedUpdates(ctx context.Context) {
+	histories, err := s.updateHistoryRepo.GetByStatus(ctx, model.UpdateStatusRunning)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list interrupted updates")
+		return
+	}
+
+	for _, history := range histories {
+		logger := logrus.WithFields(logrus.Fields{
+			"update_id":    history.ID,
+			"container_id": history.ContainerID,
+		})
+
+		container, err := s.containerRepo.GetByID(ctx, int64(history.ContainerID))
+		if err != nil {
+			logger.WithError(err).Warn("Failed to get container of interrupted update")
+			continue
+		}
+		// Self-updates are settled by FinishSelfUpdate
+		if s.IsSelfContainer(container) {
+			continue
+		}
+
+		status, recoverErr := s.recoverUpdate(ctx, container, history)
+		s.settleInterruptedUpdate(ctx, container, history, status, recoverErr)
+		logger.WithField("status", status).Info("Settled interrupted update")
+	}
+}
+
+// recoverUpdate finishes or rolls back one interrupted update and returns
+// the status to record
+func (s *ContainerService) recoverUpdate(ctx context.Context, container *model.Container, history *model.UpdateHistory) (model.UpdateStatus, error) {
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return model.UpdateStatusFailed, fmt.Errorf("interrupted by a restart; docker host unavailable: %w", err)
+	}
+	if history.Checkpoint != nil {
+		return s.recoverCheckpointedUpdate(ctx, dc, container, history)
+	}
+	return s.recoverStagedUpdate(ctx, dc, container, history)
+}
+
+// recoverCheckpointedUpdate settles a recreate update from its checkpoint
+func (s *ContainerService) recoverCheckpointedUpdate(ctx context.Context, dc *docker.DockerClient, container *model.Container, history *model.UpdateHistory) (model.UpdateStatus, error) {
+	checkpoint := history.Checkpoint
+	interrupted := errors.New("interrupted by a restart")
+
+	if checkpoint.OldContainerID == "" {
+		failRunningSteps(checkpoint, interrupted)
+		return model.UpdateStatusFailed, fmt.Errorf("%w before the container was replaced", interrupted)
+	}
+
+	if checkpoint.NewContainerID != "" {
+		newRunning, err := dc.IsContainerRunning(ctx, checkpoint.NewContainerID)
+		if err == nil && newRunning {
+			if err := s.finishRecovered(ctx, dc, container, checkpoint.OldContainerID, checkpoint.NewContainerID, checkpoint.ResolvedDigest); err != nil {
+				return model.UpdateStatusFailed, fmt.Errorf("%w; finishing it failed: %v", interrupted, err)
+			}
+			checkpoint.Complete(model.UpdateStepFinalize)
+			history.NewImage = checkpoint.TargetImage
+			return model.UpdateStatusCompleted, nil
+		}
+	}
+
+	oldExists, err := dc.ContainerExists(ctx, checkpoint.OldContainerID)
+	if err != nil {
+		return model.UpdateStatusFailed, fmt.Errorf("%w; failed to inspect the old container: %v", interrupted, err)
+	}
+	failRunningSteps(checkpoint, interrupted)
+	if !oldExists {
+		return model.UpdateStatusFailed, fmt.Errorf("%w after the old container was removed", interrupted)
+	}
+
+	if checkpoint.NewContainerID != "" {
+		removeContainerQuietly(ctx, dc, checkpoint.NewContainerID)
+	}
+	// The old container was only stopped by the update once stop_old began
+	wasRunning := checkpoint.IsStarted(model.UpdateStepStopOld) && container.IsRunning()
+	return model.UpdateStatusRollback, restoreOldContainer(ctx, dc, checkpoint.OldContainerID, container.Name, wasRunning,
+		fmt.Errorf("%w; rolled back to the old container", interrupted))
+}
+
+// recoverStagedUpdate settles a health-gated update from the containers it
+// left: the old one, possibly retired, and the staged one
+func (s *ContainerService) recoverStagedUpdate(ctx context.Context, dc *docker.DockerClient, container *model.Container, history *model.UpdateHistory) (model.UpdateStatus, error) {
+	interrupted := errors.New("interrupted by a restart")
+	stagingName := fmt.Sprintf("%s-update-%d", container.Name, history.ID)
+
+	newID, err := dc.FindContainerIDByName(ctx, container.Name)
+	if err != nil || newID == container.ContainerID {
+		newID, _ = dc.FindContainerIDByName(ctx, stagingName)
+	}
+
+	oldExists := false
+	if container.ContainerID != "" {
+		if oldExists, err = dc.ContainerExists(ctx, container.ContainerID); err != nil {
+			return model.UpdateStatusFailed, fmt.Errorf("%w; failed to inspect the old container: %v", interrupted, err)
+		}
+	}
+
+	// The swap is done once the staged container has the name; the old one
+	// only waits to be removed
+	swapped := false
+	if newID != "" {
+		if current, err := dc.GetContainer(ctx, newID); err == nil && current.Name == "/"+container.Name {
+			swapped = current.State != nil && current.State.Running
+		}
+	}
+
+	if oldExists && !swapped {
+		if newID != "" {
+			removeContainerQuietly(ctx, dc, newID)
+		}
+		return model.UpdateStatusRollback, restoreOldContainer(ctx, dc, container.ContainerID, container.Name, container.IsRunning(),
+			fmt.Errorf("%w; rolled back to the old container", interrupted))
+	}
+	if newID == "" {
+		return model.UpdateStatusFailed, fmt.Errorf("%w; neither the old nor the new container remains", interrupted)
+	}
+
+	oldID := ""
+	if oldExists {
+		oldID = container.ContainerID
+	}
+	if err := s.finishRecovered(ctx, dc, container, oldID, newID, ""); err != nil {
+		return model.UpdateStatusFailed, fmt.Errorf("%w; finishing it failed: %v", interrupted, err)
+	}
+	return model.UpdateStatusCompleted, nil
+}
+
+// finishRecovered replaces the old container, if any remains, with the new
+// one: it removes the old one, names and starts the new one and records it
+func (s *ContainerService) finishRecovered(ctx context.Context, dc *docker.DockerClient, container *model.Container, oldID, newID, digest string) error {
+	if oldID != "" {
+		if err := dc.RemoveContainer(ctx, oldID, types.ContainerRemoveOptions{Force: true}); err != nil && !docker.IsContainerNotFoundError(err) {
+			return fmt.Errorf("failed to remove old container: %w", err)
+		}
+	}
+
+	current, err := dc.GetContainer(ctx, newID)
+	if err != nil {
+		return err
+	}
+	if current.Name != "/"+container.Name {
+		if err := dc.RenameContainer(ctx, newID, container.Name); err != nil {
+			return err
+		}
+	}
+	if container.IsRunning() && (current.State == nil || !current.State.Running) {
+		if err := dc.StartContainer(ctx, newID); err != nil {
+			return err
+		}
+	}
+
+	if container.ContainerID != newID {
+		if err := s.containerRepo.UpdateContainerID(ctx, int64(container.ID), newID); err != nil {
+			return fmt.Errorf("failed to record new container ID: %w", err)
+		}
+		container.SetDeployedContainer(newID)
+	}
+	if digest != "" && container.ImageDigest != digest {
+		container.ImageDigest = digest
+		container.PendingDigest = ""
+		if err := s.containerRepo.Update(ctx, container); err != nil {
+			return fmt.Errorf("failed to record pinned digest: %w", err)
+		}
+	}
+	return nil
+}
+
+// settleInterruptedUpdate records the outcome of a recovered update
+func (s *ContainerService) settleInterruptedUpdate(ctx context.Context, container *model.Container, history *model.UpdateHistory, status model.UpdateStatus, cause error) {
+	completedAt := time.Now()
+	history.CompletedAt = &completedAt
+	history.DurationSeconds = int(completedAt.Sub(history.StartedAt).Seconds())
+	history.Status = status
+	if cause != nil {
+		history.ErrorMessage = cause.Error()
+	}
+
+	if err := s.updateHistoryRepo.Update(ctx, history); err != nil {
+		logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to update history record")
+	}
+	metrics.RecordContainerUpdate(string(history.Status))
+	s.publishUpdateCompleted(container, history)
+	s.recordAppliedUpdate(ctx, container, history)
+	s.cache.Delete(fmt.Sprintf("container:status:%d", container.ID))
+
+	// The server that started the update is gone; its outcome is recorded
+	// by the system
+	actor := model.SystemActor(model.ActorComponentSystem)
+	details := map[string]interface{}{
+		"old_image": history.OldImage,
+		"new_image": history.NewImage,
+		"update_id": history.ID,
+	}
+	if status != model.UpdateStatusCompleted {
+		message := "Interrupted update failed"
+		if status == model.UpdateStatusRollback {
+			message = "Interrupted update rolled back"
+		}
+		details["error"] = history.ErrorMessage
+		s.logContainerActivity(actor, int64(container.ID), "image_update_failed", message, details)
+		return
+	}
+	s.logContainerActivity(actor, int64(container.ID), "image_updated", "Interrupted update finished", details)
+}
+
+// failRunningSteps marks the steps a restart cut short as failed
+func failRunningSteps(checkpoint *model.UpdateCheckpoint, cause error) {
+	for _, step := range checkpoint.Steps {
+		if step.Status == model.UpdateStepRunning {
+			checkpoint.Fail(step.Name, cause)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSelfUpdateUnavailable is returned when docker-auto cannot update its own
+// container: it does not run in one, or the helper cannot reach Docker
+var ErrSelfUpdateUnavailable = errors.New("self-update is unavailable")
+
+// Steps reported by update_progress events of a self-update
+const (
+	selfUpdateStepPulling  = "pulling_image"
+	selfUpdateStepChecking = "checking_helper"
+	selfUpdateStepHandOff  = "helper_started"
+)
+
+// selfUpdateLogLimit bounds the helper output kept on the update record
+const selfUpdateLogLimit = 64 * 1024
+
+// IsSelfContainer reports whether the container is the one docker-auto runs
+// in. Only containers on the local daemon can be.
+func (s *ContainerService) IsSelfContainer(container *model.Container) bool {
+	if s.dockerClient == nil || container.HostID != nil || container.ContainerID == "" {
+		return false
+	}
+	selfID := docker.SelfContainerID()
+	if selfID == "" {
+		return false
+	}
+	return strings.HasPrefix(container.ContainerID, selfID) || strings.HasPrefix(selfID, container.ContainerID)
+}
+
+// SelfUpdate updates the container docker-auto runs in to its deploy image,
+// or to req.Tag. The update is handed to a helper container and completes
+// after this server has been replaced; it is followed on the events stream.
+func (s *ContainerService) SelfUpdate(ctx context.Context, actor model.Actor, req *dto.SelfUpdateRequest) (*model.UpdateHistory, error) {
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+	if req.Note != "" {
+		if _, err := model.SanitizeUpdateNote(req.Note); err != nil {
+			return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+		}
+	}
+
+	container, err := s.selfContainer(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	retagged := req.Tag != "" && req.Tag != container.Tag
+	if err := checkVersionPolicy(container, req.Tag); err != nil {
+		return nil, err
+	}
+	if retagged {
+		container.Tag = req.Tag
+	}
+
+	history, err := s.StartSelfUpdate(ctx, actor, container, model.TriggerTypeManual, req.HealthTimeoutSeconds)
+	if err != nil {
+		return nil, err
+	}
+	if retagged {
+		if err := s.containerRepo.Update(ctx, container); err != nil {
+			return nil, fmt.Errorf("failed to update container: %w", err)
+		}
+	}
+
+	if req.Note != "" {
+		note, err := s.addUpdateNote(ctx, actor, history, &UpdateNoteRequest{Body: req.Note})
+		if err != nil {
+			logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to attach note to update")
+		} else {
+			history.Notes = []*model.UpdateNote{note}
+		}
+	}
+
+	return history, nil
+}
+
+// StartSelfUpdate records an update of the container docker-auto runs in and
+// hands it to a helper container. The returned update is still running.
+func (s *ContainerService) StartSelfUpdate(ctx context.Context, actor model.Actor, container *model.Container, trigger model.TriggerType, healthTimeoutSeconds int) (*model.UpdateHistory, error) {
+	history := &model.UpdateHistory{
+		ContainerID: container.ID,
+		OldImage:    container.GetDeployImageRef(),
+		Strategy:    model.UpdateStrategyRecreate,
+		TriggeredBy: trigger,
+	}
+	history.SetActor(actor)
+
+	req := &dto.UpdateImageRequest{
+		Strategy:             string(model.UpdateStrategyRecreate),
+		HealthTimeoutSeconds: healthTimeoutSeconds,
+	}
+	if err := s.applyImageUpdate(ctx, actor, container, history, req); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// selfContainer returns the managed container docker-auto runs in
+func (s *ContainerService) selfContainer(ctx context.Context) (*model.Container, error) {
+	selfID := docker.SelfContainerID()
+	if s.dockerClient == nil || selfID == "" {
+		return nil, fmt.Errorf("%w: docker-auto does not run in a container", ErrSelfUpdateUnavailable)
+	}
+
+	self, err := s.dockerClient.GetContainer(ctx, selfID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to inspect own container: %v", ErrSelfUpdateUnavailable, err)
+	}
+
+	container, err := s.containerRepo.GetByContainerID(ctx, self.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get own container: %w", err)
+	}
+	return container, nil
+}
+
+// startSelfUpdate pulls the deploy image of the container docker-auto runs in
+// and starts the helper that replaces it, once a check run has shown the
+// helper reaches Docker. Stopping its own container would end the update, so
+// the helper recreates it from its configuration after this server exits,
+// and brings the old container back if the new one does not run healthy.
+// FinishSelfUpdate records the outcome.
+func (s *ContainerService) startSelfUpdate(ctx context.Context, actor model.Actor, container *model.Container, history *model.UpdateHistory, req *dto.UpdateImageRequest) error {
+	self, err := s.dockerClient.GetContainer(ctx, container.ContainerID)
+	if err != nil {
+		return s.failSelfUpdate(ctx, actor, container, history, fmt.Errorf("failed to inspect container: %w", err))
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return s.failSelfUpdate(ctx, actor, container, history, fmt.Errorf("%w: %v", ErrSelfUpdateUnavailable, err))
+	}
+
+	target := container.GetDeployImageRef()
+	history.NewImage = target
+	s.publishUpdateProgress(container, history, selfUpdateStepPulling)
+	err = docker.Retry(func() error {
+		return s.dockerClient.PullImageWithProgress(ctx, docker.ContainerPullKey(int64(container.ID)), target, types.ImagePullOptions{}, nil,
+			s.PullProgressReporter(container, history))
+	}, docker.DefaultRetryConfig())
+	if err != nil {
+		return s.failSelfUpdate(ctx, actor, container, history, fmt.Errorf("failed to pull image: %w", err))
+	}
+
+	s.publishUpdateProgress(container, history, selfUpdateStepChecking)
+	if err := s.dockerClient.CheckSelfUpdateHelper(ctx, self, executable); err != nil {
+		return s.failSelfUpdate(ctx, actor, container, history, fmt.Errorf("%w: the helper cannot reach Docker: %v", ErrSelfUpdateUnavailable, err))
+	}
+
+	plan := &docker.SelfUpdatePlan{
+		ContainerID:          self.ID,
+		Image:                target,
+		RetiredName:          fmt.Sprintf("%s-old-%d", strings.TrimPrefix(self.Name, "/"), history.ID),
+		StopTimeoutSeconds:   container.StopTimeout(),
+		HealthTimeoutSeconds: int(req.HealthTimeout() / time.Second),
+	}
+	helperID, err := s.dockerClient.StartSelfUpdateHelper(ctx, self, executable, plan, strconv.Itoa(history.ID))
+	if err != nil {
+		return s.failSelfUpdate(ctx, actor, container, history, err)
+	}
+
+	if err := s.updateHistoryRepo.Update(ctx, history); err != nil {
+		logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to update history record")
+	}
+	s.publishUpdateProgress(container, history, selfUpdateStepHandOff)
+	logrus.WithFields(logrus.Fields{
+		"update_id": history.ID,
+		"helper_id": helperID,
+		"image":     target,
+	}).Info("Handed self-update to helper container")
+	return nil
+}
+
+// failSelfUpdate records a self-update that failed before the helper took
+// over and returns cause
+func (s *ContainerService) failSelfUpdate(ctx context.Context, actor model.Actor, container *model.Container, history *model.UpdateHistory, cause error) error {
+	completedAt := time.Now()
+	history.Status = model.UpdateStatusFailed
+	history.ErrorMessage = cause.Error()
+	history.CompletedAt = &completedAt
+	history.DurationSeconds = int(completedAt.Sub(history.StartedAt).Seconds())
+
+	if err := s.updateHistoryRepo.Update(ctx, history); err != nil {
+		logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to update history record")
+	}
+	metrics.RecordContainerUpdate(string(history.Status))
+	s.publishUpdateCompleted(container, history)
+
+	s.logContainerActivity(actor, int64(container.ID), "image_update_failed", "Self-update failed", map[string]interface{}{
+		"old_image": history.OldImage,
+		"new_image": history.NewImage,
+		"update_id": history.ID,
+		"error":     cause.Error(),
+	})
+	return fmt.Errorf("self-update failed: %w", cause)
+}
+
+// FinishSelfUpdate records the outcome of the self-updates whose helpers are
+// on the local daemon, waiting for those still running, and removes the
+// helpers. It is run at startup, by the server the helper started or
+// brought back.
+func (s *ContainerService) FinishSelfUpdate(ctx context.Context) {
+	if s.dockerClient == nil {
+		return
+	}
+
+	helpers, err := s.dockerClient.SelfUpdateHelpers(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list self-update helpers")
+		return
+	}
+	for _, helper := range helpers {
+		s.finishSelfUpdateHelper(ctx, helper)
+	}
+}
+
+// finishSelfUpdateHelper records the outcome of one self-update helper
+func (s *ContainerService) finishSelfUpdateHelper(ctx context.Context, helper types.Container) {
+	logger := logrus.WithField("helper_id", helper.ID)
+
+	exitCode, output, err := s.dockerClient.AwaitSelfUpdateHelper(ctx, helper.ID)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to wait for self-update helper")
+		return
+	}
+	defer func() {
+		if err := s.dockerClient.RemoveContainer(ctx, helper.ID, types.ContainerRemoveOptions{Force: true}); err != nil && !docker.IsContainerNotFoundError(err) {
+			logger.WithError(err).Warn("Failed to remove self-update helper")
+		}
+	}()
+
+	updateID, err := strconv.ParseInt(helper.Labels[docker.SelfUpdateLabel], 10, 64)
+	if err != nil {
+		logger.Warn("Self-update helper has no update ID")
+		return
+	}
+	history, err := s.updateHistoryRepo.GetByID(ctx, updateID)
+	if err != nil {
+		logger.WithError(err).WithField("update_id", updateID).Warn("Failed to get self-update record")
+		return
+	}
+	if history.Status != model.UpdateStatusRunning {
+		return
+	}
+	container, err := s.containerRepo.GetByID(ctx, int64(history.ContainerID))
+	if err != nil {
+		logger.WithError(err).WithField("update_id", updateID).Warn("Failed to get self-updated container")
+		return
+	}
+
+	completedAt := time.Now()
+	history.CompletedAt = &completedAt
+	history.DurationSeconds = int(completedAt.Sub(history.StartedAt).Seconds())
+	if len(output) > selfUpdateLogLimit {
+		output = output[len(output)-selfUpdateLogLimit:]
+	}
+	history.Logs = output
+
+	switch exitCode {
+	case docker.SelfUpdateExitOK:
+		history.Status = model.UpdateStatusCompleted
+		s.recordSelfContainerID(ctx, container)
+	case docker.SelfUpdateExitFailed:
+		history.Status = model.UpdateStatusRollback
+		history.ErrorMessage = "self-update failed; the old container was kept"
+	default:
+		history.Status = model.UpdateStatusFailed
+		history.ErrorMessage = fmt.Sprintf("self-update helper exited with code %d", exitCode)
+	}
+
+	if err := s.updateHistoryRepo.Update(ctx, history); err != nil {
+		logger.WithError(err).WithField("update_id", history.ID).Warn("Failed to update history record")
+	}
+	metrics.RecordContainerUpdate(string(history.Status))
+	s.publishUpdateCompleted(container, history)
+	s.recordAppliedUpdate(ctx, container, history)
+	s.cache.Delete(fmt.Sprintf("container:status:%d", container.ID))
+
+	// The server that started the update is gone; its outcome is recorded
+	// by the system
+	actor := model.SystemActor(model.ActorComponentSystem)
+	details := map[string]interface{}{
+		"old_image": history.OldImage,
+		"new_image": history.NewImage,
+		"update_id": history.ID,
+	}
+	if history.Status != model.UpdateStatusCompleted {
+		details["error"] = history.ErrorMessage
+		s.logContainerActivity(actor, int64(container.ID), "image_update_failed", "Self-update failed", details)
+		return
+	}
+	s.logContainerActivity(actor, int64(container.ID), "image_updated", "Container image updated", details)
+}
+
+// recordSelfContainerID records the ID of the container this server runs in
+// for its managed container, which the helper recreated
+func (s *ContainerService) recordSelfContainerID(ctx context.Context, container *model.Container) {
+	selfID := docker.SelfContainerID()
+	if selfID == "" {
+		return
+	}
+	self, err := s.dockerClient.GetContainer(ctx, selfID)
+	if err != nil || self.ID == container.ContainerID {
+		return
+	}
+	if err := s.containerRepo.UpdateContainerID(ctx, int64(container.ID), self.ID); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to record new container ID")
+		return
+	}
+	container.SetDeployedContainer(self.ID)
+}
@@ -0,0 +1,275 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// containerSyncState tracks the containers that had daemon events since the
+// last status sync
+type containerSyncState struct {
+	mu        sync.Mutex
+	changed   map[string]struct{}
+	watermark time.Time
+}
+
+func newContainerSyncState() *containerSyncState {
+	return &containerSyncState{changed: make(map[string]struct{})}
+}
+
+// markChanged records an event for a Docker container
+func (st *containerSyncState) markChanged(dockerID string, at time.Time) {
+	if dockerID == "" {
+		return
+	}
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	st.changed[dockerID] = struct{}{}
+	if at.After(st.watermark) {
+		st.watermark = at
+	}
+}
+
+// take returns and clears the containers changed since the last take, with
+// the time of the latest event seen
+func (st *containerSyncState) take() (map[string]struct{}, time.Time) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	changed := st.changed
+	st.changed = make(map[string]struct{})
+	return changed, st.watermark
+}
+
+// restore marks containers changed again after a sync failed to inspect them
+func (st *containerSyncState) restore(dockerIDs []string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	for _, id := range dockerIDs {
+		st.changed[id] = struct{}{}
+	}
+}
+
+// transitionalStatuses are container states that are expected to change
+// soon, so containers in them are inspected on every sync
+var transitionalStatuses = map[model.ContainerStatus]bool{
+	model.ContainerStatusRestarting: true,
+	model.ContainerStatusRemoving:   true,
+	model.ContainerStatusUnknown:    true,
+}
+
+// SyncContainerStatus synchronizes container status with the Docker daemons:
+// the local one and every enabled Docker host. Unless full is set, only
+// local containers that had events since the last sync, are in a
+// transitional state or have gone unverified for the max staleness are
+// inspected. Every local container is inspected when events are not being
+// received, since changes may then have been missed; remote hosts send no
+// events, so their containers are always inspected. Containers on disabled
+// hosts are skipped.
+func (s *ContainerService) SyncContainerStatus(ctx context.Context, full bool) (*dto.SyncResult, error) {
+	// Get all containers with Docker IDs
+	allContainers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+
+	startTime := time.Now()
+	syncResult := &dto.SyncResult{
+		TotalContainers: len(allContainers),
+		Timestamp:       startTime,
+	}
+
+	changed, watermark := s.syncState.take()
+	if !watermark.IsZero() {
+		syncResult.EventWatermark = &watermark
+	}
+	if stats := s.dockerClient.EventWatcherStats(); stats == nil || !stats.Connected {
+		full = true
+	}
+	syncResult.Full = full
+
+	var local []*model.Container
+	remote := make(map[int][]*model.Container)
+	for _, container := range allContainers {
+		if container.HostID == nil {
+			local = append(local, container)
+		} else {
+			remote[*container.HostID] = append(remote[*container.HostID], container)
+		}
+	}
+
+	run := &containerSyncRun{
+		result:  syncResult,
+		changed: changed,
+		images:  make(map[string]*types.ImageInspect),
+	}
+	s.syncContainers(ctx, s.dockerClient, s.selectForSync(local, changed, full), run)
+
+	hosts, err := s.enabledDockerHosts(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list Docker hosts for sync")
+	}
+	for _, host := range hosts {
+		containers := remote[host.ID]
+		hostResult := dto.HostSyncResult{HostID: host.ID, Name: host.Name, Containers: len(containers)}
+
+		dc, err := s.pingDockerHost(ctx, host)
+		if err != nil {
+			hostResult.Error = err.Error()
+			for _, container := range containers {
+				run.fail(container, err)
+			}
+		} else {
+			// Images are per daemon, so the inspection cache is too
+			run.images = make(map[string]*types.ImageInspect)
+			s.syncContainers(ctx, dc, containers, run)
+		}
+		syncResult.Hosts = append(syncResult.Hosts, hostResult)
+	}
+	syncResult.Skipped = len(allContainers) - syncResult.Inspected
+
+	s.syncState.restore(run.retry)
+	s.refreshContainerMetrics(ctx)
+	if err := s.containerRepo.MarkSynced(ctx, run.synced, startTime); err != nil {
+		logrus.WithError(err).Warn("Failed to record container sync times")
+	}
+
+	syncResult.Duration = time.Since(startTime)
+
+	logrus.WithFields(logrus.Fields{
+		"total_containers":  syncResult.TotalContainers,
+		"inspected":         syncResult.Inspected,
+		"skipped":           syncResult.Skipped,
+		"full":              syncResult.Full,
+		"hosts":             len(syncResult.Hosts),
+		"synced_containers": syncResult.SyncedContainers,
+		"error_containers":  syncResult.ErrorContainers,
+		"status_changes":    len(syncResult.StatusChanges),
+		"duration":          syncResult.Duration,
+	}).Info("Container status sync completed")
+
+	return syncResult, nil
+}
+
+// containerSyncRun collects the outcome of one status sync across hosts
+type containerSyncRun struct {
+	result  *dto.SyncResult
+	changed map[string]struct{}
+	images  map[string]*types.ImageInspect
+	synced  []int64
+	retry   []string
+}
+
+// fail records a container the sync could not inspect; containers that had
+// events are retried on the next sync
+func (r *containerSyncRun) fail(container *model.Container, err error) {
+	if _, ok := r.changed[container.ContainerID]; ok {
+		r.retry = append(r.retry, container.ContainerID)
+	}
+	r.result.Inspected++
+	r.result.ErrorContainers++
+	r.result.Errors = append(r.result.Errors, dto.SyncError{
+		ContainerID: int64(container.ID),
+		Name:        container.Name,
+		Error:       err.Error(),
+		Recoverable: true,
+	})
+}
+
+// syncContainers inspects containers on the daemon dc and records their
+// status, drift and restarts
+func (s *ContainerService) syncContainers(ctx context.Context, dc *docker.DockerClient, containers []*model.Container, run *containerSyncRun) {
+	syncResult := run.result
+
+	for _, container := range containers {
+		// Get Docker status, re-resolving the Docker ID by name when it is
+		// missing or stale (the container was recreated outside the app)
+		var dockerStatus model.ContainerStatus
+		var err error
+		if container.ContainerID != "" {
+			dockerStatus, err = dc.GetContainerStatus(ctx, container.ContainerID)
+		}
+		if container.ContainerID == "" || (err != nil && docker.IsNotFoundError(err)) {
+			if s.resolveContainerID(ctx, dc, container) {
+				dockerStatus, err = dc.GetContainerStatus(ctx, container.ContainerID)
+			} else if container.ContainerID == "" {
+				syncResult.Inspected++
+				continue
+			}
+		}
+		if err != nil {
+			run.fail(container, err)
+			continue
+		}
+		syncResult.Inspected++
+
+		// Update status if changed
+		if container.Status != dockerStatus {
+			if err := s.containerRepo.UpdateStatus(ctx, int64(container.ID), dockerStatus); err != nil {
+				logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to update container status")
+			} else {
+				syncResult.StatusChanges = append(syncResult.StatusChanges, dto.ContainerStatusChange{
+					ContainerID: int64(container.ID),
+					Name:        container.Name,
+					OldStatus:   container.Status,
+					NewStatus:   dockerStatus,
+					Reason:      "Docker status sync",
+				})
+			}
+		}
+
+		if report, live, err := s.detectDrift(ctx, dc, container, run.images); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Debug("Failed to check container drift")
+		} else {
+			s.recordDrift(ctx, container, report.Drifted)
+			s.recordImageDigests(ctx, container, run.images[live.Image])
+			s.recordRestarts(ctx, container, live, time.Now())
+		}
+
+		run.synced = append(run.synced, int64(container.ID))
+		syncResult.SyncedContainers++
+	}
+}
+
+// selectForSync picks the containers a sync inspects. Stale containers are
+// taken least recently synced first, at most the stale batch per run, so a
+// full verification pass is spread across runs. Crash looping containers are
+// inspected on every run until they recover.
+func (s *ContainerService) selectForSync(containers []*model.Container, changed map[string]struct{}, full bool) []*model.Container {
+	if full {
+		return containers
+	}
+
+	staleness := time.Duration(s.config.Docker.SyncMaxStalenessSeconds) * time.Second
+	staleBefore := time.Now().Add(-staleness)
+
+	var selected, stale []*model.Container
+	for _, container := range containers {
+		_, hadEvents := changed[container.ContainerID]
+		switch {
+		case container.ContainerID == "", hadEvents, transitionalStatuses[container.Status], container.LastSyncedAt == nil, container.CrashLooping:
+			selected = append(selected, container)
+		case staleness > 0 && container.LastSyncedAt.Before(staleBefore):
+			stale = append(stale, container)
+		}
+	}
+
+	sort.SliceStable(stale, func(i, j int) bool {
+		return stale[i].LastSyncedAt.Before(*stale[j].LastSyncedAt)
+	})
+	if batch := s.config.Docker.SyncStaleBatch; batch > 0 && len(stale) > batch {
+		stale = stale[:batch]
+	}
+
+	return append(selected, stale...)
+}
@@ -0,0 +1,272 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ContainerTemplateService manages container templates and creates
+// containers from them
+type ContainerTemplateService struct {
+	templateRepo     repository.ContainerTemplateRepository
+	userRepo         repository.UserRepository
+	containerService *ContainerService
+	activityRepo     repository.ActivityLogRepository
+}
+
+// NewContainerTemplateService creates a new container template service instance
+func NewContainerTemplateService(
+	templateRepo repository.ContainerTemplateRepository,
+	userRepo repository.UserRepository,
+	containerService *ContainerService,
+	activityRepo repository.ActivityLogRepository,
+) *ContainerTemplateService {
+	return &ContainerTemplateService{
+		templateRepo:     templateRepo,
+		userRepo:         userRepo,
+		containerService: containerService,
+		activityRepo:     activityRepo,
+	}
+}
+
+// ListTemplates returns the shared templates and the actor's own
+func (s *ContainerTemplateService) ListTemplates(ctx context.Context, actor model.Actor) ([]*model.ContainerTemplate, error) {
+	return s.templateRepo.ListVisible(ctx, actor.OwnerID())
+}
+
+// GetTemplate returns a template the actor can see
+func (s *ContainerTemplateService) GetTemplate(ctx context.Context, actor model.Actor, id int) (*model.ContainerTemplate, error) {
+	template, err := s.templateRepo.GetByID(ctx, id)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+	// Other users' private templates are not found rather than forbidden
+	if err != nil || !template.VisibleTo(actor.OwnerID()) {
+		return nil, apperrors.Newf(apperrors.CodeNotFound, "container template %d not found", id)
+	}
+	return template, nil
+}
+
+// CreateTemplate creates a template owned by the actor
+func (s *ContainerTemplateService) CreateTemplate(ctx context.Context, actor model.Actor, req *dto.ContainerTemplateRequest) (*model.ContainerTemplate, error) {
+	if actor.UserID == nil {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "container templates are created by users")
+	}
+	if req.Shared && !s.isAdmin(ctx, actor) {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "only admins can share container templates")
+	}
+
+	template := &model.ContainerTemplate{OwnerID: actor.OwnerID()}
+	if err := applyTemplateRequest(template, req); err != nil {
+		return nil, err
+	}
+	if err := s.templateRepo.Create(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.logTemplateActivity(actor, "container_template_create", template, "Container template created", nil)
+	return template, nil
+}
+
+// UpdateTemplate replaces a template. Owners change their private templates,
+// admins shared ones; built-in templates cannot be changed.
+func (s *ContainerTemplateService) UpdateTemplate(ctx context.Context, actor model.Actor, id int, req *dto.ContainerTemplateRequest) (*model.ContainerTemplate, error) {
+	template, err := s.manageableTemplate(ctx, actor, id, "changed")
+	if err != nil {
+		return nil, err
+	}
+	if req.Shared != template.Shared && !s.isAdmin(ctx, actor) {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "only admins can share container templates")
+	}
+
+	if err := applyTemplateRequest(template, req); err != nil {
+		return nil, err
+	}
+	if err := s.templateRepo.Update(ctx, template); err != nil {
+		return nil, err
+	}
+
+	s.logTemplateActivity(actor, "container_template_update", template, "Container template updated", nil)
+	return template, nil
+}
+
+// DeleteTemplate deletes a template on the same terms as UpdateTemplate
+func (s *ContainerTemplateService) DeleteTemplate(ctx context.Context, actor model.Actor, id int) error {
+	template, err := s.manageableTemplate(ctx, actor, id, "deleted")
+	if err != nil {
+		return err
+	}
+	if err := s.templateRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logTemplateActivity(actor, "container_template_delete", template, "Container template deleted", nil)
+	return nil
+}
+
+// Instantiate renders a template with the request's variables and creates
+// the container it describes
+func (s *ContainerTemplateService) Instantiate(ctx context.Context, actor model.Actor, id int, req *dto.InstantiateTemplateRequest) (*model.Container, error) {
+	template, err := s.GetTemplate(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+
+	create, err := renderTemplate(template, req)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	container, err := s.containerService.CreateContainer(ctx, actor, create)
+	if err != nil {
+		return nil, err
+	}
+
+	// Values may be secrets; only which variables were given is logged
+	given := make([]string, 0, len(req.Variables))
+	for name := range req.Variables {
+		given = append(given, name)
+	}
+	sort.Strings(given)
+	s.logTemplateActivity(actor, "container_template_instantiate", template, fmt.Sprintf("Container %s created from template", container.Name), map[string]interface{}{
+		"container_id":   container.ID,
+		"container_name": container.Name,
+		"variables":      given,
+	})
+	return container, nil
+}
+
+// manageableTemplate returns a template the actor may change or delete
+func (s *ContainerTemplateService) manageableTemplate(ctx context.Context, actor model.Actor, id int, action string) (*model.ContainerTemplate, error) {
+	template, err := s.GetTemplate(ctx, actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if template.BuiltIn {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "built-in template %s cannot be %s", template.Name, action)
+	}
+	if template.Shared && !s.isAdmin(ctx, actor) {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "only admins can change shared container templates")
+	}
+	return template, nil
+}
+
+func (s *ContainerTemplateService) isAdmin(ctx context.Context, actor model.Actor) bool {
+	if actor.UserID == nil || s.userRepo == nil {
+		return false
+	}
+	user, err := s.userRepo.GetByID(ctx, *actor.UserID)
+	return err == nil && user.IsAdmin()
+}
+
+// applyTemplateRequest copies a request onto template and checks that the
+// template renders a valid create request, with sample values for its
+// required variables
+func applyTemplateRequest(template *model.ContainerTemplate, req *dto.ContainerTemplateRequest) error {
+	template.Name = req.Name
+	template.Description = strings.TrimSpace(req.Description)
+	template.Image = req.Image
+	template.DefaultTag = req.DefaultTag
+	template.Config = model.JSONMap(req.Config)
+	template.Variables = model.TemplateVariables(req.Variables)
+	template.Shared = req.Shared
+	if template.Config == nil {
+		template.Config = model.JSONMap{}
+	}
+	if template.Variables == nil {
+		template.Variables = model.TemplateVariables{}
+	}
+
+	if err := template.Validate(); err != nil {
+		return apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	samples := make(map[string]string)
+	for _, variable := range template.Variables {
+		if !variable.Required() {
+			continue
+		}
+		samples[variable.Name] = "sample"
+		if variable.Type == model.TemplateVariableNumber {
+			samples[variable.Name] = "1"
+		}
+	}
+	if _, err := renderTemplate(template, &dto.InstantiateTemplateRequest{Name: template.Name, Variables: samples}); err != nil {
+		return apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request: template does not render a valid container")
+	}
+	return nil
+}
+
+// renderTemplate renders a template into the request creating the container
+func renderTemplate(template *model.ContainerTemplate, req *dto.InstantiateTemplateRequest) (*dto.CreateContainerRequest, error) {
+	config, err := template.Render(req.Variables)
+	if err != nil {
+		return nil, err
+	}
+
+	encoded, err := json.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode rendered config: %w", err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(encoded))
+	decoder.DisallowUnknownFields()
+	var create dto.CreateContainerRequest
+	if err := decoder.Decode(&create); err != nil {
+		return nil, fmt.Errorf("rendered config: %w", err)
+	}
+
+	create.Name = strings.TrimSpace(req.Name)
+	create.Image = template.Image
+	create.Tag = template.DefaultTag
+	if req.Tag != "" {
+		create.Tag = req.Tag
+	}
+	if req.TeamID != nil {
+		create.TeamID = req.TeamID
+	}
+	if req.HostID != nil {
+		create.HostID = req.HostID
+	}
+
+	if err := create.Validate(); err != nil {
+		return nil, err
+	}
+	return &create, nil
+}
+
+func (s *ContainerTemplateService) logTemplateActivity(actor model.Actor, action string, template *model.ContainerTemplate, description string, metadata map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+	}
+	metadata["shared"] = template.Shared
+	encoded, _ := json.Marshal(metadata)
+
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "container_template",
+		ResourceID:   &template.ID,
+		ResourceName: template.Name,
+		Description:  description,
+		Metadata:     string(encoded),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("template_id", template.ID).Warn("Failed to log container template activity")
+	}
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+)
+
+// retagRepo serves one container and counts the updates stored for it
+type retagRepo struct {
+	repository.ContainerRepository
+	container model.Container
+	updates   int
+}
+
+func (r *retagRepo) GetByID(ctx context.Context, id int64) (*model.Container, error) {
+	container := r.container
+	return &container, nil
+}
+
+func (r *retagRepo) Update(ctx context.Context, container *model.Container) error {
+	r.updates++
+	r.container = *container
+	return nil
+}
+
+// countingHistoryRepo counts the update histories created
+type countingHistoryRepo struct {
+	repository.UpdateHistoryRepository
+	created int
+}
+
+func (r *countingHistoryRepo) Create(ctx context.Context, history *model.UpdateHistory) error {
+	r.created++
+	return nil
+}
+
+func TestUpdateContainerImageKeepsTagUntilContainerIsRecreated(t *testing.T) {
+	ctx := context.Background()
+
+	// None of these strategies replace the Docker container yet
+	strategies := []model.UpdateStrategy{"", model.UpdateStrategyRecreate, model.UpdateStrategyRolling, model.UpdateStrategyBlueGreen}
+	for _, strategy := range strategies {
+		t.Run(string(strategy), func(t *testing.T) {
+			containers := &retagRepo{container: model.Container{ID: 4, Name: "web", Image: "nginx", Tag: "1.25", ContainerID: "abc123"}}
+			histories := &countingHistoryRepo{}
+			s := &ContainerService{containerRepo: containers, updateHistoryRepo: histories}
+
+			_, err := s.UpdateContainerImage(ctx, model.SystemActor("test"), 4, &dto.UpdateImageRequest{Strategy: string(strategy), Tag: "1.26"})
+			if !apperrors.HasCode(err, apperrors.CodeNotImplemented) {
+				t.Fatalf("UpdateContainerImage = %v, want a not implemented error", err)
+			}
+			if containers.updates != 0 || containers.container.Tag != "1.25" {
+				t.Errorf("stored %d updates, tag %s, want the container left at 1.25", containers.updates, containers.container.Tag)
+			}
+			if histories.created != 0 {
+				t.Errorf("recorded %d updates that did not run", histories.created)
+			}
+		})
+	}
+}
@@ -0,0 +1,437 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+)
+
+// recordAppliedUpdate drops the cached update check of a container whose
+// update completed, so the update it applied no longer shows as available,
+// and resets its count of failed automatic updates
+func (s *ContainerService) recordAppliedUpdate(ctx context.Context, container *model.Container, history *model.UpdateHistory) {
+	if history.Status != model.UpdateStatusCompleted {
+		return
+	}
+	if s.imageService != nil {
+		s.imageService.ClearUpdateInfo(container)
+	}
+	if container.ResetUpdateFailures() {
+		if err := s.containerRepo.SaveUpdateFailures(ctx, container); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to reset container update failures")
+		}
+	}
+}
+
+// CheckContainerUpdates compares the image digest each container runs with
+// the digest its tag resolves to in the registry. Containers on the same
+// image and tag share one registry lookup, at most MaxConcurrency lookups
+// run at once, and cached versions are reused unless Force is set. A
+// container that cannot be checked reports its error in its entry.
+func (s *ContainerService) CheckContainerUpdates(ctx context.Context, actor model.Actor, req *dto.UpdateCheckRequest) (*dto.UpdateCheckResult, error) {
+	if s.imageService == nil {
+		return nil, fmt.Errorf("image update checks are not configured")
+	}
+	if req == nil {
+		req = &dto.UpdateCheckRequest{}
+	}
+
+	checks, containers, err := s.updateCheckTargets(ctx, actor, req.ContainerIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	// Group by image and tag, keeping the order containers were listed in
+	var keys []string
+	groups := make(map[string][]int)
+	for i, container := range containers {
+		if container == nil {
+			continue
+		}
+		key := container.GetFullImageName()
+		if _, exists := groups[key]; !exists {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	limit := s.config.ImageCheck.MaxConcurrentChecks
+	if limit <= 0 {
+		limit = 10
+	}
+	if req.MaxConcurrency > 0 && req.MaxConcurrency < limit {
+		limit = req.MaxConcurrency
+	}
+
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(indexes []int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.checkImageGroup(ctx, containers, checks, indexes, req.Force)
+		}(groups[key])
+	}
+	wg.Wait()
+
+	result := &dto.UpdateCheckResult{Containers: checks}
+	for _, check := range checks {
+		switch {
+		case check.Error != "":
+			result.Failed++
+		case check.UpdateAvailable:
+			result.UpdatesAvailable++
+		}
+		if check.Error == "" {
+			result.Checked++
+		}
+		if check.Cached {
+			result.Cached++
+		}
+	}
+
+	s.logUserActivity(actor, "container_update_check", fmt.Sprintf("Checked %d containers for image updates", len(checks)), map[string]interface{}{
+		"checked":           result.Checked,
+		"updates_available": result.UpdatesAvailable,
+		"failed":            result.Failed,
+		"cached":            result.Cached,
+		"force":             req.Force,
+	})
+
+	return result, nil
+}
+
+// updateCheckTargets returns an entry per container to check and the
+// containers themselves; containers that cannot be read or belong to
+// someone else have a nil container and an entry with the error
+func (s *ContainerService) updateCheckTargets(ctx context.Context, actor model.Actor, ids []int64) ([]dto.ContainerUpdateCheck, []*model.Container, error) {
+	if len(ids) == 0 {
+		access, err := s.containerAccessFor(ctx, actor)
+		if err != nil {
+			return nil, nil, err
+		}
+		if access.none() {
+			return []dto.ContainerUpdateCheck{}, nil, nil
+		}
+		filter := &model.ContainerFilter{}
+		access.scope(filter)
+		containers, _, err := s.containerRepo.List(ctx, filter)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get containers: %w", err)
+		}
+		checks := make([]dto.ContainerUpdateCheck, len(containers))
+		for i, container := range containers {
+			checks[i] = newContainerUpdateCheck(container)
+		}
+		return checks, containers, nil
+	}
+
+	seen := make(map[int64]bool)
+	var checks []dto.ContainerUpdateCheck
+	var containers []*model.Container
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		container, err := s.containerRepo.GetByID(ctx, id)
+		if err == nil {
+			err = s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView)
+		}
+		if err != nil {
+			checks = append(checks, dto.ContainerUpdateCheck{ContainerID: id, CheckedAt: time.Now(), Error: err.Error()})
+			containers = append(containers, nil)
+			continue
+		}
+		checks = append(checks, newContainerUpdateCheck(container))
+		containers = append(containers, container)
+	}
+	return checks, containers, nil
+}
+
+func newContainerUpdateCheck(container *model.Container) dto.ContainerUpdateCheck {
+	tag := container.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+	return dto.ContainerUpdateCheck{
+		ContainerID: int64(container.ID),
+		Name:        container.Name,
+		Image:       container.Image,
+		CurrentTag:  tag,
+	}
+}
+
+// checkImageGroup resolves the latest digest of one image and tag and
+// compares it with what each of its containers runs
+func (s *ContainerService) checkImageGroup(ctx context.Context, containers []*model.Container, checks []dto.ContainerUpdateCheck, indexes []int, force bool) {
+	latest, cached, err := s.imageService.ResolveLatestVersion(ctx, containers[indexes[0]], force)
+
+	for _, i := range indexes {
+		check := &checks[i]
+		if err != nil {
+			check.CheckedAt = time.Now()
+			check.Error = err.Error()
+			continue
+		}
+
+		// A newer tag is reported only when the version policy allows it
+		check.LatestTag = latest.Tag
+		if tag := latestVersionTag(latest); tag != "" && containers[i].GetVersionPolicy().AllowsTag(latest.Tag, tag) {
+			check.LatestTag = tag
+		}
+		check.LatestDigest = latest.Digest
+		check.CheckedAt = latest.CheckedAt
+		check.Cached = cached
+
+		current, digestErr := s.runningImageDigest(ctx, containers[i])
+		if digestErr != nil {
+			check.Error = digestErr.Error()
+			continue
+		}
+		check.CurrentDigest = current
+		check.UpdateAvailable = current != latest.Digest && checkVersionPolicy(containers[i], "") == nil
+	}
+
+	if err != nil {
+		logrus.WithError(err).WithField("image", containers[indexes[0]].GetFullImageName()).Warn("Failed to check image for updates")
+	}
+}
+
+// latestVersionTag reads the newer tag a version's tag resolved to, if any
+func latestVersionTag(version *model.ImageVersion) string {
+	var metadata struct {
+		LatestTag string `json:"latest_tag"`
+	}
+	if version.Metadata == "" || json.Unmarshal([]byte(version.Metadata), &metadata) != nil {
+		return ""
+	}
+	return metadata.LatestTag
+}
+
+// runningImageDigest returns the registry digest of the image the container
+// runs, read from Docker rather than the stored configuration
+func (s *ContainerService) runningImageDigest(ctx context.Context, container *model.Container) (string, error) {
+	if container.ContainerID == "" {
+		return "", fmt.Errorf("container is not deployed")
+	}
+
+	dc, err := s.dockerFor(ctx, container)
+	if err != nil {
+		return "", err
+	}
+
+	live, err := dc.GetContainer(ctx, container.ContainerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container: %w", err)
+	}
+	image, err := dc.InspectImage(ctx, live.Image)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	repository := model.NormalizeRepository(container.Image)
+	for _, repoDigest := range image.RepoDigests {
+		name, _, digest := model.ParseImageReference(repoDigest)
+		if digest != "" && model.NormalizeRepository(name) == repository {
+			return digest, nil
+		}
+	}
+	return "", fmt.Errorf("running image has no registry digest for %s; it was built or loaded locally", container.Image)
+}
+
+// checkVersionPolicy rejects an update of the container to tag, its current
+// tag when empty, that its version policy does not allow
+func checkVersionPolicy(container *model.Container, tag string) error {
+	current := container.Tag
+	if current == "" {
+		current = "latest"
+	}
+	if tag == "" {
+		tag = current
+	}
+
+	policy := container.GetVersionPolicy()
+	if policy.AllowsTag(current, tag) {
+		return nil
+	}
+	if policy == model.VersionPolicyPinned {
+		return apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: container is pinned by its version policy")
+	}
+	return apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: version policy %s does not allow updating from %s to %s", policy, current, tag)
+}
+
+// logWindowOverride records a manual update of a container whose maintenance
+// windows are closed. Windows only hold back automatic updates, so the update
+// goes ahead.
+func (s *ContainerService) logWindowOverride(ctx context.Context, actor model.Actor, container *model.Container) {
+	effective, err := s.EffectivePolicy(ctx, container)
+	if err != nil || len(effective.MaintenanceWindows) == 0 {
+		return
+	}
+
+	now := time.Now()
+	windows := model.ParseMaintenanceWindows(effective.MaintenanceWindows)
+	if windows.IsOpen(now) {
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"maintenance_windows": effective.MaintenanceWindows,
+	}
+	if next, ok := windows.NextOpen(now); ok {
+		metadata["next_window_start"] = next.Start
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"container_id": container.ID,
+		"actor":        actor.String(),
+	}).Info("Manual update overrides closed maintenance window")
+	s.logContainerActivity(actor, int64(container.ID), "maintenance_window_overridden",
+		fmt.Sprintf("Updated container %s outside its maintenance windows", container.Name), metadata)
+}
+
+// PlanContainerUpdate runs the checks of an image update, the version policy,
+// the registry lookup and the configuration diff, without pulling or
+// recreating anything. No update history is written; the plan is logged as a
+// dry run in the activity log.
+func (s *ContainerService) PlanContainerUpdate(ctx context.Context, actor model.Actor, containerID int64, req *dto.UpdateImageRequest) (*dto.UpdatePlan, error) {
+	if req == nil {
+		req = &dto.UpdateImageRequest{}
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+	if err := checkVersionPolicy(container, req.Tag); err != nil {
+		return nil, err
+	}
+	if s.imageService == nil {
+		return nil, fmt.Errorf("image update checks are not configured")
+	}
+
+	plan := &dto.UpdatePlan{
+		ContainerID:   containerID,
+		Name:          container.Name,
+		Strategy:      req.Strategy,
+		VersionPolicy: container.GetVersionPolicy(),
+		OldImage:      container.GetDeployImageRef(),
+		Changes:       []dto.ConfigChange{},
+	}
+	if plan.Strategy == "" {
+		plan.Strategy = string(model.UpdateStrategyRecreate)
+	}
+
+	target := *container
+	if req.Tag != "" {
+		target.Tag = req.Tag
+	}
+	latest, _, err := s.imageService.ResolveLatestVersion(ctx, &target, true)
+	if err != nil {
+		return nil, err
+	}
+	if target.PinByDigest {
+		target.ImageDigest = latest.Digest
+	}
+	plan.NewImage = target.GetDeployImageRef()
+	plan.NewDigest = latest.Digest
+	plan.UpdateAvailable = true
+
+	if container.ContainerID == "" {
+		plan.Warnings = append(plan.Warnings, "container is not deployed; the update would create it")
+	} else {
+		if digest, err := s.runningImageDigest(ctx, container); err != nil {
+			plan.Warnings = append(plan.Warnings, err.Error())
+		} else {
+			plan.OldDigest = digest
+			plan.UpdateAvailable = digest != latest.Digest || target.Tag != container.Tag
+		}
+
+		if dc, err := s.dockerFor(ctx, container); err != nil {
+			plan.Warnings = append(plan.Warnings, err.Error())
+		} else if report, live, err := s.detectDrift(ctx, dc, container, nil); err != nil {
+			plan.Warnings = append(plan.Warnings, err.Error())
+		} else {
+			for _, field := range report.Fields {
+				if field.Field == "image" || field.Field == "image_digest" {
+					continue
+				}
+				plan.Changes = append(plan.Changes, dto.ConfigChange{
+					Field: field.Field, Key: field.Key, Change: field.Change,
+					Current: field.Actual, Desired: field.Desired, Secret: field.Secret,
+				})
+			}
+			plan.EstimatedDowntimeSeconds = estimatedDowntime(container, live)
+		}
+	}
+
+	s.logContainerActivity(actor, containerID, "image_update_planned", "Container image update planned (dry run)", map[string]interface{}{
+		"dry_run":          true,
+		"old_image":        plan.OldImage,
+		"new_image":        plan.NewImage,
+		"old_digest":       plan.OldDigest,
+		"new_digest":       plan.NewDigest,
+		"update_available": plan.UpdateAvailable,
+		"changes":          len(plan.Changes),
+	})
+
+	return plan, nil
+}
+
+// estimatedDowntime is the stop timeout of a running container plus the time
+// its replacement takes to start: the health check start period and warmup
+func estimatedDowntime(container *model.Container, live *types.ContainerJSON) int {
+	if live.State == nil || !live.State.Running {
+		return 0
+	}
+	seconds := container.StopTimeout() + container.WarmupSeconds
+	if live.Config.Healthcheck != nil {
+		seconds += int(live.Config.Healthcheck.StartPeriod / time.Second)
+	}
+	return seconds
+}
+
+// ResumeUpdates lifts the suspension repeated failures put on automatic
+// updates of the container and resets its failure count
+func (s *ContainerService) ResumeUpdates(ctx context.Context, actor model.Actor, containerID int64) (*model.Container, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionOperate); err != nil {
+		return nil, err
+	}
+
+	if !container.UpdateSuspended {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: automatic updates of container %s are not suspended", container.Name)
+	}
+
+	failures, lastError := container.UpdateFailures, container.LastUpdateError
+	container.ResetUpdateFailures()
+	if err := s.containerRepo.SaveUpdateFailures(ctx, container); err != nil {
+		return nil, err
+	}
+
+	s.logContainerActivity(actor, containerID, "update_resume",
+		fmt.Sprintf("Resumed automatic updates of container %s", container.Name),
+		map[string]interface{}{"failures": failures, "last_error": lastError})
+
+	return container, nil
+}
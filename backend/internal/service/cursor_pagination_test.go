@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// walkCursor follows next cursors from the first page, inserting a row before
+// each later page, and returns the IDs read
+func walkCursor(t *testing.T, insert func(), page func(cursor *model.Cursor) ([]int64, string)) []int64 {
+	t.Helper()
+
+	var read []int64
+	cursor := &model.Cursor{}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("cursor never ran out")
+		}
+		ids, next := page(cursor)
+		read = append(read, ids...)
+		if next == "" {
+			return read
+		}
+		insert()
+
+		var err error
+		if cursor, err = model.DecodeCursor(next); err != nil {
+			t.Fatalf("next cursor %q: %v", next, err)
+		}
+	}
+}
+
+func TestCursorPagingOfExecutionsAndNotifications(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &model.User{}, &model.ScheduledTask{}, &model.TaskExecutionLog{}, &model.UserNotification{})
+	if err := db.Create(&model.User{ID: 1, Username: "owner", Email: "owner@example.com", Role: model.UserRoleOperator}).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+	owner := 1
+	taskRepo := repository.NewScheduledTaskRepository(db)
+	task := &model.ScheduledTask{Name: "cleanup", Type: model.TaskTypeCleanup, CronExpression: "@every 1s", CreatedBy: &owner}
+	if err := taskRepo.Create(ctx, task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+
+	executionRepo := repository.NewTaskExecutionLogRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	userID := int64(owner)
+	start := time.Now().Add(-time.Hour).UTC()
+	var wantExecutions, wantNotifications []int64
+	// Rows share timestamps in pairs, so pages must break ties by ID
+	for i := 0; i < 7; i++ {
+		at := start.Add(time.Duration(i/2) * time.Minute)
+		execution := &model.TaskExecutionLog{TaskID: task.ID, Status: model.ExecutionStatusSuccess, StartedAt: at}
+		if err := executionRepo.Create(ctx, execution); err != nil {
+			t.Fatal(err)
+		}
+		notification := &model.UserNotification{UserID: &userID, Type: "info", Title: "t", Message: "m", CreatedAt: at}
+		if err := notificationRepo.Create(ctx, notification); err != nil {
+			t.Fatal(err)
+		}
+		wantExecutions = append([]int64{int64(execution.ID)}, wantExecutions...)
+		wantNotifications = append([]int64{notification.ID}, wantNotifications...)
+	}
+
+	scheduler := &SchedulerService{
+		taskRepo:         taskRepo,
+		executionLogRepo: executionRepo,
+		userService:      &UserService{userRepo: repository.NewUserRepository(db)},
+	}
+	executions := walkCursor(t, func() {
+		executionRepo.Create(ctx, &model.TaskExecutionLog{TaskID: task.ID, Status: model.ExecutionStatusRunning, StartedAt: time.Now().UTC()})
+	}, func(cursor *model.Cursor) ([]int64, string) {
+		response, err := scheduler.GetTaskExecutions(ctx, userID, int64(task.ID), &ExecutionFilter{Limit: 3, Cursor: cursor})
+		if err != nil {
+			t.Fatalf("GetTaskExecutions failed: %v", err)
+		}
+		if response.HasNext != (response.NextCursor != "") || response.HasPrev != !cursor.IsZero() {
+			t.Errorf("page after %+v has next %v, prev %v with next cursor %q", cursor, response.HasNext, response.HasPrev, response.NextCursor)
+		}
+		ids := make([]int64, len(response.Executions))
+		for i, execution := range response.Executions {
+			ids[i] = int64(execution.ID)
+		}
+		return ids, response.NextCursor
+	})
+	if !reflect.DeepEqual(executions, wantExecutions) {
+		t.Errorf("executions read %v, want %v", executions, wantExecutions)
+	}
+
+	notifications := &NotificationService{notificationRepo: notificationRepo, logger: logrus.New()}
+	read := walkCursor(t, func() {
+		notificationRepo.Create(ctx, &model.UserNotification{UserID: &userID, Type: "info", Title: "new", Message: "m", CreatedAt: time.Now().UTC()})
+	}, func(cursor *model.Cursor) ([]int64, string) {
+		page, next, err := notifications.GetNotificationsAfter(ctx, &model.UserNotificationFilter{UserID: &userID}, cursor, 3)
+		if err != nil {
+			t.Fatalf("GetNotificationsAfter failed: %v", err)
+		}
+		ids := make([]int64, len(page))
+		for i, notification := range page {
+			ids[i] = notification.ID
+		}
+		return ids, next
+	})
+	if !reflect.DeepEqual(read, wantNotifications) {
+		t.Errorf("notifications read %v, want %v", read, wantNotifications)
+	}
+
+	// Another user cannot page through the task's executions
+	if _, err := scheduler.GetTaskExecutions(ctx, 2, int64(task.ID), &ExecutionFilter{Limit: 3, Cursor: &model.Cursor{}}); err == nil {
+		t.Error("a stranger paged through the executions")
+	}
+}
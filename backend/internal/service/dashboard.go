@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// DefaultDashboardRecentUpdates is how many recent updates the dashboard
+	// lists unless asked for another number
+	DefaultDashboardRecentUpdates = 10
+
+	// MaxDashboardRecentUpdates is the most recent updates the dashboard lists
+	MaxDashboardRecentUpdates = 50
+
+	// dashboardPartTimeout bounds each part of the dashboard, so an
+	// unreachable Docker daemon does not hold up the rest
+	dashboardPartTimeout = 5 * time.Second
+)
+
+// DashboardUpdate is an update in the dashboard's recent updates
+type DashboardUpdate struct {
+	ID              int                `json:"id"`
+	ContainerID     int                `json:"container_id"`
+	ContainerName   string             `json:"container_name,omitempty"`
+	OldImage        string             `json:"old_image,omitempty"`
+	NewImage        string             `json:"new_image"`
+	Status          model.UpdateStatus `json:"status"`
+	TriggeredBy     model.TriggerType  `json:"triggered_by"`
+	ErrorMessage    string             `json:"error_message,omitempty"`
+	DurationSeconds int                `json:"duration_seconds"`
+	StartedAt       time.Time          `json:"started_at"`
+	CompletedAt     *time.Time         `json:"completed_at,omitempty"`
+}
+
+// Dashboard is the landing page summary. A part that could not be read is
+// null and named in Warnings; the others are still filled in.
+type Dashboard struct {
+	ContainersByStatus  map[model.ContainerStatus]int64 `json:"containers_by_status"`
+	ContainersTotal     *int64                          `json:"containers_total"`
+	UpdatesAvailable    *int64                          `json:"updates_available"`
+	RecentUpdates       []DashboardUpdate               `json:"recent_updates"`
+	Scheduler           *SchedulerStatus                `json:"scheduler"`
+	DiskUsage           *docker.DiskUsageSummary        `json:"disk_usage"`
+	UnreadNotifications *int64                          `json:"unread_notifications"`
+	Warnings            []string                        `json:"warnings"`
+	GeneratedAt         time.Time                       `json:"generated_at"`
+}
+
+// DashboardService gathers the landing page summary
+type DashboardService struct {
+	containerRepo       repository.ContainerRepository
+	updateHistoryRepo   repository.UpdateHistoryRepository
+	schedulerService    *SchedulerService
+	notificationService *NotificationService
+	dockerClient        *docker.DockerClient
+}
+
+// NewDashboardService creates a new dashboard service instance
+func NewDashboardService(
+	containerRepo repository.ContainerRepository,
+	updateHistoryRepo repository.UpdateHistoryRepository,
+	schedulerService *SchedulerService,
+	notificationService *NotificationService,
+	dockerClient *docker.DockerClient,
+) *DashboardService {
+	return &DashboardService{
+		containerRepo:       containerRepo,
+		updateHistoryRepo:   updateHistoryRepo,
+		schedulerService:    schedulerService,
+		notificationService: notificationService,
+		dockerClient:        dockerClient,
+	}
+}
+
+// GetDashboard reads the parts of the dashboard concurrently. A failing part
+// is left out with a warning; only a cancelled request fails the whole call.
+func (s *DashboardService) GetDashboard(ctx context.Context, userID int64, recentUpdates int) (*Dashboard, error) {
+	if recentUpdates < 1 || recentUpdates > MaxDashboardRecentUpdates {
+		return nil, fmt.Errorf("invalid request: recent_updates must be between 1 and %d", MaxDashboardRecentUpdates)
+	}
+
+	dashboard := &Dashboard{Warnings: []string{}}
+	var mu sync.Mutex
+
+	g, gctx := errgroup.WithContext(ctx)
+	part := func(name string, read func(ctx context.Context) (func(), error)) {
+		g.Go(func() error {
+			partCtx, cancel := context.WithTimeout(gctx, dashboardPartTimeout)
+			defer cancel()
+
+			apply, err := read(partCtx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+				logrus.WithError(err).WithField("part", name).Warn("Dashboard part unavailable")
+				dashboard.Warnings = append(dashboard.Warnings, name+" unavailable")
+				return nil
+			}
+			apply()
+			return nil
+		})
+	}
+
+	part("container counts", func(ctx context.Context) (func(), error) {
+		counts, err := s.containerRepo.CountByStatus(ctx)
+		if err != nil {
+			return nil, err
+		}
+		var total int64
+		for _, count := range counts {
+			total += count
+		}
+		return func() {
+			dashboard.ContainersByStatus = counts
+			dashboard.ContainersTotal = &total
+		}, nil
+	})
+
+	part("available updates", func(ctx context.Context) (func(), error) {
+		count, err := s.containerRepo.CountWithCachedUpdates(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return func() { dashboard.UpdatesAvailable = &count }, nil
+	})
+
+	part("recent updates", func(ctx context.Context) (func(), error) {
+		histories, err := s.updateHistoryRepo.GetRecent(ctx, recentUpdates)
+		if err != nil {
+			return nil, err
+		}
+		updates := make([]DashboardUpdate, 0, len(histories))
+		for _, history := range histories {
+			updates = append(updates, DashboardUpdate{
+				ID:              history.ID,
+				ContainerID:     history.ContainerID,
+				ContainerName:   history.Container.Name,
+				OldImage:        history.OldImage,
+				NewImage:        history.NewImage,
+				Status:          history.Status,
+				TriggeredBy:     history.TriggeredBy,
+				ErrorMessage:    history.ErrorMessage,
+				DurationSeconds: history.DurationSeconds,
+				StartedAt:       history.StartedAt,
+				CompletedAt:     history.CompletedAt,
+			})
+		}
+		return func() { dashboard.RecentUpdates = updates }, nil
+	})
+
+	if s.schedulerService != nil {
+		part("scheduler status", func(ctx context.Context) (func(), error) {
+			status, err := s.schedulerService.GetSchedulerStatus(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return func() { dashboard.Scheduler = status }, nil
+		})
+	}
+
+	if s.dockerClient != nil {
+		part("disk usage", func(ctx context.Context) (func(), error) {
+			usage, err := s.dockerClient.GetDiskUsage(ctx)
+			if err != nil {
+				return nil, err
+			}
+			return func() { dashboard.DiskUsage = usage }, nil
+		})
+	}
+
+	if s.notificationService != nil {
+		part("unread notifications", func(ctx context.Context) (func(), error) {
+			count, err := s.notificationService.GetUnreadCount(ctx, userID)
+			if err != nil {
+				return nil, err
+			}
+			return func() { dashboard.UnreadNotifications = &count }, nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Strings(dashboard.Warnings)
+	dashboard.GeneratedAt = time.Now()
+	return dashboard, nil
+}
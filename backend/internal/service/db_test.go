@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newTestDB opens an in-memory SQLite database with models migrated. It is
+// closed when the test ends.
+func newTestDB(tb testing.TB, models ...interface{}) *gorm.DB {
+	tb.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		tb.Fatalf("failed to open database: %v", err)
+	}
+
+	// Every connection to :memory: opens a database of its own
+	sqlDB, err := db.DB()
+	if err != nil {
+		tb.Fatalf("failed to get connection pool: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	tb.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(models...); err != nil {
+		tb.Fatalf("failed to migrate: %v", err)
+	}
+	// As in database/init.sql, container_id is not unique: containers not
+	// yet created in Docker share an empty one
+	if err := db.Exec("DROP INDEX IF EXISTS idx_containers_container_id").Error; err != nil {
+		tb.Fatalf("failed to drop index: %v", err)
+	}
+	return db
+}
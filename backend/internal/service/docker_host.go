@@ -0,0 +1,238 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DockerHostService manages the remote Docker daemons containers can run on
+type DockerHostService struct {
+	hostRepo      repository.DockerHostRepository
+	containerRepo repository.ContainerRepository
+	activityRepo  repository.ActivityLogRepository
+	hostPool      *docker.HostPool
+}
+
+// NewDockerHostService creates a new Docker host service instance
+func NewDockerHostService(
+	hostRepo repository.DockerHostRepository,
+	containerRepo repository.ContainerRepository,
+	activityRepo repository.ActivityLogRepository,
+	hostPool *docker.HostPool,
+) *DockerHostService {
+	return &DockerHostService{
+		hostRepo:      hostRepo,
+		containerRepo: containerRepo,
+		activityRepo:  activityRepo,
+		hostPool:      hostPool,
+	}
+}
+
+// ListHosts lists every Docker host by name
+func (s *DockerHostService) ListHosts(ctx context.Context) ([]*model.DockerHost, error) {
+	return s.hostRepo.List(ctx, false)
+}
+
+// GetHost returns a Docker host
+func (s *DockerHostService) GetHost(ctx context.Context, id int) (*model.DockerHost, error) {
+	return s.hostRepo.GetByID(ctx, id)
+}
+
+// CreateHost adds a Docker host. Its daemon is not contacted until a
+// container on it is used or the host is pinged.
+func (s *DockerHostService) CreateHost(ctx context.Context, actor model.Actor, req *dto.CreateDockerHostRequest) (*model.DockerHost, error) {
+	host := &model.DockerHost{
+		Name:       strings.TrimSpace(req.Name),
+		Endpoint:   strings.TrimSpace(req.Endpoint),
+		TLSCACert:  req.TLSCACert,
+		TLSCert:    req.TLSCert,
+		TLSKey:     req.TLSKey,
+		SSHKeyPath: req.SSHKeyPath,
+		Enabled:    req.Enabled == nil || *req.Enabled,
+		CreatedBy:  actor.OwnerID(),
+	}
+
+	if err := s.validateHost(ctx, host); err != nil {
+		return nil, err
+	}
+	if err := s.hostRepo.Create(ctx, host); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(actor, "docker_host_create", host, fmt.Sprintf("Added Docker host %s", host.Name), nil)
+	return host, nil
+}
+
+// UpdateHost changes a Docker host. A cached client is rebuilt on next use
+// when the connection settings changed, and dropped when the host is
+// disabled.
+func (s *DockerHostService) UpdateHost(ctx context.Context, actor model.Actor, id int, req *dto.UpdateDockerHostRequest) (*model.DockerHost, error) {
+	host, err := s.hostRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		host.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Endpoint != nil {
+		host.Endpoint = strings.TrimSpace(*req.Endpoint)
+	}
+	if req.TLSCACert != nil {
+		host.TLSCACert = *req.TLSCACert
+	}
+	if req.TLSCert != nil {
+		host.TLSCert = *req.TLSCert
+	}
+	if req.TLSKey != nil {
+		host.TLSKey = *req.TLSKey
+	}
+	if req.SSHKeyPath != nil {
+		host.SSHKeyPath = *req.SSHKeyPath
+	}
+	if req.Enabled != nil {
+		host.Enabled = *req.Enabled
+	}
+
+	if err := s.validateHost(ctx, host); err != nil {
+		return nil, err
+	}
+	if err := s.hostRepo.Update(ctx, host); err != nil {
+		return nil, err
+	}
+	if !host.Enabled {
+		s.hostPool.Remove(host.ID)
+	}
+
+	s.logActivity(actor, "docker_host_update", host, fmt.Sprintf("Updated Docker host %s", host.Name), nil)
+	return host, nil
+}
+
+// DeleteHost removes a Docker host. It fails while containers are attached
+// unless force is set; forcing removes their records, leaving the Docker
+// containers on the daemon untouched.
+func (s *DockerHostService) DeleteHost(ctx context.Context, actor model.Actor, id int, force bool) error {
+	host, err := s.hostRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	attached, err := s.hostRepo.CountContainers(ctx, id)
+	if err != nil {
+		return err
+	}
+	if attached > 0 && !force {
+		return fmt.Errorf("docker host %s still has %d containers attached; remove them or force the deletion", host.Name, attached)
+	}
+
+	if attached > 0 {
+		containers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{HostID: &id})
+		if err != nil {
+			return err
+		}
+		for _, container := range containers {
+			if err := s.containerRepo.Delete(ctx, int64(container.ID)); err != nil {
+				return fmt.Errorf("failed to remove container %s of docker host %s: %w", container.Name, host.Name, err)
+			}
+		}
+	}
+
+	if err := s.hostRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.hostPool.Remove(id)
+
+	s.logActivity(actor, "docker_host_delete", host, fmt.Sprintf("Removed Docker host %s", host.Name), map[string]interface{}{
+		"forced":             force,
+		"removed_containers": attached,
+	})
+	return nil
+}
+
+// PingHost checks that a Docker host's daemon answers and records the
+// outcome on the host. A failed ping is reported in the result, not as an
+// error.
+func (s *DockerHostService) PingHost(ctx context.Context, id int) (*dto.DockerHostPingResult, error) {
+	host, err := s.hostRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	start := time.Now()
+	err = pingDockerHost(ctx, s.hostRepo, s.hostPool, host)
+	result := &dto.DockerHostPingResult{DurationMS: time.Since(start).Milliseconds()}
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+	}
+	return result, nil
+}
+
+// validateHost checks the host's settings and that its name is free
+func (s *DockerHostService) validateHost(ctx context.Context, host *model.DockerHost) error {
+	if err := host.Validate(); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+
+	existing, err := s.hostRepo.GetByName(ctx, host.Name)
+	if err == nil && existing.ID != host.ID {
+		return fmt.Errorf("docker host with name '%s' already exists", host.Name)
+	}
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return err
+	}
+	return nil
+}
+
+// logActivity audits a change to a Docker host
+func (s *DockerHostService) logActivity(actor model.Actor, action string, host *model.DockerHost, description string, metadata map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["endpoint"] = host.Endpoint
+	metadata["enabled"] = host.Enabled
+	metadataJSON, _ := json.Marshal(metadata)
+
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "docker_host",
+		ResourceID:   &host.ID,
+		ResourceName: host.Name,
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("host_id", host.ID).Warn("Failed to log Docker host activity")
+	}
+}
+
+// pingDockerHost pings a host through the pool and records the outcome on it
+func pingDockerHost(ctx context.Context, hostRepo repository.DockerHostRepository, hostPool *docker.HostPool, host *model.DockerHost) error {
+	pingErr := hostPool.Ping(ctx, host)
+
+	message := ""
+	if pingErr != nil {
+		message = pingErr.Error()
+	}
+	if err := hostRepo.RecordPing(ctx, host.ID, message, time.Now()); err != nil {
+		logrus.WithError(err).WithField("host_id", host.ID).Warn("Failed to record Docker host ping")
+	}
+	return pingErr
+}
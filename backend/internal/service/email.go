@@ -1,7 +1,7 @@
 package service
 
 import (
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 	"fmt"
 )
 
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrFeatureNotFound is returned for flags that are not declared
+	ErrFeatureNotFound = errors.New("feature flag not found")
+
+	// ErrFeatureRequiresRestart is returned when changing a flag that only
+	// takes effect at startup
+	ErrFeatureRequiresRestart = errors.New("feature flag requires a restart; set it through FEATURE_FLAGS instead")
+)
+
+// featureOverrideTTL bounds how long runtime overrides are cached, so
+// changes made through another instance are picked up
+const featureOverrideTTL = 30 * time.Second
+
+// FeatureSource tells which layer decided a flag's value
+type FeatureSource string
+
+const (
+	FeatureSourceDefault FeatureSource = "default"
+	FeatureSourceConfig  FeatureSource = "config"
+	FeatureSourceRuntime FeatureSource = "runtime"
+)
+
+// FeatureState is the effective value of a feature flag
+type FeatureState struct {
+	model.FeatureDefinition
+	Enabled bool          `json:"enabled"`
+	Source  FeatureSource `json:"source"`
+}
+
+// FeatureFlags is the frontend-facing view of the feature flags: the
+// effective values by name, and every flag with its definition
+type FeatureFlags struct {
+	Features map[model.Feature]bool `json:"features"`
+	Flags    []*FeatureState        `json:"flags"`
+}
+
+// SetFeatureRequest sets or, with a null value, clears the runtime override
+// of a feature flag
+type SetFeatureRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// FeatureService resolves feature flags: the default declared in code is
+// overridden by FEATURE_FLAGS, which is overridden by runtime changes stored
+// in the system settings
+type FeatureService struct {
+	configRepo   repository.SystemConfigRepository
+	activityRepo repository.ActivityLogRepository
+	configFlags  map[model.Feature]bool
+
+	mu        sync.RWMutex
+	overrides map[model.Feature]bool
+	loadedAt  time.Time
+}
+
+// NewFeatureService creates a new feature flag service instance
+func NewFeatureService(
+	cfg *config.Config,
+	configRepo repository.SystemConfigRepository,
+	activityRepo repository.ActivityLogRepository,
+) *FeatureService {
+	service := &FeatureService{
+		configRepo:   configRepo,
+		activityRepo: activityRepo,
+		configFlags:  make(map[model.Feature]bool),
+	}
+
+	if cfg != nil {
+		// Load validates FEATURE_FLAGS, so an error here means an unvalidated config
+		flags, err := cfg.GetFeatureFlags()
+		if err != nil {
+			logrus.WithError(err).Warn("Ignoring invalid feature flag configuration")
+		}
+		for name, enabled := range flags {
+			definition, ok := model.LookupFeature(name)
+			if !ok {
+				logrus.WithField("feature", name).Warn("Ignoring unknown feature flag in FEATURE_FLAGS")
+				continue
+			}
+			service.configFlags[definition.Name] = enabled
+		}
+	}
+
+	return service
+}
+
+// Enabled reports whether a feature is enabled. Without a service, flags
+// keep their declared default.
+func (s *FeatureService) Enabled(ctx context.Context, feature model.Feature) bool {
+	definition, ok := model.LookupFeature(string(feature))
+	if !ok {
+		logrus.WithField("feature", feature).Warn("Checked undeclared feature flag")
+		return false
+	}
+	if s == nil {
+		return definition.Default
+	}
+	return s.resolve(definition, s.runtimeOverrides(ctx)).Enabled
+}
+
+// List returns the effective state of every declared flag
+func (s *FeatureService) List(ctx context.Context) *FeatureFlags {
+	overrides := s.runtimeOverrides(ctx)
+
+	definitions := model.FeatureDefinitions()
+	flags := &FeatureFlags{
+		Features: make(map[model.Feature]bool, len(definitions)),
+		Flags:    make([]*FeatureState, 0, len(definitions)),
+	}
+	for _, definition := range definitions {
+		state := s.resolve(definition, overrides)
+		flags.Features[definition.Name] = state.Enabled
+		flags.Flags = append(flags.Flags, state)
+	}
+	return flags
+}
+
+// SetFeature stores or clears the runtime override of a flag and records the
+// change in the activity log
+func (s *FeatureService) SetFeature(ctx context.Context, actor model.Actor, name string, req *SetFeatureRequest) (*FeatureState, error) {
+	if req == nil {
+		return nil, fmt.Errorf("set feature request cannot be nil")
+	}
+
+	definition, ok := model.LookupFeature(name)
+	if !ok {
+		return nil, ErrFeatureNotFound
+	}
+	if definition.RequiresRestart {
+		return nil, ErrFeatureRequiresRestart
+	}
+	if s.configRepo == nil {
+		return nil, fmt.Errorf("feature flag overrides are not available")
+	}
+
+	previous := s.resolve(definition, s.runtimeOverrides(ctx))
+
+	if err := s.saveOverride(ctx, definition, req.Enabled); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	if s.overrides == nil {
+		s.overrides = make(map[model.Feature]bool)
+	}
+	if req.Enabled != nil {
+		s.overrides[definition.Name] = *req.Enabled
+	} else {
+		delete(s.overrides, definition.Name)
+	}
+	overrides := copyFeatureOverrides(s.overrides)
+	s.mu.Unlock()
+
+	state := s.resolve(definition, overrides)
+	s.logFeatureChange(actor, previous, state)
+
+	logrus.WithFields(logrus.Fields{
+		"feature": definition.Name,
+		"enabled": state.Enabled,
+		"source":  state.Source,
+		"actor":   actor.String(),
+	}).Info("Feature flag changed")
+
+	return state, nil
+}
+
+// resolve applies the override layers to a flag's default. Runtime overrides
+// of flags requiring a restart are ignored.
+func (s *FeatureService) resolve(definition model.FeatureDefinition, overrides map[model.Feature]bool) *FeatureState {
+	state := &FeatureState{
+		FeatureDefinition: definition,
+		Enabled:           definition.Default,
+		Source:            FeatureSourceDefault,
+	}
+
+	if enabled, ok := s.configFlags[definition.Name]; ok {
+		state.Enabled = enabled
+		state.Source = FeatureSourceConfig
+	}
+	if definition.RequiresRestart {
+		return state
+	}
+	if enabled, ok := overrides[definition.Name]; ok {
+		state.Enabled = enabled
+		state.Source = FeatureSourceRuntime
+	}
+	return state
+}
+
+// runtimeOverrides returns the stored runtime overrides, reloading them once
+// the cached copy has expired. A failed reload keeps the previous copy.
+func (s *FeatureService) runtimeOverrides(ctx context.Context) map[model.Feature]bool {
+	s.mu.RLock()
+	if s.overrides != nil && time.Since(s.loadedAt) < featureOverrideTTL {
+		overrides := copyFeatureOverrides(s.overrides)
+		s.mu.RUnlock()
+		return overrides
+	}
+	s.mu.RUnlock()
+
+	if s.configRepo == nil {
+		return map[model.Feature]bool{}
+	}
+
+	definitions := model.FeatureDefinitions()
+	keys := make([]string, len(definitions))
+	for i, definition := range definitions {
+		keys[i] = definition.Name.ConfigKey()
+	}
+
+	values, err := s.configRepo.GetValues(ctx, keys)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to load feature flag overrides")
+		return copyFeatureOverrides(s.overrides)
+	}
+
+	overrides := make(map[model.Feature]bool)
+	for _, definition := range definitions {
+		value, ok := values[definition.Name.ConfigKey()]
+		if !ok {
+			continue
+		}
+		var enabled bool
+		if err := json.Unmarshal([]byte(value), &enabled); err != nil {
+			logrus.WithField("feature", definition.Name).Warn("Ignoring invalid feature flag override")
+			continue
+		}
+		overrides[definition.Name] = enabled
+	}
+
+	s.overrides = overrides
+	s.loadedAt = time.Now()
+	return copyFeatureOverrides(overrides)
+}
+
+// saveOverride writes the override to the system settings, deleting it when
+// enabled is nil
+func (s *FeatureService) saveOverride(ctx context.Context, definition model.FeatureDefinition, enabled *bool) error {
+	key := definition.Name.ConfigKey()
+
+	existing, err := s.configRepo.GetByKey(ctx, key)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("failed to get feature flag override: %w", err)
+	}
+
+	switch {
+	case enabled == nil && existing == nil:
+		return nil
+	case enabled == nil:
+		if err := s.configRepo.Delete(ctx, int64(existing.ID)); err != nil {
+			return fmt.Errorf("failed to clear feature flag override: %w", err)
+		}
+	case existing == nil:
+		err := s.configRepo.Create(ctx, &model.SystemConfig{
+			ConfigKey:   key,
+			ConfigValue: strconv.FormatBool(*enabled),
+			Description: "Runtime override of feature flag " + string(definition.Name),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save feature flag override: %w", err)
+		}
+	default:
+		if err := s.configRepo.SetValue(ctx, key, strconv.FormatBool(*enabled)); err != nil {
+			return fmt.Errorf("failed to save feature flag override: %w", err)
+		}
+	}
+	return nil
+}
+
+// logFeatureChange records a flag change in the activity log
+func (s *FeatureService) logFeatureChange(actor model.Actor, previous, current *FeatureState) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"previous_enabled": previous.Enabled,
+		"previous_source":  previous.Source,
+		"enabled":          current.Enabled,
+		"source":           current.Source,
+	})
+
+	activity := &model.ActivityLog{
+		Action:       "feature_flag_changed",
+		ResourceType: "feature_flag",
+		ResourceName: string(current.Name),
+		Description:  fmt.Sprintf("Feature flag %s set to %t (%s)", current.Name, current.Enabled, current.Source),
+		Metadata:     string(metadata),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("feature", current.Name).Warn("Failed to log feature flag change")
+	}
+}
+
+func copyFeatureOverrides(overrides map[model.Feature]bool) map[model.Feature]bool {
+	copied := make(map[model.Feature]bool, len(overrides))
+	for name, enabled := range overrides {
+		copied[name] = enabled
+	}
+	return copied
+}
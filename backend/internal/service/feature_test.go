@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+// featureConfigRepo keeps system config values by key
+type featureConfigRepo struct {
+	repository.SystemConfigRepository
+	values map[string]string
+}
+
+func (r *featureConfigRepo) GetByKey(ctx context.Context, key string) (*model.SystemConfig, error) {
+	value, ok := r.values[key]
+	if !ok {
+		return nil, fmt.Errorf("config %s not found", key)
+	}
+	return &model.SystemConfig{ID: 1, ConfigKey: key, ConfigValue: value}, nil
+}
+
+func (r *featureConfigRepo) GetValues(ctx context.Context, keys []string) (map[string]string, error) {
+	values := make(map[string]string)
+	for _, key := range keys {
+		if value, ok := r.values[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+func (r *featureConfigRepo) Create(ctx context.Context, config *model.SystemConfig) error {
+	r.values[config.ConfigKey] = config.ConfigValue
+	return nil
+}
+
+func (r *featureConfigRepo) SetValue(ctx context.Context, key, value string) error {
+	r.values[key] = value
+	return nil
+}
+
+func (r *featureConfigRepo) Delete(ctx context.Context, id int64) error {
+	// Only one override is changed at a time in these tests
+	for key := range r.values {
+		delete(r.values, key)
+	}
+	return nil
+}
+
+func newFeatureTestService(flags string, stored map[string]string) (*FeatureService, *activityRecorder) {
+	cfg := &config.Config{}
+	cfg.Features.Flags = flags
+	activity := &activityRecorder{}
+	return NewFeatureService(cfg, &featureConfigRepo{values: stored}, activity), activity
+}
+
+func TestFeatureFlagPrecedence(t *testing.T) {
+	ctx := context.Background()
+	s, _ := newFeatureTestService("health_remediation=false,image_history=false,multi_host", map[string]string{
+		model.FeatureImageHistory.ConfigKey(): "true",
+		// Runtime overrides of flags requiring a restart are ignored
+		model.FeatureMultiHost.ConfigKey(): "false",
+	})
+
+	tests := []struct {
+		feature model.Feature
+		enabled bool
+		source  FeatureSource
+	}{
+		{model.FeatureImageScanning, true, FeatureSourceDefault},
+		{model.FeatureOIDC, false, FeatureSourceDefault},
+		{model.FeatureHealthRemediation, false, FeatureSourceConfig},
+		{model.FeatureImageHistory, true, FeatureSourceRuntime},
+		{model.FeatureMultiHost, true, FeatureSourceConfig},
+	}
+
+	flags := s.List(ctx)
+	states := make(map[model.Feature]*FeatureState)
+	for _, state := range flags.Flags {
+		states[state.Name] = state
+	}
+	for _, tt := range tests {
+		if got := s.Enabled(ctx, tt.feature); got != tt.enabled {
+			t.Errorf("Enabled(%s) = %t, want %t", tt.feature, got, tt.enabled)
+		}
+		if state := states[tt.feature]; state == nil || state.Enabled != tt.enabled || state.Source != tt.source {
+			t.Errorf("%s = %+v, want %t from %s", tt.feature, state, tt.enabled, tt.source)
+		}
+		if flags.Features[tt.feature] != tt.enabled {
+			t.Errorf("features[%s] = %t, want %t", tt.feature, flags.Features[tt.feature], tt.enabled)
+		}
+	}
+
+	// Without a service flags keep their default
+	var unset *FeatureService
+	if !unset.Enabled(ctx, model.FeatureImageScanning) || unset.Enabled(ctx, model.FeatureOIDC) {
+		t.Error("a nil service did not report the declared defaults")
+	}
+}
+
+func TestSetFeatureOverridesAndClears(t *testing.T) {
+	ctx := context.Background()
+	s, activity := newFeatureTestService("health_remediation=false", map[string]string{})
+	actor := model.UserActor(1, "admin")
+
+	enabled := true
+	state, err := s.SetFeature(ctx, actor, "health_remediation", &SetFeatureRequest{Enabled: &enabled})
+	if err != nil {
+		t.Fatalf("SetFeature failed: %v", err)
+	}
+	if !state.Enabled || state.Source != FeatureSourceRuntime || !s.Enabled(ctx, model.FeatureHealthRemediation) {
+		t.Errorf("after the override: %+v, want enabled at runtime", state)
+	}
+
+	// Clearing the override falls back to FEATURE_FLAGS
+	state, err = s.SetFeature(ctx, actor, "health_remediation", &SetFeatureRequest{})
+	if err != nil {
+		t.Fatalf("clearing the override failed: %v", err)
+	}
+	if state.Enabled || state.Source != FeatureSourceConfig || s.Enabled(ctx, model.FeatureHealthRemediation) {
+		t.Errorf("after clearing: %+v, want disabled by config", state)
+	}
+
+	if len(activity.logs) != 2 {
+		t.Fatalf("logged %d changes, want 2", len(activity.logs))
+	}
+	var metadata map[string]interface{}
+	json.Unmarshal([]byte(activity.logs[0].Metadata), &metadata)
+	if log := activity.logs[0]; log.Action != "feature_flag_changed" || log.ResourceName != "health_remediation" ||
+		metadata["previous_source"] != "config" || metadata["source"] != "runtime" {
+		t.Errorf("first change logged as %s on %s with %s", log.Action, log.ResourceName, log.Metadata)
+	}
+}
+
+func TestSetFeatureRefusals(t *testing.T) {
+	ctx := context.Background()
+	s, activity := newFeatureTestService("", map[string]string{})
+	enabled := true
+
+	if _, err := s.SetFeature(ctx, model.UserActor(1, "admin"), "websockets", &SetFeatureRequest{Enabled: &enabled}); !errors.Is(err, ErrFeatureRequiresRestart) {
+		t.Errorf("setting websockets = %v, want it to require a restart", err)
+	}
+	if _, err := s.SetFeature(ctx, model.UserActor(1, "admin"), "teleport", &SetFeatureRequest{Enabled: &enabled}); !errors.Is(err, ErrFeatureNotFound) {
+		t.Errorf("setting an undeclared flag = %v, want not found", err)
+	}
+	if len(activity.logs) != 0 {
+		t.Errorf("logged %d refused changes", len(activity.logs))
+	}
+}
+
+func TestFeatureFlagsResponseShape(t *testing.T) {
+	s, _ := newFeatureTestService("oidc", map[string]string{model.FeatureImageScanning.ConfigKey(): "false"})
+
+	got, err := json.MarshalIndent(s.List(context.Background()), "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareGolden(t, "feature_flags.golden.json", append(got, '\n'))
+}
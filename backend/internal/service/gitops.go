@@ -0,0 +1,666 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
+)
+
+// maxGitOpsSourceSize bounds the YAML file a reconcile reads
+const maxGitOpsSourceSize = 1 << 20
+
+// ErrGitOpsReconcileInProgress is returned while another reconcile runs
+var ErrGitOpsReconcileInProgress = errors.New("a gitops reconcile is already running")
+
+// GitOpsService reconciles the managed containers with container specs kept
+// in a YAML file, fetched from a URL or read from a mounted path. Containers
+// it creates or updates are recorded as managed by the source, so edits made
+// outside the source since are reported as conflicts rather than overwritten.
+type GitOpsService struct {
+	gitopsRepo          repository.GitOpsRepository
+	containerRepo       repository.ContainerRepository
+	userRepo            repository.UserRepository
+	containerService    *ContainerService
+	notificationService *NotificationService
+	config              *config.Config
+	httpClient          *http.Client
+
+	mu sync.Mutex
+}
+
+// NewGitOpsService creates a new GitOps service instance
+func NewGitOpsService(
+	gitopsRepo repository.GitOpsRepository,
+	containerRepo repository.ContainerRepository,
+	userRepo repository.UserRepository,
+	containerService *ContainerService,
+	notificationService *NotificationService,
+	cfg *config.Config,
+) *GitOpsService {
+	return &GitOpsService{
+		gitopsRepo:          gitopsRepo,
+		containerRepo:       containerRepo,
+		userRepo:            userRepo,
+		containerService:    containerService,
+		notificationService: notificationService,
+		config:              cfg,
+		httpClient:          &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// gitOpsFile is the YAML a GitOps source holds
+type gitOpsFile struct {
+	Containers []gitOpsFileSpec `yaml:"containers"`
+}
+
+// gitOpsFileSpec is one container of a GitOps source. Env, ports and volumes
+// take the compose syntax.
+type gitOpsFileSpec struct {
+	Name         string      `yaml:"name"`
+	Image        string      `yaml:"image"`
+	Tag          string      `yaml:"tag"`
+	UpdatePolicy string      `yaml:"update_policy"`
+	Env          interface{} `yaml:"env"`
+	Ports        interface{} `yaml:"ports"`
+	Volumes      interface{} `yaml:"volumes"`
+}
+
+// gitOpsSpec is a container spec of the source, parsed
+type gitOpsSpec struct {
+	name     string
+	state    gitOpsState
+	warnings []string
+	err      error
+}
+
+// gitOpsState is the part of a container a GitOps source manages
+type gitOpsState struct {
+	Image        string               `json:"image"`
+	Tag          string               `json:"tag"`
+	UpdatePolicy model.UpdatePolicy   `json:"update_policy"`
+	Env          []string             `json:"env"`
+	Ports        []dto.PortMapping    `json:"ports"`
+	Volumes      []docker.VolumeMount `json:"volumes"`
+}
+
+// Reconcile compares the containers of the source with the managed
+// containers: missing ones are created, changed ones updated and left
+// pending recreation, and with Prune the ones the source no longer lists
+// removed. The run is stored as a report, and admins are notified when the
+// containers differed from the source.
+func (s *GitOpsService) Reconcile(ctx context.Context, actor model.Actor, req *dto.GitOpsReconcileRequest) (*model.GitOpsRun, error) {
+	if req == nil {
+		req = &dto.GitOpsReconcileRequest{}
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	source := req.Source
+	if source == "" {
+		source = s.config.System.GitOpsSource
+	}
+	if source == "" {
+		return nil, fmt.Errorf("invalid request: no source given and GITOPS_SOURCE is not configured")
+	}
+
+	if !s.mu.TryLock() {
+		return nil, ErrGitOpsReconcileInProgress
+	}
+	defer s.mu.Unlock()
+
+	run := &model.GitOpsRun{
+		Source:    source,
+		Status:    model.GitOpsRunSuccess,
+		Trigger:   model.TriggerTypeSchedule,
+		DryRun:    req.DryRun,
+		Prune:     req.Prune,
+		Force:     req.Force,
+		Changes:   model.GitOpsChanges{},
+		StartedAt: time.Now(),
+	}
+	if actor.UserID != nil {
+		run.Trigger = model.TriggerTypeManual
+		run.RequestedBy = actor.OwnerID()
+	}
+
+	specs, err := s.loadSpecs(ctx, source)
+	if err == nil {
+		err = s.reconcile(ctx, actor, run, specs)
+	}
+	if err != nil {
+		run.Status = model.GitOpsRunFailed
+		run.ErrorMessage = err.Error()
+	}
+
+	completedAt := time.Now()
+	run.CompletedAt = &completedAt
+	if createErr := s.gitopsRepo.CreateRun(ctx, run); createErr != nil {
+		return nil, createErr
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"run_id":    run.ID,
+		"source":    source,
+		"status":    run.Status,
+		"created":   run.Created,
+		"updated":   run.Updated,
+		"pruned":    run.Pruned,
+		"conflicts": run.Conflicts,
+		"failed":    run.Failed,
+		"dry_run":   run.DryRun,
+		"actor":     actor.String(),
+	}).Info("GitOps source reconciled")
+
+	if err != nil {
+		return run, fmt.Errorf("failed to reconcile %s: %w", source, err)
+	}
+	if run.Drifted() {
+		s.notifyDrift(ctx, run)
+	}
+	return run, nil
+}
+
+// ListRuns returns the most recent reconcile reports
+func (s *GitOpsService) ListRuns(ctx context.Context, limit int) ([]*model.GitOpsRun, error) {
+	if limit <= 0 || limit > 100 {
+		limit = 20
+	}
+	return s.gitopsRepo.ListRuns(ctx, limit)
+}
+
+// GetRun returns a reconcile report
+func (s *GitOpsService) GetRun(ctx context.Context, id int) (*model.GitOpsRun, error) {
+	return s.gitopsRepo.GetRun(ctx, id)
+}
+
+// reconcile applies the specs, recording a change per container in run
+func (s *GitOpsService) reconcile(ctx context.Context, actor model.Actor, run *model.GitOpsRun, specs []*gitOpsSpec) error {
+	resources, err := s.gitopsRepo.ListResources(ctx, run.Source)
+	if err != nil {
+		return err
+	}
+
+	listed := make(map[int]bool, len(specs))
+	for _, spec := range specs {
+		change := s.reconcileSpec(ctx, actor, run, spec)
+		if change.ContainerID != 0 {
+			listed[change.ContainerID] = true
+		}
+		run.Record(change)
+	}
+
+	if !run.Prune {
+		return nil
+	}
+	for _, resource := range resources {
+		if listed[resource.ContainerID] {
+			continue
+		}
+		run.Record(s.pruneResource(ctx, actor, run, resource))
+	}
+	return nil
+}
+
+// reconcileSpec creates or updates the container of one spec
+func (s *GitOpsService) reconcileSpec(ctx context.Context, actor model.Actor, run *model.GitOpsRun, spec *gitOpsSpec) model.GitOpsChange {
+	change := model.GitOpsChange{Name: spec.name, Warnings: spec.warnings}
+	fail := func(err error) model.GitOpsChange {
+		change.Action = model.GitOpsActionFailed
+		change.Reason = err.Error()
+		return change
+	}
+
+	exists, err := s.containerRepo.Exists(ctx, spec.name)
+	if err != nil {
+		return fail(fmt.Errorf("failed to check container existence: %w", err))
+	}
+	var container *model.Container
+	if exists {
+		if container, err = s.containerRepo.GetByName(ctx, spec.name); err != nil {
+			return fail(err)
+		}
+		change.ContainerID = container.ID
+	}
+	// A broken spec still lists its container, so it is not pruned
+	if spec.err != nil {
+		return fail(spec.err)
+	}
+
+	if container == nil {
+		change.Action = model.GitOpsActionCreate
+		if run.DryRun {
+			return change
+		}
+		container, err = s.containerService.CreateContainer(ctx, actor, spec.createRequest())
+		if err != nil {
+			return fail(err)
+		}
+		change.ContainerID = container.ID
+		if err := s.recordResource(ctx, run.Source, container); err != nil {
+			return fail(err)
+		}
+		return change
+	}
+
+	resource, err := s.gitopsRepo.GetResourceByContainer(ctx, container.ID)
+	if err != nil {
+		return fail(err)
+	}
+	current, err := containerGitOpsState(container)
+	if err != nil {
+		return fail(err)
+	}
+	change.Fields = current.diff(&spec.state)
+
+	switch {
+	case resource != nil && resource.Source != run.Source && !run.Force:
+		change.Action = model.GitOpsActionConflict
+		change.Reason = "managed by another source: " + resource.Source
+		return change
+	case len(change.Fields) == 0:
+		change.Action = model.GitOpsActionUnchanged
+		if !run.DryRun && (resource == nil || resource.Source != run.Source || resource.AppliedHash != current.hash()) {
+			// Take over a container already matching the source
+			if err := s.recordResource(ctx, run.Source, container); err != nil {
+				return fail(err)
+			}
+		}
+		return change
+	case resource == nil && !run.Force:
+		change.Action = model.GitOpsActionConflict
+		change.Reason = "the container was not created from this source"
+		return change
+	case resource != nil && resource.AppliedHash != current.hash() && !run.Force:
+		change.Action = model.GitOpsActionConflict
+		change.Reason = "the container was edited outside the source since the last reconcile"
+		return change
+	}
+
+	change.Action = model.GitOpsActionUpdate
+	if run.DryRun {
+		return change
+	}
+	if err := s.applySpec(ctx, actor, container, spec, change.Fields); err != nil {
+		return fail(err)
+	}
+	if err := s.recordResource(ctx, run.Source, container); err != nil {
+		return fail(err)
+	}
+	return change
+}
+
+// applySpec stores the spec on the container. Environment, ports and
+// volumes replace those in its config; other config is kept. A container
+// already created in Docker gets the changes when next recreated.
+func (s *GitOpsService) applySpec(ctx context.Context, actor model.Actor, container *model.Container, spec *gitOpsSpec, fields []string) error {
+	config := make(map[string]interface{})
+	if container.ConfigJSON != "" {
+		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
+			return fmt.Errorf("failed to parse container config: %w", err)
+		}
+	}
+	setConfig := func(key string, value interface{}, empty bool) {
+		if empty {
+			delete(config, key)
+		} else {
+			config[key] = value
+		}
+	}
+	setConfig("env", spec.state.Env, len(spec.state.Env) == 0)
+	setConfig("ports", spec.state.Ports, len(spec.state.Ports) == 0)
+	setConfig("volumes", spec.state.Volumes, len(spec.state.Volumes) == 0)
+	configJSON, err := json.Marshal(config)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	if container.Image != spec.state.Image || container.Tag != spec.state.Tag {
+		// A pinned digest belongs to the old image
+		container.ImageDigest = ""
+		container.PendingDigest = ""
+	}
+	container.Image = spec.state.Image
+	container.Tag = spec.state.Tag
+	container.UpdatePolicy = spec.state.UpdatePolicy
+	container.ConfigJSON = string(configJSON)
+	if err := s.containerRepo.Update(ctx, container); err != nil {
+		return fmt.Errorf("failed to update container: %w", err)
+	}
+	if container.ContainerID != "" {
+		s.containerService.recordDrift(ctx, container, true)
+	}
+
+	s.containerService.logContainerActivity(actor, int64(container.ID), "container_gitops_updated", "Container configuration updated from the GitOps source", map[string]interface{}{
+		"fields": fields,
+	})
+	s.containerService.invalidateContainerCache(actor)
+	if s.containerService.cache != nil {
+		s.containerService.cache.Delete(fmt.Sprintf("container:detail:%d", container.ID))
+	}
+	return nil
+}
+
+// pruneResource removes a container the source no longer lists, unless it
+// was edited outside the source
+func (s *GitOpsService) pruneResource(ctx context.Context, actor model.Actor, run *model.GitOpsRun, resource *model.GitOpsResource) model.GitOpsChange {
+	change := model.GitOpsChange{Name: resource.Name, ContainerID: resource.ContainerID, Action: model.GitOpsActionPrune}
+
+	container, err := s.containerRepo.GetByID(ctx, int64(resource.ContainerID))
+	if err == nil {
+		var current *gitOpsState
+		if current, err = containerGitOpsState(container); err == nil && current.hash() != resource.AppliedHash && !run.Force {
+			change.Action = model.GitOpsActionConflict
+			change.Reason = "no longer in the source, but edited outside it since the last reconcile; not pruned"
+			return change
+		}
+	}
+	if err == nil && !run.DryRun {
+		err = s.containerService.DeleteContainer(ctx, actor, int64(resource.ContainerID))
+	}
+	if err != nil {
+		change.Action = model.GitOpsActionFailed
+		change.Reason = err.Error()
+	}
+	return change
+}
+
+// recordResource records the container as managed by the source in its
+// current state
+func (s *GitOpsService) recordResource(ctx context.Context, source string, container *model.Container) error {
+	state, err := containerGitOpsState(container)
+	if err != nil {
+		return err
+	}
+	return s.gitopsRepo.SaveResource(ctx, &model.GitOpsResource{
+		Source:       source,
+		ContainerID:  container.ID,
+		Name:         container.Name,
+		AppliedHash:  state.hash(),
+		ReconciledAt: time.Now(),
+	})
+}
+
+// notifyDrift tells every active admin how the containers differed from the
+// source
+func (s *GitOpsService) notifyDrift(ctx context.Context, run *model.GitOpsRun) {
+	if s.notificationService == nil || s.userRepo == nil {
+		return
+	}
+
+	lines := make([]string, 0, len(run.Changes))
+	for _, change := range run.Changes {
+		if change.Action == model.GitOpsActionUnchanged {
+			continue
+		}
+		line := fmt.Sprintf("[%s] %s", change.Action, change.Name)
+		if len(change.Fields) > 0 {
+			line += ": " + strings.Join(change.Fields, ", ")
+		}
+		if change.Reason != "" {
+			line += " (" + change.Reason + ")"
+		}
+		lines = append(lines, line)
+	}
+
+	title := fmt.Sprintf("GitOps drift: %d created, %d updated, %d pruned, %d conflicts", run.Created, run.Updated, run.Pruned, run.Conflicts)
+	if run.DryRun {
+		title = fmt.Sprintf("GitOps drift (dry run): %d to create, %d to update, %d to prune, %d conflicts", run.Created, run.Updated, run.Pruned, run.Conflicts)
+	}
+	notificationType := NotificationTypeInfo
+	if run.Conflicts > 0 || run.Failed > 0 {
+		notificationType = NotificationTypeWarning
+	}
+	data := map[string]interface{}{
+		"run_id": run.ID,
+		"source": run.Source,
+	}
+
+	users, err := s.userRepo.GetActiveUsers(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get admins to notify of gitops drift")
+		return
+	}
+	for _, user := range users {
+		if !user.IsAdmin() {
+			continue
+		}
+		userID := user.ID
+		if _, err := s.notificationService.CreateNotification(ctx, &userID, notificationType, title, strings.Join(lines, "\n"), data); err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Warn("Failed to notify admin of gitops drift")
+		}
+	}
+}
+
+// loadSpecs reads and parses the container specs of a source
+func (s *GitOpsService) loadSpecs(ctx context.Context, source string) ([]*gitOpsSpec, error) {
+	data, err := s.readSource(ctx, source)
+	if err != nil {
+		return nil, err
+	}
+
+	var file gitOpsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse source: %w", err)
+	}
+
+	specs := make([]*gitOpsSpec, 0, len(file.Containers))
+	seen := make(map[string]bool, len(file.Containers))
+	for i, entry := range file.Containers {
+		spec := parseGitOpsSpec(entry)
+		if spec.name == "" {
+			return nil, fmt.Errorf("container %d of the source has no name", i+1)
+		}
+		if seen[spec.name] {
+			return nil, fmt.Errorf("container %s is listed more than once", spec.name)
+		}
+		seen[spec.name] = true
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// readSource fetches an http(s) source or reads a file
+func (s *GitOpsService) readSource(ctx context.Context, source string) ([]byte, error) {
+	var body io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid source URL: %w", err)
+		}
+		if token := s.config.System.GitOpsToken; token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		resp, err := s.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch source: %w", err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("failed to fetch source: %s", resp.Status)
+		}
+		body = resp.Body
+	} else {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read source: %w", err)
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, maxGitOpsSourceSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source: %w", err)
+	}
+	if len(data) > maxGitOpsSourceSize {
+		return nil, fmt.Errorf("source is larger than %d bytes", maxGitOpsSourceSize)
+	}
+	return data, nil
+}
+
+// parseGitOpsSpec maps an entry of the source onto the state it manages.
+// Problems that make the entry unusable are kept in err.
+func parseGitOpsSpec(entry gitOpsFileSpec) *gitOpsSpec {
+	spec := &gitOpsSpec{name: strings.TrimSpace(entry.Name)}
+	warn := func(format string, args ...interface{}) {
+		spec.warnings = append(spec.warnings, fmt.Sprintf(format, args...))
+	}
+
+	image, tag, digest := model.ParseImageReference(strings.TrimSpace(entry.Image))
+	switch {
+	case image == "":
+		spec.err = fmt.Errorf("image is required")
+	case digest != "":
+		spec.err = fmt.Errorf("image digests are not supported; give a tag")
+	case tag != "" && entry.Tag != "" && tag != entry.Tag:
+		spec.err = fmt.Errorf("image tag %s differs from tag %s", tag, entry.Tag)
+	}
+	if tag == "" {
+		tag = entry.Tag
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+
+	policy := entry.UpdatePolicy
+	if policy == "" {
+		policy = string(model.UpdatePolicyManual)
+	}
+	if spec.err == nil && !dto.IsValidUpdatePolicy(policy) {
+		spec.err = fmt.Errorf("invalid update policy %q", policy)
+	}
+
+	spec.state = gitOpsState{
+		Image:        image,
+		Tag:          tag,
+		UpdatePolicy: model.UpdatePolicy(policy),
+		Env:          composeEnvironment(entry.Env, warn),
+		Ports:        composePorts(entry.Ports, warn),
+		Volumes:      composeVolumes(entry.Volumes, warn),
+	}
+	spec.state.normalize()
+	return spec
+}
+
+// createRequest is the request creating the container of the spec
+func (spec *gitOpsSpec) createRequest() *dto.CreateContainerRequest {
+	config := make(map[string]interface{})
+	if len(spec.state.Env) > 0 {
+		config["env"] = spec.state.Env
+	}
+	if len(spec.state.Ports) > 0 {
+		config["ports"] = spec.state.Ports
+	}
+	if len(spec.state.Volumes) > 0 {
+		config["volumes"] = spec.state.Volumes
+	}
+
+	req := &dto.CreateContainerRequest{
+		Name:         spec.name,
+		Image:        spec.state.Image,
+		Tag:          spec.state.Tag,
+		UpdatePolicy: string(spec.state.UpdatePolicy),
+	}
+	if len(config) > 0 {
+		req.Config = config
+	}
+	return req
+}
+
+// containerGitOpsState returns the managed part of a container
+func containerGitOpsState(container *model.Container) (*gitOpsState, error) {
+	desired, err := desiredContainerState(container)
+	if err != nil {
+		return nil, err
+	}
+	state := &gitOpsState{
+		Image:        container.Image,
+		Tag:          container.Tag,
+		UpdatePolicy: container.UpdatePolicy,
+		Env:          desired.Env,
+		Ports:        desired.Ports,
+		Volumes:      desired.Volumes,
+	}
+	state.normalize()
+	return state, nil
+}
+
+// normalize orders the lists so equal states compare and hash equal
+func (st *gitOpsState) normalize() {
+	if st.Env == nil {
+		st.Env = []string{}
+	}
+	if st.Ports == nil {
+		st.Ports = []dto.PortMapping{}
+	}
+	if st.Volumes == nil {
+		st.Volumes = []docker.VolumeMount{}
+	}
+	for i := range st.Ports {
+		if st.Ports[i].Protocol == "" {
+			st.Ports[i].Protocol = "tcp"
+		}
+	}
+	sort.Strings(st.Env)
+	sort.SliceStable(st.Ports, func(i, j int) bool {
+		a, b := st.Ports[i], st.Ports[j]
+		if a.ContainerPort != b.ContainerPort {
+			return a.ContainerPort < b.ContainerPort
+		}
+		return a.Protocol < b.Protocol
+	})
+	sort.SliceStable(st.Volumes, func(i, j int) bool {
+		return st.Volumes[i].Target < st.Volumes[j].Target
+	})
+}
+
+// hash identifies the state
+func (st *gitOpsState) hash() string {
+	data, _ := json.Marshal(st)
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// diff names the fields that differ from other
+func (st *gitOpsState) diff(other *gitOpsState) []string {
+	var fields []string
+	if st.Image != other.Image {
+		fields = append(fields, "image")
+	}
+	if st.Tag != other.Tag {
+		fields = append(fields, "tag")
+	}
+	if st.UpdatePolicy != other.UpdatePolicy {
+		fields = append(fields, "update_policy")
+	}
+	if !reflect.DeepEqual(st.Env, other.Env) {
+		fields = append(fields, "env")
+	}
+	if !reflect.DeepEqual(st.Ports, other.Ports) {
+		fields = append(fields, "ports")
+	}
+	if !reflect.DeepEqual(st.Volumes, other.Volumes) {
+		fields = append(fields, "volumes")
+	}
+	return fields
+}
@@ -3,14 +3,17 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"docker-auto/internal/config"
-	"docker-auto/internal/model"
 	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/registry"
+	"docker-auto/pkg/utils"
 
 	"github.com/sirupsen/logrus"
 )
@@ -21,8 +24,12 @@ type ImageService struct {
 	containerRepo   repository.ContainerRepository
 	activityRepo    repository.ActivityLogRepository
 	updateRepo      repository.UpdateHistoryRepository
+	scanResultRepo  repository.ScanResultRepository
+	policyService   *ImagePolicyService
 	imageChecker    registry.ImageChecker
 	cache           *CacheService
+	shared          *utils.RedisCache // see SetSharedCache
+	cacheCounters   imageCacheCounters
 	config          *config.Config
 	scheduledChecks map[int64]*scheduledCheck
 	checksMutex     sync.RWMutex
@@ -63,6 +70,7 @@ type ImageUpdateInfo struct {
 	VersionInfo     *registry.VersionComparisonResult    `json:"version_info,omitempty"`
 	SecurityIssues  []registry.SecurityVulnerability     `json:"security_issues,omitempty"`
 	Recommendation  string                               `json:"recommendation,omitempty"`
+	ReleaseNotesURL string                               `json:"release_notes_url,omitempty"`
 }
 
 // NewImageService creates a new image service instance
@@ -71,8 +79,10 @@ func NewImageService(
 	containerRepo repository.ContainerRepository,
 	activityRepo repository.ActivityLogRepository,
 	updateRepo repository.UpdateHistoryRepository,
+	scanResultRepo repository.ScanResultRepository,
 	cache *CacheService,
 	config *config.Config,
+	policyService *ImagePolicyService,
 ) *ImageService {
 	ctx, cancel := context.WithCancel(context.Background())
 
@@ -81,6 +91,8 @@ func NewImageService(
 		containerRepo:   containerRepo,
 		activityRepo:    activityRepo,
 		updateRepo:      updateRepo,
+		scanResultRepo:  scanResultRepo,
+		policyService:   policyService,
 		cache:           cache,
 		config:          config,
 		scheduledChecks: make(map[int64]*scheduledCheck),
@@ -137,10 +149,14 @@ func (s *ImageService) CheckImageUpdate(ctx context.Context, containerID int64)
 
 	// Check for update using image checker
 	fullImageName := container.GetFullImageName()
+	generation := s.cacheGeneration(ctx, fullImageName)
 	updateResult, err := s.imageChecker.CheckImageUpdate(ctx, fullImageName, currentDigest, container.RegistryURL)
 	if err != nil {
 		return nil, fmt.Errorf("failed to check image update: %w", err)
 	}
+	if err := s.ApplyVersionPolicy(ctx, container, updateResult); err != nil {
+		return nil, fmt.Errorf("failed to apply version policy: %w", err)
+	}
 
 	// Convert to ImageUpdateInfo
 	updateInfo := &ImageUpdateInfo{
@@ -160,6 +176,12 @@ func (s *ImageService) CheckImageUpdate(ctx context.Context, containerID int64)
 		updateInfo.LatestDigest = updateResult.LatestDigest
 		updateInfo.LatestImage = container.Image // Same image, different tag
 
+		if record, err := s.RecordImageVersion(ctx, container, updateResult.LatestTag, updateResult.LatestDigest); err == nil {
+			updateInfo.ReleaseNotesURL = record.ReleaseNotesURL
+		} else {
+			logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to record image version")
+		}
+
 		// Get version comparison if available
 		if currentImageVersion, err := s.getCurrentImageVersion(ctx, container); err == nil {
 			if latestImageVersion, err := s.getLatestImageVersion(ctx, container); err == nil {
@@ -171,8 +193,13 @@ func (s *ImageService) CheckImageUpdate(ctx context.Context, containerID int64)
 		}
 	}
 
-	// Cache the result
-	s.cacheUpdateInfo(containerID, updateInfo)
+	// Cache the result, unless the image cache was flushed during the check;
+	// the checker may have cached what it fetched by then
+	if generation >= 0 && s.cacheGeneration(ctx, fullImageName) == generation {
+		s.cacheUpdateInfo(containerID, updateInfo)
+	} else {
+		s.imageChecker.InvalidateCache(fullImageName)
+	}
 
 	// Log the check
 	s.logImageActivity(containerID, "image_check", "Image update check performed", map[string]interface{}{
@@ -364,28 +391,29 @@ func (s *ImageService) GetImageVersions(ctx context.Context, image string) ([]*m
 func (s *ImageService) GetLatestImageInfo(ctx context.Context, image string, registryURL string) (*model.ImageVersion, error) {
 	// Try cache first
 	if cachedInfo, found := s.imageChecker.GetCachedImageInfo(image); found {
+		s.cacheCounters.hits.Add(1)
 		return cachedInfo, nil
 	}
+	s.cacheCounters.misses.Add(1)
+
+	// Read the generation before fetching, so a flush during the fetch
+	// keeps the result out of the cache
+	generation := s.cacheGeneration(ctx, image)
 
 	// Get client for the registry
 	client, err := s.imageChecker.GetClient(registryURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get registry client: %w", err)
+		return nil, registryError(err, "failed to get registry client")
 	}
 
 	// Get latest image info
 	latestInfo, err := client.GetLatestImageInfo(ctx, image)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get latest image info: %w", err)
+		return nil, registryError(err, "failed to get latest image info")
 	}
 
-	// Cache the result
-	s.imageChecker.CacheImageInfo(image, latestInfo, 6*time.Hour)
-
-	// Save to database
-	if err := s.imageRepo.UpsertVersion(ctx, latestInfo); err != nil {
-		logrus.WithError(err).WithField("image", image).Warn("Failed to save image version to database")
-	}
+	// Save to database and cache the result
+	s.storeLatestInfo(ctx, image, latestInfo, generation)
 
 	return latestInfo, nil
 }
@@ -440,11 +468,17 @@ func (s *ImageService) TestRegistryConnection(ctx context.Context, registryURL s
 	return client.TestConnection(ctx)
 }
 
+// SetRegistryCredentials makes update checks against the registry
+// authenticate with auth; nil removes the credentials
+func (s *ImageService) SetRegistryCredentials(registryURL string, auth *registry.AuthConfig) {
+	s.imageChecker.SetCredentials(registryURL, auth)
+}
+
 // GetRegistryInfo gets information about a registry
 func (s *ImageService) GetRegistryInfo(ctx context.Context, registryURL string) (*registry.RegistryInfo, error) {
 	client, err := s.imageChecker.GetClient(registryURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get registry client: %w", err)
+		return nil, registryError(err, "failed to get registry client")
 	}
 
 	return client.GetRegistryInfo(ctx)
@@ -454,7 +488,7 @@ func (s *ImageService) GetRegistryInfo(ctx context.Context, registryURL string)
 func (s *ImageService) SearchImages(ctx context.Context, query string, registryURL string) ([]*registry.ImageSearchResult, error) {
 	client, err := s.imageChecker.GetClient(registryURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get registry client: %w", err)
+		return nil, registryError(err, "failed to get registry client")
 	}
 
 	searchOptions := &registry.SearchOptions{
@@ -464,7 +498,7 @@ func (s *ImageService) SearchImages(ctx context.Context, query string, registryU
 
 	repositories, err := client.SearchRepositories(ctx, searchOptions)
 	if err != nil {
-		return nil, fmt.Errorf("failed to search repositories: %w", err)
+		return nil, registryError(err, "failed to search repositories")
 	}
 
 	// Convert to ImageSearchResult
@@ -483,6 +517,36 @@ func (s *ImageService) SearchImages(ctx context.Context, query string, registryU
 	return results, nil
 }
 
+// ListImageTags returns a page of the tags of an image's repository, read
+// with the stored credentials of its registry
+func (s *ImageService) ListImageTags(ctx context.Context, image, registryURL string, page, pageSize int) (*registry.TagPage, error) {
+	tags, err := s.imageChecker.ListTags(ctx, image, registryURL, page, pageSize)
+	if err != nil {
+		return nil, registryError(err, "failed to list image tags")
+	}
+	return tags, nil
+}
+
+// registryError gives an error of a registry request the code of its cause
+func registryError(err error, message string) error {
+	code := apperrors.CodeRegistryError
+	var registryErr *registry.RegistryError
+	switch {
+	case errors.Is(err, registry.ErrTagListingUnsupported):
+		code = apperrors.CodeTagListingUnsupported
+	case errors.As(err, &registryErr):
+		switch registryErr.Code {
+		case registry.ErrorCodeImageNotFound, registry.ErrorCodeTagNotFound:
+			code = apperrors.CodeImageNotFound
+		case registry.ErrorCodeUnauthorized:
+			code = apperrors.CodeRegistryAccessDenied
+		case registry.ErrorCodeRateLimit:
+			code = apperrors.CodeRegistryRateLimited
+		}
+	}
+	return apperrors.Wrap(err, code, message)
+}
+
 // Helper methods
 
 // initializeImageChecker initializes the image checker with registry clients
@@ -628,24 +692,46 @@ func (s *ImageService) detectRegistryType(registryURL string) string {
 
 // cacheUpdateInfo caches update information
 func (s *ImageService) cacheUpdateInfo(containerID int64, updateInfo *ImageUpdateInfo) {
+	if s.shared != nil {
+		ctx, cancel := context.WithTimeout(s.ctx, sharedCacheTimeout)
+		defer cancel()
+		if err := s.shared.SetJSON(ctx, updateInfoKey(containerID), updateInfo, updateInfoCacheTTL); err != nil {
+			logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to write shared update info")
+		}
+	}
+
 	if s.cache == nil {
 		return
 	}
 
-	cacheKey := fmt.Sprintf("update_info:%d", containerID)
-	if err := s.cache.Set(cacheKey, updateInfo, 30*time.Minute); err != nil {
+	if err := s.cache.Set(updateInfoKey(containerID), updateInfo, updateInfoCacheTTL); err != nil {
 		logrus.WithError(err).WithField("container_id", containerID).Debug("Failed to cache update info")
 	}
 }
 
-// getCachedUpdateInfo gets cached update information
+// GetCachedUpdateInfo gets cached update information. With a shared cache
+// only its entries are served, falling back to memory while it is unreachable.
 func (s *ImageService) GetCachedUpdateInfo(containerID int64) (*ImageUpdateInfo, bool) {
+	if s.shared != nil {
+		ctx, cancel := context.WithTimeout(s.ctx, sharedCacheTimeout)
+		defer cancel()
+
+		var updateInfo ImageUpdateInfo
+		found, err := s.shared.GetJSON(ctx, updateInfoKey(containerID), &updateInfo)
+		if err == nil {
+			if !found {
+				return nil, false
+			}
+			return &updateInfo, true
+		}
+		logrus.WithError(err).WithField("container_id", containerID).Debug("Shared update info unavailable, using memory")
+	}
+
 	if s.cache == nil {
 		return nil, false
 	}
 
-	cacheKey := fmt.Sprintf("update_info:%d", containerID)
-	if value, found := s.cache.Get(cacheKey); found {
+	if value, found := s.cache.Get(updateInfoKey(containerID)); found {
 		if updateInfo, ok := value.(*ImageUpdateInfo); ok {
 			return updateInfo, true
 		}
@@ -654,6 +740,38 @@ func (s *ImageService) GetCachedUpdateInfo(containerID int64) (*ImageUpdateInfo,
 	return nil, false
 }
 
+// ImageScanResult is a stored vulnerability scan of an image
+type ImageScanResult struct {
+	*model.ScanResult
+	Vulnerabilities json.RawMessage `json:"vulnerabilities"`
+}
+
+// GetScanResult returns the stored scan for an image reference, by digest when
+// the reference has one and otherwise the latest scan of that name and tag
+func (s *ImageService) GetScanResult(ctx context.Context, imageRef string) (*ImageScanResult, error) {
+	if s.scanResultRepo == nil {
+		return nil, apperrors.New(apperrors.CodeScanResultsUnavailable, "scan results are not available")
+	}
+
+	var (
+		stored *model.ScanResult
+		err    error
+	)
+	if _, _, digest := model.ParseImageReference(imageRef); digest != "" {
+		stored, err = s.scanResultRepo.GetByDigest(ctx, digest)
+	} else {
+		stored, err = s.scanResultRepo.GetLatestByImage(ctx, imageRef)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ImageScanResult{
+		ScanResult:      stored,
+		Vulnerabilities: json.RawMessage(stored.Vulnerabilities),
+	}, nil
+}
+
 // Activity logging
 
 // logImageActivity logs image-related activities
@@ -669,16 +787,15 @@ func (s *ImageService) logImageActivity(containerID int64, action, description s
 		}
 	}
 
-	userID := int64(0)
 	intContainerID := int(containerID)
 	activity := &model.ActivityLog{
-		UserID:       &userID, // System activity
 		Action:       action,
 		ResourceType: "container",
 		ResourceID:   &intContainerID,
 		Description:  description,
 		Metadata:     metadataJSON,
 	}
+	activity.SetActor(model.SystemActor(model.ActorComponentImageService))
 
 	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
 		logrus.WithError(err).WithFields(logrus.Fields{
@@ -701,13 +818,12 @@ func (s *ImageService) logSystemActivity(action, description string, metadata ma
 		}
 	}
 
-	systemUserID := int64(0)
 	activity := &model.ActivityLog{
-		UserID:      &systemUserID, // System activity
 		Action:      action,
 		Description: description,
 		Metadata:    metadataJSON,
 	}
+	activity.SetActor(model.SystemActor(model.ActorComponentImageService))
 
 	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
 		logrus.WithError(err).WithField("action", action).Warn("Failed to log system activity")
@@ -0,0 +1,408 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// updateInfoCacheTTL is how long the result of an update check is cached
+	updateInfoCacheTTL = 30 * time.Minute
+
+	// sharedCacheTimeout bounds a shared cache call, after which the memory
+	// cache is used
+	sharedCacheTimeout = 2 * time.Second
+)
+
+// imageCacheCounters counts image cache lookups and events since startup
+type imageCacheCounters struct {
+	hits        atomic.Int64
+	misses      atomic.Int64
+	flushes     atomic.Int64
+	refreshes   atomic.Int64
+	invalidated atomic.Int64
+	// discarded counts fetched versions not stored because the image was
+	// invalidated while they were being fetched
+	discarded atomic.Int64
+}
+
+// ImageCacheStats describes the image version cache
+type ImageCacheStats struct {
+	*model.ImageCacheStats
+	Hits      int64   `json:"hits"`
+	Misses    int64   `json:"misses"`
+	HitRate   float64 `json:"hit_rate"`
+	Flushes   int64   `json:"flushes"`
+	Refreshes int64   `json:"refreshes"`
+	// InvalidatedRows counts the cached versions flushed since startup
+	InvalidatedRows int64 `json:"invalidated_rows"`
+	DiscardedWrites int64 `json:"discarded_writes"`
+}
+
+// ImageCacheFlushResult reports what a cache flush removed
+type ImageCacheFlushResult struct {
+	// Repository is the normalized repository flushed, empty for all
+	Repository      string   `json:"repository,omitempty"`
+	Images          []string `json:"images"`
+	InvalidatedRows int64    `json:"invalidated_rows"`
+	Containers      int      `json:"containers"`
+}
+
+// FlushImageCache invalidates the cached versions of a repository, or of
+// every image when repository is empty, along with the update information
+// cached for the containers running it. Checks in flight when the flush
+// happens do not store what they fetched.
+func (s *ImageService) FlushImageCache(ctx context.Context, actor model.Actor, repository string) (*ImageCacheFlushResult, error) {
+	result, err := s.flushImageCache(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cacheCounters.flushes.Add(1)
+	s.logCacheActivity(actor, "image_cache_flush", result.Repository, "Image version cache flushed", map[string]interface{}{
+		"images":           result.Images,
+		"invalidated_rows": result.InvalidatedRows,
+		"containers":       result.Containers,
+	})
+
+	return result, nil
+}
+
+// RefreshRepository flushes the cache of a repository after a push and
+// fetches the latest versions of the images containers run from it in the
+// background
+func (s *ImageService) RefreshRepository(ctx context.Context, actor model.Actor, repository string) (*ImageCacheFlushResult, error) {
+	if model.NormalizeRepository(repository) == "" {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: repository is required")
+	}
+
+	result, err := s.FlushImageCache(ctx, actor, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := s.containersOfRepository(ctx, result.Repository)
+	if err != nil {
+		return result, err
+	}
+
+	refreshed := make(map[string]bool)
+	for _, container := range containers {
+		image := container.GetFullImageName()
+		if refreshed[image] {
+			continue
+		}
+		refreshed[image] = true
+
+		go func(image, registryURL string) {
+			if _, err := s.GetLatestImageInfo(s.ctx, image, registryURL); err != nil {
+				logrus.WithError(err).WithField("image", image).Warn("Failed to refresh image after push")
+				return
+			}
+			s.cacheCounters.refreshes.Add(1)
+		}(image, container.RegistryURL)
+	}
+
+	return result, nil
+}
+
+// GetImageCacheStats returns the cached versions per repository with the
+// hit rate and flush counts since startup
+func (s *ImageService) GetImageCacheStats(ctx context.Context) (*ImageCacheStats, error) {
+	stored, err := s.imageRepo.GetCacheStats(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := &ImageCacheStats{
+		ImageCacheStats: stored,
+		Hits:            s.cacheCounters.hits.Load(),
+		Misses:          s.cacheCounters.misses.Load(),
+		Flushes:         s.cacheCounters.flushes.Load(),
+		Refreshes:       s.cacheCounters.refreshes.Load(),
+		InvalidatedRows: s.cacheCounters.invalidated.Load(),
+		DiscardedWrites: s.cacheCounters.discarded.Load(),
+	}
+	if lookups := stats.Hits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits) / float64(lookups)
+	}
+
+	return stats, nil
+}
+
+func (s *ImageService) flushImageCache(ctx context.Context, repository string) (*ImageCacheFlushResult, error) {
+	result := &ImageCacheFlushResult{Images: []string{}}
+
+	if repository == "" {
+		rows, err := s.imageRepo.InvalidateCache(ctx, "")
+		if err != nil {
+			return nil, err
+		}
+		result.InvalidatedRows = rows
+		s.cacheCounters.invalidated.Add(rows)
+
+		if err := s.imageChecker.ClearCache(); err != nil {
+			logrus.WithError(err).Warn("Failed to clear image cache")
+		}
+
+		containers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get containers: %w", err)
+		}
+		s.dropUpdateInfo(containers)
+		result.Containers = len(containers)
+		return result, nil
+	}
+
+	result.Repository = model.NormalizeRepository(repository)
+	if result.Repository == "" {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: repository %q is not a valid image repository", repository)
+	}
+
+	containers, err := s.containersOfRepository(ctx, result.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, err := s.imageRepo.ListCachedImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make(map[string]bool)
+	for _, image := range cached {
+		if model.NormalizeRepository(image) == result.Repository {
+			images[image] = true
+		}
+	}
+	for _, container := range containers {
+		images[container.GetFullImageName()] = true
+	}
+
+	for image := range images {
+		// Rows first: a check finishing in between then finds its generation
+		// outdated and drops what it cached in memory
+		rows, err := s.imageRepo.InvalidateCache(ctx, image)
+		if err != nil {
+			return nil, err
+		}
+		result.InvalidatedRows += rows
+		s.cacheCounters.invalidated.Add(rows)
+
+		if err := s.imageChecker.InvalidateCache(image); err != nil {
+			logrus.WithError(err).WithField("image", image).Warn("Failed to invalidate image cache")
+		}
+		if s.cache != nil {
+			s.cache.InvalidateImageCache(image)
+		}
+		result.Images = append(result.Images, image)
+	}
+
+	s.dropUpdateInfo(containers)
+	result.Containers = len(containers)
+	return result, nil
+}
+
+// containersOfRepository returns the containers running an image of a
+// normalized repository
+func (s *ImageService) containersOfRepository(ctx context.Context, repository string) ([]*model.Container, error) {
+	containers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+
+	var matched []*model.Container
+	for _, container := range containers {
+		if model.NormalizeRepository(container.Image) == repository {
+			matched = append(matched, container)
+		}
+	}
+	return matched, nil
+}
+
+func (s *ImageService) dropUpdateInfo(containers []*model.Container) {
+	keys := make([]string, 0, len(containers))
+	for _, container := range containers {
+		keys = append(keys, updateInfoKey(int64(container.ID)))
+	}
+
+	if s.shared != nil && len(keys) > 0 {
+		ctx, cancel := context.WithTimeout(s.ctx, sharedCacheTimeout)
+		defer cancel()
+		if err := s.shared.Delete(ctx, keys...); err != nil {
+			logrus.WithError(err).Warn("Failed to drop shared update info")
+		}
+	}
+
+	if s.cache == nil {
+		return
+	}
+	for _, key := range keys {
+		s.cache.Delete(key)
+	}
+}
+
+// ClearUpdateInfo drops the update information cached for a container once
+// an update of it completed, so it stops showing the applied update as
+// available; with a shared cache on every replica at once
+func (s *ImageService) ClearUpdateInfo(container *model.Container) {
+	s.dropUpdateInfo([]*model.Container{container})
+}
+
+// SetSharedCache makes the service cache image versions and update
+// information in a cache shared between replicas, such as Redis, so checks
+// and invalidations on one replica are seen by all. Without it they are
+// cached per replica. It must be called before the service is used.
+func (s *ImageService) SetSharedCache(cache *utils.RedisCache) {
+	if cache == nil {
+		return
+	}
+	s.shared = cache
+	s.imageChecker.SetSharedCache(cache)
+}
+
+func updateInfoKey(containerID int64) string {
+	return fmt.Sprintf("update_info:%d", containerID)
+}
+
+// cacheGeneration returns the cache generation of an image, or -1 when it
+// cannot be read so that callers do not treat the cache as unchanged
+func (s *ImageService) cacheGeneration(ctx context.Context, image string) int64 {
+	generation, err := s.imageRepo.GetCacheGeneration(ctx, image)
+	if err != nil {
+		logrus.WithError(err).WithField("image", image).Debug("Failed to read image cache generation")
+		return -1
+	}
+	return generation
+}
+
+// storeLatestInfo stores a version fetched while the image was at generation,
+// unless the image was written or invalidated since. It reports whether the
+// version was stored.
+func (s *ImageService) storeLatestInfo(ctx context.Context, image string, info *model.ImageVersion, generation int64) bool {
+	if generation < 0 {
+		return false
+	}
+
+	stored, err := s.imageRepo.UpsertVersionIfGeneration(ctx, info, generation)
+	if err != nil {
+		logrus.WithError(err).WithField("image", image).Warn("Failed to save image version to database")
+		return false
+	}
+	if !stored {
+		s.cacheCounters.discarded.Add(1)
+		logrus.WithField("image", image).Debug("Image cache changed during the check, discarding fetched version")
+		return false
+	}
+
+	s.imageChecker.CacheImageInfo(image, info, model.ImageVersionCacheTTL)
+
+	// A flush between the write above and caching in memory has already
+	// cleared memory, so the entry just cached would outlive it
+	if s.cacheGeneration(ctx, image) != generation+1 {
+		s.imageChecker.InvalidateCache(image)
+	}
+	return true
+}
+
+// ResolveLatestVersion returns the digest a container's tag resolves to in
+// its registry. A cached version checked within IMAGE_CACHE_HOURS is served
+// unless force is set; fresh results are written back to the cache. cached
+// reports whether the registry was skipped.
+func (s *ImageService) ResolveLatestVersion(ctx context.Context, container *model.Container, force bool) (version *model.ImageVersion, cached bool, err error) {
+	tag := container.Tag
+	if tag == "" {
+		tag = "latest"
+	}
+
+	if !force {
+		stored, err := s.imageRepo.GetByImageAndTag(ctx, container.Image, tag)
+		if err == nil && stored.InvalidatedAt == nil && time.Since(stored.CheckedAt) < s.versionCacheTTL() {
+			s.cacheCounters.hits.Add(1)
+			return stored, true, nil
+		}
+	}
+	s.cacheCounters.misses.Add(1)
+
+	generation := s.cacheGeneration(ctx, container.Image)
+
+	client, err := s.registryClient(container)
+	if err != nil {
+		return nil, false, err
+	}
+	latest, err := client.GetLatestImageInfo(ctx, container.Image+":"+tag)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get latest image info: %w", err)
+	}
+
+	// Stored under the tag the container tracks, which Docker Hub may have
+	// resolved to a newer tag
+	version = &model.ImageVersion{
+		ImageName:    container.Image,
+		Tag:          tag,
+		Digest:       latest.Digest,
+		SizeBytes:    latest.SizeBytes,
+		PublishedAt:  latest.PublishedAt,
+		Architecture: latest.Architecture,
+		OS:           latest.OS,
+		RegistryURL:  container.RegistryURL,
+		IsLatest:     true,
+	}
+	if latest.Tag != "" && latest.Tag != tag {
+		metadata, _ := json.Marshal(map[string]string{"latest_tag": latest.Tag})
+		version.Metadata = string(metadata)
+	}
+
+	if generation >= 0 {
+		stored, err := s.imageRepo.UpsertVersionIfGeneration(ctx, version, generation)
+		switch {
+		case err != nil:
+			logrus.WithError(err).WithField("image", container.Image).Warn("Failed to save image version to database")
+		case !stored:
+			s.cacheCounters.discarded.Add(1)
+		}
+	}
+	if version.CheckedAt.IsZero() {
+		version.CheckedAt = time.Now().UTC()
+	}
+
+	return version, false, nil
+}
+
+// versionCacheTTL is how long a stored version is served to update checks
+func (s *ImageService) versionCacheTTL() time.Duration {
+	if s.config == nil || s.config.ImageCheck.ImageCacheHours <= 0 {
+		return model.ImageVersionCacheTTL
+	}
+	return time.Duration(s.config.ImageCheck.ImageCacheHours) * time.Hour
+}
+
+// logCacheActivity records a cache operation performed by actor
+func (s *ImageService) logCacheActivity(actor model.Actor, action, repository, description string, metadata map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadataJSON, _ := json.Marshal(metadata)
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "image_cache",
+		ResourceName: repository,
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("action", action).Warn("Failed to log image cache activity")
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/registry"
+)
+
+// generationRepo keeps cached versions with their generation, as the image
+// version table does
+type generationRepo struct {
+	repository.ImageVersionRepository
+	mu       sync.Mutex
+	versions map[string]*model.ImageVersion
+}
+
+func (r *generationRepo) version(image string) *model.ImageVersion {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if version, ok := r.versions[image]; ok {
+		copied := *version
+		return &copied
+	}
+	return nil
+}
+
+func (r *generationRepo) GetCacheGeneration(ctx context.Context, imageName string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if version, ok := r.versions[imageName]; ok {
+		return version.Generation, nil
+	}
+	return 0, nil
+}
+
+func (r *generationRepo) UpsertVersionIfGeneration(ctx context.Context, version *model.ImageVersion, generation int64) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var current int64
+	if existing, ok := r.versions[version.ImageName]; ok {
+		current = existing.Generation
+	}
+	if current != generation {
+		return false, nil
+	}
+	stored := *version
+	stored.Generation = generation + 1
+	stored.InvalidatedAt = nil
+	r.versions[version.ImageName] = &stored
+	return true, nil
+}
+
+func (r *generationRepo) ListCachedImages(ctx context.Context) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var images []string
+	for image := range r.versions {
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+func (r *generationRepo) InvalidateCache(ctx context.Context, imageName string) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	version, ok := r.versions[imageName]
+	if !ok {
+		return 0, nil
+	}
+	now := time.Now()
+	version.Generation++
+	version.InvalidatedAt = &now
+	return 1, nil
+}
+
+// pushedRegistry serves the digest an image has when a fetch starts. The
+// first fetch is held until release is closed.
+type pushedRegistry struct {
+	registry.Client
+
+	mu       sync.Mutex
+	digest   string
+	fetches  int
+	fetching chan struct{}
+	release  chan struct{}
+}
+
+func (r *pushedRegistry) push(digest string) {
+	r.mu.Lock()
+	r.digest = digest
+	r.mu.Unlock()
+}
+
+func (r *pushedRegistry) GetLatestImageInfo(ctx context.Context, image string) (*model.ImageVersion, error) {
+	r.mu.Lock()
+	digest := r.digest
+	r.fetches++
+	first := r.fetches == 1
+	r.mu.Unlock()
+
+	if first {
+		close(r.fetching)
+		<-r.release
+	}
+	return &model.ImageVersion{ImageName: image, Tag: "latest", Digest: digest}, nil
+}
+
+// memoryChecker caches image versions in memory and fetches them from client
+type memoryChecker struct {
+	registry.ImageChecker
+	client registry.Client
+
+	mu     sync.Mutex
+	cached map[string]*model.ImageVersion
+}
+
+func (r *memoryChecker) GetClient(registryURL string) (registry.Client, error) {
+	return r.client, nil
+}
+
+func (r *memoryChecker) GetCachedImageInfo(image string) (*model.ImageVersion, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	info, ok := r.cached[image]
+	return info, ok
+}
+
+func (r *memoryChecker) CacheImageInfo(image string, info *model.ImageVersion, ttl time.Duration) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cached[image] = info
+	return nil
+}
+
+func (r *memoryChecker) InvalidateCache(image string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.cached, image)
+	return nil
+}
+
+// checkedContainers lists a fixed set of containers
+type checkedContainers struct {
+	repository.ContainerRepository
+	containers []*model.Container
+}
+
+func (r *checkedContainers) List(ctx context.Context, filter *model.ContainerFilter) ([]*model.Container, int64, error) {
+	return r.containers, int64(len(r.containers)), nil
+}
+
+func TestWebhookRefreshWinsOverConcurrentScheduledCheck(t *testing.T) {
+	ctx := context.Background()
+	images := &generationRepo{versions: map[string]*model.ImageVersion{
+		"nginx:latest": {ImageName: "nginx:latest", Tag: "latest", Digest: "sha256:old", Generation: 1},
+	}}
+	reg := &pushedRegistry{
+		digest:   "sha256:old",
+		fetching: make(chan struct{}),
+		release:  make(chan struct{}),
+	}
+	checker := &memoryChecker{client: reg, cached: map[string]*model.ImageVersion{}}
+	web := &model.Container{ID: 1, Name: "web", Image: "nginx", Tag: "latest"}
+	s := &ImageService{
+		imageRepo:     images,
+		containerRepo: &checkedContainers{containers: []*model.Container{web}},
+		imageChecker:  checker,
+		ctx:           ctx,
+	}
+
+	// The scheduled check fetches the old digest and is held before storing it
+	scheduled := make(chan error, 1)
+	go func() {
+		_, err := s.getLatestImageVersion(ctx, web)
+		scheduled <- err
+	}()
+	<-reg.fetching
+
+	// A push arrives meanwhile; the webhook flushes and refreshes nginx
+	reg.push("sha256:new")
+	result, err := s.RefreshRepository(ctx, model.SystemActor("webhook"), "nginx")
+	if err != nil {
+		t.Fatalf("RefreshRepository failed: %v", err)
+	}
+	if result.InvalidatedRows != 1 || len(result.Images) != 1 || result.Containers != 1 {
+		t.Errorf("flush = %+v, want nginx:latest and web", result)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for s.cacheCounters.refreshes.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the refresh after the push did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	// The held check finishes last and must not write the old digest back
+	close(reg.release)
+	if err := <-scheduled; err != nil {
+		t.Fatalf("scheduled check failed: %v", err)
+	}
+
+	if stored := images.version("nginx:latest"); stored == nil || stored.Digest != "sha256:new" || stored.InvalidatedAt != nil {
+		t.Errorf("stored version = %+v, want the refreshed sha256:new", stored)
+	}
+	if cached, ok := checker.GetCachedImageInfo("nginx:latest"); !ok || cached.Digest != "sha256:new" {
+		t.Errorf("memory cache = %+v, want sha256:new", cached)
+	}
+	if discarded := s.cacheCounters.discarded.Load(); discarded != 1 {
+		t.Errorf("discarded %d writes, want the scheduled check's", discarded)
+	}
+}
@@ -0,0 +1,269 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/registry"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	defaultImageHistoryLimit = 50
+	maxImageHistoryLimit     = 200
+
+	// maxDeploymentUpdates bounds the update history read per container
+	maxDeploymentUpdates = 100
+)
+
+// ImageVersionHistory is the version history of a repository, most recently
+// first seen version first
+type ImageVersionHistory struct {
+	Repository string                      `json:"repository"`
+	Versions   []*ImageVersionHistoryEntry `json:"versions"`
+}
+
+// ImageVersionHistoryEntry is a version of a repository with the containers
+// that ran it
+type ImageVersionHistoryEntry struct {
+	*model.ImageVersionRecord
+	Deployments []ImageVersionDeployment `json:"deployments"`
+}
+
+// ImageVersionDeployment is a period during which a container ran a version.
+// From is unset when the version was deployed before the update history
+// begins, Until while the container still runs it.
+type ImageVersionDeployment struct {
+	ContainerID   int64      `json:"container_id"`
+	ContainerName string     `json:"container_name"`
+	UpdateID      *int       `json:"update_id,omitempty"`
+	From          *time.Time `json:"from,omitempty"`
+	Until         *time.Time `json:"until,omitempty"`
+	Current       bool       `json:"current"`
+}
+
+// deployedVersion is a version a container ran, taken from its update history
+type deployedVersion struct {
+	tag        string
+	digest     string
+	deployment ImageVersionDeployment
+}
+
+// RecordImageVersion adds a version seen for a container's image to the
+// repository's version history, or refreshes when it was last seen. A missing
+// release notes link is resolved from the image policies; the image's OCI
+// labels are only read the first time a version is seen.
+func (s *ImageService) RecordImageVersion(ctx context.Context, container *model.Container, tag, digest string) (*model.ImageVersionRecord, error) {
+	repository := model.NormalizeRepository(container.Image)
+	if repository == "" {
+		return nil, fmt.Errorf("container has no image")
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+
+	record, err := s.imageRepo.GetVersionRecord(ctx, repository, tag, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	firstSeen := record == nil
+	if firstSeen {
+		record = &model.ImageVersionRecord{
+			Repository:  repository,
+			Tag:         tag,
+			Digest:      digest,
+			FirstSeenAt: now,
+		}
+	}
+	record.LastSeenAt = now
+
+	if record.ReleaseNotesURL == "" {
+		record.ReleaseNotesURL = s.resolveReleaseNotesURL(ctx, container, tag, digest, firstSeen)
+	}
+
+	if err := s.imageRepo.SaveVersionRecord(ctx, record); err != nil {
+		return nil, err
+	}
+
+	return record, nil
+}
+
+// GetImageHistory returns the version history of the repository of an image,
+// with the periods each managed container ran a version joined from the
+// update history
+func (s *ImageService) GetImageHistory(ctx context.Context, image string, limit int) (*ImageVersionHistory, error) {
+	repository := model.NormalizeRepository(image)
+	if repository == "" {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: image name is required")
+	}
+	if limit <= 0 {
+		limit = defaultImageHistoryLimit
+	}
+	if limit > maxImageHistoryLimit {
+		limit = maxImageHistoryLimit
+	}
+
+	records, err := s.imageRepo.ListVersionRecords(ctx, repository, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get image version history: %w", err)
+	}
+
+	history := &ImageVersionHistory{
+		Repository: repository,
+		Versions:   make([]*ImageVersionHistoryEntry, len(records)),
+	}
+	for i, record := range records {
+		history.Versions[i] = &ImageVersionHistoryEntry{
+			ImageVersionRecord: record,
+			Deployments:        []ImageVersionDeployment{},
+		}
+	}
+	if len(records) == 0 {
+		return history, nil
+	}
+
+	containers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+
+	for _, container := range containers {
+		if model.NormalizeRepository(container.Image) != repository {
+			continue
+		}
+
+		for _, deployed := range s.containerDeployments(ctx, container) {
+			for _, entry := range history.Versions {
+				if entry.Matches(deployed.tag, deployed.digest) {
+					entry.Deployments = append(entry.Deployments, deployed.deployment)
+					break
+				}
+			}
+		}
+	}
+
+	return history, nil
+}
+
+// containerDeployments lists the versions a container ran, oldest first: the
+// image it had before its first successful update, one per successful update,
+// and the running image when there is no update history
+func (s *ImageService) containerDeployments(ctx context.Context, container *model.Container) []deployedVersion {
+	base := ImageVersionDeployment{
+		ContainerID:   int64(container.ID),
+		ContainerName: container.Name,
+	}
+
+	var updates []*model.UpdateHistory
+	if s.updateRepo != nil {
+		containerID := container.ID
+		histories, _, err := s.updateRepo.List(ctx, &model.UpdateHistoryFilter{
+			ContainerID: &containerID,
+			Limit:       maxDeploymentUpdates,
+		})
+		if err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to get update history")
+		}
+		for _, history := range histories {
+			if history.IsSuccessful() && history.NewImage != "" {
+				updates = append(updates, history)
+			}
+		}
+	}
+
+	if len(updates) == 0 {
+		deployment := base
+		deployment.Current = true
+		return []deployedVersion{{tag: container.Tag, digest: container.ImageDigest, deployment: deployment}}
+	}
+
+	sort.Slice(updates, func(i, j int) bool { return updates[i].StartedAt.Before(updates[j].StartedAt) })
+
+	deployed := make([]deployedVersion, 0, len(updates)+1)
+	if first := updates[0]; first.OldImage != "" {
+		_, tag, digest := model.ParseImageReference(first.OldImage)
+		if digest == "" {
+			digest = first.OldDigest
+		}
+		deployment := base
+		deployment.Until = updateFinishedAt(first)
+		deployed = append(deployed, deployedVersion{tag: tag, digest: digest, deployment: deployment})
+	}
+
+	for i, update := range updates {
+		_, tag, digest := model.ParseImageReference(update.NewImage)
+		if digest == "" {
+			digest = update.NewDigest
+		}
+
+		updateID := update.ID
+		deployment := base
+		deployment.UpdateID = &updateID
+		deployment.From = updateFinishedAt(update)
+		if i+1 < len(updates) {
+			deployment.Until = updateFinishedAt(updates[i+1])
+		} else {
+			deployment.Current = true
+		}
+		deployed = append(deployed, deployedVersion{tag: tag, digest: digest, deployment: deployment})
+	}
+
+	return deployed
+}
+
+// resolveReleaseNotesURL finds the release notes link of a version: from the
+// URL template of a matching image policy, otherwise, when readLabels is set,
+// from the OCI labels of the image config
+func (s *ImageService) resolveReleaseNotesURL(ctx context.Context, container *model.Container, tag, digest string, readLabels bool) string {
+	logger := logrus.WithFields(logrus.Fields{
+		"image": container.Image,
+		"tag":   tag,
+	})
+
+	if s.policyService != nil {
+		link, err := s.policyService.ReleaseNotesURL(ctx, container.Image, tag, digest)
+		if err != nil {
+			logger.WithError(err).Warn("Failed to resolve release notes URL template")
+		} else if link != "" {
+			return link
+		}
+	}
+
+	if !readLabels || s.imageChecker == nil {
+		return ""
+	}
+
+	client, err := s.imageChecker.GetClient(container.RegistryURL)
+	if err != nil {
+		logger.WithError(err).Debug("No registry client to read image labels")
+		return ""
+	}
+
+	image, _, _ := model.ParseImageReference(container.Image)
+	manifest, err := client.GetImageManifest(ctx, image, tag)
+	if err != nil {
+		logger.WithError(err).Debug("Failed to read image labels")
+		return ""
+	}
+
+	labels := manifest.Labels
+	if manifest.Config != nil && len(manifest.Config.Labels) > 0 {
+		labels = manifest.Config.Labels
+	}
+	return registry.ReleaseNotesURLFromLabels(labels)
+}
+
+func updateFinishedAt(update *model.UpdateHistory) *time.Time {
+	if update.CompletedAt != nil {
+		return update.CompletedAt
+	}
+	startedAt := update.StartedAt
+	return &startedAt
+}
@@ -0,0 +1,421 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrImagePolicyNotFound is returned when an image policy does not exist
+	ErrImagePolicyNotFound = errors.New("image policy not found")
+
+	// ErrImagePolicyExists is returned when a policy already covers the repository and tag pattern
+	ErrImagePolicyExists = errors.New("image policy already exists")
+)
+
+// ImagePolicyService manages image-level update policy defaults and resolves
+// the effective policy of containers
+type ImagePolicyService struct {
+	policyRepo    repository.ImagePolicyRepository
+	containerRepo repository.ContainerRepository
+	stackRepo     repository.StackRepository
+	cache         *CacheService
+	config        *config.Config
+}
+
+// NewImagePolicyService creates a new image policy service instance
+func NewImagePolicyService(
+	policyRepo repository.ImagePolicyRepository,
+	containerRepo repository.ContainerRepository,
+	stackRepo repository.StackRepository,
+	cache *CacheService,
+	config *config.Config,
+) *ImagePolicyService {
+	return &ImagePolicyService{
+		policyRepo:    policyRepo,
+		containerRepo: containerRepo,
+		stackRepo:     stackRepo,
+		cache:         cache,
+		config:        config,
+	}
+}
+
+// ImagePolicyRequest represents a request to create or replace an image policy.
+// Omitted settings are inherited from the global defaults.
+type ImagePolicyRequest struct {
+	Repository             string                    `json:"repository" binding:"required"`
+	TagPattern             string                    `json:"tag_pattern,omitempty"`
+	UpdatePolicy           *string                   `json:"update_policy,omitempty"`
+	CheckIntervalMinutes   *int                      `json:"check_interval_minutes,omitempty"`
+	HoldDownHours          *int                      `json:"hold_down_hours,omitempty"`
+	MaintenanceWindows     []model.MaintenanceWindow `json:"maintenance_windows,omitempty"`
+	VulnerabilityThreshold *string                   `json:"vulnerability_threshold,omitempty"`
+	Description            string                    `json:"description,omitempty"`
+
+	// ReleaseNotesURLTemplate links versions to their release notes, e.g.
+	// https://github.com/org/repo/releases/tag/{tag}
+	ReleaseNotesURLTemplate string `json:"release_notes_url_template,omitempty"`
+}
+
+// ImagePolicyResult is returned by policy changes together with the
+// recomputed eligibility of the containers the change affects
+type ImagePolicyResult struct {
+	Policy             *model.ImagePolicy      `json:"policy,omitempty"`
+	AffectedContainers []*ContainerEligibility `json:"affected_containers"`
+}
+
+// ContainerEligibility describes whether a container is eligible for automatic updates
+type ContainerEligibility struct {
+	ContainerID     int64                  `json:"container_id"`
+	Name            string                 `json:"name"`
+	Eligible        bool                   `json:"eligible"`
+	EffectivePolicy *model.EffectivePolicy `json:"effective_policy"`
+}
+
+// Validate validates ImagePolicyRequest
+func (r *ImagePolicyRequest) Validate() error {
+	r.Repository = strings.TrimSpace(r.Repository)
+	r.TagPattern = strings.TrimSpace(r.TagPattern)
+
+	if model.NormalizeRepository(r.Repository) == "" {
+		return fmt.Errorf("repository is required")
+	}
+	if len(r.TagPattern) > 100 {
+		return fmt.Errorf("tag pattern must be at most 100 characters")
+	}
+	if r.TagPattern != "" {
+		if _, err := path.Match(r.TagPattern, ""); err != nil {
+			return fmt.Errorf("invalid tag pattern: %w", err)
+		}
+	}
+	if r.UpdatePolicy != nil && !dto.IsValidUpdatePolicy(*r.UpdatePolicy) {
+		return fmt.Errorf("invalid update policy")
+	}
+	if r.CheckIntervalMinutes != nil && *r.CheckIntervalMinutes < 1 {
+		return fmt.Errorf("check interval must be at least 1 minute")
+	}
+	if r.HoldDownHours != nil && *r.HoldDownHours < 0 {
+		return fmt.Errorf("hold-down cannot be negative")
+	}
+	if r.VulnerabilityThreshold != nil && !dto.IsValidVulnerabilityThreshold(*r.VulnerabilityThreshold) {
+		return fmt.Errorf("invalid vulnerability threshold")
+	}
+	if err := dto.ValidateMaintenanceWindows(r.MaintenanceWindows); err != nil {
+		return err
+	}
+	r.ReleaseNotesURLTemplate = strings.TrimSpace(r.ReleaseNotesURLTemplate)
+	if r.ReleaseNotesURLTemplate != "" {
+		if err := validateReleaseNotesURLTemplate(r.ReleaseNotesURLTemplate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Defaults returns the global policy defaults
+func (s *ImagePolicyService) Defaults() model.PolicyDefaults {
+	defaults := model.PolicyDefaults{
+		UpdatePolicy:           model.UpdatePolicyManual,
+		CheckIntervalMinutes:   60,
+		MaintenanceWindows:     []model.MaintenanceWindow{},
+		VulnerabilityThreshold: model.VulnerabilityThresholdNone,
+	}
+	if s.config != nil && s.config.ImageCheck.DefaultInterval > 0 {
+		defaults.CheckIntervalMinutes = s.config.ImageCheck.DefaultInterval
+	}
+	return defaults
+}
+
+// EffectivePolicy resolves the policy that applies to a container
+func (s *ImagePolicyService) EffectivePolicy(ctx context.Context, container *model.Container) (*model.EffectivePolicy, error) {
+	if container == nil {
+		return nil, fmt.Errorf("container cannot be nil")
+	}
+
+	policies, err := s.policyRepo.GetByRepository(ctx, container.Image)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image policies: %w", err)
+	}
+
+	stack, err := s.containerStack(ctx, container)
+	if err != nil {
+		return nil, err
+	}
+
+	return model.ResolveEffectivePolicy(container, stack, policies, s.Defaults()), nil
+}
+
+// ReleaseNotesURL resolves the release notes link of a version from the most
+// specific matching policy that sets a URL template. It returns "" when no
+// policy does.
+func (s *ImagePolicyService) ReleaseNotesURL(ctx context.Context, image, tag, digest string) (string, error) {
+	policies, err := s.policyRepo.GetByRepository(ctx, image)
+	if err != nil {
+		return "", fmt.Errorf("failed to load image policies: %w", err)
+	}
+
+	withTemplate := make([]*model.ImagePolicy, 0, len(policies))
+	for _, policy := range policies {
+		if policy.ReleaseNotesURLTemplate != "" {
+			withTemplate = append(withTemplate, policy)
+		}
+	}
+
+	return model.SelectImagePolicy(withTemplate, image, tag).ReleaseNotesURL(tag, digest), nil
+}
+
+// containerStack loads the stack a container belongs to, if any
+func (s *ImagePolicyService) containerStack(ctx context.Context, container *model.Container) (*model.Stack, error) {
+	if container.StackID == nil || s.stackRepo == nil {
+		return nil, nil
+	}
+	stack, err := s.stackRepo.GetByID(ctx, int64(*container.StackID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load stack: %w", err)
+	}
+	return stack, nil
+}
+
+// ListPolicies lists image policies
+func (s *ImagePolicyService) ListPolicies(ctx context.Context, filter *model.ImagePolicyFilter) ([]*model.ImagePolicy, int64, error) {
+	policies, total, err := s.policyRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list image policies: %w", err)
+	}
+	return policies, total, nil
+}
+
+// GetPolicy retrieves an image policy by ID
+func (s *ImagePolicyService) GetPolicy(ctx context.Context, id int64) (*model.ImagePolicy, error) {
+	policy, err := s.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, translatePolicyError(err)
+	}
+	return policy, nil
+}
+
+// CreatePolicy creates an image policy and recomputes the affected containers
+func (s *ImagePolicyService) CreatePolicy(ctx context.Context, userID int64, req *ImagePolicyRequest) (*ImagePolicyResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("image policy request cannot be nil")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	userIDInt := int(userID)
+	policy := &model.ImagePolicy{CreatedBy: &userIDInt}
+	if err := req.apply(policy); err != nil {
+		return nil, err
+	}
+
+	if err := s.policyRepo.Create(ctx, policy); err != nil {
+		return nil, translatePolicyError(err)
+	}
+
+	affected, err := s.RecomputeEligibility(ctx, policy.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"policy_id":   policy.ID,
+		"repository":  policy.Repository,
+		"tag_pattern": policy.TagPattern,
+		"user_id":     userID,
+		"affected":    len(affected),
+	}).Info("Image policy created")
+
+	return &ImagePolicyResult{Policy: policy, AffectedContainers: affected}, nil
+}
+
+// UpdatePolicy replaces an image policy and recomputes the affected containers
+func (s *ImagePolicyService) UpdatePolicy(ctx context.Context, userID int64, id int64, req *ImagePolicyRequest) (*ImagePolicyResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("image policy request cannot be nil")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	policy, err := s.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, translatePolicyError(err)
+	}
+	previousRepository := policy.Repository
+
+	if err := req.apply(policy); err != nil {
+		return nil, err
+	}
+	if err := s.policyRepo.Update(ctx, policy); err != nil {
+		return nil, translatePolicyError(err)
+	}
+
+	affected, err := s.RecomputeEligibility(ctx, policy.Repository)
+	if err != nil {
+		return nil, err
+	}
+	if previousRepository != policy.Repository {
+		previous, err := s.RecomputeEligibility(ctx, previousRepository)
+		if err != nil {
+			return nil, err
+		}
+		affected = append(affected, previous...)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"policy_id":  policy.ID,
+		"repository": policy.Repository,
+		"user_id":    userID,
+		"affected":   len(affected),
+	}).Info("Image policy updated")
+
+	return &ImagePolicyResult{Policy: policy, AffectedContainers: affected}, nil
+}
+
+// DeletePolicy removes an image policy and recomputes the affected containers
+func (s *ImagePolicyService) DeletePolicy(ctx context.Context, userID int64, id int64) (*ImagePolicyResult, error) {
+	policy, err := s.policyRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, translatePolicyError(err)
+	}
+
+	if err := s.policyRepo.Delete(ctx, id); err != nil {
+		return nil, translatePolicyError(err)
+	}
+
+	affected, err := s.RecomputeEligibility(ctx, policy.Repository)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"policy_id":  id,
+		"repository": policy.Repository,
+		"user_id":    userID,
+		"affected":   len(affected),
+	}).Info("Image policy deleted")
+
+	return &ImagePolicyResult{AffectedContainers: affected}, nil
+}
+
+// RecomputeEligibility resolves the effective policy of every container running
+// the repository and drops their cached details so the new policy shows up
+func (s *ImagePolicyService) RecomputeEligibility(ctx context.Context, repository string) ([]*ContainerEligibility, error) {
+	normalized := model.NormalizeRepository(repository)
+	if normalized == "" {
+		return []*ContainerEligibility{}, nil
+	}
+
+	// Containers may store the image in any of its equivalent forms, so search
+	// by the last path segment and compare normalized names
+	name := normalized[strings.LastIndex(normalized, "/")+1:]
+	containers, err := s.containerRepo.SearchByImage(ctx, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find affected containers: %w", err)
+	}
+
+	policies, err := s.policyRepo.GetByRepository(ctx, normalized)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load image policies: %w", err)
+	}
+	defaults := s.Defaults()
+	stacks := make(map[int]*model.Stack)
+
+	affected := make([]*ContainerEligibility, 0, len(containers))
+	for _, container := range containers {
+		if model.NormalizeRepository(container.Image) != normalized {
+			continue
+		}
+
+		var stack *model.Stack
+		if container.StackID != nil {
+			var loaded bool
+			if stack, loaded = stacks[*container.StackID]; !loaded {
+				if stack, err = s.containerStack(ctx, container); err != nil {
+					return nil, err
+				}
+				stacks[*container.StackID] = stack
+			}
+		}
+
+		effective := model.ResolveEffectivePolicy(container, stack, policies, defaults)
+		affected = append(affected, &ContainerEligibility{
+			ContainerID:     int64(container.ID),
+			Name:            container.Name,
+			Eligible:        effective.IsEligibleForAutoUpdate(),
+			EffectivePolicy: effective,
+		})
+
+		if s.cache != nil {
+			s.cache.Delete(fmt.Sprintf("container:detail:%d", container.ID))
+		}
+	}
+
+	return affected, nil
+}
+
+// apply copies the request onto the policy, clearing settings the request omits
+func (r *ImagePolicyRequest) apply(policy *model.ImagePolicy) error {
+	policy.Repository = model.NormalizeRepository(r.Repository)
+	policy.TagPattern = r.TagPattern
+	policy.CheckIntervalMinutes = r.CheckIntervalMinutes
+	policy.HoldDownHours = r.HoldDownHours
+	policy.VulnerabilityThreshold = r.VulnerabilityThreshold
+	policy.Description = r.Description
+	policy.ReleaseNotesURLTemplate = r.ReleaseNotesURLTemplate
+
+	policy.UpdatePolicy = nil
+	if r.UpdatePolicy != nil {
+		updatePolicy := model.UpdatePolicy(*r.UpdatePolicy)
+		policy.UpdatePolicy = &updatePolicy
+	}
+
+	policy.MaintenanceWindows = ""
+	if r.MaintenanceWindows != nil {
+		windowsJSON, err := json.Marshal(r.MaintenanceWindows)
+		if err != nil {
+			return fmt.Errorf("failed to marshal maintenance windows: %w", err)
+		}
+		policy.MaintenanceWindows = string(windowsJSON)
+	}
+
+	return nil
+}
+
+// translatePolicyError maps repository errors onto the service's sentinel errors
+func translatePolicyError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return fmt.Errorf("%w: %v", ErrImagePolicyNotFound, err)
+	case strings.Contains(err.Error(), "already exists"):
+		return fmt.Errorf("%w: %v", ErrImagePolicyExists, err)
+	default:
+		return err
+	}
+}
+
+func validateReleaseNotesURLTemplate(template string) error {
+	if len(template) > 500 {
+		return fmt.Errorf("release notes URL template must be at most 500 characters")
+	}
+	expanded := model.ExpandReleaseNotesURL(template, "library/example", "v1.0.0", "sha256:0")
+	u, err := url.Parse(expanded)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("release notes URL template must be an http or https URL")
+	}
+	return nil
+}
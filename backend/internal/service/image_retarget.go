@@ -0,0 +1,598 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	dockerregistry "github.com/docker/docker/api/types/registry"
+	"github.com/sirupsen/logrus"
+)
+
+// retargetOperation names retarget confirmations
+const retargetOperation = "image_retarget"
+
+// RetargetAction is what a retarget does to a matched container
+type RetargetAction string
+
+const (
+	RetargetActionRetarget RetargetAction = "retarget"
+	RetargetActionSkip     RetargetAction = "skip"
+)
+
+// RetargetRequest moves containers from one image reference to another, e.g.
+// postgres:15 to postgres:15-bookworm. Without a confirm token it only
+// previews; repeating it with the preview's token runs it.
+type RetargetRequest struct {
+	Source string `json:"source" binding:"required"`
+	Target string `json:"target" binding:"required"`
+
+	// Optional filter; containers must also be on the source or target image
+	ContainerIDs []int64 `json:"container_ids,omitempty"`
+	StackID      *int    `json:"stack_id,omitempty"`
+	NamePattern  string  `json:"name_pattern,omitempty"`
+
+	// Execute updates containers whose update window is open right away;
+	// otherwise, and for the rest, the update is left pending for the updater.
+	// It needs the health_gated strategy, which it defaults to.
+	Execute  bool   `json:"execute,omitempty"`
+	Strategy string `json:"strategy,omitempty"`
+
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// RetargetCandidate is a container matched by a retarget
+type RetargetCandidate struct {
+	ContainerID  int64          `json:"container_id"`
+	Name         string         `json:"name"`
+	CurrentImage string         `json:"current_image"`
+	Platform     string         `json:"platform,omitempty"`
+	Action       RetargetAction `json:"action"`
+	Note         string         `json:"note,omitempty"`
+}
+
+// RetargetPreview lists what a retarget would do and carries the token that
+// confirms it
+type RetargetPreview struct {
+	Source          string               `json:"source"`
+	Target          string               `json:"target"`
+	TargetDigest    string               `json:"target_digest,omitempty"`
+	TargetPlatforms []string             `json:"target_platforms,omitempty"`
+	Execute         bool                 `json:"execute"`
+	Containers      []*RetargetCandidate `json:"containers"`
+	ToRetarget      int                  `json:"to_retarget"`
+	Skipped         int                  `json:"skipped"`
+	ConfirmToken    string               `json:"confirm_token,omitempty"`
+	ExpiresAt       *time.Time           `json:"expires_at,omitempty"`
+}
+
+// RetargetResponse is the preview of a retarget and, once confirmed, the
+// bulk operation tracking it
+type RetargetResponse struct {
+	Preview   *RetargetPreview     `json:"preview"`
+	Operation *model.BulkOperation `json:"operation,omitempty"`
+}
+
+// ImageRetargetService moves containers between image tags as one tracked
+// bulk operation
+type ImageRetargetService struct {
+	containerRepo     repository.ContainerRepository
+	updateHistoryRepo repository.UpdateHistoryRepository
+	operationRepo     repository.BulkOperationRepository
+	activityRepo      repository.ActivityLogRepository
+	containerService  *ContainerService
+	dockerClient      *docker.DockerClient
+	tokens            *confirmationTokens
+}
+
+// NewImageRetargetService creates a new image retarget service instance
+func NewImageRetargetService(
+	containerRepo repository.ContainerRepository,
+	updateHistoryRepo repository.UpdateHistoryRepository,
+	operationRepo repository.BulkOperationRepository,
+	activityRepo repository.ActivityLogRepository,
+	containerService *ContainerService,
+	dockerClient *docker.DockerClient,
+	cfg *config.Config,
+) *ImageRetargetService {
+	return &ImageRetargetService{
+		containerRepo:     containerRepo,
+		updateHistoryRepo: updateHistoryRepo,
+		operationRepo:     operationRepo,
+		activityRepo:      activityRepo,
+		containerService:  containerService,
+		dockerClient:      dockerClient,
+		tokens:            newConfirmationTokens(cfg.JWT.Secret),
+	}
+}
+
+// retargetRefs is a parsed retarget request
+type retargetRefs struct {
+	sourceImage, sourceTag string
+	targetImage, targetTag string
+}
+
+func (r retargetRefs) target() string {
+	return r.targetImage + ":" + r.targetTag
+}
+
+// Retarget previews the retarget or, given the token of an unchanged
+// preview, starts it and returns the operation to follow its progress
+func (s *ImageRetargetService) Retarget(ctx context.Context, actor model.Actor, req *RetargetRequest) (*RetargetResponse, error) {
+	refs, err := parseRetargetRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	preview, target, err := s.preview(ctx, actor, req, refs)
+	if err != nil {
+		return nil, err
+	}
+
+	var userID int64
+	if actor.UserID != nil {
+		userID = *actor.UserID
+	}
+	digest := preview.digest(req.Strategy)
+
+	if req.ConfirmToken == "" {
+		if preview.ToRetarget > 0 {
+			token, expiresAt := s.tokens.Issue(retargetOperation, userID, digest)
+			preview.ConfirmToken = token
+			preview.ExpiresAt = &expiresAt
+		}
+		return &RetargetResponse{Preview: preview}, nil
+	}
+
+	if err := s.tokens.Verify(req.ConfirmToken, retargetOperation, userID, digest); err != nil {
+		return nil, err
+	}
+	if preview.ToRetarget == 0 {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: no containers to retarget")
+	}
+
+	parameters, _ := json.Marshal(req)
+	operation := &model.BulkOperation{
+		Type:       model.BulkOperationImageRetarget,
+		Status:     model.BulkOperationStatusRunning,
+		Summary:    fmt.Sprintf("Retarget %s to %s", req.Source, req.Target),
+		Parameters: string(parameters),
+		Total:      len(preview.Containers),
+		StartedAt:  time.Now(),
+	}
+	operation.SetActor(actor)
+	if err := s.operationRepo.Create(ctx, operation); err != nil {
+		return nil, err
+	}
+
+	s.logOperation(actor, operation, "image_retarget_started")
+
+	snapshot := *operation
+	go s.run(actor, operation, preview, target, refs, req)
+
+	return &RetargetResponse{Preview: preview, Operation: &snapshot}, nil
+}
+
+// GetOperation returns a retarget operation with its progress so far
+func (s *ImageRetargetService) GetOperation(ctx context.Context, actor model.Actor, operationID int64) (*model.BulkOperation, error) {
+	operation, err := s.operationRepo.GetByID(ctx, operationID)
+	if err != nil {
+		return nil, err
+	}
+	if operation.Type != model.BulkOperationImageRetarget {
+		return nil, fmt.Errorf("bulk operation with ID %d not found", operationID)
+	}
+	if !actor.IsSystem() && (operation.CreatedBy == nil || !actor.IsUser(int64(*operation.CreatedBy))) {
+		return nil, apperrors.Newf(apperrors.CodePermissionDenied, "access denied: operation belongs to different user")
+	}
+	return operation, nil
+}
+
+// preview matches the containers and validates the target against the
+// registry for the platform each container runs on
+func (s *ImageRetargetService) preview(ctx context.Context, actor model.Actor, req *RetargetRequest, refs retargetRefs) (*RetargetPreview, *docker.RegistryImage, error) {
+	filter := &model.ContainerFilter{StackID: req.StackID}
+	access, err := s.containerService.containerAccessFor(ctx, actor)
+	if err != nil {
+		return nil, nil, err
+	}
+	access.scope(filter)
+	var containers []*model.Container
+	if !access.none() {
+		containers, _, err = s.containerRepo.List(ctx, filter)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to list containers: %w", err)
+		}
+	}
+
+	selected := make(map[int64]bool, len(req.ContainerIDs))
+	for _, id := range req.ContainerIDs {
+		selected[id] = true
+	}
+
+	preview := &RetargetPreview{
+		Source:     req.Source,
+		Target:     req.Target,
+		Execute:    req.Execute,
+		Containers: []*RetargetCandidate{},
+	}
+	var auth *dockerregistry.AuthConfig
+
+	for _, container := range containers {
+		if len(selected) > 0 && !selected[int64(container.ID)] {
+			continue
+		}
+		if !access.permission(container).Allows(model.ContainerPermissionManage) {
+			continue
+		}
+		if req.NamePattern != "" {
+			if matched, _ := path.Match(req.NamePattern, container.Name); !matched {
+				continue
+			}
+		}
+
+		candidate := &RetargetCandidate{
+			ContainerID:  int64(container.ID),
+			Name:         container.Name,
+			CurrentImage: container.GetFullImageName(),
+			Action:       RetargetActionRetarget,
+		}
+
+		switch {
+		case isOnImage(container, refs.targetImage, refs.targetTag):
+			candidate.Action = RetargetActionSkip
+			candidate.Note = "already on " + refs.target()
+		case !isOnImage(container, refs.sourceImage, refs.sourceTag):
+			if !selected[int64(container.ID)] {
+				continue
+			}
+			candidate.Action = RetargetActionSkip
+			candidate.Note = "not on " + req.Source
+		default:
+			if image, err := s.dockerClient.InspectImage(ctx, container.GetDeployImageRef()); err == nil {
+				candidate.Platform = docker.FormatPlatform(image.Os, image.Architecture, image.Variant)
+			}
+			if auth == nil {
+				auth = s.containerService.registryAuthConfig(container)
+			}
+		}
+
+		preview.Containers = append(preview.Containers, candidate)
+	}
+
+	var target *docker.RegistryImage
+	if preview.countRetargets() > 0 {
+		target, err = s.dockerClient.InspectRegistryImage(ctx, refs.target(), auth)
+		if err != nil {
+			return nil, nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: target %s cannot be resolved: %w", refs.target(), err)
+		}
+		preview.TargetDigest = target.Digest
+		preview.TargetPlatforms = target.Platforms
+
+		for _, candidate := range preview.Containers {
+			if candidate.Action == RetargetActionRetarget && candidate.Platform != "" &&
+				len(target.Platforms) > 0 && !platformAvailable(candidate.Platform, target.Platforms) {
+				candidate.Action = RetargetActionSkip
+				candidate.Note = fmt.Sprintf("%s has no %s image", refs.target(), candidate.Platform)
+			}
+		}
+	}
+
+	preview.ToRetarget = preview.countRetargets()
+	preview.Skipped = len(preview.Containers) - preview.ToRetarget
+	return preview, target, nil
+}
+
+// run retargets the containers one by one, saving progress after each
+func (s *ImageRetargetService) run(actor model.Actor, operation *model.BulkOperation, preview *RetargetPreview, target *docker.RegistryImage, refs retargetRefs, req *RetargetRequest) {
+	ctx := model.WithActor(context.Background(), actor)
+	logger := logrus.WithFields(logrus.Fields{"operation_id": operation.ID, "source": req.Source, "target": req.Target})
+
+	for _, candidate := range preview.Containers {
+		result := model.BulkOperationResult{
+			ContainerID:   int(candidate.ContainerID),
+			ContainerName: candidate.Name,
+			Outcome:       model.BulkOutcomeSkipped,
+			Message:       candidate.Note,
+		}
+		if candidate.Action == RetargetActionRetarget {
+			result = s.retargetContainer(ctx, actor, operation, candidate, target, refs, req)
+		}
+
+		operation.Record(result)
+		if err := s.operationRepo.Update(ctx, operation); err != nil {
+			logger.WithError(err).Warn("Failed to save retarget progress")
+		}
+	}
+
+	operation.Finish()
+	if err := s.operationRepo.Update(ctx, operation); err != nil {
+		logger.WithError(err).Error("Failed to save retarget outcome")
+	}
+
+	s.logOperation(actor, operation, "image_retarget_completed")
+
+	logger.WithFields(logrus.Fields{
+		"status":  operation.Status,
+		"updated": operation.Updated,
+		"pending": operation.Pending,
+		"skipped": operation.Skipped,
+		"failed":  operation.Failed,
+	}).Info("Image retarget completed")
+}
+
+// retargetContainer points one container at the target and records the
+// update, applying it when requested and the container's window is open
+func (s *ImageRetargetService) retargetContainer(ctx context.Context, actor model.Actor, operation *model.BulkOperation, candidate *RetargetCandidate, target *docker.RegistryImage, refs retargetRefs, req *RetargetRequest) model.BulkOperationResult {
+	result := model.BulkOperationResult{
+		ContainerID:   int(candidate.ContainerID),
+		ContainerName: candidate.Name,
+		Outcome:       model.BulkOutcomeFailed,
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, candidate.ContainerID)
+	if err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	if err := s.containerService.checkContainerPermission(ctx, container, actor, model.ContainerPermissionManage); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	if isOnImage(container, refs.targetImage, refs.targetTag) {
+		result.Outcome = model.BulkOutcomeSkipped
+		result.Message = "already on " + refs.target()
+		return result
+	}
+
+	effective, err := s.containerService.EffectivePolicy(ctx, container)
+	if err != nil {
+		result.Message = fmt.Sprintf("failed to resolve effective policy: %v", err)
+		return result
+	}
+	if effective.UpdatePolicy == model.UpdatePolicyDisabled {
+		result.Outcome = model.BulkOutcomeSkipped
+		result.Message = "updates are disabled by policy"
+		return result
+	}
+
+	history := &model.UpdateHistory{
+		ContainerID: container.ID,
+		OldImage:    container.GetDeployImageRef(),
+		NewImage:    refs.target(),
+		OldDigest:   container.ImageDigest,
+		NewDigest:   target.Digest,
+		Status:      model.UpdateStatusPending,
+		Strategy:    model.UpdateStrategy(req.Strategy),
+		TriggeredBy: model.TriggerTypeRetarget,
+		OperationID: &operation.ID,
+	}
+	history.SetActor(actor)
+
+	container.Image = refs.targetImage
+	container.Tag = refs.targetTag
+	if container.PinByDigest {
+		// Pinned containers keep their digest until the update is applied
+		container.PendingDigest = target.Digest
+	}
+	if err := s.containerRepo.Update(ctx, container); err != nil {
+		result.Message = err.Error()
+		return result
+	}
+	s.invalidateContainer(container.ID)
+
+	if err := s.updateHistoryRepo.Create(ctx, history); err != nil {
+		result.Message = fmt.Sprintf("container retargeted but the update could not be recorded: %v", err)
+		return result
+	}
+	result.UpdateID = &history.ID
+	result.Outcome = model.BulkOutcomePending
+	result.Message = "retargeted; the updater applies it within the container's update policy"
+
+	if !req.Execute {
+		return result
+	}
+	updateReq := &dto.UpdateImageRequest{Strategy: req.Strategy}
+	if !s.containerService.recreatesContainer(container, updateReq) {
+		result.Message = "retargeted; the container is not created yet, left pending"
+		return result
+	}
+
+	window, err := s.containerService.NextUpdateWindow(ctx, int64(container.ID))
+	if err != nil {
+		result.Message = fmt.Sprintf("retargeted; update window unknown, left pending: %v", err)
+		return result
+	}
+	if !window.Eligible {
+		result.Message = "retargeted; update window closed, left pending"
+		if window.NextEligibleAt != nil {
+			result.Message += " until " + window.NextEligibleAt.UTC().Format(time.RFC3339)
+		}
+		return result
+	}
+
+	if container.PinByDigest {
+		container.ImageDigest = target.Digest
+		container.PendingDigest = ""
+		if err := s.containerRepo.Update(ctx, container); err != nil {
+			result.Outcome = model.BulkOutcomeFailed
+			result.Message = err.Error()
+			return result
+		}
+	}
+
+	if err := s.containerService.applyImageUpdate(ctx, actor, container, history, updateReq); err != nil {
+		result.Outcome = model.BulkOutcomeFailed
+		result.Message = err.Error()
+		return result
+	}
+
+	result.Outcome = model.BulkOutcomeUpdated
+	result.Message = "updated to " + refs.target()
+	return result
+}
+
+// logOperation records the start or outcome of a retarget in the activity log
+func (s *ImageRetargetService) logOperation(actor model.Actor, operation *model.BulkOperation, action string) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"status":  operation.Status,
+		"total":   operation.Total,
+		"updated": operation.Updated,
+		"pending": operation.Pending,
+		"skipped": operation.Skipped,
+		"failed":  operation.Failed,
+	})
+
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "bulk_operation",
+		ResourceID:   &operation.ID,
+		ResourceName: string(operation.Type),
+		Description:  operation.Summary,
+		Metadata:     string(metadata),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("operation_id", operation.ID).Warn("Failed to log retarget operation")
+	}
+}
+
+func (s *ImageRetargetService) invalidateContainer(containerID int) {
+	if s.containerService == nil || s.containerService.cache == nil {
+		return
+	}
+	s.containerService.cache.Delete(fmt.Sprintf("container:detail:%d", containerID))
+}
+
+func (p *RetargetPreview) countRetargets() int {
+	count := 0
+	for _, candidate := range p.Containers {
+		if candidate.Action == RetargetActionRetarget {
+			count++
+		}
+	}
+	return count
+}
+
+// digest summarizes everything a confirmation vouches for: the request, the
+// target digest and what happens to each container
+func (p *RetargetPreview) digest(strategy string) string {
+	entries := make([]string, 0, len(p.Containers))
+	for _, candidate := range p.Containers {
+		entries = append(entries, fmt.Sprintf("%d:%s", candidate.ContainerID, candidate.Action))
+	}
+	sort.Strings(entries)
+
+	sum := sha256.Sum256([]byte(strings.Join([]string{
+		p.Source, p.Target, p.TargetDigest, fmt.Sprint(p.Execute), strategy, strings.Join(entries, ","),
+	}, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// parseRetargetRequest validates the request and splits its references
+func parseRetargetRequest(req *RetargetRequest) (retargetRefs, error) {
+	var refs retargetRefs
+	var digest string
+
+	refs.sourceImage, refs.sourceTag, digest = model.ParseImageReference(strings.TrimSpace(req.Source))
+	if refs.sourceImage == "" || refs.sourceTag == "" || digest != "" {
+		return refs, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: source must be an image:tag reference")
+	}
+	refs.targetImage, refs.targetTag, digest = model.ParseImageReference(strings.TrimSpace(req.Target))
+	if refs.targetImage == "" || refs.targetTag == "" || digest != "" {
+		return refs, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: target must be an image:tag reference")
+	}
+	if model.NormalizeRepository(refs.sourceImage) == model.NormalizeRepository(refs.targetImage) && refs.sourceTag == refs.targetTag {
+		return refs, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: source and target are the same")
+	}
+
+	if req.NamePattern != "" {
+		if _, err := path.Match(req.NamePattern, ""); err != nil {
+			return refs, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: invalid name pattern: %w", err)
+		}
+	}
+
+	switch req.Strategy {
+	case "":
+		req.Strategy = string(model.UpdateStrategyRecreate)
+		if req.Execute {
+			req.Strategy = string(model.UpdateStrategyHealthGated)
+		}
+	case "recreate", "rolling", "blue_green", "health_gated":
+	default:
+		return refs, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: unsupported strategy '%s'", req.Strategy)
+	}
+	// Only health_gated updates recreate the container so far; the others
+	// are left pending for the updater
+	if req.Execute && req.Strategy != string(model.UpdateStrategyHealthGated) {
+		return refs, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: execute needs the %s strategy", model.UpdateStrategyHealthGated)
+	}
+
+	return refs, nil
+}
+
+// isOnImage reports whether the container is configured with the image and tag
+func isOnImage(container *model.Container, image, tag string) bool {
+	containerTag := container.Tag
+	if containerTag == "" {
+		containerTag = "latest"
+	}
+	return containerTag == tag && model.NormalizeRepository(container.Image) == model.NormalizeRepository(image)
+}
+
+// platformAvailable reports whether a registry platform list covers the
+// platform an image runs on. A variant only has to match when both give one.
+func platformAvailable(platform string, available []string) bool {
+	want := strings.Split(platform, "/")
+	for _, candidate := range available {
+		have := strings.Split(candidate, "/")
+		if len(want) < 2 || len(have) < 2 || want[0] != have[0] || want[1] != have[1] {
+			continue
+		}
+		if len(want) > 2 && len(have) > 2 && want[2] != have[2] {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// registryAuthConfig returns the registry credentials stored on the
+// container, nil when it has none
+func (s *ContainerService) registryAuthConfig(container *model.Container) *dockerregistry.AuthConfig {
+	stored, err := s.openRegistryAuth(container)
+	if err != nil || stored == "" {
+		return nil
+	}
+	var auth dto.RegistryAuth
+	if err := json.Unmarshal([]byte(stored), &auth); err != nil {
+		return nil
+	}
+	if auth.Username == "" && auth.Token == "" {
+		return nil
+	}
+	return &dockerregistry.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		RegistryToken: auth.Token,
+		ServerAddress: container.RegistryURL,
+	}
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// retargetDaemon keeps containers in memory and serves the parts of the
+// Docker API a health_gated update uses. Running containers report healthy.
+type retargetDaemon struct {
+	mu         sync.Mutex
+	containers map[string]*retargetDaemonContainer
+	created    int
+}
+
+type retargetDaemonContainer struct {
+	id, name, image string
+	running         bool
+}
+
+var retargetOldID = fmt.Sprintf("%064x", 1)
+
+func newRetargetDaemon() *retargetDaemon {
+	return &retargetDaemon{containers: map[string]*retargetDaemonContainer{
+		retargetOldID: {id: retargetOldID, name: "web", image: "nginx:1.25", running: true},
+	}}
+}
+
+func (d *retargetDaemon) lookup(ref string) *retargetDaemonContainer {
+	if c, ok := d.containers[ref]; ok {
+		return c
+	}
+	for _, c := range d.containers {
+		if c.name == strings.TrimPrefix(ref, "/") {
+			return c
+		}
+	}
+	return nil
+}
+
+func (d *retargetDaemon) serve(w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	fail := func(status int, message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"message": message})
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1.44")
+	switch {
+	case r.Method == http.MethodPost && path == "/images/create":
+		json.NewEncoder(w).Encode(map[string]string{"status": "Downloaded newer image"})
+	case r.Method == http.MethodGet && path == "/containers/json":
+		args, _ := filters.FromJSON(r.URL.Query().Get("filters"))
+		list := []types.Container{}
+		for _, c := range d.containers {
+			if len(args.Get("name")) == 0 || strings.Contains(c.name, args.Get("name")[0]) {
+				list = append(list, types.Container{ID: c.id, Names: []string{"/" + c.name}, Image: c.image})
+			}
+		}
+		json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		var body struct{ Image string }
+		json.NewDecoder(r.Body).Decode(&body)
+		d.created++
+		id := fmt.Sprintf("%064x", 100+d.created)
+		d.containers[id] = &retargetDaemonContainer{id: id, name: r.URL.Query().Get("name"), image: body.Image}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": id})
+	case strings.HasPrefix(path, "/containers/"):
+		ref, action, _ := strings.Cut(strings.TrimPrefix(path, "/containers/"), "/")
+		c := d.lookup(ref)
+		if c == nil {
+			fail(http.StatusNotFound, "No such container: "+ref)
+			return
+		}
+		switch {
+		case r.Method == http.MethodGet && action == "json":
+			state := &types.ContainerState{Running: c.running}
+			if c.running {
+				state.Status = "running"
+				state.Health = &types.Health{Status: types.Healthy}
+			}
+			json.NewEncoder(w).Encode(types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:         c.id,
+					Name:       "/" + c.name,
+					State:      state,
+					HostConfig: &container.HostConfig{NetworkMode: "bridge"},
+				},
+				Config:          &container.Config{Image: c.image},
+				NetworkSettings: &types.NetworkSettings{},
+			})
+		case r.Method == http.MethodPost && action == "start":
+			c.running = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && action == "stop":
+			c.running = false
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && action == "rename":
+			c.name = r.URL.Query().Get("name")
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && action == "":
+			delete(d.containers, c.id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fail(http.StatusNotFound, "page not found")
+		}
+	default:
+		fail(http.StatusNotFound, "page not found")
+	}
+}
+
+// retargetRepo stores one container
+type retargetRepo struct {
+	repository.ContainerRepository
+	container model.Container
+}
+
+func (r *retargetRepo) GetByID(ctx context.Context, id int64) (*model.Container, error) {
+	container := r.container
+	return &container, nil
+}
+
+func (r *retargetRepo) Update(ctx context.Context, container *model.Container) error {
+	r.container = *container
+	return nil
+}
+
+func (r *retargetRepo) UpdateContainerID(ctx context.Context, id int64, containerID string) error {
+	r.container.ContainerID = containerID
+	return nil
+}
+
+// retargetHistoryRepo keeps the last saved state of each update
+type retargetHistoryRepo struct {
+	repository.UpdateHistoryRepository
+	histories []model.UpdateHistory
+}
+
+func (r *retargetHistoryRepo) Create(ctx context.Context, history *model.UpdateHistory) error {
+	history.ID = len(r.histories) + 1
+	r.histories = append(r.histories, *history)
+	return nil
+}
+
+func (r *retargetHistoryRepo) Update(ctx context.Context, history *model.UpdateHistory) error {
+	r.histories[history.ID-1] = *history
+	return nil
+}
+
+func TestRetargetExecuteRecreatesContainerOnTargetImage(t *testing.T) {
+	ctx := context.Background()
+	daemon := newRetargetDaemon()
+	dc := newFakeDockerClient(t, daemon.serve)
+
+	cfg := &config.Config{}
+	cfg.Cache.CleanupIntervalMinutes = 1
+	cache := NewCacheService(cfg)
+	t.Cleanup(func() { cache.Stop() })
+
+	containers := &retargetRepo{container: model.Container{ID: 3, Name: "web", Image: "nginx", Tag: "1.25", ContainerID: retargetOldID}}
+	histories := &retargetHistoryRepo{}
+	containerService := &ContainerService{
+		containerRepo:     containers,
+		updateHistoryRepo: histories,
+		dockerClient:      dc,
+		cache:             cache,
+		config:            cfg,
+		updates:           &updateDrain{},
+		progress:          newUpdateProgressRegistry(),
+	}
+	s := &ImageRetargetService{
+		containerRepo:     containers,
+		updateHistoryRepo: histories,
+		containerService:  containerService,
+		dockerClient:      dc,
+	}
+
+	req := &RetargetRequest{Source: "nginx:1.25", Target: "nginx:1.26", Execute: true}
+	refs, err := parseRetargetRequest(req)
+	if err != nil {
+		t.Fatalf("parseRetargetRequest failed: %v", err)
+	}
+	if req.Strategy != string(model.UpdateStrategyHealthGated) {
+		t.Fatalf("strategy = %q, want executed retargets to default to health_gated", req.Strategy)
+	}
+
+	result := s.retargetContainer(ctx, model.SystemActor("test"), &model.BulkOperation{ID: 1},
+		&RetargetCandidate{ContainerID: 3, Name: "web", Action: RetargetActionRetarget},
+		&docker.RegistryImage{Digest: "sha256:" + strings.Repeat("b", 64)}, refs, req)
+	if result.Outcome != model.BulkOutcomeUpdated {
+		t.Fatalf("outcome = %s (%s), want updated", result.Outcome, result.Message)
+	}
+
+	// The daemon runs web from the target image, as the one recorded
+	if len(daemon.containers) != 1 {
+		t.Fatalf("daemon has %d containers, want web alone", len(daemon.containers))
+	}
+	web := daemon.lookup("web")
+	if web == nil || !web.running || web.image != "nginx:1.26" {
+		t.Fatalf("web = %+v, want it running nginx:1.26", web)
+	}
+	if containers.container.ContainerID != web.id || containers.container.Tag != "1.26" {
+		t.Errorf("recorded container %s on tag %s, want %s on 1.26", containers.container.ContainerID, containers.container.Tag, web.id)
+	}
+	if len(histories.histories) != 1 || histories.histories[0].Status != model.UpdateStatusCompleted || histories.histories[0].NewImage != "nginx:1.26" {
+		t.Errorf("update histories = %+v, want one completed update to nginx:1.26", histories.histories)
+	}
+}
+
+func TestRetargetExecuteNeedsHealthGatedStrategy(t *testing.T) {
+	for _, strategy := range []string{"recreate", "rolling", "blue_green"} {
+		req := &RetargetRequest{Source: "nginx:1.25", Target: "nginx:1.26", Execute: true, Strategy: strategy}
+		if _, err := parseRetargetRequest(req); !apperrors.HasCode(err, apperrors.CodeInvalidRequest) {
+			t.Errorf("execute with %s = %v, want an invalid request", strategy, err)
+		}
+
+		// Left pending, the updater applies them
+		req.Execute = false
+		if _, err := parseRetargetRequest(req); err != nil {
+			t.Errorf("retarget with %s failed: %v", strategy, err)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/registry"
+
+	"github.com/sirupsen/logrus"
+)
+
+// versionPolicyTagLimit bounds the tags listed when looking for the newest
+// version a container's policy allows
+const versionPolicyTagLimit = 100
+
+// ApplyVersionPolicy narrows an update check result to what the container's
+// version policy allows. Policies that follow versions replace the tag the
+// registry reported with the newest allowed semver tag; when there is none,
+// or the tag is not semver, the result falls back to comparing the digest
+// behind the current tag. Pinned containers never have an update.
+func (s *ImageService) ApplyVersionPolicy(ctx context.Context, container *model.Container, result *registry.UpdateCheckResult) error {
+	policy := container.GetVersionPolicy()
+	current := container.Tag
+	if current == "" {
+		current = "latest"
+	}
+
+	switch {
+	case policy == model.VersionPolicyLatest:
+		return nil
+	case policy == model.VersionPolicyPinned:
+		result.LatestTag = current
+		result.LatestDigest = result.CurrentDigest
+		result.UpdateAvailable = false
+		result.UpdateType = ""
+		return nil
+	}
+
+	client, err := s.registryClient(container)
+	if err != nil {
+		return err
+	}
+	repository := registry.ImageRepository(container.Image)
+
+	if _, ok := model.ParseSemVer(current); ok && policy.FollowsVersions() {
+		tags, err := client.GetImageTags(ctx, repository, &registry.TagListOptions{
+			Repository: repository,
+			Limit:      versionPolicyTagLimit,
+			Sort:       "last_updated",
+			Order:      "desc",
+		})
+		if err != nil {
+			return fmt.Errorf("failed to list tags of %s: %w", container.Image, err)
+		}
+		names := make([]string, 0, len(tags))
+		for _, tag := range tags {
+			names = append(names, tag.Name)
+		}
+
+		if newest := policy.NewestAllowedTag(current, names); newest != current {
+			manifest, err := client.GetImageManifest(ctx, repository, newest)
+			if err != nil {
+				return fmt.Errorf("failed to resolve %s:%s: %w", container.Image, newest, err)
+			}
+			result.LatestTag = newest
+			result.LatestDigest = manifest.Digest
+			result.UpdateAvailable = true
+			result.UpdateType = model.SemVerUpdateType(current, newest)
+			return nil
+		}
+	}
+
+	// Fall back to the digest behind the current tag
+	if result.LatestTag != current {
+		logrus.WithFields(logrus.Fields{
+			"container_id":   container.ID,
+			"version_policy": policy,
+			"ignored_tag":    result.LatestTag,
+		}).Debug("Version policy ignores the newest tag")
+
+		manifest, err := client.GetImageManifest(ctx, repository, current)
+		if err != nil {
+			return fmt.Errorf("failed to resolve %s:%s: %w", container.Image, current, err)
+		}
+		result.LatestTag = current
+		result.LatestDigest = manifest.Digest
+		result.UpdateAvailable = result.CurrentDigest != "" && manifest.Digest != result.CurrentDigest
+	}
+	result.UpdateType = ""
+	if result.UpdateAvailable {
+		result.UpdateType = "digest"
+	}
+	return nil
+}
+
+// registryClient returns the registry client for a container's image
+func (s *ImageService) registryClient(container *model.Container) (registry.Client, error) {
+	registryURL := container.RegistryURL
+	if registryURL == "" {
+		registryURL = registry.ImageRegistryHost(container.Image)
+	}
+	client, err := s.imageChecker.GetClient(registryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry client: %w", err)
+	}
+	return client, nil
+}
@@ -2,15 +2,16 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"html/template"
 	"strings"
 	"sync"
 	"time"
 
-	"docker-auto/internal/model"
 	"docker-auto/internal/repository"
 	"docker-auto/pkg/events"
+	"docker-auto/pkg/model"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -26,6 +27,50 @@ const (
 	NotificationTypeSuccess NotificationType = "success"
 )
 
+// ErrNotificationSnoozed is returned when a notification is suppressed because
+// an identical one was acknowledged and is still snoozed
+var ErrNotificationSnoozed = errors.New("notification snoozed by acknowledgment")
+
+const (
+	defaultAckSnoozeMinutes = 60
+	maxAckSnoozeMinutes     = 7 * 24 * 60
+	maxAckNoteLength        = 500
+)
+
+// AcknowledgeNotificationsRequest selects the current user's notifications to
+// acknowledge. At least one of Type, ContainerIDs or the time range is required.
+type AcknowledgeNotificationsRequest struct {
+	Type          string     `json:"type"`
+	ContainerIDs  []int64    `json:"container_ids"`
+	CreatedAfter  *time.Time `json:"created_after"`
+	CreatedBefore *time.Time `json:"created_before"`
+	Note          string     `json:"note"`
+	SnoozeMinutes int        `json:"snooze_minutes"` // 0 uses the default of 60
+}
+
+// AcknowledgeNotificationsResult reports the outcome of a bulk acknowledgment
+type AcknowledgeNotificationsResult struct {
+	Acknowledged int64     `json:"acknowledged"`
+	SnoozedUntil time.Time `json:"snoozed_until"`
+}
+
+// Validate validates the acknowledge request
+func (r *AcknowledgeNotificationsRequest) Validate() error {
+	if r.Type == "" && len(r.ContainerIDs) == 0 && r.CreatedAfter == nil && r.CreatedBefore == nil {
+		return fmt.Errorf("invalid request: type, container_ids or a time range is required")
+	}
+	if r.CreatedAfter != nil && r.CreatedBefore != nil && r.CreatedBefore.Before(*r.CreatedAfter) {
+		return fmt.Errorf("invalid request: created_before must not be before created_after")
+	}
+	if r.SnoozeMinutes < 0 || r.SnoozeMinutes > maxAckSnoozeMinutes {
+		return fmt.Errorf("invalid request: snooze_minutes must be between 0 and %d", maxAckSnoozeMinutes)
+	}
+	if len(r.Note) > maxAckNoteLength {
+		return fmt.Errorf("invalid request: note cannot exceed %d characters", maxAckNoteLength)
+	}
+	return nil
+}
+
 // NotificationTemplate represents a notification template
 type NotificationTemplate struct {
 	ID       string           `json:"id"`
@@ -46,6 +91,7 @@ type NotificationService struct {
 	notificationRepo  repository.NotificationRepository
 	emailService      *EmailService
 	webhookService    *WebhookService
+	channelService    *NotificationChannelService
 }
 
 // NotificationServiceInterface defines the notification service interface
@@ -56,10 +102,16 @@ type NotificationServiceInterface interface {
 	GetUnreadCount(ctx context.Context, userID int64) (int64, error)
 	MarkAsRead(ctx context.Context, notificationID int64, userID int64) error
 	MarkAllAsRead(ctx context.Context, userID int64) error
+	AcknowledgeNotifications(ctx context.Context, userID int64, req *AcknowledgeNotificationsRequest) (*AcknowledgeNotificationsResult, error)
+	ListNotifications(ctx context.Context, filter *model.UserNotificationFilter) ([]*model.UserNotification, int64, error)
 	DeleteNotification(ctx context.Context, notificationID int64, userID int64) error
 	BroadcastNotification(ctx context.Context, notificationType NotificationType, title, message string, data map[string]interface{}) error
 	RegisterTemplate(templateID string, notificationType NotificationType, title, message string) error
 	GetNotificationsByType(ctx context.Context, userID int64, notificationType NotificationType, limit, offset int) ([]*model.UserNotification, error)
+	GetNotificationsAfter(ctx context.Context, filter *model.UserNotificationFilter, cursor *model.Cursor, limit int) ([]*model.UserNotification, string, error)
+	GetPayloadSchemas() []model.PayloadSchemaDescription
+	GetNotificationStats(ctx context.Context, userID int64) (map[string]interface{}, error)
+	CleanupOldNotifications(ctx context.Context, retentionDays int) error
 }
 
 // NewNotificationService creates a new notification service
@@ -71,6 +123,7 @@ func NewNotificationService(
 	notificationRepo repository.NotificationRepository,
 	emailService *EmailService,
 	webhookService *WebhookService,
+	channelService *NotificationChannelService,
 ) *NotificationService {
 	if logger == nil {
 		logger = logrus.New()
@@ -85,6 +138,7 @@ func NewNotificationService(
 		notificationRepo: notificationRepo,
 		emailService:     emailService,
 		webhookService:   webhookService,
+		channelService:   channelService,
 	}
 
 	// Register default templates
@@ -93,7 +147,10 @@ func NewNotificationService(
 	return service
 }
 
-// CreateNotification creates a new notification
+// CreateNotification creates a new notification. Notifications about a
+// container whose identical predecessor was acknowledged are suppressed with
+// ErrNotificationSnoozed until the snooze expires; after that the new
+// notification references the prior acknowledgment.
 func (ns *NotificationService) CreateNotification(
 	ctx context.Context,
 	userID *int64,
@@ -102,13 +159,33 @@ func (ns *NotificationService) CreateNotification(
 	data map[string]interface{},
 ) (*model.UserNotification, error) {
 	notification := &model.UserNotification{
-		UserID:    userID,
-		Type:      string(notificationType),
-		Title:     title,
-		Message:   message,
-		Data:      data,
-		IsRead:    false,
-		CreatedAt: time.Now(),
+		UserID:      userID,
+		Type:        string(notificationType),
+		Title:       title,
+		Message:     message,
+		Data:        data,
+		ContainerID: model.NotificationContainerID(data),
+		IsRead:      false,
+		CreatedAt:   time.Now(),
+	}
+
+	if notification.ContainerID != nil {
+		prior, err := ns.notificationRepo.GetLatestAcknowledged(ctx, userID, notification.Type, *notification.ContainerID)
+		if err != nil {
+			ns.logger.WithError(err).Warn("Failed to look up acknowledged notifications")
+		} else if prior != nil {
+			if prior.IsSnoozed(notification.CreatedAt) {
+				ns.logger.WithFields(logrus.Fields{
+					"user_id":       userID,
+					"type":          notificationType,
+					"container_id":  *notification.ContainerID,
+					"prior_ack_id":  prior.ID,
+					"snoozed_until": prior.SnoozedUntil,
+				}).Debug("Notification suppressed by acknowledgment")
+				return nil, ErrNotificationSnoozed
+			}
+			notification.PriorAckID = &prior.ID
+		}
 	}
 
 	// Save to database
@@ -179,12 +256,20 @@ func (ns *NotificationService) GetNotifications(ctx context.Context, userID int6
 		return nil, fmt.Errorf("failed to get notifications: %w", err)
 	}
 
+	renderNotificationPayloads(notifications)
 	return notifications, nil
 }
 
-// GetUnreadCount returns the count of unread notifications for a user
+// GetUnreadCount returns the count of unread, unacknowledged notifications for
+// a user. Acknowledged notifications are handled and no longer count as alerts.
 func (ns *NotificationService) GetUnreadCount(ctx context.Context, userID int64) (int64, error) {
-	count, err := ns.notificationRepo.GetUnreadCount(ctx, userID)
+	isRead, acknowledged := false, false
+	_, count, err := ns.notificationRepo.List(ctx, &model.UserNotificationFilter{
+		UserID:       &userID,
+		IsRead:       &isRead,
+		Acknowledged: &acknowledged,
+		Limit:        1,
+	})
 	if err != nil {
 		ns.logger.WithError(err).Error("Failed to get unread count")
 		return 0, fmt.Errorf("failed to get unread count: %w", err)
@@ -256,6 +341,61 @@ func (ns *NotificationService) MarkAllAsRead(ctx context.Context, userID int64)
 	return nil
 }
 
+// AcknowledgeNotifications acknowledges the user's unacknowledged notifications
+// matching the request and snoozes identical notifications
+func (ns *NotificationService) AcknowledgeNotifications(ctx context.Context, userID int64, req *AcknowledgeNotificationsRequest) (*AcknowledgeNotificationsResult, error) {
+	if err := req.Validate(); err != nil {
+		return nil, err
+	}
+
+	snoozeMinutes := req.SnoozeMinutes
+	if snoozeMinutes == 0 {
+		snoozeMinutes = defaultAckSnoozeMinutes
+	}
+	snoozedUntil := time.Now().Add(time.Duration(snoozeMinutes) * time.Minute)
+
+	acknowledged := false
+	filter := &model.UserNotificationFilter{
+		UserID:        &userID,
+		Type:          req.Type,
+		ContainerIDs:  req.ContainerIDs,
+		CreatedAfter:  req.CreatedAfter,
+		CreatedBefore: req.CreatedBefore,
+		Acknowledged:  &acknowledged,
+	}
+
+	count, err := ns.notificationRepo.AcknowledgeMatching(ctx, filter, userID, strings.TrimSpace(req.Note), snoozedUntil)
+	if err != nil {
+		ns.logger.WithError(err).Error("Failed to acknowledge notifications")
+		return nil, fmt.Errorf("failed to acknowledge notifications: %w", err)
+	}
+
+	ns.logger.WithFields(logrus.Fields{
+		"user_id":       userID,
+		"type":          req.Type,
+		"container_ids": req.ContainerIDs,
+		"count":         count,
+		"snoozed_until": snoozedUntil,
+	}).Info("Acknowledged notifications")
+
+	return &AcknowledgeNotificationsResult{
+		Acknowledged: count,
+		SnoozedUntil: snoozedUntil,
+	}, nil
+}
+
+// ListNotifications retrieves notifications matching the filter with the total count
+func (ns *NotificationService) ListNotifications(ctx context.Context, filter *model.UserNotificationFilter) ([]*model.UserNotification, int64, error) {
+	notifications, total, err := ns.notificationRepo.List(ctx, filter)
+	if err != nil {
+		ns.logger.WithError(err).Error("Failed to list notifications")
+		return nil, 0, fmt.Errorf("failed to list notifications: %w", err)
+	}
+
+	renderNotificationPayloads(notifications)
+	return notifications, total, nil
+}
+
 // DeleteNotification deletes a notification
 func (ns *NotificationService) DeleteNotification(ctx context.Context, notificationID int64, userID int64) error {
 	notification, err := ns.notificationRepo.GetByID(ctx, notificationID)
@@ -310,7 +450,7 @@ func (ns *NotificationService) BroadcastNotification(
 	// Create notifications for each user
 	for _, user := range users {
 		_, err := ns.CreateNotification(ctx, &user.ID, notificationType, title, message, data)
-		if err != nil {
+		if err != nil && !errors.Is(err, ErrNotificationSnoozed) {
 			ns.logger.WithError(err).WithField("user_id", user.ID).Error("Failed to create broadcast notification")
 		}
 	}
@@ -364,9 +504,49 @@ func (ns *NotificationService) GetNotificationsByType(ctx context.Context, userI
 		return nil, fmt.Errorf("failed to get notifications by type: %w", err)
 	}
 
+	renderNotificationPayloads(notifications)
 	return notifications, nil
 }
 
+// GetNotificationsAfter retrieves a keyset page of the notifications matching
+// the filter and returns the cursor for the next page
+func (ns *NotificationService) GetNotificationsAfter(ctx context.Context, filter *model.UserNotificationFilter, cursor *model.Cursor, limit int) ([]*model.UserNotification, string, error) {
+	if cursor == nil {
+		cursor = &model.Cursor{}
+	}
+
+	filter.Limit = limit
+	filter.Cursor = cursor
+
+	notifications, _, err := ns.notificationRepo.List(ctx, filter)
+	if err != nil {
+		ns.logger.WithError(err).Error("Failed to get notifications")
+		return nil, "", fmt.Errorf("failed to get notifications: %w", err)
+	}
+
+	nextCursor := ""
+	if n := len(notifications); n > 0 {
+		last := notifications[n-1]
+		nextCursor = model.NextCursor(n, limit, last.CreatedAt, last.ID)
+	}
+
+	renderNotificationPayloads(notifications)
+	return notifications, nextCursor, nil
+}
+
+// GetPayloadSchemas returns the current notification payload schemas
+func (ns *NotificationService) GetPayloadSchemas() []model.PayloadSchemaDescription {
+	return model.NotificationPayloadSchemas()
+}
+
+// renderNotificationPayloads attaches the type- and version-aware rendering of
+// each notification's data for inbox consumers
+func renderNotificationPayloads(notifications []*model.UserNotification) {
+	for _, notification := range notifications {
+		notification.Rendered = model.RenderNotificationData(notification.Data)
+	}
+}
+
 // executeTemplate executes a notification template
 func (ns *NotificationService) executeTemplate(tmpl *NotificationTemplate, data map[string]interface{}) (string, string, error) {
 	var titleBuf, messageBuf strings.Builder
@@ -436,6 +616,11 @@ func (ns *NotificationService) sendExternalNotifications(notification *model.Use
 			ns.logger.WithError(err).Error("Failed to send webhook notification")
 		}
 	}
+
+	// Deliver to the user's notification channels
+	if ns.channelService != nil {
+		ns.channelService.Deliver(ctx, notification.UserID, runtimeNotification)
+	}
 }
 
 // registerDefaultTemplates registers default notification templates
@@ -556,8 +741,11 @@ func (ns *NotificationService) SendNotification(ctx context.Context, notificatio
 		"priority": notification.Priority,
 	}).Info("Sending notification")
 
-	// For now, just log the notification
-	// In a full implementation, you would route to appropriate channels based on type
+	// Deliver to the notification channels subscribed to the type and priority
+	if ns.channelService != nil {
+		ns.channelService.Deliver(ctx, nil, notification)
+	}
+
 	ns.logger.WithFields(logrus.Fields{
 		"type":     notification.Type,
 		"title":    notification.Title,
@@ -0,0 +1,509 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	maxNotificationChannelNameLength = 100
+
+	// A failed delivery is attempted channelDeliveryAttempts times in all,
+	// waiting channelDeliveryBackoff before the first retry and twice as long
+	// before each one after
+	channelDeliveryAttempts = 4
+	channelDeliveryBackoff  = 5 * time.Second
+)
+
+// ChannelOwner is who manages notification channels: users manage their own
+// channels and admins also the global ones
+type ChannelOwner struct {
+	UserID int64
+	Admin  bool
+}
+
+// NotificationChannelService manages notification channels and delivers
+// notifications through them. Channel settings are stored encrypted and
+// returned with their secrets redacted.
+type NotificationChannelService struct {
+	channelRepo   repository.NotificationChannelRepository
+	activityRepo  repository.ActivityLogRepository
+	secretService *SecretService
+	userRepo      repository.UserRepository
+	pendingRepo   repository.PendingNotificationRepository
+	senders       map[model.NotificationChannelType]notificationSender
+}
+
+// NewNotificationChannelService creates a new notification channel service
+// instance
+func NewNotificationChannelService(
+	channelRepo repository.NotificationChannelRepository,
+	activityRepo repository.ActivityLogRepository,
+	secretService *SecretService,
+	userRepo repository.UserRepository,
+	pendingRepo repository.PendingNotificationRepository,
+) *NotificationChannelService {
+	client := &http.Client{Timeout: defaultChannelTimeout}
+
+	return &NotificationChannelService{
+		channelRepo:   channelRepo,
+		activityRepo:  activityRepo,
+		secretService: secretService,
+		userRepo:      userRepo,
+		pendingRepo:   pendingRepo,
+		senders: map[model.NotificationChannelType]notificationSender{
+			model.NotificationChannelWebhook: &webhookSender{client: client},
+			model.NotificationChannelEmail:   &emailSender{},
+			model.NotificationChannelSlack:   &slackSender{client: client},
+		},
+	}
+}
+
+// ListChannels lists the owner's channels, and the global ones for admins
+func (s *NotificationChannelService) ListChannels(ctx context.Context, owner ChannelOwner) ([]*model.NotificationChannel, error) {
+	channels, err := s.channelRepo.List(ctx, &model.NotificationChannelFilter{
+		UserID: &owner.UserID,
+		Global: owner.Admin,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, channel := range channels {
+		if err := s.open(channel); err != nil {
+			logrus.WithError(err).WithField("channel_id", channel.ID).Warn("Failed to read notification channel settings")
+		}
+		channel.Redact()
+	}
+	return channels, nil
+}
+
+// GetChannel returns a channel the owner manages
+func (s *NotificationChannelService) GetChannel(ctx context.Context, owner ChannelOwner, id int) (*model.NotificationChannel, error) {
+	channel, err := s.channel(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+	channel.Redact()
+	return channel, nil
+}
+
+// CreateChannel adds a channel for the owner, or a global one
+func (s *NotificationChannelService) CreateChannel(ctx context.Context, actor model.Actor, owner ChannelOwner, req *dto.CreateNotificationChannelRequest) (*model.NotificationChannel, error) {
+	if req.Global && !owner.Admin {
+		return nil, fmt.Errorf("access denied: global notification channels are managed by admins")
+	}
+
+	channel := &model.NotificationChannel{
+		Name:              strings.TrimSpace(req.Name),
+		Type:              model.NotificationChannelType(req.Type),
+		Enabled:           req.Enabled == nil || *req.Enabled,
+		NotificationTypes: model.StringList(req.NotificationTypes),
+		MinPriority:       model.NotificationPriority(req.MinPriority),
+		Settings:          req.Settings,
+		CreatedBy:         actor.OwnerID(),
+	}
+	if !req.Global {
+		userID := owner.UserID
+		channel.UserID = &userID
+	}
+	if channel.MinPriority == "" {
+		channel.MinPriority = model.NotificationPriorityLow
+	}
+
+	if err := validateNotificationChannel(channel); err != nil {
+		return nil, err
+	}
+	if err := s.secretService.SealNotificationChannel(channel); err != nil {
+		return nil, err
+	}
+	if err := s.channelRepo.Create(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(actor, "notification_channel_create", channel, fmt.Sprintf("Created %s notification channel %s", channel.Type, channel.Name))
+
+	channel.Redact()
+	return channel, nil
+}
+
+// UpdateChannel changes a channel the owner manages. Secrets left empty or
+// sent back redacted keep their stored values.
+func (s *NotificationChannelService) UpdateChannel(ctx context.Context, actor model.Actor, owner ChannelOwner, id int, req *dto.UpdateNotificationChannelRequest) (*model.NotificationChannel, error) {
+	channel, err := s.channel(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		channel.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+	if req.NotificationTypes != nil {
+		channel.NotificationTypes = model.StringList(*req.NotificationTypes)
+	}
+	if req.MinPriority != nil {
+		channel.MinPriority = model.NotificationPriority(*req.MinPriority)
+	}
+	if req.Settings != nil {
+		channel.Settings = keepStoredSecrets(*req.Settings, channel.Settings)
+	}
+
+	if err := validateNotificationChannel(channel); err != nil {
+		return nil, err
+	}
+	if err := s.secretService.SealNotificationChannel(channel); err != nil {
+		return nil, err
+	}
+	if err := s.channelRepo.Update(ctx, channel); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(actor, "notification_channel_update", channel, fmt.Sprintf("Updated notification channel %s", channel.Name))
+
+	channel.Redact()
+	return channel, nil
+}
+
+// DeleteChannel removes a channel the owner manages
+func (s *NotificationChannelService) DeleteChannel(ctx context.Context, actor model.Actor, owner ChannelOwner, id int) error {
+	channel, err := s.channel(ctx, owner, id)
+	if err != nil {
+		return err
+	}
+	if err := s.channelRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logActivity(actor, "notification_channel_delete", channel, fmt.Sprintf("Deleted notification channel %s", channel.Name))
+	return nil
+}
+
+// TestChannel sends a test notification through a channel the owner manages,
+// once and without retries, and records the outcome like any delivery. A
+// failed delivery is reported in the result, not as an error.
+func (s *NotificationChannelService) TestChannel(ctx context.Context, owner ChannelOwner, id int) (*dto.NotificationChannelTestResult, error) {
+	channel, err := s.channel(ctx, owner, id)
+	if err != nil {
+		return nil, err
+	}
+
+	notification := &model.Notification{
+		Type:     model.NotificationTypeSystemMaintenance,
+		Title:    "Test notification",
+		Message:  fmt.Sprintf("This is a test notification for channel %s.", channel.Name),
+		Priority: model.NotificationPriorityNormal,
+	}
+
+	start := time.Now()
+	err = s.send(ctx, channel, notification)
+	result := &dto.NotificationChannelTestResult{DurationMS: time.Since(start).Milliseconds()}
+	s.recordDelivery(ctx, channel, notification, 1, err)
+
+	if err != nil {
+		result.Message = "Delivery failed"
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+		result.Message = "Test notification delivered"
+	}
+
+	return result, nil
+}
+
+// Deliver fans a notification out to the enabled channels matching its type
+// and priority: a user's channels for a notification to that user, or every
+// channel for one raised by the system when userID is nil. Deliveries run in
+// the background; failures are retried with backoff and the outcome is
+// recorded on each channel. Update notifications for the channels of users
+// who chose a digest are buffered for it instead.
+func (s *NotificationChannelService) Deliver(ctx context.Context, userID *int64, notification *model.Notification) {
+	channels, err := s.channelRepo.List(ctx, &model.NotificationChannelFilter{
+		UserID:      userID,
+		EnabledOnly: true,
+	})
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list notification channels")
+		return
+	}
+
+	buffered := make(map[int64]bool)
+	for _, channel := range channels {
+		if !channel.Matches(notification) {
+			continue
+		}
+		if s.bufferForDigest(ctx, channel, notification, buffered) {
+			continue
+		}
+		if err := s.open(channel); err != nil {
+			s.recordDelivery(ctx, channel, notification, 0, err)
+			continue
+		}
+		go s.deliverWithRetry(channel, notification)
+	}
+}
+
+// deliverWithRetry delivers a notification, retrying with exponential
+// backoff, and records the outcome
+func (s *NotificationChannelService) deliverWithRetry(channel *model.NotificationChannel, notification *model.Notification) {
+	ctx := context.Background()
+	backoff := channelDeliveryBackoff
+
+	var err error
+	attempt := 1
+	for ; ; attempt++ {
+		if err = s.send(ctx, channel, notification); err == nil || attempt == channelDeliveryAttempts {
+			break
+		}
+
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"channel_id": channel.ID,
+			"attempt":    attempt,
+			"retry_in":   backoff,
+		}).Debug("Notification delivery failed, retrying")
+
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+
+	s.recordDelivery(ctx, channel, notification, attempt, err)
+}
+
+// send makes one delivery attempt
+func (s *NotificationChannelService) send(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification) error {
+	sender, ok := s.senders[channel.Type]
+	if !ok {
+		return fmt.Errorf("unsupported notification channel type: %s", channel.Type)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, defaultChannelTimeout)
+	defer cancel()
+
+	return sender.Send(ctx, channel, notification)
+}
+
+// recordDelivery stores the outcome of a delivery on the channel, so the UI
+// can flag a failing channel, and in the notification log
+func (s *NotificationChannelService) recordDelivery(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification, attempts int, deliveryErr error) {
+	logger := logrus.WithFields(logrus.Fields{
+		"channel_id":        channel.ID,
+		"channel_type":      channel.Type,
+		"notification_type": notification.Type,
+		"attempts":          attempts,
+	})
+
+	status, message := model.NotificationStatusSent, ""
+	if deliveryErr != nil {
+		status, message = model.NotificationStatusFailed, deliveryErr.Error()
+		logger.WithError(deliveryErr).Warn("Notification delivery failed")
+	} else {
+		logger.Debug("Notification delivered")
+	}
+
+	now := time.Now()
+	if err := s.channelRepo.RecordDelivery(ctx, channel.ID, status, message, now); err != nil {
+		logger.WithError(err).Warn("Failed to record notification delivery")
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"channel_id":        channel.ID,
+		"notification_type": notification.Type,
+		"priority":          notification.Priority,
+		"attempts":          attempts,
+	})
+	entry := &model.NotificationLog{
+		Recipient: channel.Name,
+		Subject:   notification.Title,
+		Content:   notification.Message,
+		Type:      model.NotificationType(channel.Type),
+		Metadata:  string(metadata),
+	}
+	if deliveryErr != nil {
+		entry.MarkAsFailed(message)
+	} else {
+		entry.MarkAsSent()
+	}
+	if err := s.channelRepo.LogDelivery(ctx, entry); err != nil {
+		logger.WithError(err).Warn("Failed to log notification delivery")
+	}
+}
+
+// channel loads a channel the owner manages, with its settings
+func (s *NotificationChannelService) channel(ctx context.Context, owner ChannelOwner, id int) (*model.NotificationChannel, error) {
+	channel, err := s.channelRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case channel.IsGlobal() && !owner.Admin:
+		return nil, fmt.Errorf("access denied: global notification channels are managed by admins")
+	case !channel.IsGlobal() && *channel.UserID != owner.UserID:
+		return nil, fmt.Errorf("access denied: notification channel belongs to different user")
+	}
+
+	if err := s.open(channel); err != nil {
+		return nil, err
+	}
+	return channel, nil
+}
+
+// open decrypts the channel's settings
+func (s *NotificationChannelService) open(channel *model.NotificationChannel) error {
+	return s.secretService.OpenNotificationChannel(channel)
+}
+
+// keepStoredSecrets returns settings with the secrets an update left empty
+// or sent back redacted replaced by the stored ones
+func keepStoredSecrets(settings, stored model.NotificationConfig) model.NotificationConfig {
+	keep := func(value, storedValue string) string {
+		if value == "" || model.IsRedactedSecret(value) {
+			return storedValue
+		}
+		return value
+	}
+
+	if settings.Email != nil && stored.Email != nil {
+		email := *settings.Email
+		email.SMTPPassword = keep(email.SMTPPassword, stored.Email.SMTPPassword)
+		settings.Email = &email
+	}
+	if settings.Webhook != nil && stored.Webhook != nil {
+		webhook := *settings.Webhook
+		webhook.Secret = keep(webhook.Secret, stored.Webhook.Secret)
+		settings.Webhook = &webhook
+	}
+	if settings.Slack != nil && stored.Slack != nil {
+		slack := *settings.Slack
+		slack.WebhookURL = keep(slack.WebhookURL, stored.Slack.WebhookURL)
+		settings.Slack = &slack
+	}
+	return settings
+}
+
+// validateNotificationChannel checks a channel before it is stored
+func validateNotificationChannel(channel *model.NotificationChannel) error {
+	if channel.Name == "" {
+		return fmt.Errorf("invalid request: name is required")
+	}
+	if len(channel.Name) > maxNotificationChannelNameLength {
+		return fmt.Errorf("invalid request: name cannot exceed %d characters", maxNotificationChannelNameLength)
+	}
+	if !model.IsValidNotificationPriority(string(channel.MinPriority)) {
+		return fmt.Errorf("invalid request: min_priority must be low, normal, high or critical")
+	}
+
+	deliverable := make(map[string]bool)
+	for _, notificationType := range model.GetDeliverableNotificationTypes() {
+		deliverable[string(notificationType)] = true
+	}
+	for _, notificationType := range channel.NotificationTypes {
+		if !deliverable[notificationType] {
+			return fmt.Errorf("invalid request: unknown notification type %q", notificationType)
+		}
+	}
+
+	settings := channel.Settings
+	switch channel.Type {
+	case model.NotificationChannelWebhook:
+		if settings.Webhook == nil {
+			return fmt.Errorf("invalid request: webhook channels require settings.webhook")
+		}
+		if err := validateChannelURL("settings.webhook.url", settings.Webhook.URL); err != nil {
+			return err
+		}
+		switch settings.Webhook.Method {
+		case "", http.MethodPost, http.MethodPut:
+		default:
+			return fmt.Errorf("invalid request: settings.webhook.method must be POST or PUT")
+		}
+		if settings.Webhook.Timeout < 0 {
+			return fmt.Errorf("invalid request: settings.webhook.timeout cannot be negative")
+		}
+
+	case model.NotificationChannelEmail:
+		email := settings.Email
+		if email == nil {
+			return fmt.Errorf("invalid request: email channels require settings.email")
+		}
+		if email.SMTPHost == "" {
+			return fmt.Errorf("invalid request: settings.email.smtp_host is required")
+		}
+		if email.SMTPPort <= 0 || email.SMTPPort > 65535 {
+			return fmt.Errorf("invalid request: settings.email.smtp_port must be between 1 and 65535")
+		}
+		if _, err := mail.ParseAddress(email.FromEmail); err != nil {
+			return fmt.Errorf("invalid request: settings.email.from_email is not a valid address")
+		}
+		if len(email.To) == 0 {
+			return fmt.Errorf("invalid request: settings.email.to requires at least one recipient")
+		}
+		for _, recipient := range email.To {
+			if _, err := mail.ParseAddress(recipient); err != nil {
+				return fmt.Errorf("invalid request: recipient %q is not a valid address", recipient)
+			}
+		}
+
+	case model.NotificationChannelSlack:
+		if settings.Slack == nil {
+			return fmt.Errorf("invalid request: slack channels require settings.slack")
+		}
+		if err := validateChannelURL("settings.slack.webhook_url", settings.Slack.WebhookURL); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("invalid request: type must be webhook, email or slack")
+	}
+
+	return nil
+}
+
+// validateChannelURL checks that a channel posts to an absolute http(s) URL
+func validateChannelURL(field, value string) error {
+	parsed, err := url.Parse(value)
+	if err != nil || parsed.Host == "" || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return fmt.Errorf("invalid request: %s must be an http or https URL", field)
+	}
+	return nil
+}
+
+// logActivity audits a change to a notification channel
+func (s *NotificationChannelService) logActivity(actor model.Actor, action string, channel *model.NotificationChannel, description string) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"type":    channel.Type,
+		"global":  channel.IsGlobal(),
+		"enabled": channel.Enabled,
+	})
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "notification_channel",
+		ResourceID:   &channel.ID,
+		ResourceName: channel.Name,
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("channel_id", channel.ID).Warn("Failed to log notification channel activity")
+	}
+}
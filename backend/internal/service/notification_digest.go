@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/internal/dto"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GetPreferences returns the user's delivery preferences and how many
+// notifications wait for the next digest
+func (s *NotificationChannelService) GetPreferences(ctx context.Context, userID int64) (*dto.NotificationPreferences, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.preferences(ctx, user)
+}
+
+// UpdatePreferences changes the user's delivery preferences
+func (s *NotificationChannelService) UpdatePreferences(ctx context.Context, userID int64, req *dto.UpdateNotificationPreferencesRequest) (*dto.NotificationPreferences, error) {
+	if req == nil || !model.IsValidDigestInterval(req.Digest) {
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "invalid request: digest must be off, hourly or daily")
+	}
+
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if user.NotificationDigest != model.DigestInterval(req.Digest) {
+		user.NotificationDigest = model.DigestInterval(req.Digest)
+		if err := s.userRepo.Update(ctx, user); err != nil {
+			return nil, err
+		}
+	}
+	return s.preferences(ctx, user)
+}
+
+func (s *NotificationChannelService) preferences(ctx context.Context, user *model.User) (*dto.NotificationPreferences, error) {
+	pending, err := s.pendingRepo.ListByUser(ctx, user.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	digest := user.NotificationDigest
+	if digest == "" {
+		digest = model.DigestOff
+	}
+	return &dto.NotificationPreferences{
+		Digest:             digest,
+		DigestSentAt:       user.DigestSentAt,
+		PendingDigestItems: len(pending),
+	}, nil
+}
+
+// FlushDigests sends the digests that are due: a user's buffered
+// notifications are sent once the oldest has waited the user's digest
+// interval, so digests are at least an interval apart. Notifications of users
+// who turned the digest off since are sent at once.
+func (s *NotificationChannelService) FlushDigests(ctx context.Context, now time.Time) (*dto.DigestFlushResult, error) {
+	userIDs, err := s.pendingRepo.ListUserIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.DigestFlushResult{Users: len(userIDs)}
+	for _, userID := range userIDs {
+		flushed, err := s.flushDigest(ctx, userID, now)
+		if err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Warn("Failed to send notification digest")
+			result.Failed++
+			continue
+		}
+		if flushed > 0 {
+			result.Sent++
+			result.Notifications += flushed
+		}
+	}
+	return result, nil
+}
+
+// flushDigest sends the user's digest if it is due and returns the number of
+// buffered notifications it summarized
+func (s *NotificationChannelService) flushDigest(ctx context.Context, userID int64, now time.Time) (int, error) {
+	pending, err := s.pendingRepo.ListByUser(ctx, userID)
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	if now.Sub(pending[0].CreatedAt) < user.NotificationDigest.Period() {
+		return 0, nil
+	}
+
+	channels, err := s.channelRepo.List(ctx, &model.NotificationChannelFilter{
+		UserID:      &userID,
+		EnabledOnly: true,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to list notification channels: %w", err)
+	}
+
+	payload := model.NewUpdateDigestPayload(user.NotificationDigest, pending)
+	digest := &model.Notification{
+		Type:     model.NotificationTypeUpdateDigest,
+		Title:    fmt.Sprintf("Update digest: %d available, %d updated", payload.UpdatesAvailable, payload.UpdatesCompleted),
+		Message:  payload.Summary(),
+		Priority: model.NotificationPriorityNormal,
+		Data:     model.NotificationData(payload),
+	}
+
+	// A channel gets the digest if it would have delivered any of the
+	// buffered notifications
+	for _, channel := range channels {
+		if !subscribedToAny(channel, pending) {
+			continue
+		}
+		if err := s.open(channel); err != nil {
+			s.recordDelivery(ctx, channel, digest, 0, err)
+			continue
+		}
+		go s.deliverWithRetry(channel, digest)
+	}
+
+	ids := make([]int64, 0, len(pending))
+	for _, entry := range pending {
+		ids = append(ids, entry.ID)
+	}
+	if err := s.pendingRepo.DeleteByIDs(ctx, ids); err != nil {
+		return 0, err
+	}
+
+	user.DigestSentAt = &now
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		logrus.WithError(err).WithField("user_id", userID).Warn("Failed to record notification digest")
+	}
+	return len(pending), nil
+}
+
+// bufferForDigest reports whether the notification waits for the digest of
+// the channel's owner instead of being delivered through the channel. It is
+// buffered once per owner; buffered holds the owners decided so far.
+func (s *NotificationChannelService) bufferForDigest(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification, buffered map[int64]bool) bool {
+	if s.pendingRepo == nil || s.userRepo == nil || channel.IsGlobal() || !notification.Digestible() {
+		return false
+	}
+
+	userID := *channel.UserID
+	if digest, ok := buffered[userID]; ok {
+		return digest
+	}
+
+	digest := false
+	if user, err := s.userRepo.GetByID(ctx, userID); err == nil && user.NotificationDigest.IsEnabled() {
+		if err := s.pendingRepo.Create(ctx, model.NewPendingNotification(userID, notification)); err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Warn("Failed to buffer notification for digest")
+		} else {
+			digest = true
+		}
+	}
+	buffered[userID] = digest
+	return digest
+}
+
+// subscribedToAny reports whether the channel matches any of the buffered
+// notifications
+func subscribedToAny(channel *model.NotificationChannel, pending []*model.PendingNotification) bool {
+	for _, entry := range pending {
+		if channel.Matches(&model.Notification{Type: entry.Type, Priority: entry.Priority}) {
+			return true
+		}
+	}
+	return false
+}
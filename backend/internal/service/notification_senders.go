@@ -0,0 +1,209 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// defaultChannelTimeout bounds a single delivery attempt
+const defaultChannelTimeout = 15 * time.Second
+
+// notificationSender delivers a notification over one type of channel
+type notificationSender interface {
+	Send(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification) error
+}
+
+// webhookSender posts the notification as JSON, signed with HMAC-SHA256 in
+// the WebhookSignatureHeader when the channel has a secret
+type webhookSender struct {
+	client *http.Client
+}
+
+func (ws *webhookSender) Send(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification) error {
+	config := channel.Settings.Webhook
+	if config == nil {
+		return fmt.Errorf("webhook channel has no webhook settings")
+	}
+
+	body, err := json.Marshal(RenderWebhookBody(notification))
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	headers := make(map[string]string, len(config.Headers)+1)
+	for name, value := range config.Headers {
+		headers[name] = value
+	}
+	if config.Secret != "" {
+		headers[WebhookSignatureHeader] = SignWebhookBody(config.Secret, body)
+	}
+
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	method := config.Method
+	if method == "" {
+		method = http.MethodPost
+	}
+	return sendJSON(ctx, ws.client, method, config.URL, body, headers)
+}
+
+// slackSender posts the plain rendering of the notification to a Slack
+// incoming webhook
+type slackSender struct {
+	client *http.Client
+}
+
+func (ss *slackSender) Send(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification) error {
+	config := channel.Settings.Slack
+	if config == nil {
+		return fmt.Errorf("slack channel has no slack settings")
+	}
+
+	message := map[string]string{"text": RenderWebhookBody(notification).Text}
+	if config.Channel != "" {
+		message["channel"] = config.Channel
+	}
+	if config.Username != "" {
+		message["username"] = config.Username
+	}
+	if config.IconEmoji != "" {
+		message["icon_emoji"] = config.IconEmoji
+	}
+
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to encode slack message: %w", err)
+	}
+	return sendJSON(ctx, ss.client, http.MethodPost, config.WebhookURL, body, nil)
+}
+
+// sendJSON sends a JSON body and fails on any status other than 2xx
+func sendJSON(ctx context.Context, client *http.Client, method, url string, body []byte, headers map[string]string) error {
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// emailSender mails the plain rendering of the notification over SMTP.
+// Without UseTLS the connection is upgraded with STARTTLS when the server
+// offers it; with UseTLS it is TLS from the start, as on port 465.
+type emailSender struct{}
+
+func (es *emailSender) Send(ctx context.Context, channel *model.NotificationChannel, notification *model.Notification) error {
+	config := channel.Settings.Email
+	if config == nil {
+		return fmt.Errorf("email channel has no email settings")
+	}
+
+	addr := net.JoinHostPort(config.SMTPHost, strconv.Itoa(config.SMTPPort))
+	dialer := &net.Dialer{Timeout: defaultChannelTimeout}
+
+	var conn net.Conn
+	var err error
+	if config.UseTLS {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, &tls.Config{ServerName: config.SMTPHost})
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, config.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %w", err)
+	}
+	defer client.Close()
+
+	if !config.UseTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: config.SMTPHost}); err != nil {
+				return fmt.Errorf("failed to start TLS: %w", err)
+			}
+		}
+	}
+	if config.SMTPUsername != "" {
+		auth := smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(config.FromEmail); err != nil {
+		return fmt.Errorf("SMTP server rejected sender: %w", err)
+	}
+	for _, recipient := range config.To {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("SMTP server rejected recipient %s: %w", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if _, err := writer.Write(composeEmail(config, notification)); err != nil {
+		writer.Close()
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	return client.Quit()
+}
+
+// composeEmail builds a plain text message for the notification
+func composeEmail(config *model.EmailConfig, notification *model.Notification) []byte {
+	from := (&mail.Address{Name: config.FromName, Address: config.FromEmail}).String()
+
+	var msg strings.Builder
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(config.To, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", notification.Title) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	msg.WriteString("\r\n")
+	msg.WriteString(strings.ReplaceAll(RenderWebhookBody(notification).Text, "\n", "\r\n"))
+	msg.WriteString("\r\n")
+
+	return []byte(msg.String())
+}
@@ -0,0 +1,324 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/config"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/oidc"
+
+	"github.com/sirupsen/logrus"
+)
+
+// OIDCStateTTL is how long a user has to sign in at the provider
+const OIDCStateTTL = 10 * time.Minute
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-zA-Z0-9_-]+`)
+
+// OIDCService signs users in through the configured OpenID Connect provider.
+// The login state travels in a cookie signed with a key derived from the JWT
+// secret, so nothing is kept between login and callback.
+type OIDCService struct {
+	userService *UserService
+	config      *config.Config
+	stateKey    []byte
+	httpClient  *http.Client
+
+	mu       sync.Mutex
+	provider *oidc.Provider
+}
+
+// OIDCCallbackRequest is what the provider and the browser send back to the
+// callback
+type OIDCCallbackRequest struct {
+	Code        string
+	State       string
+	SealedState string // the cookie set by LoginURL
+	IPAddress   string
+	UserAgent   string
+}
+
+// NewOIDCService creates a new OIDC login service instance
+func NewOIDCService(userService *UserService, config *config.Config) *OIDCService {
+	key := sha256.Sum256([]byte("oidc-login-state:" + config.JWT.Secret))
+	return &OIDCService{
+		userService: userService,
+		config:      config,
+		stateKey:    key[:],
+		httpClient:  &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Configured returns true if an OpenID Connect provider is configured
+func (s *OIDCService) Configured() bool {
+	return s.config.OIDCConfigured()
+}
+
+// PostLoginRedirect returns the frontend URL a finished login is sent to,
+// empty to answer the callback with JSON
+func (s *OIDCService) PostLoginRedirect() string {
+	return s.config.OIDC.PostLoginRedirect
+}
+
+// SecureCookies returns true if the callback is served over HTTPS, so the
+// state cookie can be marked secure
+func (s *OIDCService) SecureCookies() bool {
+	return strings.HasPrefix(strings.ToLower(s.config.OIDC.RedirectURL), "https://")
+}
+
+// LoginURL starts a login. It returns the provider URL to send the user to
+// and the sealed state the callback expects back in a cookie.
+func (s *OIDCService) LoginURL(ctx context.Context) (string, string, error) {
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return "", "", err
+	}
+
+	state, err := oidc.NewLoginState(OIDCStateTTL)
+	if err != nil {
+		return "", "", err
+	}
+	sealed, err := state.Seal(s.stateKey)
+	if err != nil {
+		return "", "", err
+	}
+	return provider.AuthCodeURL(state.State, state.Nonce, state.CodeVerifier), sealed, nil
+}
+
+// Callback completes a login: it redeems the code, verifies the ID token,
+// provisions or links the user by email and starts a session as a password
+// login would
+func (s *OIDCService) Callback(ctx context.Context, req *OIDCCallbackRequest) (*LoginResponse, error) {
+	provider, err := s.getProvider(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The state cookie ties the callback to the browser that started the login
+	state, err := oidc.OpenLoginState(s.stateKey, req.SealedState)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeUnauthorized, "invalid login state")
+	}
+	if req.Code == "" || subtle.ConstantTimeCompare([]byte(req.State), []byte(state.State)) != 1 {
+		return nil, apperrors.New(apperrors.CodeUnauthorized, "invalid login state")
+	}
+
+	rawIDToken, err := provider.Exchange(ctx, req.Code, state.CodeVerifier)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeUnauthorized, "sign-in at the identity provider failed")
+	}
+	token, err := provider.Verify(ctx, rawIDToken, state.Nonce)
+	if err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeUnauthorized, "sign-in at the identity provider failed")
+	}
+
+	user, err := s.resolveUser(ctx, token)
+	if err != nil {
+		s.userService.logUserActivity(0, "login_failed", fmt.Sprintf("Failed OIDC login for subject %s", token.Subject), map[string]interface{}{
+			"method": "oidc",
+			"email":  token.Email,
+		})
+		return nil, err
+	}
+
+	response, err := s.userService.startSession(ctx, user, req.IPAddress, req.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	s.userService.logUserActivity(user.ID, "login_success", "User logged in through OIDC", map[string]interface{}{
+		"method":  "oidc",
+		"subject": token.Subject,
+	})
+	return response, nil
+}
+
+// resolveUser returns the user the ID token signs in, linking an existing
+// user by verified email or provisioning a new one
+func (s *OIDCService) resolveUser(ctx context.Context, token *oidc.IDToken) (*model.User, error) {
+	email := strings.TrimSpace(token.Email)
+	if email == "" || !s.userService.isValidEmail(email) {
+		return nil, apperrors.New(apperrors.CodeUnauthorized, "the identity provider did not return an email address")
+	}
+	// Accounts are linked by email, so an address the provider does not vouch
+	// for could take over an existing account, admins included
+	if token.EmailVerified == nil || !*token.EmailVerified {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "email address is not verified at the identity provider")
+	}
+	if !s.domainAllowed(email) {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "email domain is not allowed to sign in")
+	}
+
+	role := s.mappedRole(ctx, token.Strings(s.config.OIDC.GroupsClaim))
+
+	repo := s.userService.userRepo
+	user, err := repo.GetByEmail(ctx, email)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+	if err != nil {
+		return s.provisionUser(ctx, token, email, role)
+	}
+
+	if !user.IsActive {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "user account is inactive")
+	}
+	if role != "" && role != user.Role {
+		if err := s.userService.ChangeUserRole(ctx, user.ID, string(role)); err != nil {
+			return nil, err
+		}
+		user.Role = role
+	}
+	return user, nil
+}
+
+// provisionUser creates the user of a first OIDC login. Its password is
+// random, so it signs in through the provider only.
+func (s *OIDCService) provisionUser(ctx context.Context, token *oidc.IDToken, email string, role model.UserRole) (*model.User, error) {
+	if role == "" {
+		role = model.UserRole(s.config.OIDC.DefaultRole)
+		if !s.userService.isValidRole(ctx, string(role)) {
+			role = model.UserRoleViewer
+		}
+	}
+
+	username, err := s.uniqueUsername(ctx, token, email)
+	if err != nil {
+		return nil, err
+	}
+	passwordHash, err := s.userService.hashPassword(s.userService.generateSecureRandomString(32))
+	if err != nil {
+		return nil, err
+	}
+
+	user := &model.User{
+		Username:     username,
+		Email:        email,
+		PasswordHash: passwordHash,
+		Role:         role,
+		IsActive:     true,
+	}
+	if err := s.userService.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.userService.logUserActivity(user.ID, "user_created", "User provisioned by OIDC login", map[string]interface{}{
+		"username": user.Username,
+		"email":    user.Email,
+		"role":     user.Role,
+		"subject":  token.Subject,
+	})
+	logrus.WithFields(logrus.Fields{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"role":     user.Role,
+	}).Info("Provisioned user from OIDC login")
+	return user, nil
+}
+
+// uniqueUsername derives a free username from the preferred username or the
+// email address
+func (s *OIDCService) uniqueUsername(ctx context.Context, token *oidc.IDToken, email string) (string, error) {
+	base := token.Username
+	if at := strings.Index(base, "@"); at >= 0 {
+		base = base[:at]
+	}
+	if base == "" {
+		base = email[:strings.Index(email, "@")]
+	}
+	base = strings.Trim(usernameInvalidChars.ReplaceAllString(base, "-"), "-")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	for len(base) < 3 {
+		base += "_"
+	}
+
+	for i := 0; i < 100; i++ {
+		candidate := base
+		if i > 0 {
+			candidate = fmt.Sprintf("%s-%d", base, i+1)
+		}
+		exists, err := s.userService.userRepo.Exists(ctx, candidate, "")
+		if err != nil {
+			return "", fmt.Errorf("failed to check user existence: %w", err)
+		}
+		if !exists {
+			return candidate, nil
+		}
+	}
+	return "", apperrors.Newf(apperrors.CodeConflict, "no free username for %s", email)
+}
+
+// mappedRole returns the role of the first mapping matching one of the
+// groups, or "" without a match
+func (s *OIDCService) mappedRole(ctx context.Context, groups []string) model.UserRole {
+	mappings, _ := s.config.GetOIDCRoleMapping()
+	for _, mapping := range mappings {
+		for _, group := range groups {
+			if group != mapping.Group {
+				continue
+			}
+			if !s.userService.isValidRole(ctx, mapping.Role) {
+				logrus.WithFields(logrus.Fields{
+					"group": mapping.Group,
+					"role":  mapping.Role,
+				}).Warn("OIDC role mapping names an unknown role")
+				continue
+			}
+			return model.UserRole(mapping.Role)
+		}
+	}
+	return ""
+}
+
+func (s *OIDCService) domainAllowed(email string) bool {
+	domains := s.config.GetOIDCAllowedDomains()
+	if len(domains) == 0 {
+		return true
+	}
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+	for _, allowed := range domains {
+		if domain == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// getProvider returns the provider, reading its discovery document on first
+// use; a failed read is retried by the next login
+func (s *OIDCService) getProvider(ctx context.Context) (*oidc.Provider, error) {
+	if !s.Configured() {
+		return nil, apperrors.New(apperrors.CodeUnavailable, "OIDC login is not configured")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.provider != nil {
+		return s.provider, nil
+	}
+
+	provider, err := oidc.NewProvider(ctx, oidc.Config{
+		IssuerURL:    s.config.OIDC.IssuerURL,
+		ClientID:     s.config.OIDC.ClientID,
+		ClientSecret: s.config.OIDC.ClientSecret,
+		RedirectURL:  s.config.OIDC.RedirectURL,
+		Scopes:       s.config.GetOIDCScopes(),
+	}, s.httpClient)
+	if err != nil {
+		logrus.WithError(err).WithField("issuer", s.config.OIDC.IssuerURL).Error("Failed to discover OIDC provider")
+		return nil, apperrors.Wrap(err, apperrors.CodeUnavailable, "identity provider is unavailable")
+	}
+	s.provider = provider
+	return provider, nil
+}
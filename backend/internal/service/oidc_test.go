@@ -0,0 +1,72 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/oidc"
+)
+
+func newOIDCTestService(t *testing.T) (*OIDCService, repository.UserRepository) {
+	t.Helper()
+
+	db := newTestDB(t, &model.User{})
+	userRepo := repository.NewUserRepository(db)
+	cfg := &config.Config{}
+	return NewOIDCService(&UserService{userRepo: userRepo, config: cfg}, cfg), userRepo
+}
+
+func TestOIDCResolveUserRequiresVerifiedEmail(t *testing.T) {
+	verified, unverified := true, false
+
+	tests := []struct {
+		name          string
+		emailVerified *bool
+		wantLinked    bool
+	}{
+		{"verified", &verified, true},
+		{"unverified", &unverified, false},
+		{"claim missing", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			oidcService, userRepo := newOIDCTestService(t)
+
+			admin := &model.User{
+				Username:     "admin",
+				Email:        "admin@example.com",
+				PasswordHash: "hash",
+				Role:         model.UserRoleAdmin,
+				IsActive:     true,
+			}
+			if err := userRepo.Create(ctx, admin); err != nil {
+				t.Fatalf("failed to create admin: %v", err)
+			}
+
+			user, err := oidcService.resolveUser(ctx, &oidc.IDToken{
+				Subject:       "attacker",
+				Email:         "admin@example.com",
+				EmailVerified: tt.emailVerified,
+			})
+
+			if !tt.wantLinked {
+				if !apperrors.HasCode(err, apperrors.CodePermissionDenied) {
+					t.Fatalf("resolveUser error = %v, want permission denied", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveUser failed: %v", err)
+			}
+			if user.ID != admin.ID {
+				t.Errorf("linked user %d, want %d", user.ID, admin.ID)
+			}
+		})
+	}
+}
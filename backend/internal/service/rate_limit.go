@@ -0,0 +1,487 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/security"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Bounds of an endpoint limit's window
+const (
+	minEndpointRateWindow = time.Second
+	maxEndpointRateWindow = 24 * time.Hour
+)
+
+// authEndpointMinLimits are the lowest limits, per hour, of the endpoints
+// needed to sign in. Lower ones would lock every user out, including the
+// admin who set them.
+var authEndpointMinLimits = map[string]int{
+	"/api/auth/login":         3,
+	"/api/auth/refresh":       3,
+	"/api/auth/oidc/login":    3,
+	"/api/auth/oidc/callback": 3,
+}
+
+// rateLimitMethods are the methods an endpoint limit can be restricted to
+var rateLimitMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut,
+	http.MethodPatch, http.MethodDelete, http.MethodOptions,
+}
+
+// rateLimitOverrides is what the system settings keep of the limiter's
+// configuration: the parts that can be changed through the API
+type rateLimitOverrides struct {
+	EndpointLimits map[string]security.EndpointLimit `json:"endpoint_limits"`
+	IPWhitelist    []string                          `json:"ip_whitelist"`
+	IPBlacklist    []string                          `json:"ip_blacklist"`
+	UserWhitelist  []int64                           `json:"user_whitelist"`
+	UserBlacklist  []int64                           `json:"user_blacklist"`
+}
+
+// RateLimitService manages the endpoint limits, access lists and bans of the
+// running enhanced rate limiter. Changes apply to the limiter at once and
+// are kept in the system settings.
+type RateLimitService struct {
+	limiter      *security.EnhancedRateLimiter
+	configRepo   repository.SystemConfigRepository
+	activityRepo repository.ActivityLogRepository
+
+	// mu serializes changes, which read, modify and swap the configuration
+	mu        sync.Mutex
+	persisted bool
+}
+
+// NewRateLimitService creates a new rate limit service instance
+func NewRateLimitService(
+	limiter *security.EnhancedRateLimiter,
+	configRepo repository.SystemConfigRepository,
+	activityRepo repository.ActivityLogRepository,
+) *RateLimitService {
+	return &RateLimitService{
+		limiter:      limiter,
+		configRepo:   configRepo,
+		activityRepo: activityRepo,
+	}
+}
+
+// Limiter returns the rate limiter the service manages
+func (s *RateLimitService) Limiter() *security.EnhancedRateLimiter {
+	return s.limiter
+}
+
+// LoadOverrides applies the endpoint limits and access lists kept in the
+// system settings, replacing the limiter's defaults. It is called once at
+// startup.
+func (s *RateLimitService) LoadOverrides(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, err := s.configRepo.GetByKey(ctx, model.ConfigKeySecurityRateLimits)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return nil
+		}
+		return fmt.Errorf("failed to load rate limit overrides: %w", err)
+	}
+
+	var overrides rateLimitOverrides
+	if err := json.Unmarshal([]byte(stored.ConfigValue), &overrides); err != nil {
+		return fmt.Errorf("invalid rate limit overrides: %w", err)
+	}
+
+	config := s.limiter.Config()
+	if overrides.EndpointLimits != nil {
+		config.EndpointLimits = overrides.EndpointLimits
+	}
+	config.IPWhitelist = overrides.IPWhitelist
+	config.IPBlacklist = overrides.IPBlacklist
+	config.UserWhitelist = overrides.UserWhitelist
+	config.UserBlacklist = overrides.UserBlacklist
+	s.limiter.UpdateConfig(config)
+	s.persisted = true
+
+	logrus.WithField("endpoint_limits", len(config.EndpointLimits)).Info("Loaded rate limit overrides")
+	return nil
+}
+
+// SetToggles turns banning and dynamic limiting on or off. They come from the
+// configuration, which keeps them, so they are not saved with the overrides.
+func (s *RateLimitService) SetToggles(banning, dynamicLimits bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	config := s.limiter.Config()
+	config.EnableBanning = banning
+	config.EnableDynamicLimits = dynamicLimits
+	s.limiter.UpdateConfig(config)
+}
+
+// GetSettings returns the limits the limiter applies now
+func (s *RateLimitService) GetSettings() *dto.RateLimitSettings {
+	s.mu.Lock()
+	persisted := s.persisted
+	s.mu.Unlock()
+
+	config := s.limiter.EffectiveConfig()
+	settings := &dto.RateLimitSettings{
+		GlobalLimit:         config.GlobalLimit,
+		GlobalWindowSeconds: int(config.GlobalWindow.Seconds()),
+		UserLimit:           config.UserLimit,
+		UserWindowSeconds:   int(config.UserWindow.Seconds()),
+		IPLimit:             config.IPLimit,
+		IPWindowSeconds:     int(config.IPWindow.Seconds()),
+		SubnetLimit:         config.SubnetLimit,
+		SubnetWindowSeconds: int(config.SubnetWindow.Seconds()),
+		BanningEnabled:      config.EnableBanning,
+		BanThreshold:        config.BanThreshold,
+		BanDurationSeconds:  int(config.BanDuration.Seconds()),
+		Endpoints:           make([]dto.EndpointRateLimit, 0, len(config.EndpointLimits)),
+		IPWhitelist:         append([]string{}, config.IPWhitelist...),
+		IPBlacklist:         append([]string{}, config.IPBlacklist...),
+		UserWhitelist:       append([]int64{}, config.UserWhitelist...),
+		UserBlacklist:       append([]int64{}, config.UserBlacklist...),
+		Persisted:           persisted,
+	}
+	for endpoint, limit := range config.EndpointLimits {
+		settings.Endpoints = append(settings.Endpoints, dto.EndpointRateLimit{
+			Endpoint:      endpoint,
+			Limit:         limit.Limit,
+			WindowSeconds: int(limit.Window.Seconds()),
+			Methods:       limit.Methods,
+			RequireAuth:   limit.RequireAuth,
+		})
+	}
+	sort.Slice(settings.Endpoints, func(i, j int) bool {
+		return settings.Endpoints[i].Endpoint < settings.Endpoints[j].Endpoint
+	})
+	return settings
+}
+
+// SetEndpointLimit adds or replaces the limit on an endpoint
+func (s *RateLimitService) SetEndpointLimit(ctx context.Context, actor model.Actor, req *dto.UpdateEndpointRateLimitRequest) (*dto.RateLimitSettings, error) {
+	limit, err := validateEndpointRateLimit(req)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("Rate limit on %s set to %d requests per %s", req.Endpoint, limit.Limit, limit.Window)
+	metadata := map[string]interface{}{
+		"endpoint":       req.Endpoint,
+		"limit":          limit.Limit,
+		"window_seconds": req.WindowSeconds,
+		"methods":        limit.Methods,
+		"require_auth":   limit.RequireAuth,
+	}
+	return s.change(ctx, actor, req.Endpoint, description, metadata, func(config *security.EnhancedRateLimitConfig) error {
+		// Keep what the API does not expose
+		limit.SkipWhitelist = config.EndpointLimits[req.Endpoint].SkipWhitelist
+		if config.EndpointLimits == nil {
+			config.EndpointLimits = map[string]security.EndpointLimit{}
+		}
+		config.EndpointLimits[req.Endpoint] = limit
+		return nil
+	})
+}
+
+// RemoveEndpointLimit removes the limit on an endpoint, leaving it to the
+// global, user and IP limits
+func (s *RateLimitService) RemoveEndpointLimit(ctx context.Context, actor model.Actor, endpoint string) (*dto.RateLimitSettings, error) {
+	description := fmt.Sprintf("Rate limit on %s removed", endpoint)
+	metadata := map[string]interface{}{"endpoint": endpoint}
+	return s.change(ctx, actor, endpoint, description, metadata, func(config *security.EnhancedRateLimitConfig) error {
+		if _, ok := config.EndpointLimits[endpoint]; !ok {
+			return fmt.Errorf("rate limit on %s not found", endpoint)
+		}
+		delete(config.EndpointLimits, endpoint)
+		return nil
+	})
+}
+
+// AddListEntry puts an IP or user on the whitelist or blacklist. Callers
+// cannot blacklist their own IP or user, and an entry cannot be on both
+// lists.
+func (s *RateLimitService) AddListEntry(ctx context.Context, actor model.Actor, clientIP, list string, subject *dto.RateLimitSubject) (*dto.RateLimitSettings, error) {
+	if err := normalizeRateLimitSubject(list, subject); err != nil {
+		return nil, err
+	}
+	if list == dto.RateLimitBlacklist {
+		if subject.IP != "" && sameIP(subject.IP, clientIP) {
+			return nil, fmt.Errorf("invalid request: cannot blacklist %s, the IP of this request", subject.IP)
+		}
+		if subject.UserID > 0 && actor.UserID != nil && *actor.UserID == subject.UserID {
+			return nil, fmt.Errorf("invalid request: cannot blacklist yourself")
+		}
+	}
+
+	description := fmt.Sprintf("%s added to the rate limit %s", rateLimitSubjectName(subject), list)
+	metadata := map[string]interface{}{"list": list, "ip": subject.IP, "user_id": subject.UserID}
+	return s.change(ctx, actor, list, description, metadata, func(config *security.EnhancedRateLimitConfig) error {
+		ips, users := rateLimitList(config, list)
+		otherIPs, otherUsers := rateLimitList(config, otherRateLimitList(list))
+		if slices.Contains(*otherIPs, subject.IP) || slices.Contains(*otherUsers, subject.UserID) {
+			return fmt.Errorf("invalid request: %s is on the %s; remove it from there first", rateLimitSubjectName(subject), otherRateLimitList(list))
+		}
+
+		switch {
+		case subject.IP != "" && !slices.Contains(*ips, subject.IP):
+			*ips = append(*ips, subject.IP)
+		case subject.UserID > 0 && !slices.Contains(*users, subject.UserID):
+			*users = append(*users, subject.UserID)
+		}
+		return nil
+	})
+}
+
+// RemoveListEntry takes an IP or user off the whitelist or blacklist
+func (s *RateLimitService) RemoveListEntry(ctx context.Context, actor model.Actor, list string, subject *dto.RateLimitSubject) (*dto.RateLimitSettings, error) {
+	if err := normalizeRateLimitSubject(list, subject); err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("%s removed from the rate limit %s", rateLimitSubjectName(subject), list)
+	metadata := map[string]interface{}{"list": list, "ip": subject.IP, "user_id": subject.UserID}
+	return s.change(ctx, actor, list, description, metadata, func(config *security.EnhancedRateLimitConfig) error {
+		ips, users := rateLimitList(config, list)
+		before := len(*ips) + len(*users)
+		*ips = slices.DeleteFunc(*ips, func(ip string) bool { return subject.IP != "" && ip == subject.IP })
+		*users = slices.DeleteFunc(*users, func(id int64) bool { return subject.UserID > 0 && id == subject.UserID })
+		if len(*ips)+len(*users) == before {
+			return fmt.Errorf("%s not found on the %s", rateLimitSubjectName(subject), list)
+		}
+		return nil
+	})
+}
+
+// ListBans returns the IPs and users the limiter has banned
+func (s *RateLimitService) ListBans() []dto.RateLimitBan {
+	bans := s.limiter.Bans()
+	result := make([]dto.RateLimitBan, 0, len(bans))
+	for _, ban := range bans {
+		result = append(result, dto.RateLimitBan{
+			RateLimitSubject: dto.RateLimitSubject{IP: ban.IP, UserID: ban.UserID},
+			Violations:       ban.Violations,
+			BannedUntil:      ban.BannedUntil,
+		})
+	}
+	return result
+}
+
+// Unban lifts the ban of an IP or user and forgets their violations
+func (s *RateLimitService) Unban(ctx context.Context, actor model.Actor, subject *dto.RateLimitSubject) error {
+	if err := normalizeRateLimitSubject("", subject); err != nil {
+		return err
+	}
+
+	var banned bool
+	if subject.IP != "" {
+		banned = s.limiter.UnbanIP(subject.IP)
+	} else {
+		banned = s.limiter.UnbanUser(subject.UserID)
+	}
+	if !banned {
+		return fmt.Errorf("ban of %s not found", rateLimitSubjectName(subject))
+	}
+
+	description := fmt.Sprintf("Rate limit ban of %s lifted", rateLimitSubjectName(subject))
+	s.logChange(actor, "rate_limit_ban_lifted", "ban", description, map[string]interface{}{"ip": subject.IP, "user_id": subject.UserID})
+
+	logrus.WithFields(logrus.Fields{
+		"ip":      subject.IP,
+		"user_id": subject.UserID,
+		"actor":   actor.String(),
+	}).Info("Rate limit ban lifted")
+	return nil
+}
+
+// change applies modify to a copy of the limiter's configuration, stores
+// the result and swaps it into the limiter. Nothing changes when modify or
+// storing fails.
+func (s *RateLimitService) change(
+	ctx context.Context,
+	actor model.Actor,
+	resource, description string,
+	metadata map[string]interface{},
+	modify func(config *security.EnhancedRateLimitConfig) error,
+) (*dto.RateLimitSettings, error) {
+	s.mu.Lock()
+	config := s.limiter.Config()
+	if err := modify(config); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	if err := s.saveOverrides(ctx, config); err != nil {
+		s.mu.Unlock()
+		return nil, err
+	}
+	s.limiter.UpdateConfig(config)
+	s.persisted = true
+	s.mu.Unlock()
+
+	s.logChange(actor, "rate_limits_changed", resource, description, metadata)
+	logrus.WithField("actor", actor.String()).Info(description)
+
+	return s.GetSettings(), nil
+}
+
+// saveOverrides writes the endpoint limits and access lists of config to
+// the system settings
+func (s *RateLimitService) saveOverrides(ctx context.Context, config *security.EnhancedRateLimitConfig) error {
+	value, err := json.Marshal(rateLimitOverrides{
+		EndpointLimits: config.EndpointLimits,
+		IPWhitelist:    config.IPWhitelist,
+		IPBlacklist:    config.IPBlacklist,
+		UserWhitelist:  config.UserWhitelist,
+		UserBlacklist:  config.UserBlacklist,
+	})
+	if err != nil {
+		return err
+	}
+
+	existing, err := s.configRepo.GetByKey(ctx, model.ConfigKeySecurityRateLimits)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("failed to get rate limit overrides: %w", err)
+	}
+	if existing == nil {
+		err = s.configRepo.Create(ctx, &model.SystemConfig{
+			ConfigKey:   model.ConfigKeySecurityRateLimits,
+			ConfigValue: string(value),
+			Description: "Endpoint rate limits and access lists set through the API",
+		})
+	} else {
+		updated := *existing
+		updated.ConfigValue = string(value)
+		err = s.configRepo.Update(ctx, &updated)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save rate limit overrides: %w", err)
+	}
+	return nil
+}
+
+// logChange records a rate limit change in the activity log
+func (s *RateLimitService) logChange(actor model.Actor, action, resource, description string, metadata map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	data, _ := json.Marshal(metadata)
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "rate_limit",
+		ResourceName: resource,
+		Description:  description,
+		Metadata:     string(data),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).Warn("Failed to log rate limit change")
+	}
+}
+
+// validateEndpointRateLimit checks an endpoint limit and converts it for the
+// limiter. The sign-in endpoints keep a minimum rate so a limit cannot lock
+// everyone out.
+func validateEndpointRateLimit(req *dto.UpdateEndpointRateLimitRequest) (security.EndpointLimit, error) {
+	if !strings.HasPrefix(req.Endpoint, "/api/") {
+		return security.EndpointLimit{}, fmt.Errorf("invalid request: endpoint must be an API path starting with /api/")
+	}
+	if req.Limit < 1 {
+		return security.EndpointLimit{}, fmt.Errorf("invalid request: limit must be at least 1")
+	}
+
+	window := time.Duration(req.WindowSeconds) * time.Second
+	if window < minEndpointRateWindow || window > maxEndpointRateWindow {
+		return security.EndpointLimit{}, fmt.Errorf("invalid request: window_seconds must be between %d and %d",
+			int(minEndpointRateWindow.Seconds()), int(maxEndpointRateWindow.Seconds()))
+	}
+
+	methods := make([]string, 0, len(req.Methods))
+	for _, method := range req.Methods {
+		method = strings.ToUpper(method)
+		if !slices.Contains(rateLimitMethods, method) {
+			return security.EndpointLimit{}, fmt.Errorf("invalid request: unknown method %q", method)
+		}
+		if !slices.Contains(methods, method) {
+			methods = append(methods, method)
+		}
+	}
+
+	if minimum, ok := authEndpointMinLimits[req.Endpoint]; ok {
+		perHour := float64(req.Limit) * float64(time.Hour) / float64(window)
+		if perHour < float64(minimum) {
+			return security.EndpointLimit{}, fmt.Errorf("invalid request: %s must allow at least %d requests per hour, or no one could sign in", req.Endpoint, minimum)
+		}
+	}
+
+	return security.EndpointLimit{
+		Limit:       req.Limit,
+		Window:      window,
+		Methods:     methods,
+		RequireAuth: req.RequireAuth,
+	}, nil
+}
+
+// normalizeRateLimitSubject checks the list, when given, and that exactly
+// one of IP and user is set, and puts the IP in canonical form
+func normalizeRateLimitSubject(list string, subject *dto.RateLimitSubject) error {
+	if list != "" && list != dto.RateLimitWhitelist && list != dto.RateLimitBlacklist {
+		return fmt.Errorf("rate limit list %s not found", list)
+	}
+	if (subject.IP == "") == (subject.UserID == 0) {
+		return fmt.Errorf("invalid request: set either ip or user_id")
+	}
+	if subject.UserID < 0 {
+		return fmt.Errorf("invalid request: invalid user_id")
+	}
+	if subject.IP != "" {
+		ip := net.ParseIP(subject.IP)
+		if ip == nil {
+			return fmt.Errorf("invalid request: invalid IP address %q", subject.IP)
+		}
+		subject.IP = ip.String()
+	}
+	return nil
+}
+
+// rateLimitList returns the IP and user entries of a list
+func rateLimitList(config *security.EnhancedRateLimitConfig, list string) (*[]string, *[]int64) {
+	if list == dto.RateLimitBlacklist {
+		return &config.IPBlacklist, &config.UserBlacklist
+	}
+	return &config.IPWhitelist, &config.UserWhitelist
+}
+
+func otherRateLimitList(list string) string {
+	if list == dto.RateLimitBlacklist {
+		return dto.RateLimitWhitelist
+	}
+	return dto.RateLimitBlacklist
+}
+
+func rateLimitSubjectName(subject *dto.RateLimitSubject) string {
+	if subject.IP != "" {
+		return "IP " + subject.IP
+	}
+	return fmt.Sprintf("user %d", subject.UserID)
+}
+
+// sameIP compares two IPs in any notation
+func sameIP(a, b string) bool {
+	ipA, ipB := net.ParseIP(a), net.ParseIP(b)
+	return ipA != nil && ipB != nil && ipA.Equal(ipB)
+}
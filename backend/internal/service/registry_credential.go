@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/registry"
+
+	dockerregistry "github.com/docker/docker/api/types/registry"
+	"github.com/sirupsen/logrus"
+)
+
+// RegistryCredentialService stores credentials for private registries and
+// hands them to update checks and pulls. Credentials apply to every image
+// whose registry host matches theirs; passwords and tokens are stored
+// encrypted and never returned.
+type RegistryCredentialService struct {
+	credentialRepo repository.RegistryCredentialsRepository
+	activityRepo   repository.ActivityLogRepository
+	secretService  *SecretService
+	imageService   *ImageService
+
+	mu     sync.RWMutex
+	byHost map[string]*registry.AuthConfig
+}
+
+// NewRegistryCredentialService creates a new registry credential service
+// instance. Pulls through dockerClient authenticate with the stored
+// credentials once Reload has loaded them.
+func NewRegistryCredentialService(
+	credentialRepo repository.RegistryCredentialsRepository,
+	activityRepo repository.ActivityLogRepository,
+	secretService *SecretService,
+	imageService *ImageService,
+	dockerClient *docker.DockerClient,
+) *RegistryCredentialService {
+	s := &RegistryCredentialService{
+		credentialRepo: credentialRepo,
+		activityRepo:   activityRepo,
+		secretService:  secretService,
+		imageService:   imageService,
+		byHost:         make(map[string]*registry.AuthConfig),
+	}
+	if dockerClient != nil {
+		dockerClient.SetRegistryAuthFunc(s.pullAuth)
+	}
+	return s
+}
+
+// ListCredentials lists stored credentials without their secrets
+func (s *RegistryCredentialService) ListCredentials(ctx context.Context, filter *model.RegistryCredentialsFilter) ([]*model.RegistryCredentials, int64, error) {
+	return s.credentialRepo.List(ctx, filter)
+}
+
+// GetCredential returns stored credentials without their secrets
+func (s *RegistryCredentialService) GetCredential(ctx context.Context, id int64) (*model.RegistryCredentials, error) {
+	return s.credentialRepo.GetByID(ctx, id)
+}
+
+// CreateCredential stores credentials for a registry. A registry host can
+// have only one active set of credentials.
+func (s *RegistryCredentialService) CreateCredential(ctx context.Context, actor model.Actor, req *dto.CreateRegistryCredentialRequest) (*model.RegistryCredentials, error) {
+	credentials := &model.RegistryCredentials{
+		Name:        strings.TrimSpace(req.Name),
+		RegistryURL: strings.TrimSpace(req.RegistryURL),
+		Username:    strings.TrimSpace(req.Username),
+		AuthType:    model.RegistryAuthType(req.AuthType),
+		IsActive:    req.IsActive == nil || *req.IsActive,
+		CreatedBy:   actor.OwnerID(),
+	}
+	if credentials.AuthType == "" {
+		credentials.AuthType = model.RegistryAuthTypeBasic
+	}
+
+	if err := s.validate(ctx, credentials, req.Password, req.Token); err != nil {
+		return nil, err
+	}
+	if err := s.secretService.SealRegistryCredentials(credentials, req.Password, req.Token); err != nil {
+		return nil, err
+	}
+	if err := s.credentialRepo.Create(ctx, credentials); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(actor, "registry_credentials_create", credentials, fmt.Sprintf("Stored credentials for %s", credentials.RegistryURL))
+	s.reload(ctx)
+
+	return credentials, nil
+}
+
+// UpdateCredential changes stored credentials; an omitted password or token
+// keeps the stored one
+func (s *RegistryCredentialService) UpdateCredential(ctx context.Context, actor model.Actor, id int64, req *dto.UpdateRegistryCredentialRequest) (*model.RegistryCredentials, error) {
+	credentials, err := s.credentialRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	password, token, err := s.secretService.OpenRegistryCredentials(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		credentials.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.RegistryURL != nil {
+		credentials.RegistryURL = strings.TrimSpace(*req.RegistryURL)
+	}
+	if req.AuthType != nil {
+		credentials.AuthType = model.RegistryAuthType(*req.AuthType)
+	}
+	if req.Username != nil {
+		credentials.Username = strings.TrimSpace(*req.Username)
+	}
+	if req.Password != nil {
+		password = *req.Password
+	}
+	if req.Token != nil {
+		token = *req.Token
+	}
+	if req.IsActive != nil {
+		credentials.IsActive = *req.IsActive
+	}
+
+	if err := s.validate(ctx, credentials, password, token); err != nil {
+		return nil, err
+	}
+	if err := s.secretService.SealRegistryCredentials(credentials, password, token); err != nil {
+		return nil, err
+	}
+	if err := s.credentialRepo.Update(ctx, credentials); err != nil {
+		return nil, err
+	}
+
+	s.logActivity(actor, "registry_credentials_update", credentials, fmt.Sprintf("Updated credentials for %s", credentials.RegistryURL))
+	s.reload(ctx)
+
+	return credentials, nil
+}
+
+// DeleteCredential removes stored credentials; the registry is reached
+// anonymously afterwards
+func (s *RegistryCredentialService) DeleteCredential(ctx context.Context, actor model.Actor, id int64) error {
+	credentials, err := s.credentialRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.credentialRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.logActivity(actor, "registry_credentials_delete", credentials, fmt.Sprintf("Deleted credentials for %s", credentials.RegistryURL))
+	s.reload(ctx)
+
+	return nil
+}
+
+// SetDefaultCredential marks credentials as the default registry
+func (s *RegistryCredentialService) SetDefaultCredential(ctx context.Context, actor model.Actor, id int64) (*model.RegistryCredentials, error) {
+	if _, err := s.credentialRepo.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	if err := s.credentialRepo.SetDefault(ctx, id); err != nil {
+		return nil, err
+	}
+
+	credentials, err := s.credentialRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	s.logActivity(actor, "registry_credentials_default", credentials, fmt.Sprintf("Made %s the default registry", credentials.RegistryURL))
+
+	return credentials, nil
+}
+
+// TestCredential authenticates against the registry's /v2/ endpoint with the
+// stored credentials. A failed connection is reported in the result, not as
+// an error.
+func (s *RegistryCredentialService) TestCredential(ctx context.Context, id int64) (*dto.RegistryTestResult, error) {
+	credentials, err := s.credentialRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := s.authConfig(credentials)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.RegistryTestResult{Host: registry.RegistryHost(credentials.RegistryURL)}
+
+	start := time.Now()
+	err = registry.NewV2Client(credentials.RegistryURL, auth).TestConnection(ctx)
+	result.DurationMS = time.Since(start).Milliseconds()
+
+	if err != nil {
+		result.Message = "Connection failed"
+		result.Error = err.Error()
+	} else {
+		result.Success = true
+		result.Message = "Connection successful"
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"credentials_id": id,
+		"host":           result.Host,
+		"success":        result.Success,
+		"duration_ms":    result.DurationMS,
+	}).Info("Registry credentials tested")
+
+	return result, nil
+}
+
+// Reload loads the active credentials into the update checker and the pull
+// lookup. Call it once at startup; changes through the service reload on
+// their own.
+func (s *RegistryCredentialService) Reload(ctx context.Context) error {
+	active, err := s.credentialRepo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+	sort.Slice(active, func(i, j int) bool { return active[i].ID < active[j].ID })
+
+	byHost := make(map[string]*registry.AuthConfig, len(active))
+	for _, credentials := range active {
+		host := registry.RegistryHost(credentials.RegistryURL)
+		if _, exists := byHost[host]; exists {
+			logrus.WithField("credentials_id", credentials.ID).Warnf("Ignoring duplicate active credentials for registry %s", host)
+			continue
+		}
+		auth, err := s.authConfig(credentials)
+		if err != nil {
+			logrus.WithError(err).WithField("credentials_id", credentials.ID).Warn("Failed to load registry credentials")
+			continue
+		}
+		byHost[host] = auth
+	}
+
+	s.mu.Lock()
+	previous := s.byHost
+	s.byHost = byHost
+	s.mu.Unlock()
+
+	if s.imageService != nil {
+		for host := range previous {
+			if byHost[host] == nil {
+				s.imageService.SetRegistryCredentials(host, nil)
+			}
+		}
+		for host, auth := range byHost {
+			s.imageService.SetRegistryCredentials(host, auth)
+		}
+	}
+
+	logrus.WithField("registries", len(byHost)).Info("Registry credentials loaded")
+	return nil
+}
+
+// reload applies a change; the change itself is already stored, so a
+// failure only delays it until the next reload
+func (s *RegistryCredentialService) reload(ctx context.Context) {
+	if err := s.Reload(ctx); err != nil {
+		logrus.WithError(err).Warn("Failed to reload registry credentials")
+	}
+}
+
+// validate checks credentials before they are stored
+func (s *RegistryCredentialService) validate(ctx context.Context, credentials *model.RegistryCredentials, password, token string) error {
+	if credentials.Name == "" {
+		return fmt.Errorf("invalid request: name is required")
+	}
+	if credentials.RegistryURL == "" {
+		return fmt.Errorf("invalid request: registry_url is required")
+	}
+
+	switch credentials.AuthType {
+	case model.RegistryAuthTypeBasic:
+		if credentials.Username == "" || password == "" {
+			return fmt.Errorf("invalid request: basic auth requires a username and password")
+		}
+	case model.RegistryAuthTypeToken:
+		if token == "" {
+			return fmt.Errorf("invalid request: token auth requires a token")
+		}
+	default:
+		return fmt.Errorf("invalid request: auth_type must be basic or token")
+	}
+
+	if !credentials.IsActive {
+		return nil
+	}
+	host := registry.RegistryHost(credentials.RegistryURL)
+	active, err := s.credentialRepo.GetActive(ctx)
+	if err != nil {
+		return err
+	}
+	for _, other := range active {
+		if other.ID != credentials.ID && registry.RegistryHost(other.RegistryURL) == host {
+			return fmt.Errorf("active credentials for registry %s already exist: %s", host, other.Name)
+		}
+	}
+	return nil
+}
+
+// authConfig decrypts stored credentials for the registry clients
+func (s *RegistryCredentialService) authConfig(credentials *model.RegistryCredentials) (*registry.AuthConfig, error) {
+	password, token, err := s.secretService.OpenRegistryCredentials(credentials)
+	if err != nil {
+		return nil, err
+	}
+	return &registry.AuthConfig{
+		Username: credentials.Username,
+		Password: password,
+		Token:    token,
+		AuthType: string(credentials.AuthType),
+	}, nil
+}
+
+// pullAuth returns the Docker credentials for pulling ref, nil when its
+// registry has none
+func (s *RegistryCredentialService) pullAuth(ctx context.Context, ref string) *dockerregistry.AuthConfig {
+	host := registry.ImageRegistryHost(ref)
+
+	s.mu.RLock()
+	auth := s.byHost[host]
+	s.mu.RUnlock()
+
+	if auth == nil {
+		return nil
+	}
+
+	config := &dockerregistry.AuthConfig{
+		Username:      auth.Username,
+		Password:      auth.Password,
+		ServerAddress: host,
+	}
+	if auth.AuthType == string(model.RegistryAuthTypeToken) {
+		// Registries take a personal access token as the password of its
+		// user; without a user it is sent as a bearer token
+		if auth.Username != "" {
+			config.Password = auth.Token
+		} else {
+			config.RegistryToken = auth.Token
+		}
+	}
+	return config
+}
+
+// logActivity audits a change to stored credentials
+func (s *RegistryCredentialService) logActivity(actor model.Actor, action string, credentials *model.RegistryCredentials, description string) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"registry_url": credentials.RegistryURL,
+		"auth_type":    credentials.AuthType,
+		"is_active":    credentials.IsActive,
+	})
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "registry_credentials",
+		ResourceID:   &credentials.ID,
+		ResourceName: credentials.Name,
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("credentials_id", credentials.ID).Warn("Failed to log registry credentials activity")
+	}
+}
@@ -0,0 +1,412 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrReportAccessDenied is returned for actors that cannot export reports
+var ErrReportAccessDenied = errors.New("access denied: reports can only be exported by users")
+
+// defaultReportWindow is the period exported when no from date is given
+const defaultReportWindow = 90 * 24 * time.Hour
+
+// reportFlushRows is how many rows are buffered before flushing to the client
+const reportFlushRows = 500
+
+// ReportKind identifies an exportable report
+type ReportKind string
+
+const (
+	ReportKindUpdates        ReportKind = "updates"
+	ReportKindTaskExecutions ReportKind = "task_executions"
+)
+
+// ReportFormat is the file format of an export
+type ReportFormat string
+
+const (
+	ReportFormatCSV  ReportFormat = "csv"
+	ReportFormatJSON ReportFormat = "json"
+)
+
+// ContentType returns the MIME type of files in the format
+func (f ReportFormat) ContentType() string {
+	if f == ReportFormatJSON {
+		return "application/json; charset=utf-8"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// ReportScope tells which rows an export covers
+type ReportScope string
+
+const (
+	ReportScopeAll   ReportScope = "all"
+	ReportScopeOwned ReportScope = "owned"
+)
+
+// ReportRequest represents the query of a report export. Dates are RFC3339
+// timestamps or YYYY-MM-DD days; a day given as to is included.
+type ReportRequest struct {
+	From   string `form:"from"`
+	To     string `form:"to"`
+	Format string `form:"format"`
+}
+
+// ReportExport is a prepared export: its rows are counted so the response
+// headers can announce truncation before the body is streamed
+type ReportExport struct {
+	Kind      ReportKind
+	Format    ReportFormat
+	Filter    model.ReportFilter
+	Scope     ReportScope
+	TotalRows int64
+	RowLimit  int
+
+	actor model.Actor
+}
+
+// Truncated reports whether the export stops short of the matching rows
+func (e *ReportExport) Truncated() bool {
+	return e.TotalRows > int64(e.RowLimit)
+}
+
+// ContentType returns the MIME type of the export
+func (e *ReportExport) ContentType() string {
+	return e.Format.ContentType()
+}
+
+// Filename returns the download name of the export, e.g.
+// "updates_20260101_20260401.csv"
+func (e *ReportExport) Filename() string {
+	return fmt.Sprintf("%s_%s_%s.%s", e.Kind,
+		e.Filter.From.UTC().Format("20060102"), e.Filter.To.UTC().Format("20060102"), e.Format)
+}
+
+// ReportService exports update and task execution history for compliance
+// reporting
+type ReportService struct {
+	reportRepo   repository.ReportRepository
+	userRepo     repository.UserRepository
+	activityRepo repository.ActivityLogRepository
+	maxRows      int
+}
+
+// NewReportService creates a new report service instance
+func NewReportService(
+	reportRepo repository.ReportRepository,
+	userRepo repository.UserRepository,
+	activityRepo repository.ActivityLogRepository,
+	cfg *config.Config,
+) *ReportService {
+	return &ReportService{
+		reportRepo:   reportRepo,
+		userRepo:     userRepo,
+		activityRepo: activityRepo,
+		maxRows:      cfg.System.ReportExportMaxRows,
+	}
+}
+
+// PrepareExport validates the request, resolves the actor's scope and counts
+// the rows to export. Admins and system actors export everything; other
+// users only the containers and tasks they created.
+func (s *ReportService) PrepareExport(ctx context.Context, actor model.Actor, kind ReportKind, req *ReportRequest) (*ReportExport, error) {
+	format, err := parseReportFormat(req.Format)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := parseReportWindow(req.From, req.To, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	scope, err := s.resolveScope(ctx, actor, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	switch kind {
+	case ReportKindUpdates:
+		total, err = s.reportRepo.CountUpdates(ctx, filter)
+	case ReportKindTaskExecutions:
+		total, err = s.reportRepo.CountTaskExecutions(ctx, filter)
+	default:
+		return nil, fmt.Errorf("invalid request: unknown report %q", kind)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReportExport{
+		Kind:      kind,
+		Format:    format,
+		Filter:    *filter,
+		Scope:     scope,
+		TotalRows: total,
+		RowLimit:  s.maxRows,
+		actor:     actor,
+	}, nil
+}
+
+// WriteExport streams the prepared export to w and records it in the
+// activity log. w is flushed as rows are written when it supports it.
+func (s *ReportService) WriteExport(ctx context.Context, w io.Writer, export *ReportExport) error {
+	var (
+		rows int
+		err  error
+	)
+	switch export.Format {
+	case ReportFormatJSON:
+		rows, err = s.writeJSON(ctx, w, export)
+	default:
+		rows, err = s.writeCSV(ctx, w, export)
+	}
+
+	s.logExport(export, rows, err)
+	return err
+}
+
+// writeCSV writes the header, the rows and, when truncated, a trailing
+// comment line explaining the cut
+func (s *ReportService) writeCSV(ctx context.Context, w io.Writer, export *ReportExport) (int, error) {
+	cw := csv.NewWriter(w)
+	rows := 0
+
+	write := func(record []string) error {
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+		rows++
+		if rows%reportFlushRows == 0 {
+			return flushReport(cw, w)
+		}
+		return nil
+	}
+
+	var err error
+	switch export.Kind {
+	case ReportKindUpdates:
+		if err = cw.Write(model.UpdateReportColumns); err != nil {
+			return 0, err
+		}
+		err = s.reportRepo.StreamUpdates(ctx, &export.Filter, export.RowLimit, func(r *model.UpdateReportRecord) error {
+			return write(r.Row().Record())
+		})
+	case ReportKindTaskExecutions:
+		if err = cw.Write(model.TaskExecutionReportColumns); err != nil {
+			return 0, err
+		}
+		err = s.reportRepo.StreamTaskExecutions(ctx, &export.Filter, export.RowLimit, func(r *model.TaskExecutionReportRecord) error {
+			return write(r.Row().Record())
+		})
+	}
+	if err != nil {
+		return rows, err
+	}
+
+	if export.Truncated() {
+		cw.Flush()
+		if _, err := fmt.Fprintf(w, "# Export truncated: %d of %d rows; narrow the date range to export the rest\n",
+			rows, export.TotalRows); err != nil {
+			return rows, err
+		}
+	}
+
+	return rows, flushReport(cw, w)
+}
+
+// writeJSON writes an object holding the truncation details and the rows,
+// encoding one row at a time
+func (s *ReportService) writeJSON(ctx context.Context, w io.Writer, export *ReportExport) (int, error) {
+	if _, err := fmt.Fprintf(w, `{"report":%q,"truncated":%t,"row_limit":%d,"total_rows":%d,"rows":[`,
+		export.Kind, export.Truncated(), export.RowLimit, export.TotalRows); err != nil {
+		return 0, err
+	}
+
+	rows := 0
+	write := func(row interface{}) error {
+		data, err := json.Marshal(row)
+		if err != nil {
+			return err
+		}
+		if rows > 0 {
+			if _, err := io.WriteString(w, ","); err != nil {
+				return err
+			}
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+		rows++
+		if rows%reportFlushRows == 0 {
+			flushWriter(w)
+		}
+		return nil
+	}
+
+	var err error
+	switch export.Kind {
+	case ReportKindUpdates:
+		err = s.reportRepo.StreamUpdates(ctx, &export.Filter, export.RowLimit, func(r *model.UpdateReportRecord) error {
+			return write(r.Row())
+		})
+	case ReportKindTaskExecutions:
+		err = s.reportRepo.StreamTaskExecutions(ctx, &export.Filter, export.RowLimit, func(r *model.TaskExecutionReportRecord) error {
+			return write(r.Row())
+		})
+	}
+	if err != nil {
+		return rows, err
+	}
+
+	if _, err := io.WriteString(w, "]}\n"); err != nil {
+		return rows, err
+	}
+	flushWriter(w)
+	return rows, nil
+}
+
+// resolveScope restricts the filter to the actor's own resources unless the
+// actor is an admin or a system component
+func (s *ReportService) resolveScope(ctx context.Context, actor model.Actor, filter *model.ReportFilter) (ReportScope, error) {
+	if actor.IsSystem() {
+		return ReportScopeAll, nil
+	}
+	if actor.UserID == nil {
+		return "", ErrReportAccessDenied
+	}
+
+	user, err := s.userRepo.GetByID(ctx, *actor.UserID)
+	if err != nil {
+		return "", err
+	}
+	if user.IsAdmin() {
+		return ReportScopeAll, nil
+	}
+
+	filter.OwnerID = actor.OwnerID()
+	return ReportScopeOwned, nil
+}
+
+// logExport records an export, including failed ones, in the activity log
+func (s *ReportService) logExport(export *ReportExport, rows int, exportErr error) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata := map[string]interface{}{
+		"from":       export.Filter.From.UTC().Format(time.RFC3339),
+		"to":         export.Filter.To.UTC().Format(time.RFC3339),
+		"format":     export.Format,
+		"scope":      export.Scope,
+		"rows":       rows,
+		"total_rows": export.TotalRows,
+		"truncated":  export.Truncated(),
+	}
+	description := fmt.Sprintf("Exported %d %s rows as %s", rows, export.Kind, export.Format)
+	if exportErr != nil {
+		metadata["error"] = exportErr.Error()
+		description = fmt.Sprintf("Export of %s as %s failed after %d rows", export.Kind, export.Format, rows)
+	}
+	metadataJSON, _ := json.Marshal(metadata)
+
+	activity := &model.ActivityLog{
+		Action:       "report_exported",
+		ResourceType: "report",
+		ResourceName: string(export.Kind),
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(export.actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("report", export.Kind).Warn("Failed to log report export")
+	}
+}
+
+func parseReportFormat(value string) (ReportFormat, error) {
+	switch ReportFormat(strings.ToLower(strings.TrimSpace(value))) {
+	case "", ReportFormatCSV:
+		return ReportFormatCSV, nil
+	case ReportFormatJSON:
+		return ReportFormatJSON, nil
+	default:
+		return "", fmt.Errorf("invalid request: format must be csv or json")
+	}
+}
+
+// parseReportWindow parses the from/to bounds into a half-open window,
+// defaulting to the last 90 days
+func parseReportWindow(from, to string, now time.Time) (*model.ReportFilter, error) {
+	filter := &model.ReportFilter{To: now}
+
+	if to != "" {
+		t, day, err := parseReportTime(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: to: %w", err)
+		}
+		if day {
+			t = t.AddDate(0, 0, 1)
+		}
+		filter.To = t
+	}
+
+	filter.From = filter.To.Add(-defaultReportWindow)
+	if from != "" {
+		t, _, err := parseReportTime(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid request: from: %w", err)
+		}
+		filter.From = t
+	}
+
+	if !filter.From.Before(filter.To) {
+		return nil, fmt.Errorf("invalid request: from must be before to")
+	}
+
+	return filter, nil
+}
+
+// parseReportTime parses an RFC3339 timestamp or a YYYY-MM-DD day, reporting
+// whether a day was given
+func parseReportTime(value string) (time.Time, bool, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("expected RFC3339 or YYYY-MM-DD, got %q", value)
+	}
+	return t, true, nil
+}
+
+func flushReport(cw *csv.Writer, w io.Writer) error {
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	flushWriter(w)
+	return nil
+}
+
+func flushWriter(w io.Writer) {
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
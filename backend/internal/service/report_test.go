@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+// fixedReportRepo serves the same rows for every filter
+type fixedReportRepo struct {
+	updates []*model.UpdateReportRecord
+	tasks   []*model.TaskExecutionReportRecord
+}
+
+func (r *fixedReportRepo) CountUpdates(ctx context.Context, filter *model.ReportFilter) (int64, error) {
+	return int64(len(r.updates)), nil
+}
+
+func (r *fixedReportRepo) StreamUpdates(ctx context.Context, filter *model.ReportFilter, limit int, fn func(*model.UpdateReportRecord) error) error {
+	for i, record := range r.updates {
+		if i == limit {
+			break
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fixedReportRepo) CountTaskExecutions(ctx context.Context, filter *model.ReportFilter) (int64, error) {
+	return int64(len(r.tasks)), nil
+}
+
+func (r *fixedReportRepo) StreamTaskExecutions(ctx context.Context, filter *model.ReportFilter, limit int, fn func(*model.TaskExecutionReportRecord) error) error {
+	for i, record := range r.tasks {
+		if i == limit {
+			break
+		}
+		if err := fn(record); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fixedActivityRepo serves the same entries for every filter and keeps the
+// entries logged by exports apart
+type fixedActivityRepo struct {
+	repository.ActivityLogRepository
+	entries []*model.ActivityLog
+	logged  []*model.ActivityLog
+}
+
+func (r *fixedActivityRepo) Count(ctx context.Context, filter *model.ActivityLogFilter) (int64, error) {
+	return int64(len(r.entries)), nil
+}
+
+func (r *fixedActivityRepo) Stream(ctx context.Context, filter *model.ActivityLogFilter, limit int, fn func(*model.ActivityLog) error) error {
+	for i, entry := range r.entries {
+		if i == limit {
+			break
+		}
+		if err := fn(entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fixedActivityRepo) Create(ctx context.Context, activity *model.ActivityLog) error {
+	r.logged = append(r.logged, activity)
+	return nil
+}
+
+func reportTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func reportTimePtr(value string) *time.Time {
+	t := reportTime(value)
+	return &t
+}
+
+// newReportFixtures returns one row per trigger, actor and scan verdict the
+// exports distinguish, with values that need CSV quoting
+func newReportFixtures() (*fixedReportRepo, *fixedActivityRepo) {
+	passed, failed := true, false
+	userID := int64(3)
+	resourceID := 12
+
+	reports := &fixedReportRepo{
+		updates: []*model.UpdateReportRecord{
+			{
+				ID: 1, ContainerID: 12, ContainerName: "web",
+				OldImage: "nginx:1.25", OldDigest: "sha256:aaa", NewImage: "nginx:1.26", NewDigest: "sha256:bbb",
+				Status: model.UpdateStatusCompleted, DurationSeconds: 42,
+				TriggeredBy: model.TriggerTypeSchedule, ActorType: model.ActorTypeSystem, ActorName: "scheduler",
+				StartedAt: reportTime("2026-03-01T02:00:00Z"), CompletedAt: reportTimePtr("2026-03-01T02:00:42Z"),
+				ScanPassed: &passed,
+			},
+			{
+				ID: 2, ContainerID: 13, ContainerName: "api",
+				OldImage: "app:2.0", NewImage: "app:2.1", NewDigest: "sha256:ccc",
+				Status: model.UpdateStatusFailed, ErrorMessage: "health check failed: \"GET /health\", status 503", DurationSeconds: 90,
+				TriggeredBy: model.TriggerTypeManual, ActorType: model.ActorTypeUser, Username: "alice",
+				StartedAt: reportTime("2026-03-02T10:30:00+02:00"), CompletedAt: reportTimePtr("2026-03-02T10:31:30+02:00"),
+				ScanPassed: &failed, Notes: "rolled back,\nsee incident 7",
+			},
+			{
+				ID: 3, ContainerID: 12, ContainerName: "web",
+				OldImage: "nginx:1.26", NewImage: "nginx:1.26", NewDigest: "sha256:ddd",
+				Status: model.UpdateStatusCompleted, DurationSeconds: 12,
+				TriggeredBy: model.TriggerTypeManual, ActorType: model.ActorTypeUser, ActorName: "bob", Username: "bob",
+				ResolvedDigest: "sha256:ddd",
+				StartedAt:      reportTime("2026-03-03T08:00:00Z"), CompletedAt: reportTimePtr("2026-03-03T08:00:12Z"),
+			},
+			{
+				ID: 4, ContainerID: 14, ContainerName: "worker",
+				OldImage: "worker:1", NewImage: "worker:2",
+				Status:      model.UpdateStatusRunning,
+				TriggeredBy: model.TriggerTypeWebhook, ActorType: model.ActorTypeAPIToken, ActorName: "ci-deploy",
+				StartedAt: reportTime("2026-03-04T12:00:00Z"),
+			},
+			{
+				ID: 5, ContainerID: 15, ContainerName: "cache",
+				OldImage: "redis:7.2", NewImage: "redis:7.4",
+				Status: model.UpdateStatusCompleted, DurationSeconds: 5,
+				TriggeredBy: model.TriggerTypeAuto, ActorType: model.ActorTypeSystem, ActorName: "image-checker",
+				Approver:  "policy:minor-updates",
+				StartedAt: reportTime("2026-03-05T00:00:00Z"), CompletedAt: reportTimePtr("2026-03-05T00:00:05Z"),
+			},
+		},
+		tasks: []*model.TaskExecutionReportRecord{
+			{
+				ID: 10, TaskID: 1, TaskName: "Nightly image check", TaskType: model.TaskTypeImageCheck, Username: "admin",
+				TriggeredBy: model.TriggerTypeSchedule, Status: model.ExecutionStatusSuccess, Message: "3 updates found",
+				DurationSeconds: 8, StartedAt: reportTime("2026-03-01T01:00:00Z"), CompletedAt: reportTimePtr("2026-03-01T01:00:08Z"),
+			},
+			{
+				ID: 11, TaskID: 2, TaskName: "Cleanup, images", TaskType: model.TaskTypeCleanup, Username: "alice",
+				TriggeredBy: model.TriggerTypeManual, Status: model.ExecutionStatusFailed, Message: "removing \"old\": in use",
+				DurationSeconds: 2, StartedAt: reportTime("2026-03-02T04:00:00-05:00"), CompletedAt: reportTimePtr("2026-03-02T04:00:02-05:00"),
+			},
+			{
+				ID: 12, TaskID: 1, TaskName: "Nightly image check", TaskType: model.TaskTypeImageCheck,
+				TriggeredBy: model.TriggerTypeWebhook, Status: model.ExecutionStatusRunning,
+				StartedAt: reportTime("2026-03-03T01:00:00Z"),
+			},
+		},
+	}
+
+	activity := &fixedActivityRepo{
+		entries: []*model.ActivityLog{
+			{
+				ID: 100, UserID: &userID, ActorType: model.ActorTypeUser, ActorName: "alice",
+				Action: "container_updated", ResourceType: "container", ResourceID: &resourceID, ResourceName: "web",
+				Description: "Updated web, then restarted", IPAddress: "192.0.2.10", UserAgent: "Mozilla/5.0 (X11; Linux)",
+				Metadata: `{"from":"nginx:1.25","to":"nginx:1.26"}`, CreatedAt: reportTime("2026-03-01T09:00:00+01:00"),
+			},
+			{
+				ID: 101, ActorType: model.ActorTypeSystem, ActorName: "scheduler",
+				Action: "task_executed", ResourceType: "task", ResourceName: "Nightly image check",
+				Metadata: "{}", CreatedAt: reportTime("2026-03-01T01:00:08Z"),
+			},
+		},
+	}
+
+	return reports, activity
+}
+
+func TestReportExportsMatchGolden(t *testing.T) {
+	ctx := context.Background()
+	filter := model.ReportFilter{From: reportTime("2026-03-01T00:00:00Z"), To: reportTime("2026-04-01T00:00:00Z")}
+
+	tests := []struct {
+		golden   string
+		kind     ReportKind
+		format   ReportFormat
+		rowLimit int
+	}{
+		{"report_updates.csv", ReportKindUpdates, ReportFormatCSV, 100},
+		{"report_updates.json", ReportKindUpdates, ReportFormatJSON, 100},
+		{"report_updates_truncated.csv", ReportKindUpdates, ReportFormatCSV, 2},
+		{"report_updates_truncated.json", ReportKindUpdates, ReportFormatJSON, 2},
+		{"report_task_executions.csv", ReportKindTaskExecutions, ReportFormatCSV, 100},
+		{"report_task_executions.json", ReportKindTaskExecutions, ReportFormatJSON, 100},
+		{"report_task_executions_truncated.csv", ReportKindTaskExecutions, ReportFormatCSV, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			reports, activity := newReportFixtures()
+			cfg := &config.Config{}
+			cfg.System.ReportExportMaxRows = tt.rowLimit
+			service := NewReportService(reports, nil, activity, cfg)
+
+			export, err := service.PrepareExport(ctx, model.SystemActor("compliance"), tt.kind, &ReportRequest{
+				From:   "2026-03-01",
+				To:     "2026-03-31",
+				Format: string(tt.format),
+			})
+			if err != nil {
+				t.Fatalf("PrepareExport failed: %v", err)
+			}
+			if !export.Filter.From.Equal(filter.From) || !export.Filter.To.Equal(filter.To) {
+				t.Fatalf("filter = %v to %v, want %v to %v", export.Filter.From, export.Filter.To, filter.From, filter.To)
+			}
+
+			var buf bytes.Buffer
+			if err := service.WriteExport(ctx, &buf, export); err != nil {
+				t.Fatalf("WriteExport failed: %v", err)
+			}
+			compareGolden(t, tt.golden, buf.Bytes())
+
+			if len(activity.logged) != 1 || activity.logged[0].Action != "report_exported" {
+				t.Errorf("logged %+v, want one report_exported entry", activity.logged)
+			}
+		})
+	}
+}
+
+func TestActivityExportsMatchGolden(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		golden   string
+		format   ReportFormat
+		rowLimit int
+	}{
+		{"report_activity.csv", ReportFormatCSV, 100},
+		{"report_activity.json", ReportFormatJSON, 100},
+		{"report_activity_truncated.csv", ReportFormatCSV, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.golden, func(t *testing.T) {
+			_, activity := newReportFixtures()
+			cfg := &config.Config{}
+			cfg.System.ReportExportMaxRows = tt.rowLimit
+			service := NewActivityService(activity, nil, cfg)
+
+			export, err := service.PrepareExport(ctx, model.SystemActor("compliance"), &dto.ActivityQuery{
+				From:   "2026-03-01",
+				To:     "2026-03-31",
+				Format: string(tt.format),
+			})
+			if err != nil {
+				t.Fatalf("PrepareExport failed: %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := service.WriteExport(ctx, &buf, export); err != nil {
+				t.Fatalf("WriteExport failed: %v", err)
+			}
+			compareGolden(t, tt.golden, buf.Bytes())
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// rolePermissionsTTL is how long resolved role permissions are reused, so a
+// change made on another replica applies within it
+const rolePermissionsTTL = 30 * time.Second
+
+// RoleService manages custom roles and resolves the permissions roles grant.
+// The built-in roles are listed alongside custom ones but defined in code.
+type RoleService struct {
+	roleRepo     repository.RoleRepository
+	activityRepo repository.ActivityLogRepository
+
+	mu       sync.RWMutex
+	roles    map[model.UserRole][]model.Permission
+	loadedAt time.Time
+}
+
+// NewRoleService creates a new role service instance
+func NewRoleService(roleRepo repository.RoleRepository, activityRepo repository.ActivityLogRepository) *RoleService {
+	return &RoleService{
+		roleRepo:     roleRepo,
+		activityRepo: activityRepo,
+	}
+}
+
+// ListRoles lists the built-in roles followed by the custom roles
+func (s *RoleService) ListRoles(ctx context.Context) ([]*dto.RoleResponse, error) {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.roleRepo.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]*dto.RoleResponse, len(roles))
+	for i, role := range roles {
+		responses[i] = &dto.RoleResponse{Role: role, UserCount: counts[role.Name]}
+	}
+	return responses, nil
+}
+
+// GetRole returns a role
+func (s *RoleService) GetRole(ctx context.Context, id int) (*dto.RoleResponse, error) {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := s.roleRepo.CountUsers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.RoleResponse{Role: role, UserCount: counts[role.Name]}, nil
+}
+
+// CreateRole adds a custom role
+func (s *RoleService) CreateRole(ctx context.Context, actor model.Actor, req *dto.CreateRoleRequest) (*model.Role, error) {
+	role := &model.Role{
+		Name:        model.UserRole(strings.TrimSpace(req.Name)),
+		Description: strings.TrimSpace(req.Description),
+		Permissions: permissionStrings(req.Permissions),
+	}
+
+	if model.CanonicalRole(role.Name) != role.Name {
+		return nil, fmt.Errorf("invalid request: role name %s is reserved", role.Name)
+	}
+	if err := role.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	existing, err := s.roleRepo.GetByName(ctx, role.Name)
+	if err == nil && existing != nil {
+		return nil, fmt.Errorf("role with name '%s' already exists", role.Name)
+	}
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return nil, err
+	}
+
+	if err := s.roleRepo.Create(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	s.logActivity(actor, "role_create", role, fmt.Sprintf("Created role %s", role.Name), nil)
+	return role, nil
+}
+
+// UpdateRole changes the description or permissions of a custom role. Users
+// assigned the role get the new permissions on their next request.
+func (s *RoleService) UpdateRole(ctx context.Context, actor model.Actor, id int, req *dto.UpdateRoleRequest) (*model.Role, error) {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if role.BuiltIn {
+		return nil, fmt.Errorf("invalid request: built-in role %s cannot be changed", role.Name)
+	}
+
+	previous := append(model.StringList(nil), role.Permissions...)
+	if req.Description != nil {
+		role.Description = strings.TrimSpace(*req.Description)
+	}
+	if req.Permissions != nil {
+		role.Permissions = permissionStrings(req.Permissions)
+	}
+	if err := role.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	if err := s.roleRepo.Update(ctx, role); err != nil {
+		return nil, err
+	}
+	s.invalidate()
+
+	s.logActivity(actor, "role_update", role, fmt.Sprintf("Updated role %s", role.Name), map[string]interface{}{
+		"previous_permissions": previous,
+	})
+	return role, nil
+}
+
+// DeleteRole removes a custom role no user is assigned
+func (s *RoleService) DeleteRole(ctx context.Context, actor model.Actor, id int) error {
+	role, err := s.roleRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if role.BuiltIn {
+		return fmt.Errorf("invalid request: built-in role %s cannot be deleted", role.Name)
+	}
+
+	counts, err := s.roleRepo.CountUsers(ctx)
+	if err != nil {
+		return err
+	}
+	if assigned := counts[role.Name]; assigned > 0 {
+		return fmt.Errorf("role %s still has %d users assigned; assign them another role first", role.Name, assigned)
+	}
+
+	if err := s.roleRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate()
+
+	s.logActivity(actor, "role_delete", role, fmt.Sprintf("Deleted role %s", role.Name), nil)
+	return nil
+}
+
+// GetPermissionMatrix lists every permission with the roles granting it
+func (s *RoleService) GetPermissionMatrix(ctx context.Context) (*dto.PermissionMatrix, error) {
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix := &dto.PermissionMatrix{
+		Roles:       make([]model.UserRole, len(roles)),
+		Permissions: make([]dto.PermissionMatrixRow, len(model.Permissions)),
+	}
+	for i, role := range roles {
+		matrix.Roles[i] = role.Name
+	}
+	for i, info := range model.Permissions {
+		row := dto.PermissionMatrixRow{
+			Permission:  info.Permission,
+			Description: info.Description,
+			GrantedTo:   []model.UserRole{},
+		}
+		for _, role := range roles {
+			if role.Grants(info.Permission) {
+				row.GrantedTo = append(row.GrantedTo, role.Name)
+			}
+		}
+		matrix.Permissions[i] = row
+	}
+	return matrix, nil
+}
+
+// RolePermissions returns the permissions a role grants, nothing for an
+// unknown role. It implements middleware.RoleResolver.
+func (s *RoleService) RolePermissions(ctx context.Context, role model.UserRole) ([]model.Permission, error) {
+	if permissions, ok := model.BuiltinRolePermissions(model.CanonicalRole(role)); ok {
+		return permissions, nil
+	}
+
+	s.mu.RLock()
+	if s.roles != nil && time.Since(s.loadedAt) < rolePermissionsTTL {
+		permissions := s.roles[role]
+		s.mu.RUnlock()
+		return permissions, nil
+	}
+	s.mu.RUnlock()
+
+	roles, err := s.roleRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	resolved := make(map[model.UserRole][]model.Permission, len(roles))
+	for _, r := range roles {
+		resolved[r.Name] = r.PermissionList()
+	}
+
+	s.mu.Lock()
+	s.roles = resolved
+	s.loadedAt = time.Now()
+	s.mu.Unlock()
+
+	return resolved[role], nil
+}
+
+// roleExists reports whether role is a built-in role or a stored custom role
+func roleExists(ctx context.Context, roleRepo repository.RoleRepository, role model.UserRole) (bool, error) {
+	if model.IsBuiltinRole(role) {
+		return true, nil
+	}
+	if roleRepo == nil {
+		return false, nil
+	}
+	_, err := roleRepo.GetByName(ctx, role)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// invalidate drops the resolved role permissions after a change
+func (s *RoleService) invalidate() {
+	s.mu.Lock()
+	s.roles = nil
+	s.mu.Unlock()
+}
+
+// logActivity audits a change to a role
+func (s *RoleService) logActivity(actor model.Actor, action string, role *model.Role, description string, metadata map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	if metadata == nil {
+		metadata = make(map[string]interface{})
+	}
+	metadata["permissions"] = role.Permissions
+	metadataJSON, _ := json.Marshal(metadata)
+
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "role",
+		ResourceID:   &role.ID,
+		ResourceName: string(role.Name),
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("role_id", role.ID).Warn("Failed to log role activity")
+	}
+}
+
+// permissionStrings converts permissions for storage on a role
+func permissionStrings(permissions []model.Permission) model.StringList {
+	list := make(model.StringList, len(permissions))
+	for i, p := range permissions {
+		list[i] = string(p)
+	}
+	return list
+}
@@ -3,14 +3,17 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"docker-auto/internal/config"
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 	"docker-auto/internal/repository"
 	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/events"
 	"docker-auto/pkg/registry"
 	"docker-auto/pkg/scheduler"
 	// "docker-auto/pkg/scheduler/tasks" // Temporarily commented to fix import cycle
@@ -18,23 +21,41 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+// maxTaskUpdateAttempts bounds how often a task write that raced a concurrent
+// one is retried
+const maxTaskUpdateAttempts = 3
+
 // SchedulerService manages scheduled tasks and their execution
 type SchedulerService struct {
 	// Dependencies
-	taskRepo            repository.ScheduledTaskRepository
-	executionLogRepo    repository.TaskExecutionLogRepository
-	containerRepo       repository.ContainerRepository
-	updateHistoryRepo   repository.UpdateHistoryRepository
-	activityLogRepo     repository.ActivityLogRepository
-	imageVersionRepo    repository.ImageVersionRepository
-	notificationRepo    repository.NotificationRepository
-	containerService    *ContainerService
-	imageService        *ImageService
-	notificationService *NotificationService
-	userService         *UserService
-	dockerClient        *docker.DockerClient
-	registryChecker     *registry.Checker
-	config              *config.Config
+	taskRepo              repository.ScheduledTaskRepository
+	executionLogRepo      repository.TaskExecutionLogRepository
+	containerRepo         repository.ContainerRepository
+	updateHistoryRepo     repository.UpdateHistoryRepository
+	activityLogRepo       repository.ActivityLogRepository
+	imageVersionRepo      repository.ImageVersionRepository
+	notificationRepo      repository.NotificationRepository
+	scanResultRepo        repository.ScanResultRepository
+	metricRepo            repository.ContainerMetricRepository
+	healthStateRepo       repository.ContainerHealthStateRepository
+	healthCheckRepo       repository.ContainerHealthCheckRepository
+	eventRepo             repository.SchedulerEventRepository
+	containerService      *ContainerService
+	imageService          *ImageService
+	notificationService   *NotificationService
+	channelService        *NotificationChannelService
+	changeFeedService     *ChangeFeedService
+	webhookService        *WebhookService
+	featureService        *FeatureService
+	volumeService         *VolumeService
+	approvalPolicyService *ApprovalPolicyService
+	userService           *UserService
+	postureService        *SecurityPostureService
+	gitopsService         *GitOpsService
+	dockerClient          *docker.DockerClient
+	registryChecker       *registry.Checker
+	publisher             events.Publisher
+	config                *config.Config
 
 	// Scheduler components
 	scheduler      scheduler.Scheduler
@@ -56,29 +77,57 @@ func NewSchedulerService(
 	activityLogRepo repository.ActivityLogRepository,
 	imageVersionRepo repository.ImageVersionRepository,
 	notificationRepo repository.NotificationRepository,
+	scanResultRepo repository.ScanResultRepository,
+	metricRepo repository.ContainerMetricRepository,
+	healthStateRepo repository.ContainerHealthStateRepository,
+	healthCheckRepo repository.ContainerHealthCheckRepository,
+	eventRepo repository.SchedulerEventRepository,
 	containerService *ContainerService,
 	imageService *ImageService,
 	notificationService *NotificationService,
+	channelService *NotificationChannelService,
+	changeFeedService *ChangeFeedService,
+	webhookService *WebhookService,
+	featureService *FeatureService,
+	volumeService *VolumeService,
+	approvalPolicyService *ApprovalPolicyService,
 	userService *UserService,
+	postureService *SecurityPostureService,
+	gitopsService *GitOpsService,
 	dockerClient *docker.DockerClient,
 	registryChecker *registry.Checker,
+	publisher events.Publisher,
 	config *config.Config,
 ) *SchedulerService {
 	service := &SchedulerService{
-		taskRepo:            taskRepo,
-		executionLogRepo:    executionLogRepo,
-		containerRepo:       containerRepo,
-		updateHistoryRepo:   updateHistoryRepo,
-		activityLogRepo:     activityLogRepo,
-		imageVersionRepo:    imageVersionRepo,
-		notificationRepo:    notificationRepo,
-		containerService:    containerService,
-		imageService:        imageService,
-		notificationService: notificationService,
-		userService:         userService,
-		dockerClient:        dockerClient,
-		registryChecker:     registryChecker,
-		config:              config,
+		taskRepo:              taskRepo,
+		executionLogRepo:      executionLogRepo,
+		containerRepo:         containerRepo,
+		updateHistoryRepo:     updateHistoryRepo,
+		activityLogRepo:       activityLogRepo,
+		imageVersionRepo:      imageVersionRepo,
+		notificationRepo:      notificationRepo,
+		scanResultRepo:        scanResultRepo,
+		metricRepo:            metricRepo,
+		healthStateRepo:       healthStateRepo,
+		healthCheckRepo:       healthCheckRepo,
+		eventRepo:             eventRepo,
+		containerService:      containerService,
+		imageService:          imageService,
+		notificationService:   notificationService,
+		channelService:        channelService,
+		changeFeedService:     changeFeedService,
+		webhookService:        webhookService,
+		featureService:        featureService,
+		volumeService:         volumeService,
+		approvalPolicyService: approvalPolicyService,
+		userService:           userService,
+		postureService:        postureService,
+		gitopsService:         gitopsService,
+		dockerClient:          dockerClient,
+		registryChecker:       registryChecker,
+		publisher:             publisher,
+		config:                config,
 	}
 
 	// Initialize scheduler components
@@ -92,6 +141,8 @@ func NewSchedulerService(
 		TaskTimeout:        30 * time.Minute,
 		RetryDelay:         5 * time.Minute,
 		MaxRetries:         3,
+		RetryBackoff:       2,
+		RetryMaxDelay:      1 * time.Hour,
 		CleanupInterval:    1 * time.Hour,
 		HistoryRetention:   24 * time.Hour,
 		LogLevel:           "info",
@@ -100,7 +151,7 @@ func NewSchedulerService(
 	}
 
 	// Override with config values if available
-	if config != nil && config.Scheduler != nil {
+	if config != nil {
 		if config.Scheduler.MaxConcurrentTasks > 0 {
 			schedulerConfig.MaxConcurrentTasks = config.Scheduler.MaxConcurrentTasks
 		}
@@ -113,6 +164,12 @@ func NewSchedulerService(
 		if config.Scheduler.MaxRetries > 0 {
 			schedulerConfig.MaxRetries = config.Scheduler.MaxRetries
 		}
+		if config.Scheduler.RetryBackoff > 0 {
+			schedulerConfig.RetryBackoff = config.Scheduler.RetryBackoff
+		}
+		if config.Scheduler.RetryMaxDelay > 0 {
+			schedulerConfig.RetryMaxDelay = config.Scheduler.RetryMaxDelay
+		}
 		if config.Scheduler.CleanupInterval > 0 {
 			schedulerConfig.CleanupInterval = config.Scheduler.CleanupInterval
 		}
@@ -153,7 +210,7 @@ func (s *SchedulerService) Start(ctx context.Context) error {
 	defer s.mu.Unlock()
 
 	if s.isRunning {
-		return fmt.Errorf("scheduler service is already running")
+		return apperrors.New(apperrors.CodeSchedulerRunning, "scheduler service is already running")
 	}
 
 	// Start the scheduler
@@ -173,7 +230,7 @@ func (s *SchedulerService) Stop(ctx context.Context) error {
 	defer s.mu.Unlock()
 
 	if !s.isRunning {
-		return fmt.Errorf("scheduler service is not running")
+		return apperrors.New(apperrors.CodeSchedulerNotRunning, "scheduler service is not running")
 	}
 
 	// Stop the scheduler
@@ -194,16 +251,31 @@ func (s *SchedulerService) IsRunning() bool {
 	return s.isRunning
 }
 
+// RunningTaskCount returns how many task executions are in progress
+func (s *SchedulerService) RunningTaskCount() int {
+	if !s.IsRunning() {
+		return 0
+	}
+	return len(s.scheduler.GetRunningTasks())
+}
+
+// SetLimits changes how many tasks run at once and the default timeout of
+// the runs started afterwards
+func (s *SchedulerService) SetLimits(maxConcurrentTasks int, taskTimeout time.Duration) {
+	s.scheduler.SetLimits(maxConcurrentTasks, taskTimeout)
+	s.taskExecutor.SetConcurrencyLimit(maxConcurrentTasks)
+}
+
 // Task Management
 
 // CreateTask creates a new scheduled task
 func (s *SchedulerService) CreateTask(ctx context.Context, userID int64, req *CreateTaskRequest) (*model.ScheduledTask, error) {
 	if req == nil {
-		return nil, fmt.Errorf("create task request cannot be nil")
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "create task request cannot be nil")
 	}
 
 	if err := req.Validate(); err != nil {
-		return nil, fmt.Errorf("invalid request: %w", err)
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
 	}
 
 	// Check if task name already exists
@@ -212,23 +284,28 @@ func (s *SchedulerService) CreateTask(ctx context.Context, userID int64, req *Cr
 		Limit: 1,
 	})
 	if err == nil && len(tasks) > 0 {
-		return nil, fmt.Errorf("task with name '%s' already exists", req.Name)
+		return nil, apperrors.Newf(apperrors.CodeTaskExists, "task with name '%s' already exists", req.Name)
 	}
 
 	// Create task model
 	task := &model.ScheduledTask{
 		Name:             req.Name,
 		Type:             req.Type,
+		ScheduleType:     req.ScheduleType,
 		CronExpression:   req.CronExpression,
+		RunAt:            req.RunAt,
 		TargetContainers: s.serializeTargetContainers(req.TargetContainers),
 		Parameters:       s.serializeParameters(req.Parameters),
 		IsActive:         req.IsActive,
 		CreatedBy:        func() *int { u := int(userID); return &u }(),
 	}
+	if task.ScheduleType == "" {
+		task.ScheduleType = model.ScheduleTypeCron
+	}
 
-	// Validate cron expression
-	if err := task.ValidateCronExpression(); err != nil {
-		return nil, fmt.Errorf("invalid cron expression: %w", err)
+	// Validate the schedule
+	if err := task.ValidateSchedule(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
 	}
 
 	// Calculate next run time
@@ -250,9 +327,11 @@ func (s *SchedulerService) CreateTask(ctx context.Context, userID int64, req *Cr
 
 	// Log activity
 	s.logTaskActivity(userID, int64(task.ID), "task_created", "Scheduled task created", map[string]interface{}{
-		"task_name": task.Name,
-		"task_type": task.Type,
-		"cron_expr": task.CronExpression,
+		"task_name":     task.Name,
+		"task_type":     task.Type,
+		"schedule_type": task.ScheduleType,
+		"cron_expr":     task.CronExpression,
+		"run_at":        task.RunAt,
 	})
 
 	logrus.WithFields(logrus.Fields{
@@ -265,6 +344,22 @@ func (s *SchedulerService) CreateTask(ctx context.Context, userID int64, req *Cr
 	return task, nil
 }
 
+// ScheduleTasks adds active tasks created outside the service, such as by a
+// system import, to the running scheduler
+func (s *SchedulerService) ScheduleTasks(tasks []*model.ScheduledTask) {
+	if !s.IsRunning() {
+		return
+	}
+	for _, task := range tasks {
+		if !task.IsActive {
+			continue
+		}
+		if err := s.scheduler.AddTask(task); err != nil {
+			logrus.WithError(err).WithField("task_id", task.ID).Warn("Failed to add task to scheduler")
+		}
+	}
+}
+
 // GetTask retrieves a scheduled task by ID
 func (s *SchedulerService) GetTask(ctx context.Context, userID int64, taskID int64) (*TaskDetail, error) {
 	task, err := s.taskRepo.GetByID(ctx, taskID)
@@ -301,72 +396,79 @@ func (s *SchedulerService) GetTask(ctx context.Context, userID int64, taskID int
 // UpdateTask updates a scheduled task
 func (s *SchedulerService) UpdateTask(ctx context.Context, userID int64, taskID int64, req *UpdateTaskRequest) error {
 	if req == nil {
-		return fmt.Errorf("update task request cannot be nil")
+		return apperrors.New(apperrors.CodeInvalidRequest, "update task request cannot be nil")
 	}
 
-	task, err := s.taskRepo.GetByID(ctx, taskID)
-	if err != nil {
-		return fmt.Errorf("failed to get task: %w", err)
-	}
-
-	// Check permissions
-	if err := s.checkTaskPermission(task, userID); err != nil {
-		return err
-	}
+	var changes map[string]interface{}
+	task, updated, err := s.modifyTask(ctx, taskID, func(task *model.ScheduledTask) (bool, error) {
+		// Check permissions
+		if err := s.checkTaskPermission(task, userID); err != nil {
+			return false, err
+		}
 
-	// Update fields
-	updated := false
-	changes := make(map[string]interface{})
+		// Update fields
+		changes = make(map[string]interface{})
 
-	if req.CronExpression != nil && *req.CronExpression != task.CronExpression {
-		task.CronExpression = *req.CronExpression
-		changes["cron_expression"] = *req.CronExpression
-		updated = true
-	}
+		if req.CronExpression != nil && *req.CronExpression != task.CronExpression {
+			task.CronExpression = *req.CronExpression
+			changes["cron_expression"] = *req.CronExpression
+		}
 
-	if req.TargetContainers != nil {
-		newTargets := s.serializeTargetContainers(*req.TargetContainers)
-		if newTargets != task.TargetContainers {
-			task.TargetContainers = newTargets
-			changes["target_containers"] = *req.TargetContainers
-			updated = true
+		// A new run time schedules a one-shot task again, even a completed one
+		if req.RunAt != nil && (task.RunAt == nil || !req.RunAt.Equal(*task.RunAt)) {
+			if err := model.ValidateRunAt(*req.RunAt, time.Now()); err != nil {
+				return false, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+			}
+			task.RunAt = req.RunAt
+			task.CompletedAt = nil
+			changes["run_at"] = *req.RunAt
 		}
-	}
 
-	if req.Parameters != nil {
-		newParams := s.serializeParameters(*req.Parameters)
-		if newParams != task.Parameters {
-			task.Parameters = newParams
-			changes["parameters"] = *req.Parameters
-			updated = true
+		if req.TargetContainers != nil {
+			newTargets := s.serializeTargetContainers(*req.TargetContainers)
+			if newTargets != task.TargetContainers {
+				task.TargetContainers = newTargets
+				changes["target_containers"] = *req.TargetContainers
+			}
 		}
-	}
 
-	if req.IsActive != nil && *req.IsActive != task.IsActive {
-		task.IsActive = *req.IsActive
-		changes["is_active"] = *req.IsActive
-		updated = true
-	}
+		if req.Parameters != nil {
+			newParams := s.serializeParameters(*req.Parameters)
+			if newParams != task.Parameters {
+				task.Parameters = newParams
+				changes["parameters"] = *req.Parameters
+			}
+		}
 
-	if !updated {
-		return nil // No changes made
-	}
+		if req.IsActive != nil && *req.IsActive != task.IsActive {
+			task.IsActive = *req.IsActive
+			changes["is_active"] = *req.IsActive
+		}
 
-	// Validate cron expression if changed
-	if _, exists := changes["cron_expression"]; exists {
-		if err := task.ValidateCronExpression(); err != nil {
-			return fmt.Errorf("invalid cron expression: %w", err)
+		if len(changes) == 0 {
+			return false, nil // No changes made
+		}
+		if task.IsActive && task.IsOneShot() && task.CompletedAt != nil {
+			return false, errOneShotCompleted
 		}
-	}
 
-	// Recalculate next run if needed
-	if err := task.CalculateNextRun(); err != nil {
-		return fmt.Errorf("failed to calculate next run: %w", err)
-	}
+		// Validate the schedule if changed
+		_, cronChanged := changes["cron_expression"]
+		_, runAtChanged := changes["run_at"]
+		if cronChanged || runAtChanged {
+			if err := task.ValidateSchedule(); err != nil {
+				return false, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+			}
+		}
 
-	// Update in database
-	if err := s.taskRepo.Update(ctx, task); err != nil {
-		return fmt.Errorf("failed to update task: %w", err)
+		// Recalculate next run if needed
+		if err := task.CalculateNextRun(); err != nil {
+			return false, fmt.Errorf("failed to calculate next run: %w", err)
+		}
+		return true, nil
+	})
+	if err != nil || !updated {
+		return err
 	}
 
 	// Update in scheduler
@@ -389,6 +491,56 @@ func (s *SchedulerService) UpdateTask(ctx context.Context, userID int64, taskID
 	return nil
 }
 
+// modifyTask reads a task, applies mutate and writes the task back, starting
+// over from a fresh read when the task was written concurrently in between.
+// mutate reports whether it changed the task; unchanged tasks are not written.
+func (s *SchedulerService) modifyTask(ctx context.Context, taskID int64, mutate func(task *model.ScheduledTask) (bool, error)) (*model.ScheduledTask, bool, error) {
+	for attempt := 1; ; attempt++ {
+		task, err := s.taskRepo.GetByID(ctx, taskID)
+		if err != nil {
+			return nil, false, fmt.Errorf("failed to get task: %w", err)
+		}
+
+		changed, err := mutate(task)
+		if err != nil || !changed {
+			return task, false, err
+		}
+
+		err = s.taskRepo.Update(ctx, task)
+		if err == nil {
+			return task, true, nil
+		}
+		if !errors.Is(err, model.ErrTaskVersionConflict) || attempt >= maxTaskUpdateAttempts {
+			return nil, false, fmt.Errorf("failed to update task: %w", err)
+		}
+
+		logrus.WithFields(logrus.Fields{
+			"task_id": taskID,
+			"attempt": attempt,
+		}).Debug("Scheduled task changed concurrently, retrying update")
+	}
+}
+
+// errSchedulerNotRunning is returned for operations that need the scheduler
+var errSchedulerNotRunning = apperrors.New(apperrors.CodeSchedulerNotRunning, "scheduler is not running")
+
+// errOneShotCompleted rejects activating a one-shot task that already ran
+var errOneShotCompleted = apperrors.New(apperrors.CodeInvalidRequest, "invalid request: a completed one-shot task needs a new run_at to be activated")
+
+// setTaskActive returns a modifyTask mutation activating or pausing a task
+func setTaskActive(active bool) func(task *model.ScheduledTask) (bool, error) {
+	return func(task *model.ScheduledTask) (bool, error) {
+		if task.IsActive == active {
+			return false, nil
+		}
+		if active && task.IsOneShot() && task.CompletedAt != nil {
+			return false, errOneShotCompleted
+		}
+		task.IsActive = active
+		return true, nil
+	}
+}
+
 // DeleteTask deletes a scheduled task
 func (s *SchedulerService) DeleteTask(ctx context.Context, userID int64, taskID int64) error {
 	task, err := s.taskRepo.GetByID(ctx, taskID)
@@ -469,7 +621,10 @@ func (s *SchedulerService) ListTasks(ctx context.Context, userID int64, filter *
 			ID:             int64(task.ID),
 			Name:           task.Name,
 			Type:           task.Type,
+			ScheduleType:   task.ScheduleType,
 			CronExpression: task.CronExpression,
+			RunAt:          task.RunAt,
+			CompletedAt:    task.CompletedAt,
 			IsActive:       task.IsActive,
 			LastRunAt:      task.LastRunAt,
 			NextRunAt:      task.NextRunAt,
@@ -528,8 +683,7 @@ func (s *SchedulerService) PauseTask(ctx context.Context, userID int64, taskID i
 	}
 
 	// Update database
-	task.IsActive = false
-	if err := s.taskRepo.Update(ctx, task); err != nil {
+	if _, _, err := s.modifyTask(ctx, taskID, setTaskActive(false)); err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
 
@@ -565,8 +719,7 @@ func (s *SchedulerService) ResumeTask(ctx context.Context, userID int64, taskID
 	}
 
 	// Update database
-	task.IsActive = true
-	if err := s.taskRepo.Update(ctx, task); err != nil {
+	if _, _, err := s.modifyTask(ctx, taskID, setTaskActive(true)); err != nil {
 		return fmt.Errorf("failed to update task status: %w", err)
 	}
 
@@ -596,7 +749,7 @@ func (s *SchedulerService) TriggerTask(ctx context.Context, userID int64, taskID
 
 	// Trigger in scheduler
 	if !s.isRunning {
-		return fmt.Errorf("scheduler is not running")
+		return errSchedulerNotRunning
 	}
 
 	if err := s.scheduler.TriggerTask(int(task.ID)); err != nil {
@@ -615,6 +768,43 @@ func (s *SchedulerService) TriggerTask(ctx context.Context, userID int64, taskID
 	return nil
 }
 
+// CancelExecution cancels a running execution of a task, e.g. one started by
+// TriggerTask. The task stops at its next check and the run is recorded as
+// cancelled with what it completed.
+func (s *SchedulerService) CancelExecution(ctx context.Context, userID int64, taskID int64, executionID string) error {
+	task, err := s.taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		return fmt.Errorf("failed to get task: %w", err)
+	}
+
+	// Check permissions
+	if err := s.checkTaskPermission(task, userID); err != nil {
+		return err
+	}
+
+	if !s.isRunning {
+		return errSchedulerNotRunning
+	}
+
+	if err := s.scheduler.CancelExecution(int(task.ID), executionID); err != nil {
+		return err
+	}
+
+	// Log activity
+	s.logTaskActivity(userID, int64(task.ID), "task_execution_cancelled", "Scheduled task execution cancelled", map[string]interface{}{
+		"execution_id": executionID,
+	})
+
+	logrus.WithFields(logrus.Fields{
+		"task_id":      task.ID,
+		"task_name":    task.Name,
+		"execution_id": executionID,
+		"user_id":      userID,
+	}).Info("Scheduled task execution cancelled")
+
+	return nil
+}
+
 // Monitoring and Status
 
 // GetSchedulerStatus returns the current scheduler status
@@ -629,13 +819,7 @@ func (s *SchedulerService) GetSchedulerStatus(ctx context.Context) (*SchedulerSt
 		runningTasks := s.scheduler.GetRunningTasks()
 		status.RunningTasks = len(runningTasks)
 
-		// Get metrics if available - this would need to be implemented in the scheduler interface
-		// For now, we'll leave it empty
-		status.Metrics = &scheduler.SchedulerMetrics{
-			TotalTasks:   status.TotalTasks,
-			ActiveTasks:  status.ActiveTasks,
-			RunningTasks: status.RunningTasks,
-		}
+		status.Metrics = s.scheduler.GetMetrics()
 
 		// Get task counts
 		activeTasks, err := s.taskRepo.GetActiveTasks(ctx)
@@ -649,6 +833,15 @@ func (s *SchedulerService) GetSchedulerStatus(ctx context.Context) (*SchedulerSt
 		}
 	}
 
+	if s.eventRepo != nil {
+		recent, _, err := s.eventRepo.List(ctx, &model.SchedulerEventFilter{Limit: schedulerStatusEventCount})
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to get recent scheduler events")
+		} else {
+			status.RecentEvents = recent
+		}
+	}
+
 	return status, nil
 }
 
@@ -683,6 +876,7 @@ func (s *SchedulerService) GetTaskExecutions(ctx context.Context, userID int64,
 		Limit:  filter.Limit,
 		Offset: filter.Offset,
 		OrderBy: "started_at DESC",
+		Cursor: filter.Cursor,
 	}
 
 	if !filter.StartedAfter.IsZero() {
@@ -698,6 +892,20 @@ func (s *SchedulerService) GetTaskExecutions(ctx context.Context, userID int64,
 		return nil, fmt.Errorf("failed to list executions: %w", err)
 	}
 
+	if filter.Cursor != nil {
+		response := &ExecutionListResponse{
+			Executions: executions,
+			Limit:      filter.Limit,
+			HasPrev:    !filter.Cursor.IsZero(),
+		}
+		if n := len(executions); n > 0 {
+			last := executions[n-1]
+			response.NextCursor = model.NextCursor(n, filter.Limit, last.StartedAt, int64(last.ID))
+		}
+		response.HasNext = response.NextCursor != ""
+		return response, nil
+	}
+
 	// Calculate pagination
 	page := (filter.Offset / filter.Limit) + 1
 	hasNext := filter.Offset+filter.Limit < int(total)
@@ -728,6 +936,7 @@ func (s *SchedulerService) registerTaskTypes() {
 			s.containerService,
 			s.imageService,
 			s.notificationService,
+			s.approvalPolicyService,
 		)
 	})
 
@@ -740,6 +949,7 @@ func (s *SchedulerService) registerTaskTypes() {
 			s.imageService,
 			s.notificationService,
 			s.dockerClient,
+			s.publisher,
 		)
 	})
 
@@ -752,8 +962,11 @@ func (s *SchedulerService) registerTaskTypes() {
 			s.activityLogRepo,
 			s.imageVersionRepo,
 			s.notificationRepo,
+			s.scanResultRepo,
+			s.metricRepo,
 			s.containerService,
 			s.notificationService,
+			s.changeFeedService,
 			s.dockerClient,
 		)
 	})
@@ -762,8 +975,12 @@ func (s *SchedulerService) registerTaskTypes() {
 	s.taskRegistry.RegisterTask(model.TaskTypeHealthCheck, func() scheduler.Task {
 		return tasks.NewHealthCheckerTask(
 			s.containerRepo,
+			s.healthStateRepo,
+			s.healthCheckRepo,
 			s.containerService,
 			s.notificationService,
+			s.webhookService,
+			s.featureService,
 			s.dockerClient,
 		)
 	})
@@ -777,9 +994,46 @@ func (s *SchedulerService) registerTaskTypes() {
 			s.containerService,
 			s.notificationService,
 			s.dockerClient,
+			nil,
+			s.config,
 		)
 	})
 
+	// Register change feed delivery task
+	s.taskRegistry.RegisterTask(model.TaskTypeChangeFeed, func() scheduler.Task {
+		return tasks.NewChangeFeedTask(s.changeFeedService)
+	})
+
+	// Register volume usage sampling task
+	s.taskRegistry.RegisterTask(model.TaskTypeVolumeUsage, func() scheduler.Task {
+		return tasks.NewVolumeUsageTask(s.volumeService)
+	})
+
+	// Register container status sync task
+	s.taskRegistry.RegisterTask(model.TaskTypeStatusSync, func() scheduler.Task {
+		return tasks.NewStatusSyncTask(s.containerService)
+	})
+
+	// Register security posture evaluation task
+	s.taskRegistry.RegisterTask(model.TaskTypeSecurityPosture, func() scheduler.Task {
+		return tasks.NewSecurityPostureTask(s.postureService)
+	})
+
+	// Register GitOps reconcile task
+	s.taskRegistry.RegisterTask(model.TaskTypeGitOps, func() scheduler.Task {
+		return tasks.NewGitOpsTask(s.gitopsService)
+	})
+
+	// Register notification digest task
+	s.taskRegistry.RegisterTask(model.TaskTypeNotificationDigest, func() scheduler.Task {
+		return tasks.NewNotificationDigestTask(s.channelService)
+	})
+
+	// Register container discovery task
+	s.taskRegistry.RegisterTask(model.TaskTypeContainerDiscovery, func() scheduler.Task {
+		return tasks.NewContainerDiscoveryTask(s.containerService)
+	})
+
 	logrus.Info("Registered all task types")
 }
 */
@@ -794,7 +1048,7 @@ func (s *SchedulerService) checkTaskPermission(task *model.ScheduledTask, userID
 
 	// Users can only access their own tasks
 	if task.CreatedBy == nil || int64(*task.CreatedBy) != userID {
-		return fmt.Errorf("access denied: user %d cannot access task %d", userID, task.ID)
+		return apperrors.Newf(apperrors.CodePermissionDenied, "access denied: user %d cannot access task %d", userID, task.ID)
 	}
 
 	return nil
@@ -815,6 +1069,7 @@ func (s *SchedulerService) logTaskActivity(userID, taskID int64, action, descrip
 
 	log := &model.ActivityLog{
 		UserID:       &userID,
+		ActorType:    model.ActorTypeUser,
 		Action:       action,
 		ResourceType: "scheduled_task",
 		ResourceID:   func() *int { r := int(taskID); return &r }(),
@@ -863,7 +1118,9 @@ func (s *SchedulerService) serializeParameters(params map[string]interface{}) st
 type CreateTaskRequest struct {
 	Name             string                 `json:"name" binding:"required"`
 	Type             model.TaskType         `json:"type" binding:"required"`
-	CronExpression   string                 `json:"cron_expression" binding:"required"`
+	ScheduleType     model.ScheduleType     `json:"schedule_type,omitempty"` // cron (default) or once
+	CronExpression   string                 `json:"cron_expression,omitempty"`
+	RunAt            *time.Time             `json:"run_at,omitempty"` // Run time of a one-shot task
 	TargetContainers []int64                `json:"target_containers,omitempty"`
 	Parameters       map[string]interface{} `json:"parameters,omitempty"`
 	IsActive         bool                   `json:"is_active"`
@@ -874,16 +1131,26 @@ func (r *CreateTaskRequest) Validate() error {
 	if r.Name == "" {
 		return fmt.Errorf("task name is required")
 	}
-	if r.CronExpression == "" {
-		return fmt.Errorf("cron expression is required")
+	switch r.ScheduleType {
+	case model.ScheduleTypeCron, "":
+		if r.CronExpression == "" {
+			return fmt.Errorf("cron expression is required")
+		}
+	case model.ScheduleTypeOnce:
+		if r.RunAt == nil {
+			return fmt.Errorf("run_at is required for one-shot tasks")
+		}
+		return model.ValidateRunAt(*r.RunAt, time.Now())
+	default:
+		return fmt.Errorf("unknown schedule type %q", r.ScheduleType)
 	}
-	// Additional validation can be added here
 	return nil
 }
 
 // UpdateTaskRequest represents a request to update a scheduled task
 type UpdateTaskRequest struct {
 	CronExpression   *string                 `json:"cron_expression,omitempty"`
+	RunAt            *time.Time              `json:"run_at,omitempty"` // One-shot tasks only
 	TargetContainers *[]int64                `json:"target_containers,omitempty"`
 	Parameters       *map[string]interface{} `json:"parameters,omitempty"`
 	IsActive         *bool                   `json:"is_active,omitempty"`
@@ -905,6 +1172,9 @@ type ExecutionFilter struct {
 	StartedBefore time.Time             `json:"started_before,omitempty"`
 	Limit         int                   `json:"limit,omitempty"`
 	Offset        int                   `json:"offset,omitempty"`
+
+	// Cursor selects keyset pagination instead of Offset
+	Cursor *model.Cursor `json:"-"`
 }
 
 // Response types
@@ -921,7 +1191,10 @@ type TaskSummary struct {
 	ID             int64              `json:"id"`
 	Name           string             `json:"name"`
 	Type           model.TaskType     `json:"type"`
-	CronExpression string             `json:"cron_expression"`
+	ScheduleType   model.ScheduleType `json:"schedule_type"`
+	CronExpression string             `json:"cron_expression,omitempty"`
+	RunAt          *time.Time         `json:"run_at,omitempty"`
+	CompletedAt    *time.Time         `json:"completed_at,omitempty"` // Set once a one-shot task has run
 	IsActive       bool               `json:"is_active"`
 	IsRunning      bool               `json:"is_running"`
 	IsPaused       bool               `json:"is_paused"`
@@ -952,6 +1225,7 @@ type ExecutionListResponse struct {
 	Limit      int                       `json:"limit"`
 	HasNext    bool                      `json:"has_next"`
 	HasPrev    bool                      `json:"has_prev"`
+	NextCursor string                    `json:"next_cursor,omitempty"`
 }
 
 // SchedulerStatus represents the current status of the scheduler
@@ -961,5 +1235,6 @@ type SchedulerStatus struct {
 	ActiveTasks  int                       `json:"active_tasks"`
 	RunningTasks int                       `json:"running_tasks"`
 	Metrics      *scheduler.SchedulerMetrics `json:"metrics,omitempty"`
+	RecentEvents []*model.SchedulerEventLog `json:"recent_events,omitempty"`
 	Timestamp    time.Time                 `json:"timestamp"`
 }
\ No newline at end of file
@@ -3,17 +3,25 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"strconv"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/events"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/scheduler"
 
 	"github.com/sirupsen/logrus"
 )
 
-// SchedulerEventListener handles scheduler events
+// schedulerEventPruneInterval is how many events are recorded between
+// prunes of the event log
+const schedulerEventPruneInterval = 100
+
+// SchedulerEventListener handles scheduler events. The scheduler calls it from
+// a single goroutine.
 type SchedulerEventListener struct {
 	schedulerService *SchedulerService
+	sincePrune       int
 }
 
 // NewSchedulerEventListener creates a new event listener
@@ -34,6 +42,9 @@ func (l *SchedulerEventListener) OnEvent(event scheduler.SchedulerEvent) {
 		logger = logger.WithField("task_id", *event.TaskID)
 	}
 
+	l.record(event)
+	l.forward(event)
+
 	switch event.Type {
 	case scheduler.EventSchedulerStarted:
 		logger.Info("Scheduler started")
@@ -67,20 +78,87 @@ func (l *SchedulerEventListener) OnEvent(event scheduler.SchedulerEvent) {
 		logger.Info("Task execution cancelled")
 		l.logActivity("task_execution_cancelled", "Task execution cancelled", event.Data)
 
+	case scheduler.EventTaskMissed:
+		logger.Warn("Task missed its scheduled run")
+		l.logActivity("task_execution_missed", "Task missed its scheduled run", event.Data)
+
+	case scheduler.EventTaskAdded, scheduler.EventTaskRemoved, scheduler.EventTaskUpdated,
+		scheduler.EventTaskPaused, scheduler.EventTaskResumed, scheduler.EventTaskScheduled:
+		logger.Debug(event.Message)
+
 	default:
 		logger.Debug("Received unknown scheduler event")
 	}
 }
 
+// record stores the event in the event log, pruning the log to the configured
+// retention every schedulerEventPruneInterval events
+func (l *SchedulerEventListener) record(event scheduler.SchedulerEvent) {
+	repo := l.schedulerService.eventRepo
+	if repo == nil {
+		return
+	}
+
+	entry := &model.SchedulerEventLog{
+		Type:      string(event.Type),
+		TaskID:    event.TaskID,
+		Message:   event.Message,
+		Data:      model.JSONMap(event.Data),
+		CreatedAt: event.Timestamp,
+	}
+	if err := repo.Create(context.Background(), entry); err != nil {
+		logrus.WithError(err).WithField("event_type", event.Type).Warn("Failed to record scheduler event")
+		return
+	}
+
+	l.sincePrune++
+	if l.sincePrune < schedulerEventPruneInterval {
+		return
+	}
+	l.sincePrune = 0
+
+	if _, err := repo.Prune(context.Background(), l.schedulerService.eventRetention()); err != nil {
+		logrus.WithError(err).Warn("Failed to prune scheduler events")
+	}
+}
+
+// forward publishes the event for WebSocket clients subscribed to the
+// scheduler topic
+func (l *SchedulerEventListener) forward(event scheduler.SchedulerEvent) {
+	publisher := l.schedulerService.publisher
+	if publisher == nil {
+		return
+	}
+
+	severity := events.SeverityInfo
+	switch event.Type {
+	case scheduler.EventTaskFailed, scheduler.EventTaskTimeout:
+		severity = events.SeverityError
+	case scheduler.EventTaskMissed, scheduler.EventTaskRetried:
+		severity = events.SeverityWarning
+	case scheduler.EventTaskCompleted:
+		severity = events.SeveritySuccess
+	}
+
+	published := events.NewEvent(events.EventSchedulerLog, severity, "scheduler", string(event.Type), event.Message).
+		WithData("scheduler_event", string(event.Type))
+	if event.TaskID != nil {
+		published.WithResource("scheduled_task", strconv.Itoa(*event.TaskID)).
+			WithData("task_id", *event.TaskID)
+	}
+	for k, v := range event.Data {
+		published.WithData(k, v)
+	}
+
+	publisher.PublishAsync(published)
+}
+
 // logActivity logs scheduler activity to the activity log
 func (l *SchedulerEventListener) logActivity(action, description string, data map[string]interface{}) {
 	if l.schedulerService.activityLogRepo == nil {
 		return
 	}
 
-	// Use system user ID (0) for scheduler activities
-	userID := int64(0)
-
 	metadataJSON := "{}"
 	if data != nil {
 		if jsonData, err := json.Marshal(data); err == nil {
@@ -89,12 +167,12 @@ func (l *SchedulerEventListener) logActivity(action, description string, data ma
 	}
 
 	log := &model.ActivityLog{
-		UserID:       &userID,
 		Action:       action,
 		ResourceType: "scheduler",
 		Description:  description,
 		Metadata:     metadataJSON,
 	}
+	log.SetActor(model.SystemActor(model.ActorComponentScheduler))
 
 	if err := l.schedulerService.activityLogRepo.Create(context.Background(), log); err != nil {
 		logrus.WithError(err).Warn("Failed to log scheduler activity")
@@ -170,8 +248,17 @@ type TaskMetrics struct {
 	TotalExecutions      int64
 	SuccessfulExecutions int64
 	FailedExecutions     int64
+	PartialExecutions    int64
+	CancelledExecutions  int64
 	TotalDuration        time.Duration
 	LastExecution        time.Time
+
+	// Items completed and deferred, as reported by tasks working through a
+	// list, in total and for the last execution
+	ItemsCompleted     int64
+	ItemsDeferred      int64
+	LastItemsCompleted int64
+	LastItemsDeferred  int64
 }
 
 // NewMetricsHook creates a new metrics hook
@@ -199,12 +286,26 @@ func (h *MetricsHook) AfterExecution(ctx context.Context, execution *scheduler.T
 	metrics.TotalDuration += result.Duration
 	metrics.LastExecution = time.Now()
 
-	if result.Success {
+	switch {
+	case result.Cancelled:
+		metrics.CancelledExecutions++
+	case result.Partial:
+		metrics.PartialExecutions++
+	case result.Success:
 		metrics.SuccessfulExecutions++
-	} else {
+	default:
 		metrics.FailedExecutions++
 	}
 
+	if completed, ok := result.Data[scheduler.ResultDataCompleted].(int); ok {
+		metrics.ItemsCompleted += int64(completed)
+		metrics.LastItemsCompleted = int64(completed)
+	}
+	if deferred, ok := result.Data[scheduler.ResultDataDeferred].(int); ok {
+		metrics.ItemsDeferred += int64(deferred)
+		metrics.LastItemsDeferred = int64(deferred)
+	}
+
 	// Log metrics periodically (simplified)
 	if metrics.TotalExecutions%10 == 0 {
 		logrus.WithFields(logrus.Fields{
@@ -212,6 +313,8 @@ func (h *MetricsHook) AfterExecution(ctx context.Context, execution *scheduler.T
 			"total_executions":      metrics.TotalExecutions,
 			"successful_executions": metrics.SuccessfulExecutions,
 			"failed_executions":     metrics.FailedExecutions,
+			"partial_executions":    metrics.PartialExecutions,
+			"items_deferred":        metrics.ItemsDeferred,
 			"average_duration":      metrics.TotalDuration / time.Duration(metrics.TotalExecutions),
 		}).Info("Task metrics update")
 	}
@@ -238,8 +341,14 @@ func (h *MetricsHook) GetMetrics() map[string]*TaskMetrics {
 			TotalExecutions:      metrics.TotalExecutions,
 			SuccessfulExecutions: metrics.SuccessfulExecutions,
 			FailedExecutions:     metrics.FailedExecutions,
+			PartialExecutions:    metrics.PartialExecutions,
+			CancelledExecutions:  metrics.CancelledExecutions,
 			TotalDuration:        metrics.TotalDuration,
 			LastExecution:        metrics.LastExecution,
+			ItemsCompleted:       metrics.ItemsCompleted,
+			ItemsDeferred:        metrics.ItemsDeferred,
+			LastItemsCompleted:   metrics.LastItemsCompleted,
+			LastItemsDeferred:    metrics.LastItemsDeferred,
 		}
 	}
 	return result
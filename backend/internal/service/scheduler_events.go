@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+)
+
+const (
+	// schedulerStatusEventCount is the number of recent events included in
+	// the scheduler status
+	schedulerStatusEventCount = 20
+
+	// defaultSchedulerEventRetention is used when SCHEDULER_EVENT_RETENTION
+	// is unset
+	defaultSchedulerEventRetention = 10000
+)
+
+// SchedulerEventQuery filters the scheduler event log
+type SchedulerEventQuery struct {
+	TaskID *int
+	Types  []string
+	Limit  int
+	Offset int
+}
+
+// SchedulerEventListResponse is a page of the scheduler event log
+type SchedulerEventListResponse struct {
+	Events []*model.SchedulerEventLog `json:"events"`
+	Total  int64                      `json:"total"`
+	Limit  int                        `json:"limit"`
+	Offset int                        `json:"offset"`
+}
+
+// ListEvents returns scheduler events, newest first. Filtering by a task
+// requires access to it.
+func (s *SchedulerService) ListEvents(ctx context.Context, userID int64, query *SchedulerEventQuery) (*SchedulerEventListResponse, error) {
+	if s.eventRepo == nil {
+		return nil, apperrors.New(apperrors.CodeUnavailable, "scheduler event log is not available")
+	}
+	if query == nil {
+		query = &SchedulerEventQuery{}
+	}
+
+	if query.Limit <= 0 {
+		query.Limit = 50
+	}
+	if query.Limit > 500 {
+		query.Limit = 500
+	}
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	if query.TaskID != nil {
+		task, err := s.taskRepo.GetByID(ctx, int64(*query.TaskID))
+		if err != nil {
+			return nil, fmt.Errorf("failed to get task: %w", err)
+		}
+		if err := s.checkTaskPermission(task, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	events, total, err := s.eventRepo.List(ctx, &model.SchedulerEventFilter{
+		TaskID: query.TaskID,
+		Types:  query.Types,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list scheduler events: %w", err)
+	}
+
+	return &SchedulerEventListResponse{
+		Events: events,
+		Total:  total,
+		Limit:  query.Limit,
+		Offset: query.Offset,
+	}, nil
+}
+
+// eventRetention returns the number of events kept in the event log
+func (s *SchedulerService) eventRetention() int {
+	if s.config != nil && s.config.Scheduler.EventRetention > 0 {
+		return s.config.Scheduler.EventRetention
+	}
+	return defaultSchedulerEventRetention
+}
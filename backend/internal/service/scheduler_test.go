@@ -0,0 +1,265 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+func TestCreateTaskRequestValidatesSchedule(t *testing.T) {
+	at := func(d time.Duration) *time.Time {
+		runAt := time.Now().Add(d)
+		return &runAt
+	}
+
+	tests := []struct {
+		name    string
+		req     CreateTaskRequest
+		wantErr bool
+	}{
+		{"cron", CreateTaskRequest{Name: "nightly", CronExpression: "0 2 * * *"}, false},
+		{"cron without expression", CreateTaskRequest{Name: "nightly"}, true},
+		{"once in 30 seconds", CreateTaskRequest{Name: "saturday", ScheduleType: model.ScheduleTypeOnce, RunAt: at(30 * time.Second)}, false},
+		{"once within the grace period", CreateTaskRequest{Name: "saturday", ScheduleType: model.ScheduleTypeOnce, RunAt: at(-30 * time.Second)}, false},
+		{"once in the past", CreateTaskRequest{Name: "saturday", ScheduleType: model.ScheduleTypeOnce, RunAt: at(-time.Hour)}, true},
+		{"once without run_at", CreateTaskRequest{Name: "saturday", ScheduleType: model.ScheduleTypeOnce}, true},
+		{"unknown schedule type", CreateTaskRequest{Name: "saturday", ScheduleType: "twice", RunAt: at(time.Hour)}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.req.Validate(); (err != nil) != tt.wantErr {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func newSchedulerTestService(t *testing.T) (*SchedulerService, repository.ScheduledTaskRepository) {
+	t.Helper()
+
+	db := newTestDB(t, &model.User{}, &model.ScheduledTask{})
+	owner := &model.User{ID: 1, Username: "owner", Email: "owner@example.com", Role: model.UserRoleOperator}
+	if err := db.Create(owner).Error; err != nil {
+		t.Fatalf("failed to create user: %v", err)
+	}
+
+	taskRepo := repository.NewScheduledTaskRepository(db)
+	return &SchedulerService{
+		taskRepo:    taskRepo,
+		userService: &UserService{userRepo: repository.NewUserRepository(db)},
+	}, taskRepo
+}
+
+// TestUpdateTaskConcurrentWithRuns hammers UpdateTask while an executor
+// records finished runs the way the cron scheduler does, and checks that no
+// run is lost and the next run never moves back
+func TestUpdateTaskConcurrentWithRuns(t *testing.T) {
+	ctx := context.Background()
+	s, taskRepo := newSchedulerTestService(t)
+
+	owner := 1
+	task := &model.ScheduledTask{Name: "cleanup", Type: model.TaskTypeCleanup, CronExpression: "@every 1s", CreatedBy: &owner}
+	if err := taskRepo.Create(ctx, task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	taskID := int64(task.ID)
+
+	const (
+		runs    = 60
+		editors = 4
+		edits   = 15
+	)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		updated   int
+		conflicts int
+		failures  int
+		lastStart time.Time
+		latest    time.Time
+	)
+	done := make(chan struct{})
+
+	// The executor; every fourth run finishes late, with a next run computed
+	// before the ones already recorded
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < runs; i++ {
+			startedAt := time.Now()
+			nextRun, _ := task.NextRunAfter(startedAt)
+			if i%4 == 3 {
+				startedAt = startedAt.Add(-3 * time.Second)
+				nextRun, _ = task.NextRunAfter(startedAt)
+			}
+			if startedAt.After(lastStart) {
+				lastStart = startedAt
+			}
+
+			failed := i%3 == 0
+			if failed {
+				failures++
+			}
+			if err := taskRepo.RecordRun(ctx, taskID, startedAt, failed, &nextRun); err != nil {
+				t.Errorf("RecordRun failed: %v", err)
+				return
+			}
+
+			// Edits recompute the next run from a later time, so the
+			// stored one is never before any recorded so far
+			if nextRun.After(latest) {
+				latest = nextRun
+			}
+			stored, err := taskRepo.GetByID(ctx, taskID)
+			if err != nil {
+				t.Errorf("GetByID failed: %v", err)
+				return
+			}
+			if stored.NextRunAt.Before(latest) {
+				t.Errorf("next run %s is before the recorded %s", stored.NextRunAt, latest)
+			}
+		}
+	}()
+
+	for e := 0; e < editors; e++ {
+		wg.Add(1)
+		go func(editor int) {
+			defer wg.Done()
+			for j := 0; j < edits; j++ {
+				params := map[string]interface{}{"editor": editor, "edit": j}
+				err := s.UpdateTask(ctx, int64(owner), taskID, &UpdateTaskRequest{Parameters: &params})
+
+				mu.Lock()
+				switch {
+				case err == nil:
+					updated++
+				case errors.Is(err, model.ErrTaskVersionConflict):
+					// Retries are bounded, so contending editors may give up
+					conflicts++
+				default:
+					t.Errorf("UpdateTask failed: %v", err)
+				}
+				mu.Unlock()
+			}
+		}(e)
+	}
+
+	// Watch the stored task while the writers run
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		var last *model.ScheduledTask
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			current, err := taskRepo.GetByID(ctx, taskID)
+			if err != nil {
+				t.Errorf("GetByID failed: %v", err)
+				return
+			}
+			if last != nil {
+				if current.RunCount < last.RunCount || current.FailureCount < last.FailureCount {
+					t.Errorf("counters went from %d/%d to %d/%d", last.RunCount, last.FailureCount, current.RunCount, current.FailureCount)
+				}
+				if current.NextRunAt.Before(*last.NextRunAt) {
+					t.Errorf("next run moved back from %s to %s", last.NextRunAt, current.NextRunAt)
+				}
+				if current.Version < last.Version {
+					t.Errorf("version went from %d to %d", last.Version, current.Version)
+				}
+			}
+			last = current
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+	<-watched
+
+	stored, err := taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if stored.RunCount != runs || stored.FailureCount != failures {
+		t.Errorf("counters = %d runs, %d failures, want %d and %d", stored.RunCount, stored.FailureCount, runs, failures)
+	}
+	if stored.LastRunAt == nil || !stored.LastRunAt.Equal(lastStart) {
+		t.Errorf("last run = %v, want %s", stored.LastRunAt, lastStart)
+	}
+	if updated == 0 || updated+conflicts != editors*edits {
+		t.Errorf("%d updates and %d conflicts, want %d edits with some applied", updated, conflicts, editors*edits)
+	}
+	if stored.Parameters == "{}" {
+		t.Error("no edit of the parameters was stored")
+	}
+}
+
+// racingTaskRepo runs beforeUpdate ahead of the first task update, to let a
+// run finish between UpdateTask reading the task and writing it back
+type racingTaskRepo struct {
+	repository.ScheduledTaskRepository
+	beforeUpdate func(task *model.ScheduledTask)
+}
+
+func (r *racingTaskRepo) Update(ctx context.Context, task *model.ScheduledTask) error {
+	if before := r.beforeUpdate; before != nil {
+		r.beforeUpdate = nil
+		before(task)
+	}
+	return r.ScheduledTaskRepository.Update(ctx, task)
+}
+
+func TestUpdateTaskRetriesAfterRunAdvancesSchedule(t *testing.T) {
+	ctx := context.Background()
+	s, taskRepo := newSchedulerTestService(t)
+
+	owner := 1
+	task := &model.ScheduledTask{Name: "cleanup", Type: model.TaskTypeCleanup, CronExpression: "@every 1s", CreatedBy: &owner}
+	if err := taskRepo.Create(ctx, task); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	taskID := int64(task.ID)
+
+	// A run finishes once the next run UpdateTask computed has passed
+	var recorded time.Time
+	s.taskRepo = &racingTaskRepo{
+		ScheduledTaskRepository: taskRepo,
+		beforeUpdate: func(edited *model.ScheduledTask) {
+			time.Sleep(time.Until(*edited.NextRunAt) + 10*time.Millisecond)
+			recorded, _ = edited.NextRunAfter(time.Now())
+			if err := taskRepo.RecordRun(ctx, taskID, time.Now(), false, &recorded); err != nil {
+				t.Fatalf("RecordRun failed: %v", err)
+			}
+		},
+	}
+
+	params := map[string]interface{}{"keep_days": 7}
+	if err := s.UpdateTask(ctx, int64(owner), taskID, &UpdateTaskRequest{Parameters: &params}); err != nil {
+		t.Fatalf("UpdateTask failed: %v", err)
+	}
+
+	stored, err := taskRepo.GetByID(ctx, taskID)
+	if err != nil {
+		t.Fatalf("GetByID failed: %v", err)
+	}
+	if stored.NextRunAt.Before(recorded) {
+		t.Errorf("next run = %s, want the edit to keep it at or after the recorded %s", stored.NextRunAt, recorded)
+	}
+	if stored.RunCount != 1 {
+		t.Errorf("run count = %d, want the run recorded during the edit", stored.RunCount)
+	}
+	if stored.Parameters != `{"keep_days":7}` {
+		t.Errorf("parameters = %s, want the edit applied", stored.Parameters)
+	}
+}
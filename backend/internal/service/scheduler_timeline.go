@@ -0,0 +1,302 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+)
+
+const (
+	// MaxTimelineWindow bounds the range a timeline query may cover. Executions
+	// that started more than one window before the range are not looked up.
+	MaxTimelineWindow = 7 * 24 * time.Hour
+
+	// maxTimelineRecords caps the execution logs and update records read per query
+	maxTimelineRecords = 5000
+)
+
+// SchedulerTimeline is the task execution timeline for a time range, ready to
+// be drawn as a Gantt chart
+type SchedulerTimeline struct {
+	From      time.Time           `json:"from"`
+	To        time.Time           `json:"to"`
+	Intervals []*TimelineInterval `json:"intervals"`
+	Summary   *TimelineSummary    `json:"summary"`
+	Truncated bool                `json:"truncated"`
+}
+
+// TimelineInterval is a single task execution. End is nil while it is running.
+type TimelineInterval struct {
+	ID          string                `json:"id"`
+	TaskID      int                   `json:"task_id"`
+	TaskName    string                `json:"task_name"`
+	TaskType    model.TaskType        `json:"task_type"`
+	Start       time.Time             `json:"start"`
+	End         *time.Time            `json:"end"`
+	Status      model.ExecutionStatus `json:"status"`
+	TriggeredBy model.TriggerType     `json:"triggered_by,omitempty"`
+	Operations  []*TimelineOperation  `json:"operations,omitempty"`
+}
+
+// TimelineOperation is a container update performed during a task execution
+type TimelineOperation struct {
+	UpdateID      int                `json:"update_id"`
+	ContainerID   int                `json:"container_id"`
+	ContainerName string             `json:"container_name,omitempty"`
+	OldImage      string             `json:"old_image,omitempty"`
+	NewImage      string             `json:"new_image,omitempty"`
+	Status        model.UpdateStatus `json:"status"`
+	Start         time.Time          `json:"start"`
+	End           *time.Time         `json:"end"`
+}
+
+// TimelineSummary aggregates the intervals of a timeline, clipped to its range
+type TimelineSummary struct {
+	Executions       int               `json:"executions"`
+	BusySeconds      int64             `json:"busy_seconds"`
+	MaxConcurrency   int               `json:"max_concurrency"`
+	MaxConcurrencyAt *time.Time        `json:"max_concurrency_at,omitempty"`
+	LongestExecution *LongestExecution `json:"longest_execution,omitempty"`
+}
+
+// LongestExecution identifies the longest running interval of a timeline
+type LongestExecution struct {
+	ID              string `json:"id"`
+	TaskName        string `json:"task_name"`
+	DurationSeconds int64  `json:"duration_seconds"`
+}
+
+// GetTimeline returns the task executions overlapping [from, to), including
+// running ones, with the container updates each container update task made
+func (s *SchedulerService) GetTimeline(ctx context.Context, userID int64, from, to time.Time) (*SchedulerTimeline, error) {
+	if !to.After(from) {
+		return nil, apperrors.New(apperrors.CodeInvalidRequest, "invalid range: 'to' must be after 'from'")
+	}
+	if to.Sub(from) > MaxTimelineWindow {
+		return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid range: window cannot exceed %s", MaxTimelineWindow)
+	}
+
+	now := time.Now()
+	lookback := from.Add(-MaxTimelineWindow)
+	timeline := &SchedulerTimeline{From: from, To: to, Intervals: []*TimelineInterval{}}
+
+	// Bounded on started_at so the query stays on the started_at index
+	logs, total, err := s.executionLogRepo.List(ctx, &model.TaskExecutionLogFilter{
+		StartedAfter:  &lookback,
+		StartedBefore: &to,
+		Limit:         maxTimelineRecords,
+		OrderBy:       "started_at ASC",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list executions: %w", err)
+	}
+	timeline.Truncated = total > int64(len(logs))
+
+	tasks := make(map[int]*model.ScheduledTask)
+	allowed := func(taskID int) (*model.ScheduledTask, bool) {
+		task, seen := tasks[taskID]
+		if !seen {
+			found, err := s.taskRepo.GetByID(ctx, int64(taskID))
+			if err == nil && s.checkTaskPermission(found, userID) == nil {
+				task = found
+			}
+			tasks[taskID] = task
+		}
+		return task, task != nil
+	}
+
+	for _, log := range logs {
+		if log.CompletedAt != nil && !log.CompletedAt.After(from) {
+			continue
+		}
+		task, ok := allowed(log.TaskID)
+		if !ok {
+			continue
+		}
+		timeline.Intervals = append(timeline.Intervals, &TimelineInterval{
+			ID:          strconv.Itoa(log.ID),
+			TaskID:      log.TaskID,
+			TaskName:    task.Name,
+			TaskType:    task.Type,
+			Start:       log.StartedAt,
+			End:         log.CompletedAt,
+			Status:      log.Status,
+			TriggeredBy: log.TriggeredBy,
+		})
+	}
+
+	// Running executions are only logged once they finish
+	if s.isRunning {
+		for _, execution := range s.scheduler.GetRunningTasks() {
+			if !execution.StartedAt.Before(to) {
+				continue
+			}
+			if _, ok := allowed(execution.TaskID); !ok {
+				continue
+			}
+			timeline.Intervals = append(timeline.Intervals, &TimelineInterval{
+				ID:          execution.ID,
+				TaskID:      execution.TaskID,
+				TaskName:    execution.TaskName,
+				TaskType:    execution.TaskType,
+				Start:       execution.StartedAt,
+				Status:      model.ExecutionStatusRunning,
+				TriggeredBy: execution.TriggeredBy,
+			})
+		}
+	}
+
+	sort.Slice(timeline.Intervals, func(i, j int) bool {
+		return timeline.Intervals[i].Start.Before(timeline.Intervals[j].Start)
+	})
+
+	if err := s.attachTimelineOperations(ctx, timeline, lookback, now); err != nil {
+		return nil, err
+	}
+
+	timeline.Summary = summarizeTimeline(timeline.Intervals, from, to, now)
+
+	return timeline, nil
+}
+
+// attachTimelineOperations assigns automatic container updates to the container
+// update executions that were running when they started
+func (s *SchedulerService) attachTimelineOperations(ctx context.Context, timeline *SchedulerTimeline, since, now time.Time) error {
+	var updateIntervals []*TimelineInterval
+	for _, interval := range timeline.Intervals {
+		if interval.TaskType == model.TaskTypeContainerUpdate {
+			updateIntervals = append(updateIntervals, interval)
+		}
+	}
+	if len(updateIntervals) == 0 || s.updateHistoryRepo == nil {
+		return nil
+	}
+
+	histories, _, err := s.updateHistoryRepo.List(ctx, &model.UpdateHistoryFilter{
+		StartedAfter:  &since,
+		StartedBefore: &timeline.To,
+		Limit:         maxTimelineRecords,
+		OrderBy:       "started_at ASC",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to list container updates: %w", err)
+	}
+
+	var containerIDs []int64
+	seen := make(map[int]bool)
+	for _, history := range histories {
+		if !seen[history.ContainerID] {
+			seen[history.ContainerID] = true
+			containerIDs = append(containerIDs, int64(history.ContainerID))
+		}
+	}
+	names := make(map[int]string)
+	if len(containerIDs) > 0 {
+		if containers, err := s.containerRepo.GetByIDs(ctx, containerIDs); err == nil {
+			for _, container := range containers {
+				names[container.ID] = container.Name
+			}
+		}
+	}
+
+	for _, history := range histories {
+		if history.TriggeredBy == model.TriggerTypeManual {
+			continue
+		}
+		for _, interval := range updateIntervals {
+			end := now
+			if interval.End != nil {
+				end = *interval.End
+			}
+			if history.StartedAt.Before(interval.Start) || history.StartedAt.After(end) {
+				continue
+			}
+			interval.Operations = append(interval.Operations, &TimelineOperation{
+				UpdateID:      history.ID,
+				ContainerID:   history.ContainerID,
+				ContainerName: names[history.ContainerID],
+				OldImage:      history.OldImage,
+				NewImage:      history.NewImage,
+				Status:        history.Status,
+				Start:         history.StartedAt,
+				End:           history.CompletedAt,
+			})
+			break
+		}
+	}
+
+	return nil
+}
+
+// summarizeTimeline computes busy time and peak concurrency within [from, to)
+// and the longest execution; running intervals are treated as ending now
+func summarizeTimeline(intervals []*TimelineInterval, from, to, now time.Time) *TimelineSummary {
+	summary := &TimelineSummary{Executions: len(intervals)}
+
+	type event struct {
+		at    time.Time
+		delta int
+	}
+	var events []event
+	var longest time.Duration
+
+	for _, interval := range intervals {
+		end := now
+		if interval.End != nil {
+			end = *interval.End
+		}
+		if duration := end.Sub(interval.Start); duration > longest || summary.LongestExecution == nil {
+			longest = duration
+			summary.LongestExecution = &LongestExecution{
+				ID:              interval.ID,
+				TaskName:        interval.TaskName,
+				DurationSeconds: int64(duration.Seconds()),
+			}
+		}
+
+		start := interval.Start
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if end.After(start) {
+			events = append(events, event{start, 1}, event{end, -1})
+		}
+	}
+
+	// Ends sort before starts at the same instant so back-to-back runs don't overlap
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	var busy time.Duration
+	var busySince time.Time
+	active := 0
+	for _, e := range events {
+		if active == 0 && e.delta > 0 {
+			busySince = e.at
+		}
+		active += e.delta
+		if active == 0 {
+			busy += e.at.Sub(busySince)
+		}
+		if active > summary.MaxConcurrency {
+			at := e.at
+			summary.MaxConcurrency = active
+			summary.MaxConcurrencyAt = &at
+		}
+	}
+	summary.BusySeconds = int64(busy.Seconds())
+
+	return summary
+}
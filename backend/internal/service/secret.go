@@ -0,0 +1,444 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+)
+
+// secretPageSize is the number of rows read per query when scanning secret
+// columns
+const secretPageSize = 100
+
+// SecretFailure is a stored secret that could not be read
+type SecretFailure struct {
+	Column string `json:"column"`
+	ID     int    `json:"id"`
+	Error  string `json:"error"`
+}
+
+// SecretRotationResult summarizes a pass re-encrypting the secret columns
+type SecretRotationResult struct {
+	KeyVersion int `json:"key_version"`
+	// Encrypted counts plaintext values migrated
+	Encrypted int `json:"encrypted"`
+	// Rotated counts values moved from an older key
+	Rotated   int `json:"rotated"`
+	Unchanged int `json:"unchanged"`
+	// Conflicts counts values changed by another writer mid-pass; running
+	// the rotation again picks them up
+	Conflicts int             `json:"conflicts"`
+	Failures  []SecretFailure `json:"failures,omitempty"`
+}
+
+// SecretStatus describes how the stored secrets are protected
+type SecretStatus struct {
+	KeyConfigured bool `json:"key_configured"`
+	KeyVersion    int  `json:"key_version"`
+
+	Plaintext int `json:"plaintext"`
+	// Encrypted counts encrypted values by key version
+	Encrypted map[int]int `json:"encrypted"`
+	// Stale counts values encrypted with a key other than the current one
+	Stale    int             `json:"stale"`
+	Failures []SecretFailure `json:"failures,omitempty"`
+	Problems []string        `json:"problems,omitempty"`
+	Warnings []string        `json:"warnings,omitempty"`
+}
+
+// Healthy reports whether every stored secret is encrypted and readable
+func (st *SecretStatus) Healthy() bool {
+	return len(st.Problems) == 0
+}
+
+// SecretService encrypts the secrets kept in the database — registry
+// credentials, container registry logins and notification channel settings —
+// and keeps them readable across encryption key rotations
+type SecretService struct {
+	secretRepo repository.SecretRepository
+	configRepo repository.SystemConfigRepository
+	cipher     *utils.SecretCipher
+	keyErr     error
+	keyVersion int
+}
+
+// NewSecretService creates a new secret service instance. A missing or
+// invalid key is reported when a secret is encrypted or checked.
+func NewSecretService(secretRepo repository.SecretRepository, configRepo repository.SystemConfigRepository, cfg *config.Config) *SecretService {
+	s := &SecretService{
+		secretRepo: secretRepo,
+		configRepo: configRepo,
+		keyVersion: cfg.Security.EncryptionKeyVersion,
+	}
+
+	keys, err := cfg.GetEncryptionKeys()
+	if err == nil {
+		s.cipher, err = utils.NewSecretCipher(cfg.Security.EncryptionKeyVersion, keys)
+	}
+	s.keyErr = err
+
+	return s
+}
+
+// Encrypt seals a secret with the current key
+func (s *SecretService) Encrypt(plaintext string) (string, error) {
+	if s.cipher == nil {
+		return "", s.keyErr
+	}
+	return s.cipher.Encrypt(plaintext)
+}
+
+// Decrypt opens a stored secret; legacy plaintext is returned unchanged
+func (s *SecretService) Decrypt(value string) (string, error) {
+	if !utils.IsEncryptedSecret(value) {
+		return value, nil
+	}
+	if s.cipher == nil {
+		return "", s.keyErr
+	}
+	return s.cipher.Decrypt(value)
+}
+
+// SealRegistryCredentials stores the password and token of registry
+// credentials encrypted
+func (s *SecretService) SealRegistryCredentials(credentials *model.RegistryCredentials, password, token string) error {
+	encryptedPassword, err := s.Encrypt(password)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt registry password: %w", err)
+	}
+	encryptedToken, err := s.Encrypt(token)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt registry token: %w", err)
+	}
+
+	credentials.PasswordEncrypted = encryptedPassword
+	credentials.TokenEncrypted = encryptedToken
+	return nil
+}
+
+// OpenRegistryCredentials returns the password and token of registry
+// credentials
+func (s *SecretService) OpenRegistryCredentials(credentials *model.RegistryCredentials) (password, token string, err error) {
+	if password, err = s.Decrypt(credentials.PasswordEncrypted); err != nil {
+		return "", "", fmt.Errorf("failed to decrypt registry password: %w", err)
+	}
+	if token, err = s.Decrypt(credentials.TokenEncrypted); err != nil {
+		return "", "", fmt.Errorf("failed to decrypt registry token: %w", err)
+	}
+	return password, token, nil
+}
+
+// SealConfigValue encrypts a JSON setting, such as a notification channel,
+// for storage in the system settings; set IsEncrypted on the row
+func (s *SecretService) SealConfigValue(value string) (string, error) {
+	encrypted, err := s.Encrypt(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt setting: %w", err)
+	}
+	sealed, _ := json.Marshal(encrypted)
+	return string(sealed), nil
+}
+
+// OpenConfigValue returns the JSON value of a setting stored by
+// SealConfigValue, or the value itself for unencrypted settings
+func (s *SecretService) OpenConfigValue(setting *model.SystemConfig) (string, error) {
+	value, err := s.openJSONValue(setting.ConfigValue)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt setting %s: %w", setting.ConfigKey, err)
+	}
+	return value, nil
+}
+
+// SealContainerRegistryAuth stores the registry login of a container, a JSON
+// document, encrypted as a JSON string so it still fits the jsonb column
+func (s *SecretService) SealContainerRegistryAuth(container *model.Container, auth string) error {
+	if auth == "" {
+		container.RegistryAuth = ""
+		return nil
+	}
+
+	encrypted, err := s.Encrypt(auth)
+	if err != nil {
+		return fmt.Errorf("failed to encrypt registry login: %w", err)
+	}
+	sealed, _ := json.Marshal(encrypted)
+	container.RegistryAuth = string(sealed)
+	return nil
+}
+
+// OpenContainerRegistryAuth returns the registry login of a container;
+// logins stored before they were encrypted are returned unchanged
+func (s *SecretService) OpenContainerRegistryAuth(container *model.Container) (string, error) {
+	auth, err := s.openJSONValue(container.RegistryAuth)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt registry login of container %s: %w", container.Name, err)
+	}
+	return auth, nil
+}
+
+// openJSONValue opens a secret held as a JSON string, or returns the JSON
+// document itself when it is not encrypted
+func (s *SecretService) openJSONValue(value string) (string, error) {
+	var sealed string
+	if err := json.Unmarshal([]byte(value), &sealed); err != nil || !utils.IsEncryptedSecret(sealed) {
+		return value, nil
+	}
+	return s.Decrypt(sealed)
+}
+
+// SealNotificationChannel stores the settings of a notification channel
+// encrypted
+func (s *SecretService) SealNotificationChannel(channel *model.NotificationChannel) error {
+	settings, err := json.Marshal(channel.Settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode notification channel settings: %w", err)
+	}
+	encrypted, err := s.Encrypt(string(settings))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt notification channel settings: %w", err)
+	}
+	channel.SettingsEncrypted = encrypted
+	return nil
+}
+
+// OpenNotificationChannel fills in the settings of a notification channel
+func (s *SecretService) OpenNotificationChannel(channel *model.NotificationChannel) error {
+	settings, err := s.Decrypt(channel.SettingsEncrypted)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt notification channel settings: %w", err)
+	}
+	channel.Settings = model.NotificationConfig{}
+	if err := json.Unmarshal([]byte(settings), &channel.Settings); err != nil {
+		return fmt.Errorf("failed to decode notification channel settings: %w", err)
+	}
+	return nil
+}
+
+// Rotate brings every stored secret under the current key, encrypting
+// plaintext and re-encrypting values sealed with older keys. Rows are
+// rewritten one at a time, each guarded by its previous value, so servers
+// keep running throughout as long as they have both keys configured.
+func (s *SecretService) Rotate(ctx context.Context) (*SecretRotationResult, error) {
+	if s.cipher == nil {
+		return nil, s.keyErr
+	}
+
+	result := &SecretRotationResult{KeyVersion: s.cipher.CurrentVersion()}
+
+	err := s.eachSecret(ctx, func(column model.SecretColumn, stored model.SecretValue, secret string) error {
+		wasEncrypted := utils.IsEncryptedSecret(secret)
+
+		replacement, changed, err := s.cipher.Reencrypt(secret)
+		if err != nil {
+			result.Failures = append(result.Failures, SecretFailure{Column: column.Name(), ID: stored.ID, Error: err.Error()})
+			return nil
+		}
+		if !changed {
+			result.Unchanged++
+			return nil
+		}
+
+		if column.JSON {
+			encoded, _ := json.Marshal(replacement)
+			replacement = string(encoded)
+		}
+
+		replaced, err := s.secretRepo.ReplaceValue(ctx, column, stored.ID, stored.Value, replacement)
+		if err != nil {
+			return err
+		}
+		switch {
+		case !replaced:
+			result.Conflicts++
+		case wasEncrypted:
+			result.Rotated++
+		default:
+			result.Encrypted++
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if err := s.recordKeyFingerprints(ctx, nil); err != nil {
+		return result, err
+	}
+
+	return result, nil
+}
+
+// Check reports stored secrets that are unencrypted, unreadable or sealed
+// with a retired key, and whether a configured key differs from the one
+// previously used under its version. Fingerprints of keys seen for the first
+// time are recorded.
+func (s *SecretService) Check(ctx context.Context) (*SecretStatus, error) {
+	status := &SecretStatus{
+		KeyConfigured: s.cipher != nil,
+		KeyVersion:    s.keyVersion,
+		Encrypted:     make(map[int]int),
+	}
+	if s.cipher == nil {
+		status.Problems = append(status.Problems, fmt.Sprintf("secret encryption key unavailable: %v", s.keyErr))
+	}
+
+	err := s.eachSecret(ctx, func(column model.SecretColumn, stored model.SecretValue, secret string) error {
+		version, encrypted := utils.EncryptedSecretVersion(secret)
+		if !encrypted {
+			status.Plaintext++
+			return nil
+		}
+
+		status.Encrypted[version]++
+		if version != s.keyVersion {
+			status.Stale++
+		}
+		if s.cipher != nil {
+			if _, err := s.cipher.Decrypt(secret); err != nil {
+				status.Failures = append(status.Failures, SecretFailure{Column: column.Name(), ID: stored.ID, Error: err.Error()})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if status.Plaintext > 0 {
+		status.Problems = append(status.Problems, fmt.Sprintf("%d stored secrets are not encrypted with a versioned key", status.Plaintext))
+	}
+	if len(status.Failures) > 0 {
+		status.Problems = append(status.Problems, fmt.Sprintf("%d stored secrets cannot be decrypted with the configured keys", len(status.Failures)))
+	}
+	if status.Stale > 0 {
+		status.Warnings = append(status.Warnings, fmt.Sprintf("%d stored secrets use a retired key; run the key rotation", status.Stale))
+	}
+
+	if s.cipher != nil {
+		var changed []int
+		if err := s.recordKeyFingerprints(ctx, &changed); err != nil {
+			return nil, err
+		}
+		for _, version := range changed {
+			status.Problems = append(status.Problems, fmt.Sprintf("encryption key version %d differs from the key previously used under that version", version))
+		}
+	}
+
+	return status, nil
+}
+
+// eachSecret calls fn for every non-empty stored secret, with the secret
+// unwrapped from JSON columns
+func (s *SecretService) eachSecret(ctx context.Context, fn func(column model.SecretColumn, stored model.SecretValue, secret string) error) error {
+	for _, column := range model.SecretColumns() {
+		afterID := 0
+		for {
+			values, err := s.secretRepo.ListValues(ctx, column, afterID, secretPageSize)
+			if err != nil {
+				return err
+			}
+
+			for _, stored := range values {
+				afterID = stored.ID
+
+				secret := stored.Value
+				if column.JSON {
+					secret = unwrapJSONSecret(secret)
+				}
+				if secret == "" {
+					continue
+				}
+
+				if err := fn(column, stored, secret); err != nil {
+					return err
+				}
+			}
+
+			if len(values) < secretPageSize {
+				break
+			}
+		}
+	}
+	return nil
+}
+
+// recordKeyFingerprints stores fingerprints of configured keys not seen
+// before. Versions whose recorded fingerprint differs are left alone and
+// appended to changed when given.
+func (s *SecretService) recordKeyFingerprints(ctx context.Context, changed *[]int) error {
+	existing, err := s.configRepo.GetByKey(ctx, model.ConfigKeySecurityEncryptionKeyCheck)
+	if err != nil && !strings.Contains(err.Error(), "not found") {
+		return fmt.Errorf("failed to get encryption key fingerprints: %w", err)
+	}
+
+	fingerprints := make(map[string]string)
+	if existing != nil {
+		if err := json.Unmarshal([]byte(existing.ConfigValue), &fingerprints); err != nil {
+			return fmt.Errorf("invalid encryption key fingerprints: %w", err)
+		}
+	}
+
+	versions := s.cipher.Versions()
+	sort.Ints(versions)
+
+	added := false
+	for _, version := range versions {
+		key := strconv.Itoa(version)
+		fingerprint := s.cipher.KeyFingerprint(version)
+
+		recorded, exists := fingerprints[key]
+		switch {
+		case !exists:
+			fingerprints[key] = fingerprint
+			added = true
+		case recorded != fingerprint && changed != nil:
+			*changed = append(*changed, version)
+		}
+	}
+	if !added {
+		return nil
+	}
+
+	value, _ := json.Marshal(fingerprints)
+	if existing == nil {
+		err = s.configRepo.Create(ctx, &model.SystemConfig{
+			ConfigKey:   model.ConfigKeySecurityEncryptionKeyCheck,
+			ConfigValue: string(value),
+			Description: "Fingerprints of the secret encryption keys by version",
+			IsSystem:    true,
+		})
+	} else {
+		updated := *existing
+		updated.ConfigValue = string(value)
+		err = s.configRepo.Update(ctx, &updated)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save encryption key fingerprints: %w", err)
+	}
+	return nil
+}
+
+// unwrapJSONSecret returns the ciphertext held as a JSON string, or the JSON
+// document itself when it is not encrypted. Empty documents hold no secret.
+func unwrapJSONSecret(value string) string {
+	var sealed string
+	if err := json.Unmarshal([]byte(value), &sealed); err == nil {
+		if utils.IsEncryptedSecret(sealed) {
+			return sealed
+		}
+	}
+
+	switch strings.TrimSpace(value) {
+	case "", "{}", "null", `""`:
+		return ""
+	}
+	return value
+}
@@ -0,0 +1,171 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+const (
+	oldTestSecretKey = "old-key-0123456789abcdef0123456789"
+	newTestSecretKey = "new-key-0123456789abcdef0123456789"
+)
+
+// memorySecretRepo holds secret column values by column name and row ID
+type memorySecretRepo struct {
+	values map[string]map[int]string
+}
+
+func newMemorySecretRepo() *memorySecretRepo {
+	return &memorySecretRepo{values: make(map[string]map[int]string)}
+}
+
+func (r *memorySecretRepo) set(column string, id int, value string) {
+	if r.values[column] == nil {
+		r.values[column] = make(map[int]string)
+	}
+	r.values[column][id] = value
+}
+
+func (r *memorySecretRepo) ListValues(ctx context.Context, column model.SecretColumn, afterID, limit int) ([]model.SecretValue, error) {
+	ids := make([]int, 0, len(r.values[column.Name()]))
+	for id := range r.values[column.Name()] {
+		if id > afterID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Ints(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+
+	values := make([]model.SecretValue, 0, len(ids))
+	for _, id := range ids {
+		values = append(values, model.SecretValue{ID: id, Value: r.values[column.Name()][id]})
+	}
+	return values, nil
+}
+
+func (r *memorySecretRepo) ReplaceValue(ctx context.Context, column model.SecretColumn, id int, current, replacement string) (bool, error) {
+	if r.values[column.Name()][id] != current {
+		return false, nil
+	}
+	r.values[column.Name()][id] = replacement
+	return true, nil
+}
+
+// memoryConfigRepo stores system settings by key
+type memoryConfigRepo struct {
+	repository.SystemConfigRepository
+	configs map[string]*model.SystemConfig
+}
+
+func (r *memoryConfigRepo) GetByKey(ctx context.Context, key string) (*model.SystemConfig, error) {
+	config, ok := r.configs[key]
+	if !ok {
+		return nil, fmt.Errorf("system config with key '%s' not found", key)
+	}
+	return config, nil
+}
+
+func (r *memoryConfigRepo) Create(ctx context.Context, config *model.SystemConfig) error {
+	r.configs[config.ConfigKey] = config
+	return nil
+}
+
+func (r *memoryConfigRepo) Update(ctx context.Context, config *model.SystemConfig) error {
+	r.configs[config.ConfigKey] = config
+	return nil
+}
+
+func newTestSecretService(repo repository.SecretRepository, configs *memoryConfigRepo, version int, key, previous string) *SecretService {
+	return NewSecretService(repo, configs, &config.Config{Security: config.SecurityConfig{
+		EncryptionKey:          key,
+		EncryptionKeyVersion:   version,
+		EncryptionPreviousKeys: previous,
+	}})
+}
+
+func TestSecretServiceRotateMovesEverySecretColumn(t *testing.T) {
+	ctx := context.Background()
+	repo := newMemorySecretRepo()
+	configs := &memoryConfigRepo{configs: make(map[string]*model.SystemConfig)}
+	before := newTestSecretService(repo, configs, 1, oldTestSecretKey, "")
+
+	// Secrets written under the old key, and some never encrypted
+	registry := &model.RegistryCredentials{}
+	if err := before.SealRegistryCredentials(registry, "registry-password", ""); err != nil {
+		t.Fatal(err)
+	}
+	repo.set("registry_credentials.password_encrypted", 1, registry.PasswordEncrypted)
+
+	sealedLogin := &model.Container{Name: "web"}
+	if err := before.SealContainerRegistryAuth(sealedLogin, `{"username":"deploy","password":"hunter2"}`); err != nil {
+		t.Fatal(err)
+	}
+	repo.set("containers.registry_auth", 1, sealedLogin.RegistryAuth)
+	repo.set("containers.registry_auth", 2, `{"username":"ci","password":"plain"}`)
+	repo.set("containers.registry_auth", 3, "{}")
+
+	corrupted := strings.TrimSuffix(registry.PasswordEncrypted, "=") + "A="
+	repo.set("notification_channels.settings_encrypted", 7, corrupted)
+
+	during := newTestSecretService(repo, configs, 2, newTestSecretKey, "1:"+oldTestSecretKey)
+	result, err := during.Rotate(ctx)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if result.Rotated != 2 || result.Encrypted != 1 || result.Unchanged != 0 || result.Conflicts != 0 {
+		t.Errorf("result = %+v, want 2 rotated and 1 encrypted", result)
+	}
+	if len(result.Failures) != 1 || result.Failures[0].Column != "notification_channels.settings_encrypted" || result.Failures[0].ID != 7 {
+		t.Fatalf("failures = %+v, want the corrupted channel settings", result.Failures)
+	}
+	if repo.values["notification_channels.settings_encrypted"][7] != corrupted {
+		t.Error("the corrupted value was rewritten")
+	}
+	if repo.values["containers.registry_auth"][3] != "{}" {
+		t.Error("an empty registry login was rewritten")
+	}
+
+	// Everything readable is now under the new key alone
+	after := newTestSecretService(repo, configs, 2, newTestSecretKey, "")
+	password, _, err := after.OpenRegistryCredentials(&model.RegistryCredentials{PasswordEncrypted: repo.values["registry_credentials.password_encrypted"][1]})
+	if err != nil || password != "registry-password" {
+		t.Errorf("registry password = %q, %v, want it readable with the new key", password, err)
+	}
+	logins := map[int]string{1: `{"username":"deploy","password":"hunter2"}`, 2: `{"username":"ci","password":"plain"}`}
+	for id, want := range logins {
+		stored := &model.Container{Name: "web", RegistryAuth: repo.values["containers.registry_auth"][id]}
+		if strings.Contains(stored.RegistryAuth, "password") {
+			t.Errorf("registry login %d is stored in plaintext: %s", id, stored.RegistryAuth)
+		}
+		if got, err := after.OpenContainerRegistryAuth(stored); err != nil || got != want {
+			t.Errorf("registry login %d = %q, %v, want %q", id, got, err, want)
+		}
+	}
+
+	// A second pass has nothing left to do but still reports the failure
+	again, err := during.Rotate(ctx)
+	if err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if again.Rotated != 0 || again.Encrypted != 0 || again.Unchanged != 3 || len(again.Failures) != 1 {
+		t.Errorf("second pass = %+v, want 3 unchanged and the one failure", again)
+	}
+
+	status, err := after.Check(ctx)
+	if err != nil {
+		t.Fatalf("Check failed: %v", err)
+	}
+	// Only the corrupted value is left under the old key
+	if status.Plaintext != 0 || status.Stale != 1 || status.Encrypted[2] != 3 || len(status.Failures) != 1 {
+		t.Errorf("status = %+v, want 3 values under the new key and the corrupted one", status)
+	}
+}
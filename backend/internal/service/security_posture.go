@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/security"
+
+	"github.com/sirupsen/logrus"
+)
+
+// SecurityPostureService evaluates the runtime security configuration into
+// scored, persisted reports and compares them over time. Evaluations only
+// read configuration and stored state; nothing is probed or changed.
+type SecurityPostureService struct {
+	postureRepo         repository.SecurityPostureRepository
+	containerRepo       repository.ContainerRepository
+	scanResultRepo      repository.ScanResultRepository
+	userRepo            repository.UserRepository
+	notificationService *NotificationService
+	config              *config.Config
+}
+
+// NewSecurityPostureService creates a new security posture service instance
+func NewSecurityPostureService(
+	postureRepo repository.SecurityPostureRepository,
+	containerRepo repository.ContainerRepository,
+	scanResultRepo repository.ScanResultRepository,
+	userRepo repository.UserRepository,
+	notificationService *NotificationService,
+	cfg *config.Config,
+) *SecurityPostureService {
+	return &SecurityPostureService{
+		postureRepo:         postureRepo,
+		containerRepo:       containerRepo,
+		scanResultRepo:      scanResultRepo,
+		userRepo:            userRepo,
+		notificationService: notificationService,
+		config:              cfg,
+	}
+}
+
+// Evaluate scores the current configuration and stores the report. Admins
+// are notified of findings that regressed since the previous report.
+func (s *SecurityPostureService) Evaluate(ctx context.Context, actor model.Actor) (*model.SecurityPostureReport, error) {
+	input, err := s.gatherInput(ctx)
+	if err != nil {
+		return nil, err
+	}
+	result := security.EvaluatePosture(input)
+
+	previous, err := s.postureRepo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.SecurityPostureReport{
+		Score:       result.Score,
+		Grade:       result.Grade,
+		Trigger:     model.TriggerTypeSchedule,
+		Rules:       result.Rules,
+		Findings:    result.Findings,
+		EvaluatedAt: input.Now,
+	}
+	if actor.UserID != nil {
+		report.Trigger = model.TriggerTypeManual
+		report.RequestedBy = actor.OwnerID()
+	}
+	if err := s.postureRepo.Create(ctx, report); err != nil {
+		return nil, err
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"report_id": report.ID,
+		"score":     report.Score,
+		"findings":  len(report.Findings),
+		"actor":     actor.String(),
+	}).Info("Security posture evaluated")
+
+	if previous != nil && s.config.Security.PostureNotifyRegressions {
+		s.notifyRegressions(ctx, security.ComparePosture(previous, report))
+	}
+
+	return report, nil
+}
+
+// GetLatest returns the most recent report
+func (s *SecurityPostureService) GetLatest(ctx context.Context) (*model.SecurityPostureReport, error) {
+	report, err := s.postureRepo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, fmt.Errorf("security posture report not found: none has been evaluated yet")
+	}
+	return report, nil
+}
+
+// Compare compares two reports, each given as a report ID or a date or time
+// meaning the latest report at or before it. An empty to is the latest
+// report, and an empty from the report before to.
+func (s *SecurityPostureService) Compare(ctx context.Context, from, to string) (*model.PostureComparison, error) {
+	toReport, err := s.resolveReport(ctx, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var fromReport *model.SecurityPostureReport
+	if strings.TrimSpace(from) == "" {
+		fromReport, err = s.postureRepo.GetAtOrBefore(ctx, toReport.EvaluatedAt.Add(-time.Nanosecond))
+		if err == nil && fromReport == nil {
+			err = fmt.Errorf("security posture report not found: no report precedes report %d", toReport.ID)
+		}
+	} else {
+		fromReport, err = s.resolveReport(ctx, from)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return security.ComparePosture(fromReport, toReport), nil
+}
+
+// resolveReport finds the report a compare parameter refers to
+func (s *SecurityPostureService) resolveReport(ctx context.Context, ref string) (*model.SecurityPostureReport, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return s.GetLatest(ctx)
+	}
+
+	if id, err := strconv.Atoi(ref); err == nil {
+		return s.postureRepo.GetByID(ctx, id)
+	}
+
+	at, err := time.Parse(time.RFC3339, ref)
+	if err != nil {
+		day, dayErr := time.Parse("2006-01-02", ref)
+		if dayErr != nil {
+			return nil, fmt.Errorf("invalid request: %q is not a report ID, date or RFC3339 time", ref)
+		}
+		// A date covers the whole day
+		at = day.Add(24*time.Hour - time.Nanosecond)
+	}
+
+	report, err := s.postureRepo.GetAtOrBefore(ctx, at)
+	if err != nil {
+		return nil, err
+	}
+	if report == nil {
+		return nil, fmt.Errorf("security posture report not found at or before %s", ref)
+	}
+	return report, nil
+}
+
+// gatherInput reads the configuration and stored state the evaluation scores
+func (s *SecurityPostureService) gatherInput(ctx context.Context) (*security.PostureInput, error) {
+	cfg := s.config
+	input := &security.PostureInput{
+		Production:              cfg.IsProduction(),
+		HTTPSEnabled:            cfg.Security.HTTPSEnabled,
+		SSLCertPath:             cfg.Security.SSLCertPath,
+		SSLKeyPath:              cfg.Security.SSLKeyPath,
+		JWTSecret:               cfg.JWT.Secret,
+		JWTExpireHours:          cfg.JWT.ExpireHours,
+		JWTRefreshDays:          cfg.JWT.RefreshDays,
+		CORSAllowedOrigins:      cfg.Security.CORSAllowedOrigins,
+		APIKeyCount:             len(cfg.GetAPIKeys()),
+		APIKeyRole:              cfg.Security.APIKeyRole,
+		EncryptionKey:           cfg.Security.EncryptionKey,
+		RequireEncryptedSecrets: cfg.Security.RequireEncryptedSecrets,
+		LogRedactionEnabled:     cfg.Security.LogRedactionEnabled,
+		StatusPageRateLimit:     cfg.System.StatusPageRateLimit,
+		InactiveUserDays:        cfg.Security.PostureInactiveUserDays,
+		Now:                     time.Now(),
+	}
+
+	containers, _, err := s.containerRepo.List(ctx, &model.ContainerFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get containers: %w", err)
+	}
+	images := make(map[string]bool)
+	for _, container := range containers {
+		input.Containers = append(input.Containers, postureContainer(container))
+		images[container.GetFullImageName()] = true
+	}
+
+	names := make([]string, 0, len(images))
+	for name := range images {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		image := security.PostureImage{Image: name}
+		if scan, err := s.scanResultRepo.GetLatestByImage(ctx, name); err == nil {
+			image.Scanned = true
+			image.Passed = scan.Passed
+			image.Critical = scan.CriticalVulns
+			image.High = scan.HighVulns
+		}
+		input.Images = append(input.Images, image)
+	}
+
+	users, err := s.userRepo.GetActiveUsers(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+	for _, user := range users {
+		input.Users = append(input.Users, security.PostureUser{
+			Username:    user.Username,
+			Admin:       user.IsAdmin(),
+			LastLoginAt: user.LastLoginAt,
+			CreatedAt:   user.CreatedAt,
+		})
+	}
+
+	return input, nil
+}
+
+// postureContainer reads the hardening settings from a container's config
+func postureContainer(container *model.Container) security.PostureContainer {
+	var config struct {
+		Privileged   bool                     `json:"privileged"`
+		NetworkMode  string                   `json:"network_mode"`
+		User         string                   `json:"user"`
+		Resources    *docker.ResourceConfig   `json:"resources"`
+		Capabilities *docker.CapabilityConfig `json:"capabilities"`
+	}
+	if container.ConfigJSON != "" {
+		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err != nil {
+			logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to parse container config")
+		}
+	}
+
+	pc := security.PostureContainer{
+		Name:        container.Name,
+		Privileged:  config.Privileged,
+		NetworkMode: config.NetworkMode,
+		User:        config.User,
+	}
+	if config.Resources != nil {
+		pc.MemoryLimit = config.Resources.Memory
+	}
+	if config.Capabilities != nil {
+		pc.CapAdd = config.Capabilities.Add
+	}
+	return pc
+}
+
+// notifyRegressions tells every active admin what got worse
+func (s *SecurityPostureService) notifyRegressions(ctx context.Context, cmp *model.PostureComparison) {
+	if s.notificationService == nil || len(cmp.Regressed) == 0 {
+		return
+	}
+
+	lines := make([]string, 0, len(cmp.Regressed))
+	for _, f := range cmp.Regressed {
+		line := fmt.Sprintf("[%s] %s", f.Severity, f.Title)
+		if f.Resource != "" {
+			line += ": " + f.Resource
+		}
+		lines = append(lines, line)
+	}
+	title := fmt.Sprintf("Security posture regressed: %d findings (score %d to %d)", len(cmp.Regressed), cmp.FromScore, cmp.ToScore)
+	data := map[string]interface{}{
+		"from_id":   cmp.FromID,
+		"to_id":     cmp.ToID,
+		"regressed": cmp.Regressed,
+	}
+
+	users, err := s.userRepo.GetActiveUsers(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to get admins to notify of security posture regressions")
+		return
+	}
+	for _, user := range users {
+		if !user.IsAdmin() {
+			continue
+		}
+		userID := user.ID
+		if _, err := s.notificationService.CreateNotification(ctx, &userID, NotificationTypeWarning, title, strings.Join(lines, "\n"), data); err != nil {
+			logrus.WithError(err).WithField("user_id", userID).Warn("Failed to notify admin of security posture regressions")
+		}
+	}
+}
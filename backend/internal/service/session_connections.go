@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sessionConnection is a long-lived connection opened with a login session
+type sessionConnection struct {
+	userID int64
+	close  func()
+}
+
+// sessionConnections tracks long-lived connections, such as exec sockets, by
+// the login session they were opened with. The auth chain checks the session
+// only when a connection opens, so revoking it closes them here.
+type sessionConnections struct {
+	mu     sync.Mutex
+	nextID int
+	byID   map[string]map[int]sessionConnection
+}
+
+func (t *sessionConnections) add(userID int64, sessionID string, close func()) int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.byID == nil {
+		t.byID = make(map[string]map[int]sessionConnection)
+	}
+	if t.byID[sessionID] == nil {
+		t.byID[sessionID] = make(map[int]sessionConnection)
+	}
+	t.nextID++
+	t.byID[sessionID][t.nextID] = sessionConnection{userID: userID, close: close}
+	return t.nextID
+}
+
+// remove stops tracking a connection and returns it, unless it was closed
+// already
+func (t *sessionConnections) remove(sessionID string, id int) (sessionConnection, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conn, ok := t.byID[sessionID][id]
+	delete(t.byID[sessionID], id)
+	if len(t.byID[sessionID]) == 0 {
+		delete(t.byID, sessionID)
+	}
+	return conn, ok
+}
+
+// closeSessions closes the connections of the sessions and returns how many
+// it closed
+func (t *sessionConnections) closeSessions(sessionIDs ...string) int {
+	t.mu.Lock()
+	var closing []sessionConnection
+	for _, sessionID := range sessionIDs {
+		for _, conn := range t.byID[sessionID] {
+			closing = append(closing, conn)
+		}
+		delete(t.byID, sessionID)
+	}
+	t.mu.Unlock()
+
+	// Closing may block on the connection; the tracker stays usable
+	for _, conn := range closing {
+		conn.close()
+	}
+	return len(closing)
+}
+
+// closeUser closes the connections of every session of the user
+func (t *sessionConnections) closeUser(userID int64) int {
+	t.mu.Lock()
+	var sessionIDs []string
+	for sessionID, conns := range t.byID {
+		for _, conn := range conns {
+			if conn.userID == userID {
+				sessionIDs = append(sessionIDs, sessionID)
+				break
+			}
+		}
+	}
+	t.mu.Unlock()
+
+	return t.closeSessions(sessionIDs...)
+}
+
+// TrackSessionConnection closes a long-lived connection through close when
+// the login session it was opened with is revoked. A session revoked before
+// the connection is tracked, or not held by the user, closes it right away.
+// The caller releases the connection once it ends.
+func (s *UserService) TrackSessionConnection(ctx context.Context, userID int64, sessionID string, close func()) (release func()) {
+	id := s.connections.add(userID, sessionID, close)
+	release = func() { s.connections.remove(sessionID, id) }
+
+	if err := s.ResolveSession(ctx, userID, sessionID); err != nil {
+		logrus.WithField("session_id", sessionID).Info("Closing a connection of a revoked session")
+		if conn, ok := s.connections.remove(sessionID, id); ok {
+			conn.close()
+		}
+	}
+	return release
+}
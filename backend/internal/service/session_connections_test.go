@@ -0,0 +1,99 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+func TestRevokingSessionsClosesTheirConnections(t *testing.T) {
+	ctx := context.Background()
+	db := newTestDB(t, &model.User{}, &model.ActivityLog{})
+	// The uuid default of the model is postgres only
+	if err := db.Exec(`CREATE TABLE user_sessions (
+		id TEXT PRIMARY KEY, user_id INTEGER NOT NULL, refresh_token TEXT NOT NULL UNIQUE,
+		expires_at DATETIME NOT NULL, ip_address TEXT, user_agent TEXT, created_at DATETIME, last_seen_at DATETIME)`).Error; err != nil {
+		t.Fatalf("failed to create sessions table: %v", err)
+	}
+	users := []*model.User{
+		{ID: 1, Username: "alice", Email: "alice@example.com", Role: model.UserRoleOperator, IsActive: true},
+		{ID: 2, Username: "bob", Email: "bob@example.com", Role: model.UserRoleOperator, IsActive: true},
+	}
+	if err := db.Create(users).Error; err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	expires := time.Now().Add(time.Hour)
+	sessions := []*model.UserSession{
+		{ID: "laptop", UserID: 1, RefreshToken: "r1", ExpiresAt: expires},
+		{ID: "phone", UserID: 1, RefreshToken: "r2", ExpiresAt: expires},
+		{ID: "tablet", UserID: 1, RefreshToken: "r3", ExpiresAt: expires},
+		{ID: "desktop", UserID: 2, RefreshToken: "r4", ExpiresAt: expires},
+	}
+	if err := db.Create(sessions).Error; err != nil {
+		t.Fatalf("failed to create sessions: %v", err)
+	}
+
+	s := &UserService{
+		userRepo:     repository.NewUserRepository(db),
+		sessionRepo:  repository.NewUserSessionRepository(db),
+		activityRepo: repository.NewActivityLogRepository(db),
+	}
+
+	var mu sync.Mutex
+	var closed []string
+	track := func(userID int64, sessionID, name string) func() {
+		return s.TrackSessionConnection(ctx, userID, sessionID, func() {
+			mu.Lock()
+			defer mu.Unlock()
+			closed = append(closed, name)
+		})
+	}
+	expectClosed := func(step string, want ...string) {
+		t.Helper()
+		mu.Lock()
+		defer mu.Unlock()
+		sort.Strings(closed)
+		if !reflect.DeepEqual(closed, want) {
+			t.Errorf("%s closed %q, want %q", step, closed, want)
+		}
+		closed = nil
+	}
+
+	track(1, "laptop", "laptop shell")
+	track(1, "laptop", "laptop logs")
+	track(1, "phone", "phone shell")
+	release := track(1, "tablet", "tablet shell")
+	track(2, "desktop", "bob shell")
+	expectClosed("opening")
+
+	// A connection of another user's session is refused when it opens
+	track(2, "laptop", "stolen shell")
+	expectClosed("opening with alice's session as bob", "stolen shell")
+
+	if err := s.RevokeSession(ctx, model.UserActor(1, "alice"), 1, "laptop"); err != nil {
+		t.Fatal(err)
+	}
+	expectClosed("revoking the laptop", "laptop logs", "laptop shell")
+
+	// A session revoked before the connection is tracked closes it right away
+	track(1, "laptop", "late shell")
+	expectClosed("opening with the revoked laptop", "late shell")
+
+	// Released connections are left alone
+	release()
+	if _, err := s.RevokeOtherSessions(ctx, model.UserActor(1, "alice"), 1, "phone"); err != nil {
+		t.Fatal(err)
+	}
+	expectClosed("signing out elsewhere")
+
+	if err := s.DeactivateUser(ctx, 1); err != nil {
+		t.Fatal(err)
+	}
+	expectClosed("deactivating alice", "phone shell")
+}
@@ -0,0 +1,498 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// setupLockTTL bounds how long a setup submission may hold the bootstrap
+// lock. A lock older than this was left by a process that died mid-setup and
+// is cleared, so setup does not stay in progress forever.
+const setupLockTTL = 15 * time.Minute
+
+// SetupState describes where the system is in the first-run setup flow
+type SetupState string
+
+const (
+	SetupStateUninitialized SetupState = "uninitialized"
+	SetupStateInProgress    SetupState = "in_progress"
+	SetupStateComplete      SetupState = "complete"
+)
+
+var (
+	// ErrSetupComplete is returned when setup is attempted after it already finished
+	ErrSetupComplete = errors.New("setup has already been completed")
+
+	// ErrSetupInProgress is returned when another setup submission holds the bootstrap lock
+	ErrSetupInProgress = errors.New("setup is already in progress")
+)
+
+// SetupService drives the first-run setup wizard and bootstrap admin creation
+type SetupService struct {
+	userService *UserService
+	userRepo    repository.UserRepository
+	configRepo  repository.SystemConfigRepository
+	taskRepo    repository.ScheduledTaskRepository
+	config      *config.Config
+
+	mu       sync.Mutex
+	complete atomic.Bool
+}
+
+// NewSetupService creates a new setup service instance
+func NewSetupService(
+	userService *UserService,
+	userRepo repository.UserRepository,
+	configRepo repository.SystemConfigRepository,
+	taskRepo repository.ScheduledTaskRepository,
+	config *config.Config,
+) *SetupService {
+	return &SetupService{
+		userService: userService,
+		userRepo:    userRepo,
+		configRepo:  configRepo,
+		taskRepo:    taskRepo,
+		config:      config,
+	}
+}
+
+// SetupRequest represents the payload submitted by the setup wizard
+type SetupRequest struct {
+	Admin       SetupAdminRequest     `json:"admin" binding:"required"`
+	Docker      SetupDockerRequest    `json:"docker"`
+	Retention   SetupRetentionRequest `json:"retention"`
+	EnableTasks []string              `json:"enable_tasks,omitempty"`
+}
+
+// SetupAdminRequest holds the initial admin account
+type SetupAdminRequest struct {
+	Username string `json:"username" binding:"required,min=3,max=50"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+}
+
+// SetupDockerRequest holds the Docker connection settings to test and persist
+type SetupDockerRequest struct {
+	Host       string `json:"host,omitempty"`
+	APIVersion string `json:"api_version,omitempty"`
+	Timeout    int    `json:"timeout,omitempty"`
+}
+
+// SetupRetentionRequest holds the database retention defaults
+type SetupRetentionRequest struct {
+	LogRetentionDays        int `json:"log_retention_days,omitempty"`
+	HistoryRetentionCount   int `json:"history_retention_count,omitempty"`
+	ImageCacheRetentionDays int `json:"image_cache_retention_days,omitempty"`
+}
+
+// SetupStatus is returned by the setup status endpoint
+type SetupStatus struct {
+	State         SetupState                    `json:"state"`
+	AdminExists   bool                          `json:"admin_exists"`
+	TaskTemplates []model.ScheduledTaskTemplate `json:"task_templates,omitempty"`
+}
+
+// SetupResult summarises a completed setup run
+type SetupResult struct {
+	Admin         *UserResponse `json:"admin"`
+	DockerHost    string        `json:"docker_host"`
+	DockerVersion string        `json:"docker_version,omitempty"`
+	EnabledTasks  []string      `json:"enabled_tasks"`
+	CompletedAt   time.Time     `json:"completed_at"`
+}
+
+// Validate validates the setup request
+func (r *SetupRequest) Validate() error {
+	if r.Admin.Username == "" || r.Admin.Email == "" || r.Admin.Password == "" {
+		return fmt.Errorf("admin username, email and password are required")
+	}
+	if r.Docker.Timeout < 0 {
+		return fmt.Errorf("docker timeout cannot be negative")
+	}
+	if r.Retention.LogRetentionDays < 0 || r.Retention.HistoryRetentionCount < 0 || r.Retention.ImageCacheRetentionDays < 0 {
+		return fmt.Errorf("retention values cannot be negative")
+	}
+
+	templates := make(map[string]bool)
+	for _, tmpl := range model.GetDefaultTaskTemplates() {
+		templates[tmpl.Key] = true
+	}
+	for _, key := range r.EnableTasks {
+		if !templates[key] {
+			return fmt.Errorf("unknown task template: %s", key)
+		}
+	}
+
+	return nil
+}
+
+// IsComplete reports whether setup has finished; a positive answer is cached
+func (s *SetupService) IsComplete(ctx context.Context) bool {
+	if s.complete.Load() {
+		return true
+	}
+
+	state, err := s.currentState(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to determine setup state")
+		return false
+	}
+
+	return state == SetupStateComplete
+}
+
+// GetStatus returns the current setup state
+func (s *SetupService) GetStatus(ctx context.Context) (*SetupStatus, error) {
+	state, err := s.currentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	adminExists, err := s.adminExists(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &SetupStatus{
+		State:       state,
+		AdminExists: adminExists,
+	}
+	if state != SetupStateComplete {
+		status.TaskTemplates = model.GetDefaultTaskTemplates()
+	}
+
+	return status, nil
+}
+
+// RunSetup performs the bootstrap: admin creation, Docker settings, retention and default tasks
+func (s *SetupService) RunSetup(ctx context.Context, req *SetupRequest) (*SetupResult, error) {
+	if req == nil {
+		return nil, fmt.Errorf("setup request cannot be nil")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid setup request: %w", err)
+	}
+
+	// Serialize submissions within this process; the setup lock row below
+	// covers concurrent submissions hitting other replicas.
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state, err := s.currentState(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch state {
+	case SetupStateComplete:
+		return nil, ErrSetupComplete
+	case SetupStateInProgress:
+		return nil, ErrSetupInProgress
+	}
+
+	// Test the Docker connection before touching any state
+	dockerHost, dockerVersion, err := s.testDockerConnection(ctx, &req.Docker)
+	if err != nil {
+		return nil, fmt.Errorf("docker connection test failed: %w", err)
+	}
+
+	lock, err := s.acquireSetupLock(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.bootstrap(ctx, req, dockerHost)
+	if err != nil {
+		s.releaseSetupLock(ctx, lock)
+		return nil, err
+	}
+	result.DockerVersion = dockerVersion
+
+	s.complete.Store(true)
+	s.releaseSetupLock(ctx, lock)
+
+	logrus.WithFields(logrus.Fields{
+		"admin":         result.Admin.Username,
+		"docker_host":   result.DockerHost,
+		"enabled_tasks": result.EnabledTasks,
+	}).Info("First-run setup completed")
+
+	return result, nil
+}
+
+// bootstrap persists everything the wizard collected once the lock is held
+func (s *SetupService) bootstrap(ctx context.Context, req *SetupRequest, dockerHost string) (*SetupResult, error) {
+	// Re-check under the lock: an admin may have been created by another replica
+	if exists, err := s.adminExists(ctx); err != nil {
+		return nil, err
+	} else if exists {
+		return nil, ErrSetupComplete
+	}
+
+	admin, err := s.userService.CreateUser(ctx, &CreateUserRequest{
+		Username: req.Admin.Username,
+		Email:    req.Admin.Email,
+		Password: req.Admin.Password,
+		Role:     string(model.UserRoleAdmin),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create admin user: %w", err)
+	}
+
+	settings := []setupSetting{
+		{model.ConfigKeyDockerHost, strconv.Quote(dockerHost), "Docker daemon host"},
+	}
+	if req.Docker.APIVersion != "" {
+		settings = append(settings, setupSetting{
+			model.ConfigKeyDockerAPIVersion, strconv.Quote(req.Docker.APIVersion), "Docker API version",
+		})
+	}
+	if req.Retention.LogRetentionDays > 0 {
+		settings = append(settings, setupSetting{
+			model.ConfigKeyCleanupLogRetentionDays, strconv.Itoa(req.Retention.LogRetentionDays), "Log retention period in days",
+		})
+	}
+	if req.Retention.HistoryRetentionCount > 0 {
+		settings = append(settings, setupSetting{
+			model.ConfigKeyCleanupHistoryRetentionCount, strconv.Itoa(req.Retention.HistoryRetentionCount), "Update history retention count",
+		})
+	}
+	if req.Retention.ImageCacheRetentionDays > 0 {
+		settings = append(settings, setupSetting{
+			model.ConfigKeyCleanupImageCacheRetentionDays, strconv.Itoa(req.Retention.ImageCacheRetentionDays), "Image cache retention period in days",
+		})
+	}
+	for _, setting := range settings {
+		if err := s.upsertConfig(ctx, setting.key, setting.value, setting.description); err != nil {
+			s.rollbackAdmin(ctx, admin)
+			return nil, fmt.Errorf("failed to save settings: %w", err)
+		}
+	}
+
+	enabled, err := s.seedDefaultTasks(ctx, req.EnableTasks, admin)
+	if err != nil {
+		s.rollbackAdmin(ctx, admin)
+		return nil, err
+	}
+
+	if err := s.upsertConfig(ctx, model.ConfigKeyAppInitialized, "true", "Application initialization status"); err != nil {
+		s.rollbackAdmin(ctx, admin)
+		return nil, fmt.Errorf("failed to mark setup complete: %w", err)
+	}
+
+	return &SetupResult{
+		Admin:        s.userService.userToResponse(admin),
+		DockerHost:   dockerHost,
+		EnabledTasks: enabled,
+		CompletedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// setupSetting is a configuration entry written during bootstrap
+type setupSetting struct {
+	key, value, description string
+}
+
+// upsertConfig writes a configuration value, creating the key when missing
+func (s *SetupService) upsertConfig(ctx context.Context, key, value, description string) error {
+	existing, err := s.configRepo.GetByKey(ctx, key)
+	if err != nil {
+		return s.configRepo.Create(ctx, &model.SystemConfig{
+			ConfigKey:   key,
+			ConfigValue: value,
+			Description: description,
+			IsSystem:    key == model.ConfigKeyAppInitialized,
+		})
+	}
+
+	updated := *existing
+	updated.ConfigValue = value
+	return s.configRepo.Update(ctx, &updated)
+}
+
+// rollbackAdmin removes the bootstrap admin when a later setup step fails,
+// so the system returns to the uninitialized state and can be retried
+func (s *SetupService) rollbackAdmin(ctx context.Context, admin *model.User) {
+	if err := s.userRepo.Delete(ctx, admin.ID); err != nil {
+		logrus.WithError(err).WithField("user_id", admin.ID).Error("Failed to roll back bootstrap admin")
+	}
+}
+
+// seedDefaultTasks creates the selected task templates owned by the new admin
+func (s *SetupService) seedDefaultTasks(ctx context.Context, keys []string, admin *model.User) ([]string, error) {
+	enabled := make([]string, 0, len(keys))
+	if len(keys) == 0 {
+		return enabled, nil
+	}
+	if s.taskRepo == nil {
+		return nil, fmt.Errorf("scheduled task repository is not available")
+	}
+
+	selected := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		selected[key] = true
+	}
+
+	createdBy := int(admin.ID)
+	for _, tmpl := range model.GetDefaultTaskTemplates() {
+		if !selected[tmpl.Key] {
+			continue
+		}
+		if err := s.taskRepo.Create(ctx, tmpl.ToScheduledTask(&createdBy)); err != nil {
+			return nil, fmt.Errorf("failed to create task %q: %w", tmpl.Name, err)
+		}
+		enabled = append(enabled, tmpl.Key)
+	}
+
+	return enabled, nil
+}
+
+// testDockerConnection pings the daemon with the submitted settings
+func (s *SetupService) testDockerConnection(ctx context.Context, req *SetupDockerRequest) (string, string, error) {
+	host := req.Host
+	if host == "" {
+		host = s.config.Docker.Host
+	}
+
+	timeout := time.Duration(req.Timeout) * time.Second
+	if timeout <= 0 {
+		timeout = time.Duration(s.config.Docker.Timeout) * time.Second
+	}
+
+	client, err := docker.NewDockerClientWithConfig(docker.ClientConfig{
+		Host:       host,
+		APIVersion: req.APIVersion,
+		Timeout:    timeout,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	defer client.GetClient().Close()
+
+	pingCtx, cancel := client.WithTimeout(ctx)
+	defer cancel()
+
+	if err := client.Ping(pingCtx); err != nil {
+		return "", "", err
+	}
+
+	version := ""
+	if v, err := client.GetVersion(pingCtx); err == nil {
+		version = v.Version
+	}
+
+	return host, version, nil
+}
+
+// currentState derives the setup state from persisted configuration
+func (s *SetupService) currentState(ctx context.Context) (SetupState, error) {
+	if s.complete.Load() {
+		return SetupStateComplete, nil
+	}
+
+	initialized, err := s.configRepo.GetValueWithDefault(ctx, model.ConfigKeyAppInitialized, "false")
+	if err != nil {
+		return "", fmt.Errorf("failed to read setup state: %w", err)
+	}
+	if initialized == "true" {
+		s.complete.Store(true)
+		return SetupStateComplete, nil
+	}
+
+	// Installs that predate the wizard already have an admin; treat them as set up
+	locked := s.setupLockHeld(ctx)
+	if exists, err := s.adminExists(ctx); err != nil {
+		return "", err
+	} else if exists {
+		if locked {
+			return SetupStateInProgress, nil
+		}
+		s.complete.Store(true)
+		return SetupStateComplete, nil
+	}
+
+	if locked {
+		return SetupStateInProgress, nil
+	}
+
+	return SetupStateUninitialized, nil
+}
+
+// adminExists reports whether at least one admin account exists
+func (s *SetupService) adminExists(ctx context.Context) (bool, error) {
+	_, total, err := s.userRepo.List(ctx, &model.UserFilter{
+		Role:  model.UserRoleAdmin,
+		Limit: 1,
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to count admin users: %w", err)
+	}
+	return total > 0, nil
+}
+
+// acquireSetupLock claims the bootstrap lock row; the unique config key
+// guarantees only one submission can hold it at a time.
+func (s *SetupService) acquireSetupLock(ctx context.Context) (*model.SystemConfig, error) {
+	lock := &model.SystemConfig{
+		ConfigKey:   model.ConfigKeyAppSetupLock,
+		ConfigValue: strconv.Quote(time.Now().UTC().Format(time.RFC3339)),
+		Description: "First-run setup in progress",
+		IsSystem:    false,
+	}
+	if err := s.configRepo.Create(ctx, lock); err != nil {
+		// A stale lock is cleared by setupLockHeld; the unique key still lets
+		// only one of several retrying submissions win
+		if s.setupLockHeld(ctx) {
+			return nil, ErrSetupInProgress
+		}
+		lock.ID = 0
+		if err := s.configRepo.Create(ctx, lock); err != nil {
+			return nil, ErrSetupInProgress
+		}
+	}
+	return lock, nil
+}
+
+// setupLockHeld reports whether a submission holds the bootstrap lock. A
+// lock older than setupLockTTL is deleted and reported as free.
+func (s *SetupService) setupLockHeld(ctx context.Context) bool {
+	lock, err := s.configRepo.GetByKey(ctx, model.ConfigKeyAppSetupLock)
+	if err != nil {
+		return false
+	}
+
+	acquiredAt := lock.CreatedAt
+	if value, err := strconv.Unquote(lock.ConfigValue); err == nil {
+		if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+			acquiredAt = parsed
+		}
+	}
+	if time.Since(acquiredAt) < setupLockTTL {
+		return true
+	}
+
+	logrus.WithField("acquired_at", acquiredAt).Warn("Clearing stale setup lock left by an interrupted setup")
+	s.releaseSetupLock(ctx, lock)
+	return false
+}
+
+// releaseSetupLock drops the bootstrap lock so a failed setup can be retried
+func (s *SetupService) releaseSetupLock(ctx context.Context, lock *model.SystemConfig) {
+	if lock == nil || lock.ID == 0 {
+		return
+	}
+	if err := s.configRepo.Delete(ctx, int64(lock.ID)); err != nil {
+		logrus.WithError(err).Warn("Failed to release setup lock")
+	}
+}
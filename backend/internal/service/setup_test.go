@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+func newSetupTestService(t *testing.T) (*SetupService, repository.SystemConfigRepository) {
+	t.Helper()
+
+	db := newTestDB(t, &model.User{}, &model.SystemConfig{})
+	configRepo := repository.NewSystemConfigRepository(db)
+	return NewSetupService(nil, repository.NewUserRepository(db), configRepo, nil, nil), configRepo
+}
+
+// seedSetupLock stores a lock taken at acquiredAt, as acquireSetupLock does
+func seedSetupLock(t *testing.T, configRepo repository.SystemConfigRepository, acquiredAt time.Time) {
+	t.Helper()
+
+	if err := configRepo.Create(context.Background(), &model.SystemConfig{
+		ConfigKey:   model.ConfigKeyAppSetupLock,
+		ConfigValue: strconv.Quote(acquiredAt.UTC().Format(time.RFC3339)),
+	}); err != nil {
+		t.Fatalf("failed to seed setup lock: %v", err)
+	}
+}
+
+func TestSetupStateWithLock(t *testing.T) {
+	tests := []struct {
+		name       string
+		acquiredAt time.Duration // before now
+		want       SetupState
+		lockKept   bool
+	}{
+		{"live lock", time.Minute, SetupStateInProgress, true},
+		{"stale lock of a crashed setup", setupLockTTL + time.Minute, SetupStateUninitialized, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			setup, configRepo := newSetupTestService(t)
+			seedSetupLock(t, configRepo, time.Now().Add(-tt.acquiredAt))
+
+			state, err := setup.currentState(ctx)
+			if err != nil {
+				t.Fatalf("currentState failed: %v", err)
+			}
+			if state != tt.want {
+				t.Errorf("state = %s, want %s", state, tt.want)
+			}
+
+			_, err = configRepo.GetByKey(ctx, model.ConfigKeyAppSetupLock)
+			if kept := err == nil; kept != tt.lockKept {
+				t.Errorf("lock kept = %v, want %v", kept, tt.lockKept)
+			}
+		})
+	}
+}
+
+func TestAcquireSetupLock(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("held by another submission", func(t *testing.T) {
+		setup, configRepo := newSetupTestService(t)
+		seedSetupLock(t, configRepo, time.Now())
+
+		if _, err := setup.acquireSetupLock(ctx); !errors.Is(err, ErrSetupInProgress) {
+			t.Fatalf("acquireSetupLock error = %v, want ErrSetupInProgress", err)
+		}
+	})
+
+	t.Run("takes over a stale lock", func(t *testing.T) {
+		setup, configRepo := newSetupTestService(t)
+		seedSetupLock(t, configRepo, time.Now().Add(-2*setupLockTTL))
+
+		lock, err := setup.acquireSetupLock(ctx)
+		if err != nil {
+			t.Fatalf("acquireSetupLock failed: %v", err)
+		}
+		if state, _ := setup.currentState(ctx); state != SetupStateInProgress {
+			t.Errorf("state after acquiring = %s, want %s", state, SetupStateInProgress)
+		}
+
+		setup.releaseSetupLock(ctx, lock)
+		if state, _ := setup.currentState(ctx); state != SetupStateUninitialized {
+			t.Errorf("state after releasing = %s, want %s", state, SetupStateUninitialized)
+		}
+	})
+}
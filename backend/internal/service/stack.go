@@ -0,0 +1,571 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+var (
+	// ErrStackNotFound is returned when a stack does not exist
+	ErrStackNotFound = errors.New("stack not found")
+
+	// ErrStackExists is returned when another stack already has the name
+	ErrStackExists = errors.New("stack already exists")
+)
+
+// Stack group actions
+const (
+	StackActionStart   = "start"
+	StackActionStop    = "stop"
+	StackActionRestart = "restart"
+	StackActionUpdate  = "update"
+)
+
+// StackService manages container stacks: groups of containers operated
+// together that share update policy overrides
+type StackService struct {
+	stackRepo        repository.StackRepository
+	containerRepo    repository.ContainerRepository
+	containerService *ContainerService
+	imageService     *ImageService
+	policyService    *ImagePolicyService
+}
+
+// NewStackService creates a new stack service instance
+func NewStackService(
+	stackRepo repository.StackRepository,
+	containerRepo repository.ContainerRepository,
+	containerService *ContainerService,
+	imageService *ImageService,
+	policyService *ImagePolicyService,
+) *StackService {
+	return &StackService{
+		stackRepo:        stackRepo,
+		containerRepo:    containerRepo,
+		containerService: containerService,
+		imageService:     imageService,
+		policyService:    policyService,
+	}
+}
+
+// StackRequest represents a request to create or replace a stack. Containers
+// are started in the order given and stopped in reverse; omitted policy
+// settings fall through to image policies and global defaults.
+type StackRequest struct {
+	Name                   string                    `json:"name" binding:"required"`
+	Description            string                    `json:"description,omitempty"`
+	ContainerIDs           []int64                   `json:"container_ids"`
+	UpdatePolicy           *string                   `json:"update_policy,omitempty"`
+	CheckIntervalMinutes   *int                      `json:"check_interval_minutes,omitempty"`
+	HoldDownHours          *int                      `json:"hold_down_hours,omitempty"`
+	MaintenanceWindows     []model.MaintenanceWindow `json:"maintenance_windows,omitempty"`
+	VulnerabilityThreshold *string                   `json:"vulnerability_threshold,omitempty"`
+}
+
+// StackSummary is the list view of a stack
+type StackSummary struct {
+	ID             int                   `json:"id"`
+	Name           string                `json:"name"`
+	Description    string                `json:"description,omitempty"`
+	ComposeProject string                `json:"compose_project,omitempty"`
+	Status         model.ContainerStatus `json:"status"`
+	Members        int                   `json:"members"`
+	Running        int                   `json:"running"`
+	PendingUpdates int                   `json:"pending_updates"`
+	UpdatedAt      time.Time             `json:"updated_at"`
+}
+
+// StackDetail aggregates a stack's members, their health and pending updates
+type StackDetail struct {
+	*model.Stack
+	Status         model.ContainerStatus `json:"status"`
+	Members        []*StackMember        `json:"members"`
+	PendingUpdates int                   `json:"pending_updates"`
+}
+
+// StackMember is a member container as shown in a stack detail view
+type StackMember struct {
+	ContainerID     int64                  `json:"container_id"`
+	Name            string                 `json:"name"`
+	Image           string                 `json:"image"`
+	Tag             string                 `json:"tag"`
+	StackOrder      int                    `json:"stack_order"`
+	Status          model.ContainerStatus  `json:"status"`
+	Health          string                 `json:"health,omitempty"`
+	UpdateAvailable bool                   `json:"update_available"`
+	LatestVersion   string                 `json:"latest_version,omitempty"`
+	EffectivePolicy *model.EffectivePolicy `json:"effective_policy,omitempty"`
+}
+
+// StackOperationResult reports a group action on a stack member by member
+type StackOperationResult struct {
+	StackID int64                  `json:"stack_id"`
+	Action  string                 `json:"action"`
+	Status  model.ContainerStatus  `json:"status"`
+	Results []*dto.OperationResult `json:"results"`
+}
+
+// Validate validates StackRequest
+func (r *StackRequest) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+
+	if r.Name == "" {
+		return fmt.Errorf("stack name is required")
+	}
+	if len(r.Name) > 100 {
+		return fmt.Errorf("stack name must be at most 100 characters")
+	}
+	seen := make(map[int64]bool, len(r.ContainerIDs))
+	for _, id := range r.ContainerIDs {
+		if id <= 0 {
+			return fmt.Errorf("invalid container ID: %d", id)
+		}
+		if seen[id] {
+			return fmt.Errorf("container %d listed more than once", id)
+		}
+		seen[id] = true
+	}
+	if r.UpdatePolicy != nil && !dto.IsValidUpdatePolicy(*r.UpdatePolicy) {
+		return fmt.Errorf("invalid update policy")
+	}
+	if r.CheckIntervalMinutes != nil && *r.CheckIntervalMinutes < 1 {
+		return fmt.Errorf("check interval must be at least 1 minute")
+	}
+	if r.HoldDownHours != nil && *r.HoldDownHours < 0 {
+		return fmt.Errorf("hold-down cannot be negative")
+	}
+	if r.VulnerabilityThreshold != nil && !dto.IsValidVulnerabilityThreshold(*r.VulnerabilityThreshold) {
+		return fmt.Errorf("invalid vulnerability threshold")
+	}
+	if err := dto.ValidateMaintenanceWindows(r.MaintenanceWindows); err != nil {
+		return err
+	}
+	return nil
+}
+
+// apply copies the request onto the stack, clearing settings the request omits
+func (r *StackRequest) apply(stack *model.Stack) error {
+	stack.Name = r.Name
+	stack.Description = r.Description
+	stack.CheckIntervalMinutes = r.CheckIntervalMinutes
+	stack.HoldDownHours = r.HoldDownHours
+	stack.VulnerabilityThreshold = r.VulnerabilityThreshold
+
+	stack.UpdatePolicy = nil
+	if r.UpdatePolicy != nil {
+		updatePolicy := model.UpdatePolicy(*r.UpdatePolicy)
+		stack.UpdatePolicy = &updatePolicy
+	}
+
+	stack.MaintenanceWindows = ""
+	if len(r.MaintenanceWindows) > 0 {
+		windowsJSON, err := json.Marshal(r.MaintenanceWindows)
+		if err != nil {
+			return fmt.Errorf("failed to marshal maintenance windows: %w", err)
+		}
+		stack.MaintenanceWindows = string(windowsJSON)
+	}
+
+	return nil
+}
+
+// ListStacks lists the user's stacks, or every stack for admins, with their combined status
+func (s *StackService) ListStacks(ctx context.Context, userID int64, filter *model.StackFilter) ([]*StackSummary, int64, error) {
+	if filter == nil {
+		filter = &model.StackFilter{}
+	}
+	admin, err := s.isAdmin(ctx, userID)
+	if err != nil {
+		return nil, 0, err
+	}
+	if !admin {
+		userIDInt := int(userID)
+		filter.CreatedBy = &userIDInt
+	}
+
+	stacks, total, err := s.stackRepo.List(ctx, filter)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to list stacks: %w", err)
+	}
+
+	summaries := make([]*StackSummary, 0, len(stacks))
+	for _, stack := range stacks {
+		summary := &StackSummary{
+			ID:             stack.ID,
+			Name:           stack.Name,
+			Description:    stack.Description,
+			ComposeProject: stack.ComposeProject,
+			Status:         model.CombinedStatus(stack.Members),
+			Members:        len(stack.Members),
+			UpdatedAt:      stack.UpdatedAt,
+		}
+		for _, member := range stack.Members {
+			if member.IsRunning() {
+				summary.Running++
+			}
+			if available, _ := s.pendingUpdate(member); available {
+				summary.PendingUpdates++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+
+	return summaries, total, nil
+}
+
+// GetStack returns a stack with its members' health, pending updates and
+// effective policies
+func (s *StackService) GetStack(ctx context.Context, userID int64, stackID int64) (*StackDetail, error) {
+	stack, err := s.getOwnedStack(ctx, userID, stackID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := stack.OrderedMembers()
+	detail := &StackDetail{
+		Stack:   stack,
+		Status:  model.CombinedStatus(members),
+		Members: make([]*StackMember, 0, len(members)),
+	}
+
+	for _, container := range members {
+		member := &StackMember{
+			ContainerID: int64(container.ID),
+			Name:        container.Name,
+			Image:       container.Image,
+			Tag:         container.Tag,
+			StackOrder:  container.StackOrder,
+			Status:      container.Status,
+		}
+
+		if status, err := s.containerService.GetContainerStatus(ctx, userActor(ctx, userID), int64(container.ID)); err == nil {
+			member.Health = status.Health
+		}
+
+		member.UpdateAvailable, member.LatestVersion = s.pendingUpdate(container)
+		if member.UpdateAvailable {
+			detail.PendingUpdates++
+		}
+
+		if effective, err := s.containerService.EffectivePolicy(ctx, container); err == nil {
+			member.EffectivePolicy = effective
+		}
+
+		detail.Members = append(detail.Members, member)
+	}
+
+	return detail, nil
+}
+
+// CreateStack creates a stack from containers the user owns
+func (s *StackService) CreateStack(ctx context.Context, userID int64, req *StackRequest) (*StackDetail, error) {
+	if req == nil {
+		return nil, fmt.Errorf("stack request cannot be nil")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	if err := s.checkMembers(ctx, userID, 0, req.ContainerIDs); err != nil {
+		return nil, err
+	}
+
+	userIDInt := int(userID)
+	stack := &model.Stack{CreatedBy: &userIDInt}
+	if err := req.apply(stack); err != nil {
+		return nil, err
+	}
+
+	if err := s.stackRepo.CreateWithMembers(ctx, stack, req.ContainerIDs); err != nil {
+		return nil, translateStackError(err)
+	}
+
+	s.invalidateMembers(req.ContainerIDs)
+
+	logrus.WithFields(logrus.Fields{
+		"stack_id":   stack.ID,
+		"stack_name": stack.Name,
+		"members":    len(req.ContainerIDs),
+		"user_id":    userID,
+	}).Info("Stack created")
+
+	return s.GetStack(ctx, userID, int64(stack.ID))
+}
+
+// UpdateStack replaces a stack's settings and membership
+func (s *StackService) UpdateStack(ctx context.Context, userID int64, stackID int64, req *StackRequest) (*StackDetail, error) {
+	if req == nil {
+		return nil, fmt.Errorf("stack request cannot be nil")
+	}
+	if err := req.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	stack, err := s.getOwnedStack(ctx, userID, stackID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkMembers(ctx, userID, stackID, req.ContainerIDs); err != nil {
+		return nil, err
+	}
+
+	if stack.Name != req.Name {
+		if existing, err := s.stackRepo.GetByName(ctx, req.Name); err == nil && existing.ID != stack.ID {
+			return nil, ErrStackExists
+		}
+	}
+
+	previous := make([]int64, 0, len(stack.Members))
+	for _, member := range stack.Members {
+		previous = append(previous, int64(member.ID))
+	}
+
+	if err := req.apply(stack); err != nil {
+		return nil, err
+	}
+	if err := s.stackRepo.UpdateWithMembers(ctx, stack, req.ContainerIDs); err != nil {
+		return nil, translateStackError(err)
+	}
+
+	s.invalidateMembers(previous)
+	s.invalidateMembers(req.ContainerIDs)
+
+	logrus.WithFields(logrus.Fields{
+		"stack_id":   stack.ID,
+		"stack_name": stack.Name,
+		"members":    len(req.ContainerIDs),
+		"user_id":    userID,
+	}).Info("Stack updated")
+
+	return s.GetStack(ctx, userID, stackID)
+}
+
+// DeleteStack deletes a stack. With deleteMembers the member containers are
+// deleted too, in stop order; otherwise they are only released from the group.
+func (s *StackService) DeleteStack(ctx context.Context, userID int64, stackID int64, deleteMembers bool) (*StackOperationResult, error) {
+	stack, err := s.getOwnedStack(ctx, userID, stackID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &StackOperationResult{
+		StackID: stackID,
+		Action:  "dissolve",
+		Results: []*dto.OperationResult{},
+	}
+
+	members := stack.OrderedMembers()
+	memberIDs := make([]int64, 0, len(members))
+	for _, member := range members {
+		memberIDs = append(memberIDs, int64(member.ID))
+	}
+
+	if deleteMembers {
+		result.Action = "delete"
+		failed := false
+		for i := len(members) - 1; i >= 0; i-- {
+			member := members[i]
+			op := &dto.OperationResult{ContainerID: int64(member.ID), Name: member.Name, Success: true, Message: "Container deleted"}
+			if err := s.containerService.DeleteContainer(ctx, userActor(ctx, userID), int64(member.ID)); err != nil {
+				op.Success = false
+				op.Message = ""
+				op.Error = err.Error()
+				failed = true
+			}
+			result.Results = append(result.Results, op)
+		}
+		if failed {
+			// Keep the stack so the remaining members stay grouped
+			result.Status = model.ContainerStatusUnknown
+			return result, nil
+		}
+	}
+
+	if err := s.stackRepo.Delete(ctx, stackID); err != nil {
+		return nil, translateStackError(err)
+	}
+
+	s.invalidateMembers(memberIDs)
+
+	logrus.WithFields(logrus.Fields{
+		"stack_id":       stackID,
+		"stack_name":     stack.Name,
+		"delete_members": deleteMembers,
+		"user_id":        userID,
+	}).Info("Stack deleted")
+
+	return result, nil
+}
+
+// RunAction starts, stops, restarts or updates every member of a stack. Start,
+// restart and update follow the start order; stop runs in reverse. Starting
+// stops at the first failure so dependents don't come up without what they need.
+func (s *StackService) RunAction(ctx context.Context, userID int64, stackID int64, action string, updateReq *dto.UpdateImageRequest) (*StackOperationResult, error) {
+	stack, err := s.getOwnedStack(ctx, userID, stackID)
+	if err != nil {
+		return nil, err
+	}
+
+	members := stack.OrderedMembers()
+	if action == StackActionStop {
+		for i, j := 0, len(members)-1; i < j; i, j = i+1, j-1 {
+			members[i], members[j] = members[j], members[i]
+		}
+	}
+
+	result := &StackOperationResult{
+		StackID: stackID,
+		Action:  action,
+		Results: make([]*dto.OperationResult, 0, len(members)),
+	}
+
+	halted := false
+	for _, member := range members {
+		op := &dto.OperationResult{ContainerID: int64(member.ID), Name: member.Name}
+		if halted {
+			op.Error = "skipped: an earlier member failed"
+			result.Results = append(result.Results, op)
+			continue
+		}
+
+		var err error
+		switch action {
+		case StackActionStart:
+			op.Warnings, err = s.containerService.StartContainer(ctx, userActor(ctx, userID), int64(member.ID))
+		case StackActionStop:
+			err = s.containerService.StopContainer(ctx, userActor(ctx, userID), int64(member.ID))
+		case StackActionRestart:
+			err = s.containerService.RestartContainer(ctx, userActor(ctx, userID), int64(member.ID))
+		case StackActionUpdate:
+			_, err = s.containerService.UpdateContainerImage(ctx, userActor(ctx, userID), int64(member.ID), updateReq)
+		default:
+			return nil, fmt.Errorf("invalid request: unsupported stack action '%s'", action)
+		}
+
+		if err != nil {
+			op.Error = err.Error()
+			halted = action != StackActionStop
+		} else {
+			op.Success = true
+			op.Message = fmt.Sprintf("Container %s completed", action)
+		}
+		result.Results = append(result.Results, op)
+	}
+
+	if refreshed, err := s.stackRepo.GetByID(ctx, stackID); err == nil {
+		result.Status = model.CombinedStatus(refreshed.Members)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"stack_id":   stackID,
+		"stack_name": stack.Name,
+		"action":     action,
+		"status":     result.Status,
+		"user_id":    userID,
+	}).Info("Stack action completed")
+
+	return result, nil
+}
+
+// getOwnedStack loads a stack and checks the user created it or is an admin
+func (s *StackService) getOwnedStack(ctx context.Context, userID int64, stackID int64) (*model.Stack, error) {
+	stack, err := s.stackRepo.GetByID(ctx, stackID)
+	if err != nil {
+		return nil, translateStackError(err)
+	}
+	if stack.CreatedBy != nil && int64(*stack.CreatedBy) == userID {
+		return stack, nil
+	}
+
+	admin, err := s.isAdmin(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !admin {
+		return nil, fmt.Errorf("access denied: stack belongs to different user")
+	}
+	return stack, nil
+}
+
+// isAdmin reports whether the user manages every stack, as admins manage
+// every container
+func (s *StackService) isAdmin(ctx context.Context, userID int64) (bool, error) {
+	user, err := s.containerService.permissionUser(ctx, userActor(ctx, userID))
+	if err != nil {
+		return false, err
+	}
+	return user.IsAdmin(), nil
+}
+
+// checkMembers verifies the user owns every container and none belongs to
+// another stack
+func (s *StackService) checkMembers(ctx context.Context, userID int64, stackID int64, containerIDs []int64) error {
+	if len(containerIDs) == 0 {
+		return nil
+	}
+
+	containers, err := s.containerRepo.GetByIDs(ctx, containerIDs)
+	if err != nil {
+		return fmt.Errorf("failed to get containers: %w", err)
+	}
+	if len(containers) != len(containerIDs) {
+		return fmt.Errorf("invalid request: one or more containers not found")
+	}
+
+	for _, container := range containers {
+		if err := s.containerService.checkContainerPermission(ctx, container, userActor(ctx, userID), model.ContainerPermissionManage); err != nil {
+			return err
+		}
+		if container.StackID != nil && int64(*container.StackID) != stackID {
+			return fmt.Errorf("invalid request: container '%s' already belongs to another stack", container.Name)
+		}
+	}
+
+	return nil
+}
+
+// pendingUpdate reports a member's pending update from the last image check
+func (s *StackService) pendingUpdate(container *model.Container) (bool, string) {
+	if container.HasDigestDrift() {
+		return true, container.PendingDigest
+	}
+	if s.imageService == nil {
+		return false, ""
+	}
+	if info, found := s.imageService.GetCachedUpdateInfo(int64(container.ID)); found && info.UpdateAvailable {
+		return true, info.LatestTag
+	}
+	return false, ""
+}
+
+// invalidateMembers drops cached details of containers whose stack changed so
+// their effective policy is recomputed
+func (s *StackService) invalidateMembers(containerIDs []int64) {
+	if s.containerService == nil || s.containerService.cache == nil {
+		return
+	}
+	for _, id := range containerIDs {
+		s.containerService.cache.Delete(fmt.Sprintf("container:detail:%d", id))
+	}
+}
+
+// translateStackError maps repository errors onto the stack sentinel errors
+func translateStackError(err error) error {
+	switch {
+	case strings.Contains(err.Error(), "not found"):
+		return fmt.Errorf("%w: %v", ErrStackNotFound, err)
+	case strings.Contains(err.Error(), "already exists"):
+		return fmt.Errorf("%w: %v", ErrStackExists, err)
+	default:
+		return err
+	}
+}
@@ -0,0 +1,214 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+const (
+	stackOwnerID int64 = 1
+	stackAdminID int64 = 2
+	stackOtherID int64 = 3
+)
+
+func newStackTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	db := newTestDB(t, &model.User{}, &model.Container{}, &model.Stack{}, &model.ImagePolicy{})
+	users := []*model.User{
+		{ID: stackOwnerID, Username: "owner", Email: "owner@example.com", Role: model.UserRoleOperator},
+		{ID: stackAdminID, Username: "admin", Email: "admin@example.com", Role: model.UserRoleAdmin},
+		{ID: stackOtherID, Username: "other", Email: "other@example.com", Role: model.UserRoleOperator},
+	}
+	if err := db.Create(users).Error; err != nil {
+		t.Fatalf("failed to create users: %v", err)
+	}
+	return db
+}
+
+func policyPtr(policy model.UpdatePolicy) *model.UpdatePolicy {
+	return &policy
+}
+
+func newStackTestService(t *testing.T, db *gorm.DB, containerRepo repository.ContainerRepository) *StackService {
+	t.Helper()
+
+	cfg := &config.Config{}
+	stackRepo := repository.NewStackRepository(db)
+	containerService := &ContainerService{
+		containerRepo: containerRepo,
+		dockerClient: newFakeDockerClient(t, func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+		}),
+		userService:   &UserService{userRepo: repository.NewUserRepository(db)},
+		policyService: NewImagePolicyService(repository.NewImagePolicyRepository(db), containerRepo, stackRepo, nil, cfg),
+		config:        cfg,
+	}
+	return NewStackService(stackRepo, containerRepo, containerService, nil, containerService.policyService)
+}
+
+// phantomContainerRepo reports containers as present that the database
+// doesn't hold, so membership fails only once the stack row is written
+type phantomContainerRepo struct {
+	repository.ContainerRepository
+}
+
+func (r *phantomContainerRepo) GetByIDs(ctx context.Context, ids []int64) ([]*model.Container, error) {
+	owner := int(stackOwnerID)
+	containers := make([]*model.Container, 0, len(ids))
+	for _, id := range ids {
+		containers = append(containers, &model.Container{ID: int(id), Name: "phantom", CreatedBy: &owner})
+	}
+	return containers, nil
+}
+
+func TestCreateStackLeavesNoStackWhenMembersFail(t *testing.T) {
+	ctx := context.Background()
+	db := newStackTestDB(t)
+	s := newStackTestService(t, db, &phantomContainerRepo{repository.NewContainerRepository(db)})
+
+	_, err := s.CreateStack(ctx, stackOwnerID, &StackRequest{Name: "web", ContainerIDs: []int64{99}})
+	if err == nil || !strings.Contains(err.Error(), "container with ID 99 not found") {
+		t.Fatalf("CreateStack error = %v, want the missing member", err)
+	}
+
+	var count int64
+	if err := db.Model(&model.Stack{}).Count(&count).Error; err != nil {
+		t.Fatalf("failed to count stacks: %v", err)
+	}
+	if count != 0 {
+		t.Errorf("%d stacks left behind, want none", count)
+	}
+}
+
+func TestStackAccessOwnerAndAdmin(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   int64
+		allowed  bool
+		listSize int
+	}{
+		{"owner", stackOwnerID, true, 1},
+		{"admin", stackAdminID, true, 2},
+		{"other user", stackOtherID, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newStackTestDB(t)
+			s := newStackTestService(t, db, repository.NewContainerRepository(db))
+
+			owned, err := s.CreateStack(ctx, stackOwnerID, &StackRequest{Name: "web"})
+			if err != nil {
+				t.Fatalf("CreateStack failed: %v", err)
+			}
+			if _, err := s.CreateStack(ctx, stackOtherID, &StackRequest{Name: "db"}); err != nil {
+				t.Fatalf("CreateStack failed: %v", err)
+			}
+			stackID := int64(owned.ID)
+
+			_, err = s.GetStack(ctx, tt.userID, stackID)
+			checkStackAccess(t, "GetStack", err, tt.allowed)
+
+			_, err = s.UpdateStack(ctx, tt.userID, stackID, &StackRequest{Name: "web-renamed"})
+			checkStackAccess(t, "UpdateStack", err, tt.allowed)
+
+			_, err = s.RunAction(ctx, tt.userID, stackID, StackActionStart, nil)
+			checkStackAccess(t, "RunAction", err, tt.allowed)
+
+			summaries, _, err := s.ListStacks(ctx, tt.userID, nil)
+			if err != nil {
+				t.Fatalf("ListStacks failed: %v", err)
+			}
+			if len(summaries) != tt.listSize {
+				t.Errorf("ListStacks returned %d stacks, want %d", len(summaries), tt.listSize)
+			}
+
+			_, err = s.DeleteStack(ctx, tt.userID, stackID, false)
+			checkStackAccess(t, "DeleteStack", err, tt.allowed)
+		})
+	}
+}
+
+func checkStackAccess(t *testing.T, op string, err error, allowed bool) {
+	t.Helper()
+
+	if allowed && err != nil {
+		t.Errorf("%s failed: %v", op, err)
+	}
+	if !allowed && (err == nil || !strings.HasPrefix(err.Error(), "access denied")) {
+		t.Errorf("%s error = %v, want access denied", op, err)
+	}
+}
+
+// TestStackMemberPolicyPrecedence checks that stack members resolve their
+// update policy as container > stack > image > global
+func TestStackMemberPolicyPrecedence(t *testing.T) {
+	tests := []struct {
+		name       string
+		container  model.UpdatePolicy
+		stack      *model.UpdatePolicy
+		image      *model.UpdatePolicy
+		wantPolicy model.UpdatePolicy
+		wantSource model.PolicySource
+	}{
+		{"container beats stack", model.UpdatePolicyAuto, policyPtr(model.UpdatePolicyDisabled), policyPtr(model.UpdatePolicyScheduled), model.UpdatePolicyAuto, model.PolicySourceContainer},
+		{"stack beats image", model.UpdatePolicyInherit, policyPtr(model.UpdatePolicyDisabled), policyPtr(model.UpdatePolicyScheduled), model.UpdatePolicyDisabled, model.PolicySourceStack},
+		{"stack set to inherit defers to image", model.UpdatePolicyInherit, policyPtr(model.UpdatePolicyInherit), policyPtr(model.UpdatePolicyScheduled), model.UpdatePolicyScheduled, model.PolicySourceImage},
+		{"stack without policy defers to image", model.UpdatePolicyInherit, nil, policyPtr(model.UpdatePolicyScheduled), model.UpdatePolicyScheduled, model.PolicySourceImage},
+		{"global when nothing is set", model.UpdatePolicyInherit, nil, nil, model.UpdatePolicyManual, model.PolicySourceGlobal},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			db := newStackTestDB(t)
+			s := newStackTestService(t, db, repository.NewContainerRepository(db))
+
+			owner := int(stackOwnerID)
+			stack := &model.Stack{Name: "web", UpdatePolicy: tt.stack, CreatedBy: &owner}
+			if err := db.Create(stack).Error; err != nil {
+				t.Fatalf("failed to create stack: %v", err)
+			}
+			if tt.image != nil {
+				if err := db.Create(&model.ImagePolicy{Repository: "library/nginx", UpdatePolicy: tt.image}).Error; err != nil {
+					t.Fatalf("failed to create image policy: %v", err)
+				}
+			}
+			// Membership is seeded directly: the change feed SetMembers
+			// records needs PostgreSQL
+			container := &model.Container{Name: "web-1", Image: "nginx", Tag: "latest", UpdatePolicy: tt.container, StackID: &stack.ID, CreatedBy: &owner}
+			if err := db.Create(container).Error; err != nil {
+				t.Fatalf("failed to create container: %v", err)
+			}
+
+			detail, err := s.GetStack(ctx, stackOwnerID, int64(stack.ID))
+			if err != nil {
+				t.Fatalf("GetStack failed: %v", err)
+			}
+			if len(detail.Members) != 1 || detail.Members[0].EffectivePolicy == nil {
+				t.Fatalf("members = %+v, want one with an effective policy", detail.Members)
+			}
+
+			effective := detail.Members[0].EffectivePolicy
+			if effective.UpdatePolicy != tt.wantPolicy {
+				t.Errorf("update policy = %s, want %s", effective.UpdatePolicy, tt.wantPolicy)
+			}
+			if source := effective.Sources["update_policy"]; source != tt.wantSource {
+				t.Errorf("update policy source = %s, want %s", source, tt.wantSource)
+			}
+			if effective.StackID == nil || *effective.StackID != stack.ID {
+				t.Errorf("stack = %v, want %d", effective.StackID, stack.ID)
+			}
+		})
+	}
+}
@@ -0,0 +1,348 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// statusPageTokenBytes is the entropy of status page tokens
+const statusPageTokenBytes = 24
+
+// StatusPageService manages public status pages and renders them from the
+// containers' status and health
+type StatusPageService struct {
+	pageRepo        repository.StatusPageRepository
+	containerRepo   repository.ContainerRepository
+	healthStateRepo repository.ContainerHealthStateRepository
+	activityRepo    repository.ActivityLogRepository
+	cache           *CacheService
+	config          *config.Config
+}
+
+// NewStatusPageService creates a new status page service instance
+func NewStatusPageService(
+	pageRepo repository.StatusPageRepository,
+	containerRepo repository.ContainerRepository,
+	healthStateRepo repository.ContainerHealthStateRepository,
+	activityRepo repository.ActivityLogRepository,
+	cache *CacheService,
+	config *config.Config,
+) *StatusPageService {
+	return &StatusPageService{
+		pageRepo:        pageRepo,
+		containerRepo:   containerRepo,
+		healthStateRepo: healthStateRepo,
+		activityRepo:    activityRepo,
+		cache:           cache,
+		config:          config,
+	}
+}
+
+// StatusPageRequest creates or replaces a status page
+type StatusPageRequest struct {
+	Title      string                      `json:"title" binding:"required"`
+	Enabled    bool                        `json:"enabled"`
+	Components []model.StatusPageComponent `json:"components"`
+	// SignalStates overrides the default state of signals such as
+	// "container:restarting" or "health:warning"
+	SignalStates model.StatusSignalStates `json:"signal_states,omitempty"`
+}
+
+// PublicStatus is what a status page shows: display names and states only
+type PublicStatus struct {
+	Title     string               `json:"title"`
+	Status    model.ComponentState `json:"status"`
+	Groups    []*PublicStatusGroup `json:"groups"`
+	UpdatedAt time.Time            `json:"updated_at"`
+}
+
+// PublicStatusGroup is a group of components, unnamed for ungrouped ones
+type PublicStatusGroup struct {
+	Name       string                   `json:"name,omitempty"`
+	Status     model.ComponentState     `json:"status"`
+	Components []*PublicStatusComponent `json:"components"`
+}
+
+// PublicStatusComponent is the state of one component
+type PublicStatusComponent struct {
+	Name           string               `json:"name"`
+	Status         model.ComponentState `json:"status"`
+	LastIncidentAt *time.Time           `json:"last_incident_at,omitempty"`
+	LastResolvedAt *time.Time           `json:"last_resolved_at,omitempty"`
+}
+
+// CacheTTL returns how long a rendered status page may be served from cache
+func (s *StatusPageService) CacheTTL() time.Duration {
+	if s.config != nil && s.config.System.StatusPageCacheSeconds > 0 {
+		return time.Duration(s.config.System.StatusPageCacheSeconds) * time.Second
+	}
+	return 30 * time.Second
+}
+
+// ListPages returns every status page
+func (s *StatusPageService) ListPages(ctx context.Context) ([]*model.StatusPage, error) {
+	return s.pageRepo.List(ctx)
+}
+
+// GetPage returns a status page
+func (s *StatusPageService) GetPage(ctx context.Context, id int) (*model.StatusPage, error) {
+	return s.pageRepo.GetByID(ctx, id)
+}
+
+// CreatePage creates a status page with a new token
+func (s *StatusPageService) CreatePage(ctx context.Context, actor model.Actor, req *StatusPageRequest) (*model.StatusPage, error) {
+	token, err := newStatusPageToken()
+	if err != nil {
+		return nil, err
+	}
+
+	page := &model.StatusPage{Token: token}
+	if actor.UserID != nil {
+		createdBy := int(*actor.UserID)
+		page.CreatedBy = &createdBy
+	}
+	if err := s.applyRequest(ctx, page, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.pageRepo.Create(ctx, page); err != nil {
+		return nil, err
+	}
+
+	s.logPageActivity(actor, "status_page_create", page, "Status page created")
+	return page, nil
+}
+
+// UpdatePage replaces the configuration of a status page, keeping its token
+func (s *StatusPageService) UpdatePage(ctx context.Context, actor model.Actor, id int, req *StatusPageRequest) (*model.StatusPage, error) {
+	page, err := s.pageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRequest(ctx, page, req); err != nil {
+		return nil, err
+	}
+
+	if err := s.pageRepo.Update(ctx, page); err != nil {
+		return nil, err
+	}
+	s.invalidate(page)
+
+	s.logPageActivity(actor, "status_page_update", page, "Status page updated")
+	return page, nil
+}
+
+// RotateToken gives a status page a new token; the old URL stops working
+func (s *StatusPageService) RotateToken(ctx context.Context, actor model.Actor, id int) (*model.StatusPage, error) {
+	page, err := s.pageRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newStatusPageToken()
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(page)
+	page.Token = token
+
+	if err := s.pageRepo.Update(ctx, page); err != nil {
+		return nil, err
+	}
+
+	s.logPageActivity(actor, "status_page_rotate_token", page, "Status page token rotated")
+	return page, nil
+}
+
+// DeletePage deletes a status page
+func (s *StatusPageService) DeletePage(ctx context.Context, actor model.Actor, id int) error {
+	page, err := s.pageRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.pageRepo.Delete(ctx, id); err != nil {
+		return err
+	}
+	s.invalidate(page)
+
+	s.logPageActivity(actor, "status_page_delete", page, "Status page deleted")
+	return nil
+}
+
+// GetPublicStatus renders the enabled status page with the token. It returns
+// nil without an error when no enabled page has the token, so callers cannot
+// tell a disabled page from a missing one.
+func (s *StatusPageService) GetPublicStatus(ctx context.Context, token string) (*PublicStatus, error) {
+	if len(token) > 64 {
+		return nil, nil
+	}
+
+	cacheKey := "status_page:" + token
+	if s.cache != nil {
+		if cached, found := s.cache.Get(cacheKey); found {
+			if status, ok := cached.(*PublicStatus); ok {
+				return status, nil
+			}
+		}
+	}
+
+	page, err := s.pageRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if page == nil || !page.Enabled {
+		return nil, nil
+	}
+
+	status := s.render(ctx, page)
+
+	if s.cache != nil {
+		if err := s.cache.Set(cacheKey, status, s.CacheTTL()); err != nil {
+			logrus.WithError(err).WithField("status_page_id", page.ID).Debug("Failed to cache status page")
+		}
+	}
+	return status, nil
+}
+
+// render builds the public view of a page. Components whose container is
+// gone show as down rather than failing the page.
+func (s *StatusPageService) render(ctx context.Context, page *model.StatusPage) *PublicStatus {
+	status := &PublicStatus{
+		Title:     page.Title,
+		Status:    model.ComponentOperational,
+		Groups:    []*PublicStatusGroup{},
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	groups := make(map[string]*PublicStatusGroup)
+	down := 0
+	for _, configured := range page.Components {
+		component := s.componentStatus(ctx, page, configured)
+
+		group, ok := groups[configured.Group]
+		if !ok {
+			group = &PublicStatusGroup{Name: configured.Group, Status: model.ComponentOperational}
+			groups[configured.Group] = group
+			status.Groups = append(status.Groups, group)
+		}
+		group.Components = append(group.Components, component)
+		group.Status = group.Status.Worse(component.Status)
+
+		if component.Status == model.ComponentDown {
+			down++
+		}
+		status.Status = status.Status.Worse(component.Status)
+	}
+
+	// A single component down is a partial outage; the page is only down
+	// when every component is
+	if status.Status == model.ComponentDown && down < len(page.Components) {
+		status.Status = model.ComponentDegraded
+	}
+
+	return status
+}
+
+func (s *StatusPageService) componentStatus(ctx context.Context, page *model.StatusPage, configured model.StatusPageComponent) *PublicStatusComponent {
+	component := &PublicStatusComponent{Name: configured.DisplayName, Status: model.ComponentDown}
+
+	container, err := s.containerRepo.GetByID(ctx, int64(configured.ContainerID))
+	if err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"status_page_id": page.ID,
+			"container_id":   configured.ContainerID,
+		}).Warn("Status page component has no container")
+		return component
+	}
+
+	component.Status = page.SignalStates.StateOf(model.StatusSignalContainerPrefix + string(container.Status))
+
+	if s.healthStateRepo != nil {
+		state, err := s.healthStateRepo.GetByContainerID(ctx, configured.ContainerID)
+		if err != nil {
+			logrus.WithError(err).WithField("container_id", configured.ContainerID).Warn("Failed to get health state for status page")
+			return component
+		}
+		component.Status = component.Status.Worse(page.SignalStates.StateOf(model.StatusSignalHealthPrefix + state.Status))
+		component.LastIncidentAt = state.LastIncidentAt
+		if state.LastRecoveredAt != nil && state.LastIncidentAt != nil && state.LastRecoveredAt.After(*state.LastIncidentAt) {
+			component.LastResolvedAt = state.LastRecoveredAt
+		}
+	}
+
+	return component
+}
+
+// applyRequest validates a request and copies it onto page
+func (s *StatusPageService) applyRequest(ctx context.Context, page *model.StatusPage, req *StatusPageRequest) error {
+	page.Title = req.Title
+	page.Enabled = req.Enabled
+	page.Components = req.Components
+	page.SignalStates = req.SignalStates
+	if page.Components == nil {
+		page.Components = model.StatusPageComponents{}
+	}
+	if page.SignalStates == nil {
+		page.SignalStates = model.StatusSignalStates{}
+	}
+
+	if err := page.Validate(); err != nil {
+		return fmt.Errorf("invalid request: %w", err)
+	}
+
+	for _, component := range page.Components {
+		if _, err := s.containerRepo.GetByID(ctx, int64(component.ContainerID)); err != nil {
+			return fmt.Errorf("invalid request: component %q: %w", component.DisplayName, err)
+		}
+	}
+	return nil
+}
+
+func (s *StatusPageService) invalidate(page *model.StatusPage) {
+	if s.cache != nil {
+		s.cache.Delete("status_page:" + page.Token)
+	}
+}
+
+func newStatusPageToken() (string, error) {
+	bytes, err := utils.GenerateSecureRandomBytes(statusPageTokenBytes)
+	if err != nil {
+		return "", fmt.Errorf("failed to generate status page token: %w", err)
+	}
+	return fmt.Sprintf("%x", bytes), nil
+}
+
+// logPageActivity records a status page change. The token is left out.
+func (s *StatusPageService) logPageActivity(actor model.Actor, action string, page *model.StatusPage, description string) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadata, _ := json.Marshal(map[string]interface{}{
+		"enabled":    page.Enabled,
+		"components": len(page.Components),
+	})
+
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "status_page",
+		ResourceID:   &page.ID,
+		ResourceName: page.Title,
+		Description:  description,
+		Metadata:     string(metadata),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("status_page_id", page.ID).Warn("Failed to log status page activity")
+	}
+}
@@ -0,0 +1,599 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/registry"
+	"docker-auto/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// bundlePassphraseCheck is encrypted into every bundle to check the
+// passphrase of an import
+const bundlePassphraseCheck = "docker-auto system bundle"
+
+// SystemBundleService exports the server's configuration — containers,
+// scheduled tasks, registry credentials and global notification channels —
+// as a versioned bundle, and imports such bundles on another server. Secrets
+// leave the server only encrypted under a passphrase chosen at export.
+type SystemBundleService struct {
+	bundleRepo       repository.SystemBundleRepository
+	activityRepo     repository.ActivityLogRepository
+	secretService    *SecretService
+	registryService  *RegistryCredentialService
+	schedulerService *SchedulerService
+}
+
+// NewSystemBundleService creates a new system bundle service instance.
+// Imported registry credentials and tasks take effect right away through
+// registryService and schedulerService, when given.
+func NewSystemBundleService(
+	bundleRepo repository.SystemBundleRepository,
+	activityRepo repository.ActivityLogRepository,
+	secretService *SecretService,
+	registryService *RegistryCredentialService,
+	schedulerService *SchedulerService,
+) *SystemBundleService {
+	return &SystemBundleService{
+		bundleRepo:       bundleRepo,
+		activityRepo:     activityRepo,
+		secretService:    secretService,
+		registryService:  registryService,
+		schedulerService: schedulerService,
+	}
+}
+
+// Export returns the server's configuration as a bundle with its secrets
+// encrypted under passphrase
+func (s *SystemBundleService) Export(ctx context.Context, actor model.Actor, passphrase string) (*dto.SystemBundle, error) {
+	if len(passphrase) < dto.MinBundlePassphraseLength {
+		return nil, fmt.Errorf("invalid request: passphrase must be at least %d characters", dto.MinBundlePassphraseLength)
+	}
+	seal := func(value string) (string, error) {
+		if value == "" {
+			return "", nil
+		}
+		return utils.EncryptSensitiveData(value, passphrase)
+	}
+
+	snapshot, err := s.bundleRepo.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := seal(bundlePassphraseCheck)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt bundle: %w", err)
+	}
+	bundle := &dto.SystemBundle{
+		Version:              dto.SystemBundleVersion,
+		ExportedAt:           time.Now().UTC(),
+		PassphraseCheck:      check,
+		Containers:           make([]dto.BundleContainer, 0, len(snapshot.Containers)),
+		ScheduledTasks:       make([]dto.BundleScheduledTask, 0, len(snapshot.ScheduledTasks)),
+		RegistryCredentials:  make([]dto.BundleRegistryCredential, 0, len(snapshot.RegistryCredentials)),
+		NotificationChannels: make([]dto.BundleNotificationChannel, 0, len(snapshot.NotificationChannels)),
+	}
+
+	containerNames := make(map[int64]string, len(snapshot.Containers))
+	for _, container := range snapshot.Containers {
+		containerNames[int64(container.ID)] = container.Name
+
+		registryAuth, err := s.secretService.OpenContainerRegistryAuth(container)
+		if err != nil {
+			return nil, err
+		}
+		registryAuth, err = seal(registryAuth)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt registry login of container %s: %w", container.Name, err)
+		}
+		bundle.Containers = append(bundle.Containers, dto.BundleContainer{
+			Name:                   container.Name,
+			Image:                  container.Image,
+			Tag:                    container.Tag,
+			Config:                 bundleJSON(container.ConfigJSON),
+			UpdatePolicy:           string(container.UpdatePolicy),
+			VersionPolicy:          string(container.VersionPolicy),
+			RegistryURL:            container.RegistryURL,
+			RegistryAuth:           registryAuth,
+			HealthCheck:            bundleJSON(container.HealthCheck),
+			Labels:                 bundleJSON(container.Labels),
+			RestartPolicy:          container.RestartPolicy,
+			StopTimeoutSeconds:     container.StopTimeoutSeconds,
+			StopSignal:             container.StopSignal,
+			CheckIntervalMinutes:   container.CheckIntervalMinutes,
+			HoldDownHours:          container.HoldDownHours,
+			MaintenanceWindows:     bundleJSON(container.MaintenanceWindows),
+			VulnerabilityThreshold: container.VulnerabilityThreshold,
+			PinByDigest:            container.PinByDigest,
+			ImageDigest:            container.ImageDigest,
+			HealthActions:          container.HealthActions,
+			WarmupSeconds:          container.WarmupSeconds,
+			PostStartHooks:         container.PostStartHooks,
+			LogRedactPatterns:      container.LogRedactPatterns,
+			SecretEnv:              container.SecretEnv,
+			StackOrder:             container.StackOrder,
+		})
+	}
+
+	for _, task := range snapshot.ScheduledTasks {
+		var targetIDs []int64
+		if task.TargetContainers != "" {
+			if err := json.Unmarshal([]byte(task.TargetContainers), &targetIDs); err != nil {
+				return nil, fmt.Errorf("failed to parse targets of scheduled task %s: %w", task.Name, err)
+			}
+		}
+		var targets []string
+		for _, id := range targetIDs {
+			name, ok := containerNames[id]
+			if !ok {
+				logrus.WithField("task_id", task.ID).Warnf("Scheduled task targets container %d, which no longer exists", id)
+				continue
+			}
+			targets = append(targets, name)
+		}
+
+		bundle.ScheduledTasks = append(bundle.ScheduledTasks, dto.BundleScheduledTask{
+			Name:             task.Name,
+			Type:             string(task.Type),
+			ScheduleType:     string(task.ScheduleType),
+			CronExpression:   task.CronExpression,
+			RunAt:            task.RunAt,
+			TargetContainers: targets,
+			Parameters:       bundleJSON(task.Parameters),
+			IsActive:         task.IsActive,
+		})
+	}
+
+	for _, credentials := range snapshot.RegistryCredentials {
+		password, token, err := s.secretService.OpenRegistryCredentials(credentials)
+		if err != nil {
+			return nil, fmt.Errorf("registry credentials %s: %w", credentials.Name, err)
+		}
+		if password, err = seal(password); err != nil {
+			return nil, fmt.Errorf("failed to encrypt registry credentials %s: %w", credentials.Name, err)
+		}
+		if token, err = seal(token); err != nil {
+			return nil, fmt.Errorf("failed to encrypt registry credentials %s: %w", credentials.Name, err)
+		}
+
+		bundle.RegistryCredentials = append(bundle.RegistryCredentials, dto.BundleRegistryCredential{
+			Name:        credentials.Name,
+			RegistryURL: credentials.RegistryURL,
+			Username:    credentials.Username,
+			Password:    password,
+			Token:       token,
+			AuthType:    string(credentials.AuthType),
+			IsDefault:   credentials.IsDefault,
+			IsActive:    credentials.IsActive,
+			Metadata:    bundleJSON(credentials.Metadata),
+		})
+	}
+
+	for _, channel := range snapshot.NotificationChannels {
+		if err := s.secretService.OpenNotificationChannel(channel); err != nil {
+			return nil, fmt.Errorf("notification channel %s: %w", channel.Name, err)
+		}
+		settingsJSON, err := json.Marshal(channel.Settings)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode notification channel settings: %w", err)
+		}
+		settings, err := seal(string(settingsJSON))
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt notification channel %s: %w", channel.Name, err)
+		}
+
+		bundle.NotificationChannels = append(bundle.NotificationChannels, dto.BundleNotificationChannel{
+			Name:              channel.Name,
+			Type:              string(channel.Type),
+			Enabled:           channel.Enabled,
+			NotificationTypes: channel.NotificationTypes,
+			MinPriority:       string(channel.MinPriority),
+			Settings:          settings,
+		})
+	}
+
+	s.logActivity(actor, "system_exported", "System configuration exported", bundleCounts(bundle))
+
+	return bundle, nil
+}
+
+// Import checks a bundle against the existing configuration and, unless it
+// is a dry run or there are conflicts, creates everything in it in one
+// transaction. Existing entries are never changed.
+func (s *SystemBundleService) Import(ctx context.Context, actor model.Actor, req *dto.ImportSystemBundleRequest) (*dto.SystemImportResult, error) {
+	bundle := req.Bundle
+	if bundle == nil {
+		return nil, fmt.Errorf("invalid request: bundle is required")
+	}
+	if bundle.Version != dto.SystemBundleVersion {
+		return nil, fmt.Errorf("invalid request: unsupported bundle version %d; this server reads version %d", bundle.Version, dto.SystemBundleVersion)
+	}
+	open := func(value string) (string, error) {
+		if value == "" {
+			return "", nil
+		}
+		return utils.DecryptSensitiveData(value, req.Passphrase)
+	}
+	if check, err := open(bundle.PassphraseCheck); err != nil || check != bundlePassphraseCheck {
+		return nil, fmt.Errorf("invalid request: wrong passphrase, or the bundle is damaged")
+	}
+
+	snapshot, secrets, err := bundleSnapshot(bundle, open)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.bundleRepo.Snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &dto.SystemImportResult{
+		DryRun:               req.DryRun,
+		Conflicts:            bundleConflicts(snapshot, existing),
+		Containers:           len(snapshot.Containers),
+		ScheduledTasks:       len(snapshot.ScheduledTasks),
+		RegistryCredentials:  len(snapshot.RegistryCredentials),
+		NotificationChannels: len(snapshot.NotificationChannels),
+	}
+	if req.DryRun {
+		return result, nil
+	}
+	if len(result.Conflicts) > 0 {
+		return result, fmt.Errorf("%d entries of the bundle conflict with existing ones; nothing was imported", len(result.Conflicts))
+	}
+
+	hasDefault := false
+	for _, credentials := range existing.RegistryCredentials {
+		hasDefault = hasDefault || credentials.IsDefault
+	}
+	for i, credentials := range snapshot.RegistryCredentials {
+		// Only one set of credentials can be the default
+		if credentials.IsDefault && hasDefault {
+			credentials.IsDefault = false
+		}
+		hasDefault = hasDefault || credentials.IsDefault
+
+		credentials.CreatedBy = actor.OwnerID()
+		if err := s.secretService.SealRegistryCredentials(credentials, secrets[i].password, secrets[i].token); err != nil {
+			return nil, err
+		}
+	}
+	for _, channel := range snapshot.NotificationChannels {
+		channel.CreatedBy = actor.OwnerID()
+		if err := s.secretService.SealNotificationChannel(channel); err != nil {
+			return nil, err
+		}
+	}
+	for _, container := range snapshot.Containers {
+		container.CreatedBy = actor.OwnerID()
+		if err := s.secretService.SealContainerRegistryAuth(container, container.RegistryAuth); err != nil {
+			return nil, err
+		}
+	}
+	for _, task := range snapshot.ScheduledTasks {
+		task.CreatedBy = actor.OwnerID()
+	}
+
+	if err := s.bundleRepo.Restore(ctx, snapshot); err != nil {
+		return nil, fmt.Errorf("failed to import bundle, nothing was imported: %w", err)
+	}
+	result.Applied = true
+
+	if s.registryService != nil && len(snapshot.RegistryCredentials) > 0 {
+		s.registryService.reload(ctx)
+	}
+	if s.schedulerService != nil {
+		s.schedulerService.ScheduleTasks(snapshot.ScheduledTasks)
+	}
+
+	metadata := bundleCounts(bundle)
+	metadata["exported_at"] = bundle.ExportedAt
+	s.logActivity(actor, "system_imported", "System configuration imported", metadata)
+
+	logrus.WithFields(logrus.Fields{
+		"actor":                 actor.String(),
+		"containers":            result.Containers,
+		"scheduled_tasks":       result.ScheduledTasks,
+		"registry_credentials":  result.RegistryCredentials,
+		"notification_channels": result.NotificationChannels,
+	}).Info("System configuration imported")
+
+	return result, nil
+}
+
+// registrySecrets are the decrypted password and token of bundled registry
+// credentials
+type registrySecrets struct {
+	password string
+	token    string
+}
+
+// bundleSnapshot validates a bundle and turns it into the records to
+// create; the registry secrets are returned by index for sealing
+func bundleSnapshot(bundle *dto.SystemBundle, open func(string) (string, error)) (*model.SystemSnapshot, []registrySecrets, error) {
+	snapshot := &model.SystemSnapshot{TaskTargets: make(map[string][]string)}
+
+	containers := make(map[string]bool, len(bundle.Containers))
+	for _, entry := range bundle.Containers {
+		name := strings.TrimSpace(entry.Name)
+		if name == "" || strings.TrimSpace(entry.Image) == "" {
+			return nil, nil, fmt.Errorf("invalid request: containers need a name and an image")
+		}
+		if containers[name] {
+			return nil, nil, fmt.Errorf("invalid request: container %s appears twice in the bundle", name)
+		}
+		containers[name] = true
+		if entry.VersionPolicy != "" && !model.IsValidVersionPolicy(entry.VersionPolicy) {
+			return nil, nil, fmt.Errorf("invalid request: container %s has unknown version policy %q", name, entry.VersionPolicy)
+		}
+
+		registryAuth, err := open(entry.RegistryAuth)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid request: registry login of container %s cannot be decrypted", name)
+		}
+		container := &model.Container{
+			Name:                   name,
+			Image:                  entry.Image,
+			Tag:                    entry.Tag,
+			Status:                 model.ContainerStatusStopped,
+			ConfigJSON:             string(entry.Config),
+			UpdatePolicy:           model.UpdatePolicy(entry.UpdatePolicy),
+			VersionPolicy:          model.VersionPolicy(entry.VersionPolicy),
+			RegistryURL:            entry.RegistryURL,
+			RegistryAuth:           registryAuth,
+			HealthCheck:            string(entry.HealthCheck),
+			Labels:                 string(entry.Labels),
+			RestartPolicy:          entry.RestartPolicy,
+			StopTimeoutSeconds:     entry.StopTimeoutSeconds,
+			StopSignal:             entry.StopSignal,
+			CheckIntervalMinutes:   entry.CheckIntervalMinutes,
+			HoldDownHours:          entry.HoldDownHours,
+			MaintenanceWindows:     string(entry.MaintenanceWindows),
+			VulnerabilityThreshold: entry.VulnerabilityThreshold,
+			PinByDigest:            entry.PinByDigest,
+			ImageDigest:            entry.ImageDigest,
+			HealthActions:          entry.HealthActions,
+			WarmupSeconds:          entry.WarmupSeconds,
+			PostStartHooks:         entry.PostStartHooks,
+			LogRedactPatterns:      entry.LogRedactPatterns,
+			SecretEnv:              entry.SecretEnv,
+			StackOrder:             entry.StackOrder,
+		}
+		if container.ConfigJSON == "" {
+			container.ConfigJSON = "{}"
+		}
+		snapshot.Containers = append(snapshot.Containers, container)
+	}
+
+	validTypes := make(map[model.TaskType]bool)
+	for _, taskType := range model.GetValidTaskTypes() {
+		validTypes[taskType] = true
+	}
+	tasks := make(map[string]bool, len(bundle.ScheduledTasks))
+	for _, entry := range bundle.ScheduledTasks {
+		name := strings.TrimSpace(entry.Name)
+		if name == "" {
+			return nil, nil, fmt.Errorf("invalid request: scheduled tasks need a name")
+		}
+		if tasks[name] {
+			return nil, nil, fmt.Errorf("invalid request: scheduled task %s appears twice in the bundle", name)
+		}
+		tasks[name] = true
+
+		task := &model.ScheduledTask{
+			Name:           name,
+			Type:           model.TaskType(entry.Type),
+			ScheduleType:   model.ScheduleType(entry.ScheduleType),
+			CronExpression: entry.CronExpression,
+			RunAt:          entry.RunAt,
+			Parameters:     string(entry.Parameters),
+			IsActive:       entry.IsActive,
+		}
+		if task.ScheduleType == "" {
+			task.ScheduleType = model.ScheduleTypeCron
+		}
+		if !validTypes[task.Type] {
+			return nil, nil, fmt.Errorf("invalid request: scheduled task %s has unknown type %q", name, entry.Type)
+		}
+		if err := task.ValidateSchedule(); err != nil {
+			return nil, nil, fmt.Errorf("invalid request: scheduled task %s: %v", name, err)
+		}
+		if task.Parameters == "" {
+			task.Parameters = "{}"
+		}
+		snapshot.ScheduledTasks = append(snapshot.ScheduledTasks, task)
+		snapshot.TaskTargets[name] = entry.TargetContainers
+	}
+
+	var secrets []registrySecrets
+	credentialNames := make(map[string]bool, len(bundle.RegistryCredentials))
+	for _, entry := range bundle.RegistryCredentials {
+		name := strings.TrimSpace(entry.Name)
+		if name == "" || strings.TrimSpace(entry.RegistryURL) == "" {
+			return nil, nil, fmt.Errorf("invalid request: registry credentials need a name and a registry_url")
+		}
+		if credentialNames[name] {
+			return nil, nil, fmt.Errorf("invalid request: registry credentials %s appear twice in the bundle", name)
+		}
+		credentialNames[name] = true
+
+		password, err := open(entry.Password)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid request: password of registry credentials %s cannot be decrypted", name)
+		}
+		token, err := open(entry.Token)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid request: token of registry credentials %s cannot be decrypted", name)
+		}
+
+		credentials := &model.RegistryCredentials{
+			Name:        name,
+			RegistryURL: entry.RegistryURL,
+			Username:    entry.Username,
+			AuthType:    model.RegistryAuthType(entry.AuthType),
+			IsDefault:   entry.IsDefault,
+			IsActive:    entry.IsActive,
+			Metadata:    string(entry.Metadata),
+		}
+		switch credentials.AuthType {
+		case model.RegistryAuthTypeBasic, model.RegistryAuthTypeToken, model.RegistryAuthTypeOAuth:
+		default:
+			return nil, nil, fmt.Errorf("invalid request: registry credentials %s have unknown auth type %q", name, entry.AuthType)
+		}
+		if credentials.Metadata == "" {
+			credentials.Metadata = "{}"
+		}
+		snapshot.RegistryCredentials = append(snapshot.RegistryCredentials, credentials)
+		secrets = append(secrets, registrySecrets{password: password, token: token})
+	}
+
+	for _, entry := range bundle.NotificationChannels {
+		settings, err := open(entry.Settings)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid request: settings of notification channel %s cannot be decrypted", entry.Name)
+		}
+
+		channel := &model.NotificationChannel{
+			Name:              strings.TrimSpace(entry.Name),
+			Type:              model.NotificationChannelType(entry.Type),
+			Enabled:           entry.Enabled,
+			NotificationTypes: model.StringList(entry.NotificationTypes),
+			MinPriority:       model.NotificationPriority(entry.MinPriority),
+		}
+		if settings != "" {
+			if err := json.Unmarshal([]byte(settings), &channel.Settings); err != nil {
+				return nil, nil, fmt.Errorf("invalid request: settings of notification channel %s are malformed", entry.Name)
+			}
+		}
+		if err := validateNotificationChannel(channel); err != nil {
+			return nil, nil, fmt.Errorf("%w (notification channel %s)", err, entry.Name)
+		}
+		snapshot.NotificationChannels = append(snapshot.NotificationChannels, channel)
+	}
+
+	return snapshot, secrets, nil
+}
+
+// bundleConflicts lists the entries of a bundle that clash with the
+// existing configuration
+func bundleConflicts(snapshot, existing *model.SystemSnapshot) []dto.BundleConflict {
+	conflicts := []dto.BundleConflict{}
+	conflict := func(kind, name, reason string) {
+		conflicts = append(conflicts, dto.BundleConflict{Kind: kind, Name: name, Reason: reason})
+	}
+
+	containerNames := make(map[string]bool)
+	for _, container := range existing.Containers {
+		containerNames[container.Name] = true
+	}
+	for _, container := range snapshot.Containers {
+		if containerNames[container.Name] {
+			conflict(dto.BundleKindContainer, container.Name, "a container with this name already exists")
+		}
+	}
+	// Tasks may target containers of the bundle or existing ones
+	for _, container := range snapshot.Containers {
+		containerNames[container.Name] = true
+	}
+
+	taskNames := make(map[string]bool)
+	for _, task := range existing.ScheduledTasks {
+		taskNames[task.Name] = true
+	}
+	for _, task := range snapshot.ScheduledTasks {
+		if taskNames[task.Name] {
+			conflict(dto.BundleKindScheduledTask, task.Name, "a scheduled task with this name already exists")
+		}
+		for _, target := range snapshot.TaskTargets[task.Name] {
+			if !containerNames[target] {
+				conflict(dto.BundleKindScheduledTask, task.Name, fmt.Sprintf("target container %s does not exist", target))
+			}
+		}
+	}
+
+	credentialNames := make(map[string]bool)
+	activeHosts := make(map[string]string)
+	for _, credentials := range existing.RegistryCredentials {
+		credentialNames[credentials.Name] = true
+		if credentials.IsActive {
+			activeHosts[registry.RegistryHost(credentials.RegistryURL)] = credentials.Name
+		}
+	}
+	for _, credentials := range snapshot.RegistryCredentials {
+		if credentialNames[credentials.Name] {
+			conflict(dto.BundleKindRegistryCredentials, credentials.Name, "registry credentials with this name already exist")
+			continue
+		}
+		if !credentials.IsActive {
+			continue
+		}
+		host := registry.RegistryHost(credentials.RegistryURL)
+		if other, exists := activeHosts[host]; exists {
+			conflict(dto.BundleKindRegistryCredentials, credentials.Name, fmt.Sprintf("active credentials for registry %s already exist: %s", host, other))
+			continue
+		}
+		activeHosts[host] = credentials.Name
+	}
+
+	channelNames := make(map[string]bool)
+	for _, channel := range existing.NotificationChannels {
+		channelNames[channel.Name] = true
+	}
+	for _, channel := range snapshot.NotificationChannels {
+		if channelNames[channel.Name] {
+			conflict(dto.BundleKindNotificationChannel, channel.Name, "a global notification channel with this name already exists")
+		}
+		channelNames[channel.Name] = true
+	}
+
+	return conflicts
+}
+
+// bundleJSON returns a JSON column for a bundle, leaving out empty ones
+func bundleJSON(value string) json.RawMessage {
+	switch strings.TrimSpace(value) {
+	case "", "null", "{}", "[]":
+		return nil
+	}
+	return json.RawMessage(value)
+}
+
+// bundleCounts summarizes a bundle for the activity log
+func bundleCounts(bundle *dto.SystemBundle) map[string]interface{} {
+	return map[string]interface{}{
+		"containers":            len(bundle.Containers),
+		"scheduled_tasks":       len(bundle.ScheduledTasks),
+		"registry_credentials":  len(bundle.RegistryCredentials),
+		"notification_channels": len(bundle.NotificationChannels),
+	}
+}
+
+// logActivity records an export or import in the activity log
+func (s *SystemBundleService) logActivity(actor model.Actor, action, description string, metadata map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadataJSON, _ := json.Marshal(metadata)
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "system",
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).Warn("Failed to log system bundle activity")
+	}
+}
@@ -0,0 +1,405 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/utils"
+
+	"gorm.io/gorm"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden bundles in testdata")
+
+const (
+	testBundlePassphrase = "correct horse battery staple"
+	// sealedPlaceholder replaces encrypted values in the export golden file,
+	// which differ on every export
+	sealedPlaceholder = "sealed"
+)
+
+func newBundleTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	return newTestDB(t, &model.Container{}, &model.ScheduledTask{}, &model.RegistryCredentials{}, &model.NotificationChannel{})
+}
+
+func newBundleTestService(t *testing.T, db *gorm.DB, key string) (*SystemBundleService, *SecretService) {
+	t.Helper()
+
+	secrets := NewSecretService(nil, nil, &config.Config{Security: config.SecurityConfig{
+		EncryptionKey:        key,
+		EncryptionKeyVersion: 1,
+	}})
+	return NewSystemBundleService(repository.NewSystemBundleRepository(db), nil, secrets, nil, nil), secrets
+}
+
+// seedBundleSource stores the configuration the export golden file shows
+func seedBundleSource(t *testing.T, db *gorm.DB, secrets *SecretService) {
+	t.Helper()
+
+	interval := 30
+	containers := []*model.Container{
+		{
+			Name:         "web",
+			Image:        "ghcr.io/acme/web",
+			Tag:          "1.4.2",
+			ConfigJSON:   `{"env":["PORT=8080","API_KEY=s3cret"],"ports":[{"container_port":8080,"host_port":80,"protocol":"tcp"}]}`,
+			UpdatePolicy: model.UpdatePolicyManual,
+			RegistryURL:  "ghcr.io",
+			RegistryAuth: `{"username":"deploy","password":"hunter2"}`,
+			Labels:       `{"tier":"frontend"}`,
+			SecretEnv:    model.StringList{"API_KEY"},
+
+			CheckIntervalMinutes: &interval,
+			PinByDigest:          true,
+			ImageDigest:          "sha256:4b1c",
+		},
+		{
+			Name:         "db",
+			Image:        "postgres",
+			Tag:          "16",
+			ConfigJSON:   `{"volumes":[{"source":"pgdata","target":"/var/lib/postgresql/data"}]}`,
+			UpdatePolicy: model.UpdatePolicyDisabled,
+		},
+	}
+	for _, container := range containers {
+		if err := db.Create(container).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tasks := []*model.ScheduledTask{
+		{Name: "nightly backup", Type: model.TaskTypeBackup, CronExpression: "0 3 * * *", TargetContainers: `[2]`, Parameters: `{"retention_days":7}`, IsActive: true},
+		{Name: "weekly cleanup", Type: model.TaskTypeCleanup, CronExpression: "0 4 * * 0", TargetContainers: `[]`, Parameters: `{}`},
+	}
+	for _, task := range tasks {
+		if err := db.Create(task).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := db.Model(tasks[1]).UpdateColumn("is_active", false).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	credentials := &model.RegistryCredentials{Name: "ghcr", RegistryURL: "https://ghcr.io", Username: "deploy", AuthType: model.RegistryAuthTypeBasic, IsDefault: true, IsActive: true, Metadata: "{}"}
+	if err := secrets.SealRegistryCredentials(credentials, "ghcr-password", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Create(credentials).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	userID := int64(9)
+	channels := []*model.NotificationChannel{
+		{
+			Name:              "ops webhook",
+			Type:              model.NotificationChannelWebhook,
+			Enabled:           true,
+			NotificationTypes: model.StringList{string(model.NotificationTypeContainerUpdate)},
+			MinPriority:       model.NotificationPriorityHigh,
+			Settings:          model.NotificationConfig{Webhook: &model.WebhookConfig{URL: "https://hooks.example.com/ops", Method: "POST", Secret: "hmac-key"}},
+		},
+		// Personal channels belong to users and are not exported
+		{
+			UserID:      &userID,
+			Name:        "my webhook",
+			Type:        model.NotificationChannelWebhook,
+			Enabled:     true,
+			MinPriority: model.NotificationPriorityLow,
+			Settings:    model.NotificationConfig{Webhook: &model.WebhookConfig{URL: "https://hooks.example.com/me"}},
+		},
+	}
+	for _, channel := range channels {
+		if err := secrets.SealNotificationChannel(channel); err != nil {
+			t.Fatal(err)
+		}
+		if err := db.Create(channel).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// compareGolden compares got with a golden file, rewriting it with -update
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs from the golden file; got:\n%s", name, got)
+	}
+}
+
+func readBundle(t *testing.T, name string) *dto.SystemBundle {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bundle dto.SystemBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatal(err)
+	}
+	return &bundle
+}
+
+func TestSystemBundleExportMatchesGolden(t *testing.T) {
+	ctx := context.Background()
+	db := newBundleTestDB(t)
+	bundles, secrets := newBundleTestService(t, db, "source-server-key")
+	seedBundleSource(t, db, secrets)
+
+	if _, err := bundles.Export(ctx, model.UserActor(1, "admin"), "short"); err == nil || !strings.HasPrefix(err.Error(), "invalid request:") {
+		t.Fatalf("Export with a short passphrase: err = %v, want an invalid request", err)
+	}
+
+	bundle, err := bundles.Export(ctx, model.UserActor(1, "admin"), testBundlePassphrase)
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	if *updateGolden {
+		// The import tests read this bundle as one written by an earlier export
+		bundle.ExportedAt = time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+		fixture, err := json.MarshalIndent(bundle, "", "  ")
+		if err != nil {
+			t.Fatal(err)
+		}
+		compareGolden(t, "system_bundle_v1.json", append(fixture, '\n'))
+	}
+
+	// Secrets must open with the passphrase and nothing else
+	open := func(sealed, want string) string {
+		t.Helper()
+		if sealed == "" {
+			return ""
+		}
+		if strings.Contains(sealed, want) {
+			t.Errorf("secret %q is exported in plaintext", want)
+		}
+		if _, err := utils.DecryptSensitiveData(sealed, "another passphrase"); err == nil {
+			t.Error("secret opens with the wrong passphrase")
+		}
+		plaintext, err := utils.DecryptSensitiveData(sealed, testBundlePassphrase)
+		if err != nil {
+			t.Fatalf("secret does not open with the passphrase: %v", err)
+		}
+		if plaintext != want {
+			t.Errorf("secret = %q, want %q", plaintext, want)
+		}
+		return sealedPlaceholder
+	}
+	bundle.PassphraseCheck = open(bundle.PassphraseCheck, bundlePassphraseCheck)
+	bundle.Containers[1].RegistryAuth = open(bundle.Containers[1].RegistryAuth, `{"username":"deploy","password":"hunter2"}`)
+	bundle.RegistryCredentials[0].Password = open(bundle.RegistryCredentials[0].Password, "ghcr-password")
+	bundle.NotificationChannels[0].Settings = open(bundle.NotificationChannels[0].Settings,
+		`{"webhook":{"url":"https://hooks.example.com/ops","method":"POST","timeout":0,"secret":"hmac-key"}}`)
+	bundle.ExportedAt = time.Time{}
+
+	got, err := json.MarshalIndent(bundle, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareGolden(t, "system_bundle_export.golden.json", append(got, '\n'))
+}
+
+func TestSystemBundleImportGolden(t *testing.T) {
+	ctx := context.Background()
+	db := newBundleTestDB(t)
+	bundles, secrets := newBundleTestService(t, db, "target-server-key")
+	actor := model.UserActor(3, "admin")
+
+	result, err := bundles.Import(ctx, actor, &dto.ImportSystemBundleRequest{
+		Passphrase: testBundlePassphrase,
+		Bundle:     readBundle(t, "system_bundle_v1.json"),
+	})
+	if err != nil {
+		t.Fatalf("Import: %v", err)
+	}
+	if !result.Applied || len(result.Conflicts) != 0 {
+		t.Fatalf("result = %+v, want applied without conflicts", result)
+	}
+	if result.Containers != 2 || result.ScheduledTasks != 2 || result.RegistryCredentials != 1 || result.NotificationChannels != 1 {
+		t.Fatalf("result = %+v, want 2 containers, 2 tasks, 1 registry and 1 channel", result)
+	}
+
+	var web, postgres model.Container
+	if err := db.Where("name = ?", "web").First(&web).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("name = ?", "db").First(&postgres).Error; err != nil {
+		t.Fatal(err)
+	}
+	registryAuth, err := secrets.OpenContainerRegistryAuth(&web)
+	if err != nil {
+		t.Fatalf("OpenContainerRegistryAuth: %v", err)
+	}
+	if strings.Contains(web.RegistryAuth, "hunter2") || registryAuth != `{"username":"deploy","password":"hunter2"}` || !web.PinByDigest || web.Tag != "1.4.2" {
+		t.Errorf("web = %+v, want its registry login encrypted, digest pin and tag", web)
+	}
+	if web.CreatedBy == nil || *web.CreatedBy != 3 || web.ContainerID != "" || web.Status != model.ContainerStatusStopped {
+		t.Errorf("web was not created as a stopped container of the importing user: %+v", web)
+	}
+
+	var backup, cleanup model.ScheduledTask
+	if err := db.Where("name = ?", "nightly backup").First(&backup).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := db.Where("name = ?", "weekly cleanup").First(&cleanup).Error; err != nil {
+		t.Fatal(err)
+	}
+	var targets []int64
+	if err := json.Unmarshal([]byte(backup.TargetContainers), &targets); err != nil {
+		t.Fatal(err)
+	}
+	if len(targets) != 1 || targets[0] != int64(postgres.ID) {
+		t.Errorf("backup targets %v, want the imported db container %d", targets, postgres.ID)
+	}
+	if !backup.IsActive || cleanup.IsActive {
+		t.Errorf("active = %v/%v, want the backup active and the cleanup paused", backup.IsActive, cleanup.IsActive)
+	}
+
+	// Secrets are stored under the importing server's key
+	var credentials model.RegistryCredentials
+	if err := db.First(&credentials).Error; err != nil {
+		t.Fatal(err)
+	}
+	if !utils.IsEncryptedSecret(credentials.PasswordEncrypted) {
+		t.Errorf("registry password stored as %q, want it encrypted", credentials.PasswordEncrypted)
+	}
+	if password, _, err := secrets.OpenRegistryCredentials(&credentials); err != nil || password != "ghcr-password" {
+		t.Errorf("registry password = %q, %v; want ghcr-password", password, err)
+	}
+
+	var channel model.NotificationChannel
+	if err := db.First(&channel).Error; err != nil {
+		t.Fatal(err)
+	}
+	if err := secrets.OpenNotificationChannel(&channel); err != nil {
+		t.Fatal(err)
+	}
+	if channel.UserID != nil || channel.Settings.Webhook == nil || channel.Settings.Webhook.Secret != "hmac-key" {
+		t.Errorf("channel = %+v, want the global ops webhook with its secret", channel)
+	}
+}
+
+func TestSystemBundleImportReportsConflicts(t *testing.T) {
+	ctx := context.Background()
+	db := newBundleTestDB(t)
+	bundles, _ := newBundleTestService(t, db, "target-server-key")
+	actor := model.UserActor(3, "admin")
+
+	if err := db.Create(&model.Container{Name: "web", Image: "nginx", ConfigJSON: "{}"}).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := bundles.Import(ctx, actor, &dto.ImportSystemBundleRequest{
+		Passphrase: testBundlePassphrase,
+		DryRun:     true,
+		Bundle:     readBundle(t, "system_bundle_v1.json"),
+	})
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if result.Applied || len(result.Conflicts) != 1 || result.Conflicts[0].Kind != dto.BundleKindContainer || result.Conflicts[0].Name != "web" {
+		t.Fatalf("dry run result = %+v, want the web container as the only conflict", result)
+	}
+
+	result, err = bundles.Import(ctx, actor, &dto.ImportSystemBundleRequest{
+		Passphrase: testBundlePassphrase,
+		Bundle:     readBundle(t, "system_bundle_v1.json"),
+	})
+	if err == nil || result == nil || result.Applied || len(result.Conflicts) != 1 {
+		t.Fatalf("Import = %+v, %v; want it refused over the conflict", result, err)
+	}
+
+	var containers, tasks int64
+	db.Model(&model.Container{}).Count(&containers)
+	db.Model(&model.ScheduledTask{}).Count(&tasks)
+	if containers != 1 || tasks != 0 {
+		t.Errorf("%d containers and %d tasks after a refused import, want only the existing container", containers, tasks)
+	}
+}
+
+func TestSystemBundleImportChecksVersionAndPassphrase(t *testing.T) {
+	ctx := context.Background()
+	db := newBundleTestDB(t)
+	bundles, _ := newBundleTestService(t, db, "target-server-key")
+	actor := model.UserActor(3, "admin")
+
+	if _, err := bundles.Import(ctx, actor, &dto.ImportSystemBundleRequest{
+		Passphrase: "not the passphrase",
+		Bundle:     readBundle(t, "system_bundle_v1.json"),
+	}); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("Import with a wrong passphrase: err = %v", err)
+	}
+
+	future := readBundle(t, "system_bundle_v1.json")
+	future.Version = dto.SystemBundleVersion + 1
+	if _, err := bundles.Import(ctx, actor, &dto.ImportSystemBundleRequest{
+		Passphrase: testBundlePassphrase,
+		Bundle:     future,
+	}); err == nil || !strings.Contains(err.Error(), "unsupported bundle version") {
+		t.Errorf("Import of a newer bundle: err = %v", err)
+	}
+
+	var containers int64
+	db.Model(&model.Container{}).Count(&containers)
+	if containers != 0 {
+		t.Errorf("%d containers after rejected imports, want none", containers)
+	}
+}
+
+func TestSystemBundleRestoreRollsBackOnFailure(t *testing.T) {
+	ctx := context.Background()
+	db := newBundleTestDB(t)
+	repo := repository.NewSystemBundleRepository(db)
+
+	// The second task's target is missing, which fails the transaction
+	// after the containers and registry credentials were written
+	err := repo.Restore(ctx, &model.SystemSnapshot{
+		Containers:          []*model.Container{{Name: "web", Image: "nginx", ConfigJSON: "{}"}},
+		RegistryCredentials: []*model.RegistryCredentials{{Name: "hub", RegistryURL: "docker.io", Metadata: "{}"}},
+		ScheduledTasks: []*model.ScheduledTask{
+			{Name: "backup", Type: model.TaskTypeBackup, CronExpression: "0 3 * * *", Parameters: "{}"},
+			{Name: "health", Type: model.TaskTypeHealthCheck, CronExpression: "*/5 * * * *", Parameters: "{}"},
+		},
+		TaskTargets: map[string][]string{"backup": {"web"}, "health": {"api"}},
+	})
+	if err == nil {
+		t.Fatal("Restore succeeded with a missing task target")
+	}
+
+	var containers, credentials, tasks int64
+	db.Model(&model.Container{}).Count(&containers)
+	db.Model(&model.RegistryCredentials{}).Count(&credentials)
+	db.Model(&model.ScheduledTask{}).Count(&tasks)
+	if containers != 0 || credentials != 0 || tasks != 0 {
+		t.Errorf("partial import kept %d containers, %d credentials and %d tasks, want none", containers, credentials, tasks)
+	}
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// systemCheckTimeout bounds each check of the system diagnostics, so a hung
+// Docker socket or database does not hold up the response
+const systemCheckTimeout = 5 * time.Second
+
+// ComponentStatus is the outcome of checking one backend component
+type ComponentStatus string
+
+const (
+	ComponentStatusOK       ComponentStatus = "ok"
+	ComponentStatusError    ComponentStatus = "error"
+	ComponentStatusDisabled ComponentStatus = "disabled"
+)
+
+// DockerDiagnostics describes the Docker daemon the backend talks to
+type DockerDiagnostics struct {
+	Status           ComponentStatus          `json:"status"`
+	Error            string                   `json:"error,omitempty"`
+	Host             string                   `json:"host,omitempty"`
+	ServerVersion    string                   `json:"server_version,omitempty"`
+	APIVersion       string                   `json:"api_version,omitempty"`
+	ServerAPIVersion string                   `json:"server_api_version,omitempty"`
+	OperatingSystem  string                   `json:"operating_system,omitempty"`
+	Architecture     string                   `json:"architecture,omitempty"`
+	StorageDriver    string                   `json:"storage_driver,omitempty"`
+	Containers       int                      `json:"containers"`
+	Images           int                      `json:"images"`
+	Volumes          int                      `json:"volumes"`
+	DiskUsage        *docker.DiskUsageSummary `json:"disk_usage,omitempty"`
+	DiskUsageError   string                   `json:"disk_usage_error,omitempty"`
+}
+
+// DatabaseDiagnostics describes the database connection and the schema
+// version it was migrated to
+type DatabaseDiagnostics struct {
+	Status                ComponentStatus `json:"status"`
+	Error                 string          `json:"error,omitempty"`
+	Version               string          `json:"version,omitempty"`
+	SchemaVersion         int             `json:"schema_version"`
+	ExpectedSchemaVersion int             `json:"expected_schema_version"`
+	OpenConnections       int             `json:"open_connections"`
+	InUseConnections      int             `json:"in_use_connections"`
+	IdleConnections       int             `json:"idle_connections"`
+}
+
+// CacheDiagnostics describes the shared Redis cache. It is disabled when no
+// REDIS_URL is configured and caches stay in process.
+type CacheDiagnostics struct {
+	Status ComponentStatus `json:"status"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// SchedulerDiagnostics describes the task scheduler
+type SchedulerDiagnostics struct {
+	Status  ComponentStatus `json:"status"`
+	Error   string          `json:"error,omitempty"`
+	Running bool            `json:"running"`
+}
+
+// SystemDiagnostics is the state of each backend component. A component that
+// fails its check reports the error in its own entry; the others are still
+// filled in.
+type SystemDiagnostics struct {
+	Docker    DockerDiagnostics    `json:"docker"`
+	Database  DatabaseDiagnostics  `json:"database"`
+	Cache     CacheDiagnostics     `json:"cache"`
+	Scheduler SchedulerDiagnostics `json:"scheduler"`
+}
+
+// Healthy reports whether no enabled component failed its check
+func (d *SystemDiagnostics) Healthy() bool {
+	for _, status := range []ComponentStatus{d.Docker.Status, d.Database.Status, d.Cache.Status, d.Scheduler.Status} {
+		if status == ComponentStatusError {
+			return false
+		}
+	}
+	return true
+}
+
+// SystemInfoService checks the components the backend depends on
+type SystemInfoService struct {
+	db               *gorm.DB
+	redisClient      *redis.Client
+	schedulerService *SchedulerService
+	dockerClient     *docker.DockerClient
+}
+
+// NewSystemInfoService creates a new system info service instance. db,
+// redisClient and schedulerService may be nil when the backend runs without
+// them.
+func NewSystemInfoService(
+	db *gorm.DB,
+	redisClient *redis.Client,
+	schedulerService *SchedulerService,
+	dockerClient *docker.DockerClient,
+) *SystemInfoService {
+	return &SystemInfoService{
+		db:               db,
+		redisClient:      redisClient,
+		schedulerService: schedulerService,
+		dockerClient:     dockerClient,
+	}
+}
+
+// GetDiagnostics checks every component concurrently, each within
+// systemCheckTimeout
+func (s *SystemInfoService) GetDiagnostics(ctx context.Context) *SystemDiagnostics {
+	diagnostics := &SystemDiagnostics{}
+
+	var wg sync.WaitGroup
+	check := func(run func(ctx context.Context)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			checkCtx, cancel := context.WithTimeout(ctx, systemCheckTimeout)
+			defer cancel()
+			run(checkCtx)
+		}()
+	}
+
+	var diskUsage *docker.DiskUsageSummary
+	var diskUsageErr string
+	check(func(ctx context.Context) { diagnostics.Docker = s.checkDocker(ctx) })
+	check(func(ctx context.Context) { diskUsage, diskUsageErr = s.checkDiskUsage(ctx) })
+	check(func(ctx context.Context) { diagnostics.Database = s.checkDatabase(ctx) })
+	check(func(ctx context.Context) { diagnostics.Cache = s.checkCache(ctx) })
+	diagnostics.Scheduler = s.checkScheduler()
+	wg.Wait()
+
+	diagnostics.Docker.DiskUsage = diskUsage
+	diagnostics.Docker.DiskUsageError = diskUsageErr
+	if diskUsage != nil {
+		diagnostics.Docker.Volumes = diskUsage.Volumes
+	}
+	return diagnostics
+}
+
+// checkDocker reads the daemon's version and info
+func (s *SystemInfoService) checkDocker(ctx context.Context) DockerDiagnostics {
+	if s.dockerClient == nil {
+		return DockerDiagnostics{Status: ComponentStatusError, Error: "Docker client not configured"}
+	}
+
+	result := DockerDiagnostics{Host: s.dockerClient.GetDaemonHost()}
+
+	version, err := s.dockerClient.GetVersion(ctx)
+	if err != nil {
+		result.Status = ComponentStatusError
+		result.Error = err.Error()
+		return result
+	}
+	result.ServerVersion = version.Version
+	result.ServerAPIVersion = version.APIVersion
+	// The client negotiated its API version on the request above
+	result.APIVersion = s.dockerClient.GetClientVersion()
+
+	info, err := s.dockerClient.GetInfo(ctx)
+	if err != nil {
+		result.Status = ComponentStatusError
+		result.Error = err.Error()
+		return result
+	}
+	result.OperatingSystem = info.OperatingSystem
+	result.Architecture = info.Architecture
+	result.StorageDriver = info.Driver
+	result.Containers = info.Containers
+	result.Images = info.Images
+
+	result.Status = ComponentStatusOK
+	return result
+}
+
+// checkDiskUsage reads the daemon's disk usage. It runs apart from
+// checkDocker since it can take far longer on a host with many layers.
+func (s *SystemInfoService) checkDiskUsage(ctx context.Context) (*docker.DiskUsageSummary, string) {
+	if s.dockerClient == nil {
+		return nil, ""
+	}
+	usage, err := s.dockerClient.GetDiskUsage(ctx)
+	if err != nil {
+		return nil, err.Error()
+	}
+	return usage, ""
+}
+
+// checkDatabase pings the database and reads its version, pool statistics
+// and the schema version recorded by the last migration
+func (s *SystemInfoService) checkDatabase(ctx context.Context) DatabaseDiagnostics {
+	result := DatabaseDiagnostics{ExpectedSchemaVersion: model.SchemaVersion}
+	if s.db == nil {
+		result.Status = ComponentStatusError
+		result.Error = "database not configured"
+		return result
+	}
+
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		result.Status = ComponentStatusError
+		result.Error = err.Error()
+		return result
+	}
+	stats := sqlDB.Stats()
+	result.OpenConnections = stats.OpenConnections
+	result.InUseConnections = stats.InUse
+	result.IdleConnections = stats.Idle
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		result.Status = ComponentStatusError
+		result.Error = fmt.Sprintf("failed to ping database: %v", err)
+		return result
+	}
+
+	if err := s.db.WithContext(ctx).Raw("SELECT version()").Scan(&result.Version).Error; err != nil {
+		logrus.WithError(err).Debug("Failed to read database version")
+	}
+
+	var config model.SystemConfig
+	err = s.db.WithContext(ctx).Where("config_key = ?", model.ConfigKeyAppSchemaVersion).First(&config).Error
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		result.Status = ComponentStatusError
+		result.Error = "schema version not recorded; migrations have not run"
+		return result
+	case err != nil:
+		result.Status = ComponentStatusError
+		result.Error = fmt.Sprintf("failed to read schema version: %v", err)
+		return result
+	}
+	if result.SchemaVersion, err = strconv.Atoi(config.ConfigValue); err != nil {
+		result.Status = ComponentStatusError
+		result.Error = fmt.Sprintf("invalid schema version %q", config.ConfigValue)
+		return result
+	}
+	if result.SchemaVersion != model.SchemaVersion {
+		result.Status = ComponentStatusError
+		result.Error = fmt.Sprintf("schema version %d, expected %d", result.SchemaVersion, model.SchemaVersion)
+		return result
+	}
+
+	result.Status = ComponentStatusOK
+	return result
+}
+
+// checkCache pings Redis
+func (s *SystemInfoService) checkCache(ctx context.Context) CacheDiagnostics {
+	if s.redisClient == nil {
+		return CacheDiagnostics{Status: ComponentStatusDisabled}
+	}
+	if err := s.redisClient.Ping(ctx).Err(); err != nil {
+		return CacheDiagnostics{Status: ComponentStatusError, Error: fmt.Sprintf("failed to ping Redis: %v", err)}
+	}
+	return CacheDiagnostics{Status: ComponentStatusOK}
+}
+
+// checkScheduler reports whether the scheduler is running
+func (s *SystemInfoService) checkScheduler() SchedulerDiagnostics {
+	if s.schedulerService == nil {
+		return SchedulerDiagnostics{Status: ComponentStatusDisabled}
+	}
+	if !s.schedulerService.IsRunning() {
+		return SchedulerDiagnostics{Status: ComponentStatusError, Error: "scheduler is not running"}
+	}
+	return SchedulerDiagnostics{Status: ComponentStatusOK, Running: true}
+}
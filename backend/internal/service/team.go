@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
+
+	"github.com/sirupsen/logrus"
+)
+
+// defaultCPUPeriod is the CFS period Docker uses when a quota is set without one
+const defaultCPUPeriod = 100000
+
+// TeamRequest creates a team
+type TeamRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Description string `json:"description"`
+}
+
+// TeamMemberRequest is one member of a team
+type TeamMemberRequest struct {
+	UserID  int64 `json:"user_id" binding:"required"`
+	IsAdmin bool  `json:"is_admin"`
+}
+
+// TeamMembersRequest replaces the members of a team
+type TeamMembersRequest struct {
+	Members []TeamMemberRequest `json:"members"`
+}
+
+// TeamQuotaRequest replaces a team's quota; omitted limits are unlimited
+type TeamQuotaRequest struct {
+	MaxContainers          *int   `json:"max_containers"`
+	MaxMemoryBytes         *int64 `json:"max_memory_bytes"`
+	MaxNanoCPUs            *int64 `json:"max_nano_cpus"`
+	MaxUnlimitedContainers *int   `json:"max_unlimited_containers"`
+}
+
+// TeamQuotaStatus is a team's usage against its quota
+type TeamQuotaStatus struct {
+	TeamID     int                    `json:"team_id"`
+	TeamName   string                 `json:"team_name"`
+	Quota      *model.TeamQuota       `json:"quota"`
+	Usage      model.QuotaUsage       `json:"usage"`
+	Dimensions []model.QuotaDimension `json:"dimensions"`
+	// DefaultReservation is what a container without limits reserves
+	DefaultReservation model.ResourceReservation `json:"default_reservation"`
+	WarnPercent        int                       `json:"warn_percent"`
+}
+
+// QuotaExceededError is returned when admitting a container would take its
+// team over quota. Status is the usage the team would have had.
+type QuotaExceededError struct {
+	Exceeded  []string                  `json:"exceeded"`
+	Requested model.ResourceReservation `json:"requested"`
+	Status    *TeamQuotaStatus          `json:"status"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("quota of team %d exceeded: %s", e.Status.TeamID, strings.Join(e.Exceeded, ", "))
+}
+
+// TeamService manages teams and enforces their resource quotas on the
+// containers assigned to them
+type TeamService struct {
+	teamRepo            repository.TeamRepository
+	userRepo            repository.UserRepository
+	activityRepo        repository.ActivityLogRepository
+	notificationService *NotificationService
+	config              *config.Config
+
+	// admission serializes quota checks with the writes they admit, so two
+	// containers can't both fit into the last free slot
+	admission sync.Mutex
+}
+
+// NewTeamService creates a new team service instance
+func NewTeamService(
+	teamRepo repository.TeamRepository,
+	userRepo repository.UserRepository,
+	activityRepo repository.ActivityLogRepository,
+	notificationService *NotificationService,
+	cfg *config.Config,
+) *TeamService {
+	return &TeamService{
+		teamRepo:            teamRepo,
+		userRepo:            userRepo,
+		activityRepo:        activityRepo,
+		notificationService: notificationService,
+		config:              cfg,
+	}
+}
+
+// ListTeams returns every team with its members and quota
+func (s *TeamService) ListTeams(ctx context.Context) ([]*model.Team, error) {
+	return s.teamRepo.List(ctx)
+}
+
+// CreateTeam creates a team without members or quota
+func (s *TeamService) CreateTeam(ctx context.Context, actor model.Actor, req *TeamRequest) (*model.Team, error) {
+	team := &model.Team{
+		Name:        req.Name,
+		Description: req.Description,
+		CreatedBy:   actor.OwnerID(),
+	}
+	if err := team.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	if err := s.teamRepo.Create(ctx, team); err != nil {
+		return nil, err
+	}
+
+	s.logTeamActivity(actor, "team_create", team, "Team created", map[string]interface{}{
+		"description": team.Description,
+	})
+	return team, nil
+}
+
+// SetMembers replaces the members of a team
+func (s *TeamService) SetMembers(ctx context.Context, actor model.Actor, teamID int, req *TeamMembersRequest) (*model.Team, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[int64]bool, len(req.Members))
+	members := make([]model.TeamMember, 0, len(req.Members))
+	for _, m := range req.Members {
+		if seen[m.UserID] {
+			return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: user %d is listed twice", m.UserID)
+		}
+		seen[m.UserID] = true
+		if _, err := s.userRepo.GetByID(ctx, m.UserID); err != nil {
+			return nil, apperrors.Newf(apperrors.CodeInvalidRequest, "invalid request: user %d not found", m.UserID)
+		}
+		members = append(members, model.TeamMember{UserID: m.UserID, IsAdmin: m.IsAdmin})
+	}
+
+	if err := s.teamRepo.ReplaceMembers(ctx, teamID, members); err != nil {
+		return nil, err
+	}
+	team.Members = members
+
+	s.logTeamActivity(actor, "team_members_update", team, "Team members updated", map[string]interface{}{
+		"members": req.Members,
+	})
+	return team, nil
+}
+
+// GetQuota returns a team's usage against its quota
+func (s *TeamService) GetQuota(ctx context.Context, teamID int) (*TeamQuotaStatus, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+	usage, err := s.usage(ctx, teamID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(team, usage), nil
+}
+
+// SetQuota replaces a team's quota
+func (s *TeamService) SetQuota(ctx context.Context, actor model.Actor, teamID int, req *TeamQuotaRequest) (*TeamQuotaStatus, error) {
+	team, err := s.teamRepo.GetByID(ctx, teamID)
+	if err != nil {
+		return nil, err
+	}
+
+	quota := &model.TeamQuota{
+		TeamID:        teamID,
+		MaxContainers: req.MaxContainers,
+		MaxMemory:     req.MaxMemoryBytes,
+		MaxNanoCPUs:   req.MaxNanoCPUs,
+		MaxUnlimited:  req.MaxUnlimitedContainers,
+		UpdatedBy:     actor.OwnerID(),
+	}
+	if err := quota.Validate(); err != nil {
+		return nil, apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+	}
+
+	if err := s.teamRepo.SaveQuota(ctx, quota); err != nil {
+		return nil, err
+	}
+
+	s.logTeamActivity(actor, "team_quota_update", team, "Team quota updated", map[string]interface{}{
+		"before": team.Quota,
+		"after":  quota,
+	})
+
+	team.Quota = quota
+	usage, err := s.usage(ctx, teamID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return s.status(team, usage), nil
+}
+
+// Admit checks that the container fits into its team's quota alongside the
+// team's other containers, then runs apply, which stores the container. A
+// container without a team is always admitted. Only dimensions the container
+// adds to can be exceeded, so a team already over a lowered quota may still
+// shrink or edit its containers.
+func (s *TeamService) Admit(ctx context.Context, actor model.Actor, container *model.Container, apply func() error) error {
+	if container.TeamID == nil {
+		return apply()
+	}
+
+	s.admission.Lock()
+	defer s.admission.Unlock()
+
+	team, err := s.teamRepo.GetByID(ctx, *container.TeamID)
+	if err != nil {
+		if strings.Contains(err.Error(), "not found") {
+			return apperrors.Wrap(err, apperrors.CodeInvalidRequest, "invalid request")
+		}
+		return err
+	}
+	if err := s.checkMembership(ctx, team, actor); err != nil {
+		return err
+	}
+
+	before, err := s.usage(ctx, team.ID, container.ID)
+	if err != nil {
+		return err
+	}
+	requested := s.Reservation(container)
+	after := before
+	after.Add(requested)
+
+	beforeDims := team.Quota.Dimensions(before)
+	afterDims := team.Quota.Dimensions(after)
+
+	var exceeded []string
+	for i, dim := range afterDims {
+		if dim.Exceeded() && dim.Used > beforeDims[i].Used {
+			exceeded = append(exceeded, dim.Name)
+		}
+	}
+	if len(exceeded) > 0 {
+		return &QuotaExceededError{
+			Exceeded:  exceeded,
+			Requested: requested,
+			Status:    s.status(team, after),
+		}
+	}
+
+	if err := apply(); err != nil {
+		return err
+	}
+
+	s.warnNearingQuota(ctx, team, beforeDims, afterDims)
+	return nil
+}
+
+// Reservation is what a container counts against its team's quota. Limits
+// come from the resources of the stored config; a container without a memory
+// or CPU limit reserves the configured default for it.
+func (s *TeamService) Reservation(container *model.Container) model.ResourceReservation {
+	var resources docker.ResourceConfig
+	if container.ConfigJSON != "" {
+		var config struct {
+			Resources *docker.ResourceConfig `json:"resources"`
+		}
+		if err := json.Unmarshal([]byte(container.ConfigJSON), &config); err == nil && config.Resources != nil {
+			resources = *config.Resources
+		}
+	}
+
+	defaults := s.defaultReservation()
+	reservation := model.ResourceReservation{
+		Memory:   resources.Memory,
+		NanoCPUs: defaults.NanoCPUs,
+	}
+	if resources.CPUQuota > 0 {
+		period := resources.CPUPeriod
+		if period <= 0 {
+			period = defaultCPUPeriod
+		}
+		reservation.NanoCPUs = resources.CPUQuota * 1e9 / period
+	} else {
+		reservation.Unlimited = true
+	}
+	if reservation.Memory <= 0 {
+		reservation.Memory = defaults.Memory
+		reservation.Unlimited = true
+	}
+	return reservation
+}
+
+// usage sums the reservations of a team's containers, leaving out the one
+// being admitted
+func (s *TeamService) usage(ctx context.Context, teamID, excludeID int) (model.QuotaUsage, error) {
+	var usage model.QuotaUsage
+
+	containers, err := s.teamRepo.ListContainers(ctx, teamID)
+	if err != nil {
+		return usage, err
+	}
+	for _, c := range containers {
+		if c.ID == excludeID {
+			continue
+		}
+		usage.Add(s.Reservation(c))
+	}
+	return usage, nil
+}
+
+func (s *TeamService) status(team *model.Team, usage model.QuotaUsage) *TeamQuotaStatus {
+	return &TeamQuotaStatus{
+		TeamID:             team.ID,
+		TeamName:           team.Name,
+		Quota:              team.Quota,
+		Usage:              usage,
+		Dimensions:         team.Quota.Dimensions(usage),
+		DefaultReservation: s.defaultReservation(),
+		WarnPercent:        s.warnPercent(),
+	}
+}
+
+// checkMembership allows system components, members of the team and admins
+// to place containers in it
+func (s *TeamService) checkMembership(ctx context.Context, team *model.Team, actor model.Actor) error {
+	if actor.IsSystem() {
+		return nil
+	}
+	for _, m := range team.Members {
+		if actor.IsUser(m.UserID) {
+			return nil
+		}
+	}
+	if actor.UserID != nil {
+		if user, err := s.userRepo.GetByID(ctx, *actor.UserID); err == nil && user.IsAdmin() {
+			return nil
+		}
+	}
+	return apperrors.Newf(apperrors.CodePermissionDenied, "access denied: not a member of team %q", team.Name)
+}
+
+// warnNearingQuota notifies the team admins of the dimensions that crossed
+// the warn percent
+func (s *TeamService) warnNearingQuota(ctx context.Context, team *model.Team, before, after []model.QuotaDimension) {
+	if s.notificationService == nil {
+		return
+	}
+
+	warn := float64(s.warnPercent())
+	var lines []string
+	for i, dim := range after {
+		if dim.Percent == nil || *dim.Percent < warn {
+			continue
+		}
+		if before[i].Percent != nil && *before[i].Percent >= warn {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s: %d of %d (%.0f%%)", dim.Name, dim.Used, *dim.Limit, *dim.Percent))
+	}
+	if len(lines) == 0 {
+		return
+	}
+
+	title := fmt.Sprintf("Team %s is nearing its quota", team.Name)
+	message := strings.Join(lines, "\n")
+	data := map[string]interface{}{
+		"team_id":    team.ID,
+		"dimensions": after,
+	}
+	for _, m := range team.Members {
+		if !m.IsAdmin {
+			continue
+		}
+		userID := m.UserID
+		if _, err := s.notificationService.CreateNotification(ctx, &userID, NotificationTypeWarning, title, message, data); err != nil {
+			logrus.WithError(err).WithFields(logrus.Fields{
+				"team_id": team.ID,
+				"user_id": userID,
+			}).Warn("Failed to notify team admin of quota usage")
+		}
+	}
+}
+
+func (s *TeamService) defaultReservation() model.ResourceReservation {
+	memoryMB, cpus := 512, 1.0
+	if s.config != nil {
+		if s.config.System.QuotaDefaultMemoryMB > 0 {
+			memoryMB = s.config.System.QuotaDefaultMemoryMB
+		}
+		if s.config.System.QuotaDefaultCPUs > 0 {
+			cpus = s.config.System.QuotaDefaultCPUs
+		}
+	}
+	return model.ResourceReservation{
+		Memory:    int64(memoryMB) << 20,
+		NanoCPUs:  int64(cpus * 1e9),
+		Unlimited: true,
+	}
+}
+
+func (s *TeamService) warnPercent() int {
+	if s.config == nil || s.config.System.QuotaWarnPercent <= 0 {
+		return 80
+	}
+	return s.config.System.QuotaWarnPercent
+}
+
+// logTeamActivity audits a change to a team, its members or its quota
+func (s *TeamService) logTeamActivity(actor model.Actor, action string, team *model.Team, description string, metadata map[string]interface{}) {
+	if s.activityRepo == nil {
+		return
+	}
+
+	metadataJSON, _ := json.Marshal(metadata)
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "team",
+		ResourceID:   &team.ID,
+		ResourceName: team.Name,
+		Description:  description,
+		Metadata:     string(metadataJSON),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithField("team_id", team.ID).Warn("Failed to log team activity")
+	}
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/model"
+)
+
+// ListUpdateHistory lists the updates of the actor's containers matching the
+// filter, newest first. A filter with a Cursor pages by keyset instead of
+// offset and leaves Total unset.
+func (s *ContainerService) ListUpdateHistory(ctx context.Context, actor model.Actor, filter *model.UpdateHistoryFilter) (*dto.UpdateHistoryListResponse, error) {
+	if filter == nil {
+		filter = &model.UpdateHistoryFilter{}
+	}
+	if err := validateUpdateHistoryFilter(filter); err != nil {
+		return nil, err
+	}
+	if !actor.IsSystem() {
+		if actor.UserID == nil {
+			return &dto.UpdateHistoryListResponse{Updates: []*dto.UpdateHistoryEntry{}}, nil
+		}
+		filter.ContainerOwner = actor.OwnerID()
+	}
+
+	// Set defaults
+	if filter.Limit <= 0 {
+		filter.Limit = 20
+	}
+	if filter.Limit > 100 {
+		filter.Limit = 100
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+	filter.OrderBy = "started_at DESC, id DESC"
+
+	histories, total, err := s.updateHistoryRepo.List(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list update history: %w", err)
+	}
+
+	response := &dto.UpdateHistoryListResponse{
+		Updates: make([]*dto.UpdateHistoryEntry, len(histories)),
+		Limit:   filter.Limit,
+	}
+	for i, history := range histories {
+		response.Updates[i] = dto.NewUpdateHistoryEntry(history)
+	}
+
+	if filter.Cursor != nil {
+		response.HasPrev = !filter.Cursor.IsZero()
+		if n := len(histories); n > 0 {
+			last := histories[n-1]
+			response.NextCursor = model.NextCursor(n, filter.Limit, last.StartedAt, int64(last.ID))
+		}
+		response.HasNext = response.NextCursor != ""
+		return response, nil
+	}
+
+	response.Total = total
+	response.Page = filter.Offset/filter.Limit + 1
+	response.HasNext = filter.Offset+filter.Limit < int(total)
+	response.HasPrev = filter.Offset > 0
+	return response, nil
+}
+
+// GetContainerUpdateHistory lists the updates of one container matching the
+// filter, newest first
+func (s *ContainerService) GetContainerUpdateHistory(ctx context.Context, actor model.Actor, containerID int64, filter *model.UpdateHistoryFilter) (*dto.UpdateHistoryListResponse, error) {
+	container, err := s.containerRepo.GetByID(ctx, containerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	if filter == nil {
+		filter = &model.UpdateHistoryFilter{}
+	}
+	filter.ContainerID = &container.ID
+	return s.ListUpdateHistory(ctx, actor, filter)
+}
+
+// SummarizeUpdateHistory counts the updates of the actor's containers
+// matching the filter per status and averages their duration. Updates
+// recorded as finished without a completion time count as interrupted and
+// are left out of the average.
+func (s *ContainerService) SummarizeUpdateHistory(ctx context.Context, actor model.Actor, filter *model.UpdateHistoryFilter) (*model.UpdateHistorySummary, error) {
+	if filter == nil {
+		filter = &model.UpdateHistoryFilter{}
+	}
+	if err := validateUpdateHistoryFilter(filter); err != nil {
+		return nil, err
+	}
+	if !actor.IsSystem() {
+		if actor.UserID == nil {
+			return &model.UpdateHistorySummary{ByStatus: map[model.UpdateStatus]int64{}}, nil
+		}
+		filter.ContainerOwner = actor.OwnerID()
+	}
+
+	summary, err := s.updateHistoryRepo.Summarize(ctx, filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize update history: %w", err)
+	}
+	return summary, nil
+}
+
+// validateUpdateHistoryFilter checks the status, trigger and date range of an
+// update history filter
+func validateUpdateHistoryFilter(filter *model.UpdateHistoryFilter) error {
+	if filter.Status != "" && !slices.Contains(model.GetValidUpdateStatuses(), filter.Status) {
+		return fmt.Errorf("invalid request: unknown status %q", filter.Status)
+	}
+	if filter.TriggeredBy != "" && !slices.Contains(model.GetValidTriggerTypes(), filter.TriggeredBy) {
+		return fmt.Errorf("invalid request: unknown trigger %q", filter.TriggeredBy)
+	}
+	if filter.StartedAfter != nil && filter.StartedBefore != nil && filter.StartedAfter.After(*filter.StartedBefore) {
+		return fmt.Errorf("invalid request: start_date is after end_date")
+	}
+	return nil
+}
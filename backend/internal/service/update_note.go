@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// UpdateNoteRequest adds or edits a note on an update
+type UpdateNoteRequest struct {
+	Body     string `json:"body" binding:"required"`
+	Markdown bool   `json:"markdown"`
+}
+
+// AddUpdateNote attaches a note to an update the actor can see
+func (s *ContainerService) AddUpdateNote(ctx context.Context, actor model.Actor, updateID int64, req *UpdateNoteRequest) (*model.UpdateNote, error) {
+	history, err := s.GetUpdate(ctx, actor, updateID)
+	if err != nil {
+		return nil, err
+	}
+	return s.addUpdateNote(ctx, actor, history, req)
+}
+
+// EditUpdateNote replaces the body of a note. Authors may edit their notes
+// within the configured window, admins at any time.
+func (s *ContainerService) EditUpdateNote(ctx context.Context, actor model.Actor, updateID int64, noteID int, req *UpdateNoteRequest) (*model.UpdateNote, error) {
+	if s.updateNoteRepo == nil {
+		return nil, fmt.Errorf("update notes are not available")
+	}
+	history, err := s.GetUpdate(ctx, actor, updateID)
+	if err != nil {
+		return nil, err
+	}
+
+	note, err := s.updateNoteRepo.GetByID(ctx, noteID)
+	if err != nil {
+		return nil, err
+	}
+	if note.UpdateID != history.ID {
+		return nil, fmt.Errorf("update note with ID %d not found", noteID)
+	}
+
+	if err := s.checkNoteEditPermission(ctx, actor, note); err != nil {
+		return nil, err
+	}
+
+	body, err := model.SanitizeUpdateNote(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+	note.Body = body
+	note.Markdown = req.Markdown
+	note.UpdatedAt = time.Now()
+
+	if err := s.updateNoteRepo.Update(ctx, note); err != nil {
+		return nil, err
+	}
+
+	s.logContainerActivity(actor, int64(history.ContainerID), "update_note_edited", "Update note edited", map[string]interface{}{
+		"update_id": note.UpdateID,
+		"note_id":   note.ID,
+	})
+	return note, nil
+}
+
+func (s *ContainerService) addUpdateNote(ctx context.Context, actor model.Actor, history *model.UpdateHistory, req *UpdateNoteRequest) (*model.UpdateNote, error) {
+	if s.updateNoteRepo == nil {
+		return nil, fmt.Errorf("update notes are not available")
+	}
+
+	body, err := model.SanitizeUpdateNote(req.Body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request: %w", err)
+	}
+
+	note := &model.UpdateNote{
+		UpdateID:   history.ID,
+		Body:       body,
+		Markdown:   req.Markdown,
+		AuthorID:   actor.OwnerID(),
+		AuthorName: actor.Name,
+	}
+	if err := s.updateNoteRepo.Create(ctx, note); err != nil {
+		return nil, err
+	}
+
+	s.logContainerActivity(actor, int64(history.ContainerID), "update_note_added", "Note added to update", map[string]interface{}{
+		"update_id": history.ID,
+		"note_id":   note.ID,
+	})
+	return note, nil
+}
+
+// checkNoteEditPermission allows admins, and the author while the edit
+// window is open
+func (s *ContainerService) checkNoteEditPermission(ctx context.Context, actor model.Actor, note *model.UpdateNote) error {
+	if actor.UserID != nil && s.userService != nil {
+		user, err := s.userService.GetUserByID(ctx, *actor.UserID)
+		if err == nil && user.IsAdmin() {
+			return nil
+		}
+	}
+
+	if note.AuthorID == nil || !actor.IsUser(int64(*note.AuthorID)) {
+		return fmt.Errorf("access denied: only the author or an admin can edit a note")
+	}
+	if time.Since(note.CreatedAt) > s.noteEditWindow() {
+		return fmt.Errorf("access denied: notes can only be edited within %s of being added", s.noteEditWindow())
+	}
+	return nil
+}
+
+func (s *ContainerService) noteEditWindow() time.Duration {
+	if s.config != nil && s.config.System.UpdateNoteEditWindowMinutes > 0 {
+		return time.Duration(s.config.System.UpdateNoteEditWindowMinutes) * time.Minute
+	}
+	return time.Hour
+}
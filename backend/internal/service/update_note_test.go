@@ -0,0 +1,177 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+// noteRepo keeps update notes in memory
+type noteRepo struct {
+	repository.UpdateNoteRepository
+	notes []*model.UpdateNote
+}
+
+func (r *noteRepo) Create(ctx context.Context, note *model.UpdateNote) error {
+	note.ID = len(r.notes) + 1
+	note.CreatedAt = time.Now()
+	note.UpdatedAt = note.CreatedAt
+	copied := *note
+	r.notes = append(r.notes, &copied)
+	return nil
+}
+
+func (r *noteRepo) GetByID(ctx context.Context, id int) (*model.UpdateNote, error) {
+	if id < 1 || id > len(r.notes) {
+		return nil, fmt.Errorf("update note with ID %d not found", id)
+	}
+	copied := *r.notes[id-1]
+	return &copied, nil
+}
+
+func (r *noteRepo) Update(ctx context.Context, note *model.UpdateNote) error {
+	copied := *note
+	r.notes[note.ID-1] = &copied
+	return nil
+}
+
+func (r *noteRepo) ListByUpdate(ctx context.Context, updateID int) ([]*model.UpdateNote, error) {
+	var notes []*model.UpdateNote
+	for _, note := range r.notes {
+		if note.UpdateID == updateID {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+// noteHistoryRepo serves updates of container 5
+type noteHistoryRepo struct {
+	repository.UpdateHistoryRepository
+}
+
+func (r *noteHistoryRepo) GetByID(ctx context.Context, id int64) (*model.UpdateHistory, error) {
+	return &model.UpdateHistory{ID: int(id), ContainerID: 5, Status: model.UpdateStatusCompleted}, nil
+}
+
+// noteUserRepo knows alice, the container's creator, and carol, an admin
+type noteUserRepo struct {
+	repository.UserRepository
+}
+
+func (r *noteUserRepo) GetByID(ctx context.Context, id int64) (*model.User, error) {
+	switch id {
+	case 1:
+		return &model.User{ID: 1, Username: "alice", Role: model.UserRoleOperator, IsActive: true}, nil
+	case 3:
+		return &model.User{ID: 3, Username: "carol", Role: model.UserRoleAdmin, IsActive: true}, nil
+	}
+	return nil, fmt.Errorf("user with ID %d not found", id)
+}
+
+func newNoteTestService(editWindowMinutes int) (*ContainerService, *noteRepo) {
+	cfg := &config.Config{}
+	cfg.System.UpdateNoteEditWindowMinutes = editWindowMinutes
+	creator := 1
+	notes := &noteRepo{}
+	return &ContainerService{
+		containerRepo:     &execContainerRepo{container: model.Container{ID: 5, Name: "web", CreatedBy: &creator}},
+		updateHistoryRepo: &noteHistoryRepo{},
+		updateNoteRepo:    notes,
+		userService:       &UserService{userRepo: &noteUserRepo{}},
+		activityRepo:      &activityRecorder{},
+		config:            cfg,
+	}, notes
+}
+
+func TestAddUpdateNoteSanitizesBody(t *testing.T) {
+	ctx := context.Background()
+	s, notes := newNoteTestService(0)
+
+	note, err := s.AddUpdateNote(ctx, model.UserActor(1, "alice"), 9, &UpdateNoteRequest{
+		Body:     "  Coordinated with <b>team X</b>\r\nmigration ran\x07 by hand  ",
+		Markdown: true,
+	})
+	if err != nil {
+		t.Fatalf("AddUpdateNote failed: %v", err)
+	}
+	want := "Coordinated with &lt;b>team X&lt;/b>\nmigration ran by hand"
+	if note.Body != want || !note.Markdown || note.UpdateID != 9 || note.AuthorName != "alice" || note.AuthorID == nil || *note.AuthorID != 1 {
+		t.Errorf("note = %+v, want %q by alice on update 9", note, want)
+	}
+
+	// The update detail carries its notes
+	history, err := s.GetUpdate(ctx, model.UserActor(1, "alice"), 9)
+	if err != nil {
+		t.Fatalf("GetUpdate failed: %v", err)
+	}
+	if len(history.Notes) != 1 || history.Notes[0].Body != want {
+		t.Errorf("update notes = %+v, want the note added", history.Notes)
+	}
+
+	for _, body := range []string{" \t\r\n ", strings.Repeat("é", model.MaxUpdateNoteLength+1)} {
+		if _, err := s.AddUpdateNote(ctx, model.UserActor(1, "alice"), 9, &UpdateNoteRequest{Body: body}); err == nil {
+			t.Errorf("added a note of %d characters", len([]rune(body)))
+		}
+	}
+	if len(notes.notes) != 1 {
+		t.Errorf("stored %d notes, want only the valid one", len(notes.notes))
+	}
+}
+
+func TestEditUpdateNotePermissions(t *testing.T) {
+	ctx := context.Background()
+	alice, carol := model.UserActor(1, "alice"), model.UserActor(3, "carol")
+	edit := &UpdateNoteRequest{Body: "migration ran at 02:00"}
+
+	s, notes := newNoteTestService(30)
+	aliceNote, err := s.AddUpdateNote(ctx, alice, 9, &UpdateNoteRequest{Body: "migration ran by hand"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	carolNote, err := s.AddUpdateNote(ctx, carol, 9, &UpdateNoteRequest{Body: "approved in the change board"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Authors edit their notes within the window
+	if _, err := s.EditUpdateNote(ctx, alice, 9, aliceNote.ID, edit); err != nil {
+		t.Errorf("author editing within the window: %v", err)
+	}
+
+	// Nobody but an admin edits someone else's note
+	if _, err := s.EditUpdateNote(ctx, alice, 9, carolNote.ID, edit); !isAccessDenied(err) {
+		t.Errorf("alice editing carol's note = %v, want access denied", err)
+	}
+
+	// Past the window only admins edit
+	notes.notes[aliceNote.ID-1].CreatedAt = time.Now().Add(-31 * time.Minute)
+	if _, err := s.EditUpdateNote(ctx, alice, 9, aliceNote.ID, edit); !isAccessDenied(err) {
+		t.Errorf("author editing after the window = %v, want access denied", err)
+	}
+	if _, err := s.EditUpdateNote(ctx, carol, 9, aliceNote.ID, &UpdateNoteRequest{Body: "ran at 02:30"}); err != nil {
+		t.Errorf("admin editing after the window: %v", err)
+	}
+
+	// A note is only reached through its own update
+	if _, err := s.EditUpdateNote(ctx, carol, 10, carolNote.ID, edit); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("editing through another update = %v, want not found", err)
+	}
+
+	if body := notes.notes[carolNote.ID-1].Body; body != "approved in the change board" {
+		t.Errorf("carol's note = %q, want it unchanged", body)
+	}
+	if body := notes.notes[aliceNote.ID-1].Body; body != "ran at 02:30" {
+		t.Errorf("alice's note = %q, want the admin's edit", body)
+	}
+}
+
+func isAccessDenied(err error) bool {
+	return err != nil && strings.HasPrefix(err.Error(), "access denied")
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/events"
+	"docker-auto/pkg/model"
+)
+
+const (
+	// updateStepPullingImage is the step pull progress is published under
+	updateStepPullingImage = "pulling_image"
+	// pullProgressPublishInterval limits how often the pull progress of an
+	// update is published on the container state stream
+	pullProgressPublishInterval = time.Second
+)
+
+// updateProgressRegistry keeps the pull progress of the updates running in
+// this process by update ID, so concurrent updates never share an entry
+type updateProgressRegistry struct {
+	mu      sync.Mutex
+	entries map[int]*updateProgressEntry
+}
+
+type updateProgressEntry struct {
+	pull        model.PullProgress
+	updatedAt   time.Time
+	publishedAt time.Time
+}
+
+func newUpdateProgressRegistry() *updateProgressRegistry {
+	return &updateProgressRegistry{entries: make(map[int]*updateProgressEntry)}
+}
+
+// set records the progress of an update and reports whether it is due to be
+// published: a second after the last publication, or once the pull is done
+func (r *updateProgressRegistry) set(updateID int, pull model.PullProgress) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[updateID]
+	if !ok {
+		entry = &updateProgressEntry{}
+		r.entries[updateID] = entry
+	}
+	entry.pull = pull
+	entry.updatedAt = time.Now()
+
+	if pull.Percent < 100 && entry.updatedAt.Sub(entry.publishedAt) < pullProgressPublishInterval {
+		return false
+	}
+	entry.publishedAt = entry.updatedAt
+	return true
+}
+
+func (r *updateProgressRegistry) get(updateID int) (model.PullProgress, time.Time, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	entry, ok := r.entries[updateID]
+	if !ok {
+		return model.PullProgress{}, time.Time{}, false
+	}
+	return entry.pull, entry.updatedAt, true
+}
+
+func (r *updateProgressRegistry) delete(updateID int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.entries, updateID)
+}
+
+// PullProgressReporter returns the function the image pull of an update
+// reports its progress to. The progress is kept for GetUpdateProgress and
+// published on the container state stream at most once a second.
+func (s *ContainerService) PullProgressReporter(container *model.Container, history *model.UpdateHistory) func(model.PullProgress) {
+	if history == nil || history.ID == 0 {
+		return nil
+	}
+
+	updateID := history.ID
+	return func(pull model.PullProgress) {
+		if !s.progress.set(updateID, pull) {
+			return
+		}
+		s.publishContainerState(container, &events.ContainerState{
+			Action:   events.ContainerActionUpdateProgress,
+			Status:   string(model.UpdateStatusRunning),
+			UpdateID: updateID,
+			Step:     updateStepPullingImage,
+			Pull:     &pull,
+			Time:     time.Now(),
+		})
+	}
+}
+
+// ForgetUpdateProgress drops the progress kept for an update that ended
+func (s *ContainerService) ForgetUpdateProgress(updateID int) {
+	s.progress.delete(updateID)
+}
+
+// GetUpdateProgress returns how far an update has come, with the progress of
+// its image pull while the update runs in this server
+func (s *ContainerService) GetUpdateProgress(ctx context.Context, actor model.Actor, updateID int64) (*dto.UpdateProgress, error) {
+	history, err := s.updateHistoryRepo.GetByID(ctx, updateID)
+	if err != nil {
+		return nil, err
+	}
+
+	container, err := s.containerRepo.GetByID(ctx, int64(history.ContainerID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get container: %w", err)
+	}
+
+	if err := s.checkContainerPermission(ctx, container, actor, model.ContainerPermissionView); err != nil {
+		return nil, err
+	}
+
+	progress := &dto.UpdateProgress{
+		UpdateID:    history.ID,
+		ContainerID: history.ContainerID,
+		Status:      history.Status,
+	}
+	if history.Status == model.UpdateStatusRunning {
+		if pull, updatedAt, ok := s.progress.get(history.ID); ok {
+			progress.Pull = &pull
+			progress.UpdatedAt = &updatedAt
+		}
+	}
+	return progress, nil
+}
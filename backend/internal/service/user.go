@@ -6,27 +6,36 @@ import (
 	"time"
 
 	"docker-auto/internal/config"
-	"docker-auto/internal/model"
 	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
+	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// sessionSeenInterval is how stale last_seen_at of a session may get before
+// a use of its tokens is written
+const sessionSeenInterval = time.Minute
+
 // UserService manages user authentication and operations
 type UserService struct {
 	userRepo     repository.UserRepository
+	roleRepo     repository.RoleRepository
 	sessionRepo  repository.UserSessionRepository
 	activityRepo repository.ActivityLogRepository
 	config       *config.Config
 	cache        *CacheService
 	jwtManager   *utils.JWTManager
+	connections  sessionConnections
 }
 
 // NewUserService creates a new user service instance
 func NewUserService(
 	userRepo repository.UserRepository,
+	roleRepo repository.RoleRepository,
 	sessionRepo repository.UserSessionRepository,
 	activityRepo repository.ActivityLogRepository,
 	config *config.Config,
@@ -34,6 +43,7 @@ func NewUserService(
 ) *UserService {
 	return &UserService{
 		userRepo:     userRepo,
+		roleRepo:     roleRepo,
 		sessionRepo:  sessionRepo,
 		activityRepo: activityRepo,
 		config:       config,
@@ -49,6 +59,9 @@ func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 	if req == nil {
 		return nil, fmt.Errorf("login request cannot be nil")
 	}
+	if !s.config.OIDC.LocalLoginEnabled {
+		return nil, apperrors.New(apperrors.CodePermissionDenied, "password login is disabled")
+	}
 
 	// Validate input
 	if err := s.validateLoginRequest(req); err != nil {
@@ -69,17 +82,33 @@ func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 		return nil, fmt.Errorf("user account is inactive")
 	}
 
-	// Generate token pair
-	tokenPair, err := s.jwtManager.GenerateTokenPair(user)
+	response, err := s.startSession(ctx, user, req.IPAddress, req.UserAgent)
+	if err != nil {
+		return nil, err
+	}
+
+	// Log successful login
+	s.logUserActivity(user.ID, "login_success", "User logged in successfully", map[string]interface{}{
+		"remember": req.Remember,
+	})
+
+	return response, nil
+}
+
+// startSession creates a login session of an authenticated user and issues
+// its token pair
+func (s *UserService) startSession(ctx context.Context, user *model.User, ipAddress, userAgent string) (*LoginResponse, error) {
+	// Generate token pair, bound to the session created below
+	sessionID := uuid.New().String()
+	tokenPair, err := s.jwtManager.GenerateSessionTokenPair(user, sessionID)
 	if err != nil {
 		s.logUserActivity(user.ID, "token_generation_failed", "Failed to generate tokens", nil)
 		return nil, fmt.Errorf("failed to generate tokens: %w", err)
 	}
 
-	// Create user session
-	if err := s.createUserSession(user.ID, tokenPair.RefreshToken); err != nil {
-		logrus.WithError(err).WithField("user_id", user.ID).Warn("Failed to create user session")
-		// Don't fail login if session creation fails
+	// Create user session; its tokens are refused without it
+	if err := s.createUserSession(sessionID, user.ID, tokenPair.RefreshToken, ipAddress, userAgent); err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
 	}
 
 	// Update last login time
@@ -87,11 +116,6 @@ func (s *UserService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 		logrus.WithError(err).WithField("user_id", user.ID).Warn("Failed to update last login time")
 	}
 
-	// Log successful login
-	s.logUserActivity(user.ID, "login_success", "User logged in successfully", map[string]interface{}{
-		"remember": req.Remember,
-	})
-
 	return &LoginResponse{
 		User:         s.userToResponse(user),
 		AccessToken:  tokenPair.AccessToken,
@@ -106,10 +130,14 @@ func (s *UserService) Logout(ctx context.Context, userID int64, sessionID string
 		return fmt.Errorf("invalid user ID")
 	}
 
-	// Revoke session if provided
+	// Revoke session if provided; only the user's own
 	if sessionID != "" {
-		if err := s.sessionRepo.Delete(ctx, sessionID); err != nil {
+		if session, err := s.sessionRepo.GetByID(ctx, sessionID); err != nil || session.UserID != userID {
+			logrus.WithField("session_id", sessionID).Warn("Logout of an unknown session")
+		} else if err := s.sessionRepo.Delete(ctx, sessionID); err != nil {
 			logrus.WithError(err).WithField("session_id", sessionID).Warn("Failed to delete session")
+		} else {
+			s.connections.closeSessions(sessionID)
 		}
 	}
 
@@ -160,7 +188,7 @@ func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*T
 	}
 
 	// Generate new access token
-	newAccessToken, err := s.jwtManager.GenerateAccessToken(user)
+	newAccessToken, err := s.jwtManager.GenerateSessionAccessToken(user, session.ID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate new access token: %w", err)
 	}
@@ -218,7 +246,7 @@ func (s *UserService) Register(ctx context.Context, req *RegisterRequest) (*mode
 	}
 
 	// Validate input
-	if err := s.validateRegisterRequest(req); err != nil {
+	if err := s.validateRegisterRequest(ctx, req); err != nil {
 		return nil, fmt.Errorf("invalid register request: %w", err)
 	}
 
@@ -393,6 +421,7 @@ func (s *UserService) ChangePassword(ctx context.Context, userID int64, req *Cha
 	if err := s.sessionRepo.DeleteUserSessions(ctx, userID); err != nil {
 		logrus.WithError(err).WithField("user_id", userID).Warn("Failed to revoke user sessions")
 	}
+	s.connections.closeUser(userID)
 
 	// Clear cache
 	s.invalidateUserCache(userID)
@@ -418,6 +447,7 @@ func (s *UserService) DeactivateUser(ctx context.Context, userID int64) error {
 	if err := s.sessionRepo.DeleteUserSessions(ctx, userID); err != nil {
 		logrus.WithError(err).WithField("user_id", userID).Warn("Failed to revoke user sessions")
 	}
+	s.connections.closeUser(userID)
 
 	// Clear cache
 	s.invalidateUserCache(userID)
@@ -456,7 +486,7 @@ func (s *UserService) CreateUser(ctx context.Context, req *CreateUserRequest) (*
 	}
 
 	// Validate input
-	if err := s.validateCreateUserRequest(req); err != nil {
+	if err := s.validateCreateUserRequest(ctx, req); err != nil {
 		return nil, fmt.Errorf("invalid create user request: %w", err)
 	}
 
@@ -536,6 +566,9 @@ func (s *UserService) UpdateUser(ctx context.Context, userID int64, req *UpdateU
 	}
 
 	if req.Role != nil && model.UserRole(*req.Role) != user.Role {
+		if !s.isValidRole(ctx, *req.Role) {
+			return fmt.Errorf("invalid role: %s", *req.Role)
+		}
 		user.Role = model.UserRole(*req.Role)
 		changes["role"] = *req.Role
 		updated = true
@@ -549,6 +582,7 @@ func (s *UserService) UpdateUser(ctx context.Context, userID int64, req *UpdateU
 		// If user is being deactivated, revoke all sessions
 		if !*req.IsActive {
 			s.sessionRepo.DeleteUserSessions(ctx, userID)
+			s.connections.closeUser(userID)
 		}
 	}
 
@@ -589,7 +623,7 @@ func (s *UserService) CheckPermission(ctx context.Context, userID int64, permiss
 
 	// Check cache first
 	if cachedPermissions := s.getCachedPermissions(userID); cachedPermissions != nil {
-		return s.hasPermissionInList(cachedPermissions, permission), nil
+		return s.hasPermission(cachedPermissions, permission), nil
 	}
 
 	// Get user to determine role
@@ -598,14 +632,15 @@ func (s *UserService) CheckPermission(ctx context.Context, userID int64, permiss
 		return false, fmt.Errorf("user not found: %w", err)
 	}
 
-	// Check if user has permission based on role
-	hasPermission := s.hasPermission(string(user.Role), permission)
-
 	// Cache user permissions
-	permissions := s.getUserPermissions(string(user.Role))
+	permissions, err := s.getUserPermissions(ctx, user.Role)
+	if err != nil {
+		return false, fmt.Errorf("failed to get role permissions: %w", err)
+	}
 	s.cachePermissions(userID, permissions)
 
-	return hasPermission, nil
+	// Check if user has permission based on role
+	return s.hasPermission(permissions, permission), nil
 }
 
 // GetUserPermissions returns all permissions for a user
@@ -626,7 +661,10 @@ func (s *UserService) GetUserPermissions(ctx context.Context, userID int64) ([]s
 	}
 
 	// Get permissions for user role
-	permissions := s.getUserPermissions(string(user.Role))
+	permissions, err := s.getUserPermissions(ctx, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role permissions: %w", err)
+	}
 
 	// Cache permissions
 	s.cachePermissions(userID, permissions)
@@ -644,15 +682,7 @@ func (s *UserService) ChangeUserRole(ctx context.Context, userID int64, newRole
 	}
 
 	// Validate role
-	validRoles := model.GetValidRoles()
-	roleValid := false
-	for _, role := range validRoles {
-		if string(role) == newRole {
-			roleValid = true
-			break
-		}
-	}
-	if !roleValid {
+	if !s.isValidRole(ctx, newRole) {
 		return fmt.Errorf("invalid role: %s", newRole)
 	}
 
@@ -709,49 +739,89 @@ func (s *UserService) GetActiveSessions(ctx context.Context, userID int64) ([]*m
 	return activeSessions, nil
 }
 
-// RevokeSession revokes a specific session
-func (s *UserService) RevokeSession(ctx context.Context, sessionID string) error {
-	if sessionID == "" {
-		return fmt.Errorf("session ID cannot be empty")
+// ListSessions returns the active sessions of a user, marking the session
+// currentSessionID as the current one
+func (s *UserService) ListSessions(ctx context.Context, userID int64, currentSessionID string) ([]*SessionInfo, error) {
+	sessions, err := s.GetActiveSessions(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 
-	// Get session to log which user
+	result := make([]*SessionInfo, 0, len(sessions))
+	for _, session := range sessions {
+		info := s.sessionToResponse(session)
+		info.Current = currentSessionID != "" && session.ID == currentSessionID
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+// ResolveSession checks that the login session of an access token is still
+// active and records its use. Revoked sessions are deleted, so their tokens
+// are refused from the next request on.
+func (s *UserService) ResolveSession(ctx context.Context, userID int64, sessionID string) error {
 	session, err := s.sessionRepo.GetByID(ctx, sessionID)
 	if err != nil {
-		return fmt.Errorf("session not found: %w", err)
+		return err
+	}
+	if session.UserID != userID || session.IsExpired() {
+		return fmt.Errorf("session is expired or revoked")
+	}
+
+	now := time.Now()
+	if session.LastSeenAt == nil || now.Sub(*session.LastSeenAt) >= sessionSeenInterval {
+		if err := s.sessionRepo.TouchLastSeen(ctx, session.ID, now); err != nil {
+			logrus.WithError(err).WithField("session_id", session.ID).Warn("Failed to record session use")
+		}
+	}
+	return nil
+}
+
+// RevokeSession revokes a session of a user, logging the actor who did.
+// Sessions of other users are not found.
+func (s *UserService) RevokeSession(ctx context.Context, actor model.Actor, userID int64, sessionID string) error {
+	session, err := s.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil || session.UserID != userID {
+		return apperrors.New(apperrors.CodeNotFound, "session not found")
 	}
 
-	// Delete session
 	if err := s.sessionRepo.Delete(ctx, sessionID); err != nil {
 		return fmt.Errorf("failed to delete session: %w", err)
 	}
+	s.connections.closeSessions(sessionID)
 
-	// Log session revocation
-	s.logUserActivity(session.UserID, "session_revoked", "User session revoked", map[string]interface{}{
-		"session_id": sessionID,
-	})
-
+	s.logSessionRevocation(actor, userID, "session_revoked", []*model.UserSession{session})
 	return nil
 }
 
-// RevokeAllSessions revokes all sessions for a user
-func (s *UserService) RevokeAllSessions(ctx context.Context, userID int64) error {
+// RevokeOtherSessions revokes the sessions of a user except keepSessionID,
+// all of them when it is empty, and returns how many were revoked
+func (s *UserService) RevokeOtherSessions(ctx context.Context, actor model.Actor, userID int64, keepSessionID string) (int, error) {
 	if userID <= 0 {
-		return fmt.Errorf("invalid user ID")
+		return 0, apperrors.New(apperrors.CodeInvalidRequest, "invalid user ID")
 	}
 
-	// Delete all user sessions
-	if err := s.sessionRepo.DeleteUserSessions(ctx, userID); err != nil {
-		return fmt.Errorf("failed to delete user sessions: %w", err)
+	revoked, err := s.sessionRepo.DeleteOtherUserSessions(ctx, userID, keepSessionID)
+	if err != nil {
+		return 0, err
 	}
+	if len(revoked) == 0 {
+		return 0, nil
+	}
+	sessionIDs := make([]string, len(revoked))
+	for i, session := range revoked {
+		sessionIDs[i] = session.ID
+	}
+	s.connections.closeSessions(sessionIDs...)
 
-	// Clear cache
-	s.invalidateUserCache(userID)
-
-	// Log session revocation
-	s.logUserActivity(userID, "all_sessions_revoked", "All user sessions revoked", nil)
-
-	return nil
+	if keepSessionID == "" {
+		// Clear cache
+		s.invalidateUserCache(userID)
+		s.logSessionRevocation(actor, userID, "all_sessions_revoked", revoked)
+	} else {
+		s.logSessionRevocation(actor, userID, "other_sessions_revoked", revoked)
+	}
+	return len(revoked), nil
 }
 
 // Activity logging methods
@@ -777,6 +847,7 @@ func (s *UserService) GetUserActivities(ctx context.Context, userID int64, filte
 		Limit:   filter.Limit,
 		Offset:  filter.Page * filter.Limit,
 		OrderBy: "created_at DESC",
+		Cursor:  filter.Cursor,
 	}
 
 	if filter.Action != "" {
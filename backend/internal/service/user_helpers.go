@@ -1,6 +1,7 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/mail"
@@ -8,10 +9,9 @@ import (
 	"strings"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/utils"
 
-	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/crypto/bcrypt"
 )
@@ -64,11 +64,13 @@ func (s *UserService) generateTokens(user *model.User) (*TokenResponse, error) {
 }
 
 // createUserSession creates a new user session with refresh token
-func (s *UserService) createUserSession(userID int64, refreshToken string) error {
+func (s *UserService) createUserSession(sessionID string, userID int64, refreshToken, ipAddress, userAgent string) error {
 	session := &model.UserSession{
-		ID:           uuid.New().String(),
+		ID:           sessionID,
 		UserID:       userID,
 		RefreshToken: refreshToken,
+		IPAddress:    ipAddress,
+		UserAgent:    userAgent,
 		ExpiresAt:    time.Now().UTC().Add(time.Duration(s.config.JWT.RefreshDays) * 24 * time.Hour),
 		CreatedAt:    time.Now().UTC(),
 	}
@@ -126,53 +128,46 @@ func (s *UserService) validatePassword(password string) error {
 
 // Permission management helpers
 
-// hasPermission checks if a role has a specific permission
-func (s *UserService) hasPermission(userRole string, permission string) bool {
-	permissions, exists := RolePermissions[userRole]
-	if !exists {
-		return false
-	}
-
-	for _, p := range permissions {
-		if p == permission {
-			return true
-		}
+// hasPermission checks if a list of permissions grants a permission,
+// directly or through a broader one
+func (s *UserService) hasPermission(permissions []string, permission string) bool {
+	granted := make([]model.Permission, len(permissions))
+	for i, p := range permissions {
+		granted[i] = model.Permission(p)
 	}
-
-	return false
+	return model.GrantsPermission(granted, model.Permission(permission))
 }
 
-// hasAnyPermission checks if a role has any of the specified permissions
-func (s *UserService) hasAnyPermission(userRole string, permissions []string) bool {
-	for _, permission := range permissions {
-		if s.hasPermission(userRole, permission) {
-			return true
+// getUserPermissions returns all permissions a built-in or custom user role
+// grants
+func (s *UserService) getUserPermissions(ctx context.Context, userRole model.UserRole) ([]string, error) {
+	permissions, builtin := model.BuiltinRolePermissions(model.CanonicalRole(userRole))
+	if !builtin && s.roleRepo != nil {
+		role, err := s.roleRepo.GetByName(ctx, userRole)
+		if err != nil && !strings.Contains(err.Error(), "not found") {
+			return nil, err
+		}
+		if err == nil {
+			permissions = role.PermissionList()
 		}
-	}
-	return false
-}
-
-// getUserPermissions returns all permissions for a user role
-func (s *UserService) getUserPermissions(userRole string) []string {
-	permissions, exists := RolePermissions[userRole]
-	if !exists {
-		return []string{}
 	}
 
-	// Return a copy to prevent modification
 	result := make([]string, len(permissions))
-	copy(result, permissions)
-	return result
+	for i, p := range permissions {
+		result[i] = string(p)
+	}
+	return result, nil
 }
 
-// hasPermissionInList checks if a permission exists in a list of permissions
-func (s *UserService) hasPermissionInList(permissions []string, permission string) bool {
-	for _, p := range permissions {
-		if p == permission {
-			return true
-		}
+// isValidRole checks if users can be assigned a role: a built-in role or an
+// existing custom role
+func (s *UserService) isValidRole(ctx context.Context, role string) bool {
+	exists, err := roleExists(ctx, s.roleRepo, model.UserRole(role))
+	if err != nil {
+		logrus.WithError(err).WithField("role", role).Warn("Failed to look up role")
+		return false
 	}
-	return false
+	return exists
 }
 
 // Data conversion helpers
@@ -202,12 +197,13 @@ func (s *UserService) sessionToResponse(session *model.UserSession) *SessionInfo
 	}
 
 	return &SessionInfo{
-		ID:        session.ID,
-		UserID:    session.UserID,
-		IPAddress: session.IPAddress,
-		UserAgent: session.UserAgent,
-		ExpiresAt: session.ExpiresAt,
-		CreatedAt: session.CreatedAt,
+		ID:         session.ID,
+		UserID:     session.UserID,
+		IPAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		ExpiresAt:  session.ExpiresAt,
+		CreatedAt:  session.CreatedAt,
+		LastSeenAt: session.LastSeenAt,
 	}
 }
 
@@ -239,7 +235,7 @@ func (s *UserService) validateLoginRequest(req *LoginRequest) error {
 }
 
 // validateRegisterRequest validates registration request data
-func (s *UserService) validateRegisterRequest(req *RegisterRequest) error {
+func (s *UserService) validateRegisterRequest(ctx context.Context, req *RegisterRequest) error {
 	var errors []ValidationError
 
 	// Validate username
@@ -291,15 +287,7 @@ func (s *UserService) validateRegisterRequest(req *RegisterRequest) error {
 
 	// Validate role if provided
 	if req.Role != "" {
-		validRoles := model.GetValidRoles()
-		roleValid := false
-		for _, role := range validRoles {
-			if string(role) == req.Role {
-				roleValid = true
-				break
-			}
-		}
-		if !roleValid {
+		if !s.isValidRole(ctx, req.Role) {
 			errors = append(errors, ValidationError{
 				Field:   "role",
 				Message: "Invalid role",
@@ -316,7 +304,7 @@ func (s *UserService) validateRegisterRequest(req *RegisterRequest) error {
 }
 
 // validateCreateUserRequest validates create user request data
-func (s *UserService) validateCreateUserRequest(req *CreateUserRequest) error {
+func (s *UserService) validateCreateUserRequest(ctx context.Context, req *CreateUserRequest) error {
 	var errors []ValidationError
 
 	// Validate username
@@ -367,15 +355,7 @@ func (s *UserService) validateCreateUserRequest(req *CreateUserRequest) error {
 			Message: "Role is required",
 		})
 	} else {
-		validRoles := model.GetValidRoles()
-		roleValid := false
-		for _, role := range validRoles {
-			if string(role) == req.Role {
-				roleValid = true
-				break
-			}
-		}
-		if !roleValid {
+		if !s.isValidRole(ctx, req.Role) {
 			errors = append(errors, ValidationError{
 				Field:   "role",
 				Message: "Invalid role",
@@ -458,14 +438,15 @@ func (s *UserService) logUserActivity(userID int64, action, description string,
 	}
 
 	activity := &model.ActivityLog{
-		UserID:       &userID,
 		Action:       action,
 		ResourceType: "user",
+		ActorType:    model.ActorTypeUser,
 		Description:  description,
 		Metadata:     metadataJSON,
 		CreatedAt:    time.Now().UTC(),
 	}
 
+	// Failed logins have no known user
 	if userID > 0 {
 		activity.UserID = &userID
 	}
@@ -481,6 +462,37 @@ func (s *UserService) logUserActivity(userID int64, action, description string,
 	return nil
 }
 
+// logSessionRevocation logs the revocation of sessions of a user by actor
+func (s *UserService) logSessionRevocation(actor model.Actor, userID int64, action string, sessions []*model.UserSession) {
+	revoked := make([]map[string]interface{}, 0, len(sessions))
+	for _, session := range sessions {
+		revoked = append(revoked, map[string]interface{}{
+			"session_id": session.ID,
+			"ip_address": session.IPAddress,
+			"user_agent": session.UserAgent,
+		})
+	}
+	metadata, _ := json.Marshal(map[string]interface{}{"sessions": revoked})
+
+	resourceID := int(userID)
+	activity := &model.ActivityLog{
+		Action:       action,
+		ResourceType: "user",
+		ResourceID:   &resourceID,
+		Description:  fmt.Sprintf("Revoked %d session(s) of user %d", len(sessions), userID),
+		Metadata:     string(metadata),
+		CreatedAt:    time.Now().UTC(),
+	}
+	activity.SetActor(actor)
+
+	if err := s.activityRepo.Create(context.Background(), activity); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"user_id": userID,
+			"action":  action,
+		}).Warn("Failed to log session revocation")
+	}
+}
+
 // Cache management helpers
 
 // cacheUser caches user information
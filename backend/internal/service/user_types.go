@@ -2,6 +2,8 @@ package service
 
 import (
 	"time"
+
+	"docker-auto/pkg/model"
 )
 
 // Authentication related request types
@@ -9,6 +11,10 @@ type LoginRequest struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
 	Remember bool   `json:"remember,omitempty"`
+
+	// Client the session is created for, set by the controller
+	IPAddress string `json:"-"`
+	UserAgent string `json:"-"`
 }
 
 type RegisterRequest struct {
@@ -48,7 +54,7 @@ type CreateUserRequest struct {
 	Username string `json:"username" binding:"required,min=3,max=50"`
 	Email    string `json:"email" binding:"required,email"`
 	Password string `json:"password" binding:"required,min=6"`
-	Role     string `json:"role" binding:"required,oneof=admin operator viewer"`
+	Role     string `json:"role" binding:"required"`
 }
 
 type UpdateUserRequest struct {
@@ -88,76 +94,21 @@ type ActivityFilter struct {
 	Action    string     `json:"action,omitempty"`
 	Page      int        `json:"page,omitempty"`
 	Limit     int        `json:"limit,omitempty"`
-}
 
-// Permission definitions
-const (
-	// Container related permissions
-	PermissionContainerRead   = "container:read"
-	PermissionContainerCreate = "container:create"
-	PermissionContainerUpdate = "container:update"
-	PermissionContainerDelete = "container:delete"
-	PermissionContainerStart  = "container:start"
-	PermissionContainerStop   = "container:stop"
-
-	// Image related permissions
-	PermissionImageRead   = "image:read"
-	PermissionImageCheck  = "image:check"
-	PermissionImageUpdate = "image:update"
-
-	// Update related permissions
-	PermissionUpdateRead     = "update:read"
-	PermissionUpdateCreate   = "update:create"
-	PermissionUpdateRollback = "update:rollback"
-
-	// System related permissions
-	PermissionSystemRead   = "system:read"
-	PermissionSystemConfig = "system:config"
-	PermissionSystemLogs   = "system:logs"
-
-	// User related permissions
-	PermissionUserRead   = "user:read"
-	PermissionUserCreate = "user:create"
-	PermissionUserUpdate = "user:update"
-	PermissionUserDelete = "user:delete"
-)
-
-// Role permissions mapping
-var RolePermissions = map[string][]string{
-	"admin": {
-		// Administrator has all permissions
-		PermissionContainerRead, PermissionContainerCreate, PermissionContainerUpdate, PermissionContainerDelete,
-		PermissionContainerStart, PermissionContainerStop,
-		PermissionImageRead, PermissionImageCheck, PermissionImageUpdate,
-		PermissionUpdateRead, PermissionUpdateCreate, PermissionUpdateRollback,
-		PermissionSystemRead, PermissionSystemConfig, PermissionSystemLogs,
-		PermissionUserRead, PermissionUserCreate, PermissionUserUpdate, PermissionUserDelete,
-	},
-	"operator": {
-		// Operator permissions
-		PermissionContainerRead, PermissionContainerCreate, PermissionContainerUpdate,
-		PermissionContainerStart, PermissionContainerStop,
-		PermissionImageRead, PermissionImageCheck, PermissionImageUpdate,
-		PermissionUpdateRead, PermissionUpdateCreate, PermissionUpdateRollback,
-		PermissionSystemRead, PermissionSystemLogs,
-	},
-	"viewer": {
-		// Viewer permissions (read-only)
-		PermissionContainerRead,
-		PermissionImageRead,
-		PermissionUpdateRead,
-		PermissionSystemRead,
-	},
+	// Cursor selects keyset pagination instead of Page
+	Cursor *model.Cursor `json:"-"`
 }
 
 // SessionInfo represents user session information
 type SessionInfo struct {
-	ID        string    `json:"id"`
-	UserID    int64     `json:"user_id"`
-	IPAddress string    `json:"ip_address,omitempty"`
-	UserAgent string    `json:"user_agent,omitempty"`
-	ExpiresAt time.Time `json:"expires_at"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string     `json:"id"`
+	UserID     int64      `json:"user_id"`
+	IPAddress  string     `json:"ip_address,omitempty"`
+	UserAgent  string     `json:"user_agent,omitempty"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	Current    bool       `json:"current"`
 }
 
 // UserStats represents user statistics
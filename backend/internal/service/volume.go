@@ -0,0 +1,567 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/dto"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types/volume"
+	"github.com/sirupsen/logrus"
+)
+
+// volumeSampleRetention is how long volume samples are kept; it must cover
+// the longest growth window
+const volumeSampleRetention = 90 * 24 * time.Hour
+
+// VolumeMountRef is a container mounting a volume
+type VolumeMountRef struct {
+	ContainerID   string `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Destination   string `json:"destination"`
+	ReadOnly      bool   `json:"read_only"`
+}
+
+// VolumeGrowth is a volume's growth over a window of days. Percent is nil
+// when the volume was empty at the start of the window.
+type VolumeGrowth struct {
+	Days        int       `json:"days"`
+	Since       time.Time `json:"since"`
+	FromBytes   int64     `json:"from_bytes"`
+	ToBytes     int64     `json:"to_bytes"`
+	DeltaBytes  int64     `json:"delta_bytes"`
+	Percent     *float64  `json:"percent"`
+	BytesPerDay float64   `json:"bytes_per_day"`
+}
+
+// VolumeInfo describes a volume with its latest sampled size. SizeBytes is
+// nil when the size is unknown; SizeNote then says why.
+type VolumeInfo struct {
+	Name       string                 `json:"name"`
+	Driver     string                 `json:"driver"`
+	Mountpoint string                 `json:"mountpoint"`
+	CreatedAt  string                 `json:"created_at,omitempty"`
+	Labels     map[string]string      `json:"labels,omitempty"`
+	SizeBytes  *int64                 `json:"size_bytes"`
+	SizeStatus model.VolumeSizeStatus `json:"size_status,omitempty"`
+	SizeNote   string                 `json:"size_note,omitempty"`
+	SampledAt  *time.Time             `json:"sampled_at,omitempty"`
+	MountedBy  []VolumeMountRef       `json:"mounted_by"`
+	Growth7d   *VolumeGrowth          `json:"growth_7d"`
+	Growth30d  *VolumeGrowth          `json:"growth_30d"`
+}
+
+// VolumeList is a page of the volume usage overview. Total counts the
+// volumes matching the query; RefreshedAt is when the daemon was read.
+// DataRoot is the Docker data root filesystem as of the last sampling run.
+type VolumeList struct {
+	Volumes       []*VolumeInfo           `json:"volumes"`
+	Total         int64                   `json:"total"`
+	RefreshedAt   time.Time               `json:"refreshed_at"`
+	DataRoot      *docker.FilesystemUsage `json:"data_root,omitempty"`
+	LastSampledAt *time.Time              `json:"last_sampled_at,omitempty"`
+}
+
+// VolumeService samples Docker volume sizes, reports their growth and raises
+// volume growth and data root free space alerts
+type VolumeService struct {
+	volumeRepo          repository.VolumeUsageRepository
+	notificationService *NotificationService
+	dockerClient        *docker.DockerClient
+	config              *config.Config
+
+	sampling sync.Mutex
+
+	mu       sync.RWMutex
+	dataRoot *docker.FilesystemUsage
+}
+
+// NewVolumeService creates a new volume service instance
+func NewVolumeService(
+	volumeRepo repository.VolumeUsageRepository,
+	notificationService *NotificationService,
+	dockerClient *docker.DockerClient,
+	cfg *config.Config,
+) *VolumeService {
+	return &VolumeService{
+		volumeRepo:          volumeRepo,
+		notificationService: notificationService,
+		dockerClient:        dockerClient,
+		config:              cfg,
+	}
+}
+
+// ListVolumes lists a page of volumes with their latest sampled size, the
+// containers mounting them and their 7 and 30 day growth. The daemon is read
+// once per call; filtering and sorting happen here, and growth is only
+// computed for the volumes on the page. Listing never measures volumes; sizes
+// come from the last sampling run.
+func (s *VolumeService) ListVolumes(ctx context.Context, query *dto.VolumeListQuery) (*VolumeList, error) {
+	if query == nil {
+		query = &dto.VolumeListQuery{}
+	}
+	if err := query.Validate(); err != nil {
+		return nil, err
+	}
+
+	volumes, err := s.dockerClient.ListVolumes(ctx, volume.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := s.dockerClient.ListAllContainers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	refreshedAt := time.Now()
+
+	mounts := make(map[string][]VolumeMountRef)
+	for _, c := range containers {
+		if c.Labels[docker.HelperLabel] != "" {
+			continue
+		}
+		name := ""
+		if len(c.Names) > 0 {
+			name = strings.TrimPrefix(c.Names[0], "/")
+		}
+		for _, m := range c.Mounts {
+			if m.Type != "volume" || m.Name == "" {
+				continue
+			}
+			mounts[m.Name] = append(mounts[m.Name], VolumeMountRef{
+				ContainerID:   c.ID,
+				ContainerName: name,
+				Destination:   m.Destination,
+				ReadOnly:      !m.RW,
+			})
+		}
+	}
+
+	latest, err := s.volumeRepo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	samples := make(map[string]*model.VolumeUsageSample, len(latest))
+	for _, sample := range latest {
+		samples[sample.VolumeName] = sample
+	}
+
+	list := &VolumeList{RefreshedAt: refreshedAt}
+	matched := make([]*VolumeInfo, 0, len(volumes))
+	for _, v := range volumes {
+		if !volumeMatches(query, v, len(mounts[v.Name]) > 0) {
+			continue
+		}
+
+		info := &VolumeInfo{
+			Name:       v.Name,
+			Driver:     v.Driver,
+			Mountpoint: v.Mountpoint,
+			CreatedAt:  v.CreatedAt,
+			Labels:     v.Labels,
+			MountedBy:  mounts[v.Name],
+		}
+		if info.MountedBy == nil {
+			info.MountedBy = []VolumeMountRef{}
+		}
+
+		if sample, ok := samples[v.Name]; ok {
+			sampledAt := sample.SampledAt
+			info.SampledAt = &sampledAt
+			info.SizeStatus = sample.SizeStatus
+			info.SizeNote = sample.SizeNote()
+			if sample.SizeKnown() {
+				size := sample.SizeBytes
+				info.SizeBytes = &size
+			}
+			if list.LastSampledAt == nil || sampledAt.After(*list.LastSampledAt) {
+				list.LastSampledAt = &sampledAt
+			}
+		}
+
+		matched = append(matched, info)
+	}
+	sortVolumes(matched, query.Sort, query.Desc)
+
+	list.Total = int64(len(matched))
+	list.Volumes = pageVolumes(matched, query.Offset, query.Limit)
+	for _, info := range list.Volumes {
+		sample, ok := samples[info.Name]
+		if !ok {
+			continue
+		}
+		if info.Growth7d, err = s.growth(ctx, sample, 7); err != nil {
+			return nil, err
+		}
+		if info.Growth30d, err = s.growth(ctx, sample, 30); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.RLock()
+	list.DataRoot = s.dataRoot
+	s.mu.RUnlock()
+
+	return list, nil
+}
+
+// volumeMatches applies the listing filters to a volume
+func volumeMatches(query *dto.VolumeListQuery, v *volume.Volume, inUse bool) bool {
+	if query.Name != "" {
+		if ok, _ := path.Match(query.Name, v.Name); !ok {
+			return false
+		}
+	}
+	if query.Driver != "" && v.Driver != query.Driver {
+		return false
+	}
+	if !query.MatchesLabel(v.Labels) {
+		return false
+	}
+
+	switch query.Status {
+	case dto.VolumeStatusInUse:
+		return inUse
+	case dto.VolumeStatusUnused:
+		return !inUse
+	}
+	return true
+}
+
+// sortVolumes orders volumes by the sort field, then by name. Volumes of
+// unknown size sort after every known size.
+func sortVolumes(volumes []*VolumeInfo, field string, desc bool) {
+	sort.SliceStable(volumes, func(i, j int) bool {
+		a, b := volumes[i], volumes[j]
+		switch field {
+		case dto.VolumeSortSize:
+			if (a.SizeBytes == nil) != (b.SizeBytes == nil) {
+				return b.SizeBytes == nil
+			}
+			if a.SizeBytes != nil && *a.SizeBytes != *b.SizeBytes {
+				return (*a.SizeBytes < *b.SizeBytes) != desc
+			}
+		case dto.VolumeSortCreatedAt:
+			if a.CreatedAt != b.CreatedAt {
+				return (a.CreatedAt < b.CreatedAt) != desc
+			}
+		default:
+			if a.Name != b.Name {
+				return (a.Name < b.Name) != desc
+			}
+		}
+		return a.Name < b.Name
+	})
+}
+
+// pageVolumes returns the page of volumes at offset; a limit of zero returns
+// the rest
+func pageVolumes(volumes []*VolumeInfo, offset, limit int) []*VolumeInfo {
+	if offset >= len(volumes) {
+		return []*VolumeInfo{}
+	}
+	volumes = volumes[offset:]
+	if limit > 0 && limit < len(volumes) {
+		volumes = volumes[:limit]
+	}
+	return volumes
+}
+
+// Sample records the size of every volume and the data root free space, then
+// evaluates the alert rules. Runs are rate-limited: sampling again within the
+// minimum interval fails with ErrVolumeSampleRateLimited.
+func (s *VolumeService) Sample(ctx context.Context, req *dto.VolumeSampleRequest) (*dto.VolumeSampleResult, error) {
+	if req == nil {
+		req = &dto.VolumeSampleRequest{}
+	}
+
+	if !s.sampling.TryLock() {
+		return nil, dto.ErrVolumeSampleInProgress
+	}
+	defer s.sampling.Unlock()
+
+	lastSampledAt, err := s.volumeRepo.GetLastSampledAt(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if lastSampledAt != nil {
+		if next := lastSampledAt.Add(s.minSampleInterval()); time.Now().Before(next) {
+			return nil, fmt.Errorf("%w: next sample allowed after %s", dto.ErrVolumeSampleRateLimited, next.Format(time.RFC3339))
+		}
+	}
+
+	volumes, err := s.dockerClient.GetVolumeUsage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.volumeRepo.GetLatest(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previous := make(map[string]*model.VolumeUsageSample, len(latest))
+	for _, sample := range latest {
+		previous[sample.VolumeName] = sample
+	}
+
+	result := &dto.VolumeSampleResult{SampledAt: time.Now(), Volumes: len(volumes)}
+	samples := make([]*model.VolumeUsageSample, 0, len(volumes))
+	for _, v := range volumes {
+		sample := s.sampleVolume(ctx, v.Name, v.Driver, v.UsageData, previous[v.Name], req.RescanLarge)
+		sample.SampledAt = result.SampledAt
+		samples = append(samples, sample)
+
+		switch sample.SizeStatus {
+		case model.VolumeSizeReported:
+			result.Reported++
+		case model.VolumeSizeMeasured:
+			result.Measured++
+		case model.VolumeSizeTooLarge:
+			result.TooLarge++
+		default:
+			result.Unavailable++
+		}
+	}
+
+	if err := s.volumeRepo.CreateBatch(ctx, samples); err != nil {
+		return nil, err
+	}
+
+	dataRoot, err := s.dockerClient.GetDataRootUsage(ctx, s.helperImage())
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to measure Docker data root free space")
+	} else {
+		s.mu.Lock()
+		s.dataRoot = dataRoot
+		s.mu.Unlock()
+		result.DataRoot = dataRoot
+	}
+
+	result.Alerts, err = s.evaluateAlerts(ctx, samples, dataRoot)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to evaluate volume alerts")
+	}
+	if len(result.Alerts) > 0 {
+		s.sendAlertNotification(ctx, result.Alerts)
+	}
+
+	if _, err := s.volumeRepo.DeleteOlderThan(ctx, result.SampledAt.Add(-volumeSampleRetention)); err != nil {
+		logrus.WithError(err).Warn("Failed to prune old volume usage samples")
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"volumes":     result.Volumes,
+		"reported":    result.Reported,
+		"measured":    result.Measured,
+		"too_large":   result.TooLarge,
+		"unavailable": result.Unavailable,
+		"alerts":      len(result.Alerts),
+	}).Info("Volume usage sampled")
+
+	return result, nil
+}
+
+// sampleVolume takes the size reported by the driver, or measures it with du.
+// Volumes last seen above the scan limit, or that previously timed out, are
+// skipped unless rescanLarge is set.
+func (s *VolumeService) sampleVolume(ctx context.Context, name, driver string, usage *volume.UsageData, previous *model.VolumeUsageSample, rescanLarge bool) *model.VolumeUsageSample {
+	sample := &model.VolumeUsageSample{VolumeName: name, Driver: driver, SizeBytes: -1}
+
+	if usage != nil && usage.Size >= 0 {
+		sample.SizeBytes = usage.Size
+		sample.SizeStatus = model.VolumeSizeReported
+		return sample
+	}
+
+	maxBytes := s.maxScanBytes()
+	if previous != nil && !rescanLarge {
+		if previous.SizeStatus == model.VolumeSizeTooLarge {
+			sample.SizeStatus = model.VolumeSizeTooLarge
+			sample.Error = previous.Error
+			return sample
+		}
+		if previous.SizeKnown() && previous.SizeBytes > maxBytes {
+			sample.SizeStatus = model.VolumeSizeTooLarge
+			sample.Error = fmt.Sprintf("last measured at %d bytes, above the %d byte scan limit", previous.SizeBytes, maxBytes)
+			return sample
+		}
+	}
+
+	timeout := s.scanTimeout()
+	size, err := s.dockerClient.MeasureVolumeSize(ctx, name, s.helperImage(), timeout)
+	switch {
+	case errors.Is(err, docker.ErrHelperTimeout):
+		sample.SizeStatus = model.VolumeSizeTooLarge
+		sample.Error = fmt.Sprintf("du did not finish within %s", timeout)
+	case err != nil:
+		logrus.WithError(err).WithField("volume", name).Warn("Failed to measure volume size")
+		sample.SizeStatus = model.VolumeSizeUnavailable
+		sample.Error = err.Error()
+		if len(sample.Error) > 500 {
+			sample.Error = sample.Error[:500]
+		}
+	default:
+		sample.SizeBytes = size
+		sample.SizeStatus = model.VolumeSizeMeasured
+	}
+
+	return sample
+}
+
+// evaluateAlerts checks the volume growth and data root free space rules.
+// A threshold of zero or less disables its rule.
+func (s *VolumeService) evaluateAlerts(ctx context.Context, samples []*model.VolumeUsageSample, dataRoot *docker.FilesystemUsage) ([]model.VolumeAlertEntry, error) {
+	var alerts []model.VolumeAlertEntry
+
+	threshold, days := s.growthAlertRule()
+	if threshold > 0 && days > 0 {
+		for _, sample := range samples {
+			growth, err := s.growth(ctx, sample, days)
+			if err != nil {
+				return alerts, err
+			}
+			if growth == nil || growth.Percent == nil || *growth.Percent <= threshold {
+				continue
+			}
+			alerts = append(alerts, model.VolumeAlertEntry{
+				Rule:             model.VolumeAlertRuleGrowth,
+				VolumeName:       sample.VolumeName,
+				ThresholdPercent: threshold,
+				ObservedPercent:  *growth.Percent,
+				Days:             days,
+				Bytes:            sample.SizeBytes,
+				Message: fmt.Sprintf("Volume %s grew %.1f%% in %d days (%d to %d bytes)",
+					sample.VolumeName, *growth.Percent, days, growth.FromBytes, growth.ToBytes),
+			})
+		}
+	}
+
+	minFree := s.minDataRootFreePercent()
+	if minFree > 0 && dataRoot != nil && dataRoot.TotalBytes > 0 {
+		if free := dataRoot.FreePercent(); free < minFree {
+			alerts = append(alerts, model.VolumeAlertEntry{
+				Rule:             model.VolumeAlertRuleDataRootSpace,
+				ThresholdPercent: minFree,
+				ObservedPercent:  free,
+				Bytes:            dataRoot.AvailableBytes,
+				Message: fmt.Sprintf("Free space on the Docker data root %s is %.1f%%, below %.0f%%",
+					dataRoot.Path, free, minFree),
+			})
+		}
+	}
+
+	return alerts, nil
+}
+
+// growth compares a sample with the volume's last known size at the start of
+// the window. Windows whose start sample is more than twice as old as the
+// window are not reported.
+func (s *VolumeService) growth(ctx context.Context, latest *model.VolumeUsageSample, days int) (*VolumeGrowth, error) {
+	if !latest.SizeKnown() {
+		return nil, nil
+	}
+
+	window := time.Duration(days) * 24 * time.Hour
+	base, err := s.volumeRepo.GetKnownSizeAt(ctx, latest.VolumeName, latest.SampledAt.Add(-window))
+	if err != nil || base == nil {
+		return nil, err
+	}
+
+	elapsed := latest.SampledAt.Sub(base.SampledAt)
+	if elapsed > 2*window {
+		return nil, nil
+	}
+
+	growth := &VolumeGrowth{
+		Days:       days,
+		Since:      base.SampledAt,
+		FromBytes:  base.SizeBytes,
+		ToBytes:    latest.SizeBytes,
+		DeltaBytes: latest.SizeBytes - base.SizeBytes,
+	}
+	if elapsed > 0 {
+		growth.BytesPerDay = float64(growth.DeltaBytes) / elapsed.Hours() * 24
+	}
+	if base.SizeBytes > 0 {
+		percent := float64(growth.DeltaBytes) / float64(base.SizeBytes) * 100
+		growth.Percent = &percent
+	}
+
+	return growth, nil
+}
+
+// sendAlertNotification sends the triggered alerts as one notification
+func (s *VolumeService) sendAlertNotification(ctx context.Context, alerts []model.VolumeAlertEntry) {
+	if s.notificationService == nil {
+		return
+	}
+
+	messages := make([]string, len(alerts))
+	for i, alert := range alerts {
+		messages[i] = alert.Message
+	}
+
+	notification := &model.Notification{
+		Type:     model.NotificationTypeDiskUsage,
+		Title:    "Volume Usage Alert",
+		Message:  strings.Join(messages, "\n"),
+		Priority: model.NotificationPriorityHigh,
+		Data:     model.NotificationData(model.NewVolumeAlertPayload(alerts)),
+	}
+
+	if err := s.notificationService.SendNotification(ctx, notification); err != nil {
+		logrus.WithError(err).Warn("Failed to send volume usage alert")
+	}
+}
+
+func (s *VolumeService) minSampleInterval() time.Duration {
+	if s.config == nil || s.config.Monitoring.VolumeSampleMinIntervalMinutes <= 0 {
+		return 60 * time.Minute
+	}
+	return time.Duration(s.config.Monitoring.VolumeSampleMinIntervalMinutes) * time.Minute
+}
+
+func (s *VolumeService) maxScanBytes() int64 {
+	if s.config == nil || s.config.Monitoring.VolumeScanMaxSizeGB <= 0 {
+		return 100 << 30
+	}
+	return int64(s.config.Monitoring.VolumeScanMaxSizeGB) << 30
+}
+
+func (s *VolumeService) scanTimeout() time.Duration {
+	if s.config == nil || s.config.Monitoring.VolumeScanTimeoutSeconds <= 0 {
+		return 5 * time.Minute
+	}
+	return time.Duration(s.config.Monitoring.VolumeScanTimeoutSeconds) * time.Second
+}
+
+func (s *VolumeService) helperImage() string {
+	if s.config == nil || s.config.Monitoring.VolumeHelperImage == "" {
+		return "busybox:stable"
+	}
+	return s.config.Monitoring.VolumeHelperImage
+}
+
+func (s *VolumeService) growthAlertRule() (float64, int) {
+	if s.config == nil {
+		return 20, 7
+	}
+	return float64(s.config.Monitoring.VolumeGrowthAlertPercent), s.config.Monitoring.VolumeGrowthAlertDays
+}
+
+func (s *VolumeService) minDataRootFreePercent() float64 {
+	if s.config == nil {
+		return 15
+	}
+	return float64(s.config.Monitoring.DockerRootMinFreePercent)
+}
@@ -1,21 +1,52 @@
 package service
 
 import (
-	"docker-auto/internal/model"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
+	"net/http"
+	"time"
+
+	"docker-auto/pkg/model"
 )
 
+// WebhookSignatureHeader carries the HMAC-SHA256 of a webhook body as
+// "sha256=<hex>" when a webhook secret is configured
+const WebhookSignatureHeader = "X-Docker-Auto-Signature"
+
 // WebhookService handles webhook notifications
 type WebhookService struct {
 	enabled bool
 	url     string
+	secret  string
+	client  *http.Client
+}
+
+// WebhookBody is the JSON body posted to webhook endpoints. Text carries a
+// plain rendering of the payload so Slack-compatible endpoints display it as is.
+type WebhookBody struct {
+	Type          model.NotificationType     `json:"type"`
+	Title         string                     `json:"title"`
+	Message       string                     `json:"message"`
+	Priority      model.NotificationPriority `json:"priority"`
+	Text          string                     `json:"text"`
+	Schema        model.PayloadSchema        `json:"schema,omitempty"`
+	SchemaVersion int                        `json:"schema_version,omitempty"`
+	Data          model.JSONMap              `json:"data,omitempty"`
 }
 
-// NewWebhookService creates a new webhook service
-func NewWebhookService(enabled bool, url string) *WebhookService {
+// NewWebhookService creates a new webhook service. Bodies are signed when
+// secret is not empty.
+func NewWebhookService(enabled bool, url, secret string) *WebhookService {
 	return &WebhookService{
 		enabled: enabled,
 		url:     url,
+		secret:  secret,
+		client:  &http.Client{Timeout: 10 * time.Second},
 	}
 }
 
@@ -25,14 +56,69 @@ func (ws *WebhookService) SendNotificationWebhook(notification *model.Notificati
 		return nil // Webhook service disabled or no URL configured
 	}
 
-	// TODO: Implement actual webhook sending
-	// This is a placeholder implementation
-	fmt.Printf("Sending webhook to %s: %s - %s\n", ws.url, notification.Title, notification.Message)
+	_, err := ws.Post(context.Background(), ws.url, RenderWebhookBody(notification))
+	return err
+}
+
+// Post sends payload as a signed JSON webhook to url, regardless of whether
+// notification webhooks are enabled, and returns the response status code
+func (ws *WebhookService) Post(ctx context.Context, url string, payload interface{}) (int, error) {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("failed to encode webhook body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if ws.secret != "" {
+		req.Header.Set(WebhookSignatureHeader, SignWebhookBody(ws.secret, body))
+	}
+
+	resp, err := ws.client.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
 
-	return nil
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return resp.StatusCode, nil
+}
+
+// SignWebhookBody returns the signature header value for a webhook body
+func SignWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// RenderWebhookBody renders a notification for webhook delivery. Payloads of an
+// unknown schema or version are passed through with their raw JSON as text.
+func RenderWebhookBody(notification *model.Notification) *WebhookBody {
+	body := &WebhookBody{
+		Type:     notification.Type,
+		Title:    notification.Title,
+		Message:  notification.Message,
+		Priority: notification.Priority,
+		Text:     notification.Title + "\n" + notification.Message,
+		Data:     notification.Data,
+	}
+
+	if rendering := model.RenderNotificationData(notification.Data); rendering != nil {
+		body.Schema = rendering.Schema
+		body.SchemaVersion = rendering.SchemaVersion
+		body.Text = notification.Title + "\n" + rendering.Text
+	}
+
+	return body
 }
 
 // IsEnabled returns whether webhook service is enabled
 func (ws *WebhookService) IsEnabled() bool {
 	return ws.enabled
-}
\ No newline at end of file
+}
@@ -0,0 +1,38 @@
+package client
+
+import (
+	"context"
+	"net/http"
+)
+
+// Login signs in with a username and password and makes the client use the
+// access token
+func (c *Client) Login(ctx context.Context, username, password string) (*LoginResponse, error) {
+	body := map[string]interface{}{
+		"username": username,
+		"password": password,
+	}
+	login := &LoginResponse{}
+	if _, err := c.do(ctx, http.MethodPost, "/auth/login", nil, body, login); err != nil {
+		return nil, err
+	}
+	c.SetToken(login.AccessToken)
+	return login, nil
+}
+
+// CreateToken creates an API token for the signed in user. It needs the
+// access token of a login; API tokens cannot create tokens.
+func (c *Client) CreateToken(ctx context.Context, name string, scopes []string, expiresInDays int) (*APIToken, error) {
+	body := map[string]interface{}{
+		"name":   name,
+		"scopes": scopes,
+	}
+	if expiresInDays > 0 {
+		body["expires_in_days"] = expiresInDays
+	}
+	token := &APIToken{}
+	if _, err := c.do(ctx, http.MethodPost, "/tokens", nil, body, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
@@ -0,0 +1,265 @@
+// Package client is a Go client for the docker-auto REST API. It depends on
+// the standard library only, so other programs can import it.
+//
+//	c := client.New("https://docker-auto.example.com", os.Getenv("DACTL_TOKEN"))
+//	list, err := c.ListContainers(ctx, client.ContainerListOptions{Status: "running"})
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds requests of clients created by New. Log streams are
+// not bounded by it.
+const DefaultTimeout = 60 * time.Second
+
+// Client calls the docker-auto API with an API token ("dat_...") or the
+// access token of a login
+type Client struct {
+	baseURL string
+	token   string
+
+	// HTTPClient sends the requests; its timeout does not apply to log
+	// streams, which run until their context is done
+	HTTPClient *http.Client
+	// UserAgent is sent with every request when set
+	UserAgent string
+}
+
+// New creates a client for the server at baseURL, e.g.
+// "http://localhost:8080". The token may be empty for Login.
+func New(baseURL, token string) *Client {
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		token:      token,
+		HTTPClient: &http.Client{Timeout: DefaultTimeout},
+	}
+}
+
+// BaseURL returns the server address
+func (c *Client) BaseURL() string {
+	return c.baseURL
+}
+
+// SetToken sets the token sent with later requests
+func (c *Client) SetToken(token string) {
+	c.token = token
+}
+
+// Error is a request the server refused or failed
+type Error struct {
+	StatusCode int
+	// Code classifies the error, e.g. CONTAINER_NOT_FOUND
+	Code      string
+	Message   string
+	Details   []string
+	RequestID string
+}
+
+func (e *Error) Error() string {
+	msg := e.Message
+	if msg == "" {
+		msg = http.StatusText(e.StatusCode)
+	}
+	if len(e.Details) > 0 {
+		msg += ": " + strings.Join(e.Details, "; ")
+	}
+	if e.Code != "" {
+		return fmt.Sprintf("%s (%d %s)", msg, e.StatusCode, e.Code)
+	}
+	return fmt.Sprintf("%s (%d)", msg, e.StatusCode)
+}
+
+// IsNotFound reports whether err is a 404 response
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// IsUnauthorized reports whether err is a 401 response, as for a missing,
+// expired or revoked token
+func IsUnauthorized(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusUnauthorized
+}
+
+// envelope is the response body of most endpoints
+type envelope struct {
+	Success   bool            `json:"success"`
+	Message   string          `json:"message"`
+	Data      json.RawMessage `json:"data"`
+	RequestID string          `json:"request_id"`
+	ErrorCode string          `json:"error_code"`
+	Meta      *Meta           `json:"meta"`
+	Details   []struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	} `json:"details"`
+}
+
+// rawError is the error body of the endpoints answering without the envelope,
+// such as the scheduler's
+type rawError struct {
+	Error     string `json:"error"`
+	Details   string `json:"details"`
+	ErrorCode string `json:"error_code"`
+	RequestID string `json:"request_id"`
+}
+
+// Meta is the pagination of list responses
+type Meta struct {
+	Pagination *Pagination `json:"pagination,omitempty"`
+}
+
+// Pagination describes a page of a list
+type Pagination struct {
+	Page       int   `json:"page"`
+	Limit      int   `json:"limit"`
+	Total      int64 `json:"total"`
+	TotalPages int   `json:"total_pages"`
+	HasNext    bool  `json:"has_next"`
+	HasPrev    bool  `json:"has_prev"`
+}
+
+// newRequest builds a request for path, relative to /api
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	u := c.baseURL + "/api" + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+
+	var reader io.Reader
+	if body != nil {
+		encoded, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reader = bytes.NewReader(encoded)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+	return req, nil
+}
+
+// do sends a request to an endpoint answering with the envelope and decodes
+// its data into out, if not nil. The envelope's meta is returned.
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) (*Meta, error) {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, responseError(resp, data)
+	}
+
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("invalid response from %s: %w", path, err)
+	}
+	if out != nil && len(env.Data) > 0 && string(env.Data) != "null" {
+		if err := json.Unmarshal(env.Data, out); err != nil {
+			return nil, fmt.Errorf("invalid response from %s: %w", path, err)
+		}
+	}
+	return env.Meta, nil
+}
+
+// doRaw sends a request to an endpoint answering with a bare JSON body
+func (c *Client) doRaw(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		return responseError(resp, data)
+	}
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("invalid response from %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// responseError decodes the error body of a failed request, in either form
+func responseError(resp *http.Response, data []byte) error {
+	apiErr := &Error{StatusCode: resp.StatusCode, RequestID: resp.Header.Get("X-Request-ID")}
+
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(data, &fields) != nil {
+		apiErr.Message = strings.TrimSpace(string(data))
+		return apiErr
+	}
+
+	if _, ok := fields["success"]; ok {
+		var env envelope
+		json.Unmarshal(data, &env)
+		apiErr.Code = env.ErrorCode
+		apiErr.Message = env.Message
+		for _, detail := range env.Details {
+			if detail.Field != "" {
+				apiErr.Details = append(apiErr.Details, detail.Field+": "+detail.Message)
+			} else {
+				apiErr.Details = append(apiErr.Details, detail.Message)
+			}
+		}
+		if env.RequestID != "" {
+			apiErr.RequestID = env.RequestID
+		}
+		return apiErr
+	}
+
+	var raw rawError
+	json.Unmarshal(data, &raw)
+	apiErr.Code = raw.ErrorCode
+	apiErr.Message = raw.Error
+	if raw.Details != "" {
+		apiErr.Details = []string{raw.Details}
+	}
+	if raw.RequestID != "" {
+		apiErr.RequestID = raw.RequestID
+	}
+	return apiErr
+}
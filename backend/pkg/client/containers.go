@@ -0,0 +1,206 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// ListContainers returns a page of the containers visible to the token
+func (c *Client) ListContainers(ctx context.Context, opts ContainerListOptions) (*ContainerList, error) {
+	query := url.Values{}
+	if opts.Search != "" {
+		query.Set("search", opts.Search)
+	}
+	if opts.Status != "" {
+		query.Set("status", opts.Status)
+	}
+	if opts.UpdatePolicy != "" {
+		query.Set("update_policy", opts.UpdatePolicy)
+	}
+	if opts.HasUpdate != nil {
+		query.Set("has_update", strconv.FormatBool(*opts.HasUpdate))
+	}
+	if opts.Page > 0 {
+		query.Set("page", strconv.Itoa(opts.Page))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+
+	list := &ContainerList{}
+	if _, err := c.do(ctx, http.MethodGet, "/containers", query, nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetContainer returns a container
+func (c *Client) GetContainer(ctx context.Context, id int64) (*Container, error) {
+	container := &Container{}
+	if _, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/containers/%d", id), nil, nil, container); err != nil {
+		return nil, err
+	}
+	return container, nil
+}
+
+// StartContainer starts a container
+func (c *Client) StartContainer(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%d/start", id), nil, nil, nil)
+	return err
+}
+
+// StopContainer stops a container
+func (c *Client) StopContainer(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%d/stop", id), nil, nil, nil)
+	return err
+}
+
+// RestartContainer restarts a container
+func (c *Client) RestartContainer(ctx context.Context, id int64) error {
+	_, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%d/restart", id), nil, nil, nil)
+	return err
+}
+
+// UpdateContainer starts an image update of a container. The update runs on
+// the server; WaitForUpdate follows it to the end.
+func (c *Client) UpdateContainer(ctx context.Context, id int64, req *UpdateRequest) (*UpdateHistory, error) {
+	if req == nil {
+		req = &UpdateRequest{}
+	}
+	update := &UpdateHistory{}
+	if _, err := c.do(ctx, http.MethodPost, fmt.Sprintf("/containers/%d/update", id), nil, req, update); err != nil {
+		return nil, err
+	}
+	return update, nil
+}
+
+// ContainerUpdates returns the latest image updates of a container, newest
+// first
+func (c *Client) ContainerUpdates(ctx context.Context, id int64, limit int) ([]*UpdateHistory, error) {
+	query := url.Values{}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+	var updates []*UpdateHistory
+	if _, err := c.do(ctx, http.MethodGet, fmt.Sprintf("/containers/%d/history", id), query, nil, &updates); err != nil {
+		return nil, err
+	}
+	return updates, nil
+}
+
+// WaitForUpdate polls the update of a container every interval until it
+// finishes or ctx is done, and returns it as it ended
+func (c *Client) WaitForUpdate(ctx context.Context, containerID, updateID int64, interval time.Duration) (*UpdateHistory, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		updates, err := c.ContainerUpdates(ctx, containerID, 20)
+		if err != nil {
+			return nil, err
+		}
+		for _, update := range updates {
+			if update.ID == updateID && update.Done() {
+				return update, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForStatus polls a container every interval until its status is one of
+// statuses or ctx is done
+func (c *Client) WaitForStatus(ctx context.Context, id int64, interval time.Duration, statuses ...string) (*Container, error) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		container, err := c.GetContainer(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			if container.Status == status {
+				return container, nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return container, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// Bulk runs an action on several containers. The request succeeding does
+// not mean every container did; see the results.
+func (c *Client) Bulk(ctx context.Context, req *BulkRequest) ([]*OperationResult, error) {
+	var results []*OperationResult
+	if _, err := c.do(ctx, http.MethodPost, "/containers/bulk", nil, req, &results); err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// CheckUpdates asks the registries whether the containers' images have
+// updates
+func (c *Client) CheckUpdates(ctx context.Context, req *UpdateCheckRequest) (*UpdateCheckResult, error) {
+	if req == nil {
+		req = &UpdateCheckRequest{}
+	}
+	result := &UpdateCheckResult{}
+	if _, err := c.do(ctx, http.MethodPost, "/containers/check-updates", nil, req, result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// StreamLogs copies the logs of a container to w until the stream ends, or
+// ctx is done when following. Secrets are redacted by the server.
+func (c *Client) StreamLogs(ctx context.Context, id int64, opts LogOptions, w io.Writer) error {
+	query := url.Values{}
+	if opts.Tail > 0 {
+		query.Set("tail", strconv.Itoa(opts.Tail))
+	}
+	query.Set("follow", strconv.FormatBool(opts.Follow))
+	query.Set("timestamps", strconv.FormatBool(opts.Timestamps))
+	if !opts.Since.IsZero() {
+		query.Set("since", opts.Since.Format(time.RFC3339))
+	}
+
+	req, err := c.newRequest(ctx, http.MethodGet, fmt.Sprintf("/containers/%d/logs/stream", id), query, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "text/plain")
+
+	// A copy without the timeout, which would cut a followed stream
+	httpClient := *c.HTTPClient
+	httpClient.Timeout = 0
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		data, _ := io.ReadAll(resp.Body)
+		return responseError(resp, data)
+	}
+
+	if _, err := io.Copy(w, resp.Body); err != nil && ctx.Err() == nil {
+		return fmt.Errorf("log stream interrupted: %w", err)
+	}
+	return nil
+}
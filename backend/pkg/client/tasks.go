@@ -0,0 +1,40 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// ListTasks returns the scheduled tasks
+func (c *Client) ListTasks(ctx context.Context, opts TaskListOptions) (*TaskList, error) {
+	query := url.Values{}
+	if opts.Name != "" {
+		query.Set("name", opts.Name)
+	}
+	if opts.Type != "" {
+		query.Set("type", opts.Type)
+	}
+	if opts.Active != nil {
+		query.Set("is_active", strconv.FormatBool(*opts.Active))
+	}
+	if opts.Limit > 0 {
+		query.Set("limit", strconv.Itoa(opts.Limit))
+	}
+	if opts.Offset > 0 {
+		query.Set("offset", strconv.Itoa(opts.Offset))
+	}
+
+	list := &TaskList{}
+	if err := c.doRaw(ctx, http.MethodGet, "/scheduler/tasks", query, nil, list); err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// TriggerTask runs a task now
+func (c *Client) TriggerTask(ctx context.Context, id int64) error {
+	return c.doRaw(ctx, http.MethodPost, fmt.Sprintf("/scheduler/tasks/%d/trigger", id), nil, nil, nil)
+}
@@ -0,0 +1,214 @@
+package client
+
+import "time"
+
+// Container is a container as listed by the API
+type Container struct {
+	ID             int64     `json:"id"`
+	Name           string    `json:"name"`
+	Image          string    `json:"image"`
+	Tag            string    `json:"tag"`
+	Status         string    `json:"status"`
+	DockerStatus   string    `json:"docker_status,omitempty"`
+	UpdatePolicy   string    `json:"update_policy"`
+	VersionPolicy  string    `json:"version_policy,omitempty"`
+	HasUpdate      bool      `json:"has_update"`
+	Drifted        bool      `json:"drifted"`
+	CrashLooping   bool      `json:"crash_looping"`
+	Unmanaged      bool      `json:"unmanaged"`
+	PublishedPorts string    `json:"published_ports,omitempty"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// ContainerList is a page of containers
+type ContainerList struct {
+	Containers []*Container `json:"containers"`
+	Total      int64        `json:"total"`
+	Page       int          `json:"page"`
+	Limit      int          `json:"limit"`
+	HasNext    bool         `json:"has_next"`
+	HasPrev    bool         `json:"has_prev"`
+}
+
+// ContainerListOptions filters ListContainers; zero values are not sent
+type ContainerListOptions struct {
+	Search       string
+	Status       string
+	UpdatePolicy string
+	HasUpdate    *bool
+	Page         int
+	Limit        int
+}
+
+// UpdateRequest is an image update of a container
+type UpdateRequest struct {
+	Strategy string `json:"strategy,omitempty"`
+	Force    bool   `json:"force,omitempty"`
+	Backup   bool   `json:"backup,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+	Note     string `json:"note,omitempty"`
+}
+
+// BulkRequest runs one action, start, stop, restart or update, on several
+// containers
+type BulkRequest struct {
+	ContainerIDs   []int64        `json:"container_ids"`
+	Action         string         `json:"action"`
+	UpdateImage    *UpdateRequest `json:"update_image,omitempty"`
+	MaxConcurrency int            `json:"max_concurrency,omitempty"`
+	FailFast       bool           `json:"fail_fast,omitempty"`
+}
+
+// OperationResult is the outcome of a bulk action on one container
+type OperationResult struct {
+	ContainerID int64    `json:"container_id"`
+	Name        string   `json:"name"`
+	Success     bool     `json:"success"`
+	Message     string   `json:"message,omitempty"`
+	Error       string   `json:"error,omitempty"`
+	Warnings    []string `json:"warnings,omitempty"`
+	DurationMS  int64    `json:"duration_ms,omitempty"`
+}
+
+// Update statuses of UpdateHistory
+const (
+	UpdateStatusPending   = "pending"
+	UpdateStatusRunning   = "running"
+	UpdateStatusSuccess   = "success"
+	UpdateStatusCompleted = "completed"
+	UpdateStatusFailed    = "failed"
+	UpdateStatusRollback  = "rollback"
+	UpdateStatusCancelled = "cancelled"
+)
+
+// UpdateHistory is an image update of a container
+type UpdateHistory struct {
+	ID              int64      `json:"id"`
+	ContainerID     int64      `json:"container_id"`
+	OldImage        string     `json:"old_image,omitempty"`
+	NewImage        string     `json:"new_image"`
+	Status          string     `json:"status"`
+	ErrorMessage    string     `json:"error_message,omitempty"`
+	DurationSeconds int        `json:"duration_seconds"`
+	Strategy        string     `json:"strategy"`
+	Warnings        []string   `json:"warnings,omitempty"`
+	StartedAt       time.Time  `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at,omitempty"`
+}
+
+// Done reports whether the update has finished, successfully or not
+func (h *UpdateHistory) Done() bool {
+	switch h.Status {
+	case UpdateStatusPending, UpdateStatusRunning:
+		return false
+	}
+	return true
+}
+
+// Succeeded reports whether the update finished successfully
+func (h *UpdateHistory) Succeeded() bool {
+	return h.Status == UpdateStatusSuccess || h.Status == UpdateStatusCompleted
+}
+
+// UpdateCheckRequest selects the containers CheckUpdates asks the registries
+// about, every container when ContainerIDs is empty
+type UpdateCheckRequest struct {
+	ContainerIDs   []int64 `json:"container_ids,omitempty"`
+	MaxConcurrency int     `json:"max_concurrency,omitempty"`
+	Force          bool    `json:"force,omitempty"`
+}
+
+// UpdateCheck compares the digest a container runs with the registry's
+type UpdateCheck struct {
+	ContainerID     int64     `json:"container_id"`
+	Name            string    `json:"name"`
+	Image           string    `json:"image"`
+	CurrentTag      string    `json:"current_tag"`
+	CurrentDigest   string    `json:"current_digest,omitempty"`
+	LatestTag       string    `json:"latest_tag,omitempty"`
+	LatestDigest    string    `json:"latest_digest,omitempty"`
+	UpdateAvailable bool      `json:"update_available"`
+	Cached          bool      `json:"cached"`
+	CheckedAt       time.Time `json:"checked_at"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// UpdateCheckResult is the outcome of CheckUpdates
+type UpdateCheckResult struct {
+	Checked          int           `json:"checked"`
+	UpdatesAvailable int           `json:"updates_available"`
+	Failed           int           `json:"failed"`
+	Cached           int           `json:"cached"`
+	Containers       []UpdateCheck `json:"containers"`
+}
+
+// Task is a scheduled task
+type Task struct {
+	ID             int64      `json:"id"`
+	Name           string     `json:"name"`
+	Type           string     `json:"type"`
+	ScheduleType   string     `json:"schedule_type"`
+	CronExpression string     `json:"cron_expression,omitempty"`
+	IsActive       bool       `json:"is_active"`
+	IsRunning      bool       `json:"is_running"`
+	IsPaused       bool       `json:"is_paused"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	RunCount       int        `json:"run_count"`
+	FailureCount   int        `json:"failure_count"`
+	SuccessRate    float64    `json:"success_rate"`
+}
+
+// TaskList is a page of tasks
+type TaskList struct {
+	Tasks   []*Task `json:"tasks"`
+	Total   int64   `json:"total"`
+	HasNext bool    `json:"has_next"`
+}
+
+// TaskListOptions filters ListTasks; zero values are not sent
+type TaskListOptions struct {
+	Name   string
+	Type   string
+	Active *bool
+	Limit  int
+	Offset int
+}
+
+// LoginResponse is a successful login. AccessToken is short-lived; use it
+// to create an API token.
+type LoginResponse struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// API token scopes
+const (
+	ScopeContainersRead    = "containers:read"
+	ScopeContainersControl = "containers:control"
+	ScopeContainersUpdate  = "containers:update"
+	ScopeTasksTrigger      = "tasks:trigger"
+)
+
+// APIToken is a personal access token. Token is only set when it was just
+// created.
+type APIToken struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Prefix    string     `json:"prefix"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	Token     string     `json:"token,omitempty"`
+}
+
+// LogOptions select the lines StreamLogs sends
+type LogOptions struct {
+	// Tail is the number of lines before following, 100 when zero
+	Tail       int
+	Follow     bool
+	Timestamps bool
+	Since      time.Time
+}
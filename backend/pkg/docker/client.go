@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/docker/docker/api/types"
@@ -23,6 +24,13 @@ type DockerClient struct {
 	operationQueue chan Operation
 	workerDone chan struct{}
 	metrics    *ClientMetrics
+	pullThrottle *PullThrottle
+	names      *nameCache
+	sessions   *SessionRegistry
+	// eventWatcher holds the *EventWatcher once WatchContainerEvents runs
+	eventWatcher atomic.Value
+	// registryAuth holds the RegistryAuthFunc set by SetRegistryAuthFunc
+	registryAuth atomic.Value
 }
 
 // ConnectionPool manages Docker client connections for performance
@@ -50,6 +58,14 @@ type ClientMetrics struct {
 	ActiveOperations  int64
 	ConnectionsInUse  int64
 	LastOperationTime time.Time
+
+	// Open exec attachments and streams by type, and how many the janitor
+	// has closed
+	ActiveSessions map[SessionType]int
+	ReapedSessions int64
+
+	// EventWatcher is the health of the container event watcher, if running
+	EventWatcher *EventWatcherStats
 }
 
 // ClientConfig holds configuration for Docker client creation
@@ -65,45 +81,60 @@ func NewDockerClient(cfg *config.Config) (*DockerClient, error) {
 	if cfg == nil {
 		return nil, fmt.Errorf("config cannot be nil")
 	}
+	return newDockerClient(cfg, cfg.Docker.Host)
+}
 
+// newDockerClient creates a client for the daemon at host; extraOpts are
+// applied after the HTTP client is set, so they may adjust its transport
+func newDockerClient(cfg *config.Config, host string, extraOpts ...client.Opt) (*DockerClient, error) {
 	timeout := time.Duration(cfg.Docker.Timeout) * time.Second
 	if timeout <= 0 {
 		timeout = 30 * time.Second
 	}
 
-	// Configure optimized HTTP client with connection pooling
-	httpClient := &http.Client{
-		Timeout: timeout,
-		Transport: &http.Transport{
-			MaxIdleConns:        100,
-			MaxIdleConnsPerHost: 20,
-			IdleConnTimeout:     90 * time.Second,
-			TLSHandshakeTimeout: 10 * time.Second,
-			ForceAttemptHTTP2:   true,
-		},
-	}
+	// clientOpts returns the options of one client. Every client needs an
+	// HTTP client of its own: NewClientWithOpts wraps the transport for
+	// tracing, after which a host can no longer be applied to it. The host
+	// comes after the HTTP client so its transport is set up for unix
+	// sockets.
+	clientOpts := func() []client.Opt {
+		// Configure optimized HTTP client with connection pooling. HTTP/2
+		// is not forced: it sets a TLS config on the transport, after which
+		// exec and attach connections dial plain TCP daemons with TLS.
+		httpClient := &http.Client{
+			Timeout: timeout,
+			Transport: &http.Transport{
+				MaxIdleConns:        100,
+				MaxIdleConnsPerHost: 20,
+				IdleConnTimeout:     90 * time.Second,
+				TLSHandshakeTimeout: 10 * time.Second,
+			},
+		}
 
-	// Create Docker client options
-	opts := []client.Opt{
-		client.WithHost(cfg.Docker.Host),
-		client.WithAPIVersionNegotiation(),
-		client.WithHTTPClient(httpClient),
-	}
+		opts := []client.Opt{
+			client.WithHTTPClient(httpClient),
+			client.WithHost(host),
+			client.WithAPIVersionNegotiation(),
+		}
+		opts = append(opts, extraOpts...)
 
-	// Set specific API version if provided
-	if cfg.Docker.APIVersion != "" {
-		opts = append(opts, client.WithVersion(cfg.Docker.APIVersion))
+		// Set specific API version if provided
+		if cfg.Docker.APIVersion != "" {
+			opts = append(opts, client.WithVersion(cfg.Docker.APIVersion))
+		}
+		return opts
 	}
 
 	// Create primary Docker client
-	dockerClient, err := client.NewClientWithOpts(opts...)
+	dockerClient, err := client.NewClientWithOpts(clientOpts()...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Docker client: %w", err)
 	}
 
 	// Initialize connection pool
-	connPool, err := NewConnectionPool(5, opts) // Pool of 5 connections
+	connPool, err := NewConnectionPool(5, clientOpts) // Pool of 5 connections
 	if err != nil {
+		dockerClient.Close()
 		return nil, fmt.Errorf("failed to create connection pool: %w", err)
 	}
 
@@ -117,6 +148,11 @@ func NewDockerClient(cfg *config.Config) (*DockerClient, error) {
 		metrics: &ClientMetrics{
 			LastOperationTime: time.Now(),
 		},
+		pullThrottle: NewPullThrottle(cfg.Docker.PullMaxConcurrent, cfg.Docker.PullBandwidthMBps),
+		sessions: NewSessionRegistry(
+			time.Duration(cfg.Docker.SessionIdleTimeoutSeconds)*time.Second,
+			time.Duration(cfg.Docker.SessionMaxAgeSeconds)*time.Second,
+		),
 	}
 
 	// Start worker goroutines for parallel operations
@@ -124,8 +160,18 @@ func NewDockerClient(cfg *config.Config) (*DockerClient, error) {
 		go dockerClientWrapper.operationWorker()
 	}
 
+	// The name cache watcher stops when the client is closed
+	if cfg.Docker.NameCacheSeconds > 0 {
+		watchCtx, cancel := context.WithCancel(context.Background())
+		go func() {
+			<-dockerClientWrapper.workerDone
+			cancel()
+		}()
+		dockerClientWrapper.EnableNameCache(watchCtx, time.Duration(cfg.Docker.NameCacheSeconds)*time.Second)
+	}
+
 	logrus.WithFields(logrus.Fields{
-		"host":            cfg.Docker.Host,
+		"host":            host,
 		"api_version":     cfg.Docker.APIVersion,
 		"timeout":         timeout,
 		"pool_size":       5,
@@ -164,13 +210,21 @@ func NewDockerClientWithConfig(clientConfig ClientConfig) (*DockerClient, error)
 	}
 
 	return &DockerClient{
-		client:  dockerClient,
-		timeout: timeout,
+		client:   dockerClient,
+		timeout:  timeout,
+		sessions: NewSessionRegistry(defaultSessionIdleTimeout, 0),
 	}, nil
 }
 
 // Close closes the Docker client and all pooled connections
 func (d *DockerClient) Close() error {
+	// Hijacked connections would outlive the client otherwise
+	if d.sessions != nil {
+		if closed := d.sessions.Stop(); closed > 0 {
+			logrus.WithField("sessions", closed).Info("Closed open Docker sessions")
+		}
+	}
+
 	// Signal workers to stop
 	close(d.workerDone)
 
@@ -193,6 +247,11 @@ func (d *DockerClient) GetClient() *client.Client {
 	return d.client
 }
 
+// GetPullThrottle returns the host pull throttle, nil when pulls are unthrottled
+func (d *DockerClient) GetPullThrottle() *PullThrottle {
+	return d.pullThrottle
+}
+
 // GetTimeout returns the client timeout
 func (d *DockerClient) GetTimeout() time.Duration {
 	return d.timeout
@@ -361,8 +420,9 @@ func (h *HealthStatus) GetTotalContainers() int {
 	return h.ContainersRunning + h.ContainersPaused + h.ContainersStopped
 }
 
-// NewConnectionPool creates a new connection pool
-func NewConnectionPool(size int, opts []client.Opt) (*ConnectionPool, error) {
+// NewConnectionPool creates a new connection pool of size clients, each
+// created with the options newOpts returns
+func NewConnectionPool(size int, newOpts func() []client.Opt) (*ConnectionPool, error) {
 	pool := &ConnectionPool{
 		maxSize: size,
 		clients: make([]*client.Client, 0, size),
@@ -370,8 +430,9 @@ func NewConnectionPool(size int, opts []client.Opt) (*ConnectionPool, error) {
 
 	// Create initial connections
 	for i := 0; i < size; i++ {
-		cli, err := client.NewClientWithOpts(opts...)
+		cli, err := client.NewClientWithOpts(newOpts()...)
 		if err != nil {
+			pool.Close()
 			return nil, fmt.Errorf("failed to create pooled client %d: %w", i, err)
 		}
 		pool.clients = append(pool.clients, cli)
@@ -553,9 +614,7 @@ func (d *DockerClient) ExecuteSync(name string, timeout time.Duration, operation
 // GetMetrics returns current client metrics
 func (d *DockerClient) GetMetrics() *ClientMetrics {
 	d.metrics.mu.RLock()
-	defer d.metrics.mu.RUnlock()
-
-	return &ClientMetrics{
+	metrics := &ClientMetrics{
 		OperationCount:    d.metrics.OperationCount,
 		TotalDuration:     d.metrics.TotalDuration,
 		FailureCount:      d.metrics.FailureCount,
@@ -563,6 +622,14 @@ func (d *DockerClient) GetMetrics() *ClientMetrics {
 		ConnectionsInUse:  d.metrics.ConnectionsInUse,
 		LastOperationTime: d.metrics.LastOperationTime,
 	}
+	d.metrics.mu.RUnlock()
+
+	if d.sessions != nil {
+		metrics.ActiveSessions = d.sessions.Counts()
+		metrics.ReapedSessions = d.sessions.Reaped()
+	}
+	metrics.EventWatcher = d.EventWatcherStats()
+	return metrics
 }
 
 // GetAverageOperationTime calculates average operation time
@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/client"
+)
+
+// servePings answers daemon pings on listener and counts them
+func servePings(t *testing.T, listener net.Listener) *atomic.Int32 {
+	t.Helper()
+
+	pings := &atomic.Int32{}
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/_ping") {
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+			return
+		}
+		pings.Add(1)
+		w.Header().Set("API-Version", "1.44")
+		w.Write([]byte("OK"))
+	}))
+	server.Listener.Close()
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+	return pings
+}
+
+func newClientTestConfig(host string) *config.Config {
+	cfg := &config.Config{}
+	cfg.Docker.Host = host
+	cfg.Docker.APIVersion = "1.44"
+	cfg.Docker.Timeout = 5
+	return cfg
+}
+
+func TestNewDockerClientBuildsItsPool(t *testing.T) {
+	ctx := context.Background()
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Unix socket paths are short; t.TempDir may be too long for one
+	dir, err := os.MkdirTemp("", "docker-auto")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "docker.sock")
+	unix, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		host     string
+		listener net.Listener
+	}{
+		{"tcp", "tcp://" + tcp.Addr().String(), tcp},
+		{"unix", "unix://" + socket, unix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pings := servePings(t, tt.listener)
+
+			dc, err := NewDockerClient(newClientTestConfig(tt.host))
+			if err != nil {
+				t.Fatalf("NewDockerClient failed: %v", err)
+			}
+			t.Cleanup(func() { dc.Close() })
+
+			if err := dc.Ping(ctx); err != nil {
+				t.Fatalf("Ping failed: %v", err)
+			}
+
+			// Every pooled client reaches the daemon on a transport of its own
+			var pooled []*client.Client
+			transports := map[http.RoundTripper]bool{dc.client.HTTPClient().Transport: true}
+			for cli := dc.connPool.GetClient(); cli != nil; cli = dc.connPool.GetClient() {
+				pooled = append(pooled, cli)
+				transports[cli.HTTPClient().Transport] = true
+				if _, err := cli.Ping(ctx); err != nil {
+					t.Errorf("pooled client %d: Ping failed: %v", len(pooled), err)
+				}
+			}
+			for _, cli := range pooled {
+				dc.connPool.ReturnClient(cli)
+			}
+
+			if len(pooled) != 5 {
+				t.Errorf("pool holds %d clients, want 5", len(pooled))
+			}
+			if len(transports) != len(pooled)+1 {
+				t.Errorf("%d clients share %d transports, want one each", len(pooled)+1, len(transports))
+			}
+			if got := pings.Load(); got != int32(len(pooled)+1) {
+				t.Errorf("daemon answered %d pings, want %d", got, len(pooled)+1)
+			}
+
+			// Operations run on the pooled clients
+			err = dc.ExecuteSync("ping", time.Second, func(cli *client.Client) error {
+				_, err := cli.Ping(ctx)
+				return err
+			})
+			if err != nil {
+				t.Errorf("ExecuteSync failed: %v", err)
+			}
+		})
+	}
+}
+
+func TestHostPoolBuildsRemoteClients(t *testing.T) {
+	ctx := context.Background()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pings := servePings(t, listener)
+
+	pool := NewHostPool(newClientTestConfig("unix:///nonexistent.sock"), nil)
+	t.Cleanup(func() { pool.Close() })
+
+	host := &model.DockerHost{ID: 1, Name: "remote", Endpoint: "tcp://" + listener.Addr().String()}
+	dc, err := pool.Client(ctx, host)
+	if err != nil {
+		t.Fatalf("Client failed: %v", err)
+	}
+	if pings.Load() != 1 {
+		t.Errorf("daemon answered %d pings, want the first use pinged once", pings.Load())
+	}
+
+	// The cached client is reused until the ping interval passes
+	again, err := pool.Client(ctx, host)
+	if err != nil || again != dc {
+		t.Errorf("second Client = %p, %v, want the cached client %p", again, err, dc)
+	}
+	if err := pool.Ping(ctx, host); err != nil {
+		t.Errorf("Ping failed: %v", err)
+	}
+	if pings.Load() != 2 {
+		t.Errorf("daemon answered %d pings, want 2", pings.Load())
+	}
+}
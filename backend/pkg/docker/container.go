@@ -11,10 +11,10 @@ import (
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/network"
 	"github.com/docker/docker/pkg/stdcopy"
-	"github.com/sirupsen/logrus"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 )
 
 // Container lifecycle operations
@@ -73,6 +73,13 @@ func (d *DockerClient) StartContainer(ctx context.Context, containerID string) e
 
 // StopContainer stops a Docker container by ID with optional timeout
 func (d *DockerClient) StopContainer(ctx context.Context, containerID string, timeout *int) error {
+	return d.StopContainerWithSignal(ctx, containerID, "", timeout)
+}
+
+// StopContainerWithSignal stops a Docker container by ID, sending signal
+// instead of the container's stop signal unless it is empty, and killing it
+// after the optional timeout
+func (d *DockerClient) StopContainerWithSignal(ctx context.Context, containerID string, signal string, timeout *int) error {
 	if ctx == nil {
 		var cancel context.CancelFunc
 		ctx, cancel = d.WithTimeout(context.Background())
@@ -83,12 +90,7 @@ func (d *DockerClient) StopContainer(ctx context.Context, containerID string, ti
 		return fmt.Errorf("container ID cannot be empty")
 	}
 
-	var stopTimeout *int
-	if timeout != nil {
-		stopTimeout = timeout
-	}
-
-	err := d.client.ContainerStop(ctx, containerID, container.StopOptions{Timeout: stopTimeout})
+	err := d.client.ContainerStop(ctx, containerID, container.StopOptions{Signal: signal, Timeout: timeout})
 	if err != nil {
 		return fmt.Errorf("failed to stop container %s: %w", containerID, err)
 	}
@@ -98,6 +100,12 @@ func (d *DockerClient) StopContainer(ctx context.Context, containerID string, ti
 
 // RestartContainer restarts a Docker container by ID with optional timeout
 func (d *DockerClient) RestartContainer(ctx context.Context, containerID string, timeout *int) error {
+	return d.RestartContainerWithSignal(ctx, containerID, "", timeout)
+}
+
+// RestartContainerWithSignal restarts a Docker container by ID, stopping it
+// as StopContainerWithSignal does
+func (d *DockerClient) RestartContainerWithSignal(ctx context.Context, containerID string, signal string, timeout *int) error {
 	if ctx == nil {
 		var cancel context.CancelFunc
 		ctx, cancel = d.WithTimeout(context.Background())
@@ -108,12 +116,7 @@ func (d *DockerClient) RestartContainer(ctx context.Context, containerID string,
 		return fmt.Errorf("container ID cannot be empty")
 	}
 
-	var restartTimeout *int
-	if timeout != nil {
-		restartTimeout = timeout
-	}
-
-	err := d.client.ContainerRestart(ctx, containerID, container.StopOptions{Timeout: restartTimeout})
+	err := d.client.ContainerRestart(ctx, containerID, container.StopOptions{Signal: signal, Timeout: timeout})
 	if err != nil {
 		return fmt.Errorf("failed to restart container %s: %w", containerID, err)
 	}
@@ -255,24 +258,28 @@ func (d *DockerClient) ListRunningContainers(ctx context.Context) ([]types.Conta
 
 // FindContainerByName finds a container by name
 func (d *DockerClient) FindContainerByName(ctx context.Context, name string) (*types.Container, error) {
+	name = strings.TrimPrefix(name, "/")
 	if name == "" {
 		return nil, fmt.Errorf("container name cannot be empty")
 	}
 
-	// Add leading slash if not present (Docker container names start with /)
-	if !strings.HasPrefix(name, "/") {
-		name = "/" + name
-	}
+	// The daemon's name filter matches substrings, so "web" also lists
+	// "web-1"; only an exact match on the canonical "/name" counts
+	filterArgs := filters.NewArgs()
+	filterArgs.Add("name", name)
 
-	containers, err := d.ListAllContainers(ctx)
+	containers, err := d.ListContainers(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filterArgs,
+	})
 	if err != nil {
 		return nil, err
 	}
 
-	for _, container := range containers {
-		for _, containerName := range container.Names {
-			if containerName == name {
-				return &container, nil
+	for i := range containers {
+		for _, containerName := range containers[i].Names {
+			if containerName == "/"+name {
+				return &containers[i], nil
 			}
 		}
 	}
@@ -280,6 +287,23 @@ func (d *DockerClient) FindContainerByName(ctx context.Context, name string) (*t
 	return nil, fmt.Errorf("container with name %s not found", name)
 }
 
+// FindContainerIDByName resolves a container name to its ID, from the name
+// cache when it is enabled
+func (d *DockerClient) FindContainerIDByName(ctx context.Context, name string) (string, error) {
+	name = strings.TrimPrefix(name, "/")
+	if id, ok := d.names.get(name); ok {
+		return id, nil
+	}
+
+	container, err := d.FindContainerByName(ctx, name)
+	if err != nil {
+		return "", err
+	}
+
+	d.names.set(name, container.ID)
+	return container.ID, nil
+}
+
 // FindContainersByImage finds containers by image name
 func (d *DockerClient) FindContainersByImage(ctx context.Context, imageName string) ([]types.Container, error) {
 	if imageName == "" {
@@ -319,6 +343,27 @@ func (d *DockerClient) GetContainerStatus(ctx context.Context, containerID strin
 	return d.mapDockerStateToModelStatus(containerJSON.State), nil
 }
 
+// GetContainerStatusAndPorts gets the status and port bindings of a container
+// from a single inspect
+func (d *DockerClient) GetContainerStatusAndPorts(ctx context.Context, containerID string) (model.ContainerStatus, []PortEntry, error) {
+	containerJSON, err := d.GetContainer(ctx, containerID)
+	if err != nil {
+		return model.ContainerStatusUnknown, nil, err
+	}
+
+	status, ports := d.StatusAndPorts(containerJSON)
+	return status, ports, nil
+}
+
+// StatusAndPorts returns the status and port bindings of an already
+// inspected container
+func (d *DockerClient) StatusAndPorts(containerJSON *types.ContainerJSON) (model.ContainerStatus, []PortEntry) {
+	if containerJSON.ContainerJSONBase == nil || containerJSON.State == nil {
+		return model.ContainerStatusUnknown, containerPorts(containerJSON)
+	}
+	return d.mapDockerStateToModelStatus(containerJSON.State), containerPorts(containerJSON)
+}
+
 // mapDockerStateToModelStatus maps Docker container state to model status
 func (d *DockerClient) mapDockerStateToModelStatus(state *types.ContainerState) model.ContainerStatus {
 	if state.Running {
@@ -420,7 +465,58 @@ func (d *DockerClient) ExecInContainer(ctx context.Context, containerID string,
 		return types.HijackedResponse{}, fmt.Errorf("failed to attach to exec instance: %w", err)
 	}
 
-	return resp, nil
+	return d.trackExec(ctx, containerID, cmd, resp), nil
+}
+
+// StartExecSession starts an interactive command in a running container with
+// a TTY and stdin attached. It returns the exec ID, which resizes the TTY, and
+// the attached connection; closing the connection ends the session.
+func (d *DockerClient) StartExecSession(ctx context.Context, containerID string, cmd []string, options types.ExecConfig) (string, types.HijackedResponse, error) {
+	if containerID == "" {
+		return "", types.HijackedResponse{}, fmt.Errorf("container ID cannot be empty")
+	}
+	if len(cmd) == 0 {
+		return "", types.HijackedResponse{}, fmt.Errorf("command cannot be empty")
+	}
+
+	execIDResp, err := d.client.ContainerExecCreate(ctx, containerID, types.ExecConfig{
+		Cmd:          cmd,
+		User:         options.User,
+		WorkingDir:   options.WorkingDir,
+		Env:          options.Env,
+		Tty:          true,
+		AttachStdin:  true,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return "", types.HijackedResponse{}, fmt.Errorf("failed to create exec instance: %w", err)
+	}
+
+	resp, err := d.client.ContainerExecAttach(ctx, execIDResp.ID, types.ExecStartCheck{Tty: true})
+	if err != nil {
+		return "", types.HijackedResponse{}, fmt.Errorf("failed to attach to exec instance: %w", err)
+	}
+
+	return execIDResp.ID, d.trackExec(ctx, containerID, cmd, resp), nil
+}
+
+// ResizeExec resizes the TTY of an exec instance
+func (d *DockerClient) ResizeExec(ctx context.Context, execID string, height, width uint) error {
+	if err := d.client.ContainerExecResize(ctx, execID, types.ResizeOptions{Height: height, Width: width}); err != nil {
+		return fmt.Errorf("failed to resize exec instance: %w", err)
+	}
+	return nil
+}
+
+// ExecExitCode returns the exit code of an exec instance and whether it is
+// still running
+func (d *DockerClient) ExecExitCode(ctx context.Context, execID string) (int, bool, error) {
+	inspect, err := d.client.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to inspect exec instance: %w", err)
+	}
+	return inspect.ExitCode, inspect.Running, nil
 }
 
 // ExecSimpleCommand executes a simple command and returns output
@@ -431,33 +527,69 @@ func (d *DockerClient) ExecSimpleCommand(ctx context.Context, containerID string
 		defer cancel()
 	}
 
-	execConfig := types.ExecConfig{
+	stdout, stderr, _, err := d.execAndCollect(ctx, containerID, cmd)
+	return stdout, stderr, err
+}
+
+// ExecResult is the outcome of a command run with ExecCommand
+type ExecResult struct {
+	ExitCode int    `json:"exit_code"`
+	Stdout   string `json:"stdout"`
+	Stderr   string `json:"stderr"`
+}
+
+// ExecCommand runs a command in a running container, waits for it to finish
+// and returns its output and exit code. A non-zero exit code is not an error.
+func (d *DockerClient) ExecCommand(ctx context.Context, containerID string, cmd []string) (*ExecResult, error) {
+	if containerID == "" {
+		return nil, fmt.Errorf("container ID cannot be empty")
+	}
+	if len(cmd) == 0 {
+		return nil, fmt.Errorf("command cannot be empty")
+	}
+
+	stdout, stderr, execID, err := d.execAndCollect(ctx, containerID, cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// The exec is finished once its output stream closes, so inspect reports
+	// the final exit code
+	inspect, err := d.client.ContainerExecInspect(ctx, execID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect exec instance: %w", err)
+	}
+
+	return &ExecResult{
+		ExitCode: inspect.ExitCode,
+		Stdout:   stdout,
+		Stderr:   stderr,
+	}, nil
+}
+
+func (d *DockerClient) execAndCollect(ctx context.Context, containerID string, cmd []string) (string, string, string, error) {
+	execIDResp, err := d.client.ContainerExecCreate(ctx, containerID, types.ExecConfig{
 		Cmd:          cmd,
 		AttachStdout: true,
 		AttachStderr: true,
-	}
-
-	// Create exec instance
-	execIDResp, err := d.client.ContainerExecCreate(ctx, containerID, execConfig)
+	})
 	if err != nil {
-		return "", "", fmt.Errorf("failed to create exec instance: %w", err)
+		return "", "", "", fmt.Errorf("failed to create exec instance: %w", err)
 	}
 
-	// Attach to exec
 	resp, err := d.client.ContainerExecAttach(ctx, execIDResp.ID, types.ExecStartCheck{})
 	if err != nil {
-		return "", "", fmt.Errorf("failed to attach to exec instance: %w", err)
+		return "", "", "", fmt.Errorf("failed to attach to exec instance: %w", err)
 	}
+	resp = d.trackExec(ctx, containerID, cmd, resp)
 	defer resp.Close()
 
-	// Read output
 	var stdout, stderr strings.Builder
-	_, err = stdcopy.StdCopy(&stdout, &stderr, resp.Reader)
-	if err != nil {
-		return "", "", fmt.Errorf("failed to read exec output: %w", err)
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, resp.Reader); err != nil {
+		return "", "", "", fmt.Errorf("failed to read exec output: %w", err)
 	}
 
-	return stdout.String(), stderr.String(), nil
+	return stdout.String(), stderr.String(), execIDResp.ID, nil
 }
 
 // Container copying
@@ -532,6 +664,50 @@ func (d *DockerClient) UpdateContainer(ctx context.Context, containerID string,
 	return resp, nil
 }
 
+// UpdateContainerLimits sets the memory, CPU and process limits of a
+// container in place and returns the daemon's warnings. Limits at zero are
+// left as they are, since Docker cannot lift a limit from an existing container.
+func (d *DockerClient) UpdateContainerLimits(ctx context.Context, containerID string, limits *ResourceConfig) ([]string, error) {
+	live, err := d.GetContainer(ctx, containerID)
+	if err != nil {
+		return nil, err
+	}
+
+	resources := container.Resources{
+		Memory:     limits.Memory,
+		MemorySwap: limits.MemorySwap,
+	}
+	// Raising memory past the swap limit Docker gave the container at
+	// creation needs a new swap limit too; use the default for the new limit
+	if resources.Memory > 0 && resources.MemorySwap == 0 && live.HostConfig != nil &&
+		live.HostConfig.MemorySwap > 0 && resources.Memory > live.HostConfig.MemorySwap {
+		resources.MemorySwap = 2 * resources.Memory
+	}
+	if limits.CPUQuota > 0 {
+		period := limits.CPUPeriod
+		if period <= 0 {
+			period = 100000
+		}
+		if live.HostConfig != nil && live.HostConfig.NanoCPUs > 0 {
+			// Docker refuses a quota next to nano-CPUs set by docker run --cpus
+			resources.NanoCPUs = limits.CPUQuota * 1e9 / period
+		} else {
+			resources.CPUQuota = limits.CPUQuota
+			resources.CPUPeriod = period
+		}
+	}
+	if limits.PidsLimit > 0 {
+		pidsLimit := limits.PidsLimit
+		resources.PidsLimit = &pidsLimit
+	}
+
+	body, err := d.UpdateContainer(ctx, containerID, container.UpdateConfig{Resources: resources})
+	if err != nil {
+		return nil, err
+	}
+	return body.Warnings, nil
+}
+
 // RenameContainer renames a container
 func (d *DockerClient) RenameContainer(ctx context.Context, containerID, newName string) error {
 	if ctx == nil {
@@ -556,6 +732,112 @@ func (d *DockerClient) RenameContainer(ctx context.Context, containerID, newName
 	return nil
 }
 
+// CloneContainer creates a container with the configuration of an existing one
+// but a different image and name, and returns the new container's ID. The
+// clone joins the source's primary network with the same aliases.
+func (d *DockerClient) CloneContainer(ctx context.Context, sourceID, image, name string) (string, error) {
+	return d.cloneContainer(ctx, sourceID, image, name, false)
+}
+
+// StageContainer clones a container like CloneContainer, but so that the clone
+// can run next to its source without taking its traffic: host ports are not
+// published, and the clone has neither the source's static address nor its
+// network aliases.
+func (d *DockerClient) StageContainer(ctx context.Context, sourceID, image, name string) (string, error) {
+	return d.cloneContainer(ctx, sourceID, image, name, true)
+}
+
+// CanSwapStaged reports whether a staged clone of the container can take its
+// place by being renamed, because staging dropped nothing it has: it
+// publishes no host ports and has no static address or network aliases.
+func CanSwapStaged(source *types.ContainerJSON) bool {
+	if source.HostConfig != nil {
+		if source.HostConfig.PublishAllPorts {
+			return false
+		}
+		for _, bindings := range source.HostConfig.PortBindings {
+			for _, binding := range bindings {
+				if binding.HostPort != "" {
+					return false
+				}
+			}
+		}
+	}
+
+	if source.NetworkSettings != nil {
+		name := strings.TrimPrefix(source.Name, "/")
+		for _, endpoint := range source.NetworkSettings.Networks {
+			if endpoint == nil {
+				continue
+			}
+			if endpoint.IPAMConfig != nil && (endpoint.IPAMConfig.IPv4Address != "" || endpoint.IPAMConfig.IPv6Address != "") {
+				return false
+			}
+			for _, alias := range endpoint.Aliases {
+				// The short ID and name aliases follow the container
+				if alias != name && !strings.HasPrefix(source.ID, alias) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+func (d *DockerClient) cloneContainer(ctx context.Context, sourceID, image, name string, staging bool) (string, error) {
+	if image == "" || name == "" {
+		return "", fmt.Errorf("image and name cannot be empty")
+	}
+
+	source, err := d.GetContainer(ctx, sourceID)
+	if err != nil {
+		return "", err
+	}
+
+	config := *source.Config
+	config.Image = image
+	// A hostname Docker derived from the source ID would stick to the clone
+	if len(source.ID) >= 12 && config.Hostname == source.ID[:12] {
+		config.Hostname = ""
+	}
+
+	hostConfig := source.HostConfig
+	if staging && hostConfig != nil {
+		staged := *hostConfig
+		staged.PortBindings = nil
+		staged.PublishAllPorts = false
+		hostConfig = &staged
+	}
+
+	var networking *network.NetworkingConfig
+	if source.HostConfig != nil && source.NetworkSettings != nil {
+		mode := string(source.HostConfig.NetworkMode)
+		if endpoint, ok := source.NetworkSettings.Networks[mode]; ok && endpoint != nil {
+			// The daemon adds the short ID alias itself
+			aliases := make([]string, 0, len(endpoint.Aliases))
+			for _, alias := range endpoint.Aliases {
+				if !strings.HasPrefix(source.ID, alias) {
+					aliases = append(aliases, alias)
+				}
+			}
+			settings := &network.EndpointSettings{IPAMConfig: endpoint.IPAMConfig, Aliases: aliases}
+			if staging {
+				settings = &network.EndpointSettings{}
+			}
+			networking = &network.NetworkingConfig{
+				EndpointsConfig: map[string]*network.EndpointSettings{mode: settings},
+			}
+		}
+	}
+
+	resp, err := d.client.ContainerCreate(ctx, &config, hostConfig, networking, nil, name)
+	if err != nil {
+		return "", fmt.Errorf("failed to create container %s: %w", name, err)
+	}
+
+	return resp.ID, nil
+}
+
 // GetContainerSize gets the size of a container's filesystem
 func (d *DockerClient) GetContainerSize(ctx context.Context, containerID string) (types.ContainerJSON, error) {
 	if ctx == nil {
@@ -663,21 +945,22 @@ func (d *DockerClient) GetContainerImage(ctx context.Context, containerID string
 	return containerJSON.Config.Image, nil
 }
 
-// GetContainerPorts gets the port mappings of a container
-func (d *DockerClient) GetContainerPorts(ctx context.Context, containerID string) (map[string]string, error) {
+// GetContainerPorts gets every port binding of a container, including
+// exposed ports that are not published
+func (d *DockerClient) GetContainerPorts(ctx context.Context, containerID string) ([]PortEntry, error) {
 	containerJSON, err := d.GetContainer(ctx, containerID)
 	if err != nil {
 		return nil, err
 	}
 
-	ports := make(map[string]string)
-	for containerPort, hostBindings := range containerJSON.NetworkSettings.Ports {
-		if len(hostBindings) > 0 {
-			ports[hostBindings[0].HostPort] = string(containerPort)
-		}
-	}
+	return containerPorts(containerJSON), nil
+}
 
-	return ports, nil
+func containerPorts(containerJSON *types.ContainerJSON) []PortEntry {
+	if containerJSON.NetworkSettings == nil {
+		return []PortEntry{}
+	}
+	return PortEntries(containerJSON.NetworkSettings.Ports)
 }
 
 // Parallel Container Operations for Performance Optimization
@@ -783,119 +1066,9 @@ func (d *DockerClient) executeParallelOperation(
 	config BulkOperationConfig,
 	opFunc func(context.Context, string) error,
 ) []ParallelOperationResult {
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(ctx, time.Duration(config.Timeout)*time.Second)
-	defer cancel()
-
-	// Initialize results
-	results := make([]ParallelOperationResult, len(containerIDs))
-	for i, containerID := range containerIDs {
-		results[i] = ParallelOperationResult{
-			ContainerID: containerID,
-			Operation:   operation,
-		}
-	}
-
-	// Create worker pool
-	semaphore := make(chan struct{}, config.MaxConcurrency)
-	var wg sync.WaitGroup
-	resultsMu := sync.RWMutex{}
-	completed := 0
-
-	logrus.WithFields(logrus.Fields{
-		"operation":      operation,
-		"container_count": len(containerIDs),
-		"max_concurrency": config.MaxConcurrency,
-		"timeout":        config.Timeout,
-	}).Info("Starting parallel container operation")
-
-	start := time.Now()
-
-	// Process each container
-	for i, containerID := range containerIDs {
-		wg.Add(1)
-		go func(index int, cID string) {
-			defer wg.Done()
-
-			// Acquire semaphore
-			semaphore <- struct{}{}
-			defer func() { <-semaphore }()
-
-			// Execute operation with timing
-			opStart := time.Now()
-			err := opFunc(ctx, cID)
-			duration := time.Since(opStart)
-
-			// Update result
-			resultsMu.Lock()
-			if err != nil {
-				results[index].Error = err.Error()
-				results[index].Success = false
-			} else {
-				results[index].Success = true
-			}
-			results[index].Duration = duration
-			completed++
-
-			// Call progress callback
-			if config.ProgressCallback != nil {
-				config.ProgressCallback(completed, len(containerIDs))
-			}
-			resultsMu.Unlock()
-
-			// Log individual operation
-			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"container_id": cID,
-					"operation":    operation,
-					"duration":     duration,
-					"error":        err,
-				}).Error("Container operation failed")
-
-				// Fail fast if configured
-				if config.FailFast {
-					cancel()
-				}
-			} else {
-				logrus.WithFields(logrus.Fields{
-					"container_id": cID,
-					"operation":    operation,
-					"duration":     duration,
-				}).Debug("Container operation completed")
-			}
-		}(i, containerID)
-	}
-
-	// Wait for all operations to complete
-	wg.Wait()
-
-	// Calculate summary statistics
-	totalDuration := time.Since(start)
-	successCount := 0
-	failureCount := 0
-
-	for _, result := range results {
-		if result.Error == "" {
-			successCount++
-		} else {
-			failureCount++
-		}
-	}
-
-	logrus.WithFields(logrus.Fields{
-		"operation":       operation,
-		"total_containers": len(containerIDs),
-		"successful":      successCount,
-		"failed":          failureCount,
-		"total_duration":  totalDuration,
-		"avg_duration":    totalDuration / time.Duration(len(containerIDs)),
-	}).Info("Parallel container operation completed")
-
-	return results
+	return RunParallel(ctx, containerIDs, operation, config, func(ctx context.Context, index int) error {
+		return opFunc(ctx, containerIDs[index])
+	})
 }
 
 // GetOperationSummary returns a summary of parallel operation results
@@ -0,0 +1,157 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// nameDaemon lists containers by name the way the daemon does: the name
+// filter matches any part of a name
+type nameDaemon struct {
+	mu    sync.Mutex
+	names []string // in listing order
+	ids   map[string]string
+	lists int
+}
+
+func newNameDaemon(containers ...string) *nameDaemon {
+	d := &nameDaemon{ids: make(map[string]string)}
+	for _, pair := range containers {
+		name, id, _ := strings.Cut(pair, "=")
+		d.names = append(d.names, name)
+		d.ids[name] = id
+	}
+	return d
+}
+
+// rename renames a container and lists it first, as a recently changed
+// container may be
+func (d *nameDaemon) rename(oldName, newName string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	id := d.ids[oldName]
+	delete(d.ids, oldName)
+	d.ids[newName] = id
+	names := []string{newName}
+	for _, name := range d.names {
+		if name != oldName {
+			names = append(names, name)
+		}
+	}
+	d.names = names
+}
+
+func (d *nameDaemon) serveList(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.lists++
+	args, err := filters.FromJSON(r.URL.Query().Get("filters"))
+	if err != nil {
+		t.Errorf("invalid filters: %v", err)
+	}
+	list := []types.Container{}
+	for _, name := range d.names {
+		match := true
+		for _, want := range args.Get("name") {
+			match = match && strings.Contains("/"+name, want)
+		}
+		if match {
+			list = append(list, types.Container{ID: d.ids[name], Names: []string{"/" + name}})
+		}
+	}
+	writeJSON(t, w, list)
+}
+
+func (d *nameDaemon) client(t *testing.T) *DockerClient {
+	return newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/containers/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		d.serveList(t, w, r)
+	})
+}
+
+func TestFindContainerByNameMatchesExactly(t *testing.T) {
+	// The daemon lists the longer names first
+	daemon := newNameDaemon("web-1=id-web-1", "old-web=id-old-web", "web=id-web", "web-2=id-web-2")
+	dc := daemon.client(t)
+
+	tests := []struct {
+		name   string
+		wantID string
+	}{
+		{"web", "id-web"},
+		{"/web", "id-web"},
+		{"web-1", "id-web-1"},
+		{"old-web", "id-old-web"},
+		{"we", ""},
+		{"eb", ""},
+		{"web-", ""},
+		{"web-3", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container, err := dc.FindContainerByName(context.Background(), tt.name)
+			if tt.wantID == "" {
+				if err == nil {
+					t.Fatalf("found %s (%v), want not found", container.ID, container.Names)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FindContainerByName: %v", err)
+			}
+			if container.ID != tt.wantID {
+				t.Errorf("found %s (%v), want %s", container.ID, container.Names, tt.wantID)
+			}
+
+			id, err := dc.FindContainerIDByName(context.Background(), tt.name)
+			if err != nil || id != tt.wantID {
+				t.Errorf("FindContainerIDByName = %s, %v, want %s", id, err, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestFindContainerByNameOnlyPrefixedNamesLeft(t *testing.T) {
+	// web was removed; only names containing it are left
+	dc := newNameDaemon("web-1=id-web-1", "web-2=id-web-2").client(t)
+
+	if container, err := dc.FindContainerByName(context.Background(), "web"); err == nil {
+		t.Fatalf("found %s (%v), want not found", container.ID, container.Names)
+	}
+	if id, err := dc.FindContainerIDByName(context.Background(), "web"); err == nil {
+		t.Fatalf("FindContainerIDByName = %s, want not found", id)
+	}
+}
+
+func TestFindContainerByNameAfterRename(t *testing.T) {
+	daemon := newNameDaemon("web=id-a", "web-1=id-b")
+	dc := daemon.client(t)
+
+	// web is renamed to web-old and a new web takes its name
+	daemon.rename("web", "web-old")
+	daemon.mu.Lock()
+	daemon.names = append([]string{"web"}, daemon.names...)
+	daemon.ids["web"] = "id-c"
+	daemon.mu.Unlock()
+
+	tests := map[string]string{"web": "id-c", "web-old": "id-a", "web-1": "id-b"}
+	for name, wantID := range tests {
+		container, err := dc.FindContainerByName(context.Background(), name)
+		if err != nil || container.ID != wantID {
+			t.Errorf("FindContainerByName(%s) = %v, %v, want %s", name, container, err, wantID)
+		}
+	}
+}
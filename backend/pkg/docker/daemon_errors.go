@@ -0,0 +1,316 @@
+package docker
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+)
+
+// Error codes of translated daemon errors, as returned to API clients
+const (
+	CodePortAllocated        = "PORT_ALLOCATED"
+	CodeDiskFull             = "DISK_FULL"
+	CodeOutOfMemory          = "OUT_OF_MEMORY"
+	CodeRegistryRateLimited  = "REGISTRY_RATE_LIMITED"
+	CodeRegistryAccessDenied = "REGISTRY_ACCESS_DENIED"
+	CodeImageNotFound        = "IMAGE_NOT_FOUND"
+	CodeNameConflict         = "NAME_CONFLICT"
+	CodeNetworkNotFound      = "NETWORK_NOT_FOUND"
+	CodeContainerNotFound    = "CONTAINER_NOT_FOUND"
+	CodeContainerConflict    = "CONTAINER_CONFLICT"
+	CodeInvalidConfig        = "INVALID_CONFIG"
+	CodePermissionDenied     = "PERMISSION_DENIED"
+	CodeUnsupported          = "UNSUPPORTED"
+	CodeDaemonUnavailable    = "DAEMON_UNAVAILABLE"
+	CodeDaemonTimeout        = "DAEMON_TIMEOUT"
+	CodeDockerError          = "DOCKER_ERROR"
+)
+
+// maxErrorDetailsLength bounds the daemon message kept in DockerError.Details
+const maxErrorDetailsLength = 500
+
+// daemonKind is the class of a daemon error, from its errdefs type or the
+// type of a DockerError
+type daemonKind string
+
+const (
+	kindNotFound       daemonKind = "not_found"
+	kindConflict       daemonKind = "conflict"
+	kindInvalid        daemonKind = "invalid"
+	kindUnauthorized   daemonKind = "unauthorized"
+	kindForbidden      daemonKind = "forbidden"
+	kindNotModified    daemonKind = "not_modified"
+	kindNotImplemented daemonKind = "not_implemented"
+	kindUnavailable    daemonKind = "unavailable"
+	kindDeadline       daemonKind = "deadline"
+	kindSystem         daemonKind = "system"
+)
+
+// daemonErrorRule maps a class of daemon errors to an API error. Rules are
+// tried in order; match receives the lower-cased daemon message.
+type daemonErrorRule struct {
+	code      string
+	status    int
+	errorType ErrorType
+	message   string
+	retryable bool
+	match     func(kind daemonKind, resource, message string) bool
+}
+
+var daemonErrorRules = []daemonErrorRule{
+	// Message patterns first: the daemon reports these as plain 500s
+	{CodePortAllocated, http.StatusConflict, ErrorTypeConflict, "Port is already allocated", false,
+		containsAny("port is already allocated", "address already in use")},
+	{CodeDiskFull, http.StatusInsufficientStorage, ErrorTypeOutOfDisk, "Not enough disk space on the Docker host", false,
+		containsAny("no space left on device", "disk quota exceeded")},
+	{CodeOutOfMemory, http.StatusInsufficientStorage, ErrorTypeOutOfMemory, "Not enough memory on the Docker host", false,
+		containsAny("cannot allocate memory", "out of memory")},
+	{CodeRegistryRateLimited, http.StatusTooManyRequests, ErrorTypeRegistryError, "Registry rate limit reached", true,
+		containsAny("toomanyrequests", "rate limit")},
+	{CodeRegistryAccessDenied, http.StatusForbidden, ErrorTypeAuthentication, "Registry access denied", false,
+		func(kind daemonKind, resource, message string) bool {
+			return kind == kindUnauthorized || containsAny("pull access denied", "no basic auth credentials")(kind, resource, message)
+		}},
+	{CodeImageNotFound, http.StatusNotFound, ErrorTypeNotFound, "Image not found", false,
+		func(kind daemonKind, resource, message string) bool {
+			return strings.Contains(message, "no such image") || strings.Contains(message, "manifest unknown") ||
+				(strings.Contains(message, "manifest for") && strings.Contains(message, "not found")) ||
+				(kind == kindNotFound && resource == "image")
+		}},
+	{CodeNameConflict, http.StatusConflict, ErrorTypeAlreadyExists, "Container name is already in use", false,
+		containsAny("is already in use by container")},
+	{CodeNetworkNotFound, http.StatusNotFound, ErrorTypeNotFound, "Network not found", false,
+		func(kind daemonKind, resource, message string) bool {
+			return kind == kindNotFound && strings.Contains(message, "network")
+		}},
+
+	// Then the errdefs class
+	{CodeContainerNotFound, http.StatusNotFound, ErrorTypeNotFound, "Container not found in Docker", false, isKind(kindNotFound)},
+	{CodeContainerConflict, http.StatusConflict, ErrorTypeConflict, "Container is in a conflicting state", false, isKind(kindConflict, kindNotModified)},
+	{CodeInvalidConfig, http.StatusBadRequest, ErrorTypeInvalidConfig, "Docker rejected the container configuration", false, isKind(kindInvalid)},
+	{CodePermissionDenied, http.StatusForbidden, ErrorTypePermission, "Docker denied the operation", false, isKind(kindForbidden)},
+	{CodeUnsupported, http.StatusNotImplemented, ErrorTypeUnsupported, "Operation not supported by the Docker daemon", false, isKind(kindNotImplemented)},
+	{CodeDaemonUnavailable, http.StatusServiceUnavailable, ErrorTypeConnection, "Docker daemon is unavailable", true, isKind(kindUnavailable)},
+	{CodeDaemonTimeout, http.StatusGatewayTimeout, ErrorTypeTimeout, "Docker daemon timed out", true, isKind(kindDeadline)},
+}
+
+// detailsHidden lists codes whose daemon message is not passed on; it names
+// the daemon's address and says nothing actionable beyond the code
+var detailsHidden = map[string]bool{
+	CodeDaemonUnavailable: true,
+	CodeDaemonTimeout:     true,
+}
+
+var (
+	daemonEndpointPattern = regexp.MustCompile(`(?i)\b(?:unix|npipe|tcp|ssh|fd|https?)://[^\s"',;)]+`)
+	socketPathPattern     = regexp.MustCompile(`(?:/[\w.\-]+)+\.sock\b`)
+	lookupPattern         = regexp.MustCompile(`(?i)\blookup [^\s:]+(?: on [^\s:]+(?::\d+)?)?`)
+	dialPattern           = regexp.MustCompile(`(?i)\bdial (tcp|tcp4|tcp6|udp|unix) [^\s:]+(?::\d+)?`)
+	ipAddressPattern      = regexp.MustCompile(`\[?[0-9a-fA-F:.]*[0-9a-fA-F]\]?(?::\d+)?`)
+)
+
+// TranslateError classifies an error returned by the Docker client into a
+// DockerError carrying an API error code and HTTP status. The daemon's
+// message is kept, sanitized, in Details. Errors that did not come from Docker
+// yield nil.
+func TranslateError(err error, operation, resource string) *DockerError {
+	if err == nil {
+		return nil
+	}
+
+	var translated *DockerError
+	if errors.As(err, &translated) && translated.Code != "" {
+		return translated
+	}
+
+	kind, daemonMessage, ok := daemonErrorKind(err)
+	if !ok {
+		return nil
+	}
+
+	rule := daemonErrorRule{
+		code:      CodeDockerError,
+		status:    http.StatusInternalServerError,
+		errorType: ErrorTypeSystemError,
+		message:   "Docker daemon error",
+		retryable: isTransientError(err),
+	}
+	lowered := strings.ToLower(daemonMessage)
+	for _, candidate := range daemonErrorRules {
+		if candidate.match(kind, resource, lowered) {
+			rule = candidate
+			break
+		}
+	}
+
+	details := ""
+	if !detailsHidden[rule.code] {
+		details = SanitizeDaemonMessage(daemonMessage)
+	}
+
+	return &DockerError{
+		Type:       rule.errorType,
+		Operation:  operation,
+		Resource:   resource,
+		Message:    rule.message,
+		Details:    details,
+		Underlying: err,
+		Code:       rule.code,
+		StatusCode: rule.status,
+		Retryable:  rule.retryable,
+		Timestamp:  time.Now(),
+	}
+}
+
+// SanitizeDaemonMessage prepares a daemon message for API clients: the
+// "Error response from daemon" prefix is dropped, and daemon endpoints, socket
+// paths and host addresses are masked. Wildcard and loopback bind addresses,
+// which tell which port is taken, are kept.
+func SanitizeDaemonMessage(message string) string {
+	message = strings.TrimSpace(message)
+	message = strings.TrimPrefix(message, "Error response from daemon: ")
+	message = strings.TrimPrefix(message, "Error: ")
+
+	message = daemonEndpointPattern.ReplaceAllString(message, "[endpoint]")
+	message = socketPathPattern.ReplaceAllString(message, "[socket]")
+	message = lookupPattern.ReplaceAllString(message, "lookup [host]")
+	message = dialPattern.ReplaceAllString(message, "dial $1 [address]")
+	message = ipAddressPattern.ReplaceAllStringFunc(message, maskAddress)
+
+	if len(message) > maxErrorDetailsLength {
+		message = message[:maxErrorDetailsLength] + "..."
+	}
+	return message
+}
+
+// maskAddress masks an IP address, with or without port, unless it is a
+// wildcard or loopback address. Anything that is not an IP is left alone.
+func maskAddress(candidate string) string {
+	host := candidate
+	if h, _, err := net.SplitHostPort(candidate); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+
+	ip := net.ParseIP(host)
+	if ip == nil || !strings.ContainsAny(host, ".:") {
+		return candidate
+	}
+	if ip.IsUnspecified() || ip.IsLoopback() {
+		return candidate
+	}
+	return "[address]"
+}
+
+// daemonErrorKind finds the daemon error in err's chain and returns its class
+// and message. errdefs types are preferred over DockerErrors wrapping them.
+// The client marks failed connections as unknown errors, so they are looked
+// for first.
+func daemonErrorKind(err error) (daemonKind, string, bool) {
+	if client.IsErrConnectionFailed(err) {
+		return kindUnavailable, "", true
+	}
+
+	var dockerErr *DockerError
+	for current := err; current != nil; current = unwrapError(current) {
+		if kind, ok := errdefsKind(current); ok {
+			return kind, current.Error(), true
+		}
+		if de, ok := current.(*DockerError); ok && dockerErr == nil {
+			dockerErr = de
+		}
+	}
+
+	if dockerErr != nil {
+		return dockerErrorKind(dockerErr.Type), dockerErr.Message, true
+	}
+	return "", "", false
+}
+
+func errdefsKind(err error) (daemonKind, bool) {
+	switch {
+	case errdefs.IsNotFound(err):
+		return kindNotFound, true
+	case errdefs.IsConflict(err):
+		return kindConflict, true
+	case errdefs.IsInvalidParameter(err):
+		return kindInvalid, true
+	case errdefs.IsUnauthorized(err):
+		return kindUnauthorized, true
+	case errdefs.IsForbidden(err):
+		return kindForbidden, true
+	case errdefs.IsNotModified(err):
+		return kindNotModified, true
+	case errdefs.IsNotImplemented(err):
+		return kindNotImplemented, true
+	case errdefs.IsUnavailable(err):
+		return kindUnavailable, true
+	case errdefs.IsDeadline(err):
+		return kindDeadline, true
+	case errdefs.IsSystem(err), errdefs.IsUnknown(err), errdefs.IsDataLoss(err):
+		return kindSystem, true
+	}
+	return "", false
+}
+
+func dockerErrorKind(errorType ErrorType) daemonKind {
+	switch errorType {
+	case ErrorTypeNotFound:
+		return kindNotFound
+	case ErrorTypeAlreadyExists, ErrorTypeConflict:
+		return kindConflict
+	case ErrorTypeInvalidConfig, ErrorTypeInvalidOperation:
+		return kindInvalid
+	case ErrorTypeAuthentication:
+		return kindUnauthorized
+	case ErrorTypePermission:
+		return kindForbidden
+	case ErrorTypeUnsupported:
+		return kindNotImplemented
+	case ErrorTypeConnection, ErrorTypeNetworkError, ErrorTypeDNSError:
+		return kindUnavailable
+	case ErrorTypeTimeout:
+		return kindDeadline
+	default:
+		return kindSystem
+	}
+}
+
+// unwrapError follows both Unwrap and the Cause convention used by errdefs
+func unwrapError(err error) error {
+	if next := errors.Unwrap(err); next != nil {
+		return next
+	}
+	if causer, ok := err.(interface{ Cause() error }); ok {
+		return causer.Cause()
+	}
+	return nil
+}
+
+func containsAny(patterns ...string) func(daemonKind, string, string) bool {
+	return func(_ daemonKind, _ string, message string) bool {
+		for _, pattern := range patterns {
+			if strings.Contains(message, pattern) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+func isKind(kinds ...daemonKind) func(daemonKind, string, string) bool {
+	return func(kind daemonKind, _ string, _ string) bool {
+		for _, k := range kinds {
+			if kind == k {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,180 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+func TestTranslateErrorMapsDaemonResponses(t *testing.T) {
+	ctx := context.Background()
+	start := func(dc *DockerClient) error { return dc.StartContainer(ctx, "web") }
+	pull := func(dc *DockerClient) error {
+		_, err := dc.PullImage(ctx, "internal/app:1", types.ImagePullOptions{})
+		return err
+	}
+
+	tests := []struct {
+		name      string
+		call      func(dc *DockerClient) error
+		resource  string
+		status    int
+		message   string
+		code      string
+		httpCode  int
+		retryable bool
+		details   string // part of the details kept
+		hidden    []string
+	}{
+		{
+			name: "port allocated", call: start, resource: "web",
+			status:  http.StatusInternalServerError,
+			message: "driver failed programming external connectivity on endpoint web (3f2a): Bind for 0.0.0.0:8080 failed: port is already allocated",
+			code:    CodePortAllocated, httpCode: http.StatusConflict,
+			details: "Bind for 0.0.0.0:8080 failed",
+		},
+		{
+			name: "disk full", call: start, resource: "web",
+			status:  http.StatusInternalServerError,
+			message: "error creating overlay mount: write /var/lib/docker/tmp/layer: no space left on device",
+			code:    CodeDiskFull, httpCode: http.StatusInsufficientStorage,
+			details: "no space left on device",
+		},
+		{
+			name: "rate limited", call: pull, resource: "image",
+			status:  http.StatusTooManyRequests,
+			message: "toomanyrequests: You have reached your pull rate limit",
+			code:    CodeRegistryRateLimited, httpCode: http.StatusTooManyRequests, retryable: true,
+			details: "pull rate limit",
+		},
+		{
+			name: "pull access denied", call: pull, resource: "image",
+			status:  http.StatusNotFound,
+			message: "pull access denied for internal/app, repository does not exist or may require 'docker login'",
+			code:    CodeRegistryAccessDenied, httpCode: http.StatusForbidden,
+			details: "pull access denied",
+		},
+		{
+			name: "image not found", call: pull, resource: "image",
+			status:  http.StatusNotFound,
+			message: "manifest for internal/app:1 not found: manifest unknown: manifest unknown",
+			code:    CodeImageNotFound, httpCode: http.StatusNotFound,
+			details: "manifest unknown",
+		},
+		{
+			name: "container not found", call: start, resource: "web",
+			status:  http.StatusNotFound,
+			message: "No such container: web",
+			code:    CodeContainerNotFound, httpCode: http.StatusNotFound,
+			details: "No such container: web",
+		},
+		{
+			name: "name conflict", call: start, resource: "web",
+			status:  http.StatusConflict,
+			message: `Conflict. The container name "/web" is already in use by container "3f2a". You have to remove (or rename) that container to be able to reuse that name.`,
+			code:    CodeNameConflict, httpCode: http.StatusConflict,
+			details: "already in use",
+		},
+		{
+			name: "registry unreachable", call: pull, resource: "image",
+			status:  http.StatusInternalServerError,
+			message: `Get "https://registry.corp.internal:5000/v2/": dial tcp 10.1.2.3:5000: connect: connection refused`,
+			code:    CodeDockerError, httpCode: http.StatusInternalServerError,
+			details: "dial tcp [address]",
+			hidden:  []string{"registry.corp.internal", "10.1.2.3"},
+		},
+		{
+			name: "registry lookup", call: pull, resource: "image",
+			status:  http.StatusInternalServerError,
+			message: "Get https://registry.corp.internal/v2/: dial tcp: lookup registry.corp.internal on 10.0.0.2:53: no such host",
+			code:    CodeDockerError, httpCode: http.StatusInternalServerError,
+			details: "lookup [host]",
+			hidden:  []string{"registry.corp.internal", "10.0.0.2"},
+		},
+		{
+			name: "containerd down", call: start, resource: "web",
+			status:  http.StatusServiceUnavailable,
+			message: "dial unix /run/containerd/containerd.sock: connect: connection refused",
+			code:    CodeDaemonUnavailable, httpCode: http.StatusServiceUnavailable, retryable: true,
+			hidden: []string{"/run/containerd/containerd.sock"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				writeJSON(t, w, map[string]string{"message": tt.message})
+			})
+
+			err := tt.call(dc)
+			if err == nil {
+				t.Fatal("call succeeded, want the daemon's error")
+			}
+			translated := TranslateError(err, "test", tt.resource)
+			if translated == nil {
+				t.Fatalf("TranslateError(%v) = nil", err)
+			}
+
+			if translated.Code != tt.code || translated.StatusCode != tt.httpCode {
+				t.Errorf("mapped to %s %d, want %s %d", translated.Code, translated.StatusCode, tt.code, tt.httpCode)
+			}
+			if translated.Retryable != tt.retryable || IsRetryableError(err) != tt.retryable {
+				t.Errorf("retryable = %t, IsRetryableError = %t, want %t", translated.Retryable, IsRetryableError(err), tt.retryable)
+			}
+			if tt.details != "" && !strings.Contains(translated.Details, tt.details) {
+				t.Errorf("details = %q, want them to keep %q", translated.Details, tt.details)
+			}
+			assertNothingLeaks(t, translated, tt.hidden...)
+		})
+	}
+}
+
+func TestTranslateErrorHidesUnreachableDaemon(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	address := strings.TrimPrefix(server.URL, "http://")
+	server.Close()
+
+	cli, err := client.NewClientWithOpts(client.WithHost("tcp://"+address), client.WithVersion("1.44"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	dc := &DockerClient{client: cli, timeout: 5 * time.Second}
+
+	startErr := dc.StartContainer(context.Background(), "web")
+	translated := TranslateError(startErr, "start", "web")
+	if translated == nil || translated.Code != CodeDaemonUnavailable || translated.StatusCode != http.StatusServiceUnavailable || !translated.Retryable {
+		t.Fatalf("TranslateError(%v) = %+v, want a retryable %s", startErr, translated, CodeDaemonUnavailable)
+	}
+	if translated.Details != "" {
+		t.Errorf("details = %q, want none for an unreachable daemon", translated.Details)
+	}
+	assertNothingLeaks(t, translated, address)
+}
+
+func TestTranslateErrorIgnoresOtherErrors(t *testing.T) {
+	if translated := TranslateError(context.Canceled, "start", "web"); translated != nil {
+		t.Errorf("TranslateError(context.Canceled) = %+v, want nil", translated)
+	}
+}
+
+// assertNothingLeaks checks that the client-facing message and details name
+// no socket path, daemon endpoint or any of hidden
+func assertNothingLeaks(t *testing.T, translated *DockerError, hidden ...string) {
+	t.Helper()
+	for _, field := range []string{translated.Message, translated.Details} {
+		for _, leak := range append([]string{".sock", "unix://", "tcp://"}, hidden...) {
+			if strings.Contains(field, leak) {
+				t.Errorf("%q leaks %q", field, leak)
+			}
+		}
+	}
+}
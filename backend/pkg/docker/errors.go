@@ -17,8 +17,10 @@ type DockerError struct {
 	Operation  string    `json:"operation"`
 	Resource   string    `json:"resource"`
 	Message    string    `json:"message"`
+	Details    string    `json:"details,omitempty"`
 	Underlying error     `json:"-"`
 	Code       string    `json:"code,omitempty"`
+	StatusCode int       `json:"status_code,omitempty"`
 	Retryable  bool      `json:"retryable"`
 	Timestamp  time.Time `json:"timestamp"`
 }
@@ -258,6 +260,9 @@ func IsRetryableError(err error) bool {
 	if dockerErr, ok := err.(*DockerError); ok {
 		return dockerErr.Retryable
 	}
+	if translated := TranslateError(err, "", ""); translated != nil {
+		return translated.Retryable
+	}
 	return isTransientError(err)
 }
 
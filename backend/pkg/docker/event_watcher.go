@@ -0,0 +1,367 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/sirupsen/logrus"
+
+	"docker-auto/pkg/model"
+)
+
+// defaultEventQueueSize bounds the container events waiting for the handler
+const defaultEventQueueSize = 256
+
+// ContainerEvent is a container state change reported by the daemon. Status
+// follows the same rules as a full sync, so a container that died with a
+// non-zero exit code is exited; it is empty for destroy, stop and
+// health_status, which carries the new health instead.
+type ContainerEvent struct {
+	ContainerID string                `json:"container_id"`
+	Name        string                `json:"name"`
+	Action      string                `json:"action"`
+	Status      model.ContainerStatus `json:"status,omitempty"`
+	Health      string                `json:"health,omitempty"`
+	Time        time.Time             `json:"time"`
+}
+
+// ContainerEventHandler applies a container event. Handlers may see an event
+// twice after a reconnect, and must tolerate that.
+type ContainerEventHandler func(ctx context.Context, event *ContainerEvent) error
+
+// EventWatcherStats describes the health of an event watcher
+type EventWatcherStats struct {
+	Connected bool `json:"connected"`
+	// DisconnectedSince is set while the watcher is not connected
+	DisconnectedSince *time.Time `json:"disconnected_since,omitempty"`
+	LastEventAt       *time.Time `json:"last_event_at,omitempty"`
+	EventsProcessed   int64      `json:"events_processed"`
+	// Duplicates are replayed events skipped because they were already applied
+	Duplicates int64 `json:"duplicates"`
+	// Coalesced are events superseded by a later event for the same container
+	// before the handler got to them
+	Coalesced  int64 `json:"coalesced"`
+	Reconnects int64 `json:"reconnects"`
+	QueueDepth int   `json:"queue_depth"`
+}
+
+// eventSource is the part of the Docker client the watcher subscribes with
+type eventSource interface {
+	Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error)
+}
+
+// appliedEvent is the last event applied for a container
+type appliedEvent struct {
+	timeNano int64
+	action   string
+}
+
+// EventWatcher keeps container statuses current from the daemon's event
+// stream.
+//
+// On reconnect it subscribes from the time of the last processed event, so
+// events during the gap are replayed; events at or before the last one
+// applied for a container are skipped. The read loop never blocks on the
+// handler: when the queue is full, events overflow into a per-container slot
+// that keeps only the latest, and once a container has overflowed its later
+// events go there too so it is never applied out of order.
+type EventWatcher struct {
+	source   eventSource
+	handler  ContainerEventHandler
+	sessions *SessionRegistry
+	queue    chan *ContainerEvent
+
+	mu       sync.Mutex
+	overflow map[string]*ContainerEvent
+	wake     chan struct{}
+	applied  map[string]appliedEvent
+
+	// lastEvent is the daemon time of the last processed event, in
+	// nanoseconds
+	lastEvent int64
+	connected int32
+	// disconnectedAt is when the stream last dropped, in nanoseconds
+	disconnectedAt int64
+	processed      int64
+	duplicates     int64
+	coalesced      int64
+	reconnects     int64
+}
+
+// newEventWatcher creates a watcher; Run starts it
+func newEventWatcher(source eventSource, handler ContainerEventHandler, queueSize int, sessions *SessionRegistry) *EventWatcher {
+	if queueSize <= 0 {
+		queueSize = defaultEventQueueSize
+	}
+	return &EventWatcher{
+		source:   source,
+		handler:  handler,
+		sessions: sessions,
+		queue:    make(chan *ContainerEvent, queueSize),
+		overflow: make(map[string]*ContainerEvent),
+		wake:     make(chan struct{}, 1),
+		applied:  make(map[string]appliedEvent),
+		// Not connected until the first subscription
+		disconnectedAt: time.Now().UnixNano(),
+	}
+}
+
+// WatchContainerEvents starts a watcher that passes container state changes
+// to handler until ctx is done
+func (d *DockerClient) WatchContainerEvents(ctx context.Context, handler ContainerEventHandler, queueSize int) *EventWatcher {
+	w := newEventWatcher(d.client, handler, queueSize, d.sessions)
+	d.eventWatcher.Store(w)
+	go w.Run(ctx)
+	return w
+}
+
+// EventWatcherStats returns the health of the container event watcher, nil
+// when none is running
+func (d *DockerClient) EventWatcherStats() *EventWatcherStats {
+	w, _ := d.eventWatcher.Load().(*EventWatcher)
+	if w == nil {
+		return nil
+	}
+	return w.Stats()
+}
+
+// Stats returns the watcher's health
+func (w *EventWatcher) Stats() *EventWatcherStats {
+	stats := &EventWatcherStats{
+		Connected:       atomic.LoadInt32(&w.connected) == 1,
+		EventsProcessed: atomic.LoadInt64(&w.processed),
+		Duplicates:      atomic.LoadInt64(&w.duplicates),
+		Coalesced:       atomic.LoadInt64(&w.coalesced),
+		Reconnects:      atomic.LoadInt64(&w.reconnects),
+	}
+	if !stats.Connected {
+		disconnectedSince := time.Unix(0, atomic.LoadInt64(&w.disconnectedAt))
+		stats.DisconnectedSince = &disconnectedSince
+	}
+	if last := atomic.LoadInt64(&w.lastEvent); last > 0 {
+		lastEventAt := time.Unix(0, last)
+		stats.LastEventAt = &lastEventAt
+	}
+
+	w.mu.Lock()
+	stats.QueueDepth = len(w.queue) + len(w.overflow)
+	w.mu.Unlock()
+	return stats
+}
+
+// Run subscribes to container events and reconnects with backoff until ctx
+// is done
+func (w *EventWatcher) Run(ctx context.Context) {
+	go w.process(ctx)
+
+	backoff := time.Second
+	for {
+		err := w.subscribe(ctx, func() { backoff = time.Second })
+		atomic.StoreInt64(&w.disconnectedAt, time.Now().UnixNano())
+		atomic.StoreInt32(&w.connected, 0)
+		if ctx.Err() != nil {
+			return
+		}
+
+		logrus.WithError(err).Warn("Container event stream interrupted, reconnecting")
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+		atomic.AddInt64(&w.reconnects, 1)
+	}
+}
+
+// subscribe reads one connection of the event stream until it fails
+func (w *EventWatcher) subscribe(ctx context.Context, onEvent func()) error {
+	streamCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// The stream is listed as a session so it shows up and can be cut
+	if w.sessions != nil {
+		stream := w.sessions.register(ctx, SessionEvents, "", "container status watcher", func() error {
+			cancel()
+			return nil
+		})
+		stream.begin()
+		defer func() {
+			stream.end()
+			stream.close()
+		}()
+	}
+
+	options := types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("type", "container"),
+			filters.Arg("event", "start"),
+			filters.Arg("event", "restart"),
+			filters.Arg("event", "die"),
+			filters.Arg("event", "pause"),
+			filters.Arg("event", "unpause"),
+			filters.Arg("event", "destroy"),
+			filters.Arg("event", "stop"),
+			filters.Arg("event", "health_status"),
+		),
+	}
+	// Replay the gap since the last processed event
+	if last := atomic.LoadInt64(&w.lastEvent); last > 0 {
+		options.Since = fmt.Sprintf("%d.%09d", last/int64(time.Second), last%int64(time.Second))
+	}
+
+	messages, errs := w.source.Events(streamCtx, options)
+	atomic.StoreInt32(&w.connected, 1)
+
+	for {
+		select {
+		case msg := <-messages:
+			onEvent()
+			w.enqueue(newContainerEvent(msg))
+		case err := <-errs:
+			return err
+		}
+	}
+}
+
+// enqueue hands an event to the processing loop without blocking
+func (w *EventWatcher) enqueue(event *ContainerEvent) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if pending, ok := w.overflow[event.ContainerID]; ok {
+		// An event without a status must not hide the status of the one it
+		// supersedes
+		if event.Status != "" || pending.Status == "" {
+			w.overflow[event.ContainerID] = event
+		}
+		atomic.AddInt64(&w.coalesced, 1)
+		return
+	}
+
+	select {
+	case w.queue <- event:
+	default:
+		w.overflow[event.ContainerID] = event
+		select {
+		case w.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// process applies queued events, then overflowed ones, from one goroutine so
+// that each container's events are applied in order
+func (w *EventWatcher) process(ctx context.Context) {
+	for {
+		select {
+		case event := <-w.queue:
+			w.apply(ctx, event)
+			continue
+		default:
+		}
+
+		if pending := w.takeOverflow(); len(pending) > 0 {
+			for _, event := range pending {
+				w.apply(ctx, event)
+			}
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-w.queue:
+			w.apply(ctx, event)
+		case <-w.wake:
+		}
+	}
+}
+
+// takeOverflow empties the overflow slots, oldest event first
+func (w *EventWatcher) takeOverflow() []*ContainerEvent {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.overflow) == 0 {
+		return nil
+	}
+	pending := make([]*ContainerEvent, 0, len(w.overflow))
+	for _, event := range w.overflow {
+		pending = append(pending, event)
+	}
+	w.overflow = make(map[string]*ContainerEvent)
+
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Time.Before(pending[j].Time)
+	})
+	return pending
+}
+
+// apply passes an event to the handler unless it was already applied
+func (w *EventWatcher) apply(ctx context.Context, event *ContainerEvent) {
+	timeNano := event.Time.UnixNano()
+	last, seen := w.applied[event.ContainerID]
+	if seen && (timeNano < last.timeNano || timeNano == last.timeNano && event.Action == last.action) {
+		atomic.AddInt64(&w.duplicates, 1)
+		return
+	}
+
+	if err := w.handler(ctx, event); err != nil {
+		logrus.WithError(err).WithFields(logrus.Fields{
+			"container_id": event.ContainerID,
+			"action":       event.Action,
+		}).Warn("Failed to apply container event")
+	}
+
+	if event.Action == "destroy" {
+		delete(w.applied, event.ContainerID)
+	} else {
+		w.applied[event.ContainerID] = appliedEvent{timeNano: timeNano, action: event.Action}
+	}
+	atomic.AddInt64(&w.processed, 1)
+	if timeNano > atomic.LoadInt64(&w.lastEvent) {
+		atomic.StoreInt64(&w.lastEvent, timeNano)
+	}
+}
+
+// newContainerEvent converts a daemon event message
+func newContainerEvent(msg events.Message) *ContainerEvent {
+	event := &ContainerEvent{
+		ContainerID: msg.Actor.ID,
+		Name:        msg.Actor.Attributes["name"],
+		Action:      string(msg.Action),
+		Time:        time.Unix(0, msg.TimeNano),
+	}
+	// Health changes arrive as "health_status: healthy"
+	if health, ok := strings.CutPrefix(event.Action, "health_status:"); ok {
+		event.Action = "health_status"
+		event.Health = strings.TrimSpace(health)
+	}
+	if msg.TimeNano == 0 {
+		event.Time = time.Unix(msg.Time, 0)
+	}
+
+	switch event.Action {
+	case "start", "restart", "unpause":
+		event.Status = model.ContainerStatusRunning
+	case "pause":
+		event.Status = model.ContainerStatusPaused
+	case "die":
+		event.Status = model.ContainerStatusStopped
+		if code := msg.Actor.Attributes["exitCode"]; code != "" && code != "0" {
+			event.Status = model.ContainerStatusExited
+		}
+	}
+	return event
+}
@@ -0,0 +1,262 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+
+	"docker-auto/pkg/model"
+)
+
+// eventStream is one subscription to the daemon's event stream
+type eventStream struct {
+	messages chan events.Message
+	errs     chan error
+}
+
+// scriptedEvents serves each subscription from the next stream, recording the
+// options it was opened with. Streams end when their context does.
+type scriptedEvents struct {
+	mu      sync.Mutex
+	streams []*eventStream
+	options []types.EventsOptions
+	opened  chan int
+}
+
+func newScriptedEvents(streams int) *scriptedEvents {
+	s := &scriptedEvents{opened: make(chan int, streams+1)}
+	for i := 0; i < streams; i++ {
+		s.streams = append(s.streams, &eventStream{messages: make(chan events.Message), errs: make(chan error, 1)})
+	}
+	return s
+}
+
+func (s *scriptedEvents) Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
+	s.mu.Lock()
+	n := len(s.options)
+	s.options = append(s.options, options)
+	stream := &eventStream{messages: make(chan events.Message), errs: make(chan error, 1)}
+	if n < len(s.streams) {
+		stream = s.streams[n]
+	}
+	s.mu.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		select {
+		case stream.errs <- ctx.Err():
+		default:
+		}
+	}()
+	s.opened <- n
+	return stream.messages, stream.errs
+}
+
+// send hands msg to the watcher's read loop, failing when it does not take it
+func (s *scriptedEvents) send(t *testing.T, stream int, msg events.Message) {
+	t.Helper()
+	select {
+	case s.streams[stream].messages <- msg:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("the read loop did not take %s of %s", msg.Action, msg.Actor.ID)
+	}
+}
+
+func (s *scriptedEvents) waitOpened(t *testing.T, stream int) {
+	t.Helper()
+	select {
+	case n := <-s.opened:
+		if n != stream {
+			t.Fatalf("opened stream %d, want %d", n, stream)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("stream %d was not opened", stream)
+	}
+}
+
+var eventEpoch = time.Date(2026, 3, 7, 2, 0, 0, 0, time.UTC)
+
+func containerMessage(id, action string, second int, exitCode string) events.Message {
+	msg := events.Message{
+		Type:     events.ContainerEventType,
+		Action:   events.Action(action),
+		Actor:    events.Actor{ID: id, Attributes: map[string]string{"name": id}},
+		TimeNano: eventEpoch.Add(time.Duration(second) * time.Second).UnixNano(),
+	}
+	if exitCode != "" {
+		msg.Actor.Attributes["exitCode"] = exitCode
+	}
+	return msg
+}
+
+// eventRecorder is a handler keeping the events applied
+type eventRecorder struct {
+	mu      sync.Mutex
+	applied []string
+	notify  chan struct{}
+	hold    chan struct{} // when set, the first event waits for it
+}
+
+func newEventRecorder() *eventRecorder {
+	return &eventRecorder{notify: make(chan struct{}, 64)}
+}
+
+func (r *eventRecorder) handle(ctx context.Context, event *ContainerEvent) error {
+	r.mu.Lock()
+	first := len(r.applied) == 0
+	r.applied = append(r.applied, fmt.Sprintf("%s %s %s %d", event.ContainerID, event.Action, event.Status, event.Time.Sub(eventEpoch)/time.Second))
+	r.mu.Unlock()
+	r.notify <- struct{}{}
+
+	if first && r.hold != nil {
+		<-r.hold
+	}
+	return nil
+}
+
+func (r *eventRecorder) waitApplied(t *testing.T, n int) []string {
+	t.Helper()
+	deadline := time.After(5 * time.Second)
+	for {
+		r.mu.Lock()
+		applied := append([]string(nil), r.applied...)
+		r.mu.Unlock()
+		if len(applied) >= n {
+			return applied
+		}
+		select {
+		case <-r.notify:
+		case <-deadline:
+			t.Fatalf("applied %v, want %d events", applied, n)
+		}
+	}
+}
+
+func assertApplied(t *testing.T, got, want []string) {
+	t.Helper()
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("applied %q\nwant    %q", got, want)
+	}
+}
+
+func TestEventWatcherReplaysGapAfterReconnect(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := newScriptedEvents(2)
+	recorder := newEventRecorder()
+	w := newEventWatcher(source, recorder.handle, 0, nil)
+	go w.Run(ctx)
+
+	source.waitOpened(t, 0)
+	if source.options[0].Since != "" {
+		t.Errorf("first subscription since %q, want none", source.options[0].Since)
+	}
+	source.send(t, 0, containerMessage("web", "start", 1, ""))
+	source.send(t, 0, containerMessage("web", "die", 2, "137"))
+	recorder.waitApplied(t, 2)
+
+	// The stream drops; the watcher reconnects from the last event it applied
+	source.streams[0].errs <- fmt.Errorf("unexpected EOF")
+	source.waitOpened(t, 1)
+	wantSince := fmt.Sprintf("%d.%09d", eventEpoch.Unix()+2, 0)
+	if since := source.options[1].Since; since != wantSince {
+		t.Errorf("resubscribed since %q, want %q", since, wantSince)
+	}
+
+	// The daemon replays from that time, including the event already applied
+	source.send(t, 1, containerMessage("web", "die", 2, "137"))
+	source.send(t, 1, containerMessage("web", "start", 3, ""))
+	source.send(t, 1, containerMessage("db", "stop", 4, ""))
+	assertApplied(t, recorder.waitApplied(t, 4), []string{
+		"web start running 1",
+		"web die exited 2",
+		"web start running 3",
+		"db stop  4",
+	})
+
+	stats := w.Stats()
+	if !stats.Connected || stats.Reconnects != 1 || stats.Duplicates != 1 || stats.EventsProcessed != 4 {
+		t.Errorf("stats = %+v, want connected after one reconnect, one duplicate and 4 processed", stats)
+	}
+	if stats.LastEventAt == nil || !stats.LastEventAt.Equal(eventEpoch.Add(4*time.Second)) {
+		t.Errorf("last event at %v, want the db stop", stats.LastEventAt)
+	}
+}
+
+func TestEventWatcherCoalescesForSlowHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	source := newScriptedEvents(1)
+	recorder := newEventRecorder()
+	recorder.hold = make(chan struct{})
+	w := newEventWatcher(source, recorder.handle, 1, nil)
+	go w.Run(ctx)
+	source.waitOpened(t, 0)
+
+	// The handler is stuck on the first event, as on a slow database
+	source.send(t, 0, containerMessage("web", "start", 1, ""))
+	recorder.waitApplied(t, 1)
+
+	// The read loop keeps taking events: one fits in the queue, the rest
+	// overflow and only the latest with a status is kept per container
+	source.send(t, 0, containerMessage("web", "die", 2, "0"))
+	source.send(t, 0, containerMessage("web", "start", 3, ""))
+	source.send(t, 0, containerMessage("db", "start", 4, ""))
+	source.send(t, 0, containerMessage("web", "die", 5, "1"))
+	source.send(t, 0, containerMessage("web", "health_status: unhealthy", 6, ""))
+	// The last event is queued just after the read loop took it
+	deadline := time.Now().Add(2 * time.Second)
+	stats := w.Stats()
+	for stats.Coalesced < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		stats = w.Stats()
+	}
+	if stats.Coalesced != 2 || stats.QueueDepth != 3 {
+		t.Errorf("stats = %+v, want 2 coalesced and 3 waiting", stats)
+	}
+
+	close(recorder.hold)
+	assertApplied(t, recorder.waitApplied(t, 4), []string{
+		"web start running 1",
+		"web die stopped 2",
+		"db start running 4",
+		"web die exited 5",
+	})
+
+	// Nothing else is applied, and web ends up exited as it is
+	time.Sleep(50 * time.Millisecond)
+	if applied := recorder.waitApplied(t, 0); len(applied) != 4 {
+		t.Errorf("applied %q, want the coalesced events left out", applied)
+	}
+	if stats := w.Stats(); stats.EventsProcessed != 4 || stats.QueueDepth != 0 {
+		t.Errorf("stats = %+v, want 4 processed and none waiting", stats)
+	}
+}
+
+func TestNewContainerEventStatus(t *testing.T) {
+	tests := []struct {
+		action, exitCode string
+		status           model.ContainerStatus
+		health           string
+	}{
+		{"start", "", model.ContainerStatusRunning, ""},
+		{"unpause", "", model.ContainerStatusRunning, ""},
+		{"pause", "", model.ContainerStatusPaused, ""},
+		{"die", "0", model.ContainerStatusStopped, ""},
+		{"die", "137", model.ContainerStatusExited, ""},
+		{"health_status: healthy", "", "", "healthy"},
+		{"destroy", "", "", ""},
+	}
+
+	for _, tt := range tests {
+		event := newContainerEvent(containerMessage("web", tt.action, 1, tt.exitCode))
+		if event.Status != tt.status || event.Health != tt.health {
+			t.Errorf("%s (exit %q) = status %q health %q, want %q %q", tt.action, tt.exitCode, event.Status, event.Health, tt.status, tt.health)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/sirupsen/logrus"
+
+	"docker-auto/internal/config"
+	"docker-auto/pkg/model"
+)
+
+// HostPool hands out one DockerClient per Docker host. Clients for remote
+// hosts are created on first use and cached until the host's connection
+// settings change or a health ping fails; containers without a host use the
+// local client.
+type HostPool struct {
+	cfg          *config.Config
+	local        *DockerClient
+	pingInterval time.Duration
+
+	mu      sync.Mutex
+	clients map[int]*hostClient
+}
+
+// hostClient is a cached client and when its daemon last answered a ping
+type hostClient struct {
+	client   *DockerClient
+	key      string
+	dialer   io.Closer
+	pingedAt time.Time
+}
+
+// NewHostPool creates a pool around the local client
+func NewHostPool(cfg *config.Config, local *DockerClient) *HostPool {
+	pingInterval := time.Duration(cfg.Docker.HostPingIntervalSeconds) * time.Second
+	if pingInterval <= 0 {
+		pingInterval = time.Minute
+	}
+
+	return &HostPool{
+		cfg:          cfg,
+		local:        local,
+		pingInterval: pingInterval,
+		clients:      make(map[int]*hostClient),
+	}
+}
+
+// Local returns the client of the local daemon
+func (p *HostPool) Local() *DockerClient {
+	return p.local
+}
+
+// Client returns the client for host, or the local client when host is nil.
+// A remote client whose last ping is older than the ping interval is pinged
+// first and dropped if the daemon does not answer.
+func (p *HostPool) Client(ctx context.Context, host *model.DockerHost) (*DockerClient, error) {
+	if host == nil {
+		return p.local, nil
+	}
+
+	entry, err := p.entry(host)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	fresh := time.Since(entry.pingedAt) < p.pingInterval
+	p.mu.Unlock()
+	if fresh {
+		return entry.client, nil
+	}
+
+	if err := p.ping(ctx, host, entry); err != nil {
+		return nil, err
+	}
+	return entry.client, nil
+}
+
+// Ping checks that the host's daemon answers, creating its client if needed
+func (p *HostPool) Ping(ctx context.Context, host *model.DockerHost) error {
+	if host == nil {
+		return p.local.Ping(ctx)
+	}
+
+	entry, err := p.entry(host)
+	if err != nil {
+		return err
+	}
+	return p.ping(ctx, host, entry)
+}
+
+// Remove closes and forgets the client of a host
+func (p *HostPool) Remove(hostID int) {
+	p.mu.Lock()
+	entry := p.clients[hostID]
+	delete(p.clients, hostID)
+	p.mu.Unlock()
+
+	if entry != nil {
+		entry.close()
+	}
+}
+
+// Close closes every remote client; the local client is left to its owner
+func (p *HostPool) Close() error {
+	p.mu.Lock()
+	clients := p.clients
+	p.clients = make(map[int]*hostClient)
+	p.mu.Unlock()
+
+	for _, entry := range clients {
+		entry.close()
+	}
+	return nil
+}
+
+// entry returns the cached client of host, replacing it when the host's
+// connection settings changed
+func (p *HostPool) entry(host *model.DockerHost) (*hostClient, error) {
+	key := host.ConnectionKey()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if entry, ok := p.clients[host.ID]; ok {
+		if entry.key == key {
+			return entry, nil
+		}
+		delete(p.clients, host.ID)
+		go entry.close()
+	}
+
+	entry, err := p.newHostClient(host)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for docker host %s: %w", host.Name, err)
+	}
+	p.clients[host.ID] = entry
+	return entry, nil
+}
+
+// ping pings the host's daemon, dropping the client when it fails
+func (p *HostPool) ping(ctx context.Context, host *model.DockerHost, entry *hostClient) error {
+	pingCtx, cancel := context.WithTimeout(ctx, entry.client.GetTimeout())
+	defer cancel()
+
+	if err := entry.client.Ping(pingCtx); err != nil {
+		// Whoever removes the entry closes it
+		p.mu.Lock()
+		owned := p.clients[host.ID] == entry
+		if owned {
+			delete(p.clients, host.ID)
+		}
+		p.mu.Unlock()
+		if owned {
+			entry.close()
+		}
+
+		logrus.WithError(err).WithField("docker_host", host.Name).Warn("Docker host did not answer ping")
+		return fmt.Errorf("docker host %s is unreachable: %w", host.Name, err)
+	}
+
+	p.mu.Lock()
+	entry.pingedAt = time.Now()
+	p.mu.Unlock()
+	return nil
+}
+
+// newHostClient builds a client from the host's endpoint and credentials
+func (p *HostPool) newHostClient(host *model.DockerHost) (*hostClient, error) {
+	if err := host.Validate(); err != nil {
+		return nil, err
+	}
+
+	entry := &hostClient{key: host.ConnectionKey()}
+	endpoint := host.Endpoint
+	var opts []client.Opt
+
+	switch host.Scheme() {
+	case "ssh":
+		timeout := time.Duration(p.cfg.Docker.Timeout) * time.Second
+		if timeout <= 0 {
+			timeout = 30 * time.Second
+		}
+		dialer, err := newSSHDialer(host.Endpoint, host.SSHKeyPath, p.cfg.Docker.SSHKnownHostsFile, timeout)
+		if err != nil {
+			return nil, err
+		}
+		entry.dialer = dialer
+		// The host only names the transport; every connection goes through the dialer
+		endpoint = "unix://" + dialer.socket
+		opts = append(opts, client.WithDialContext(dialer.DialContext))
+	case "tcp":
+		if host.UsesTLS() {
+			opts = append(opts, client.WithTLSClientConfig(host.TLSCACert, host.TLSCert, host.TLSKey))
+		}
+	}
+
+	dockerClient, err := newDockerClient(p.cfg, endpoint, opts...)
+	if err != nil {
+		if entry.dialer != nil {
+			entry.dialer.Close()
+		}
+		return nil, err
+	}
+	entry.client = dockerClient
+	return entry, nil
+}
+
+// close closes the client and its SSH connection, if any
+func (e *hostClient) close() {
+	if err := e.client.Close(); err != nil {
+		logrus.WithError(err).Warn("Failed to close docker host client")
+	}
+	if e.dialer != nil {
+		e.dialer.Close()
+	}
+}
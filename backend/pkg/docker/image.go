@@ -6,9 +6,12 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"strings"
 	"time"
 
+	"docker-auto/pkg/model"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
 	"github.com/docker/docker/api/types/image"
@@ -17,6 +20,34 @@ import (
 
 // Image pulling and management
 
+// RegistryAuthFunc returns the credentials to reach the registry of an image
+// reference with, nil for anonymous access
+type RegistryAuthFunc func(ctx context.Context, ref string) *registry.AuthConfig
+
+// SetRegistryAuthFunc sets where pulls and registry inspections that are not
+// given credentials look them up
+func (d *DockerClient) SetRegistryAuthFunc(fn RegistryAuthFunc) {
+	d.registryAuth.Store(fn)
+}
+
+// lookupRegistryAuth returns the encoded credentials for ref from the
+// RegistryAuthFunc, "" when there are none
+func (d *DockerClient) lookupRegistryAuth(ctx context.Context, ref string) (string, error) {
+	fn, _ := d.registryAuth.Load().(RegistryAuthFunc)
+	if fn == nil {
+		return "", nil
+	}
+	authConfig := fn(ctx, ref)
+	if authConfig == nil {
+		return "", nil
+	}
+	authConfigBytes, err := json.Marshal(authConfig)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal auth config: %w", err)
+	}
+	return base64.URLEncoding.EncodeToString(authConfigBytes), nil
+}
+
 // PullImage pulls a Docker image from a registry
 func (d *DockerClient) PullImage(ctx context.Context, imageName string, options types.ImagePullOptions) (io.ReadCloser, error) {
 	if ctx == nil {
@@ -29,6 +60,14 @@ func (d *DockerClient) PullImage(ctx context.Context, imageName string, options
 		return nil, fmt.Errorf("image name cannot be empty")
 	}
 
+	if options.RegistryAuth == "" {
+		encodedAuth, err := d.lookupRegistryAuth(ctx, imageName)
+		if err != nil {
+			return nil, err
+		}
+		options.RegistryAuth = encodedAuth
+	}
+
 	reader, err := d.client.ImagePull(ctx, imageName, options)
 	if err != nil {
 		return nil, fmt.Errorf("failed to pull image %s: %w", imageName, err)
@@ -63,25 +102,159 @@ func (d *DockerClient) PullImageWithAuth(ctx context.Context, imageName string,
 
 // PullImageAndWait pulls an image and waits for completion
 func (d *DockerClient) PullImageAndWait(ctx context.Context, imageName string, options types.ImagePullOptions) error {
+	return d.PullImageThrottled(ctx, imageName, imageName, options, nil)
+}
+
+// PullImageThrottled pulls an image through the host pull throttle and waits
+// for completion. key identifies the caller in queue status (e.g. a container),
+// and onQueued is invoked with the number of pulls ahead if the pull must wait.
+func (d *DockerClient) PullImageThrottled(ctx context.Context, key, imageName string, options types.ImagePullOptions, onQueued func(ahead int)) error {
+	return d.PullImageWithProgress(ctx, key, imageName, options, onQueued, nil)
+}
+
+// PullImageWithProgress is PullImageThrottled reporting the pull progress,
+// aggregated over the layers, to onProgress as the stream advances
+func (d *DockerClient) PullImageWithProgress(ctx context.Context, key, imageName string, options types.ImagePullOptions, onQueued func(ahead int), onProgress func(model.PullProgress)) error {
 	if ctx == nil {
 		var cancel context.CancelFunc
 		ctx, cancel = d.WithTimeout(context.Background())
 		defer cancel()
 	}
 
+	ticket, err := d.pullThrottle.Acquire(ctx, key, onQueued)
+	if err != nil {
+		return err
+	}
+
+	var downloaded int64
+	defer func() { ticket.Release(downloaded) }()
+
 	reader, err := d.PullImage(ctx, imageName, options)
 	if err != nil {
 		return err
 	}
 	defer reader.Close()
 
-	// Read all data to ensure pull completes
-	_, err = io.ReadAll(reader)
-	if err != nil {
-		return fmt.Errorf("failed to read pull response: %w", err)
+	tracker := newPullTracker(imageName)
+	err = consumePullProgress(reader, func(msg *pullProgressMessage) {
+		if tracker.observe(msg) && onProgress != nil {
+			onProgress(tracker.progress())
+		}
+	})
+	downloaded = tracker.downloaded()
+	return err
+}
+
+// pullProgressMessage is a single JSON message from the pull progress stream
+type pullProgressMessage struct {
+	ID             string `json:"id"`
+	Status         string `json:"status"`
+	Error          string `json:"error"`
+	ProgressDetail struct {
+		Current int64 `json:"current"`
+		Total   int64 `json:"total"`
+	} `json:"progressDetail"`
+}
+
+// consumePullProgress drains the pull stream, passing each message to observe
+func consumePullProgress(reader io.Reader, observe func(msg *pullProgressMessage)) error {
+	decoder := json.NewDecoder(reader)
+
+	for {
+		var msg pullProgressMessage
+		if err := decoder.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read pull response: %w", err)
+		}
+		if msg.Error != "" {
+			return fmt.Errorf("pull failed: %s", msg.Error)
+		}
+		observe(&msg)
 	}
+}
 
-	return nil
+// layerProgress is the download progress of one layer of a pull
+type layerProgress struct {
+	current int64
+	total   int64
+	done    bool
+}
+
+// pullTracker aggregates the per-layer messages of one pull stream
+type pullTracker struct {
+	image  string
+	layers map[string]*layerProgress
+}
+
+func newPullTracker(image string) *pullTracker {
+	return &pullTracker{image: image, layers: make(map[string]*layerProgress)}
+}
+
+// observe records a message and reports whether the progress changed
+func (t *pullTracker) observe(msg *pullProgressMessage) bool {
+	// Messages without a layer ID, or about the tag being pulled, describe
+	// the pull as a whole
+	if msg.ID == "" || strings.HasPrefix(msg.Status, "Pulling from") {
+		return false
+	}
+
+	layer, ok := t.layers[msg.ID]
+	if !ok {
+		layer = &layerProgress{}
+		t.layers[msg.ID] = layer
+	}
+
+	switch msg.Status {
+	case "Downloading":
+		if msg.ProgressDetail.Total > 0 {
+			layer.total = msg.ProgressDetail.Total
+		}
+		if msg.ProgressDetail.Current > layer.current {
+			layer.current = msg.ProgressDetail.Current
+		}
+		return true
+	case "Download complete", "Pull complete", "Already exists":
+		if layer.done {
+			return false
+		}
+		layer.done = true
+		if layer.total > 0 {
+			layer.current = layer.total
+		}
+		return true
+	}
+	return !ok
+}
+
+// downloaded returns the number of layer bytes downloaded
+func (t *pullTracker) downloaded() int64 {
+	var total int64
+	for _, layer := range t.layers {
+		total += layer.current
+	}
+	return total
+}
+
+// progress returns the progress aggregated over the layers seen so far
+func (t *pullTracker) progress() model.PullProgress {
+	progress := model.PullProgress{Image: t.image, Layers: len(t.layers)}
+	for _, layer := range t.layers {
+		progress.CurrentBytes += layer.current
+		progress.TotalBytes += layer.total
+		if layer.done {
+			progress.LayersDone++
+		}
+	}
+
+	switch {
+	case progress.Layers > 0 && progress.LayersDone == progress.Layers:
+		progress.Percent = 100
+	case progress.TotalBytes > 0:
+		progress.Percent = math.Round(float64(progress.CurrentBytes)*1000/float64(progress.TotalBytes)) / 10
+	}
+	return progress
 }
 
 // BuildImage builds a Docker image from a Dockerfile
@@ -382,6 +555,57 @@ func (d *DockerClient) CompareImageDigests(ctx context.Context, image1, image2 s
 	return digest1 == digest2, nil
 }
 
+// RegistryImage is an image reference as resolved by its registry
+type RegistryImage struct {
+	Digest    string   `json:"digest"`
+	Platforms []string `json:"platforms"`
+}
+
+// InspectRegistryImage resolves ref in its registry without pulling it and
+// returns its manifest digest and the platforms it provides as os/arch[/variant]
+func (d *DockerClient) InspectRegistryImage(ctx context.Context, ref string, authConfig *registry.AuthConfig) (*RegistryImage, error) {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = d.WithTimeout(context.Background())
+		defer cancel()
+	}
+
+	encodedAuth := ""
+	if authConfig != nil {
+		authConfigBytes, err := json.Marshal(authConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal auth config: %w", err)
+		}
+		encodedAuth = base64.URLEncoding.EncodeToString(authConfigBytes)
+	} else {
+		var err error
+		if encodedAuth, err = d.lookupRegistryAuth(ctx, ref); err != nil {
+			return nil, err
+		}
+	}
+
+	inspect, err := d.client.DistributionInspect(ctx, ref, encodedAuth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect %s in registry: %w", ref, err)
+	}
+
+	result := &RegistryImage{Digest: inspect.Descriptor.Digest.String()}
+	for _, platform := range inspect.Platforms {
+		result.Platforms = append(result.Platforms, FormatPlatform(platform.OS, platform.Architecture, platform.Variant))
+	}
+
+	return result, nil
+}
+
+// FormatPlatform formats a platform as os/arch[/variant], e.g. "linux/arm64/v8"
+func FormatPlatform(os, architecture, variant string) string {
+	platform := os + "/" + architecture
+	if variant != "" {
+		platform += "/" + variant
+	}
+	return platform
+}
+
 // Image utility functions
 
 // ImageExists checks if an image exists
@@ -78,6 +78,10 @@ func (d *DockerClient) GetContainerLogs(ctx context.Context, containerID string,
 		return nil, fmt.Errorf("failed to get logs for container %s: %w", containerID, err)
 	}
 
+	// Only a followed stream stays open after the logs are read
+	if options.Follow {
+		return d.trackStream(ctx, SessionLogs, containerID, "logs follow", reader), nil
+	}
 	return reader, nil
 }
 
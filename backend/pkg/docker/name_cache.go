@@ -0,0 +1,168 @@
+package docker
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/sirupsen/logrus"
+)
+
+// nameCache is a short-lived container name to ID index. Entries expire after
+// the TTL and are dropped as soon as the event watcher sees the name created,
+// renamed or destroyed. The cache is only used while the watcher is
+// connected. A nil cache is valid and never hits.
+type nameCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	live    bool
+	entries map[string]nameCacheEntry
+}
+
+type nameCacheEntry struct {
+	id      string
+	expires time.Time
+}
+
+func newNameCache(ttl time.Duration) *nameCache {
+	return &nameCache{
+		ttl:     ttl,
+		entries: make(map[string]nameCacheEntry),
+	}
+}
+
+func (c *nameCache) get(name string) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[name]
+	if !ok || !c.live {
+		return "", false
+	}
+	if time.Now().After(entry.expires) {
+		delete(c.entries, name)
+		return "", false
+	}
+	return entry.id, true
+}
+
+func (c *nameCache) set(name, id string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.live {
+		return
+	}
+	c.entries[name] = nameCacheEntry{id: id, expires: time.Now().Add(c.ttl)}
+}
+
+func (c *nameCache) drop(names ...string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, name := range names {
+		delete(c.entries, strings.TrimPrefix(name, "/"))
+	}
+}
+
+// reset empties the cache and switches it on or off
+func (c *nameCache) reset(live bool) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.live = live
+	c.entries = make(map[string]nameCacheEntry)
+}
+
+// EnableNameCache turns on the name to ID cache used by FindContainerIDByName
+// and starts the event watcher that invalidates it. The watcher runs until ctx
+// is done. It must be called before the client is shared.
+func (d *DockerClient) EnableNameCache(ctx context.Context, ttl time.Duration) {
+	if ttl <= 0 || d.names != nil {
+		return
+	}
+
+	d.names = newNameCache(ttl)
+	go d.watchContainerNames(ctx)
+}
+
+// watchContainerNames drops cache entries for names touched by container
+// create, rename and destroy events. While the event stream is down the cache
+// is bypassed, since missed events could leave stale entries behind.
+func (d *DockerClient) watchContainerNames(ctx context.Context) {
+	backoff := time.Second
+
+	for {
+		// The stream is listed as a session so it shows up and can be cut;
+		// the loop below is always waiting on it, so it is never idle
+		streamCtx, cancel := context.WithCancel(ctx)
+		var stream *session
+		if d.sessions != nil {
+			stream = d.sessions.register(ctx, SessionEvents, "", "container name cache", func() error {
+				cancel()
+				return nil
+			})
+			stream.begin()
+		}
+
+		events, errs := d.client.Events(streamCtx, types.EventsOptions{
+			Filters: filters.NewArgs(
+				filters.Arg("type", "container"),
+				filters.Arg("event", "create"),
+				filters.Arg("event", "rename"),
+				filters.Arg("event", "destroy"),
+			),
+		})
+		d.names.reset(true)
+
+		err := func() error {
+			for {
+				select {
+				case event := <-events:
+					backoff = time.Second
+					d.names.drop(event.Actor.Attributes["name"], event.Actor.Attributes["oldName"])
+				case err := <-errs:
+					return err
+				}
+			}
+		}()
+
+		if stream != nil {
+			stream.end()
+			stream.close()
+		}
+		cancel()
+
+		d.names.reset(false)
+		if ctx.Err() != nil {
+			return
+		}
+
+		logrus.WithError(err).Warn("Container event stream interrupted, name cache bypassed until it reconnects")
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < time.Minute {
+			backoff *= 2
+		}
+	}
+}
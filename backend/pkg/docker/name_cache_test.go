@@ -0,0 +1,188 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/events"
+)
+
+// newNameCacheClient returns a client of daemon with the name cache enabled.
+// Messages sent on the returned channel are streamed to the event watcher;
+// closing it ends the stream.
+func newNameCacheClient(t *testing.T, daemon *nameDaemon) (*DockerClient, chan<- events.Message) {
+	t.Helper()
+
+	stream := make(chan events.Message)
+	connected := make(chan struct{}, 1)
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/containers/json":
+			daemon.serveList(t, w, r)
+		case r.Method == http.MethodGet && r.URL.Path == "/events":
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			select {
+			case connected <- struct{}{}:
+			default: // a reconnect
+			}
+			for {
+				select {
+				case message, ok := <-stream:
+					if !ok {
+						return
+					}
+					json.NewEncoder(w).Encode(message)
+					w.(http.Flusher).Flush()
+				case <-r.Context().Done():
+					return
+				}
+			}
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	dc.EnableNameCache(ctx, time.Hour)
+
+	select {
+	case <-connected:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event watcher did not connect")
+	}
+	waitFor(t, "the name cache to go live", func() bool {
+		dc.names.mu.Lock()
+		defer dc.names.mu.Unlock()
+		return dc.names.live
+	})
+	return dc, stream
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func lookup(t *testing.T, dc *DockerClient, name string) string {
+	t.Helper()
+
+	id, err := dc.FindContainerIDByName(context.Background(), name)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+func (d *nameDaemon) listCount() int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lists
+}
+
+func TestNameCacheKeepsPrefixedNamesApart(t *testing.T) {
+	daemon := newNameDaemon("web-1=id-web-1", "web=id-web")
+	dc, _ := newNameCacheClient(t, daemon)
+
+	// Caching web-1 first must not answer for web, nor the other way round
+	for i := 0; i < 2; i++ {
+		if id := lookup(t, dc, "web-1"); id != "id-web-1" {
+			t.Fatalf("web-1 = %q, want id-web-1", id)
+		}
+		if id := lookup(t, dc, "web"); id != "id-web" {
+			t.Fatalf("web = %q, want id-web", id)
+		}
+	}
+	if lists := daemon.listCount(); lists != 2 {
+		t.Errorf("daemon listed %d times, want 2 with the second round cached", lists)
+	}
+	if id := lookup(t, dc, "we"); id != "" {
+		t.Errorf("we = %q, want not found", id)
+	}
+}
+
+func TestNameCacheDropsRenamedContainers(t *testing.T) {
+	daemon := newNameDaemon("web=id-a", "web-1=id-b")
+	dc, stream := newNameCacheClient(t, daemon)
+
+	if id := lookup(t, dc, "web"); id != "id-a" {
+		t.Fatalf("web = %q, want id-a", id)
+	}
+	if id := lookup(t, dc, "web-1"); id != "id-b" {
+		t.Fatalf("web-1 = %q, want id-b", id)
+	}
+
+	// web is renamed and a new web created; the daemon reports the old name
+	// with a leading slash and the new one without
+	daemon.rename("web", "web-old")
+	stream <- events.Message{Type: events.ContainerEventType, Action: "rename", Actor: events.Actor{
+		ID:         "id-a",
+		Attributes: map[string]string{"name": "web-old", "oldName": "/web"},
+	}}
+	waitFor(t, "the renamed name to be dropped", func() bool {
+		_, ok := dc.names.get("web")
+		return !ok
+	})
+	if id := lookup(t, dc, "web"); id != "" {
+		t.Errorf("web after rename = %q, want not found", id)
+	}
+
+	daemon.mu.Lock()
+	daemon.names = append(daemon.names, "web")
+	daemon.ids["web"] = "id-c"
+	daemon.mu.Unlock()
+	stream <- events.Message{Type: events.ContainerEventType, Action: "create", Actor: events.Actor{
+		ID:         "id-c",
+		Attributes: map[string]string{"name": "web"},
+	}}
+	waitFor(t, "the created name to be dropped", func() bool {
+		_, ok := dc.names.get("web")
+		return !ok
+	})
+
+	tests := map[string]string{"web": "id-c", "web-old": "id-a", "web-1": "id-b"}
+	for name, wantID := range tests {
+		if id := lookup(t, dc, name); id != wantID {
+			t.Errorf("%s = %q, want %s", name, id, wantID)
+		}
+	}
+}
+
+func TestNameCacheBypassedWhileEventsAreDown(t *testing.T) {
+	daemon := newNameDaemon("web=id-a")
+	dc, stream := newNameCacheClient(t, daemon)
+
+	if id := lookup(t, dc, "web"); id != "id-a" {
+		t.Fatalf("web = %q, want id-a", id)
+	}
+
+	// Without events a rename could go unnoticed, so lookups go to the daemon
+	close(stream)
+	waitFor(t, "the name cache to be bypassed", func() bool {
+		dc.names.mu.Lock()
+		defer dc.names.mu.Unlock()
+		return !dc.names.live
+	})
+	daemon.rename("web", "web-old")
+
+	before := daemon.listCount()
+	if id := lookup(t, dc, "web"); id != "" {
+		t.Errorf("web after an unseen rename = %q, want not found", id)
+	}
+	if daemon.listCount() == before {
+		t.Error("lookup was answered from the cache while the event stream was down")
+	}
+}
@@ -0,0 +1,45 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/docker/docker/api/types"
+)
+
+// ListNetworks lists Docker networks with optional filters. The daemon does
+// not fill in Containers when listing; use the "dangling" filter to find
+// networks without attached containers.
+func (d *DockerClient) ListNetworks(ctx context.Context, options types.NetworkListOptions) ([]types.NetworkResource, error) {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = d.WithTimeout(context.Background())
+		defer cancel()
+	}
+
+	networks, err := d.client.NetworkList(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list networks: %w", err)
+	}
+
+	return networks, nil
+}
+
+// RemoveNetwork removes a Docker network
+func (d *DockerClient) RemoveNetwork(ctx context.Context, networkID string) error {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = d.WithTimeout(context.Background())
+		defer cancel()
+	}
+
+	if networkID == "" {
+		return fmt.Errorf("network ID cannot be empty")
+	}
+
+	if err := d.client.NetworkRemove(ctx, networkID); err != nil {
+		return fmt.Errorf("failed to remove network %s: %w", networkID, err)
+	}
+
+	return nil
+}
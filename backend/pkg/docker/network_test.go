@@ -0,0 +1,69 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+func TestListNetworksPassesFilters(t *testing.T) {
+	var gotFilters string
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/networks" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		gotFilters = r.URL.Query().Get("filters")
+		writeJSON(t, w, []types.NetworkResource{
+			{ID: "n1", Name: "app_default", Driver: "bridge"},
+		})
+	})
+
+	networks, err := dc.ListNetworks(context.Background(), types.NetworkListOptions{
+		Filters: filters.NewArgs(filters.Arg("dangling", "true"), filters.Arg("type", "custom")),
+	})
+	if err != nil {
+		t.Fatalf("ListNetworks: %v", err)
+	}
+	if len(networks) != 1 || networks[0].ID != "n1" || networks[0].Name != "app_default" {
+		t.Fatalf("networks = %+v, want app_default", networks)
+	}
+
+	args, err := filters.FromJSON(gotFilters)
+	if err != nil {
+		t.Fatalf("invalid filters %q: %v", gotFilters, err)
+	}
+	if !args.ExactMatch("dangling", "true") || !args.ExactMatch("type", "custom") {
+		t.Fatalf("filters = %q, want dangling=true and type=custom", gotFilters)
+	}
+}
+
+func TestRemoveNetwork(t *testing.T) {
+	var gotMethod, gotPath string
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		gotMethod, gotPath = r.Method, r.URL.Path
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := dc.RemoveNetwork(context.Background(), "n1"); err != nil {
+		t.Fatalf("RemoveNetwork: %v", err)
+	}
+	if gotMethod != http.MethodDelete || gotPath != "/networks/n1" {
+		t.Fatalf("request = %s %s, want DELETE /networks/n1", gotMethod, gotPath)
+	}
+}
+
+func TestRemoveNetworkError(t *testing.T) {
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+		writeJSON(t, w, map[string]string{"message": "error while removing network: network n1 has active endpoints"})
+	})
+
+	err := dc.RemoveNetwork(context.Background(), "n1")
+	if err == nil || !strings.Contains(err.Error(), "active endpoints") {
+		t.Fatalf("err = %v, want the daemon's active endpoints error", err)
+	}
+}
@@ -0,0 +1,137 @@
+package docker
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrSkippedFailFast is the error of operations a fail-fast batch never started
+const ErrSkippedFailFast = "skipped: an earlier operation failed and fail_fast is set"
+
+// RunParallel runs opFunc once per key, at most config.MaxConcurrency at a
+// time, and returns a result per key in the order given. Operations start in
+// key order. A failure does not stop the batch unless config.FailFast is set,
+// in which case operations not yet started are skipped while those already
+// running finish. config.Timeout bounds the whole batch when positive.
+func RunParallel(
+	ctx context.Context,
+	keys []string,
+	operation string,
+	config BulkOperationConfig,
+	opFunc func(ctx context.Context, index int) error,
+) []ParallelOperationResult {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if config.MaxConcurrency <= 0 {
+		config.MaxConcurrency = DefaultBulkConfig().MaxConcurrency
+	}
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(config.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	results := make([]ParallelOperationResult, len(keys))
+	for i, key := range keys {
+		results[i] = ParallelOperationResult{
+			ContainerID: key,
+			Operation:   operation,
+		}
+	}
+	if len(keys) == 0 {
+		return results
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"operation":       operation,
+		"container_count": len(keys),
+		"max_concurrency": config.MaxConcurrency,
+		"timeout":         config.Timeout,
+	}).Info("Starting parallel container operation")
+
+	start := time.Now()
+	semaphore := make(chan struct{}, config.MaxConcurrency)
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	completed := 0
+	failed := false
+
+	for i := range keys {
+		semaphore <- struct{}{}
+
+		mu.Lock()
+		abort := failed && config.FailFast
+		mu.Unlock()
+		if abort {
+			<-semaphore
+			for j := i; j < len(keys); j++ {
+				results[j].Error = ErrSkippedFailFast
+			}
+			break
+		}
+
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-semaphore }()
+
+			opStart := time.Now()
+			err := opFunc(ctx, index)
+			duration := time.Since(opStart)
+
+			mu.Lock()
+			results[index].StartedAt = opStart
+			results[index].Duration = duration
+			if err != nil {
+				results[index].Error = err.Error()
+				failed = true
+			} else {
+				results[index].Success = true
+			}
+			completed++
+			if config.ProgressCallback != nil {
+				config.ProgressCallback(completed, len(keys))
+			}
+			mu.Unlock()
+
+			if err != nil {
+				logrus.WithFields(logrus.Fields{
+					"container_id": keys[index],
+					"operation":    operation,
+					"duration":     duration,
+					"error":        err,
+				}).Error("Container operation failed")
+			} else {
+				logrus.WithFields(logrus.Fields{
+					"container_id": keys[index],
+					"operation":    operation,
+					"duration":     duration,
+				}).Debug("Container operation completed")
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	successCount := 0
+	for _, result := range results {
+		if result.Success {
+			successCount++
+		}
+	}
+
+	totalDuration := time.Since(start)
+	logrus.WithFields(logrus.Fields{
+		"operation":        operation,
+		"total_containers": len(keys),
+		"successful":       successCount,
+		"failed":           len(keys) - successCount,
+		"total_duration":   totalDuration,
+		"avg_duration":     totalDuration / time.Duration(len(keys)),
+	}).Info("Parallel container operation completed")
+
+	return results
+}
@@ -0,0 +1,132 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func parallelKeys(n int) []string {
+	keys := make([]string, n)
+	for i := range keys {
+		keys[i] = strconv.Itoa(i)
+	}
+	return keys
+}
+
+func TestRunParallelBoundsConcurrency(t *testing.T) {
+	const limit = 3
+	var running, peak int32
+
+	results := RunParallel(context.Background(), parallelKeys(20), "test", BulkOperationConfig{MaxConcurrency: limit}, func(ctx context.Context, index int) error {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		for {
+			seen := atomic.LoadInt32(&peak)
+			if now <= seen || atomic.CompareAndSwapInt32(&peak, seen, now) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		return nil
+	})
+
+	if peak > limit {
+		t.Fatalf("peak concurrency = %d, want at most %d", peak, limit)
+	}
+	if peak < 2 {
+		t.Fatalf("peak concurrency = %d, operations did not run in parallel", peak)
+	}
+	for i, result := range results {
+		if !result.Success {
+			t.Errorf("result %d failed: %s", i, result.Error)
+		}
+		if result.StartedAt.IsZero() || result.Duration < 10*time.Millisecond {
+			t.Errorf("result %d: started at %v, took %v", i, result.StartedAt, result.Duration)
+		}
+	}
+}
+
+func TestRunParallelDefaultsConcurrency(t *testing.T) {
+	var running, peak int32
+	var mu sync.Mutex
+
+	RunParallel(context.Background(), parallelKeys(15), "test", BulkOperationConfig{}, func(ctx context.Context, index int) error {
+		now := atomic.AddInt32(&running, 1)
+		defer atomic.AddInt32(&running, -1)
+		mu.Lock()
+		if now > peak {
+			peak = now
+		}
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		return nil
+	})
+
+	if want := int32(DefaultBulkConfig().MaxConcurrency); peak > want {
+		t.Fatalf("peak concurrency = %d, want at most %d", peak, want)
+	}
+}
+
+func TestRunParallelContinuesAfterFailure(t *testing.T) {
+	var calls int32
+
+	results := RunParallel(context.Background(), parallelKeys(6), "test", BulkOperationConfig{MaxConcurrency: 2}, func(ctx context.Context, index int) error {
+		atomic.AddInt32(&calls, 1)
+		if index == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if calls != 6 {
+		t.Fatalf("ran %d operations, want 6", calls)
+	}
+	for i, result := range results {
+		if result.ContainerID != strconv.Itoa(i) {
+			t.Errorf("result %d is for %q", i, result.ContainerID)
+		}
+		if wantSuccess := i != 1; result.Success != wantSuccess {
+			t.Errorf("result %d success = %v, want %v (%s)", i, result.Success, wantSuccess, result.Error)
+		}
+	}
+	if results[1].Error != "boom" {
+		t.Errorf("result 1 error = %q, want boom", results[1].Error)
+	}
+}
+
+func TestRunParallelFailFastSkipsRemaining(t *testing.T) {
+	var calls int32
+
+	results := RunParallel(context.Background(), parallelKeys(10), "test", BulkOperationConfig{MaxConcurrency: 1, FailFast: true}, func(ctx context.Context, index int) error {
+		atomic.AddInt32(&calls, 1)
+		if index == 2 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	if calls != 3 {
+		t.Fatalf("ran %d operations, want 3", calls)
+	}
+	for i, result := range results {
+		switch {
+		case i < 2:
+			if !result.Success {
+				t.Errorf("result %d failed: %s", i, result.Error)
+			}
+		case i == 2:
+			if result.Error != "boom" {
+				t.Errorf("result 2 error = %q, want boom", result.Error)
+			}
+		default:
+			if result.Success || result.Error != ErrSkippedFailFast || !result.StartedAt.IsZero() {
+				t.Errorf("result %d = %+v, want skipped", i, result)
+			}
+		}
+	}
+}
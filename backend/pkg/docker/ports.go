@@ -0,0 +1,93 @@
+package docker
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/docker/go-connections/nat"
+)
+
+// PortEntry is one binding of a container port. A port that is exposed but
+// not published has a single entry with Published unset and no host address.
+type PortEntry struct {
+	ContainerPort int    `json:"container_port"`
+	Protocol      string `json:"protocol"`
+	HostIP        string `json:"host_ip,omitempty"`
+	HostPort      int    `json:"host_port,omitempty"`
+	Published     bool   `json:"published"`
+}
+
+// PortEntries flattens a port map, such as NetworkSettings.Ports, into one
+// entry per host binding, ordered by container port, protocol, host port and
+// host IP
+func PortEntries(ports nat.PortMap) []PortEntry {
+	entries := make([]PortEntry, 0, len(ports))
+	for port, bindings := range ports {
+		containerPort := port.Int()
+		protocol := port.Proto()
+
+		if len(bindings) == 0 {
+			entries = append(entries, PortEntry{ContainerPort: containerPort, Protocol: protocol})
+			continue
+		}
+		for _, binding := range bindings {
+			hostPort, _ := strconv.Atoi(binding.HostPort)
+			entries = append(entries, PortEntry{
+				ContainerPort: containerPort,
+				Protocol:      protocol,
+				HostIP:        binding.HostIP,
+				HostPort:      hostPort,
+				Published:     true,
+			})
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ContainerPort != b.ContainerPort {
+			return a.ContainerPort < b.ContainerPort
+		}
+		if a.Protocol != b.Protocol {
+			return a.Protocol < b.Protocol
+		}
+		if a.HostPort != b.HostPort {
+			return a.HostPort < b.HostPort
+		}
+		return a.HostIP < b.HostIP
+	})
+	return entries
+}
+
+// FormatPublishedPorts renders the published entries compactly, e.g.
+// "8080->80/tcp, 127.0.0.1:53->53/udp, [::1]:9000->9000/tcp". A host port
+// bound on both the IPv4 and IPv6 wildcard addresses is listed once without
+// an address.
+func FormatPublishedPorts(entries []PortEntry) string {
+	var parts []string
+	seen := make(map[string]bool)
+	for _, entry := range entries {
+		if !entry.Published {
+			continue
+		}
+
+		host := strconv.Itoa(entry.HostPort)
+		switch entry.HostIP {
+		case "", "0.0.0.0", "::":
+		default:
+			ip := entry.HostIP
+			if strings.Contains(ip, ":") {
+				ip = "[" + ip + "]"
+			}
+			host = ip + ":" + host
+		}
+
+		part := fmt.Sprintf("%s->%d/%s", host, entry.ContainerPort, entry.Protocol)
+		if !seen[part] {
+			seen[part] = true
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,84 @@
+package docker
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+// dnsInspect is the inspect output of a DNS server publishing 53 on tcp and
+// udp, on two host addresses and the IPv6 loopback, an admin port on both
+// wildcard addresses, and exposing a metrics port it does not publish
+const dnsInspect = `{
+	"Id": "abc123",
+	"Name": "/dns",
+	"State": {"Status": "running", "Running": true},
+	"Config": {"Image": "coredns:1.11"},
+	"NetworkSettings": {
+		"Ports": {
+			"53/tcp": [{"HostIp": "10.0.0.5", "HostPort": "53"}],
+			"53/udp": [
+				{"HostIp": "10.0.0.5", "HostPort": "53"},
+				{"HostIp": "127.0.0.1", "HostPort": "5353"},
+				{"HostIp": "::1", "HostPort": "53"}
+			],
+			"8080/tcp": [
+				{"HostIp": "0.0.0.0", "HostPort": "8081"},
+				{"HostIp": "::", "HostPort": "8081"}
+			],
+			"9153/tcp": null
+		}
+	}
+}`
+
+func TestGetContainerPortsReportsEveryBinding(t *testing.T) {
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/containers/dns/json" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(dnsInspect))
+	})
+
+	ports, err := dc.GetContainerPorts(context.Background(), "dns")
+	if err != nil {
+		t.Fatalf("GetContainerPorts: %v", err)
+	}
+
+	want := []PortEntry{
+		{ContainerPort: 53, Protocol: "tcp", HostIP: "10.0.0.5", HostPort: 53, Published: true},
+		{ContainerPort: 53, Protocol: "udp", HostIP: "10.0.0.5", HostPort: 53, Published: true},
+		{ContainerPort: 53, Protocol: "udp", HostIP: "::1", HostPort: 53, Published: true},
+		{ContainerPort: 53, Protocol: "udp", HostIP: "127.0.0.1", HostPort: 5353, Published: true},
+		{ContainerPort: 8080, Protocol: "tcp", HostIP: "0.0.0.0", HostPort: 8081, Published: true},
+		{ContainerPort: 8080, Protocol: "tcp", HostIP: "::", HostPort: 8081, Published: true},
+		{ContainerPort: 9153, Protocol: "tcp"},
+	}
+	if !reflect.DeepEqual(ports, want) {
+		t.Fatalf("ports = %+v\nwant %+v", ports, want)
+	}
+
+	wantSummary := "10.0.0.5:53->53/tcp, 10.0.0.5:53->53/udp, [::1]:53->53/udp, 127.0.0.1:5353->53/udp, 8081->8080/tcp"
+	if summary := FormatPublishedPorts(ports); summary != wantSummary {
+		t.Errorf("published ports = %q\nwant %q", summary, wantSummary)
+	}
+}
+
+func TestGetContainerPortsWithoutNetworkSettings(t *testing.T) {
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"Id": "abc123", "Name": "/job", "State": {"Status": "exited"}, "Config": {"Image": "busybox"}}`))
+	})
+
+	ports, err := dc.GetContainerPorts(context.Background(), "job")
+	if err != nil {
+		t.Fatalf("GetContainerPorts: %v", err)
+	}
+	if ports == nil || len(ports) != 0 {
+		t.Errorf("ports = %#v, want an empty list", ports)
+	}
+	if summary := FormatPublishedPorts(ports); summary != "" {
+		t.Errorf("published ports = %q, want none", summary)
+	}
+}
@@ -0,0 +1,276 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// PullThrottle limits image pulls on the host. It caps the number of
+// concurrent pulls and, when a bandwidth budget is configured, serializes
+// pulls and holds the slot long enough to keep the average rate under it.
+type PullThrottle struct {
+	mu            sync.Mutex
+	maxConcurrent int
+	bandwidth     float64 // bytes per second, 0 means unlimited
+	active        map[*PullTicket]struct{}
+	queue         []*pullWaiter
+	held          int // slots held back by the bandwidth budget
+	stats         pullCounters
+}
+
+// PullTicket represents an admitted pull; it must be released when the pull ends
+type PullTicket struct {
+	throttle  *PullThrottle
+	key       string
+	startedAt time.Time
+	waited    time.Duration
+	once      sync.Once
+}
+
+// PullThrottleStats is a snapshot of the pull queue
+type PullThrottleStats struct {
+	MaxConcurrent int           `json:"max_concurrent"`
+	BandwidthMBps float64       `json:"bandwidth_mbps"`
+	ActivePulls   int           `json:"active_pulls"`
+	QueueDepth    int           `json:"queue_depth"`
+	TotalPulls    int64         `json:"total_pulls"`
+	QueuedPulls   int64         `json:"queued_pulls"`
+	TotalWait     time.Duration `json:"total_wait"`
+	MaxWait       time.Duration `json:"max_wait"`
+	AverageWait   time.Duration `json:"average_wait"`
+	LastRateMBps  float64       `json:"last_rate_mbps"`
+	LastPullBytes int64         `json:"last_pull_bytes"`
+}
+
+type pullWaiter struct {
+	key        string
+	enqueuedAt time.Time
+	ready      chan *PullTicket
+}
+
+type pullCounters struct {
+	totalPulls    int64
+	queuedPulls   int64
+	totalWait     time.Duration
+	maxWait       time.Duration
+	lastRateMBps  float64
+	lastPullBytes int64
+}
+
+const bytesPerMB = 1024 * 1024
+
+// ContainerPullKey returns the throttle key used for pulls made on behalf of a container
+func ContainerPullKey(containerID int64) string {
+	return fmt.Sprintf("container:%d", containerID)
+}
+
+// NewPullThrottle creates a pull throttle. maxConcurrent <= 0 defaults to 3;
+// a positive bandwidthMBps serializes pulls regardless of maxConcurrent.
+func NewPullThrottle(maxConcurrent int, bandwidthMBps float64) *PullThrottle {
+	if maxConcurrent <= 0 {
+		maxConcurrent = 3
+	}
+	if bandwidthMBps < 0 {
+		bandwidthMBps = 0
+	}
+
+	return &PullThrottle{
+		maxConcurrent: maxConcurrent,
+		bandwidth:     bandwidthMBps * bytesPerMB,
+		active:        make(map[*PullTicket]struct{}),
+	}
+}
+
+// Acquire blocks until a pull slot is available. onQueued, when non-nil, is
+// called once with the number of pulls ahead if the caller has to wait.
+func (t *PullThrottle) Acquire(ctx context.Context, key string, onQueued func(ahead int)) (*PullTicket, error) {
+	if t == nil {
+		return &PullTicket{key: key, startedAt: time.Now()}, nil
+	}
+
+	t.mu.Lock()
+	if len(t.queue) == 0 && t.hasFreeSlotLocked() {
+		ticket := t.admitLocked(key, 0)
+		t.mu.Unlock()
+		return ticket, nil
+	}
+
+	waiter := &pullWaiter{
+		key:        key,
+		enqueuedAt: time.Now(),
+		ready:      make(chan *PullTicket, 1),
+	}
+	t.queue = append(t.queue, waiter)
+	t.stats.queuedPulls++
+	ahead := len(t.active) + t.held + len(t.queue) - 1
+	t.mu.Unlock()
+
+	if onQueued != nil {
+		onQueued(ahead)
+	}
+
+	select {
+	case ticket := <-waiter.ready:
+		return ticket, nil
+	case <-ctx.Done():
+		t.mu.Lock()
+		removed := t.removeWaiterLocked(waiter)
+		t.mu.Unlock()
+		if !removed {
+			// Admitted concurrently with cancellation; hand the slot back
+			(<-waiter.ready).Release(0)
+		}
+		return nil, fmt.Errorf("waiting for pull slot: %w", ctx.Err())
+	}
+}
+
+// Release frees the slot. bytes is the amount downloaded by the pull and is
+// used to enforce the bandwidth budget.
+func (p *PullTicket) Release(bytes int64) {
+	if p == nil || p.throttle == nil {
+		return
+	}
+
+	p.once.Do(func() {
+		t := p.throttle
+		elapsed := time.Since(p.startedAt)
+
+		t.mu.Lock()
+		delete(t.active, p)
+		t.stats.lastPullBytes = bytes
+		if elapsed > 0 && bytes > 0 {
+			t.stats.lastRateMBps = float64(bytes) / elapsed.Seconds() / bytesPerMB
+		}
+
+		// Hold the slot until the pull would have finished at the budgeted rate
+		var hold time.Duration
+		if t.bandwidth > 0 && bytes > 0 {
+			budgeted := time.Duration(float64(bytes) / t.bandwidth * float64(time.Second))
+			hold = budgeted - elapsed
+		}
+		if hold > 0 {
+			t.held++
+			t.mu.Unlock()
+			time.AfterFunc(hold, func() {
+				t.mu.Lock()
+				t.held--
+				t.admitWaitersLocked()
+				t.mu.Unlock()
+			})
+			return
+		}
+
+		t.admitWaitersLocked()
+		t.mu.Unlock()
+	})
+}
+
+// Waited returns how long the pull waited for its slot
+func (p *PullTicket) Waited() time.Duration {
+	if p == nil {
+		return 0
+	}
+	return p.waited
+}
+
+// Status describes the pull state for key, e.g. "queued behind 3 pulls".
+// An empty string means no pull is pending for key.
+func (t *PullThrottle) Status(key string) string {
+	if t == nil {
+		return ""
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for i, waiter := range t.queue {
+		if waiter.key == key {
+			return fmt.Sprintf("queued behind %d pulls", len(t.active)+t.held+i)
+		}
+	}
+	for ticket := range t.active {
+		if ticket.key == key {
+			return "pulling"
+		}
+	}
+
+	return ""
+}
+
+// Stats returns a snapshot of the pull queue
+func (t *PullThrottle) Stats() PullThrottleStats {
+	if t == nil {
+		return PullThrottleStats{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := PullThrottleStats{
+		MaxConcurrent: t.maxConcurrent,
+		BandwidthMBps: t.bandwidth / bytesPerMB,
+		ActivePulls:   len(t.active),
+		QueueDepth:    len(t.queue),
+		TotalPulls:    t.stats.totalPulls,
+		QueuedPulls:   t.stats.queuedPulls,
+		TotalWait:     t.stats.totalWait,
+		MaxWait:       t.stats.maxWait,
+		LastRateMBps:  t.stats.lastRateMBps,
+		LastPullBytes: t.stats.lastPullBytes,
+	}
+	if t.stats.totalPulls > 0 {
+		stats.AverageWait = t.stats.totalWait / time.Duration(t.stats.totalPulls)
+	}
+
+	return stats
+}
+
+// limitLocked returns the effective concurrency limit
+func (t *PullThrottle) limitLocked() int {
+	if t.bandwidth > 0 {
+		return 1
+	}
+	return t.maxConcurrent
+}
+
+func (t *PullThrottle) hasFreeSlotLocked() bool {
+	return len(t.active)+t.held < t.limitLocked()
+}
+
+func (t *PullThrottle) admitLocked(key string, waited time.Duration) *PullTicket {
+	ticket := &PullTicket{
+		throttle:  t,
+		key:       key,
+		startedAt: time.Now(),
+		waited:    waited,
+	}
+	t.active[ticket] = struct{}{}
+
+	t.stats.totalPulls++
+	t.stats.totalWait += waited
+	if waited > t.stats.maxWait {
+		t.stats.maxWait = waited
+	}
+
+	return ticket
+}
+
+func (t *PullThrottle) admitWaitersLocked() {
+	for len(t.queue) > 0 && t.hasFreeSlotLocked() {
+		waiter := t.queue[0]
+		t.queue = t.queue[1:]
+		waiter.ready <- t.admitLocked(waiter.key, time.Since(waiter.enqueuedAt))
+	}
+}
+
+func (t *PullThrottle) removeWaiterLocked(target *pullWaiter) bool {
+	for i, waiter := range t.queue {
+		if waiter == target {
+			t.queue = append(t.queue[:i], t.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
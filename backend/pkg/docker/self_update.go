@@ -0,0 +1,327 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// SelfUpdateCommand is the argument that runs the server binary as the
+// self-update helper
+const SelfUpdateCommand = "updater"
+
+// SelfUpdatePlanEnv passes the helper its SelfUpdatePlan as JSON
+const SelfUpdatePlanEnv = "DOCKER_AUTO_SELF_UPDATE_PLAN"
+
+// SelfUpdateLabel holds the update ID on self-update helper containers
+const SelfUpdateLabel = "docker-auto.self-update"
+
+// Exit codes of the self-update helper
+const (
+	SelfUpdateExitOK = 0
+	// SelfUpdateExitFailed means the update failed and the old container
+	// runs again, or was never stopped
+	SelfUpdateExitFailed = 1
+	// SelfUpdateExitRestoreFailed means the update failed and the old
+	// container could not be brought back
+	SelfUpdateExitRestoreFailed = 2
+)
+
+const (
+	// selfUpdateCheckTimeout bounds the helper run checking the daemon
+	selfUpdateCheckTimeout = time.Minute
+	// selfUpdateMinUptime is how long a new container without a health
+	// check must keep running to count as updated
+	selfUpdateMinUptime = 10 * time.Second
+)
+
+// ErrSelfUpdateRestoreFailed is wrapped by RunSelfUpdate errors when the old
+// container could not be brought back
+var ErrSelfUpdateRestoreFailed = errors.New("restoring the old container failed")
+
+var (
+	containerIDPattern      = regexp.MustCompile(`[0-9a-f]{64}`)
+	shortContainerIDPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+)
+
+// SelfUpdatePlan tells the self-update helper what to replace
+type SelfUpdatePlan struct {
+	// Check only checks that the helper reaches the Docker daemon
+	Check bool `json:"check,omitempty"`
+
+	ContainerID          string `json:"container_id,omitempty"`
+	Image                string `json:"image,omitempty"`
+	RetiredName          string `json:"retired_name,omitempty"` // name of the old container until it is removed
+	StopTimeoutSeconds   int    `json:"stop_timeout_seconds,omitempty"`
+	HealthTimeoutSeconds int    `json:"health_timeout_seconds,omitempty"`
+}
+
+// SelfContainerID returns the ID of the container this process runs in, or
+// "" outside a container. It is taken from the cgroup, else from the mount
+// of /etc/hostname; when only the hostname Docker set is left it is the
+// short ID.
+func SelfContainerID() string {
+	if data, err := os.ReadFile("/proc/self/cgroup"); err == nil {
+		if id := containerIDPattern.FindString(string(data)); id != "" {
+			return id
+		}
+	}
+
+	// Under cgroup v2 the cgroup path is hidden, but the container's
+	// hostname file is mounted from its directory
+	if data, err := os.ReadFile("/proc/self/mountinfo"); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, " /etc/hostname ") {
+				if id := containerIDPattern.FindString(line); id != "" {
+					return id
+				}
+			}
+		}
+	}
+
+	if hostname := os.Getenv("HOSTNAME"); shortContainerIDPattern.MatchString(hostname) {
+		return hostname
+	}
+	return ""
+}
+
+// CheckSelfUpdateHelper runs the self-update helper in check mode and
+// returns an error when it cannot reach the Docker daemon
+func (d *DockerClient) CheckSelfUpdateHelper(ctx context.Context, self *types.ContainerJSON, executable string) error {
+	id, err := d.createSelfUpdateHelper(ctx, self, executable, &SelfUpdatePlan{Check: true}, "check")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		removeCtx, cancel := d.WithTimeout(context.Background())
+		defer cancel()
+		_ = d.client.ContainerRemove(removeCtx, id, types.ContainerRemoveOptions{Force: true})
+	}()
+
+	exitCode, stdout, stderr, err := d.waitHelper(ctx, id, selfUpdateCheckTimeout)
+	if err != nil {
+		return err
+	}
+	if exitCode != SelfUpdateExitOK {
+		output := strings.TrimSpace(stderr + stdout)
+		return fmt.Errorf("helper exited with code %d: %s", exitCode, output)
+	}
+	return nil
+}
+
+// StartSelfUpdateHelper starts the helper that replaces self following
+// plan, labelled with updateID, and returns its ID. The helper is kept after
+// it exits so its outcome can be read.
+func (d *DockerClient) StartSelfUpdateHelper(ctx context.Context, self *types.ContainerJSON, executable string, plan *SelfUpdatePlan, updateID string) (string, error) {
+	id, err := d.createSelfUpdateHelper(ctx, self, executable, plan, updateID)
+	if err != nil {
+		return "", err
+	}
+	if err := d.client.ContainerStart(ctx, id, types.ContainerStartOptions{}); err != nil {
+		_ = d.client.ContainerRemove(ctx, id, types.ContainerRemoveOptions{Force: true})
+		return "", fmt.Errorf("failed to start self-update helper: %w", err)
+	}
+	return id, nil
+}
+
+// SelfUpdateHelpers lists the self-update helpers on the daemon, running or
+// exited, except those of check runs
+func (d *DockerClient) SelfUpdateHelpers(ctx context.Context) ([]types.Container, error) {
+	helpers, err := d.ListContainers(ctx, types.ContainerListOptions{
+		All:     true,
+		Filters: filters.NewArgs(filters.Arg("label", SelfUpdateLabel)),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	updates := helpers[:0]
+	for _, helper := range helpers {
+		if helper.Labels[SelfUpdateLabel] != "check" {
+			updates = append(updates, helper)
+		}
+	}
+	return updates, nil
+}
+
+// AwaitSelfUpdateHelper waits for a self-update helper to exit and returns
+// its exit code and output
+func (d *DockerClient) AwaitSelfUpdateHelper(ctx context.Context, helperID string) (int64, string, error) {
+	var exitCode int64
+	statusCh, errCh := d.client.ContainerWait(ctx, helperID, container.WaitConditionNotRunning)
+	select {
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	case err := <-errCh:
+		return 0, "", fmt.Errorf("failed to wait for self-update helper: %w", err)
+	case <-ctx.Done():
+		return 0, "", ctx.Err()
+	}
+
+	stdout, stderr, err := d.helperOutput(ctx, helperID)
+	if err != nil {
+		return exitCode, "", err
+	}
+	return exitCode, stdout + stderr, nil
+}
+
+// createSelfUpdateHelper creates a helper from the image self runs, running
+// executable as the updater. It gets the environment, mounts, user and
+// network of self, so it reaches the daemon the way self does.
+func (d *DockerClient) createSelfUpdateHelper(ctx context.Context, self *types.ContainerJSON, executable string, plan *SelfUpdatePlan, label string) (string, error) {
+	if self.Config == nil || self.HostConfig == nil {
+		return "", fmt.Errorf("container %s has no configuration", self.ID)
+	}
+	encoded, err := json.Marshal(plan)
+	if err != nil {
+		return "", err
+	}
+
+	env := append(append([]string{}, self.Config.Env...), SelfUpdatePlanEnv+"="+string(encoded))
+	config := &container.Config{
+		Image:       self.Image,
+		Entrypoint:  []string{executable},
+		Cmd:         []string{SelfUpdateCommand},
+		Env:         env,
+		User:        self.Config.User,
+		WorkingDir:  self.Config.WorkingDir,
+		Labels:      map[string]string{HelperLabel: "self-update", SelfUpdateLabel: label},
+		Healthcheck: &container.HealthConfig{Test: []string{"NONE"}},
+	}
+	hostConfig := &container.HostConfig{
+		Binds:       self.HostConfig.Binds,
+		Mounts:      self.HostConfig.Mounts,
+		NetworkMode: self.HostConfig.NetworkMode,
+		GroupAdd:    self.HostConfig.GroupAdd,
+		ExtraHosts:  self.HostConfig.ExtraHosts,
+	}
+	// Sharing the network of self would end with it
+	if hostConfig.NetworkMode.IsContainer() {
+		hostConfig.NetworkMode = ""
+	}
+
+	resp, err := d.client.ContainerCreate(ctx, config, hostConfig, nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create self-update helper: %w", err)
+	}
+	return resp.ID, nil
+}
+
+// RunSelfUpdate replaces the container of plan with one created from its
+// configuration and the plan's image, which must be present. It runs in the
+// self-update helper: the old container is stopped and kept under the
+// retired name until the new one runs healthy, and brought back when it
+// does not.
+func (d *DockerClient) RunSelfUpdate(ctx context.Context, plan *SelfUpdatePlan, logf func(format string, args ...interface{})) error {
+	old, err := d.GetContainer(ctx, plan.ContainerID)
+	if err != nil {
+		return err
+	}
+	name := strings.TrimPrefix(old.Name, "/")
+
+	logf("Stopping %s", name)
+	stopTimeout := plan.StopTimeoutSeconds
+	if err := d.StopContainer(ctx, old.ID, &stopTimeout); err != nil {
+		return d.restoreSelf(ctx, old.ID, name, fmt.Errorf("failed to stop %s: %w", name, err))
+	}
+	if err := d.RenameContainer(ctx, old.ID, plan.RetiredName); err != nil {
+		return d.restoreSelf(ctx, old.ID, name, err)
+	}
+
+	logf("Creating %s from %s", name, plan.Image)
+	newID, err := d.CloneContainer(ctx, old.ID, plan.Image, name)
+	if err != nil {
+		return d.restoreSelf(ctx, old.ID, name, err)
+	}
+	if err := d.StartContainer(ctx, newID); err != nil {
+		d.removeQuietly(newID)
+		return d.restoreSelf(ctx, old.ID, name, err)
+	}
+
+	logf("Waiting for %s to run healthy", name)
+	if err := d.waitSelfUpdated(ctx, newID, time.Duration(plan.HealthTimeoutSeconds)*time.Second); err != nil {
+		if lines, logErr := d.TailLogs(ctx, newID, 20); logErr == nil {
+			for _, line := range lines {
+				logf("new container: %s", line)
+			}
+		}
+		d.removeQuietly(newID)
+		return d.restoreSelf(ctx, old.ID, name, err)
+	}
+
+	if err := d.RemoveContainer(ctx, old.ID, types.ContainerRemoveOptions{}); err != nil {
+		logf("Failed to remove the old container %s: %v", plan.RetiredName, err)
+	}
+	logf("Updated %s to %s", name, plan.Image)
+	return nil
+}
+
+// waitSelfUpdated waits until a started container reports healthy, or
+// without a health check keeps running for selfUpdateMinUptime
+func (d *DockerClient) waitSelfUpdated(ctx context.Context, containerID string, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	started := time.Now()
+	ticker := time.NewTicker(2 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("new container did not become healthy within %s", timeout)
+		case <-ticker.C:
+		}
+
+		inspected, err := d.GetContainer(ctx, containerID)
+		if err != nil {
+			continue
+		}
+		state := inspected.State
+		switch {
+		case state == nil || !state.Running:
+			exitCode := 0
+			if state != nil {
+				exitCode = state.ExitCode
+			}
+			return fmt.Errorf("new container exited with code %d", exitCode)
+		case state.Health != nil && state.Health.Status == types.Healthy:
+			return nil
+		case state.Health != nil && state.Health.Status == types.Unhealthy:
+			return fmt.Errorf("new container is unhealthy")
+		case state.Health == nil && time.Since(started) >= selfUpdateMinUptime:
+			return nil
+		}
+	}
+}
+
+// restoreSelf gives the old container its name back and starts it, and
+// returns cause with any failure to do so
+func (d *DockerClient) restoreSelf(ctx context.Context, oldID, name string, cause error) error {
+	current, err := d.GetContainer(ctx, oldID)
+	if err == nil && current.Name != "/"+name {
+		err = d.RenameContainer(ctx, oldID, name)
+	}
+	if err == nil && (current.State == nil || !current.State.Running) {
+		err = d.StartContainer(ctx, oldID)
+	}
+	if err != nil {
+		return fmt.Errorf("%w; %w: %v", cause, ErrSelfUpdateRestoreFailed, err)
+	}
+	return cause
+}
+
+func (d *DockerClient) removeQuietly(containerID string) {
+	ctx, cancel := d.WithTimeout(context.Background())
+	defer cancel()
+	_ = d.client.ContainerRemove(ctx, containerID, types.ContainerRemoveOptions{Force: true})
+}
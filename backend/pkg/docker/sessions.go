@@ -0,0 +1,395 @@
+package docker
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/sirupsen/logrus"
+
+	"docker-auto/pkg/model"
+)
+
+// SessionType is the kind of long-lived daemon connection a session holds
+type SessionType string
+
+const (
+	SessionExec   SessionType = "exec"
+	SessionLogs   SessionType = "logs"
+	SessionStats  SessionType = "stats"
+	SessionEvents SessionType = "events"
+)
+
+// defaultSessionIdleTimeout applies when no idle timeout is configured
+const defaultSessionIdleTimeout = 5 * time.Minute
+
+// ErrSessionNotFound is returned when closing a session that is not open
+var ErrSessionNotFound = errors.New("docker session not found")
+
+// SessionInfo describes an open session
+type SessionInfo struct {
+	ID           string      `json:"id"`
+	Type         SessionType `json:"type"`
+	Purpose      string      `json:"purpose"`
+	Owner        string      `json:"owner"`
+	ContainerID  string      `json:"container_id,omitempty"`
+	StartedAt    time.Time   `json:"started_at"`
+	LastActiveAt time.Time   `json:"last_active_at"`
+	// Reading is set while a caller is blocked reading or writing, which
+	// keeps a quiet but attended stream from counting as idle
+	Reading bool `json:"reading"`
+}
+
+// session is a tracked connection. It is idle when nobody has read from or
+// written to it for the idle timeout and no read or write is in progress.
+type session struct {
+	info       SessionInfo
+	registry   *SessionRegistry
+	closer     func() error
+	lastActive int64
+	pending    int32
+	closeOnce  sync.Once
+	closeErr   error
+}
+
+func (s *session) begin() {
+	atomic.AddInt32(&s.pending, 1)
+}
+
+func (s *session) end() {
+	atomic.StoreInt64(&s.lastActive, time.Now().UnixNano())
+	atomic.AddInt32(&s.pending, -1)
+}
+
+// close unregisters the session and closes its connection once
+func (s *session) close() error {
+	s.closeOnce.Do(func() {
+		s.registry.remove(s.info.ID)
+		s.closeErr = s.closer()
+	})
+	return s.closeErr
+}
+
+func (s *session) snapshot() SessionInfo {
+	info := s.info
+	info.LastActiveAt = time.Unix(0, atomic.LoadInt64(&s.lastActive))
+	info.Reading = atomic.LoadInt32(&s.pending) > 0
+	return info
+}
+
+// SessionRegistry tracks exec attachments and streaming connections to the
+// daemon so that abandoned ones are closed instead of holding connections
+// until the daemon runs out
+type SessionRegistry struct {
+	mu          sync.Mutex
+	sessions    map[string]*session
+	nextID      uint64
+	idleTimeout time.Duration
+	maxAge      time.Duration
+	reaped      int64
+	done        chan struct{}
+	stopOnce    sync.Once
+}
+
+// NewSessionRegistry creates a registry and starts its janitor. A maxAge of
+// zero lets sessions live as long as they are used.
+func NewSessionRegistry(idleTimeout, maxAge time.Duration) *SessionRegistry {
+	if idleTimeout <= 0 {
+		idleTimeout = defaultSessionIdleTimeout
+	}
+
+	r := &SessionRegistry{
+		sessions:    make(map[string]*session),
+		idleTimeout: idleTimeout,
+		maxAge:      maxAge,
+		done:        make(chan struct{}),
+	}
+	go r.janitor(janitorInterval(idleTimeout))
+	return r
+}
+
+// janitorInterval checks often enough to close a session within a quarter
+// of the idle timeout after it expires
+func janitorInterval(idleTimeout time.Duration) time.Duration {
+	interval := idleTimeout / 4
+	if interval < 100*time.Millisecond {
+		interval = 100 * time.Millisecond
+	}
+	if interval > 30*time.Second {
+		interval = 30 * time.Second
+	}
+	return interval
+}
+
+// register tracks a connection; closer releases it. The owner is the actor
+// on ctx.
+func (r *SessionRegistry) register(ctx context.Context, sessionType SessionType, containerID, purpose string, closer func() error) *session {
+	owner := model.SystemActor(model.ActorComponentSystem)
+	if ctx != nil {
+		owner = model.ActorFromContext(ctx)
+	}
+
+	now := time.Now()
+	s := &session{
+		info: SessionInfo{
+			Type:        sessionType,
+			Purpose:     purpose,
+			Owner:       owner.String(),
+			ContainerID: containerID,
+			StartedAt:   now,
+		},
+		registry:   r,
+		closer:     closer,
+		lastActive: now.UnixNano(),
+	}
+
+	r.mu.Lock()
+	r.nextID++
+	s.info.ID = fmt.Sprintf("%s-%d", sessionType, r.nextID)
+	r.sessions[s.info.ID] = s
+	r.mu.Unlock()
+
+	return s
+}
+
+func (r *SessionRegistry) remove(id string) {
+	r.mu.Lock()
+	delete(r.sessions, id)
+	r.mu.Unlock()
+}
+
+// List returns the open sessions, oldest first
+func (r *SessionRegistry) List() []SessionInfo {
+	r.mu.Lock()
+	infos := make([]SessionInfo, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		infos = append(infos, s.snapshot())
+	}
+	r.mu.Unlock()
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].StartedAt.Before(infos[j].StartedAt)
+	})
+	return infos
+}
+
+// Counts returns the number of open sessions of each type
+func (r *SessionRegistry) Counts() map[SessionType]int {
+	counts := map[SessionType]int{
+		SessionExec:   0,
+		SessionLogs:   0,
+		SessionStats:  0,
+		SessionEvents: 0,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, s := range r.sessions {
+		counts[s.info.Type]++
+	}
+	return counts
+}
+
+// Reaped returns the number of sessions the janitor has closed
+func (r *SessionRegistry) Reaped() int64 {
+	return atomic.LoadInt64(&r.reaped)
+}
+
+// IdleTimeout returns how long a session may sit unused before it is closed
+func (r *SessionRegistry) IdleTimeout() time.Duration {
+	return r.idleTimeout
+}
+
+// CloseSession force-closes an open session
+func (r *SessionRegistry) CloseSession(id string) error {
+	r.mu.Lock()
+	s, ok := r.sessions[id]
+	r.mu.Unlock()
+	if !ok {
+		return ErrSessionNotFound
+	}
+	return s.close()
+}
+
+// CloseAll closes every open session and returns how many were open
+func (r *SessionRegistry) CloseAll() int {
+	r.mu.Lock()
+	open := make([]*session, 0, len(r.sessions))
+	for _, s := range r.sessions {
+		open = append(open, s)
+	}
+	r.mu.Unlock()
+
+	for _, s := range open {
+		if err := s.close(); err != nil {
+			logrus.WithError(err).WithField("session_id", s.info.ID).Debug("Failed to close Docker session")
+		}
+	}
+	return len(open)
+}
+
+// Stop stops the janitor and closes every open session
+func (r *SessionRegistry) Stop() int {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+	return r.CloseAll()
+}
+
+func (r *SessionRegistry) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-r.done:
+			return
+		case now := <-ticker.C:
+			r.reap(now)
+		}
+	}
+}
+
+// reap closes the sessions that are idle or past the max age
+func (r *SessionRegistry) reap(now time.Time) int {
+	var expired []*session
+	r.mu.Lock()
+	for _, s := range r.sessions {
+		info := s.snapshot()
+		idle := !info.Reading && now.Sub(info.LastActiveAt) >= r.idleTimeout
+		old := r.maxAge > 0 && now.Sub(info.StartedAt) >= r.maxAge
+		if idle || old {
+			expired = append(expired, s)
+		}
+	}
+	r.mu.Unlock()
+
+	for _, s := range expired {
+		logrus.WithFields(logrus.Fields{
+			"session_id":   s.info.ID,
+			"type":         s.info.Type,
+			"purpose":      s.info.Purpose,
+			"owner":        s.info.Owner,
+			"container_id": s.info.ContainerID,
+		}).Warn("Closing abandoned Docker session")
+
+		if err := s.close(); err != nil {
+			logrus.WithError(err).WithField("session_id", s.info.ID).Debug("Failed to close Docker session")
+		}
+		atomic.AddInt64(&r.reaped, 1)
+	}
+	return len(expired)
+}
+
+// sessionReader marks the session active around each read
+type sessionReader struct {
+	r io.Reader
+	s *session
+}
+
+func (sr *sessionReader) Read(p []byte) (int, error) {
+	sr.s.begin()
+	defer sr.s.end()
+	return sr.r.Read(p)
+}
+
+// sessionReadCloser is a tracked stream; closing it ends the session
+type sessionReadCloser struct {
+	sessionReader
+}
+
+func (rc *sessionReadCloser) Close() error {
+	return rc.s.close()
+}
+
+// sessionConn is a tracked hijacked connection; closing it ends the session
+type sessionConn struct {
+	net.Conn
+	s *session
+}
+
+func (c *sessionConn) Read(p []byte) (int, error) {
+	c.s.begin()
+	defer c.s.end()
+	return c.Conn.Read(p)
+}
+
+func (c *sessionConn) Write(p []byte) (int, error) {
+	c.s.begin()
+	defer c.s.end()
+	return c.Conn.Write(p)
+}
+
+func (c *sessionConn) Close() error {
+	return c.s.close()
+}
+
+// CloseWrite half-closes the connection, as HijackedResponse.CloseWrite expects
+func (c *sessionConn) CloseWrite() error {
+	if cw, ok := c.Conn.(interface{ CloseWrite() error }); ok {
+		return cw.CloseWrite()
+	}
+	return nil
+}
+
+// ActiveSessions returns the open exec attachments and streams
+func (d *DockerClient) ActiveSessions() []SessionInfo {
+	if d.sessions == nil {
+		return []SessionInfo{}
+	}
+	return d.sessions.List()
+}
+
+// CloseSession force-closes an open exec attachment or stream
+func (d *DockerClient) CloseSession(id string) error {
+	if d.sessions == nil {
+		return ErrSessionNotFound
+	}
+	return d.sessions.CloseSession(id)
+}
+
+// SessionIdleTimeout returns how long a session may sit unused before the
+// janitor closes it
+func (d *DockerClient) SessionIdleTimeout() time.Duration {
+	if d.sessions == nil {
+		return 0
+	}
+	return d.sessions.IdleTimeout()
+}
+
+// trackStream registers a streaming response; closing the returned reader
+// ends the session
+func (d *DockerClient) trackStream(ctx context.Context, sessionType SessionType, containerID, purpose string, rc io.ReadCloser) io.ReadCloser {
+	if d.sessions == nil {
+		return rc
+	}
+	s := d.sessions.register(ctx, sessionType, containerID, purpose, rc.Close)
+	return &sessionReadCloser{sessionReader{r: rc, s: s}}
+}
+
+// trackExec registers an exec attachment; closing the response ends the
+// session
+func (d *DockerClient) trackExec(ctx context.Context, containerID string, cmd []string, resp types.HijackedResponse) types.HijackedResponse {
+	if d.sessions == nil {
+		return resp
+	}
+
+	purpose := "exec: " + strings.Join(cmd, " ")
+	if len(purpose) > 120 {
+		purpose = purpose[:117] + "..."
+	}
+
+	s := d.sessions.register(ctx, SessionExec, containerID, purpose, resp.Conn.Close)
+	resp.Reader = bufio.NewReader(&sessionReader{r: resp.Reader, s: s})
+	resp.Conn = &sessionConn{Conn: resp.Conn, s: s}
+	return resp
+}
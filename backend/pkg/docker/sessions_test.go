@@ -0,0 +1,156 @@
+package docker
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types"
+)
+
+// sessionDaemon runs exec instances that wait for input and follows logs
+// that never end, and reports each connection the client closes on closed
+type sessionDaemon struct {
+	closed chan string
+}
+
+func newSessionDaemon() *sessionDaemon {
+	return &sessionDaemon{closed: make(chan string, 4)}
+}
+
+func (d *sessionDaemon) serve(t *testing.T) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/containers/web/exec":
+			w.WriteHeader(http.StatusCreated)
+			writeJSON(t, w, map[string]string{"Id": "exec1"})
+		case r.Method == http.MethodPost && r.URL.Path == "/exec/exec1/start":
+			conn, buf, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Errorf("hijack failed: %v", err)
+				return
+			}
+			defer conn.Close()
+			buf.WriteString("HTTP/1.1 101 UPGRADED\r\nContent-Type: application/vnd.docker.raw-stream\r\nConnection: Upgrade\r\nUpgrade: tcp\r\n\r\n")
+			buf.Flush()
+			io.Copy(io.Discard, buf)
+			d.closed <- "exec"
+		case r.Method == http.MethodGet && r.URL.Path == "/containers/web/logs":
+			w.Header().Set("Content-Type", "application/vnd.docker.raw-stream")
+			w.WriteHeader(http.StatusOK)
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			d.closed <- "logs"
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func (d *sessionDaemon) waitClosed(t *testing.T, within time.Duration) string {
+	t.Helper()
+	select {
+	case kind := <-d.closed:
+		return kind
+	case <-time.After(within):
+		t.Fatalf("no connection closed within %s", within)
+		return ""
+	}
+}
+
+func newSessionTestClient(t *testing.T, daemon *sessionDaemon, idleTimeout time.Duration) *DockerClient {
+	t.Helper()
+	dc := newFakeDaemonClient(t, daemon.serve(t))
+	dc.sessions = NewSessionRegistry(idleTimeout, 0)
+	t.Cleanup(func() { dc.sessions.Stop() })
+	return dc
+}
+
+func TestJanitorClosesAbandonedExecAttachment(t *testing.T) {
+	daemon := newSessionDaemon()
+	idleTimeout := 200 * time.Millisecond
+	dc := newSessionTestClient(t, daemon, idleTimeout)
+
+	// The caller attaches, then goes away without closing the attachment
+	_, _, err := dc.StartExecSession(context.Background(), "web", []string{"sh"}, types.ExecConfig{})
+	if err != nil {
+		t.Fatalf("StartExecSession: %v", err)
+	}
+	sessions := dc.ActiveSessions()
+	if len(sessions) != 1 || sessions[0].Type != SessionExec || sessions[0].Purpose != "exec: sh" || sessions[0].ContainerID != "web" {
+		t.Fatalf("sessions = %+v, want the exec attachment", sessions)
+	}
+
+	// Closed within the idle timeout and one janitor interval, with slack
+	started := time.Now()
+	if kind := daemon.waitClosed(t, idleTimeout+janitorInterval(idleTimeout)+time.Second); kind != "exec" {
+		t.Fatalf("closed %s, want the exec attachment", kind)
+	}
+	if waited := time.Since(started); waited < idleTimeout/2 {
+		t.Errorf("closed after %s, before the session went idle", waited)
+	}
+	if sessions := dc.ActiveSessions(); len(sessions) != 0 {
+		t.Errorf("sessions = %+v, want none", sessions)
+	}
+	if reaped := dc.sessions.Reaped(); reaped != 1 {
+		t.Errorf("reaped %d sessions, want 1", reaped)
+	}
+}
+
+func TestAttendedStreamsOutliveIdleTimeout(t *testing.T) {
+	daemon := newSessionDaemon()
+	idleTimeout := 200 * time.Millisecond
+	dc := newSessionTestClient(t, daemon, idleTimeout)
+
+	// A followed log stream with a reader waiting on a quiet container
+	logs, err := dc.GetContainerLogs(context.Background(), "web", types.ContainerLogsOptions{ShowStdout: true, Follow: true})
+	if err != nil {
+		t.Fatalf("GetContainerLogs: %v", err)
+	}
+	go io.Copy(io.Discard, logs)
+
+	_, exec, err := dc.StartExecSession(context.Background(), "web", []string{"sh"}, types.ExecConfig{})
+	if err != nil {
+		t.Fatalf("StartExecSession: %v", err)
+	}
+	go io.Copy(io.Discard, exec.Reader)
+
+	time.Sleep(3 * idleTimeout)
+	if counts := dc.sessions.Counts(); counts[SessionLogs] != 1 || counts[SessionExec] != 1 {
+		t.Fatalf("open sessions = %v, want the attended logs and exec", counts)
+	}
+	if reaped := dc.sessions.Reaped(); reaped != 0 {
+		t.Errorf("reaped %d attended sessions", reaped)
+	}
+
+	// An admin force-closes the exec attachment
+	var execID string
+	for _, s := range dc.ActiveSessions() {
+		if s.Type == SessionExec {
+			execID = s.ID
+		}
+	}
+	if err := dc.CloseSession(execID); err != nil {
+		t.Fatalf("CloseSession: %v", err)
+	}
+	if kind := daemon.waitClosed(t, 2*time.Second); kind != "exec" {
+		t.Fatalf("closed %s, want the exec attachment", kind)
+	}
+	if err := dc.CloseSession(execID); err != ErrSessionNotFound {
+		t.Errorf("closing it again = %v, want %v", err, ErrSessionNotFound)
+	}
+
+	// Shutting down closes what is left
+	if closed := dc.sessions.Stop(); closed != 1 {
+		t.Errorf("Stop closed %d sessions, want 1", closed)
+	}
+	if kind := daemon.waitClosed(t, 2*time.Second); kind != "logs" {
+		t.Fatalf("closed %s, want the log stream", kind)
+	}
+	if sessions := dc.ActiveSessions(); len(sessions) != 0 {
+		t.Errorf("sessions after Stop = %+v, want none", sessions)
+	}
+}
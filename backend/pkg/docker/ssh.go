@@ -0,0 +1,160 @@
+package docker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// defaultSSHSocket is the daemon socket used when an ssh:// endpoint has no path
+const defaultSSHSocket = "/var/run/docker.sock"
+
+// sshDialer reaches a remote daemon's unix socket through one SSH
+// connection, redialed when it drops
+type sshDialer struct {
+	addr    string
+	socket  string
+	config  *ssh.ClientConfig
+	timeout time.Duration
+
+	mu   sync.Mutex
+	conn *ssh.Client
+}
+
+// newSSHDialer parses an ssh://user@host[:port][/socket] endpoint. The host
+// key must be in knownHostsFile.
+func newSSHDialer(endpoint, keyPath, knownHostsFile string, timeout time.Duration) (*sshDialer, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("invalid ssh endpoint: %w", err)
+	}
+
+	key, err := os.ReadFile(expandHome(keyPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ssh key: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ssh key: %w", err)
+	}
+
+	hostKeyCallback, err := knownhosts.New(expandHome(knownHostsFile))
+	if err != nil {
+		return nil, fmt.Errorf("failed to load ssh known hosts: %w", err)
+	}
+
+	port := u.Port()
+	if port == "" {
+		port = "22"
+	}
+	socket := u.Path
+	if socket == "" || socket == "/" {
+		socket = defaultSSHSocket
+	}
+
+	return &sshDialer{
+		addr:   net.JoinHostPort(u.Hostname(), port),
+		socket: socket,
+		config: &ssh.ClientConfig{
+			User:            u.User.Username(),
+			Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         timeout,
+		},
+		timeout: timeout,
+	}, nil
+}
+
+// DialContext opens a connection to the daemon socket; the network and
+// address the Docker client asks for are ignored
+func (d *sshDialer) DialContext(ctx context.Context, _, _ string) (net.Conn, error) {
+	conn, err := d.client(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	socketConn, err := conn.Dial("unix", d.socket)
+	if err == nil {
+		return socketConn, nil
+	}
+
+	// The SSH connection may have dropped; redial once
+	d.reset(conn)
+	if conn, err = d.client(ctx); err != nil {
+		return nil, err
+	}
+	socketConn, err = conn.Dial("unix", d.socket)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach docker socket over ssh: %w", err)
+	}
+	return socketConn, nil
+}
+
+// Close closes the SSH connection
+func (d *sshDialer) Close() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn == nil {
+		return nil
+	}
+	err := d.conn.Close()
+	d.conn = nil
+	return err
+}
+
+// client returns the SSH connection, dialing it if needed
+func (d *sshDialer) client(ctx context.Context) (*ssh.Client, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn != nil {
+		return d.conn, nil
+	}
+
+	dialer := net.Dialer{Timeout: d.timeout}
+	netConn, err := dialer.DialContext(ctx, "tcp", d.addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", d.addr, err)
+	}
+	sshConn, chans, reqs, err := ssh.NewClientConn(netConn, d.addr, d.config)
+	if err != nil {
+		netConn.Close()
+		return nil, fmt.Errorf("ssh handshake with %s failed: %w", d.addr, err)
+	}
+
+	d.conn = ssh.NewClient(sshConn, chans, reqs)
+	return d.conn, nil
+}
+
+// reset drops conn if it is still the current connection
+func (d *sshDialer) reset(conn *ssh.Client) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.conn == conn {
+		d.conn.Close()
+		d.conn = nil
+	}
+}
+
+// expandHome replaces a leading ~/ with the user's home directory
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
@@ -198,7 +198,8 @@ func (d *DockerClient) StreamContainerStats(ctx context.Context, containerID str
 			errChan <- fmt.Errorf("failed to start stats stream: %w", err)
 			return
 		}
-		defer stats.Body.Close()
+		body := d.trackStream(ctx, SessionStats, containerID, "stats stream", stats.Body)
+		defer body.Close()
 
 		// Get container info once
 		containerInfo, err := d.GetContainer(ctx, containerID)
@@ -207,7 +208,7 @@ func (d *DockerClient) StreamContainerStats(ctx context.Context, containerID str
 			return
 		}
 
-		decoder := json.NewDecoder(stats.Body)
+		decoder := json.NewDecoder(body)
 		for {
 			var statsJSON types.StatsJSON
 			if err := decoder.Decode(&statsJSON); err != nil {
@@ -86,6 +86,7 @@ type ContainerCreateConfig struct {
 	Name          string                 `json:"name"`
 	Image         string                 `json:"image"`
 	Tag           string                 `json:"tag"`
+	Digest        string                 `json:"digest,omitempty"`
 	Env           []string               `json:"env"`
 	Ports         map[string]string      `json:"ports"`
 	Volumes       []VolumeMount          `json:"volumes"`
@@ -112,6 +113,11 @@ type ContainerCreateConfig struct {
 	Tmpfs         map[string]string      `json:"tmpfs,omitempty"`
 	Ulimits       []UlimitConfig         `json:"ulimits,omitempty"`
 	LogConfig     *LogConfig             `json:"log_config,omitempty"`
+
+	// StopSignal and StopTimeout are what the daemon stops the container
+	// with when a stop names neither
+	StopSignal  string `json:"stop_signal,omitempty"`
+	StopTimeout *int   `json:"stop_timeout,omitempty"`
 }
 
 // VolumeMount represents a volume mount configuration
@@ -313,14 +319,8 @@ func (c *ContainerCreateConfig) ValidateConfig() error {
 
 // ToDockerConfig converts ContainerCreateConfig to Docker API types
 func (c *ContainerCreateConfig) ToDockerConfig() (*container.Config, *container.HostConfig, *network.NetworkingConfig, error) {
-	// Set default tag if not specified
-	tag := c.Tag
-	if tag == "" {
-		tag = "latest"
-	}
-
 	// Build full image name
-	image := c.Image + ":" + tag
+	image := c.GetFullImageName()
 
 	// Container config
 	config := &container.Config{
@@ -330,6 +330,8 @@ func (c *ContainerCreateConfig) ToDockerConfig() (*container.Config, *container.
 		WorkingDir:   c.WorkingDir,
 		User:         c.User,
 		Hostname:     c.Hostname,
+		StopSignal:   c.StopSignal,
+		StopTimeout:  c.StopTimeout,
 		AttachStdout: false,
 		AttachStderr: false,
 		AttachStdin:  false,
@@ -536,8 +538,12 @@ func (c *ContainerCreateConfig) ToDockerConfig() (*container.Config, *container.
 	return config, hostConfig, networkingConfig, nil
 }
 
-// GetFullImageName returns the full image name with tag
+// GetFullImageName returns the full image name with tag, or with the digest
+// when one is set
 func (c *ContainerCreateConfig) GetFullImageName() string {
+	if c.Digest != "" {
+		return c.Image + "@" + c.Digest
+	}
 	tag := c.Tag
 	if tag == "" {
 		tag = "latest"
@@ -672,6 +678,7 @@ type ParallelOperationResult struct {
 	Success     bool          `json:"success"`
 	Error       string        `json:"error,omitempty"`
 	Data        interface{}   `json:"data,omitempty"`
+	StartedAt   time.Time     `json:"started_at"`
 	Duration    time.Duration `json:"duration,omitempty"`
 }
 
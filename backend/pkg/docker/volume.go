@@ -0,0 +1,378 @@
+package docker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/mount"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/errdefs"
+	"github.com/docker/docker/pkg/stdcopy"
+)
+
+// HelperLabel marks short-lived helper containers started by docker-auto
+const HelperLabel = "docker-auto.helper"
+
+// VolumeLastUsedLabel may hold the RFC 3339 time a volume was last used;
+// volume cleanup measures retention from it instead of the creation time
+const VolumeLastUsedLabel = "docker-auto.last-used"
+
+// helperMountPath is where helper containers see the measured path
+const helperMountPath = "/data"
+
+// ErrHelperTimeout is returned when a helper container does not finish in time
+var ErrHelperTimeout = errors.New("helper container timed out")
+
+// FilesystemUsage describes the space of a filesystem
+type FilesystemUsage struct {
+	Path           string `json:"path"`
+	TotalBytes     int64  `json:"total_bytes"`
+	UsedBytes      int64  `json:"used_bytes"`
+	AvailableBytes int64  `json:"available_bytes"`
+}
+
+// FreePercent returns the available space as a percentage of the total
+func (u *FilesystemUsage) FreePercent() float64 {
+	if u.TotalBytes <= 0 {
+		return 0
+	}
+	return float64(u.AvailableBytes) / float64(u.TotalBytes) * 100
+}
+
+// DiskUsageSummary is the space used by each kind of Docker object, as
+// reported by the daemon's system df
+type DiskUsageSummary struct {
+	Images          int   `json:"images"`
+	ImagesBytes     int64 `json:"images_bytes"`
+	Containers      int   `json:"containers"`
+	ContainersBytes int64 `json:"containers_bytes"`
+	Volumes         int   `json:"volumes"`
+	VolumesBytes    int64 `json:"volumes_bytes"`
+	BuildCacheBytes int64 `json:"build_cache_bytes"`
+	TotalBytes      int64 `json:"total_bytes"`
+}
+
+// GetDiskUsage summarizes the daemon's disk usage. Volumes whose driver does
+// not report a size are counted but add no bytes.
+func (d *DockerClient) GetDiskUsage(ctx context.Context) (*DiskUsageSummary, error) {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = d.WithTimeout(context.Background())
+		defer cancel()
+	}
+
+	usage, err := d.client.DiskUsage(ctx, types.DiskUsageOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get disk usage: %w", err)
+	}
+
+	summary := &DiskUsageSummary{
+		Images:      len(usage.Images),
+		ImagesBytes: usage.LayersSize,
+		Containers:  len(usage.Containers),
+		Volumes:     len(usage.Volumes),
+	}
+	for _, c := range usage.Containers {
+		summary.ContainersBytes += c.SizeRw
+	}
+	for _, v := range usage.Volumes {
+		if v.UsageData != nil && v.UsageData.Size > 0 {
+			summary.VolumesBytes += v.UsageData.Size
+		}
+	}
+	for _, cache := range usage.BuildCache {
+		summary.BuildCacheBytes += cache.Size
+	}
+	summary.TotalBytes = summary.ImagesBytes + summary.ContainersBytes + summary.VolumesBytes + summary.BuildCacheBytes
+
+	return summary, nil
+}
+
+// ListVolumes lists Docker volumes with optional filters
+func (d *DockerClient) ListVolumes(ctx context.Context, options volume.ListOptions) ([]*volume.Volume, error) {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = d.WithTimeout(context.Background())
+		defer cancel()
+	}
+
+	resp, err := d.client.VolumeList(ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list volumes: %w", err)
+	}
+
+	return resp.Volumes, nil
+}
+
+// RemoveVolume removes a Docker volume. The daemon refuses to remove a
+// volume in use by a container unless force is set.
+func (d *DockerClient) RemoveVolume(ctx context.Context, volumeName string, force bool) error {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = d.WithTimeout(context.Background())
+		defer cancel()
+	}
+
+	if volumeName == "" {
+		return fmt.Errorf("volume name cannot be empty")
+	}
+
+	if err := d.client.VolumeRemove(ctx, volumeName, force); err != nil {
+		return fmt.Errorf("failed to remove volume %s: %w", volumeName, err)
+	}
+
+	return nil
+}
+
+// GetVolumeUsage lists volumes with the usage data reported by the daemon.
+// UsageData.Size is -1 for volumes whose driver does not report sizes.
+func (d *DockerClient) GetVolumeUsage(ctx context.Context) ([]*volume.Volume, error) {
+	if ctx == nil {
+		var cancel context.CancelFunc
+		ctx, cancel = d.WithTimeout(context.Background())
+		defer cancel()
+	}
+
+	usage, err := d.client.DiskUsage(ctx, types.DiskUsageOptions{
+		Types: []types.DiskUsageObject{types.VolumeObject},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get volume usage: %w", err)
+	}
+
+	return usage.Volumes, nil
+}
+
+// MeasureVolumeSize measures a volume by running du in a helper container
+// with the volume mounted read-only. Runs longer than timeout are stopped
+// and return ErrHelperTimeout.
+func (d *DockerClient) MeasureVolumeSize(ctx context.Context, volumeName, helperImage string, timeout time.Duration) (int64, error) {
+	if volumeName == "" {
+		return 0, fmt.Errorf("volume name cannot be empty")
+	}
+
+	stdout, err := d.runHelper(ctx, helperImage, timeout, "volume-size",
+		mount.Mount{Type: mount.TypeVolume, Source: volumeName, Target: helperMountPath, ReadOnly: true},
+		[]string{"du", "-sk", helperMountPath})
+	if err != nil && stdout == "" {
+		return 0, err
+	}
+
+	// du exits non-zero on unreadable entries but still prints the total
+	fields := strings.Fields(stdout)
+	if len(fields) == 0 {
+		return 0, fmt.Errorf("unexpected du output for volume %s: %q", volumeName, stdout)
+	}
+	kilobytes, parseErr := strconv.ParseInt(fields[0], 10, 64)
+	if parseErr != nil {
+		return 0, fmt.Errorf("unexpected du output for volume %s: %q", volumeName, stdout)
+	}
+
+	return kilobytes * 1024, nil
+}
+
+// ExportVolume writes a tar archive of a volume's contents to w and returns
+// the number of bytes written. The volume is mounted read-only in a helper
+// container that is never started; the archive is streamed out of it.
+func (d *DockerClient) ExportVolume(ctx context.Context, volumeName, helperImage string, w io.Writer) (int64, error) {
+	if volumeName == "" {
+		return 0, fmt.Errorf("volume name cannot be empty")
+	}
+	if helperImage == "" {
+		return 0, fmt.Errorf("helper image cannot be empty")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := d.ensureHelperImage(ctx, helperImage); err != nil {
+		return 0, err
+	}
+
+	resp, err := d.client.ContainerCreate(ctx,
+		&container.Config{
+			Image:           helperImage,
+			Labels:          map[string]string{HelperLabel: "volume-export"},
+			NetworkDisabled: true,
+		},
+		&container.HostConfig{
+			Mounts: []mount.Mount{{Type: mount.TypeVolume, Source: volumeName, Target: helperMountPath, ReadOnly: true}},
+		},
+		nil, nil, "")
+	if err != nil {
+		return 0, fmt.Errorf("failed to create helper container: %w", err)
+	}
+	defer func() {
+		removeCtx, cancel := d.WithTimeout(context.Background())
+		defer cancel()
+		_ = d.client.ContainerRemove(removeCtx, resp.ID, types.ContainerRemoveOptions{Force: true})
+	}()
+
+	reader, _, err := d.client.CopyFromContainer(ctx, resp.ID, helperMountPath+"/.")
+	if err != nil {
+		return 0, fmt.Errorf("failed to read volume %s: %w", volumeName, err)
+	}
+	defer reader.Close()
+
+	written, err := io.Copy(w, reader)
+	if err != nil {
+		return written, fmt.Errorf("failed to export volume %s: %w", volumeName, err)
+	}
+	return written, nil
+}
+
+// GetDataRootUsage measures the filesystem holding the Docker data root by
+// running df in a helper container, so it also works against remote daemons
+func (d *DockerClient) GetDataRootUsage(ctx context.Context, helperImage string) (*FilesystemUsage, error) {
+	info, err := d.GetInfo(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if info.DockerRootDir == "" {
+		return nil, fmt.Errorf("docker data root not reported by the daemon")
+	}
+
+	stdout, err := d.runHelper(ctx, helperImage, d.timeout, "data-root-usage",
+		mount.Mount{Type: mount.TypeBind, Source: info.DockerRootDir, Target: helperMountPath, ReadOnly: true},
+		[]string{"df", "-Pk", helperMountPath})
+	if err != nil {
+		return nil, err
+	}
+
+	// Filesystem 1024-blocks Used Available Capacity Mounted-on
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	fields := strings.Fields(lines[len(lines)-1])
+	if len(lines) < 2 || len(fields) < 4 {
+		return nil, fmt.Errorf("unexpected df output: %q", stdout)
+	}
+
+	usage := &FilesystemUsage{Path: info.DockerRootDir}
+	for i, target := range []*int64{&usage.TotalBytes, &usage.UsedBytes, &usage.AvailableBytes} {
+		kilobytes, err := strconv.ParseInt(fields[i+1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected df output: %q", stdout)
+		}
+		*target = kilobytes * 1024
+	}
+
+	return usage, nil
+}
+
+// ensureHelperImage pulls the helper image unless it is already present
+func (d *DockerClient) ensureHelperImage(ctx context.Context, helperImage string) error {
+	if _, _, err := d.client.ImageInspectWithRaw(ctx, helperImage); err != nil {
+		if !errdefs.IsNotFound(err) {
+			return fmt.Errorf("failed to inspect helper image %s: %w", helperImage, err)
+		}
+		if err := d.PullImageAndWait(ctx, helperImage, types.ImagePullOptions{}); err != nil {
+			return fmt.Errorf("failed to pull helper image %s: %w", helperImage, err)
+		}
+	}
+	return nil
+}
+
+// runHelper runs cmd in a throwaway container with m mounted and returns its
+// stdout. The helper image is pulled when missing, and the container is
+// removed afterwards whatever the outcome.
+func (d *DockerClient) runHelper(ctx context.Context, helperImage string, timeout time.Duration, purpose string, m mount.Mount, cmd []string) (string, error) {
+	if helperImage == "" {
+		return "", fmt.Errorf("helper image cannot be empty")
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	if err := d.ensureHelperImage(ctx, helperImage); err != nil {
+		return "", err
+	}
+
+	resp, err := d.client.ContainerCreate(ctx,
+		&container.Config{
+			Image:           helperImage,
+			Cmd:             cmd,
+			Labels:          map[string]string{HelperLabel: purpose},
+			NetworkDisabled: true,
+		},
+		&container.HostConfig{
+			Mounts:     []mount.Mount{m},
+			AutoRemove: false,
+		},
+		nil, nil, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to create helper container: %w", err)
+	}
+	defer func() {
+		removeCtx, cancel := d.WithTimeout(context.Background())
+		defer cancel()
+		_ = d.client.ContainerRemove(removeCtx, resp.ID, types.ContainerRemoveOptions{Force: true})
+	}()
+
+	exitCode, stdout, stderr, err := d.waitHelper(ctx, resp.ID, timeout)
+	if err != nil {
+		return "", err
+	}
+
+	if exitCode != 0 {
+		return stdout, fmt.Errorf("helper command %s exited with code %d: %s",
+			cmd[0], exitCode, strings.TrimSpace(stderr))
+	}
+
+	return stdout, nil
+}
+
+// waitHelper starts a created helper container, waits up to timeout for it
+// to exit and returns its exit code and output
+func (d *DockerClient) waitHelper(ctx context.Context, containerID string, timeout time.Duration) (int64, string, string, error) {
+	runCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if err := d.client.ContainerStart(runCtx, containerID, types.ContainerStartOptions{}); err != nil {
+		return 0, "", "", fmt.Errorf("failed to start helper container: %w", err)
+	}
+
+	var exitCode int64
+	statusCh, errCh := d.client.ContainerWait(runCtx, containerID, container.WaitConditionNotRunning)
+	select {
+	case status := <-statusCh:
+		exitCode = status.StatusCode
+	case err := <-errCh:
+		if runCtx.Err() == context.DeadlineExceeded {
+			return 0, "", "", ErrHelperTimeout
+		}
+		return 0, "", "", fmt.Errorf("failed to wait for helper container: %w", err)
+	case <-runCtx.Done():
+		if runCtx.Err() == context.DeadlineExceeded {
+			return 0, "", "", ErrHelperTimeout
+		}
+		return 0, "", "", runCtx.Err()
+	}
+
+	stdout, stderr, err := d.helperOutput(ctx, containerID)
+	if err != nil {
+		return 0, "", "", err
+	}
+	return exitCode, stdout, stderr, nil
+}
+
+// helperOutput returns the stdout and stderr of an exited helper container
+func (d *DockerClient) helperOutput(ctx context.Context, containerID string) (string, string, error) {
+	logs, err := d.client.ContainerLogs(ctx, containerID, types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to read helper container output: %w", err)
+	}
+	defer logs.Close()
+
+	var stdout, stderr strings.Builder
+	if _, err := stdcopy.StdCopy(&stdout, &stderr, logs); err != nil {
+		return "", "", fmt.Errorf("failed to read helper container output: %w", err)
+	}
+
+	return stdout.String(), stderr.String(), nil
+}
@@ -0,0 +1,121 @@
+package docker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
+	"github.com/docker/docker/client"
+)
+
+// newFakeDaemonClient returns a client talking to handler in place of the
+// Docker daemon. Request paths reach handler without the API version prefix.
+func newFakeDaemonClient(t *testing.T, handler http.HandlerFunc) *DockerClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if i := strings.Index(r.URL.Path[1:], "/"); strings.HasPrefix(r.URL.Path, "/v") && i > 0 {
+			r.URL.Path = r.URL.Path[i+1:]
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")),
+		client.WithVersion("1.44"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+
+	return &DockerClient{client: cli, timeout: 5 * time.Second}
+}
+
+func writeJSON(t *testing.T, w http.ResponseWriter, value interface{}) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		t.Errorf("failed to encode response: %v", err)
+	}
+}
+
+func TestListVolumesPassesFilters(t *testing.T) {
+	var gotFilters string
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet || r.URL.Path != "/volumes" {
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+		}
+		gotFilters = r.URL.Query().Get("filters")
+		writeJSON(t, w, volume.ListResponse{Volumes: []*volume.Volume{
+			{Name: "data", Driver: "local"},
+			{Name: "cache", Driver: "local"},
+		}})
+	})
+
+	volumes, err := dc.ListVolumes(context.Background(), volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("dangling", "true")),
+	})
+	if err != nil {
+		t.Fatalf("ListVolumes: %v", err)
+	}
+	if len(volumes) != 2 || volumes[0].Name != "data" || volumes[1].Name != "cache" {
+		t.Fatalf("volumes = %+v, want data and cache", volumes)
+	}
+
+	args, err := filters.FromJSON(gotFilters)
+	if err != nil {
+		t.Fatalf("invalid filters %q: %v", gotFilters, err)
+	}
+	if !args.ExactMatch("dangling", "true") {
+		t.Fatalf("filters = %q, want dangling=true", gotFilters)
+	}
+}
+
+func TestRemoveVolume(t *testing.T) {
+	var gotPath, gotForce string
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			t.Errorf("method = %s, want DELETE", r.Method)
+		}
+		gotPath = r.URL.Path
+		gotForce = r.URL.Query().Get("force")
+		w.WriteHeader(http.StatusNoContent)
+	})
+
+	if err := dc.RemoveVolume(context.Background(), "data", false); err != nil {
+		t.Fatalf("RemoveVolume: %v", err)
+	}
+	if gotPath != "/volumes/data" || gotForce == "1" || gotForce == "true" {
+		t.Fatalf("request = %s force=%q, want /volumes/data without force", gotPath, gotForce)
+	}
+}
+
+func TestRemoveVolumeInUse(t *testing.T) {
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+		writeJSON(t, w, map[string]string{"message": "remove data: volume is in use"})
+	})
+
+	err := dc.RemoveVolume(context.Background(), "data", false)
+	if err == nil || !strings.Contains(err.Error(), "volume is in use") {
+		t.Fatalf("err = %v, want the daemon's in use error", err)
+	}
+}
+
+func TestRemoveVolumeRequiresName(t *testing.T) {
+	dc := newFakeDaemonClient(t, func(w http.ResponseWriter, r *http.Request) {
+		t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+	})
+
+	if err := dc.RemoveVolume(context.Background(), "", false); err == nil {
+		t.Fatal("RemoveVolume with an empty name succeeded")
+	}
+}
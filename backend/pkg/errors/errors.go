@@ -0,0 +1,214 @@
+// Package errors defines the error codes reported to API clients and an error
+// type carrying them, so that services classify their failures and handlers
+// map them to HTTP statuses without matching messages.
+package errors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Code identifies a class of failure to API clients
+type Code string
+
+// Generic codes, also reported for responses sent without a specific one
+const (
+	CodeInvalidRequest   Code = "INVALID_REQUEST"
+	CodeUnauthorized     Code = "UNAUTHORIZED"
+	CodePermissionDenied Code = "PERMISSION_DENIED"
+	CodeNotFound         Code = "NOT_FOUND"
+	CodeConflict         Code = "CONFLICT"
+	CodeValidationFailed Code = "VALIDATION_FAILED"
+	CodeRateLimited      Code = "RATE_LIMITED"
+	CodeNotImplemented   Code = "NOT_IMPLEMENTED"
+	CodeUnavailable      Code = "SERVICE_UNAVAILABLE"
+	CodeInternal         Code = "INTERNAL_ERROR"
+)
+
+// Containers and Docker hosts
+const (
+	CodeContainerNotFound Code = "CONTAINER_NOT_FOUND"
+	CodeContainerExists   Code = "CONTAINER_EXISTS"
+	// CodeContainerNotCreated is reported for operations on a managed
+	// container that has no Docker container yet
+	CodeContainerNotCreated Code = "CONTAINER_NOT_CREATED"
+	CodeDockerUnavailable   Code = "DOCKER_UNAVAILABLE"
+	CodeShuttingDown        Code = "SHUTTING_DOWN"
+	CodeExecDisabled        Code = "EXEC_DISABLED"
+)
+
+// Images and registries
+const (
+	CodeImageNotFound          Code = "IMAGE_NOT_FOUND"
+	CodeImagePullFailed        Code = "IMAGE_PULL_FAILED"
+	CodeRegistryError          Code = "REGISTRY_ERROR"
+	CodeRegistryAccessDenied   Code = "REGISTRY_ACCESS_DENIED"
+	CodeRegistryRateLimited    Code = "REGISTRY_RATE_LIMITED"
+	CodeTagListingUnsupported  Code = "TAG_LISTING_UNSUPPORTED"
+	CodeScanResultsUnavailable Code = "SCAN_RESULTS_UNAVAILABLE"
+)
+
+// Scheduled tasks
+const (
+	CodeTaskNotFound        Code = "TASK_NOT_FOUND"
+	CodeTaskExists          Code = "TASK_EXISTS"
+	CodeExecutionNotFound   Code = "EXECUTION_NOT_FOUND"
+	CodeExecutionNotRunning Code = "EXECUTION_NOT_RUNNING"
+	CodeSchedulerNotRunning Code = "SCHEDULER_NOT_RUNNING"
+	CodeSchedulerRunning    Code = "SCHEDULER_RUNNING"
+)
+
+// Configuration
+const (
+	// CodeRestartRequired is reported for changes to settings that are only
+	// read at startup
+	CodeRestartRequired Code = "RESTART_REQUIRED"
+)
+
+// statuses maps each code to the HTTP status it is reported with
+var statuses = map[Code]int{
+	CodeInvalidRequest:   http.StatusBadRequest,
+	CodeUnauthorized:     http.StatusUnauthorized,
+	CodePermissionDenied: http.StatusForbidden,
+	CodeNotFound:         http.StatusNotFound,
+	CodeConflict:         http.StatusConflict,
+	CodeValidationFailed: http.StatusUnprocessableEntity,
+	CodeRateLimited:      http.StatusTooManyRequests,
+	CodeNotImplemented:   http.StatusNotImplemented,
+	CodeUnavailable:      http.StatusServiceUnavailable,
+	CodeInternal:         http.StatusInternalServerError,
+
+	CodeContainerNotFound:   http.StatusNotFound,
+	CodeContainerExists:     http.StatusConflict,
+	CodeContainerNotCreated: http.StatusConflict,
+	CodeDockerUnavailable:   http.StatusServiceUnavailable,
+	CodeShuttingDown:        http.StatusServiceUnavailable,
+	CodeExecDisabled:        http.StatusForbidden,
+
+	CodeImageNotFound:          http.StatusNotFound,
+	CodeImagePullFailed:        http.StatusBadGateway,
+	CodeRegistryError:          http.StatusBadGateway,
+	CodeRegistryAccessDenied:   http.StatusForbidden,
+	CodeRegistryRateLimited:    http.StatusTooManyRequests,
+	CodeTagListingUnsupported:  http.StatusUnprocessableEntity,
+	CodeScanResultsUnavailable: http.StatusServiceUnavailable,
+
+	CodeTaskNotFound:        http.StatusNotFound,
+	CodeTaskExists:          http.StatusConflict,
+	CodeExecutionNotFound:   http.StatusNotFound,
+	CodeExecutionNotRunning: http.StatusConflict,
+	CodeSchedulerNotRunning: http.StatusServiceUnavailable,
+	CodeSchedulerRunning:    http.StatusConflict,
+
+	CodeRestartRequired: http.StatusUnprocessableEntity,
+}
+
+// HTTPStatus returns the HTTP status a code is reported with, 500 for codes
+// it does not know
+func HTTPStatus(code Code) int {
+	if status, ok := statuses[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// CodeForStatus returns the generic code of an HTTP error status, for
+// responses sent without a specific code
+func CodeForStatus(status int) Code {
+	switch status {
+	case http.StatusBadRequest:
+		return CodeInvalidRequest
+	case http.StatusUnauthorized:
+		return CodeUnauthorized
+	case http.StatusForbidden:
+		return CodePermissionDenied
+	case http.StatusNotFound:
+		return CodeNotFound
+	case http.StatusConflict:
+		return CodeConflict
+	case http.StatusUnprocessableEntity:
+		return CodeValidationFailed
+	case http.StatusTooManyRequests:
+		return CodeRateLimited
+	case http.StatusNotImplemented:
+		return CodeNotImplemented
+	case http.StatusServiceUnavailable:
+		return CodeUnavailable
+	}
+	if status >= http.StatusBadRequest && status < http.StatusInternalServerError {
+		return CodeInvalidRequest
+	}
+	return CodeInternal
+}
+
+// Error is an error with the code it is reported with. Its message is
+// returned to API clients as is.
+type Error struct {
+	Code    Code
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Unwrap returns the error this one was made from
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New returns an error with the code and message
+func New(code Code, message string) *Error {
+	return &Error{Code: code, Message: message}
+}
+
+// Newf returns an error with the code and a message formatted as by
+// fmt.Errorf; an error formatted with %w is wrapped
+func Newf(code Code, format string, args ...interface{}) *Error {
+	err := fmt.Errorf(format, args...)
+	return &Error{Code: code, Message: err.Error(), Err: errors.Unwrap(err)}
+}
+
+// Wrap returns err with the code and its message prefixed by message, or nil
+// when err is nil
+func Wrap(err error, code Code, message string) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Code: code, Message: message + ": " + err.Error(), Err: err}
+}
+
+// As returns the outermost Error in err's chain, nil if there is none
+func As(err error) *Error {
+	var coded *Error
+	if errors.As(err, &coded) {
+		return coded
+	}
+	return nil
+}
+
+// CodeOf returns the code of the outermost Error in err's chain, empty if
+// there is none
+func CodeOf(err error) Code {
+	if coded := As(err); coded != nil {
+		return coded.Code
+	}
+	return ""
+}
+
+// HasCode reports whether err's chain has an Error with the code
+func HasCode(err error, code Code) bool {
+	for err != nil {
+		var coded *Error
+		if !errors.As(err, &coded) {
+			return false
+		}
+		if coded.Code == code {
+			return true
+		}
+		err = coded.Err
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package events
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// Actions of the container state stream
+const (
+	ContainerActionStart           = "start"
+	ContainerActionStop            = "stop"
+	ContainerActionDie             = "die"
+	ContainerActionHealthStatus    = "health_status"
+	ContainerActionUpdateStarted   = "update_started"
+	ContainerActionUpdateProgress  = "update_progress"
+	ContainerActionUpdateCompleted = "update_completed"
+)
+
+// containerActionTypes are the event types container state changes are
+// published as
+var containerActionTypes = map[string]EventType{
+	ContainerActionStart:           EventContainerStarted,
+	ContainerActionStop:            EventContainerStopped,
+	ContainerActionDie:             EventContainerDied,
+	ContainerActionHealthStatus:    EventContainerHealth,
+	ContainerActionUpdateStarted:   EventImageUpdateStarted,
+	ContainerActionUpdateProgress:  EventContainerUpdated,
+	ContainerActionUpdateCompleted: EventImageUpdateCompleted,
+}
+
+// ContainerState is a state change of a managed container, carried in the
+// "state" data of its event
+type ContainerState struct {
+	Action      string `json:"type"`
+	ContainerID int64  `json:"container_id"`
+	Name        string `json:"name"`
+	// Status is the container status after the change, or for
+	// update_completed the outcome of the update
+	Status   string    `json:"status,omitempty"`
+	Health   string    `json:"health,omitempty"`
+	UpdateID int       `json:"update_id,omitempty"`
+	Step     string    `json:"step,omitempty"` // the step an update_progress event reports
+	Error    string    `json:"error,omitempty"`
+	Time     time.Time `json:"time"`
+	// Pull is the image pull progress an update_progress event in the
+	// pulling_image step reports
+	Pull *model.PullProgress `json:"pull,omitempty"`
+}
+
+// NewContainerStateEvent creates the event for a container state change.
+// The event belongs to the container's owner, when it has one.
+func NewContainerStateEvent(state *ContainerState, ownerID *int) *Event {
+	if state.Time.IsZero() {
+		state.Time = time.Now()
+	}
+
+	severity := SeverityInfo
+	switch {
+	case state.Error != "" || state.Action == ContainerActionDie || state.Health == "unhealthy":
+		severity = SeverityWarning
+	case state.Action == ContainerActionUpdateCompleted:
+		severity = SeveritySuccess
+	}
+
+	event := NewEvent(containerActionTypes[state.Action], severity, "container",
+		fmt.Sprintf("Container %s: %s", state.Name, state.Action), containerStateMessage(state)).
+		WithResource("container", strconv.FormatInt(state.ContainerID, 10)).
+		WithData("state", state)
+	event.Timestamp = state.Time
+	if ownerID != nil {
+		event.WithUserID(strconv.Itoa(*ownerID))
+	}
+	return event
+}
+
+// ContainerStateFilter matches container state events; with userID only
+// those of the user's containers
+func ContainerStateFilter(userID *string) EventFilter {
+	resourceType := "container"
+	filter := EventFilter{
+		Sources:      []string{"container"},
+		ResourceType: &resourceType,
+		UserID:       userID,
+	}
+	for _, eventType := range containerActionTypes {
+		filter.Types = append(filter.Types, eventType)
+	}
+	return filter
+}
+
+// ContainerStateOf returns the container state an event carries
+func ContainerStateOf(event *Event) (*ContainerState, bool) {
+	if event == nil || event.Data == nil {
+		return nil, false
+	}
+	state, ok := event.Data["state"].(*ContainerState)
+	return state, ok
+}
+
+func containerStateMessage(state *ContainerState) string {
+	switch {
+	case state.Error != "":
+		return state.Error
+	case state.Pull != nil:
+		return fmt.Sprintf("Pulling %s: %.1f%%", state.Pull.Image, state.Pull.Percent)
+	case state.Step != "":
+		return "Update step: " + state.Step
+	case state.Health != "":
+		return "Health is " + state.Health
+	case state.Status != "":
+		return "Status is " + state.Status
+	}
+	return ""
+}
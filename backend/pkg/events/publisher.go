@@ -171,8 +171,11 @@ func (p *EventPublisher) Unsubscribe(subscriptionID string) error {
 		return ErrSubscriptionNotFound
 	}
 
-	subscription.Active = false
-	close(subscription.Channel)
+	// Close already closed the channels of active subscriptions
+	if subscription.Active {
+		subscription.Active = false
+		close(subscription.Channel)
+	}
 	delete(p.subscribers, subscriptionID)
 
 	p.logger.WithField("subscription_id", subscriptionID).Debug("Subscription removed")
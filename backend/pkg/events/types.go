@@ -19,6 +19,8 @@ const (
 	EventContainerCreated   EventType = "container.created"
 	EventContainerDeleted   EventType = "container.deleted"
 	EventContainerRestarted EventType = "container.restarted"
+	EventContainerDied      EventType = "container.died"
+	EventContainerHealth    EventType = "container.health_status"
 
 	// Image update events
 	EventImageUpdateAvailable EventType = "image.update_available"
@@ -49,6 +51,7 @@ const (
 	EventTaskFailed     EventType = "task.failed"
 	EventTaskScheduled  EventType = "task.scheduled"
 	EventTaskCancelled  EventType = "task.cancelled"
+	EventSchedulerLog   EventType = "scheduler.event"
 
 	// Notification events
 	EventNotificationCreated EventType = "notification.created"
@@ -65,6 +65,18 @@ func (ha *HealthAPI) GetReadiness(c *gin.Context) {
 		"checks":    len(aggregate.Checks),
 	}
 
+	// Checks that report details, such as the event watcher's connection and
+	// counters, are listed with them
+	details := make(map[string]interface{})
+	for name, result := range aggregate.Checks {
+		if len(result.Details) > 0 {
+			details[name] = gin.H{"status": result.Status, "details": result.Details}
+		}
+	}
+	if len(details) > 0 {
+		status["details"] = details
+	}
+
 	// Add details about failed checks if not ready
 	if !ready {
 		var failedChecks []string
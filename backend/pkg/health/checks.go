@@ -12,6 +12,8 @@ import (
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+
+	"docker-auto/pkg/docker"
 )
 
 // DatabaseHealthCheck implements health check for database
@@ -183,6 +185,75 @@ func (dhc *DockerHealthCheck) Check(ctx context.Context) HealthResult {
 	return result
 }
 
+// EventWatcherHealthCheck reports whether the container event watcher is
+// connected. A disconnected watcher only degrades the service: statuses lag
+// until it reconnects and replays the gap, or until the next full sync.
+type EventWatcherHealthCheck struct {
+	config       EventWatcherHealthConfig
+	dockerClient *docker.DockerClient
+}
+
+// NewEventWatcherHealthCheck creates a new event watcher health check
+func NewEventWatcherHealthCheck(config EventWatcherHealthConfig, dockerClient *docker.DockerClient) *EventWatcherHealthCheck {
+	return &EventWatcherHealthCheck{
+		config:       config,
+		dockerClient: dockerClient,
+	}
+}
+
+func (ewc *EventWatcherHealthCheck) Name() string {
+	return ewc.config.Name
+}
+
+func (ewc *EventWatcherHealthCheck) Dependencies() []string {
+	return []string{}
+}
+
+func (ewc *EventWatcherHealthCheck) Config() HealthCheckConfig {
+	return ewc.config.HealthCheckConfig
+}
+
+func (ewc *EventWatcherHealthCheck) Check(ctx context.Context) HealthResult {
+	start := time.Now()
+	result := HealthResult{
+		Status:    HealthStatusHealthy,
+		Timestamp: start,
+		Details:   make(map[string]interface{}),
+	}
+
+	var stats *docker.EventWatcherStats
+	if ewc.dockerClient != nil {
+		stats = ewc.dockerClient.EventWatcherStats()
+	}
+	if stats == nil {
+		result.Status = HealthStatusUnknown
+		result.Message = "Event watcher not running"
+		result.Duration = time.Since(start)
+		return result
+	}
+
+	result.Details["connected"] = stats.Connected
+	result.Details["disconnected_since"] = stats.DisconnectedSince
+	result.Details["last_event_at"] = stats.LastEventAt
+	result.Details["events_processed"] = stats.EventsProcessed
+	result.Details["duplicates"] = stats.Duplicates
+	result.Details["coalesced"] = stats.Coalesced
+	result.Details["reconnects"] = stats.Reconnects
+	result.Details["queue_depth"] = stats.QueueDepth
+
+	result.Message = "Event watcher is connected"
+	if !stats.Connected {
+		result.Message = "Event watcher is reconnecting"
+		if down := start.Sub(*stats.DisconnectedSince); down >= ewc.config.MaxDisconnected {
+			result.Status = HealthStatusDegraded
+			result.Message = fmt.Sprintf("Event watcher disconnected for %s", down.Round(time.Second))
+		}
+	}
+
+	result.Duration = time.Since(start)
+	return result
+}
+
 // HTTPHealthCheck implements health check for HTTP endpoints
 type HTTPHealthCheck struct {
 	config     HTTPHealthConfig
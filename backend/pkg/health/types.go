@@ -86,6 +86,14 @@ type DockerHealthConfig struct {
 	CheckNetworks   bool         `json:"check_networks"`
 }
 
+// EventWatcherHealthConfig represents Docker event watcher health check
+// configuration. The watcher is degraded once it has been disconnected for
+// MaxDisconnected.
+type EventWatcherHealthConfig struct {
+	HealthCheckConfig
+	MaxDisconnected time.Duration `json:"max_disconnected"`
+}
+
 // RegistryHealthConfig represents container registry health check configuration
 type RegistryHealthConfig struct {
 	HealthCheckConfig
@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "docker_auto"
+
+var (
+	containersManaged = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "containers_managed",
+		Help:      "Number of containers managed by docker-auto.",
+	})
+
+	containersByStatus = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "containers_by_status",
+		Help:      "Number of managed containers in each status.",
+	}, []string{"status"})
+
+	containerUpdates = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "container_updates_total",
+		Help:      "Container image updates by result (completed, failed, rollback).",
+	}, []string{"result"})
+
+	schedulerTaskRuns = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_task_runs_total",
+		Help:      "Scheduled task executions by task type and status.",
+	}, []string{"task_type", "status"})
+
+	schedulerTaskFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "scheduler_task_failures_total",
+		Help:      "Scheduled task executions that failed or timed out, by task type.",
+	}, []string{"task_type"})
+
+	schedulerRunningTasks = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "scheduler_running_tasks",
+		Help:      "Number of scheduled tasks currently executing.",
+	})
+
+	dockerSecurityEvents = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "docker_security_events_total",
+		Help:      "Docker security operations by event (operation, blocked, container_created, container_blocked, violation).",
+	}, []string{"event"})
+
+	rateLimitBlocked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_blocked_requests_total",
+		Help:      "Requests rejected by the rate limiter, by the limit that rejected them.",
+	}, []string{"reason"})
+)
+
+// Handler serves the registered metrics in the Prometheus exposition format
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
+
+// SetContainerCounts replaces the container gauges with the given number of
+// containers in each status
+func SetContainerCounts(counts map[string]int64) {
+	var total int64
+	containersByStatus.Reset()
+	for status, count := range counts {
+		containersByStatus.WithLabelValues(status).Set(float64(count))
+		total += count
+	}
+	containersManaged.Set(float64(total))
+}
+
+// RecordContainerUpdate counts a finished container image update
+func RecordContainerUpdate(result string) {
+	containerUpdates.WithLabelValues(result).Inc()
+}
+
+// TaskStarted tracks a scheduled task execution that has begun
+func TaskStarted() {
+	schedulerRunningTasks.Inc()
+}
+
+// TaskFinished counts a finished scheduled task execution
+func TaskFinished(taskType, status string, failed bool) {
+	schedulerRunningTasks.Dec()
+	schedulerTaskRuns.WithLabelValues(taskType, status).Inc()
+	if failed {
+		schedulerTaskFailures.WithLabelValues(taskType).Inc()
+	}
+}
+
+// RecordSecurityEvent counts a Docker security event
+func RecordSecurityEvent(event string) {
+	dockerSecurityEvents.WithLabelValues(event).Inc()
+}
+
+// RecordRateLimitBlock counts a request rejected by the rate limiter
+func RecordRateLimitBlock(reason string) {
+	rateLimitBlocked.WithLabelValues(reason).Inc()
+}
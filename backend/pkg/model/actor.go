@@ -0,0 +1,130 @@
+package model
+
+import (
+	"context"
+	"fmt"
+)
+
+// ActorType identifies what kind of principal performed an operation
+type ActorType string
+
+const (
+	ActorTypeUser     ActorType = "user"
+	ActorTypeAPIToken ActorType = "api_token"
+	ActorTypeSystem   ActorType = "system"
+)
+
+// System component names recorded as the actor of background operations
+const (
+	ActorComponentSystem        = "system"
+	ActorComponentScheduler     = "scheduler"
+	ActorComponentUpdateChecker = "update-checker"
+	ActorComponentUpdater       = "container-updater"
+	ActorComponentCleanup       = "cleanup"
+	ActorComponentBackup        = "backup"
+	ActorComponentHealthChecker = "health-checker"
+	ActorComponentChangeFeed    = "change-feed"
+	ActorComponentVolumeUsage   = "volume-usage"
+	ActorComponentStatusSync    = "status-sync"
+	ActorComponentPosture       = "security-posture"
+	ActorComponentGitOps        = "gitops"
+	ActorComponentDigest        = "notification-digest"
+	ActorComponentImageService  = "image-service"
+	ActorComponentWebhook       = "registry-webhook"
+	ActorComponentApproval      = "approval-policy"
+	ActorComponentDiscovery     = "container-discovery"
+)
+
+// taskActorComponents maps scheduled task types to the component they run as
+var taskActorComponents = map[TaskType]string{
+	TaskTypeImageCheck:         ActorComponentUpdateChecker,
+	TaskTypeContainerUpdate:    ActorComponentUpdater,
+	TaskTypeCleanup:            ActorComponentCleanup,
+	TaskTypeBackup:             ActorComponentBackup,
+	TaskTypeHealthCheck:        ActorComponentHealthChecker,
+	TaskTypeChangeFeed:         ActorComponentChangeFeed,
+	TaskTypeVolumeUsage:        ActorComponentVolumeUsage,
+	TaskTypeStatusSync:         ActorComponentStatusSync,
+	TaskTypeSecurityPosture:    ActorComponentPosture,
+	TaskTypeGitOps:             ActorComponentGitOps,
+	TaskTypeNotificationDigest: ActorComponentDigest,
+	TaskTypeContainerDiscovery: ActorComponentDiscovery,
+}
+
+// Actor is the principal an operation is performed on behalf of: a user, an
+// API token or a system component. Only users and the personal access tokens
+// acting for them carry a user ID.
+type Actor struct {
+	Type   ActorType `json:"type"`
+	UserID *int64    `json:"user_id,omitempty"`
+	Name   string    `json:"name"`
+}
+
+// UserActor returns the actor for a signed in user
+func UserActor(userID int64, username string) Actor {
+	return Actor{Type: ActorTypeUser, UserID: &userID, Name: username}
+}
+
+// APITokenActor returns the actor for a request authenticated by an API token
+func APITokenActor(name string) Actor {
+	return Actor{Type: ActorTypeAPIToken, Name: name}
+}
+
+// SystemActor returns the actor for a background component such as
+// "health-checker"
+func SystemActor(component string) Actor {
+	return Actor{Type: ActorTypeSystem, Name: component}
+}
+
+// TaskActor returns the system actor a scheduled task of the type runs as
+func TaskActor(taskType TaskType) Actor {
+	if component, ok := taskActorComponents[taskType]; ok {
+		return SystemActor(component)
+	}
+	return SystemActor(ActorComponentScheduler)
+}
+
+// IsSystem reports whether the actor is a system component
+func (a Actor) IsSystem() bool {
+	return a.Type == ActorTypeSystem
+}
+
+// IsUser reports whether the actor is the user with the given ID
+func (a Actor) IsUser(userID int64) bool {
+	return a.UserID != nil && *a.UserID == userID
+}
+
+// OwnerID returns the user ID to record as the creator of a resource, nil for
+// actors that are not users
+func (a Actor) OwnerID() *int {
+	if a.UserID == nil {
+		return nil
+	}
+	id := int(*a.UserID)
+	return &id
+}
+
+// String returns the actor as type:identifier, e.g. "user:42" or
+// "system:health-checker"
+func (a Actor) String() string {
+	if a.UserID != nil {
+		return fmt.Sprintf("%s:%d", a.Type, *a.UserID)
+	}
+	return fmt.Sprintf("%s:%s", a.Type, a.Name)
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor operations are attributed to
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor recorded on the context, or the generic
+// system actor when there is none
+func ActorFromContext(ctx context.Context) Actor {
+	if actor, ok := ctx.Value(actorKey{}).(Actor); ok {
+		return actor
+	}
+	return SystemActor(ActorComponentSystem)
+}
@@ -0,0 +1,119 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// TokenScope is an operation a personal access token may be used for
+type TokenScope string
+
+const (
+	// TokenScopeContainersRead reads containers, stacks and their updates
+	TokenScopeContainersRead TokenScope = "containers:read"
+	// TokenScopeContainersControl starts, stops and restarts containers
+	TokenScopeContainersControl TokenScope = "containers:control"
+	// TokenScopeContainersUpdate updates the images of containers
+	TokenScopeContainersUpdate TokenScope = "containers:update"
+	// TokenScopeTasksTrigger runs scheduled tasks on demand
+	TokenScopeTasksTrigger TokenScope = "tasks:trigger"
+)
+
+// GetValidTokenScopes returns every scope a token can be granted
+func GetValidTokenScopes() []TokenScope {
+	return []TokenScope{
+		TokenScopeContainersRead,
+		TokenScopeContainersControl,
+		TokenScopeContainersUpdate,
+		TokenScopeTasksTrigger,
+	}
+}
+
+// IsValidTokenScope reports whether scope can be granted to a token
+func IsValidTokenScope(scope string) bool {
+	for _, valid := range GetValidTokenScopes() {
+		if scope == string(valid) {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	// APITokenPrefix starts every personal access token, so leaked tokens
+	// are easy to recognize
+	APITokenPrefix = "dat_"
+
+	maxAPITokenNameLength = 100
+)
+
+// APIToken is a personal access token. It authenticates automation as the
+// user who created it, with the user's role, but only for routes covered by
+// its scopes. Only the SHA-256 hash of the token is stored; Prefix keeps its
+// first characters so the user can tell tokens apart.
+type APIToken struct {
+	ID         int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID     int64      `json:"user_id" gorm:"not null;index:idx_api_tokens_user_id"`
+	Name       string     `json:"name" gorm:"size:100;not null"`
+	TokenHash  string     `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	Prefix     string     `json:"prefix" gorm:"size:16;not null"`
+	Scopes     StringList `json:"scopes" gorm:"type:jsonb;default:'[]'"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+
+	// Relationships
+	User *User `json:"-" gorm:"foreignKey:UserID"`
+}
+
+// TableName returns the table name for APIToken model
+func (APIToken) TableName() string {
+	return "api_tokens"
+}
+
+// Validate checks the token's name and scopes
+func (t *APIToken) Validate() error {
+	if strings.TrimSpace(t.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(t.Name) > maxAPITokenNameLength {
+		return fmt.Errorf("name cannot be longer than %d characters", maxAPITokenNameLength)
+	}
+	if len(t.Scopes) == 0 {
+		return fmt.Errorf("at least one scope is required")
+	}
+	for _, scope := range t.Scopes {
+		if !IsValidTokenScope(scope) {
+			return fmt.Errorf("unknown scope %q", scope)
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether the token was granted scope
+func (t *APIToken) HasScope(scope TokenScope) bool {
+	for _, granted := range t.Scopes {
+		if granted == string(scope) {
+			return true
+		}
+	}
+	return false
+}
+
+// IsUsable reports whether the token is neither revoked nor expired at now
+func (t *APIToken) IsUsable(now time.Time) bool {
+	if t.RevokedAt != nil {
+		return false
+	}
+	return t.ExpiresAt == nil || now.Before(*t.ExpiresAt)
+}
+
+// Actor returns the actor for a request authenticated by the token, which
+// acts for its user
+func (t *APIToken) Actor(username string) Actor {
+	userID := t.UserID
+	return Actor{Type: ActorTypeAPIToken, UserID: &userID, Name: username + "/" + t.Name}
+}
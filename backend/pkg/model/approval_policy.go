@@ -0,0 +1,225 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// ApprovalAction is what an approval policy does with the pending updates it
+// matches
+type ApprovalAction string
+
+const (
+	ApprovalActionApprove ApprovalAction = "auto_approve"
+	ApprovalActionReject  ApprovalAction = "auto_reject"
+)
+
+// Scan diff verdicts compare the scan of the proposed digest with the scan of
+// the running one
+const (
+	ScanDiffImproved   = "improved"
+	ScanDiffUnchanged  = "unchanged"
+	ScanDiffRegressed  = "regressed"
+	ScanDiffNotScanned = "not_scanned"
+)
+
+// Update types of a pending update, from the semver delta of the tags or a
+// new digest behind the same tag
+var approvalUpdateTypes = map[string]bool{
+	"patch":  true,
+	"minor":  true,
+	"major":  true,
+	"digest": true,
+}
+
+var approvalScanDiffs = map[string]bool{
+	ScanDiffImproved:   true,
+	ScanDiffUnchanged:  true,
+	ScanDiffRegressed:  true,
+	ScanDiffNotScanned: true,
+}
+
+const maxApprovalPolicyNameLength = 100
+
+// ApprovalPolicy decides pending updates without a human. Enabled policies
+// are evaluated by position and the first one whose conditions all match
+// decides. Version advances on every change so that audited decisions can be
+// traced to the rules that made them.
+type ApprovalPolicy struct {
+	ID         int                `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name       string             `json:"name" gorm:"size:100;not null;uniqueIndex"`
+	Position   int                `json:"position" gorm:"not null;default:0;index"`
+	Enabled    bool               `json:"enabled" gorm:"not null;default:true"`
+	Action     ApprovalAction     `json:"action" gorm:"size:20;not null"`
+	Conditions ApprovalConditions `json:"conditions" gorm:"type:jsonb;default:'{}'"`
+	Version    int                `json:"version" gorm:"not null;default:1"`
+	CreatedBy  *int               `json:"created_by,omitempty"`
+	CreatedAt  time.Time          `json:"created_at"`
+	UpdatedAt  time.Time          `json:"updated_at"`
+}
+
+// TableName returns the table name for ApprovalPolicy model
+func (ApprovalPolicy) TableName() string {
+	return "approval_policies"
+}
+
+// ApprovalConditions are the conditions of a policy. An empty condition
+// matches every update.
+type ApprovalConditions struct {
+	// UpdateTypes are patch, minor, major or digest
+	UpdateTypes []string `json:"update_types,omitempty"`
+	// ScanDiffs are scan diff verdicts such as "improved"
+	ScanDiffs []string `json:"scan_diffs,omitempty"`
+	// ImagePatterns are path.Match patterns on the image repository, e.g.
+	// "library/*"
+	ImagePatterns []string `json:"image_patterns,omitempty"`
+	// Labels must all be set on the container; a value of "*" matches any
+	// value
+	Labels map[string]string `json:"labels,omitempty"`
+	// MinAgeHours is how long ago the proposed version must have been first
+	// seen; updates of unknown age do not match
+	MinAgeHours int `json:"min_age_hours,omitempty"`
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (c ApprovalConditions) Value() (driver.Value, error) {
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (c *ApprovalConditions) Scan(value interface{}) error {
+	return scanJSON(value, c, "ApprovalConditions")
+}
+
+// ApprovalCandidate is a pending update as the policies see it
+type ApprovalCandidate struct {
+	UpdateType string            `json:"update_type"`
+	ScanDiff   string            `json:"scan_diff"`
+	Repository string            `json:"repository"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	ReleasedAt *time.Time        `json:"released_at,omitempty"`
+}
+
+// Matches reports whether every condition of the policy matches the candidate
+func (p *ApprovalPolicy) Matches(candidate *ApprovalCandidate, now time.Time) bool {
+	c := p.Conditions
+
+	if len(c.UpdateTypes) > 0 && !containsString(c.UpdateTypes, candidate.UpdateType) {
+		return false
+	}
+	if len(c.ScanDiffs) > 0 && !containsString(c.ScanDiffs, candidate.ScanDiff) {
+		return false
+	}
+
+	if len(c.ImagePatterns) > 0 {
+		matched := false
+		for _, pattern := range c.ImagePatterns {
+			if ok, _ := path.Match(pattern, candidate.Repository); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	for key, want := range c.Labels {
+		got, ok := candidate.Labels[key]
+		if !ok || (want != "*" && got != want) {
+			return false
+		}
+	}
+
+	if c.MinAgeHours > 0 {
+		if candidate.ReleasedAt == nil || now.Sub(*candidate.ReleasedAt) < time.Duration(c.MinAgeHours)*time.Hour {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Approver is how the policy is recorded as the approver of an update
+func (p *ApprovalPolicy) Approver() string {
+	return fmt.Sprintf("policy:%s@v%d", p.Name, p.Version)
+}
+
+// Validate validates the policy's name, action and conditions
+func (p *ApprovalPolicy) Validate() error {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(p.Name) > maxApprovalPolicyNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxApprovalPolicyNameLength)
+	}
+
+	switch p.Action {
+	case ApprovalActionApprove, ApprovalActionReject:
+	default:
+		return fmt.Errorf("action must be %q or %q", ApprovalActionApprove, ApprovalActionReject)
+	}
+
+	c := p.Conditions
+	for _, updateType := range c.UpdateTypes {
+		if !approvalUpdateTypes[updateType] {
+			return fmt.Errorf("unknown update type %q", updateType)
+		}
+	}
+	for _, verdict := range c.ScanDiffs {
+		if !approvalScanDiffs[verdict] {
+			return fmt.Errorf("unknown scan diff verdict %q", verdict)
+		}
+	}
+	for _, pattern := range c.ImagePatterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid image pattern %q", pattern)
+		}
+	}
+	for key := range c.Labels {
+		if strings.TrimSpace(key) == "" {
+			return fmt.Errorf("label keys must not be empty")
+		}
+	}
+	if c.MinAgeHours < 0 {
+		return fmt.Errorf("min_age_hours must not be negative")
+	}
+
+	return nil
+}
+
+// ScanDiffVerdict compares the scan of the proposed digest with the scan of
+// the running digest. An update is a regression when it fails a scan the
+// running image passed or adds critical or high findings, and an improvement
+// when it removes some without adding any.
+func ScanDiffVerdict(running, proposed *ScanResult) string {
+	if running == nil || proposed == nil {
+		return ScanDiffNotScanned
+	}
+
+	if running.Passed && !proposed.Passed ||
+		proposed.CriticalVulns > running.CriticalVulns ||
+		proposed.HighVulns > running.HighVulns {
+		return ScanDiffRegressed
+	}
+	if !running.Passed && proposed.Passed ||
+		proposed.CriticalVulns < running.CriticalVulns ||
+		proposed.HighVulns < running.HighVulns {
+		return ScanDiffImproved
+	}
+	return ScanDiffUnchanged
+}
+
+func containsString(list []string, value string) bool {
+	for _, item := range list {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"regexp"
+	"time"
+)
+
+// Files and directories of a backup written by the backup task, relative to
+// the backup directory
+const (
+	BackupManifestFile        = "manifest.json"
+	BackupDatabaseDumpFile    = "database.sql.gz"   // PostgreSQL plain pg_dump
+	BackupDatabaseExportFile  = "database.jsonl.gz" // rows of each table as JSON lines
+	BackupConfigurationsDir   = "configurations"
+	BackupContainerConfigsDir = "container_configs"
+	BackupVolumesDir          = "volumes"
+)
+
+// BackupIDLayout is the time layout following "backup-" in a backup ID
+const BackupIDLayout = "20060102-150405"
+
+// backupIDPattern matches the directory names the backup task creates
+var backupIDPattern = regexp.MustCompile(`^backup-\d{8}-\d{6}$`)
+
+// IsBackupID reports whether id names a backup directory. Anything else,
+// including paths, is rejected.
+func IsBackupID(id string) bool {
+	return backupIDPattern.MatchString(id)
+}
+
+// BackupConfigurationFiles are the files, relative to the working
+// directory, saved under BackupConfigurationsDir
+var BackupConfigurationFiles = []string{
+	"config.yaml",
+	"docker-compose.yml",
+	".env",
+}
+
+// BackupManifest lists every file of a backup with its SHA-256
+type BackupManifest struct {
+	Version    string                 `json:"version"`
+	CreatedAt  time.Time              `json:"created_at"`
+	BackupType string                 `json:"backup_type"`
+	TotalSize  int64                  `json:"total_size"`
+	FileCount  int                    `json:"file_count"`
+	Checksums  map[string]string      `json:"checksums"`
+	Metadata   map[string]interface{} `json:"metadata"`
+}
+
+// BackupContainerConfig is a container as saved under
+// BackupContainerConfigsDir, one file per container
+type BackupContainerConfig struct {
+	ID           int          `json:"id"`
+	Name         string       `json:"name"`
+	Image        string       `json:"image"`
+	Tag          string       `json:"tag"`
+	ConfigJSON   string       `json:"config_json"`
+	UpdatePolicy UpdatePolicy `json:"update_policy"`
+	RegistryURL  string       `json:"registry_url"`
+	CreatedAt    time.Time    `json:"created_at"`
+}
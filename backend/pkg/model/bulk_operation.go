@@ -0,0 +1,133 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// BulkOperationType identifies what a bulk operation does
+type BulkOperationType string
+
+const (
+	BulkOperationImageRetarget BulkOperationType = "image_retarget"
+)
+
+// BulkOperationStatus is the progress of a bulk operation
+type BulkOperationStatus string
+
+const (
+	BulkOperationStatusRunning   BulkOperationStatus = "running"
+	BulkOperationStatusCompleted BulkOperationStatus = "completed"
+	// BulkOperationStatusPartial means at least one container failed
+	BulkOperationStatusPartial BulkOperationStatus = "partial"
+	BulkOperationStatusFailed  BulkOperationStatus = "failed"
+)
+
+// BulkOutcome is what a bulk operation did to one container
+type BulkOutcome string
+
+const (
+	BulkOutcomeUpdated BulkOutcome = "updated"
+	// BulkOutcomePending means the change is recorded and the update is left
+	// to the updater, within the container's windows and policy
+	BulkOutcomePending BulkOutcome = "pending"
+	BulkOutcomeSkipped BulkOutcome = "skipped"
+	BulkOutcomeFailed  BulkOutcome = "failed"
+)
+
+// BulkOperation groups the per-container work of one bulk request so its
+// progress can be followed and its outcome reviewed afterwards
+type BulkOperation struct {
+	ID         int                 `json:"id" gorm:"primaryKey;autoIncrement"`
+	Type       BulkOperationType   `json:"type" gorm:"not null;size:50;index:idx_bulk_operations_type"`
+	Status     BulkOperationStatus `json:"status" gorm:"not null;size:20;default:'running';index:idx_bulk_operations_status"`
+	Summary    string              `json:"summary" gorm:"size:500"`
+	Parameters string              `json:"parameters" gorm:"type:jsonb;default:'{}'"`
+
+	Total   int                  `json:"total" gorm:"not null;default:0"`
+	Updated int                  `json:"updated" gorm:"not null;default:0"`
+	Pending int                  `json:"pending" gorm:"not null;default:0"`
+	Skipped int                  `json:"skipped" gorm:"not null;default:0"`
+	Failed  int                  `json:"failed" gorm:"not null;default:0"`
+	Results BulkOperationResults `json:"results" gorm:"type:jsonb;default:'[]'"`
+
+	CreatedBy   *int       `json:"created_by,omitempty" gorm:"index:idx_bulk_operations_created_by"`
+	ActorType   ActorType  `json:"actor_type" gorm:"not null;size:20;default:'system'"`
+	ActorName   string     `json:"actor_name,omitempty" gorm:"size:100"`
+	StartedAt   time.Time  `json:"started_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// BulkOperationResult is the outcome for one container of a bulk operation
+type BulkOperationResult struct {
+	ContainerID   int         `json:"container_id"`
+	ContainerName string      `json:"container_name"`
+	Outcome       BulkOutcome `json:"outcome"`
+	UpdateID      *int        `json:"update_id,omitempty"`
+	Message       string      `json:"message,omitempty"`
+}
+
+// BulkOperationResults is the JSON list of per-container outcomes
+type BulkOperationResults []BulkOperationResult
+
+// Value implements the driver.Valuer interface for database storage
+func (r BulkOperationResults) Value() (driver.Value, error) {
+	if r == nil {
+		return "[]", nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (r *BulkOperationResults) Scan(value interface{}) error {
+	return scanJSON(value, r, "BulkOperationResults")
+}
+
+// TableName returns the table name for BulkOperation model
+func (BulkOperation) TableName() string {
+	return "bulk_operations"
+}
+
+// SetActor attributes the operation to the actor
+func (o *BulkOperation) SetActor(actor Actor) {
+	o.CreatedBy = actor.OwnerID()
+	o.ActorType = actor.Type
+	o.ActorName = actor.Name
+}
+
+// Record adds a container's outcome and updates the counters
+func (o *BulkOperation) Record(result BulkOperationResult) {
+	o.Results = append(o.Results, result)
+	switch result.Outcome {
+	case BulkOutcomeUpdated:
+		o.Updated++
+	case BulkOutcomePending:
+		o.Pending++
+	case BulkOutcomeSkipped:
+		o.Skipped++
+	case BulkOutcomeFailed:
+		o.Failed++
+	}
+}
+
+// Finish sets the final status from the recorded outcomes
+func (o *BulkOperation) Finish() {
+	now := time.Now()
+	o.CompletedAt = &now
+
+	switch {
+	case o.Failed == 0:
+		o.Status = BulkOperationStatusCompleted
+	case o.Failed == len(o.Results):
+		o.Status = BulkOperationStatusFailed
+	default:
+		o.Status = BulkOperationStatusPartial
+	}
+}
+
+// IsCompleted reports whether the operation has finished
+func (o *BulkOperation) IsCompleted() bool {
+	return o.Status != BulkOperationStatusRunning
+}
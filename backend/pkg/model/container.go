@@ -0,0 +1,426 @@
+package model
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Container represents a Docker container managed by the system
+type Container struct {
+	ID            int             `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name          string          `json:"name" gorm:"uniqueIndex;not null;size:255;index:idx_containers_name"`
+	Image         string          `json:"image" gorm:"not null;size:255;index:idx_containers_image"`
+	Tag           string          `json:"tag" gorm:"not null;size:100;default:'latest'"`
+	ContainerID   string          `json:"container_id,omitempty" gorm:"uniqueIndex:idx_containers_container_id;size:64"`
+	Status        ContainerStatus `json:"status" gorm:"not null;default:'stopped';index:idx_containers_status"`
+	ConfigJSON    string          `json:"config_json" gorm:"type:jsonb;not null;default:'{}'"`
+	UpdatePolicy  UpdatePolicy    `json:"update_policy" gorm:"not null;default:'inherit';index:idx_containers_update_policy"`
+	VersionPolicy VersionPolicy   `json:"version_policy" gorm:"size:20;not null;default:'latest'"`
+	RegistryURL   string          `json:"registry_url,omitempty" gorm:"size:255"`
+	RegistryAuth  string          `json:"registry_auth,omitempty" gorm:"type:jsonb"`
+	HealthCheck   string          `json:"health_check,omitempty" gorm:"type:jsonb"`
+	Labels        string          `json:"labels" gorm:"type:jsonb;default:'{}'"`
+	Environment   string          `json:"environment" gorm:"type:jsonb;default:'{}'"`
+	Ports         string          `json:"ports" gorm:"type:jsonb;default:'[]'"`
+	Volumes       string          `json:"volumes" gorm:"type:jsonb;default:'[]'"`
+	RestartPolicy string          `json:"restart_policy" gorm:"size:20;default:'unless-stopped'"`
+
+	// Stopping sends StopSignal, the image's stop signal when empty, and kills
+	// the container after StopTimeoutSeconds, DefaultStopTimeoutSeconds when
+	// unset
+	StopTimeoutSeconds *int   `json:"stop_timeout_seconds,omitempty"`
+	StopSignal         string `json:"stop_signal,omitempty" gorm:"size:20"`
+
+	// Policy overrides; unset values are inherited from the image policy or global defaults
+	CheckIntervalMinutes   *int   `json:"check_interval_minutes,omitempty"`
+	HoldDownHours          *int   `json:"hold_down_hours,omitempty"`
+	MaintenanceWindows     string `json:"maintenance_windows,omitempty" gorm:"type:jsonb"`
+	VulnerabilityThreshold string `json:"vulnerability_threshold,omitempty" gorm:"size:20"`
+
+	// Digest pinning; a pinned container is deployed by ImageDigest and its tag is
+	// informational. PendingDigest is what the tag resolved to at the last check.
+	PinByDigest   bool   `json:"pin_by_digest" gorm:"not null;default:false"`
+	ImageDigest   string `json:"image_digest,omitempty" gorm:"size:100"`
+	PendingDigest string `json:"pending_digest,omitempty" gorm:"size:100"`
+
+	// DeployedDigest is the registry digest of the image the Docker container
+	// was created with, recorded by the first status sync after the
+	// application created it; RunningDigest is the one the sync last found.
+	// They differ once the image was replaced outside the application, such
+	// as by a manual pull and recreate.
+	DeployedDigest string `json:"deployed_digest,omitempty" gorm:"size:100"`
+	RunningDigest  string `json:"running_digest,omitempty" gorm:"size:100"`
+
+	// UpdateCheckedAt is when the update checker last queried the registry
+	// for the container; the least recently checked go first
+	UpdateCheckedAt *time.Time `json:"update_checked_at,omitempty" gorm:"index:idx_containers_update_checked_at"`
+
+	// UpdateDeferredUntil is when the maintenance window next opens for a
+	// container the updater skipped; deferred containers are updated first
+	UpdateDeferredUntil *time.Time `json:"update_deferred_until,omitempty"`
+
+	// Remediation actions the health checker runs, in order, while the
+	// container is unhealthy
+	HealthActions HealthActionList `json:"health_actions,omitempty" gorm:"type:jsonb;default:'[]'"`
+
+	// Post-start sequence: once the container runs (and is healthy, if it has
+	// a health check), wait WarmupSeconds, then run PostStartHooks in order.
+	// Dependent containers are started only after it completes.
+	WarmupSeconds  int               `json:"warmup_seconds" gorm:"not null;default:0"`
+	PostStartHooks PostStartHookList `json:"post_start_hooks,omitempty" gorm:"type:jsonb;default:'[]'"`
+	LastPostStart  *PostStartRun     `json:"last_post_start,omitempty" gorm:"type:jsonb"`
+
+	// Regular expressions redacted from the container's logs in addition to
+	// the built-in secret patterns
+	LogRedactPatterns StringList `json:"log_redact_patterns,omitempty" gorm:"type:jsonb;default:'[]'"`
+
+	// Names of environment variables holding secrets; drift reports compare
+	// their values by hash and never show them
+	SecretEnv StringList `json:"secret_env,omitempty" gorm:"type:jsonb;default:'[]'"`
+
+	// Drifted is set by the status sync when the live container no longer
+	// matches the stored configuration
+	Drifted        bool       `json:"drifted" gorm:"not null;default:false;index:idx_containers_drifted"`
+	DriftCheckedAt *time.Time `json:"drift_checked_at,omitempty"`
+
+	// CrashLooping is set by the status sync while the container keeps
+	// restarting and cleared once it has run stably. CrashLoopHold keeps
+	// automatic updates off until the crash loop is acknowledged.
+	CrashLooping        bool       `json:"crash_looping" gorm:"not null;default:false;index:idx_containers_crash_looping"`
+	CrashLoopDetectedAt *time.Time `json:"crash_loop_detected_at,omitempty"`
+	CrashLoopHold       bool       `json:"crash_loop_hold" gorm:"not null;default:false"`
+
+	// UpdateFailures counts the automatic updates that failed in a row and
+	// is reset by any successful update. Reaching the updater's failure limit
+	// sets UpdateSuspended, which keeps automatic updates off until an
+	// operator resumes them or the image digest moves on from
+	// UpdateSuspendedDigest, the one the failed updates targeted.
+	UpdateFailures        int        `json:"update_failures" gorm:"not null;default:0"`
+	LastUpdateError       string     `json:"last_update_error,omitempty" gorm:"type:text"`
+	LastUpdateFailedAt    *time.Time `json:"last_update_failed_at,omitempty"`
+	UpdateSuspended       bool       `json:"update_suspended" gorm:"not null;default:false;index:idx_containers_update_suspended"`
+	UpdateSuspendedDigest string     `json:"update_suspended_digest,omitempty" gorm:"size:100"`
+
+	// LastSyncedAt is when the status sync last inspected the container
+	LastSyncedAt *time.Time `json:"last_synced_at,omitempty"`
+
+	// Stack membership; members start in ascending StackOrder
+	StackID    *int `json:"stack_id,omitempty" gorm:"index:idx_containers_stack_id"`
+	StackOrder int  `json:"stack_order" gorm:"not null;default:0"`
+
+	// TeamID is the team whose quota the container counts against
+	TeamID *int `json:"team_id,omitempty" gorm:"index:idx_containers_team_id"`
+
+	// HostID is the Docker host the container runs on; nil is the local daemon
+	HostID *int `json:"host_id,omitempty" gorm:"index:idx_containers_host_id"`
+
+	// Adopted containers were registered by the container discovery task from
+	// their labels. Unmanaged is set once the labels are removed; the record
+	// and its history are kept but the container is no longer updated.
+	Adopted   bool `json:"adopted" gorm:"not null;default:false;index:idx_containers_adopted"`
+	Unmanaged bool `json:"unmanaged" gorm:"not null;default:false"`
+
+	// Warnings the Docker daemon returned the last time the container was
+	// created or its resources were changed, minus ignored ones
+	Warnings   StringList `json:"warnings,omitempty" gorm:"type:jsonb;default:'[]'"`
+	WarningsAt *time.Time `json:"warnings_at,omitempty"`
+
+	CreatedBy     *int            `json:"created_by,omitempty" gorm:"index:idx_containers_created_by"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+
+	// Relationships
+	CreatedByUser   *User           `json:"created_by_user,omitempty" gorm:"foreignKey:CreatedBy"`
+	UpdateHistories []UpdateHistory `json:"update_histories,omitempty" gorm:"foreignKey:ContainerID"`
+}
+
+// HasPostStart reports whether starting the container is followed by a
+// warmup delay or hooks
+func (c *Container) HasPostStart() bool {
+	return c.WarmupSeconds > 0 || len(c.PostStartHooks) > 0
+}
+
+// ContainerStatus defines container status
+type ContainerStatus string
+
+const (
+	ContainerStatusRunning    ContainerStatus = "running"
+	ContainerStatusStopped    ContainerStatus = "stopped"
+	ContainerStatusPaused     ContainerStatus = "paused"
+	ContainerStatusRestarting ContainerStatus = "restarting"
+	ContainerStatusRemoving   ContainerStatus = "removing"
+	ContainerStatusExited     ContainerStatus = "exited"
+	ContainerStatusDead       ContainerStatus = "dead"
+	ContainerStatusUnknown    ContainerStatus = "unknown"
+)
+
+// UpdatePolicy defines update policies
+type UpdatePolicy string
+
+const (
+	UpdatePolicyAuto      UpdatePolicy = "auto"
+	UpdatePolicyManual    UpdatePolicy = "manual"
+	UpdatePolicyScheduled UpdatePolicy = "scheduled"
+	UpdatePolicyDisabled  UpdatePolicy = "disabled"
+
+	// UpdatePolicyInherit defers to the image policy or global default
+	UpdatePolicyInherit UpdatePolicy = "inherit"
+)
+
+// RegistryCredentials represents registry authentication
+type RegistryCredentials struct {
+	ID              int                    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name            string                 `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	RegistryURL     string                 `json:"registry_url" gorm:"not null;size:255;index:idx_registry_credentials_registry_url"`
+	Username        string                 `json:"username,omitempty" gorm:"size:100"`
+	PasswordEncrypted string               `json:"-" gorm:"type:text"`
+	TokenEncrypted  string                 `json:"-" gorm:"type:text"`
+	AuthType        RegistryAuthType       `json:"auth_type" gorm:"not null;default:'basic'"`
+	IsDefault       bool                   `json:"is_default" gorm:"not null;default:false;index:idx_registry_credentials_is_default"`
+	IsActive        bool                   `json:"is_active" gorm:"not null;default:true;index:idx_registry_credentials_is_active"`
+	Metadata        string                 `json:"metadata,omitempty" gorm:"type:jsonb;default:'{}'"`
+	CreatedBy       *int                   `json:"created_by,omitempty"`
+	CreatedAt       time.Time              `json:"created_at"`
+	UpdatedAt       time.Time              `json:"updated_at"`
+
+	// Relationships
+	CreatedByUser *User `json:"-" gorm:"foreignKey:CreatedBy"`
+}
+
+// RegistryAuthType defines registry authentication types
+type RegistryAuthType string
+
+const (
+	RegistryAuthTypeBasic  RegistryAuthType = "basic"
+	RegistryAuthTypeToken  RegistryAuthType = "token"
+	RegistryAuthTypeOAuth  RegistryAuthType = "oauth"
+)
+
+
+// ContainerFilter represents filters for querying containers
+type ContainerFilter struct {
+	CreatedBy    *int            `json:"created_by,omitempty"`
+	// AccessibleBy restricts the containers to those the user created or
+	// holds a permission on, directly or through AccessibleRole
+	AccessibleBy   *int          `json:"accessible_by,omitempty"`
+	AccessibleRole UserRole      `json:"accessible_role,omitempty"`
+	Name         string          `json:"name,omitempty"`
+	Image        string          `json:"image,omitempty"`
+	Status       ContainerStatus `json:"status,omitempty"`
+	UpdatePolicy UpdatePolicy    `json:"update_policy,omitempty"`
+	StackID      *int            `json:"stack_id,omitempty"`
+	HostID       *int            `json:"host_id,omitempty"`
+	Drifted      *bool           `json:"drifted,omitempty"`
+	Limit        int             `json:"limit,omitempty"`
+	Offset       int             `json:"offset,omitempty"`
+	OrderBy      string          `json:"order_by,omitempty"`
+}
+
+// RegistryCredentialsFilter represents filters for querying registry credentials
+type RegistryCredentialsFilter struct {
+	RegistryURL string           `json:"registry_url,omitempty"`
+	AuthType    RegistryAuthType `json:"auth_type,omitempty"`
+	IsDefault   *bool            `json:"is_default,omitempty"`
+	IsActive    *bool            `json:"is_active,omitempty"`
+	CreatedBy   *int             `json:"created_by,omitempty"`
+	Limit       int              `json:"limit,omitempty"`
+	Offset      int              `json:"offset,omitempty"`
+	OrderBy     string           `json:"order_by,omitempty"`
+}
+
+// TableName returns the table name for Container model
+func (Container) TableName() string {
+	return "containers"
+}
+
+// TableName returns the table name for RegistryCredentials model
+func (RegistryCredentials) TableName() string {
+	return "registry_credentials"
+}
+
+// IsRunning checks if container is running
+func (c *Container) IsRunning() bool {
+	return c.Status == ContainerStatusRunning
+}
+
+// IsAutoUpdateEnabled checks if auto update is enabled and neither held by a
+// crash loop nor suspended after failures, on a container that is still
+// managed
+func (c *Container) IsAutoUpdateEnabled() bool {
+	return c.UpdatePolicy == UpdatePolicyAuto && !c.CrashLoopHold && !c.UpdateSuspended && !c.Unmanaged
+}
+
+// RecordUpdateFailure counts a failed automatic update and reports whether it
+// suspended automatic updates, which it does once limit failures ran in a
+// row. A limit of 0 never suspends.
+func (c *Container) RecordUpdateFailure(message, targetDigest string, at time.Time, limit int) bool {
+	c.UpdateFailures++
+	c.LastUpdateError = message
+	failedAt := at.UTC()
+	c.LastUpdateFailedAt = &failedAt
+
+	if c.UpdateSuspended || limit <= 0 || c.UpdateFailures < limit {
+		return false
+	}
+	c.UpdateSuspended = true
+	c.UpdateSuspendedDigest = targetDigest
+	return true
+}
+
+// ResetUpdateFailures clears the failure count and any suspension after a
+// successful update or an operator resuming updates, reporting whether there
+// was anything to clear
+func (c *Container) ResetUpdateFailures() bool {
+	if c.UpdateFailures == 0 && !c.UpdateSuspended && c.LastUpdateError == "" {
+		return false
+	}
+	c.UpdateFailures = 0
+	c.LastUpdateError = ""
+	c.LastUpdateFailedAt = nil
+	c.UpdateSuspended = false
+	c.UpdateSuspendedDigest = ""
+	return true
+}
+
+// HasImageDrift reports whether the container runs another image than the
+// one it was deployed with
+func (c *Container) HasImageDrift() bool {
+	return c.DeployedDigest != "" && c.RunningDigest != "" && c.DeployedDigest != c.RunningDigest
+}
+
+// SetDeployedContainer records a Docker container the application created
+// for the container; the digest it runs is recorded as deployed by the next
+// status sync
+func (c *Container) SetDeployedContainer(dockerID string) {
+	c.ContainerID = dockerID
+	c.DeployedDigest = ""
+	c.RunningDigest = ""
+}
+
+// GetFullImageName returns full image name with tag
+func (c *Container) GetFullImageName() string {
+	if c.Tag == "" {
+		return c.Image + ":latest"
+	}
+	return c.Image + ":" + c.Tag
+}
+
+// GetDeployImageRef returns the image reference handed to the Docker daemon,
+// image@digest for pinned containers
+func (c *Container) GetDeployImageRef() string {
+	if c.PinByDigest && c.ImageDigest != "" {
+		return c.Image + "@" + c.ImageDigest
+	}
+	return c.GetFullImageName()
+}
+
+// HasDigestDrift reports whether the tag of a pinned container has moved to a
+// digest other than the one deployed
+func (c *Container) HasDigestDrift() bool {
+	return c.PinByDigest && c.PendingDigest != "" && c.PendingDigest != c.ImageDigest
+}
+
+// HasWarnings reports whether the daemon warned about the container's current
+// configuration
+func (c *Container) HasWarnings() bool {
+	return len(c.Warnings) > 0
+}
+
+// FilterWarnings drops daemon warnings containing any of the ignored patterns,
+// compared case-insensitively
+func FilterWarnings(warnings []string, ignored []string) StringList {
+	var kept StringList
+	for _, warning := range warnings {
+		warning = strings.TrimSpace(warning)
+		if warning == "" {
+			continue
+		}
+		skip := false
+		for _, pattern := range ignored {
+			if pattern != "" && strings.Contains(strings.ToLower(warning), strings.ToLower(pattern)) {
+				skip = true
+				break
+			}
+		}
+		if !skip {
+			kept = append(kept, warning)
+		}
+	}
+	return kept
+}
+
+// ParseImageReference splits an image reference into repository, tag and digest.
+// Registry ports are not mistaken for tags.
+func ParseImageReference(ref string) (image, tag, digest string) {
+	image = ref
+	if i := strings.Index(image, "@"); i >= 0 {
+		digest = image[i+1:]
+		image = image[:i]
+	}
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		tag = image[i+1:]
+		image = image[:i]
+	}
+	return image, tag, digest
+}
+
+// GetValidStatuses returns all valid container statuses
+func GetValidContainerStatuses() []ContainerStatus {
+	return []ContainerStatus{
+		ContainerStatusRunning,
+		ContainerStatusStopped,
+		ContainerStatusPaused,
+		ContainerStatusRestarting,
+		ContainerStatusRemoving,
+		ContainerStatusExited,
+		ContainerStatusDead,
+		ContainerStatusUnknown,
+	}
+}
+
+// GetValidUpdatePolicies returns all valid update policies
+func GetValidUpdatePolicies() []UpdatePolicy {
+	return []UpdatePolicy{
+		UpdatePolicyAuto,
+		UpdatePolicyManual,
+		UpdatePolicyScheduled,
+		UpdatePolicyDisabled,
+		UpdatePolicyInherit,
+	}
+}
+
+// GetValidRegistryAuthTypes returns all valid registry auth types
+func GetValidRegistryAuthTypes() []RegistryAuthType {
+	return []RegistryAuthType{
+		RegistryAuthTypeBasic,
+		RegistryAuthTypeToken,
+		RegistryAuthTypeOAuth,
+	}
+}
+
+// BeforeCreate hook for Container model
+func (c *Container) BeforeCreate(tx *gorm.DB) error {
+	if c.Tag == "" {
+		c.Tag = "latest"
+	}
+	if c.UpdatePolicy == "" {
+		c.UpdatePolicy = UpdatePolicyInherit
+	}
+	if c.VersionPolicy == "" {
+		c.VersionPolicy = VersionPolicyLatest
+	}
+	if c.RestartPolicy == "" {
+		c.RestartPolicy = "unless-stopped"
+	}
+	return nil
+}
+
+// BeforeCreate hook for RegistryCredentials model
+func (rc *RegistryCredentials) BeforeCreate(tx *gorm.DB) error {
+	if rc.AuthType == "" {
+		rc.AuthType = RegistryAuthTypeBasic
+	}
+	return nil
+}
\ No newline at end of file
@@ -0,0 +1,149 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"time"
+)
+
+// ContainerChangeType identifies what a change feed record describes
+type ContainerChangeType string
+
+const (
+	ContainerChangeCreate ContainerChangeType = "create"
+	ContainerChangeUpdate ContainerChangeType = "update"
+	ContainerChangeDelete ContainerChangeType = "delete"
+	ContainerChangeStatus ContainerChangeType = "status"
+)
+
+// ChangeFeedWebhookConsumer is the cursor name of the change feed webhook
+const ChangeFeedWebhookConsumer = "webhook"
+
+// ContainerChange is an immutable change feed record of a managed container.
+// Seq is assigned in commit order without gaps, so consumers resume from the
+// last seq they processed and deduplicate on it.
+type ContainerChange struct {
+	Seq           int64               `json:"seq" gorm:"primaryKey;autoIncrement:false"`
+	ContainerID   int                 `json:"container_id" gorm:"not null;index:idx_container_changes_container_id"`
+	ContainerName string              `json:"container_name" gorm:"size:255"`
+	ChangeType    ContainerChangeType `json:"change_type" gorm:"not null;size:20"`
+	Diff          JSONMap             `json:"diff" gorm:"type:jsonb;not null;default:'{}'"`
+	ActorType     ActorType           `json:"actor_type" gorm:"not null;size:20;default:'system'"`
+	ActorID       *int                `json:"actor_id,omitempty"`
+	Actor         string              `json:"actor" gorm:"not null;size:100;default:'system'"`
+	CreatedAt     time.Time           `json:"created_at" gorm:"not null;index:idx_container_changes_created_at"`
+}
+
+// TableName returns the table name for ContainerChange model
+func (ContainerChange) TableName() string {
+	return "container_changes"
+}
+
+// ChangeFeedCursor is the last change feed seq a push consumer acknowledged
+type ChangeFeedCursor struct {
+	Consumer  string    `json:"consumer" gorm:"primaryKey;size:100"`
+	LastSeq   int64     `json:"last_seq" gorm:"not null;default:0"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for ChangeFeedCursor model
+func (ChangeFeedCursor) TableName() string {
+	return "change_feed_cursors"
+}
+
+// FieldChange is the old and new value of a field in a change diff
+type FieldChange struct {
+	Old interface{} `json:"old"`
+	New interface{} `json:"new"`
+}
+
+// redactedValue replaces values of fields that may carry secrets
+const redactedValue = "[redacted]"
+
+// containerDiffIgnored are bookkeeping fields left out of change diffs
+var containerDiffIgnored = map[string]bool{
+	"created_at":            true,
+	"updated_at":            true,
+	"warnings_at":           true,
+	"drift_checked_at":      true,
+	"update_checked_at":     true,
+	"update_deferred_until": true,
+	"update_failures":       true,
+	"last_update_error":     true,
+	"last_update_failed_at": true,
+	"last_synced_at":        true,
+	"last_post_start":       true,
+	"created_by_user":       true,
+	"update_histories":      true,
+}
+
+// containerDiffRedacted are fields whose values may hold credentials; the diff
+// records that they changed but not what to
+var containerDiffRedacted = map[string]bool{
+	"registry_auth": true,
+	"environment":   true,
+}
+
+// DiffContainers returns the fields that differ between two versions of a
+// container, keyed by JSON field name. A nil before diffs a creation and a nil
+// after a deletion.
+func DiffContainers(before, after *Container) JSONMap {
+	oldFields := containerFields(before)
+	newFields := containerFields(after)
+
+	diff := JSONMap{}
+	for field, newValue := range newFields {
+		oldValue, ok := oldFields[field]
+		if ok && reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+		diff[field] = fieldChange(field, oldValue, newValue)
+	}
+	for field, oldValue := range oldFields {
+		if _, ok := newFields[field]; !ok {
+			diff[field] = fieldChange(field, oldValue, nil)
+		}
+	}
+
+	return diff
+}
+
+// ContainerChangeTypeFor classifies an update diff; one touching nothing but
+// the status is a status transition
+func ContainerChangeTypeFor(diff JSONMap) ContainerChangeType {
+	if _, ok := diff["status"]; ok && len(diff) == 1 {
+		return ContainerChangeStatus
+	}
+	return ContainerChangeUpdate
+}
+
+func fieldChange(field string, oldValue, newValue interface{}) FieldChange {
+	if containerDiffRedacted[field] {
+		if oldValue != nil {
+			oldValue = redactedValue
+		}
+		if newValue != nil {
+			newValue = redactedValue
+		}
+	}
+	return FieldChange{Old: oldValue, New: newValue}
+}
+
+func containerFields(container *Container) map[string]interface{} {
+	fields := map[string]interface{}{}
+	if container == nil {
+		return fields
+	}
+
+	data, err := json.Marshal(container)
+	if err != nil {
+		return fields
+	}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return map[string]interface{}{}
+	}
+	for field := range containerDiffIgnored {
+		delete(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,188 @@
+package model
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContainerDependency records that a managed container depends on another.
+// The dependency is started first and stopped last, and an update of the
+// dependent waits for the dependency to be healthy.
+type ContainerDependency struct {
+	ContainerID int       `json:"container_id" gorm:"primaryKey"`
+	DependsOnID int       `json:"depends_on_id" gorm:"primaryKey;index:idx_container_dependencies_depends_on_id"`
+	CreatedAt   time.Time `json:"created_at"`
+
+	// Relationships
+	Container *Container `json:"-" gorm:"foreignKey:ContainerID;constraint:OnDelete:CASCADE"`
+	DependsOn *Container `json:"-" gorm:"foreignKey:DependsOnID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName returns the table name for ContainerDependency model
+func (ContainerDependency) TableName() string {
+	return "container_dependencies"
+}
+
+// DependencyCycleError rejects dependencies that would form a loop
+type DependencyCycleError struct {
+	// Cycle lists the container IDs around the loop, the first repeated at
+	// the end
+	Cycle []int
+}
+
+func (e *DependencyCycleError) Error() string {
+	ids := make([]string, len(e.Cycle))
+	for i, id := range e.Cycle {
+		ids[i] = strconv.Itoa(id)
+	}
+	return "dependency cycle: " + strings.Join(ids, " -> ")
+}
+
+// DependencyGraph maps a container ID to the IDs of the containers it
+// depends on
+type DependencyGraph map[int][]int
+
+// NewDependencyGraph builds the graph of the given dependencies
+func NewDependencyGraph(dependencies []*ContainerDependency) DependencyGraph {
+	graph := make(DependencyGraph)
+	for _, dependency := range dependencies {
+		graph[dependency.ContainerID] = append(graph[dependency.ContainerID], dependency.DependsOnID)
+	}
+	return graph
+}
+
+// Dependents returns the IDs of the containers depending on id directly
+func (g DependencyGraph) Dependents(id int) []int {
+	var dependents []int
+	for containerID, dependsOn := range g {
+		for _, dependency := range dependsOn {
+			if dependency == id {
+				dependents = append(dependents, containerID)
+				break
+			}
+		}
+	}
+	sort.Ints(dependents)
+	return dependents
+}
+
+// FindCycle returns a loop in the graph, or nil when there is none. The
+// search runs in ascending container ID so the same graph always reports the
+// same loop.
+func (g DependencyGraph) FindCycle() []int {
+	const (
+		unvisited = iota
+		visiting
+		done
+	)
+	state := make(map[int]int)
+	var path []int
+
+	var visit func(id int) []int
+	visit = func(id int) []int {
+		state[id] = visiting
+		path = append(path, id)
+
+		dependsOn := append([]int(nil), g[id]...)
+		sort.Ints(dependsOn)
+		for _, dependency := range dependsOn {
+			switch state[dependency] {
+			case visiting:
+				for i, onPath := range path {
+					if onPath == dependency {
+						return append(append([]int(nil), path[i:]...), dependency)
+					}
+				}
+			case unvisited:
+				if cycle := visit(dependency); cycle != nil {
+					return cycle
+				}
+			}
+		}
+
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+
+	ids := make([]int, 0, len(g))
+	for id := range g {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		if state[id] == unvisited {
+			if cycle := visit(id); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+// Waves groups ids so that every container comes after the containers it
+// depends on, directly or through containers not in ids. Each wave only
+// depends on earlier ones, so the containers of a wave can be started or
+// updated together; stopping runs the waves in reverse. ids keep their order
+// within a wave. The graph must be free of cycles.
+func (g DependencyGraph) Waves(ids []int) [][]int {
+	depths := make(map[int]int)
+	var depth func(id int, seen map[int]bool) int
+	depth = func(id int, seen map[int]bool) int {
+		if d, ok := depths[id]; ok {
+			return d
+		}
+		if seen[id] {
+			return 0
+		}
+		seen[id] = true
+		d := 0
+		for _, dependency := range g[id] {
+			if dd := depth(dependency, seen) + 1; dd > d {
+				d = dd
+			}
+		}
+		depths[id] = d
+		return d
+	}
+
+	var waves [][]int
+	for _, id := range ids {
+		d := depth(id, make(map[int]bool))
+		for len(waves) <= d {
+			waves = append(waves, nil)
+		}
+		waves[d] = append(waves[d], id)
+	}
+
+	// Depths the given containers skip leave empty waves
+	ordered := waves[:0]
+	for _, wave := range waves {
+		if len(wave) > 0 {
+			ordered = append(ordered, wave)
+		}
+	}
+	return ordered
+}
+
+// ValidateDependencies checks the dependencies a container is given: it may
+// not depend on itself and lists each dependency once
+func ValidateDependencies(containerID int, dependsOn []int) error {
+	seen := make(map[int]bool, len(dependsOn))
+	for _, id := range dependsOn {
+		if id <= 0 {
+			return fmt.Errorf("invalid container ID %d", id)
+		}
+		if id == containerID {
+			return fmt.Errorf("a container cannot depend on itself")
+		}
+		if seen[id] {
+			return fmt.Errorf("container %d is listed twice", id)
+		}
+		seen[id] = true
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Discovery labels, under the configured prefix: <prefix>.enable=true marks a
+// container for adoption and the others set its policy
+const (
+	DiscoveryLabelEnable            = "enable"
+	DiscoveryLabelUpdatePolicy      = "update-policy"
+	DiscoveryLabelRegistry          = "registry"
+	DiscoveryLabelMaintenanceWindow = "maintenance-window"
+)
+
+var weekdayNames = map[string]int{
+	"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+}
+
+// DiscoveryLabels are the settings an adopted container takes from its labels
+type DiscoveryLabels struct {
+	UpdatePolicy UpdatePolicy
+	RegistryURL  string
+	// MaintenanceWindows is the JSON encoded form stored on the container,
+	// empty when the label is not set
+	MaintenanceWindows string
+}
+
+// DiscoveryLabel returns the full name of a discovery label
+func DiscoveryLabel(prefix, name string) string {
+	return prefix + "." + name
+}
+
+// DiscoveryEnabled reports whether the labels mark a container for adoption
+func DiscoveryEnabled(prefix string, labels map[string]string) bool {
+	if prefix == "" {
+		return false
+	}
+	enabled, err := strconv.ParseBool(strings.TrimSpace(labels[DiscoveryLabel(prefix, DiscoveryLabelEnable)]))
+	return err == nil && enabled
+}
+
+// ParseDiscoveryLabels reads the policy labels of a discovered container. The
+// update policy defaults to manual, so adopting a container never starts
+// updating it unasked.
+func ParseDiscoveryLabels(prefix string, labels map[string]string) (*DiscoveryLabels, error) {
+	parsed := &DiscoveryLabels{
+		UpdatePolicy: UpdatePolicyManual,
+		RegistryURL:  strings.TrimSpace(labels[DiscoveryLabel(prefix, DiscoveryLabelRegistry)]),
+	}
+
+	if value := strings.TrimSpace(labels[DiscoveryLabel(prefix, DiscoveryLabelUpdatePolicy)]); value != "" {
+		policy := UpdatePolicy(strings.ToLower(value))
+		valid := false
+		for _, p := range GetValidUpdatePolicies() {
+			if p == policy {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("invalid %s label %q", DiscoveryLabel(prefix, DiscoveryLabelUpdatePolicy), value)
+		}
+		parsed.UpdatePolicy = policy
+	}
+
+	if value := strings.TrimSpace(labels[DiscoveryLabel(prefix, DiscoveryLabelMaintenanceWindow)]); value != "" {
+		windows, err := ParseMaintenanceWindowLabel(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s label: %w", DiscoveryLabel(prefix, DiscoveryLabelMaintenanceWindow), err)
+		}
+		encoded, err := json.Marshal(windows)
+		if err != nil {
+			return nil, err
+		}
+		parsed.MaintenanceWindows = string(encoded)
+	}
+
+	return parsed, nil
+}
+
+// ParseMaintenanceWindowLabel parses maintenance windows written as
+// "[days ]HH:MM-HH:MM[ timezone]", several separated by ";". Days are names
+// or ranges such as "mon-fri,sun"; every day when omitted.
+// Example: "sat,sun 02:00-04:00 Europe/Berlin".
+func ParseMaintenanceWindowLabel(value string) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	for _, part := range strings.Split(value, ";") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+
+		window := MaintenanceWindow{}
+		if !strings.Contains(fields[0], ":") {
+			days, err := parseWeekdays(fields[0])
+			if err != nil {
+				return nil, err
+			}
+			window.DaysOfWeek = days
+			fields = fields[1:]
+		}
+		if len(fields) == 0 || len(fields) > 2 {
+			return nil, fmt.Errorf("window %q must be [days ]HH:MM-HH:MM[ timezone]", strings.TrimSpace(part))
+		}
+
+		start, end, ok := strings.Cut(fields[0], "-")
+		if !ok {
+			return nil, fmt.Errorf("window %q must be [days ]HH:MM-HH:MM[ timezone]", strings.TrimSpace(part))
+		}
+		window.StartTime = start
+		window.EndTime = end
+		if len(fields) == 2 {
+			window.Timezone = fields[1]
+		}
+
+		if _, err := window.Schedule(); err != nil {
+			return nil, err
+		}
+		windows = append(windows, window)
+	}
+
+	if len(windows) == 0 {
+		return nil, fmt.Errorf("no maintenance window given")
+	}
+	return windows, nil
+}
+
+// parseWeekdays parses day names and ranges such as "mon-fri,sun"
+func parseWeekdays(value string) ([]int, error) {
+	var days []int
+	for _, item := range strings.Split(strings.ToLower(value), ",") {
+		from, to, isRange := strings.Cut(item, "-")
+		first, ok := weekdayNames[from]
+		if !ok {
+			return nil, fmt.Errorf("invalid day %q", from)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdayNames[to]; !ok {
+				return nil, fmt.Errorf("invalid day %q", to)
+			}
+		}
+		// Ranges may wrap past Saturday, as in "fri-mon"
+		for day := first; ; day = (day + 1) % 7 {
+			days = append(days, day)
+			if day == last {
+				break
+			}
+		}
+	}
+	return days, nil
+}
@@ -0,0 +1,285 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// HealthActionType identifies a remediation action run for an unhealthy container
+type HealthActionType string
+
+const (
+	HealthActionRestart HealthActionType = "restart"
+	HealthActionExec    HealthActionType = "exec"
+	HealthActionWebhook HealthActionType = "webhook"
+)
+
+const (
+	defaultHealthActionAttempts = 3
+	defaultHealthActionCooldown = 5 * time.Minute
+	maxHealthActions            = 10
+	maxHealthActionRecords      = 20
+	maxHealthActionOutput       = 4096
+)
+
+// HealthActionConfig is one remediation step of a container. Steps run in
+// order until one succeeds. A step that failed is skipped while it cools down
+// or once it has used MaxAttempts since the container was last healthy; a
+// step that succeeded holds off later steps for its cooldown.
+type HealthActionConfig struct {
+	Type            HealthActionType `json:"type"`
+	Command         []string         `json:"command,omitempty"` // exec
+	URL             string           `json:"url,omitempty"`     // webhook
+	MaxAttempts     int              `json:"max_attempts,omitempty"`
+	CooldownSeconds int              `json:"cooldown_seconds,omitempty"`
+}
+
+// Attempts returns the attempt limit, 3 when unset
+func (a HealthActionConfig) Attempts() int {
+	if a.MaxAttempts <= 0 {
+		return defaultHealthActionAttempts
+	}
+	return a.MaxAttempts
+}
+
+// Cooldown returns the wait between attempts, five minutes when unset
+func (a HealthActionConfig) Cooldown() time.Duration {
+	if a.CooldownSeconds <= 0 {
+		return defaultHealthActionCooldown
+	}
+	return time.Duration(a.CooldownSeconds) * time.Second
+}
+
+// Validate validates the action
+func (a HealthActionConfig) Validate() error {
+	switch a.Type {
+	case HealthActionRestart:
+	case HealthActionExec:
+		if len(a.Command) == 0 {
+			return fmt.Errorf("exec action requires a command")
+		}
+	case HealthActionWebhook:
+		u, err := url.Parse(a.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook action requires an http or https url")
+		}
+	default:
+		return fmt.Errorf("unknown health action type %q", a.Type)
+	}
+
+	if a.MaxAttempts < 0 || a.MaxAttempts > 20 {
+		return fmt.Errorf("max_attempts must be between 0 and 20")
+	}
+	if a.CooldownSeconds < 0 {
+		return fmt.Errorf("cooldown_seconds cannot be negative")
+	}
+	return nil
+}
+
+// HealthActionList is the ordered remediation chain of a container
+type HealthActionList []HealthActionConfig
+
+// Validate validates every action of the chain
+func (l HealthActionList) Validate() error {
+	if len(l) > maxHealthActions {
+		return fmt.Errorf("at most %d health actions are allowed", maxHealthActions)
+	}
+	for i, action := range l {
+		if err := action.Validate(); err != nil {
+			return fmt.Errorf("health action %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// HasExec reports whether the chain runs commands inside the container
+func (l HealthActionList) HasExec() bool {
+	for _, action := range l {
+		if action.Type == HealthActionExec {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (l HealthActionList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (l *HealthActionList) Scan(value interface{}) error {
+	return scanJSON(value, l, "HealthActionList")
+}
+
+// ContainerHealthState is the persisted health and remediation state of a
+// container, kept across health checker runs
+type ContainerHealthState struct {
+	ID                  int                 `json:"id" gorm:"primaryKey;autoIncrement"`
+	ContainerID         int                 `json:"container_id" gorm:"uniqueIndex;not null"`
+	Status              string              `json:"status" gorm:"size:20;not null;default:'unknown'"`
+	ConsecutiveFailures int                 `json:"consecutive_failures" gorm:"not null;default:0"`
+	LastCheckedAt       *time.Time          `json:"last_checked_at,omitempty"`
+	LastHealthyAt       *time.Time          `json:"last_healthy_at,omitempty"`
+	LastIncidentAt      *time.Time          `json:"last_incident_at,omitempty"`  // start of the latest failed checks
+	LastRecoveredAt     *time.Time          `json:"last_recovered_at,omitempty"` // first healthy check after them
+	Actions             HealthActionStates  `json:"actions" gorm:"type:jsonb;default:'[]'"`
+	History             HealthActionRecords `json:"history" gorm:"type:jsonb;default:'[]'"`
+	UpdatedAt           time.Time           `json:"updated_at"`
+}
+
+// HealthActionState tracks attempts and cooldown of one configured action
+type HealthActionState struct {
+	Index         int              `json:"index"`
+	Type          HealthActionType `json:"type"`
+	Attempts      int              `json:"attempts"`
+	LastAttemptAt *time.Time       `json:"last_attempt_at,omitempty"`
+	LastSucceeded bool             `json:"last_succeeded"`
+	CooldownUntil *time.Time       `json:"cooldown_until,omitempty"`
+}
+
+// HealthActionRecord is an action the health checker took
+type HealthActionRecord struct {
+	Type       HealthActionType `json:"type"`
+	Attempt    int              `json:"attempt"`
+	Timestamp  time.Time        `json:"timestamp"`
+	Success    bool             `json:"success"`
+	Message    string           `json:"message,omitempty"`
+	Output     string           `json:"output,omitempty"`
+	ExitCode   *int             `json:"exit_code,omitempty"`
+	StatusCode int              `json:"status_code,omitempty"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// HealthActionStates is stored as a JSON array
+type HealthActionStates []HealthActionState
+
+// HealthActionRecords is stored as a JSON array
+type HealthActionRecords []HealthActionRecord
+
+// TableName returns the table name for ContainerHealthState model
+func (ContainerHealthState) TableName() string {
+	return "container_health_states"
+}
+
+// ActionState returns the state of the action at index, starting it afresh
+// when the action there has changed type
+func (s *ContainerHealthState) ActionState(index int, actionType HealthActionType) *HealthActionState {
+	for i := range s.Actions {
+		if s.Actions[i].Index == index {
+			if s.Actions[i].Type != actionType {
+				s.Actions[i] = HealthActionState{Index: index, Type: actionType}
+			}
+			return &s.Actions[i]
+		}
+	}
+	s.Actions = append(s.Actions, HealthActionState{Index: index, Type: actionType})
+	return &s.Actions[len(s.Actions)-1]
+}
+
+// RecordAction appends an action to the history, keeping the most recent ones
+// and truncating long output
+func (s *ContainerHealthState) RecordAction(record HealthActionRecord) {
+	if len(record.Output) > maxHealthActionOutput {
+		record.Output = record.Output[:maxHealthActionOutput]
+	}
+	s.History = append(s.History, record)
+	if len(s.History) > maxHealthActionRecords {
+		s.History = s.History[len(s.History)-maxHealthActionRecords:]
+	}
+}
+
+// MarkHealthy records a healthy check and resets attempts and cooldowns
+func (s *ContainerHealthState) MarkHealthy(at time.Time) {
+	if s.ConsecutiveFailures > 0 {
+		s.LastRecoveredAt = &at
+	}
+	s.Status = "healthy"
+	s.ConsecutiveFailures = 0
+	s.LastCheckedAt = &at
+	s.LastHealthyAt = &at
+	s.Actions = nil
+}
+
+// MarkUnhealthy records a failed check with the given status
+func (s *ContainerHealthState) MarkUnhealthy(status string, at time.Time) {
+	if s.ConsecutiveFailures == 0 {
+		s.LastIncidentAt = &at
+	}
+	s.Status = status
+	s.ConsecutiveFailures++
+	s.LastCheckedAt = &at
+}
+
+// Ready reports whether the action may be attempted now
+func (a *HealthActionState) Ready(config HealthActionConfig, at time.Time) bool {
+	if a.Attempts >= config.Attempts() {
+		return false
+	}
+	return a.CooldownUntil == nil || !at.Before(*a.CooldownUntil)
+}
+
+// Waiting reports whether the action succeeded and is still within its
+// cooldown, giving the remediation time to take effect before escalating
+func (a *HealthActionState) Waiting(at time.Time) bool {
+	return a.LastSucceeded && a.CooldownUntil != nil && at.Before(*a.CooldownUntil)
+}
+
+// Attempted counts an attempt with its outcome and starts the cooldown
+func (a *HealthActionState) Attempted(config HealthActionConfig, at time.Time, succeeded bool) {
+	a.Attempts++
+	a.LastAttemptAt = &at
+	a.LastSucceeded = succeeded
+	cooldownUntil := at.Add(config.Cooldown())
+	a.CooldownUntil = &cooldownUntil
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (l HealthActionStates) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (l *HealthActionStates) Scan(value interface{}) error {
+	return scanJSON(value, l, "HealthActionStates")
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (l HealthActionRecords) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (l *HealthActionRecords) Scan(value interface{}) error {
+	return scanJSON(value, l, "HealthActionRecords")
+}
+
+func scanJSON(value interface{}, dest interface{}, typeName string) error {
+	if value == nil {
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into %s", value, typeName)
+	}
+
+	return json.Unmarshal(bytes, dest)
+}
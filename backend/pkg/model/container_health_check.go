@@ -0,0 +1,178 @@
+package model
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// HealthCheckType is how a configured health check probes a container
+type HealthCheckType string
+
+const (
+	HealthCheckHTTP    HealthCheckType = "http"
+	HealthCheckTCP     HealthCheckType = "tcp"
+	HealthCheckCommand HealthCheckType = "command"
+)
+
+const (
+	defaultHealthCheckInterval  = time.Minute
+	defaultHealthCheckTimeout   = 10 * time.Second
+	defaultHealthCheckThreshold = 3
+	maxHealthCheckMessage       = 1024
+)
+
+// ContainerHealthCheck is a health check configured for one container. The
+// health checker runs it every IntervalSeconds; it fails the container once
+// FailureThreshold consecutive runs have failed, and then restarts the
+// container when RestartOnFailure is set. The outcome of the latest run is
+// kept with the check.
+type ContainerHealthCheck struct {
+	ID               int             `json:"id" gorm:"primaryKey;autoIncrement"`
+	ContainerID      int             `json:"container_id" gorm:"not null;index:idx_container_health_checks_container_id"`
+	Name             string          `json:"name" gorm:"size:100;not null"`
+	Type             HealthCheckType `json:"type" gorm:"size:20;not null"`
+	Endpoint         string          `json:"endpoint,omitempty" gorm:"size:500"`               // http URL or tcp host:port
+	Method           string          `json:"method,omitempty" gorm:"size:10"`                  // http, GET when unset
+	ExpectedStatus   int             `json:"expected_status,omitempty"`                        // http, 200 when unset
+	ExpectedBody     string          `json:"expected_body,omitempty" gorm:"size:255"`          // http
+	Command          StringList      `json:"command,omitempty" gorm:"type:jsonb;default:'[]'"` // command
+	ExpectedExit     int             `json:"expected_exit,omitempty"`                          // command
+	IntervalSeconds  int             `json:"interval_seconds" gorm:"not null;default:60"`
+	TimeoutSeconds   int             `json:"timeout_seconds" gorm:"not null;default:10"`
+	FailureThreshold int             `json:"failure_threshold" gorm:"not null;default:3"`
+	RestartOnFailure bool            `json:"restart_on_failure" gorm:"not null"`
+	Enabled          bool            `json:"enabled" gorm:"not null"`
+	CreatedAt        time.Time       `json:"created_at"`
+	UpdatedAt        time.Time       `json:"updated_at"`
+
+	// Outcome of the latest run
+	LastStatus          string     `json:"last_status,omitempty" gorm:"size:20"` // healthy, failing or unhealthy
+	LastCheckedAt       *time.Time `json:"last_checked_at,omitempty"`
+	LastMessage         string     `json:"last_message,omitempty" gorm:"type:text"`
+	LastDurationMS      int64      `json:"last_duration_ms"`
+	ConsecutiveFailures int        `json:"consecutive_failures" gorm:"not null;default:0"`
+}
+
+// TableName returns the table name for ContainerHealthCheck model
+func (ContainerHealthCheck) TableName() string {
+	return "container_health_checks"
+}
+
+// Interval returns how often the check runs, every minute when unset
+func (c *ContainerHealthCheck) Interval() time.Duration {
+	if c.IntervalSeconds <= 0 {
+		return defaultHealthCheckInterval
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// Timeout returns how long a run may take, ten seconds when unset
+func (c *ContainerHealthCheck) Timeout() time.Duration {
+	if c.TimeoutSeconds <= 0 {
+		return defaultHealthCheckTimeout
+	}
+	return time.Duration(c.TimeoutSeconds) * time.Second
+}
+
+// Threshold returns the consecutive failures that fail the container, 3 when
+// unset
+func (c *ContainerHealthCheck) Threshold() int {
+	if c.FailureThreshold <= 0 {
+		return defaultHealthCheckThreshold
+	}
+	return c.FailureThreshold
+}
+
+// Due reports whether the check should run at the given time
+func (c *ContainerHealthCheck) Due(at time.Time) bool {
+	return c.Enabled && (c.LastCheckedAt == nil || !at.Before(c.LastCheckedAt.Add(c.Interval())))
+}
+
+// Failing reports whether the check has reached its failure threshold
+func (c *ContainerHealthCheck) Failing() bool {
+	return c.ConsecutiveFailures >= c.Threshold()
+}
+
+// Record stores the outcome of a run
+func (c *ContainerHealthCheck) Record(success bool, message string, duration time.Duration, at time.Time) {
+	if len(message) > maxHealthCheckMessage {
+		message = message[:maxHealthCheckMessage]
+	}
+
+	if success {
+		c.ConsecutiveFailures = 0
+		c.LastStatus = "healthy"
+	} else {
+		c.ConsecutiveFailures++
+		c.LastStatus = "failing"
+		if c.Failing() {
+			c.LastStatus = "unhealthy"
+		}
+	}
+	c.LastMessage = message
+	c.LastDurationMS = duration.Milliseconds()
+	c.LastCheckedAt = &at
+}
+
+// HostPort splits the endpoint of a tcp check
+func (c *ContainerHealthCheck) HostPort() (string, int, error) {
+	host, portStr, err := net.SplitHostPort(c.Endpoint)
+	if err != nil {
+		return "", 0, fmt.Errorf("endpoint must be host:port")
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil || port <= 0 || port > 65535 {
+		return "", 0, fmt.Errorf("endpoint port must be between 1 and 65535")
+	}
+	return host, port, nil
+}
+
+// Validate validates the check's configuration
+func (c *ContainerHealthCheck) Validate() error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(c.Name) > 100 {
+		return fmt.Errorf("name cannot exceed 100 characters")
+	}
+
+	switch c.Type {
+	case HealthCheckHTTP:
+		u, err := url.Parse(c.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("http checks require an http or https endpoint")
+		}
+		switch c.Method {
+		case "", "GET", "HEAD":
+		default:
+			return fmt.Errorf("method must be GET or HEAD")
+		}
+		if c.ExpectedStatus != 0 && (c.ExpectedStatus < 100 || c.ExpectedStatus > 599) {
+			return fmt.Errorf("expected_status must be a valid HTTP status")
+		}
+	case HealthCheckTCP:
+		if _, _, err := c.HostPort(); err != nil {
+			return err
+		}
+	case HealthCheckCommand:
+		if len(c.Command) == 0 {
+			return fmt.Errorf("command checks require a command")
+		}
+	default:
+		return fmt.Errorf("unknown health check type %q", c.Type)
+	}
+
+	if c.IntervalSeconds < 0 || c.IntervalSeconds > 86400 {
+		return fmt.Errorf("interval_seconds must be between 0 and 86400")
+	}
+	if c.TimeoutSeconds < 0 || c.TimeoutSeconds > 300 {
+		return fmt.Errorf("timeout_seconds must be between 0 and 300")
+	}
+	if c.FailureThreshold < 0 || c.FailureThreshold > 100 {
+		return fmt.Errorf("failure_threshold must be between 0 and 100")
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"time"
+)
+
+// ContainerMetric summarizes a managed container's resource usage over one
+// collection period starting at SampledAt. CPU and memory are averages of
+// the samples taken in the period; network and disk values are the bytes
+// transferred during it.
+type ContainerMetric struct {
+	ID              int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	ContainerID     int       `json:"container_id" gorm:"not null;index:idx_container_metrics_container,priority:1"`
+	SampledAt       time.Time `json:"sampled_at" gorm:"not null;index:idx_container_metrics_container,priority:2;index:idx_container_metrics_sampled_at"`
+	Samples         int       `json:"samples" gorm:"not null;default:1"`
+	CPUPercent      float64   `json:"cpu_percent" gorm:"not null;default:0"`
+	MemoryUsage     int64     `json:"memory_usage" gorm:"not null;default:0"`
+	MemoryLimit     int64     `json:"memory_limit" gorm:"not null;default:0"`
+	NetworkRxBytes  int64     `json:"network_rx_bytes" gorm:"not null;default:0"`
+	NetworkTxBytes  int64     `json:"network_tx_bytes" gorm:"not null;default:0"`
+	BlockReadBytes  int64     `json:"block_read_bytes" gorm:"not null;default:0"`
+	BlockWriteBytes int64     `json:"block_write_bytes" gorm:"not null;default:0"`
+
+	// Relationships
+	Container *Container `json:"-" gorm:"foreignKey:ContainerID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName returns the table name for ContainerMetric model
+func (ContainerMetric) TableName() string {
+	return "container_metrics"
+}
+
+// ContainerMetricBucket aggregates the metrics of a container over one step
+// of a time range. Start is the Unix time the step begins.
+type ContainerMetricBucket struct {
+	Start           int64
+	CPUPercent      float64
+	MemoryUsage     int64
+	NetworkRxBytes  int64
+	NetworkTxBytes  int64
+	BlockReadBytes  int64
+	BlockWriteBytes int64
+}
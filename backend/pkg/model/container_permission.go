@@ -0,0 +1,95 @@
+package model
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ContainerPermissionLevel is what a container permission allows; each level
+// includes the ones below it
+type ContainerPermissionLevel string
+
+const (
+	// ContainerPermissionView reads the container, its logs, stats and history
+	ContainerPermissionView ContainerPermissionLevel = "view"
+	// ContainerPermissionOperate starts, stops, restarts and updates it
+	ContainerPermissionOperate ContainerPermissionLevel = "operate"
+	// ContainerPermissionManage changes its configuration, deletes it and
+	// shares it
+	ContainerPermissionManage ContainerPermissionLevel = "manage"
+)
+
+var containerPermissionRanks = map[ContainerPermissionLevel]int{
+	ContainerPermissionView:    1,
+	ContainerPermissionOperate: 2,
+	ContainerPermissionManage:  3,
+}
+
+// IsValid reports whether the level is one of the defined levels
+func (l ContainerPermissionLevel) IsValid() bool {
+	return containerPermissionRanks[l] > 0
+}
+
+// Allows reports whether the level includes required. The empty level allows
+// nothing.
+func (l ContainerPermissionLevel) Allows(required ContainerPermissionLevel) bool {
+	return l.IsValid() && containerPermissionRanks[l] >= containerPermissionRanks[required]
+}
+
+// Max returns the higher of the two levels
+func (l ContainerPermissionLevel) Max(other ContainerPermissionLevel) ContainerPermissionLevel {
+	if containerPermissionRanks[other] > containerPermissionRanks[l] {
+		return other
+	}
+	return l
+}
+
+// ContainerPermission grants a user, or every user of a role, a permission
+// level on a container. Exactly one of UserID and Role is set.
+type ContainerPermission struct {
+	ID          int                      `json:"id" gorm:"primaryKey;autoIncrement"`
+	ContainerID int                      `json:"container_id" gorm:"not null;index:idx_container_permissions_container_id"`
+	UserID      *int                     `json:"user_id,omitempty" gorm:"index:idx_container_permissions_user_id"`
+	Role        UserRole                 `json:"role,omitempty" gorm:"size:50;index:idx_container_permissions_role"`
+	Level       ContainerPermissionLevel `json:"permission" gorm:"not null;size:20"`
+	GrantedBy   *int                     `json:"granted_by,omitempty"`
+	CreatedAt   time.Time                `json:"created_at"`
+	UpdatedAt   time.Time                `json:"updated_at"`
+
+	// Relationships
+	Container *Container `json:"-" gorm:"foreignKey:ContainerID;constraint:OnDelete:CASCADE"`
+	User      *User      `json:"user,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName returns the table name for ContainerPermission model
+func (ContainerPermission) TableName() string {
+	return "container_permissions"
+}
+
+// Grants reports whether the permission applies to the user with the role
+func (p *ContainerPermission) Grants(userID int, role UserRole) bool {
+	if p.UserID != nil {
+		return *p.UserID == userID
+	}
+	return p.Role != "" && p.Role == role
+}
+
+// MigrateContainerPermissions grants the creators of containers manage on
+// them where they hold no permission yet
+func MigrateContainerPermissions(db *gorm.DB) error {
+	now := time.Now().UTC()
+	err := db.Exec(`INSERT INTO container_permissions (container_id, user_id, role, level, granted_by, created_at, updated_at)
+		SELECT c.id, c.created_by, '', ?, c.created_by, ?, ?
+		FROM containers c
+		WHERE c.created_by IS NOT NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM container_permissions p
+			WHERE p.container_id = c.id AND p.user_id = c.created_by
+		)`, ContainerPermissionManage, now, now).Error
+	if err != nil {
+		return fmt.Errorf("failed to grant container creators manage: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultStopTimeoutSeconds is how long a container without a stop timeout
+// of its own gets to exit before it is killed
+const DefaultStopTimeoutSeconds = 30
+
+// maxStopTimeoutSeconds bounds the stop timeout of a container
+const maxStopTimeoutSeconds = 3600
+
+// stopSignals are the signals a container may be stopped with
+var stopSignals = []string{"SIGTERM", "SIGINT", "SIGQUIT", "SIGHUP", "SIGUSR1", "SIGUSR2", "SIGWINCH", "SIGPWR", "SIGKILL"}
+
+// StopTimeout returns the seconds the container gets to exit when stopped
+func (c *Container) StopTimeout() int {
+	if c.StopTimeoutSeconds != nil {
+		return *c.StopTimeoutSeconds
+	}
+	return DefaultStopTimeoutSeconds
+}
+
+// ValidateStopTimeout validates a stop timeout in seconds
+func ValidateStopTimeout(seconds int) error {
+	if seconds < 0 || seconds > maxStopTimeoutSeconds {
+		return fmt.Errorf("stop_timeout_seconds must be between 0 and %d", maxStopTimeoutSeconds)
+	}
+	return nil
+}
+
+// ValidateStopSignal checks that signal is one containers may be stopped
+// with, e.g. SIGQUIT
+func ValidateStopSignal(signal string) error {
+	for _, valid := range stopSignals {
+		if signal == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("stop_signal must be one of %s", strings.Join(stopSignals, ", "))
+}
@@ -0,0 +1,400 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Template variable types. A number variable placed alone in a string, such
+// as "host_port": "{{PORT}}", renders as a JSON number.
+const (
+	TemplateVariableString = "string"
+	TemplateVariableNumber = "number"
+)
+
+const (
+	maxTemplateNameLength        = 100
+	maxTemplateDescriptionLength = 2000
+	maxTemplateVariables         = 50
+)
+
+// templatePlaceholder matches {{NAME}} placeholders; names are upper case
+var (
+	templatePlaceholder  = regexp.MustCompile(`\{\{\s*([A-Z][A-Z0-9_]*)\s*\}\}`)
+	templateVariableName = regexp.MustCompile(`^[A-Z][A-Z0-9_]*$`)
+)
+
+// templateReservedKeys are create request fields a template config cannot
+// set; the name is given when instantiating and the image is the template's
+var templateReservedKeys = []string{"name", "image", "tag"}
+
+// ContainerTemplate is a reusable container definition. Config holds the
+// fields of a container create request other than the name and image, with
+// {{NAME}} placeholders in its strings for the declared variables. Templates
+// are private to their owner unless shared by an admin; built-in ones are
+// stored by the migration and cannot be changed.
+type ContainerTemplate struct {
+	ID          int               `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string            `json:"name" gorm:"size:100;not null"`
+	Description string            `json:"description,omitempty" gorm:"type:text"`
+	Image       string            `json:"image" gorm:"size:255;not null"`
+	DefaultTag  string            `json:"default_tag,omitempty" gorm:"size:100"`
+	Config      JSONMap           `json:"config" gorm:"type:jsonb;default:'{}'"`
+	Variables   TemplateVariables `json:"variables" gorm:"type:jsonb;default:'[]'"`
+	Shared      bool              `json:"shared" gorm:"not null;default:false;index:idx_container_templates_shared"`
+	BuiltIn     bool              `json:"built_in" gorm:"not null;default:false"`
+	OwnerID     *int              `json:"owner_id,omitempty" gorm:"index:idx_container_templates_owner_id"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// TableName returns the table name for ContainerTemplate model
+func (ContainerTemplate) TableName() string {
+	return "container_templates"
+}
+
+// TemplateVariable is a value asked for when a template is instantiated.
+// Variables without a default are required.
+type TemplateVariable struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"` // string or number; unset is string
+	Default     string `json:"default,omitempty"`
+}
+
+// Required reports whether the variable must be given a value
+func (v TemplateVariable) Required() bool {
+	return v.Default == ""
+}
+
+// TemplateVariables is stored as a JSON array
+type TemplateVariables []TemplateVariable
+
+// Value implements the driver.Valuer interface for database storage
+func (l TemplateVariables) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (l *TemplateVariables) Scan(value interface{}) error {
+	return scanJSON(value, l, "TemplateVariables")
+}
+
+// VisibleTo reports whether the user may see and instantiate the template
+func (t *ContainerTemplate) VisibleTo(userID *int) bool {
+	return t.Shared || (t.OwnerID != nil && userID != nil && *t.OwnerID == *userID)
+}
+
+// Validate checks the template's fields and that its placeholders and
+// variables match
+func (t *ContainerTemplate) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	t.Image = strings.TrimSpace(t.Image)
+	t.DefaultTag = strings.TrimSpace(t.DefaultTag)
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(t.Name) > maxTemplateNameLength {
+		return fmt.Errorf("name must be at most %d characters", maxTemplateNameLength)
+	}
+	if len(t.Description) > maxTemplateDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", maxTemplateDescriptionLength)
+	}
+	if t.Image == "" {
+		return fmt.Errorf("image is required")
+	}
+	for _, key := range templateReservedKeys {
+		if _, ok := t.Config[key]; ok {
+			return fmt.Errorf("config cannot set %q", key)
+		}
+	}
+
+	if len(t.Variables) > maxTemplateVariables {
+		return fmt.Errorf("at most %d variables are allowed", maxTemplateVariables)
+	}
+	declared := make(map[string]bool, len(t.Variables))
+	for i := range t.Variables {
+		variable := &t.Variables[i]
+		variable.Name = strings.TrimSpace(variable.Name)
+		if !templateVariableName.MatchString(variable.Name) {
+			return fmt.Errorf("variable %d: name %q must be upper case letters, digits and underscores", i+1, variable.Name)
+		}
+		if declared[variable.Name] {
+			return fmt.Errorf("variable %s is declared twice", variable.Name)
+		}
+		declared[variable.Name] = true
+
+		switch variable.Type {
+		case "", TemplateVariableString:
+		case TemplateVariableNumber:
+			if variable.Default != "" {
+				if _, err := strconv.ParseInt(variable.Default, 10, 64); err != nil {
+					return fmt.Errorf("default of variable %s must be a whole number", variable.Name)
+				}
+			}
+		default:
+			return fmt.Errorf("variable %s has unknown type %q", variable.Name, variable.Type)
+		}
+	}
+
+	for _, name := range t.Placeholders() {
+		if !declared[name] {
+			return fmt.Errorf("placeholder {{%s}} has no declared variable", name)
+		}
+	}
+	return nil
+}
+
+// Placeholders returns the sorted names of the placeholders in the config
+func (t *ContainerTemplate) Placeholders() []string {
+	seen := make(map[string]bool)
+	walkTemplateStrings(map[string]interface{}(t.Config), func(s string) {
+		for _, match := range templatePlaceholder.FindAllStringSubmatch(s, -1) {
+			seen[match[1]] = true
+		}
+	})
+
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Render returns a copy of the config with its placeholders replaced by
+// values, falling back to the variables' defaults. It fails for values of
+// undeclared variables, missing required ones and numbers that are not.
+func (t *ContainerTemplate) Render(values map[string]string) (map[string]interface{}, error) {
+	variables := make(map[string]TemplateVariable, len(t.Variables))
+	for _, variable := range t.Variables {
+		variables[variable.Name] = variable
+	}
+	for name := range values {
+		if _, ok := variables[name]; !ok {
+			return nil, fmt.Errorf("unknown variable %s", name)
+		}
+	}
+
+	resolved := make(map[string]string, len(t.Variables))
+	var missing []string
+	for _, variable := range t.Variables {
+		value, ok := values[variable.Name]
+		if !ok || value == "" {
+			value = variable.Default
+		}
+		if value == "" {
+			missing = append(missing, variable.Name)
+			continue
+		}
+		if variable.Type == TemplateVariableNumber {
+			if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, fmt.Errorf("variable %s must be a whole number", variable.Name)
+			}
+		}
+		resolved[variable.Name] = value
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing required variables: %s", strings.Join(missing, ", "))
+	}
+
+	rendered, _ := renderTemplateValue(map[string]interface{}(t.Config), func(s string) interface{} {
+		// A number variable alone in a string becomes a number
+		if match := templatePlaceholder.FindStringSubmatch(s); match != nil && match[0] == s {
+			if variables[match[1]].Type == TemplateVariableNumber {
+				n, _ := strconv.ParseInt(resolved[match[1]], 10, 64)
+				return n
+			}
+		}
+		return templatePlaceholder.ReplaceAllStringFunc(s, func(placeholder string) string {
+			return resolved[templatePlaceholder.FindStringSubmatch(placeholder)[1]]
+		})
+	}).(map[string]interface{})
+	return rendered, nil
+}
+
+// renderTemplateValue copies a decoded JSON value, passing its strings
+// through render
+func renderTemplateValue(value interface{}, render func(string) interface{}) interface{} {
+	switch v := value.(type) {
+	case string:
+		return render(v)
+	case map[string]interface{}:
+		copied := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			copied[key] = renderTemplateValue(item, render)
+		}
+		return copied
+	case []interface{}:
+		copied := make([]interface{}, len(v))
+		for i, item := range v {
+			copied[i] = renderTemplateValue(item, render)
+		}
+		return copied
+	}
+	return value
+}
+
+func walkTemplateStrings(value interface{}, visit func(string)) {
+	renderTemplateValue(value, func(s string) interface{} {
+		visit(s)
+		return s
+	})
+}
+
+// BuiltinContainerTemplates returns the templates stored by the migration
+func BuiltinContainerTemplates() []*ContainerTemplate {
+	return []*ContainerTemplate{
+		{
+			Name:        "PostgreSQL",
+			Description: "PostgreSQL database with its data in a named volume",
+			Image:       "postgres",
+			DefaultTag:  "16",
+			Config: JSONMap{
+				"config": map[string]interface{}{
+					"env": []interface{}{
+						"POSTGRES_USER={{DB_USER}}",
+						"POSTGRES_PASSWORD={{DB_PASSWORD}}",
+						"POSTGRES_DB={{DB_NAME}}",
+					},
+					"ports": []interface{}{
+						map[string]interface{}{"container_port": 5432, "host_port": "{{HOST_PORT}}", "protocol": "tcp"},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{"source": "{{DATA_VOLUME}}", "target": "/var/lib/postgresql/data", "type": "volume"},
+					},
+				},
+				"update_policy":  "manual",
+				"version_policy": "minor",
+				"restart_policy": "unless-stopped",
+				"secret_env":     []interface{}{"POSTGRES_PASSWORD"},
+			},
+			Variables: TemplateVariables{
+				{Name: "DB_USER", Description: "Superuser name", Default: "postgres"},
+				{Name: "DB_PASSWORD", Description: "Superuser password"},
+				{Name: "DB_NAME", Description: "Database created on first start", Default: "postgres"},
+				{Name: "HOST_PORT", Description: "Host port to publish", Type: TemplateVariableNumber, Default: "5432"},
+				{Name: "DATA_VOLUME", Description: "Volume holding the data", Default: "postgres-data"},
+			},
+		},
+		{
+			Name:        "MySQL",
+			Description: "MySQL database with its data in a named volume",
+			Image:       "mysql",
+			DefaultTag:  "8.4",
+			Config: JSONMap{
+				"config": map[string]interface{}{
+					"env": []interface{}{
+						"MYSQL_ROOT_PASSWORD={{ROOT_PASSWORD}}",
+						"MYSQL_DATABASE={{DB_NAME}}",
+					},
+					"ports": []interface{}{
+						map[string]interface{}{"container_port": 3306, "host_port": "{{HOST_PORT}}", "protocol": "tcp"},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{"source": "{{DATA_VOLUME}}", "target": "/var/lib/mysql", "type": "volume"},
+					},
+				},
+				"update_policy":  "manual",
+				"version_policy": "minor",
+				"restart_policy": "unless-stopped",
+				"secret_env":     []interface{}{"MYSQL_ROOT_PASSWORD"},
+			},
+			Variables: TemplateVariables{
+				{Name: "ROOT_PASSWORD", Description: "Root password"},
+				{Name: "DB_NAME", Description: "Database created on first start", Default: "app"},
+				{Name: "HOST_PORT", Description: "Host port to publish", Type: TemplateVariableNumber, Default: "3306"},
+				{Name: "DATA_VOLUME", Description: "Volume holding the data", Default: "mysql-data"},
+			},
+		},
+		{
+			Name:        "Redis",
+			Description: "Redis with its data in a named volume",
+			Image:       "redis",
+			DefaultTag:  "7",
+			Config: JSONMap{
+				"config": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"container_port": 6379, "host_port": "{{HOST_PORT}}", "protocol": "tcp"},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{"source": "{{DATA_VOLUME}}", "target": "/data", "type": "volume"},
+					},
+				},
+				"update_policy":  "auto",
+				"version_policy": "minor",
+				"restart_policy": "unless-stopped",
+			},
+			Variables: TemplateVariables{
+				{Name: "HOST_PORT", Description: "Host port to publish", Type: TemplateVariableNumber, Default: "6379"},
+				{Name: "DATA_VOLUME", Description: "Volume holding the data", Default: "redis-data"},
+			},
+		},
+		{
+			Name:        "Nginx",
+			Description: "Nginx serving static files from a host directory",
+			Image:       "nginx",
+			DefaultTag:  "stable",
+			Config: JSONMap{
+				"config": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"container_port": 80, "host_port": "{{HOST_PORT}}", "protocol": "tcp"},
+					},
+					"volumes": []interface{}{
+						map[string]interface{}{"source": "{{CONTENT_DIR}}", "target": "/usr/share/nginx/html", "type": "bind", "read_only": true},
+					},
+				},
+				"update_policy":  "auto",
+				"restart_policy": "unless-stopped",
+			},
+			Variables: TemplateVariables{
+				{Name: "HOST_PORT", Description: "Host port to publish", Type: TemplateVariableNumber, Default: "8080"},
+				{Name: "CONTENT_DIR", Description: "Host directory with the files to serve"},
+			},
+		},
+	}
+}
+
+// MigrateContainerTemplates stores the built-in templates with their current
+// definitions, shared with every user
+func MigrateContainerTemplates(db *gorm.DB) error {
+	for _, template := range BuiltinContainerTemplates() {
+		template.Shared = true
+		template.BuiltIn = true
+
+		var existing ContainerTemplate
+		err := db.Where("name = ? AND built_in = ?", template.Name, true).First(&existing).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			if err := db.Create(template).Error; err != nil {
+				return fmt.Errorf("failed to create built-in template %s: %w", template.Name, err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to get template %s: %w", template.Name, err)
+		default:
+			err := db.Model(&existing).Updates(map[string]interface{}{
+				"description": template.Description,
+				"image":       template.Image,
+				"default_tag": template.DefaultTag,
+				"config":      template.Config,
+				"variables":   template.Variables,
+				"shared":      true,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to update built-in template %s: %w", template.Name, err)
+			}
+		}
+	}
+	return nil
+}
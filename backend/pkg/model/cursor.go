@@ -0,0 +1,65 @@
+package model
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrInvalidCursor is returned when a pagination cursor cannot be decoded
+var ErrInvalidCursor = errors.New("invalid pagination cursor")
+
+// Cursor is a keyset pagination position: the sort timestamp and ID of the
+// last row of the previous page. A zero Cursor requests the first page.
+type Cursor struct {
+	Time time.Time `json:"t"`
+	ID   int64     `json:"id"`
+}
+
+// NewCursor creates a cursor positioned after the given row
+func NewCursor(t time.Time, id int64) *Cursor {
+	return &Cursor{Time: t.UTC(), ID: id}
+}
+
+// IsZero reports whether the cursor points at the first page
+func (c *Cursor) IsZero() bool {
+	return c == nil || (c.Time.IsZero() && c.ID == 0)
+}
+
+// Encode returns the opaque token handed to API clients
+func (c *Cursor) Encode() string {
+	if c.IsZero() {
+		return ""
+	}
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// NextCursor returns the token for the page after one that returned count
+// rows ending at (t, id). A short page means there is nothing more to read.
+func NextCursor(count, limit int, t time.Time, id int64) string {
+	if limit <= 0 || count < limit {
+		return ""
+	}
+	return NewCursor(t, id).Encode()
+}
+
+// DecodeCursor parses an opaque cursor token. An empty token yields a zero
+// cursor, i.e. the first page in cursor mode.
+func DecodeCursor(token string) (*Cursor, error) {
+	cursor := &Cursor{}
+	if token == "" {
+		return cursor, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if err := json.Unmarshal(data, cursor); err != nil || cursor.ID <= 0 {
+		return nil, ErrInvalidCursor
+	}
+
+	return cursor, nil
+}
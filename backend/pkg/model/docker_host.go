@@ -0,0 +1,98 @@
+package model
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DockerHost is a Docker daemon the system manages containers on, besides
+// the local daemon configured by DOCKER_HOST. Endpoint is a tcp://, unix://
+// or ssh://user@host[:port][/path/to/docker.sock] URL. TCP endpoints may use
+// TLS with the given certificate files; SSH endpoints authenticate with the
+// private key at SSHKeyPath.
+type DockerHost struct {
+	ID         int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name       string    `json:"name" gorm:"uniqueIndex;size:100;not null"`
+	Endpoint   string    `json:"endpoint" gorm:"size:500;not null"`
+	TLSCACert  string    `json:"tls_ca_cert,omitempty" gorm:"size:500"`
+	TLSCert    string    `json:"tls_cert,omitempty" gorm:"size:500"`
+	TLSKey     string    `json:"tls_key,omitempty" gorm:"size:500"`
+	SSHKeyPath string    `json:"ssh_key_path,omitempty" gorm:"size:500"`
+	Enabled    bool      `json:"enabled" gorm:"not null;index:idx_docker_hosts_enabled"`
+	CreatedBy  *int      `json:"created_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+
+	// Outcome of the latest health ping
+	LastPingAt    *time.Time `json:"last_ping_at,omitempty"`
+	LastPingError string     `json:"last_ping_error,omitempty" gorm:"type:text"`
+}
+
+// TableName returns the table name for DockerHost model
+func (DockerHost) TableName() string {
+	return "docker_hosts"
+}
+
+// Scheme returns the endpoint's URL scheme: tcp, unix or ssh
+func (h *DockerHost) Scheme() string {
+	scheme, _, _ := strings.Cut(h.Endpoint, "://")
+	return strings.ToLower(scheme)
+}
+
+// UsesTLS reports whether the host is reached over TLS
+func (h *DockerHost) UsesTLS() bool {
+	return h.TLSCACert != "" || h.TLSCert != "" || h.TLSKey != ""
+}
+
+// ConnectionKey identifies the settings a client for the host is built
+// from; a cached client is rebuilt when it changes
+func (h *DockerHost) ConnectionKey() string {
+	return strings.Join([]string{h.Endpoint, h.TLSCACert, h.TLSCert, h.TLSKey, h.SSHKeyPath}, "|")
+}
+
+// Validate checks the host's name, endpoint and credentials
+func (h *DockerHost) Validate() error {
+	if strings.TrimSpace(h.Name) == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	endpoint, err := url.Parse(h.Endpoint)
+	if err != nil || h.Endpoint == "" {
+		return fmt.Errorf("endpoint must be a tcp://, unix:// or ssh:// URL")
+	}
+
+	switch h.Scheme() {
+	case "tcp":
+		if endpoint.Host == "" {
+			return fmt.Errorf("tcp endpoint needs a host and port")
+		}
+		if h.UsesTLS() && (h.TLSCert == "") != (h.TLSKey == "") {
+			return fmt.Errorf("tls_cert and tls_key must be given together")
+		}
+		if h.SSHKeyPath != "" {
+			return fmt.Errorf("ssh_key_path only applies to ssh endpoints")
+		}
+	case "unix":
+		if endpoint.Path == "" {
+			return fmt.Errorf("unix endpoint needs a socket path")
+		}
+		if h.UsesTLS() || h.SSHKeyPath != "" {
+			return fmt.Errorf("unix endpoints take no TLS or SSH credentials")
+		}
+	case "ssh":
+		if endpoint.Hostname() == "" || endpoint.User.Username() == "" {
+			return fmt.Errorf("ssh endpoint needs a user and host, e.g. ssh://docker@host")
+		}
+		if h.SSHKeyPath == "" {
+			return fmt.Errorf("ssh_key_path is required for ssh endpoints")
+		}
+		if h.UsesTLS() {
+			return fmt.Errorf("ssh endpoints take no TLS certificates")
+		}
+	default:
+		return fmt.Errorf("endpoint must be a tcp://, unix:// or ssh:// URL")
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package model
+
+import (
+	"sort"
+	"strings"
+)
+
+// Feature names a feature flag. Code checks flags through these constants;
+// every flag must be declared in featureDefinitions.
+type Feature string
+
+const (
+	FeatureWebSockets        Feature = "websockets"
+	FeatureImageScanning     Feature = "image_scanning"
+	FeatureHealthRemediation Feature = "health_remediation"
+	FeatureImageHistory      Feature = "image_history"
+	FeatureMultiHost         Feature = "multi_host"
+	FeatureOIDC              Feature = "oidc"
+)
+
+// FeatureStability describes how mature a flagged feature is
+type FeatureStability string
+
+const (
+	FeatureStabilityAlpha      FeatureStability = "alpha"
+	FeatureStabilityBeta       FeatureStability = "beta"
+	FeatureStabilityStable     FeatureStability = "stable"
+	FeatureStabilityDeprecated FeatureStability = "deprecated"
+)
+
+// featureConfigKeyPrefix prefixes the system config keys of runtime overrides
+const featureConfigKeyPrefix = "features."
+
+// FeatureDefinition declares a feature flag. Flags that require a restart
+// take effect at startup only and cannot be changed at runtime.
+type FeatureDefinition struct {
+	Name            Feature          `json:"name"`
+	Description     string           `json:"description"`
+	Default         bool             `json:"default"`
+	Stability       FeatureStability `json:"stability"`
+	RequiresRestart bool             `json:"requires_restart"`
+}
+
+var featureDefinitions = []FeatureDefinition{
+	{
+		Name:            FeatureWebSockets,
+		Description:     "Real-time updates over the /api/ws WebSocket",
+		Default:         true,
+		Stability:       FeatureStabilityStable,
+		RequiresRestart: true,
+	},
+	{
+		Name:        FeatureImageScanning,
+		Description: "Vulnerability scan results for images",
+		Default:     true,
+		Stability:   FeatureStabilityBeta,
+	},
+	{
+		Name:        FeatureHealthRemediation,
+		Description: "Remediation actions run for unhealthy containers",
+		Default:     true,
+		Stability:   FeatureStabilityBeta,
+	},
+	{
+		Name:        FeatureImageHistory,
+		Description: "Per-repository image version history with release notes",
+		Default:     true,
+		Stability:   FeatureStabilityBeta,
+	},
+	{
+		Name:            FeatureMultiHost,
+		Description:     "Managing containers on more than one Docker host",
+		Default:         false,
+		Stability:       FeatureStabilityAlpha,
+		RequiresRestart: true,
+	},
+	{
+		Name:            FeatureOIDC,
+		Description:     "Single sign-on through an OpenID Connect provider",
+		Default:         false,
+		Stability:       FeatureStabilityAlpha,
+		RequiresRestart: true,
+	},
+}
+
+// FeatureDefinitions returns the declared feature flags sorted by name
+func FeatureDefinitions() []FeatureDefinition {
+	definitions := make([]FeatureDefinition, len(featureDefinitions))
+	copy(definitions, featureDefinitions)
+	sort.Slice(definitions, func(i, j int) bool { return definitions[i].Name < definitions[j].Name })
+	return definitions
+}
+
+// LookupFeature returns the definition of a flag by name
+func LookupFeature(name string) (FeatureDefinition, bool) {
+	name = strings.TrimSpace(strings.ToLower(name))
+	for _, definition := range featureDefinitions {
+		if string(definition.Name) == name {
+			return definition, true
+		}
+	}
+	return FeatureDefinition{}, false
+}
+
+// ConfigKey returns the system config key holding the flag's runtime override
+func (f Feature) ConfigKey() string {
+	return featureConfigKeyPrefix + string(f)
+}
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// GitOps reconcile actions on one container
+const (
+	GitOpsActionCreate    = "create"
+	GitOpsActionUpdate    = "update"
+	GitOpsActionPrune     = "prune"
+	GitOpsActionUnchanged = "unchanged"
+	GitOpsActionConflict  = "conflict"
+	GitOpsActionFailed    = "failed"
+)
+
+// GitOpsRunStatus is the outcome of a reconcile run
+type GitOpsRunStatus string
+
+const (
+	GitOpsRunSuccess GitOpsRunStatus = "success"
+	// GitOpsRunPartial runs applied what they could but left conflicting
+	// containers alone or failed on some
+	GitOpsRunPartial GitOpsRunStatus = "partial"
+	GitOpsRunFailed  GitOpsRunStatus = "failed"
+)
+
+// GitOpsResource records that a container is managed by a GitOps source and
+// the configuration the last reconcile left it with, so that manual edits
+// made since can be told apart from changes to the source
+type GitOpsResource struct {
+	ID          int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Source      string `json:"source" gorm:"size:500;not null;index:idx_gitops_resources_source"`
+	ContainerID int    `json:"container_id" gorm:"not null;uniqueIndex:idx_gitops_resources_container_id"`
+	Name        string `json:"name" gorm:"size:255;not null"`
+	// AppliedHash hashes the managed fields of the container as reconciled
+	AppliedHash  string    `json:"applied_hash" gorm:"size:64;not null"`
+	ReconciledAt time.Time `json:"reconciled_at"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+
+	// Relationships
+	Container *Container `json:"-" gorm:"foreignKey:ContainerID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName returns the table name for GitOpsResource model
+func (GitOpsResource) TableName() string {
+	return "gitops_resources"
+}
+
+// GitOpsRun is the report of one reconcile of a GitOps source against the
+// managed containers
+type GitOpsRun struct {
+	ID     int             `json:"id" gorm:"primaryKey;autoIncrement"`
+	Source string          `json:"source" gorm:"size:500;not null"`
+	Status GitOpsRunStatus `json:"status" gorm:"size:20;not null;index:idx_gitops_runs_status"`
+	// Trigger is schedule or manual; RequestedBy is the user who asked
+	Trigger     TriggerType `json:"trigger" gorm:"size:20;not null"`
+	RequestedBy *int        `json:"requested_by,omitempty"`
+	DryRun      bool        `json:"dry_run"`
+	Prune       bool        `json:"prune"`
+	Force       bool        `json:"force"`
+
+	Created   int `json:"created"`
+	Updated   int `json:"updated"`
+	Pruned    int `json:"pruned"`
+	Unchanged int `json:"unchanged"`
+	Conflicts int `json:"conflicts"`
+	Failed    int `json:"failed"`
+
+	Changes      GitOpsChanges `json:"changes" gorm:"type:jsonb;default:'[]'"`
+	ErrorMessage string        `json:"error_message,omitempty" gorm:"type:text"`
+	StartedAt    time.Time     `json:"started_at" gorm:"not null;index:idx_gitops_runs_started_at,sort:desc"`
+	CompletedAt  *time.Time    `json:"completed_at,omitempty"`
+}
+
+// TableName returns the table name for GitOpsRun model
+func (GitOpsRun) TableName() string {
+	return "gitops_runs"
+}
+
+// Drifted reports whether the run found containers differing from the source
+func (r *GitOpsRun) Drifted() bool {
+	return r.Created+r.Updated+r.Pruned+r.Conflicts > 0
+}
+
+// Record adds a change to the run and counts it. Conflicts and failures
+// mark the run, but do not fail it.
+func (r *GitOpsRun) Record(change GitOpsChange) {
+	r.Changes = append(r.Changes, change)
+	switch change.Action {
+	case GitOpsActionCreate:
+		r.Created++
+	case GitOpsActionUpdate:
+		r.Updated++
+	case GitOpsActionPrune:
+		r.Pruned++
+	case GitOpsActionUnchanged:
+		r.Unchanged++
+	case GitOpsActionConflict:
+		r.Conflicts++
+	case GitOpsActionFailed:
+		r.Failed++
+	}
+	if (r.Conflicts > 0 || r.Failed > 0) && r.Status == GitOpsRunSuccess {
+		r.Status = GitOpsRunPartial
+	}
+}
+
+// GitOpsChange is what a reconcile did, or would do in a dry run, to one
+// container. Fields lists the managed fields that differ from the source.
+type GitOpsChange struct {
+	Name        string   `json:"name"`
+	ContainerID int      `json:"container_id,omitempty"`
+	Action      string   `json:"action"`
+	Fields      []string `json:"fields,omitempty"`
+	Reason      string   `json:"reason,omitempty"`
+	// Warnings list what of the spec was skipped or could not be mapped
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// GitOpsChanges is a list of changes stored as JSON
+type GitOpsChanges []GitOpsChange
+
+// Value implements driver.Valuer
+func (c GitOpsChanges) Value() (driver.Value, error) {
+	if c == nil {
+		return "[]", nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements sql.Scanner
+func (c *GitOpsChanges) Scan(value interface{}) error {
+	return scanJSON(value, c, "GitOpsChanges")
+}
@@ -0,0 +1,366 @@
+package model
+
+import (
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"docker-auto/pkg/schedule"
+
+	"gorm.io/gorm"
+)
+
+// ImagePolicy holds update policy defaults for every container running an image.
+// A policy applies to a normalized repository and, optionally, only to tags
+// matching TagPattern. Nil fields leave the setting to the global default.
+type ImagePolicy struct {
+	ID                      int           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Repository              string        `json:"repository" gorm:"not null;size:255;uniqueIndex:idx_image_policies_repository_tag"`
+	TagPattern              string        `json:"tag_pattern" gorm:"not null;size:100;default:'';uniqueIndex:idx_image_policies_repository_tag"`
+	UpdatePolicy            *UpdatePolicy `json:"update_policy,omitempty" gorm:"size:20"`
+	CheckIntervalMinutes    *int          `json:"check_interval_minutes,omitempty"`
+	HoldDownHours           *int          `json:"hold_down_hours,omitempty"` // minimum hours between automatic updates
+	MaintenanceWindows      string        `json:"maintenance_windows,omitempty" gorm:"type:jsonb"`
+	VulnerabilityThreshold  *string       `json:"vulnerability_threshold,omitempty" gorm:"size:20"`
+	Description             string        `json:"description,omitempty" gorm:"type:text"`
+	ReleaseNotesURLTemplate string        `json:"release_notes_url_template,omitempty" gorm:"size:500"`
+	CreatedBy               *int          `json:"created_by,omitempty"`
+	CreatedAt               time.Time     `json:"created_at"`
+	UpdatedAt               time.Time     `json:"updated_at"`
+
+	// Relationships
+	CreatedByUser *User `json:"-" gorm:"foreignKey:CreatedBy"`
+}
+
+// ImagePolicyFilter represents filters for querying image policies
+type ImagePolicyFilter struct {
+	Repository string `json:"repository,omitempty"`
+	Limit      int    `json:"limit,omitempty"`
+	Offset     int    `json:"offset,omitempty"`
+	OrderBy    string `json:"order_by,omitempty"`
+}
+
+// MaintenanceWindow is a recurring time range in which updates may be applied
+type MaintenanceWindow struct {
+	StartTime  string `json:"start_time"`   // HH:MM format
+	EndTime    string `json:"end_time"`     // HH:MM format
+	DaysOfWeek []int  `json:"days_of_week"` // 0=Sunday, 1=Monday, etc.
+	Timezone   string `json:"timezone"`
+}
+
+// PolicySource identifies where an effective policy setting came from
+type PolicySource string
+
+const (
+	PolicySourceContainer PolicySource = "container"
+	PolicySourceStack     PolicySource = "stack"
+	PolicySourceImage     PolicySource = "image"
+	PolicySourceGlobal    PolicySource = "global"
+	PolicySourceCrashLoop PolicySource = "crash_loop"
+	PolicySourceUnmanaged PolicySource = "unmanaged"
+	PolicySourceSuspended PolicySource = "update_suspended"
+)
+
+// Vulnerability thresholds, from least to most permissive
+const (
+	VulnerabilityThresholdNone     = "none"
+	VulnerabilityThresholdLow      = "low"
+	VulnerabilityThresholdMedium   = "medium"
+	VulnerabilityThresholdHigh     = "high"
+	VulnerabilityThresholdCritical = "critical"
+)
+
+// PolicyDefaults are the global settings used when neither the container nor
+// an image policy sets a value
+type PolicyDefaults struct {
+	UpdatePolicy           UpdatePolicy
+	CheckIntervalMinutes   int
+	HoldDownHours          int
+	MaintenanceWindows     []MaintenanceWindow
+	VulnerabilityThreshold string
+}
+
+// EffectivePolicy is the resolved update policy for a container
+type EffectivePolicy struct {
+	UpdatePolicy           UpdatePolicy            `json:"update_policy"`
+	CheckIntervalMinutes   int                     `json:"check_interval_minutes"`
+	HoldDownHours          int                     `json:"hold_down_hours"`
+	MaintenanceWindows     []MaintenanceWindow     `json:"maintenance_windows"`
+	VulnerabilityThreshold string                  `json:"vulnerability_threshold"`
+	Sources                map[string]PolicySource `json:"sources"`
+	ImagePolicyID          *int                    `json:"image_policy_id,omitempty"`
+	StackID                *int                    `json:"stack_id,omitempty"`
+}
+
+// TableName returns the table name for ImagePolicy model
+func (ImagePolicy) TableName() string {
+	return "image_policies"
+}
+
+// BeforeSave normalizes the repository so lookups by container image match
+func (p *ImagePolicy) BeforeSave(tx *gorm.DB) error {
+	p.Repository = NormalizeRepository(p.Repository)
+	p.TagPattern = strings.TrimSpace(p.TagPattern)
+	return nil
+}
+
+// Matches reports whether the policy applies to the given repository and tag
+func (p *ImagePolicy) Matches(repository, tag string) bool {
+	if p.Repository != NormalizeRepository(repository) {
+		return false
+	}
+	if p.TagPattern == "" {
+		return true
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	matched, err := path.Match(p.TagPattern, tag)
+	return err == nil && matched
+}
+
+// ReleaseNotesURL expands the policy's release notes URL template for a
+// version, or returns "" when the policy has none
+func (p *ImagePolicy) ReleaseNotesURL(tag, digest string) string {
+	if p == nil || p.ReleaseNotesURLTemplate == "" {
+		return ""
+	}
+	return ExpandReleaseNotesURL(p.ReleaseNotesURLTemplate, p.Repository, tag, digest)
+}
+
+// GetMaintenanceWindows decodes the policy's maintenance windows
+func (p *ImagePolicy) GetMaintenanceWindows() []MaintenanceWindow {
+	return decodeMaintenanceWindows(p.MaintenanceWindows)
+}
+
+// IsEligibleForAutoUpdate reports whether the updater may apply updates on its own
+func (e *EffectivePolicy) IsEligibleForAutoUpdate() bool {
+	return e != nil && e.UpdatePolicy == UpdatePolicyAuto
+}
+
+// Schedule parses the maintenance window for evaluation
+func (w MaintenanceWindow) Schedule() (*schedule.Window, error) {
+	return schedule.NewWindow(w.DaysOfWeek, w.StartTime, w.EndTime, w.Timezone)
+}
+
+// ParseMaintenanceWindows parses maintenance windows for evaluation. Windows
+// that fail to parse are dropped, so they never open.
+func ParseMaintenanceWindows(windows []MaintenanceWindow) schedule.Windows {
+	parsed := make(schedule.Windows, 0, len(windows))
+	for _, window := range windows {
+		if w, err := window.Schedule(); err == nil {
+			parsed = append(parsed, w)
+		}
+	}
+	return parsed
+}
+
+// NormalizeRepository reduces an image reference to the repository form used
+// to key image policies: lower case, no tag or digest, Docker Hub registry
+// prefix removed and official images under library/.
+func NormalizeRepository(image string) string {
+	repository := strings.ToLower(strings.TrimSpace(image))
+
+	if i := strings.Index(repository, "@"); i >= 0 {
+		repository = repository[:i]
+	}
+	if i := strings.LastIndex(repository, ":"); i > strings.LastIndex(repository, "/") {
+		repository = repository[:i]
+	}
+
+	for _, prefix := range []string{"docker.io/", "index.docker.io/", "registry-1.docker.io/"} {
+		repository = strings.TrimPrefix(repository, prefix)
+	}
+	if repository != "" && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+
+	return repository
+}
+
+// NormalizeImageReference reduces an image reference to the repository:tag
+// form scan results are stored under, so nginx, nginx:latest and
+// docker.io/library/nginx:latest name the same image. A digest is dropped.
+func NormalizeImageReference(ref string) string {
+	image, tag, _ := ParseImageReference(strings.TrimSpace(ref))
+	repository := NormalizeRepository(image)
+	if repository == "" {
+		return ""
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	return repository + ":" + tag
+}
+
+// ExpandReleaseNotesURL fills a release notes URL template. {repository} is
+// replaced with the normalized repository; {tag}, {version} (the tag without a
+// leading "v") and {digest} with path-escaped values.
+func ExpandReleaseNotesURL(template, repository, tag, digest string) string {
+	if tag == "" {
+		tag = "latest"
+	}
+	return strings.NewReplacer(
+		"{repository}", repository,
+		"{tag}", url.PathEscape(tag),
+		"{version}", url.PathEscape(strings.TrimPrefix(tag, "v")),
+		"{digest}", url.PathEscape(digest),
+	).Replace(template)
+}
+
+// SelectImagePolicy picks the most specific policy matching the image: a
+// matching tag pattern beats a repository-wide policy, and a longer pattern
+// beats a shorter one.
+func SelectImagePolicy(policies []*ImagePolicy, repository, tag string) *ImagePolicy {
+	var selected *ImagePolicy
+	for _, policy := range policies {
+		if policy == nil || !policy.Matches(repository, tag) {
+			continue
+		}
+		if selected == nil || len(policy.TagPattern) > len(selected.TagPattern) {
+			selected = policy
+		}
+	}
+	return selected
+}
+
+// ResolveEffectivePolicy computes the policy that applies to a container. Each
+// setting is taken from the container if it sets one, otherwise from its stack,
+// otherwise from the most specific matching image policy, otherwise from the
+// global defaults.
+func ResolveEffectivePolicy(container *Container, stack *Stack, policies []*ImagePolicy, defaults PolicyDefaults) *EffectivePolicy {
+	effective := &EffectivePolicy{
+		UpdatePolicy:           defaults.UpdatePolicy,
+		CheckIntervalMinutes:   defaults.CheckIntervalMinutes,
+		HoldDownHours:          defaults.HoldDownHours,
+		MaintenanceWindows:     defaults.MaintenanceWindows,
+		VulnerabilityThreshold: defaults.VulnerabilityThreshold,
+		Sources: map[string]PolicySource{
+			"update_policy":           PolicySourceGlobal,
+			"check_interval_minutes":  PolicySourceGlobal,
+			"hold_down_hours":         PolicySourceGlobal,
+			"maintenance_windows":     PolicySourceGlobal,
+			"vulnerability_threshold": PolicySourceGlobal,
+		},
+	}
+	if container == nil {
+		return effective
+	}
+
+	if policy := SelectImagePolicy(policies, container.Image, container.Tag); policy != nil {
+		id := policy.ID
+		effective.ImagePolicyID = &id
+
+		if policy.UpdatePolicy != nil && *policy.UpdatePolicy != "" && *policy.UpdatePolicy != UpdatePolicyInherit {
+			effective.UpdatePolicy = *policy.UpdatePolicy
+			effective.Sources["update_policy"] = PolicySourceImage
+		}
+		if policy.CheckIntervalMinutes != nil {
+			effective.CheckIntervalMinutes = *policy.CheckIntervalMinutes
+			effective.Sources["check_interval_minutes"] = PolicySourceImage
+		}
+		if policy.HoldDownHours != nil {
+			effective.HoldDownHours = *policy.HoldDownHours
+			effective.Sources["hold_down_hours"] = PolicySourceImage
+		}
+		if policy.MaintenanceWindows != "" {
+			effective.MaintenanceWindows = policy.GetMaintenanceWindows()
+			effective.Sources["maintenance_windows"] = PolicySourceImage
+		}
+		if policy.VulnerabilityThreshold != nil && *policy.VulnerabilityThreshold != "" {
+			effective.VulnerabilityThreshold = *policy.VulnerabilityThreshold
+			effective.Sources["vulnerability_threshold"] = PolicySourceImage
+		}
+	}
+
+	if stack != nil {
+		id := stack.ID
+		effective.StackID = &id
+
+		if stack.UpdatePolicy != nil && *stack.UpdatePolicy != "" && *stack.UpdatePolicy != UpdatePolicyInherit {
+			effective.UpdatePolicy = *stack.UpdatePolicy
+			effective.Sources["update_policy"] = PolicySourceStack
+		}
+		if stack.CheckIntervalMinutes != nil {
+			effective.CheckIntervalMinutes = *stack.CheckIntervalMinutes
+			effective.Sources["check_interval_minutes"] = PolicySourceStack
+		}
+		if stack.HoldDownHours != nil {
+			effective.HoldDownHours = *stack.HoldDownHours
+			effective.Sources["hold_down_hours"] = PolicySourceStack
+		}
+		if stack.MaintenanceWindows != "" {
+			effective.MaintenanceWindows = stack.GetMaintenanceWindows()
+			effective.Sources["maintenance_windows"] = PolicySourceStack
+		}
+		if stack.VulnerabilityThreshold != nil && *stack.VulnerabilityThreshold != "" {
+			effective.VulnerabilityThreshold = *stack.VulnerabilityThreshold
+			effective.Sources["vulnerability_threshold"] = PolicySourceStack
+		}
+	}
+
+	if container.UpdatePolicy != "" && container.UpdatePolicy != UpdatePolicyInherit {
+		effective.UpdatePolicy = container.UpdatePolicy
+		effective.Sources["update_policy"] = PolicySourceContainer
+	}
+	if container.CheckIntervalMinutes != nil {
+		effective.CheckIntervalMinutes = *container.CheckIntervalMinutes
+		effective.Sources["check_interval_minutes"] = PolicySourceContainer
+	}
+	if container.HoldDownHours != nil {
+		effective.HoldDownHours = *container.HoldDownHours
+		effective.Sources["hold_down_hours"] = PolicySourceContainer
+	}
+	if container.MaintenanceWindows != "" {
+		effective.MaintenanceWindows = decodeMaintenanceWindows(container.MaintenanceWindows)
+		effective.Sources["maintenance_windows"] = PolicySourceContainer
+	}
+	if container.VulnerabilityThreshold != "" {
+		effective.VulnerabilityThreshold = container.VulnerabilityThreshold
+		effective.Sources["vulnerability_threshold"] = PolicySourceContainer
+	}
+
+	// An unacknowledged crash loop holds automatic updates
+	if container.CrashLoopHold && (effective.UpdatePolicy == UpdatePolicyAuto || effective.UpdatePolicy == UpdatePolicyScheduled) {
+		effective.UpdatePolicy = UpdatePolicyManual
+		effective.Sources["update_policy"] = PolicySourceCrashLoop
+	}
+
+	// Repeated failures suspend automatic updates the same way
+	if container.UpdateSuspended && (effective.UpdatePolicy == UpdatePolicyAuto || effective.UpdatePolicy == UpdatePolicyScheduled) {
+		effective.UpdatePolicy = UpdatePolicyManual
+		effective.Sources["update_policy"] = PolicySourceSuspended
+	}
+
+	// Containers whose discovery labels were removed are left alone
+	if container.Unmanaged {
+		effective.UpdatePolicy = UpdatePolicyDisabled
+		effective.Sources["update_policy"] = PolicySourceUnmanaged
+	}
+
+	return effective
+}
+
+// GetValidVulnerabilityThresholds returns all valid vulnerability thresholds
+func GetValidVulnerabilityThresholds() []string {
+	return []string{
+		VulnerabilityThresholdNone,
+		VulnerabilityThresholdLow,
+		VulnerabilityThresholdMedium,
+		VulnerabilityThresholdHigh,
+		VulnerabilityThresholdCritical,
+	}
+}
+
+func decodeMaintenanceWindows(raw string) []MaintenanceWindow {
+	windows := []MaintenanceWindow{}
+	if raw == "" {
+		return windows
+	}
+	if err := json.Unmarshal([]byte(raw), &windows); err != nil {
+		return []MaintenanceWindow{}
+	}
+	return windows
+}
@@ -0,0 +1,190 @@
+package model
+
+import "testing"
+
+func updatePolicyPtr(policy UpdatePolicy) *UpdatePolicy {
+	return &policy
+}
+
+func intPtr(value int) *int {
+	return &value
+}
+
+func TestResolveEffectivePolicyUpdatePolicyPrecedence(t *testing.T) {
+	defaults := PolicyDefaults{UpdatePolicy: UpdatePolicyManual, CheckIntervalMinutes: 60}
+	repoPolicy := &ImagePolicy{ID: 1, Repository: "library/nginx", UpdatePolicy: updatePolicyPtr(UpdatePolicyScheduled)}
+	tagPolicy := &ImagePolicy{ID: 2, Repository: "library/nginx", TagPattern: "1.*", UpdatePolicy: updatePolicyPtr(UpdatePolicyAuto)}
+	inheritPolicy := &ImagePolicy{ID: 3, Repository: "library/nginx", UpdatePolicy: updatePolicyPtr(UpdatePolicyInherit)}
+	otherPolicy := &ImagePolicy{ID: 4, Repository: "library/redis", UpdatePolicy: updatePolicyPtr(UpdatePolicyAuto)}
+	stack := &Stack{ID: 1, UpdatePolicy: updatePolicyPtr(UpdatePolicyDisabled)}
+	inheritStack := &Stack{ID: 2, UpdatePolicy: updatePolicyPtr(UpdatePolicyInherit)}
+
+	tests := []struct {
+		name       string
+		container  Container
+		stack      *Stack
+		policies   []*ImagePolicy
+		wantPolicy UpdatePolicy
+		wantSource PolicySource
+	}{
+		{
+			name:       "new container inherits the global default",
+			container:  Container{Image: "nginx", Tag: "latest", UpdatePolicy: UpdatePolicyInherit},
+			wantPolicy: UpdatePolicyManual,
+			wantSource: PolicySourceGlobal,
+		},
+		{
+			name:       "unset container policy inherits the global default",
+			container:  Container{Image: "nginx", Tag: "latest"},
+			wantPolicy: UpdatePolicyManual,
+			wantSource: PolicySourceGlobal,
+		},
+		{
+			name:       "image policy of another repository is ignored",
+			container:  Container{Image: "nginx", Tag: "latest", UpdatePolicy: UpdatePolicyInherit},
+			policies:   []*ImagePolicy{otherPolicy},
+			wantPolicy: UpdatePolicyManual,
+			wantSource: PolicySourceGlobal,
+		},
+		{
+			name:       "image policy beats the global default",
+			container:  Container{Image: "docker.io/library/nginx", Tag: "latest", UpdatePolicy: UpdatePolicyInherit},
+			policies:   []*ImagePolicy{repoPolicy},
+			wantPolicy: UpdatePolicyScheduled,
+			wantSource: PolicySourceImage,
+		},
+		{
+			name:       "matching tag pattern beats the repository policy",
+			container:  Container{Image: "nginx", Tag: "1.25", UpdatePolicy: UpdatePolicyInherit},
+			policies:   []*ImagePolicy{repoPolicy, tagPolicy},
+			wantPolicy: UpdatePolicyAuto,
+			wantSource: PolicySourceImage,
+		},
+		{
+			name:       "image policy set to inherit defers to the global default",
+			container:  Container{Image: "nginx", Tag: "latest", UpdatePolicy: UpdatePolicyInherit},
+			policies:   []*ImagePolicy{inheritPolicy},
+			wantPolicy: UpdatePolicyManual,
+			wantSource: PolicySourceGlobal,
+		},
+		{
+			name:       "stack beats the image policy",
+			container:  Container{Image: "nginx", Tag: "1.25", UpdatePolicy: UpdatePolicyInherit},
+			stack:      stack,
+			policies:   []*ImagePolicy{tagPolicy},
+			wantPolicy: UpdatePolicyDisabled,
+			wantSource: PolicySourceStack,
+		},
+		{
+			name:       "stack set to inherit defers to the image policy",
+			container:  Container{Image: "nginx", Tag: "1.25", UpdatePolicy: UpdatePolicyInherit},
+			stack:      inheritStack,
+			policies:   []*ImagePolicy{tagPolicy},
+			wantPolicy: UpdatePolicyAuto,
+			wantSource: PolicySourceImage,
+		},
+		{
+			name:       "container beats the stack",
+			container:  Container{Image: "nginx", Tag: "1.25", UpdatePolicy: UpdatePolicyAuto},
+			stack:      stack,
+			policies:   []*ImagePolicy{repoPolicy},
+			wantPolicy: UpdatePolicyAuto,
+			wantSource: PolicySourceContainer,
+		},
+		{
+			name:       "crash loop holds an inherited automatic policy",
+			container:  Container{Image: "nginx", Tag: "1.25", UpdatePolicy: UpdatePolicyInherit, CrashLoopHold: true},
+			policies:   []*ImagePolicy{tagPolicy},
+			wantPolicy: UpdatePolicyManual,
+			wantSource: PolicySourceCrashLoop,
+		},
+		{
+			name:       "crash loop leaves a disabled policy alone",
+			container:  Container{Image: "nginx", Tag: "latest", UpdatePolicy: UpdatePolicyDisabled, CrashLoopHold: true},
+			wantPolicy: UpdatePolicyDisabled,
+			wantSource: PolicySourceContainer,
+		},
+		{
+			name:       "suspension holds a scheduled container policy",
+			container:  Container{Image: "nginx", Tag: "latest", UpdatePolicy: UpdatePolicyScheduled, UpdateSuspended: true},
+			wantPolicy: UpdatePolicyManual,
+			wantSource: PolicySourceSuspended,
+		},
+		{
+			name:       "unmanaged beats everything",
+			container:  Container{Image: "nginx", Tag: "latest", UpdatePolicy: UpdatePolicyAuto, CrashLoopHold: true, Unmanaged: true},
+			stack:      stack,
+			wantPolicy: UpdatePolicyDisabled,
+			wantSource: PolicySourceUnmanaged,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			container := tt.container
+			effective := ResolveEffectivePolicy(&container, tt.stack, tt.policies, defaults)
+
+			if effective.UpdatePolicy != tt.wantPolicy {
+				t.Errorf("update policy = %s, want %s", effective.UpdatePolicy, tt.wantPolicy)
+			}
+			if source := effective.Sources["update_policy"]; source != tt.wantSource {
+				t.Errorf("update policy source = %s, want %s", source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestResolveEffectivePolicySettingsResolveIndependently(t *testing.T) {
+	defaults := PolicyDefaults{UpdatePolicy: UpdatePolicyManual, CheckIntervalMinutes: 60, HoldDownHours: 0, VulnerabilityThreshold: VulnerabilityThresholdNone}
+	threshold := VulnerabilityThresholdHigh
+	policies := []*ImagePolicy{{ID: 7, Repository: "library/nginx", CheckIntervalMinutes: intPtr(30), VulnerabilityThreshold: &threshold}}
+	stack := &Stack{ID: 3, HoldDownHours: intPtr(12)}
+	container := &Container{Image: "nginx", Tag: "latest", UpdatePolicy: UpdatePolicyAuto, CheckIntervalMinutes: intPtr(5)}
+
+	effective := ResolveEffectivePolicy(container, stack, policies, defaults)
+
+	want := map[string]PolicySource{
+		"update_policy":           PolicySourceContainer,
+		"check_interval_minutes":  PolicySourceContainer,
+		"hold_down_hours":         PolicySourceStack,
+		"maintenance_windows":     PolicySourceGlobal,
+		"vulnerability_threshold": PolicySourceImage,
+	}
+	for setting, source := range want {
+		if effective.Sources[setting] != source {
+			t.Errorf("%s source = %s, want %s", setting, effective.Sources[setting], source)
+		}
+	}
+	if effective.CheckIntervalMinutes != 5 || effective.HoldDownHours != 12 || effective.VulnerabilityThreshold != VulnerabilityThresholdHigh {
+		t.Errorf("effective = %d minutes, %d hours, %s threshold, want 5, 12, high",
+			effective.CheckIntervalMinutes, effective.HoldDownHours, effective.VulnerabilityThreshold)
+	}
+	if effective.ImagePolicyID == nil || *effective.ImagePolicyID != 7 || effective.StackID == nil || *effective.StackID != 3 {
+		t.Errorf("image policy %v and stack %v, want 7 and 3", effective.ImagePolicyID, effective.StackID)
+	}
+}
+
+func TestNormalizeImageReference(t *testing.T) {
+	tests := []struct {
+		ref  string
+		want string
+	}{
+		{"nginx", "library/nginx:latest"},
+		{"nginx:latest", "library/nginx:latest"},
+		{"docker.io/library/nginx:latest", "library/nginx:latest"},
+		{"index.docker.io/library/nginx", "library/nginx:latest"},
+		{" NGINX:1.25 ", "library/nginx:1.25"},
+		{"nginx:1.25@sha256:abc", "library/nginx:1.25"},
+		{"nginx@sha256:abc", "library/nginx:latest"},
+		{"bitnami/redis:7.2", "bitnami/redis:7.2"},
+		{"registry.example.com:5000/team/app", "registry.example.com:5000/team/app:latest"},
+		{"registry.example.com:5000/team/app:v1-RC", "registry.example.com:5000/team/app:v1-RC"},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeImageReference(tt.ref); got != tt.want {
+			t.Errorf("NormalizeImageReference(%q) = %q, want %q", tt.ref, got, tt.want)
+		}
+	}
+}
@@ -21,6 +21,31 @@ type ImageVersion struct {
 	CheckedAt    time.Time `json:"checked_at" gorm:"index:idx_image_versions_checked_at"`
 	IsLatest     bool      `json:"is_latest" gorm:"not null;default:false;index:idx_image_versions_is_latest"`
 	Metadata     string    `json:"metadata,omitempty" gorm:"type:jsonb;default:'{}'"`
+
+	// Generation advances on every write and invalidation of the image's
+	// rows; a check only stores what it fetched if no other write or
+	// invalidation happened since it started
+	Generation int64 `json:"generation" gorm:"not null;default:0"`
+	// InvalidatedAt is set when the row was flushed and cleared when it is
+	// written again; invalidated rows are not served from the cache
+	InvalidatedAt *time.Time `json:"invalidated_at,omitempty"`
+}
+
+// ImageVersionCacheTTL is how long a cached image version is served before
+// it is checked again
+const ImageVersionCacheTTL = 6 * time.Hour
+
+// ImageVersionRecord is a tag and digest of a repository seen by the update
+// checker. Records outlive the cached ImageVersion rows, which only track the
+// current digest of each tag, and make up the repository's version history.
+type ImageVersionRecord struct {
+	ID              int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Repository      string    `json:"repository" gorm:"not null;size:255;uniqueIndex:idx_image_version_records_version;index:idx_image_version_records_first_seen,priority:1"`
+	Tag             string    `json:"tag" gorm:"not null;size:100;uniqueIndex:idx_image_version_records_version"`
+	Digest          string    `json:"digest" gorm:"not null;size:71;default:'';uniqueIndex:idx_image_version_records_version"`
+	FirstSeenAt     time.Time `json:"first_seen_at" gorm:"not null;index:idx_image_version_records_first_seen,priority:2,sort:desc"`
+	LastSeenAt      time.Time `json:"last_seen_at" gorm:"not null"`
+	ReleaseNotesURL string    `json:"release_notes_url,omitempty" gorm:"size:500"`
 }
 
 // ImageVersionFilter represents filters for querying image versions
@@ -51,11 +76,47 @@ type ImageSummary struct {
 	LastPublished *time.Time `json:"last_published,omitempty"`
 }
 
+// ImageCacheStats summarizes the image version cache
+type ImageCacheStats struct {
+	Entries      int64 `json:"entries"`
+	StaleEntries int64 `json:"stale_entries"`
+	Invalidated  int64 `json:"invalidated"`
+	// OldestCheckedAt is the check time of the oldest entry not invalidated
+	OldestCheckedAt *time.Time                  `json:"oldest_checked_at,omitempty"`
+	Repositories    []ImageCacheRepositoryStats `json:"repositories"`
+}
+
+// ImageCacheRepositoryStats counts the cached versions of one image
+type ImageCacheRepositoryStats struct {
+	ImageName     string    `json:"image_name"`
+	RegistryURL   string    `json:"registry_url"`
+	Entries       int64     `json:"entries"`
+	Generation    int64     `json:"generation"`
+	LastCheckedAt time.Time `json:"last_checked_at"`
+}
+
 // TableName returns the table name for ImageVersion model
 func (ImageVersion) TableName() string {
 	return "image_versions"
 }
 
+// TableName returns the table name for ImageVersionRecord model
+func (ImageVersionRecord) TableName() string {
+	return "image_version_records"
+}
+
+// Matches reports whether the record is the version deployed by the image
+// reference. Digests are compared when both sides have one, tags otherwise.
+func (r *ImageVersionRecord) Matches(tag, digest string) bool {
+	if r.Digest != "" && digest != "" {
+		return r.Digest == digest
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	return r.Tag == tag
+}
+
 // GetFullImageName returns full image name with registry
 func (iv *ImageVersion) GetFullImageName() string {
 	if iv.RegistryURL == "" || iv.RegistryURL == "docker.io" {
@@ -0,0 +1,45 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ReservedLabelPrefix prefixes the labels this application sets on the
+// containers it creates; users cannot set or remove them
+const ReservedLabelPrefix = "docker-auto."
+
+const (
+	maxLabelKeyLength   = 255
+	maxLabelValueLength = 4096
+)
+
+// labelKeyPattern follows Docker's label key guidelines: alphanumerics
+// separated by dots, dashes, underscores or slashes
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]*[A-Za-z0-9])?$`)
+
+// ValidateLabelKey validates a container label key users may manage
+func ValidateLabelKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("label key is required")
+	}
+	if len(key) > maxLabelKeyLength {
+		return fmt.Errorf("label key %q is longer than %d characters", key, maxLabelKeyLength)
+	}
+	if !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("label key %q may only contain letters, digits, '.', '-', '_' and '/', and must start and end with a letter or digit", key)
+	}
+	if strings.HasPrefix(strings.ToLower(key), ReservedLabelPrefix) {
+		return fmt.Errorf("label key %q uses the reserved %s prefix", key, ReservedLabelPrefix)
+	}
+	return nil
+}
+
+// ValidateLabelValue validates a container label value
+func ValidateLabelValue(key, value string) error {
+	if len(value) > maxLabelValueLength {
+		return fmt.Errorf("value of label %q is longer than %d characters", key, maxLabelValueLength)
+	}
+	return nil
+}
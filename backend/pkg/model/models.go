@@ -1,30 +1,97 @@
 package model
 
 import (
+	"strconv"
+	"time"
+
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
+// SchemaVersion is the version of the database schema these models describe.
+// Bump it with any model change that existing deployments must migrate to.
+const SchemaVersion = 1
+
 // AllModels returns a slice of all model structs for auto-migration
 func AllModels() []interface{} {
 	return []interface{}{
 		&User{},
+		&Role{},
 		&UserSession{},
+		&APIToken{},
 		&ActivityLog{},
+		&Stack{},
+		&Team{},
+		&TeamMember{},
+		&TeamQuota{},
+		&DockerHost{},
 		&Container{},
+		&ContainerDependency{},
+		&ContainerPermission{},
+		&ContainerTemplate{},
 		&RegistryCredentials{},
 		&UpdateHistory{},
+		&UpdateNote{},
+		&ApprovalPolicy{},
+		&BulkOperation{},
 		&ImageVersion{},
+		&ImageVersionRecord{},
+		&ImagePolicy{},
+		&ScanResult{},
+		&SecurityPostureReport{},
+		&GitOpsResource{},
+		&GitOpsRun{},
+		&ContainerChange{},
+		&ChangeFeedCursor{},
+		&ContainerHealthState{},
+		&ContainerHealthCheck{},
+		&ContainerMetric{},
+		&StatusPage{},
+		&VolumeUsageSample{},
 		&SystemConfig{},
 		&NotificationTemplate{},
 		&NotificationLog{},
+		&NotificationChannel{},
+		&PendingNotification{},
 		&ScheduledTask{},
 		&TaskExecutionLog{},
+		&SchedulerEventLog{},
 	}
 }
 
-// AutoMigrate runs auto-migration for all models
+// AutoMigrate runs auto-migration for all models, stores the built-in roles
+// and container templates, grants container creators manage on their
+// containers and records the schema version migrated to
 func AutoMigrate(db *gorm.DB) error {
-	return db.AutoMigrate(AllModels()...)
+	if err := db.AutoMigrate(AllModels()...); err != nil {
+		return err
+	}
+	if err := MigrateRoles(db); err != nil {
+		return err
+	}
+	if err := MigrateContainerTemplates(db); err != nil {
+		return err
+	}
+	if err := MigrateContainerPermissions(db); err != nil {
+		return err
+	}
+	return recordSchemaVersion(db)
+}
+
+// recordSchemaVersion stores SchemaVersion under ConfigKeyAppSchemaVersion
+func recordSchemaVersion(db *gorm.DB) error {
+	now := time.Now().UTC()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "config_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"config_value", "updated_at"}),
+	}).Create(&SystemConfig{
+		ConfigKey:   ConfigKeyAppSchemaVersion,
+		ConfigValue: strconv.Itoa(SchemaVersion),
+		Description: "Database schema version migrated to",
+		IsSystem:    true,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}).Error
 }
 
 // DatabaseSeeder represents the interface for seeding database with initial data
@@ -137,6 +204,7 @@ func GetModelTableNames() map[string]string {
 		"SystemConfig":         SystemConfig{}.TableName(),
 		"NotificationTemplate": NotificationTemplate{}.TableName(),
 		"NotificationLog":      NotificationLog{}.TableName(),
+		"NotificationChannel":  NotificationChannel{}.TableName(),
 		"ScheduledTask":        ScheduledTask{}.TableName(),
 		"TaskExecutionLog":     TaskExecutionLog{}.TableName(),
 	}
@@ -54,6 +54,9 @@ const (
 	NotificationTypeSecurityUpdate   NotificationType = "security_update"
 	NotificationTypeSystemMaintenance NotificationType = "system_maintenance"
 	NotificationTypeContainerUpdate   NotificationType = "container_update"
+	NotificationTypeDiskUsage         NotificationType = "disk_usage"
+	NotificationTypeHealthCheck       NotificationType = "health_check"
+	NotificationTypeUpdateDigest      NotificationType = "update_digest"
 )
 
 // NotificationStatus defines notification status
@@ -75,6 +78,31 @@ const (
 	NotificationPriorityCritical NotificationPriority = "critical"
 )
 
+// Rank orders priorities from low to critical; an unset priority ranks as
+// normal and an unknown one as low
+func (p NotificationPriority) Rank() int {
+	switch p {
+	case NotificationPriorityLow:
+		return 0
+	case NotificationPriorityNormal, "":
+		return 1
+	case NotificationPriorityHigh:
+		return 2
+	case NotificationPriorityCritical:
+		return 3
+	}
+	return 0
+}
+
+// IsValidNotificationPriority reports whether priority is a known priority
+func IsValidNotificationPriority(priority string) bool {
+	switch NotificationPriority(priority) {
+	case NotificationPriorityLow, NotificationPriorityNormal, NotificationPriorityHigh, NotificationPriorityCritical:
+		return true
+	}
+	return false
+}
+
 // Notification represents a runtime notification (not stored in database)
 type Notification struct {
 	Type     NotificationType     `json:"type"`
@@ -135,13 +163,14 @@ type NotificationConfig struct {
 
 // EmailConfig represents email notification configuration
 type EmailConfig struct {
-	SMTPHost     string `json:"smtp_host"`
-	SMTPPort     int    `json:"smtp_port"`
-	SMTPUsername string `json:"smtp_username"`
-	SMTPPassword string `json:"smtp_password"`
-	FromEmail    string `json:"from_email"`
-	FromName     string `json:"from_name"`
-	UseTLS       bool   `json:"use_tls"`
+	SMTPHost     string   `json:"smtp_host"`
+	SMTPPort     int      `json:"smtp_port"`
+	SMTPUsername string   `json:"smtp_username"`
+	SMTPPassword string   `json:"smtp_password"`
+	FromEmail    string   `json:"from_email"`
+	FromName     string   `json:"from_name"`
+	To           []string `json:"to,omitempty"`
+	UseTLS       bool     `json:"use_tls"`
 }
 
 // WebhookConfig represents webhook notification configuration
@@ -150,6 +179,7 @@ type WebhookConfig struct {
 	Method  string            `json:"method"`
 	Headers map[string]string `json:"headers,omitempty"`
 	Timeout int               `json:"timeout"`
+	Secret  string            `json:"secret,omitempty"` // Signs bodies with HMAC-SHA256 when set
 }
 
 // SlackConfig represents Slack notification configuration
@@ -0,0 +1,138 @@
+package model
+
+import (
+	"net/url"
+	"strings"
+	"time"
+)
+
+// NotificationChannelType is how a notification channel delivers
+type NotificationChannelType string
+
+const (
+	NotificationChannelWebhook NotificationChannelType = "webhook"
+	NotificationChannelEmail   NotificationChannelType = "email"
+	NotificationChannelSlack   NotificationChannelType = "slack"
+)
+
+// redactedSecret replaces secrets in channel settings returned by the API
+const redactedSecret = "********"
+
+// IsValidNotificationChannelType reports whether channelType is supported
+func IsValidNotificationChannelType(channelType string) bool {
+	switch NotificationChannelType(channelType) {
+	case NotificationChannelWebhook, NotificationChannelEmail, NotificationChannelSlack:
+		return true
+	}
+	return false
+}
+
+// GetDeliverableNotificationTypes returns the notification types channels can
+// subscribe to
+func GetDeliverableNotificationTypes() []NotificationType {
+	return []NotificationType{
+		NotificationTypeContainerUpdate,
+		NotificationTypeImageUpdate,
+		NotificationTypeSecurityUpdate,
+		NotificationTypeHealthCheck,
+		NotificationTypeSystemMaintenance,
+		NotificationTypeDiskUsage,
+		NotificationTypeBackup,
+	}
+}
+
+// NotificationChannel delivers notifications outside the app. Every channel
+// receives the notifications raised by the system, such as scheduled task
+// results; a user's channel also receives that user's notifications. Global
+// channels, without a user, are managed by admins. Settings are stored
+// encrypted in SettingsEncrypted.
+type NotificationChannel struct {
+	ID                int                     `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID            *int64                  `json:"user_id,omitempty" gorm:"index:idx_notification_channels_user_id"`
+	Name              string                  `json:"name" gorm:"size:100;not null"`
+	Type              NotificationChannelType `json:"type" gorm:"size:20;not null"`
+	Enabled           bool                    `json:"enabled" gorm:"not null"`
+	NotificationTypes StringList              `json:"notification_types" gorm:"type:jsonb;default:'[]'"` // Empty matches every type
+	MinPriority       NotificationPriority    `json:"min_priority" gorm:"size:20;not null;default:'low'"`
+	Settings          NotificationConfig      `json:"settings" gorm:"-"`
+	SettingsEncrypted string                  `json:"-" gorm:"type:text;not null"`
+	CreatedBy         *int                    `json:"created_by,omitempty"`
+	CreatedAt         time.Time               `json:"created_at"`
+	UpdatedAt         time.Time               `json:"updated_at"`
+
+	// Outcome of the latest delivery, after retries
+	LastDeliveryAt     *time.Time         `json:"last_delivery_at,omitempty"`
+	LastDeliveryStatus NotificationStatus `json:"last_delivery_status,omitempty" gorm:"size:20"`
+	LastDeliveryError  string             `json:"last_delivery_error,omitempty" gorm:"type:text"`
+	FailedDeliveries   int                `json:"failed_deliveries" gorm:"not null;default:0"` // Consecutive failures
+}
+
+// TableName returns the table name for NotificationChannel model
+func (NotificationChannel) TableName() string {
+	return "notification_channels"
+}
+
+// IsGlobal reports whether the channel belongs to no user
+func (c *NotificationChannel) IsGlobal() bool {
+	return c.UserID == nil
+}
+
+// Matches reports whether the channel delivers the notification: it is
+// enabled, subscribed to the type and the priority is at least MinPriority
+func (c *NotificationChannel) Matches(notification *Notification) bool {
+	if !c.Enabled || notification.Priority.Rank() < c.MinPriority.Rank() {
+		return false
+	}
+	if len(c.NotificationTypes) == 0 {
+		return true
+	}
+	for _, notificationType := range c.NotificationTypes {
+		if NotificationType(notificationType) == notification.Type {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact hides the secrets in the channel's settings: the SMTP password,
+// the webhook signing secret and the path of the Slack webhook URL, which
+// authorizes posting
+func (c *NotificationChannel) Redact() {
+	if email := c.Settings.Email; email != nil {
+		redacted := *email
+		if redacted.SMTPPassword != "" {
+			redacted.SMTPPassword = redactedSecret
+		}
+		c.Settings.Email = &redacted
+	}
+	if webhook := c.Settings.Webhook; webhook != nil {
+		redacted := *webhook
+		if redacted.Secret != "" {
+			redacted.Secret = redactedSecret
+		}
+		c.Settings.Webhook = &redacted
+	}
+	if slack := c.Settings.Slack; slack != nil {
+		redacted := *slack
+		if parsed, err := url.Parse(redacted.WebhookURL); err == nil && parsed.Host != "" {
+			redacted.WebhookURL = parsed.Scheme + "://" + parsed.Host + "/" + redactedSecret
+		} else if redacted.WebhookURL != "" {
+			redacted.WebhookURL = redactedSecret
+		}
+		c.Settings.Slack = &redacted
+	}
+}
+
+// IsRedactedSecret reports whether value is a secret as returned by Redact,
+// which an update sends back unchanged
+func IsRedactedSecret(value string) bool {
+	return strings.HasSuffix(value, redactedSecret)
+}
+
+// NotificationChannelFilter represents filters for querying notification
+// channels. Without UserID and Global every channel matches.
+type NotificationChannelFilter struct {
+	UserID      *int64 `json:"user_id,omitempty"` // The user's channels
+	Global      bool   `json:"global,omitempty"`  // The global channels, in addition to the user's
+	EnabledOnly bool   `json:"enabled_only,omitempty"`
+}
@@ -0,0 +1,102 @@
+package model
+
+import "time"
+
+// DigestInterval is how often a user's buffered update notifications are
+// sent as one digest
+type DigestInterval string
+
+const (
+	DigestOff    DigestInterval = "off"
+	DigestHourly DigestInterval = "hourly"
+	DigestDaily  DigestInterval = "daily"
+)
+
+// IsValidDigestInterval reports whether interval is a known digest interval
+func IsValidDigestInterval(interval string) bool {
+	switch DigestInterval(interval) {
+	case DigestOff, DigestHourly, DigestDaily:
+		return true
+	}
+	return false
+}
+
+// Period returns the time between digests, zero when digests are off
+func (d DigestInterval) Period() time.Duration {
+	switch d {
+	case DigestHourly:
+		return time.Hour
+	case DigestDaily:
+		return 24 * time.Hour
+	}
+	return 0
+}
+
+// IsEnabled reports whether notifications are buffered for a digest
+func (d DigestInterval) IsEnabled() bool {
+	return d.Period() > 0
+}
+
+// PendingNotification is an update notification buffered for a user's next
+// digest
+type PendingNotification struct {
+	ID             int64                `json:"id" gorm:"primaryKey;autoIncrement"`
+	UserID         int64                `json:"user_id" gorm:"not null;index:idx_pending_notifications_user_id"`
+	Type           NotificationType     `json:"type" gorm:"size:50;not null"`
+	Priority       NotificationPriority `json:"priority" gorm:"size:20;not null"`
+	Schema         PayloadSchema        `json:"schema" gorm:"size:50;not null"`
+	Title          string               `json:"title" gorm:"size:255;not null"`
+	Count          int                  `json:"count" gorm:"not null;default:0"`
+	ContainerNames StringList           `json:"container_names" gorm:"type:jsonb;default:'[]'"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"index:idx_pending_notifications_created_at"`
+}
+
+// TableName returns the table name for PendingNotification model
+func (PendingNotification) TableName() string {
+	return "pending_notifications"
+}
+
+// Digestible reports whether the notification may wait for a digest: update
+// available and update completed notifications below high priority. Failures
+// and health alerts are always sent at once.
+func (n *Notification) Digestible() bool {
+	if n.Priority.Rank() >= NotificationPriorityHigh.Rank() {
+		return false
+	}
+	schema, _ := n.Data["schema"].(string)
+	switch PayloadSchema(schema) {
+	case PayloadSchemaUpdateAvailable, PayloadSchemaUpdateCompleted:
+		return true
+	}
+	return false
+}
+
+// NewPendingNotification buffers a digestible notification for a user,
+// keeping the number of containers and their names
+func NewPendingNotification(userID int64, notification *Notification) *PendingNotification {
+	pending := &PendingNotification{
+		UserID:         userID,
+		Type:           notification.Type,
+		Priority:       notification.Priority,
+		Title:          notification.Title,
+		ContainerNames: StringList{},
+	}
+
+	payload, err := DecodeNotificationPayload(notification.Data)
+	if err != nil {
+		return pending
+	}
+	pending.Schema = payload.Header().Schema
+
+	switch p := payload.(type) {
+	case *UpdateAvailablePayload:
+		pending.Count = p.TotalUpdates
+		for _, update := range p.Updates {
+			pending.ContainerNames = append(pending.ContainerNames, update.ContainerName)
+		}
+	case *UpdateCompletedPayload:
+		pending.Count = p.SuccessfulUpdates
+		pending.ContainerNames = append(pending.ContainerNames, p.Containers...)
+	}
+	return pending
+}
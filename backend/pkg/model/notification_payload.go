@@ -0,0 +1,759 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrUnknownPayloadSchema is returned when notification data carries no
+// schema header, or a schema/version this build does not know
+var ErrUnknownPayloadSchema = errors.New("unknown notification payload schema")
+
+// PayloadSchema identifies the shape of Notification.Data
+type PayloadSchema string
+
+const (
+	PayloadSchemaUpdateAvailable PayloadSchema = "update_available"
+	PayloadSchemaUpdateCompleted PayloadSchema = "update_completed"
+	PayloadSchemaUpdateFailed    PayloadSchema = "update_failed"
+	PayloadSchemaHealthAlert     PayloadSchema = "health_alert"
+	PayloadSchemaCleanupSummary  PayloadSchema = "cleanup_summary"
+	PayloadSchemaBackupSummary   PayloadSchema = "backup_summary"
+	PayloadSchemaSecurityAlert   PayloadSchema = "security_alert"
+	PayloadSchemaVolumeAlert     PayloadSchema = "volume_alert"
+	PayloadSchemaCrashLoop       PayloadSchema = "crash_loop"
+	PayloadSchemaBackupRestore   PayloadSchema = "backup_restore"
+	PayloadSchemaUpdateDigest    PayloadSchema = "update_digest"
+	PayloadSchemaImageDrift      PayloadSchema = "image_drift"
+	PayloadSchemaUpdateSuspended PayloadSchema = "update_suspended"
+)
+
+// Health alert events
+const (
+	HealthAlertEventUnhealthy = "unhealthy"
+	HealthAlertEventRecovery  = "recovery"
+)
+
+// Volume alert rules
+const (
+	VolumeAlertRuleGrowth        = "volume_growth"
+	VolumeAlertRuleDataRootSpace = "data_root_free_space"
+)
+
+// PayloadHeader is embedded in every payload. Consumers switch on Schema and
+// SchemaVersion; fields are only ever added within a version, never moved.
+type PayloadHeader struct {
+	Schema        PayloadSchema `json:"schema"`
+	SchemaVersion int           `json:"schema_version"`
+}
+
+// NotificationPayload is implemented by every typed notification payload
+type NotificationPayload interface {
+	Header() PayloadHeader
+	Summary() string
+}
+
+// Header returns the payload's schema header
+func (h PayloadHeader) Header() PayloadHeader {
+	return h
+}
+
+// ImageUpdateEntry describes one container with a newer image available
+type ImageUpdateEntry struct {
+	ContainerID    int64  `json:"container_id"`
+	ContainerName  string `json:"container_name"`
+	CurrentVersion string `json:"current_version"`
+	LatestVersion  string `json:"latest_version"`
+	UpdateType     string `json:"update_type"`
+	Security       bool   `json:"security"`
+
+	// ReleaseNotesURL is added within v1; older payloads omit it
+	ReleaseNotesURL string `json:"release_notes_url,omitempty"`
+}
+
+// UpdateFailureEntry describes one failed container update
+type UpdateFailureEntry struct {
+	ContainerID   int64  `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Step          string `json:"step"`
+	Error         string `json:"error"`
+	Code          string `json:"code,omitempty"`
+	Recoverable   bool   `json:"recoverable"`
+}
+
+// CleanupOperationEntry describes one cleanup operation
+type CleanupOperationEntry struct {
+	Type            string `json:"type"`
+	Description     string `json:"description"`
+	Success         bool   `json:"success"`
+	Error           string `json:"error,omitempty"`
+	ItemsRemoved    int    `json:"items_removed"`
+	SpaceFreedBytes int64  `json:"space_freed_bytes"`
+}
+
+// VolumeAlertEntry describes one triggered volume alert rule. Bytes is the
+// volume size for growth alerts and the free space for data root alerts.
+type VolumeAlertEntry struct {
+	Rule             string  `json:"rule"`
+	VolumeName       string  `json:"volume_name,omitempty"`
+	ThresholdPercent float64 `json:"threshold_percent"`
+	ObservedPercent  float64 `json:"observed_percent"`
+	Days             int     `json:"days,omitempty"`
+	Bytes            int64   `json:"bytes"`
+	Message          string  `json:"message"`
+}
+
+// UpdateAvailablePayload (update_available v1) lists containers with updates
+type UpdateAvailablePayload struct {
+	PayloadHeader
+	Updates         []ImageUpdateEntry `json:"updates"`
+	TotalUpdates    int                `json:"total_updates"`
+	SecurityUpdates int                `json:"security_updates"`
+}
+
+// SecurityAlertPayload (security_alert v1) lists containers with security updates
+type SecurityAlertPayload struct {
+	PayloadHeader
+	Updates      []ImageUpdateEntry `json:"updates"`
+	TotalUpdates int                `json:"total_updates"`
+}
+
+// UpdateCompletedPayload (update_completed v1) summarizes an update run
+type UpdateCompletedPayload struct {
+	PayloadHeader
+	SuccessfulUpdates int     `json:"successful_updates"`
+	FailedUpdates     int     `json:"failed_updates"`
+	Rollbacks         int     `json:"rollbacks"`
+	DurationSeconds   float64 `json:"duration_seconds"`
+
+	// Containers is added within v1; older payloads omit it
+	Containers []string `json:"containers,omitempty"`
+}
+
+// UpdateFailedPayload (update_failed v1) summarizes an update run with failures
+type UpdateFailedPayload struct {
+	PayloadHeader
+	SuccessfulUpdates int                  `json:"successful_updates"`
+	FailedUpdates     int                  `json:"failed_updates"`
+	Rollbacks         int                  `json:"rollbacks"`
+	Failures          []UpdateFailureEntry `json:"failures"`
+}
+
+// HealthAlertPayload (health_alert v1) reports unhealthy containers or recovery actions
+type HealthAlertPayload struct {
+	PayloadHeader
+	Event               string   `json:"event"`
+	TotalChecked        int      `json:"total_checked"`
+	HealthyCount        int      `json:"healthy_count"`
+	UnhealthyCount      int      `json:"unhealthy_count"`
+	RestartedCount      int      `json:"restarted_count"`
+	UnhealthyContainers []string `json:"unhealthy_containers"`
+}
+
+// CleanupSummaryPayload (cleanup_summary v1) summarizes a cleanup run
+type CleanupSummaryPayload struct {
+	PayloadHeader
+	SuccessfulOperations int                     `json:"successful_operations"`
+	FailedOperations     int                     `json:"failed_operations"`
+	SpaceFreedBytes      int64                   `json:"space_freed_bytes"`
+	DurationSeconds      float64                 `json:"duration_seconds"`
+	Operations           []CleanupOperationEntry `json:"operations"`
+}
+
+// BackupSummaryPayload (backup_summary v1) summarizes a backup run
+type BackupSummaryPayload struct {
+	PayloadHeader
+	BackupID             string  `json:"backup_id"`
+	BackupPath           string  `json:"backup_path"`
+	BackupType           string  `json:"backup_type"`
+	SuccessfulOperations int     `json:"successful_operations"`
+	FailedOperations     int     `json:"failed_operations"`
+	TotalSizeBytes       int64   `json:"total_size_bytes"`
+	Compressed           bool    `json:"compressed"`
+	DurationSeconds      float64 `json:"duration_seconds"`
+}
+
+// VolumeAlertPayload (volume_alert v1) lists triggered volume and disk space alerts
+type VolumeAlertPayload struct {
+	PayloadHeader
+	Alerts []VolumeAlertEntry `json:"alerts"`
+}
+
+// CrashLoopPayload (crash_loop v1) reports a container restarting repeatedly
+type CrashLoopPayload struct {
+	PayloadHeader
+	ContainerID   int    `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Restarts      int    `json:"restarts"`
+	WindowMinutes int    `json:"window_minutes"`
+	UpdatesHeld   bool   `json:"updates_held"`
+}
+
+// ImageDriftPayload (image_drift v1) reports a container running another
+// image than it was deployed with
+type ImageDriftPayload struct {
+	PayloadHeader
+	ContainerID    int    `json:"container_id"`
+	ContainerName  string `json:"container_name"`
+	Image          string `json:"image"`
+	DeployedDigest string `json:"deployed_digest"`
+	RunningDigest  string `json:"running_digest"`
+}
+
+// UpdateSuspendedPayload (update_suspended v1) reports a container whose
+// automatic updates were suspended after failing repeatedly
+type UpdateSuspendedPayload struct {
+	PayloadHeader
+	ContainerID   int    `json:"container_id"`
+	ContainerName string `json:"container_name"`
+	Failures      int    `json:"failures"`
+	LastError     string `json:"last_error"`
+}
+
+// BackupRestorePayload (backup_restore v1) reports the outcome of restoring
+// a backup
+type BackupRestorePayload struct {
+	PayloadHeader
+	BackupID          string   `json:"backup_id"`
+	Components        []string `json:"components"`
+	Success           bool     `json:"success"`
+	Error             string   `json:"error,omitempty"`
+	ContainersCreated int      `json:"containers_created"`
+	ContainersUpdated int      `json:"containers_updated"`
+	Conflicts         int      `json:"conflicts"`
+	DurationSeconds   float64  `json:"duration_seconds"`
+}
+
+// UpdateDigestPayload (update_digest v1) summarizes the update notifications
+// buffered for a user since the last digest
+type UpdateDigestPayload struct {
+	PayloadHeader
+	Interval            string   `json:"interval"`
+	Notifications       int      `json:"notifications"`
+	UpdatesAvailable    int      `json:"updates_available"`
+	UpdatesCompleted    int      `json:"updates_completed"`
+	AvailableContainers []string `json:"available_containers"`
+	UpdatedContainers   []string `json:"updated_containers"`
+}
+
+// payloadVersions holds the version each schema is currently emitted at
+var payloadVersions = map[PayloadSchema]int{
+	PayloadSchemaUpdateAvailable: 1,
+	PayloadSchemaUpdateCompleted: 1,
+	PayloadSchemaUpdateFailed:    1,
+	PayloadSchemaHealthAlert:     1,
+	PayloadSchemaCleanupSummary:  1,
+	PayloadSchemaBackupSummary:   1,
+	PayloadSchemaSecurityAlert:   1,
+	PayloadSchemaVolumeAlert:     1,
+	PayloadSchemaCrashLoop:       1,
+	PayloadSchemaBackupRestore:   1,
+	PayloadSchemaUpdateDigest:    1,
+	PayloadSchemaImageDrift:      1,
+	PayloadSchemaUpdateSuspended: 1,
+}
+
+func newPayloadHeader(schema PayloadSchema) PayloadHeader {
+	return PayloadHeader{Schema: schema, SchemaVersion: payloadVersions[schema]}
+}
+
+// NewUpdateAvailablePayload creates an update_available payload
+func NewUpdateAvailablePayload(updates []ImageUpdateEntry) *UpdateAvailablePayload {
+	payload := &UpdateAvailablePayload{
+		PayloadHeader: newPayloadHeader(PayloadSchemaUpdateAvailable),
+		Updates:       nonNilSlice(updates),
+		TotalUpdates:  len(updates),
+	}
+	for _, update := range updates {
+		if update.Security {
+			payload.SecurityUpdates++
+		}
+	}
+	return payload
+}
+
+// NewSecurityAlertPayload creates a security_alert payload
+func NewSecurityAlertPayload(updates []ImageUpdateEntry, totalUpdates int) *SecurityAlertPayload {
+	return &SecurityAlertPayload{
+		PayloadHeader: newPayloadHeader(PayloadSchemaSecurityAlert),
+		Updates:       nonNilSlice(updates),
+		TotalUpdates:  totalUpdates,
+	}
+}
+
+// NewUpdateCompletedPayload creates an update_completed payload
+func NewUpdateCompletedPayload(successful, failed, rollbacks int, duration time.Duration) *UpdateCompletedPayload {
+	return &UpdateCompletedPayload{
+		PayloadHeader:     newPayloadHeader(PayloadSchemaUpdateCompleted),
+		SuccessfulUpdates: successful,
+		FailedUpdates:     failed,
+		Rollbacks:         rollbacks,
+		DurationSeconds:   duration.Seconds(),
+	}
+}
+
+// NewUpdateFailedPayload creates an update_failed payload
+func NewUpdateFailedPayload(successful, failed, rollbacks int, failures []UpdateFailureEntry) *UpdateFailedPayload {
+	return &UpdateFailedPayload{
+		PayloadHeader:     newPayloadHeader(PayloadSchemaUpdateFailed),
+		SuccessfulUpdates: successful,
+		FailedUpdates:     failed,
+		Rollbacks:         rollbacks,
+		Failures:          nonNilSlice(failures),
+	}
+}
+
+// NewHealthAlertPayload creates a health_alert payload
+func NewHealthAlertPayload(event string, totalChecked, healthy, unhealthy, restarted int, unhealthyContainers []string) *HealthAlertPayload {
+	return &HealthAlertPayload{
+		PayloadHeader:       newPayloadHeader(PayloadSchemaHealthAlert),
+		Event:               event,
+		TotalChecked:        totalChecked,
+		HealthyCount:        healthy,
+		UnhealthyCount:      unhealthy,
+		RestartedCount:      restarted,
+		UnhealthyContainers: nonNilSlice(unhealthyContainers),
+	}
+}
+
+// NewCleanupSummaryPayload creates a cleanup_summary payload
+func NewCleanupSummaryPayload(successful, failed int, spaceFreed int64, duration time.Duration, operations []CleanupOperationEntry) *CleanupSummaryPayload {
+	return &CleanupSummaryPayload{
+		PayloadHeader:        newPayloadHeader(PayloadSchemaCleanupSummary),
+		SuccessfulOperations: successful,
+		FailedOperations:     failed,
+		SpaceFreedBytes:      spaceFreed,
+		DurationSeconds:      duration.Seconds(),
+		Operations:           nonNilSlice(operations),
+	}
+}
+
+// NewBackupSummaryPayload creates a backup_summary payload
+func NewBackupSummaryPayload(backupID, backupPath, backupType string, successful, failed int, totalSize int64, compressed bool, duration time.Duration) *BackupSummaryPayload {
+	return &BackupSummaryPayload{
+		PayloadHeader:        newPayloadHeader(PayloadSchemaBackupSummary),
+		BackupID:             backupID,
+		BackupPath:           backupPath,
+		BackupType:           backupType,
+		SuccessfulOperations: successful,
+		FailedOperations:     failed,
+		TotalSizeBytes:       totalSize,
+		Compressed:           compressed,
+		DurationSeconds:      duration.Seconds(),
+	}
+}
+
+// NewVolumeAlertPayload creates a volume_alert payload
+func NewVolumeAlertPayload(alerts []VolumeAlertEntry) *VolumeAlertPayload {
+	return &VolumeAlertPayload{
+		PayloadHeader: newPayloadHeader(PayloadSchemaVolumeAlert),
+		Alerts:        nonNilSlice(alerts),
+	}
+}
+
+// NewCrashLoopPayload creates a crash_loop payload
+func NewCrashLoopPayload(containerID int, containerName string, restarts, windowMinutes int, updatesHeld bool) *CrashLoopPayload {
+	return &CrashLoopPayload{
+		PayloadHeader: newPayloadHeader(PayloadSchemaCrashLoop),
+		ContainerID:   containerID,
+		ContainerName: containerName,
+		Restarts:      restarts,
+		WindowMinutes: windowMinutes,
+		UpdatesHeld:   updatesHeld,
+	}
+}
+
+// NewImageDriftPayload creates an image_drift payload
+func NewImageDriftPayload(containerID int, containerName, image, deployedDigest, runningDigest string) *ImageDriftPayload {
+	return &ImageDriftPayload{
+		PayloadHeader:  newPayloadHeader(PayloadSchemaImageDrift),
+		ContainerID:    containerID,
+		ContainerName:  containerName,
+		Image:          image,
+		DeployedDigest: deployedDigest,
+		RunningDigest:  runningDigest,
+	}
+}
+
+// NewUpdateSuspendedPayload creates an update_suspended payload
+func NewUpdateSuspendedPayload(containerID int, containerName string, failures int, lastError string) *UpdateSuspendedPayload {
+	return &UpdateSuspendedPayload{
+		PayloadHeader: newPayloadHeader(PayloadSchemaUpdateSuspended),
+		ContainerID:   containerID,
+		ContainerName: containerName,
+		Failures:      failures,
+		LastError:     lastError,
+	}
+}
+
+// NewBackupRestorePayload creates a backup_restore payload
+func NewBackupRestorePayload(backupID string, components []string, restoreErr error, created, updated, conflicts int, duration time.Duration) *BackupRestorePayload {
+	payload := &BackupRestorePayload{
+		PayloadHeader:     newPayloadHeader(PayloadSchemaBackupRestore),
+		BackupID:          backupID,
+		Components:        nonNilSlice(components),
+		Success:           restoreErr == nil,
+		ContainersCreated: created,
+		ContainersUpdated: updated,
+		Conflicts:         conflicts,
+		DurationSeconds:   duration.Seconds(),
+	}
+	if restoreErr != nil {
+		payload.Error = restoreErr.Error()
+	}
+	return payload
+}
+
+// NewUpdateDigestPayload creates an update_digest payload from the buffered
+// notifications; container names are listed once each
+func NewUpdateDigestPayload(interval DigestInterval, pending []*PendingNotification) *UpdateDigestPayload {
+	payload := &UpdateDigestPayload{
+		PayloadHeader:       newPayloadHeader(PayloadSchemaUpdateDigest),
+		Interval:            string(interval),
+		Notifications:       len(pending),
+		AvailableContainers: []string{},
+		UpdatedContainers:   []string{},
+	}
+
+	available, updated := map[string]bool{}, map[string]bool{}
+	for _, entry := range pending {
+		switch entry.Schema {
+		case PayloadSchemaUpdateAvailable:
+			payload.UpdatesAvailable += entry.Count
+			payload.AvailableContainers = appendUnique(payload.AvailableContainers, available, entry.ContainerNames)
+		case PayloadSchemaUpdateCompleted:
+			payload.UpdatesCompleted += entry.Count
+			payload.UpdatedContainers = appendUnique(payload.UpdatedContainers, updated, entry.ContainerNames)
+		}
+	}
+	return payload
+}
+
+// Summary renders the payload as plain text
+func (p *UpdateAvailablePayload) Summary() string {
+	lines := []string{fmt.Sprintf("%d update(s) available, %d security", p.TotalUpdates, p.SecurityUpdates)}
+	return strings.Join(append(lines, imageUpdateLines(p.Updates)...), "\n")
+}
+
+// Summary renders the payload as plain text
+func (p *SecurityAlertPayload) Summary() string {
+	lines := []string{fmt.Sprintf("%d security update(s) available", len(p.Updates))}
+	return strings.Join(append(lines, imageUpdateLines(p.Updates)...), "\n")
+}
+
+// Summary renders the payload as plain text
+func (p *UpdateCompletedPayload) Summary() string {
+	return fmt.Sprintf("%d updated, %d failed, %d rolled back in %.0fs",
+		p.SuccessfulUpdates, p.FailedUpdates, p.Rollbacks, p.DurationSeconds)
+}
+
+// Summary renders the payload as plain text
+func (p *UpdateFailedPayload) Summary() string {
+	lines := []string{fmt.Sprintf("%d failed, %d updated, %d rolled back", p.FailedUpdates, p.SuccessfulUpdates, p.Rollbacks)}
+	for _, failure := range p.Failures {
+		lines = append(lines, fmt.Sprintf("- %s (%s): %s", failure.ContainerName, failure.Step, failure.Error))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary renders the payload as plain text
+func (p *HealthAlertPayload) Summary() string {
+	if p.Event == HealthAlertEventRecovery {
+		return fmt.Sprintf("Restarted %d of %d unhealthy container(s)", p.RestartedCount, p.UnhealthyCount)
+	}
+	return fmt.Sprintf("%d of %d container(s) unhealthy: %s",
+		p.UnhealthyCount, p.TotalChecked, strings.Join(p.UnhealthyContainers, ", "))
+}
+
+// Summary renders the payload as plain text
+func (p *CleanupSummaryPayload) Summary() string {
+	lines := []string{fmt.Sprintf("%d operation(s) succeeded, %d failed, %d bytes freed",
+		p.SuccessfulOperations, p.FailedOperations, p.SpaceFreedBytes)}
+	for _, operation := range p.Operations {
+		if !operation.Success {
+			lines = append(lines, fmt.Sprintf("- %s failed: %s", operation.Type, operation.Error))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary renders the payload as plain text
+func (p *BackupSummaryPayload) Summary() string {
+	return fmt.Sprintf("Backup %s (%s): %d operation(s) succeeded, %d failed, %d bytes",
+		p.BackupID, p.BackupType, p.SuccessfulOperations, p.FailedOperations, p.TotalSizeBytes)
+}
+
+// Summary renders the payload as plain text
+func (p *VolumeAlertPayload) Summary() string {
+	lines := []string{fmt.Sprintf("%d volume alert(s)", len(p.Alerts))}
+	for _, alert := range p.Alerts {
+		lines = append(lines, "- "+alert.Message)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Summary renders the payload as plain text
+func (p *CrashLoopPayload) Summary() string {
+	summary := fmt.Sprintf("%s restarted %d times in %d minutes", p.ContainerName, p.Restarts, p.WindowMinutes)
+	if p.UpdatesHeld {
+		summary += "; automatic updates held until acknowledged"
+	}
+	return summary
+}
+
+// Summary renders the payload as plain text
+func (p *ImageDriftPayload) Summary() string {
+	return fmt.Sprintf("%s runs %s@%s instead of the deployed %s", p.ContainerName, p.Image, p.RunningDigest, p.DeployedDigest)
+}
+
+// Summary renders the payload as plain text
+func (p *UpdateSuspendedPayload) Summary() string {
+	return fmt.Sprintf("Automatic updates of %s suspended after %d failures in a row; last error: %s", p.ContainerName, p.Failures, p.LastError)
+}
+
+// Summary renders the payload as plain text
+func (p *BackupRestorePayload) Summary() string {
+	if !p.Success {
+		return fmt.Sprintf("Restore of backup %s (%s) failed: %s", p.BackupID, strings.Join(p.Components, ", "), p.Error)
+	}
+	summary := fmt.Sprintf("Restored %s from backup %s", strings.Join(p.Components, ", "), p.BackupID)
+	if p.ContainersCreated+p.ContainersUpdated+p.Conflicts > 0 {
+		summary += fmt.Sprintf(": %d container(s) created, %d updated, %d conflict(s)", p.ContainersCreated, p.ContainersUpdated, p.Conflicts)
+	}
+	return summary
+}
+
+// Summary renders the payload as plain text
+func (p *UpdateDigestPayload) Summary() string {
+	lines := []string{fmt.Sprintf("%d update(s) available, %d container(s) updated", p.UpdatesAvailable, p.UpdatesCompleted)}
+	if len(p.AvailableContainers) > 0 {
+		lines = append(lines, "Available: "+strings.Join(p.AvailableContainers, ", "))
+	}
+	if len(p.UpdatedContainers) > 0 {
+		lines = append(lines, "Updated: "+strings.Join(p.UpdatedContainers, ", "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// NotificationData converts a payload to the map stored in Notification.Data
+func NotificationData(payload NotificationPayload) JSONMap {
+	data := JSONMap{}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return data
+	}
+	_ = json.Unmarshal(raw, &data)
+	return data
+}
+
+// DecodeNotificationPayload decodes notification data into its typed payload.
+// Data without a header, or with a schema or version this build does not
+// know, yields ErrUnknownPayloadSchema.
+func DecodeNotificationPayload(data JSONMap) (NotificationPayload, error) {
+	schema, _ := data["schema"].(string)
+	version, _ := data["schema_version"].(float64)
+	if schema == "" {
+		return nil, ErrUnknownPayloadSchema
+	}
+
+	var payload NotificationPayload
+	switch {
+	case schema == string(PayloadSchemaUpdateAvailable) && version == 1:
+		payload = &UpdateAvailablePayload{}
+	case schema == string(PayloadSchemaSecurityAlert) && version == 1:
+		payload = &SecurityAlertPayload{}
+	case schema == string(PayloadSchemaUpdateCompleted) && version == 1:
+		payload = &UpdateCompletedPayload{}
+	case schema == string(PayloadSchemaUpdateFailed) && version == 1:
+		payload = &UpdateFailedPayload{}
+	case schema == string(PayloadSchemaHealthAlert) && version == 1:
+		payload = &HealthAlertPayload{}
+	case schema == string(PayloadSchemaCleanupSummary) && version == 1:
+		payload = &CleanupSummaryPayload{}
+	case schema == string(PayloadSchemaBackupSummary) && version == 1:
+		payload = &BackupSummaryPayload{}
+	case schema == string(PayloadSchemaVolumeAlert) && version == 1:
+		payload = &VolumeAlertPayload{}
+	case schema == string(PayloadSchemaCrashLoop) && version == 1:
+		payload = &CrashLoopPayload{}
+	case schema == string(PayloadSchemaBackupRestore) && version == 1:
+		payload = &BackupRestorePayload{}
+	case schema == string(PayloadSchemaUpdateDigest) && version == 1:
+		payload = &UpdateDigestPayload{}
+	case schema == string(PayloadSchemaImageDrift) && version == 1:
+		payload = &ImageDriftPayload{}
+	case schema == string(PayloadSchemaUpdateSuspended) && version == 1:
+		payload = &UpdateSuspendedPayload{}
+	default:
+		return nil, fmt.Errorf("%w: %s v%d", ErrUnknownPayloadSchema, schema, int(version))
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode notification data: %w", err)
+	}
+	if err := json.Unmarshal(raw, payload); err != nil {
+		return nil, fmt.Errorf("failed to decode %s payload: %w", schema, err)
+	}
+
+	return payload, nil
+}
+
+// PayloadRendering is the consumer-facing view of notification data
+type PayloadRendering struct {
+	Schema        PayloadSchema `json:"schema,omitempty"`
+	SchemaVersion int           `json:"schema_version,omitempty"`
+	Known         bool          `json:"known"`
+	Text          string        `json:"text"`
+}
+
+// RenderNotificationData renders notification data for display. Payloads of
+// an unknown schema or version degrade to their raw JSON instead of failing.
+func RenderNotificationData(data JSONMap) *PayloadRendering {
+	if len(data) == 0 {
+		return nil
+	}
+
+	payload, err := DecodeNotificationPayload(data)
+	if err != nil {
+		rendering := &PayloadRendering{Text: data.String()}
+		if schema, ok := data["schema"].(string); ok {
+			rendering.Schema = PayloadSchema(schema)
+		}
+		if version, ok := data["schema_version"].(float64); ok {
+			rendering.SchemaVersion = int(version)
+		}
+		return rendering
+	}
+
+	header := payload.Header()
+	return &PayloadRendering{
+		Schema:        header.Schema,
+		SchemaVersion: header.SchemaVersion,
+		Known:         true,
+		Text:          payload.Summary(),
+	}
+}
+
+// PayloadSchemaDescription documents a payload schema for integrators
+type PayloadSchemaDescription struct {
+	Schema        PayloadSchema  `json:"schema"`
+	SchemaVersion int            `json:"schema_version"`
+	Fields        []PayloadField `json:"fields"`
+}
+
+// PayloadField documents one payload field
+type PayloadField struct {
+	Name   string         `json:"name"`
+	Type   string         `json:"type"`
+	Fields []PayloadField `json:"fields,omitempty"` // element fields of object arrays
+}
+
+// NotificationPayloadSchemas describes the current version of every payload schema
+func NotificationPayloadSchemas() []PayloadSchemaDescription {
+	examples := map[PayloadSchema]NotificationPayload{
+		PayloadSchemaUpdateAvailable: &UpdateAvailablePayload{},
+		PayloadSchemaUpdateCompleted: &UpdateCompletedPayload{},
+		PayloadSchemaUpdateFailed:    &UpdateFailedPayload{},
+		PayloadSchemaHealthAlert:     &HealthAlertPayload{},
+		PayloadSchemaCleanupSummary:  &CleanupSummaryPayload{},
+		PayloadSchemaBackupSummary:   &BackupSummaryPayload{},
+		PayloadSchemaSecurityAlert:   &SecurityAlertPayload{},
+		PayloadSchemaVolumeAlert:     &VolumeAlertPayload{},
+		PayloadSchemaCrashLoop:       &CrashLoopPayload{},
+		PayloadSchemaBackupRestore:   &BackupRestorePayload{},
+		PayloadSchemaUpdateDigest:    &UpdateDigestPayload{},
+		PayloadSchemaImageDrift:      &ImageDriftPayload{},
+		PayloadSchemaUpdateSuspended: &UpdateSuspendedPayload{},
+	}
+
+	schemas := make([]PayloadSchemaDescription, 0, len(examples))
+	for schema, example := range examples {
+		schemas = append(schemas, PayloadSchemaDescription{
+			Schema:        schema,
+			SchemaVersion: payloadVersions[schema],
+			Fields:        describePayloadFields(reflect.TypeOf(example).Elem()),
+		})
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Schema < schemas[j].Schema })
+
+	return schemas
+}
+
+// describePayloadFields lists the JSON fields of a payload struct, flattening
+// embedded structs the way encoding/json does
+func describePayloadFields(t reflect.Type) []PayloadField {
+	var fields []PayloadField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			fields = append(fields, describePayloadFields(field.Type)...)
+			continue
+		}
+
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "" || name == "-" {
+			continue
+		}
+
+		described := PayloadField{Name: name, Type: jsonTypeName(field.Type)}
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
+			described.Fields = describePayloadFields(field.Type.Elem())
+		}
+		fields = append(fields, described)
+	}
+	return fields
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "integer"
+	case reflect.Float32, reflect.Float64:
+		return "number"
+	case reflect.Slice, reflect.Array:
+		return "array<" + jsonTypeName(t.Elem()) + ">"
+	default:
+		return "object"
+	}
+}
+
+func imageUpdateLines(updates []ImageUpdateEntry) []string {
+	lines := make([]string, 0, len(updates))
+	for _, update := range updates {
+		line := fmt.Sprintf("- %s: %s -> %s (%s)",
+			update.ContainerName, update.CurrentVersion, update.LatestVersion, update.UpdateType)
+		if update.ReleaseNotesURL != "" {
+			line += " " + update.ReleaseNotesURL
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func appendUnique(items []string, seen map[string]bool, add []string) []string {
+	for _, item := range add {
+		if !seen[item] {
+			seen[item] = true
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func nonNilSlice[T any](items []T) []T {
+	if items == nil {
+		return []T{}
+	}
+	return items
+}
@@ -0,0 +1,213 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden payloads in testdata")
+
+// compareGolden compares got with a golden file, rewriting it with -update
+func compareGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read golden file: %v", err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("%s differs from the golden file; got:\n%s", name, got)
+	}
+}
+
+// goldenPayloads holds one payload of every schema, built by its constructor
+// the way the senders build them
+func goldenPayloads() []NotificationPayload {
+	updates := []ImageUpdateEntry{
+		{ContainerID: 1, ContainerName: "web", CurrentVersion: "1.25", LatestVersion: "1.26", UpdateType: "minor", Security: true, ReleaseNotesURL: "https://example.com/releases/1.26"},
+		{ContainerID: 2, ContainerName: "db", CurrentVersion: "15.3", LatestVersion: "15.4", UpdateType: "patch"},
+	}
+	completed := NewUpdateCompletedPayload(3, 0, 1, 95*time.Second)
+	completed.Containers = []string{"web", "db", "cache"}
+
+	return []NotificationPayload{
+		NewUpdateAvailablePayload(updates),
+		NewSecurityAlertPayload(updates[:1], 2),
+		completed,
+		NewUpdateFailedPayload(1, 1, 1, []UpdateFailureEntry{
+			{ContainerID: 2, ContainerName: "db", Step: "pull_image", Error: "registry timeout", Code: "REGISTRY_TIMEOUT", Recoverable: true},
+		}),
+		NewHealthAlertPayload(HealthAlertEventUnhealthy, 5, 3, 2, 0, []string{"web", "worker"}),
+		NewCleanupSummaryPayload(2, 1, 1048576, 12*time.Second, []CleanupOperationEntry{
+			{Type: "images", Description: "Removed dangling images", Success: true, ItemsRemoved: 4, SpaceFreedBytes: 1048576},
+			{Type: "volumes", Description: "Removed unused volumes", Error: "volume is in use"},
+		}),
+		NewBackupSummaryPayload("backup-20240630", "/backups/backup-20240630.tar.gz", "full", 3, 0, 52428800, true, 40*time.Second),
+		NewVolumeAlertPayload([]VolumeAlertEntry{
+			{Rule: VolumeAlertRuleGrowth, VolumeName: "db-data", ThresholdPercent: 50, ObservedPercent: 72.5, Days: 7, Bytes: 2147483648, Message: "db-data grew 72.5% in 7 days"},
+			{Rule: VolumeAlertRuleDataRootSpace, ThresholdPercent: 10, ObservedPercent: 8, Bytes: 4294967296, Message: "8% free on the Docker data root"},
+		}),
+		NewCrashLoopPayload(1, "web", 6, 10, true),
+		NewBackupRestorePayload("backup-20240630", []string{"database", "containers"}, nil, 2, 5, 1, 30*time.Second),
+		NewUpdateDigestPayload(DigestDaily, []*PendingNotification{
+			{Schema: PayloadSchemaUpdateAvailable, Count: 2, ContainerNames: StringList{"web", "db"}},
+			{Schema: PayloadSchemaUpdateCompleted, Count: 1, ContainerNames: StringList{"web"}},
+			{Schema: PayloadSchemaUpdateAvailable, Count: 1, ContainerNames: StringList{"web"}},
+		}),
+		NewImageDriftPayload(1, "web", "nginx", "sha256:aaaa", "sha256:bbbb"),
+		NewUpdateSuspendedPayload(1, "web", 3, "port is already allocated"),
+	}
+}
+
+func TestNotificationPayloadsMatchGolden(t *testing.T) {
+	covered := map[PayloadSchema]bool{}
+	for _, payload := range goldenPayloads() {
+		header := payload.Header()
+		covered[header.Schema] = true
+
+		t.Run(string(header.Schema), func(t *testing.T) {
+			got, err := json.MarshalIndent(payload, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			compareGolden(t, filepath.Join("payloads", string(header.Schema)+".v1.json"), append(got, '\n'))
+
+			// The stored form decodes back to the same payload
+			decoded, err := DecodeNotificationPayload(NotificationData(payload))
+			if err != nil {
+				t.Fatalf("DecodeNotificationPayload failed: %v", err)
+			}
+			if !reflect.DeepEqual(decoded, payload) {
+				t.Errorf("decoded %+v, want %+v", decoded, payload)
+			}
+		})
+	}
+
+	// A new schema needs a golden file before it ships
+	for schema := range payloadVersions {
+		if !covered[schema] {
+			t.Errorf("schema %s has no golden payload", schema)
+		}
+	}
+}
+
+func TestNotificationPayloadSchemasMatchGolden(t *testing.T) {
+	schemas := NotificationPayloadSchemas()
+
+	got, err := json.MarshalIndent(schemas, "", "  ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compareGolden(t, "payload_schemas.golden.json", append(got, '\n'))
+
+	listed := map[PayloadSchema]bool{}
+	for _, schema := range schemas {
+		listed[schema.Schema] = true
+	}
+	for schema := range payloadVersions {
+		if !listed[schema] {
+			t.Errorf("schema %s is not described for integrators", schema)
+		}
+	}
+}
+
+func TestNotificationPayloadsEncodeEmptyListsAsArrays(t *testing.T) {
+	// Senders with nothing to list pass nil; consumers iterate without a
+	// null check
+	payloads := []NotificationPayload{
+		NewUpdateAvailablePayload(nil),
+		NewSecurityAlertPayload(nil, 0),
+		NewUpdateFailedPayload(0, 0, 0, nil),
+		NewHealthAlertPayload(HealthAlertEventUnhealthy, 0, 0, 0, 0, nil),
+		NewCleanupSummaryPayload(0, 0, 0, 0, nil),
+		NewVolumeAlertPayload(nil),
+		NewBackupRestorePayload("b", nil, errors.New("disk full"), 0, 0, 0, 0),
+		NewUpdateDigestPayload(DigestHourly, nil),
+	}
+
+	for _, payload := range payloads {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.Contains(string(data), "null") {
+			t.Errorf("%s encodes a null list: %s", payload.Header().Schema, data)
+		}
+	}
+}
+
+func TestDecodeNotificationPayloadAcceptsEarlierV1(t *testing.T) {
+	// Fields added within v1 are missing from payloads stored before them
+	data := JSONMap{}
+	if err := json.Unmarshal([]byte(`{
+		"schema": "update_available",
+		"schema_version": 1,
+		"updates": [{"container_id": 1, "container_name": "web", "current_version": "1.25", "latest_version": "1.26", "update_type": "minor", "security": false}],
+		"total_updates": 1,
+		"security_updates": 0
+	}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := DecodeNotificationPayload(data)
+	if err != nil {
+		t.Fatalf("DecodeNotificationPayload failed: %v", err)
+	}
+	updates := payload.(*UpdateAvailablePayload).Updates
+	if len(updates) != 1 || updates[0].ContainerName != "web" || updates[0].ReleaseNotesURL != "" {
+		t.Errorf("updates = %+v", updates)
+	}
+}
+
+func TestRenderNotificationDataDegradesToRawJSON(t *testing.T) {
+	tests := []struct {
+		name        string
+		data        JSONMap
+		wantSchema  PayloadSchema
+		wantVersion int
+	}{
+		{"future version", JSONMap{"schema": "update_available", "schema_version": float64(2), "entries": []interface{}{"web"}}, PayloadSchemaUpdateAvailable, 2},
+		{"unknown schema", JSONMap{"schema": "quota_alert", "schema_version": float64(1), "used": float64(90)}, "quota_alert", 1},
+		{"no header", JSONMap{"operations": []interface{}{"images"}}, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecodeNotificationPayload(tt.data); !errors.Is(err, ErrUnknownPayloadSchema) {
+				t.Errorf("DecodeNotificationPayload error = %v, want ErrUnknownPayloadSchema", err)
+			}
+
+			rendering := RenderNotificationData(tt.data)
+			if rendering == nil || rendering.Known || rendering.Schema != tt.wantSchema || rendering.SchemaVersion != tt.wantVersion {
+				t.Fatalf("rendering = %+v, want an unknown %s v%d", rendering, tt.wantSchema, tt.wantVersion)
+			}
+			var raw JSONMap
+			if err := json.Unmarshal([]byte(rendering.Text), &raw); err != nil || !reflect.DeepEqual(raw, tt.data) {
+				t.Errorf("text = %s, want the raw data", rendering.Text)
+			}
+		})
+	}
+
+	known := RenderNotificationData(NotificationData(NewCrashLoopPayload(1, "web", 6, 10, false)))
+	if known == nil || !known.Known || known.Text != "web restarted 6 times in 10 minutes" {
+		t.Errorf("known rendering = %+v", known)
+	}
+}
@@ -0,0 +1,204 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// PostStartHookType identifies what a post-start hook runs
+type PostStartHookType string
+
+const (
+	PostStartHookExec    PostStartHookType = "exec"
+	PostStartHookWebhook PostStartHookType = "webhook"
+)
+
+// HookFailurePolicy decides what a failed post-start hook does to the start
+type HookFailurePolicy string
+
+const (
+	HookFailureIgnore HookFailurePolicy = "ignore"
+	HookFailureWarn   HookFailurePolicy = "warn"
+	// HookFailureMarkStartFailed fails the start, and rolls back an update
+	HookFailureMarkStartFailed HookFailurePolicy = "mark_start_failed"
+)
+
+// Post-start triggers
+const (
+	PostStartTriggerStart   = "start"
+	PostStartTriggerRestart = "restart"
+	PostStartTriggerUpdate  = "update"
+)
+
+const (
+	defaultPostStartHookTimeout = time.Minute
+	maxPostStartHookTimeout     = 30 * time.Minute
+	maxPostStartHooks           = 10
+	maxWarmupSeconds            = 3600
+	maxPostStartHookOutput      = 4096
+)
+
+// PostStartHook is a command run in the container, or a webhook called,
+// once the container has started
+type PostStartHook struct {
+	Name           string            `json:"name,omitempty"`
+	Type           PostStartHookType `json:"type"`
+	Command        []string          `json:"command,omitempty"` // exec
+	URL            string            `json:"url,omitempty"`     // webhook
+	TimeoutSeconds int               `json:"timeout_seconds,omitempty"`
+	OnFailure      HookFailurePolicy `json:"on_failure,omitempty"`
+}
+
+// Timeout returns the hook's time limit, one minute when unset
+func (h PostStartHook) Timeout() time.Duration {
+	if h.TimeoutSeconds <= 0 {
+		return defaultPostStartHookTimeout
+	}
+	return time.Duration(h.TimeoutSeconds) * time.Second
+}
+
+// FailurePolicy returns what a failure of the hook does, warn when unset
+func (h PostStartHook) FailurePolicy() HookFailurePolicy {
+	if h.OnFailure == "" {
+		return HookFailureWarn
+	}
+	return h.OnFailure
+}
+
+// Label names the hook in results, falling back to its type
+func (h PostStartHook) Label() string {
+	if h.Name != "" {
+		return h.Name
+	}
+	return string(h.Type)
+}
+
+// Validate validates the hook
+func (h PostStartHook) Validate() error {
+	switch h.Type {
+	case PostStartHookExec:
+		if len(h.Command) == 0 {
+			return fmt.Errorf("exec hook requires a command")
+		}
+	case PostStartHookWebhook:
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("webhook hook requires an http or https url")
+		}
+	default:
+		return fmt.Errorf("unknown post-start hook type %q", h.Type)
+	}
+
+	switch h.OnFailure {
+	case "", HookFailureIgnore, HookFailureWarn, HookFailureMarkStartFailed:
+	default:
+		return fmt.Errorf("unknown failure policy %q", h.OnFailure)
+	}
+
+	if h.TimeoutSeconds < 0 || time.Duration(h.TimeoutSeconds)*time.Second > maxPostStartHookTimeout {
+		return fmt.Errorf("timeout_seconds must be between 0 and %d", int(maxPostStartHookTimeout.Seconds()))
+	}
+	return nil
+}
+
+// PostStartHookList is the ordered list of post-start hooks of a container
+type PostStartHookList []PostStartHook
+
+// Validate validates every hook of the list
+func (l PostStartHookList) Validate() error {
+	if len(l) > maxPostStartHooks {
+		return fmt.Errorf("at most %d post-start hooks are allowed", maxPostStartHooks)
+	}
+	for i, hook := range l {
+		if err := hook.Validate(); err != nil {
+			return fmt.Errorf("post-start hook %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// HasExec reports whether the list runs commands inside the container
+func (l PostStartHookList) HasExec() bool {
+	for _, hook := range l {
+		if hook.Type == PostStartHookExec {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (l PostStartHookList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (l *PostStartHookList) Scan(value interface{}) error {
+	return scanJSON(value, l, "PostStartHookList")
+}
+
+// ValidateWarmup validates a warmup delay in seconds
+func ValidateWarmup(seconds int) error {
+	if seconds < 0 || seconds > maxWarmupSeconds {
+		return fmt.Errorf("warmup_seconds must be between 0 and %d", maxWarmupSeconds)
+	}
+	return nil
+}
+
+// PostStartHookResult is the outcome of one post-start hook
+type PostStartHookResult struct {
+	Name       string            `json:"name"`
+	Type       PostStartHookType `json:"type"`
+	OnFailure  HookFailurePolicy `json:"on_failure"`
+	Success    bool              `json:"success"`
+	Skipped    bool              `json:"skipped,omitempty"`
+	Output     string            `json:"output,omitempty"`
+	ExitCode   *int              `json:"exit_code,omitempty"`
+	StatusCode int               `json:"status_code,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	StartedAt  time.Time         `json:"started_at"`
+	DurationMs int64             `json:"duration_ms"`
+}
+
+// SetOutput records the hook's output, truncating long output
+func (r *PostStartHookResult) SetOutput(output string) {
+	if len(output) > maxPostStartHookOutput {
+		output = output[:maxPostStartHookOutput]
+	}
+	r.Output = output
+}
+
+// PostStartRun is the latest post-start sequence run for a container: the
+// wait for it to come up, the warmup delay and the hooks
+type PostStartRun struct {
+	Trigger       string     `json:"trigger"`
+	DockerID      string     `json:"docker_id"`
+	WarmupSeconds int        `json:"warmup_seconds,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	CompletedAt   *time.Time `json:"completed_at,omitempty"`
+	// StartFailed is set when the container did not come up or a hook with
+	// the mark_start_failed policy failed
+	StartFailed bool                  `json:"start_failed"`
+	Warnings    []string              `json:"warnings,omitempty"`
+	Error       string                `json:"error,omitempty"`
+	Hooks       []PostStartHookResult `json:"hooks"`
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (r *PostStartRun) Value() (driver.Value, error) {
+	if r == nil {
+		return nil, nil
+	}
+	return json.Marshal(r)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (r *PostStartRun) Scan(value interface{}) error {
+	return scanJSON(value, r, "PostStartRun")
+}
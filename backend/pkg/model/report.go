@@ -0,0 +1,324 @@
+package model
+
+import (
+	"strconv"
+	"time"
+)
+
+// Report triggers, derived from how an update or task run was started
+const (
+	ReportTriggerScheduled = "scheduled"
+	ReportTriggerManual    = "manual"
+	ReportTriggerApproval  = "approval"
+)
+
+// Scan verdicts of the image an update deployed
+const (
+	ScanVerdictPassed     = "passed"
+	ScanVerdictFailed     = "failed"
+	ScanVerdictNotScanned = "not_scanned"
+)
+
+// ReportFilter selects the rows of a report export. OwnerID limits the
+// report to resources created by that user.
+type ReportFilter struct {
+	From    time.Time
+	To      time.Time
+	OwnerID *int
+}
+
+// UpdateReportColumns is the header of the update export. Downstream
+// spreadsheets depend on it: append new columns, never reorder or rename.
+var UpdateReportColumns = []string{
+	"update_id",
+	"container_id",
+	"container_name",
+	"old_image",
+	"old_digest",
+	"new_image",
+	"new_digest",
+	"trigger",
+	"actor",
+	"approver",
+	"started_at",
+	"completed_at",
+	"duration_seconds",
+	"result",
+	"error",
+	"scan_verdict",
+	"notes",
+}
+
+// TaskExecutionReportColumns is the header of the task execution export,
+// under the same compatibility rules as UpdateReportColumns
+var TaskExecutionReportColumns = []string{
+	"execution_id",
+	"task_id",
+	"task_name",
+	"task_type",
+	"task_owner",
+	"trigger",
+	"started_at",
+	"completed_at",
+	"duration_seconds",
+	"result",
+	"message",
+}
+
+// ActivityReportColumns is the header of the activity log export, under the
+// same compatibility rules as UpdateReportColumns
+var ActivityReportColumns = []string{
+	"activity_id",
+	"created_at",
+	"user_id",
+	"actor_type",
+	"actor_name",
+	"action",
+	"resource_type",
+	"resource_id",
+	"resource_name",
+	"description",
+	"ip_address",
+	"user_agent",
+	"metadata",
+}
+
+// UpdateReportRecord is an update history entry joined with its container,
+// creating user and the scan result of the deployed digest
+type UpdateReportRecord struct {
+	ID              int
+	ContainerID     int
+	ContainerName   string
+	OldImage        string
+	OldDigest       string
+	NewImage        string
+	NewDigest       string
+	Status          UpdateStatus
+	ErrorMessage    string
+	DurationSeconds int
+	TriggeredBy     TriggerType
+	ActorType       ActorType
+	ActorName       string
+	Username        string
+	Approver        string
+	ResolvedDigest  string
+	StartedAt       time.Time
+	CompletedAt     *time.Time
+	ScanPassed      *bool
+	Notes           string
+}
+
+// UpdateReportRow is one row of the update export
+type UpdateReportRow struct {
+	UpdateID        int        `json:"update_id"`
+	ContainerID     int        `json:"container_id"`
+	ContainerName   string     `json:"container_name"`
+	OldImage        string     `json:"old_image"`
+	OldDigest       string     `json:"old_digest"`
+	NewImage        string     `json:"new_image"`
+	NewDigest       string     `json:"new_digest"`
+	Trigger         string     `json:"trigger"`
+	Actor           string     `json:"actor"`
+	Approver        string     `json:"approver"`
+	StartedAt       time.Time  `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	DurationSeconds int        `json:"duration_seconds"`
+	Result          string     `json:"result"`
+	Error           string     `json:"error"`
+	ScanVerdict     string     `json:"scan_verdict"`
+	Notes           string     `json:"notes"`
+}
+
+// Row converts the record to its export row. Updates started by a user that
+// deployed the pending digest of a pinned container count as approvals; the
+// approver is the user behind a manual or approval trigger, or the approval
+// policy that approved the update.
+func (r *UpdateReportRecord) Row() *UpdateReportRow {
+	row := &UpdateReportRow{
+		UpdateID:        r.ID,
+		ContainerID:     r.ContainerID,
+		ContainerName:   r.ContainerName,
+		OldImage:        r.OldImage,
+		OldDigest:       r.OldDigest,
+		NewImage:        r.NewImage,
+		NewDigest:       r.NewDigest,
+		Trigger:         ReportTriggerScheduled,
+		StartedAt:       r.StartedAt.UTC(),
+		DurationSeconds: r.DurationSeconds,
+		Result:          string(r.Status),
+		Error:           r.ErrorMessage,
+		ScanVerdict:     ScanVerdictNotScanned,
+		Notes:           r.Notes,
+	}
+	if r.CompletedAt != nil {
+		completedAt := r.CompletedAt.UTC()
+		row.CompletedAt = &completedAt
+	}
+
+	userName := r.ActorName
+	if userName == "" {
+		userName = r.Username
+	}
+
+	switch r.ActorType {
+	case ActorTypeUser:
+		row.Actor = "user:" + userName
+		row.Approver = userName
+		row.Trigger = ReportTriggerManual
+		if r.ResolvedDigest != "" {
+			row.Trigger = ReportTriggerApproval
+		}
+	case ActorTypeAPIToken:
+		row.Actor = "api_token:" + r.ActorName
+		row.Trigger = ReportTriggerManual
+	default:
+		row.Actor = "system:" + r.ActorName
+		if r.TriggeredBy == TriggerTypeManual || r.TriggeredBy == TriggerTypeWebhook || r.TriggeredBy == TriggerTypeRetarget {
+			row.Trigger = ReportTriggerManual
+		}
+	}
+
+	if r.Approver != "" {
+		row.Approver = r.Approver
+		row.Trigger = ReportTriggerApproval
+	}
+
+	if r.ScanPassed != nil {
+		row.ScanVerdict = ScanVerdictFailed
+		if *r.ScanPassed {
+			row.ScanVerdict = ScanVerdictPassed
+		}
+	}
+
+	return row
+}
+
+// Record returns the row's CSV fields in UpdateReportColumns order
+func (r *UpdateReportRow) Record() []string {
+	return []string{
+		strconv.Itoa(r.UpdateID),
+		strconv.Itoa(r.ContainerID),
+		r.ContainerName,
+		r.OldImage,
+		r.OldDigest,
+		r.NewImage,
+		r.NewDigest,
+		r.Trigger,
+		r.Actor,
+		r.Approver,
+		formatReportTime(&r.StartedAt),
+		formatReportTime(r.CompletedAt),
+		strconv.Itoa(r.DurationSeconds),
+		r.Result,
+		r.Error,
+		r.ScanVerdict,
+		r.Notes,
+	}
+}
+
+// TaskExecutionReportRecord is a task execution log joined with its task and
+// the task's creator
+type TaskExecutionReportRecord struct {
+	ID              int
+	TaskID          int
+	TaskName        string
+	TaskType        TaskType
+	Username        string
+	TriggeredBy     TriggerType
+	Status          ExecutionStatus
+	Message         string
+	DurationSeconds int
+	StartedAt       time.Time
+	CompletedAt     *time.Time
+}
+
+// TaskExecutionReportRow is one row of the task execution export
+type TaskExecutionReportRow struct {
+	ExecutionID     int        `json:"execution_id"`
+	TaskID          int        `json:"task_id"`
+	TaskName        string     `json:"task_name"`
+	TaskType        string     `json:"task_type"`
+	TaskOwner       string     `json:"task_owner"`
+	Trigger         string     `json:"trigger"`
+	StartedAt       time.Time  `json:"started_at"`
+	CompletedAt     *time.Time `json:"completed_at"`
+	DurationSeconds int        `json:"duration_seconds"`
+	Result          string     `json:"result"`
+	Message         string     `json:"message"`
+}
+
+// Row converts the record to its export row
+func (r *TaskExecutionReportRecord) Row() *TaskExecutionReportRow {
+	row := &TaskExecutionReportRow{
+		ExecutionID:     r.ID,
+		TaskID:          r.TaskID,
+		TaskName:        r.TaskName,
+		TaskType:        string(r.TaskType),
+		TaskOwner:       r.Username,
+		Trigger:         ReportTriggerScheduled,
+		StartedAt:       r.StartedAt.UTC(),
+		DurationSeconds: r.DurationSeconds,
+		Result:          string(r.Status),
+		Message:         r.Message,
+	}
+	if r.CompletedAt != nil {
+		completedAt := r.CompletedAt.UTC()
+		row.CompletedAt = &completedAt
+	}
+	if r.TriggeredBy == TriggerTypeManual || r.TriggeredBy == TriggerTypeWebhook {
+		row.Trigger = ReportTriggerManual
+	}
+	return row
+}
+
+// Record returns the row's CSV fields in TaskExecutionReportColumns order
+func (r *TaskExecutionReportRow) Record() []string {
+	return []string{
+		strconv.Itoa(r.ExecutionID),
+		strconv.Itoa(r.TaskID),
+		r.TaskName,
+		r.TaskType,
+		r.TaskOwner,
+		r.Trigger,
+		formatReportTime(&r.StartedAt),
+		formatReportTime(r.CompletedAt),
+		strconv.Itoa(r.DurationSeconds),
+		r.Result,
+		r.Message,
+	}
+}
+
+// Record returns the entry's CSV fields in ActivityReportColumns order
+func (a *ActivityLog) Record() []string {
+	userID := ""
+	if a.UserID != nil {
+		userID = strconv.FormatInt(*a.UserID, 10)
+	}
+	resourceID := ""
+	if a.ResourceID != nil {
+		resourceID = strconv.Itoa(*a.ResourceID)
+	}
+
+	return []string{
+		strconv.Itoa(a.ID),
+		formatReportTime(&a.CreatedAt),
+		userID,
+		string(a.ActorType),
+		a.ActorName,
+		a.Action,
+		a.ResourceType,
+		resourceID,
+		a.ResourceName,
+		a.Description,
+		a.IPAddress,
+		a.UserAgent,
+		a.Metadata,
+	}
+}
+
+func formatReportTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,314 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Permission is an action a role grants, as resource:action. The admin
+// permission grants every other one, resource:manage and resource:* grant
+// every action on the resource but the explicit ones, and write and delete
+// imply read.
+type Permission string
+
+const (
+	// Basic permissions
+	PermissionRead   Permission = "read"
+	PermissionWrite  Permission = "write"
+	PermissionDelete Permission = "delete"
+	PermissionAdmin  Permission = "admin"
+
+	// Resource-specific permissions
+	PermissionContainerRead   Permission = "container:read"
+	PermissionContainerWrite  Permission = "container:write"
+	PermissionContainerUpdate Permission = "container:update"
+	PermissionContainerDelete Permission = "container:delete"
+	PermissionContainerManage Permission = "container:manage"
+	PermissionContainerExec   Permission = "container:exec"
+	// PermissionContainerSecrets reveals secret environment variable and
+	// label values, which are masked otherwise
+	PermissionContainerSecrets Permission = "container:secrets"
+
+	PermissionImageRead   Permission = "image:read"
+	PermissionImageWrite  Permission = "image:write"
+	PermissionImageDelete Permission = "image:delete"
+
+	PermissionUserRead   Permission = "user:read"
+	PermissionUserWrite  Permission = "user:write"
+	PermissionUserDelete Permission = "user:delete"
+	PermissionUserManage Permission = "user:manage"
+
+	PermissionSystemRead   Permission = "system:read"
+	PermissionSystemWrite  Permission = "system:write"
+	PermissionSystemManage Permission = "system:manage"
+
+	PermissionScheduleRead   Permission = "schedule:read"
+	PermissionScheduleWrite  Permission = "schedule:write"
+	PermissionScheduleDelete Permission = "schedule:delete"
+
+	PermissionNotificationRead  Permission = "notification:read"
+	PermissionNotificationWrite Permission = "notification:write"
+)
+
+// PermissionInfo describes a permission roles can grant
+type PermissionInfo struct {
+	Permission  Permission `json:"permission"`
+	Description string     `json:"description"`
+}
+
+// Permissions lists every permission roles can grant
+var Permissions = []PermissionInfo{
+	{PermissionRead, "Read dashboards, images, updates and other shared resources"},
+	{PermissionWrite, "Change shared resources such as scheduled checks and notifications"},
+	{PermissionDelete, "Delete shared resources"},
+	{PermissionAdmin, "Administer the system; grants every permission"},
+	{PermissionContainerRead, "View containers, their logs and stats"},
+	{PermissionContainerWrite, "Create and edit containers"},
+	{PermissionContainerUpdate, "Update containers to new images"},
+	{PermissionContainerDelete, "Delete containers"},
+	{PermissionContainerManage, "Start, stop, restart and update containers; grants every container permission"},
+	{PermissionContainerExec, "Run commands inside containers"},
+	{PermissionContainerSecrets, "Reveal secret environment variable and label values of containers; only granted explicitly"},
+	{PermissionImageRead, "View images and versions"},
+	{PermissionImageWrite, "Pull images and check for updates"},
+	{PermissionImageDelete, "Delete images"},
+	{PermissionUserRead, "View users"},
+	{PermissionUserWrite, "Create and edit users"},
+	{PermissionUserDelete, "Delete users"},
+	{PermissionUserManage, "Manage users; grants every user permission"},
+	{PermissionSystemRead, "View system information"},
+	{PermissionSystemWrite, "Change system settings"},
+	{PermissionSystemManage, "Manage the system; grants every system permission"},
+	{PermissionScheduleRead, "View scheduled tasks"},
+	{PermissionScheduleWrite, "Create, edit and run scheduled tasks"},
+	{PermissionScheduleDelete, "Delete scheduled tasks"},
+	{PermissionNotificationRead, "View notifications"},
+	{PermissionNotificationWrite, "Change notification settings"},
+}
+
+// explicitPermissions are not granted by resource:manage or resource:*
+var explicitPermissions = map[Permission]bool{
+	PermissionContainerSecrets: true,
+}
+
+// builtinRolePermissions are the permissions of the built-in roles. They are
+// defined here rather than stored, so upgrades can extend them.
+var builtinRolePermissions = map[UserRole][]Permission{
+	UserRoleAdmin: {
+		// Admin has all permissions
+		PermissionRead, PermissionWrite, PermissionDelete, PermissionAdmin,
+		PermissionContainerRead, PermissionContainerWrite, PermissionContainerUpdate, PermissionContainerDelete, PermissionContainerManage, PermissionContainerExec, PermissionContainerSecrets,
+		PermissionImageRead, PermissionImageWrite, PermissionImageDelete,
+		PermissionUserRead, PermissionUserWrite, PermissionUserDelete, PermissionUserManage,
+		PermissionSystemRead, PermissionSystemWrite, PermissionSystemManage,
+		PermissionScheduleRead, PermissionScheduleWrite, PermissionScheduleDelete,
+		PermissionNotificationRead, PermissionNotificationWrite,
+	},
+	UserRoleOperator: {
+		// Operator can manage containers and images, read system info
+		PermissionRead, PermissionWrite,
+		PermissionContainerRead, PermissionContainerWrite, PermissionContainerUpdate, PermissionContainerDelete, PermissionContainerManage, PermissionContainerExec,
+		PermissionImageRead, PermissionImageWrite, PermissionImageDelete,
+		PermissionSystemRead,
+		PermissionScheduleRead, PermissionScheduleWrite, PermissionScheduleDelete,
+		PermissionNotificationRead, PermissionNotificationWrite,
+	},
+	UserRoleViewer: {
+		// Viewer can only read most resources
+		PermissionRead,
+		PermissionContainerRead,
+		PermissionImageRead,
+		PermissionSystemRead,
+		PermissionScheduleRead,
+		PermissionNotificationRead,
+	},
+}
+
+// builtinRoleDescriptions describe the built-in roles in the role list
+var builtinRoleDescriptions = map[UserRole]string{
+	UserRoleAdmin:    "Full access to the system",
+	UserRoleOperator: "Manages containers, images and scheduled tasks",
+	UserRoleViewer:   "Read-only access",
+}
+
+// legacyRoles maps role names of earlier versions to the built-in role their
+// users are migrated to
+var legacyRoles = map[UserRole]UserRole{
+	"user":      UserRoleViewer,
+	"developer": UserRoleOperator,
+}
+
+// roleNamePattern restricts custom role names to lowercase identifiers
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// Role is a named set of permissions. Users are assigned roles by name, so a
+// role cannot be renamed. The built-in admin, operator and viewer roles are
+// stored too, but cannot be changed or deleted.
+type Role struct {
+	ID          int        `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        UserRole   `json:"name" gorm:"uniqueIndex;size:50;not null"`
+	Description string     `json:"description,omitempty" gorm:"size:255"`
+	Permissions StringList `json:"permissions" gorm:"type:text"`
+	BuiltIn     bool       `json:"built_in" gorm:"not null;default:false"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// TableName returns the table name for Role model
+func (Role) TableName() string {
+	return "roles"
+}
+
+// PermissionList returns the permissions the role grants directly
+func (r *Role) PermissionList() []Permission {
+	permissions := make([]Permission, len(r.Permissions))
+	for i, p := range r.Permissions {
+		permissions[i] = Permission(p)
+	}
+	return permissions
+}
+
+// Grants reports whether the role grants the permission
+func (r *Role) Grants(permission Permission) bool {
+	return GrantsPermission(r.PermissionList(), permission)
+}
+
+// Validate checks the role's name and permissions
+func (r *Role) Validate() error {
+	if !roleNamePattern.MatchString(string(r.Name)) {
+		return fmt.Errorf("role name must be 2-50 lowercase letters, digits, '-' or '_', starting with a letter")
+	}
+	if len(r.Permissions) == 0 {
+		return fmt.Errorf("role must grant at least one permission")
+	}
+	for _, p := range r.Permissions {
+		if err := ValidatePermission(Permission(p)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidatePermission checks that a permission can be granted: one listed in
+// Permissions, or resource:* for a resource listed there
+func ValidatePermission(permission Permission) error {
+	resource, action, scoped := strings.Cut(string(permission), ":")
+	for _, info := range Permissions {
+		if info.Permission == permission {
+			return nil
+		}
+		if scoped && action == "*" && strings.HasPrefix(string(info.Permission), resource+":") {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown permission %q", permission)
+}
+
+// GrantsPermission reports whether the granted permissions include required,
+// directly or through admin, a resource wildcard or manage permission, or a
+// basic permission implying it
+func GrantsPermission(granted []Permission, required Permission) bool {
+	resource, _, scoped := strings.Cut(string(required), ":")
+
+	for _, p := range granted {
+		if p == required || p == PermissionAdmin {
+			return true
+		}
+
+		// container:* and container:manage allow container:read
+		if scoped && !explicitPermissions[required] && (p == Permission(resource+":*") || p == Permission(resource+":manage")) {
+			return true
+		}
+
+		// Basic permission inheritance
+		if required == PermissionRead && (p == PermissionWrite || p == PermissionDelete) {
+			return true
+		}
+		if required == PermissionWrite && p == PermissionDelete {
+			return true
+		}
+	}
+
+	return false
+}
+
+// IsBuiltinRole reports whether the role is one of the built-in roles
+func IsBuiltinRole(role UserRole) bool {
+	_, exists := builtinRolePermissions[role]
+	return exists
+}
+
+// BuiltinRolePermissions returns the permissions of a built-in role, false
+// for other roles
+func BuiltinRolePermissions(role UserRole) ([]Permission, bool) {
+	permissions, exists := builtinRolePermissions[role]
+	if !exists {
+		return nil, false
+	}
+	return append([]Permission(nil), permissions...), true
+}
+
+// BuiltinRoles returns the built-in roles as stored in the roles table
+func BuiltinRoles() []*Role {
+	roles := make([]*Role, 0, len(builtinRolePermissions))
+	for _, name := range GetValidRoles() {
+		permissions := make(StringList, 0, len(builtinRolePermissions[name]))
+		for _, p := range builtinRolePermissions[name] {
+			permissions = append(permissions, string(p))
+		}
+		roles = append(roles, &Role{
+			Name:        name,
+			Description: builtinRoleDescriptions[name],
+			Permissions: permissions,
+			BuiltIn:     true,
+		})
+	}
+	return roles
+}
+
+// CanonicalRole returns the role a role name of this or an earlier version
+// stands for, e.g. "operator" for "Developer"
+func CanonicalRole(role UserRole) UserRole {
+	role = UserRole(strings.ToLower(string(role)))
+	if current, ok := legacyRoles[role]; ok {
+		return current
+	}
+	return role
+}
+
+// MigrateRoles stores the built-in roles with their current permissions and
+// moves users off role names of earlier versions
+func MigrateRoles(db *gorm.DB) error {
+	for _, role := range BuiltinRoles() {
+		var existing Role
+		err := db.Where("name = ?", role.Name).First(&existing).Error
+		switch {
+		case err == gorm.ErrRecordNotFound:
+			if err := db.Create(role).Error; err != nil {
+				return fmt.Errorf("failed to create built-in role %s: %w", role.Name, err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to get role %s: %w", role.Name, err)
+		default:
+			err := db.Model(&existing).Updates(map[string]interface{}{
+				"description": role.Description,
+				"permissions": role.Permissions,
+				"built_in":    true,
+			}).Error
+			if err != nil {
+				return fmt.Errorf("failed to update built-in role %s: %w", role.Name, err)
+			}
+		}
+	}
+
+	for legacy, current := range legacyRoles {
+		if err := db.Model(&User{}).Where("role = ?", legacy).Update("role", current).Error; err != nil {
+			return fmt.Errorf("failed to migrate %s users to %s: %w", legacy, current, err)
+		}
+	}
+	return nil
+}
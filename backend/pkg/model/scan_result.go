@@ -0,0 +1,35 @@
+package model
+
+import (
+	"time"
+)
+
+// ScanResult is the stored outcome of a vulnerability scan of an image.
+// Results are keyed by digest so every tag of the same image shares one scan.
+type ScanResult struct {
+	ID              int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	ImageDigest     string    `json:"image_digest" gorm:"uniqueIndex:idx_scan_results_digest;not null;size:100"`
+	ImageName       string    `json:"image_name" gorm:"not null;size:255;index:idx_scan_results_image_name"`
+	ScannedAt       time.Time `json:"scanned_at" gorm:"not null;index:idx_scan_results_scanned_at"`
+	Vulnerabilities string    `json:"vulnerabilities" gorm:"type:jsonb;not null;default:'[]'"`
+	TotalVulns      int       `json:"total_vulns" gorm:"not null;default:0"`
+	CriticalVulns   int       `json:"critical_vulns" gorm:"not null;default:0"`
+	HighVulns       int       `json:"high_vulns" gorm:"not null;default:0"`
+	MediumVulns     int       `json:"medium_vulns" gorm:"not null;default:0"`
+	LowVulns        int       `json:"low_vulns" gorm:"not null;default:0"`
+	Passed          bool      `json:"passed" gorm:"not null;default:false"`
+	ScannerVersion  string    `json:"scanner_version" gorm:"size:50"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for ScanResult model
+func (ScanResult) TableName() string {
+	return "scan_results"
+}
+
+// IsFresh reports whether the result was produced by the given scanner version
+// within ttl of now
+func (sr *ScanResult) IsFresh(scannerVersion string, ttl time.Duration, now time.Time) bool {
+	return sr.ScannerVersion == scannerVersion && now.Sub(sr.ScannedAt) < ttl
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"errors"
 	"fmt"
 	"time"
 
@@ -8,12 +9,19 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrTaskVersionConflict is returned when a scheduled task was modified
+// between being read and written back
+var ErrTaskVersionConflict = errors.New("scheduled task was modified concurrently")
+
 // ScheduledTask represents scheduled tasks in the system
 type ScheduledTask struct {
 	ID               int              `json:"id" gorm:"primaryKey;autoIncrement"`
 	Name             string           `json:"name" gorm:"uniqueIndex;not null;size:100"`
 	Type             TaskType         `json:"type" gorm:"not null;index:idx_scheduled_tasks_type"`
-	CronExpression   string           `json:"cron_expression" gorm:"not null;size:100"`
+	ScheduleType     ScheduleType     `json:"schedule_type" gorm:"not null;size:10;default:'cron'"`
+	CronExpression   string           `json:"cron_expression" gorm:"not null;size:100"` // Empty for one-shot tasks
+	RunAt            *time.Time       `json:"run_at,omitempty"`                         // Run time of a one-shot task
+	CompletedAt      *time.Time       `json:"completed_at,omitempty"`                   // Set once a one-shot task has run
 	TargetContainers string           `json:"target_containers,omitempty" gorm:"type:jsonb;default:'[]'"`
 	Parameters       string           `json:"parameters,omitempty" gorm:"type:jsonb;default:'{}'"`
 	IsActive         bool             `json:"is_active" gorm:"not null;default:true;index:idx_scheduled_tasks_is_active"`
@@ -21,6 +29,7 @@ type ScheduledTask struct {
 	NextRunAt        *time.Time       `json:"next_run_at,omitempty" gorm:"index:idx_scheduled_tasks_next_run_at"`
 	RunCount         int              `json:"run_count" gorm:"default:0"`
 	FailureCount     int              `json:"failure_count" gorm:"default:0"`
+	Version          int              `json:"version" gorm:"not null;default:1"` // Bumped on every write of the definition or next run
 	CreatedBy        *int             `json:"created_by,omitempty"`
 	CreatedAt        time.Time        `json:"created_at"`
 	UpdatedAt        time.Time        `json:"updated_at"`
@@ -37,6 +46,7 @@ type TaskExecutionLog struct {
 	Status          ExecutionStatus  `json:"status" gorm:"not null;default:'running';index:idx_task_execution_logs_status"`
 	Message         string           `json:"message,omitempty" gorm:"type:text"`
 	DurationSeconds int              `json:"duration_seconds" gorm:"default:0"`
+	TriggeredBy     TriggerType      `json:"triggered_by" gorm:"size:20;default:'schedule'"`
 	StartedAt       time.Time        `json:"started_at" gorm:"index:idx_task_execution_logs_started_at,sort:desc"`
 	CompletedAt     *time.Time       `json:"completed_at,omitempty"`
 
@@ -53,8 +63,30 @@ const (
 	TaskTypeCleanup       TaskType = "cleanup"
 	TaskTypeBackup        TaskType = "backup"
 	TaskTypeHealthCheck   TaskType = "health_check"
+	TaskTypeChangeFeed    TaskType = "change_feed"
+	TaskTypeVolumeUsage   TaskType = "volume_usage"
+	TaskTypeStatusSync    TaskType = "status_sync"
+	TaskTypeSecurityPosture TaskType = "security_posture"
+	TaskTypeGitOps        TaskType = "gitops_reconcile"
+	TaskTypeNotificationDigest TaskType = "notification_digest"
+	TaskTypeContainerDiscovery TaskType = "container_discovery"
+)
+
+// ScheduleType defines how a scheduled task is timed
+type ScheduleType string
+
+const (
+	// ScheduleTypeCron runs the task on its cron expression
+	ScheduleTypeCron ScheduleType = "cron"
+	// ScheduleTypeOnce runs the task once at its run time, after which it is
+	// completed and deactivated
+	ScheduleTypeOnce ScheduleType = "once"
 )
 
+// OneShotGracePeriod is how long a one-shot run time may have passed and
+// still be accepted; such a task runs straight away
+const OneShotGracePeriod = time.Minute
+
 // ExecutionStatus defines task execution status
 type ExecutionStatus string
 
@@ -63,6 +95,18 @@ const (
 	ExecutionStatusSuccess ExecutionStatus = "success"
 	ExecutionStatusFailed  ExecutionStatus = "failed"
 	ExecutionStatusTimeout ExecutionStatus = "timeout"
+
+	// ExecutionStatusPartial is a run that stopped at its time budget after
+	// completing part of its work; the rest is deferred to the next run
+	ExecutionStatusPartial ExecutionStatus = "partial"
+
+	// ExecutionStatusCancelled is a run stopped on request; whatever it
+	// completed before stopping is kept
+	ExecutionStatusCancelled ExecutionStatus = "cancelled"
+
+	// ExecutionStatusInterrupted is a run cut short by a shutdown, or found
+	// still running at startup after a crash
+	ExecutionStatusInterrupted ExecutionStatus = "interrupted"
 )
 
 // TaskParameters represents different task parameter structures
@@ -81,6 +125,7 @@ type ImageCheckParams struct {
 	CheckTags         []string `json:"check_tags"`
 	IgnoreArchs       []string `json:"ignore_archs"`
 	NotifyOnNewImage  bool     `json:"notify_on_new_image"`
+	RunBudgetSeconds  int      `json:"run_budget_seconds"`
 }
 
 // ContainerUpdateParams represents parameters for container update tasks
@@ -143,6 +188,9 @@ type TaskExecutionLogFilter struct {
 	Limit           int             `json:"limit,omitempty"`
 	Offset          int             `json:"offset,omitempty"`
 	OrderBy         string          `json:"order_by,omitempty"`
+
+	// Cursor switches to keyset pagination on (started_at, id); Offset and OrderBy are ignored
+	Cursor *Cursor `json:"-"`
 }
 
 // TableName returns the table name for ScheduledTask model
@@ -171,8 +219,22 @@ func (st *ScheduledTask) ShouldRun() bool {
 	return time.Now().After(*st.NextRunAt)
 }
 
-// CalculateNextRun calculates the next run time based on cron expression
+// IsOneShot reports whether the task runs once at RunAt
+func (st *ScheduledTask) IsOneShot() bool {
+	return st.ScheduleType == ScheduleTypeOnce
+}
+
+// CalculateNextRun calculates the next run time based on cron expression, or
+// the run time of a one-shot task that has not run yet
 func (st *ScheduledTask) CalculateNextRun() error {
+	if st.IsOneShot() {
+		st.NextRunAt = nil
+		if st.CompletedAt == nil {
+			st.NextRunAt = st.RunAt
+		}
+		return nil
+	}
+
 	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
 	schedule, err := parser.Parse(st.CronExpression)
 	if err != nil {
@@ -184,6 +246,24 @@ func (st *ScheduledTask) CalculateNextRun() error {
 	return nil
 }
 
+// NextRunAfter returns the first run time of the task's schedule after t. A
+// one-shot task has none once its run time has passed.
+func (st *ScheduledTask) NextRunAfter(t time.Time) (time.Time, error) {
+	if st.IsOneShot() {
+		if st.CompletedAt != nil || st.RunAt == nil || !st.RunAt.After(t) {
+			return time.Time{}, fmt.Errorf("one-shot task has no run after %s", t.Format(time.RFC3339))
+		}
+		return *st.RunAt, nil
+	}
+
+	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+	schedule, err := parser.Parse(st.CronExpression)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid cron expression: %v", err)
+	}
+	return schedule.Next(t), nil
+}
+
 // ValidateCronExpression validates the cron expression
 func (st *ScheduledTask) ValidateCronExpression() error {
 	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
@@ -191,6 +271,39 @@ func (st *ScheduledTask) ValidateCronExpression() error {
 	return err
 }
 
+// ValidateSchedule validates the cron expression of a cron task, or that a
+// one-shot task has a run time and no cron expression
+func (st *ScheduledTask) ValidateSchedule() error {
+	switch st.ScheduleType {
+	case ScheduleTypeCron, "":
+		if st.RunAt != nil {
+			return fmt.Errorf("run_at is only allowed for one-shot tasks")
+		}
+		if err := st.ValidateCronExpression(); err != nil {
+			return fmt.Errorf("invalid cron expression: %w", err)
+		}
+	case ScheduleTypeOnce:
+		if st.RunAt == nil {
+			return fmt.Errorf("run_at is required for one-shot tasks")
+		}
+		if st.CronExpression != "" {
+			return fmt.Errorf("one-shot tasks take no cron expression")
+		}
+	default:
+		return fmt.Errorf("unknown schedule type %q", st.ScheduleType)
+	}
+	return nil
+}
+
+// ValidateRunAt rejects a one-shot run time that passed more than
+// OneShotGracePeriod before now
+func ValidateRunAt(runAt, now time.Time) error {
+	if runAt.Before(now.Add(-OneShotGracePeriod)) {
+		return fmt.Errorf("run_at %s is in the past", runAt.Format(time.RFC3339))
+	}
+	return nil
+}
+
 // GetSuccessRate returns the success rate of the task
 func (st *ScheduledTask) GetSuccessRate() float64 {
 	if st.RunCount == 0 {
@@ -216,7 +329,10 @@ func (st *ScheduledTask) IncrementFailureCount() {
 func (tel *TaskExecutionLog) IsCompleted() bool {
 	return tel.Status == ExecutionStatusSuccess ||
 		tel.Status == ExecutionStatusFailed ||
-		tel.Status == ExecutionStatusTimeout
+		tel.Status == ExecutionStatusTimeout ||
+		tel.Status == ExecutionStatusPartial ||
+		tel.Status == ExecutionStatusCancelled ||
+		tel.Status == ExecutionStatusInterrupted
 }
 
 // IsSuccessful checks if the task execution was successful
@@ -252,6 +368,13 @@ func GetValidTaskTypes() []TaskType {
 		TaskTypeCleanup,
 		TaskTypeBackup,
 		TaskTypeHealthCheck,
+		TaskTypeChangeFeed,
+		TaskTypeVolumeUsage,
+		TaskTypeStatusSync,
+		TaskTypeSecurityPosture,
+		TaskTypeGitOps,
+		TaskTypeNotificationDigest,
+		TaskTypeContainerDiscovery,
 	}
 }
 
@@ -262,6 +385,9 @@ func GetValidExecutionStatuses() []ExecutionStatus {
 		ExecutionStatusSuccess,
 		ExecutionStatusFailed,
 		ExecutionStatusTimeout,
+		ExecutionStatusPartial,
+		ExecutionStatusCancelled,
+		ExecutionStatusInterrupted,
 	}
 }
 
@@ -284,9 +410,105 @@ func GetDefaultCronExpressions() map[string]string {
 	}
 }
 
+// ScheduledTaskTemplate describes a built-in task that can be seeded on demand
+type ScheduledTaskTemplate struct {
+	Key            string   `json:"key"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Type           TaskType `json:"type"`
+	CronExpression string   `json:"cron_expression"`
+	Parameters     string   `json:"parameters"`
+}
+
+// GetDefaultTaskTemplates returns the scheduled tasks offered during first-run setup
+func GetDefaultTaskTemplates() []ScheduledTaskTemplate {
+	return []ScheduledTaskTemplate{
+		{
+			Key:            "nightly_update_check",
+			Name:           "Nightly update check",
+			Description:    "Check all managed images for new versions every night",
+			Type:           TaskTypeImageCheck,
+			CronExpression: "0 2 * * *",
+			Parameters:     `{"registry_timeout":60,"max_concurrent":5,"notify_on_new_image":true}`,
+		},
+		{
+			Key:            "weekly_cleanup",
+			Name:           "Weekly cleanup",
+			Description:    "Remove old logs, history and dangling images every Sunday",
+			Type:           TaskTypeCleanup,
+			CronExpression: "0 3 * * 0",
+			Parameters:     `{"log_retention_days":30,"history_retention_count":1000,"image_cache_retention_days":7,"cleanup_dangling_images":true}`,
+		},
+		{
+			Key:            "change_feed_delivery",
+			Name:           "Change feed delivery",
+			Description:    "Push new container change records to the change feed webhook every minute",
+			Type:           TaskTypeChangeFeed,
+			CronExpression: "* * * * *",
+			Parameters:     `{"batch_size":100}`,
+		},
+		{
+			Key:            "daily_volume_usage",
+			Name:           "Daily volume usage",
+			Description:    "Sample Docker volume sizes and check volume growth and free space alerts every day",
+			Type:           TaskTypeVolumeUsage,
+			CronExpression: "0 4 * * *",
+			Parameters:     `{}`,
+		},
+		{
+			Key:            "container_status_sync",
+			Name:           "Container status sync",
+			Description:    "Inspect containers that changed or have not been verified recently every 5 minutes",
+			Type:           TaskTypeStatusSync,
+			CronExpression: "*/5 * * * *",
+			Parameters:     `{"full":false}`,
+		},
+		{
+			Key:            "weekly_security_posture",
+			Name:           "Weekly security posture",
+			Description:    "Score the security configuration every Monday and notify admins of regressions",
+			Type:           TaskTypeSecurityPosture,
+			CronExpression: "0 5 * * 1",
+			Parameters:     `{}`,
+		},
+		{
+			Key:            "notification_digest",
+			Name:           "Notification digest",
+			Description:    "Send the hourly and daily update digests users chose every 15 minutes",
+			Type:           TaskTypeNotificationDigest,
+			CronExpression: "*/15 * * * *",
+			Parameters:     `{}`,
+		},
+		{
+			Key:            "container_discovery",
+			Name:           "Container discovery",
+			Description:    "Adopt containers labeled for discovery and mark those whose label was removed as unmanaged every 5 minutes",
+			Type:           TaskTypeContainerDiscovery,
+			CronExpression: "*/5 * * * *",
+			Parameters:     `{}`,
+		},
+	}
+}
+
+// ToScheduledTask builds an active ScheduledTask from the template
+func (t ScheduledTaskTemplate) ToScheduledTask(createdBy *int) *ScheduledTask {
+	return &ScheduledTask{
+		Name:             t.Name,
+		Type:             t.Type,
+		CronExpression:   t.CronExpression,
+		TargetContainers: "[]",
+		Parameters:       t.Parameters,
+		IsActive:         true,
+		CreatedBy:        createdBy,
+	}
+}
+
 // BeforeCreate hook for ScheduledTask model
 func (st *ScheduledTask) BeforeCreate(tx *gorm.DB) error {
-	if err := st.ValidateCronExpression(); err != nil {
+	if st.ScheduleType == "" {
+		st.ScheduleType = ScheduleTypeCron
+	}
+	if err := st.ValidateSchedule(); err != nil {
 		return err
 	}
 	if err := st.CalculateNextRun(); err != nil {
@@ -297,7 +519,7 @@ func (st *ScheduledTask) BeforeCreate(tx *gorm.DB) error {
 
 // BeforeUpdate hook for ScheduledTask model
 func (st *ScheduledTask) BeforeUpdate(tx *gorm.DB) error {
-	if err := st.ValidateCronExpression(); err != nil {
+	if err := st.ValidateSchedule(); err != nil {
 		return err
 	}
 	if err := st.CalculateNextRun(); err != nil {
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+// SchedulerEventLog is a recorded scheduler event, such as a task starting,
+// failing or missing its run. The table is pruned to a fixed number of rows.
+type SchedulerEventLog struct {
+	ID        int64     `json:"id" gorm:"primaryKey;autoIncrement"`
+	Type      string    `json:"type" gorm:"size:32;not null;index"`
+	TaskID    *int      `json:"task_id,omitempty" gorm:"index"`
+	Message   string    `json:"message" gorm:"type:text"`
+	Data      JSONMap   `json:"data,omitempty" gorm:"type:jsonb;default:'{}'"`
+	CreatedAt time.Time `json:"created_at" gorm:"index"`
+}
+
+// TableName returns the table name for SchedulerEventLog model
+func (SchedulerEventLog) TableName() string {
+	return "scheduler_events"
+}
+
+// SchedulerEventFilter represents filters for listing scheduler events
+type SchedulerEventFilter struct {
+	TaskID *int     `json:"task_id,omitempty"`
+	Types  []string `json:"types,omitempty"`
+	Limit  int      `json:"limit,omitempty"`
+	Offset int      `json:"offset,omitempty"`
+}
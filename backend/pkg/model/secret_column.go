@@ -0,0 +1,56 @@
+package model
+
+// ConfigKeySecurityEncryptionKeyCheck stores fingerprints of the secret
+// encryption keys, by version, to detect a key changed under the same version
+const ConfigKeySecurityEncryptionKeyCheck = "security.encryption_key_check"
+
+// SecretColumn is a database column holding secrets encrypted at rest
+type SecretColumn struct {
+	Table  string
+	Column string
+
+	// Where limits the rows holding secrets, e.g. to some config keys
+	Where     string
+	WhereArgs []interface{}
+
+	// JSON columns store the ciphertext as a JSON string
+	JSON bool
+
+	// EncryptedFlag names a boolean column marking encrypted rows
+	EncryptedFlag string
+}
+
+// Name returns the table and column, e.g. "registry_credentials.token_encrypted"
+func (c SecretColumn) Name() string {
+	return c.Table + "." + c.Column
+}
+
+// SecretValue is one stored value of a secret column
+type SecretValue struct {
+	ID    int
+	Value string
+}
+
+// SecretColumns returns every column holding encrypted secrets: registry
+// credentials, the registry logins of containers and the notification
+// channel settings, which include SMTP passwords and webhook secrets
+func SecretColumns() []SecretColumn {
+	return []SecretColumn{
+		{Table: "registry_credentials", Column: "password_encrypted"},
+		{Table: "registry_credentials", Column: "token_encrypted"},
+		{Table: "containers", Column: "registry_auth", JSON: true},
+		{Table: "notification_channels", Column: "settings_encrypted"},
+		{
+			Table:  "system_configs",
+			Column: "config_value",
+			Where:  "config_key IN ?",
+			WhereArgs: []interface{}{[]string{
+				ConfigKeyNotificationEmail,
+				ConfigKeyNotificationWebhook,
+				ConfigKeyNotificationSlack,
+			}},
+			JSON:          true,
+			EncryptedFlag: "is_encrypted",
+		},
+	}
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// Posture finding severities, from least to most severe
+const (
+	PostureSeverityLow      = "low"
+	PostureSeverityMedium   = "medium"
+	PostureSeverityHigh     = "high"
+	PostureSeverityCritical = "critical"
+)
+
+// PostureSeverityRank orders severities; unknown severities rank lowest
+func PostureSeverityRank(severity string) int {
+	switch severity {
+	case PostureSeverityLow:
+		return 1
+	case PostureSeverityMedium:
+		return 2
+	case PostureSeverityHigh:
+		return 3
+	case PostureSeverityCritical:
+		return 4
+	}
+	return 0
+}
+
+// SecurityPostureReport is a scored snapshot of the runtime security
+// configuration
+type SecurityPostureReport struct {
+	ID    int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	Score int    `json:"score" gorm:"not null"`
+	Grade string `json:"grade" gorm:"size:2;not null"`
+	// Trigger is schedule or manual; RequestedBy is the admin who asked
+	Trigger     TriggerType     `json:"trigger" gorm:"size:20;not null"`
+	RequestedBy *int            `json:"requested_by,omitempty"`
+	Rules       PostureRuleList `json:"rules" gorm:"type:jsonb;default:'[]'"`
+	Findings    PostureFindings `json:"findings" gorm:"type:jsonb;default:'[]'"`
+	EvaluatedAt time.Time       `json:"evaluated_at" gorm:"not null;index:idx_security_posture_evaluated_at"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// TableName returns the table name for SecurityPostureReport model
+func (SecurityPostureReport) TableName() string {
+	return "security_posture_reports"
+}
+
+// PostureFinding is one way the configuration falls short. ID is the rule
+// and resource, and identifies the finding across reports.
+type PostureFinding struct {
+	ID       string `json:"id"`
+	Rule     string `json:"rule"`
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	Resource string `json:"resource,omitempty"`
+	Title    string `json:"title"`
+	Detail   string `json:"detail,omitempty"`
+}
+
+// PostureFindings is a list of findings stored as JSON
+type PostureFindings []PostureFinding
+
+// Value implements driver.Valuer
+func (f PostureFindings) Value() (driver.Value, error) {
+	if f == nil {
+		return "[]", nil
+	}
+	return json.Marshal(f)
+}
+
+// Scan implements sql.Scanner
+func (f *PostureFindings) Scan(value interface{}) error {
+	return scanJSON(value, f, "PostureFindings")
+}
+
+// PostureRuleScore is what one rule took off the score
+type PostureRuleScore struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	Findings int    `json:"findings"`
+	Penalty  int    `json:"penalty"`
+}
+
+// PostureRuleList is a list of rule scores stored as JSON
+type PostureRuleList []PostureRuleScore
+
+// Value implements driver.Valuer
+func (l PostureRuleList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements sql.Scanner
+func (l *PostureRuleList) Scan(value interface{}) error {
+	return scanJSON(value, l, "PostureRuleList")
+}
+
+// PostureFindingChange is a finding that differs between two reports.
+// Previous is the severity in the earlier report, when it had the finding;
+// Resolved findings are no longer reported at all.
+type PostureFindingChange struct {
+	PostureFinding
+	Previous string `json:"previous_severity,omitempty"`
+	Resolved bool   `json:"resolved,omitempty"`
+}
+
+// PostureComparison lists how findings changed from one report to another
+type PostureComparison struct {
+	FromID     int                    `json:"from_id"`
+	ToID       int                    `json:"to_id"`
+	FromScore  int                    `json:"from_score"`
+	ToScore    int                    `json:"to_score"`
+	ScoreDelta int                    `json:"score_delta"`
+	Improved   []PostureFindingChange `json:"improved"`
+	Regressed  []PostureFindingChange `json:"regressed"`
+	Unchanged  []PostureFinding       `json:"unchanged"`
+}
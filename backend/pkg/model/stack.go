@@ -0,0 +1,99 @@
+package model
+
+import (
+	"sort"
+	"time"
+)
+
+// ComposeProjectLabel is the label Docker Compose puts on every service container
+const ComposeProjectLabel = "com.docker.compose.project"
+
+// Stack groups containers that are operated together, such as the services of
+// a compose project. Its policy settings apply to every member that doesn't set
+// its own; nil fields fall through to image policies and global defaults.
+type Stack struct {
+	ID                     int           `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name                   string        `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	Description            string        `json:"description,omitempty" gorm:"type:text"`
+	ComposeProject         string        `json:"compose_project,omitempty" gorm:"size:255;index:idx_stacks_compose_project"`
+	UpdatePolicy           *UpdatePolicy `json:"update_policy,omitempty" gorm:"size:20"`
+	CheckIntervalMinutes   *int          `json:"check_interval_minutes,omitempty"`
+	HoldDownHours          *int          `json:"hold_down_hours,omitempty"`
+	MaintenanceWindows     string        `json:"maintenance_windows,omitempty" gorm:"type:jsonb"`
+	VulnerabilityThreshold *string       `json:"vulnerability_threshold,omitempty" gorm:"size:20"`
+	CreatedBy              *int          `json:"created_by,omitempty" gorm:"index:idx_stacks_created_by"`
+	CreatedAt              time.Time     `json:"created_at"`
+	UpdatedAt              time.Time     `json:"updated_at"`
+
+	// Relationships
+	Members       []*Container `json:"members,omitempty" gorm:"foreignKey:StackID"`
+	CreatedByUser *User        `json:"-" gorm:"foreignKey:CreatedBy"`
+}
+
+// StackFilter represents filters for querying stacks
+type StackFilter struct {
+	Name      string `json:"name,omitempty"`
+	CreatedBy *int   `json:"created_by,omitempty"`
+	Limit     int    `json:"limit,omitempty"`
+	Offset    int    `json:"offset,omitempty"`
+	OrderBy   string `json:"order_by,omitempty"`
+}
+
+// TableName returns the table name for Stack model
+func (Stack) TableName() string {
+	return "stacks"
+}
+
+// GetMaintenanceWindows decodes the stack's maintenance windows
+func (s *Stack) GetMaintenanceWindows() []MaintenanceWindow {
+	return decodeMaintenanceWindows(s.MaintenanceWindows)
+}
+
+// OrderedMembers returns the members in start order. Members start in
+// ascending StackOrder and stop in reverse.
+func (s *Stack) OrderedMembers() []*Container {
+	members := make([]*Container, len(s.Members))
+	copy(members, s.Members)
+	sort.SliceStable(members, func(i, j int) bool {
+		if members[i].StackOrder != members[j].StackOrder {
+			return members[i].StackOrder < members[j].StackOrder
+		}
+		return members[i].ID < members[j].ID
+	})
+	return members
+}
+
+// statusSeverity ranks container statuses from healthy to worst
+var statusSeverity = map[ContainerStatus]int{
+	ContainerStatusRunning:    0,
+	ContainerStatusPaused:     1,
+	ContainerStatusRestarting: 2,
+	ContainerStatusStopped:    3,
+	ContainerStatusRemoving:   4,
+	ContainerStatusExited:     5,
+	ContainerStatusUnknown:    6,
+	ContainerStatusDead:       7,
+}
+
+// CombinedStatus returns the worst status among the members, or unknown for an
+// empty stack
+func CombinedStatus(members []*Container) ContainerStatus {
+	if len(members) == 0 {
+		return ContainerStatusUnknown
+	}
+
+	worst := members[0].Status
+	for _, member := range members[1:] {
+		if severityOf(member.Status) > severityOf(worst) {
+			worst = member.Status
+		}
+	}
+	return worst
+}
+
+func severityOf(status ContainerStatus) int {
+	if severity, ok := statusSeverity[status]; ok {
+		return severity
+	}
+	return statusSeverity[ContainerStatusUnknown]
+}
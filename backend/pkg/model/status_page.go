@@ -0,0 +1,207 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ComponentState is the state a status page shows for a component
+type ComponentState string
+
+const (
+	ComponentOperational ComponentState = "operational"
+	ComponentDegraded    ComponentState = "degraded"
+	ComponentDown        ComponentState = "down"
+)
+
+// severity orders states from best to worst
+func (s ComponentState) severity() int {
+	switch s {
+	case ComponentOperational:
+		return 0
+	case ComponentDegraded:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Worse returns the worse of two states
+func (s ComponentState) Worse(other ComponentState) ComponentState {
+	if other.severity() > s.severity() {
+		return other
+	}
+	return s
+}
+
+// Valid reports whether the state is one a status page can show
+func (s ComponentState) Valid() bool {
+	switch s {
+	case ComponentOperational, ComponentDegraded, ComponentDown:
+		return true
+	}
+	return false
+}
+
+const (
+	maxStatusPageComponents  = 50
+	maxStatusPageNameLength  = 100
+	maxStatusPageTitleLength = 100
+)
+
+// StatusPage publishes the state of selected containers, under display names
+// only, at a URL holding an unguessable token
+type StatusPage struct {
+	ID           int                  `json:"id" gorm:"primaryKey;autoIncrement"`
+	Title        string               `json:"title" gorm:"size:100;not null"`
+	Enabled      bool                 `json:"enabled" gorm:"not null;default:false"`
+	Token        string               `json:"token" gorm:"size:64;not null;uniqueIndex"`
+	Components   StatusPageComponents `json:"components" gorm:"type:jsonb;default:'[]'"`
+	SignalStates StatusSignalStates   `json:"signal_states" gorm:"type:jsonb;default:'{}'"`
+	CreatedBy    *int                 `json:"created_by,omitempty"`
+	CreatedAt    time.Time            `json:"created_at"`
+	UpdatedAt    time.Time            `json:"updated_at"`
+}
+
+// TableName returns the table name for StatusPage model
+func (StatusPage) TableName() string {
+	return "status_pages"
+}
+
+// StatusPageComponent is a container shown on a status page
+type StatusPageComponent struct {
+	ContainerID int    `json:"container_id"`
+	DisplayName string `json:"display_name"`
+	Group       string `json:"group,omitempty"`
+}
+
+// StatusPageComponents is stored as a JSON array
+type StatusPageComponents []StatusPageComponent
+
+// Value implements the driver.Valuer interface for database storage
+func (l StatusPageComponents) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (l *StatusPageComponents) Scan(value interface{}) error {
+	return scanJSON(value, l, "StatusPageComponents")
+}
+
+// Status page signals. Container signals are the container's status, health
+// signals the health checker's last result.
+const (
+	StatusSignalContainerPrefix = "container:"
+	StatusSignalHealthPrefix    = "health:"
+)
+
+// statusSignals lists every signal a status page maps, with its default state
+var statusSignals = map[string]ComponentState{
+	StatusSignalContainerPrefix + string(ContainerStatusRunning):    ComponentOperational,
+	StatusSignalContainerPrefix + string(ContainerStatusRestarting): ComponentDegraded,
+	StatusSignalContainerPrefix + string(ContainerStatusPaused):     ComponentDown,
+	StatusSignalContainerPrefix + string(ContainerStatusStopped):    ComponentDown,
+	StatusSignalContainerPrefix + string(ContainerStatusExited):     ComponentDown,
+	StatusSignalContainerPrefix + string(ContainerStatusDead):       ComponentDown,
+	StatusSignalContainerPrefix + string(ContainerStatusRemoving):   ComponentDown,
+	StatusSignalContainerPrefix + string(ContainerStatusUnknown):    ComponentDegraded,
+	StatusSignalHealthPrefix + "healthy":                            ComponentOperational,
+	StatusSignalHealthPrefix + "unknown":                            ComponentOperational,
+	StatusSignalHealthPrefix + "warning":                            ComponentDegraded,
+	StatusSignalHealthPrefix + "unhealthy":                          ComponentDown,
+}
+
+// StatusSignalStates overrides the state shown for signals, e.g.
+// {"health:warning": "operational"}. Unlisted signals keep their default.
+type StatusSignalStates map[string]ComponentState
+
+// StateOf returns the state shown for a signal. Signals without a mapping,
+// such as a status this version does not know, show as degraded.
+func (m StatusSignalStates) StateOf(signal string) ComponentState {
+	if state, ok := m[signal]; ok {
+		return state
+	}
+	if state, ok := statusSignals[signal]; ok {
+		return state
+	}
+	return ComponentDegraded
+}
+
+// Validate checks that every overridden signal and state is known
+func (m StatusSignalStates) Validate() error {
+	for signal, state := range m {
+		if _, ok := statusSignals[signal]; !ok {
+			return fmt.Errorf("unknown status signal %q", signal)
+		}
+		if !state.Valid() {
+			return fmt.Errorf("signal %q maps to unknown state %q", signal, state)
+		}
+	}
+	return nil
+}
+
+// DefaultStatusSignalStates returns the default state of every signal
+func DefaultStatusSignalStates() StatusSignalStates {
+	states := make(StatusSignalStates, len(statusSignals))
+	for signal, state := range statusSignals {
+		states[signal] = state
+	}
+	return states
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (m StatusSignalStates) Value() (driver.Value, error) {
+	if m == nil {
+		return "{}", nil
+	}
+	return json.Marshal(m)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (m *StatusSignalStates) Scan(value interface{}) error {
+	return scanJSON(value, m, "StatusSignalStates")
+}
+
+// Validate validates the page's title, components and signal mapping
+func (p *StatusPage) Validate() error {
+	p.Title = strings.TrimSpace(p.Title)
+	if p.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if len(p.Title) > maxStatusPageTitleLength {
+		return fmt.Errorf("title must be at most %d characters", maxStatusPageTitleLength)
+	}
+
+	if len(p.Components) > maxStatusPageComponents {
+		return fmt.Errorf("at most %d components are allowed", maxStatusPageComponents)
+	}
+	seen := make(map[int]bool, len(p.Components))
+	for i := range p.Components {
+		component := &p.Components[i]
+		component.DisplayName = strings.TrimSpace(component.DisplayName)
+		component.Group = strings.TrimSpace(component.Group)
+
+		if component.ContainerID <= 0 {
+			return fmt.Errorf("component %d: container_id is required", i+1)
+		}
+		if seen[component.ContainerID] {
+			return fmt.Errorf("component %d: container %d is listed twice", i+1, component.ContainerID)
+		}
+		seen[component.ContainerID] = true
+
+		if component.DisplayName == "" {
+			return fmt.Errorf("component %d: display_name is required", i+1)
+		}
+		if len(component.DisplayName) > maxStatusPageNameLength || len(component.Group) > maxStatusPageNameLength {
+			return fmt.Errorf("component %d: display_name and group must be at most %d characters", i+1, maxStatusPageNameLength)
+		}
+	}
+
+	return p.SignalStates.Validate()
+}
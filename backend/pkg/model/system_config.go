@@ -45,7 +45,11 @@ const (
 	// Application settings
 	ConfigKeyAppVersion           = "app.version"
 	ConfigKeyAppInitialized       = "app.initialized"
+	ConfigKeyAppSetupLock         = "app.setup_lock"
 	ConfigKeyAppMaintenanceMode   = "app.maintenance_mode"
+	ConfigKeyAppSchemaVersion     = "app.schema_version"
+	// Hot-reloadable settings changed through the API, winning over the environment
+	ConfigKeyAppSettingOverrides  = "app.setting_overrides"
 
 	// Image check settings
 	ConfigKeyImageCheckInterval      = "image_check.interval"
@@ -70,17 +74,23 @@ const (
 	ConfigKeyCleanupHistoryRetentionCount = "cleanup.history_retention_count"
 	ConfigKeyCleanupImageCacheRetentionDays = "cleanup.image_cache_retention_days"
 
+	// Change feed settings
+	ConfigKeyChangesWebhookURL = "changes.webhook_url"
+
 	// Security settings
 	ConfigKeySecurityJWTSecret          = "security.jwt_secret"
 	ConfigKeySecurityJWTExpirationTime  = "security.jwt_expiration_time"
 	ConfigKeySecurityPasswordMinLength  = "security.password_min_length"
 	ConfigKeySecuritySessionTimeout     = "security.session_timeout"
+	// Rate limit overrides set through the API, replacing the compiled-in endpoint limits and access lists
+	ConfigKeySecurityRateLimits         = "security.rate_limits"
 
 	// Docker settings
 	ConfigKeyDockerHost           = "docker.host"
 	ConfigKeyDockerAPIVersion     = "docker.api_version"
 	ConfigKeyDockerTLSVerify      = "docker.tls_verify"
 	ConfigKeyDockerCertPath       = "docker.cert_path"
+	ConfigKeyDockerIgnoredWarnings = "docker.ignored_warnings"
 )
 
 // TableName returns the table name for SystemConfig model
@@ -196,7 +206,7 @@ func GetDefaultConfigs() []SystemConfig {
 		},
 		{
 			ConfigKey:   ConfigKeyAppInitialized,
-			ConfigValue: `true`,
+			ConfigValue: `false`,
 			Description: "Application initialization status",
 			IsSystem:    true,
 		},
@@ -266,6 +276,18 @@ func GetDefaultConfigs() []SystemConfig {
 			Description: "Session timeout in seconds",
 			IsSystem:    false,
 		},
+		{
+			ConfigKey:   ConfigKeyDockerIgnoredWarnings,
+			ConfigValue: `[]`,
+			Description: "Docker daemon warnings to suppress, matched as case-insensitive substrings",
+			IsSystem:    false,
+		},
+		{
+			ConfigKey:   ConfigKeyChangesWebhookURL,
+			ConfigValue: `""`,
+			Description: "URL the container change feed is pushed to; empty disables delivery",
+			IsSystem:    false,
+		},
 	}
 }
 
@@ -0,0 +1,15 @@
+package model
+
+// SystemSnapshot is the configuration a system export moves between
+// servers: containers, scheduled tasks, registry credentials and global
+// notification channels
+type SystemSnapshot struct {
+	Containers           []*Container
+	ScheduledTasks       []*ScheduledTask
+	RegistryCredentials  []*RegistryCredentials
+	NotificationChannels []*NotificationChannel
+
+	// TaskTargets holds, by task name, the names of the containers a
+	// scheduled task targets; container IDs differ between servers
+	TaskTargets map[string][]string
+}
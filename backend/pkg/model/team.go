@@ -0,0 +1,171 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Team groups the users and containers that share a resource quota on the
+// hosts
+type Team struct {
+	ID          int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	Name        string    `json:"name" gorm:"uniqueIndex;not null;size:100"`
+	Description string    `json:"description,omitempty" gorm:"type:text"`
+	CreatedBy   *int      `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+
+	// Relationships
+	Members []TeamMember `json:"members,omitempty" gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE"`
+	Quota   *TeamQuota   `json:"quota,omitempty" gorm:"foreignKey:TeamID;constraint:OnDelete:CASCADE"`
+}
+
+// TableName returns the table name for Team model
+func (Team) TableName() string {
+	return "teams"
+}
+
+// Validate validates the team's name
+func (t *Team) Validate() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(t.Name) > 100 {
+		return fmt.Errorf("name must be at most 100 characters")
+	}
+	return nil
+}
+
+// TeamMember is a user's membership of a team. Team admins are notified when
+// the team nears its quota.
+type TeamMember struct {
+	ID        int       `json:"id" gorm:"primaryKey;autoIncrement"`
+	TeamID    int       `json:"team_id" gorm:"not null;uniqueIndex:idx_team_members_team_user"`
+	UserID    int64     `json:"user_id" gorm:"not null;uniqueIndex:idx_team_members_team_user;index"`
+	IsAdmin   bool      `json:"is_admin" gorm:"not null;default:false"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName returns the table name for TeamMember model
+func (TeamMember) TableName() string {
+	return "team_members"
+}
+
+// TeamQuota limits what a team's containers may reserve. A nil limit is
+// unlimited.
+type TeamQuota struct {
+	TeamID        int    `json:"team_id" gorm:"primaryKey"`
+	MaxContainers *int   `json:"max_containers"`
+	MaxMemory     *int64 `json:"max_memory_bytes"`
+	MaxNanoCPUs   *int64 `json:"max_nano_cpus"`
+	// MaxUnlimited caps the containers that set no memory or no CPU limit
+	MaxUnlimited *int      `json:"max_unlimited_containers"`
+	UpdatedBy    *int      `json:"updated_by,omitempty"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for TeamQuota model
+func (TeamQuota) TableName() string {
+	return "team_quotas"
+}
+
+// Validate checks that no limit is negative
+func (q *TeamQuota) Validate() error {
+	if q.MaxContainers != nil && *q.MaxContainers < 0 {
+		return fmt.Errorf("max_containers must not be negative")
+	}
+	if q.MaxMemory != nil && *q.MaxMemory < 0 {
+		return fmt.Errorf("max_memory_bytes must not be negative")
+	}
+	if q.MaxNanoCPUs != nil && *q.MaxNanoCPUs < 0 {
+		return fmt.Errorf("max_nano_cpus must not be negative")
+	}
+	if q.MaxUnlimited != nil && *q.MaxUnlimited < 0 {
+		return fmt.Errorf("max_unlimited_containers must not be negative")
+	}
+	return nil
+}
+
+// Quota dimensions
+const (
+	QuotaContainers = "containers"
+	QuotaMemory     = "memory"
+	QuotaNanoCPUs   = "nano_cpus"
+	QuotaUnlimited  = "unlimited_containers"
+)
+
+// ResourceReservation is what a container counts against its team's quota.
+// Containers without a memory or CPU limit reserve the configured default for
+// it and are Unlimited.
+type ResourceReservation struct {
+	Memory    int64 `json:"memory_bytes"`
+	NanoCPUs  int64 `json:"nano_cpus"`
+	Unlimited bool  `json:"unlimited"`
+}
+
+// QuotaUsage is the sum of a team's reservations
+type QuotaUsage struct {
+	Containers int   `json:"containers"`
+	Memory     int64 `json:"memory_bytes"`
+	NanoCPUs   int64 `json:"nano_cpus"`
+	Unlimited  int   `json:"unlimited_containers"`
+}
+
+// Add adds a container's reservation to the usage
+func (u *QuotaUsage) Add(r ResourceReservation) {
+	u.Containers++
+	u.Memory += r.Memory
+	u.NanoCPUs += r.NanoCPUs
+	if r.Unlimited {
+		u.Unlimited++
+	}
+}
+
+// QuotaDimension is the usage of one quota dimension against its limit. Limit
+// and Percent are nil when the dimension is unlimited.
+type QuotaDimension struct {
+	Name    string   `json:"name"`
+	Used    int64    `json:"used"`
+	Limit   *int64   `json:"limit"`
+	Percent *float64 `json:"percent"`
+}
+
+// Exceeded reports whether usage is over the limit
+func (d QuotaDimension) Exceeded() bool {
+	return d.Limit != nil && d.Used > *d.Limit
+}
+
+// Dimensions compares usage with the quota's limits
+func (q *TeamQuota) Dimensions(usage QuotaUsage) []QuotaDimension {
+	var maxContainers, maxUnlimited *int64
+	if q != nil && q.MaxContainers != nil {
+		v := int64(*q.MaxContainers)
+		maxContainers = &v
+	}
+	if q != nil && q.MaxUnlimited != nil {
+		v := int64(*q.MaxUnlimited)
+		maxUnlimited = &v
+	}
+	var maxMemory, maxNanoCPUs *int64
+	if q != nil {
+		maxMemory, maxNanoCPUs = q.MaxMemory, q.MaxNanoCPUs
+	}
+
+	return []QuotaDimension{
+		newQuotaDimension(QuotaContainers, int64(usage.Containers), maxContainers),
+		newQuotaDimension(QuotaMemory, usage.Memory, maxMemory),
+		newQuotaDimension(QuotaNanoCPUs, usage.NanoCPUs, maxNanoCPUs),
+		newQuotaDimension(QuotaUnlimited, int64(usage.Unlimited), maxUnlimited),
+	}
+}
+
+func newQuotaDimension(name string, used int64, limit *int64) QuotaDimension {
+	d := QuotaDimension{Name: name, Used: used, Limit: limit}
+	if limit != nil && *limit > 0 {
+		percent := float64(used) / float64(*limit) * 100
+		d.Percent = &percent
+	}
+	return d
+}
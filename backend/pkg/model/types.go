@@ -54,4 +54,34 @@ func (j JSONMap) Set(key string, value interface{}) {
 // Get retrieves a value from the JSON map
 func (j JSONMap) Get(key string) interface{} {
 	return j[key]
-}
\ No newline at end of file
+}
+// StringList represents a JSON array of strings stored in the database
+type StringList []string
+
+// Value implements the driver.Valuer interface for database storage
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		return "[]", nil
+	}
+	return json.Marshal(l)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (l *StringList) Scan(value interface{}) error {
+	if value == nil {
+		*l = nil
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into StringList", value)
+	}
+
+	return json.Unmarshal(bytes, l)
+}
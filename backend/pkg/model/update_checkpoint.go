@@ -0,0 +1,140 @@
+package model
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Update steps recorded in an update checkpoint, in execution order
+const (
+	UpdateStepResolve   = "resolve_image"
+	UpdateStepPull      = "pull_image"
+	UpdateStepStopOld   = "stop_old"
+	UpdateStepCreateNew = "create_new"
+	UpdateStepStartNew  = "start_new"
+	UpdateStepPostStart = "post_start"
+	UpdateStepFinalize  = "finalize"
+)
+
+// UpdateStepStatus is the state of a single checkpointed update step
+type UpdateStepStatus string
+
+const (
+	UpdateStepPending   UpdateStepStatus = "pending"
+	UpdateStepRunning   UpdateStepStatus = "running"
+	UpdateStepCompleted UpdateStepStatus = "completed"
+	UpdateStepFailed    UpdateStepStatus = "failed"
+)
+
+// UpdateCheckpoint is the persisted progress of an update. It is saved after
+// every step, so an update interrupted by a restart resumes after the last
+// completed step instead of repeating it.
+type UpdateCheckpoint struct {
+	TargetImage    string                 `json:"target_image,omitempty"`
+	ResolvedDigest string                 `json:"resolved_digest,omitempty"`
+	OldContainerID string                 `json:"old_container_id,omitempty"`
+	NewContainerID string                 `json:"new_container_id,omitempty"`
+	Resumes        int                    `json:"resumes"`
+	Steps          []UpdateCheckpointStep `json:"steps"`
+}
+
+// UpdateCheckpointStep is one entry of the update step checklist
+type UpdateCheckpointStep struct {
+	Name        string           `json:"name"`
+	Status      UpdateStepStatus `json:"status"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// NewUpdateCheckpoint returns a checkpoint with the given steps pending
+func NewUpdateCheckpoint(steps ...string) *UpdateCheckpoint {
+	checkpoint := &UpdateCheckpoint{Steps: make([]UpdateCheckpointStep, 0, len(steps))}
+	for _, name := range steps {
+		checkpoint.Steps = append(checkpoint.Steps, UpdateCheckpointStep{Name: name, Status: UpdateStepPending})
+	}
+	return checkpoint
+}
+
+// IsCompleted reports whether the step has completed
+func (c *UpdateCheckpoint) IsCompleted(name string) bool {
+	step := c.step(name)
+	return step != nil && step.Status == UpdateStepCompleted
+}
+
+// IsStarted reports whether the step has begun, whatever its outcome
+func (c *UpdateCheckpoint) IsStarted(name string) bool {
+	step := c.step(name)
+	return step != nil && step.Status != UpdateStepPending
+}
+
+// Begin marks the step as running
+func (c *UpdateCheckpoint) Begin(name string) {
+	if step := c.step(name); step != nil {
+		now := time.Now()
+		step.Status = UpdateStepRunning
+		step.StartedAt = &now
+		step.CompletedAt = nil
+		step.Error = ""
+	}
+}
+
+// Complete marks the step as completed
+func (c *UpdateCheckpoint) Complete(name string) {
+	if step := c.step(name); step != nil {
+		now := time.Now()
+		step.Status = UpdateStepCompleted
+		step.CompletedAt = &now
+	}
+}
+
+// Fail marks the step as failed with the error
+func (c *UpdateCheckpoint) Fail(name string, err error) {
+	if step := c.step(name); step != nil {
+		now := time.Now()
+		step.Status = UpdateStepFailed
+		step.CompletedAt = &now
+		if err != nil {
+			step.Error = err.Error()
+		}
+	}
+}
+
+func (c *UpdateCheckpoint) step(name string) *UpdateCheckpointStep {
+	for i := range c.Steps {
+		if c.Steps[i].Name == name {
+			return &c.Steps[i]
+		}
+	}
+	return nil
+}
+
+// Value implements the driver.Valuer interface for database storage
+func (c *UpdateCheckpoint) Value() (driver.Value, error) {
+	if c == nil {
+		return nil, nil
+	}
+	return json.Marshal(c)
+}
+
+// Scan implements the sql.Scanner interface for database retrieval
+func (c *UpdateCheckpoint) Scan(value interface{}) error {
+	if value == nil {
+		*c = UpdateCheckpoint{}
+		return nil
+	}
+
+	var bytes []byte
+	switch v := value.(type) {
+	case []byte:
+		bytes = v
+	case string:
+		bytes = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan %T into UpdateCheckpoint", value)
+	}
+
+	return json.Unmarshal(bytes, c)
+}
@@ -22,14 +22,28 @@ type UpdateHistory struct {
 	BackupCreated   bool          `json:"backup_created" gorm:"not null;default:false"`
 	RollbackAvailable bool        `json:"rollback_available" gorm:"not null;default:false"`
 	Logs            string        `json:"logs,omitempty" gorm:"type:text"`
+	Warnings        StringList    `json:"warnings,omitempty" gorm:"type:jsonb;default:'[]'"`
+	Checkpoint      *UpdateCheckpoint `json:"checkpoint,omitempty" gorm:"type:jsonb"`
 	Metadata        string        `json:"metadata,omitempty" gorm:"type:jsonb;default:'{}'"`
 	StartedAt       time.Time     `json:"started_at" gorm:"index:idx_update_history_started_at,sort:desc"`
 	CompletedAt     *time.Time    `json:"completed_at,omitempty"`
 	CreatedBy       *int          `json:"created_by,omitempty"`
+	ActorType       ActorType     `json:"actor_type" gorm:"not null;size:20;default:'system'"`
+	ActorName       string        `json:"actor_name,omitempty" gorm:"size:100"`
+
+	// Approver is the approval policy that approved or rejected a pending
+	// update, as "policy:name@vN"
+	Approver string `json:"approver,omitempty" gorm:"size:150"`
+
+	// OperationID is the bulk operation that created the update, if any
+	OperationID *int `json:"operation_id,omitempty" gorm:"index:idx_update_history_operation_id"`
 
 	// Relationships
 	Container     Container `json:"-" gorm:"foreignKey:ContainerID"`
 	CreatedByUser *User     `json:"created_by_user,omitempty" gorm:"foreignKey:CreatedBy"`
+
+	// Notes are loaded for the update detail only
+	Notes []*UpdateNote `json:"notes,omitempty" gorm:"foreignKey:UpdateID"`
 }
 
 // UpdateStatus defines update status
@@ -53,16 +67,22 @@ const (
 	TriggerTypeManual   TriggerType = "manual"
 	TriggerTypeSchedule TriggerType = "schedule"
 	TriggerTypeWebhook  TriggerType = "webhook"
+	TriggerTypeRetarget TriggerType = "retarget"
+	TriggerTypeConverge TriggerType = "converge"
+	TriggerTypeRedeploy TriggerType = "redeploy"
 )
 
 // UpdateStrategy defines update strategies
 type UpdateStrategy string
 
 const (
-	UpdateStrategyRecreate  UpdateStrategy = "recreate"
-	UpdateStrategyRolling   UpdateStrategy = "rolling"
-	UpdateStrategyBlueGreen UpdateStrategy = "blue_green"
-	UpdateStrategyCanary    UpdateStrategy = "canary"
+	UpdateStrategyRecreate    UpdateStrategy = "recreate"
+	UpdateStrategyRolling     UpdateStrategy = "rolling"
+	UpdateStrategyBlueGreen   UpdateStrategy = "blue_green"
+	UpdateStrategyCanary      UpdateStrategy = "canary"
+	// UpdateStrategyHealthGated starts the new container next to the old one
+	// and only replaces the old one once the new one is healthy
+	UpdateStrategyHealthGated UpdateStrategy = "health_gated"
 )
 
 // UpdateHistoryFilter represents filters for querying update history
@@ -76,9 +96,16 @@ type UpdateHistoryFilter struct {
 	StartedBefore *time.Time `json:"started_before,omitempty"`
 	CompletedAfter *time.Time `json:"completed_after,omitempty"`
 	CompletedBefore *time.Time `json:"completed_before,omitempty"`
+	// Image matches updates whose old or new image contains it
+	Image       string       `json:"image,omitempty"`
+	// ContainerOwner restricts the updates to containers the user created
+	ContainerOwner *int      `json:"container_owner,omitempty"`
 	Limit       int          `json:"limit,omitempty"`
 	Offset      int          `json:"offset,omitempty"`
 	OrderBy     string       `json:"order_by,omitempty"`
+
+	// Cursor switches to keyset pagination on (started_at, id); Offset and OrderBy are ignored
+	Cursor *Cursor `json:"-"`
 }
 
 // TableName returns the table name for UpdateHistory model
@@ -99,11 +126,47 @@ type UpdateStats struct {
 	AverageUpdateDuration int `json:"average_update_duration"`
 }
 
+// UpdateHistorySummary aggregates the updates matching a filter
+type UpdateHistorySummary struct {
+	Total    int64                  `json:"total"`
+	ByStatus map[UpdateStatus]int64 `json:"by_status"`
+	// Interrupted counts updates recorded as finished without a completion
+	// time; they are also counted under their status
+	Interrupted int64 `json:"interrupted"`
+	// AverageDurationSeconds averages the updates with a completion time
+	AverageDurationSeconds float64 `json:"average_duration_seconds"`
+}
+
+// PullProgress is the overall progress of an image pull, aggregated over its
+// layers. TotalBytes only counts layers whose size the registry reported so
+// far, so Percent can move back as layers start downloading.
+type PullProgress struct {
+	Image        string  `json:"image"`
+	Percent      float64 `json:"percent"`
+	CurrentBytes int64   `json:"current_bytes"`
+	TotalBytes   int64   `json:"total_bytes"`
+	Layers       int     `json:"layers"`
+	LayersDone   int     `json:"layers_done"` // downloaded or already present
+}
+
+// SetActor attributes the update to the actor
+func (uh *UpdateHistory) SetActor(actor Actor) {
+	uh.CreatedBy = actor.OwnerID()
+	uh.ActorType = actor.Type
+	uh.ActorName = actor.Name
+}
+
 // IsCompleted checks if update is completed (success or failed)
 func (uh *UpdateHistory) IsCompleted() bool {
 	return uh.Status == UpdateStatusSuccess || uh.Status == UpdateStatusFailed || uh.Status == UpdateStatusCancelled
 }
 
+// IsResumable reports whether the update was interrupted with checkpointed
+// progress it can resume from
+func (uh *UpdateHistory) IsResumable() bool {
+	return uh.Status == UpdateStatusRunning && uh.Checkpoint != nil
+}
+
 // IsSuccessful checks if update was successful
 func (uh *UpdateHistory) IsSuccessful() bool {
 	return uh.Status == UpdateStatusSuccess
@@ -120,6 +183,28 @@ func (uh *UpdateHistory) GetDuration() time.Duration {
 	return time.Since(uh.StartedAt)
 }
 
+// IsInterrupted reports whether the update is recorded as finished but has
+// no completion time, as older rows may be, so how long it took is unknown
+func (uh *UpdateHistory) IsInterrupted() bool {
+	if uh.CompletedAt != nil {
+		return false
+	}
+	switch uh.Status {
+	case UpdateStatusPending, UpdateStatusRunning:
+		return false
+	}
+	return true
+}
+
+// Elapsed returns how long a finished update took from its start and
+// completion times, and false while it runs or when it was interrupted
+func (uh *UpdateHistory) Elapsed() (time.Duration, bool) {
+	if uh.CompletedAt == nil || uh.CompletedAt.Before(uh.StartedAt) {
+		return 0, false
+	}
+	return uh.CompletedAt.Sub(uh.StartedAt), true
+}
+
 // GetValidUpdateStatuses returns all valid update statuses
 func GetValidUpdateStatuses() []UpdateStatus {
 	return []UpdateStatus{
@@ -139,6 +224,9 @@ func GetValidTriggerTypes() []TriggerType {
 		TriggerTypeManual,
 		TriggerTypeSchedule,
 		TriggerTypeWebhook,
+		TriggerTypeRetarget,
+		TriggerTypeConverge,
+		TriggerTypeRedeploy,
 	}
 }
 
@@ -149,6 +237,7 @@ func GetValidUpdateStrategies() []UpdateStrategy {
 		UpdateStrategyRolling,
 		UpdateStrategyBlueGreen,
 		UpdateStrategyCanary,
+		UpdateStrategyHealthGated,
 	}
 }
 
@@ -160,6 +249,13 @@ func (uh *UpdateHistory) BeforeCreate(tx *gorm.DB) error {
 	if uh.Strategy == "" {
 		uh.Strategy = UpdateStrategyRecreate
 	}
+	if uh.ActorType == "" {
+		if uh.CreatedBy != nil {
+			uh.ActorType = ActorTypeUser
+		} else {
+			uh.ActorType = ActorTypeSystem
+		}
+	}
 	if uh.Status == "" {
 		uh.Status = UpdateStatusPending
 	}
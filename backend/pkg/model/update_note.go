@@ -0,0 +1,59 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxUpdateNoteLength is the maximum length of a note in characters
+const MaxUpdateNoteLength = 2000
+
+// UpdateNote is context an operator attached to an update, such as who it
+// was coordinated with or what was done by hand
+type UpdateNote struct {
+	ID       int    `json:"id" gorm:"primaryKey;autoIncrement"`
+	UpdateID int    `json:"update_id" gorm:"not null;index:idx_update_notes_update_id"`
+	Body     string `json:"body" gorm:"type:text;not null"`
+	// Markdown tells clients to render the body as markdown rather than
+	// plain text. Raw HTML is never rendered either way.
+	Markdown   bool      `json:"markdown" gorm:"not null;default:false"`
+	AuthorID   *int      `json:"author_id,omitempty"`
+	AuthorName string    `json:"author_name" gorm:"size:100"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName returns the table name for UpdateNote model
+func (UpdateNote) TableName() string {
+	return "update_notes"
+}
+
+// SanitizeUpdateNote normalizes a note body: line endings are unified,
+// control characters other than newlines and tabs are removed and "<" is
+// escaped so the body cannot carry HTML. Empty and overlong notes are
+// rejected.
+func SanitizeUpdateNote(body string) (string, error) {
+	body = strings.ReplaceAll(body, "\r\n", "\n")
+	body = strings.Map(func(r rune) rune {
+		if r == '\n' || r == '\t' {
+			return r
+		}
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, body)
+	body = strings.TrimSpace(body)
+
+	if body == "" {
+		return "", fmt.Errorf("note cannot be empty")
+	}
+	if utf8.RuneCountInString(body) > MaxUpdateNoteLength {
+		return "", fmt.Errorf("note must be at most %d characters", MaxUpdateNoteLength)
+	}
+
+	return strings.ReplaceAll(body, "<", "&lt;"), nil
+}
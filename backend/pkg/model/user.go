@@ -12,9 +12,11 @@ type User struct {
 	Username           string         `json:"username" gorm:"uniqueIndex;not null;size:50;index:idx_users_username"`
 	Email              string         `json:"email" gorm:"uniqueIndex;not null;size:100;index:idx_users_email"`
 	PasswordHash       string         `json:"-" gorm:"column:password_hash;not null;size:255"` // Exclude from JSON
-	Role               UserRole       `json:"role" gorm:"not null;default:'user';index:idx_users_role"`
+	Role               UserRole       `json:"role" gorm:"not null;default:'viewer';index:idx_users_role"`
 	IsActive           bool           `json:"is_active" gorm:"not null;default:true;index:idx_users_is_active"`
 	EmailNotifications bool           `json:"email_notifications" gorm:"not null;default:true"`
+	NotificationDigest DigestInterval `json:"notification_digest" gorm:"size:10;not null;default:'off'"`
+	DigestSentAt       *time.Time     `json:"digest_sent_at,omitempty"`
 	AvatarURL          string         `json:"avatar_url,omitempty" gorm:"size:255"`
 	LastLoginAt        *time.Time     `json:"last_login_at,omitempty"`
 	CreatedAt          time.Time      `json:"created_at"`
@@ -40,6 +42,10 @@ type UserSession struct {
 	UserAgent    string    `json:"user_agent,omitempty" gorm:"type:text"`
 	CreatedAt    time.Time `json:"created_at"`
 
+	// LastSeenAt is when an access token of the session was last used,
+	// recorded at most once a minute
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+
 	// Relationships
 	User User `json:"-" gorm:"foreignKey:UserID"`
 }
@@ -47,16 +53,18 @@ type UserSession struct {
 // ActivityLog represents system activity logs
 type ActivityLog struct {
 	ID           int       `json:"id" gorm:"primaryKey;autoIncrement"`
-	UserID       *int64    `json:"user_id,omitempty" gorm:"index:idx_activity_logs_user_id"`
+	UserID       *int64    `json:"user_id,omitempty" gorm:"index:idx_activity_logs_user_id;index:idx_activity_logs_user_created,priority:1"`
+	ActorType    ActorType `json:"actor_type" gorm:"not null;size:20;default:'user';index:idx_activity_logs_actor_type"`
+	ActorName    string    `json:"actor_name,omitempty" gorm:"size:100"`
 	Action       string    `json:"action" gorm:"not null;size:100;index:idx_activity_logs_action"`
-	ResourceType string    `json:"resource_type" gorm:"not null;size:50;index:idx_activity_logs_resource_type"`
-	ResourceID   *int      `json:"resource_id,omitempty"`
+	ResourceType string    `json:"resource_type" gorm:"not null;size:50;index:idx_activity_logs_resource_type;index:idx_activity_logs_resource,priority:1"`
+	ResourceID   *int      `json:"resource_id,omitempty" gorm:"index:idx_activity_logs_resource,priority:2"`
 	ResourceName string    `json:"resource_name,omitempty" gorm:"size:255"`
 	Description  string    `json:"description,omitempty" gorm:"type:text"`
 	IPAddress    string    `json:"ip_address,omitempty" gorm:"type:inet"`
 	UserAgent    string    `json:"user_agent,omitempty" gorm:"type:text"`
 	Metadata     string    `json:"metadata,omitempty" gorm:"type:jsonb;default:'{}'"`
-	CreatedAt    time.Time `json:"created_at" gorm:"index:idx_activity_logs_created_at,sort:desc"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index:idx_activity_logs_created_at,sort:desc;index:idx_activity_logs_user_created,priority:2,sort:desc"`
 
 	// Relationships
 	User *User `json:"-" gorm:"foreignKey:UserID"`
@@ -84,13 +92,18 @@ type UserFilter struct {
 
 // ActivityLogFilter represents filters for querying activity logs
 type ActivityLogFilter struct {
-	UserID       *int64 `json:"user_id,omitempty"`
-	Action       string `json:"action,omitempty"`
-	ResourceType string `json:"resource_type,omitempty"`
-	ResourceID   *int   `json:"resource_id,omitempty"`
-	Limit        int    `json:"limit,omitempty"`
-	Offset       int    `json:"offset,omitempty"`
-	OrderBy      string `json:"order_by,omitempty"`
+	UserID       *int64     `json:"user_id,omitempty"`
+	Action       string     `json:"action,omitempty"`
+	ResourceType string     `json:"resource_type,omitempty"`
+	ResourceID   *int       `json:"resource_id,omitempty"`
+	Since        *time.Time `json:"since,omitempty"` // Inclusive
+	Until        *time.Time `json:"until,omitempty"` // Exclusive
+	Limit        int        `json:"limit,omitempty"`
+	Offset       int        `json:"offset,omitempty"`
+	OrderBy      string     `json:"order_by,omitempty"`
+
+	// Cursor switches to keyset pagination on (created_at, id); Offset and OrderBy are ignored
+	Cursor *Cursor `json:"-"`
 }
 
 // TableName returns the table name for User model
@@ -108,6 +121,13 @@ func (ActivityLog) TableName() string {
 	return "activity_logs"
 }
 
+// SetActor attributes the activity to the actor
+func (al *ActivityLog) SetActor(actor Actor) {
+	al.UserID = actor.UserID
+	al.ActorType = actor.Type
+	al.ActorName = actor.Name
+}
+
 // IsAdmin checks if user has admin role
 func (u *User) IsAdmin() bool {
 	return u.Role == UserRoleAdmin
@@ -118,6 +138,14 @@ func (u *User) IsUserActive() bool {
 	return u.IsActive
 }
 
+// CanExecInContainers reports whether the role may run commands inside
+// containers, e.g. through exec health actions. Only built-in roles are
+// known here; custom roles never qualify.
+func (r UserRole) CanExecInContainers() bool {
+	permissions, _ := BuiltinRolePermissions(CanonicalRole(r))
+	return GrantsPermission(permissions, PermissionContainerExec)
+}
+
 // GetValidRoles returns all valid user roles
 func GetValidRoles() []UserRole {
 	return []UserRole{UserRoleAdmin, UserRoleOperator, UserRoleViewer}
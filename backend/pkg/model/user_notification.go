@@ -1,6 +1,7 @@
 package model
 
 import (
+	"encoding/json"
 	"time"
 
 	"gorm.io/gorm"
@@ -19,6 +20,23 @@ type UserNotification struct {
 	CreatedAt time.Time              `json:"created_at" gorm:"index:idx_notifications_created_at,sort:desc"`
 	UpdatedAt time.Time              `json:"updated_at"`
 
+	// ContainerID is the container the notification is about, taken from
+	// Data["container_id"]; it keys acknowledgment snoozes together with Type
+	ContainerID *int64 `json:"container_id,omitempty" gorm:"index:idx_notifications_container_id"`
+
+	// Acknowledgment is separate from read state: it records who handled the
+	// event and snoozes identical notifications until SnoozedUntil
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty" gorm:"index:idx_notifications_acknowledged_at"`
+	AcknowledgedBy *int64     `json:"acknowledged_by,omitempty"`
+	AckNote        string     `json:"ack_note,omitempty" gorm:"size:500"`
+	SnoozedUntil   *time.Time `json:"snoozed_until,omitempty"`
+
+	// PriorAckID is the acknowledged notification this one recurred after
+	PriorAckID *int64 `json:"prior_ack_id,omitempty"`
+
+	// Rendered is the schema-aware view of Data, filled in when listing
+	Rendered *PayloadRendering `json:"rendered,omitempty" gorm:"-"`
+
 	// Relationships
 	User *User `json:"user,omitempty" gorm:"foreignKey:UserID"`
 }
@@ -51,9 +69,14 @@ type UserNotificationFilter struct {
 	IsRead    *bool  `json:"is_read,omitempty"`
 	CreatedAfter  *time.Time `json:"created_after,omitempty"`
 	CreatedBefore *time.Time `json:"created_before,omitempty"`
+	Acknowledged  *bool      `json:"acknowledged,omitempty"`
+	ContainerIDs  []int64    `json:"container_ids,omitempty"`
 	Limit     int    `json:"limit,omitempty"`
 	Offset    int    `json:"offset,omitempty"`
 	OrderBy   string `json:"order_by,omitempty"`
+
+	// Cursor switches to keyset pagination on (created_at, id); Offset and OrderBy are ignored
+	Cursor *Cursor `json:"-"`
 }
 
 // TableName returns the table name for Notification model
@@ -84,6 +107,52 @@ func (n *UserNotification) MarkAsUnread() {
 	n.ReadAt = nil
 }
 
+// IsAcknowledged checks if the notification has been acknowledged
+func (n *UserNotification) IsAcknowledged() bool {
+	return n.AcknowledgedAt != nil
+}
+
+// Acknowledge marks the notification as handled by the user and snoozes
+// identical notifications until snoozedUntil
+func (n *UserNotification) Acknowledge(userID int64, note string, snoozedUntil time.Time) {
+	now := time.Now()
+	n.AcknowledgedAt = &now
+	n.AcknowledgedBy = &userID
+	n.AckNote = note
+	n.SnoozedUntil = &snoozedUntil
+}
+
+// IsSnoozed checks if the acknowledgment still suppresses identical notifications
+func (n *UserNotification) IsSnoozed(at time.Time) bool {
+	return n.SnoozedUntil != nil && at.Before(*n.SnoozedUntil)
+}
+
+// NotificationContainerID returns the container ID carried in notification
+// data, or nil when there is none
+func NotificationContainerID(data map[string]interface{}) *int64 {
+	var id int64
+	switch v := data["container_id"].(type) {
+	case int:
+		id = int64(v)
+	case int64:
+		id = v
+	case float64:
+		id = int64(v)
+	case json.Number:
+		parsed, err := v.Int64()
+		if err != nil {
+			return nil
+		}
+		id = parsed
+	default:
+		return nil
+	}
+	if id <= 0 {
+		return nil
+	}
+	return &id
+}
+
 // IsBroadcast checks if the notification is a broadcast (no specific user)
 func (n *UserNotification) IsBroadcast() bool {
 	return n.UserID == nil
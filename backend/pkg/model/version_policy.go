@@ -0,0 +1,195 @@
+package model
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VersionPolicy limits the tags an update may move a container to. The
+// update policy decides when a container is updated; the version policy
+// decides to what.
+type VersionPolicy string
+
+const (
+	// VersionPolicyLatest follows whatever the registry reports as newest
+	VersionPolicyLatest VersionPolicy = "latest"
+	// VersionPolicyDigestOnly keeps the tag and follows new digests behind it
+	VersionPolicyDigestOnly VersionPolicy = "digest-only"
+	// VersionPolicyPatch, VersionPolicyMinor and VersionPolicyMajor allow newer
+	// semver tags up to the given delta; non-semver tags follow digests only
+	VersionPolicyPatch VersionPolicy = "patch"
+	VersionPolicyMinor VersionPolicy = "minor"
+	VersionPolicyMajor VersionPolicy = "major"
+	// VersionPolicyPinned never updates the container
+	VersionPolicyPinned VersionPolicy = "pinned"
+)
+
+// GetValidVersionPolicies returns all valid version policies
+func GetValidVersionPolicies() []VersionPolicy {
+	return []VersionPolicy{
+		VersionPolicyLatest,
+		VersionPolicyDigestOnly,
+		VersionPolicyPatch,
+		VersionPolicyMinor,
+		VersionPolicyMajor,
+		VersionPolicyPinned,
+	}
+}
+
+// IsValidVersionPolicy reports whether policy is a known version policy
+func IsValidVersionPolicy(policy string) bool {
+	for _, valid := range GetValidVersionPolicies() {
+		if VersionPolicy(policy) == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// GetVersionPolicy returns the container's version policy, latest when unset
+func (c *Container) GetVersionPolicy() VersionPolicy {
+	if c.VersionPolicy == "" {
+		return VersionPolicyLatest
+	}
+	return c.VersionPolicy
+}
+
+// FollowsVersions reports whether the policy moves semver tags to newer
+// versions, as opposed to following a single tag or the registry's latest
+func (p VersionPolicy) FollowsVersions() bool {
+	return p == VersionPolicyPatch || p == VersionPolicyMinor || p == VersionPolicyMajor
+}
+
+// AllowsTag reports whether the policy lets a container on tag current move
+// to candidate. Staying on the same tag, i.e. a digest update, is allowed by
+// every policy but pinned. For patch, minor and major both tags must be
+// semver of the same precision and variant, e.g. nginx:1.25.2 with policy
+// patch may move to 1.25.3 but not to 1.26.0, 1.25 or 1.25.3-alpine.
+func (p VersionPolicy) AllowsTag(current, candidate string) bool {
+	switch p {
+	case VersionPolicyPinned:
+		return false
+	case "", VersionPolicyLatest:
+		return true
+	}
+	if candidate == current {
+		return true
+	}
+	if !p.FollowsVersions() {
+		return false
+	}
+
+	from, ok := ParseSemVer(current)
+	if !ok {
+		return false
+	}
+	to, ok := ParseSemVer(candidate)
+	if !ok || to.Parts != from.Parts || to.Suffix != from.Suffix || to.Compare(from) <= 0 {
+		return false
+	}
+
+	switch p {
+	case VersionPolicyPatch:
+		return to.Major == from.Major && to.Minor == from.Minor
+	case VersionPolicyMinor:
+		return to.Major == from.Major
+	}
+	return true
+}
+
+// NewestAllowedTag returns the newest of tags the policy lets current move
+// to, or current when none is newer
+func (p VersionPolicy) NewestAllowedTag(current string, tags []string) string {
+	newest := current
+	var newestVersion SemVer
+	for _, tag := range tags {
+		if tag == current || !p.AllowsTag(current, tag) {
+			continue
+		}
+		version, ok := ParseSemVer(tag)
+		if !ok {
+			continue
+		}
+		if newest == current || version.Compare(newestVersion) > 0 {
+			newest, newestVersion = tag, version
+		}
+	}
+	return newest
+}
+
+// SemVer is a tag read as a semantic version. Tags may have a "v" prefix,
+// one to three numeric components and a variant suffix, e.g. "v1.25",
+// "1.25.2" or "1.25.2-alpine".
+type SemVer struct {
+	Major int
+	Minor int
+	Patch int
+	// Parts is the number of numeric components in the tag
+	Parts int
+	// Suffix is everything after the numbers, e.g. "-alpine"
+	Suffix string
+}
+
+// ParseSemVer parses a tag as a semantic version
+func ParseSemVer(tag string) (SemVer, bool) {
+	var version SemVer
+	numbers := strings.TrimPrefix(tag, "v")
+	if i := strings.IndexAny(numbers, "-+"); i >= 0 {
+		numbers, version.Suffix = numbers[:i], numbers[i:]
+	}
+
+	parts := strings.Split(numbers, ".")
+	if len(parts) > 3 {
+		return SemVer{}, false
+	}
+	values := [3]int{}
+	for i, part := range parts {
+		if part == "" || strings.Trim(part, "0123456789") != "" {
+			return SemVer{}, false
+		}
+		value, err := strconv.Atoi(part)
+		if err != nil {
+			return SemVer{}, false
+		}
+		values[i] = value
+	}
+
+	version.Major, version.Minor, version.Patch = values[0], values[1], values[2]
+	version.Parts = len(parts)
+	return version, true
+}
+
+// Compare returns -1, 0 or 1 as v is older than, equal to or newer than
+// other, ignoring suffixes
+func (v SemVer) Compare(other SemVer) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		switch {
+		case pair[0] < pair[1]:
+			return -1
+		case pair[0] > pair[1]:
+			return 1
+		}
+	}
+	return 0
+}
+
+// SemVerUpdateType returns patch, minor or major for the semver delta from
+// current to candidate, or an empty string when either is not semver or
+// candidate is not newer
+func SemVerUpdateType(current, candidate string) string {
+	from, ok := ParseSemVer(current)
+	if !ok {
+		return ""
+	}
+	to, ok := ParseSemVer(candidate)
+	if !ok || to.Compare(from) <= 0 {
+		return ""
+	}
+	switch {
+	case to.Major != from.Major:
+		return "major"
+	case to.Minor != from.Minor:
+		return "minor"
+	}
+	return "patch"
+}
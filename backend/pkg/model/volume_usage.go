@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+)
+
+// VolumeSizeStatus tells how a volume's size was obtained, or why it is unknown
+type VolumeSizeStatus string
+
+const (
+	// VolumeSizeReported sizes come from the volume driver
+	VolumeSizeReported VolumeSizeStatus = "reported"
+	// VolumeSizeMeasured sizes were measured by running du in a helper container
+	VolumeSizeMeasured VolumeSizeStatus = "measured"
+	// VolumeSizeTooLarge volumes were skipped or timed out to avoid long du runs
+	VolumeSizeTooLarge VolumeSizeStatus = "too_large"
+	// VolumeSizeUnavailable volumes could not be measured
+	VolumeSizeUnavailable VolumeSizeStatus = "unavailable"
+)
+
+// VolumeUsageSample records a Docker volume's size at one point in time.
+// SizeBytes is -1 when the size is unknown.
+type VolumeUsageSample struct {
+	ID         int64            `json:"id" gorm:"primaryKey;autoIncrement"`
+	VolumeName string           `json:"volume_name" gorm:"not null;size:255;index:idx_volume_usage_samples_volume,priority:1"`
+	Driver     string           `json:"driver" gorm:"size:100"`
+	SizeBytes  int64            `json:"size_bytes" gorm:"not null;default:-1"`
+	SizeStatus VolumeSizeStatus `json:"size_status" gorm:"not null;size:20"`
+	Error      string           `json:"error,omitempty" gorm:"size:500"`
+	SampledAt  time.Time        `json:"sampled_at" gorm:"not null;index:idx_volume_usage_samples_volume,priority:2,sort:desc;index:idx_volume_usage_samples_sampled_at"`
+}
+
+// TableName returns the table name for VolumeUsageSample model
+func (VolumeUsageSample) TableName() string {
+	return "volume_usage_samples"
+}
+
+// SizeKnown reports whether the sample holds a size
+func (s *VolumeUsageSample) SizeKnown() bool {
+	return s.SizeBytes >= 0 && (s.SizeStatus == VolumeSizeReported || s.SizeStatus == VolumeSizeMeasured)
+}
+
+// SizeNote describes an unknown size for display
+func (s *VolumeUsageSample) SizeNote() string {
+	switch s.SizeStatus {
+	case VolumeSizeTooLarge:
+		return "size unknown (too large to scan)"
+	case VolumeSizeUnavailable:
+		return "size unknown"
+	default:
+		return ""
+	}
+}
@@ -8,6 +8,7 @@ import (
 
 	"docker-auto/pkg/alerting"
 	"docker-auto/pkg/config"
+	"docker-auto/pkg/docker"
 	"docker-auto/pkg/health"
 	"docker-auto/pkg/logging"
 	"github.com/gin-gonic/gin"
@@ -46,8 +47,9 @@ type ObservabilityManager struct {
 	HealthAPI       *healthAPI
 }
 
-// NewObservabilityManager creates a complete observability setup
-func NewObservabilityManager(cfg *config.MonitoringConfig, db *sql.DB) (*ObservabilityManager, error) {
+// NewObservabilityManager creates a complete observability setup. dockerClient
+// may be nil; when set, its image pull queue is monitored.
+func NewObservabilityManager(cfg *config.MonitoringConfig, db *sql.DB, dockerClient *docker.DockerClient) (*ObservabilityManager, error) {
 	// Initialize logger
 	logger, err := logging.NewLogger(cfg.Logging)
 	if err != nil {
@@ -73,6 +75,9 @@ func NewObservabilityManager(cfg *config.MonitoringConfig, db *sql.DB) (*Observa
 	apiMonitor := NewAPIMonitoring(metricsCollector)
 	databaseMonitor := NewDatabaseMonitoring(metricsCollector, db)
 	dockerMonitor := NewDockerMonitoring(metricsCollector)
+	if dockerClient != nil {
+		dockerMonitor.TrackPullThrottle(dockerClient.GetPullThrottle())
+	}
 	websocketMonitor := NewWebSocketMonitoring(metricsCollector)
 	businessMonitor := NewBusinessLogicMonitoring(metricsCollector)
 
@@ -294,11 +299,12 @@ func ExampleUsage() {
 	// Load configuration
 	cfg := config.DefaultMonitoringConfig()
 
-	// Initialize database (placeholder)
+	// Initialize database and Docker client (placeholders)
 	var db *sql.DB
+	var dockerClient *docker.DockerClient
 
 	// Create observability manager
-	om, err := NewObservabilityManager(cfg, db)
+	om, err := NewObservabilityManager(cfg, db, dockerClient)
 	if err != nil {
 		log.Fatal("Failed to initialize observability:", err)
 	}
@@ -354,7 +360,7 @@ func ExampleUsage() {
 	}
 
 	// Example: Manual health check
-	_, err := om.HealthChecker.CheckHealth("docker")
+	_, err = om.HealthChecker.CheckHealth("docker")
 	if err != nil {
 		om.Logger.Error("Health check failed", err)
 	} else {
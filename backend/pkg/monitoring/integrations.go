@@ -6,6 +6,8 @@ import (
 	"sync"
 	"time"
 
+	"docker-auto/pkg/docker"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
 	"github.com/gin-gonic/gin"
@@ -258,6 +260,7 @@ type DockerMonitoring struct {
 	operationDuration *Histogram
 	containerGauge    *Gauge
 	imageGauge        *Gauge
+	pullThrottle      *docker.PullThrottle
 }
 
 // NewDockerMonitoring creates a new Docker monitoring instance
@@ -303,6 +306,7 @@ func (dm *DockerMonitoring) periodicCollection() {
 
 	for range ticker.C {
 		dm.collectDockerMetrics()
+		dm.collectPullThrottleMetrics()
 	}
 }
 
@@ -341,6 +345,31 @@ func (dm *DockerMonitoring) collectDockerMetrics() {
 	}
 }
 
+// TrackPullThrottle publishes pull queue depth and wait time metrics for the
+// host pull throttle with the periodic Docker metrics
+func (dm *DockerMonitoring) TrackPullThrottle(throttle *docker.PullThrottle) {
+	dm.mu.Lock()
+	defer dm.mu.Unlock()
+	dm.pullThrottle = throttle
+}
+
+// collectPullThrottleMetrics collects the pull queue metrics of the tracked throttle
+func (dm *DockerMonitoring) collectPullThrottleMetrics() {
+	dm.mu.RLock()
+	throttle := dm.pullThrottle
+	dm.mu.RUnlock()
+	if throttle == nil {
+		return
+	}
+
+	stats := throttle.Stats()
+	dm.collector.RegisterGauge("docker_pull_queue_depth", "Image pulls waiting for a slot", nil).Set(float64(stats.QueueDepth))
+	dm.collector.RegisterGauge("docker_pull_active", "Image pulls in progress", nil).Set(float64(stats.ActivePulls))
+	dm.collector.RegisterGauge("docker_pull_wait_seconds_avg", "Average image pull wait time in seconds", nil).Set(stats.AverageWait.Seconds())
+	dm.collector.RegisterGauge("docker_pull_wait_seconds_max", "Maximum image pull wait time in seconds", nil).Set(stats.MaxWait.Seconds())
+	dm.collector.RegisterGauge("docker_pull_rate_mbps", "Download rate of the last image pull in MB/s", nil).Set(stats.LastRateMBps)
+}
+
 // TrackOperation tracks a Docker operation
 func (dm *DockerMonitoring) TrackOperation(operation string, fn func() error) error {
 	start := time.Now()
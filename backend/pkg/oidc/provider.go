@@ -0,0 +1,247 @@
+// Package oidc signs users in through an OpenID Connect provider with the
+// authorization code flow and PKCE
+package oidc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// clockSkew is the leeway given to the provider's clock when checking the
+// times of an ID token
+const clockSkew = time.Minute
+
+// signingMethods are the ID token algorithms accepted; "none" and HMAC
+// algorithms, keyed with the client secret, are not
+var signingMethods = []string{"RS256", "RS384", "RS512", "PS256", "PS384", "PS512", "ES256", "ES384", "ES512"}
+
+// Config identifies the client at the provider
+type Config struct {
+	IssuerURL    string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+}
+
+// Provider is an OpenID Connect provider, as described by its discovery
+// document
+type Provider struct {
+	config     Config
+	httpClient *http.Client
+	metadata   metadata
+	keys       *keySet
+}
+
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// IDToken is a verified ID token
+type IDToken struct {
+	Subject       string
+	Email         string
+	EmailVerified *bool // nil when the provider does not say
+	Name          string
+	Username      string // the preferred_username claim
+
+	claims jwt.MapClaims
+}
+
+// Strings returns a claim holding a string or a list of strings, such as the
+// groups claim
+func (t *IDToken) Strings(claim string) []string {
+	switch value := t.claims[claim].(type) {
+	case string:
+		return []string{value}
+	case []interface{}:
+		values := make([]string, 0, len(value))
+		for _, item := range value {
+			if s, ok := item.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+	return nil
+}
+
+// NewProvider reads the discovery document of the issuer. httpClient may be
+// nil for a client with a 10 second timeout.
+func NewProvider(ctx context.Context, config Config, httpClient *http.Client) (*Provider, error) {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	if len(config.Scopes) == 0 {
+		config.Scopes = []string{"openid", "email", "profile"}
+	}
+	if !slices.Contains(config.Scopes, "openid") {
+		config.Scopes = append([]string{"openid"}, config.Scopes...)
+	}
+
+	p := &Provider{config: config, httpClient: httpClient}
+	wellKnown := strings.TrimSuffix(config.IssuerURL, "/") + "/.well-known/openid-configuration"
+	if err := p.getJSON(ctx, wellKnown, &p.metadata); err != nil {
+		return nil, fmt.Errorf("failed to read provider discovery document: %w", err)
+	}
+	if strings.TrimSuffix(p.metadata.Issuer, "/") != strings.TrimSuffix(config.IssuerURL, "/") {
+		return nil, fmt.Errorf("provider issuer %q does not match the configured issuer %q", p.metadata.Issuer, config.IssuerURL)
+	}
+	if p.metadata.AuthorizationEndpoint == "" || p.metadata.TokenEndpoint == "" || p.metadata.JWKSURI == "" {
+		return nil, fmt.Errorf("provider discovery document lacks the authorization, token or JWKS endpoint")
+	}
+
+	p.keys = newKeySet(p.metadata.JWKSURI, p.getJSON)
+	return p, nil
+}
+
+// AuthCodeURL returns the provider URL the user signs in at. The code
+// verifier is kept by the caller for Exchange.
+func (p *Provider) AuthCodeURL(state, nonce, codeVerifier string) string {
+	challenge := sha256.Sum256([]byte(codeVerifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {p.config.ClientID},
+		"redirect_uri":          {p.config.RedirectURL},
+		"scope":                 {strings.Join(p.config.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+
+	separator := "?"
+	if strings.Contains(p.metadata.AuthorizationEndpoint, "?") {
+		separator = "&"
+	}
+	return p.metadata.AuthorizationEndpoint + separator + query.Encode()
+}
+
+// Exchange redeems an authorization code and returns the raw ID token
+func (p *Provider) Exchange(ctx context.Context, code, codeVerifier string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.config.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	if p.config.ClientSecret == "" {
+		form.Set("client_id", p.config.ClientID)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if p.config.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(p.config.ClientID), url.QueryEscape(p.config.ClientSecret))
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&token); err != nil && resp.StatusCode == http.StatusOK {
+		return "", fmt.Errorf("invalid token response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK || token.Error != "" {
+		if token.Error == "" {
+			token.Error = resp.Status
+		}
+		return "", fmt.Errorf("token request refused: %s", strings.TrimSpace(token.Error+" "+token.ErrorDescription))
+	}
+	if token.IDToken == "" {
+		return "", fmt.Errorf("token response has no ID token")
+	}
+	return token.IDToken, nil
+}
+
+// Verify checks the signature, issuer, audience, times and nonce of an ID
+// token
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*IDToken, error) {
+	parser := jwt.NewParser(
+		jwt.WithValidMethods(signingMethods),
+		jwt.WithIssuer(p.metadata.Issuer),
+		jwt.WithAudience(p.config.ClientID),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(clockSkew),
+	)
+
+	claims := jwt.MapClaims{}
+	if _, err := parser.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return p.keys.key(ctx, kid)
+	}); err != nil {
+		return nil, fmt.Errorf("invalid ID token: %w", err)
+	}
+
+	// With several audiences the token must have been issued to this client
+	if audiences, _ := claims.GetAudience(); len(audiences) > 1 {
+		if azp, _ := claims["azp"].(string); azp != p.config.ClientID {
+			return nil, fmt.Errorf("invalid ID token: issued to %q", azp)
+		}
+	}
+	if tokenNonce, _ := claims["nonce"].(string); tokenNonce == "" || tokenNonce != nonce {
+		return nil, fmt.Errorf("invalid ID token: nonce mismatch")
+	}
+
+	token := &IDToken{claims: claims}
+	token.Subject, _ = claims.GetSubject()
+	if token.Subject == "" {
+		return nil, fmt.Errorf("invalid ID token: no subject")
+	}
+	token.Email, _ = claims["email"].(string)
+	token.Name, _ = claims["name"].(string)
+	token.Username, _ = claims["preferred_username"].(string)
+	switch verified := claims["email_verified"].(type) {
+	case bool:
+		token.EmailVerified = &verified
+	case string: // some providers send "true"
+		value := verified == "true"
+		token.EmailVerified = &value
+	}
+	return token, nil
+}
+
+func (p *Provider) getJSON(ctx context.Context, endpoint string, into interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(into)
+}
@@ -0,0 +1,325 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testClientID = "docker-auto"
+	testKeyID    = "key-1"
+	testNonce    = "nonce-1"
+)
+
+// fakeIssuer is an OpenID Connect provider serving discovery, keys and a
+// token endpoint that returns idToken
+type fakeIssuer struct {
+	server  *httptest.Server
+	key     *rsa.PrivateKey
+	idToken string
+	form    url.Values // the last token request
+}
+
+func newFakeIssuer(t *testing.T) *fakeIssuer {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	issuer := &fakeIssuer{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 issuer.server.URL,
+			"authorization_endpoint": issuer.server.URL + "/authorize",
+			"token_endpoint":         issuer.server.URL + "/token",
+			"jwks_uri":               issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": testKeyID,
+				"kty": "RSA",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		issuer.form = r.PostForm
+		json.NewEncoder(w).Encode(map[string]string{"id_token": issuer.idToken})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(issuer.server.Close)
+	return issuer
+}
+
+func (f *fakeIssuer) provider(t *testing.T) *Provider {
+	t.Helper()
+
+	provider, err := NewProvider(context.Background(), Config{
+		IssuerURL:   f.server.URL,
+		ClientID:    testClientID,
+		RedirectURL: "https://docker-auto.example.com/api/auth/oidc/callback",
+	}, f.server.Client())
+	if err != nil {
+		t.Fatalf("NewProvider failed: %v", err)
+	}
+	return provider
+}
+
+// claims returns the claims of a valid ID token
+func (f *fakeIssuer) claims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            f.server.URL,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"nonce":          testNonce,
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"groups":         []string{"ops", "dev"},
+	}
+}
+
+func (f *fakeIssuer) sign(t *testing.T, claims jwt.MapClaims, kid string) string {
+	t.Helper()
+
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(f.key)
+	if err != nil {
+		t.Fatalf("failed to sign token: %v", err)
+	}
+	return signed
+}
+
+func TestProviderVerify(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := issuer.provider(t)
+
+	token, err := provider.Verify(context.Background(), issuer.sign(t, issuer.claims(), testKeyID), testNonce)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if token.Subject != "user-1" || token.Email != "alice@example.com" {
+		t.Errorf("token = %s %s, want user-1 alice@example.com", token.Subject, token.Email)
+	}
+	if token.EmailVerified == nil || !*token.EmailVerified {
+		t.Errorf("EmailVerified = %v, want true", token.EmailVerified)
+	}
+	if groups := token.Strings("groups"); strings.Join(groups, ",") != "ops,dev" {
+		t.Errorf("groups = %v, want [ops dev]", groups)
+	}
+}
+
+func TestProviderVerifyEmailVerified(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := issuer.provider(t)
+
+	tests := []struct {
+		name  string
+		claim interface{} // nil leaves the claim out
+		want  *bool
+	}{
+		{"true", true, boolPtr(true)},
+		{"false", false, boolPtr(false)},
+		{"string true", "true", boolPtr(true)},
+		{"string false", "false", boolPtr(false)},
+		{"missing", nil, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims := issuer.claims()
+			delete(claims, "email_verified")
+			if tt.claim != nil {
+				claims["email_verified"] = tt.claim
+			}
+
+			token, err := provider.Verify(context.Background(), issuer.sign(t, claims, testKeyID), testNonce)
+			if err != nil {
+				t.Fatalf("Verify failed: %v", err)
+			}
+			switch {
+			case tt.want == nil && token.EmailVerified != nil:
+				t.Errorf("EmailVerified = %v, want nil", *token.EmailVerified)
+			case tt.want != nil && (token.EmailVerified == nil || *token.EmailVerified != *tt.want):
+				t.Errorf("EmailVerified = %v, want %v", token.EmailVerified, *tt.want)
+			}
+		})
+	}
+}
+
+func TestProviderVerifyRejects(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := issuer.provider(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		token func() string
+		nonce string
+	}{
+		{"nonce mismatch", func() string { return issuer.sign(t, issuer.claims(), testKeyID) }, "other-nonce"},
+		{"wrong audience", func() string {
+			claims := issuer.claims()
+			claims["aud"] = "other-client"
+			return issuer.sign(t, claims, testKeyID)
+		}, testNonce},
+		{"wrong issuer", func() string {
+			claims := issuer.claims()
+			claims["iss"] = "https://evil.example.com"
+			return issuer.sign(t, claims, testKeyID)
+		}, testNonce},
+		{"expired", func() string {
+			claims := issuer.claims()
+			claims["exp"] = time.Now().Add(-time.Hour).Unix()
+			return issuer.sign(t, claims, testKeyID)
+		}, testNonce},
+		{"no expiry", func() string {
+			claims := issuer.claims()
+			delete(claims, "exp")
+			return issuer.sign(t, claims, testKeyID)
+		}, testNonce},
+		{"no subject", func() string {
+			claims := issuer.claims()
+			delete(claims, "sub")
+			return issuer.sign(t, claims, testKeyID)
+		}, testNonce},
+		{"several audiences without azp", func() string {
+			claims := issuer.claims()
+			claims["aud"] = []string{testClientID, "other-client"}
+			return issuer.sign(t, claims, testKeyID)
+		}, testNonce},
+		{"unknown key ID", func() string { return issuer.sign(t, issuer.claims(), "key-2") }, testNonce},
+		{"signed by another key", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodRS256, issuer.claims())
+			token.Header["kid"] = testKeyID
+			signed, _ := token.SignedString(otherKey)
+			return signed
+		}, testNonce},
+		{"HMAC keyed with the client ID", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodHS256, issuer.claims())
+			signed, _ := token.SignedString([]byte(testClientID))
+			return signed
+		}, testNonce},
+		{"unsigned", func() string {
+			token := jwt.NewWithClaims(jwt.SigningMethodNone, issuer.claims())
+			signed, _ := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+			return signed
+		}, testNonce},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := provider.Verify(context.Background(), tt.token(), tt.nonce); err == nil {
+				t.Fatal("Verify accepted the token")
+			}
+		})
+	}
+}
+
+func TestProviderExchange(t *testing.T) {
+	issuer := newFakeIssuer(t)
+	provider := issuer.provider(t)
+	issuer.idToken = issuer.sign(t, issuer.claims(), testKeyID)
+
+	authURL, err := url.Parse(provider.AuthCodeURL("state-1", testNonce, "verifier-1"))
+	if err != nil {
+		t.Fatalf("invalid auth URL: %v", err)
+	}
+	query := authURL.Query()
+	if query.Get("code_challenge_method") != "S256" || query.Get("code_challenge") == "" {
+		t.Errorf("auth URL lacks a PKCE challenge: %s", authURL)
+	}
+	if !strings.Contains(query.Get("scope"), "openid") {
+		t.Errorf("scope = %q, want openid", query.Get("scope"))
+	}
+
+	raw, err := provider.Exchange(context.Background(), "code-1", "verifier-1")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if raw != issuer.idToken {
+		t.Error("Exchange returned another ID token")
+	}
+	if issuer.form.Get("code_verifier") != "verifier-1" || issuer.form.Get("client_id") != testClientID {
+		t.Errorf("token request = %v, want the code verifier and client ID", issuer.form)
+	}
+}
+
+func TestNewProviderRejectsIssuerMismatch(t *testing.T) {
+	issuer := newFakeIssuer(t)
+
+	_, err := NewProvider(context.Background(), Config{
+		IssuerURL: issuer.server.URL + "/other",
+		ClientID:  testClientID,
+	}, issuer.server.Client())
+	if err == nil {
+		t.Fatal("NewProvider accepted a discovery document of another issuer")
+	}
+}
+
+func TestLoginState(t *testing.T) {
+	key := []byte("state-key")
+	state, err := NewLoginState(time.Minute)
+	if err != nil {
+		t.Fatalf("NewLoginState failed: %v", err)
+	}
+	sealed, err := state.Seal(key)
+	if err != nil {
+		t.Fatalf("Seal failed: %v", err)
+	}
+
+	opened, err := OpenLoginState(key, sealed)
+	if err != nil {
+		t.Fatalf("OpenLoginState failed: %v", err)
+	}
+	if opened.State != state.State || opened.Nonce != state.Nonce || opened.CodeVerifier != state.CodeVerifier {
+		t.Error("opened state differs from the sealed one")
+	}
+
+	if _, err := OpenLoginState([]byte("other-key"), sealed); err == nil {
+		t.Error("state opened with another key")
+	}
+	_, signature, _ := strings.Cut(sealed, ".")
+	tampered := base64.RawURLEncoding.EncodeToString([]byte(`{"state":"x"}`)) + "." + signature
+	if _, err := OpenLoginState(key, tampered); err == nil {
+		t.Error("tampered state opened")
+	}
+
+	expired := &LoginState{State: "s", ExpiresAt: time.Now().Add(-time.Second)}
+	sealedExpired, _ := expired.Seal(key)
+	if _, err := OpenLoginState(key, sealedExpired); err == nil {
+		t.Error("expired state opened")
+	}
+}
+
+func boolPtr(value bool) *bool {
+	return &value
+}
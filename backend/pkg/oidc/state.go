@@ -0,0 +1,76 @@
+package oidc
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LoginState is what a login keeps between sending the user to the provider
+// and the callback. It travels in a cookie signed by Seal, so the server
+// keeps nothing.
+type LoginState struct {
+	State        string    `json:"state"`
+	Nonce        string    `json:"nonce"`
+	CodeVerifier string    `json:"code_verifier"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// NewLoginState returns a login state with fresh random values, valid for ttl
+func NewLoginState(ttl time.Duration) (*LoginState, error) {
+	state := &LoginState{ExpiresAt: time.Now().Add(ttl)}
+	for _, value := range []*string{&state.State, &state.Nonce, &state.CodeVerifier} {
+		random := make([]byte, 32)
+		if _, err := rand.Read(random); err != nil {
+			return nil, fmt.Errorf("failed to generate login state: %w", err)
+		}
+		*value = base64.RawURLEncoding.EncodeToString(random)
+	}
+	return state, nil
+}
+
+// Seal encodes and signs the state with key
+func (s *LoginState) Seal(key []byte) (string, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(sign(key, encoded)), nil
+}
+
+// OpenLoginState checks the signature and expiry of a sealed state
+func OpenLoginState(key []byte, sealed string) (*LoginState, error) {
+	encoded, signature, found := strings.Cut(sealed, ".")
+	if !found {
+		return nil, fmt.Errorf("malformed login state")
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, sign(key, encoded)) {
+		return nil, fmt.Errorf("login state signature mismatch")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("malformed login state")
+	}
+	var state LoginState
+	if err := json.Unmarshal(payload, &state); err != nil {
+		return nil, fmt.Errorf("malformed login state")
+	}
+	if time.Now().After(state.ExpiresAt) {
+		return nil, fmt.Errorf("login state expired")
+	}
+	return &state, nil
+}
+
+func sign(key []byte, value string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(value))
+	return mac.Sum(nil)
+}
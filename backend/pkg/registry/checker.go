@@ -10,7 +10,7 @@ import (
 	"sync"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 
 	"github.com/sirupsen/logrus"
 )
@@ -18,16 +18,27 @@ import (
 // imageChecker implements the ImageChecker interface
 type imageChecker struct {
 	clients           map[string]Client
+	hostClients       map[string]Client // by registry host; see SetCredentials
 	clientsMutex      sync.RWMutex
 	cache             map[string]*cacheEntry
 	cacheMutex        sync.RWMutex
 	cacheConfig       *CacheConfig
+	shared            SharedCache // see SetSharedCache
+	tagLists          map[string]*tagListEntry // see ListTags
+	tagListsMutex     sync.RWMutex
 	defaultRegistry   string
 	cleanupTicker     *time.Ticker
 	ctx               context.Context
 	cancel            context.CancelFunc
 }
 
+// sharedCacheTimeout bounds a shared cache call, after which the checker
+// falls back to its memory cache
+const sharedCacheTimeout = 2 * time.Second
+
+// imageInfoKeyPrefix prefixes the shared cache keys of image information
+const imageInfoKeyPrefix = "registry:image:"
+
 // cacheEntry represents a cached image information entry
 type cacheEntry struct {
 	data      *model.ImageVersion
@@ -39,8 +50,10 @@ func NewImageChecker() ImageChecker {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	checker := &imageChecker{
-		clients: make(map[string]Client),
+		clients:     make(map[string]Client),
+		hostClients: make(map[string]Client),
 		cache:   make(map[string]*cacheEntry),
+		tagLists:    make(map[string]*tagListEntry),
 		cacheConfig: &CacheConfig{
 			TTL:             6 * time.Hour,
 			MaxEntries:      1000,
@@ -271,22 +284,60 @@ func (c *imageChecker) RegisterClient(registryType string, client Client) {
 	c.clients[registryType] = client
 }
 
-// GetClient gets a client for a specific registry URL
+// SetCredentials makes requests to the registry's host authenticate with
+// auth; nil removes the credentials
+func (c *imageChecker) SetCredentials(registryURL string, auth *AuthConfig) {
+	host := RegistryHost(registryURL)
+
+	c.clientsMutex.Lock()
+	defer c.clientsMutex.Unlock()
+
+	if auth == nil {
+		delete(c.hostClients, host)
+		return
+	}
+
+	switch c.detectRegistryType(host) {
+	case "dockerhub":
+		c.hostClients[host] = NewDockerHubClient(auth)
+	case "harbor":
+		c.hostClients[host] = NewHarborClient("https://"+host, auth)
+	default:
+		c.hostClients[host] = NewV2Client(registryURL, auth)
+	}
+}
+
+// GetClient gets a client for a specific registry URL. Hosts with
+// credentials get their own client; registries without a client for their
+// type are reached through the plain registry API v2.
 func (c *imageChecker) GetClient(registryURL string) (Client, error) {
 	if registryURL == "" {
 		registryURL = c.defaultRegistry
 	}
 
 	registryType := c.detectRegistryType(registryURL)
+	host := RegistryHost(registryURL)
 
 	c.clientsMutex.RLock()
-	client, exists := c.clients[registryType]
+	client, exists := c.hostClients[host]
+	if !exists {
+		client, exists = c.clients[registryType]
+	}
 	c.clientsMutex.RUnlock()
 
-	if !exists {
+	if exists {
+		return client, nil
+	}
+	if registryType == "dockerhub" {
 		return nil, fmt.Errorf("no client registered for registry type: %s", registryType)
 	}
 
+	c.clientsMutex.Lock()
+	defer c.clientsMutex.Unlock()
+	if client, exists = c.hostClients[host]; !exists {
+		client = NewV2Client(registryURL, nil)
+		c.hostClients[host] = client
+	}
 	return client, nil
 }
 
@@ -305,11 +356,10 @@ func (c *imageChecker) GetSupportedRegistries() []string {
 
 // Cache management methods
 
-// CacheImageInfo caches image information
+// CacheImageInfo caches image information, in the shared cache too when one
+// is set
 func (c *imageChecker) CacheImageInfo(image string, info *model.ImageVersion, ttl time.Duration) error {
 	c.cacheMutex.Lock()
-	defer c.cacheMutex.Unlock()
-
 	// Check cache size limit
 	if len(c.cache) >= c.cacheConfig.MaxEntries {
 		c.evictOldestEntries(c.cacheConfig.MaxEntries / 4) // Evict 25% of entries
@@ -319,14 +369,40 @@ func (c *imageChecker) CacheImageInfo(image string, info *model.ImageVersion, tt
 		data:      info,
 		expiresAt: time.Now().Add(ttl),
 	}
+	c.cacheMutex.Unlock()
+
+	if c.shared != nil {
+		ctx, cancel := context.WithTimeout(c.ctx, sharedCacheTimeout)
+		defer cancel()
+		if err := c.shared.SetJSON(ctx, imageInfoKeyPrefix+image, info, ttl); err != nil {
+			logrus.WithError(err).WithField("image", image).Warn("Failed to write shared image cache")
+		}
+	}
 
 	return nil
 }
 
-// GetCachedImageInfo retrieves cached image information
+// GetCachedImageInfo retrieves cached image information. With a shared cache
+// only its entries are served, so an invalidation by another replica takes
+// effect here too; memory is used while the shared cache is unreachable.
 func (c *imageChecker) GetCachedImageInfo(image string) (*model.ImageVersion, bool) {
-	c.cacheMutex.RLock()
-	defer c.cacheMutex.RUnlock()
+	if c.shared != nil {
+		ctx, cancel := context.WithTimeout(c.ctx, sharedCacheTimeout)
+		defer cancel()
+
+		var info model.ImageVersion
+		found, err := c.shared.GetJSON(ctx, imageInfoKeyPrefix+image, &info)
+		if err == nil {
+			if !found {
+				return nil, false
+			}
+			return &info, true
+		}
+		logrus.WithError(err).WithField("image", image).Debug("Shared image cache unavailable, using memory")
+	}
+
+	c.cacheMutex.Lock()
+	defer c.cacheMutex.Unlock()
 
 	entry, exists := c.cache[image]
 	if !exists {
@@ -345,21 +421,46 @@ func (c *imageChecker) GetCachedImageInfo(image string) (*model.ImageVersion, bo
 // InvalidateCache removes specific image from cache
 func (c *imageChecker) InvalidateCache(image string) error {
 	c.cacheMutex.Lock()
-	defer c.cacheMutex.Unlock()
-
 	delete(c.cache, image)
+	c.cacheMutex.Unlock()
+
+	if c.shared != nil {
+		ctx, cancel := context.WithTimeout(c.ctx, sharedCacheTimeout)
+		defer cancel()
+		if err := c.shared.Delete(ctx, imageInfoKeyPrefix+image); err != nil {
+			return fmt.Errorf("failed to invalidate shared image cache: %w", err)
+		}
+	}
 	return nil
 }
 
 // ClearCache clears all cached data
 func (c *imageChecker) ClearCache() error {
 	c.cacheMutex.Lock()
-	defer c.cacheMutex.Unlock()
-
 	c.cache = make(map[string]*cacheEntry)
+	c.cacheMutex.Unlock()
+
+	c.tagListsMutex.Lock()
+	c.tagLists = make(map[string]*tagListEntry)
+	c.tagListsMutex.Unlock()
+
+	if c.shared != nil {
+		ctx, cancel := context.WithTimeout(c.ctx, sharedCacheTimeout)
+		defer cancel()
+		if err := c.shared.DeletePrefix(ctx, imageInfoKeyPrefix); err != nil {
+			return fmt.Errorf("failed to clear shared image cache: %w", err)
+		}
+	}
 	return nil
 }
 
+// SetSharedCache makes the checker cache image information, and with it the
+// latest digest of each image, in a cache shared between replicas. It must be
+// called before the checker is used.
+func (c *imageChecker) SetSharedCache(cache SharedCache) {
+	c.shared = cache
+}
+
 // Batch operations
 
 // CheckMultipleImages checks multiple images concurrently
@@ -796,6 +897,14 @@ func (c *imageChecker) cleanupExpiredEntries() {
 			delete(c.cache, image)
 		}
 	}
+
+	c.tagListsMutex.Lock()
+	defer c.tagListsMutex.Unlock()
+	for key, entry := range c.tagLists {
+		if now.Sub(entry.listedAt) > tagListTTL {
+			delete(c.tagLists, key)
+		}
+	}
 }
 
 // evictOldestEntries removes the oldest entries from cache
@@ -10,7 +10,7 @@ import (
 	"strings"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 )
 
 // DockerHub API endpoints
@@ -194,7 +194,11 @@ func (c *dockerHubClient) GetLatestImageInfo(ctx context.Context, image string)
 	return imageVersion, nil
 }
 
-// GetImageTags gets available tags for a repository
+// dockerHubMaxPageSize is the largest page the Docker Hub tag API serves
+const dockerHubMaxPageSize = 100
+
+// GetImageTags gets available tags for a repository. Docker Hub pages tag
+// lists, so a Limit above one page follows the next links until it is met.
 func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, options *TagListOptions) ([]*ImageTag, error) {
 	// Handle library repositories
 	if !strings.Contains(repository, "/") {
@@ -205,10 +209,12 @@ func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, o
 	tagsURL := fmt.Sprintf("%s/repositories/%s/tags", c.baseURL, repository)
 
 	// Add query parameters
+	limit := 0
 	params := url.Values{}
 	if options != nil {
 		if options.Limit > 0 {
-			params.Set("page_size", strconv.Itoa(options.Limit))
+			limit = options.Limit
+			params.Set("page_size", strconv.Itoa(min(limit, dockerHubMaxPageSize)))
 		}
 		if options.Sort != "" {
 			params.Set("ordering", options.Sort)
@@ -222,10 +228,32 @@ func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, o
 		tagsURL += "?" + params.Encode()
 	}
 
+	var tags []*ImageTag
+	for tagsURL != "" {
+		page, next, err := c.getTagPage(ctx, tagsURL)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page...)
+		if limit == 0 || len(tags) >= limit {
+			break
+		}
+		tagsURL = next
+	}
+
+	if limit > 0 && len(tags) > limit {
+		tags = tags[:limit]
+	}
+	return tags, nil
+}
+
+// getTagPage fetches one page of the tag API, returning the URL of the
+// next page, empty on the last
+func (c *dockerHubClient) getTagPage(ctx context.Context, tagsURL string) ([]*ImageTag, string, error) {
 	// Make request
 	req, err := http.NewRequestWithContext(ctx, "GET", tagsURL, nil)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, "", fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Add authentication if available
@@ -235,12 +263,15 @@ func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, o
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return nil, "", fmt.Errorf("failed to make request: %w", err)
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, "", NewRegistryError(ErrorCodeImageNotFound, "repository not found on Docker Hub")
+	}
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("Docker Hub API returned status %d", resp.StatusCode)
+		return nil, "", fmt.Errorf("Docker Hub API returned status %d", resp.StatusCode)
 	}
 
 	// Parse response
@@ -249,15 +280,17 @@ func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, o
 		Next     string `json:"next"`
 		Previous string `json:"previous"`
 		Results  []struct {
-			Name        string    `json:"name"`
-			FullSize    int64     `json:"full_size"`
-			ID          int64     `json:"id"`
-			Repository  int64     `json:"repository"`
-			Creator     int64     `json:"creator"`
-			LastUpdater int64     `json:"last_updater"`
-			LastUpdated time.Time `json:"last_updated"`
-			ImageID     string    `json:"image_id"`
-			V2          bool      `json:"v2"`
+			Name          string    `json:"name"`
+			FullSize      int64     `json:"full_size"`
+			ID            int64     `json:"id"`
+			Repository    int64     `json:"repository"`
+			Creator       int64     `json:"creator"`
+			LastUpdater   int64     `json:"last_updater"`
+			LastUpdated   time.Time `json:"last_updated"`
+			TagLastPushed time.Time `json:"tag_last_pushed"`
+			ImageID       string    `json:"image_id"`
+			Digest        string    `json:"digest"`
+			V2            bool      `json:"v2"`
 			Platforms   []struct {
 				Architecture string `json:"architecture"`
 				OS           string `json:"os"`
@@ -283,7 +316,7 @@ func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, o
 	}
 
 	if err := json.NewDecoder(resp.Body).Decode(&tagsResponse); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		return nil, "", fmt.Errorf("failed to decode response: %w", err)
 	}
 
 	// Convert to ImageTag objects
@@ -291,13 +324,20 @@ func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, o
 	for i, result := range tagsResponse.Results {
 		tag := &ImageTag{
 			Name:    result.Name,
+			Digest:  result.Digest,
 			Created: result.LastUpdated,
 			Size:    result.FullSize,
 		}
+		if !result.TagLastPushed.IsZero() {
+			pushed := result.TagLastPushed
+			tag.LastPushed = &pushed
+		}
 
-		// Get digest from images if available
+		// Fall back to the first platform's digest for tags without an index
 		if len(result.Images) > 0 {
-			tag.Digest = result.Images[0].Digest
+			if tag.Digest == "" {
+				tag.Digest = result.Images[0].Digest
+			}
 			tag.Architecture = result.Images[0].Architecture
 			tag.OS = result.Images[0].OS
 		}
@@ -305,7 +345,7 @@ func (c *dockerHubClient) GetImageTags(ctx context.Context, repository string, o
 		tags[i] = tag
 	}
 
-	return tags, nil
+	return tags, tagsResponse.Next, nil
 }
 
 // GetImageManifest gets manifest information for a specific image tag
@@ -394,9 +434,68 @@ func (c *dockerHubClient) GetImageManifest(ctx context.Context, repository, tag
 		MediaType: manifestV2.Config.MediaType,
 	}
 
+	// Labels and creation time live in the config blob; the manifest is
+	// still useful without them
+	if manifestV2.Config.Digest != "" {
+		if config, err := c.getImageConfig(ctx, repository, manifestV2.Config.Digest); err == nil {
+			manifest.Config.Env = config.Config.Env
+			manifest.Config.Cmd = config.Config.Cmd
+			manifest.Config.Labels = config.Config.Labels
+			manifest.Labels = config.Config.Labels
+			manifest.Architecture = config.Architecture
+			manifest.OS = config.OS
+			if !config.Created.IsZero() {
+				manifest.Created = config.Created
+			}
+		}
+	}
+
 	return manifest, nil
 }
 
+// imageConfigBlob is the part of an image config blob the client reads
+type imageConfigBlob struct {
+	Architecture string    `json:"architecture"`
+	OS           string    `json:"os"`
+	Created      time.Time `json:"created"`
+	Config       struct {
+		Env    []string          `json:"Env"`
+		Cmd    []string          `json:"Cmd"`
+		Labels map[string]string `json:"Labels"`
+	} `json:"config"`
+}
+
+// getImageConfig fetches and decodes the config blob of an image
+func (c *dockerHubClient) getImageConfig(ctx context.Context, repository, digest string) (*imageConfigBlob, error) {
+	blobURL := fmt.Sprintf("%s/%s/blobs/%s", DockerHubRegistryV2, repository, digest)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", blobURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if c.auth != nil {
+		c.addAuthHeader(req)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Registry API returned status %d for blob %s", resp.StatusCode, digest)
+	}
+
+	var config imageConfigBlob
+	if err := json.NewDecoder(resp.Body).Decode(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode image config: %w", err)
+	}
+
+	return &config, nil
+}
+
 // SearchRepositories searches for repositories on Docker Hub
 func (c *dockerHubClient) SearchRepositories(ctx context.Context, options *SearchOptions) ([]*RepositorySearchResult, error) {
 	if options == nil || options.Query == "" {
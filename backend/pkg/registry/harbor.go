@@ -10,7 +10,7 @@ import (
 	"strings"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 )
 
 // harborClient implements the HarborClient interface for Harbor registries
@@ -286,6 +286,10 @@ func (c *harborClient) GetImageTags(ctx context.Context, repository string, opti
 			Name:    tag.Name,
 			Created: tag.PushTime,
 		}
+		if !tag.PushTime.IsZero() {
+			pushed := tag.PushTime
+			imageTags[i].LastPushed = &pushed
+		}
 	}
 
 	// Apply sorting and limits if specified
@@ -328,7 +332,7 @@ func (c *harborClient) GetImageManifest(ctx context.Context, repository, tag str
 	}
 
 	if targetArtifact == nil {
-		return nil, fmt.Errorf("tag %s not found in %s", tag, repository)
+		return nil, NewRegistryError(ErrorCodeTagNotFound, fmt.Sprintf("tag %s not found in %s", tag, repository))
 	}
 
 	// Build manifest from artifact information
@@ -431,7 +435,7 @@ func (c *harborClient) GetRepositoryInfo(ctx context.Context, repository string)
 	}
 
 	if targetRepo == nil {
-		return nil, fmt.Errorf("repository not found: %s", repository)
+		return nil, NewRegistryError(ErrorCodeImageNotFound, fmt.Sprintf("repository not found: %s", repository))
 	}
 
 	info := &RepositoryInfo{
@@ -4,7 +4,7 @@ import (
 	"context"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 )
 
 // Client defines the interface for registry clients
@@ -124,12 +124,17 @@ type ImageChecker interface {
 	RegisterClient(registryType string, client Client)
 	GetClient(registryURL string) (Client, error)
 	GetSupportedRegistries() []string
+	SetCredentials(registryURL string, auth *AuthConfig)
+
+	// Tag browsing
+	ListTags(ctx context.Context, image, registryURL string, page, pageSize int) (*TagPage, error)
 
 	// Cache management
 	CacheImageInfo(image string, info *model.ImageVersion, ttl time.Duration) error
 	GetCachedImageInfo(image string) (*model.ImageVersion, bool)
 	InvalidateCache(image string) error
 	ClearCache() error
+	SetSharedCache(cache SharedCache)
 
 	// Batch operations
 	CheckMultipleImages(ctx context.Context, images []string, registryURL string) ([]*UpdateCheckResult, error)
@@ -150,6 +155,15 @@ type CacheConfig struct {
 	PersistencePath    string        `json:"persistence_path,omitempty"`
 }
 
+// SharedCache is a cache shared between replicas, such as utils.RedisCache.
+// found is false for a missing key; an error means the cache is unreachable.
+type SharedCache interface {
+	GetJSON(ctx context.Context, key string, dest interface{}) (found bool, err error)
+	SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error
+	Delete(ctx context.Context, keys ...string) error
+	DeletePrefix(ctx context.Context, prefix string) error
+}
+
 // VersionComparisonResult represents the result of version comparison
 type VersionComparisonResult struct {
 	CurrentVersion string                 `json:"current_version"`
@@ -0,0 +1,186 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// ErrTagListingUnsupported is returned for registries that do not serve a
+// repository's tag list
+var ErrTagListingUnsupported = errors.New("registry does not support listing tags")
+
+const (
+	// tagListTTL is how long a repository's tag list is reused
+	tagListTTL = 2 * time.Minute
+	// maxListedTags bounds the tags read from one repository
+	maxListedTags = 2000
+	// digestLookups bounds the concurrent manifest requests of a page
+	digestLookups = 4
+)
+
+// TagPage is one page of a repository's tags
+type TagPage struct {
+	Registry   string      `json:"registry"`
+	Repository string      `json:"repository"`
+	Tags       []*ImageTag `json:"tags"`
+	Total      int         `json:"total"`
+	// Truncated is set when the repository has more tags than are listed
+	Truncated bool      `json:"truncated"`
+	ListedAt  time.Time `json:"listed_at"`
+}
+
+// tagListEntry is a cached, sorted tag list of a repository
+type tagListEntry struct {
+	tags      []*ImageTag
+	truncated bool
+	listedAt  time.Time
+}
+
+// ListTags returns a page of the tags of image's repository, versions newest
+// first followed by the other tags. The tag list is cached for tagListTTL;
+// digests the registry does not list with the tags are resolved for the
+// page only.
+func (c *imageChecker) ListTags(ctx context.Context, image, registryURL string, page, pageSize int) (*TagPage, error) {
+	if registryURL == "" {
+		registryURL = ImageRegistryHost(image)
+	}
+	host := RegistryHost(registryURL)
+	repository := ImageRepository(image)
+	if repository == "" {
+		return nil, fmt.Errorf("image is required")
+	}
+
+	client, err := c.GetClient(registryURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get registry client: %w", err)
+	}
+
+	key := host + "/" + repository
+	c.tagListsMutex.RLock()
+	entry, exists := c.tagLists[key]
+	c.tagListsMutex.RUnlock()
+
+	if !exists || time.Since(entry.listedAt) > tagListTTL {
+		tags, err := client.GetImageTags(ctx, repository, &TagListOptions{
+			Repository: repository,
+			Limit:      maxListedTags + 1,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		entry = &tagListEntry{listedAt: time.Now()}
+		if len(tags) > maxListedTags {
+			tags, entry.truncated = tags[:maxListedTags], true
+		}
+		sortTags(tags)
+		entry.tags = tags
+
+		c.tagListsMutex.Lock()
+		c.tagLists[key] = entry
+		c.tagListsMutex.Unlock()
+	}
+
+	result := &TagPage{
+		Registry:   host,
+		Repository: repository,
+		Tags:       []*ImageTag{},
+		Total:      len(entry.tags),
+		Truncated:  entry.truncated,
+		ListedAt:   entry.listedAt,
+	}
+
+	start := (page - 1) * pageSize
+	if start < 0 || start >= len(entry.tags) {
+		return result, nil
+	}
+	end := min(start+pageSize, len(entry.tags))
+
+	c.tagListsMutex.RLock()
+	for _, tag := range entry.tags[start:end] {
+		copied := *tag
+		result.Tags = append(result.Tags, &copied)
+	}
+	c.tagListsMutex.RUnlock()
+
+	c.resolveDigests(ctx, client, repository, result.Tags)
+
+	// Keep the digests for the next request of the page
+	c.tagListsMutex.Lock()
+	for i, tag := range result.Tags {
+		entry.tags[start+i].Digest = tag.Digest
+	}
+	c.tagListsMutex.Unlock()
+
+	return result, nil
+}
+
+// resolveDigests looks up the manifest digest of tags listed without one.
+// A tag whose manifest cannot be read is left without a digest.
+func (c *imageChecker) resolveDigests(ctx context.Context, client Client, repository string, tags []*ImageTag) {
+	slots := make(chan struct{}, digestLookups)
+	var wg sync.WaitGroup
+	for _, tag := range tags {
+		if tag.Digest != "" {
+			continue
+		}
+		wg.Add(1)
+		go func(tag *ImageTag) {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			if manifest, err := client.GetImageManifest(ctx, repository, tag.Name); err == nil {
+				tag.Digest = manifest.Digest
+			}
+		}(tag)
+	}
+	wg.Wait()
+}
+
+// sortTags orders the tags that parse as versions newest first, ahead of
+// the other tags, which are ordered by last push and then name
+func sortTags(tags []*ImageTag) {
+	type sortable struct {
+		tag     *ImageTag
+		version model.SemVer
+		ok      bool
+	}
+	items := make([]sortable, len(tags))
+	for i, tag := range tags {
+		version, ok := model.ParseSemVer(tag.Name)
+		items[i] = sortable{tag: tag, version: version, ok: ok}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		a, b := items[i], items[j]
+		if a.ok != b.ok {
+			return a.ok
+		}
+		if a.ok {
+			if cmp := a.version.Compare(b.version); cmp != 0 {
+				return cmp > 0
+			}
+			if (a.version.Suffix == "") != (b.version.Suffix == "") {
+				return a.version.Suffix == ""
+			}
+			return a.tag.Name < b.tag.Name
+		}
+		if pa, pb := a.tag.LastPushed, b.tag.LastPushed; pa != nil && pb != nil && !pa.Equal(*pb) {
+			return pa.After(*pb)
+		} else if (pa != nil) != (pb != nil) {
+			return pa != nil
+		}
+		return a.tag.Name < b.tag.Name
+	})
+
+	for i, item := range items {
+		tags[i] = item.tag
+	}
+}
@@ -2,10 +2,18 @@ package registry
 
 import (
 	"fmt"
+	"net/url"
 	"strings"
 	"time"
 )
 
+// OCI image annotations, also used as config labels
+const (
+	LabelImageURL      = "org.opencontainers.image.url"
+	LabelImageSource   = "org.opencontainers.image.source"
+	LabelImageRevision = "org.opencontainers.image.revision"
+)
+
 // AuthConfig represents registry authentication configuration
 type AuthConfig struct {
 	Username string `json:"username,omitempty"`
@@ -20,6 +28,7 @@ type ImageTag struct {
 	Name        string            `json:"name"`
 	Digest      string            `json:"digest"`
 	Created     time.Time         `json:"created"`
+	LastPushed  *time.Time        `json:"last_pushed,omitempty"`
 	Size        int64             `json:"size"`
 	Architecture string           `json:"architecture,omitempty"`
 	OS          string            `json:"os,omitempty"`
@@ -396,4 +405,36 @@ func BuildImageRef(registry, namespace, repository, tag string) string {
 	}
 
 	return imageRef
-}
\ No newline at end of file
+}
+
+// ReleaseNotesURLFromLabels derives a release notes link from OCI labels: the
+// image URL when set, otherwise the commit of the source revision for GitHub
+// and GitLab sources. Anything but an http(s) URL yields "".
+func ReleaseNotesURLFromLabels(labels map[string]string) string {
+	if link := httpURL(labels[LabelImageURL]); link != "" {
+		return link
+	}
+
+	source := httpURL(labels[LabelImageSource])
+	revision := labels[LabelImageRevision]
+	if source == "" || revision == "" {
+		return ""
+	}
+
+	source = strings.TrimSuffix(strings.TrimSuffix(source, "/"), ".git")
+	switch {
+	case strings.Contains(source, "://github.com/"):
+		return source + "/commit/" + url.PathEscape(revision)
+	case strings.Contains(source, "://gitlab.com/"):
+		return source + "/-/commit/" + url.PathEscape(revision)
+	}
+	return ""
+}
+
+func httpURL(raw string) string {
+	u, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return ""
+	}
+	return u.String()
+}
@@ -0,0 +1,457 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// manifestAccept lists the manifest media types a v2 client accepts, so the
+// registry returns the digest the Docker daemon would pull
+var manifestAccept = strings.Join([]string{
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.oci.image.manifest.v1+json",
+}, ", ")
+
+// v2Client talks the plain Docker Registry HTTP API v2, which GHCR, GCR,
+// ECR, ACR and self-hosted registries all serve. Bearer token challenges are
+// answered with the configured credentials.
+type v2Client struct {
+	host       string
+	baseURL    string
+	httpClient *http.Client
+	auth       *AuthConfig
+
+	tokensMu sync.Mutex
+	tokens   map[string]string // bearer tokens by scope
+}
+
+// NewV2Client creates a client for the registry API v2 at registryURL,
+// which may be a host or a URL. auth may be nil for anonymous access.
+func NewV2Client(registryURL string, auth *AuthConfig) Client {
+	baseURL := strings.TrimSuffix(registryURL, "/")
+	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
+		baseURL = "https://" + baseURL
+	}
+	if u, err := url.Parse(baseURL); err == nil {
+		baseURL = u.Scheme + "://" + u.Host
+	}
+
+	host := RegistryHost(registryURL)
+	if host == "docker.io" {
+		// Docker Hub serves the API from its own host, not its aliases
+		baseURL = "https://registry-1.docker.io"
+	}
+
+	return &v2Client{
+		host:       host,
+		baseURL:    baseURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		auth:       auth,
+		tokens:     make(map[string]string),
+	}
+}
+
+// RegistryHost normalizes a registry URL or host to the host credentials
+// are matched by, e.g. "https://GHCR.io/" to "ghcr.io". Docker Hub's
+// aliases all map to "docker.io".
+func RegistryHost(registryURL string) string {
+	host := strings.ToLower(strings.TrimSpace(registryURL))
+	host = strings.TrimPrefix(strings.TrimPrefix(host, "https://"), "http://")
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	switch host {
+	case "", "docker.io", "index.docker.io", "registry-1.docker.io", "registry.hub.docker.com":
+		return "docker.io"
+	}
+	return host
+}
+
+// ImageRegistryHost returns the registry host of an image reference
+func ImageRegistryHost(image string) string {
+	if i := strings.Index(image, "/"); i > 0 {
+		first := image[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" {
+			return RegistryHost(first)
+		}
+	}
+	return "docker.io"
+}
+
+// ImageRepository returns the repository of an image reference within its
+// registry, e.g. "library/nginx" for "nginx:1.25" and "team/app" for
+// "ghcr.io/team/app"
+func ImageRepository(image string) string {
+	repository := model.NormalizeRepository(image)
+	if ImageRegistryHost(repository) != "docker.io" {
+		if i := strings.Index(repository, "/"); i > 0 {
+			repository = repository[i+1:]
+		}
+	}
+	return repository
+}
+
+// TestConnection checks that the registry answers /v2/ with the credentials
+func (c *v2Client) TestConnection(ctx context.Context) error {
+	resp, err := c.do(ctx, http.MethodGet, "/v2/", "", "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return fmt.Errorf("registry %s rejected the credentials (status %d)", c.host, resp.StatusCode)
+	default:
+		return fmt.Errorf("registry %s returned status %d", c.host, resp.StatusCode)
+	}
+}
+
+// GetRegistryInfo reports whether the registry is reachable
+func (c *v2Client) GetRegistryInfo(ctx context.Context) (*RegistryInfo, error) {
+	err := c.TestConnection(ctx)
+	info := &RegistryInfo{
+		Name:        c.host,
+		URL:         c.baseURL,
+		Type:        "generic",
+		Available:   err == nil,
+		Features:    []string{"pull", "tags"},
+		LastChecked: time.Now(),
+	}
+	return info, err
+}
+
+// CheckImageUpdate compares the digest the tag resolves to with currentDigest
+func (c *v2Client) CheckImageUpdate(ctx context.Context, image, currentDigest string) (*UpdateCheckResult, error) {
+	repository, tag := c.splitImage(image)
+
+	manifest, err := c.GetImageManifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &UpdateCheckResult{
+		Repository:      repository,
+		CurrentTag:      tag,
+		CurrentDigest:   currentDigest,
+		LatestTag:       tag,
+		LatestDigest:    manifest.Digest,
+		UpdateAvailable: currentDigest != "" && manifest.Digest != currentDigest,
+		LastChecked:     time.Now(),
+	}, nil
+}
+
+// GetLatestImageInfo returns the digest the image's tag resolves to
+func (c *v2Client) GetLatestImageInfo(ctx context.Context, image string) (*model.ImageVersion, error) {
+	repository, tag := c.splitImage(image)
+
+	manifest, err := c.GetImageManifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ImageVersion{
+		ImageName:   c.host + "/" + repository,
+		Tag:         tag,
+		Digest:      manifest.Digest,
+		SizeBytes:   manifest.Size,
+		RegistryURL: c.host,
+		CheckedAt:   time.Now(),
+		IsLatest:    true,
+	}, nil
+}
+
+// GetImageTags lists the tags of a repository, following the registry's
+// Link pagination until Limit is met, or to the end without one. The tag
+// list carries names only.
+func (c *v2Client) GetImageTags(ctx context.Context, repository string, options *TagListOptions) ([]*ImageTag, error) {
+	limit := 0
+	path := "/v2/" + repository + "/tags/list"
+	if options != nil && options.Limit > 0 {
+		limit = options.Limit
+		path += "?n=" + strconv.Itoa(options.Limit)
+	}
+
+	var tags []*ImageTag
+	for path != "" {
+		names, next, err := c.getTagPage(ctx, repository, path)
+		if err != nil {
+			return nil, err
+		}
+		for _, name := range names {
+			tags = append(tags, &ImageTag{Name: name})
+		}
+		if limit > 0 && len(tags) >= limit {
+			return tags[:limit], nil
+		}
+		path = next
+	}
+	return tags, nil
+}
+
+// getTagPage fetches one page of a tag list, returning the path of the next
+// page from the Link header, empty on the last
+func (c *v2Client) getTagPage(ctx context.Context, repository, path string) ([]string, string, error) {
+	resp, err := c.do(ctx, http.MethodGet, path, "repository:"+repository+":pull", "")
+	if err != nil {
+		return nil, "", err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return nil, "", fmt.Errorf("%w: registry %s", ErrTagListingUnsupported, c.host)
+	case http.StatusNotFound:
+		var body struct {
+			Errors []struct {
+				Code string `json:"code"`
+			} `json:"errors"`
+		}
+		json.NewDecoder(resp.Body).Decode(&body)
+		if len(body.Errors) > 0 && body.Errors[0].Code == "UNSUPPORTED" {
+			return nil, "", fmt.Errorf("%w: registry %s", ErrTagListingUnsupported, c.host)
+		}
+		return nil, "", NewRegistryError(ErrorCodeImageNotFound, fmt.Sprintf("repository %s not found in registry %s", repository, c.host))
+	default:
+		return nil, "", fmt.Errorf("registry %s returned status %d listing tags of %s", c.host, resp.StatusCode, repository)
+	}
+
+	var list struct {
+		Tags []string `json:"tags"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return nil, "", fmt.Errorf("failed to decode tag list: %w", err)
+	}
+	return list.Tags, nextLinkPath(resp.Header.Get("Link")), nil
+}
+
+// GetImageManifest resolves a tag to its manifest digest
+func (c *v2Client) GetImageManifest(ctx context.Context, repository, tag string) (*ImageManifest, error) {
+	resp, err := c.do(ctx, http.MethodHead, "/v2/"+repository+"/manifests/"+tag, "repository:"+repository+":pull", manifestAccept)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, NewRegistryError(ErrorCodeTagNotFound, fmt.Sprintf("tag %s not found in %s", tag, repository))
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("registry %s returned status %d for %s:%s", c.host, resp.StatusCode, repository, tag)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return nil, fmt.Errorf("registry %s returned no digest for %s:%s", c.host, repository, tag)
+	}
+
+	manifest := &ImageManifest{
+		Digest:    digest,
+		MediaType: resp.Header.Get("Content-Type"),
+	}
+	if resp.ContentLength > 0 {
+		manifest.Size = resp.ContentLength
+	}
+	return manifest, nil
+}
+
+// SearchRepositories is not part of the registry API v2
+func (c *v2Client) SearchRepositories(ctx context.Context, options *SearchOptions) ([]*RepositorySearchResult, error) {
+	return nil, fmt.Errorf("search is not supported by registry %s", c.host)
+}
+
+// GetRepositoryInfo is not part of the registry API v2
+func (c *v2Client) GetRepositoryInfo(ctx context.Context, repository string) (*RepositoryInfo, error) {
+	return nil, fmt.Errorf("repository info is not supported by registry %s", c.host)
+}
+
+// GetSecurityScanResult is not part of the registry API v2
+func (c *v2Client) GetSecurityScanResult(ctx context.Context, repository, tag string) (*ScanResult, error) {
+	return nil, fmt.Errorf("security scans are not supported by registry %s", c.host)
+}
+
+// Close closes the client connection
+func (c *v2Client) Close() error {
+	return nil
+}
+
+// splitImage splits an image reference on this registry into repository
+// and tag
+func (c *v2Client) splitImage(image string) (repository, tag string) {
+	repository, tag, _ = model.ParseImageReference(image)
+	if i := strings.Index(repository, "/"); i > 0 && RegistryHost(repository[:i]) == c.host {
+		repository = repository[i+1:]
+	}
+	if tag == "" {
+		tag = "latest"
+	}
+	return repository, tag
+}
+
+// do sends a request, answering a bearer token challenge once
+func (c *v2Client) do(ctx context.Context, method, path, scope, accept string) (*http.Response, error) {
+	send := func(token string) (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		switch {
+		case token != "":
+			req.Header.Set("Authorization", "Bearer "+token)
+		case c.auth != nil && c.auth.AuthType == "token" && c.auth.Token != "":
+			req.Header.Set("Authorization", "Bearer "+c.auth.Token)
+		case c.auth != nil && c.auth.Username != "":
+			req.SetBasicAuth(c.auth.Username, c.auth.Password)
+		}
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("failed to reach registry %s: %w", c.host, err)
+		}
+		return resp, nil
+	}
+
+	c.tokensMu.Lock()
+	token := c.tokens[scope]
+	c.tokensMu.Unlock()
+
+	resp, err := send(token)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+
+	challenge := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	if challenge == nil {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if challenge["scope"] == "" {
+		challenge["scope"] = scope
+	}
+	token, err = c.fetchToken(ctx, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	c.tokensMu.Lock()
+	c.tokens[scope] = token
+	c.tokensMu.Unlock()
+
+	return send(token)
+}
+
+// fetchToken gets a bearer token from the challenge's realm, authenticating
+// with the username and password or token when configured
+func (c *v2Client) fetchToken(ctx context.Context, challenge map[string]string) (string, error) {
+	realm, err := url.Parse(challenge["realm"])
+	if err != nil || realm.Scheme == "" {
+		return "", fmt.Errorf("registry %s sent an invalid token realm", c.host)
+	}
+	query := realm.Query()
+	for _, key := range []string{"service", "scope"} {
+		if challenge[key] != "" {
+			query.Set(key, challenge[key])
+		}
+	}
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create token request: %w", err)
+	}
+	if c.auth != nil && c.auth.Username != "" {
+		secret := c.auth.Password
+		if secret == "" {
+			secret = c.auth.Token
+		}
+		req.SetBasicAuth(c.auth.Username, secret)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to reach token service of registry %s: %w", c.host, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		io.Copy(io.Discard, resp.Body)
+		return "", fmt.Errorf("registry %s rejected the credentials (token service status %d)", c.host, resp.StatusCode)
+	}
+
+	var body struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("failed to decode token response: %w", err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	if body.AccessToken != "" {
+		return body.AccessToken, nil
+	}
+	return "", fmt.Errorf("token service of registry %s returned no token", c.host)
+}
+
+// nextLinkPath returns the path and query of a Link header of the form
+// </v2/name/tags/list?n=100&last=x>; rel="next", empty without one
+func nextLinkPath(header string) string {
+	target, params, found := strings.Cut(header, ";")
+	if !found || !strings.Contains(params, `rel="next"`) {
+		return ""
+	}
+	target = strings.Trim(strings.TrimSpace(target), "<>")
+	u, err := url.Parse(target)
+	if err != nil || u.Path == "" {
+		return ""
+	}
+	return u.RequestURI()
+}
+
+// parseBearerChallenge parses a WWW-Authenticate header of the form
+// Bearer realm="...",service="...",scope="...", nil for other schemes
+func parseBearerChallenge(header string) map[string]string {
+	scheme, params, found := strings.Cut(header, " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return nil
+	}
+
+	challenge := make(map[string]string)
+	for params != "" {
+		var key, value string
+		key, params, _ = strings.Cut(strings.TrimLeft(params, ", "), "=")
+		if strings.HasPrefix(params, `"`) {
+			end := strings.Index(params[1:], `"`)
+			if end < 0 {
+				return nil
+			}
+			value, params = params[1:end+1], params[end+2:]
+		} else {
+			value, params, _ = strings.Cut(params, ",")
+		}
+		challenge[strings.ToLower(strings.TrimSpace(key))] = value
+	}
+	if challenge["realm"] == "" {
+		return nil
+	}
+	return challenge
+}
@@ -0,0 +1,310 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// Clock is a wall clock time of day
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+// ParseClock parses a time of day in HH:MM format
+func ParseClock(value string) (Clock, error) {
+	parsed, err := time.Parse("15:04", value)
+	if err != nil {
+		return Clock{}, fmt.Errorf("invalid time of day %q", value)
+	}
+	return Clock{Hour: parsed.Hour(), Minute: parsed.Minute()}, nil
+}
+
+// String returns the clock in HH:MM format
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+func (c Clock) minutes() int {
+	return c.Hour*60 + c.Minute
+}
+
+// Interval is a half-open range of instants [Start, End)
+type Interval struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// Contains reports whether t falls within the interval
+func (i Interval) Contains(t time.Time) bool {
+	return !t.Before(i.Start) && t.Before(i.End)
+}
+
+// Duration returns the length of the interval
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// Window is a recurring time range defined in wall clock time of a location.
+//
+// An occurrence opens at Start on each of Days and closes at End. When End is
+// not after Start the occurrence runs overnight and closes on the following
+// day; Start equal to End spans a full day. Days are the days an occurrence
+// opens, so a Friday 22:00-02:00 window is open early on Saturday but not early
+// on Friday.
+//
+// Wall clock times that do not exist because of a DST spring-forward resolve to
+// the instant the clocks jump, so a 02:30 start opens at 03:00 that night and a
+// window lying entirely in the gap does not open at all. Wall clock times that
+// occur twice because of a fall-back resolve to the earlier instant for Start
+// and the later one for End, so the window never shrinks, except that a
+// full-day window closes at the earlier instant where the next day's opens.
+type Window struct {
+	Days     []time.Weekday
+	Start    Clock
+	End      Clock
+	Location *time.Location
+}
+
+// NewWindow builds a window from HH:MM clock times, weekday numbers
+// (0=Sunday) and an IANA timezone. No days means every day and an empty
+// timezone means UTC.
+func NewWindow(days []int, start, end, timezone string) (*Window, error) {
+	startClock, err := ParseClock(start)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window start: %w", err)
+	}
+	endClock, err := ParseClock(end)
+	if err != nil {
+		return nil, fmt.Errorf("invalid window end: %w", err)
+	}
+
+	location := time.UTC
+	if timezone != "" {
+		location, err = time.LoadLocation(timezone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid window timezone %q", timezone)
+		}
+	}
+
+	weekdays := make([]time.Weekday, 0, len(days))
+	for _, day := range days {
+		if day < 0 || day > 6 {
+			return nil, fmt.Errorf("invalid window day %d", day)
+		}
+		weekdays = append(weekdays, time.Weekday(day))
+	}
+
+	return &Window{
+		Days:     weekdays,
+		Start:    startClock,
+		End:      endClock,
+		Location: location,
+	}, nil
+}
+
+// IsOpen reports whether the window is open at t
+func (w *Window) IsOpen(t time.Time) bool {
+	_, ok := w.Containing(t)
+	return ok
+}
+
+// Containing returns the occurrence of the window that is open at t
+func (w *Window) Containing(t time.Time) (Interval, bool) {
+	// An occurrence lasts at most a day plus a DST shift, so only the ones
+	// opening on the local date of t or the day before can contain it
+	local := t.In(w.location())
+	for offset := -1; offset <= 0; offset++ {
+		interval, ok := w.occurrence(local.Year(), local.Month(), local.Day()+offset)
+		if ok && interval.Contains(t) {
+			return interval, true
+		}
+	}
+	return Interval{}, false
+}
+
+// NextOpen returns the occurrence open at t, or else the first one opening
+// after t. It returns false only when the window never opens.
+func (w *Window) NextOpen(t time.Time) (Interval, bool) {
+	local := t.In(w.location())
+	// Every weekday is covered within eight days of the day before t
+	for offset := -1; offset <= 8; offset++ {
+		interval, ok := w.occurrence(local.Year(), local.Month(), local.Day()+offset)
+		if ok && interval.End.After(t) {
+			return interval, true
+		}
+	}
+	return Interval{}, false
+}
+
+// occurrence returns the occurrence opening on the given local date, if the
+// window opens that day
+func (w *Window) occurrence(year int, month time.Month, day int) (Interval, bool) {
+	location := w.location()
+
+	// Normalize the date before checking the weekday
+	date := time.Date(year, month, day, 12, 0, 0, 0, time.UTC)
+	if !w.opensOn(date.Weekday()) {
+		return Interval{}, false
+	}
+
+	endDate := date
+	if w.End.minutes() <= w.Start.minutes() {
+		endDate = date.AddDate(0, 0, 1)
+	}
+
+	// A full-day window closes where the next occurrence opens, so both ends
+	// resolve alike to keep occurrences from overlapping
+	start := resolveWallClock(date, w.Start, location, false)
+	end := resolveWallClock(endDate, w.End, location, w.End != w.Start)
+	if !end.After(start) {
+		// The whole occurrence falls in a DST gap
+		return Interval{}, false
+	}
+
+	return Interval{Start: start, End: end}, true
+}
+
+func (w *Window) opensOn(weekday time.Weekday) bool {
+	if len(w.Days) == 0 {
+		return true
+	}
+	for _, day := range w.Days {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *Window) location() *time.Location {
+	if w.Location == nil {
+		return time.UTC
+	}
+	return w.Location
+}
+
+// resolveWallClock returns the instant at which clock is shown on date in
+// location. A time skipped by a DST gap resolves to the end of the gap; a time
+// repeated by a DST overlap resolves to its later occurrence if late is set and
+// its earlier one otherwise.
+func resolveWallClock(date time.Time, clock Clock, location *time.Location, late bool) time.Time {
+	year, month, day := date.Date()
+	t := time.Date(year, month, day, clock.Hour, clock.Minute, 0, 0, location)
+
+	if !showsWallClock(t, date, clock, location) {
+		// Skipped by a gap; time.Date applied the offset from one side of the
+		// transition, so the transition is the nearest zone bound
+		zoneStart, zoneEnd := t.ZoneBounds()
+		if wallClock(t.In(location)).After(time.Date(year, month, day, clock.Hour, clock.Minute, 0, 0, time.UTC)) {
+			return zoneStart
+		}
+		return zoneEnd
+	}
+
+	_, offset := t.Zone()
+	zoneStart, zoneEnd := t.ZoneBounds()
+	if late && !zoneEnd.IsZero() {
+		_, nextOffset := zoneEnd.Zone()
+		alt := t.Add(time.Duration(offset-nextOffset) * time.Second)
+		if !alt.Before(zoneEnd) && showsWallClock(alt, date, clock, location) {
+			return alt
+		}
+	}
+	if !late && !zoneStart.IsZero() {
+		_, prevOffset := zoneStart.Add(-time.Second).Zone()
+		alt := t.Add(time.Duration(offset-prevOffset) * time.Second)
+		if alt.Before(zoneStart) && showsWallClock(alt, date, clock, location) {
+			return alt
+		}
+	}
+
+	return t
+}
+
+// wallClock returns the wall clock reading of t as a UTC time, for comparing
+// readings across offsets
+func wallClock(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+}
+
+func showsWallClock(t, date time.Time, clock Clock, location *time.Location) bool {
+	local := t.In(location)
+	year, month, day := date.Date()
+	localYear, localMonth, localDay := local.Date()
+	return localYear == year && localMonth == month && localDay == day &&
+		local.Hour() == clock.Hour && local.Minute() == clock.Minute
+}
+
+// Windows is a set of windows, open whenever any of them is
+type Windows []*Window
+
+// IsOpen reports whether any window is open at t
+func (ws Windows) IsOpen(t time.Time) bool {
+	_, ok := ws.Containing(t)
+	return ok
+}
+
+// Containing returns the open occurrence at t that closes last
+func (ws Windows) Containing(t time.Time) (Interval, bool) {
+	var best Interval
+	found := false
+	for _, w := range ws {
+		interval, ok := w.Containing(t)
+		if ok && (!found || interval.End.After(best.End)) {
+			best = interval
+			found = true
+		}
+	}
+	return best, found
+}
+
+// NextOpen returns the occurrence open at t that closes last, or else the
+// earliest occurrence opening after t
+func (ws Windows) NextOpen(t time.Time) (Interval, bool) {
+	if interval, ok := ws.Containing(t); ok {
+		return interval, true
+	}
+
+	var best Interval
+	found := false
+	for _, w := range ws {
+		interval, ok := w.NextOpen(t)
+		if ok && (!found || interval.Start.Before(best.Start)) {
+			best = interval
+			found = true
+		}
+	}
+	return best, found
+}
+
+// Overlapping returns the indexes of the first two windows whose occurrences
+// overlap during the week after t
+func (ws Windows) Overlapping(t time.Time) (int, int, bool) {
+	end := t.AddDate(0, 0, 7)
+	occurrences := make([][]Interval, len(ws))
+	for i, w := range ws {
+		for from := t; from.Before(end); {
+			interval, ok := w.NextOpen(from)
+			if !ok || !interval.Start.Before(end) {
+				break
+			}
+			occurrences[i] = append(occurrences[i], interval)
+			from = interval.End
+		}
+	}
+
+	for i := range occurrences {
+		for j := i + 1; j < len(occurrences); j++ {
+			for _, a := range occurrences[i] {
+				for _, b := range occurrences[j] {
+					if a.Start.Before(b.End) && b.Start.Before(a.End) {
+						return i, j, true
+					}
+				}
+			}
+		}
+	}
+	return 0, 0, false
+}
@@ -0,0 +1,380 @@
+package schedule
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func mustWindow(t *testing.T, days []int, start, end, timezone string) *Window {
+	t.Helper()
+	w, err := NewWindow(days, start, end, timezone)
+	if err != nil {
+		t.Fatalf("NewWindow(%v, %q, %q, %q): %v", days, start, end, timezone, err)
+	}
+	return w
+}
+
+func mustLocation(t *testing.T, name string) *time.Location {
+	t.Helper()
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		t.Skipf("timezone %s unavailable: %v", name, err)
+	}
+	return location
+}
+
+func TestWindowIsOpenAcrossMidnight(t *testing.T) {
+	// Friday 22:00 to Saturday 02:00 UTC
+	w := mustWindow(t, []int{5}, "22:00", "02:00", "")
+
+	tests := []struct {
+		name string
+		at   time.Time
+		open bool
+	}{
+		{"friday before opening", time.Date(2024, 3, 8, 21, 59, 0, 0, time.UTC), false},
+		{"friday at opening", time.Date(2024, 3, 8, 22, 0, 0, 0, time.UTC), true},
+		{"friday before midnight", time.Date(2024, 3, 8, 23, 59, 0, 0, time.UTC), true},
+		{"saturday after midnight", time.Date(2024, 3, 9, 1, 0, 0, 0, time.UTC), true},
+		{"saturday at closing", time.Date(2024, 3, 9, 2, 0, 0, 0, time.UTC), false},
+		{"friday early morning", time.Date(2024, 3, 8, 1, 0, 0, 0, time.UTC), false},
+		{"saturday night", time.Date(2024, 3, 9, 23, 0, 0, 0, time.UTC), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := w.IsOpen(tt.at); got != tt.open {
+				t.Errorf("IsOpen(%s) = %v, want %v", tt.at, got, tt.open)
+			}
+		})
+	}
+}
+
+func TestWindowTimezone(t *testing.T) {
+	tokyo := mustLocation(t, "Asia/Tokyo")
+	// Monday 01:00-03:00 in Tokyo is Sunday 16:00-18:00 UTC
+	w := mustWindow(t, []int{1}, "01:00", "03:00", "Asia/Tokyo")
+
+	if !w.IsOpen(time.Date(2024, 3, 10, 17, 0, 0, 0, time.UTC)) {
+		t.Error("window closed on Sunday 17:00 UTC, want open")
+	}
+	if w.IsOpen(time.Date(2024, 3, 11, 1, 30, 0, 0, time.UTC)) {
+		t.Error("window open on Monday 01:30 UTC, want closed")
+	}
+
+	next, ok := w.NextOpen(time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC))
+	if !ok {
+		t.Fatal("NextOpen found no occurrence")
+	}
+	want := time.Date(2024, 3, 11, 1, 0, 0, 0, tokyo)
+	if !next.Start.Equal(want) {
+		t.Errorf("NextOpen start = %s, want %s", next.Start, want)
+	}
+}
+
+func TestWindowAcrossMidnightInTimezone(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+	// Saturday 23:00 to Sunday 01:00 in New York
+	w := mustWindow(t, []int{6}, "23:00", "01:00", "America/New_York")
+
+	if !w.IsOpen(time.Date(2024, 1, 7, 0, 30, 0, 0, newYork)) {
+		t.Error("window closed on Sunday 00:30 local, want open")
+	}
+	// Sunday 00:30 UTC is still Saturday evening in New York
+	if w.IsOpen(time.Date(2024, 1, 7, 0, 30, 0, 0, time.UTC)) {
+		t.Error("window open on Saturday 19:30 local, want closed")
+	}
+}
+
+func TestWindowDaylightSaving(t *testing.T) {
+	newYork := mustLocation(t, "America/New_York")
+
+	tests := []struct {
+		name      string
+		start     string
+		end       string
+		day       time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{
+			// Clocks jump from 02:00 to 03:00 on 10 March 2024
+			name:      "start in spring-forward gap",
+			start:     "02:30",
+			end:       "04:00",
+			day:       time.Date(2024, 3, 10, 0, 0, 0, 0, newYork),
+			wantStart: time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 3, 10, 8, 0, 0, 0, time.UTC),
+		},
+		{
+			// Clocks fall back from 02:00 to 01:00 on 3 November 2024
+			name:      "end in fall-back overlap",
+			start:     "00:00",
+			end:       "01:30",
+			day:       time.Date(2024, 11, 3, 0, 0, 0, 0, newYork),
+			wantStart: time.Date(2024, 11, 3, 4, 0, 0, 0, time.UTC),
+			wantEnd:   time.Date(2024, 11, 3, 6, 30, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := mustWindow(t, nil, tt.start, tt.end, "America/New_York")
+			next, ok := w.NextOpen(tt.day)
+			if !ok {
+				t.Fatal("NextOpen found no occurrence")
+			}
+			if !next.Start.Equal(tt.wantStart) || !next.End.Equal(tt.wantEnd) {
+				t.Errorf("occurrence = [%s, %s), want [%s, %s)",
+					next.Start.UTC(), next.End.UTC(), tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+
+	// A window entirely within the gap does not open that night
+	gap := mustWindow(t, []int{0}, "02:10", "02:50", "America/New_York")
+	next, ok := gap.NextOpen(time.Date(2024, 3, 10, 0, 0, 0, 0, newYork))
+	if !ok {
+		t.Fatal("NextOpen found no occurrence")
+	}
+	if want := time.Date(2024, 3, 17, 2, 10, 0, 0, newYork); !next.Start.Equal(want) {
+		t.Errorf("NextOpen start = %s, want %s", next.Start, want)
+	}
+}
+
+func TestWindowDSTTransitions(t *testing.T) {
+	utc := func(month time.Month, day, hour, minute int) time.Time {
+		return time.Date(2024, month, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	// Berlin springs forward from 02:00 CET to 03:00 CEST on 31 March 2024
+	// and falls back from 03:00 CEST to 02:00 CET on 27 October 2024. New
+	// York springs forward from 02:00 EST to 03:00 EDT on 10 March 2024 and
+	// falls back from 02:00 EDT to 01:00 EST on 3 November 2024.
+	tests := []struct {
+		name      string
+		timezone  string
+		days      []int
+		start     string
+		end       string
+		from      time.Time
+		wantStart time.Time
+		wantEnd   time.Time
+	}{
+		{"berlin start in gap", "Europe/Berlin", nil, "02:30", "04:00", utc(3, 30, 23, 0), utc(3, 31, 1, 0), utc(3, 31, 2, 0)},
+		{"berlin end in gap", "Europe/Berlin", nil, "01:00", "02:30", utc(3, 30, 23, 0), utc(3, 31, 0, 0), utc(3, 31, 1, 0)},
+		{"berlin overnight across gap", "Europe/Berlin", []int{6}, "22:00", "04:00", utc(3, 30, 12, 0), utc(3, 30, 21, 0), utc(3, 31, 2, 0)},
+		{"berlin full day across gap", "Europe/Berlin", []int{0}, "02:30", "02:30", utc(3, 30, 23, 0), utc(3, 31, 1, 0), utc(4, 1, 0, 30)},
+		{"berlin start in overlap", "Europe/Berlin", nil, "02:30", "04:00", utc(10, 26, 22, 0), utc(10, 27, 0, 30), utc(10, 27, 3, 0)},
+		{"berlin end in overlap", "Europe/Berlin", nil, "01:00", "02:30", utc(10, 26, 22, 0), utc(10, 26, 23, 0), utc(10, 27, 1, 30)},
+		{"berlin within overlap", "Europe/Berlin", nil, "02:10", "02:50", utc(10, 26, 22, 0), utc(10, 27, 0, 10), utc(10, 27, 1, 50)},
+		{"berlin overnight across overlap", "Europe/Berlin", []int{6}, "22:00", "04:00", utc(10, 26, 12, 0), utc(10, 26, 20, 0), utc(10, 27, 3, 0)},
+		{"berlin full day across overlap", "Europe/Berlin", []int{0}, "02:30", "02:30", utc(10, 26, 22, 0), utc(10, 27, 0, 30), utc(10, 28, 1, 30)},
+		{"berlin full day before overlap", "Europe/Berlin", []int{6}, "02:30", "02:30", utc(10, 25, 22, 0), utc(10, 26, 0, 30), utc(10, 27, 0, 30)},
+		{"new york start in gap", "America/New_York", nil, "02:30", "04:00", utc(3, 10, 5, 0), utc(3, 10, 7, 0), utc(3, 10, 8, 0)},
+		{"new york end in gap", "America/New_York", nil, "01:00", "02:30", utc(3, 10, 5, 0), utc(3, 10, 6, 0), utc(3, 10, 7, 0)},
+		{"new york overnight across gap", "America/New_York", []int{6}, "23:00", "03:00", utc(3, 9, 17, 0), utc(3, 10, 4, 0), utc(3, 10, 7, 0)},
+		{"new york start in overlap", "America/New_York", nil, "01:30", "03:00", utc(11, 3, 4, 0), utc(11, 3, 5, 30), utc(11, 3, 8, 0)},
+		{"new york end in overlap", "America/New_York", nil, "00:00", "01:30", utc(11, 3, 4, 0), utc(11, 3, 4, 0), utc(11, 3, 6, 30)},
+		{"new york within overlap", "America/New_York", nil, "01:10", "01:50", utc(11, 3, 4, 0), utc(11, 3, 5, 10), utc(11, 3, 6, 50)},
+		{"new york overnight across overlap", "America/New_York", []int{6}, "22:00", "02:00", utc(11, 2, 16, 0), utc(11, 3, 2, 0), utc(11, 3, 7, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mustLocation(t, tt.timezone)
+			w := mustWindow(t, tt.days, tt.start, tt.end, tt.timezone)
+
+			next, ok := w.NextOpen(tt.from)
+			if !ok {
+				t.Fatal("NextOpen found no occurrence")
+			}
+			if !next.Start.Equal(tt.wantStart) || !next.End.Equal(tt.wantEnd) {
+				t.Fatalf("occurrence = [%s, %s), want [%s, %s)",
+					next.Start.UTC(), next.End.UTC(), tt.wantStart, tt.wantEnd)
+			}
+
+			// The interval bounds agree with IsOpen and Containing
+			if got, ok := w.Containing(next.Start); !ok || got != next {
+				t.Errorf("Containing(start) = %v, %v, want the occurrence", got, ok)
+			}
+			if !w.IsOpen(next.End.Add(-time.Minute)) {
+				t.Error("window closed a minute before the end, want open")
+			}
+			if w.IsOpen(next.Start.Add(-time.Minute)) {
+				t.Error("window open a minute before the start, want closed")
+			}
+			if w.IsOpen(next.End) {
+				t.Error("window open at the end, want closed")
+			}
+		})
+	}
+}
+
+// TestWindowOccurrenceProperties checks random windows across the DST
+// transitions of 2024: occurrences of a window never overlap one another,
+// open and close at their wall clock times or at the transition that skips
+// them, and IsOpen is true exactly within them
+func TestWindowOccurrenceProperties(t *testing.T) {
+	transitions := map[string][]time.Time{
+		"Europe/Berlin": {
+			time.Date(2024, 3, 31, 1, 0, 0, 0, time.UTC),
+			time.Date(2024, 10, 27, 1, 0, 0, 0, time.UTC),
+		},
+		"America/New_York": {
+			time.Date(2024, 3, 10, 7, 0, 0, 0, time.UTC),
+			time.Date(2024, 11, 3, 6, 0, 0, 0, time.UTC),
+		},
+	}
+	// Clock times near the transitions are picked more often
+	clocks := []string{"00:00", "00:30", "01:00", "01:30", "02:00", "02:30", "03:00", "03:30", "04:00", "12:00", "22:00", "23:30"}
+
+	rng := rand.New(rand.NewSource(437))
+	for timezone, instants := range transitions {
+		t.Run(timezone, func(t *testing.T) {
+			location := mustLocation(t, timezone)
+
+			for i := 0; i < 60; i++ {
+				var days []int
+				for day := 0; day < 7; day++ {
+					if rng.Intn(3) == 0 {
+						days = append(days, day)
+					}
+				}
+				w := mustWindow(t, days, clocks[rng.Intn(len(clocks))], clocks[rng.Intn(len(clocks))], timezone)
+
+				for _, transition := range instants {
+					checkWindowOccurrences(t, w, location, transition.AddDate(0, 0, -4), transition.AddDate(0, 0, 4))
+				}
+			}
+		})
+	}
+}
+
+func checkWindowOccurrences(t *testing.T, w *Window, location *time.Location, from, to time.Time) {
+	t.Helper()
+
+	var occurrences []Interval
+	for at := from; at.Before(to); {
+		next, ok := w.NextOpen(at)
+		if !ok || !next.Start.Before(to) {
+			break
+		}
+		if !next.End.After(at) || !next.Start.Before(next.End) || next.Duration() > 25*time.Hour {
+			t.Fatalf("%v: NextOpen(%s) = [%s, %s)", w, at, next.Start, next.End)
+		}
+		if len(occurrences) > 0 && next.Start.Before(occurrences[len(occurrences)-1].End) {
+			t.Fatalf("%v: occurrence [%s, %s) overlaps the one before", w, next.Start, next.End)
+		}
+		checkWindowBound(t, w, location, next.Start, w.Start)
+		checkWindowBound(t, w, location, next.End, w.End)
+		occurrences = append(occurrences, next)
+		at = next.End
+	}
+
+	for at := from.Add(7 * time.Minute); at.Before(to); at = at.Add(20 * time.Minute) {
+		var want *Interval
+		for i := range occurrences {
+			if occurrences[i].Contains(at) {
+				want = &occurrences[i]
+				break
+			}
+		}
+		got, ok := w.Containing(at)
+		if ok != (want != nil) || (ok && got != *want) {
+			t.Fatalf("%v: Containing(%s) = [%s, %s), %v, want %v", w, at, got.Start, got.End, ok, want)
+		}
+	}
+}
+
+// checkWindowBound checks that an occurrence bound shows its wall clock time
+// or, when a DST gap skips that time, is the transition itself
+func checkWindowBound(t *testing.T, w *Window, location *time.Location, bound time.Time, clock Clock) {
+	t.Helper()
+
+	local := bound.In(location)
+	if local.Hour() == clock.Hour && local.Minute() == clock.Minute {
+		return
+	}
+	if zoneStart, _ := bound.ZoneBounds(); zoneStart.Equal(bound) {
+		return
+	}
+	t.Fatalf("%v: bound %s shows %s, want %s", w, bound, local.Format("15:04"), clock)
+}
+
+func TestWindowsOverlapping(t *testing.T) {
+	from := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		windows Windows
+		overlap bool
+		i, j    int
+	}{
+		{
+			name: "disjoint days",
+			windows: Windows{
+				mustWindow(t, []int{1}, "02:00", "04:00", ""),
+				mustWindow(t, []int{2}, "02:00", "04:00", ""),
+			},
+		},
+		{
+			name: "adjacent",
+			windows: Windows{
+				mustWindow(t, []int{1}, "02:00", "04:00", ""),
+				mustWindow(t, []int{1}, "04:00", "06:00", ""),
+			},
+		},
+		{
+			name: "same day",
+			windows: Windows{
+				mustWindow(t, []int{1}, "02:00", "04:00", ""),
+				mustWindow(t, []int{3}, "12:00", "13:00", ""),
+				mustWindow(t, []int{1}, "03:00", "05:00", ""),
+			},
+			overlap: true,
+			i:       0,
+			j:       2,
+		},
+		{
+			name: "overnight into next day",
+			windows: Windows{
+				mustWindow(t, []int{5}, "22:00", "02:00", ""),
+				mustWindow(t, []int{6}, "01:00", "03:00", ""),
+			},
+			overlap: true,
+			i:       0,
+			j:       1,
+		},
+		{
+			name: "across timezones",
+			windows: Windows{
+				mustWindow(t, []int{1}, "10:00", "11:00", "UTC"),
+				mustWindow(t, []int{1}, "12:30", "13:30", "Europe/Paris"),
+			},
+		},
+		{
+			name: "same instant in different timezones",
+			windows: Windows{
+				mustWindow(t, []int{1}, "10:00", "11:00", "UTC"),
+				mustWindow(t, []int{1}, "19:30", "20:30", "Asia/Tokyo"),
+			},
+			overlap: true,
+			i:       0,
+			j:       1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			i, j, ok := tt.windows.Overlapping(from)
+			if ok != tt.overlap {
+				t.Fatalf("Overlapping = %v, want %v", ok, tt.overlap)
+			}
+			if ok && (i != tt.i || j != tt.j) {
+				t.Errorf("Overlapping = (%d, %d), want (%d, %d)", i, j, tt.i, tt.j)
+			}
+		})
+	}
+}
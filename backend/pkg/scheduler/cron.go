@@ -2,12 +2,15 @@ package scheduler
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"sync"
 	"time"
 
-	"docker-auto/internal/model"
 	"docker-auto/internal/repository"
+	apperrors "docker-auto/pkg/errors"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
 
 	"github.com/google/uuid"
 	"github.com/robfig/cron/v3"
@@ -47,7 +50,8 @@ type CronScheduler struct {
 	taskRepo      repository.ScheduledTaskRepository
 	executionRepo repository.TaskExecutionLogRepository
 	config        *SchedulerConfig
-	eventListener EventListener
+	limitsMu      sync.RWMutex // guards the limits SetLimits changes
+	events        *eventDispatcher
 	hooks         []TaskHook
 
 	// Internal state
@@ -55,10 +59,11 @@ type CronScheduler struct {
 	tasks            map[int]*scheduledTaskEntry
 	executions       map[string]*TaskExecution
 	cronEntries      map[int]cron.EntryID
+	activeRuns       int // runs not done saving their outcome; see Stop
 	mu               sync.RWMutex
 	cancelCtx        context.Context
 	cancelFunc       context.CancelFunc
-	workerPool       chan struct{}
+	workerPool       *workerPool
 	cleanupTicker    *time.Ticker
 	metrics          *SchedulerMetrics
 	startTime        time.Time
@@ -74,6 +79,30 @@ type scheduledTaskEntry struct {
 	errorCount int
 }
 
+// onceSchedule is the cron schedule of a one-shot task: it fires at its run
+// time and never again
+type onceSchedule struct {
+	at time.Time
+}
+
+// newOnceSchedule returns the schedule of a one-shot task to run at runAt.
+// A run time already passed by now is moved a second ahead, so the entry
+// still fires once cron has added it.
+func newOnceSchedule(runAt, now time.Time) onceSchedule {
+	if !runAt.After(now) {
+		runAt = now.Add(time.Second)
+	}
+	return onceSchedule{at: runAt}
+}
+
+// Next implements cron.Schedule; the zero time tells cron there is no run
+func (o onceSchedule) Next(t time.Time) time.Time {
+	if t.Before(o.at) {
+		return o.at
+	}
+	return time.Time{}
+}
+
 // NewCronScheduler creates a new cron-based scheduler
 func NewCronScheduler(
 	taskRegistry TaskRegistry,
@@ -90,6 +119,8 @@ func NewCronScheduler(
 			TaskTimeout:        30 * time.Minute,
 			RetryDelay:         5 * time.Minute,
 			MaxRetries:         3,
+			RetryBackoff:       2,
+			RetryMaxDelay:      1 * time.Hour,
 			CleanupInterval:    1 * time.Hour,
 			HistoryRetention:   24 * time.Hour,
 			LogLevel:           "info",
@@ -112,6 +143,11 @@ func NewCronScheduler(
 		cron.WithChain(cron.Recover(&cronLoggerWrapper{logger: logrus.StandardLogger()})),
 	)
 
+	var dispatcher *eventDispatcher
+	if eventListener != nil {
+		dispatcher = newEventDispatcher(eventListener, eventQueueSize)
+	}
+
 	return &CronScheduler{
 		cron:          cronScheduler,
 		taskRegistry:  taskRegistry,
@@ -119,12 +155,12 @@ func NewCronScheduler(
 		taskRepo:      taskRepo,
 		executionRepo: executionRepo,
 		config:        config,
-		eventListener: eventListener,
+		events:        dispatcher,
 		hooks:         hooks,
 		tasks:         make(map[int]*scheduledTaskEntry),
 		executions:    make(map[string]*TaskExecution),
 		cronEntries:   make(map[int]cron.EntryID),
-		workerPool:    make(chan struct{}, config.MaxConcurrentTasks),
+		workerPool:    newWorkerPool(config.MaxConcurrentTasks),
 		metrics: &SchedulerMetrics{
 			UptimeSeconds: 0,
 		},
@@ -144,6 +180,8 @@ func (s *CronScheduler) Start(ctx context.Context) error {
 	s.cancelCtx, s.cancelFunc = context.WithCancel(ctx)
 	s.startTime = time.Now()
 
+	s.recoverInterruptedExecutions(s.cancelCtx)
+
 	// Load existing tasks from database
 	if err := s.loadTasksFromDatabase(s.cancelCtx); err != nil {
 		return fmt.Errorf("failed to load tasks from database: %w", err)
@@ -169,12 +207,14 @@ func (s *CronScheduler) Start(ctx context.Context) error {
 	return nil
 }
 
-// Stop stops the scheduler gracefully
+// Stop stops the scheduler gracefully. Running tasks have their context
+// cancelled and are waited for until ctx ends; runs cut short are recorded as
+// interrupted.
 func (s *CronScheduler) Stop(ctx context.Context) error {
 	s.mu.Lock()
-	defer s.mu.Unlock()
 
 	if !s.isRunning {
+		s.mu.Unlock()
 		return fmt.Errorf("scheduler is not running")
 	}
 
@@ -194,6 +234,9 @@ func (s *CronScheduler) Stop(ctx context.Context) error {
 		s.cleanupTicker.Stop()
 	}
 
+	// Finishing runs need the lock to record their results
+	s.mu.Unlock()
+
 	// Wait for cron to stop
 	select {
 	case <-cronCtx.Done():
@@ -203,10 +246,13 @@ func (s *CronScheduler) Stop(ctx context.Context) error {
 	}
 
 	// Wait for running tasks to complete or timeout
-	s.waitForRunningTasks(ctx)
+	remaining := s.waitForRunningTasks(ctx)
 
 	s.publishEvent(EventSchedulerStopped, nil, "Scheduler stopped", nil)
 
+	if remaining > 0 {
+		return fmt.Errorf("%d task executions were still running", remaining)
+	}
 	logrus.Info("Cron scheduler stopped successfully")
 	return nil
 }
@@ -224,13 +270,16 @@ func (s *CronScheduler) AddTask(task *model.ScheduledTask) error {
 		return fmt.Errorf("task with ID %d already exists", task.ID)
 	}
 
-	// Validate cron expression
-	if err := task.ValidateCronExpression(); err != nil {
-		return fmt.Errorf("invalid cron expression: %w", err)
+	// Validate the schedule
+	if err := task.ValidateSchedule(); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
+	}
+	if task.IsOneShot() && task.CompletedAt != nil {
+		return fmt.Errorf("one-shot task %d already completed", task.ID)
 	}
 
 	// Add to cron scheduler
-	entryID, err := s.cron.AddFunc(task.CronExpression, s.createTaskRunner(task))
+	entryID, err := s.scheduleTask(task)
 	if err != nil {
 		return fmt.Errorf("failed to add task to cron: %w", err)
 	}
@@ -253,6 +302,7 @@ func (s *CronScheduler) AddTask(task *model.ScheduledTask) error {
 		"task_name": task.Name,
 		"task_type": task.Type,
 		"cron_expr": task.CronExpression,
+		"run_at":    task.RunAt,
 	}).Info("Task added to scheduler")
 
 	s.publishEvent(EventTaskAdded, &task.ID, fmt.Sprintf("Task '%s' added", task.Name), map[string]interface{}{
@@ -314,19 +364,19 @@ func (s *CronScheduler) UpdateTask(task *model.ScheduledTask) error {
 		return fmt.Errorf("task with ID %d not found", task.ID)
 	}
 
-	// Validate new cron expression
-	if err := task.ValidateCronExpression(); err != nil {
-		return fmt.Errorf("invalid cron expression: %w", err)
+	// Validate the new schedule
+	if err := task.ValidateSchedule(); err != nil {
+		return fmt.Errorf("invalid schedule: %w", err)
 	}
 
 	// Remove old entry
 	s.cron.Remove(entry.cronEntry)
 
 	// Add new entry with updated task
-	entryID, err := s.cron.AddFunc(task.CronExpression, s.createTaskRunner(task))
+	entryID, err := s.scheduleTask(task)
 	if err != nil {
 		// Re-add old entry on failure
-		oldEntryID, _ := s.cron.AddFunc(entry.task.CronExpression, s.createTaskRunner(entry.task))
+		oldEntryID, _ := s.scheduleTask(entry.task)
 		entry.cronEntry = oldEntryID
 		return fmt.Errorf("failed to update task in cron: %w", err)
 	}
@@ -352,6 +402,7 @@ func (s *CronScheduler) UpdateTask(task *model.ScheduledTask) error {
 		"task_name": task.Name,
 		"task_type": task.Type,
 		"cron_expr": task.CronExpression,
+		"run_at":    task.RunAt,
 	}).Info("Task updated in scheduler")
 
 	s.publishEvent(EventTaskUpdated, &task.ID, fmt.Sprintf("Task '%s' updated", task.Name), map[string]interface{}{
@@ -427,7 +478,7 @@ func (s *CronScheduler) TriggerTask(taskID int) error {
 	}
 
 	// Execute task immediately
-	go s.executeTask(entry.task)
+	go s.executeTask(entry.task, model.TriggerTypeManual)
 
 	logrus.WithFields(logrus.Fields{
 		"task_id":   taskID,
@@ -437,7 +488,38 @@ func (s *CronScheduler) TriggerTask(taskID int) error {
 	return nil
 }
 
-// GetRunningTasks returns currently running tasks
+// CancelExecution cancels a running execution of a task. The task stops at
+// its next check of the context and the run is recorded as cancelled.
+func (s *CronScheduler) CancelExecution(taskID int, executionID string) error {
+	s.mu.RLock()
+	execution, exists := s.executions[executionID]
+	if exists && execution.TaskID != taskID {
+		exists = false
+	}
+	var cancel context.CancelFunc
+	if exists && execution.CompletedAt == nil {
+		cancel = execution.CancelFunc
+	}
+	s.mu.RUnlock()
+
+	if !exists {
+		return apperrors.Newf(apperrors.CodeExecutionNotFound, "execution %s of task %d not found", executionID, taskID)
+	}
+	if cancel == nil {
+		return apperrors.Newf(apperrors.CodeExecutionNotRunning, "execution %s is not running", executionID)
+	}
+	cancel()
+
+	logrus.WithFields(logrus.Fields{
+		"execution_id": executionID,
+		"task_id":      taskID,
+	}).Info("Task execution cancellation requested")
+
+	return nil
+}
+
+// GetRunningTasks returns copies of the currently running executions. Their
+// cancel functions stay with the scheduler; see CancelExecution.
 func (s *CronScheduler) GetRunningTasks() []*TaskExecution {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -445,7 +527,9 @@ func (s *CronScheduler) GetRunningTasks() []*TaskExecution {
 	var running []*TaskExecution
 	for _, execution := range s.executions {
 		if execution.Status == model.ExecutionStatusRunning {
-			running = append(running, execution)
+			execCopy := *execution
+			execCopy.CancelFunc = nil
+			running = append(running, &execCopy)
 		}
 	}
 
@@ -503,6 +587,14 @@ func (s *CronScheduler) IsRunning() bool {
 	return s.isRunning
 }
 
+// scheduleTask adds the cron entry running the task on its schedule
+func (s *CronScheduler) scheduleTask(task *model.ScheduledTask) (cron.EntryID, error) {
+	if task.IsOneShot() {
+		return s.cron.Schedule(newOnceSchedule(*task.RunAt, time.Now()), cron.FuncJob(s.createTaskRunner(task))), nil
+	}
+	return s.cron.AddFunc(task.CronExpression, s.createTaskRunner(task))
+}
+
 // createTaskRunner creates a function that will be called by cron
 func (s *CronScheduler) createTaskRunner(task *model.ScheduledTask) func() {
 	return func() {
@@ -514,41 +606,67 @@ func (s *CronScheduler) createTaskRunner(task *model.ScheduledTask) func() {
 			return
 		}
 
-		s.executeTask(task)
+		s.executeTask(task, model.TriggerTypeSchedule)
 	}
 }
 
 // executeTask executes a task
-func (s *CronScheduler) executeTask(task *model.ScheduledTask) {
+func (s *CronScheduler) executeTask(task *model.ScheduledTask, triggeredBy model.TriggerType) {
 	// Acquire worker slot
-	select {
-	case s.workerPool <- struct{}{}:
-		defer func() { <-s.workerPool }()
-	case <-s.cancelCtx.Done():
+	if !s.workerPool.acquire(s.cancelCtx) {
 		return
 	}
+	defer s.workerPool.release()
 
 	executionID := uuid.New().String()
-	ctx, cancel := context.WithTimeout(s.cancelCtx, s.config.TaskTimeout)
+	ctx, cancel := context.WithTimeout(s.cancelCtx, s.taskTimeout())
 	defer cancel()
+	ctx, cancelExecution := withCancel(ctx)
+	defer cancelExecution()
 
 	// Create execution record
 	execution := &TaskExecution{
-		ID:         executionID,
-		TaskID:     task.ID,
-		TaskName:   task.Name,
-		TaskType:   task.Type,
-		Status:     model.ExecutionStatusRunning,
-		StartedAt:  time.Now(),
-		Progress:   0,
-		CancelFunc: cancel,
+		ID:          executionID,
+		TaskID:      task.ID,
+		TaskName:    task.Name,
+		TaskType:    task.Type,
+		Status:      model.ExecutionStatusRunning,
+		TriggeredBy: triggeredBy,
+		StartedAt:   time.Now(),
+		Progress:    0,
+		CancelFunc:  cancelExecution,
+	}
+
+	// Store execution, unless the task cannot run concurrently and its
+	// previous run is still in progress
+	exclusive := false
+	if taskImpl, err := s.taskRegistry.GetTask(task.Type); err == nil {
+		exclusive = !taskImpl.CanRunConcurrently()
 	}
-
-	// Store execution
 	s.mu.Lock()
+	if exclusive && s.hasActiveExecution(task.ID) {
+		s.mu.Unlock()
+		logrus.WithFields(logrus.Fields{
+			"task_id":   task.ID,
+			"task_name": task.Name,
+			"task_type": task.Type,
+		}).Warn("Skipping task run; the previous run is still in progress")
+		return
+	}
 	s.executions[executionID] = execution
 	s.metrics.RunningTasks++
+	s.activeRuns++
 	s.mu.Unlock()
+	metrics.TaskStarted()
+	defer func() {
+		s.mu.Lock()
+		s.activeRuns--
+		s.mu.Unlock()
+	}()
+
+	// The log is written as running first, so a run the process does not
+	// survive is found at the next start
+	logEntry := s.startExecutionLog(execution)
 
 	// Update task entry
 	s.mu.Lock()
@@ -573,6 +691,16 @@ func (s *CronScheduler) executeTask(task *model.ScheduledTask) {
 	// Execute task with hooks
 	result := s.executeTaskWithHooks(ctx, execution, task)
 
+	// A run the stopping scheduler cut short did not fail or get cancelled
+	// on request
+	if s.cancelCtx.Err() != nil && !IsCancelled(ctx) &&
+		result.Status != model.ExecutionStatusSuccess && result.Status != model.ExecutionStatusPartial {
+		result.Status = model.ExecutionStatusInterrupted
+		if result.Message == "" {
+			result.Message = "Interrupted by shutdown"
+		}
+	}
+
 	// Update execution
 	s.mu.Lock()
 	execution.CompletedAt = &result.CompletedAt
@@ -584,15 +712,23 @@ func (s *CronScheduler) executeTask(task *model.ScheduledTask) {
 	}
 	s.metrics.RunningTasks--
 	s.metrics.TotalExecutions++
-	if result.Status == model.ExecutionStatusSuccess {
+	failed := false
+	switch result.Status {
+	case model.ExecutionStatusSuccess:
 		s.metrics.SuccessfulExecutions++
-	} else {
+	case model.ExecutionStatusPartial:
+		s.metrics.PartialExecutions++
+	case model.ExecutionStatusCancelled, model.ExecutionStatusInterrupted:
+		s.metrics.CancelledExecutions++
+	default:
 		s.metrics.FailedExecutions++
+		failed = true
 	}
 	s.mu.Unlock()
+	metrics.TaskFinished(string(task.Type), string(result.Status), failed)
 
 	// Update task failure count
-	if result.Status != model.ExecutionStatusSuccess {
+	if failed {
 		s.mu.Lock()
 		if entry := s.tasks[task.ID]; entry != nil {
 			entry.errorCount++
@@ -600,8 +736,17 @@ func (s *CronScheduler) executeTask(task *model.ScheduledTask) {
 		s.mu.Unlock()
 	}
 
+	// Persist the run counters and next run. The scheduled run of a one-shot
+	// task is its only one.
+	var nextRunAt *time.Time
+	if task.IsOneShot() && triggeredBy == model.TriggerTypeSchedule {
+		s.completeOneShot(task, execution.StartedAt, failed)
+	} else {
+		nextRunAt = s.recordTaskRun(task, execution.StartedAt, failed)
+	}
+
 	// Save execution log to database
-	s.saveExecutionLog(execution, result)
+	s.saveExecutionLog(logEntry, execution, result)
 
 	// Remove from active executions after some time
 	go func() {
@@ -616,14 +761,29 @@ func (s *CronScheduler) executeTask(task *model.ScheduledTask) {
 		eventType = EventTaskFailed
 	} else if result.Status == model.ExecutionStatusTimeout {
 		eventType = EventTaskTimeout
+	} else if result.Status == model.ExecutionStatusCancelled || result.Status == model.ExecutionStatusInterrupted {
+		eventType = EventTaskCancelled
+	}
+
+	if result.RetryCount > 0 {
+		s.publishEvent(EventTaskRetried, &task.ID, fmt.Sprintf("Task '%s' retried %d times", task.Name, result.RetryCount), map[string]interface{}{
+			"execution_id": executionID,
+			"retries":      result.RetryCount,
+		})
 	}
 
 	s.publishEvent(eventType, &task.ID, fmt.Sprintf("Task '%s' %s", task.Name, result.Status), map[string]interface{}{
 		"execution_id": executionID,
 		"duration":     result.Duration.String(),
-		"success":      result.Status == model.ExecutionStatusSuccess,
+		"success":      !failed,
 	})
 
+	if nextRunAt != nil {
+		s.publishEvent(EventTaskScheduled, &task.ID, fmt.Sprintf("Task '%s' next runs at %s", task.Name, nextRunAt.Format(time.RFC3339)), map[string]interface{}{
+			"next_run_at": nextRunAt,
+		})
+	}
+
 	logrus.WithFields(logrus.Fields{
 		"execution_id": executionID,
 		"task_id":      task.ID,
@@ -635,6 +795,17 @@ func (s *CronScheduler) executeTask(task *model.ScheduledTask) {
 	}).Info("Task execution completed")
 }
 
+// hasActiveExecution reports whether the task has a run in progress. The
+// caller must hold s.mu.
+func (s *CronScheduler) hasActiveExecution(taskID int) bool {
+	for _, execution := range s.executions {
+		if execution.TaskID == taskID && execution.CompletedAt == nil {
+			return true
+		}
+	}
+	return false
+}
+
 // taskExecutionResult represents the result of task execution
 type taskExecutionResult struct {
 	TaskResult
@@ -691,14 +862,23 @@ func (s *CronScheduler) executeTaskWithHooks(ctx context.Context, execution *Tas
 			Error:   err,
 			Duration: time.Since(startTime),
 		}
-		if ctx.Err() == context.DeadlineExceeded {
+		if IsCancelled(ctx) {
+			result.TaskResult.Cancelled = true
+			result.TaskResult.Error = nil
+			result.Message = cancelledMessage(nil)
+			result.Status = model.ExecutionStatusCancelled
+		} else if ctx.Err() == context.DeadlineExceeded {
 			result.Status = model.ExecutionStatusTimeout
 		} else {
 			result.Status = model.ExecutionStatusFailed
 		}
 	} else {
 		result.TaskResult = *taskResult
-		if taskResult.Success {
+		if taskResult.Cancelled {
+			result.Status = model.ExecutionStatusCancelled
+		} else if taskResult.Partial {
+			result.Status = model.ExecutionStatusPartial
+		} else if taskResult.Success {
 			result.Status = model.ExecutionStatusSuccess
 		} else {
 			result.Status = model.ExecutionStatusFailed
@@ -726,42 +906,202 @@ func (s *CronScheduler) executeTaskWithHooks(ctx context.Context, execution *Tas
 	return result
 }
 
-// parseTaskParameters parses task parameters from JSON
+// taskRetryPolicy is the optional "retry" task parameter, overriding the
+// scheduler's retry settings for one task
+type taskRetryPolicy struct {
+	MaxRetries      *int    `json:"max_retries"`
+	DelaySeconds    int     `json:"delay_seconds"`
+	Backoff         float64 `json:"backoff"`
+	MaxDelaySeconds int     `json:"max_delay_seconds"`
+}
+
+// parseTaskParameters parses the target containers and parameters stored
+// with a task
 func (s *CronScheduler) parseTaskParameters(task *model.ScheduledTask) (*TaskParameters, error) {
-	// TODO: Implement parameter parsing based on task type
 	params := &TaskParameters{
-		TaskType: task.Type,
-		Timeout:  s.config.TaskTimeout,
-		MaxRetries: s.config.MaxRetries,
-		RetryDelay: s.config.RetryDelay,
+		TaskType:      task.Type,
+		Timeout:       s.taskTimeout(),
+		MaxRetries:    s.config.MaxRetries,
+		RetryDelay:    s.config.RetryDelay,
+		RetryBackoff:  s.config.RetryBackoff,
+		RetryMaxDelay: s.config.RetryMaxDelay,
 	}
 
-	// Parse target containers
-	// Implementation depends on how target containers are stored in the database
+	if task.TargetContainers != "" {
+		if err := json.Unmarshal([]byte(task.TargetContainers), &params.TargetContainers); err != nil {
+			return nil, fmt.Errorf("invalid target containers: %w", err)
+		}
+	}
+	if task.Parameters != "" {
+		if err := json.Unmarshal([]byte(task.Parameters), &params.Parameters); err != nil {
+			return nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
+	raw, ok := params.Parameters["retry"]
+	if !ok {
+		return params, nil
+	}
+	encoded, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid retry parameters: %w", err)
+	}
+	var retry taskRetryPolicy
+	if err := json.Unmarshal(encoded, &retry); err != nil {
+		return nil, fmt.Errorf("invalid retry parameters: %w", err)
+	}
+
+	if retry.MaxRetries != nil {
+		params.MaxRetries = *retry.MaxRetries
+		// 0 would mean the scheduler default to the executor
+		if params.MaxRetries == 0 {
+			params.MaxRetries = -1
+		}
+	}
+	if retry.DelaySeconds > 0 {
+		params.RetryDelay = time.Duration(retry.DelaySeconds) * time.Second
+	}
+	if retry.Backoff > 0 {
+		params.RetryBackoff = retry.Backoff
+	}
+	if retry.MaxDelaySeconds > 0 {
+		params.RetryMaxDelay = time.Duration(retry.MaxDelaySeconds) * time.Second
+	}
 
 	return params, nil
 }
 
-// saveExecutionLog saves task execution log to database
-func (s *CronScheduler) saveExecutionLog(execution *TaskExecution, result taskExecutionResult) {
+// recordTaskRun persists the bookkeeping of a finished run and returns the
+// next run. Only the counters and run times are written, so the task
+// definition may be edited while the task runs without either write losing
+// the other.
+func (s *CronScheduler) recordTaskRun(task *model.ScheduledTask, startedAt time.Time, failed bool) *time.Time {
+	if s.taskRepo == nil {
+		return nil
+	}
+
+	// The schedule may have been changed while the task ran
+	s.mu.RLock()
+	if entry := s.tasks[task.ID]; entry != nil {
+		task = entry.task
+	}
+	s.mu.RUnlock()
+
+	var nextRunAt *time.Time
+	if nextRun, err := task.NextRunAfter(time.Now()); err == nil {
+		nextRunAt = &nextRun
+	}
+
+	if err := s.taskRepo.RecordRun(context.Background(), int64(task.ID), startedAt, failed, nextRunAt); err != nil {
+		logrus.WithError(err).WithField("task_id", task.ID).Error("Failed to record task run")
+	}
+	return nextRunAt
+}
+
+// completeOneShot persists the run of a one-shot task, marking it completed
+// and inactive, and drops it from the scheduler
+func (s *CronScheduler) completeOneShot(task *model.ScheduledTask, startedAt time.Time, failed bool) {
+	if s.taskRepo != nil {
+		if err := s.taskRepo.CompleteRun(context.Background(), int64(task.ID), startedAt, failed); err != nil {
+			logrus.WithError(err).WithField("task_id", task.ID).Error("Failed to complete one-shot task")
+		}
+	}
+
+	s.mu.Lock()
+	if entry := s.tasks[task.ID]; entry != nil {
+		s.cron.Remove(entry.cronEntry)
+		delete(s.tasks, task.ID)
+		delete(s.cronEntries, task.ID)
+		s.metrics.TotalTasks--
+		if entry.task.IsActive {
+			s.metrics.ActiveTasks--
+		}
+	}
+	s.mu.Unlock()
+
+	logrus.WithFields(logrus.Fields{
+		"task_id":   task.ID,
+		"task_name": task.Name,
+		"failed":    failed,
+	}).Info("One-shot task completed")
+}
+
+// startExecutionLog records a run as running, returning its log entry, nil
+// when there is no repository
+func (s *CronScheduler) startExecutionLog(execution *TaskExecution) *model.TaskExecutionLog {
 	if s.executionRepo == nil {
-		return
+		return nil
 	}
 
 	logEntry := &model.TaskExecutionLog{
-		TaskID:          execution.TaskID,
-		Status:          result.Status,
-		Message:         result.Message,
-		DurationSeconds: int(result.Duration.Seconds()),
-		StartedAt:       execution.StartedAt,
-		CompletedAt:     &result.CompletedAt,
+		TaskID:      execution.TaskID,
+		Status:      model.ExecutionStatusRunning,
+		TriggeredBy: execution.TriggeredBy,
+		StartedAt:   execution.StartedAt,
 	}
-
 	if err := s.executionRepo.Create(context.Background(), logEntry); err != nil {
+		logrus.WithError(err).WithField("execution_id", execution.ID).Warn("Failed to record execution start")
+	}
+	return logEntry
+}
+
+// saveExecutionLog saves the outcome of a run to its log entry, creating the
+// entry when recording the start failed
+func (s *CronScheduler) saveExecutionLog(logEntry *model.TaskExecutionLog, execution *TaskExecution, result taskExecutionResult) {
+	if s.executionRepo == nil || logEntry == nil {
+		return
+	}
+
+	logEntry.Status = result.Status
+	logEntry.Message = result.Message
+	logEntry.DurationSeconds = int(result.Duration.Seconds())
+	logEntry.CompletedAt = &result.CompletedAt
+
+	var err error
+	if logEntry.ID == 0 {
+		err = s.executionRepo.Create(context.Background(), logEntry)
+	} else {
+		err = s.executionRepo.Update(context.Background(), logEntry)
+	}
+	if err != nil {
 		logrus.WithError(err).WithField("execution_id", execution.ID).Error("Failed to save execution log")
 	}
 }
 
+// recoverInterruptedExecutions marks the runs logged as running by a
+// previous process, which ended without recording their outcome, as
+// interrupted
+func (s *CronScheduler) recoverInterruptedExecutions(ctx context.Context) {
+	if s.executionRepo == nil {
+		return
+	}
+
+	logs, err := s.executionRepo.GetRunningExecutions(ctx)
+	if err != nil {
+		logrus.WithError(err).Warn("Failed to list interrupted task executions")
+		return
+	}
+
+	for _, logEntry := range logs {
+		if !logEntry.StartedAt.Before(s.startTime) {
+			continue
+		}
+		logEntry.MarkAsCompleted(model.ExecutionStatusInterrupted, "Interrupted: the server stopped before the run finished")
+		if err := s.executionRepo.Update(ctx, logEntry); err != nil {
+			logrus.WithError(err).WithField("execution_log_id", logEntry.ID).Warn("Failed to mark task execution interrupted")
+			continue
+		}
+		taskID := logEntry.TaskID
+		s.publishEvent(EventTaskCancelled, &taskID, fmt.Sprintf("Task run started at %s was interrupted", logEntry.StartedAt.Format(time.RFC3339)), map[string]interface{}{
+			"execution_log_id": logEntry.ID,
+			"interrupted":      true,
+		})
+	}
+	if len(logs) > 0 {
+		logrus.WithField("executions", len(logs)).Warn("Marked task executions interrupted by the last shutdown")
+	}
+}
+
 // loadTasksFromDatabase loads existing tasks from database
 func (s *CronScheduler) loadTasksFromDatabase(ctx context.Context) error {
 	if s.taskRepo == nil {
@@ -777,8 +1117,28 @@ func (s *CronScheduler) loadTasksFromDatabase(ctx context.Context) error {
 	}
 
 	for _, task := range tasks {
+		// A one-shot run missed by more than the grace period is not made up
+		// for either; the task is deactivated so it can be given a new run time
+		if task.IsOneShot() && task.RunAt != nil && task.RunAt.Before(s.startTime.Add(-model.OneShotGracePeriod)) {
+			if err := s.taskRepo.SetEnabled(ctx, int64(task.ID), false); err != nil {
+				logrus.WithError(err).WithField("task_id", task.ID).Error("Failed to deactivate missed one-shot task")
+			}
+			s.publishEvent(EventTaskMissed, &task.ID, fmt.Sprintf("Task '%s' missed its one-shot run at %s", task.Name, task.RunAt.Format(time.RFC3339)), map[string]interface{}{
+				"missed_run_at": task.RunAt,
+			})
+			continue
+		}
+
 		if err := s.AddTask(task); err != nil {
 			logrus.WithError(err).WithField("task_id", task.ID).Error("Failed to add task from database")
+			continue
+		}
+
+		// A run due while the scheduler was down is not made up for
+		if !task.IsOneShot() && task.NextRunAt != nil && task.NextRunAt.Before(s.startTime) {
+			s.publishEvent(EventTaskMissed, &task.ID, fmt.Sprintf("Task '%s' missed its run at %s", task.Name, task.NextRunAt.Format(time.RFC3339)), map[string]interface{}{
+				"missed_run_at": task.NextRunAt,
+			})
 		}
 	}
 
@@ -786,30 +1146,35 @@ func (s *CronScheduler) loadTasksFromDatabase(ctx context.Context) error {
 	return nil
 }
 
-// waitForRunningTasks waits for running tasks to complete
-func (s *CronScheduler) waitForRunningTasks(ctx context.Context) {
+// waitForRunningTasks waits for running tasks to complete, returning how
+// many were still running when ctx ended
+func (s *CronScheduler) waitForRunningTasks(ctx context.Context) int {
 	ticker := time.NewTicker(1 * time.Second)
 	defer ticker.Stop()
 
 	for {
+		runningCount := s.runningExecutionCount()
+		if runningCount == 0 {
+			return 0
+		}
+
 		select {
 		case <-ctx.Done():
-			logrus.Warn("Context cancelled while waiting for running tasks")
-			return
+			logrus.WithField("running_tasks", runningCount).Warn("Context cancelled while waiting for running tasks")
+			return runningCount
 		case <-ticker.C:
-			s.mu.RLock()
-			runningCount := len(s.executions)
-			s.mu.RUnlock()
-
-			if runningCount == 0 {
-				return
-			}
-
 			logrus.WithField("running_tasks", runningCount).Info("Waiting for running tasks to complete")
 		}
 	}
 }
 
+// runningExecutionCount counts the runs that have not saved their outcome
+func (s *CronScheduler) runningExecutionCount() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.activeRuns
+}
+
 // cleanupRoutine periodically cleans up old execution records
 func (s *CronScheduler) cleanupRoutine() {
 	for {
@@ -857,8 +1222,9 @@ func (s *CronScheduler) updateMetrics() {
 	defer s.mu.Unlock()
 
 	s.metrics.UptimeSeconds = int64(time.Since(s.startTime).Seconds())
-	s.metrics.QueueDepth = len(s.workerPool)
-	s.metrics.WorkerUtilization = float64(len(s.workerPool)) / float64(cap(s.workerPool)) * 100
+	running, size := s.workerPool.usage()
+	s.metrics.QueueDepth = running
+	s.metrics.WorkerUtilization = float64(running) / float64(size) * 100
 
 	// Calculate average execution time
 	if s.metrics.TotalExecutions > 0 {
@@ -873,9 +1239,10 @@ func (s *CronScheduler) updateMetrics() {
 	}
 }
 
-// publishEvent publishes a scheduler event
+// publishEvent queues a scheduler event for the listener. It never blocks;
+// the event is dropped when the listener's queue is full.
 func (s *CronScheduler) publishEvent(eventType SchedulerEventType, taskID *int, message string, data map[string]interface{}) {
-	if s.eventListener == nil {
+	if s.events == nil {
 		return
 	}
 
@@ -887,7 +1254,30 @@ func (s *CronScheduler) publishEvent(eventType SchedulerEventType, taskID *int,
 		Data:      data,
 	}
 
-	go s.eventListener.OnEvent(event)
+	if !s.events.publish(event) {
+		logrus.WithField("event_type", eventType).Debug("Scheduler event dropped: listener queue is full")
+	}
+}
+
+// SetLimits changes the number of tasks run at once and the timeout of the
+// runs started afterwards. Runs over a lowered limit are left to finish.
+func (s *CronScheduler) SetLimits(maxConcurrentTasks int, taskTimeout time.Duration) {
+	s.limitsMu.Lock()
+	s.config.MaxConcurrentTasks = maxConcurrentTasks
+	s.config.TaskTimeout = taskTimeout
+	s.limitsMu.Unlock()
+
+	s.workerPool.resize(maxConcurrentTasks)
+	logrus.WithFields(logrus.Fields{
+		"max_concurrent_tasks": maxConcurrentTasks,
+		"task_timeout":         taskTimeout,
+	}).Info("Scheduler limits changed")
+}
+
+func (s *CronScheduler) taskTimeout() time.Duration {
+	s.limitsMu.RLock()
+	defer s.limitsMu.RUnlock()
+	return s.config.TaskTimeout
 }
 
 // GetMetrics returns current scheduler metrics
@@ -897,6 +1287,9 @@ func (s *CronScheduler) GetMetrics() *SchedulerMetrics {
 
 	// Return a copy of metrics
 	metrics := *s.metrics
+	if s.events != nil {
+		metrics.DroppedEvents = s.events.Dropped()
+	}
 	return &metrics
 }
 
@@ -0,0 +1,344 @@
+package scheduler
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+)
+
+// executionLogRecorder keeps the execution logs the scheduler saves. Runs
+// are logged as running when they start and updated with their outcome.
+type executionLogRecorder struct {
+	repository.TaskExecutionLogRepository
+	saved  chan *model.TaskExecutionLog
+	nextID int
+}
+
+func (r *executionLogRecorder) Create(ctx context.Context, log *model.TaskExecutionLog) error {
+	r.nextID++
+	log.ID = r.nextID
+	return nil
+}
+
+func (r *executionLogRecorder) Update(ctx context.Context, log *model.TaskExecutionLog) error {
+	r.saved <- log
+	return nil
+}
+
+// imageCleanupTask removes images one at a time the way the cleanup task
+// does, pausing after pauseAfter removals until resumed
+type imageCleanupTask struct {
+	images     []string
+	pauseAfter int
+	paused     chan struct{}
+	resume     chan struct{}
+
+	mu       sync.Mutex
+	removed  []string
+	attempts int
+}
+
+func (t *imageCleanupTask) Execute(ctx context.Context, params TaskParameters) error {
+	t.mu.Lock()
+	t.attempts++
+	t.mu.Unlock()
+
+	for i, image := range t.images {
+		if err := ctx.Err(); err != nil {
+			SetResultData(ctx, ResultDataDeferred, len(t.images)-i)
+			return err
+		}
+		if i == t.pauseAfter {
+			close(t.paused)
+			<-t.resume
+			// The removal already under way completes
+		}
+
+		t.mu.Lock()
+		t.removed = append(t.removed, image)
+		t.mu.Unlock()
+		SetResultData(ctx, ResultDataCompleted, i+1)
+	}
+	return nil
+}
+
+func (t *imageCleanupTask) GetName() string                      { return "image cleanup" }
+func (t *imageCleanupTask) GetType() model.TaskType              { return model.TaskTypeCleanup }
+func (t *imageCleanupTask) Validate(params TaskParameters) error { return nil }
+func (t *imageCleanupTask) GetDefaultTimeout() time.Duration     { return 0 }
+func (t *imageCleanupTask) CanRunConcurrently() bool             { return false }
+
+func TestCancelExecutionStopsCleanupMidBatch(t *testing.T) {
+	task := &imageCleanupTask{
+		images:     []string{"sha256:a", "sha256:b", "sha256:c", "sha256:d", "sha256:e", "sha256:f"},
+		pauseAfter: 2,
+		paused:     make(chan struct{}),
+		resume:     make(chan struct{}),
+	}
+	registry := NewTaskRegistry()
+	if err := registry.RegisterTask(model.TaskTypeCleanup, func() Task { return task }); err != nil {
+		t.Fatal(err)
+	}
+	logs := &executionLogRecorder{saved: make(chan *model.TaskExecutionLog, 1)}
+	s := NewCronScheduler(registry, NewTaskExecutor(nil), nil, logs, &SchedulerConfig{
+		MaxConcurrentTasks: 1,
+		TaskTimeout:        time.Minute,
+		MaxRetries:         3,
+		RetryDelay:         time.Millisecond,
+		TimeZone:           "UTC",
+	}, nil, nil)
+	s.cancelCtx, s.cancelFunc = context.WithCancel(context.Background())
+	defer s.cancelFunc()
+
+	scheduled := &model.ScheduledTask{ID: 7, Name: "nightly cleanup", Type: model.TaskTypeCleanup}
+	go s.executeTask(scheduled, model.TriggerTypeManual)
+
+	select {
+	case <-task.paused:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cleanup did not start")
+	}
+
+	running := s.GetRunningTasks()
+	if len(running) != 1 {
+		t.Fatalf("running executions = %d, want 1", len(running))
+	}
+	if running[0].CancelFunc != nil {
+		t.Fatal("GetRunningTasks exposes the cancel function")
+	}
+	executionID := running[0].ID
+
+	if err := s.CancelExecution(8, executionID); err == nil {
+		t.Fatal("cancelled an execution through another task")
+	}
+	if err := s.CancelExecution(scheduled.ID, executionID); err != nil {
+		t.Fatalf("CancelExecution: %v", err)
+	}
+	close(task.resume)
+
+	var log *model.TaskExecutionLog
+	select {
+	case log = <-logs.saved:
+	case <-time.After(5 * time.Second):
+		t.Fatal("execution log was not saved")
+	}
+
+	if log.Status != model.ExecutionStatusCancelled {
+		t.Fatalf("status = %q, want %q", log.Status, model.ExecutionStatusCancelled)
+	}
+	if !strings.Contains(log.Message, "completed=3") || !strings.Contains(log.Message, "deferred=3") {
+		t.Fatalf("message = %q, want the partial results", log.Message)
+	}
+
+	task.mu.Lock()
+	removed, attempts := len(task.removed), task.attempts
+	task.mu.Unlock()
+	if removed != 3 {
+		t.Fatalf("removed %d images, want 3 of %d", removed, len(task.images))
+	}
+	if attempts != 1 {
+		t.Fatalf("attempts = %d, a cancelled run must not be retried", attempts)
+	}
+
+	if err := s.CancelExecution(scheduled.ID, executionID); err == nil {
+		t.Fatal("cancelled a finished execution")
+	}
+	if got := s.GetMetrics().CancelledExecutions; got != 1 {
+		t.Fatalf("cancelled executions = %d, want 1", got)
+	}
+}
+
+// oneShotRepo keeps the one-shot runs the scheduler completes
+type oneShotRepo struct {
+	repository.ScheduledTaskRepository
+	completed chan int64
+}
+
+func (r *oneShotRepo) CompleteRun(ctx context.Context, id int64, ranAt time.Time, failed bool) error {
+	r.completed <- id
+	return nil
+}
+
+// containerUpdateTask reports the time of each of its runs
+type containerUpdateTask struct {
+	runs chan time.Time
+}
+
+func (t *containerUpdateTask) Execute(ctx context.Context, params TaskParameters) error {
+	t.runs <- time.Now()
+	return nil
+}
+
+func (t *containerUpdateTask) GetName() string                      { return "container update" }
+func (t *containerUpdateTask) GetType() model.TaskType              { return model.TaskTypeContainerUpdate }
+func (t *containerUpdateTask) Validate(params TaskParameters) error { return nil }
+func (t *containerUpdateTask) GetDefaultTimeout() time.Duration     { return 0 }
+func (t *containerUpdateTask) CanRunConcurrently() bool             { return false }
+
+func TestOneShotTaskRunsOnceAtRunAt(t *testing.T) {
+	if testing.Short() {
+		t.Skip("waits 30 seconds for the run time")
+	}
+
+	task := &containerUpdateTask{runs: make(chan time.Time, 2)}
+	registry := NewTaskRegistry()
+	if err := registry.RegisterTask(model.TaskTypeContainerUpdate, func() Task { return task }); err != nil {
+		t.Fatal(err)
+	}
+	repo := &oneShotRepo{completed: make(chan int64, 1)}
+	logs := &executionLogRecorder{saved: make(chan *model.TaskExecutionLog, 2)}
+	s := NewCronScheduler(registry, NewTaskExecutor(nil), repo, logs, &SchedulerConfig{
+		MaxConcurrentTasks: 1,
+		TaskTimeout:        time.Minute,
+		TimeZone:           "UTC",
+	}, nil, nil)
+	s.cancelCtx, s.cancelFunc = context.WithCancel(context.Background())
+	defer s.cancelFunc()
+	s.isRunning = true
+	s.cron.Start()
+	defer s.cron.Stop()
+
+	runAt := time.Now().Add(30 * time.Second)
+	scheduled := &model.ScheduledTask{
+		ID:           3,
+		Name:         "saturday update",
+		Type:         model.TaskTypeContainerUpdate,
+		ScheduleType: model.ScheduleTypeOnce,
+		RunAt:        &runAt,
+		IsActive:     true,
+	}
+	if err := s.AddTask(scheduled); err != nil {
+		t.Fatalf("AddTask: %v", err)
+	}
+
+	select {
+	case ranAt := <-task.runs:
+		if ranAt.Before(runAt) {
+			t.Fatalf("ran at %s, before its run time %s", ranAt, runAt)
+		}
+	case <-time.After(40 * time.Second):
+		t.Fatal("one-shot task did not run")
+	}
+
+	select {
+	case id := <-repo.completed:
+		if id != int64(scheduled.ID) {
+			t.Fatalf("completed task %d, want %d", id, scheduled.ID)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("one-shot task was not completed")
+	}
+	if _, err := s.GetTaskStatus(scheduled.ID); err == nil {
+		t.Fatal("completed one-shot task is still scheduled")
+	}
+
+	select {
+	case <-task.runs:
+		t.Fatal("one-shot task ran twice")
+	case <-time.After(2 * time.Second):
+	}
+}
+
+func TestOnceScheduleFiresOnce(t *testing.T) {
+	now := time.Date(2026, 3, 7, 1, 59, 30, 0, time.UTC)
+	runAt := now.Add(30 * time.Second)
+
+	schedule := newOnceSchedule(runAt, now)
+	if next := schedule.Next(now); !next.Equal(runAt) {
+		t.Fatalf("Next = %s, want %s", next, runAt)
+	}
+	if next := schedule.Next(runAt); !next.IsZero() {
+		t.Fatalf("Next after the run = %s, want none", next)
+	}
+
+	// A run time that just passed, within the grace period, runs straight away
+	late := newOnceSchedule(now.Add(-10*time.Second), now)
+	if next := late.Next(now); next.IsZero() || next.After(now.Add(time.Second)) {
+		t.Fatalf("Next of a passed run time = %s, want right after %s", next, now)
+	}
+}
+
+// slowListener takes events only once release is closed, like a listener
+// writing to a database that stopped answering
+type slowListener struct {
+	release chan struct{}
+
+	mu     sync.Mutex
+	events []SchedulerEvent
+}
+
+func (l *slowListener) OnEvent(event SchedulerEvent) {
+	<-l.release
+	l.mu.Lock()
+	l.events = append(l.events, event)
+	l.mu.Unlock()
+}
+
+func (l *slowListener) received() []SchedulerEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]SchedulerEvent(nil), l.events...)
+}
+
+func TestSlowEventListenerDoesNotStallTasks(t *testing.T) {
+	task := &containerUpdateTask{runs: make(chan time.Time, 3)}
+	registry := NewTaskRegistry()
+	if err := registry.RegisterTask(model.TaskTypeContainerUpdate, func() Task { return task }); err != nil {
+		t.Fatal(err)
+	}
+	logs := &executionLogRecorder{saved: make(chan *model.TaskExecutionLog, 3)}
+	s := NewCronScheduler(registry, NewTaskExecutor(nil), nil, logs, &SchedulerConfig{
+		MaxConcurrentTasks: 1,
+		TaskTimeout:        time.Minute,
+		TimeZone:           "UTC",
+	}, nil, nil)
+	s.cancelCtx, s.cancelFunc = context.WithCancel(context.Background())
+	defer s.cancelFunc()
+
+	// One event in delivery and one queued saturate the listener
+	listener := &slowListener{release: make(chan struct{})}
+	s.events = newEventDispatcher(listener, 1)
+
+	scheduled := &model.ScheduledTask{ID: 4, Name: "nightly update", Type: model.TaskTypeContainerUpdate}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 3; i++ {
+			s.executeTask(scheduled, model.TriggerTypeManual)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		close(listener.release)
+		t.Fatal("runs were held up by the event listener")
+	}
+	for i := 0; i < 3; i++ {
+		if log := <-logs.saved; log.Status != model.ExecutionStatusSuccess {
+			t.Errorf("run %d ended %s, want success", i+1, log.Status)
+		}
+	}
+	if dropped := s.GetMetrics().DroppedEvents; dropped == 0 {
+		t.Error("no events dropped, want those the saturated listener could not take")
+	}
+
+	// Once the listener catches up it gets the events it had room for: the
+	// first run's start, and the event queued behind it if it was taken off
+	// the queue before the next one came
+	close(listener.release)
+	deadline := time.Now().Add(5 * time.Second)
+	for len(listener.received()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	events := listener.received()
+	if len(events) == 0 || len(events) > 2 || events[0].Type != EventTaskStarted {
+		t.Fatalf("listener got %d events, want the first run's start and at most one queued after it", len(events))
+	}
+}
@@ -0,0 +1,62 @@
+package scheduler
+
+import (
+	"sync/atomic"
+
+	"github.com/sirupsen/logrus"
+)
+
+// eventQueueSize bounds the events waiting for the listener
+const eventQueueSize = 256
+
+// eventDispatcher delivers events to the listener from a single goroutine.
+// When the listener falls behind and the queue is full, events are dropped
+// and counted, so a slow listener never holds up task execution.
+type eventDispatcher struct {
+	listener EventListener
+	queue    chan SchedulerEvent
+	dropped  int64
+}
+
+func newEventDispatcher(listener EventListener, size int) *eventDispatcher {
+	d := &eventDispatcher{
+		listener: listener,
+		queue:    make(chan SchedulerEvent, size),
+	}
+	go d.run()
+	return d
+}
+
+// publish queues an event without blocking, reporting whether it was queued
+func (d *eventDispatcher) publish(event SchedulerEvent) bool {
+	select {
+	case d.queue <- event:
+		return true
+	default:
+		atomic.AddInt64(&d.dropped, 1)
+		return false
+	}
+}
+
+// Dropped returns the number of events dropped since the scheduler was created
+func (d *eventDispatcher) Dropped() int64 {
+	return atomic.LoadInt64(&d.dropped)
+}
+
+func (d *eventDispatcher) run() {
+	for event := range d.queue {
+		d.deliver(event)
+	}
+}
+
+func (d *eventDispatcher) deliver(event SchedulerEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			logrus.WithFields(logrus.Fields{
+				"event_type": event.Type,
+				"panic":      r,
+			}).Error("Scheduler event listener panicked")
+		}
+	}()
+	d.listener.OnEvent(event)
+}
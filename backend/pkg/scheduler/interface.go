@@ -4,7 +4,7 @@ import (
 	"context"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 )
 
 // Scheduler defines the interface for task scheduling systems
@@ -33,6 +33,9 @@ type Scheduler interface {
 	// TriggerTask manually triggers a task execution
 	TriggerTask(taskID int) error
 
+	// CancelExecution cancels a running execution of a task
+	CancelExecution(taskID int, executionID string) error
+
 	// GetRunningTasks returns currently running tasks
 	GetRunningTasks() []*TaskExecution
 
@@ -41,6 +44,12 @@ type Scheduler interface {
 
 	// IsRunning returns true if the scheduler is running
 	IsRunning() bool
+
+	// GetMetrics returns the scheduler metrics
+	GetMetrics() *SchedulerMetrics
+
+	// SetLimits changes the concurrency limit and default task timeout
+	SetLimits(maxConcurrentTasks int, taskTimeout time.Duration)
 }
 
 // Task defines the interface for executable tasks
@@ -106,8 +115,13 @@ type TaskParameters struct {
 	TargetContainers []int64               `json:"target_containers,omitempty"`
 	Parameters       map[string]interface{} `json:"parameters,omitempty"`
 	Timeout          time.Duration         `json:"timeout,omitempty"`
+	// MaxRetries is the default when 0; a negative value disables retries
 	MaxRetries       int                   `json:"max_retries,omitempty"`
 	RetryDelay       time.Duration         `json:"retry_delay,omitempty"`
+	// RetryBackoff multiplies the delay after every retry, up to
+	// RetryMaxDelay; 1 or less keeps it fixed
+	RetryBackoff     float64               `json:"retry_backoff,omitempty"`
+	RetryMaxDelay    time.Duration         `json:"retry_max_delay,omitempty"`
 }
 
 // TaskResult represents the result of task execution
@@ -119,6 +133,11 @@ type TaskResult struct {
 	Duration     time.Duration          `json:"duration"`
 	RetryCount   int                    `json:"retry_count"`
 	AffectedItems []string              `json:"affected_items,omitempty"`
+	// Partial is set for successful runs that deferred part of their work
+	Partial      bool                   `json:"partial,omitempty"`
+	// Cancelled is set for runs stopped on request; Data holds what they
+	// completed
+	Cancelled    bool                   `json:"cancelled,omitempty"`
 }
 
 // TaskExecution represents an active or completed task execution
@@ -128,6 +147,7 @@ type TaskExecution struct {
 	TaskName     string                 `json:"task_name"`
 	TaskType     model.TaskType         `json:"task_type"`
 	Status       model.ExecutionStatus  `json:"status"`
+	TriggeredBy  model.TriggerType      `json:"triggered_by"`
 	StartedAt    time.Time              `json:"started_at"`
 	CompletedAt  *time.Time             `json:"completed_at,omitempty"`
 	Duration     time.Duration          `json:"duration"`
@@ -172,6 +192,11 @@ type SchedulerConfig struct {
 	// MaxRetries sets the default maximum number of retries
 	MaxRetries int `json:"max_retries"`
 
+	// RetryBackoff sets the default factor the retry delay grows by after
+	// every retry, and RetryMaxDelay what it grows to at most
+	RetryBackoff  float64       `json:"retry_backoff"`
+	RetryMaxDelay time.Duration `json:"retry_max_delay"`
+
 	// CleanupInterval sets how often to clean up completed task executions
 	CleanupInterval time.Duration `json:"cleanup_interval"`
 
@@ -197,11 +222,14 @@ type SchedulerMetrics struct {
 	TotalExecutions     int64         `json:"total_executions"`
 	SuccessfulExecutions int64        `json:"successful_executions"`
 	FailedExecutions    int64         `json:"failed_executions"`
+	PartialExecutions   int64         `json:"partial_executions"`
+	CancelledExecutions int64         `json:"cancelled_executions"`
 	AverageExecutionTime time.Duration `json:"average_execution_time"`
 	LastExecutionTime   *time.Time    `json:"last_execution_time,omitempty"`
 	QueueDepth          int           `json:"queue_depth"`
 	WorkerUtilization   float64       `json:"worker_utilization"`
 	UptimeSeconds       int64         `json:"uptime_seconds"`
+	DroppedEvents       int64         `json:"dropped_events"`
 }
 
 // TaskPriority defines task execution priority
@@ -266,6 +294,8 @@ const (
 	EventTaskTimeout         SchedulerEventType = "task_timeout"
 	EventTaskRetried         SchedulerEventType = "task_retried"
 	EventTaskCancelled       SchedulerEventType = "task_cancelled"
+	EventTaskScheduled       SchedulerEventType = "task_scheduled"
+	EventTaskMissed          SchedulerEventType = "task_missed"
 )
 
 // SchedulerEvent represents an event that occurred in the scheduler
@@ -2,11 +2,13 @@ package scheduler
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
 	"time"
 
 	"docker-auto/internal/config"
+	"docker-auto/pkg/model"
 
 	"github.com/google/uuid"
 	"github.com/sirupsen/logrus"
@@ -16,7 +18,7 @@ import (
 type DefaultTaskExecutor struct {
 	executions       map[string]*TaskExecution
 	concurrencyLimit int
-	activeTasks      chan struct{}
+	activeTasks      *workerPool
 	mu               sync.RWMutex
 }
 
@@ -30,19 +32,17 @@ func NewTaskExecutor(config *config.Config) TaskExecutor {
 	return &DefaultTaskExecutor{
 		executions:       make(map[string]*TaskExecution),
 		concurrencyLimit: concurrencyLimit,
-		activeTasks:      make(chan struct{}, concurrencyLimit),
+		activeTasks:      newWorkerPool(concurrencyLimit),
 	}
 }
 
 // ExecuteTask executes a task with the given parameters
 func (e *DefaultTaskExecutor) ExecuteTask(ctx context.Context, task Task, params TaskParameters) (*TaskResult, error) {
 	// Acquire execution slot
-	select {
-	case e.activeTasks <- struct{}{}:
-		defer func() { <-e.activeTasks }()
-	case <-ctx.Done():
+	if !e.activeTasks.acquire(ctx) {
 		return nil, ctx.Err()
 	}
+	defer e.activeTasks.release()
 
 	executionID := uuid.New().String()
 	startTime := time.Now()
@@ -58,6 +58,8 @@ func (e *DefaultTaskExecutor) ExecuteTask(ctx context.Context, task Task, params
 		execCtx, cancel = context.WithTimeout(ctx, task.GetDefaultTimeout())
 		defer cancel()
 	}
+	execCtx, cancelExecution := withCancel(execCtx)
+	defer cancelExecution()
 
 	// Create execution record
 	execution := &TaskExecution{
@@ -66,6 +68,7 @@ func (e *DefaultTaskExecutor) ExecuteTask(ctx context.Context, task Task, params
 		TaskType:  task.GetType(),
 		StartedAt: startTime,
 		Parameters: params,
+		CancelFunc: cancelExecution,
 	}
 
 	// Store execution
@@ -88,7 +91,13 @@ func (e *DefaultTaskExecutor) ExecuteTask(ctx context.Context, task Task, params
 	e.mu.Lock()
 	execution.Duration = time.Since(startTime)
 	execution.Result = result
-	if result.Success {
+	if result.Cancelled {
+		execution.Status = model.ExecutionStatusCancelled
+		execution.Message = result.Message
+	} else if result.Partial {
+		execution.Status = model.ExecutionStatusPartial
+		execution.Message = result.Message
+	} else if result.Success {
 		execution.Status = "success"
 	} else {
 		execution.Status = "failed"
@@ -126,15 +135,16 @@ func (e *DefaultTaskExecutor) executeWithRetries(ctx context.Context, task Task,
 	if maxRetries == 0 {
 		maxRetries = 3 // Default retry count
 	}
-
-	retryDelay := params.RetryDelay
-	if retryDelay == 0 {
-		retryDelay = 5 * time.Second // Default retry delay
+	if maxRetries < 0 {
+		maxRetries = 0 // Retries disabled for the task
 	}
 
 	var lastErr error
 	startTime := time.Now()
 
+	// Whatever the task changes is attributed to its component
+	ctx = model.WithActor(ctx, model.TaskActor(params.TaskType))
+
 	for attempt := 0; attempt <= maxRetries; attempt++ {
 		// Update execution progress
 		e.mu.Lock()
@@ -145,13 +155,37 @@ func (e *DefaultTaskExecutor) executeWithRetries(ctx context.Context, task Task,
 		logger.WithField("attempt", attempt+1).Debug("Executing task attempt")
 
 		// Execute task
-		err := task.Execute(ctx, params)
+		attemptCtx, data := withResultData(ctx)
+		attemptCtx = WithRetriesLeft(attemptCtx, maxRetries-attempt)
+		err := task.Execute(attemptCtx, params)
 		attemptDuration := time.Since(attemptStart)
 
 		if err == nil {
 			// Success
 			return &TaskResult{
 				Success:    true,
+				Data:       data.snapshot(),
+				Duration:   time.Since(startTime),
+				RetryCount: attempt,
+			}
+		}
+
+		// A run stopped on request is not retried; whatever the task
+		// completed before it stopped stays done
+		if IsCancelled(ctx) {
+			logger.Info("Task execution cancelled")
+			return cancelledResult(data.snapshot(), startTime, attempt)
+		}
+
+		// Running out of budget is an outcome, not a failure to retry
+		var partial *PartialError
+		if errors.As(err, &partial) {
+			logger.WithField("deferred", partial.Deferred).Warn(partial.Error())
+			return &TaskResult{
+				Success:    true,
+				Partial:    true,
+				Message:    partial.Error(),
+				Data:       data.snapshot(),
 				Duration:   time.Since(startTime),
 				RetryCount: attempt,
 			}
@@ -163,11 +197,20 @@ func (e *DefaultTaskExecutor) executeWithRetries(ctx context.Context, task Task,
 			"duration": attemptDuration,
 		}).Warn("Task execution attempt failed")
 
+		// Retry only the targets that failed
+		var retry *RetryError
+		if errors.As(err, &retry) {
+			params.TargetContainers = retry.Targets
+		}
+
 		// Check if we should retry
 		if attempt < maxRetries {
 			// Check context cancellation
 			select {
 			case <-ctx.Done():
+				if IsCancelled(ctx) {
+					return cancelledResult(nil, startTime, attempt)
+				}
 				return &TaskResult{
 					Success:    false,
 					Error:      ctx.Err(),
@@ -178,12 +221,15 @@ func (e *DefaultTaskExecutor) executeWithRetries(ctx context.Context, task Task,
 			}
 
 			// Wait before retrying
-			timer := time.NewTimer(retryDelay)
+			timer := time.NewTimer(retryDelay(params, attempt))
 			select {
 			case <-timer.C:
 				// Continue to next attempt
 			case <-ctx.Done():
 				timer.Stop()
+				if IsCancelled(ctx) {
+					return cancelledResult(nil, startTime, attempt)
+				}
 				return &TaskResult{
 					Success:    false,
 					Error:      ctx.Err(),
@@ -203,6 +249,35 @@ func (e *DefaultTaskExecutor) executeWithRetries(ctx context.Context, task Task,
 	}
 }
 
+// retryDelay returns the delay before retry n, counted from 0: the retry
+// delay grown n times by the backoff factor and capped at the maximum delay
+func retryDelay(params TaskParameters, n int) time.Duration {
+	delay := params.RetryDelay
+	if delay <= 0 {
+		delay = 5 * time.Second // Default retry delay
+	}
+
+	for i := 0; i < n && params.RetryBackoff > 1; i++ {
+		delay = time.Duration(float64(delay) * params.RetryBackoff)
+		if params.RetryMaxDelay > 0 && delay >= params.RetryMaxDelay {
+			return params.RetryMaxDelay
+		}
+	}
+	return delay
+}
+
+// cancelledResult is the result of an execution cancelled on request after
+// reporting data
+func cancelledResult(data map[string]interface{}, startTime time.Time, attempt int) *TaskResult {
+	return &TaskResult{
+		Cancelled:  true,
+		Message:    cancelledMessage(data),
+		Data:       data,
+		Duration:   time.Since(startTime),
+		RetryCount: attempt,
+	}
+}
+
 // CancelTask cancels a running task
 func (e *DefaultTaskExecutor) CancelTask(executionID string) error {
 	e.mu.RLock()
@@ -263,8 +338,5 @@ func (e *DefaultTaskExecutor) SetConcurrencyLimit(limit int) {
 	defer e.mu.Unlock()
 
 	e.concurrencyLimit = limit
-
-	// Create new channel with updated capacity
-	close(e.activeTasks)
-	e.activeTasks = make(chan struct{}, limit)
+	e.activeTasks.resize(limit)
 }
\ No newline at end of file
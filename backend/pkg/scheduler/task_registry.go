@@ -4,7 +4,7 @@ import (
 	"fmt"
 	"sync"
 
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 )
 
 // DefaultTaskRegistry implements the TaskRegistry interface
@@ -0,0 +1,145 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Result data keys tasks working through a list of items report
+const (
+	ResultDataCompleted = "completed"
+	ResultDataDeferred  = "deferred"
+)
+
+// PartialError is returned by a task that stopped at its time budget after
+// completing part of its work. The execution is recorded as partial rather
+// than failed and is not retried.
+type PartialError struct {
+	Completed int
+	Deferred  int
+	Reason    string
+}
+
+// Error describes how much of the work was done
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("%s: completed %d of %d, %d deferred to the next run",
+		e.Reason, e.Completed, e.Completed+e.Deferred, e.Deferred)
+}
+
+// RetryError is returned by a task whose run failed for some of its targets
+// only. While retries remain, the executor retries the run for Targets alone.
+type RetryError struct {
+	Targets []int64
+	Err     error
+}
+
+// Error describes the failure and what is retried
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v; %d target(s) to retry", e.Err, len(e.Targets))
+}
+
+// Unwrap returns the failure
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+type retriesLeftKey struct{}
+
+// RetriesLeft returns how many more times the executor retries the task
+// execution running with ctx if this attempt fails; 0 on the last attempt
+// and outside an execution
+func RetriesLeft(ctx context.Context) int {
+	left, _ := ctx.Value(retriesLeftKey{}).(int)
+	return left
+}
+
+// WithRetriesLeft returns a context for an execution attempt the executor
+// retries left more times if it fails
+func WithRetriesLeft(ctx context.Context, left int) context.Context {
+	return context.WithValue(ctx, retriesLeftKey{}, left)
+}
+
+// ErrExecutionCancelled is the cause of an execution's context once the
+// execution is cancelled on request. Tasks see it as ctx.Err() returning
+// context.Canceled and should stop between operations, recording what they
+// completed in the result data.
+var ErrExecutionCancelled = errors.New("execution cancelled")
+
+// withCancel returns a context for an execution and the function that
+// cancels it on request
+func withCancel(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancelCause(ctx)
+	return ctx, func() { cancel(ErrExecutionCancelled) }
+}
+
+// IsCancelled reports whether the execution running with ctx was cancelled
+// on request, as opposed to timing out or the scheduler stopping
+func IsCancelled(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrExecutionCancelled)
+}
+
+// cancelledMessage describes the partial results a cancelled execution
+// reported
+func cancelledMessage(data map[string]interface{}) string {
+	if len(data) == 0 {
+		return "Cancelled"
+	}
+
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	results := make([]string, 0, len(keys))
+	for _, key := range keys {
+		results = append(results, fmt.Sprintf("%s=%v", key, data[key]))
+	}
+	return "Cancelled with partial results: " + strings.Join(results, ", ")
+}
+
+type resultDataKey struct{}
+
+// resultData collects what a task reports about its run
+type resultData struct {
+	mu     sync.Mutex
+	values map[string]interface{}
+}
+
+// withResultData returns a context tasks can record result data in
+func withResultData(ctx context.Context) (context.Context, *resultData) {
+	data := &resultData{values: make(map[string]interface{})}
+	return context.WithValue(ctx, resultDataKey{}, data), data
+}
+
+// SetResultData records a value in the result of the task execution running
+// with ctx, e.g. the number of items processed. It does nothing outside an
+// execution.
+func SetResultData(ctx context.Context, key string, value interface{}) {
+	data, ok := ctx.Value(resultDataKey{}).(*resultData)
+	if !ok {
+		return
+	}
+	data.mu.Lock()
+	data.values[key] = value
+	data.mu.Unlock()
+}
+
+// snapshot returns the recorded values, or nil when there are none
+func (d *resultData) snapshot() map[string]interface{} {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.values) == 0 {
+		return nil
+	}
+	values := make(map[string]interface{}, len(d.values))
+	for key, value := range d.values {
+		values[key] = value
+	}
+	return values
+}
@@ -4,38 +4,51 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/internal/config"
 	"docker-auto/internal/repository"
-	"docker-auto/internal/service"
 	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/scheduler"
 
+	"github.com/docker/docker/api/types/volume"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
+// defaultVolumeHelperImage archives volumes when no helper image is configured
+const defaultVolumeHelperImage = "busybox:stable"
+
 // BackupTask implements the Task interface for system backup operations
 type BackupTask struct {
 	containerRepo       repository.ContainerRepository
 	updateHistoryRepo   repository.UpdateHistoryRepository
 	taskRepo            repository.ScheduledTaskRepository
-	containerService    *service.ContainerService
-	notificationService *service.NotificationService
+	containerService    ContainerService
+	notificationService NotificationService
 	dockerClient        *docker.DockerClient
+	db                  *gorm.DB
+	config              *config.Config
 }
 
-// NewBackupTask creates a new backup task
+// NewBackupTask creates a new backup task. PostgreSQL is dumped with
+// pg_dump using config's connection settings; other databases are exported
+// table by table through db, which may be nil on PostgreSQL.
 func NewBackupTask(
 	containerRepo repository.ContainerRepository,
 	updateHistoryRepo repository.UpdateHistoryRepository,
 	taskRepo repository.ScheduledTaskRepository,
-	containerService *service.ContainerService,
-	notificationService *service.NotificationService,
+	containerService ContainerService,
+	notificationService NotificationService,
 	dockerClient *docker.DockerClient,
+	db *gorm.DB,
+	config *config.Config,
 ) *BackupTask {
 	return &BackupTask{
 		containerRepo:       containerRepo,
@@ -44,6 +57,8 @@ func NewBackupTask(
 		containerService:    containerService,
 		notificationService: notificationService,
 		dockerClient:        dockerClient,
+		db:                  db,
+		config:              config,
 	}
 }
 
@@ -75,10 +90,12 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 		return fmt.Errorf("failed to create backup directory: %w", err)
 	}
 
-	// Perform different types of backups based on configuration
+	// Perform different types of backups based on configuration. A cancelled
+	// run stops between operations and keeps what was backed up so far.
 	if backupParams.BackupDatabase {
 		operation := t.backupDatabase(ctx, session, backupParams)
 		session.Operations = append(session.Operations, operation)
+		session.TotalSize += operation.Size
 		if operation.Success {
 			session.SuccessfulOperations++
 		} else {
@@ -86,9 +103,10 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 		}
 	}
 
-	if backupParams.BackupConfigurations {
+	if backupParams.BackupConfigurations && ctx.Err() == nil {
 		operation := t.backupConfigurations(ctx, session, backupParams)
 		session.Operations = append(session.Operations, operation)
+		session.TotalSize += operation.Size
 		if operation.Success {
 			session.SuccessfulOperations++
 		} else {
@@ -96,9 +114,10 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 		}
 	}
 
-	if backupParams.BackupContainerConfigs {
+	if backupParams.BackupContainerConfigs && ctx.Err() == nil {
 		operation := t.backupContainerConfigs(ctx, session, backupParams)
 		session.Operations = append(session.Operations, operation)
+		session.TotalSize += operation.Size
 		if operation.Success {
 			session.SuccessfulOperations++
 		} else {
@@ -106,10 +125,11 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 		}
 	}
 
-	if backupParams.BackupVolumes {
+	if backupParams.BackupVolumes && ctx.Err() == nil {
 		operations := t.backupVolumes(ctx, session, backupParams)
 		session.Operations = append(session.Operations, operations...)
 		for _, op := range operations {
+			session.TotalSize += op.Size
 			if op.Success {
 				session.SuccessfulOperations++
 			} else {
@@ -118,7 +138,7 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 		}
 	}
 
-	if backupParams.BackupImages {
+	if backupParams.BackupImages && ctx.Err() == nil {
 		operations := t.backupImages(ctx, session, backupParams)
 		session.Operations = append(session.Operations, operations...)
 		for _, op := range operations {
@@ -130,8 +150,29 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 		}
 	}
 
+	// The manifest and verification cover the artifacts written above
+	if backupParams.CreateManifest && ctx.Err() == nil {
+		operation := t.createManifest(ctx, session)
+		session.Operations = append(session.Operations, operation)
+		if operation.Success {
+			session.SuccessfulOperations++
+		} else {
+			session.FailedOperations++
+		}
+	}
+
+	if backupParams.VerifyBackup && ctx.Err() == nil {
+		operation := t.verifyBackup(ctx, session)
+		session.Operations = append(session.Operations, operation)
+		if operation.Success {
+			session.SuccessfulOperations++
+		} else {
+			session.FailedOperations++
+		}
+	}
+
 	// Compress backup if requested
-	if backupParams.CompressBackups {
+	if backupParams.CompressBackups && ctx.Err() == nil {
 		operation := t.compressBackup(ctx, session, backupParams)
 		session.Operations = append(session.Operations, operation)
 		if operation.Success {
@@ -142,7 +183,7 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 	}
 
 	// Clean up old backups
-	if backupParams.RetentionDays > 0 {
+	if backupParams.RetentionDays > 0 && ctx.Err() == nil {
 		operation := t.cleanupOldBackups(ctx, session, backupParams)
 		session.Operations = append(session.Operations, operation)
 		if operation.Success {
@@ -155,6 +196,18 @@ func (t *BackupTask) Execute(ctx context.Context, params scheduler.TaskParameter
 	session.CompletedAt = time.Now()
 	session.Duration = session.CompletedAt.Sub(session.StartedAt)
 
+	scheduler.SetResultData(ctx, "operations", len(session.Operations))
+	scheduler.SetResultData(ctx, "failed_operations", session.FailedOperations)
+	scheduler.SetResultData(ctx, "backup_path", session.BackupPath)
+
+	if err := ctx.Err(); err != nil {
+		logger.WithFields(logrus.Fields{
+			"completed_operations": len(session.Operations),
+			"backup_path":          session.BackupPath,
+		}).Warn("System backup task stopped early")
+		return fmt.Errorf("backup stopped after %d operations: %w", len(session.Operations), err)
+	}
+
 	// Send notification about backup results
 	if err := t.sendBackupNotification(ctx, session, backupParams); err != nil {
 		logger.WithError(err).Warn("Failed to send backup notification")
@@ -257,7 +310,7 @@ type BackupSession struct {
 	CompressedSize       int64             `json:"compressed_size,omitempty"`
 	IsCompressed         bool              `json:"is_compressed"`
 	IsEncrypted          bool              `json:"is_encrypted"`
-	Manifest             *BackupManifest   `json:"manifest,omitempty"`
+	Manifest             *model.BackupManifest `json:"manifest,omitempty"`
 	Errors               []BackupError     `json:"errors"`
 }
 
@@ -275,17 +328,6 @@ type BackupOperation struct {
 	Metadata    interface{}   `json:"metadata,omitempty"` // Additional metadata
 }
 
-// BackupManifest represents backup manifest information
-type BackupManifest struct {
-	Version      string                 `json:"version"`
-	CreatedAt    time.Time              `json:"created_at"`
-	BackupType   string                 `json:"backup_type"`
-	TotalSize    int64                  `json:"total_size"`
-	FileCount    int                    `json:"file_count"`
-	Checksums    map[string]string      `json:"checksums"`
-	Metadata     map[string]interface{} `json:"metadata"`
-}
-
 // BackupError represents an error during backup operations
 type BackupError struct {
 	Operation string `json:"operation"`
@@ -296,10 +338,15 @@ type BackupError struct {
 
 // parseParameters parses and validates task parameters
 func (t *BackupTask) parseParameters(params scheduler.TaskParameters) (*BackupParameters, error) {
+	storagePath := "/var/backups/docker-auto"
+	if t.config != nil && t.config.System.BackupStoragePath != "" {
+		storagePath = t.config.System.BackupStoragePath
+	}
+
 	// Set defaults
 	backupParams := &BackupParameters{
 		BackupType:             "full",
-		StoragePath:            "/var/backups/docker-auto",
+		StoragePath:            storagePath,
 		RetentionDays:          30,
 		CompressBackups:        true,
 		BackupDatabase:         true,
@@ -354,7 +401,7 @@ func (t *BackupTask) parseParameters(params scheduler.TaskParameters) (*BackupPa
 
 // createBackupDirectory creates the backup directory structure
 func (t *BackupTask) createBackupDirectory(session *BackupSession, params *BackupParameters) error {
-	timestamp := session.StartedAt.Format("20060102-150405")
+	timestamp := session.StartedAt.Format(model.BackupIDLayout)
 	session.BackupID = fmt.Sprintf("backup-%s", timestamp)
 	session.BackupPath = filepath.Join(params.StoragePath, session.BackupID)
 
@@ -378,38 +425,38 @@ func (t *BackupTask) backupDatabase(ctx context.Context, session *BackupSession,
 		operation.Duration = time.Since(startTime)
 	}()
 
-	// This is a placeholder implementation
-	// Real implementation would:
-	// 1. Determine database type (PostgreSQL, SQLite, etc.)
-	// 2. Create database dump using appropriate tools
-	// 3. Save dump to backup directory
-	// 4. Calculate checksum
+	var fileName, format string
+	var dump func(w io.Writer) error
+	switch {
+	case t.db != nil && t.db.Dialector.Name() != "postgres":
+		fileName, format = model.BackupDatabaseExportFile, "jsonl"
+		dump = func(w io.Writer) error { return exportTables(ctx, t.db, w) }
+	case t.config != nil && t.config.Database.Host != "":
+		fileName, format = model.BackupDatabaseDumpFile, "pg_dump"
+		dump = func(w io.Writer) error { return pgDump(ctx, t.config, w) }
+	default:
+		operation.Error = "Database connection not configured"
+		return operation
+	}
 
-	backupFile := filepath.Join(session.BackupPath, "database.sql")
+	backupFile := filepath.Join(session.BackupPath, fileName)
 	operation.BackupPath = backupFile
+	operation.Metadata = map[string]interface{}{"format": format}
 
-	// Placeholder: Create empty backup file
-	file, err := os.Create(backupFile)
+	size, checksum, err := writeGzipArtifact(backupFile, 0, dump)
 	if err != nil {
-		operation.Error = fmt.Sprintf("Failed to create database backup file: %v", err)
+		operation.Error = fmt.Sprintf("Failed to dump database: %v", err)
 		return operation
 	}
-	defer file.Close()
-
-	// Write placeholder content
-	content := fmt.Sprintf("-- Database backup created at %s\n-- This is a placeholder implementation\n", time.Now())
-	if _, err := file.WriteString(content); err != nil {
-		operation.Error = fmt.Sprintf("Failed to write database backup: %v", err)
-		return operation
-	}
-
-	// Get file info
-	if info, err := file.Stat(); err == nil {
-		operation.Size = info.Size()
-	}
 
+	operation.Size = size
+	operation.Checksum = checksum
 	operation.Success = true
-	logrus.Info("Database backup completed (placeholder)")
+
+	logrus.WithFields(logrus.Fields{
+		"backup_file": backupFile,
+		"size":        size,
+	}).Info("Database backup completed")
 
 	return operation
 }
@@ -426,7 +473,7 @@ func (t *BackupTask) backupConfigurations(ctx context.Context, session *BackupSe
 		operation.Duration = time.Since(startTime)
 	}()
 
-	configDir := filepath.Join(session.BackupPath, "configurations")
+	configDir := filepath.Join(session.BackupPath, model.BackupConfigurationsDir)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		operation.Error = fmt.Sprintf("Failed to create config backup directory: %v", err)
 		return operation
@@ -435,14 +482,8 @@ func (t *BackupTask) backupConfigurations(ctx context.Context, session *BackupSe
 	operation.BackupPath = configDir
 
 	// Backup configuration files
-	configFiles := []string{
-		"config.yaml",
-		"docker-compose.yml",
-		".env",
-	}
-
 	var totalSize int64
-	for _, configFile := range configFiles {
+	for _, configFile := range model.BackupConfigurationFiles {
 		sourcePath := filepath.Join(".", configFile)
 		if _, err := os.Stat(sourcePath); os.IsNotExist(err) {
 			continue // Skip non-existent files
@@ -494,7 +535,7 @@ func (t *BackupTask) backupContainerConfigs(ctx context.Context, session *Backup
 	}
 
 	// Create container configs directory
-	configDir := filepath.Join(session.BackupPath, "container_configs")
+	configDir := filepath.Join(session.BackupPath, model.BackupContainerConfigsDir)
 	if err := os.MkdirAll(configDir, 0755); err != nil {
 		operation.Error = fmt.Sprintf("Failed to create container config directory: %v", err)
 		return operation
@@ -505,20 +546,26 @@ func (t *BackupTask) backupContainerConfigs(ctx context.Context, session *Backup
 	// Export container configurations
 	var totalSize int64
 	for _, container := range containers {
+		if err := ctx.Err(); err != nil {
+			operation.Size = totalSize
+			operation.Error = fmt.Sprintf("Stopped before exporting %s: %v", container.Name, err)
+			return operation
+		}
+
 		// Skip excluded containers
 		if t.contains(params.ExcludeContainers, container.Name) {
 			continue
 		}
 
-		configData := map[string]interface{}{
-			"id":            container.ID,
-			"name":          container.Name,
-			"image":         container.Image,
-			"tag":           container.Tag,
-			"config_json":   container.ConfigJSON,
-			"update_policy": container.UpdatePolicy,
-			"registry_url":  container.RegistryURL,
-			"created_at":    container.CreatedAt,
+		configData := model.BackupContainerConfig{
+			ID:           container.ID,
+			Name:         container.Name,
+			Image:        container.Image,
+			Tag:          container.Tag,
+			ConfigJSON:   container.ConfigJSON,
+			UpdatePolicy: container.UpdatePolicy,
+			RegistryURL:  container.RegistryURL,
+			CreatedAt:    container.CreatedAt,
 		}
 
 		configJSON, err := json.MarshalIndent(configData, "", "  ")
@@ -560,34 +607,94 @@ func (t *BackupTask) backupVolumes(ctx context.Context, session *BackupSession,
 		return operations
 	}
 
-	// This is a placeholder implementation
-	// Real implementation would:
-	// 1. List Docker volumes
-	// 2. Create tar archives of volume data
-	// 3. Save to backup directory
-
-	operation := BackupOperation{
-		Type:    "volumes",
-		Name:    "Docker Volumes (placeholder)",
-		Success: true,
-		Size:    0,
+	volumes, err := t.dockerClient.ListVolumes(ctx, volume.ListOptions{})
+	if err != nil {
+		operations = append(operations, BackupOperation{
+			Type:  "volumes",
+			Name:  "Docker Volumes",
+			Error: fmt.Sprintf("Failed to list volumes: %v", err),
+		})
+		return operations
 	}
 
-	volumesDir := filepath.Join(session.BackupPath, "volumes")
+	volumesDir := filepath.Join(session.BackupPath, model.BackupVolumesDir)
 	if err := os.MkdirAll(volumesDir, 0755); err != nil {
-		operation.Error = fmt.Sprintf("Failed to create volumes directory: %v", err)
-		operation.Success = false
-	} else {
-		operation.BackupPath = volumesDir
+		operations = append(operations, BackupOperation{
+			Type:  "volumes",
+			Name:  "Docker Volumes",
+			Error: fmt.Sprintf("Failed to create volumes directory: %v", err),
+		})
+		return operations
 	}
 
-	operations = append(operations, operation)
+	helperImage := defaultVolumeHelperImage
+	if t.config != nil && t.config.Monitoring.VolumeHelperImage != "" {
+		helperImage = t.config.Monitoring.VolumeHelperImage
+	}
+
+	usedSize := session.TotalSize
+	for _, vol := range volumes {
+		if ctx.Err() != nil {
+			break
+		}
+		if t.contains(params.ExcludeVolumes, vol.Name) {
+			continue
+		}
 
-	logrus.Info("Volume backup completed (placeholder)")
+		operation := t.backupVolume(ctx, vol, volumesDir, helperImage, params.MaxBackupSize, usedSize)
+		usedSize += operation.Size
+		operations = append(operations, operation)
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"volume_count": len(operations),
+		"volumes_dir":  volumesDir,
+	}).Info("Volume backup completed")
 
 	return operations
 }
 
+// backupVolume archives one volume to volumesDir. maxSize bounds the whole
+// backup, of which usedSize is already taken.
+func (t *BackupTask) backupVolume(ctx context.Context, vol *volume.Volume, volumesDir, helperImage string, maxSize, usedSize int64) BackupOperation {
+	operation := BackupOperation{
+		Type:       "volume",
+		Name:       vol.Name,
+		SourcePath: vol.Mountpoint,
+	}
+
+	startTime := time.Now()
+	defer func() {
+		operation.Duration = time.Since(startTime)
+	}()
+
+	var limit int64
+	if maxSize > 0 {
+		limit = maxSize - usedSize
+		if limit <= 0 {
+			operation.Error = errBackupSizeExceeded.Error()
+			return operation
+		}
+	}
+
+	backupFile := filepath.Join(volumesDir, vol.Name+".tar.gz")
+	operation.BackupPath = backupFile
+
+	size, checksum, err := writeGzipArtifact(backupFile, limit, func(w io.Writer) error {
+		_, err := t.dockerClient.ExportVolume(ctx, vol.Name, helperImage, w)
+		return err
+	})
+	if err != nil {
+		operation.Error = fmt.Sprintf("Failed to archive volume: %v", err)
+		return operation
+	}
+
+	operation.Size = size
+	operation.Checksum = checksum
+	operation.Success = true
+	return operation
+}
+
 // backupImages backs up Docker images
 func (t *BackupTask) backupImages(ctx context.Context, session *BackupSession, params *BackupParameters) []BackupOperation {
 	var operations []BackupOperation
@@ -601,30 +708,13 @@ func (t *BackupTask) backupImages(ctx context.Context, session *BackupSession, p
 		return operations
 	}
 
-	// This is a placeholder implementation
-	// Real implementation would:
-	// 1. List Docker images
-	// 2. Export images to tar files
-	// 3. Save to backup directory
-
-	operation := BackupOperation{
-		Type:    "images",
-		Name:    "Docker Images (placeholder)",
-		Success: true,
-		Size:    0,
-	}
-
-	imagesDir := filepath.Join(session.BackupPath, "images")
-	if err := os.MkdirAll(imagesDir, 0755); err != nil {
-		operation.Error = fmt.Sprintf("Failed to create images directory: %v", err)
-		operation.Success = false
-	} else {
-		operation.BackupPath = imagesDir
-	}
-
-	operations = append(operations, operation)
-
-	logrus.Info("Image backup completed (placeholder)")
+	// Images can be pulled again from their registries; exporting them is
+	// not supported, and reporting it as done would hide that
+	operations = append(operations, BackupOperation{
+		Type:  "images",
+		Name:  "Docker Images",
+		Error: "Image backup is not supported",
+	})
 
 	return operations
 }
@@ -641,25 +731,152 @@ func (t *BackupTask) compressBackup(ctx context.Context, session *BackupSession,
 		operation.Duration = time.Since(startTime)
 	}()
 
-	// This is a placeholder implementation
-	// Real implementation would use tar/gzip to compress the backup directory
-
 	compressedFile := session.BackupPath + ".tar.gz"
+	operation.SourcePath = session.BackupPath
 	operation.BackupPath = compressedFile
 
-	// Placeholder: Create empty compressed file
-	file, err := os.Create(compressedFile)
+	size, checksum, err := writeGzipArtifact(compressedFile, 0, func(w io.Writer) error {
+		return writeTar(ctx, session.BackupPath, w)
+	})
 	if err != nil {
-		operation.Error = fmt.Sprintf("Failed to create compressed backup: %v", err)
+		operation.Error = fmt.Sprintf("Failed to compress backup: %v", err)
 		return operation
 	}
-	file.Close()
 
+	operation.Size = size
+	operation.Checksum = checksum
 	operation.Success = true
 	session.IsCompressed = true
+	session.CompressedSize = size
+
+	logrus.WithField("compressed_file", compressedFile).Info("Backup compression completed")
+
+	return operation
+}
+
+// createManifest records every file of the backup with its SHA-256 in
+// manifest.json
+func (t *BackupTask) createManifest(ctx context.Context, session *BackupSession) BackupOperation {
+	operation := BackupOperation{
+		Type: "manifest",
+		Name: "Backup Manifest",
+	}
+
+	startTime := time.Now()
+	defer func() {
+		operation.Duration = time.Since(startTime)
+	}()
+
+	manifestFile := filepath.Join(session.BackupPath, model.BackupManifestFile)
+	manifest := &model.BackupManifest{
+		Version:    "1",
+		CreatedAt:  time.Now(),
+		BackupType: session.BackupType,
+		Checksums:  map[string]string{},
+		Metadata:   map[string]interface{}{"backup_id": session.BackupID},
+	}
+
+	err := filepath.WalkDir(session.BackupPath, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !entry.Type().IsRegular() || path == manifestFile {
+			return nil
+		}
 
-	logrus.WithField("compressed_file", compressedFile).Info("Backup compression completed (placeholder)")
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		checksum, err := checksumFile(path)
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		rel, err := filepath.Rel(session.BackupPath, path)
+		if err != nil {
+			return err
+		}
 
+		manifest.Checksums[filepath.ToSlash(rel)] = checksum
+		manifest.FileCount++
+		manifest.TotalSize += info.Size()
+		return nil
+	})
+	if err != nil {
+		operation.Error = fmt.Sprintf("Failed to checksum backup files: %v", err)
+		return operation
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		operation.Error = fmt.Sprintf("Failed to marshal manifest: %v", err)
+		return operation
+	}
+	if err := os.WriteFile(manifestFile, data, 0644); err != nil {
+		operation.Error = fmt.Sprintf("Failed to write manifest: %v", err)
+		return operation
+	}
+
+	session.Manifest = manifest
+	operation.BackupPath = manifestFile
+	operation.Size = int64(len(data))
+	operation.Success = true
+	operation.Metadata = map[string]interface{}{"file_count": manifest.FileCount}
+
+	return operation
+}
+
+// verifyBackup re-reads every artifact and the manifest's files and checks
+// them against the checksums taken when they were written
+func (t *BackupTask) verifyBackup(ctx context.Context, session *BackupSession) BackupOperation {
+	operation := BackupOperation{
+		Type: "verification",
+		Name: "Backup Verification",
+	}
+
+	startTime := time.Now()
+	defer func() {
+		operation.Duration = time.Since(startTime)
+	}()
+
+	expected := map[string]string{}
+	if session.Manifest != nil {
+		for rel, checksum := range session.Manifest.Checksums {
+			expected[filepath.Join(session.BackupPath, filepath.FromSlash(rel))] = checksum
+		}
+	}
+	for _, op := range session.Operations {
+		if op.Success && op.Checksum != "" {
+			expected[op.BackupPath] = op.Checksum
+		}
+	}
+
+	var mismatched []string
+	for path, checksum := range expected {
+		if err := ctx.Err(); err != nil {
+			operation.Error = fmt.Sprintf("Stopped before verifying all files: %v", err)
+			return operation
+		}
+		actual, err := checksumFile(path)
+		if err != nil {
+			mismatched = append(mismatched, fmt.Sprintf("%s: %v", path, err))
+			continue
+		}
+		if actual != checksum {
+			mismatched = append(mismatched, fmt.Sprintf("%s: checksum mismatch", path))
+		}
+	}
+
+	operation.Metadata = map[string]interface{}{"verified_files": len(expected) - len(mismatched)}
+	if len(mismatched) > 0 {
+		operation.Error = fmt.Sprintf("Verification failed for %d files: %s", len(mismatched), strings.Join(mismatched, "; "))
+		return operation
+	}
+
+	operation.Success = true
 	return operation
 }
 
@@ -756,16 +973,16 @@ func (t *BackupTask) sendBackupNotification(ctx context.Context, session *Backup
 		Title:    title,
 		Message:  message,
 		Priority: priority,
-		Data: map[string]interface{}{
-			"backup_id":             session.BackupID,
-			"backup_path":           session.BackupPath,
-			"successful_operations": session.SuccessfulOperations,
-			"failed_operations":     session.FailedOperations,
-			"total_size":           session.TotalSize,
-			"duration":             session.Duration.String(),
-			"backup_type":          session.BackupType,
-			"is_compressed":        session.IsCompressed,
-		},
+		Data: model.NotificationData(model.NewBackupSummaryPayload(
+			session.BackupID,
+			session.BackupPath,
+			session.BackupType,
+			session.SuccessfulOperations,
+			session.FailedOperations,
+			session.TotalSize,
+			session.IsCompressed,
+			session.Duration,
+		)),
 	}
 
 	return t.notificationService.SendNotification(ctx, notification)
@@ -0,0 +1,233 @@
+package tasks
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"docker-auto/internal/config"
+	"docker-auto/pkg/model"
+
+	"gorm.io/gorm"
+)
+
+// errBackupSizeExceeded is returned when an artifact would take the backup
+// past MaxBackupSize
+var errBackupSizeExceeded = errors.New("backup size limit exceeded")
+
+// limitedWriter counts the bytes written through it and fails once they
+// pass limit. A limit of zero or less means no limit.
+type limitedWriter struct {
+	w     io.Writer
+	limit int64
+	n     int64
+}
+
+func (l *limitedWriter) Write(p []byte) (int, error) {
+	if l.limit > 0 && l.n+int64(len(p)) > l.limit {
+		return 0, errBackupSizeExceeded
+	}
+	n, err := l.w.Write(p)
+	l.n += int64(n)
+	return n, err
+}
+
+// writeGzipArtifact gzips what write produces into a new file at path and
+// returns the size and SHA-256 of the file. The file is removed when write
+// fails or the compressed output passes limit.
+func writeGzipArtifact(path string, limit int64, write func(w io.Writer) error) (int64, string, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0600)
+	if err != nil {
+		return 0, "", err
+	}
+
+	hash := sha256.New()
+	counter := &limitedWriter{w: io.MultiWriter(file, hash), limit: limit}
+	gz := gzip.NewWriter(counter)
+
+	err = write(gz)
+	if err == nil {
+		err = gz.Close()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return 0, "", err
+	}
+
+	return counter.n, hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// checksumFile returns the SHA-256 of a file. A gzip file is also
+// decompressed to the end so a truncated or corrupt stream is reported.
+func checksumFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	reader := io.TeeReader(file, hash)
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return "", fmt.Errorf("invalid gzip stream: %w", err)
+		}
+		if _, err := io.Copy(io.Discard, gz); err != nil {
+			return "", fmt.Errorf("invalid gzip stream: %w", err)
+		}
+	}
+	// Hash whatever the gzip reader left unread
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// pgDump runs pg_dump against the configured database and writes the plain
+// SQL dump to w
+func pgDump(ctx context.Context, cfg *config.Config, w io.Writer) error {
+	path, err := exec.LookPath("pg_dump")
+	if err != nil {
+		return fmt.Errorf("pg_dump not found: %w", err)
+	}
+
+	cmd := exec.CommandContext(ctx, path, "--no-owner", "--no-privileges", "--format=plain")
+	cmd.Env = append(os.Environ(), cfg.GetLibpqEnv()...)
+	var stderr bytes.Buffer
+	cmd.Stdout = w
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("pg_dump failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("pg_dump failed: %w", err)
+	}
+	return nil
+}
+
+// exportTables writes every row of the application tables that exist to w
+// as JSON lines of {"table": ..., "row": {...}}
+func exportTables(ctx context.Context, db *gorm.DB, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	for _, m := range model.AllModels() {
+		stmt := &gorm.Statement{DB: db}
+		if err := stmt.Parse(m); err != nil {
+			return fmt.Errorf("failed to parse model %T: %w", m, err)
+		}
+		table := stmt.Schema.Table
+		if !db.Migrator().HasTable(table) {
+			continue
+		}
+
+		rows, err := db.WithContext(ctx).Table(table).Rows()
+		if err != nil {
+			return fmt.Errorf("failed to read table %s: %w", table, err)
+		}
+		columns, err := rows.Columns()
+		if err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+
+		values := make([]interface{}, len(columns))
+		pointers := make([]interface{}, len(columns))
+		for i := range values {
+			pointers[i] = &values[i]
+		}
+		for rows.Next() {
+			if err := rows.Scan(pointers...); err != nil {
+				rows.Close()
+				return fmt.Errorf("failed to scan row of %s: %w", table, err)
+			}
+			row := make(map[string]interface{}, len(columns))
+			for i, column := range columns {
+				if b, ok := values[i].([]byte); ok {
+					row[column] = string(b)
+				} else {
+					row[column] = values[i]
+				}
+			}
+			if err := encoder.Encode(map[string]interface{}{"table": table, "row": row}); err != nil {
+				rows.Close()
+				return err
+			}
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return fmt.Errorf("failed to read table %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// writeTar archives the files under dir into w, with paths relative to dir
+func writeTar(ctx context.Context, dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			return nil
+		}
+
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.Copy(tw, file)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	return tw.Close()
+}
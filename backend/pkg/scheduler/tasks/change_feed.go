@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ChangeFeedTask implements the Task interface for pushing the container
+// change feed to its webhook
+type ChangeFeedTask struct {
+	changeFeedService ChangeFeedService
+}
+
+// NewChangeFeedTask creates a new change feed delivery task
+func NewChangeFeedTask(changeFeedService ChangeFeedService) *ChangeFeedTask {
+	return &ChangeFeedTask{
+		changeFeedService: changeFeedService,
+	}
+}
+
+// ChangeFeedParameters represents parameters for change feed delivery
+type ChangeFeedParameters struct {
+	BatchSize  int `json:"batch_size"`
+	MaxBatches int `json:"max_batches"`
+}
+
+// Execute runs the change feed delivery task
+func (t *ChangeFeedTask) Execute(ctx context.Context, params scheduler.TaskParameters) error {
+	if t.changeFeedService == nil {
+		return fmt.Errorf("change feed service not available")
+	}
+
+	feedParams, err := t.parseParameters(params)
+	if err != nil {
+		return fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	delivered, err := t.changeFeedService.DeliverWebhook(ctx, feedParams.BatchSize, feedParams.MaxBatches)
+	if delivered > 0 {
+		logrus.WithFields(logrus.Fields{
+			"task_type": t.GetType(),
+			"delivered": delivered,
+		}).Info("Delivered container changes to webhook")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to deliver change feed: %w", err)
+	}
+
+	return nil
+}
+
+// GetName returns the task name
+func (t *ChangeFeedTask) GetName() string {
+	return "Change Feed Delivery"
+}
+
+// GetType returns the task type
+func (t *ChangeFeedTask) GetType() model.TaskType {
+	return model.TaskTypeChangeFeed
+}
+
+// Validate validates task parameters
+func (t *ChangeFeedTask) Validate(params scheduler.TaskParameters) error {
+	if params.TaskType != model.TaskTypeChangeFeed {
+		return fmt.Errorf("invalid task type: expected %s, got %s", model.TaskTypeChangeFeed, params.TaskType)
+	}
+
+	if _, err := t.parseParameters(params); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return nil
+}
+
+// GetDefaultTimeout returns the default timeout for this task
+func (t *ChangeFeedTask) GetDefaultTimeout() time.Duration {
+	return 5 * time.Minute
+}
+
+// CanRunConcurrently returns false so deliveries stay in seq order
+func (t *ChangeFeedTask) CanRunConcurrently() bool {
+	return false
+}
+
+// parseParameters parses and validates task parameters
+func (t *ChangeFeedTask) parseParameters(params scheduler.TaskParameters) (*ChangeFeedParameters, error) {
+	feedParams := &ChangeFeedParameters{
+		BatchSize:  100,
+		MaxBatches: 10,
+	}
+
+	if params.Parameters != nil {
+		jsonData, err := json.Marshal(params.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parameters: %w", err)
+		}
+
+		if err := json.Unmarshal(jsonData, feedParams); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
+		}
+	}
+
+	if feedParams.BatchSize < 1 || feedParams.BatchSize > 1000 {
+		return nil, fmt.Errorf("batch_size must be between 1 and 1000")
+	}
+	if feedParams.MaxBatches < 1 {
+		feedParams.MaxBatches = 1
+	}
+
+	return feedParams, nil
+}
@@ -4,20 +4,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"strings"
 	"time"
 
-	"docker-auto/internal/model"
 	"docker-auto/internal/repository"
-	"docker-auto/internal/service"
 	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/scheduler"
 
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/filters"
+	"github.com/docker/docker/api/types/volume"
 	"github.com/sirupsen/logrus"
 )
 
+// Image prune modes
+const (
+	// ImagePruneAge removes unused images older than the retention
+	ImagePruneAge = "age"
+	// ImagePruneManaged also keeps the images of managed containers and the
+	// versions they last updated from, and removes images updates superseded
+	// beyond the kept versions regardless of age
+	ImagePruneManaged = "managed"
+)
+
+// updateHistoryPruneLimit bounds the updates per container looked at for
+// superseded images
+const updateHistoryPruneLimit = 50
+
 // CleanupTask implements the Task interface for system cleanup operations
 type CleanupTask struct {
 	containerRepo       repository.ContainerRepository
@@ -26,8 +41,11 @@ type CleanupTask struct {
 	activityLogRepo     repository.ActivityLogRepository
 	imageVersionRepo    repository.ImageVersionRepository
 	notificationRepo    repository.NotificationRepository
-	containerService    *service.ContainerService
-	notificationService *service.NotificationService
+	scanResultRepo      repository.ScanResultRepository
+	metricRepo          repository.ContainerMetricRepository
+	containerService    ContainerService
+	notificationService NotificationService
+	changeFeedService   ChangeFeedService
 	dockerClient        *docker.DockerClient
 }
 
@@ -39,8 +57,11 @@ func NewCleanupTask(
 	activityLogRepo repository.ActivityLogRepository,
 	imageVersionRepo repository.ImageVersionRepository,
 	notificationRepo repository.NotificationRepository,
-	containerService *service.ContainerService,
-	notificationService *service.NotificationService,
+	scanResultRepo repository.ScanResultRepository,
+	metricRepo repository.ContainerMetricRepository,
+	containerService ContainerService,
+	notificationService NotificationService,
+	changeFeedService ChangeFeedService,
 	dockerClient *docker.DockerClient,
 ) *CleanupTask {
 	return &CleanupTask{
@@ -50,8 +71,11 @@ func NewCleanupTask(
 		activityLogRepo:     activityLogRepo,
 		imageVersionRepo:    imageVersionRepo,
 		notificationRepo:    notificationRepo,
+		scanResultRepo:      scanResultRepo,
+		metricRepo:          metricRepo,
 		containerService:    containerService,
 		notificationService: notificationService,
+		changeFeedService:   changeFeedService,
 		dockerClient:        dockerClient,
 	}
 }
@@ -77,65 +101,38 @@ func (t *CleanupTask) Execute(ctx context.Context, params scheduler.TaskParamete
 		Operations: []CleanupOperation{},
 	}
 
-	// Clean up activity logs
-	if cleanupParams.CleanupActivityLogs {
-		operation := t.cleanupActivityLogs(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
-		}
-	}
-
-	// Clean up update history
-	if cleanupParams.CleanupUpdateHistory {
-		operation := t.cleanupUpdateHistory(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
-		}
+	steps := []struct {
+		enabled bool
+		run     func(context.Context, *CleanupParameters) CleanupOperation
+	}{
+		{cleanupParams.CleanupActivityLogs, t.cleanupActivityLogs},
+		{cleanupParams.CleanupUpdateHistory, t.cleanupUpdateHistory},
+		{cleanupParams.CleanupTaskLogs, t.cleanupTaskExecutionLogs},
+		{cleanupParams.CleanupNotifications, t.cleanupNotifications},
+		{cleanupParams.CleanupImageCache, t.cleanupImageVersionCache},
+		{cleanupParams.CleanupScanResults, t.cleanupScanResults},
+		{cleanupParams.CleanupChangeFeed, t.cleanupChangeFeed},
+		{cleanupParams.CleanupContainerMetrics, t.cleanupContainerMetrics},
+		{cleanupParams.CleanupUnusedImages, t.cleanupDockerImages},
+		{cleanupParams.CleanupStoppedContainers, t.cleanupStoppedContainers},
+		{cleanupParams.CleanupUnusedVolumes, t.cleanupDockerVolumes},
+		{cleanupParams.CleanupUnusedNetworks, t.cleanupDockerNetworks},
 	}
 
-	// Clean up task execution logs
-	if cleanupParams.CleanupTaskLogs {
-		operation := t.cleanupTaskExecutionLogs(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
-		}
-	}
-
-	// Clean up notifications
-	if cleanupParams.CleanupNotifications {
-		operation := t.cleanupNotifications(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
+	itemsRemoved := 0
+	for _, step := range steps {
+		if !step.enabled {
+			continue
 		}
-	}
-
-	// Clean up image version cache
-	if cleanupParams.CleanupImageCache {
-		operation := t.cleanupImageVersionCache(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
+		// A cancelled run stops between operations
+		if ctx.Err() != nil {
+			break
 		}
-	}
 
-	// Clean up Docker images
-	if cleanupParams.CleanupUnusedImages {
-		operation := t.cleanupDockerImages(ctx, cleanupParams)
+		operation := step.run(ctx, cleanupParams)
 		results.Operations = append(results.Operations, operation)
+		itemsRemoved += operation.ItemsRemoved
+		results.TotalSpaceFreed += operation.SpaceFreed
 		if operation.Success {
 			results.SuccessfulOperations++
 		} else {
@@ -143,42 +140,21 @@ func (t *CleanupTask) Execute(ctx context.Context, params scheduler.TaskParamete
 		}
 	}
 
-	// Clean up Docker containers
-	if cleanupParams.CleanupStoppedContainers {
-		operation := t.cleanupStoppedContainers(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
-		}
-	}
+	results.CompletedAt = time.Now()
+	results.Duration = results.CompletedAt.Sub(results.StartedAt)
 
-	// Clean up Docker volumes
-	if cleanupParams.CleanupUnusedVolumes {
-		operation := t.cleanupDockerVolumes(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
-		}
-	}
+	scheduler.SetResultData(ctx, "operations", len(results.Operations))
+	scheduler.SetResultData(ctx, "failed_operations", results.FailedOperations)
+	scheduler.SetResultData(ctx, "items_removed", itemsRemoved)
 
-	// Clean up Docker networks
-	if cleanupParams.CleanupUnusedNetworks {
-		operation := t.cleanupDockerNetworks(ctx, cleanupParams)
-		results.Operations = append(results.Operations, operation)
-		if operation.Success {
-			results.SuccessfulOperations++
-		} else {
-			results.FailedOperations++
-		}
+	if err := ctx.Err(); err != nil {
+		logger.WithFields(logrus.Fields{
+			"completed_operations": len(results.Operations),
+			"items_removed":        itemsRemoved,
+		}).Warn("System cleanup task stopped early")
+		return fmt.Errorf("cleanup stopped after %d operations: %w", len(results.Operations), err)
 	}
 
-	results.CompletedAt = time.Now()
-	results.Duration = results.CompletedAt.Sub(results.StartedAt)
-
 	// Send notification about cleanup results
 	if err := t.sendCleanupNotification(ctx, results, cleanupParams); err != nil {
 		logger.WithError(err).Warn("Failed to send cleanup notification")
@@ -237,11 +213,17 @@ type CleanupParameters struct {
 	TaskLogRetentionDays        int  `json:"task_log_retention_days"`
 	NotificationRetentionDays   int  `json:"notification_retention_days"`
 	ImageCacheRetentionDays     int  `json:"image_cache_retention_days"`
+	ScanResultRetentionDays     int  `json:"scan_result_retention_days"`
+	ChangeFeedRetentionDays     int  `json:"change_feed_retention_days"`
+	ContainerMetricsRetentionDays int `json:"container_metrics_retention_days"`
 	CleanupActivityLogs         bool `json:"cleanup_activity_logs"`
 	CleanupUpdateHistory        bool `json:"cleanup_update_history"`
 	CleanupTaskLogs             bool `json:"cleanup_task_logs"`
 	CleanupNotifications        bool `json:"cleanup_notifications"`
 	CleanupImageCache           bool `json:"cleanup_image_cache"`
+	CleanupScanResults          bool `json:"cleanup_scan_results"`
+	CleanupChangeFeed           bool `json:"cleanup_change_feed"`
+	CleanupContainerMetrics     bool `json:"cleanup_container_metrics"`
 
 	// Docker cleanup
 	CleanupUnusedImages         bool     `json:"cleanup_unused_images"`
@@ -257,6 +239,8 @@ type CleanupParameters struct {
 	ExcludeVolumes              []string `json:"exclude_volumes"`
 	ExcludeNetworks             []string `json:"exclude_networks"`
 	ForceRemoveImages           bool     `json:"force_remove_images"`
+	ImagePruneMode              string   `json:"image_prune_mode"`    // age or managed
+	KeepImageVersions           int      `json:"keep_image_versions"` // superseded versions kept per container in managed mode
 	DryRun                      bool     `json:"dry_run"`
 
 	// Notification settings
@@ -298,11 +282,17 @@ func (t *CleanupTask) parseParameters(params scheduler.TaskParameters) (*Cleanup
 		TaskLogRetentionDays:        30,
 		NotificationRetentionDays:   7,
 		ImageCacheRetentionDays:     7,
+		ScanResultRetentionDays:     30,
+		ChangeFeedRetentionDays:     90,
+		ContainerMetricsRetentionDays: 7,
 		CleanupActivityLogs:         true,
 		CleanupUpdateHistory:        true,
 		CleanupTaskLogs:             true,
 		CleanupNotifications:        true,
 		CleanupImageCache:           true,
+		CleanupScanResults:          true,
+		CleanupChangeFeed:           true,
+		CleanupContainerMetrics:     true,
 		CleanupUnusedImages:         true,
 		CleanupDanglingImages:       true,
 		CleanupStoppedContainers:    true,
@@ -316,6 +306,8 @@ func (t *CleanupTask) parseParameters(params scheduler.TaskParameters) (*Cleanup
 		ExcludeVolumes:              []string{},
 		ExcludeNetworks:             []string{"bridge", "host", "none"},
 		ForceRemoveImages:           false,
+		ImagePruneMode:              ImagePruneManaged,
+		KeepImageVersions:           2,
 		DryRun:                      false,
 		NotifyOnCompletion:          true,
 		NotifyOnErrors:              true,
@@ -344,6 +336,21 @@ func (t *CleanupTask) parseParameters(params scheduler.TaskParameters) (*Cleanup
 	if cleanupParams.TaskLogRetentionDays < 1 {
 		cleanupParams.TaskLogRetentionDays = 1
 	}
+	if cleanupParams.ScanResultRetentionDays < 1 {
+		cleanupParams.ScanResultRetentionDays = 1
+	}
+	if cleanupParams.ChangeFeedRetentionDays < 1 {
+		cleanupParams.ChangeFeedRetentionDays = 1
+	}
+	if cleanupParams.ContainerMetricsRetentionDays < 1 {
+		cleanupParams.ContainerMetricsRetentionDays = 1
+	}
+	if cleanupParams.ImagePruneMode != ImagePruneAge && cleanupParams.ImagePruneMode != ImagePruneManaged {
+		return nil, fmt.Errorf("invalid image_prune_mode %q: must be %s or %s", cleanupParams.ImagePruneMode, ImagePruneAge, ImagePruneManaged)
+	}
+	if cleanupParams.KeepImageVersions < 0 {
+		cleanupParams.KeepImageVersions = 0
+	}
 
 	return cleanupParams, nil
 }
@@ -610,6 +617,161 @@ func (t *CleanupTask) cleanupImageVersionCache(ctx context.Context, params *Clea
 	return operation
 }
 
+// cleanupScanResults removes old image scan results. Results for digests that
+// are still deployed are kept regardless of age.
+func (t *CleanupTask) cleanupScanResults(ctx context.Context, params *CleanupParameters) CleanupOperation {
+	operation := CleanupOperation{
+		Type:        "scan_results",
+		Description: "Clean up old image scan results",
+		DryRun:      params.DryRun,
+	}
+
+	startTime := time.Now()
+	defer func() {
+		operation.Duration = time.Since(startTime)
+	}()
+
+	if t.scanResultRepo == nil {
+		operation.Error = "Scan result repository not available"
+		operation.Success = false
+		return operation
+	}
+
+	keepDigests, err := t.deployedDigests(ctx)
+	if err != nil {
+		// Pruning without knowing what is deployed could drop live results
+		operation.Error = err.Error()
+		operation.Success = false
+		return operation
+	}
+
+	cutoffDate := time.Now().AddDate(0, 0, -params.ScanResultRetentionDays)
+
+	if params.DryRun {
+		count, err := t.scanResultRepo.CountOlderThan(ctx, cutoffDate, keepDigests)
+		if err != nil {
+			operation.Error = err.Error()
+			operation.Success = false
+			return operation
+		}
+		operation.ItemsRemoved = int(count)
+		operation.Success = true
+		operation.Description += fmt.Sprintf(" (DRY RUN: would remove %d items)", count)
+		return operation
+	}
+
+	deletedCount, err := t.scanResultRepo.DeleteOlderThan(ctx, cutoffDate, keepDigests)
+	if err != nil {
+		operation.Error = err.Error()
+		operation.Success = false
+		return operation
+	}
+
+	operation.ItemsRemoved = int(deletedCount)
+	operation.Success = true
+
+	logrus.WithFields(logrus.Fields{
+		"deleted_count": deletedCount,
+		"kept_digests":  len(keepDigests),
+		"cutoff_date":   cutoffDate,
+	}).Info("Cleaned up image scan results")
+
+	return operation
+}
+
+// cleanupChangeFeed removes old container change feed records
+func (t *CleanupTask) cleanupChangeFeed(ctx context.Context, params *CleanupParameters) CleanupOperation {
+	operation := CleanupOperation{
+		Type:        "change_feed",
+		Description: "Clean up old container change feed records",
+		DryRun:      params.DryRun,
+	}
+
+	startTime := time.Now()
+	defer func() {
+		operation.Duration = time.Since(startTime)
+	}()
+
+	if t.changeFeedService == nil {
+		operation.Error = "Change feed service not available"
+		operation.Success = false
+		return operation
+	}
+
+	count, err := t.changeFeedService.PruneChanges(ctx, params.ChangeFeedRetentionDays, params.DryRun)
+	if err != nil {
+		operation.Error = err.Error()
+		operation.Success = false
+		return operation
+	}
+
+	operation.ItemsRemoved = int(count)
+	operation.Success = true
+	if params.DryRun {
+		operation.Description += fmt.Sprintf(" (DRY RUN: would remove %d items)", count)
+		return operation
+	}
+
+	logrus.WithFields(logrus.Fields{
+		"deleted_count":  count,
+		"retention_days": params.ChangeFeedRetentionDays,
+	}).Info("Cleaned up container change feed")
+
+	return operation
+}
+
+// cleanupContainerMetrics removes old container stats history
+func (t *CleanupTask) cleanupContainerMetrics(ctx context.Context, params *CleanupParameters) CleanupOperation {
+	operation := CleanupOperation{
+		Type:        "container_metrics",
+		Description: "Clean up old container stats history",
+		DryRun:      params.DryRun,
+	}
+
+	startTime := time.Now()
+	defer func() {
+		operation.Duration = time.Since(startTime)
+	}()
+
+	if t.metricRepo == nil {
+		operation.Error = "Container metric repository not available"
+		operation.Success = false
+		return operation
+	}
+
+	cutoffDate := time.Now().AddDate(0, 0, -params.ContainerMetricsRetentionDays)
+
+	if params.DryRun {
+		count, err := t.metricRepo.CountOlderThan(ctx, cutoffDate)
+		if err != nil {
+			operation.Error = err.Error()
+			operation.Success = false
+			return operation
+		}
+		operation.ItemsRemoved = int(count)
+		operation.Success = true
+		operation.Description += fmt.Sprintf(" (DRY RUN: would remove %d items)", count)
+		return operation
+	}
+
+	deletedCount, err := t.metricRepo.DeleteOlderThan(ctx, cutoffDate)
+	if err != nil {
+		operation.Error = err.Error()
+		operation.Success = false
+		return operation
+	}
+
+	operation.ItemsRemoved = int(deletedCount)
+	operation.Success = true
+
+	logrus.WithFields(logrus.Fields{
+		"deleted_count":  deletedCount,
+		"retention_days": params.ContainerMetricsRetentionDays,
+	}).Info("Cleaned up container stats history")
+
+	return operation
+}
+
 // cleanupDockerImages removes unused Docker images
 func (t *CleanupTask) cleanupDockerImages(ctx context.Context, params *CleanupParameters) CleanupOperation {
 	operation := CleanupOperation{
@@ -639,12 +801,24 @@ func (t *CleanupTask) cleanupDockerImages(ctx context.Context, params *CleanupPa
 		return operation
 	}
 
+	var protected, superseded imageRefs
+	if params.ImagePruneMode == ImagePruneManaged {
+		protected, superseded, err = t.managedImages(ctx, params.KeepImageVersions)
+		if err != nil {
+			operation.Error = fmt.Sprintf("Failed to get images of managed containers: %v", err)
+			operation.Success = false
+			return operation
+		}
+	}
+
 	var imagesToRemove []string
 	var spaceToFree int64
+	protectedCount, supersededCount := 0, 0
+	cutoffDate := time.Now().AddDate(0, 0, -params.ImageRetentionDays)
 
 	for _, image := range images {
-		// Skip images that are in use
-		if t.isImageInUse(ctx, image.ID) {
+		if protected.matches(image) {
+			protectedCount++
 			continue
 		}
 
@@ -661,20 +835,31 @@ func (t *CleanupTask) cleanupDockerImages(ctx context.Context, params *CleanupPa
 			continue
 		}
 
-		// Check if image is old enough
-		if params.ImageRetentionDays > 0 {
-			cutoffDate := time.Now().AddDate(0, 0, -params.ImageRetentionDays)
-			if dockerImage.Created.After(cutoffDate) {
-				continue
-			}
+		// Superseded images go regardless of age; others once old enough
+		isSuperseded := superseded.matches(image)
+		if !isSuperseded && params.ImageRetentionDays > 0 && dockerImage.Created.After(cutoffDate) {
+			continue
 		}
 
+		// Checked last as it queries the daemon
+		if t.isImageInUse(ctx, image.ID) {
+			continue
+		}
+
+		if isSuperseded {
+			supersededCount++
+		}
 		imagesToRemove = append(imagesToRemove, image.ID)
 		spaceToFree += image.Size
 	}
 
 	operation.ItemsRemoved = len(imagesToRemove)
 	operation.SpaceFreed = spaceToFree
+	operation.Details = map[string]interface{}{
+		"mode":       params.ImagePruneMode,
+		"protected":  protectedCount,
+		"superseded": supersededCount,
+	}
 
 	if params.DryRun {
 		operation.Success = true
@@ -683,25 +868,28 @@ func (t *CleanupTask) cleanupDockerImages(ctx context.Context, params *CleanupPa
 	}
 
 	// Remove images
-	var removedCount int
-	var actualSpaceFreed int64
-
-	for _, imageID := range imagesToRemove {
+	removedCount, err := removeEach(ctx, imagesToRemove, func(ctx context.Context, imageID string) error {
 		_, err := t.dockerClient.RemoveImage(ctx, imageID, types.ImageRemoveOptions{
 			Force:         params.ForceRemoveImages,
 			PruneChildren: true,
 		})
 		if err != nil {
 			logrus.WithError(err).WithField("image_id", imageID).Warn("Failed to remove image")
-		} else {
-			removedCount++
-			// In a real implementation, you'd calculate actual space freed
-			actualSpaceFreed += spaceToFree / int64(len(imagesToRemove))
 		}
+		return err
+	})
+	// In a real implementation, you'd calculate actual space freed
+	actualSpaceFreed := int64(0)
+	if len(imagesToRemove) > 0 {
+		actualSpaceFreed = spaceToFree / int64(len(imagesToRemove)) * int64(removedCount)
 	}
 
 	operation.ItemsRemoved = removedCount
 	operation.SpaceFreed = actualSpaceFreed
+	if err != nil {
+		operation.Error = fmt.Sprintf("Stopped after removing %d of %d images: %v", removedCount, len(imagesToRemove), err)
+		return operation
+	}
 	operation.Success = true
 
 	logrus.WithFields(logrus.Fields{
@@ -783,21 +971,22 @@ func (t *CleanupTask) cleanupStoppedContainers(ctx context.Context, params *Clea
 	}
 
 	// Remove containers
-	var removedCount int
-
-	for _, containerID := range containersToRemove {
+	removedCount, err := removeEach(ctx, containersToRemove, func(ctx context.Context, containerID string) error {
 		err := t.dockerClient.RemoveContainer(ctx, containerID, types.ContainerRemoveOptions{
 			RemoveVolumes: true,
 			Force:         true,
 		})
 		if err != nil {
 			logrus.WithError(err).WithField("container_id", containerID).Warn("Failed to remove container")
-		} else {
-			removedCount++
 		}
-	}
+		return err
+	})
 
 	operation.ItemsRemoved = removedCount
+	if err != nil {
+		operation.Error = fmt.Sprintf("Stopped after removing %d of %d containers: %v", removedCount, len(containersToRemove), err)
+		return operation
+	}
 	operation.Success = true
 
 	logrus.WithFields(logrus.Fields{
@@ -826,12 +1015,69 @@ func (t *CleanupTask) cleanupDockerVolumes(ctx context.Context, params *CleanupP
 		return operation
 	}
 
-	// This is a placeholder implementation
-	// Real implementation would use Docker API to list and remove unused volumes
-	operation.ItemsRemoved = 0
+	// Only volumes no container references are listed
+	volumes, err := t.dockerClient.ListVolumes(ctx, volume.ListOptions{
+		Filters: filters.NewArgs(filters.Arg("dangling", "true")),
+	})
+	if err != nil {
+		operation.Error = fmt.Sprintf("Failed to list volumes: %v", err)
+		operation.Success = false
+		return operation
+	}
+
+	volumesToRemove := selectVolumes(volumes, params.ExcludeVolumes, params.VolumeRetentionDays, time.Now())
+
+	// Sizes come from the daemon's usage data; drivers that do not report
+	// them count as zero
+	sizes := make(map[string]int64)
+	if len(volumesToRemove) > 0 {
+		usage, err := t.dockerClient.GetVolumeUsage(ctx)
+		if err != nil {
+			logrus.WithError(err).Warn("Failed to get volume usage, space freed will not be reported")
+		}
+		for _, v := range usage {
+			if v.UsageData != nil && v.UsageData.Size > 0 {
+				sizes[v.Name] = v.UsageData.Size
+			}
+		}
+	}
+
+	var spaceToFree int64
+	for _, name := range volumesToRemove {
+		spaceToFree += sizes[name]
+	}
+
+	operation.ItemsRemoved = len(volumesToRemove)
+	operation.SpaceFreed = spaceToFree
+
+	if params.DryRun {
+		operation.Success = true
+		operation.Description += fmt.Sprintf(" (DRY RUN: would remove %d volumes, %d bytes)", len(volumesToRemove), spaceToFree)
+		return operation
+	}
+
+	var spaceFreed int64
+	removedCount, err := removeEach(ctx, volumesToRemove, func(ctx context.Context, name string) error {
+		if err := t.dockerClient.RemoveVolume(ctx, name, false); err != nil {
+			logrus.WithError(err).WithField("volume", name).Warn("Failed to remove volume")
+			return err
+		}
+		spaceFreed += sizes[name]
+		return nil
+	})
+
+	operation.ItemsRemoved = removedCount
+	operation.SpaceFreed = spaceFreed
+	if err != nil {
+		operation.Error = fmt.Sprintf("Stopped after removing %d of %d volumes: %v", removedCount, len(volumesToRemove), err)
+		return operation
+	}
 	operation.Success = true
 
-	logrus.Info("Docker volume cleanup completed")
+	logrus.WithFields(logrus.Fields{
+		"removed_count": removedCount,
+		"space_freed":   spaceFreed,
+	}).Info("Cleaned up Docker volumes")
 
 	return operation
 }
@@ -855,12 +1101,47 @@ func (t *CleanupTask) cleanupDockerNetworks(ctx context.Context, params *Cleanup
 		return operation
 	}
 
-	// This is a placeholder implementation
-	// Real implementation would use Docker API to list and remove unused networks
-	operation.ItemsRemoved = 0
+	// Only user-defined networks without attached containers are listed
+	networks, err := t.dockerClient.ListNetworks(ctx, types.NetworkListOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("dangling", "true"),
+			filters.Arg("type", "custom"),
+		),
+	})
+	if err != nil {
+		operation.Error = fmt.Sprintf("Failed to list networks: %v", err)
+		operation.Success = false
+		return operation
+	}
+
+	networksToRemove := selectNetworks(networks, params.ExcludeNetworks)
+
+	operation.ItemsRemoved = len(networksToRemove)
+
+	if params.DryRun {
+		operation.Success = true
+		operation.Description += fmt.Sprintf(" (DRY RUN: would remove %d networks)", len(networksToRemove))
+		return operation
+	}
+
+	removedCount, err := removeEach(ctx, networksToRemove, func(ctx context.Context, networkID string) error {
+		err := t.dockerClient.RemoveNetwork(ctx, networkID)
+		if err != nil {
+			logrus.WithError(err).WithField("network_id", networkID).Warn("Failed to remove network")
+		}
+		return err
+	})
+
+	operation.ItemsRemoved = removedCount
+	if err != nil {
+		operation.Error = fmt.Sprintf("Stopped after removing %d of %d networks: %v", removedCount, len(networksToRemove), err)
+		return operation
+	}
 	operation.Success = true
 
-	logrus.Info("Docker network cleanup completed")
+	logrus.WithFields(logrus.Fields{
+		"removed_count": removedCount,
+	}).Info("Cleaned up Docker networks")
 
 	return operation
 }
@@ -888,18 +1169,30 @@ func (t *CleanupTask) sendCleanupNotification(ctx context.Context, results *Clea
 		priority = model.NotificationPriorityHigh
 	}
 
+	operations := make([]model.CleanupOperationEntry, 0, len(results.Operations))
+	for _, operation := range results.Operations {
+		operations = append(operations, model.CleanupOperationEntry{
+			Type:            operation.Type,
+			Description:     operation.Description,
+			Success:         operation.Success,
+			Error:           operation.Error,
+			ItemsRemoved:    operation.ItemsRemoved,
+			SpaceFreedBytes: operation.SpaceFreed,
+		})
+	}
+
 	notification := &model.Notification{
 		Type:     model.NotificationTypeSystemMaintenance,
 		Title:    title,
 		Message:  message,
 		Priority: priority,
-		Data: map[string]interface{}{
-			"successful_operations": results.SuccessfulOperations,
-			"failed_operations":     results.FailedOperations,
-			"total_space_freed":     results.TotalSpaceFreed,
-			"duration":             results.Duration.String(),
-			"operations":           results.Operations,
-		},
+		Data: model.NotificationData(model.NewCleanupSummaryPayload(
+			results.SuccessfulOperations,
+			results.FailedOperations,
+			results.TotalSpaceFreed,
+			results.Duration,
+			operations,
+		)),
 	}
 
 	return t.notificationService.SendNotification(ctx, notification)
@@ -907,16 +1200,233 @@ func (t *CleanupTask) sendCleanupNotification(ctx context.Context, results *Clea
 
 // Helper methods
 
+// removeEach removes the items one at a time and returns how many were
+// removed. Failures are skipped; a cancelled or timed out context stops the
+// batch before the next item and its error is returned.
+func removeEach(ctx context.Context, ids []string, remove func(context.Context, string) error) (int, error) {
+	removed := 0
+	for _, id := range ids {
+		if err := ctx.Err(); err != nil {
+			return removed, err
+		}
+		if remove(ctx, id) == nil {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// selectVolumes returns the names of the volumes to remove: those not
+// excluded by name and, with a retention period, last used or created
+// before it. Volumes whose age cannot be told are kept.
+func selectVolumes(volumes []*volume.Volume, exclude []string, retentionDays int, now time.Time) []string {
+	cutoff := now.AddDate(0, 0, -retentionDays)
+	var names []string
+	for _, v := range volumes {
+		if slices.Contains(exclude, v.Name) {
+			continue
+		}
+		if retentionDays > 0 {
+			usedAt, ok := volumeLastUsed(v)
+			if !ok || usedAt.After(cutoff) {
+				continue
+			}
+		}
+		names = append(names, v.Name)
+	}
+	return names
+}
+
+// volumeLastUsed returns when a volume was last used according to its
+// VolumeLastUsedLabel, falling back to its creation time
+func volumeLastUsed(v *volume.Volume) (time.Time, bool) {
+	if value, ok := v.Labels[docker.VolumeLastUsedLabel]; ok {
+		if usedAt, err := time.Parse(time.RFC3339, value); err == nil {
+			return usedAt, true
+		}
+	}
+	createdAt, err := time.Parse(time.RFC3339, v.CreatedAt)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return createdAt, true
+}
+
+// selectNetworks returns the IDs of the networks to remove, skipping those
+// excluded by name or ID and the swarm ingress network
+func selectNetworks(networks []types.NetworkResource, exclude []string) []string {
+	var ids []string
+	for _, network := range networks {
+		if network.Ingress || slices.Contains(exclude, network.Name) || slices.Contains(exclude, network.ID) {
+			continue
+		}
+		ids = append(ids, network.ID)
+	}
+	return ids
+}
+
+// isImageInUse reports whether any container, running or not, was created
+// from the image or an image built on it. An image whose use cannot be
+// checked counts as in use.
 func (t *CleanupTask) isImageInUse(ctx context.Context, imageID string) bool {
-	// Check if any containers are using this image
-	// This is a simplified check - real implementation would be more thorough
+	containers, err := t.dockerClient.ListContainers(ctx, types.ContainerListOptions{
+		All:     true,
+		Limit:   1,
+		Filters: filters.NewArgs(filters.Arg("ancestor", imageID)),
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("image_id", imageID).Warn("Failed to check image use, keeping it")
+		return true
+	}
+	return len(containers) > 0
+}
+
+// imageRefs is a set of image references: IDs, names and registry digests
+type imageRefs map[string]bool
+
+// add adds an image reference, also by its digest if it names one
+func (r imageRefs) add(ref string) {
+	if ref == "" {
+		return
+	}
+	r[ref] = true
+	if _, digest, ok := strings.Cut(ref, "@"); ok {
+		r[digest] = true
+	}
+}
+
+// matches reports whether the image is one of the references by ID, tag or
+// registry digest
+func (r imageRefs) matches(image types.ImageSummary) bool {
+	if len(r) == 0 {
+		return false
+	}
+	if r[image.ID] {
+		return true
+	}
+	for _, tag := range image.RepoTags {
+		if r[tag] {
+			return true
+		}
+	}
+	for _, repoDigest := range image.RepoDigests {
+		if _, digest, ok := strings.Cut(repoDigest, "@"); ok && r[digest] {
+			return true
+		}
+	}
 	return false
 }
 
+// managedImages returns the images managed containers keep, their current
+// image and the version they last updated from, and the images their updates
+// superseded beyond the keep most recent versions
+func (t *CleanupTask) managedImages(ctx context.Context, keep int) (protected, superseded imageRefs, err error) {
+	protected, superseded = imageRefs{}, imageRefs{}
+	if t.containerRepo == nil {
+		return protected, superseded, nil
+	}
+
+	containers, _, err := t.containerRepo.List(ctx, &model.ContainerFilter{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	for _, container := range containers {
+		protected.add(container.GetFullImageName())
+		protected.add(container.ImageDigest)
+
+		if t.updateHistoryRepo == nil {
+			continue
+		}
+		histories, _, err := t.updateHistoryRepo.GetByContainerID(ctx, int64(container.ID), updateHistoryPruneLimit, 0)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to get updates of container %s: %w", container.Name, err)
+		}
+
+		// Newest first; the first version is the one a rollback returns to
+		versions := 0
+		for _, history := range histories {
+			if !history.IsSuccessful() && history.Status != model.UpdateStatusCompleted {
+				continue
+			}
+			if history.OldImage == "" && history.OldDigest == "" {
+				continue
+			}
+			versions++
+			switch {
+			case versions == 1:
+				protected.add(history.OldImage)
+				protected.add(history.OldDigest)
+			case versions > keep:
+				superseded.add(history.OldImage)
+				superseded.add(history.OldDigest)
+			}
+		}
+	}
+
+	return protected, superseded, nil
+}
+
+// deployedDigests returns the digests of images managed containers are pinned
+// to or currently running, both registry digests and local image IDs
+func (t *CleanupTask) deployedDigests(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var digests []string
+	add := func(digest string) {
+		if digest != "" && !seen[digest] {
+			seen[digest] = true
+			digests = append(digests, digest)
+		}
+	}
+
+	if t.containerRepo != nil {
+		containers, _, err := t.containerRepo.List(ctx, &model.ContainerFilter{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list containers: %w", err)
+		}
+		for _, container := range containers {
+			add(container.ImageDigest)
+		}
+	}
+
+	if t.dockerClient == nil {
+		return digests, nil
+	}
+
+	containers, err := t.dockerClient.ListContainers(ctx, types.ContainerListOptions{All: true})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Docker containers: %w", err)
+	}
+
+	inspected := make(map[string]bool)
+	for _, container := range containers {
+		if container.ImageID == "" || inspected[container.ImageID] {
+			continue
+		}
+		inspected[container.ImageID] = true
+		add(container.ImageID)
+
+		imageInfo, err := t.dockerClient.InspectImage(ctx, container.ImageID)
+		if err != nil {
+			logrus.WithError(err).WithField("image_id", container.ImageID).Debug("Failed to inspect image")
+			continue
+		}
+		for _, repoDigest := range imageInfo.RepoDigests {
+			if i := strings.Index(repoDigest, "@"); i >= 0 {
+				add(repoDigest[i+1:])
+			}
+		}
+	}
+
+	return digests, nil
+}
+
 func (t *CleanupTask) isImageExcluded(image docker.Image, excludePatterns []string) bool {
 	for _, pattern := range excludePatterns {
-		if strings.Contains(image.RepoTags[0], pattern) {
-			return true
+		for _, tag := range image.RepoTags {
+			if strings.Contains(tag, pattern) {
+				return true
+			}
 		}
 	}
 	return false
@@ -0,0 +1,290 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/volume"
+)
+
+func TestRemoveEachStopsWhenCancelledMidBatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	images := []string{"sha256:a", "sha256:b", "sha256:c", "sha256:d", "sha256:e"}
+	var removed []string
+	count, err := removeEach(ctx, images, func(ctx context.Context, imageID string) error {
+		removed = append(removed, imageID)
+		if len(removed) == 2 {
+			cancel()
+		}
+		return nil
+	})
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v, want context.Canceled", err)
+	}
+	if count != 2 || len(removed) != 2 {
+		t.Fatalf("removed %d images (count %d), want 2 of %d", len(removed), count, len(images))
+	}
+}
+
+func TestRemoveEachSkipsFailures(t *testing.T) {
+	count, err := removeEach(context.Background(), []string{"a", "b", "c"}, func(ctx context.Context, id string) error {
+		if id == "b" {
+			return errors.New("image is in use")
+		}
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("err = %v, want nil", err)
+	}
+	if count != 2 {
+		t.Fatalf("removed %d, want 2", count)
+	}
+}
+
+func TestSelectVolumesRetention(t *testing.T) {
+	now := time.Date(2024, 6, 30, 12, 0, 0, 0, time.UTC)
+	old := now.AddDate(0, 0, -40).Format(time.RFC3339)
+	recent := now.AddDate(0, 0, -5).Format(time.RFC3339)
+
+	volumes := []*volume.Volume{
+		{Name: "old", CreatedAt: old},
+		{Name: "recent", CreatedAt: recent},
+		{Name: "used-recently", CreatedAt: old, Labels: map[string]string{docker.VolumeLastUsedLabel: recent}},
+		{Name: "unused-since", CreatedAt: recent, Labels: map[string]string{docker.VolumeLastUsedLabel: old}},
+		{Name: "bad-label", CreatedAt: old, Labels: map[string]string{docker.VolumeLastUsedLabel: "yesterday"}},
+		{Name: "unknown-age"},
+		{Name: "keep", CreatedAt: old},
+	}
+
+	got := selectVolumes(volumes, []string{"keep"}, 30, now)
+	want := []string{"old", "unused-since", "bad-label"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("selected %v, want %v", got, want)
+	}
+
+	got = selectVolumes(volumes, nil, 0, now)
+	if len(got) != len(volumes) {
+		t.Fatalf("without retention selected %v, want all %d volumes", got, len(volumes))
+	}
+}
+
+func TestSelectNetworksSkipsExcluded(t *testing.T) {
+	networks := []types.NetworkResource{
+		{ID: "n1", Name: "app_default"},
+		{ID: "n2", Name: "bridge"},
+		{ID: "n3", Name: "ingress", Ingress: true},
+		{ID: "n4", Name: "shared"},
+		{ID: "n5", Name: "host_tools"},
+	}
+
+	got := selectNetworks(networks, []string{"bridge", "host", "none", "n4"})
+	want := []string{"n1", "n5"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("selected %v, want %v", got, want)
+	}
+}
+
+// fakeDaemon serves the parts of the Docker API used by volume and network
+// cleanup and records what was removed
+type fakeDaemon struct {
+	mu        sync.Mutex
+	volumes   []*volume.Volume
+	sizes     map[string]int64
+	networks  []types.NetworkResource
+	inUse     map[string]bool
+	removed   []string
+	listQuery []string
+}
+
+func (f *fakeDaemon) client(t *testing.T) *docker.DockerClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(f.serve))
+	t.Cleanup(server.Close)
+
+	dc, err := docker.NewDockerClient(&config.Config{Docker: config.DockerConfig{
+		Host:       "tcp://" + strings.TrimPrefix(server.URL, "http://"),
+		APIVersion: "1.44",
+		Timeout:    5,
+	}})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { dc.Close() })
+	return dc
+}
+
+func (f *fakeDaemon) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1.44")
+	switch {
+	case r.Method == http.MethodGet && path == "/volumes":
+		f.listQuery = append(f.listQuery, r.URL.Query().Get("filters"))
+		json.NewEncoder(w).Encode(volume.ListResponse{Volumes: f.volumes})
+	case r.Method == http.MethodGet && path == "/system/df":
+		usage := types.DiskUsage{}
+		for name, size := range f.sizes {
+			usage.Volumes = append(usage.Volumes, &volume.Volume{Name: name, UsageData: &volume.UsageData{Size: size}})
+		}
+		json.NewEncoder(w).Encode(usage)
+	case r.Method == http.MethodGet && path == "/networks":
+		f.listQuery = append(f.listQuery, r.URL.Query().Get("filters"))
+		json.NewEncoder(w).Encode(f.networks)
+	case r.Method == http.MethodDelete:
+		name := path[strings.LastIndex(path, "/")+1:]
+		if f.inUse[name] {
+			w.WriteHeader(http.StatusConflict)
+			json.NewEncoder(w).Encode(map[string]string{"message": name + " is in use"})
+			return
+		}
+		f.removed = append(f.removed, name)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func volumeCleanupParams(dryRun bool) *CleanupParameters {
+	return &CleanupParameters{
+		VolumeRetentionDays: 30,
+		ExcludeVolumes:      []string{"keep"},
+		ExcludeNetworks:     []string{"bridge", "host", "none"},
+		DryRun:              dryRun,
+	}
+}
+
+func newVolumeDaemon() *fakeDaemon {
+	old := time.Now().AddDate(0, 0, -60).Format(time.RFC3339)
+	return &fakeDaemon{
+		volumes: []*volume.Volume{
+			{Name: "a", CreatedAt: old},
+			{Name: "b", CreatedAt: old},
+			{Name: "keep", CreatedAt: old},
+			{Name: "new", CreatedAt: time.Now().Format(time.RFC3339)},
+		},
+		sizes: map[string]int64{"a": 100, "b": 250, "keep": 1000, "new": 1000},
+	}
+}
+
+func TestCleanupDockerVolumesDryRun(t *testing.T) {
+	daemon := newVolumeDaemon()
+	task := &CleanupTask{dockerClient: daemon.client(t)}
+
+	operation := task.cleanupDockerVolumes(context.Background(), volumeCleanupParams(true))
+
+	if !operation.Success || operation.ItemsRemoved != 2 || operation.SpaceFreed != 350 {
+		t.Fatalf("operation = %+v, want 2 volumes and 350 bytes", operation)
+	}
+	if !strings.Contains(operation.Description, "would remove 2 volumes, 350 bytes") {
+		t.Fatalf("description = %q", operation.Description)
+	}
+	if len(daemon.removed) != 0 {
+		t.Fatalf("dry run removed %v", daemon.removed)
+	}
+	if len(daemon.listQuery) != 1 || !strings.Contains(daemon.listQuery[0], "dangling") {
+		t.Fatalf("list filters = %v, want dangling", daemon.listQuery)
+	}
+}
+
+func TestCleanupDockerVolumesCountsRemovedOnly(t *testing.T) {
+	daemon := newVolumeDaemon()
+	daemon.inUse = map[string]bool{"b": true}
+	task := &CleanupTask{dockerClient: daemon.client(t)}
+
+	operation := task.cleanupDockerVolumes(context.Background(), volumeCleanupParams(false))
+
+	if !operation.Success || operation.ItemsRemoved != 1 || operation.SpaceFreed != 100 {
+		t.Fatalf("operation = %+v, want 1 volume and 100 bytes", operation)
+	}
+	if !reflect.DeepEqual(daemon.removed, []string{"a"}) {
+		t.Fatalf("removed %v, want [a]", daemon.removed)
+	}
+}
+
+func TestCleanupDockerNetworks(t *testing.T) {
+	daemon := &fakeDaemon{networks: []types.NetworkResource{
+		{ID: "n1", Name: "app_default"},
+		{ID: "n2", Name: "bridge"},
+		{ID: "n3", Name: "old_backend"},
+	}}
+	task := &CleanupTask{dockerClient: daemon.client(t)}
+
+	operation := task.cleanupDockerNetworks(context.Background(), volumeCleanupParams(true))
+	if !operation.Success || operation.ItemsRemoved != 2 || len(daemon.removed) != 0 {
+		t.Fatalf("dry run: operation = %+v, removed %v", operation, daemon.removed)
+	}
+
+	operation = task.cleanupDockerNetworks(context.Background(), volumeCleanupParams(false))
+	if !operation.Success || operation.ItemsRemoved != 2 {
+		t.Fatalf("operation = %+v, want 2 networks", operation)
+	}
+	if !reflect.DeepEqual(daemon.removed, []string{"n1", "n3"}) {
+		t.Fatalf("removed %v, want [n1 n3]", daemon.removed)
+	}
+	if !strings.Contains(daemon.listQuery[0], "dangling") || !strings.Contains(daemon.listQuery[0], "custom") {
+		t.Fatalf("list filters = %q, want dangling and type=custom", daemon.listQuery[0])
+	}
+}
+
+// deployedRepo lists containers deployed at fixed digests
+type deployedRepo struct {
+	repository.ContainerRepository
+	containers []*model.Container
+}
+
+func (r *deployedRepo) List(ctx context.Context, filter *model.ContainerFilter) ([]*model.Container, int64, error) {
+	return r.containers, int64(len(r.containers)), nil
+}
+
+func TestCleanupScanResultsKeepsDeployedDigests(t *testing.T) {
+	ctx := context.Background()
+	scans := repository.NewScanResultRepository(newTestDB(t, &model.ScanResult{}))
+	old := time.Now().AddDate(0, 0, -90)
+	for _, digest := range []string{"sha256:deployed", "sha256:stale"} {
+		if err := scans.Upsert(ctx, &model.ScanResult{ImageDigest: digest, ImageName: "nginx", ScannedAt: old}); err != nil {
+			t.Fatalf("Upsert failed: %v", err)
+		}
+	}
+
+	task := &CleanupTask{
+		containerRepo:  &deployedRepo{containers: []*model.Container{{ID: 1, ImageDigest: "sha256:deployed"}}},
+		scanResultRepo: scans,
+	}
+	params := &CleanupParameters{ScanResultRetentionDays: 30}
+
+	params.DryRun = true
+	if operation := task.cleanupScanResults(ctx, params); !operation.Success || operation.ItemsRemoved != 1 {
+		t.Fatalf("dry run = %+v, want one result to remove", operation)
+	}
+	params.DryRun = false
+	if operation := task.cleanupScanResults(ctx, params); !operation.Success || operation.ItemsRemoved != 1 {
+		t.Fatalf("cleanup = %+v, want one result removed", operation)
+	}
+
+	if _, err := scans.GetByDigest(ctx, "sha256:deployed"); err != nil {
+		t.Errorf("scan of the deployed digest was pruned: %v", err)
+	}
+	if _, err := scans.GetByDigest(ctx, "sha256:stale"); err == nil {
+		t.Error("stale scan was kept")
+	}
+}
@@ -0,0 +1,83 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ContainerDiscoveryTask implements the Task interface for adopting the
+// containers labeled for discovery
+type ContainerDiscoveryTask struct {
+	containerService ContainerService
+}
+
+// NewContainerDiscoveryTask creates a new container discovery task
+func NewContainerDiscoveryTask(containerService ContainerService) *ContainerDiscoveryTask {
+	return &ContainerDiscoveryTask{
+		containerService: containerService,
+	}
+}
+
+// Execute runs the container discovery task
+func (t *ContainerDiscoveryTask) Execute(ctx context.Context, params scheduler.TaskParameters) error {
+	if t.containerService == nil {
+		return fmt.Errorf("container service not available")
+	}
+
+	result, err := t.containerService.DiscoverContainers(ctx, model.TaskActor(params.TaskType))
+	if err != nil {
+		return fmt.Errorf("failed to discover containers: %w", err)
+	}
+
+	scheduler.SetResultData(ctx, "labeled", result.Labeled)
+	scheduler.SetResultData(ctx, "adopted", result.Adopted)
+	scheduler.SetResultData(ctx, "updated", result.Updated)
+	scheduler.SetResultData(ctx, "unmanaged", result.Unmanaged)
+	scheduler.SetResultData(ctx, "conflicts", result.Conflicts)
+	scheduler.SetResultData(ctx, "errors", len(result.Errors))
+	scheduler.SetResultData(ctx, "duration_ms", result.Duration.Milliseconds())
+
+	if len(result.Errors) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"task_type": t.GetType(),
+			"errors":    len(result.Errors),
+		}).Warn("Some labeled containers could not be adopted")
+	}
+
+	return nil
+}
+
+// GetName returns the task name
+func (t *ContainerDiscoveryTask) GetName() string {
+	return "Container Discovery"
+}
+
+// GetType returns the task type
+func (t *ContainerDiscoveryTask) GetType() model.TaskType {
+	return model.TaskTypeContainerDiscovery
+}
+
+// Validate validates task parameters
+func (t *ContainerDiscoveryTask) Validate(params scheduler.TaskParameters) error {
+	if params.TaskType != model.TaskTypeContainerDiscovery {
+		return fmt.Errorf("invalid task type: expected %s, got %s", model.TaskTypeContainerDiscovery, params.TaskType)
+	}
+	return nil
+}
+
+// GetDefaultTimeout returns the default timeout for this task
+func (t *ContainerDiscoveryTask) GetDefaultTimeout() time.Duration {
+	return 10 * time.Minute
+}
+
+// CanRunConcurrently returns false so two runs cannot adopt the same
+// container twice
+func (t *ContainerDiscoveryTask) CanRunConcurrently() bool {
+	return false
+}
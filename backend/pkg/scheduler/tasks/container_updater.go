@@ -4,15 +4,21 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
-	"docker-auto/internal/model"
 	"docker-auto/internal/repository"
-	"docker-auto/internal/service"
 	"docker-auto/pkg/docker"
+	"docker-auto/pkg/events"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/schedule"
 	"docker-auto/pkg/scheduler"
 
+	"github.com/docker/docker/api/types"
 	"github.com/sirupsen/logrus"
 )
 
@@ -20,20 +26,22 @@ import (
 type ContainerUpdaterTask struct {
 	containerRepo     repository.ContainerRepository
 	updateHistoryRepo repository.UpdateHistoryRepository
-	containerService  *service.ContainerService
-	imageService      *service.ImageService
-	notificationService *service.NotificationService
+	containerService  ContainerService
+	imageService      ImageService
+	notificationService NotificationService
 	dockerClient      *docker.DockerClient
+	publisher         events.Publisher
 }
 
 // NewContainerUpdaterTask creates a new container updater task
 func NewContainerUpdaterTask(
 	containerRepo repository.ContainerRepository,
 	updateHistoryRepo repository.UpdateHistoryRepository,
-	containerService *service.ContainerService,
-	imageService *service.ImageService,
-	notificationService *service.NotificationService,
+	containerService ContainerService,
+	imageService ImageService,
+	notificationService NotificationService,
 	dockerClient *docker.DockerClient,
+	publisher events.Publisher,
 ) *ContainerUpdaterTask {
 	return &ContainerUpdaterTask{
 		containerRepo:       containerRepo,
@@ -42,6 +50,7 @@ func NewContainerUpdaterTask(
 		imageService:        imageService,
 		notificationService: notificationService,
 		dockerClient:        dockerClient,
+		publisher:           publisher,
 	}
 }
 
@@ -85,6 +94,33 @@ func (t *ContainerUpdaterTask) Execute(ctx context.Context, params scheduler.Tas
 		return fmt.Errorf("failed to update containers: %w", err)
 	}
 
+	scheduler.SetResultData(ctx, scheduler.ResultDataCompleted, len(results.ContainerResults))
+	scheduler.SetResultData(ctx, "successful_updates", results.SuccessfulUpdates)
+	scheduler.SetResultData(ctx, "failed_updates", results.FailedUpdates)
+
+	// Containers not started before a cancellation are left for the next run
+	if err := ctx.Err(); err != nil {
+		scheduler.SetResultData(ctx, scheduler.ResultDataDeferred, len(containers)-len(results.ContainerResults))
+		logger.WithFields(logrus.Fields{
+			"containers_processed": len(results.ContainerResults),
+			"containers_skipped":   len(containers) - len(results.ContainerResults),
+		}).Warn("Container update task stopped early")
+		return fmt.Errorf("container updates stopped after %d of %d containers: %w", len(results.ContainerResults), len(containers), err)
+	}
+
+	// Transient failures are retried by the executor while the task has
+	// retries left; only the failed containers run again
+	if retry := retryTargets(results); len(retry) > 0 && scheduler.RetriesLeft(ctx) > 0 {
+		if updateParams.NotifyOnSuccess && results.SuccessfulUpdates > 0 {
+			t.sendSuccessNotification(ctx, results)
+		}
+		logger.WithField("containers_to_retry", len(retry)).Warn("Container updates failed, retrying")
+		return &scheduler.RetryError{
+			Targets: retry,
+			Err:     fmt.Errorf("%d container update(s) failed", results.FailedUpdates),
+		}
+	}
+
 	// Process results
 	if err := t.processResults(ctx, results, updateParams); err != nil {
 		return fmt.Errorf("failed to process results: %w", err)
@@ -100,6 +136,18 @@ func (t *ContainerUpdaterTask) Execute(ctx context.Context, params scheduler.Tas
 	return nil
 }
 
+// retryTargets returns the containers whose update failed for a transient
+// reason and whose automatic updates are not suspended
+func retryTargets(results *ContainerUpdateTaskResult) []int64 {
+	var ids []int64
+	for _, result := range results.ContainerResults {
+		if !result.Success && result.Recoverable && !result.Container.UpdateSuspended {
+			ids = append(ids, int64(result.Container.ID))
+		}
+	}
+	return ids
+}
+
 // GetName returns the task name
 func (t *ContainerUpdaterTask) GetName() string {
 	return "Container Updater"
@@ -150,8 +198,10 @@ type ContainerUpdateParameters struct {
 	DryRun              bool                   `json:"dry_run"`
 	ForceUpdate         bool                   `json:"force_update"`
 	PullPolicy          string                 `json:"pull_policy"` // always, if-not-present, never
-	StopGracePeriod     time.Duration          `json:"stop_grace_period"`
+	StopGracePeriod     time.Duration          `json:"stop_grace_period"` // for containers without a stop timeout of their own
 	StartupHealthCheck  bool                   `json:"startup_health_check"`
+	StaggerWindows      bool                   `json:"stagger_windows"` // spread same-window starts over the first half of the window
+	SuspendAfterFailures int                   `json:"suspend_after_failures"` // consecutive failed updates that suspend a container's automatic updates; 0 never suspends
 }
 
 // MaintenanceWindow represents a time window for updates
@@ -180,12 +230,16 @@ type SingleContainerUpdateResult struct {
 	NewVersion       string                 `json:"new_version"`
 	Success          bool                   `json:"success"`
 	Error            string                 `json:"error,omitempty"`
+	ErrorCode        string                 `json:"error_code,omitempty"`  // Docker error code of the failure, e.g. PORT_ALLOCATED
+	Recoverable      bool                   `json:"recoverable"`           // The failure is transient and a later run may succeed
 	UpdateHistory    *model.UpdateHistory   `json:"update_history,omitempty"`
 	Duration         time.Duration          `json:"duration"`
 	RolledBack       bool                   `json:"rolled_back"`
+	Interrupted      bool                   `json:"interrupted"`           // Stopped at a checkpoint by a shutdown; the next run resumes it
 	BackupCreated    bool                   `json:"backup_created"`
 	HealthCheckPassed bool                  `json:"health_check_passed"`
 	UpdateSteps      []UpdateStep           `json:"update_steps"`
+	Warnings         []string               `json:"warnings,omitempty"` // Docker daemon warnings from recreating the container
 }
 
 // UpdateStep represents a step in the update process
@@ -204,6 +258,7 @@ type ContainerUpdateError struct {
 	ContainerID   int64  `json:"container_id"`
 	ContainerName string `json:"container_name"`
 	Error         string `json:"error"`
+	Code          string `json:"code,omitempty"`
 	Step          string `json:"step"`
 	Recoverable   bool   `json:"recoverable"`
 }
@@ -228,6 +283,7 @@ func (t *ContainerUpdaterTask) parseParameters(params scheduler.TaskParameters)
 		PullPolicy:        "always",
 		StopGracePeriod:   30 * time.Second,
 		StartupHealthCheck: true,
+		SuspendAfterFailures: 3,
 	}
 
 	// Parse from parameters map
@@ -276,6 +332,11 @@ func (t *ContainerUpdaterTask) getContainersToUpdate(ctx context.Context, target
 				continue
 			}
 
+			if container.UpdateSuspended {
+				logrus.WithField("container_id", containerID).Info("Skipping container with suspended automatic updates")
+				continue
+			}
+
 			// Check if container has updates available
 			if t.hasUpdatesAvailable(ctx, container) {
 				containers = append(containers, container)
@@ -295,6 +356,11 @@ func (t *ContainerUpdaterTask) hasUpdatesAvailable(ctx context.Context, containe
 	// or call the image service to determine if updates are available
 	// For now, we'll implement a simple check
 
+	// Pinned containers only update to a digest proposed by the update checker
+	if container.PinByDigest {
+		return container.HasDigestDrift()
+	}
+
 	if t.imageService == nil {
 		return false
 	}
@@ -306,10 +372,11 @@ func (t *ContainerUpdaterTask) hasUpdatesAvailable(ctx context.Context, containe
 
 // findContainersNeedingUpdates finds all containers that need updates
 func (t *ContainerUpdaterTask) findContainersNeedingUpdates(ctx context.Context, params *ContainerUpdateParameters) []*model.Container {
+	// The update policy may be inherited from an image policy, so eligibility is
+	// decided per container rather than by filtering on the stored column
 	filter := &model.ContainerFilter{
-		UpdatePolicy: &model.UpdatePolicyAuto,
-		Status:       model.ContainerStatusRunning,
-		Limit:        1000,
+		Status: model.ContainerStatusRunning,
+		Limit:  1000,
 	}
 
 	allContainers, _, err := t.containerRepo.List(ctx, filter)
@@ -318,6 +385,7 @@ func (t *ContainerUpdaterTask) findContainersNeedingUpdates(ctx context.Context,
 		return nil
 	}
 
+	now := time.Now()
 	var needingUpdates []*model.Container
 	for _, container := range allContainers {
 		// Skip containers with excluded tags
@@ -326,79 +394,202 @@ func (t *ContainerUpdaterTask) findContainersNeedingUpdates(ctx context.Context,
 		}
 
 		// Check if container has updates available
-		if t.hasUpdatesAvailable(ctx, container) {
-			needingUpdates = append(needingUpdates, container)
+		if !t.hasUpdatesAvailable(ctx, container) {
+			continue
+		}
+
+		if allowed, reason, deferUntil := t.policyAllowsUpdate(ctx, container, now); !allowed {
+			logrus.WithFields(logrus.Fields{
+				"container_id": container.ID,
+				"reason":       reason,
+			}).Debug("Skipping container per effective update policy")
+			if deferUntil != nil {
+				t.deferUpdate(ctx, container, *deferUntil)
+			}
+			continue
 		}
+
+		needingUpdates = append(needingUpdates, container)
 	}
 
+	// Containers deferred to their maintenance window go first, longest
+	// waiting first
+	sort.SliceStable(needingUpdates, func(i, j int) bool {
+		a, b := needingUpdates[i].UpdateDeferredUntil, needingUpdates[j].UpdateDeferredUntil
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return a.Before(*b)
+	})
+
 	return needingUpdates
 }
 
+// deferUpdate queues the container's update for when its maintenance window
+// next opens
+func (t *ContainerUpdaterTask) deferUpdate(ctx context.Context, container *model.Container, until time.Time) {
+	if container.UpdateDeferredUntil != nil && container.UpdateDeferredUntil.Equal(until) {
+		return
+	}
+	if err := t.containerRepo.DeferUpdate(ctx, int64(container.ID), &until); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to defer container update")
+		return
+	}
+	container.UpdateDeferredUntil = &until
+}
+
+// policyAllowsUpdate applies the container's effective policy: automatic
+// updates must be enabled, the hold-down since the last update must have
+// passed and, if the policy defines maintenance windows, now must fall in one.
+// A container outside its windows is deferred until the next one opens.
+func (t *ContainerUpdaterTask) policyAllowsUpdate(ctx context.Context, container *model.Container, now time.Time) (bool, string, *time.Time) {
+	if t.containerService == nil {
+		return container.IsAutoUpdateEnabled(), "automatic updates disabled", nil
+	}
+
+	effective, err := t.containerService.EffectivePolicy(ctx, container)
+	if err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to resolve effective policy")
+		return false, "effective policy unavailable", nil
+	}
+
+	if !effective.IsEligibleForAutoUpdate() {
+		return false, fmt.Sprintf("update policy is %s", effective.UpdatePolicy), nil
+	}
+
+	windows := model.ParseMaintenanceWindows(effective.MaintenanceWindows)
+
+	if effective.HoldDownHours > 0 && t.updateHistoryRepo != nil {
+		histories, _, err := t.updateHistoryRepo.GetByContainerID(ctx, int64(container.ID), 1, 0)
+		if err == nil && len(histories) > 0 {
+			holdUntil := histories[0].StartedAt.Add(time.Duration(effective.HoldDownHours) * time.Hour)
+			if now.Before(holdUntil) {
+				if len(effective.MaintenanceWindows) > 0 {
+					if next, ok := windows.NextOpen(holdUntil); ok && next.Start.After(holdUntil) {
+						return false, fmt.Sprintf("held down until %s, next maintenance window opens %s",
+							holdUntil.Format(time.RFC3339), next.Start.Format(time.RFC3339)), nil
+					}
+				}
+				return false, fmt.Sprintf("held down until %s", holdUntil.Format(time.RFC3339)), nil
+			}
+		}
+	}
+
+	if len(effective.MaintenanceWindows) > 0 && !windows.IsOpen(now) {
+		if next, ok := windows.NextOpen(now); ok {
+			return false, fmt.Sprintf("outside maintenance window, deferred until %s", next.Start.Format(time.RFC3339)), &next.Start
+		}
+		return false, "outside maintenance window", nil
+	}
+
+	return true, "", nil
+}
+
 // isInMaintenanceWindow checks if current time is within maintenance window
 func (t *ContainerUpdaterTask) isInMaintenanceWindow(params *ContainerUpdateParameters) bool {
 	if len(params.MaintenanceWindows) == 0 {
 		return true // No restrictions
 	}
 
-	now := time.Now()
-	for _, window := range params.MaintenanceWindows {
-		if t.timeInWindow(now, window) {
-			return true
-		}
-	}
+	return maintenanceSchedule(params.MaintenanceWindows).IsOpen(time.Now())
+}
 
-	return false
+// maintenanceSchedule parses task maintenance windows for evaluation
+func maintenanceSchedule(windows []MaintenanceWindow) schedule.Windows {
+	converted := make([]model.MaintenanceWindow, 0, len(windows))
+	for _, window := range windows {
+		converted = append(converted, model.MaintenanceWindow(window))
+	}
+	return model.ParseMaintenanceWindows(converted)
 }
 
-// timeInWindow checks if the given time is within the maintenance window
-func (t *ContainerUpdaterTask) timeInWindow(checkTime time.Time, window MaintenanceWindow) bool {
-	// Parse timezone
-	location := time.UTC
-	if window.Timezone != "" {
-		if loc, err := time.LoadLocation(window.Timezone); err == nil {
-			location = loc
+// staggerDelay returns how long to wait before updating the container so that
+// containers sharing a window start at deterministic offsets spread across the
+// first half of the window
+func (t *ContainerUpdaterTask) staggerDelay(container *model.Container, params *ContainerUpdateParameters, now time.Time) time.Duration {
+	if window, ok := maintenanceSchedule(params.MaintenanceWindows).Containing(now); ok {
+		spread := window.Duration() / 2
+		if spread <= 0 {
+			return 0
 		}
+
+		key := container.ContainerID
+		if key == "" {
+			key = strconv.Itoa(container.ID)
+		}
+		hasher := fnv.New64a()
+		hasher.Write([]byte(key))
+		offset := time.Duration(hasher.Sum64() % uint64(spread))
+
+		return time.Until(window.Start.Add(offset))
 	}
 
-	// Convert time to window timezone
-	windowTime := checkTime.In(location)
+	return 0
+}
 
-	// Check day of week
-	if len(window.DaysOfWeek) > 0 {
-		dayMatch := false
-		for _, day := range window.DaysOfWeek {
-			if int(windowTime.Weekday()) == day {
-				dayMatch = true
-				break
-			}
-		}
-		if !dayMatch {
-			return false
+// pullImage pulls the container image through the host pull throttle, recording
+// the queue position in the update steps while the pull waits for a slot
+func (t *ContainerUpdaterTask) pullImage(ctx context.Context, dc *docker.DockerClient, container *model.Container, params *ContainerUpdateParameters, result *SingleContainerUpdateResult) error {
+	if params.PullPolicy == "never" || dc == nil {
+		return nil
+	}
+
+	imageName := targetImageRef(container)
+	if params.PullPolicy == "if-not-present" {
+		if exists, err := dc.ImageExists(ctx, imageName); err == nil && exists {
+			return nil
 		}
 	}
 
-	// Parse start and end times
-	startTime, err := time.Parse("15:04", window.StartTime)
-	if err != nil {
-		return false
+	step := UpdateStep{
+		Step:      "pull_image",
+		Status:    "running",
+		StartedAt: time.Now(),
+	}
+
+	// Transient failures, like registry rate limits or an unreachable
+	// daemon, are retried; permanent ones fail the update right away
+	var onProgress func(model.PullProgress)
+	if t.containerService != nil {
+		onProgress = t.containerService.PullProgressReporter(container, result.UpdateHistory)
 	}
-	endTime, err := time.Parse("15:04", window.EndTime)
+	err := docker.Retry(func() error {
+		return dc.PullImageWithProgress(ctx, docker.ContainerPullKey(int64(container.ID)), imageName, types.ImagePullOptions{}, func(ahead int) {
+			step.Status = "queued"
+			step.Message = fmt.Sprintf("queued behind %d pulls", ahead)
+			logrus.WithFields(logrus.Fields{
+				"container_id":   container.ID,
+				"container_name": container.Name,
+				"pulls_ahead":    ahead,
+			}).Info("Image pull queued")
+		}, onProgress)
+	}, docker.DefaultRetryConfig())
+
+	completedAt := time.Now()
+	step.CompletedAt = &completedAt
+	step.Duration = completedAt.Sub(step.StartedAt)
 	if err != nil {
-		return false
+		step.Status = "failed"
+		step.Error = err.Error()
+	} else {
+		step.Status = "completed"
 	}
+	result.UpdateSteps = append(result.UpdateSteps, step)
 
-	// Get current time in HH:MM format
-	currentTime := time.Date(0, 1, 1, windowTime.Hour(), windowTime.Minute(), 0, 0, time.UTC)
+	return err
+}
 
-	// Handle overnight windows (e.g., 22:00 to 06:00)
-	if endTime.Before(startTime) {
-		return currentTime.After(startTime) || currentTime.Before(endTime)
+// targetImageRef returns the image to update a container to: the proposed digest
+// for pinned containers with drift, otherwise the tag
+func targetImageRef(container *model.Container) string {
+	if container.HasDigestDrift() {
+		return container.Image + "@" + container.PendingDigest
 	}
-
-	return currentTime.After(startTime) && currentTime.Before(endTime)
+	return container.GetFullImageName()
 }
 
-// updateContainers performs the actual container updates
+// updateContainers performs the actual container updates, in waves so that
+// dependencies are updated before their dependents
 func (t *ContainerUpdaterTask) updateContainers(ctx context.Context, containers []*model.Container, params *ContainerUpdateParameters) (*ContainerUpdateTaskResult, error) {
 	startTime := time.Now()
 	result := &ContainerUpdateTaskResult{
@@ -406,54 +597,187 @@ func (t *ContainerUpdaterTask) updateContainers(ctx context.Context, containers
 		UpdatedAt:        startTime,
 	}
 
+	// Dependencies are updated in an earlier wave than their dependents
+	graph := model.DependencyGraph{}
+	if dependencies, err := t.containerRepo.ListDependencies(ctx); err != nil {
+		logrus.WithError(err).Warn("Updating containers without dependency ordering")
+	} else {
+		graph = model.NewDependencyGraph(dependencies)
+	}
+	byID := make(map[int]*model.Container, len(containers))
+	ids := make([]int, len(containers))
+	for i, container := range containers {
+		byID[container.ID] = container
+		ids[i] = container.ID
+	}
+
 	// Create semaphore for concurrency control
 	semaphore := make(chan struct{}, params.MaxConcurrent)
-	var wg sync.WaitGroup
 	var mu sync.Mutex
+	failed := make(map[int]string)
+
+	for _, wave := range graph.Waves(ids) {
+		var wg sync.WaitGroup
+		for _, containerID := range wave {
+			wg.Add(1)
+			go func(c *model.Container) {
+				defer wg.Done()
+
+				// Stagger start within the maintenance window to avoid pull stampedes
+				if params.StaggerWindows {
+					if delay := t.staggerDelay(c, params, time.Now()); delay > 0 {
+						select {
+						case <-time.After(delay):
+						case <-ctx.Done():
+							return
+						}
+					}
+				}
+
+				// Acquire semaphore
+				select {
+				case semaphore <- struct{}{}:
+					defer func() { <-semaphore }()
+				case <-ctx.Done():
+					return
+				}
+				// The run may have been cancelled while waiting for a slot
+				if ctx.Err() != nil {
+					return
+				}
+
+				// A started update is not cancelled with the run, so no container
+				// is left half replaced; its own timeout bounds it, and it stops
+				// at the next consistent checkpoint instead
+				updateCtx := context.WithValue(context.WithoutCancel(ctx), updateRunKey{}, ctx)
+				if params.UpdateTimeout > 0 {
+					var cancel context.CancelFunc
+					updateCtx, cancel = context.WithTimeout(updateCtx, params.UpdateTimeout)
+					defer cancel()
+				}
+
+				// Update this container once its dependencies are healthy
+				var containerResult *SingleContainerUpdateResult
+				if err := t.waitForDependencies(updateCtx, graph, c, failed, params); err != nil {
+					containerResult = &SingleContainerUpdateResult{
+						Container:   c,
+						OldVersion:  c.Tag,
+						Error:       err.Error(),
+						Recoverable: true,
+					}
+				} else {
+					containerResult = t.updateSingleContainer(updateCtx, c, params)
+					if c.UpdateDeferredUntil != nil {
+						if err := t.containerRepo.DeferUpdate(ctx, int64(c.ID), nil); err != nil {
+							logrus.WithError(err).WithField("container_id", c.ID).Warn("Failed to clear container update deferral")
+						}
+					}
+					t.recordUpdateOutcome(updateCtx, c, containerResult, params)
+				}
+
+				// Add to results
+				mu.Lock()
+				result.ContainerResults = append(result.ContainerResults, containerResult)
+				if containerResult.Success {
+					result.SuccessfulUpdates++
+				} else {
+					result.FailedUpdates++
+					result.Errors = append(result.Errors, ContainerUpdateError{
+						ContainerID:   int64(c.ID),
+						ContainerName: c.Name,
+						Error:         containerResult.Error,
+						Code:          containerResult.ErrorCode,
+						Recoverable:   containerResult.Recoverable,
+					})
+				}
+				if containerResult.RolledBack {
+					result.Rollbacks++
+				}
+				mu.Unlock()
+			}(byID[containerID])
+		}
+		wg.Wait()
 
-	for _, container := range containers {
-		wg.Add(1)
-		go func(c *model.Container) {
-			defer wg.Done()
-
-			// Acquire semaphore
-			select {
-			case semaphore <- struct{}{}:
-				defer func() { <-semaphore }()
-			case <-ctx.Done():
-				return
-			}
-
-			// Update this container
-			containerResult := t.updateSingleContainer(ctx, c, params)
-
-			// Add to results
-			mu.Lock()
-			result.ContainerResults = append(result.ContainerResults, containerResult)
-			if containerResult.Success {
-				result.SuccessfulUpdates++
-			} else {
-				result.FailedUpdates++
-				result.Errors = append(result.Errors, ContainerUpdateError{
-					ContainerID:   int64(c.ID),
-					ContainerName: c.Name,
-					Error:         containerResult.Error,
-					Recoverable:   true,
-				})
+		// Only read by the next waves, once this one is done
+		for _, containerResult := range result.ContainerResults {
+			if !containerResult.Success {
+				failed[containerResult.Container.ID] = containerResult.Container.Name
 			}
-			if containerResult.RolledBack {
-				result.Rollbacks++
-			}
-			mu.Unlock()
-		}(container)
+		}
 	}
 
-	wg.Wait()
 	result.Duration = time.Since(startTime)
 
 	return result, nil
 }
 
+// recordUpdateOutcome keeps the container's count of consecutive failed
+// updates, suspending its automatic updates once it reaches the limit.
+// Interrupted updates are resumed and not counted. A scheduled update counts
+// once: a transient failure the executor still retries is not counted, only
+// the outcome of the last attempt is.
+func (t *ContainerUpdaterTask) recordUpdateOutcome(ctx context.Context, container *model.Container, result *SingleContainerUpdateResult, params *ContainerUpdateParameters) {
+	if result.Interrupted || params.DryRun {
+		return
+	}
+	if !result.Success && result.Recoverable && !container.UpdateSuspended && scheduler.RetriesLeft(ctx) > 0 {
+		return
+	}
+
+	if result.Success {
+		if !container.ResetUpdateFailures() {
+			return
+		}
+	} else if container.RecordUpdateFailure(result.Error, container.PendingDigest, time.Now(), params.SuspendAfterFailures) {
+		logrus.WithFields(logrus.Fields{
+			"container_id":   container.ID,
+			"container_name": container.Name,
+			"failures":       container.UpdateFailures,
+		}).Warn("Suspending automatic updates of container after repeated failures")
+		defer t.sendSuspendedNotification(ctx, container)
+	}
+
+	if err := t.containerRepo.SaveUpdateFailures(ctx, container); err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to save container update failures")
+	}
+}
+
+// waitForDependencies waits for the containers c depends on to report healthy
+// before c is updated. It fails straight away when one of them failed to
+// update in this run.
+func (t *ContainerUpdaterTask) waitForDependencies(ctx context.Context, graph model.DependencyGraph, c *model.Container, failed map[int]string, params *ContainerUpdateParameters) error {
+	dependsOn := graph[c.ID]
+	if len(dependsOn) == 0 {
+		return nil
+	}
+
+	ids := make([]int64, len(dependsOn))
+	for i, id := range dependsOn {
+		if name, ok := failed[id]; ok {
+			return fmt.Errorf("skipped: dependency %s failed to update", name)
+		}
+		ids[i] = int64(id)
+	}
+
+	dependencies, err := t.containerRepo.GetByIDs(ctx, ids)
+	if err != nil {
+		return fmt.Errorf("failed to get dependencies: %w", err)
+	}
+	for _, dependency := range dependencies {
+		if dependency.ContainerID == "" {
+			return fmt.Errorf("dependency %s has no Docker container", dependency.Name)
+		}
+		dc, err := dockerFor(ctx, t.containerService, t.dockerClient, dependency)
+		if err != nil {
+			return fmt.Errorf("dependency %s is not reachable: %w", dependency.Name, err)
+		}
+		if err := dc.WaitForHealthy(ctx, dependency.ContainerID, params.HealthCheckTimeout); err != nil {
+			return fmt.Errorf("dependency %s is not healthy: %w", dependency.Name, err)
+		}
+	}
+	return nil
+}
+
 // updateSingleContainer updates a single container
 func (t *ContainerUpdaterTask) updateSingleContainer(ctx context.Context, container *model.Container, params *ContainerUpdateParameters) *SingleContainerUpdateResult {
 	startTime := time.Now()
@@ -471,26 +795,70 @@ func (t *ContainerUpdaterTask) updateSingleContainer(ctx context.Context, contai
 		"strategy":       params.UpdateStrategy,
 	})
 
-	// Create update history record
-	updateHistory := &model.UpdateHistory{
-		ContainerID: int64(container.ID),
-		OldImage:    container.GetFullImageName(),
-		Status:      model.UpdateStatusInProgress,
-		Strategy:    params.UpdateStrategy,
-		StartedAt:   startTime,
+	// Stopping the container docker-auto runs in would end the update; a
+	// helper container takes it over and this server records its outcome on
+	// the next start
+	if t.containerService != nil && t.containerService.IsSelfContainer(container) {
+		updateHistory, err := t.containerService.StartSelfUpdate(ctx, model.ActorFromContext(ctx), container, model.TriggerTypeSchedule, 0)
+		result.UpdateHistory = updateHistory
+		result.Duration = time.Since(startTime)
+		if err != nil {
+			result.Error = err.Error()
+			logger.WithError(err).Error("Container self-update failed")
+			return result
+		}
+		result.Success = true
+		result.NewVersion = updateHistory.NewImage
+		logger.WithField("update_id", updateHistory.ID).Info("Handed container self-update to helper")
+		return result
+	}
+
+	// The update runs against the daemon the container is on
+	dc, err := dockerFor(ctx, t.containerService, t.dockerClient, container)
+	if err != nil {
+		result.Error = err.Error()
+		result.Recoverable = true
+		result.Duration = time.Since(startTime)
+		logger.WithError(err).Error("Container update failed")
+		return result
 	}
 
-	if t.updateHistoryRepo != nil {
-		if err := t.updateHistoryRepo.Create(ctx, updateHistory); err != nil {
-			logger.WithError(err).Warn("Failed to create update history record")
+	// Resume an update interrupted by a restart, otherwise create the history record
+	updateHistory := t.resumableUpdate(ctx, container)
+	if updateHistory != nil {
+		updateHistory.Checkpoint.Resumes++
+		logger.WithField("update_id", updateHistory.ID).Info("Resuming interrupted container update")
+	} else {
+		updateHistory = &model.UpdateHistory{
+			ContainerID: container.ID,
+			OldImage:    container.GetDeployImageRef(),
+			Status:      model.UpdateStatusRunning,
+			Strategy:    model.UpdateStrategy(params.UpdateStrategy),
+			StartedAt:   startTime,
+			TriggeredBy: model.TriggerTypeSchedule,
+		}
+		updateHistory.SetActor(model.ActorFromContext(ctx))
+		// Created with its checkpoint, the record is resumable from the start
+		if params.UpdateStrategy == "recreate" {
+			updateHistory.Checkpoint = newRecreateCheckpoint(container)
+		}
+
+		if t.updateHistoryRepo != nil {
+			if err := t.updateHistoryRepo.Create(ctx, updateHistory); err != nil {
+				logger.WithError(err).Warn("Failed to create update history record")
+			}
 		}
-		result.UpdateHistory = updateHistory
+	}
+	result.UpdateHistory = updateHistory
+	t.publishUpdate(container, events.ContainerActionUpdateStarted, updateHistory)
+	if t.containerService != nil {
+		defer t.containerService.ForgetUpdateProgress(updateHistory.ID)
 	}
 
 	// Execute update based on strategy
 	switch params.UpdateStrategy {
 	case "recreate":
-		result = t.updateWithRecreateStrategy(ctx, container, params, result)
+		result = t.updateWithRecreateStrategy(ctx, dc, container, params, result)
 	case "rolling":
 		result = t.updateWithRollingStrategy(ctx, container, params, result)
 	case "blue-green":
@@ -502,21 +870,38 @@ func (t *ContainerUpdaterTask) updateSingleContainer(ctx context.Context, contai
 
 	result.Duration = time.Since(startTime)
 
+	// Warnings from recreating the container replace those of the old one
+	if result.Warnings != nil && t.containerService != nil {
+		result.Warnings = t.containerService.RecordDaemonWarnings(ctx, container, result.Warnings)
+	}
+
+	// An interrupted update stays running with its checkpoint to be resumed
+	if result.Interrupted {
+		logger.WithField("update_id", updateHistory.ID).Warn("Container update interrupted at a checkpoint")
+		return result
+	}
+
 	// Update history record
 	if updateHistory != nil && t.updateHistoryRepo != nil {
 		if result.Success {
 			updateHistory.Status = model.UpdateStatusCompleted
 			updateHistory.NewImage = result.NewVersion
+		} else if result.RolledBack {
+			updateHistory.Status = model.UpdateStatusRollback
+			updateHistory.ErrorMessage = result.Error
 		} else {
 			updateHistory.Status = model.UpdateStatusFailed
 			updateHistory.ErrorMessage = result.Error
 		}
+		metrics.RecordContainerUpdate(string(updateHistory.Status))
+		updateHistory.Warnings = result.Warnings
 		completedAt := time.Now()
 		updateHistory.CompletedAt = &completedAt
 
 		if err := t.updateHistoryRepo.Update(ctx, updateHistory); err != nil {
 			logger.WithError(err).Warn("Failed to update history record")
 		}
+		t.publishUpdate(container, events.ContainerActionUpdateCompleted, updateHistory)
 	}
 
 	if result.Success {
@@ -534,25 +919,429 @@ func (t *ContainerUpdaterTask) updateSingleContainer(ctx context.Context, contai
 	return result
 }
 
-// updateWithRecreateStrategy implements the recreate update strategy
-func (t *ContainerUpdaterTask) updateWithRecreateStrategy(ctx context.Context, container *model.Container, params *ContainerUpdateParameters, result *SingleContainerUpdateResult) *SingleContainerUpdateResult {
-	// Implementation for recreate strategy
-	// This is a simplified version - full implementation would include:
-	// 1. Pull new image
-	// 2. Create backup if requested
-	// 3. Stop container
-	// 4. Remove container
-	// 5. Create new container with new image
-	// 6. Start container
-	// 7. Health check
-	// 8. Rollback if needed
+// updateWithRecreateStrategy implements the recreate update strategy. Each
+// step is checkpointed on the update history, so an update interrupted by a
+// restart skips the steps it already completed once their results are
+// verified, and continues from the step it was in.
+func (t *ContainerUpdaterTask) updateWithRecreateStrategy(ctx context.Context, dc *docker.DockerClient, container *model.Container, params *ContainerUpdateParameters, result *SingleContainerUpdateResult) *SingleContainerUpdateResult {
+	if dc == nil && container.ContainerID != "" {
+		result.Error = "docker client not available"
+		result.Success = false
+		return result
+	}
 
-	result.Success = true // Placeholder
-	result.NewVersion = "latest" // Placeholder
+	history := result.UpdateHistory
+	if history.Checkpoint == nil {
+		history.Checkpoint = newRecreateCheckpoint(container)
+	}
+	checkpoint := history.Checkpoint
+
+	for _, step := range t.recreateSteps(dc, container, params, result) {
+		if checkpoint.IsCompleted(step.name) {
+			if err := step.verify(ctx); err != nil {
+				// The world changed while the update was down; start over cleanly
+				t.failRecreate(ctx, dc, container, result, step.name, fmt.Errorf("completed step no longer holds: %w", err), true)
+				return result
+			}
+			continue
+		}
+
+		// Between stopping the old container and starting the new one the
+		// container is down, so that span always runs to its end
+		inSwap := checkpoint.IsCompleted(model.UpdateStepStopOld) && !checkpoint.IsCompleted(model.UpdateStepStartNew)
+		if !inSwap && updateRunStopped(ctx) {
+			interruptRecreate(result, step.name)
+			return result
+		}
+
+		checkpoint.Begin(step.name)
+		t.saveCheckpoint(ctx, history)
+
+		stepCtx, cancel := ctx, context.CancelFunc(func() {})
+		if !inSwap && step.name != model.UpdateStepStopOld {
+			stepCtx, cancel = interruptibleStep(ctx)
+		}
+		err := step.run(stepCtx)
+		cancel()
+		if err != nil {
+			if !inSwap && updateRunStopped(ctx) {
+				// The step stays running in the checkpoint and is repeated on resume
+				interruptRecreate(result, step.name)
+				return result
+			}
+			t.failRecreate(ctx, dc, container, result, step.name, err, params.RollbackOnFailure)
+			return result
+		}
+
+		checkpoint.Complete(step.name)
+		t.saveCheckpoint(ctx, history)
+	}
+
+	result.Success = true
+	result.NewVersion = checkpoint.TargetImage
 
 	return result
 }
 
+// updateRunKey carries the context of the task run into the detached context
+// of a container update; see updateRunStopped
+type updateRunKey struct{}
+
+// updateRunStopped reports whether the task run the update belongs to was
+// cancelled, by a shutdown or on request
+func updateRunStopped(ctx context.Context) bool {
+	run, _ := ctx.Value(updateRunKey{}).(context.Context)
+	return run != nil && run.Err() != nil
+}
+
+// interruptibleStep returns a context for a step that may be abandoned when
+// the task run is cancelled, because the old container is still running
+func interruptibleStep(ctx context.Context) (context.Context, context.CancelFunc) {
+	stepCtx, cancel := context.WithCancel(ctx)
+	if run, _ := ctx.Value(updateRunKey{}).(context.Context); run != nil {
+		stop := context.AfterFunc(run, cancel)
+		return stepCtx, func() {
+			stop()
+			cancel()
+		}
+	}
+	return stepCtx, cancel
+}
+
+// interruptRecreate stops an update before step, at a checkpoint where
+// either the old container still runs or the new one has started
+func interruptRecreate(result *SingleContainerUpdateResult, step string) {
+	result.Success = false
+	result.Interrupted = true
+	result.Recoverable = true
+	result.Error = fmt.Sprintf("interrupted before %s; the update resumes from its checkpoint", step)
+}
+
+// recreateStep is a checkpointed step of the recreate strategy. verify checks
+// that the result of an already completed step still holds.
+type recreateStep struct {
+	name   string
+	run    func(ctx context.Context) error
+	verify func(ctx context.Context) error
+}
+
+// newRecreateCheckpoint returns the step checklist for recreating container.
+// Containers not backed by a Docker container only record the new image.
+func newRecreateCheckpoint(container *model.Container) *model.UpdateCheckpoint {
+	if container.ContainerID == "" {
+		return model.NewUpdateCheckpoint(model.UpdateStepResolve, model.UpdateStepPull, model.UpdateStepFinalize)
+	}
+	steps := []string{
+		model.UpdateStepResolve,
+		model.UpdateStepPull,
+		model.UpdateStepStopOld,
+		model.UpdateStepCreateNew,
+		model.UpdateStepStartNew,
+	}
+	// The new container warms up and passes its post-start hooks before the
+	// old one is removed
+	if container.HasPostStart() {
+		steps = append(steps, model.UpdateStepPostStart)
+	}
+	checkpoint := model.NewUpdateCheckpoint(append(steps, model.UpdateStepFinalize)...)
+	checkpoint.OldContainerID = container.ContainerID
+	return checkpoint
+}
+
+func (t *ContainerUpdaterTask) recreateSteps(dc *docker.DockerClient, container *model.Container, params *ContainerUpdateParameters, result *SingleContainerUpdateResult) []recreateStep {
+	checkpoint := result.UpdateHistory.Checkpoint
+	stagingName := fmt.Sprintf("%s-update-%d", container.Name, result.UpdateHistory.ID)
+
+	steps := []recreateStep{
+		{
+			name: model.UpdateStepResolve,
+			run: func(ctx context.Context) error {
+				checkpoint.TargetImage = targetImageRef(container)
+				if container.HasDigestDrift() {
+					checkpoint.ResolvedDigest = container.PendingDigest
+				}
+				return nil
+			},
+			verify: func(ctx context.Context) error {
+				if checkpoint.TargetImage == "" {
+					return fmt.Errorf("no target image recorded")
+				}
+				return nil
+			},
+		},
+		{
+			name: model.UpdateStepPull,
+			run: func(ctx context.Context) error {
+				if err := t.pullImage(ctx, dc, container, params, result); err != nil {
+					return fmt.Errorf("failed to pull image: %w", err)
+				}
+				return nil
+			},
+			verify: func(ctx context.Context) error {
+				return t.verifyImagePresent(ctx, dc, checkpoint.TargetImage)
+			},
+		},
+	}
+
+	if checkpoint.OldContainerID != "" {
+		steps = append(steps,
+			recreateStep{
+				name: model.UpdateStepStopOld,
+				run: func(ctx context.Context) error {
+					timeout := int(params.StopGracePeriod.Seconds())
+					if container.StopTimeoutSeconds != nil {
+						timeout = *container.StopTimeoutSeconds
+					}
+					return dc.StopContainerWithSignal(ctx, checkpoint.OldContainerID, container.StopSignal, &timeout)
+				},
+				verify: func(ctx context.Context) error {
+					// Finalizing removes the old container
+					if checkpoint.IsStarted(model.UpdateStepFinalize) {
+						return nil
+					}
+					running, err := dc.IsContainerRunning(ctx, checkpoint.OldContainerID)
+					if err != nil {
+						return err
+					}
+					if running {
+						return fmt.Errorf("old container %s is running again", shortID(checkpoint.OldContainerID))
+					}
+					return nil
+				},
+			},
+			recreateStep{
+				name: model.UpdateStepCreateNew,
+				run: func(ctx context.Context) error {
+					// A crash between create and checkpoint leaves an unrecorded clone behind
+					if leftover, err := dc.FindContainerIDByName(ctx, stagingName); err == nil {
+						if err := dc.RemoveContainer(ctx, leftover, types.ContainerRemoveOptions{Force: true}); err != nil {
+							return fmt.Errorf("failed to remove leftover container %s: %w", stagingName, err)
+						}
+					}
+
+					newID, err := dc.CloneContainer(ctx, checkpoint.OldContainerID, checkpoint.TargetImage, stagingName)
+					if err != nil {
+						return err
+					}
+					checkpoint.NewContainerID = newID
+					return nil
+				},
+				verify: func(ctx context.Context) error {
+					return t.verifyContainerExists(ctx, dc, checkpoint.NewContainerID)
+				},
+			},
+			recreateStep{
+				name: model.UpdateStepStartNew,
+				run: func(ctx context.Context) error {
+					return dc.StartContainer(ctx, checkpoint.NewContainerID)
+				},
+				verify: func(ctx context.Context) error {
+					running, err := dc.IsContainerRunning(ctx, checkpoint.NewContainerID)
+					if err != nil {
+						return err
+					}
+					if !running {
+						return fmt.Errorf("new container %s is not running", shortID(checkpoint.NewContainerID))
+					}
+					return nil
+				},
+			},
+		)
+	}
+
+	if checkpoint.OldContainerID != "" && container.HasPostStart() && t.containerService != nil {
+		steps = append(steps, recreateStep{
+			name: model.UpdateStepPostStart,
+			run: func(ctx context.Context) error {
+				actor := model.SystemActor(model.ActorComponentUpdater)
+				_, err := t.containerService.RunPostStart(ctx, actor, container, checkpoint.NewContainerID, model.PostStartTriggerUpdate, params.HealthCheckTimeout)
+				return err
+			},
+			verify: func(ctx context.Context) error { return nil },
+		})
+	}
+
+	steps = append(steps, recreateStep{
+		name: model.UpdateStepFinalize,
+		run: func(ctx context.Context) error {
+			return t.finalizeRecreate(ctx, dc, container, checkpoint)
+		},
+		verify: func(ctx context.Context) error { return nil },
+	})
+
+	return steps
+}
+
+// finalizeRecreate replaces the old container with the new one and records the
+// result. Every part is safe to repeat after a crash.
+func (t *ContainerUpdaterTask) finalizeRecreate(ctx context.Context, dc *docker.DockerClient, container *model.Container, checkpoint *model.UpdateCheckpoint) error {
+	if checkpoint.NewContainerID != "" {
+		if err := dc.RemoveContainer(ctx, checkpoint.OldContainerID, types.ContainerRemoveOptions{}); err != nil && !docker.IsContainerNotFoundError(err) {
+			return fmt.Errorf("failed to remove old container: %w", err)
+		}
+
+		current, err := dc.GetContainer(ctx, checkpoint.NewContainerID)
+		if err != nil {
+			return err
+		}
+		if current.Name != "/"+container.Name {
+			if err := dc.RenameContainer(ctx, checkpoint.NewContainerID, container.Name); err != nil {
+				return err
+			}
+		}
+
+		if container.ContainerID != checkpoint.NewContainerID {
+			if err := t.containerRepo.UpdateContainerID(ctx, int64(container.ID), checkpoint.NewContainerID); err != nil {
+				return fmt.Errorf("failed to record new container ID: %w", err)
+			}
+			container.SetDeployedContainer(checkpoint.NewContainerID)
+		}
+	}
+
+	// Pinned containers move to the resolved digest; the old digest stays in the
+	// update history for rollback
+	if checkpoint.ResolvedDigest != "" && container.ImageDigest != checkpoint.ResolvedDigest {
+		container.ImageDigest = checkpoint.ResolvedDigest
+		container.PendingDigest = ""
+		if err := t.containerRepo.Update(ctx, container); err != nil {
+			return fmt.Errorf("failed to record pinned digest: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// failRecreate records a failed step and, when asked to, rolls back
+func (t *ContainerUpdaterTask) failRecreate(ctx context.Context, dc *docker.DockerClient, container *model.Container, result *SingleContainerUpdateResult, stepName string, err error, rollback bool) {
+	checkpoint := result.UpdateHistory.Checkpoint
+	checkpoint.Fail(stepName, err)
+	t.saveCheckpoint(ctx, result.UpdateHistory)
+
+	result.Success = false
+	result.Error = fmt.Sprintf("%s: %v", stepName, err)
+	result.Recoverable = docker.IsRetryableError(err)
+	if dockerErr := docker.TranslateError(err, stepName, container.Name); dockerErr != nil {
+		result.ErrorCode = dockerErr.Code
+	}
+
+	if !rollback {
+		return
+	}
+	if rbErr := t.rollbackRecreate(ctx, dc, checkpoint); rbErr != nil {
+		result.Error = fmt.Sprintf("%s; rollback failed: %v", result.Error, rbErr)
+		return
+	}
+	result.RolledBack = true
+}
+
+// rollbackRecreate removes the new container and brings the old one back up.
+// Once the old container is gone the new one is all there is, so it stays.
+func (t *ContainerUpdaterTask) rollbackRecreate(ctx context.Context, dc *docker.DockerClient, checkpoint *model.UpdateCheckpoint) error {
+	if dc == nil || checkpoint.OldContainerID == "" {
+		return nil
+	}
+
+	exists, err := dc.ContainerExists(ctx, checkpoint.OldContainerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("old container %s no longer exists", shortID(checkpoint.OldContainerID))
+	}
+
+	if checkpoint.NewContainerID != "" {
+		err := dc.RemoveContainer(ctx, checkpoint.NewContainerID, types.ContainerRemoveOptions{Force: true})
+		if err != nil && !docker.IsContainerNotFoundError(err) {
+			return fmt.Errorf("failed to remove new container: %w", err)
+		}
+		checkpoint.NewContainerID = ""
+	}
+
+	running, err := dc.IsContainerRunning(ctx, checkpoint.OldContainerID)
+	if err != nil {
+		return err
+	}
+	if !running {
+		if err := dc.StartContainer(ctx, checkpoint.OldContainerID); err != nil {
+			return fmt.Errorf("failed to restart old container: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// publishUpdate publishes the progress of an update on the container state
+// stream
+func (t *ContainerUpdaterTask) publishUpdate(container *model.Container, action string, history *model.UpdateHistory) {
+	if t.publisher == nil || history == nil {
+		return
+	}
+	t.publisher.PublishAsync(events.NewContainerStateEvent(&events.ContainerState{
+		Action:      action,
+		ContainerID: int64(container.ID),
+		Name:        container.Name,
+		Status:      string(history.Status),
+		UpdateID:    history.ID,
+		Error:       history.ErrorMessage,
+	}, container.CreatedBy))
+}
+
+// resumableUpdate returns the container's latest update history when it was
+// interrupted with checkpointed progress
+func (t *ContainerUpdaterTask) resumableUpdate(ctx context.Context, container *model.Container) *model.UpdateHistory {
+	if t.updateHistoryRepo == nil {
+		return nil
+	}
+
+	histories, _, err := t.updateHistoryRepo.GetByContainerID(ctx, int64(container.ID), 1, 0)
+	if err != nil || len(histories) == 0 || !histories[0].IsResumable() {
+		return nil
+	}
+	return histories[0]
+}
+
+// saveCheckpoint persists the update's progress
+func (t *ContainerUpdaterTask) saveCheckpoint(ctx context.Context, history *model.UpdateHistory) {
+	if t.updateHistoryRepo == nil || history.ID == 0 {
+		return
+	}
+	if err := t.updateHistoryRepo.Update(ctx, history); err != nil {
+		logrus.WithError(err).WithField("update_id", history.ID).Warn("Failed to save update checkpoint")
+	}
+}
+
+func (t *ContainerUpdaterTask) verifyImagePresent(ctx context.Context, dc *docker.DockerClient, imageName string) error {
+	if dc == nil {
+		return nil
+	}
+	exists, err := dc.ImageExists(ctx, imageName)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("image %s is not present locally", imageName)
+	}
+	return nil
+}
+
+func (t *ContainerUpdaterTask) verifyContainerExists(ctx context.Context, dc *docker.DockerClient, containerID string) error {
+	exists, err := dc.ContainerExists(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return fmt.Errorf("container %s no longer exists", shortID(containerID))
+	}
+	return nil
+}
+
+func shortID(id string) string {
+	if len(id) > 12 {
+		return id[:12]
+	}
+	return id
+}
+
 // updateWithRollingStrategy implements the rolling update strategy
 func (t *ContainerUpdaterTask) updateWithRollingStrategy(ctx context.Context, container *model.Container, params *ContainerUpdateParameters, result *SingleContainerUpdateResult) *SingleContainerUpdateResult {
 	// Implementation for rolling strategy
@@ -586,22 +1375,29 @@ func (t *ContainerUpdaterTask) processResults(ctx context.Context, results *Cont
 }
 
 // sendSuccessNotification sends a notification for successful updates
-func (t *ContainerUpdaterTask) sendSuccessNotification(ctx context.Context, results *ContainerUpdateResult) {
+func (t *ContainerUpdaterTask) sendSuccessNotification(ctx context.Context, results *ContainerUpdateTaskResult) {
 	if t.notificationService == nil {
 		return
 	}
 
+	payload := model.NewUpdateCompletedPayload(
+		results.SuccessfulUpdates,
+		results.FailedUpdates,
+		results.Rollbacks,
+		results.Duration,
+	)
+	for _, result := range results.ContainerResults {
+		if result.Success && result.Container != nil {
+			payload.Containers = append(payload.Containers, result.Container.Name)
+		}
+	}
+
 	notification := &model.Notification{
 		Type:     model.NotificationTypeContainerUpdate,
 		Title:    "Container Updates Completed",
 		Message:  fmt.Sprintf("Successfully updated %d container(s)", results.SuccessfulUpdates),
 		Priority: model.NotificationPriorityNormal,
-		Data: map[string]interface{}{
-			"successful_updates": results.SuccessfulUpdates,
-			"failed_updates":     results.FailedUpdates,
-			"rollbacks":         results.Rollbacks,
-			"duration":          results.Duration.String(),
-		},
+		Data:     model.NotificationData(payload),
 	}
 
 	if err := t.notificationService.SendNotification(ctx, notification); err != nil {
@@ -610,22 +1406,34 @@ func (t *ContainerUpdaterTask) sendSuccessNotification(ctx context.Context, resu
 }
 
 // sendFailureNotification sends a notification for failed updates
-func (t *ContainerUpdaterTask) sendFailureNotification(ctx context.Context, results *ContainerUpdateResult) {
+func (t *ContainerUpdaterTask) sendFailureNotification(ctx context.Context, results *ContainerUpdateTaskResult) {
 	if t.notificationService == nil {
 		return
 	}
 
+	failures := make([]model.UpdateFailureEntry, 0, len(results.Errors))
+	for _, updateErr := range results.Errors {
+		failures = append(failures, model.UpdateFailureEntry{
+			ContainerID:   updateErr.ContainerID,
+			ContainerName: updateErr.ContainerName,
+			Step:          updateErr.Step,
+			Error:         updateErr.Error,
+			Code:          updateErr.Code,
+			Recoverable:   updateErr.Recoverable,
+		})
+	}
+
 	notification := &model.Notification{
 		Type:     model.NotificationTypeContainerUpdate,
 		Title:    "Container Update Failures",
 		Message:  fmt.Sprintf("Failed to update %d container(s)", results.FailedUpdates),
 		Priority: model.NotificationPriorityHigh,
-		Data: map[string]interface{}{
-			"successful_updates": results.SuccessfulUpdates,
-			"failed_updates":     results.FailedUpdates,
-			"rollbacks":         results.Rollbacks,
-			"errors":           results.Errors,
-		},
+		Data: model.NotificationData(model.NewUpdateFailedPayload(
+			results.SuccessfulUpdates,
+			results.FailedUpdates,
+			results.Rollbacks,
+			failures,
+		)),
 	}
 
 	if err := t.notificationService.SendNotification(ctx, notification); err != nil {
@@ -633,6 +1441,31 @@ func (t *ContainerUpdaterTask) sendFailureNotification(ctx context.Context, resu
 	}
 }
 
+// sendSuspendedNotification tells that a container's automatic updates were
+// suspended
+func (t *ContainerUpdaterTask) sendSuspendedNotification(ctx context.Context, container *model.Container) {
+	if t.notificationService == nil {
+		return
+	}
+
+	notification := &model.Notification{
+		Type:     model.NotificationTypeContainerUpdate,
+		Title:    "Container Updates Suspended",
+		Message:  fmt.Sprintf("Automatic updates of %s suspended after %d failed updates", container.Name, container.UpdateFailures),
+		Priority: model.NotificationPriorityHigh,
+		Data: model.NotificationData(model.NewUpdateSuspendedPayload(
+			container.ID,
+			container.Name,
+			container.UpdateFailures,
+			container.LastUpdateError,
+		)),
+	}
+
+	if err := t.notificationService.SendNotification(ctx, notification); err != nil {
+		logrus.WithError(err).Warn("Failed to send update suspended notification")
+	}
+}
+
 // contains checks if a slice contains a specific string
 func (t *ContainerUpdaterTask) contains(slice []string, item string) bool {
 	for _, s := range slice {
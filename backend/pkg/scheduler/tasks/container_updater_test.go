@@ -0,0 +1,582 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/config"
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// failureRepo records the containers whose update failures were saved
+type failureRepo struct {
+	repository.ContainerRepository
+	saves int
+}
+
+func (r *failureRepo) SaveUpdateFailures(ctx context.Context, container *model.Container) error {
+	r.saves++
+	return nil
+}
+
+// runScheduledUpdate plays one scheduled update of container through the
+// executor's attempts, each ending with outcome, and records them as
+// updateContainers does
+func runScheduledUpdate(task *ContainerUpdaterTask, container *model.Container, params *ContainerUpdateParameters, maxRetries int, outcome SingleContainerUpdateResult) {
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		ctx := scheduler.WithRetriesLeft(context.Background(), maxRetries-attempt)
+		result := outcome
+		result.Container = container
+		task.recordUpdateOutcome(ctx, container, &result, params)
+		if result.Success || !result.Recoverable || container.UpdateSuspended {
+			return // not in retryTargets, so the executor does not retry it
+		}
+	}
+}
+
+func TestRecordUpdateOutcomeCountsOneFailurePerScheduledUpdate(t *testing.T) {
+	transient := SingleContainerUpdateResult{Error: "registry timeout", Recoverable: true}
+	permanent := SingleContainerUpdateResult{Error: "port is already allocated", ErrorCode: "PORT_ALLOCATED"}
+
+	tests := []struct {
+		name          string
+		maxRetries    int
+		outcome       SingleContainerUpdateResult
+		wantFailures  int
+		wantSuspended bool
+	}{
+		{"transient failure retried by the executor", 3, transient, 1, false},
+		{"transient failure without retries", 0, transient, 1, false},
+		{"permanent failure", 3, permanent, 1, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &failureRepo{}
+			task := &ContainerUpdaterTask{containerRepo: repo}
+			container := &model.Container{ID: 1, Name: "web"}
+			params := &ContainerUpdateParameters{SuspendAfterFailures: 3}
+
+			runScheduledUpdate(task, container, params, tt.maxRetries, tt.outcome)
+
+			if container.UpdateFailures != tt.wantFailures {
+				t.Errorf("UpdateFailures = %d, want %d", container.UpdateFailures, tt.wantFailures)
+			}
+			if container.UpdateSuspended != tt.wantSuspended {
+				t.Errorf("UpdateSuspended = %v, want %v", container.UpdateSuspended, tt.wantSuspended)
+			}
+			if repo.saves != 1 {
+				t.Errorf("failures saved %d times, want 1", repo.saves)
+			}
+		})
+	}
+}
+
+func TestRecordUpdateOutcomeSuspendsAtThreshold(t *testing.T) {
+	task := &ContainerUpdaterTask{containerRepo: &failureRepo{}}
+	container := &model.Container{ID: 1, Name: "web"}
+	params := &ContainerUpdateParameters{SuspendAfterFailures: 3}
+	failure := SingleContainerUpdateResult{Error: "registry timeout", Recoverable: true}
+
+	for run := 1; run <= 3; run++ {
+		runScheduledUpdate(task, container, params, 3, failure)
+
+		if container.UpdateFailures != run {
+			t.Fatalf("after run %d: UpdateFailures = %d, want %d", run, container.UpdateFailures, run)
+		}
+		if wantSuspended := run == 3; container.UpdateSuspended != wantSuspended {
+			t.Fatalf("after run %d: UpdateSuspended = %v, want %v", run, container.UpdateSuspended, wantSuspended)
+		}
+	}
+	if container.IsAutoUpdateEnabled() {
+		t.Error("suspended container still has automatic updates enabled")
+	}
+}
+
+func TestRecordUpdateOutcomeResetsCounter(t *testing.T) {
+	repo := &failureRepo{}
+	task := &ContainerUpdaterTask{containerRepo: repo}
+	container := &model.Container{ID: 1, Name: "web", UpdatePolicy: model.UpdatePolicyAuto}
+	params := &ContainerUpdateParameters{SuspendAfterFailures: 3}
+
+	runScheduledUpdate(task, container, params, 3, SingleContainerUpdateResult{Error: "registry timeout", Recoverable: true})
+	runScheduledUpdate(task, container, params, 3, SingleContainerUpdateResult{Error: "registry timeout", Recoverable: true})
+	if container.UpdateFailures != 2 {
+		t.Fatalf("UpdateFailures = %d, want 2", container.UpdateFailures)
+	}
+
+	runScheduledUpdate(task, container, params, 3, SingleContainerUpdateResult{Success: true})
+	if container.UpdateFailures != 0 || container.LastUpdateError != "" || container.LastUpdateFailedAt != nil {
+		t.Errorf("success left failures = %d, error %q, failed at %v", container.UpdateFailures, container.LastUpdateError, container.LastUpdateFailedAt)
+	}
+
+	// A further success has nothing to clear and saves nothing
+	saves := repo.saves
+	runScheduledUpdate(task, container, params, 3, SingleContainerUpdateResult{Success: true})
+	if repo.saves != saves {
+		t.Errorf("success without failures saved the container")
+	}
+
+	// The count starts over, so one more failure does not suspend
+	runScheduledUpdate(task, container, params, 3, SingleContainerUpdateResult{Error: "registry timeout", Recoverable: true})
+	if container.UpdateFailures != 1 || container.UpdateSuspended {
+		t.Errorf("after reset: UpdateFailures = %d, suspended %v, want 1, false", container.UpdateFailures, container.UpdateSuspended)
+	}
+}
+
+// fakeEngine serves the parts of the Docker API the recreate strategy uses,
+// keeping containers and images in memory
+type fakeEngine struct {
+	mu         sync.Mutex
+	images     map[string]bool
+	containers map[string]*fakeContainer
+	created    int
+}
+
+type fakeContainer struct {
+	id      string
+	name    string
+	image   string
+	running bool
+}
+
+func newFakeEngine() *fakeEngine {
+	return &fakeEngine{
+		images:     map[string]bool{"nginx:1.25": true},
+		containers: map[string]*fakeContainer{oldWebID: {id: oldWebID, name: "web", image: "nginx:1.25", running: true}},
+	}
+}
+
+var oldWebID = fmt.Sprintf("%064x", 1)
+
+// client returns a new client of the engine, as a restarted server would have
+func (e *fakeEngine) client(t *testing.T) *docker.DockerClient {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(e.serve))
+	t.Cleanup(server.Close)
+
+	dc, err := docker.NewDockerClient(&config.Config{Docker: config.DockerConfig{
+		Host:       "tcp://" + strings.TrimPrefix(server.URL, "http://"),
+		APIVersion: "1.44",
+		Timeout:    5,
+	}})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { dc.Close() })
+	return dc
+}
+
+// lookup finds a container by ID or name, as the daemon does
+func (e *fakeEngine) lookup(ref string) *fakeContainer {
+	if c, ok := e.containers[ref]; ok {
+		return c
+	}
+	for _, c := range e.containers {
+		if c.name == strings.TrimPrefix(ref, "/") {
+			return c
+		}
+	}
+	return nil
+}
+
+func (e *fakeEngine) byName(name string) *fakeContainer {
+	for _, c := range e.containers {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+func (e *fakeEngine) serve(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	fail := func(status int, message string) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		json.NewEncoder(w).Encode(map[string]string{"message": message})
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1.44")
+	switch {
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/images/"):
+		name := strings.TrimSuffix(strings.TrimPrefix(path, "/images/"), "/json")
+		if !e.images[name] {
+			fail(http.StatusNotFound, "No such image: "+name)
+			return
+		}
+		json.NewEncoder(w).Encode(types.ImageInspect{ID: "sha256:" + name, RepoTags: []string{name}})
+	case r.Method == http.MethodPost && path == "/images/create":
+		e.images[r.URL.Query().Get("fromImage")+":"+r.URL.Query().Get("tag")] = true
+		json.NewEncoder(w).Encode(map[string]string{"status": "Downloaded newer image"})
+	case r.Method == http.MethodGet && path == "/containers/json":
+		args, _ := filters.FromJSON(r.URL.Query().Get("filters"))
+		list := []types.Container{}
+		for _, c := range e.containers {
+			// The name filter matches substrings
+			if len(args.Get("name")) == 0 || strings.Contains(c.name, args.Get("name")[0]) {
+				list = append(list, types.Container{ID: c.id, Names: []string{"/" + c.name}, Image: c.image})
+			}
+		}
+		json.NewEncoder(w).Encode(list)
+	case r.Method == http.MethodPost && path == "/containers/create":
+		name := r.URL.Query().Get("name")
+		if e.byName(name) != nil {
+			fail(http.StatusConflict, "Conflict. The container name \"/"+name+"\" is already in use")
+			return
+		}
+		var body struct{ Image string }
+		json.NewDecoder(r.Body).Decode(&body)
+		if !e.images[body.Image] {
+			fail(http.StatusNotFound, "No such image: "+body.Image)
+			return
+		}
+		e.created++
+		id := fmt.Sprintf("%064x", 100+e.created)
+		e.containers[id] = &fakeContainer{id: id, name: name, image: body.Image}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(map[string]string{"Id": id})
+	case strings.HasPrefix(path, "/containers/"):
+		parts := strings.SplitN(strings.TrimPrefix(path, "/containers/"), "/", 2)
+		c := e.lookup(parts[0])
+		if c == nil {
+			fail(http.StatusNotFound, "No such container: "+parts[0])
+			return
+		}
+		action := ""
+		if len(parts) == 2 {
+			action = parts[1]
+		}
+		switch {
+		case r.Method == http.MethodGet && action == "json":
+			json.NewEncoder(w).Encode(types.ContainerJSON{
+				ContainerJSONBase: &types.ContainerJSONBase{
+					ID:         c.id,
+					Name:       "/" + c.name,
+					State:      &types.ContainerState{Running: c.running},
+					HostConfig: &container.HostConfig{},
+				},
+				Config:          &container.Config{Image: c.image},
+				NetworkSettings: &types.NetworkSettings{},
+			})
+		case r.Method == http.MethodPost && action == "start":
+			c.running = true
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && action == "stop":
+			c.running = false
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodPost && action == "rename":
+			name := r.URL.Query().Get("name")
+			if other := e.byName(name); other != nil && other != c {
+				fail(http.StatusConflict, "Conflict. The container name \"/"+name+"\" is already in use")
+				return
+			}
+			c.name = name
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodDelete && action == "":
+			if c.running && r.URL.Query().Get("force") != "1" {
+				fail(http.StatusConflict, "You cannot remove a running container "+c.id)
+				return
+			}
+			delete(e.containers, c.id)
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			fail(http.StatusNotFound, "page not found")
+		}
+	default:
+		fail(http.StatusNotFound, "page not found")
+	}
+}
+
+// errCrash stands in for the server going down
+var errCrash = errors.New("simulated crash")
+
+// crashingHistoryRepo stores update histories as they were last saved. When
+// crash returns true for a save it ends the update by panicking with
+// errCrash, after storing the save when persist is set.
+type crashingHistoryRepo struct {
+	repository.UpdateHistoryRepository
+	histories map[int]*model.UpdateHistory
+	saves     int
+	crash     func(save int, history *model.UpdateHistory) bool
+	persist   bool
+}
+
+func newCrashingHistoryRepo() *crashingHistoryRepo {
+	return &crashingHistoryRepo{histories: make(map[int]*model.UpdateHistory)}
+}
+
+func (r *crashingHistoryRepo) store(history *model.UpdateHistory) {
+	stored := *history
+	if history.Checkpoint != nil {
+		data, _ := json.Marshal(history.Checkpoint)
+		stored.Checkpoint = &model.UpdateCheckpoint{}
+		json.Unmarshal(data, stored.Checkpoint)
+	}
+	r.histories[history.ID] = &stored
+}
+
+func (r *crashingHistoryRepo) Create(ctx context.Context, history *model.UpdateHistory) error {
+	history.ID = len(r.histories) + 1
+	r.store(history)
+	return nil
+}
+
+func (r *crashingHistoryRepo) Update(ctx context.Context, history *model.UpdateHistory) error {
+	r.saves++
+	if r.crash != nil && r.crash(r.saves, history) {
+		r.crash = nil
+		if r.persist {
+			r.store(history)
+		}
+		panic(errCrash)
+	}
+	r.store(history)
+	return nil
+}
+
+func (r *crashingHistoryRepo) GetByContainerID(ctx context.Context, containerID int64, limit, offset int) ([]*model.UpdateHistory, int64, error) {
+	latest := r.histories[len(r.histories)]
+	if latest == nil {
+		return nil, 0, nil
+	}
+	// Callers get their own copy, as from the database
+	r.store(latest)
+	return []*model.UpdateHistory{r.histories[len(r.histories)]}, 1, nil
+}
+
+// deployRepo records the Docker container a managed container is deployed as
+type deployRepo struct {
+	repository.ContainerRepository
+	container model.Container
+}
+
+func (r *deployRepo) UpdateContainerID(ctx context.Context, id int64, containerID string) error {
+	r.container.ContainerID = containerID
+	return nil
+}
+
+// recreateRun updates the container as stored in containers with a new task,
+// as a restarted server would, and reports whether the update crashed
+func recreateRun(t *testing.T, engine *fakeEngine, containers *deployRepo, histories *crashingHistoryRepo) (result *SingleContainerUpdateResult, crashed bool) {
+	t.Helper()
+
+	task := &ContainerUpdaterTask{containerRepo: containers, updateHistoryRepo: histories, dockerClient: engine.client(t)}
+	container := containers.container
+	params := &ContainerUpdateParameters{
+		UpdateStrategy:    "recreate",
+		PullPolicy:        "always",
+		RollbackOnFailure: true,
+		StopGracePeriod:   time.Second,
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			if r != errCrash {
+				panic(r)
+			}
+			crashed = true
+		}
+	}()
+	return task.updateSingleContainer(context.Background(), &container, params), false
+}
+
+func newRecreateFixture() (*fakeEngine, *deployRepo, *crashingHistoryRepo) {
+	containers := &deployRepo{container: model.Container{ID: 1, Name: "web", Image: "nginx", Tag: "1.26", ContainerID: oldWebID}}
+	return newFakeEngine(), containers, newCrashingHistoryRepo()
+}
+
+// assertOnlyContainer checks that the engine runs exactly one container,
+// named web, with the image, and that it is the one recorded as deployed
+func assertOnlyContainer(t *testing.T, engine *fakeEngine, containers *deployRepo, image string) {
+	t.Helper()
+
+	if len(engine.containers) != 1 {
+		names := []string{}
+		for _, c := range engine.containers {
+			names = append(names, fmt.Sprintf("%s (%s, running %v)", c.name, c.image, c.running))
+		}
+		t.Fatalf("engine has containers %v, want only web", names)
+	}
+	c := engine.byName("web")
+	if c == nil || !c.running || c.image != image {
+		t.Fatalf("web = %+v, want it running %s", c, image)
+	}
+	if containers.container.ContainerID != c.id {
+		t.Errorf("recorded container ID = %s, want %s", shortID(containers.container.ContainerID), shortID(c.id))
+	}
+}
+
+func TestRecreateResumesAfterCrashAtEverySave(t *testing.T) {
+	// A clean run counts the checkpoint saves to crash at
+	engine, containers, histories := newRecreateFixture()
+	result, _ := recreateRun(t, engine, containers, histories)
+	if !result.Success {
+		t.Fatalf("update failed: %s", result.Error)
+	}
+	assertOnlyContainer(t, engine, containers, "nginx:1.26")
+	saves := histories.saves
+
+	for save := 1; save <= saves; save++ {
+		for _, persist := range []bool{false, true} {
+			// Once the outcome is saved there is nothing to resume
+			if save == saves && persist {
+				continue
+			}
+
+			t.Run(fmt.Sprintf("save %d persisted %v", save, persist), func(t *testing.T) {
+				engine, containers, histories := newRecreateFixture()
+				histories.crash = func(n int, history *model.UpdateHistory) bool { return n == save }
+				histories.persist = persist
+
+				if _, crashed := recreateRun(t, engine, containers, histories); !crashed {
+					t.Fatal("update did not crash")
+				}
+				result, crashed := recreateRun(t, engine, containers, histories)
+				if crashed || !result.Success {
+					t.Fatalf("resumed update failed: %s", result.Error)
+				}
+
+				assertOnlyContainer(t, engine, containers, "nginx:1.26")
+				if len(histories.histories) != 1 {
+					t.Fatalf("%d update histories, want the one resumed", len(histories.histories))
+				}
+				history := histories.histories[1]
+				if history.Status != model.UpdateStatusCompleted || history.Checkpoint.Resumes != 1 {
+					t.Errorf("history status %s after %d resumes, want completed after 1", history.Status, history.Checkpoint.Resumes)
+				}
+				for _, step := range history.Checkpoint.Steps {
+					if step.Status != model.UpdateStepCompleted {
+						t.Errorf("step %s is %s", step.Name, step.Status)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestRecreateRollsBackWhenCompletedStepNoLongerHolds(t *testing.T) {
+	tests := []struct {
+		step    string
+		disturb func(e *fakeEngine)
+	}{
+		{model.UpdateStepPull, func(e *fakeEngine) { delete(e.images, "nginx:1.26") }},
+		{model.UpdateStepStopOld, func(e *fakeEngine) { e.containers[oldWebID].running = true }},
+		{model.UpdateStepCreateNew, func(e *fakeEngine) { delete(e.containers, e.byName("web-update-1").id) }},
+		{model.UpdateStepStartNew, func(e *fakeEngine) { e.byName("web-update-1").running = false }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.step, func(t *testing.T) {
+			engine, containers, histories := newRecreateFixture()
+			histories.crash = func(n int, history *model.UpdateHistory) bool {
+				return history.Checkpoint.IsCompleted(tt.step)
+			}
+			histories.persist = true
+
+			if _, crashed := recreateRun(t, engine, containers, histories); !crashed {
+				t.Fatal("update did not crash")
+			}
+			tt.disturb(engine)
+
+			result, crashed := recreateRun(t, engine, containers, histories)
+			if crashed || result.Success || !result.RolledBack {
+				t.Fatalf("resumed update: success %v, rolled back %v, error %q, want a rollback", result.Success, result.RolledBack, result.Error)
+			}
+
+			// The old container runs as before the update
+			assertOnlyContainer(t, engine, containers, "nginx:1.25")
+			if containers.container.ContainerID != oldWebID {
+				t.Errorf("recorded container ID changed to %s", shortID(containers.container.ContainerID))
+			}
+			history := histories.histories[1]
+			if history.Status != model.UpdateStatusRollback {
+				t.Errorf("history status = %s, want rollback", history.Status)
+			}
+			for _, step := range history.Checkpoint.Steps {
+				if step.Name == tt.step && step.Status != model.UpdateStepFailed {
+					t.Errorf("step %s is %s, want failed", step.Name, step.Status)
+				}
+			}
+		})
+	}
+}
+
+// hostRouter hands out the client of the one remote Docker host
+type hostRouter struct {
+	ContainerService
+	remote *docker.DockerClient
+}
+
+func (s *hostRouter) DockerFor(ctx context.Context, container *model.Container) (*docker.DockerClient, error) {
+	return s.remote, nil
+}
+
+func (s *hostRouter) IsSelfContainer(container *model.Container) bool { return false }
+
+func (s *hostRouter) PullProgressReporter(container *model.Container, history *model.UpdateHistory) func(model.PullProgress) {
+	return nil
+}
+
+func (s *hostRouter) ForgetUpdateProgress(updateID int) {}
+
+func TestRecreateRunsOnTheContainersDockerHost(t *testing.T) {
+	local := &fakeEngine{images: map[string]bool{}, containers: map[string]*fakeContainer{}}
+	remote, containers, histories := newRecreateFixture()
+	hostID := 7
+	containers.container.HostID = &hostID
+
+	task := &ContainerUpdaterTask{
+		containerRepo:     containers,
+		updateHistoryRepo: histories,
+		containerService:  &hostRouter{remote: remote.client(t)},
+		dockerClient:      local.client(t),
+	}
+	container := containers.container
+	result := task.updateSingleContainer(context.Background(), &container, &ContainerUpdateParameters{
+		UpdateStrategy:  "recreate",
+		PullPolicy:      "always",
+		StopGracePeriod: time.Second,
+	})
+	if !result.Success {
+		t.Fatalf("update failed: %s", result.Error)
+	}
+
+	assertOnlyContainer(t, remote, containers, "nginx:1.26")
+	if len(local.containers) != 0 || len(local.images) != 0 {
+		t.Errorf("the local daemon was changed: %d containers, %d images", len(local.containers), len(local.images))
+	}
+
+	// Without the container service the remote host cannot be reached, and
+	// the local daemon is not used in its place
+	engine, containers, histories := newRecreateFixture()
+	containers.container.HostID = &hostID
+	task = &ContainerUpdaterTask{containerRepo: containers, updateHistoryRepo: histories, dockerClient: engine.client(t)}
+	container = containers.container
+	result = task.updateSingleContainer(context.Background(), &container, &ContainerUpdateParameters{UpdateStrategy: "recreate", PullPolicy: "always"})
+	if result.Success || !strings.Contains(result.Error, "remote Docker host") {
+		t.Errorf("update of a container on an unreachable host: success %v, error %q", result.Success, result.Error)
+	}
+	if len(histories.histories) != 0 {
+		t.Errorf("%d update histories recorded for an update that never started", len(histories.histories))
+	}
+	assertOnlyContainer(t, engine, containers, "nginx:1.25")
+}
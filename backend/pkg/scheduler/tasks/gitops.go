@@ -0,0 +1,118 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+
+	"github.com/sirupsen/logrus"
+)
+
+// GitOpsTask implements the Task interface for reconciling the managed
+// containers with a GitOps source
+type GitOpsTask struct {
+	gitopsService GitOpsService
+}
+
+// NewGitOpsTask creates a new GitOps reconcile task
+func NewGitOpsTask(gitopsService GitOpsService) *GitOpsTask {
+	return &GitOpsTask{
+		gitopsService: gitopsService,
+	}
+}
+
+// Execute runs the GitOps reconcile task
+func (t *GitOpsTask) Execute(ctx context.Context, params scheduler.TaskParameters) error {
+	if t.gitopsService == nil {
+		return fmt.Errorf("gitops service not available")
+	}
+
+	req, err := t.parseParameters(params)
+	if err != nil {
+		return fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	run, err := t.gitopsService.Reconcile(ctx, model.TaskActor(params.TaskType), req)
+	if run != nil {
+		scheduler.SetResultData(ctx, "run_id", run.ID)
+		scheduler.SetResultData(ctx, "status", run.Status)
+		scheduler.SetResultData(ctx, "created", run.Created)
+		scheduler.SetResultData(ctx, "updated", run.Updated)
+		scheduler.SetResultData(ctx, "pruned", run.Pruned)
+		scheduler.SetResultData(ctx, "conflicts", run.Conflicts)
+		scheduler.SetResultData(ctx, "failed", run.Failed)
+	}
+	if err != nil {
+		return err
+	}
+
+	if run.Status != model.GitOpsRunSuccess {
+		logrus.WithFields(logrus.Fields{
+			"task_type": t.GetType(),
+			"run_id":    run.ID,
+			"conflicts": run.Conflicts,
+			"failed":    run.Failed,
+		}).Warn("GitOps reconcile left containers unreconciled")
+	}
+	return nil
+}
+
+// GetName returns the task name
+func (t *GitOpsTask) GetName() string {
+	return "GitOps Reconcile"
+}
+
+// GetType returns the task type
+func (t *GitOpsTask) GetType() model.TaskType {
+	return model.TaskTypeGitOps
+}
+
+// Validate validates task parameters
+func (t *GitOpsTask) Validate(params scheduler.TaskParameters) error {
+	if params.TaskType != model.TaskTypeGitOps {
+		return fmt.Errorf("invalid task type: expected %s, got %s", model.TaskTypeGitOps, params.TaskType)
+	}
+
+	req, err := t.parseParameters(params)
+	if err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+	if err := req.Validate(); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return nil
+}
+
+// GetDefaultTimeout returns the default timeout for this task
+func (t *GitOpsTask) GetDefaultTimeout() time.Duration {
+	return 10 * time.Minute
+}
+
+// CanRunConcurrently returns false; two runs would apply the same source twice
+func (t *GitOpsTask) CanRunConcurrently() bool {
+	return false
+}
+
+// parseParameters parses the source, prune, force and dry_run parameters
+func (t *GitOpsTask) parseParameters(params scheduler.TaskParameters) (*dto.GitOpsReconcileRequest, error) {
+	req := &dto.GitOpsReconcileRequest{}
+
+	if params.Parameters != nil {
+		jsonData, err := json.Marshal(params.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parameters: %w", err)
+		}
+
+		if err := json.Unmarshal(jsonData, req); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
+		}
+	}
+
+	return req, nil
+}
@@ -10,20 +10,27 @@ import (
 	"sync"
 	"time"
 
-	"docker-auto/internal/model"
 	"docker-auto/internal/repository"
-	"docker-auto/internal/service"
 	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/scheduler"
 
+	"github.com/docker/docker/api/types"
 	"github.com/sirupsen/logrus"
 )
 
+// healthActionTimeout bounds a single exec or webhook remediation action
+const healthActionTimeout = 30 * time.Second
+
 // HealthCheckerTask implements the Task interface for container health checking
 type HealthCheckerTask struct {
 	containerRepo       repository.ContainerRepository
-	containerService    *service.ContainerService
-	notificationService *service.NotificationService
+	healthStateRepo     repository.ContainerHealthStateRepository
+	healthCheckRepo     repository.ContainerHealthCheckRepository
+	containerService    ContainerService
+	notificationService NotificationService
+	webhookService      WebhookService
+	featureService      FeatureService
 	dockerClient        *docker.DockerClient
 	httpClient          *http.Client
 }
@@ -31,14 +38,22 @@ type HealthCheckerTask struct {
 // NewHealthCheckerTask creates a new health checker task
 func NewHealthCheckerTask(
 	containerRepo repository.ContainerRepository,
-	containerService *service.ContainerService,
-	notificationService *service.NotificationService,
+	healthStateRepo repository.ContainerHealthStateRepository,
+	healthCheckRepo repository.ContainerHealthCheckRepository,
+	containerService ContainerService,
+	notificationService NotificationService,
+	webhookService WebhookService,
+	featureService FeatureService,
 	dockerClient *docker.DockerClient,
 ) *HealthCheckerTask {
 	return &HealthCheckerTask{
 		containerRepo:       containerRepo,
+		healthStateRepo:     healthStateRepo,
+		healthCheckRepo:     healthCheckRepo,
 		containerService:    containerService,
 		notificationService: notificationService,
+		webhookService:      webhookService,
+		featureService:      featureService,
 		dockerClient:        dockerClient,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
@@ -351,7 +366,7 @@ func (t *HealthCheckerTask) getContainersToCheck(ctx context.Context, targetCont
 	} else {
 		// Check all running containers
 		filter := &model.ContainerFilter{
-			Status: &model.ContainerStatusRunning,
+			Status: model.ContainerStatusRunning,
 			Limit:  1000,
 		}
 
@@ -445,25 +460,50 @@ func (t *HealthCheckerTask) checkContainerHealth(ctx context.Context, container
 		"container_name": container.Name,
 	})
 
+	// Probe the daemon the container runs on. While its Docker host cannot
+	// be reached the container's health is unknown, and its state is kept.
+	dc, err := dockerFor(ctx, t.containerService, t.dockerClient, container)
+	if err != nil {
+		result.OverallHealth = HealthStatusUnknown
+		result.Error = err.Error()
+		result.Duration = time.Since(startTime)
+		logger.WithError(err).Warn("Cannot reach the Docker host of container")
+		return result
+	}
+
 	// Check Docker health status
 	if params.EnableDockerHealth {
-		result.DockerHealth = t.checkDockerHealth(ctx, container, params)
+		result.DockerHealth = t.checkDockerHealth(ctx, dc, container, params)
 	}
 
 	// Get resource metrics
-	result.ResourceMetrics = t.getResourceMetrics(ctx, container)
+	result.ResourceMetrics = t.getResourceMetrics(ctx, dc, container)
 
-	// Perform custom health checks
-	result.CustomChecks = t.performCustomChecks(ctx, container, params)
+	// Perform custom health checks, from the task parameters and those
+	// configured for the container
+	result.CustomChecks = t.performCustomChecks(ctx, dc, container, params)
+	storedChecks, restartOnFailure := t.performStoredChecks(ctx, dc, container)
+	result.CustomChecks = append(result.CustomChecks, storedChecks...)
 
 	// Determine overall health status
 	result.OverallHealth = t.determineOverallHealth(result)
 
-	// Take actions based on health status
-	if result.OverallHealth == HealthStatusUnhealthy {
-		actions := t.takeHealthActions(ctx, container, result, params)
+	// Update the persisted health state and take actions based on it
+	state := t.loadHealthState(ctx, container)
+	switch result.OverallHealth {
+	case HealthStatusHealthy:
+		state.MarkHealthy(startTime)
+	case HealthStatusUnhealthy:
+		state.MarkUnhealthy(string(result.OverallHealth), startTime)
+		result.ConsecutiveFailures = state.ConsecutiveFailures
+		actions := t.takeHealthActions(ctx, dc, container, result, state, params, restartOnFailure)
 		result.ActionsTaken = append(result.ActionsTaken, actions...)
+	default:
+		state.Status = string(result.OverallHealth)
+		state.LastCheckedAt = &startTime
 	}
+	result.LastHealthyAt = state.LastHealthyAt
+	t.saveHealthState(ctx, state)
 
 	result.Duration = time.Since(startTime)
 
@@ -477,25 +517,43 @@ func (t *HealthCheckerTask) checkContainerHealth(ctx context.Context, container
 }
 
 // checkDockerHealth checks Docker's built-in health status
-func (t *HealthCheckerTask) checkDockerHealth(ctx context.Context, container *model.Container, params *HealthCheckParameters) *DockerHealthInfo {
-	if t.dockerClient == nil || container.ContainerID == "" {
+func (t *HealthCheckerTask) checkDockerHealth(ctx context.Context, dc *docker.DockerClient, container *model.Container, params *HealthCheckParameters) *DockerHealthInfo {
+	if dc == nil || container.ContainerID == "" {
 		return nil
 	}
 
 	healthInfo := &DockerHealthInfo{}
 
-	// Get container status including health
-	status, err := t.dockerClient.GetContainerStatus(ctx, container.ContainerID)
+	// Get container state including health
+	containerJSON, err := dc.GetContainer(ctx, container.ContainerID)
 	if err != nil {
 		logrus.WithError(err).WithField("container_id", container.ContainerID).Warn("Failed to get container status")
 		return nil
 	}
+	if containerJSON.ContainerJSONBase == nil || containerJSON.State == nil {
+		return nil
+	}
+	state := containerJSON.State
 
-	healthInfo.Status = status.Health
+	if state.Health != nil {
+		healthInfo.Status = state.Health.Status
+		healthInfo.FailingStreak = state.Health.FailingStreak
+		for _, entry := range state.Health.Log {
+			if entry == nil {
+				continue
+			}
+			healthInfo.Log = append(healthInfo.Log, DockerHealthLog{
+				Start:    entry.Start,
+				End:      entry.End,
+				ExitCode: entry.ExitCode,
+				Output:   entry.Output,
+			})
+		}
+	}
 
 	// If Docker health check is not configured, return basic status
-	if healthInfo.Status == "" {
-		if status.Running {
+	if healthInfo.Status == "" || healthInfo.Status == types.NoHealthcheck {
+		if state.Running {
 			healthInfo.Status = "healthy"
 		} else {
 			healthInfo.Status = "unhealthy"
@@ -506,13 +564,13 @@ func (t *HealthCheckerTask) checkDockerHealth(ctx context.Context, container *mo
 }
 
 // getResourceMetrics retrieves resource usage metrics for the container
-func (t *HealthCheckerTask) getResourceMetrics(ctx context.Context, container *model.Container) *ResourceMetrics {
-	if t.dockerClient == nil || container.ContainerID == "" {
+func (t *HealthCheckerTask) getResourceMetrics(ctx context.Context, dc *docker.DockerClient, container *model.Container) *ResourceMetrics {
+	if dc == nil || container.ContainerID == "" {
 		return nil
 	}
 
 	// Get container stats
-	stats, err := t.dockerClient.GetContainerStats(ctx, container.ContainerID, false)
+	stats, err := dc.GetContainerMetrics(ctx, container.ContainerID)
 	if err != nil {
 		logrus.WithError(err).WithField("container_id", container.ContainerID).Warn("Failed to get container stats")
 		return nil
@@ -520,18 +578,18 @@ func (t *HealthCheckerTask) getResourceMetrics(ctx context.Context, container *m
 
 	// Convert stats to our metrics format
 	metrics := &ResourceMetrics{
-		CPUPercent:    stats.CPUPercent,
-		MemoryUsage:   int64(stats.MemoryUsage),
-		MemoryPercent: stats.MemoryPercent,
+		CPUPercent:    stats.CPU.CPUPercent,
+		MemoryUsage:   int64(stats.Memory.Usage),
+		MemoryPercent: stats.Memory.MemoryPercent,
 		NetworkIO: NetworkIO{
-			RxBytes:   int64(stats.NetworkRx),
-			TxBytes:   int64(stats.NetworkTx),
-			RxPackets: 0, // Not available in simplified stats
-			TxPackets: 0, // Not available in simplified stats
+			RxBytes:   int64(stats.Network.RxBytes),
+			TxBytes:   int64(stats.Network.TxBytes),
+			RxPackets: int64(stats.Network.RxPackets),
+			TxPackets: int64(stats.Network.TxPackets),
 		},
 		DiskIO: DiskIO{
-			ReadBytes:  int64(stats.DiskRead),
-			WriteBytes: int64(stats.DiskWrite),
+			ReadBytes:  int64(stats.BlockIO.ReadBytes),
+			WriteBytes: int64(stats.BlockIO.WriteBytes),
 		},
 	}
 
@@ -539,7 +597,7 @@ func (t *HealthCheckerTask) getResourceMetrics(ctx context.Context, container *m
 }
 
 // performCustomChecks performs custom health checks
-func (t *HealthCheckerTask) performCustomChecks(ctx context.Context, container *model.Container, params *HealthCheckParameters) []*CustomCheckResult {
+func (t *HealthCheckerTask) performCustomChecks(ctx context.Context, dc *docker.DockerClient, container *model.Container, params *HealthCheckParameters) []*CustomCheckResult {
 	var results []*CustomCheckResult
 
 	// Perform HTTP checks
@@ -561,7 +619,7 @@ func (t *HealthCheckerTask) performCustomChecks(ctx context.Context, container *
 	// Perform command checks
 	for _, cmdCheck := range params.CommandChecks {
 		if cmdCheck.ContainerName == container.Name {
-			result := t.performCommandCheck(ctx, container, cmdCheck)
+			result := t.performCommandCheck(ctx, dc, container, cmdCheck)
 			results = append(results, result)
 		}
 	}
@@ -569,6 +627,109 @@ func (t *HealthCheckerTask) performCustomChecks(ctx context.Context, container *
 	return results
 }
 
+// performStoredChecks runs the health checks configured for the container
+// that are due and saves their outcome. Every enabled check that has run is
+// reported, failed once it reaches its failure threshold, so its last outcome
+// counts between runs. It also reports whether a failed check asks for the
+// container to be restarted.
+func (t *HealthCheckerTask) performStoredChecks(ctx context.Context, dc *docker.DockerClient, container *model.Container) ([]*CustomCheckResult, bool) {
+	if t.healthCheckRepo == nil {
+		return nil, false
+	}
+
+	checks, err := t.healthCheckRepo.ListByContainer(ctx, container.ID)
+	if err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to load container health checks")
+		return nil, false
+	}
+
+	var results []*CustomCheckResult
+	restart := false
+	now := time.Now()
+	for _, check := range checks {
+		if !check.Enabled {
+			continue
+		}
+
+		if check.Due(now) {
+			run := t.runStoredCheck(ctx, dc, container, check)
+			message := run.Message
+			if !run.Success {
+				message = run.Error
+			}
+			check.Record(run.Success, message, run.Duration, now)
+			if err := t.healthCheckRepo.SaveResult(ctx, check); err != nil {
+				logrus.WithError(err).WithField("health_check_id", check.ID).Warn("Failed to save health check result")
+			}
+		}
+
+		result := &CustomCheckResult{
+			CheckType: string(check.Type),
+			CheckName: check.Name,
+			Success:   !check.Failing(),
+			Duration:  time.Duration(check.LastDurationMS) * time.Millisecond,
+			Message:   check.LastMessage,
+			Details: map[string]interface{}{
+				"health_check_id":      check.ID,
+				"consecutive_failures": check.ConsecutiveFailures,
+				"failure_threshold":    check.Threshold(),
+			},
+		}
+		if check.Failing() {
+			result.Error = check.LastMessage
+			restart = restart || check.RestartOnFailure
+		}
+		results = append(results, result)
+	}
+
+	return results, restart
+}
+
+// runStoredCheck runs one configured health check
+func (t *HealthCheckerTask) runStoredCheck(ctx context.Context, dc *docker.DockerClient, container *model.Container, check *model.ContainerHealthCheck) *CustomCheckResult {
+	switch check.Type {
+	case model.HealthCheckHTTP:
+		method := check.Method
+		if method == "" {
+			method = http.MethodGet
+		}
+		return t.performHTTPCheck(ctx, HTTPHealthCheck{
+			ContainerName:  container.Name,
+			URL:            check.Endpoint,
+			Method:         method,
+			ExpectedStatus: check.ExpectedStatus,
+			ExpectedBody:   check.ExpectedBody,
+			Timeout:        check.Timeout(),
+		})
+	case model.HealthCheckTCP:
+		host, port, err := check.HostPort()
+		if err != nil {
+			return &CustomCheckResult{CheckType: string(check.Type), CheckName: check.Name, Error: err.Error()}
+		}
+		return t.performTCPCheck(ctx, TCPHealthCheck{
+			ContainerName: container.Name,
+			Host:          host,
+			Port:          port,
+			Timeout:       check.Timeout(),
+		})
+	case model.HealthCheckCommand:
+		cmdCtx, cancel := context.WithTimeout(ctx, check.Timeout())
+		defer cancel()
+		return t.performCommandCheck(cmdCtx, dc, container, CommandHealthCheck{
+			ContainerName: container.Name,
+			Command:       []string(check.Command),
+			ExpectedExit:  check.ExpectedExit,
+			Timeout:       check.Timeout(),
+		})
+	}
+
+	return &CustomCheckResult{
+		CheckType: string(check.Type),
+		CheckName: check.Name,
+		Error:     fmt.Sprintf("unknown health check type %q", check.Type),
+	}
+}
+
 // performHTTPCheck performs an HTTP health check
 func (t *HealthCheckerTask) performHTTPCheck(ctx context.Context, check HTTPHealthCheck) *CustomCheckResult {
 	startTime := time.Now()
@@ -659,21 +820,21 @@ func (t *HealthCheckerTask) performTCPCheck(ctx context.Context, check TCPHealth
 }
 
 // performCommandCheck performs a command-based health check
-func (t *HealthCheckerTask) performCommandCheck(ctx context.Context, container *model.Container, check CommandHealthCheck) *CustomCheckResult {
+func (t *HealthCheckerTask) performCommandCheck(ctx context.Context, dc *docker.DockerClient, container *model.Container, check CommandHealthCheck) *CustomCheckResult {
 	startTime := time.Now()
 	result := &CustomCheckResult{
 		CheckType: "command",
 		CheckName: fmt.Sprintf("Command: %s", strings.Join(check.Command, " ")),
 	}
 
-	if t.dockerClient == nil || container.ContainerID == "" {
+	if dc == nil || container.ContainerID == "" {
 		result.Error = "Docker client not available or container not running"
 		result.Duration = time.Since(startTime)
 		return result
 	}
 
 	// Execute command in container
-	execResult, err := t.dockerClient.ExecCommand(ctx, container.ContainerID, check.Command)
+	execResult, err := dc.ExecCommand(ctx, container.ContainerID, check.Command)
 	if err != nil {
 		result.Error = fmt.Sprintf("Command execution failed: %v", err)
 		result.Duration = time.Since(startTime)
@@ -743,44 +904,196 @@ func (t *HealthCheckerTask) determineOverallHealth(result *ContainerHealthResult
 	return HealthStatusHealthy
 }
 
-// takeHealthActions takes appropriate actions based on health status
-func (t *HealthCheckerTask) takeHealthActions(ctx context.Context, container *model.Container, result *ContainerHealthResult, params *HealthCheckParameters) []HealthAction {
+// takeHealthActions runs the container's remediation chain in order until an
+// action succeeds. Containers without a chain fall back to a restart with the
+// task's restart settings when the task's restart_on_failure is set or a
+// failing configured check asks for it, as do all containers while the
+// health_remediation flag is off.
+func (t *HealthCheckerTask) takeHealthActions(ctx context.Context, dc *docker.DockerClient, container *model.Container, result *ContainerHealthResult, state *model.ContainerHealthState, params *HealthCheckParameters, restartOnFailure bool) []HealthAction {
+	var chain model.HealthActionList
+	if t.featureService.Enabled(ctx, model.FeatureHealthRemediation) {
+		chain = container.HealthActions
+	}
+	if len(chain) == 0 && (params.RestartOnFailure || restartOnFailure) {
+		chain = model.HealthActionList{{
+			Type:            model.HealthActionRestart,
+			MaxAttempts:     params.RestartMaxAttempts,
+			CooldownSeconds: int(params.RestartDelay.Seconds()),
+		}}
+	}
+
 	var actions []HealthAction
+	now := time.Now()
+	for i, config := range chain {
+		actionState := state.ActionState(i, config.Type)
+		if actionState.Waiting(now) {
+			break
+		}
+		if !actionState.Ready(config, now) {
+			continue
+		}
 
-	// Restart container if configured and appropriate
-	if params.RestartOnFailure && result.OverallHealth == HealthStatusUnhealthy {
-		action := t.restartContainer(ctx, container, params)
-		actions = append(actions, action)
+		record := t.runHealthAction(ctx, dc, container, result, config)
+		actionState.Attempted(config, now, record.Success)
+		record.Attempt = actionState.Attempts
+		state.RecordAction(record)
+
+		actions = append(actions, HealthAction{
+			Action:    string(record.Type),
+			Timestamp: record.Timestamp,
+			Success:   record.Success,
+			Message:   record.Message,
+			Error:     record.Error,
+		})
+
+		logrus.WithFields(logrus.Fields{
+			"container_id": container.ID,
+			"action":       record.Type,
+			"attempt":      record.Attempt,
+			"success":      record.Success,
+		}).Info("Health remediation action taken")
+
+		if record.Success {
+			break
+		}
 	}
 
 	return actions
 }
 
-// restartContainer attempts to restart an unhealthy container
-func (t *HealthCheckerTask) restartContainer(ctx context.Context, container *model.Container, params *HealthCheckParameters) HealthAction {
-	action := HealthAction{
-		Action:    "restart",
+// runHealthAction runs a single remediation action
+func (t *HealthCheckerTask) runHealthAction(ctx context.Context, dc *docker.DockerClient, container *model.Container, result *ContainerHealthResult, config model.HealthActionConfig) model.HealthActionRecord {
+	record := model.HealthActionRecord{
+		Type:      config.Type,
 		Timestamp: time.Now(),
 	}
 
+	switch config.Type {
+	case model.HealthActionRestart:
+		t.restartContainer(ctx, container, &record)
+	case model.HealthActionExec:
+		t.execRemediation(ctx, dc, container, config, &record)
+	case model.HealthActionWebhook:
+		t.webhookRemediation(ctx, container, result, config, &record)
+	default:
+		record.Error = fmt.Sprintf("unknown health action type %q", config.Type)
+	}
+
+	return record
+}
+
+// restartContainer attempts to restart an unhealthy container
+func (t *HealthCheckerTask) restartContainer(ctx context.Context, container *model.Container, record *model.HealthActionRecord) {
 	if t.containerService == nil {
-		action.Error = "Container service not available"
-		action.Success = false
-		return action
+		record.Error = "Container service not available"
+		return
 	}
 
-	// Note: In a real implementation, you would check restart limits and cooldowns
-	// For now, we'll attempt the restart
-	err := t.containerService.RestartContainer(ctx, 1, int64(container.ID)) // userID would come from context
+	actor := model.SystemActor(model.ActorComponentHealthChecker)
+	if err := t.containerService.RestartContainer(model.WithActor(ctx, actor), actor, int64(container.ID)); err != nil {
+		record.Error = fmt.Sprintf("Restart failed: %v", err)
+		return
+	}
+
+	record.Success = true
+	record.Message = "Container restarted successfully"
+}
+
+// execRemediation runs the action's command inside the container; it succeeds
+// when the command exits with 0
+func (t *HealthCheckerTask) execRemediation(ctx context.Context, dc *docker.DockerClient, container *model.Container, config model.HealthActionConfig, record *model.HealthActionRecord) {
+	if dc == nil || container.ContainerID == "" {
+		record.Error = "Docker client not available or container not running"
+		return
+	}
+
+	execCtx, cancel := context.WithTimeout(ctx, healthActionTimeout)
+	defer cancel()
+
+	execResult, err := dc.ExecCommand(execCtx, container.ContainerID, config.Command)
 	if err != nil {
-		action.Error = fmt.Sprintf("Restart failed: %v", err)
-		action.Success = false
-	} else {
-		action.Success = true
-		action.Message = "Container restarted successfully"
+		record.Error = fmt.Sprintf("Command execution failed: %v", err)
+		return
+	}
+
+	record.ExitCode = &execResult.ExitCode
+	record.Output = execResult.Stdout + execResult.Stderr
+	if execResult.ExitCode != 0 {
+		record.Error = fmt.Sprintf("Command exited with code %d", execResult.ExitCode)
+		return
 	}
 
-	return action
+	record.Success = true
+	record.Message = fmt.Sprintf("Command %q completed", strings.Join(config.Command, " "))
+}
+
+// HealthRemediationWebhook is the body posted by webhook remediation actions
+type HealthRemediationWebhook struct {
+	Event               string               `json:"event"`
+	ContainerID         int                  `json:"container_id"`
+	ContainerName       string               `json:"container_name"`
+	Image               string               `json:"image"`
+	OverallHealth       HealthStatus         `json:"overall_health"`
+	ConsecutiveFailures int                  `json:"consecutive_failures"`
+	LastHealthyAt       *time.Time           `json:"last_healthy_at,omitempty"`
+	DockerHealth        *DockerHealthInfo    `json:"docker_health,omitempty"`
+	CustomChecks        []*CustomCheckResult `json:"custom_checks,omitempty"`
+	CheckedAt           time.Time            `json:"checked_at"`
+}
+
+// webhookRemediation posts the health result to the action's webhook; it
+// succeeds on a 2xx response
+func (t *HealthCheckerTask) webhookRemediation(ctx context.Context, container *model.Container, result *ContainerHealthResult, config model.HealthActionConfig, record *model.HealthActionRecord) {
+	if t.webhookService == nil {
+		record.Error = "Webhook service not available"
+		return
+	}
+
+	webhookCtx, cancel := context.WithTimeout(ctx, healthActionTimeout)
+	defer cancel()
+
+	statusCode, err := t.webhookService.Post(webhookCtx, config.URL, &HealthRemediationWebhook{
+		Event:               "container.unhealthy",
+		ContainerID:         container.ID,
+		ContainerName:       container.Name,
+		Image:               container.GetFullImageName(),
+		OverallHealth:       result.OverallHealth,
+		ConsecutiveFailures: result.ConsecutiveFailures,
+		LastHealthyAt:       result.LastHealthyAt,
+		DockerHealth:        result.DockerHealth,
+		CustomChecks:        result.CustomChecks,
+		CheckedAt:           result.CheckedAt,
+	})
+	record.StatusCode = statusCode
+	if err != nil {
+		record.Error = fmt.Sprintf("Webhook failed: %v", err)
+		return
+	}
+
+	record.Success = true
+	record.Message = fmt.Sprintf("Webhook returned status %d", statusCode)
+}
+
+// loadHealthState returns the container's persisted health state, or a fresh
+// one when it cannot be loaded
+func (t *HealthCheckerTask) loadHealthState(ctx context.Context, container *model.Container) *model.ContainerHealthState {
+	if t.healthStateRepo != nil {
+		state, err := t.healthStateRepo.GetByContainerID(ctx, container.ID)
+		if err == nil {
+			return state
+		}
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to load container health state")
+	}
+	return &model.ContainerHealthState{ContainerID: container.ID}
+}
+
+func (t *HealthCheckerTask) saveHealthState(ctx context.Context, state *model.ContainerHealthState) {
+	if t.healthStateRepo == nil {
+		return
+	}
+	if err := t.healthStateRepo.Save(ctx, state); err != nil {
+		logrus.WithError(err).WithField("container_id", state.ContainerID).Warn("Failed to save container health state")
+	}
 }
 
 // processResults processes the health check results
@@ -821,11 +1134,14 @@ func (t *HealthCheckerTask) sendUnhealthyNotification(ctx context.Context, resul
 		Message:  fmt.Sprintf("Health check found %d unhealthy container(s): %s",
 			results.UnhealthyContainers, strings.Join(unhealthyContainers, ", ")),
 		Priority: model.NotificationPriorityHigh,
-		Data: map[string]interface{}{
-			"unhealthy_containers": unhealthyContainers,
-			"total_checked":        len(results.ContainerResults),
-			"healthy_containers":   results.HealthyContainers,
-		},
+		Data: model.NotificationData(model.NewHealthAlertPayload(
+			model.HealthAlertEventUnhealthy,
+			len(results.ContainerResults),
+			results.HealthyContainers,
+			results.UnhealthyContainers,
+			results.RestartedContainers,
+			unhealthyContainers,
+		)),
 	}
 
 	return t.notificationService.SendNotification(ctx, notification)
@@ -843,11 +1159,14 @@ func (t *HealthCheckerTask) sendRecoveryNotification(ctx context.Context, result
 		Message:  fmt.Sprintf("Attempted to restart %d unhealthy container(s)",
 			results.RestartedContainers),
 		Priority: model.NotificationPriorityNormal,
-		Data: map[string]interface{}{
-			"restarted_containers": results.RestartedContainers,
-			"unhealthy_containers": results.UnhealthyContainers,
-			"total_checked":        len(results.ContainerResults),
-		},
+		Data: model.NotificationData(model.NewHealthAlertPayload(
+			model.HealthAlertEventRecovery,
+			len(results.ContainerResults),
+			results.HealthyContainers,
+			results.UnhealthyContainers,
+			results.RestartedContainers,
+			nil,
+		)),
 	}
 
 	return t.notificationService.SendNotification(ctx, notification)
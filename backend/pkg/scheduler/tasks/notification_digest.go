@@ -0,0 +1,70 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+)
+
+// NotificationDigestTask implements the Task interface for sending the
+// update notification digests that are due
+type NotificationDigestTask struct {
+	digestService NotificationDigestService
+}
+
+// NewNotificationDigestTask creates a new notification digest task
+func NewNotificationDigestTask(digestService NotificationDigestService) *NotificationDigestTask {
+	return &NotificationDigestTask{
+		digestService: digestService,
+	}
+}
+
+// Execute runs the notification digest task
+func (t *NotificationDigestTask) Execute(ctx context.Context, params scheduler.TaskParameters) error {
+	if t.digestService == nil {
+		return fmt.Errorf("notification digest service not available")
+	}
+
+	result, err := t.digestService.FlushDigests(ctx, time.Now())
+	if err != nil {
+		return fmt.Errorf("failed to send notification digests: %w", err)
+	}
+
+	scheduler.SetResultData(ctx, "users", result.Users)
+	scheduler.SetResultData(ctx, "sent", result.Sent)
+	scheduler.SetResultData(ctx, "notifications", result.Notifications)
+	scheduler.SetResultData(ctx, "failed", result.Failed)
+
+	return nil
+}
+
+// GetName returns the task name
+func (t *NotificationDigestTask) GetName() string {
+	return "Notification Digest"
+}
+
+// GetType returns the task type
+func (t *NotificationDigestTask) GetType() model.TaskType {
+	return model.TaskTypeNotificationDigest
+}
+
+// Validate validates task parameters
+func (t *NotificationDigestTask) Validate(params scheduler.TaskParameters) error {
+	if params.TaskType != model.TaskTypeNotificationDigest {
+		return fmt.Errorf("invalid task type: expected %s, got %s", model.TaskTypeNotificationDigest, params.TaskType)
+	}
+	return nil
+}
+
+// GetDefaultTimeout returns the default timeout for this task
+func (t *NotificationDigestTask) GetDefaultTimeout() time.Duration {
+	return 5 * time.Minute
+}
+
+// CanRunConcurrently returns false so a digest is not sent twice
+func (t *NotificationDigestTask) CanRunConcurrently() bool {
+	return false
+}
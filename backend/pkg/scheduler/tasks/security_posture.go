@@ -0,0 +1,71 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+)
+
+// SecurityPostureTask implements the Task interface for scoring the security
+// posture on a schedule
+type SecurityPostureTask struct {
+	postureService SecurityPostureService
+}
+
+// NewSecurityPostureTask creates a new security posture task
+func NewSecurityPostureTask(postureService SecurityPostureService) *SecurityPostureTask {
+	return &SecurityPostureTask{
+		postureService: postureService,
+	}
+}
+
+// Execute runs the security posture task
+func (t *SecurityPostureTask) Execute(ctx context.Context, params scheduler.TaskParameters) error {
+	if t.postureService == nil {
+		return fmt.Errorf("security posture service not available")
+	}
+
+	report, err := t.postureService.Evaluate(ctx, model.TaskActor(params.TaskType))
+	if err != nil {
+		return fmt.Errorf("failed to evaluate security posture: %w", err)
+	}
+
+	scheduler.SetResultData(ctx, "report_id", report.ID)
+	scheduler.SetResultData(ctx, "score", report.Score)
+	scheduler.SetResultData(ctx, "grade", report.Grade)
+	scheduler.SetResultData(ctx, "findings", len(report.Findings))
+
+	return nil
+}
+
+// GetName returns the task name
+func (t *SecurityPostureTask) GetName() string {
+	return "Security Posture"
+}
+
+// GetType returns the task type
+func (t *SecurityPostureTask) GetType() model.TaskType {
+	return model.TaskTypeSecurityPosture
+}
+
+// Validate validates task parameters
+func (t *SecurityPostureTask) Validate(params scheduler.TaskParameters) error {
+	if params.TaskType != model.TaskTypeSecurityPosture {
+		return fmt.Errorf("invalid task type: expected %s, got %s", model.TaskTypeSecurityPosture, params.TaskType)
+	}
+	return nil
+}
+
+// GetDefaultTimeout returns the default timeout for this task
+func (t *SecurityPostureTask) GetDefaultTimeout() time.Duration {
+	return 5 * time.Minute
+}
+
+// CanRunConcurrently returns false so overlapping runs do not both report
+// the same regressions
+func (t *SecurityPostureTask) CanRunConcurrently() bool {
+	return false
+}
@@ -0,0 +1,99 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/docker"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/registry"
+)
+
+// The tasks reach the services through these interfaces so that this package
+// does not depend on the service package. A service that is not configured
+// must be passed as a nil interface, not a nil pointer, since the tasks check
+// for nil before using one.
+
+// ContainerService is the part of the container service the tasks use
+type ContainerService interface {
+	DiscoverContainers(ctx context.Context, actor model.Actor) (*dto.DiscoveryResult, error)
+	DockerFor(ctx context.Context, container *model.Container) (*docker.DockerClient, error)
+	EffectivePolicy(ctx context.Context, container *model.Container) (*model.EffectivePolicy, error)
+	IsSelfContainer(container *model.Container) bool
+	PullProgressReporter(container *model.Container, history *model.UpdateHistory) func(model.PullProgress)
+	ForgetUpdateProgress(updateID int)
+	RecordDaemonWarnings(ctx context.Context, container *model.Container, warnings []string) model.StringList
+	RestartContainer(ctx context.Context, actor model.Actor, containerID int64) error
+	RunPostStart(ctx context.Context, actor model.Actor, container *model.Container, dockerID, trigger string, healthTimeout time.Duration) (*model.PostStartRun, error)
+	StartSelfUpdate(ctx context.Context, actor model.Actor, container *model.Container, trigger model.TriggerType, healthTimeoutSeconds int) (*model.UpdateHistory, error)
+	SyncContainerStatus(ctx context.Context, full bool) (*dto.SyncResult, error)
+}
+
+// dockerFor returns the client of the daemon the container runs on: local
+// for containers without a Docker host, otherwise the host's client, which
+// the container service resolves
+func dockerFor(ctx context.Context, containerService ContainerService, local *docker.DockerClient, container *model.Container) (*docker.DockerClient, error) {
+	if container.HostID == nil {
+		return local, nil
+	}
+	if containerService == nil {
+		return nil, fmt.Errorf("container %s runs on a remote Docker host, which cannot be reached without the container service", container.Name)
+	}
+	return containerService.DockerFor(ctx, container)
+}
+
+// ImageService records the image versions the update checker finds and
+// applies the containers' version policies to them
+type ImageService interface {
+	ApplyVersionPolicy(ctx context.Context, container *model.Container, result *registry.UpdateCheckResult) error
+	RecordImageVersion(ctx context.Context, container *model.Container, tag, digest string) (*model.ImageVersionRecord, error)
+}
+
+// ApprovalService records the updates the update checker finds as pending and
+// applies the approval policies to them
+type ApprovalService interface {
+	ProposeUpdate(ctx context.Context, proposal *dto.UpdateProposal) (*dto.ApprovalDecision, error)
+}
+
+// NotificationService sends the notifications tasks raise
+type NotificationService interface {
+	SendNotification(ctx context.Context, notification *model.Notification) error
+}
+
+// ChangeFeedService delivers and prunes the container change feed
+type ChangeFeedService interface {
+	DeliverWebhook(ctx context.Context, batchSize, maxBatches int) (int, error)
+	PruneChanges(ctx context.Context, retentionDays int, dryRun bool) (int64, error)
+}
+
+// WebhookService posts health remediation webhooks
+type WebhookService interface {
+	Post(ctx context.Context, url string, payload interface{}) (int, error)
+}
+
+// FeatureService reports whether optional features are enabled
+type FeatureService interface {
+	Enabled(ctx context.Context, feature model.Feature) bool
+}
+
+// VolumeService samples volume usage
+type VolumeService interface {
+	Sample(ctx context.Context, req *dto.VolumeSampleRequest) (*dto.VolumeSampleResult, error)
+}
+
+// SecurityPostureService evaluates the security posture
+type SecurityPostureService interface {
+	Evaluate(ctx context.Context, actor model.Actor) (*model.SecurityPostureReport, error)
+}
+
+// GitOpsService reconciles the managed containers with a GitOps source
+type GitOpsService interface {
+	Reconcile(ctx context.Context, actor model.Actor, req *dto.GitOpsReconcileRequest) (*model.GitOpsRun, error)
+}
+
+// NotificationDigestService sends the notification digests that are due
+type NotificationDigestService interface {
+	FlushDigests(ctx context.Context, now time.Time) (*dto.DigestFlushResult, error)
+}
@@ -0,0 +1,117 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+
+	"github.com/sirupsen/logrus"
+)
+
+// StatusSyncTask implements the Task interface for synchronizing container
+// status with the Docker daemon
+type StatusSyncTask struct {
+	containerService ContainerService
+}
+
+// NewStatusSyncTask creates a new container status sync task
+func NewStatusSyncTask(containerService ContainerService) *StatusSyncTask {
+	return &StatusSyncTask{
+		containerService: containerService,
+	}
+}
+
+// StatusSyncParameters represents parameters for the status sync
+type StatusSyncParameters struct {
+	// Full inspects every container instead of only the changed and stale ones
+	Full bool `json:"full"`
+}
+
+// Execute runs the status sync task
+func (t *StatusSyncTask) Execute(ctx context.Context, params scheduler.TaskParameters) error {
+	if t.containerService == nil {
+		return fmt.Errorf("container service not available")
+	}
+
+	syncParams, err := t.parseParameters(params)
+	if err != nil {
+		return fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	result, err := t.containerService.SyncContainerStatus(ctx, syncParams.Full)
+	if err != nil {
+		return fmt.Errorf("failed to sync container status: %w", err)
+	}
+
+	scheduler.SetResultData(ctx, "inspected", result.Inspected)
+	scheduler.SetResultData(ctx, "skipped", result.Skipped)
+	scheduler.SetResultData(ctx, "full", result.Full)
+	scheduler.SetResultData(ctx, "status_changes", len(result.StatusChanges))
+	scheduler.SetResultData(ctx, "errors", result.ErrorContainers)
+	scheduler.SetResultData(ctx, "duration_ms", result.Duration.Milliseconds())
+
+	if result.ErrorContainers > 0 {
+		logrus.WithFields(logrus.Fields{
+			"task_type": t.GetType(),
+			"errors":    result.ErrorContainers,
+		}).Warn("Some containers could not be inspected")
+	}
+
+	return nil
+}
+
+// GetName returns the task name
+func (t *StatusSyncTask) GetName() string {
+	return "Container Status Sync"
+}
+
+// GetType returns the task type
+func (t *StatusSyncTask) GetType() model.TaskType {
+	return model.TaskTypeStatusSync
+}
+
+// Validate validates task parameters
+func (t *StatusSyncTask) Validate(params scheduler.TaskParameters) error {
+	if params.TaskType != model.TaskTypeStatusSync {
+		return fmt.Errorf("invalid task type: expected %s, got %s", model.TaskTypeStatusSync, params.TaskType)
+	}
+
+	if _, err := t.parseParameters(params); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return nil
+}
+
+// GetDefaultTimeout returns the default timeout for this task
+func (t *StatusSyncTask) GetDefaultTimeout() time.Duration {
+	return 15 * time.Minute
+}
+
+// CanRunConcurrently returns false so a slow sync is not overlapped by the
+// next scheduled run
+func (t *StatusSyncTask) CanRunConcurrently() bool {
+	return false
+}
+
+// parseParameters parses and validates task parameters
+func (t *StatusSyncTask) parseParameters(params scheduler.TaskParameters) (*StatusSyncParameters, error) {
+	syncParams := &StatusSyncParameters{}
+
+	if params.Parameters != nil {
+		jsonData, err := json.Marshal(params.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parameters: %w", err)
+		}
+
+		if err := json.Unmarshal(jsonData, syncParams); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
+		}
+	}
+
+	return syncParams, nil
+}
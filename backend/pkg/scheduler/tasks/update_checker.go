@@ -4,13 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 
-	"docker-auto/internal/model"
+	"docker-auto/internal/dto"
 	"docker-auto/internal/repository"
-	"docker-auto/internal/service"
+	"docker-auto/pkg/model"
 	"docker-auto/pkg/registry"
 	"docker-auto/pkg/scheduler"
 
@@ -22,9 +23,10 @@ type UpdateCheckerTask struct {
 	containerRepo repository.ContainerRepository
 	imageRepo     repository.ImageVersionRepository
 	registryChecker *registry.Checker
-	containerService *service.ContainerService
-	imageService     *service.ImageService
-	notificationService *service.NotificationService
+	containerService ContainerService
+	imageService     ImageService
+	notificationService NotificationService
+	approvalService     ApprovalService
 }
 
 // NewUpdateCheckerTask creates a new update checker task
@@ -32,9 +34,10 @@ func NewUpdateCheckerTask(
 	containerRepo repository.ContainerRepository,
 	imageRepo repository.ImageVersionRepository,
 	registryChecker *registry.Checker,
-	containerService *service.ContainerService,
-	imageService *service.ImageService,
-	notificationService *service.NotificationService,
+	containerService ContainerService,
+	imageService ImageService,
+	notificationService NotificationService,
+	approvalService ApprovalService,
 ) *UpdateCheckerTask {
 	return &UpdateCheckerTask{
 		containerRepo:       containerRepo,
@@ -43,6 +46,7 @@ func NewUpdateCheckerTask(
 		containerService:   containerService,
 		imageService:       imageService,
 		notificationService: notificationService,
+		approvalService:     approvalService,
 	}
 }
 
@@ -69,7 +73,7 @@ func (t *UpdateCheckerTask) Execute(ctx context.Context, params scheduler.TaskPa
 
 	logger.WithField("container_count", len(containers)).Info("Found containers to check for updates")
 
-	// Check for updates
+	// Check for updates until the run budget is spent
 	results, err := t.checkForUpdates(ctx, containers, checkParams)
 	if err != nil {
 		return fmt.Errorf("failed to check for updates: %w", err)
@@ -80,12 +84,28 @@ func (t *UpdateCheckerTask) Execute(ctx context.Context, params scheduler.TaskPa
 		return fmt.Errorf("failed to process results: %w", err)
 	}
 
+	t.markChecked(ctx, results)
+
+	scheduler.SetResultData(ctx, scheduler.ResultDataCompleted, len(results.ContainerResults))
+	scheduler.SetResultData(ctx, scheduler.ResultDataDeferred, len(results.Deferred))
+	scheduler.SetResultData(ctx, "updates_found", results.UpdatesFound)
+	scheduler.SetResultData(ctx, "errors", len(results.Errors))
+
 	logger.WithFields(logrus.Fields{
-		"containers_checked": len(containers),
-		"updates_found":      results.UpdatesFound,
-		"errors":            len(results.Errors),
+		"containers_checked":  len(results.ContainerResults),
+		"containers_deferred": len(results.Deferred),
+		"updates_found":       results.UpdatesFound,
+		"errors":              len(results.Errors),
 	}).Info("Image update check task completed")
 
+	if len(results.Deferred) > 0 {
+		return &scheduler.PartialError{
+			Completed: len(results.ContainerResults),
+			Deferred:  len(results.Deferred),
+			Reason:    "run budget exhausted",
+		}
+	}
+
 	return nil
 }
 
@@ -123,6 +143,10 @@ func (t *UpdateCheckerTask) CanRunConcurrently() bool {
 	return true
 }
 
+// defaultRunBudgetSeconds leaves room within the default timeout to process
+// the results of a run that used its whole budget
+const defaultRunBudgetSeconds = 600
+
 // ImageCheckParameters represents parameters for image checking
 type ImageCheckParameters struct {
 	RegistryTimeout   time.Duration `json:"registry_timeout"`
@@ -135,6 +159,10 @@ type ImageCheckParameters struct {
 	IncludePreRelease bool          `json:"include_pre_release"`
 	OnlyMajorUpdates  bool          `json:"only_major_updates"`
 	OnlySecurityUpdates bool        `json:"only_security_updates"`
+	// RunBudgetSeconds bounds the wall-clock time spent querying registries,
+	// within the task timeout; containers not reached by then are deferred to
+	// the next run
+	RunBudgetSeconds  int           `json:"run_budget_seconds"`
 }
 
 // UpdateCheckResult represents the result of checking updates for all containers
@@ -142,6 +170,8 @@ type UpdateCheckResult struct {
 	ContainerResults []*ContainerUpdateResult `json:"container_results"`
 	UpdatesFound     int                      `json:"updates_found"`
 	Errors           []UpdateCheckError       `json:"errors"`
+	// Deferred are the containers not checked before the run budget ran out
+	Deferred         []*model.Container       `json:"-"`
 	Duration         time.Duration            `json:"duration"`
 	CheckedAt        time.Time                `json:"checked_at"`
 }
@@ -155,6 +185,9 @@ type ContainerUpdateResult struct {
 	IsSecurityUpdate bool                 `json:"is_security_update"`
 	IsMajorUpdate    bool                 `json:"is_major_update"`
 	UpdateType       string               `json:"update_type"` // patch, minor, major
+	CurrentDigest    string               `json:"current_digest,omitempty"`
+	ProposedDigest   string               `json:"proposed_digest,omitempty"`
+	ReleaseNotesURL  string               `json:"release_notes_url,omitempty"`
 	RegistryMetadata map[string]interface{} `json:"registry_metadata,omitempty"`
 	CheckedAt        time.Time            `json:"checked_at"`
 	Error            string               `json:"error,omitempty"`
@@ -182,6 +215,7 @@ func (t *UpdateCheckerTask) parseParameters(params scheduler.TaskParameters) (*I
 		IncludePreRelease: false,
 		OnlyMajorUpdates:  false,
 		OnlySecurityUpdates: false,
+		RunBudgetSeconds:  defaultRunBudgetSeconds,
 	}
 
 	// Parse from parameters map
@@ -198,7 +232,14 @@ func (t *UpdateCheckerTask) parseParameters(params scheduler.TaskParameters) (*I
 	}
 
 	// Validate parameters
-	if checkParams.MaxConcurrent <= 0 {
+	if checkParams.MaxConcurrent < 0 {
+		return nil, fmt.Errorf("max_concurrent must not be negative")
+	}
+	if checkParams.RunBudgetSeconds < 0 {
+		return nil, fmt.Errorf("run_budget_seconds must not be negative")
+	}
+
+	if checkParams.MaxConcurrent == 0 {
 		checkParams.MaxConcurrent = 5
 	}
 	if checkParams.MaxConcurrent > 20 {
@@ -209,6 +250,10 @@ func (t *UpdateCheckerTask) parseParameters(params scheduler.TaskParameters) (*I
 		checkParams.RegistryTimeout = 30 * time.Second
 	}
 
+	if checkParams.RunBudgetSeconds == 0 {
+		checkParams.RunBudgetSeconds = defaultRunBudgetSeconds
+	}
+
 	if len(checkParams.CheckTags) == 0 {
 		checkParams.CheckTags = []string{"latest"}
 	}
@@ -231,14 +276,13 @@ func (t *UpdateCheckerTask) getContainersToCheck(ctx context.Context, targetCont
 			containers = append(containers, container)
 		}
 	} else {
-		// Check all active containers with automatic update policy
+		// Check all active containers whose effective policy allows automatic
+		// updates, and those with suspended updates for a new image to resume on
 		runningStatus := model.ContainerStatusRunning
-		autoPolicy := model.UpdatePolicyAuto
 		filter := &model.ContainerFilter{
-			Status: runningStatus,
-			// Only check containers with automatic update policies
-			UpdatePolicy: autoPolicy,
-			Limit:        1000, // Reasonable limit
+			Status:  runningStatus,
+			Limit:   1000, // Reasonable limit
+			OrderBy: "update_checked_at ASC NULLS FIRST",
 		}
 
 		allContainers, _, err := t.containerRepo.List(ctx, filter)
@@ -246,13 +290,44 @@ func (t *UpdateCheckerTask) getContainersToCheck(ctx context.Context, targetCont
 			return nil, fmt.Errorf("failed to list containers: %w", err)
 		}
 
-		containers = allContainers
+		for _, container := range allContainers {
+			if container.UpdateSuspended || t.isAutoUpdateEligible(ctx, container) {
+				containers = append(containers, container)
+			}
+		}
 	}
 
+	// Containers deferred by the last run, and those never checked, go first
+	sort.SliceStable(containers, func(i, j int) bool {
+		a, b := containers[i].UpdateCheckedAt, containers[j].UpdateCheckedAt
+		if a == nil || b == nil {
+			return a == nil && b != nil
+		}
+		return a.Before(*b)
+	})
+
 	return containers, nil
 }
 
-// checkForUpdates checks for updates for all containers
+// isAutoUpdateEligible reports whether the container's effective update
+// policy, which may be inherited from an image policy, is automatic
+func (t *UpdateCheckerTask) isAutoUpdateEligible(ctx context.Context, container *model.Container) bool {
+	if t.containerService == nil {
+		return container.IsAutoUpdateEnabled()
+	}
+
+	effective, err := t.containerService.EffectivePolicy(ctx, container)
+	if err != nil {
+		logrus.WithError(err).WithField("container_id", container.ID).Warn("Failed to resolve effective policy")
+		return false
+	}
+
+	return effective.IsEligibleForAutoUpdate()
+}
+
+// checkForUpdates checks containers for updates with a pool of workers until
+// the run budget is spent. Checks in flight when it runs out are finished;
+// containers not started by then are returned as deferred.
 func (t *UpdateCheckerTask) checkForUpdates(ctx context.Context, containers []*model.Container, params *ImageCheckParameters) (*UpdateCheckResult, error) {
 	startTime := time.Now()
 	result := &UpdateCheckResult{
@@ -260,51 +335,88 @@ func (t *UpdateCheckerTask) checkForUpdates(ctx context.Context, containers []*m
 		CheckedAt:        startTime,
 	}
 
-	// Create semaphore for concurrency control
-	semaphore := make(chan struct{}, params.MaxConcurrent)
+	budget := time.Duration(params.RunBudgetSeconds) * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		// Keep a fifth of the remaining time for processing the results
+		if remaining := time.Until(deadline) * 4 / 5; remaining < budget {
+			budget = remaining
+		}
+	}
+	budgetTimer := time.NewTimer(budget)
+	defer budgetTimer.Stop()
+
+	queue := make(chan *model.Container)
 	var wg sync.WaitGroup
 	var mu sync.Mutex
 
-	for _, container := range containers {
+	for i := 0; i < params.MaxConcurrent; i++ {
 		wg.Add(1)
-		go func(c *model.Container) {
+		go func() {
 			defer wg.Done()
 
-			// Acquire semaphore
-			select {
-			case semaphore <- struct{}{}:
-				defer func() { <-semaphore }()
-			case <-ctx.Done():
-				return
-			}
-
-			// Check this container for updates
-			containerResult := t.checkContainerUpdate(ctx, c, params)
+			for c := range queue {
+				containerResult := t.checkContainerUpdate(ctx, c, params)
 
-			// Add to results
-			mu.Lock()
-			result.ContainerResults = append(result.ContainerResults, containerResult)
-			if containerResult.UpdateAvailable {
-				result.UpdatesFound++
-			}
-			if containerResult.Error != "" {
-				result.Errors = append(result.Errors, UpdateCheckError{
-					ContainerID:   int64(c.ID),
-					ContainerName: c.Name,
-					Error:         containerResult.Error,
-					Recoverable:   true,
-				})
+				mu.Lock()
+				result.ContainerResults = append(result.ContainerResults, containerResult)
+				if containerResult.UpdateAvailable {
+					result.UpdatesFound++
+				}
+				if containerResult.Error != "" {
+					result.Errors = append(result.Errors, UpdateCheckError{
+						ContainerID:   int64(c.ID),
+						ContainerName: c.Name,
+						Error:         containerResult.Error,
+						Recoverable:   true,
+					})
+				}
+				mu.Unlock()
 			}
-			mu.Unlock()
-		}(container)
+		}()
+	}
+
+	// Hand containers to idle workers until the budget runs out
+dispatch:
+	for i, container := range containers {
+		select {
+		case queue <- container:
+		case <-budgetTimer.C:
+			result.Deferred = containers[i:]
+			break dispatch
+		case <-ctx.Done():
+			result.Deferred = containers[i:]
+			break dispatch
+		}
 	}
+	close(queue)
 
 	wg.Wait()
 	result.Duration = time.Since(startTime)
 
+	if len(result.Deferred) > 0 {
+		logrus.WithFields(logrus.Fields{
+			"checked":  len(result.ContainerResults),
+			"deferred": len(result.Deferred),
+			"budget":   budget,
+		}).Warn("Update check run budget exhausted; remaining containers deferred to the next run")
+	}
+
 	return result, nil
 }
 
+// markChecked records the check time of every container the run reached,
+// successfully or not, so deferred containers are checked first next time
+func (t *UpdateCheckerTask) markChecked(ctx context.Context, results *UpdateCheckResult) {
+	ids := make([]int64, 0, len(results.ContainerResults))
+	for _, containerResult := range results.ContainerResults {
+		ids = append(ids, int64(containerResult.Container.ID))
+	}
+
+	if err := t.containerRepo.MarkUpdateChecked(ctx, ids, results.CheckedAt); err != nil {
+		logrus.WithError(err).Warn("Failed to record update check times")
+	}
+}
+
 // checkContainerUpdate checks for updates for a single container
 func (t *UpdateCheckerTask) checkContainerUpdate(ctx context.Context, container *model.Container, params *ImageCheckParameters) *ContainerUpdateResult {
 	result := &ContainerUpdateResult{
@@ -326,7 +438,7 @@ func (t *UpdateCheckerTask) checkContainerUpdate(ctx context.Context, container
 
 	// Check for latest version using image checker
 	image := container.GetFullImageName()
-	updateResult, err := (*t.registryChecker).CheckImageUpdate(checkCtx, image, "", container.RegistryURL)
+	updateResult, err := (*t.registryChecker).CheckImageUpdate(checkCtx, image, container.ImageDigest, container.RegistryURL)
 
 	if err != nil {
 		result.Error = err.Error()
@@ -334,9 +446,33 @@ func (t *UpdateCheckerTask) checkContainerUpdate(ctx context.Context, container
 		return result
 	}
 
+	// The version policy may rule out the tag the registry reported, e.g.
+	// 1.26.0 for a container on 1.25.2 that only takes patch updates
+	if t.imageService != nil {
+		if err := t.imageService.ApplyVersionPolicy(checkCtx, container, updateResult); err != nil {
+			result.Error = err.Error()
+			logger.WithError(err).Warn("Failed to apply version policy")
+			return result
+		}
+	}
+
+	// A suspended container reports no update until its image moves on
+	if container.UpdateSuspended && !t.resumeOnNewDigest(ctx, container, updateResult.LatestDigest, logger) {
+		return result
+	}
+
+	// Pinned containers don't follow the tag; a new digest behind the same tag
+	// is proposed as a pending update instead
+	if container.PinByDigest {
+		return t.checkPinnedDigest(ctx, container, updateResult, result, logger)
+	}
+
 	result.LatestVersion = updateResult.LatestTag
 	result.UpdateAvailable = updateResult.UpdateAvailable
 	result.UpdateType = updateResult.UpdateType
+	if updateResult.UpdateAvailable {
+		result.ProposedDigest = updateResult.LatestDigest
+	}
 
 	// Determine if update is available
 	if updateResult.UpdateAvailable {
@@ -362,10 +498,72 @@ func (t *UpdateCheckerTask) checkContainerUpdate(ctx context.Context, container
 	return result
 }
 
+// checkPinnedDigest records the digest the tag of a pinned container resolves to
+// and reports an update when it differs from the pinned digest
+func (t *UpdateCheckerTask) checkPinnedDigest(ctx context.Context, container *model.Container, updateResult *registry.UpdateCheckResult, result *ContainerUpdateResult, logger *logrus.Entry) *ContainerUpdateResult {
+	result.CurrentDigest = container.ImageDigest
+	result.LatestVersion = container.Tag
+	result.UpdateType = "digest"
+
+	if updateResult.LatestDigest == "" {
+		return result
+	}
+
+	result.ProposedDigest = updateResult.LatestDigest
+	result.UpdateAvailable = updateResult.LatestDigest != container.ImageDigest
+
+	pending := ""
+	if result.UpdateAvailable {
+		pending = result.ProposedDigest
+	}
+	if container.PendingDigest != pending {
+		container.PendingDigest = pending
+		if err := t.containerRepo.Update(ctx, container); err != nil {
+			logger.WithError(err).Warn("Failed to record pending digest")
+		}
+	}
+
+	if result.UpdateAvailable {
+		logger.WithFields(logrus.Fields{
+			"pinned_digest":   container.ImageDigest,
+			"proposed_digest": result.ProposedDigest,
+		}).Info("Tag of pinned container moved to a new digest")
+	}
+
+	return result
+}
+
+// resumeOnNewDigest resumes the suspended automatic updates of a container
+// once the registry has another image than the one they failed with, and
+// reports whether it did. A container suspended without a known digest takes
+// the first one checked as that image.
+func (t *UpdateCheckerTask) resumeOnNewDigest(ctx context.Context, container *model.Container, digest string, logger *logrus.Entry) bool {
+	if digest == "" || digest == container.UpdateSuspendedDigest {
+		return false
+	}
+
+	resumed := container.UpdateSuspendedDigest != ""
+	if resumed {
+		container.ResetUpdateFailures()
+	} else {
+		container.UpdateSuspendedDigest = digest
+	}
+	if err := t.containerRepo.SaveUpdateFailures(ctx, container); err != nil {
+		logger.WithError(err).Warn("Failed to save container update suspension")
+	}
+
+	if resumed {
+		logger.WithField("digest", digest).Info("Resuming automatic updates of container on a new image digest")
+	}
+	return resumed
+}
+
 // processResults processes the update check results
 func (t *UpdateCheckerTask) processResults(ctx context.Context, results *UpdateCheckResult, params *ImageCheckParameters) error {
 	// Save image version information
 	for _, containerResult := range results.ContainerResults {
+		t.recordVersionHistory(ctx, containerResult)
+
 		if err := t.saveImageVersion(ctx, containerResult); err != nil {
 			logrus.WithError(err).WithField("container_id", containerResult.Container.ID).
 				Warn("Failed to save image version")
@@ -379,23 +577,78 @@ func (t *UpdateCheckerTask) processResults(ctx context.Context, results *UpdateC
 		}
 	}
 
-	// Update container update statuses
+	// Record available updates as pending and let the approval policies
+	// decide them
 	for _, containerResult := range results.ContainerResults {
 		if containerResult.UpdateAvailable {
-			// Update container's last checked time and available update info
-			// This would typically be stored in a separate table or field
 			logrus.WithFields(logrus.Fields{
 				"container_id":   containerResult.Container.ID,
 				"container_name": containerResult.Container.Name,
 				"latest_version": containerResult.LatestVersion,
 				"update_type":    containerResult.UpdateType,
 			}).Info("Update available for container")
+
+			t.proposeUpdate(ctx, containerResult)
 		}
 	}
 
 	return nil
 }
 
+// proposeUpdate records an available update as pending
+func (t *UpdateCheckerTask) proposeUpdate(ctx context.Context, result *ContainerUpdateResult) {
+	if t.approvalService == nil {
+		return
+	}
+
+	decision, err := t.approvalService.ProposeUpdate(ctx, &dto.UpdateProposal{
+		Container:        result.Container,
+		Tag:              result.LatestVersion,
+		Digest:           result.ProposedDigest,
+		UpdateType:       result.UpdateType,
+		IsSecurityUpdate: result.IsSecurityUpdate,
+	})
+	if err != nil {
+		logrus.WithError(err).WithField("container_id", result.Container.ID).Warn("Failed to record pending update")
+		return
+	}
+	if decision != nil && decision.Outcome != dto.ApprovalPending {
+		logrus.WithFields(logrus.Fields{
+			"container_id": result.Container.ID,
+			"update_id":    decision.UpdateID,
+			"outcome":      decision.Outcome,
+			"policy":       decision.Policy,
+			"applied":      decision.Applied,
+		}).Info("Pending update decided by approval policy")
+	}
+}
+
+// recordVersionHistory adds the running and the latest version of a container's
+// image to the version history and picks up the release notes link of the
+// proposed version
+func (t *UpdateCheckerTask) recordVersionHistory(ctx context.Context, result *ContainerUpdateResult) {
+	if t.imageService == nil || result.Error != "" {
+		return
+	}
+
+	logger := logrus.WithField("container_id", result.Container.ID)
+
+	if _, err := t.imageService.RecordImageVersion(ctx, result.Container, result.Container.Tag, result.Container.ImageDigest); err != nil {
+		logger.WithError(err).Warn("Failed to record running image version")
+	}
+
+	if !result.UpdateAvailable || result.LatestVersion == "" {
+		return
+	}
+
+	record, err := t.imageService.RecordImageVersion(ctx, result.Container, result.LatestVersion, result.ProposedDigest)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to record latest image version")
+		return
+	}
+	result.ReleaseNotesURL = record.ReleaseNotesURL
+}
+
 // saveImageVersion saves image version information to the database
 func (t *UpdateCheckerTask) saveImageVersion(ctx context.Context, result *ContainerUpdateResult) error {
 	if t.imageRepo == nil {
@@ -405,6 +658,7 @@ func (t *UpdateCheckerTask) saveImageVersion(ctx context.Context, result *Contai
 	imageVersion := &model.ImageVersion{
 		ImageName:   result.Container.Image,
 		Tag:         result.LatestVersion,
+		Digest:      result.ProposedDigest,
 		RegistryURL: result.Container.RegistryURL,
 		CheckedAt:   result.CheckedAt,
 	}
@@ -416,6 +670,9 @@ func (t *UpdateCheckerTask) saveImageVersion(ctx context.Context, result *Contai
 		existing.CheckedAt = result.CheckedAt
 		existing.IsLatest = true
 		existing.Metadata = imageVersion.Metadata
+		if imageVersion.Digest != "" {
+			existing.Digest = imageVersion.Digest
+		}
 		return t.imageRepo.Update(ctx, existing)
 	}
 
@@ -432,6 +689,8 @@ func (t *UpdateCheckerTask) sendNotifications(ctx context.Context, results *Upda
 	// Prepare notification content
 	var updatesAvailable []string
 	var securityUpdates []string
+	var updateEntries []model.ImageUpdateEntry
+	var securityEntries []model.ImageUpdateEntry
 
 	for _, result := range results.ContainerResults {
 		if result.UpdateAvailable {
@@ -440,11 +699,26 @@ func (t *UpdateCheckerTask) sendNotifications(ctx context.Context, results *Upda
 				result.CurrentVersion,
 				result.LatestVersion,
 				result.UpdateType)
+			if result.ReleaseNotesURL != "" {
+				updateMsg += "\n  Release notes: " + result.ReleaseNotesURL
+			}
+
+			entry := model.ImageUpdateEntry{
+				ContainerID:    int64(result.Container.ID),
+				ContainerName:  result.Container.Name,
+				CurrentVersion: result.CurrentVersion,
+				LatestVersion:  result.LatestVersion,
+				UpdateType:     result.UpdateType,
+				Security:       result.IsSecurityUpdate,
+				ReleaseNotesURL: result.ReleaseNotesURL,
+			}
 
 			updatesAvailable = append(updatesAvailable, updateMsg)
+			updateEntries = append(updateEntries, entry)
 
 			if result.IsSecurityUpdate {
 				securityUpdates = append(securityUpdates, updateMsg)
+				securityEntries = append(securityEntries, entry)
 			}
 		}
 	}
@@ -457,10 +731,7 @@ func (t *UpdateCheckerTask) sendNotifications(ctx context.Context, results *Upda
 			Message:  fmt.Sprintf("Security updates are available for %d container(s):\n%s",
 				len(securityUpdates), strings.Join(securityUpdates, "\n")),
 			Priority: model.NotificationPriorityHigh,
-			Data:     map[string]interface{}{
-				"security_updates": securityUpdates,
-				"total_updates":    results.UpdatesFound,
-			},
+			Data:     model.NotificationData(model.NewSecurityAlertPayload(securityEntries, results.UpdatesFound)),
 		}
 
 		if err := t.notificationService.SendNotification(ctx, notification); err != nil {
@@ -476,11 +747,7 @@ func (t *UpdateCheckerTask) sendNotifications(ctx context.Context, results *Upda
 			Message:  fmt.Sprintf("Updates are available for %d container(s):\n%s",
 				results.UpdatesFound, strings.Join(updatesAvailable, "\n")),
 			Priority: model.NotificationPriorityNormal,
-			Data:     map[string]interface{}{
-				"updates_available": updatesAvailable,
-				"total_updates":     results.UpdatesFound,
-				"security_updates":  len(securityUpdates),
-			},
+			Data:     model.NotificationData(model.NewUpdateAvailablePayload(updateEntries)),
 		}
 
 		if err := t.notificationService.SendNotification(ctx, notification); err != nil {
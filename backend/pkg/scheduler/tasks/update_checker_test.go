@@ -0,0 +1,106 @@
+package tasks
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/registry"
+	"docker-auto/pkg/scheduler"
+)
+
+// slowRegistry answers every check after delay, unless the check is
+// cancelled first
+type slowRegistry struct {
+	registry.ImageChecker
+	delay time.Duration
+
+	mu        sync.Mutex
+	cancelled int
+}
+
+func (r *slowRegistry) CheckImageUpdate(ctx context.Context, image, currentDigest, registryURL string) (*registry.UpdateCheckResult, error) {
+	select {
+	case <-time.After(r.delay):
+		return &registry.UpdateCheckResult{Repository: image, LatestTag: "latest", LatestDigest: currentDigest}, nil
+	case <-ctx.Done():
+		r.mu.Lock()
+		r.cancelled++
+		r.mu.Unlock()
+		return nil, ctx.Err()
+	}
+}
+
+// checkedRepo lists its containers in update_checked_at order and records the
+// check times the task saves
+type checkedRepo struct {
+	repository.ContainerRepository
+	containers []*model.Container
+	marked     []int64
+}
+
+func (r *checkedRepo) List(ctx context.Context, filter *model.ContainerFilter) ([]*model.Container, int64, error) {
+	return r.containers, int64(len(r.containers)), nil
+}
+
+func (r *checkedRepo) MarkUpdateChecked(ctx context.Context, ids []int64, checkedAt time.Time) error {
+	r.marked = append(r.marked, ids...)
+	for _, c := range r.containers {
+		for _, id := range ids {
+			if int64(c.ID) == id {
+				at := checkedAt
+				c.UpdateCheckedAt = &at
+			}
+		}
+	}
+	return nil
+}
+
+func TestSlowRegistryEndsUpdateCheckAsPartialRun(t *testing.T) {
+	repo := &checkedRepo{}
+	for i := 1; i <= 12; i++ {
+		repo.containers = append(repo.containers, &model.Container{ID: i, Name: "web", Image: "nginx", Tag: "latest", UpdatePolicy: model.UpdatePolicyAuto})
+	}
+	checker := registry.Checker(&slowRegistry{delay: 300 * time.Millisecond})
+	task := &UpdateCheckerTask{containerRepo: repo, registryChecker: &checker}
+
+	err := task.Execute(context.Background(), scheduler.TaskParameters{
+		TaskType: model.TaskTypeImageCheck,
+		Parameters: map[string]interface{}{
+			"max_concurrent":      2,
+			"run_budget_seconds":  1,
+			"notify_on_new_image": false,
+		},
+	})
+
+	var partial *scheduler.PartialError
+	if !errors.As(err, &partial) {
+		t.Fatalf("Execute = %v, want a partial run", err)
+	}
+	if partial.Completed == 0 || partial.Deferred == 0 || partial.Completed+partial.Deferred != len(repo.containers) {
+		t.Fatalf("completed %d, deferred %d, want both of %d containers", partial.Completed, partial.Deferred, len(repo.containers))
+	}
+	if cancelled := checker.(*slowRegistry).cancelled; cancelled != 0 {
+		t.Errorf("%d checks in flight were cancelled, want them finished", cancelled)
+	}
+
+	// Only the containers checked get a check time
+	if len(repo.marked) != partial.Completed {
+		t.Errorf("marked %d containers checked, want %d", len(repo.marked), partial.Completed)
+	}
+
+	// The deferred ones are checked first on the next run
+	next, err := task.getContainersToCheck(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("getContainersToCheck failed: %v", err)
+	}
+	for i, c := range next {
+		if deferred := i < partial.Deferred; deferred != (c.UpdateCheckedAt == nil) {
+			t.Fatalf("next run checks %v at %d, want the %d deferred containers first", c.UpdateCheckedAt, i, partial.Deferred)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package tasks
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/internal/dto"
+	"docker-auto/pkg/model"
+	"docker-auto/pkg/scheduler"
+
+	"github.com/sirupsen/logrus"
+)
+
+// VolumeUsageTask implements the Task interface for sampling volume sizes
+// and evaluating the volume alert rules
+type VolumeUsageTask struct {
+	volumeService VolumeService
+}
+
+// NewVolumeUsageTask creates a new volume usage sampling task
+func NewVolumeUsageTask(volumeService VolumeService) *VolumeUsageTask {
+	return &VolumeUsageTask{
+		volumeService: volumeService,
+	}
+}
+
+// VolumeUsageParameters represents parameters for volume usage sampling
+type VolumeUsageParameters struct {
+	RescanLarge bool `json:"rescan_large"`
+}
+
+// Execute runs the volume usage sampling task
+func (t *VolumeUsageTask) Execute(ctx context.Context, params scheduler.TaskParameters) error {
+	if t.volumeService == nil {
+		return fmt.Errorf("volume service not available")
+	}
+
+	usageParams, err := t.parseParameters(params)
+	if err != nil {
+		return fmt.Errorf("failed to parse parameters: %w", err)
+	}
+
+	logger := logrus.WithField("task_type", t.GetType())
+
+	result, err := t.volumeService.Sample(ctx, &dto.VolumeSampleRequest{RescanLarge: usageParams.RescanLarge})
+	if errors.Is(err, dto.ErrVolumeSampleRateLimited) || errors.Is(err, dto.ErrVolumeSampleInProgress) {
+		// An on-demand sample ran recently; this run has nothing to add
+		logger.WithError(err).Info("Skipping volume usage sample")
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to sample volume usage: %w", err)
+	}
+
+	if len(result.Alerts) > 0 {
+		logger.WithField("alerts", len(result.Alerts)).Warn("Volume usage alerts raised")
+	}
+
+	return nil
+}
+
+// GetName returns the task name
+func (t *VolumeUsageTask) GetName() string {
+	return "Volume Usage Sampling"
+}
+
+// GetType returns the task type
+func (t *VolumeUsageTask) GetType() model.TaskType {
+	return model.TaskTypeVolumeUsage
+}
+
+// Validate validates task parameters
+func (t *VolumeUsageTask) Validate(params scheduler.TaskParameters) error {
+	if params.TaskType != model.TaskTypeVolumeUsage {
+		return fmt.Errorf("invalid task type: expected %s, got %s", model.TaskTypeVolumeUsage, params.TaskType)
+	}
+
+	if _, err := t.parseParameters(params); err != nil {
+		return fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	return nil
+}
+
+// GetDefaultTimeout returns the default timeout for this task. Volumes are
+// measured one at a time, each bounded by VOLUME_SCAN_TIMEOUT_SECONDS.
+func (t *VolumeUsageTask) GetDefaultTimeout() time.Duration {
+	return 2 * time.Hour
+}
+
+// CanRunConcurrently returns false as sampling runs are serialized
+func (t *VolumeUsageTask) CanRunConcurrently() bool {
+	return false
+}
+
+// parseParameters parses and validates task parameters
+func (t *VolumeUsageTask) parseParameters(params scheduler.TaskParameters) (*VolumeUsageParameters, error) {
+	usageParams := &VolumeUsageParameters{}
+
+	if params.Parameters != nil {
+		jsonData, err := json.Marshal(params.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal parameters: %w", err)
+		}
+
+		if err := json.Unmarshal(jsonData, usageParams); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal parameters: %w", err)
+		}
+	}
+
+	return usageParams, nil
+}
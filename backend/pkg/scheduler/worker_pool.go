@@ -0,0 +1,70 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+)
+
+// workerPool bounds the number of tasks running at once. Unlike a buffered
+// channel its size can change while tasks run: shrinking it lets running
+// tasks finish and holds new ones until they fit.
+type workerPool struct {
+	mu      sync.Mutex
+	size    int
+	running int
+	// freed is closed and replaced whenever a slot may have become free
+	freed chan struct{}
+}
+
+func newWorkerPool(size int) *workerPool {
+	return &workerPool{size: size, freed: make(chan struct{})}
+}
+
+// acquire waits for a free slot. It reports false when ctx ends first.
+func (p *workerPool) acquire(ctx context.Context) bool {
+	for {
+		p.mu.Lock()
+		if p.running < p.size {
+			p.running++
+			p.mu.Unlock()
+			return true
+		}
+		freed := p.freed
+		p.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// release frees a slot taken by acquire
+func (p *workerPool) release() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.running--
+	p.signal()
+}
+
+// resize changes the number of slots
+func (p *workerPool) resize(size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.size = size
+	p.signal()
+}
+
+// usage returns the slots taken and the number of slots
+func (p *workerPool) usage() (running, size int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.running, p.size
+}
+
+// signal wakes the waiting acquirers; p.mu must be held
+func (p *workerPool) signal() {
+	close(p.freed)
+	p.freed = make(chan struct{})
+}
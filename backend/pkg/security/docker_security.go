@@ -3,6 +3,7 @@ package security
 import (
 	"context"
 	"crypto/tls"
+	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -12,6 +13,10 @@ import (
 	"sync"
 	"time"
 
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/metrics"
+	"docker-auto/pkg/model"
+
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/network"
@@ -58,6 +63,7 @@ type DockerSecurityConfig struct {
 	AllowedRegistries []string      `json:"allowed_registries"`
 	BlockedImages     []string      `json:"blocked_images"`
 	VulnerabilityThreshold VulnerabilityLevel `json:"vulnerability_threshold"`
+	ScanResultTTL     time.Duration `json:"scan_result_ttl"` // stored results younger than this are reused
 
 	// Runtime security
 	AppArmorProfile   string        `json:"apparmor_profile"`
@@ -68,14 +74,57 @@ type DockerSecurityConfig struct {
 	AuditEnabled      bool          `json:"audit_enabled"`
 	LogLevel          string        `json:"log_level"`
 	MonitorContainers bool          `json:"monitor_containers"`
+	MonitorInterval   time.Duration `json:"monitor_interval"`
+	DeepContainerChecks bool        `json:"deep_container_checks"` // inspect per-container stats on each pass
 	AlertOnSuspicious bool          `json:"alert_on_suspicious"`
 
 	// Cleanup policies
 	AutoCleanup       bool          `json:"auto_cleanup"`
+	CleanupInterval   time.Duration `json:"cleanup_interval"`
 	MaxContainerAge   time.Duration `json:"max_container_age"`
 	MaxImageAge       time.Duration `json:"max_image_age"`
 }
 
+const (
+	defaultScanResultTTL   = 24 * time.Hour
+	defaultMonitorInterval = 30 * time.Second
+	defaultCleanupInterval = time.Hour
+	minMonitorInterval     = 5 * time.Second
+	minCleanupInterval     = time.Minute
+)
+
+// Validate checks the background task intervals, filling in defaults for unset values
+func (c *DockerSecurityConfig) Validate() error {
+	if c.MonitorInterval < 0 {
+		return fmt.Errorf("docker monitor interval cannot be negative")
+	}
+	if c.CleanupInterval < 0 {
+		return fmt.Errorf("docker cleanup interval cannot be negative")
+	}
+	if c.ScanResultTTL < 0 {
+		return fmt.Errorf("scan result TTL cannot be negative")
+	}
+
+	if c.MonitorInterval == 0 {
+		c.MonitorInterval = defaultMonitorInterval
+	}
+	if c.CleanupInterval == 0 {
+		c.CleanupInterval = defaultCleanupInterval
+	}
+	if c.ScanResultTTL == 0 {
+		c.ScanResultTTL = defaultScanResultTTL
+	}
+
+	if c.MonitorContainers && c.MonitorInterval < minMonitorInterval {
+		return fmt.Errorf("docker monitor interval must be at least %v", minMonitorInterval)
+	}
+	if c.AutoCleanup && c.CleanupInterval < minCleanupInterval {
+		return fmt.Errorf("docker cleanup interval must be at least %v", minCleanupInterval)
+	}
+
+	return nil
+}
+
 // ResourceLimits represents container resource limits
 type ResourceLimits struct {
 	CPULimit      int64 `json:"cpu_limit"`       // CPU limit in nano CPUs
@@ -144,13 +193,16 @@ func DefaultDockerSecurityConfig() *DockerSecurityConfig {
 		SignedImagesOnly:       false, // Enable in production
 		AllowedRegistries:      []string{"docker.io", "registry.docker.io"},
 		VulnerabilityThreshold: VulnHigh,
+		ScanResultTTL:          defaultScanResultTTL,
 		AppArmorProfile:        "docker-default",
 		SeccompProfile:         "default",
 		AuditEnabled:           true,
 		LogLevel:              "info",
 		MonitorContainers:     true,
+		MonitorInterval:       defaultMonitorInterval,
 		AlertOnSuspicious:     true,
 		AutoCleanup:           true,
+		CleanupInterval:       defaultCleanupInterval,
 		MaxContainerAge:       24 * time.Hour,
 		MaxImageAge:           7 * 24 * time.Hour,
 	}
@@ -164,6 +216,11 @@ type SecureDockerClient struct {
 	scanner      *ImageScanner
 	stats        *DockerSecurityStats
 	mutex        sync.RWMutex
+
+	// Background monitoring and cleanup lifecycle
+	lifecycleMu  sync.Mutex
+	cancel       context.CancelFunc
+	wg           sync.WaitGroup
 }
 
 // DockerSecurityStats represents Docker security statistics
@@ -184,19 +241,33 @@ type DockerAuditLogger struct {
 	logger  *logrus.Logger
 }
 
-// ImageScanner handles container image security scanning
+// ScannerVersion identifies the vulnerability scanner implementation. Stored
+// results from another version are rescanned.
+const ScannerVersion = "builtin-1"
+
+// ScanCache is a shared cache consulted before the scan result store
+type ScanCache interface {
+	Get(key string) (interface{}, bool)
+	Set(key string, value interface{}, ttl time.Duration) error
+}
+
+// ImageScanner handles container image security scanning. Results are stored
+// by image digest so they survive restarts and are shared between replicas.
 type ImageScanner struct {
-	config  *DockerSecurityConfig
-	client  *client.Client
-	results map[string]*ScanResult
-	mutex   sync.RWMutex
+	config *DockerSecurityConfig
+	client *client.Client
+	store  repository.ScanResultRepository
+	cache  ScanCache
+	now    func() time.Time
 }
 
 // ScanResult represents image scan results
 type ScanResult struct {
 	ImageID         string            `json:"image_id"`
+	ImageDigest     string            `json:"image_digest"`
 	ImageName       string            `json:"image_name"`
 	ScanTime        time.Time         `json:"scan_time"`
+	ScannerVersion  string            `json:"scanner_version"`
 	Vulnerabilities []Vulnerability   `json:"vulnerabilities"`
 	Passed          bool              `json:"passed"`
 	TotalVulns      int               `json:"total_vulns"`
@@ -216,11 +287,15 @@ type Vulnerability struct {
 	FixedIn     string             `json:"fixed_in,omitempty"`
 }
 
-// NewSecureDockerClient creates a new secure Docker client
-func NewSecureDockerClient(config *DockerSecurityConfig) (*SecureDockerClient, error) {
+// NewSecureDockerClient creates a new secure Docker client. Scan results are
+// persisted in scanStore and read through scanCache; either may be nil.
+func NewSecureDockerClient(config *DockerSecurityConfig, scanStore repository.ScanResultRepository, scanCache ScanCache) (*SecureDockerClient, error) {
 	if config == nil {
 		config = DefaultDockerSecurityConfig()
 	}
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid docker security config: %w", err)
+	}
 
 	// Configure Docker client options
 	clientOpts := []client.Opt{
@@ -256,11 +331,7 @@ func NewSecureDockerClient(config *DockerSecurityConfig) (*SecureDockerClient, e
 	}
 
 	// Initialize image scanner
-	scanner := &ImageScanner{
-		config:  config,
-		client:  dockerClient,
-		results: make(map[string]*ScanResult),
-	}
+	scanner := NewImageScanner(config, dockerClient, scanStore, scanCache)
 
 	secureClient := &SecureDockerClient{
 		config:      config,
@@ -270,17 +341,81 @@ func NewSecureDockerClient(config *DockerSecurityConfig) (*SecureDockerClient, e
 		stats:       &DockerSecurityStats{LastUpdate: time.Now()},
 	}
 
-	// Start monitoring if enabled
-	if config.MonitorContainers {
-		go secureClient.startMonitoring()
+	return secureClient, nil
+}
+
+// Start launches the configured background monitoring and cleanup loops.
+// They run until ctx is cancelled or Stop is called; calling Start again
+// while the loops are running is a no-op.
+func (sdc *SecureDockerClient) Start(ctx context.Context) {
+	sdc.lifecycleMu.Lock()
+	defer sdc.lifecycleMu.Unlock()
+
+	if sdc.cancel != nil {
+		return
 	}
 
-	// Start cleanup if enabled
-	if config.AutoCleanup {
-		go secureClient.startCleanup()
+	loopCtx, cancel := context.WithCancel(ctx)
+	sdc.cancel = cancel
+
+	if sdc.config.MonitorContainers {
+		sdc.wg.Add(1)
+		go func() {
+			defer sdc.wg.Done()
+			sdc.runPeriodically(loopCtx, "monitoring", sdc.config.MonitorInterval, sdc.monitorContainers)
+		}()
 	}
 
-	return secureClient, nil
+	if sdc.config.AutoCleanup {
+		sdc.wg.Add(1)
+		go func() {
+			defer sdc.wg.Done()
+			sdc.runPeriodically(loopCtx, "cleanup", sdc.config.CleanupInterval, sdc.performCleanup)
+		}()
+	}
+}
+
+// Stop cancels the background loops and waits for any in-flight pass to return
+func (sdc *SecureDockerClient) Stop() {
+	sdc.lifecycleMu.Lock()
+	cancel := sdc.cancel
+	sdc.cancel = nil
+	sdc.lifecycleMu.Unlock()
+
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	sdc.wg.Wait()
+}
+
+// runPeriodically calls fn every interval until ctx is done. Passes run on
+// this goroutine, so a slow pass delays the next one instead of overlapping it.
+func (sdc *SecureDockerClient) runPeriodically(ctx context.Context, name string, interval time.Duration, fn func(context.Context)) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		start := time.Now()
+		fn(ctx)
+
+		if elapsed := time.Since(start); elapsed > interval {
+			logrus.WithFields(logrus.Fields{
+				"task":     name,
+				"duration": elapsed,
+				"interval": interval,
+			}).Warn("Docker security pass took longer than its interval")
+			// Drop the tick that queued up during the slow pass
+			ticker.Reset(interval)
+		}
+	}
 }
 
 // configureTLS configures TLS settings for Docker client
@@ -318,30 +453,37 @@ func (sdc *SecureDockerClient) SecureContainerCreate(ctx context.Context, config
 	defer sdc.mutex.Unlock()
 
 	sdc.stats.TotalOperations++
+	metrics.RecordSecurityEvent("operation")
 
 	// Validate user permissions
 	if err := sdc.validateUserPermissions(userContext, "container_create"); err != nil {
 		sdc.stats.BlockedOperations++
+		metrics.RecordSecurityEvent("blocked")
 		return nil, fmt.Errorf("permission denied: %w", err)
 	}
 
 	// Validate image
 	if err := sdc.validateImage(ctx, config.Image); err != nil {
 		sdc.stats.BlockedOperations++
+		metrics.RecordSecurityEvent("blocked")
 		sdc.stats.ContainersBlocked++
+		metrics.RecordSecurityEvent("container_blocked")
 		return nil, fmt.Errorf("image validation failed: %w", err)
 	}
 
 	// Apply security hardening
 	if err := sdc.applySecurityHardening(config, hostConfig); err != nil {
 		sdc.stats.BlockedOperations++
+		metrics.RecordSecurityEvent("blocked")
 		return nil, fmt.Errorf("security hardening failed: %w", err)
 	}
 
 	// Validate container configuration
 	if err := sdc.validateContainerConfig(config, hostConfig); err != nil {
 		sdc.stats.BlockedOperations++
+		metrics.RecordSecurityEvent("blocked")
 		sdc.stats.SecurityViolations++
+		metrics.RecordSecurityEvent("violation")
 		return nil, fmt.Errorf("container configuration validation failed: %w", err)
 	}
 
@@ -349,10 +491,12 @@ func (sdc *SecureDockerClient) SecureContainerCreate(ctx context.Context, config
 	response, err := sdc.client.ContainerCreate(ctx, config, hostConfig, networkingConfig, nil, containerName)
 	if err != nil {
 		sdc.stats.BlockedOperations++
+		metrics.RecordSecurityEvent("blocked")
 		return nil, fmt.Errorf("container creation failed: %w", err)
 	}
 
 	sdc.stats.ContainersCreated++
+	metrics.RecordSecurityEvent("container_created")
 
 	// Audit log
 	if sdc.config.AuditEnabled {
@@ -381,6 +525,24 @@ type DockerUserContext struct {
 	Role      string `json:"role"`
 	ClientIP  string `json:"client_ip"`
 	SessionID string `json:"session_id"`
+
+	// Permissions are the permissions Role grants; when empty, Role must be
+	// a built-in role
+	Permissions []model.Permission `json:"permissions,omitempty"`
+}
+
+// dockerOperationPermissions are the permissions Docker operations require.
+// Operations not listed require admin.
+var dockerOperationPermissions = map[string]model.Permission{
+	"container_create":  model.PermissionContainerWrite,
+	"container_start":   model.PermissionContainerManage,
+	"container_stop":    model.PermissionContainerManage,
+	"container_remove":  model.PermissionContainerDelete,
+	"container_list":    model.PermissionContainerRead,
+	"container_inspect": model.PermissionContainerRead,
+	"image_pull":        model.PermissionImageWrite,
+	"image_list":        model.PermissionImageRead,
+	"image_inspect":     model.PermissionImageRead,
 }
 
 // validateUserPermissions validates user permissions for Docker operations
@@ -410,31 +572,24 @@ func (sdc *SecureDockerClient) validateUserPermissions(userContext *DockerUserCo
 		}
 	}
 
-	// Role-based access control
-	switch strings.ToLower(userContext.Role) {
-	case "admin":
-		return nil // Admin can do anything
-	case "developer":
-		// Developers can create/manage containers but not system operations
-		allowedOps := []string{"container_create", "container_start", "container_stop", "container_remove", "image_pull"}
-		for _, op := range allowedOps {
-			if operation == op {
-				return nil
-			}
-		}
-		return fmt.Errorf("operation %s not allowed for role %s", operation, userContext.Role)
-	case "viewer":
-		// Viewers can only inspect/list
-		allowedOps := []string{"container_list", "container_inspect", "image_list", "image_inspect"}
-		for _, op := range allowedOps {
-			if operation == op {
-				return nil
-			}
+	// Permission-based access control, with the role definitions of the API
+	permissions := userContext.Permissions
+	if len(permissions) == 0 {
+		builtin, ok := model.BuiltinRolePermissions(model.CanonicalRole(model.UserRole(userContext.Role)))
+		if !ok {
+			return fmt.Errorf("unknown role: %s", userContext.Role)
 		}
+		permissions = builtin
+	}
+
+	required, ok := dockerOperationPermissions[operation]
+	if !ok {
+		required = model.PermissionAdmin
+	}
+	if !model.GrantsPermission(permissions, required) {
 		return fmt.Errorf("operation %s not allowed for role %s", operation, userContext.Role)
-	default:
-		return fmt.Errorf("unknown role: %s", userContext.Role)
 	}
+	return nil
 }
 
 // validateImage validates container image security
@@ -638,51 +793,68 @@ func (sdc *SecureDockerClient) validatePortBinding(binding nat.PortBinding) erro
 	return nil
 }
 
-// validateEnvironmentVariable validates environment variable security
-func (sdc *SecureDockerClient) validateEnvironmentVariable(env string) error {
-	// Check for sensitive patterns
-	sensitivePatterns := []string{
-		"(?i)password=",
-		"(?i)secret=",
-		"(?i)key=.*[a-f0-9]{20,}",
-		"(?i)token=",
-		"(?i)api_key=",
-	}
+// sensitiveEnvPatterns match NAME=value pairs likely to carry secrets
+var sensitiveEnvPatterns = []*regexp.Regexp{
+	regexp.MustCompile("(?i)password="),
+	regexp.MustCompile("(?i)secret="),
+	regexp.MustCompile("(?i)key=.*[a-f0-9]{20,}"),
+	regexp.MustCompile("(?i)token="),
+	regexp.MustCompile("(?i)api_key="),
+}
 
-	for _, pattern := range sensitivePatterns {
-		matched, _ := regexp.MatchString(pattern, env)
-		if matched {
-			return fmt.Errorf("potentially sensitive data in environment variable")
+// IsSensitiveEnv reports whether an environment variable or label, given as
+// NAME=value, likely carries a secret
+func IsSensitiveEnv(env string) bool {
+	for _, pattern := range sensitiveEnvPatterns {
+		if pattern.MatchString(env) {
+			return true
 		}
 	}
+	return false
+}
 
+// validateEnvironmentVariable validates environment variable security
+func (sdc *SecureDockerClient) validateEnvironmentVariable(env string) error {
+	if IsSensitiveEnv(env) {
+		return fmt.Errorf("potentially sensitive data in environment variable")
+	}
 	return nil
 }
 
-// ScanImage scans a container image for vulnerabilities
-func (is *ImageScanner) ScanImage(ctx context.Context, imageName string) (*ScanResult, error) {
-	is.mutex.Lock()
-	defer is.mutex.Unlock()
-
-	// Check if we already have results for this image
-	if result, exists := is.results[imageName]; exists {
-		// Return cached result if it's recent
-		if time.Since(result.ScanTime) < 24*time.Hour {
-			return result, nil
-		}
+// NewImageScanner creates an image scanner backed by a scan result store and
+// shared cache; without a store every scan runs against the image
+func NewImageScanner(config *DockerSecurityConfig, dockerClient *client.Client, store repository.ScanResultRepository, cache ScanCache) *ImageScanner {
+	return &ImageScanner{
+		config: config,
+		client: dockerClient,
+		store:  store,
+		cache:  cache,
+		now:    time.Now,
 	}
+}
 
+// ScanImage scans a container image for vulnerabilities. A result for the same
+// digest from the current scanner version younger than the configured TTL is
+// returned from the cache or store instead of rescanning.
+func (is *ImageScanner) ScanImage(ctx context.Context, imageName string) (*ScanResult, error) {
 	// Inspect image
 	imageInfo, _, err := is.client.ImageInspectWithRaw(ctx, imageName)
 	if err != nil {
 		return nil, fmt.Errorf("failed to inspect image: %w", err)
 	}
 
+	digest := imageDigest(imageInfo)
+	if result := is.storedResult(ctx, imageName, digest); result != nil {
+		return result, nil
+	}
+
 	// Create scan result
 	result := &ScanResult{
-		ImageID:   imageInfo.ID,
-		ImageName: imageName,
-		ScanTime:  time.Now(),
+		ImageID:        imageInfo.ID,
+		ImageDigest:    digest,
+		ImageName:      imageName,
+		ScanTime:       is.now(),
+		ScannerVersion: ScannerVersion,
 	}
 
 	// Perform vulnerability scanning
@@ -713,9 +885,130 @@ func (is *ImageScanner) ScanImage(ctx context.Context, imageName string) (*ScanR
 	// Determine if image passes security threshold
 	result.Passed = is.passesThreshold(result)
 
-	// Cache result
-	is.results[imageName] = result
+	is.saveResult(ctx, result)
+
+	return result, nil
+}
+
+// storedResult returns a fresh result for the digest from the cache or store
+func (is *ImageScanner) storedResult(ctx context.Context, imageName, digest string) *ScanResult {
+	now := is.now()
+
+	if is.cache != nil {
+		if cached, found := is.cache.Get(scanCacheKey(imageName, digest)); found {
+			if result, ok := cached.(*ScanResult); ok && result.ScannerVersion == ScannerVersion && now.Sub(result.ScanTime) < is.config.ScanResultTTL {
+				return result
+			}
+		}
+	}
+
+	if is.store == nil {
+		return nil
+	}
+
+	stored, err := is.store.GetByDigest(ctx, digest)
+	if err != nil || !stored.IsFresh(ScannerVersion, is.config.ScanResultTTL, now) {
+		return nil
+	}
+
+	result, err := scanResultFromModel(stored)
+	if err != nil {
+		logrus.WithError(err).WithField("digest", digest).Warn("Discarding unreadable stored scan result")
+		return nil
+	}
+
+	is.cacheResult(imageName, result)
+	return result
+}
+
+// saveResult persists a new result and primes the cache with it
+func (is *ImageScanner) saveResult(ctx context.Context, result *ScanResult) {
+	if is.store != nil {
+		stored, err := result.toModel()
+		if err == nil {
+			err = is.store.Upsert(ctx, stored)
+		}
+		if err != nil {
+			logrus.WithError(err).WithField("image", result.ImageName).Warn("Failed to store scan result")
+		}
+	}
+
+	is.cacheResult(result.ImageName, result)
+}
+
+// cacheResult caches a result under the name the image was scanned by; the
+// store keeps results by digest alone, so a stored result may carry another
+func (is *ImageScanner) cacheResult(imageName string, result *ScanResult) {
+	if is.cache == nil {
+		return
+	}
+	remaining := is.config.ScanResultTTL - is.now().Sub(result.ScanTime)
+	if remaining > 0 {
+		_ = is.cache.Set(scanCacheKey(imageName, result.ImageDigest), result, remaining)
+	}
+}
+
+// scanCacheKey keys a scan result by repository and digest, e.g.
+// "security:scan:library/nginx@sha256:..."
+func scanCacheKey(imageName, digest string) string {
+	return "security:scan:" + model.NormalizeRepository(imageName) + "@" + digest
+}
+
+// imageDigest returns the registry digest of an image, or its content ID for
+// images that were never pushed or pulled
+func imageDigest(imageInfo types.ImageInspect) string {
+	for _, repoDigest := range imageInfo.RepoDigests {
+		if i := strings.Index(repoDigest, "@"); i >= 0 {
+			return repoDigest[i+1:]
+		}
+	}
+	return imageInfo.ID
+}
+
+// toModel converts a scan result for storage
+func (r *ScanResult) toModel() (*model.ScanResult, error) {
+	vulnerabilities, err := json.Marshal(r.Vulnerabilities)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode vulnerabilities: %w", err)
+	}
+	if r.Vulnerabilities == nil {
+		vulnerabilities = []byte("[]")
+	}
+
+	return &model.ScanResult{
+		ImageDigest:     r.ImageDigest,
+		ImageName:       r.ImageName,
+		ScannedAt:       r.ScanTime,
+		Vulnerabilities: string(vulnerabilities),
+		TotalVulns:      r.TotalVulns,
+		CriticalVulns:   r.CriticalVulns,
+		HighVulns:       r.HighVulns,
+		MediumVulns:     r.MediumVulns,
+		LowVulns:        r.LowVulns,
+		Passed:          r.Passed,
+		ScannerVersion:  r.ScannerVersion,
+	}, nil
+}
 
+// scanResultFromModel converts a stored scan result
+func scanResultFromModel(stored *model.ScanResult) (*ScanResult, error) {
+	result := &ScanResult{
+		ImageDigest:    stored.ImageDigest,
+		ImageName:      stored.ImageName,
+		ScanTime:       stored.ScannedAt,
+		ScannerVersion: stored.ScannerVersion,
+		Passed:         stored.Passed,
+		TotalVulns:     stored.TotalVulns,
+		CriticalVulns:  stored.CriticalVulns,
+		HighVulns:      stored.HighVulns,
+		MediumVulns:    stored.MediumVulns,
+		LowVulns:       stored.LowVulns,
+	}
+	if stored.Vulnerabilities != "" {
+		if err := json.Unmarshal([]byte(stored.Vulnerabilities), &result.Vulnerabilities); err != nil {
+			return nil, fmt.Errorf("failed to decode vulnerabilities: %w", err)
+		}
+	}
 	return result, nil
 }
 
@@ -772,26 +1065,21 @@ func (sdc *SecureDockerClient) verifyImageSignature(ctx context.Context, imageNa
 	return nil
 }
 
-// startMonitoring starts container monitoring
-func (sdc *SecureDockerClient) startMonitoring() {
-	ticker := time.NewTicker(30 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		sdc.monitorContainers()
-	}
-}
-
 // monitorContainers monitors running containers for security issues
-func (sdc *SecureDockerClient) monitorContainers() {
-	ctx := context.Background()
+func (sdc *SecureDockerClient) monitorContainers(ctx context.Context) {
 	containers, err := sdc.client.ContainerList(ctx, types.ContainerListOptions{})
 	if err != nil {
-		logrus.WithError(err).Error("Failed to list containers for monitoring")
+		if ctx.Err() == nil {
+			logrus.WithError(err).Error("Failed to list containers for monitoring")
+		}
 		return
 	}
 
 	for _, container := range containers {
+		if ctx.Err() != nil {
+			return
+		}
+
 		if err := sdc.checkContainerSecurity(ctx, container); err != nil {
 			logrus.WithFields(logrus.Fields{
 				"container_id": container.ID,
@@ -816,6 +1104,11 @@ func (sdc *SecureDockerClient) checkContainerSecurity(ctx context.Context, conta
 		}
 	}
 
+	// Per-container stats are expensive; only fetch them for deep checks
+	if !sdc.config.DeepContainerChecks {
+		return nil
+	}
+
 	// Check resource usage
 	stats, err := sdc.client.ContainerStats(ctx, container.ID, false)
 	if err != nil {
@@ -852,27 +1145,15 @@ func (sdc *SecureDockerClient) handleSecurityAlert(container types.Container, is
 	// - Integration with incident response systems
 }
 
-// startCleanup starts automatic cleanup of old containers and images
-func (sdc *SecureDockerClient) startCleanup() {
-	ticker := time.NewTicker(time.Hour)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		sdc.performCleanup()
-	}
-}
-
 // performCleanup performs cleanup of old containers and images
-func (sdc *SecureDockerClient) performCleanup() {
-	ctx := context.Background()
-
+func (sdc *SecureDockerClient) performCleanup(ctx context.Context) {
 	// Clean up old containers
 	if sdc.config.MaxContainerAge > 0 {
 		sdc.cleanupOldContainers(ctx)
 	}
 
 	// Clean up old images
-	if sdc.config.MaxImageAge > 0 {
+	if sdc.config.MaxImageAge > 0 && ctx.Err() == nil {
 		sdc.cleanupOldImages(ctx)
 	}
 }
@@ -886,6 +1167,10 @@ func (sdc *SecureDockerClient) cleanupOldContainers(ctx context.Context) {
 	}
 
 	for _, container := range containers {
+		if ctx.Err() != nil {
+			return
+		}
+
 		created := time.Unix(container.Created, 0)
 		if time.Since(created) > sdc.config.MaxContainerAge {
 			logrus.WithFields(logrus.Fields{
@@ -912,6 +1197,10 @@ func (sdc *SecureDockerClient) cleanupOldImages(ctx context.Context) {
 	}
 
 	for _, image := range images {
+		if ctx.Err() != nil {
+			return
+		}
+
 		created := time.Unix(image.Created, 0)
 		if time.Since(created) > sdc.config.MaxImageAge {
 			logrus.WithFields(logrus.Fields{
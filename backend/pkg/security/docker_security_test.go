@@ -0,0 +1,366 @@
+package security
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"docker-auto/internal/repository"
+	"docker-auto/pkg/model"
+
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/client"
+)
+
+const testDigest = "sha256:" + "0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+// fakeClock is a settable time source
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// memoryScanCache is a ScanCache whose entries expire by the fake clock
+type memoryScanCache struct {
+	clock   *fakeClock
+	entries map[string]memoryScanEntry
+}
+
+type memoryScanEntry struct {
+	value     interface{}
+	expiresAt time.Time
+}
+
+func newMemoryScanCache(clock *fakeClock) *memoryScanCache {
+	return &memoryScanCache{clock: clock, entries: make(map[string]memoryScanEntry)}
+}
+
+func (c *memoryScanCache) Get(key string) (interface{}, bool) {
+	entry, ok := c.entries[key]
+	if !ok || !c.clock.Now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.value, true
+}
+
+func (c *memoryScanCache) Set(key string, value interface{}, ttl time.Duration) error {
+	c.entries[key] = memoryScanEntry{value: value, expiresAt: c.clock.Now().Add(ttl)}
+	return nil
+}
+
+// newFakeImageDaemon returns a Docker client whose daemon knows every image
+// reference as one image with testDigest
+func newFakeImageDaemon(t *testing.T) *client.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/json") || !strings.Contains(r.URL.Path, "/images/") {
+			http.Error(w, `{"message":"unexpected request"}`, http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(types.ImageInspect{
+			ID:          "sha256:image-id",
+			RepoDigests: []string{"nginx@" + testDigest},
+		})
+	}))
+	t.Cleanup(server.Close)
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://"+strings.TrimPrefix(server.URL, "http://")),
+		client.WithVersion("1.44"),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli
+}
+
+func newScanResultStore(t *testing.T) repository.ScanResultRepository {
+	t.Helper()
+
+	return repository.NewScanResultRepository(newTestDB(t, &model.ScanResult{}))
+}
+
+func newTestImageScanner(t *testing.T, store repository.ScanResultRepository, cache ScanCache, clock *fakeClock) *ImageScanner {
+	t.Helper()
+
+	config := &DockerSecurityConfig{ScanResultTTL: time.Hour}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("invalid config: %v", err)
+	}
+	scanner := NewImageScanner(config, newFakeImageDaemon(t), store, cache)
+	scanner.now = clock.Now
+	return scanner
+}
+
+func TestImageScannerReusesCachedResult(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	scanner := newTestImageScanner(t, newScanResultStore(t), newMemoryScanCache(clock), clock)
+
+	first, err := scanner.ScanImage(ctx, "nginx")
+	if err != nil {
+		t.Fatalf("ScanImage failed: %v", err)
+	}
+	if first.ImageDigest != testDigest {
+		t.Fatalf("digest = %s, want %s", first.ImageDigest, testDigest)
+	}
+
+	// Every form of the reference hits the result of the first scan
+	clock.now = clock.now.Add(30 * time.Minute)
+	for _, ref := range []string{"nginx", "nginx:latest", "docker.io/library/nginx:latest"} {
+		result, err := scanner.ScanImage(ctx, ref)
+		if err != nil {
+			t.Fatalf("ScanImage(%q) failed: %v", ref, err)
+		}
+		if result != first {
+			t.Errorf("ScanImage(%q) scanned again at %s, want the cached scan", ref, result.ScanTime)
+		}
+	}
+}
+
+func TestImageScannerFallsBackToStore(t *testing.T) {
+	ctx := context.Background()
+	clock := &fakeClock{now: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)}
+	store := newScanResultStore(t)
+
+	first, err := newTestImageScanner(t, store, nil, clock).ScanImage(ctx, "nginx:latest")
+	if err != nil {
+		t.Fatalf("ScanImage failed: %v", err)
+	}
+
+	// A replica without the cached result reads the stored one
+	clock.now = clock.now.Add(30 * time.Minute)
+	cache := newMemoryScanCache(clock)
+	replica := newTestImageScanner(t, store, cache, clock)
+	result, err := replica.ScanImage(ctx, "docker.io/library/nginx")
+	if err != nil {
+		t.Fatalf("ScanImage failed: %v", err)
+	}
+	if !result.ScanTime.Equal(first.ScanTime) {
+		t.Errorf("scan time = %s, want the stored %s", result.ScanTime, first.ScanTime)
+	}
+	if len(cache.entries) != 1 {
+		t.Errorf("cache has %d entries, want the stored result", len(cache.entries))
+	}
+
+	stored, err := store.GetLatestByImage(ctx, "nginx")
+	if err != nil {
+		t.Fatalf("GetLatestByImage failed: %v", err)
+	}
+	if stored.ImageName != "library/nginx:latest" {
+		t.Errorf("stored image name = %q, want library/nginx:latest", stored.ImageName)
+	}
+}
+
+func TestImageScannerRescansAfterTTL(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		age      time.Duration
+		version  string
+		rescans  bool
+		useCache bool
+	}{
+		{"cached within TTL", 59 * time.Minute, ScannerVersion, false, true},
+		{"cached past TTL", 61 * time.Minute, ScannerVersion, true, true},
+		{"stored within TTL", 59 * time.Minute, ScannerVersion, false, false},
+		{"stored past TTL", 61 * time.Minute, ScannerVersion, true, false},
+		{"stored by another scanner version", time.Minute, "0.0.0-old", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := &fakeClock{now: start}
+			store := newScanResultStore(t)
+			var cache ScanCache
+			if tt.useCache {
+				cache = newMemoryScanCache(clock)
+			}
+			scanner := newTestImageScanner(t, store, cache, clock)
+
+			if _, err := scanner.ScanImage(ctx, "nginx"); err != nil {
+				t.Fatalf("ScanImage failed: %v", err)
+			}
+			if tt.version != ScannerVersion {
+				stored, _ := store.GetByDigest(ctx, testDigest)
+				stored.ScannerVersion = tt.version
+				if err := store.Upsert(ctx, stored); err != nil {
+					t.Fatalf("Upsert failed: %v", err)
+				}
+			}
+
+			clock.now = start.Add(tt.age)
+			result, err := scanner.ScanImage(ctx, "nginx")
+			if err != nil {
+				t.Fatalf("ScanImage failed: %v", err)
+			}
+			if rescanned := result.ScanTime.Equal(clock.now); rescanned != tt.rescans {
+				t.Errorf("rescanned = %v, want %v", rescanned, tt.rescans)
+			}
+		})
+	}
+}
+
+// loopTransport answers the list requests of the monitoring and cleanup
+// passes with empty lists. When block is set it holds each request until it
+// is cancelled, and then takes a moment to return, as a pass winding down.
+type loopTransport struct {
+	block    bool
+	requests chan string
+	inFlight int32
+}
+
+func (l *loopTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&l.inFlight, 1)
+	defer atomic.AddInt32(&l.inFlight, -1)
+
+	select {
+	case l.requests <- r.URL.RequestURI():
+	default:
+	}
+	if l.block {
+		<-r.Context().Done()
+		time.Sleep(20 * time.Millisecond)
+		return nil, r.Context().Err()
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("[]")),
+		Request:    r,
+	}, nil
+}
+
+func newLoopTestClient(t *testing.T, transport *loopTransport, interval time.Duration) *SecureDockerClient {
+	t.Helper()
+
+	cli, err := client.NewClientWithOpts(
+		client.WithHost("tcp://docker.invalid:2375"),
+		client.WithVersion("1.44"),
+		client.WithHTTPClient(&http.Client{Transport: transport}),
+	)
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	config := &DockerSecurityConfig{
+		MonitorContainers: true,
+		MonitorInterval:   interval,
+		AutoCleanup:       true,
+		CleanupInterval:   interval,
+		MaxContainerAge:   time.Hour,
+		MaxImageAge:       time.Hour,
+	}
+	return &SecureDockerClient{config: config, client: cli, stats: &DockerSecurityStats{}}
+}
+
+// loopGoroutines counts the live goroutines launched by Start. Unlike
+// runtime.NumGoroutine, it is not thrown off by goroutines of other tests
+// that are still winding down.
+func loopGoroutines() int {
+	buf := make([]byte, 1<<20)
+	stacks := string(buf[:runtime.Stack(buf, true)])
+	return strings.Count(stacks, "created by docker-auto/pkg/security.(*SecureDockerClient).Start")
+}
+
+// waitForLoopsToExit waits for the goroutines launched by Start to exit
+func waitForLoopsToExit(t *testing.T) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for loopGoroutines() > 0 {
+		if time.Now().After(deadline) {
+			buf := make([]byte, 1<<20)
+			t.Fatalf("%d loop goroutines left:\n%s", loopGoroutines(), buf[:runtime.Stack(buf, true)])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestSecureDockerClientStartStopLifecycle(t *testing.T) {
+	// Passes are never due, so only the two loops are started
+	sdc := newLoopTestClient(t, &loopTransport{}, time.Hour)
+
+	sdc.Start(context.Background())
+	if got := loopGoroutines(); got != 2 {
+		t.Fatalf("Start launched %d goroutines, want 2", got)
+	}
+	sdc.Start(context.Background())
+	if got := loopGoroutines(); got != 2 {
+		t.Fatalf("second Start left %d goroutines, want 2", got)
+	}
+
+	sdc.Stop()
+	waitForLoopsToExit(t)
+	sdc.Stop()
+
+	// The loops can be started again once stopped
+	sdc.Start(context.Background())
+	if got := loopGoroutines(); got != 2 {
+		t.Fatalf("restart launched %d goroutines, want 2", got)
+	}
+	sdc.Stop()
+	waitForLoopsToExit(t)
+}
+
+func TestSecureDockerClientLoopsEndWithContext(t *testing.T) {
+	sdc := newLoopTestClient(t, &loopTransport{}, time.Hour)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	sdc.Start(ctx)
+	cancel()
+	waitForLoopsToExit(t)
+
+	// Stop after the context ended neither blocks nor panics
+	sdc.Stop()
+}
+
+func TestSecureDockerClientStopWaitsForInFlightPasses(t *testing.T) {
+	transport := &loopTransport{block: true, requests: make(chan string, 16)}
+	sdc := newLoopTestClient(t, transport, 10*time.Millisecond)
+
+	sdc.Start(context.Background())
+
+	// Wait until the monitoring and the cleanup pass are both blocked
+	seen := map[string]bool{}
+	timeout := time.After(5 * time.Second)
+	for len(seen) < 2 {
+		select {
+		case uri := <-transport.requests:
+			seen[uri] = true
+		case <-timeout:
+			t.Fatalf("passes reached the daemon with %v, want both", seen)
+		}
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		sdc.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop did not cancel the in-flight passes")
+	}
+
+	if n := atomic.LoadInt32(&transport.inFlight); n != 0 {
+		t.Errorf("Stop returned with %d requests in flight", n)
+	}
+	waitForLoopsToExit(t)
+}
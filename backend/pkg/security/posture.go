@@ -0,0 +1,339 @@
+package security
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"docker-auto/pkg/model"
+)
+
+// PostureInput is the runtime configuration a posture evaluation looks at.
+// It is gathered by the caller so that EvaluatePosture has no side effects.
+type PostureInput struct {
+	Production bool
+
+	HTTPSEnabled bool
+	SSLCertPath  string
+	SSLKeyPath   string
+
+	JWTSecret      string
+	JWTExpireHours int
+	JWTRefreshDays int
+
+	CORSAllowedOrigins string
+	APIKeyCount        int
+	APIKeyRole         string
+
+	EncryptionKey           string
+	RequireEncryptedSecrets bool
+	LogRedactionEnabled     bool
+
+	StatusPageRateLimit int
+
+	Containers []PostureContainer
+	Images     []PostureImage
+	Users      []PostureUser
+
+	// Active users not seen for this many days are reported
+	InactiveUserDays int
+	Now              time.Time
+}
+
+// PostureContainer is a managed container's hardening-relevant settings
+type PostureContainer struct {
+	Name        string
+	Privileged  bool
+	NetworkMode string
+	User        string
+	MemoryLimit int64
+	CapAdd      []string
+}
+
+// PostureImage is the latest scan of an image in use. Scanned is false when
+// the image has no stored scan.
+type PostureImage struct {
+	Image    string
+	Scanned  bool
+	Passed   bool
+	Critical int
+	High     int
+}
+
+// PostureUser is an active user account
+type PostureUser struct {
+	Username    string
+	Admin       bool
+	LastLoginAt *time.Time
+	CreatedAt   time.Time
+}
+
+// severityWeights is what one finding of each severity takes off the score
+var severityWeights = map[string]int{
+	model.PostureSeverityLow:      2,
+	model.PostureSeverityMedium:   5,
+	model.PostureSeverityHigh:     10,
+	model.PostureSeverityCritical: 20,
+}
+
+// maxFindingsPerRule caps how many findings of one rule count towards the
+// score, so a hundred containers with the same problem do not drown out
+// everything else
+const maxFindingsPerRule = 3
+
+// PostureResult is a scored evaluation
+type PostureResult struct {
+	Score    int
+	Grade    string
+	Rules    []model.PostureRuleScore
+	Findings []model.PostureFinding
+}
+
+// EvaluatePosture scores the configuration. The score starts at 100 and each
+// finding takes off its severity weight (low 2, medium 5, high 10, critical
+// 20), counting at most three findings per rule; it does not go below 0.
+func EvaluatePosture(in *PostureInput) *PostureResult {
+	e := &postureEvaluator{}
+
+	// TLS
+	if !in.HTTPSEnabled {
+		severity := model.PostureSeverityLow
+		if in.Production {
+			severity = model.PostureSeverityHigh
+		}
+		e.add("tls.https_disabled", "tls", severity, "", "HTTPS is disabled", "HTTPS_ENABLED is false")
+	} else if in.SSLCertPath == "" || in.SSLKeyPath == "" {
+		e.add("tls.certificate_missing", "tls", model.PostureSeverityHigh, "", "HTTPS is enabled without a certificate", "SSL_CERT_PATH or SSL_KEY_PATH is empty")
+	}
+
+	// Authentication
+	if len(in.JWTSecret) < 32 {
+		e.add("auth.jwt_secret_weak", "auth", model.PostureSeverityCritical, "", "JWT secret is too short",
+			fmt.Sprintf("JWT_SECRET has %d characters; use at least 32", len(in.JWTSecret)))
+	}
+	if in.JWTExpireHours > 24 {
+		e.add("auth.jwt_expiry_long", "auth", model.PostureSeverityMedium, "", "Access tokens live longer than a day",
+			fmt.Sprintf("JWT_EXPIRE_HOURS is %d", in.JWTExpireHours))
+	}
+	if in.JWTRefreshDays > 30 {
+		e.add("auth.refresh_expiry_long", "auth", model.PostureSeverityLow, "", "Refresh tokens live longer than 30 days",
+			fmt.Sprintf("JWT_REFRESH_DAYS is %d", in.JWTRefreshDays))
+	}
+	for _, origin := range strings.Split(in.CORSAllowedOrigins, ",") {
+		if strings.TrimSpace(origin) == "*" {
+			e.add("auth.cors_wildcard", "auth", model.PostureSeverityMedium, "", "CORS allows any origin", "CORS_ALLOWED_ORIGINS contains *")
+			break
+		}
+	}
+	if in.APIKeyCount > 0 && in.APIKeyRole == "operator" {
+		e.add("auth.api_keys_operator", "auth", model.PostureSeverityLow, "", "API keys act as operators",
+			fmt.Sprintf("%d API keys with API_KEY_ROLE operator", in.APIKeyCount))
+	}
+
+	// Secrets
+	if in.EncryptionKey == "" {
+		e.add("secrets.encryption_key_missing", "secrets", model.PostureSeverityCritical, "", "No encryption key is configured", "ENCRYPTION_KEY is empty")
+	}
+	if in.Production && !in.RequireEncryptedSecrets {
+		e.add("secrets.unencrypted_allowed", "secrets", model.PostureSeverityMedium, "", "Unencrypted secrets are allowed in production", "REQUIRE_ENCRYPTED_SECRETS is false")
+	}
+	if !in.LogRedactionEnabled {
+		e.add("secrets.log_redaction_disabled", "secrets", model.PostureSeverityMedium, "", "Container logs are not redacted", "LOG_REDACTION_ENABLED is false")
+	}
+
+	// Rate limiting
+	if in.StatusPageRateLimit <= 0 || in.StatusPageRateLimit > 600 {
+		e.add("rate_limit.status_page", "rate_limit", model.PostureSeverityLow, "", "Status page rate limit is outside the safe range",
+			fmt.Sprintf("STATUS_PAGE_RATE_LIMIT is %d; use 1 to 600 per minute", in.StatusPageRateLimit))
+	}
+
+	// Container hardening
+	for _, c := range in.Containers {
+		if c.Privileged {
+			e.add("container.privileged", "containers", model.PostureSeverityCritical, c.Name, "Container runs privileged", "")
+		}
+		if c.NetworkMode == "host" {
+			e.add("container.host_network", "containers", model.PostureSeverityHigh, c.Name, "Container uses the host network", "")
+		}
+		if len(c.CapAdd) > 0 {
+			e.add("container.capabilities_added", "containers", model.PostureSeverityMedium, c.Name, "Container adds capabilities",
+				strings.Join(c.CapAdd, ", "))
+		}
+		if c.User == "" || c.User == "root" || c.User == "0" || strings.HasPrefix(c.User, "0:") {
+			e.add("container.runs_as_root", "containers", model.PostureSeverityLow, c.Name, "Container may run as root", "No non-root user is set")
+		}
+		if c.MemoryLimit <= 0 {
+			e.add("container.no_memory_limit", "containers", model.PostureSeverityLow, c.Name, "Container has no memory limit", "")
+		}
+	}
+
+	// Images
+	for _, img := range in.Images {
+		switch {
+		case !img.Scanned:
+			e.add("image.not_scanned", "images", model.PostureSeverityLow, img.Image, "Image has not been scanned", "")
+		case !img.Passed && img.Critical > 0:
+			e.add("image.scan_failed", "images", model.PostureSeverityCritical, img.Image, "Image fails the scan threshold",
+				fmt.Sprintf("%d critical and %d high vulnerabilities", img.Critical, img.High))
+		case !img.Passed:
+			e.add("image.scan_failed", "images", model.PostureSeverityHigh, img.Image, "Image fails the scan threshold",
+				fmt.Sprintf("%d critical and %d high vulnerabilities", img.Critical, img.High))
+		}
+	}
+
+	// Users
+	if in.InactiveUserDays > 0 {
+		cutoff := in.Now.AddDate(0, 0, -in.InactiveUserDays)
+		for _, u := range in.Users {
+			lastSeen := u.CreatedAt
+			if u.LastLoginAt != nil {
+				lastSeen = *u.LastLoginAt
+			}
+			if !lastSeen.Before(cutoff) {
+				continue
+			}
+			severity := model.PostureSeverityLow
+			if u.Admin {
+				severity = model.PostureSeverityMedium
+			}
+			e.add("users.inactive", "users", severity, u.Username, "Active account has not signed in recently",
+				fmt.Sprintf("Not seen for more than %d days", in.InactiveUserDays))
+		}
+	}
+
+	return e.result()
+}
+
+// postureEvaluator collects findings and scores them
+type postureEvaluator struct {
+	findings []model.PostureFinding
+}
+
+func (e *postureEvaluator) add(rule, category, severity, resource, title, detail string) {
+	id := rule
+	if resource != "" {
+		id += ":" + resource
+	}
+	e.findings = append(e.findings, model.PostureFinding{
+		ID:       id,
+		Rule:     rule,
+		Category: category,
+		Severity: severity,
+		Resource: resource,
+		Title:    title,
+		Detail:   detail,
+	})
+}
+
+func (e *postureEvaluator) result() *PostureResult {
+	sort.SliceStable(e.findings, func(i, j int) bool {
+		return e.findings[i].ID < e.findings[j].ID
+	})
+
+	// Within a rule, the most severe findings are the ones counted
+	byRule := make(map[string][]int)
+	var rules []string
+	for _, f := range e.findings {
+		if _, ok := byRule[f.Rule]; !ok {
+			rules = append(rules, f.Rule)
+		}
+		byRule[f.Rule] = append(byRule[f.Rule], severityWeights[f.Severity])
+	}
+	sort.Strings(rules)
+
+	result := &PostureResult{Score: 100, Findings: e.findings, Rules: []model.PostureRuleScore{}}
+	if result.Findings == nil {
+		result.Findings = []model.PostureFinding{}
+	}
+	for _, rule := range rules {
+		weights := byRule[rule]
+		sort.Sort(sort.Reverse(sort.IntSlice(weights)))
+		penalty := 0
+		for i, w := range weights {
+			if i < maxFindingsPerRule {
+				penalty += w
+			}
+		}
+		result.Rules = append(result.Rules, model.PostureRuleScore{
+			Rule:     rule,
+			Severity: severityForWeight(weights[0]),
+			Findings: len(weights),
+			Penalty:  penalty,
+		})
+		result.Score -= penalty
+	}
+	if result.Score < 0 {
+		result.Score = 0
+	}
+	result.Grade = PostureGrade(result.Score)
+	return result
+}
+
+func severityForWeight(weight int) string {
+	for severity, w := range severityWeights {
+		if w == weight {
+			return severity
+		}
+	}
+	return ""
+}
+
+// PostureGrade maps a score to a letter grade
+func PostureGrade(score int) string {
+	switch {
+	case score >= 90:
+		return "A"
+	case score >= 80:
+		return "B"
+	case score >= 70:
+		return "C"
+	case score >= 60:
+		return "D"
+	}
+	return "F"
+}
+
+// ComparePosture lists the findings that were resolved or lowered in severity
+// (improved), that are new or raised in severity (regressed), and that stayed
+// the same between two reports
+func ComparePosture(from, to *model.SecurityPostureReport) *model.PostureComparison {
+	cmp := &model.PostureComparison{
+		FromID:     from.ID,
+		ToID:       to.ID,
+		FromScore:  from.Score,
+		ToScore:    to.Score,
+		ScoreDelta: to.Score - from.Score,
+		Improved:   []model.PostureFindingChange{},
+		Regressed:  []model.PostureFindingChange{},
+		Unchanged:  []model.PostureFinding{},
+	}
+
+	before := make(map[string]model.PostureFinding, len(from.Findings))
+	for _, f := range from.Findings {
+		before[f.ID] = f
+	}
+	after := make(map[string]bool, len(to.Findings))
+
+	for _, f := range to.Findings {
+		after[f.ID] = true
+		prev, existed := before[f.ID]
+		switch {
+		case !existed:
+			cmp.Regressed = append(cmp.Regressed, model.PostureFindingChange{PostureFinding: f})
+		case model.PostureSeverityRank(f.Severity) > model.PostureSeverityRank(prev.Severity):
+			cmp.Regressed = append(cmp.Regressed, model.PostureFindingChange{PostureFinding: f, Previous: prev.Severity})
+		case model.PostureSeverityRank(f.Severity) < model.PostureSeverityRank(prev.Severity):
+			cmp.Improved = append(cmp.Improved, model.PostureFindingChange{PostureFinding: f, Previous: prev.Severity})
+		default:
+			cmp.Unchanged = append(cmp.Unchanged, f)
+		}
+	}
+	for _, f := range from.Findings {
+		if !after[f.ID] {
+			cmp.Improved = append(cmp.Improved, model.PostureFindingChange{PostureFinding: f, Previous: f.Severity, Resolved: true})
+		}
+	}
+
+	return cmp
+}
@@ -5,10 +5,14 @@ import (
 	"encoding/hex"
 	"fmt"
 	"net"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
+	"docker-auto/pkg/metrics"
+
 	"github.com/sirupsen/logrus"
 )
 
@@ -89,11 +93,12 @@ func DefaultEnhancedRateLimitConfig() *EnhancedRateLimitConfig {
 		CleanupInterval:    15 * time.Minute,
 		MaxMemoryEntries:   100000,
 		EndpointLimits: map[string]EndpointLimit{
-			"/api/auth/login":    {Limit: 5, Window: time.Minute, Methods: []string{"POST"}},
-			"/api/auth/register": {Limit: 3, Window: 10 * time.Minute, Methods: []string{"POST"}},
-			"/api/auth/refresh":  {Limit: 10, Window: time.Minute, Methods: []string{"POST"}},
-			"/api/containers":    {Limit: 100, Window: time.Minute, RequireAuth: true},
-			"/api/images":        {Limit: 50, Window: time.Minute, RequireAuth: true},
+			"/api/auth/login":         {Limit: 5, Window: time.Minute, Methods: []string{"POST"}},
+			"/api/auth/register":      {Limit: 3, Window: 10 * time.Minute, Methods: []string{"POST"}},
+			"/api/auth/refresh":       {Limit: 10, Window: time.Minute, Methods: []string{"POST"}},
+			"/api/auth/oidc/callback": {Limit: 10, Window: time.Minute, Methods: []string{"GET"}},
+			"/api/containers":         {Limit: 100, Window: time.Minute, RequireAuth: true},
+			"/api/images":             {Limit: 50, Window: time.Minute, RequireAuth: true},
 		},
 	}
 }
@@ -162,7 +167,7 @@ func (rl *EnhancedRateLimiter) CheckLimit(ctx *RateLimitContext) (*RateLimitResu
 
 	// Check IP blacklist
 	if rl.isBlacklisted(ctx) {
-		rl.globalStats.BlockedRequests++
+		rl.recordBlocked("blacklisted")
 		return &RateLimitResult{
 			Allowed:     false,
 			Reason:      "IP blacklisted",
@@ -180,7 +185,7 @@ func (rl *EnhancedRateLimiter) CheckLimit(ctx *RateLimitContext) (*RateLimitResu
 
 	// Check if IP/User is banned
 	if banned := rl.checkBanned(ctx); banned != nil {
-		rl.globalStats.BlockedRequests++
+		rl.recordBlocked("banned")
 		return banned, nil
 	}
 
@@ -189,21 +194,21 @@ func (rl *EnhancedRateLimiter) CheckLimit(ctx *RateLimitContext) (*RateLimitResu
 
 	// Check global rate limit
 	if globalResult := rl.checkGlobalLimit(currentLimits); !globalResult.Allowed {
-		rl.globalStats.BlockedRequests++
+		rl.recordBlocked("global")
 		rl.recordViolation(ctx)
 		return globalResult, nil
 	}
 
 	// Check endpoint-specific limits
 	if endpointResult := rl.checkEndpointLimit(ctx, currentLimits); !endpointResult.Allowed {
-		rl.globalStats.BlockedRequests++
+		rl.recordBlocked("endpoint")
 		rl.recordViolation(ctx)
 		return endpointResult, nil
 	}
 
 	// Check IP-based limits
 	if ipResult := rl.checkIPLimit(ctx, currentLimits); !ipResult.Allowed {
-		rl.globalStats.BlockedRequests++
+		rl.recordBlocked("ip")
 		rl.recordViolation(ctx)
 		return ipResult, nil
 	}
@@ -211,7 +216,7 @@ func (rl *EnhancedRateLimiter) CheckLimit(ctx *RateLimitContext) (*RateLimitResu
 	// Check user-based limits (if authenticated)
 	if ctx.UserID > 0 {
 		if userResult := rl.checkUserLimit(ctx, currentLimits); !userResult.Allowed {
-			rl.globalStats.BlockedRequests++
+			rl.recordBlocked("user")
 			rl.recordViolation(ctx)
 			return userResult, nil
 		}
@@ -219,7 +224,7 @@ func (rl *EnhancedRateLimiter) CheckLimit(ctx *RateLimitContext) (*RateLimitResu
 
 	// Check subnet limits
 	if subnetResult := rl.checkSubnetLimit(ctx, currentLimits); !subnetResult.Allowed {
-		rl.globalStats.BlockedRequests++
+		rl.recordBlocked("subnet")
 		rl.recordViolation(ctx)
 		return subnetResult, nil
 	}
@@ -234,11 +239,18 @@ func (rl *EnhancedRateLimiter) CheckLimit(ctx *RateLimitContext) (*RateLimitResu
 	}, nil
 }
 
+// recordBlocked counts a rejected request in the global stats and the
+// Prometheus metrics. The caller must hold rl.mutex.
+func (rl *EnhancedRateLimiter) recordBlocked(reason string) {
+	rl.globalStats.BlockedRequests++
+	metrics.RecordRateLimitBlock(reason)
+}
+
 // RateLimitContext represents the context for rate limiting
 type RateLimitContext struct {
 	IP         string    `json:"ip"`
 	UserID     int64     `json:"user_id"`
-	Endpoint   string    `json:"endpoint"`
+	Endpoint   string    `json:"endpoint"` // matched route, e.g. /api/containers/:id/start
 	Method     string    `json:"method"`
 	UserAgent  string    `json:"user_agent"`
 	Timestamp  time.Time `json:"timestamp"`
@@ -904,6 +916,110 @@ func (rl *EnhancedRateLimiter) RemoveFromBlacklist(ip string, userID int64) {
 	}
 }
 
+// Clone returns a deep copy of the configuration
+func (c *EnhancedRateLimitConfig) Clone() *EnhancedRateLimitConfig {
+	clone := *c
+	clone.EndpointLimits = make(map[string]EndpointLimit, len(c.EndpointLimits))
+	for endpoint, limit := range c.EndpointLimits {
+		limit.Methods = append([]string(nil), limit.Methods...)
+		clone.EndpointLimits[endpoint] = limit
+	}
+	clone.IPBlacklist = append([]string(nil), c.IPBlacklist...)
+	clone.IPWhitelist = append([]string(nil), c.IPWhitelist...)
+	clone.UserBlacklist = append([]int64(nil), c.UserBlacklist...)
+	clone.UserWhitelist = append([]int64(nil), c.UserWhitelist...)
+	return &clone
+}
+
+// Config returns a copy of the configured limits
+func (rl *EnhancedRateLimiter) Config() *EnhancedRateLimitConfig {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	return rl.config.Clone()
+}
+
+// EffectiveConfig returns a copy of the limits currently applied, which
+// dynamic limiting lowers under load
+func (rl *EnhancedRateLimiter) EffectiveConfig() *EnhancedRateLimitConfig {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	return rl.calculateDynamicLimits().Clone()
+}
+
+// UpdateConfig replaces the configuration. Requests checked afterwards see
+// the new limits; counters and bans are kept. The cleanup interval and load
+// monitoring keep their settings until restart.
+func (rl *EnhancedRateLimiter) UpdateConfig(config *EnhancedRateLimitConfig) {
+	config = config.Clone()
+
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+	rl.config = config
+}
+
+// RateLimitBan is an IP or user banned for repeated violations
+type RateLimitBan struct {
+	IP          string    `json:"ip,omitempty"`
+	UserID      int64     `json:"user_id,omitempty"`
+	Violations  int       `json:"violations"`
+	BannedUntil time.Time `json:"banned_until"`
+}
+
+// Bans returns the IPs and users currently banned, the longest bans first
+func (rl *EnhancedRateLimiter) Bans() []RateLimitBan {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+
+	now := time.Now()
+	bans := []RateLimitBan{}
+	for key, entry := range rl.entries {
+		if entry.BannedUntil == nil || !now.Before(*entry.BannedUntil) {
+			continue
+		}
+		ban := RateLimitBan{Violations: entry.Violations, BannedUntil: *entry.BannedUntil}
+		if ip, ok := strings.CutPrefix(key, "ban:ip:"); ok {
+			ban.IP = ip
+		} else if id, ok := strings.CutPrefix(key, "ban:user:"); ok {
+			userID, err := strconv.ParseInt(id, 10, 64)
+			if err != nil {
+				continue
+			}
+			ban.UserID = userID
+		} else {
+			continue
+		}
+		bans = append(bans, ban)
+	}
+
+	sort.Slice(bans, func(i, j int) bool {
+		return bans[i].BannedUntil.After(bans[j].BannedUntil)
+	})
+	return bans
+}
+
+// UnbanIP lifts the ban of an IP and forgets its violations. It reports
+// whether the IP was banned.
+func (rl *EnhancedRateLimiter) UnbanIP(ip string) bool {
+	return rl.unban(fmt.Sprintf("ban:ip:%s", ip), fmt.Sprintf("violations:ip:%s", ip))
+}
+
+// UnbanUser lifts the ban of a user and forgets their violations. It
+// reports whether the user was banned.
+func (rl *EnhancedRateLimiter) UnbanUser(userID int64) bool {
+	return rl.unban(fmt.Sprintf("ban:user:%d", userID), fmt.Sprintf("violations:user:%d", userID))
+}
+
+func (rl *EnhancedRateLimiter) unban(banKey, violationsKey string) bool {
+	rl.mutex.Lock()
+	defer rl.mutex.Unlock()
+
+	entry, banned := rl.entries[banKey]
+	banned = banned && entry.BannedUntil != nil && time.Now().Before(*entry.BannedUntil)
+	delete(rl.entries, banKey)
+	delete(rl.entries, violationsKey)
+	return banned
+}
+
 // Helper function
 func max(a, b int) int {
 	if a > b {
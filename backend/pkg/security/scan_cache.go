@@ -0,0 +1,56 @@
+package security
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"docker-auto/pkg/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// sharedScanCacheTimeout bounds a shared cache call; past it the scanner
+// reads the scan result store instead
+const sharedScanCacheTimeout = 2 * time.Second
+
+// sharedScanCache is a ScanCache in a cache shared between replicas, so an
+// image scanned by one replica is not scanned again by the others
+type sharedScanCache struct {
+	cache *utils.RedisCache
+}
+
+// NewSharedScanCache returns a ScanCache storing results in cache. A failing
+// cache reads as a miss, falling back to the scan result store.
+func NewSharedScanCache(cache *utils.RedisCache) ScanCache {
+	return &sharedScanCache{cache: cache}
+}
+
+// Get returns the *ScanResult cached under key
+func (c *sharedScanCache) Get(key string) (interface{}, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), sharedScanCacheTimeout)
+	defer cancel()
+
+	var result ScanResult
+	found, err := c.cache.GetJSON(ctx, key, &result)
+	if err != nil {
+		logrus.WithError(err).WithField("key", key).Debug("Shared scan cache unavailable")
+		return nil, false
+	}
+	if !found {
+		return nil, false
+	}
+	return &result, true
+}
+
+// Set caches a *ScanResult under key for ttl
+func (c *sharedScanCache) Set(key string, value interface{}, ttl time.Duration) error {
+	result, ok := value.(*ScanResult)
+	if !ok {
+		return fmt.Errorf("shared scan cache only stores scan results, got %T", value)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), sharedScanCacheTimeout)
+	defer cancel()
+	return c.cache.SetJSON(ctx, key, result, ttl)
+}
@@ -403,6 +403,13 @@ func ValidateConfig(config *SecurityMasterConfig) error {
 		}
 	}
 
+	// Validate Docker background task intervals
+	if config.Docker != nil {
+		if err := config.Docker.Validate(); err != nil {
+			return err
+		}
+	}
+
 	// Production-specific validations
 	if config.Environment == "production" {
 		if config.SecurityLevel < 2 {
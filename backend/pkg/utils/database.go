@@ -5,7 +5,7 @@ import (
 	"time"
 
 	"docker-auto/internal/config"
-	"docker-auto/internal/model"
+	"docker-auto/pkg/model"
 
 	"github.com/sirupsen/logrus"
 	"gorm.io/driver/postgres"
@@ -181,21 +181,42 @@ func createIndexes(db *gorm.DB) error {
 			columns: []string{"updated_at"},
 			name:    "idx_containers_updated_at",
 		},
-		// Update history indexes - optimized for time-series queries
+		// Update history indexes - optimized for filtered history pages
 		{
-			table:   "update_histories",
-			columns: []string{"container_id", "created_at"},
-			name:    "idx_update_histories_container_created",
+			table:   "update_history",
+			columns: []string{"container_id", "started_at DESC", "id DESC"},
+			name:    "idx_update_history_container_started",
 		},
 		{
-			table:   "update_histories",
-			columns: []string{"status", "created_at"},
-			name:    "idx_update_histories_status_created",
+			table:   "update_history",
+			columns: []string{"status", "started_at DESC"},
+			name:    "idx_update_history_status_started",
 		},
 		{
-			table:   "update_histories",
-			columns: []string{"created_at"},
-			name:    "idx_update_histories_created_at",
+			table:   "update_history",
+			columns: []string{"created_by", "started_at DESC"},
+			name:    "idx_update_history_created_by_started",
+		},
+		// Keyset pagination indexes for large time-ordered tables
+		{
+			table:   "activity_logs",
+			columns: []string{"created_at DESC", "id DESC"},
+			name:    "idx_activity_logs_keyset",
+		},
+		{
+			table:   "update_history",
+			columns: []string{"started_at DESC", "id DESC"},
+			name:    "idx_update_history_keyset",
+		},
+		{
+			table:   "task_execution_logs",
+			columns: []string{"started_at DESC", "id DESC"},
+			name:    "idx_task_execution_logs_keyset",
+		},
+		{
+			table:   "user_notifications",
+			columns: []string{"user_id", "created_at DESC", "id DESC"},
+			name:    "idx_user_notifications_user_keyset",
 		},
 		// Notification indexes
 		{
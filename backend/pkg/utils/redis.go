@@ -0,0 +1,129 @@
+package utils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"docker-auto/internal/config"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sirupsen/logrus"
+)
+
+// redisConnectTimeout bounds the ping made when connecting to Redis
+const redisConnectTimeout = 5 * time.Second
+
+// InitRedis connects to the Redis server in REDIS_URL. It returns nil without
+// an error when no URL is configured, leaving caches in process.
+func InitRedis(cfg *config.Config) (*redis.Client, error) {
+	if cfg.Redis.URL == "" {
+		return nil, nil
+	}
+
+	options, err := redis.ParseURL(cfg.Redis.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	if cfg.Redis.MaxActiveConns > 0 {
+		options.PoolSize = cfg.Redis.MaxActiveConns
+		options.MaxActiveConns = cfg.Redis.MaxActiveConns
+	}
+	if cfg.Redis.MaxIdleConns > 0 {
+		options.MaxIdleConns = cfg.Redis.MaxIdleConns
+	}
+	if cfg.Redis.IdleTimeoutSeconds > 0 {
+		options.ConnMaxIdleTime = time.Duration(cfg.Redis.IdleTimeoutSeconds) * time.Second
+	}
+
+	client := redis.NewClient(options)
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisConnectTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to ping Redis: %w", err)
+	}
+
+	logrus.WithField("addr", options.Addr).Info("Redis connection established successfully")
+	return client, nil
+}
+
+// RedisCache is a JSON cache in Redis shared between replicas. Keys are
+// stored under a prefix so several deployments can share a server.
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedisCache creates a cache storing its keys under prefix
+func NewRedisCache(client *redis.Client, prefix string) *RedisCache {
+	return &RedisCache{
+		client: client,
+		prefix: prefix,
+	}
+}
+
+// GetJSON decodes the value of key into dest. found is false for a missing
+// or expired key.
+func (c *RedisCache) GetJSON(ctx context.Context, key string, dest interface{}) (found bool, err error) {
+	data, err := c.client.Get(ctx, c.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(data, dest); err != nil {
+		return false, fmt.Errorf("failed to decode cached %s: %w", key, err)
+	}
+	return true, nil
+}
+
+// SetJSON stores value under key as JSON for ttl
+func (c *RedisCache) SetJSON(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s for caching: %w", key, err)
+	}
+	return c.client.Set(ctx, c.prefix+key, data, ttl).Err()
+}
+
+// Delete removes keys from the cache
+func (c *RedisCache) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = c.prefix + key
+	}
+	return c.client.Del(ctx, prefixed...).Err()
+}
+
+// DeletePrefix removes every key starting with prefix. Keys are found with
+// SCAN, so the server is not blocked on large caches.
+func (c *RedisCache) DeletePrefix(ctx context.Context, prefix string) error {
+	iter := c.client.Scan(ctx, 0, c.prefix+prefix+"*", 500).Iterator()
+
+	batch := make([]string, 0, 500)
+	for iter.Next(ctx) {
+		batch = append(batch, iter.Val())
+		if len(batch) == cap(batch) {
+			if err := c.client.Del(ctx, batch...).Err(); err != nil {
+				return err
+			}
+			batch = batch[:0]
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if len(batch) > 0 {
+		return c.client.Del(ctx, batch...).Err()
+	}
+	return nil
+}
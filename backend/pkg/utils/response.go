@@ -4,6 +4,8 @@ import (
 	"net/http"
 	"time"
 
+	apperrors "docker-auto/pkg/errors"
+
 	"github.com/gin-gonic/gin"
 )
 
@@ -15,7 +17,9 @@ type APIResponse struct {
 	Success   bool        `json:"success"`
 	Timestamp time.Time   `json:"timestamp"`
 	RequestID string      `json:"request_id,omitempty"`
-	Meta      *Meta       `json:"meta,omitempty"`
+	// ErrorCode classifies failed requests, e.g. CONTAINER_NOT_FOUND
+	ErrorCode string `json:"error_code,omitempty"`
+	Meta      *Meta  `json:"meta,omitempty"`
 }
 
 // Meta contains additional metadata for the response
@@ -35,6 +39,10 @@ type Pagination struct {
 	TotalPages int   `json:"total_pages"`
 	HasNext    bool  `json:"has_next"`
 	HasPrev    bool  `json:"has_prev"`
+
+	// Cursor pagination; set instead of Page/Total/TotalPages on keyset endpoints
+	Cursor     string `json:"cursor,omitempty"`
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 // PaginatedResponse represents a paginated API response
@@ -100,6 +108,7 @@ func ErrorResponse(code int, message string) *APIResponse {
 		Message:   message,
 		Success:   false,
 		Timestamp: time.Now().UTC(),
+		ErrorCode: string(apperrors.CodeForStatus(code)),
 	}
 }
 
@@ -111,6 +120,7 @@ func ErrorResponseWithDetails(code int, message string, details []ErrorDetail) *
 			Message:   message,
 			Success:   false,
 			Timestamp: time.Now().UTC(),
+			ErrorCode: string(apperrors.CodeForStatus(code)),
 		},
 		Details: details,
 	}
@@ -184,29 +194,49 @@ func (rb *ResponseBuilder) NoContent() {
 	rb.ctx.Status(http.StatusNoContent)
 }
 
-// Error sends an error response
+// Error sends an error response with the generic code of its status
 func (rb *ResponseBuilder) Error(code int, message string) {
-	response := &APIResponse{
-		Code:      code,
-		Message:   message,
-		Success:   false,
-		Timestamp: time.Now().UTC(),
-		RequestID: rb.getRequestID(),
-		Meta:      rb.buildMeta(),
-	}
+	rb.sendError(code, apperrors.CodeForStatus(code), message, nil, nil)
+}
 
-	rb.ctx.JSON(code, response)
+// ErrorWithCode sends an error response with a specific error code
+func (rb *ResponseBuilder) ErrorWithCode(code int, errorCode apperrors.Code, message string, details []ErrorDetail) {
+	rb.sendError(code, errorCode, message, nil, details)
+}
+
+// FromError sends the response for err: its code, with the status of the
+// code and its message, or a 500 with fallback as message when err has no
+// code
+func (rb *ResponseBuilder) FromError(err error, fallback string) {
+	coded := apperrors.As(err)
+	if coded == nil {
+		rb.InternalServerError(fallback)
+		return
+	}
+	rb.sendError(apperrors.HTTPStatus(coded.Code), coded.Code, coded.Message, nil, nil)
 }
 
 // ErrorWithDetails sends an error response with details
 func (rb *ResponseBuilder) ErrorWithDetails(code int, message string, details []ErrorDetail) {
+	rb.sendError(code, apperrors.CodeForStatus(code), message, nil, details)
+}
+
+// ErrorWithData sends an error response with details and data describing
+// the state that caused it
+func (rb *ResponseBuilder) ErrorWithData(code int, message string, data interface{}, details []ErrorDetail) {
+	rb.sendError(code, apperrors.CodeForStatus(code), message, data, details)
+}
+
+func (rb *ResponseBuilder) sendError(code int, errorCode apperrors.Code, message string, data interface{}, details []ErrorDetail) {
 	response := &APIError{
 		APIResponse: APIResponse{
 			Code:      code,
 			Message:   message,
+			Data:      data,
 			Success:   false,
 			Timestamp: time.Now().UTC(),
 			RequestID: rb.getRequestID(),
+			ErrorCode: string(errorCode),
 			Meta:      rb.buildMeta(),
 		},
 		Details: details,
@@ -391,6 +421,17 @@ func CreatePagination(page, pageSize int, totalItems int64) *Pagination {
 	}
 }
 
+// CreateCursorPagination creates pagination metadata for cursor-based pages
+func CreateCursorPagination(limit int, cursor, nextCursor string) *Pagination {
+	return &Pagination{
+		Limit:      limit,
+		HasNext:    nextCursor != "",
+		HasPrev:    cursor != "",
+		Cursor:     cursor,
+		NextCursor: nextCursor,
+	}
+}
+
 // PaginatedSuccessResponse creates a paginated success response
 func PaginatedSuccessResponse(data interface{}, total int64, page, limit int) *PaginatedResponse {
 	pagination := CreatePagination(page, limit, total)
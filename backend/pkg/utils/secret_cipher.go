@@ -0,0 +1,222 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// encryptedSecretPrefix marks values written by SecretCipher, followed by the
+// key version, e.g. "enc:v2:<base64 nonce+ciphertext>"
+const encryptedSecretPrefix = "enc:v"
+
+var (
+	// ErrSecretKeyMissing is returned when no encryption key is configured
+	ErrSecretKeyMissing = errors.New("encryption key is not configured")
+	// ErrSecretKeyUnknown is returned for secrets encrypted under a key
+	// version that is not configured
+	ErrSecretKeyUnknown = errors.New("secret was encrypted with an unknown key version")
+	// ErrSecretCorrupted is returned for secrets whose ciphertext is
+	// malformed or fails authentication
+	ErrSecretCorrupted = errors.New("encrypted secret is corrupted or the key is wrong")
+)
+
+// SecretCipher encrypts secret database columns with AES-GCM. Every value
+// records the version of the key it was sealed with, so keys can be rotated
+// while values sealed with retired keys stay readable.
+type SecretCipher struct {
+	current int
+	keys    map[int]cipher.AEAD
+	derived map[int][]byte
+}
+
+// NewSecretCipher creates a cipher encrypting with the current key version
+// and decrypting with any of keys
+func NewSecretCipher(current int, keys map[int]string) (*SecretCipher, error) {
+	if keys[current] == "" {
+		return nil, ErrSecretKeyMissing
+	}
+
+	c := &SecretCipher{
+		current: current,
+		keys:    make(map[int]cipher.AEAD, len(keys)),
+		derived: make(map[int][]byte, len(keys)),
+	}
+	for version, key := range keys {
+		if version <= 0 || key == "" {
+			return nil, fmt.Errorf("invalid encryption key version %d", version)
+		}
+
+		derivedKey := deriveKey(key)
+		block, err := aes.NewCipher(derivedKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create AES cipher: %w", err)
+		}
+		gcm, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCM: %w", err)
+		}
+		c.keys[version] = gcm
+		c.derived[version] = derivedKey
+	}
+
+	return c, nil
+}
+
+// CurrentVersion returns the key version new values are encrypted with
+func (c *SecretCipher) CurrentVersion() int {
+	return c.current
+}
+
+// Versions returns the configured key versions
+func (c *SecretCipher) Versions() []int {
+	versions := make([]int, 0, len(c.keys))
+	for version := range c.keys {
+		versions = append(versions, version)
+	}
+	return versions
+}
+
+// Encrypt seals plaintext with the current key. Empty values stay empty.
+func (c *SecretCipher) Encrypt(plaintext string) (string, error) {
+	if plaintext == "" {
+		return "", nil
+	}
+
+	gcm := c.keys[c.current]
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	// The version is authenticated so a value cannot be relabelled
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), secretAdditionalData(c.current))
+	return encryptedSecretPrefix + strconv.Itoa(c.current) + ":" + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a value sealed by Encrypt with any configured key. Values
+// from EncryptSensitiveData are opened too, and values that were never
+// encrypted are returned unchanged, so legacy rows stay readable until they
+// are migrated.
+func (c *SecretCipher) Decrypt(value string) (string, error) {
+	version, payload, ok := parseEncryptedSecret(value)
+	if !ok {
+		if IsEncryptedSecret(value) {
+			return "", ErrSecretCorrupted
+		}
+		if plaintext, ok := c.openUnversioned(value); ok {
+			return plaintext, nil
+		}
+		return value, nil
+	}
+
+	gcm, exists := c.keys[version]
+	if !exists {
+		return "", fmt.Errorf("%w: v%d", ErrSecretKeyUnknown, version)
+	}
+
+	sealed, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil || len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+		return "", ErrSecretCorrupted
+	}
+
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, secretAdditionalData(version))
+	if err != nil {
+		return "", ErrSecretCorrupted
+	}
+	return string(plaintext), nil
+}
+
+// Reencrypt brings a stored value under the current key: plaintext is
+// encrypted and values sealed with an older key are re-sealed. It reports
+// whether the value changed. Values that cannot be opened are reported as
+// errors, including those already under the current key.
+func (c *SecretCipher) Reencrypt(value string) (string, bool, error) {
+	if value == "" {
+		return value, false, nil
+	}
+
+	plaintext, err := c.Decrypt(value)
+	if err != nil {
+		return "", false, err
+	}
+	if version, ok := EncryptedSecretVersion(value); ok && version == c.current {
+		return value, false, nil
+	}
+	encrypted, err := c.Encrypt(plaintext)
+	if err != nil {
+		return "", false, err
+	}
+	return encrypted, true, nil
+}
+
+// openUnversioned opens a value written by EncryptSensitiveData with any of
+// the configured keys
+func (c *SecretCipher) openUnversioned(value string) (string, bool) {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return "", false
+	}
+	for _, gcm := range c.keys {
+		if len(sealed) < gcm.NonceSize()+gcm.Overhead() {
+			continue
+		}
+		nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+		if plaintext, err := gcm.Open(nil, nonce, ciphertext, nil); err == nil {
+			return string(plaintext), true
+		}
+	}
+	return "", false
+}
+
+// KeyFingerprint identifies a key version's key without revealing it, so a
+// key that changed under the same version can be detected
+func (c *SecretCipher) KeyFingerprint(version int) string {
+	derivedKey, exists := c.derived[version]
+	if !exists {
+		return ""
+	}
+	mac := hmac.New(sha256.New, derivedKey)
+	mac.Write([]byte("docker-auto secret key check"))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// IsEncryptedSecret reports whether a stored value was written by SecretCipher
+func IsEncryptedSecret(value string) bool {
+	return strings.HasPrefix(value, encryptedSecretPrefix)
+}
+
+// EncryptedSecretVersion returns the key version of an encrypted value
+func EncryptedSecretVersion(value string) (int, bool) {
+	version, _, ok := parseEncryptedSecret(value)
+	return version, ok
+}
+
+func parseEncryptedSecret(value string) (int, string, bool) {
+	if !IsEncryptedSecret(value) {
+		return 0, "", false
+	}
+	version, payload, found := strings.Cut(strings.TrimPrefix(value, encryptedSecretPrefix), ":")
+	if !found {
+		return 0, "", false
+	}
+	parsed, err := strconv.Atoi(version)
+	if err != nil || parsed <= 0 {
+		return 0, "", false
+	}
+	return parsed, payload, true
+}
+
+func secretAdditionalData(version int) []byte {
+	return []byte("v" + strconv.Itoa(version))
+}
@@ -0,0 +1,133 @@
+package utils
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+)
+
+const (
+	oldSecretKey = "old-key-0123456789abcdef0123456789"
+	newSecretKey = "new-key-0123456789abcdef0123456789"
+)
+
+func newTestSecretCipher(t *testing.T, current int, keys map[int]string) *SecretCipher {
+	t.Helper()
+
+	c, err := NewSecretCipher(current, keys)
+	if err != nil {
+		t.Fatalf("NewSecretCipher failed: %v", err)
+	}
+	return c
+}
+
+func TestSecretCipherRotation(t *testing.T) {
+	before := newTestSecretCipher(t, 1, map[int]string{1: oldSecretKey})
+	during := newTestSecretCipher(t, 2, map[int]string{1: oldSecretKey, 2: newSecretKey})
+	after := newTestSecretCipher(t, 2, map[int]string{2: newSecretKey})
+
+	sealedOld, err := before.Encrypt("hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	legacy, err := EncryptSensitiveData("legacy", oldSecretKey)
+	if err != nil {
+		t.Fatalf("EncryptSensitiveData failed: %v", err)
+	}
+
+	// With both keys configured, values sealed with the old key stay
+	// readable and move to the new one
+	tests := []struct {
+		name        string
+		stored      string
+		plaintext   string
+		wantChanged bool
+	}{
+		{"sealed with the old key", sealedOld, "hunter2", true},
+		{"unversioned legacy value", legacy, "legacy", true},
+		{"plaintext", "plain", "plain", true},
+		{"empty", "", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := during.Decrypt(tt.stored); err != nil || got != tt.plaintext {
+				t.Fatalf("Decrypt = %q, %v, want %q", got, err, tt.plaintext)
+			}
+
+			rotated, changed, err := during.Reencrypt(tt.stored)
+			if err != nil {
+				t.Fatalf("Reencrypt failed: %v", err)
+			}
+			if changed != tt.wantChanged {
+				t.Fatalf("changed = %v, want %v", changed, tt.wantChanged)
+			}
+			if !changed {
+				return
+			}
+			if version, ok := EncryptedSecretVersion(rotated); !ok || version != 2 {
+				t.Fatalf("rotated value %q is not under key version 2", rotated)
+			}
+
+			// The old key can be retired once every value is rotated
+			if got, err := after.Decrypt(rotated); err != nil || got != tt.plaintext {
+				t.Errorf("Decrypt after retiring the old key = %q, %v, want %q", got, err, tt.plaintext)
+			}
+			if _, err := before.Decrypt(rotated); !errors.Is(err, ErrSecretKeyUnknown) {
+				t.Errorf("Decrypt with only the old key = %v, want ErrSecretKeyUnknown", err)
+			}
+			if again, changed, err := during.Reencrypt(rotated); err != nil || changed || again != rotated {
+				t.Errorf("Reencrypt of a current value = %q, %v, %v, want it unchanged", again, changed, err)
+			}
+		})
+	}
+
+	if _, err := after.Decrypt(sealedOld); !errors.Is(err, ErrSecretKeyUnknown) {
+		t.Errorf("Decrypt of an unrotated value after retiring its key = %v, want ErrSecretKeyUnknown", err)
+	}
+}
+
+func TestSecretCipherRejectsCorruptedCiphertext(t *testing.T) {
+	c := newTestSecretCipher(t, 2, map[int]string{1: oldSecretKey, 2: newSecretKey})
+	sealed, err := c.Encrypt("hunter2")
+	if err != nil {
+		t.Fatalf("Encrypt failed: %v", err)
+	}
+	payload := strings.TrimPrefix(sealed, "enc:v2:")
+	raw, _ := base64.StdEncoding.DecodeString(payload)
+
+	flipped := append([]byte(nil), raw...)
+	flipped[len(flipped)-1] ^= 0x01
+
+	sameVersionOtherKey := newTestSecretCipher(t, 2, map[int]string{2: oldSecretKey})
+
+	tests := []struct {
+		name   string
+		cipher *SecretCipher
+		value  string
+		want   error
+	}{
+		{"flipped ciphertext bit", c, "enc:v2:" + base64.StdEncoding.EncodeToString(flipped), ErrSecretCorrupted},
+		{"truncated ciphertext", c, "enc:v2:" + base64.StdEncoding.EncodeToString(raw[:len(raw)-4]), ErrSecretCorrupted},
+		{"shorter than a nonce", c, "enc:v2:" + base64.StdEncoding.EncodeToString(raw[:8]), ErrSecretCorrupted},
+		{"invalid base64", c, "enc:v2:" + payload[:len(payload)-1] + "!", ErrSecretCorrupted},
+		{"relabelled key version", c, "enc:v1:" + payload, ErrSecretCorrupted},
+		{"missing payload separator", c, "enc:v2" + payload, ErrSecretCorrupted},
+		{"version zero", c, "enc:v0:" + payload, ErrSecretCorrupted},
+		{"changed key under the same version", sameVersionOtherKey, sealed, ErrSecretCorrupted},
+		{"unknown key version", c, "enc:v3:" + payload, ErrSecretKeyUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := tt.cipher.Decrypt(tt.value); !errors.Is(err, tt.want) {
+				t.Errorf("Decrypt = %q, %v, want %v", got, err, tt.want)
+			}
+			// Rotation must never replace an unreadable value
+			if got, changed, err := tt.cipher.Reencrypt(tt.value); !errors.Is(err, tt.want) || changed {
+				t.Errorf("Reencrypt = %q, %v, %v, want %v", got, changed, err, tt.want)
+			}
+		})
+	}
+}
@@ -17,6 +17,7 @@ import type {
   ContainerImage,
   ImageUpdateCheck,
   ResourceMetrics,
+  UpdateWindow,
 } from "@/types/container";
 
 export class ContainerAPI {
@@ -49,6 +50,9 @@ export class ContainerAPI {
       if (filters.search) {
         params.append("search", filters.search);
       }
+      if (filters.stackId) {
+        params.append("stack_id", filters.stackId.toString());
+      }
       if (filters.labels) {
         Object.entries(filters.labels).forEach(([key, value]) => {
           params.append(`label.${key}`, value);
@@ -162,6 +166,13 @@ export class ContainerAPI {
     return get<ResourceMetrics>(`${this.baseUrl}/${id}/stats`);
   }
 
+  /**
+   * Get when the updater may next apply an update
+   */
+  async getNextUpdateWindow(id: string): Promise<UpdateWindow> {
+    return get<UpdateWindow>(`${this.baseUrl}/${id}/next-window`);
+  }
+
   /**
    * Get historical stats
    */
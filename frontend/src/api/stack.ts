@@ -0,0 +1,136 @@
+/**
+ * Stack API service
+ */
+import { get, post, put, del } from "@/utils/request";
+
+export interface StackSummary {
+  id: number;
+  name: string;
+  description?: string;
+  compose_project?: string;
+  status: string;
+  members: number;
+  running: number;
+  pending_updates: number;
+  updated_at: string;
+}
+
+export interface StackMember {
+  container_id: number;
+  name: string;
+  image: string;
+  tag: string;
+  stack_order: number;
+  status: string;
+  health?: string;
+  update_available: boolean;
+  latest_version?: string;
+  effective_policy?: Record<string, any>;
+}
+
+export interface StackDetail {
+  id: number;
+  name: string;
+  description?: string;
+  compose_project?: string;
+  update_policy?: string;
+  check_interval_minutes?: number;
+  hold_down_hours?: number;
+  vulnerability_threshold?: string;
+  status: string;
+  members: StackMember[];
+  pending_updates: number;
+  created_at: string;
+  updated_at: string;
+}
+
+export interface StackRequest {
+  name: string;
+  description?: string;
+  container_ids: number[];
+  update_policy?: string;
+  check_interval_minutes?: number;
+  hold_down_hours?: number;
+  maintenance_windows?: Array<Record<string, any>>;
+  vulnerability_threshold?: string;
+}
+
+export interface StackOperationResult {
+  stack_id: number;
+  action: string;
+  status: string;
+  results: Array<{
+    container_id: number;
+    name: string;
+    success: boolean;
+    message?: string;
+    error?: string;
+  }>;
+}
+
+export type StackAction = "start" | "stop" | "restart" | "update";
+
+export class StackAPI {
+  private readonly baseUrl = "/api/stacks";
+
+  /**
+   * Get stacks with their combined status
+   */
+  async getStacks(page = 1, limit = 20, name?: string): Promise<StackSummary[]> {
+    const params = new URLSearchParams({
+      page: page.toString(),
+      limit: limit.toString(),
+    });
+    if (name) {
+      params.append("name", name);
+    }
+
+    return get<StackSummary[]>(`${this.baseUrl}?${params.toString()}`);
+  }
+
+  /**
+   * Get stack with member health and pending updates
+   */
+  async getStack(id: number): Promise<StackDetail> {
+    return get<StackDetail>(`${this.baseUrl}/${id}`);
+  }
+
+  /**
+   * Create stack; container_ids gives the start order
+   */
+  async createStack(data: StackRequest): Promise<StackDetail> {
+    return post<StackDetail>(this.baseUrl, data);
+  }
+
+  /**
+   * Replace stack members and policy overrides
+   */
+  async updateStack(id: number, data: StackRequest): Promise<StackDetail> {
+    return put<StackDetail>(`${this.baseUrl}/${id}`, data);
+  }
+
+  /**
+   * Dissolve stack, optionally deleting its containers
+   */
+  async deleteStack(
+    id: number,
+    deleteMembers = false,
+  ): Promise<StackOperationResult> {
+    return del<StackOperationResult>(
+      `${this.baseUrl}/${id}?delete_members=${deleteMembers}`,
+    );
+  }
+
+  /**
+   * Start, stop, restart or update every member of a stack
+   */
+  async runAction(
+    id: number,
+    action: StackAction,
+  ): Promise<StackOperationResult> {
+    return post<StackOperationResult>(`${this.baseUrl}/${id}/${action}`);
+  }
+}
+
+// Export singleton instance
+export const stackAPI = new StackAPI();
@@ -186,6 +186,19 @@ const defaultWidgets: WidgetDefinition[] = [
     permissions: ["monitor:read"],
     configurable: true,
   },
+  {
+    type: "stack-health",
+    name: "Stack Health",
+    description: "Combined status and pending updates of each stack",
+    component: "StackHealth",
+    icon: "Files",
+    category: "monitoring",
+    defaultSize: { minW: 3, minH: 3, maxW: 6, maxH: 6 },
+    defaultPosition: { x: 5, y: 6, w: 3, h: 3 },
+    defaultSettings: { refreshInterval: 30000 },
+    permissions: ["container:read"],
+    configurable: true,
+  },
   {
     type: "recent-activities",
     name: "Recent Activities",
@@ -96,6 +96,19 @@ export interface NetworkConfig {
   aliases: string[];
 }
 
+export interface UpdateWindow {
+  container_id: number;
+  eligible: boolean;
+  unrestricted: boolean;
+  held_down_until?: string;
+  next_eligible_at?: string;
+  window?: {
+    start: string;
+    end: string;
+  };
+  timestamp: string;
+}
+
 export interface ResourceMetrics {
   cpu: {
     usage: number; // Percentage
@@ -204,6 +217,7 @@ export interface ContainerFilter {
   updatePolicy?: string;
   healthStatus?: string[];
   search?: string;
+  stackId?: number;
 }
 
 export interface ContainerSort {
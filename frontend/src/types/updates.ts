@@ -103,6 +103,8 @@ export interface UpdateHistoryItem {
   strategy?: UpdateStrategy;
   triggeredBy: "manual" | "scheduled" | "policy" | "webhook" | "api";
   triggeredById?: string;
+  actorType?: "user" | "api_token" | "system";
+  actorName?: string;
   startedAt: string;
   completedAt?: string;
   duration?: number; // in seconds